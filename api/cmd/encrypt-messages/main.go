@@ -0,0 +1,37 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/NdoleStudio/httpsms/pkg/di"
+	"github.com/joho/godotenv"
+	"github.com/palantir/stacktrace"
+)
+
+// batchSize is the number of messages which are encrypted in each batch
+const batchSize = 500
+
+// encrypts the content of the messages which were stored before MESSAGE_ENCRYPTION_KEY or MESSAGE_ENCRYPTION_KMS_KEY was set
+func main() {
+	err := godotenv.Load("../../.env")
+	if err != nil {
+		log.Fatal("Error loading .env file")
+	}
+
+	container := di.NewContainer("http-sms", "")
+	logger := container.Logger()
+
+	service := container.MessageEncryptionService()
+	if service == nil {
+		logger.Fatal(stacktrace.NewError("set MESSAGE_ENCRYPTION_KEY or MESSAGE_ENCRYPTION_KMS_KEY to encrypt the content of messages"))
+	}
+
+	count, err := service.EncryptExisting(context.Background(), batchSize)
+	if err != nil {
+		logger.Fatal(stacktrace.Propagate(err, fmt.Sprintf("cannot encrypt messages after encrypting [%d] messages", count)))
+	}
+
+	logger.Info(fmt.Sprintf("encrypted the content of %d messages", count))
+}
@@ -0,0 +1,47 @@
+// Command mock-server runs a lightweight version of the httpSMS API with in-memory storage.
+//
+// Integrators can use it to run integration tests without an httpSMS account or an android phone.
+// Messages sent through the API are delivered by a fake phone and the outcome of the next messages
+// can be scripted with the routes under /mock e.g.
+//
+//	curl -X POST localhost:8001/mock/outcomes -d '{"contact":"+18005550100","status":"failed","failure_code":"no-service","times":1}'
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/cors"
+)
+
+func main() {
+	host := flag.String("host", "127.0.0.1", "the host where the mock server listens")
+	port := flag.Int("port", 8001, "the port where the mock server listens")
+	apiKey := flag.String("api-key", "", "the API key which must be set in the x-api-key header, requests are not authenticated when it is empty")
+	userID := flag.String("user-id", "mock-user", "the ID of the user who owns the messages")
+	webhookURL := flag.String("webhook-url", "", "the URL where the message events are sent")
+	webhookSigningKey := flag.String("webhook-signing-key", "", "the key used to sign the webhook payloads")
+	delay := flag.Duration("delay", 500*time.Millisecond, "how long the fake phone takes to send a message")
+	flag.Parse()
+
+	s := &server{
+		store:             newStore(),
+		client:            &http.Client{Timeout: 10 * time.Second},
+		userID:            entities.UserID(*userID),
+		apiKey:            *apiKey,
+		webhookURL:        *webhookURL,
+		webhookSigningKey: *webhookSigningKey,
+		delay:             *delay,
+	}
+
+	app := fiber.New()
+	app.Use(cors.New())
+	s.RegisterRoutes(app)
+
+	log.Fatal(app.Listen(fmt.Sprintf("%s:%d", *host, *port)))
+}
@@ -0,0 +1,491 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/events"
+	"github.com/NdoleStudio/httpsms/pkg/requests"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/nyaruka/phonenumbers"
+	"github.com/palantir/stacktrace"
+)
+
+const eventSource = "mock-server"
+
+// server implements the public API with the state kept in memory and a fake phone which sends messages with the scripted outcomes
+type server struct {
+	store             *store
+	client            *http.Client
+	userID            entities.UserID
+	apiKey            string
+	webhookURL        string
+	webhookSigningKey string
+	delay             time.Duration
+}
+
+// RegisterRoutes registers the public API and the routes used to control the mock server
+func (s *server) RegisterRoutes(app *fiber.App) {
+	router := app.Group("/v1", s.authenticate)
+	router.Post("/messages/send", s.PostSend)
+	router.Post("/messages/receive", s.PostReceive)
+	router.Get("/messages", s.Index)
+	router.Put("/messages/:messageID/read", s.PutRead)
+
+	mock := app.Group("/mock")
+	mock.Get("/messages/:messageID", s.GetMessage)
+	mock.Get("/outcomes", s.GetOutcomes)
+	mock.Post("/outcomes", s.PostOutcome)
+	mock.Delete("/outcomes", s.DeleteOutcomes)
+	mock.Get("/events", s.GetEvents)
+	mock.Post("/reset", s.PostReset)
+}
+
+// authenticate checks the x-api-key header when the mock server is started with an API key
+func (s *server) authenticate(c *fiber.Ctx) error {
+	if s.apiKey == "" || c.Get("x-api-key") == s.apiKey {
+		return c.Next()
+	}
+	return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+		"status":  "error",
+		"message": "You are not authorized to carry out this request.",
+		"data":    "Make sure your API key is set in the [X-API-Key] header in the request",
+	})
+}
+
+// PostSend stores a new message and hands it over to the fake phone
+func (s *server) PostSend(c *fiber.Ctx) error {
+	var request requests.MessageSend
+	if err := c.BodyParser(&request); err != nil {
+		return s.responseBadRequest(c, err)
+	}
+	request.Sanitize()
+
+	errors := url.Values{}
+	owner := s.validatePhoneNumber(errors, "from", request.From)
+	contact := s.validatePhoneNumber(errors, "to", request.To)
+	if len(request.Content) == 0 || len(request.Content) > 1024 {
+		errors.Add("content", "The content field must be between 1 and 1024 characters")
+	}
+	if len(errors) != 0 {
+		return s.responseUnprocessableEntity(c, errors, "validation errors while sending message")
+	}
+
+	timestamp := time.Now().UTC()
+	message := &entities.Message{
+		ID:                uuid.New(),
+		Owner:             owner,
+		UserID:            s.userID,
+		Contact:           contact,
+		Content:           request.Content,
+		Type:              entities.MessageTypeMobileTerminated,
+		Status:            entities.MessageStatusPending,
+		SIM:               request.SIM,
+		Priority:          request.Priority,
+		RequestReceivedAt: timestamp,
+		CreatedAt:         timestamp,
+		UpdatedAt:         timestamp,
+		OrderTimestamp:    timestamp,
+		MaxSendAttempts:   1,
+		SendAt:            request.SendAt,
+		ConversationID:    request.ConversationID,
+		ExpiresIn:         request.ExpiresIn,
+	}
+
+	delay := s.delay
+	if request.SendAt != nil && request.SendAt.After(timestamp) {
+		message.Status = entities.MessageStatusScheduled
+		delay += request.SendAt.Sub(timestamp)
+	}
+
+	outcome, ok := s.store.NextOutcome(contact)
+	if !ok {
+		outcome = Outcome{Status: entities.MessageStatusDelivered}
+	}
+	delay += time.Duration(outcome.DelayMilliseconds) * time.Millisecond
+
+	// the response is a copy because the fake phone updates the stored message concurrently
+	response := *message
+	s.store.StoreMessage(message)
+	time.AfterFunc(delay, func() { s.send(message.ID, outcome) })
+
+	return s.responseOK(c, "message added to queue", response)
+}
+
+// PostReceive simulates a message which was received by the phone
+func (s *server) PostReceive(c *fiber.Ctx) error {
+	var request requests.MessageReceive
+	if err := c.BodyParser(&request); err != nil {
+		return s.responseBadRequest(c, err)
+	}
+	request.Sanitize()
+
+	errors := url.Values{}
+	owner := s.validatePhoneNumber(errors, "to", request.To)
+	if request.From == "" {
+		errors.Add("from", "The from field is required")
+	}
+	if len(request.Content) == 0 || len(request.Content) > 2048 {
+		errors.Add("content", "The content field must be between 1 and 2048 characters")
+	}
+	if len(errors) != 0 {
+		return s.responseUnprocessableEntity(c, errors, "validation errors while receiving message")
+	}
+
+	timestamp := request.Timestamp
+	if timestamp.IsZero() {
+		timestamp = time.Now().UTC()
+	}
+
+	now := time.Now().UTC()
+	message := &entities.Message{
+		ID:                uuid.New(),
+		Owner:             owner,
+		UserID:            s.userID,
+		Contact:           request.From,
+		Content:           request.Content,
+		Type:              entities.MessageTypeMobileOriginated,
+		Status:            entities.MessageStatusReceived,
+		SIM:               request.SIM,
+		Priority:          entities.MessagePriorityNormal,
+		RequestReceivedAt: now,
+		CreatedAt:         now,
+		UpdatedAt:         now,
+		OrderTimestamp:    timestamp,
+		ReceivedAt:        &timestamp,
+	}
+	response := *message
+	s.store.StoreMessage(message)
+
+	s.emit(events.EventTypeMessagePhoneReceived, &events.MessagePhoneReceivedPayload{
+		MessageID: message.ID,
+		UserID:    message.UserID,
+		Owner:     message.Owner,
+		Contact:   message.Contact,
+		Timestamp: timestamp,
+		Content:   message.Content,
+		SIM:       message.SIM,
+	})
+
+	return s.responseOK(c, "message received successfully", response)
+}
+
+// Index returns the messages between an owner and a contact
+func (s *server) Index(c *fiber.Ctx) error {
+	var request requests.MessageIndex
+	if err := c.QueryParser(&request); err != nil {
+		return s.responseBadRequest(c, err)
+	}
+
+	request.Sanitize()
+	params := request.ToGetParams(s.userID)
+	if params.Limit < 1 || params.Limit > 100 || params.Skip < 0 {
+		return s.responseUnprocessableEntity(c, url.Values{"limit": []string{"The limit field must be between 1 and 100"}}, "validation errors while fetching messages")
+	}
+
+	messages := s.store.IndexMessages(params.Owner, params.Contact, params.Query, params.Skip, params.Limit)
+	return s.responseOK(c, fmt.Sprintf("fetched %d %s", len(messages), s.pluralize("message", len(messages))), messages)
+}
+
+// PutRead marks a received message as read
+func (s *server) PutRead(c *fiber.Ctx) error {
+	messageID, err := uuid.Parse(c.Params("messageID"))
+	if err != nil {
+		return s.responseUnprocessableEntity(c, url.Values{"messageID": []string{"The messageID must be a valid UUID"}}, "validation errors while marking message as read")
+	}
+
+	message, ok := s.store.UpdateMessage(messageID, func(message *entities.Message) {
+		if message.ReadAt == nil {
+			timestamp := time.Now().UTC()
+			message.ReadAt = &timestamp
+			message.UpdatedAt = timestamp
+		}
+	})
+	if !ok {
+		return s.responseNotFound(c, fmt.Sprintf("cannot find message with ID [%s]", messageID))
+	}
+
+	return s.responseOK(c, "message marked as read successfully", message)
+}
+
+// GetMessage returns a message so that tests can assert its status
+func (s *server) GetMessage(c *fiber.Ctx) error {
+	messageID, err := uuid.Parse(c.Params("messageID"))
+	if err != nil {
+		return s.responseUnprocessableEntity(c, url.Values{"messageID": []string{"The messageID must be a valid UUID"}}, "validation errors while fetching message")
+	}
+
+	message, ok := s.store.LoadMessage(messageID)
+	if !ok {
+		return s.responseNotFound(c, fmt.Sprintf("cannot find message with ID [%s]", messageID))
+	}
+
+	return s.responseOK(c, "message fetched successfully", message)
+}
+
+// GetOutcomes returns the scripted outcomes
+func (s *server) GetOutcomes(c *fiber.Ctx) error {
+	outcomes := s.store.Outcomes()
+	return s.responseOK(c, fmt.Sprintf("fetched %d %s", len(outcomes), s.pluralize("outcome", len(outcomes))), outcomes)
+}
+
+// PostOutcome scripts the outcome of the next messages which are sent
+func (s *server) PostOutcome(c *fiber.Ctx) error {
+	outcome := new(Outcome)
+	if err := c.BodyParser(outcome); err != nil {
+		return s.responseBadRequest(c, err)
+	}
+
+	errors := url.Values{}
+	switch outcome.Status {
+	case entities.MessageStatusSent, entities.MessageStatusDelivered, entities.MessageStatusExpired, entities.MessageStatusPending:
+	case entities.MessageStatusFailed:
+		if outcome.FailureCode == "" {
+			outcome.FailureCode = entities.MessageFailureCodeGenericFailure
+		}
+		if outcome.ErrorMessage == "" {
+			outcome.ErrorMessage = "mock failure"
+		}
+	default:
+		errors.Add("status", "The status field must be one of sent, delivered, failed, expired or pending")
+	}
+	if outcome.Contact != "" {
+		outcome.Contact = s.validatePhoneNumber(errors, "contact", outcome.Contact)
+	}
+	if len(errors) != 0 {
+		return s.responseUnprocessableEntity(c, errors, "validation errors while storing outcome")
+	}
+
+	outcome.ID = uuid.New()
+	s.store.AddOutcome(outcome)
+
+	return s.responseCreated(c, "outcome created successfully", outcome)
+}
+
+// DeleteOutcomes removes all the scripted outcomes
+func (s *server) DeleteOutcomes(c *fiber.Ctx) error {
+	s.store.ClearOutcomes()
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// GetEvents returns the events which were emitted by the mock server
+func (s *server) GetEvents(c *fiber.Ctx) error {
+	emitted := s.store.Events()
+	return s.responseOK(c, fmt.Sprintf("fetched %d %s", len(emitted), s.pluralize("event", len(emitted))), emitted)
+}
+
+// PostReset removes all the messages, outcomes and events
+func (s *server) PostReset(c *fiber.Ctx) error {
+	s.store.Reset()
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// send moves the message through the statuses reported by a real phone until it reaches the scripted outcome
+func (s *server) send(messageID uuid.UUID, outcome Outcome) {
+	timestamp := time.Now().UTC()
+
+	switch outcome.Status {
+	case entities.MessageStatusPending:
+		return
+	case entities.MessageStatusExpired:
+		message, ok := s.store.UpdateMessage(messageID, func(message *entities.Message) {
+			message.Status = entities.MessageStatusExpired
+			message.ExpiredAt = &timestamp
+			message.UpdatedAt = timestamp
+		})
+		if ok {
+			s.emit(events.EventTypeMessageSendExpired, &events.MessageSendExpiredPayload{
+				MessageID: message.ID,
+				Owner:     message.Owner,
+				Contact:   message.Contact,
+				UserID:    message.UserID,
+				Timestamp: timestamp,
+				Content:   message.Content,
+				SIM:       message.SIM,
+			})
+		}
+		return
+	case entities.MessageStatusFailed:
+		message, ok := s.store.UpdateMessage(messageID, func(message *entities.Message) {
+			message.Status = entities.MessageStatusFailed
+			message.FailedAt = &timestamp
+			message.UpdatedAt = timestamp
+			message.SendAttemptCount++
+			message.FailureReason = &outcome.ErrorMessage
+			message.FailureCode = &outcome.FailureCode
+		})
+		if ok {
+			s.emit(events.EventTypeMessageSendFailed, &events.MessageSendFailedPayload{
+				ID:           message.ID,
+				ErrorMessage: outcome.ErrorMessage,
+				FailureCode:  outcome.FailureCode,
+				UserID:       message.UserID,
+				Owner:        message.Owner,
+				Contact:      message.Contact,
+				Timestamp:    timestamp,
+				Content:      message.Content,
+				SIM:          message.SIM,
+			})
+		}
+		return
+	}
+
+	message, ok := s.store.UpdateMessage(messageID, func(message *entities.Message) {
+		duration := timestamp.Sub(message.RequestReceivedAt).Nanoseconds()
+		message.Status = entities.MessageStatusSent
+		message.SentAt = &timestamp
+		message.SendDuration = &duration
+		message.UpdatedAt = timestamp
+		message.SendAttemptCount++
+	})
+	if !ok {
+		return
+	}
+	s.emit(events.EventTypeMessagePhoneSent, &events.MessagePhoneSentPayload{
+		ID:        message.ID,
+		UserID:    message.UserID,
+		Owner:     message.Owner,
+		Contact:   message.Contact,
+		Timestamp: timestamp,
+		Content:   message.Content,
+		SIM:       message.SIM,
+	})
+
+	if outcome.Status != entities.MessageStatusDelivered {
+		return
+	}
+
+	message, ok = s.store.UpdateMessage(messageID, func(message *entities.Message) {
+		message.Status = entities.MessageStatusDelivered
+		message.DeliveredAt = &timestamp
+		message.UpdatedAt = timestamp
+	})
+	if ok {
+		s.emit(events.EventTypeMessagePhoneDelivered, &events.MessagePhoneDeliveredPayload{
+			ID:        message.ID,
+			Owner:     message.Owner,
+			Contact:   message.Contact,
+			UserID:    message.UserID,
+			Timestamp: timestamp,
+			Content:   message.Content,
+			SIM:       message.SIM,
+		})
+	}
+}
+
+// emit records the event and sends it to the webhook when one is configured
+func (s *server) emit(eventType string, payload any) {
+	event := cloudevents.NewEvent()
+	event.SetSource(eventSource)
+	event.SetType(eventType)
+	event.SetTime(time.Now().UTC())
+	event.SetID(uuid.New().String())
+
+	if err := event.SetData(cloudevents.ApplicationJSON, payload); err != nil {
+		log.Println(stacktrace.Propagate(err, fmt.Sprintf("cannot encode %T [%#+v] as JSON", payload, payload)))
+		return
+	}
+
+	s.store.StoreEvent(event)
+	if s.webhookURL != "" {
+		go s.sendWebhook(event)
+	}
+}
+
+func (s *server) sendWebhook(event cloudevents.Event) {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		log.Println(stacktrace.Propagate(err, fmt.Sprintf("cannot marshal event [%s] with ID [%s]", event.Type(), event.ID())))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, s.webhookURL, bytes.NewReader(payload))
+	if err != nil {
+		log.Println(stacktrace.Propagate(err, fmt.Sprintf("cannot create webhook request for URL [%s]", s.webhookURL)))
+		return
+	}
+
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("X-Event-Type", event.Type())
+	request.Header.Set("X-Delivery-ID", uuid.New().String())
+	request.Header.Set(services.WebhookSignatureHeader, services.WebhookSignature(payload, time.Now().UTC(), s.webhookSigningKey))
+
+	response, err := s.client.Do(request)
+	if err != nil {
+		log.Println(stacktrace.Propagate(err, fmt.Sprintf("cannot send event [%s] with ID [%s] to webhook [%s]", event.Type(), event.ID(), s.webhookURL)))
+		return
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode >= http.StatusBadRequest {
+		log.Printf("webhook [%s] responded with status code [%d] for event [%s] with ID [%s]", s.webhookURL, response.StatusCode, event.Type(), event.ID())
+	}
+}
+
+func (s *server) validatePhoneNumber(errors url.Values, field string, value string) string {
+	number, err := phonenumbers.Parse(value, phonenumbers.UNKNOWN_REGION)
+	if err != nil || !phonenumbers.IsValidNumber(number) {
+		errors.Add(field, fmt.Sprintf("The %s field must be a valid E.164 phone number", field))
+		return value
+	}
+	return phonenumbers.Format(number, phonenumbers.E164)
+}
+
+func (s *server) pluralize(value string, count int) string {
+	if count == 1 {
+		return value
+	}
+	return value + "s"
+}
+
+func (s *server) responseOK(c *fiber.Ctx, message string, data any) error {
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  "success",
+		"message": message,
+		"data":    data,
+	})
+}
+
+func (s *server) responseCreated(c *fiber.Ctx, message string, data any) error {
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"status":  "success",
+		"message": message,
+		"data":    data,
+	})
+}
+
+func (s *server) responseBadRequest(c *fiber.Ctx, err error) error {
+	return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+		"status":  "error",
+		"message": "The request isn't properly formed",
+		"data":    err,
+	})
+}
+
+func (s *server) responseNotFound(c *fiber.Ctx, message string) error {
+	return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+		"status":  "error",
+		"message": message,
+	})
+}
+
+func (s *server) responseUnprocessableEntity(c *fiber.Ctx, errors url.Values, message string) error {
+	return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+		"status":  "error",
+		"message": message,
+		"data":    errors,
+	})
+}
@@ -0,0 +1,198 @@
+package main
+
+import (
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/google/uuid"
+)
+
+// Outcome is a scripted result for the messages sent to a contact
+type Outcome struct {
+	ID uuid.UUID `json:"id"`
+	// Contact limits the outcome to messages sent to this phone number, it matches all messages when empty
+	Contact string `json:"contact"`
+	// Status is the final status of the message, one of sent, delivered, failed, expired or pending
+	Status entities.MessageStatus `json:"status"`
+	// FailureCode is set on the message when the Status is failed
+	FailureCode entities.MessageFailureCode `json:"failure_code"`
+	// ErrorMessage is set on the message when the Status is failed
+	ErrorMessage string `json:"error_message"`
+	// DelayMilliseconds is how long the fake phone takes to send the message
+	DelayMilliseconds uint `json:"delay_ms"`
+	// Times is the number of messages the outcome applies to before it is removed, it never expires when 0
+	Times uint `json:"times"`
+}
+
+// store keeps the state of the mock server in memory
+type store struct {
+	mutex    sync.RWMutex
+	messages map[uuid.UUID]*entities.Message
+	outcomes []*Outcome
+	events   []cloudevents.Event
+}
+
+func newStore() *store {
+	return &store{
+		messages: map[uuid.UUID]*entities.Message{},
+		outcomes: []*Outcome{},
+		events:   []cloudevents.Event{},
+	}
+}
+
+// Reset removes all the messages, outcomes and events
+func (s *store) Reset() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.messages = map[uuid.UUID]*entities.Message{}
+	s.outcomes = []*Outcome{}
+	s.events = []cloudevents.Event{}
+}
+
+// StoreMessage adds a new message to the store
+func (s *store) StoreMessage(message *entities.Message) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.messages[message.ID] = message
+}
+
+// UpdateMessage applies a change to a stored message and returns a copy of the result
+func (s *store) UpdateMessage(messageID uuid.UUID, update func(message *entities.Message)) (entities.Message, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	message, ok := s.messages[messageID]
+	if !ok {
+		return entities.Message{}, false
+	}
+
+	update(message)
+	return *message, true
+}
+
+// LoadMessage returns a copy of a stored message
+func (s *store) LoadMessage(messageID uuid.UUID) (entities.Message, bool) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	message, ok := s.messages[messageID]
+	if !ok {
+		return entities.Message{}, false
+	}
+	return *message, true
+}
+
+// DeleteMessage removes a message from the store
+func (s *store) DeleteMessage(messageID uuid.UUID) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if _, ok := s.messages[messageID]; !ok {
+		return false
+	}
+
+	delete(s.messages, messageID)
+	return true
+}
+
+// IndexMessages returns the messages between the owner and the contact ordered by newest first
+func (s *store) IndexMessages(owner string, contact string, query string, skip int, limit int) []entities.Message {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	messages := make([]entities.Message, 0, len(s.messages))
+	for _, message := range s.messages {
+		if message.Owner != owner || message.Contact != contact {
+			continue
+		}
+		if query != "" && !strings.Contains(strings.ToLower(message.Content), strings.ToLower(query)) {
+			continue
+		}
+		messages = append(messages, *message)
+	}
+
+	sort.Slice(messages, func(i, j int) bool {
+		return messages[i].OrderTimestamp.After(messages[j].OrderTimestamp)
+	})
+
+	if skip >= len(messages) {
+		return []entities.Message{}
+	}
+	messages = messages[skip:]
+	if limit < len(messages) {
+		messages = messages[:limit]
+	}
+	return messages
+}
+
+// AddOutcome appends an outcome, outcomes are matched in the order in which they were added
+func (s *store) AddOutcome(outcome *Outcome) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.outcomes = append(s.outcomes, outcome)
+}
+
+// Outcomes returns the scripted outcomes which have not expired
+func (s *store) Outcomes() []Outcome {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	outcomes := make([]Outcome, 0, len(s.outcomes))
+	for _, outcome := range s.outcomes {
+		outcomes = append(outcomes, *outcome)
+	}
+	return outcomes
+}
+
+// ClearOutcomes removes all the scripted outcomes
+func (s *store) ClearOutcomes() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.outcomes = []*Outcome{}
+}
+
+// NextOutcome returns the first outcome which matches the contact and consumes one of its uses
+func (s *store) NextOutcome(contact string) (Outcome, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for index, outcome := range s.outcomes {
+		if outcome.Contact != "" && outcome.Contact != contact {
+			continue
+		}
+
+		result := *outcome
+		if outcome.Times > 0 {
+			outcome.Times--
+			if outcome.Times == 0 {
+				s.outcomes = append(s.outcomes[:index], s.outcomes[index+1:]...)
+			}
+		}
+		return result, true
+	}
+
+	return Outcome{}, false
+}
+
+// StoreEvent records an event which was emitted by the mock server
+func (s *store) StoreEvent(event cloudevents.Event) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.events = append(s.events, event)
+}
+
+// Events returns the emitted events in the order in which they were emitted
+func (s *store) Events() []cloudevents.Event {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	return append([]cloudevents.Event{}, s.events...)
+}
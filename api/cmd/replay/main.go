@@ -28,6 +28,7 @@ func main() {
 		container.Logger(),
 		container.Tracer(),
 		container.BillingService(),
+		container.EventListenerLogRepository(),
 	)
 
 	cloudEvents, err := eventRepo.FetchAll(context.Background())
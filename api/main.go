@@ -1,7 +1,9 @@
 package main
 
 import (
+	"context"
 	"fmt"
+	"net"
 	"os"
 
 	_ "github.com/NdoleStudio/httpsms/docs"
@@ -28,11 +30,30 @@ var Version string
 // @securitydefinitions.apikey ApiKeyAuth
 // @in header
 // @name x-api-Key
+//
+// @securitydefinitions.basic BasicAuth
 func main() {
 	if len(os.Args) == 1 {
 		di.LoadEnv()
 	}
 
 	container := di.NewContainer("http-sms", Version)
+	container.RunSchedulers(context.Background())
+
+	if port := os.Getenv("GRPC_PORT"); port != "" {
+		go serveGRPC(container, fmt.Sprintf("%s:%s", os.Getenv("APP_HOST"), port))
+	}
+
 	container.Logger().Info(container.App().Listen(fmt.Sprintf("%s:%s", os.Getenv("APP_HOST"), os.Getenv("APP_PORT"))).Error())
 }
+
+// serveGRPC serves the gRPC API next to the HTTP API
+func serveGRPC(container *di.Container, address string) {
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		container.Logger().Fatal(err)
+	}
+
+	container.Logger().Info(fmt.Sprintf("serving gRPC API on [%s]", address))
+	container.Logger().Info(container.GRPCServer().Serve(listener).Error())
+}
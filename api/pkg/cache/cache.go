@@ -9,4 +9,5 @@ import (
 type Cache interface {
 	Set(ctx context.Context, key string, value string, ttl time.Duration) error
 	Get(ctx context.Context, key string) (value string, err error)
+	Delete(ctx context.Context, key string) error
 }
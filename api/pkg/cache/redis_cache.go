@@ -50,3 +50,15 @@ func (cache *RedisCache) Set(ctx context.Context, key string, value string, ttl
 	}
 	return nil
 }
+
+// Delete an item from the redis cache
+func (cache *RedisCache) Delete(ctx context.Context, key string) error {
+	ctx, span := cache.tracer.Start(ctx)
+	defer span.End()
+
+	err := cache.client.Del(ctx, key).Err()
+	if err != nil {
+		return cache.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, fmt.Sprintf("cannot delete item in redis with key [%s]", key)))
+	}
+	return nil
+}
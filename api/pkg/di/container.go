@@ -2,11 +2,14 @@ package di
 
 import (
 	"context"
+	"crypto/ed25519"
 	"crypto/tls"
 	"fmt"
 	"net/http"
+	"net/url"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/NdoleStudio/httpsms/pkg/discord"
@@ -16,6 +19,10 @@ import (
 	"go.opentelemetry.io/otel/sdk/metric"
 
 	"github.com/NdoleStudio/httpsms/pkg/cache"
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+	"github.com/google/uuid"
+	"github.com/graph-gophers/graphql-go"
+	"github.com/nats-io/nats.go"
 	"github.com/redis/go-redis/v9"
 
 	"github.com/NdoleStudio/go-otelroundtripper"
@@ -31,6 +38,7 @@ import (
 	"github.com/NdoleStudio/httpsms/pkg/emails"
 
 	cloudtasks "cloud.google.com/go/cloudtasks/apiv2"
+	"cloud.google.com/go/storage"
 
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/sdk/resource"
@@ -44,13 +52,17 @@ import (
 	firebase "firebase.google.com/go"
 	"firebase.google.com/go/auth"
 	"github.com/NdoleStudio/httpsms/pkg/middlewares"
+	"google.golang.org/api/cloudkms/v1"
 	"google.golang.org/api/option"
+	"google.golang.org/grpc"
 
 	"github.com/gofiber/fiber/v2/middleware/cors"
 
 	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/graph"
 	"github.com/NdoleStudio/httpsms/pkg/listeners"
 	"github.com/NdoleStudio/httpsms/pkg/repositories"
+	"github.com/NdoleStudio/httpsms/pkg/rpc"
 	"github.com/NdoleStudio/httpsms/pkg/services"
 	"github.com/gofiber/fiber/v2"
 	fiberLogger "github.com/gofiber/fiber/v2/middleware/logger"
@@ -59,20 +71,53 @@ import (
 	"gorm.io/gorm"
 
 	"github.com/NdoleStudio/httpsms/pkg/handlers"
+	"github.com/NdoleStudio/httpsms/pkg/identity"
 	"github.com/NdoleStudio/httpsms/pkg/telemetry"
 	"github.com/NdoleStudio/httpsms/pkg/validators"
 	"gorm.io/driver/postgres"
 	gormLogger "gorm.io/gorm/logger"
 )
 
+// messageSearchIndex is the GIN index used by the postgres full text search of the content of messages
+const messageSearchIndex = "CREATE INDEX IF NOT EXISTS idx_messages__content_search ON messages USING GIN (to_tsvector('simple', content))"
+
+const (
+	// messageSequenceIndex makes the sequence of a message unique within its thread
+	messageSequenceIndex = "idx_messages__thread_sequence"
+
+	// messageSequenceBackfill numbers the messages of each thread which were stored before the sequence was assigned, or
+	// which got a duplicate sequence, while keeping the messages with a sequence after the ones without one.
+	messageSequenceBackfill = `
+UPDATE messages SET sequence = numbered.sequence
+FROM (
+    SELECT id, ROW_NUMBER() OVER (PARTITION BY user_id, owner, contact ORDER BY sequence = 0 DESC, sequence, order_timestamp, created_at, id) AS sequence
+    FROM messages
+) AS numbered
+WHERE messages.id = numbered.id AND messages.sequence <> numbered.sequence`
+
+	// messageSequenceSeed sets the counter of each thread to the last sequence of its messages
+	messageSequenceSeed = `
+INSERT INTO message_sequences (user_id, owner, contact, value, updated_at)
+SELECT user_id, owner, contact, MAX(sequence), NOW() FROM messages GROUP BY user_id, owner, contact
+ON CONFLICT (user_id, owner, contact) DO UPDATE SET value = GREATEST(message_sequences.value, excluded.value), updated_at = excluded.updated_at`
+)
+
 // Container is used to resolve services at runtime
 type Container struct {
-	projectID       string
-	db              *gorm.DB
-	version         string
-	app             *fiber.App
-	eventDispatcher *services.EventDispatcher
-	logger          telemetry.Logger
+	projectID        string
+	db               *gorm.DB
+	databaseResolver repositories.DatabaseResolver
+	version          string
+	app              *fiber.App
+	eventDispatcher  *services.EventDispatcher
+	logger           telemetry.Logger
+
+	natsPhoneTransport *services.NATSPhoneTransport
+	mqttPhoneBridge    *services.MQTTPhoneBridge
+	accessLogService   *services.AccessLogService
+	eventHub           *services.EventHub
+
+	messageEncryptionService *services.MessageEncryptionService
 }
 
 // NewContainer creates a new dependency injection container
@@ -82,6 +127,8 @@ func NewContainer(projectID string, version string) (container *Container) {
 		TimeLocation: time.UTC,
 	}
 
+	initializeErrorReporter(version)
+
 	container = &Container{
 		projectID: projectID,
 		version:   version,
@@ -101,6 +148,8 @@ func NewContainer(projectID string, version string) (container *Container) {
 
 	container.RegisterUserRoutes()
 	container.RegisterUserListeners()
+	container.RegisterUserDataRoutes()
+	container.RegisterUserDataListeners()
 
 	container.RegisterPhoneRoutes()
 
@@ -115,10 +164,113 @@ func NewContainer(projectID string, version string) (container *Container) {
 	container.RegisterWebhookListeners()
 
 	container.RegisterLemonsqueezyRoutes()
+	container.RegisterStripeRoutes()
 
 	container.RegisterDiscordRoutes()
 	container.RegisterDiscordListeners()
 
+	container.RegisterProxySessionRoutes()
+	container.RegisterProxySessionListeners()
+
+	container.RegisterNumberLeaseRoutes()
+	container.RegisterNumberLeaseListeners()
+
+	container.RegisterRuleRoutes()
+	container.RegisterRuleListeners()
+
+	container.RegisterAnalyticsRoutes()
+	container.RegisterCarrierFilteringListeners()
+
+	container.RegisterRetryPolicyRoutes()
+
+	container.RegisterNotificationPreferenceRoutes()
+
+	container.RegisterLinkPreviewRoutes()
+	container.RegisterLinkPreviewListeners()
+
+	container.RegisterStatusRoutes()
+	container.RegisterInstanceIdentityRoutes()
+
+	container.RegisterCanaryRoutes()
+	container.RegisterCanaryListeners()
+
+	container.RegisterContactRoutes()
+	container.RegisterContactListeners()
+	container.RegisterContactGroupRoutes()
+
+	container.RegisterSegmentRoutes()
+	container.RegisterTagRoutes()
+	container.RegisterSavedFilterRoutes()
+
+	container.RegisterBlocklistRoutes()
+	container.RegisterBlocklistListeners()
+
+	container.RegisterSubscriptionRoutes()
+	container.RegisterSubscriptionListeners()
+
+	container.RegisterBroadcastRoutes()
+
+	container.RegisterCampaignRoutes()
+
+	container.RegisterCreditRoutes()
+
+	container.RegisterInvoiceRoutes()
+
+	container.RegisterPromotionRoutes()
+
+	container.RegisterAttachmentRoutes()
+
+	container.RegisterReferralRoutes()
+	container.RegisterReferralListeners()
+
+	container.RegisterDashboardRoutes()
+
+	container.RegisterActivityRoutes()
+
+	container.RegisterPhonePaceListeners()
+
+	container.RegisterEventSinkListeners()
+
+	container.RegisterPhoneFailoverRoutes()
+	container.RegisterPhoneFailoverListeners()
+
+	container.RegisterAuthRoutes()
+
+	container.RegisterTwoFactorRoutes()
+	container.RegisterAuthSessionRoutes()
+
+	container.RegisterConversationRoutes()
+
+	container.RegisterPhoneRegistrationRoutes()
+
+	container.RegisterUserMetricsRoutes()
+
+	container.RegisterAccessLogRoutes()
+
+	container.RegisterForwardingRuleRoutes()
+	container.RegisterForwardingRuleListeners()
+
+	container.RegisterAlertChannelRoutes()
+	container.RegisterAlertChannelListeners()
+
+	container.RegisterTwilioRoutes()
+	container.RegisterTwilioListeners()
+
+	container.RegisterTemplateRoutes()
+	container.RegisterTemplateStatListeners()
+
+	container.RegisterPhoneReputationRoutes()
+	container.RegisterPhoneReputationListeners()
+
+	container.RegisterEventHubListeners()
+
+	container.RegisterMessageSearchListeners()
+
+	container.RegisterMessageArchiveRoutes()
+
+	container.RegisterGraphQLRoutes()
+	container.RegisterWebSocketRoutes()
+
 	// this has to be last since it registers the /* route
 	container.RegisterSwaggerRoutes()
 
@@ -133,7 +285,8 @@ func (container *Container) App() (app *fiber.App) {
 
 	container.logger.Debug(fmt.Sprintf("creating %T", app))
 
-	app = fiber.New()
+	// the body limit allows messages with 10 base64 encoded attachments of 1MB
+	app = fiber.New(fiber.Config{BodyLimit: 16 * 1024 * 1024})
 
 	if os.Getenv("APP_HTTP_LOGGER") == "true" {
 		app.Use(fiberLogger.New())
@@ -144,8 +297,13 @@ func (container *Container) App() (app *fiber.App) {
 	// Default config
 	app.Use(cors.New())
 
-	app.Use(middlewares.BearerAuth(container.Logger(), container.Tracer(), container.FirebaseAuthClient()))
+	app.Use(middlewares.BearerAuth(container.Logger(), container.Tracer(), container.IdentityProvider(), container.AuthSessionService()))
 	app.Use(middlewares.APIKeyAuth(container.Logger(), container.Tracer(), container.UserRepository()))
+	app.Use(middlewares.BasicAuth(container.Logger(), container.Tracer(), container.UserRepository()))
+
+	if os.Getenv("ACCESS_LOG_ENABLED") == "true" {
+		app.Use(middlewares.AccessLog(container.AccessLogService()))
+	}
 
 	container.app = app
 	return app
@@ -198,6 +356,10 @@ func (container *Container) DB() (db *gorm.DB) {
 	if err != nil {
 		container.logger.Fatal(err)
 	}
+
+	if err = db.Use(container.TenancyGuard()); err != nil {
+		container.logger.Fatal(stacktrace.Propagate(err, "cannot register tenancy guard"))
+	}
 	container.db = db
 
 	container.logger.Debug(fmt.Sprintf("Running migrations for %T", db))
@@ -206,6 +368,18 @@ func (container *Container) DB() (db *gorm.DB) {
 		container.logger.Fatal(stacktrace.Propagate(err, fmt.Sprintf("cannot migrate %T", &entities.Message{})))
 	}
 
+	if err = db.Exec(messageSearchIndex).Error; err != nil {
+		container.logger.Fatal(stacktrace.Propagate(err, "cannot create full text search index on messages"))
+	}
+
+	if err = migrateMessageSequences(db); err != nil {
+		container.logger.Fatal(stacktrace.Propagate(err, "cannot migrate the sequences of messages"))
+	}
+
+	if err = db.AutoMigrate(&entities.ArchivedMessage{}); err != nil {
+		container.logger.Fatal(stacktrace.Propagate(err, fmt.Sprintf("cannot migrate %T", &entities.ArchivedMessage{})))
+	}
+
 	if err = db.AutoMigrate(&repositories.GormEvent{}); err != nil {
 		container.logger.Fatal(stacktrace.Propagate(err, fmt.Sprintf("cannot migrate %T", &repositories.GormEvent{})))
 	}
@@ -250,9 +424,260 @@ func (container *Container) DB() (db *gorm.DB) {
 		container.logger.Fatal(stacktrace.Propagate(err, fmt.Sprintf("cannot migrate %T", &entities.Discord{})))
 	}
 
+	if err = db.AutoMigrate(&entities.ProxySession{}); err != nil {
+		container.logger.Fatal(stacktrace.Propagate(err, fmt.Sprintf("cannot migrate %T", &entities.ProxySession{})))
+	}
+
+	if err = db.AutoMigrate(&entities.ProxySessionMessage{}); err != nil {
+		container.logger.Fatal(stacktrace.Propagate(err, fmt.Sprintf("cannot migrate %T", &entities.ProxySessionMessage{})))
+	}
+
+	if err = db.AutoMigrate(&entities.NumberLease{}); err != nil {
+		container.logger.Fatal(stacktrace.Propagate(err, fmt.Sprintf("cannot migrate %T", &entities.NumberLease{})))
+	}
+
+	if err = db.AutoMigrate(&entities.Rule{}); err != nil {
+		container.logger.Fatal(stacktrace.Propagate(err, fmt.Sprintf("cannot migrate %T", &entities.Rule{})))
+	}
+
+	if err = db.AutoMigrate(&entities.RuleExecution{}); err != nil {
+		container.logger.Fatal(stacktrace.Propagate(err, fmt.Sprintf("cannot migrate %T", &entities.RuleExecution{})))
+	}
+
+	if err = db.AutoMigrate(&entities.RetryPolicy{}); err != nil {
+		container.logger.Fatal(stacktrace.Propagate(err, fmt.Sprintf("cannot migrate %T", &entities.RetryPolicy{})))
+	}
+
+	if err = db.AutoMigrate(&entities.NotificationPreference{}); err != nil {
+		container.logger.Fatal(stacktrace.Propagate(err, fmt.Sprintf("cannot migrate %T", &entities.NotificationPreference{})))
+	}
+
+	if err = db.AutoMigrate(&entities.LinkPreview{}); err != nil {
+		container.logger.Fatal(stacktrace.Propagate(err, fmt.Sprintf("cannot migrate %T", &entities.LinkPreview{})))
+	}
+
+	if err = db.AutoMigrate(&entities.Canary{}); err != nil {
+		container.logger.Fatal(stacktrace.Propagate(err, fmt.Sprintf("cannot migrate %T", &entities.Canary{})))
+	}
+
+	if err = db.AutoMigrate(&entities.CanaryRun{}); err != nil {
+		container.logger.Fatal(stacktrace.Propagate(err, fmt.Sprintf("cannot migrate %T", &entities.CanaryRun{})))
+	}
+
+	if err = db.AutoMigrate(&entities.NotificationDigestItem{}); err != nil {
+		container.logger.Fatal(stacktrace.Propagate(err, fmt.Sprintf("cannot migrate %T", &entities.NotificationDigestItem{})))
+	}
+
+	if err = db.AutoMigrate(&entities.Contact{}); err != nil {
+		container.logger.Fatal(stacktrace.Propagate(err, fmt.Sprintf("cannot migrate %T", &entities.Contact{})))
+	}
+
+	if err = db.AutoMigrate(&entities.ContactGroup{}); err != nil {
+		container.logger.Fatal(stacktrace.Propagate(err, fmt.Sprintf("cannot migrate %T", &entities.ContactGroup{})))
+	}
+
+	if err = db.AutoMigrate(&entities.ContactGroupMember{}); err != nil {
+		container.logger.Fatal(stacktrace.Propagate(err, fmt.Sprintf("cannot migrate %T", &entities.ContactGroupMember{})))
+	}
+
+	if err = db.AutoMigrate(&entities.Segment{}); err != nil {
+		container.logger.Fatal(stacktrace.Propagate(err, fmt.Sprintf("cannot migrate %T", &entities.Segment{})))
+	}
+
+	if err = db.AutoMigrate(&entities.Tag{}); err != nil {
+		container.logger.Fatal(stacktrace.Propagate(err, fmt.Sprintf("cannot migrate %T", &entities.Tag{})))
+	}
+
+	if err = db.AutoMigrate(&entities.EncryptionKey{}); err != nil {
+		container.logger.Fatal(stacktrace.Propagate(err, fmt.Sprintf("cannot migrate %T", &entities.EncryptionKey{})))
+	}
+
+	if err = db.AutoMigrate(&entities.SavedFilter{}); err != nil {
+		container.logger.Fatal(stacktrace.Propagate(err, fmt.Sprintf("cannot migrate %T", &entities.SavedFilter{})))
+	}
+
+	if err = db.AutoMigrate(&entities.Blocklist{}); err != nil {
+		container.logger.Fatal(stacktrace.Propagate(err, fmt.Sprintf("cannot migrate %T", &entities.Blocklist{})))
+	}
+
+	if err = db.AutoMigrate(&entities.SubscriptionKeyword{}); err != nil {
+		container.logger.Fatal(stacktrace.Propagate(err, fmt.Sprintf("cannot migrate %T", &entities.SubscriptionKeyword{})))
+	}
+
+	if err = db.AutoMigrate(&entities.Subscription{}); err != nil {
+		container.logger.Fatal(stacktrace.Propagate(err, fmt.Sprintf("cannot migrate %T", &entities.Subscription{})))
+	}
+
+	if err = db.AutoMigrate(&entities.Broadcast{}); err != nil {
+		container.logger.Fatal(stacktrace.Propagate(err, fmt.Sprintf("cannot migrate %T", &entities.Broadcast{})))
+	}
+
+	if err = db.AutoMigrate(&entities.Campaign{}); err != nil {
+		container.logger.Fatal(stacktrace.Propagate(err, fmt.Sprintf("cannot migrate %T", &entities.Campaign{})))
+	}
+
+	if err = db.AutoMigrate(&entities.CreditBalance{}); err != nil {
+		container.logger.Fatal(stacktrace.Propagate(err, fmt.Sprintf("cannot migrate %T", &entities.CreditBalance{})))
+	}
+
+	if err = db.AutoMigrate(&entities.CreditTransaction{}); err != nil {
+		container.logger.Fatal(stacktrace.Propagate(err, fmt.Sprintf("cannot migrate %T", &entities.CreditTransaction{})))
+	}
+
+	if err = db.AutoMigrate(&entities.Invoice{}); err != nil {
+		container.logger.Fatal(stacktrace.Propagate(err, fmt.Sprintf("cannot migrate %T", &entities.Invoice{})))
+	}
+
+	if err = db.AutoMigrate(&entities.Coupon{}); err != nil {
+		container.logger.Fatal(stacktrace.Propagate(err, fmt.Sprintf("cannot migrate %T", &entities.Coupon{})))
+	}
+
+	if err = db.AutoMigrate(&entities.PlanOverride{}); err != nil {
+		container.logger.Fatal(stacktrace.Propagate(err, fmt.Sprintf("cannot migrate %T", &entities.PlanOverride{})))
+	}
+
+	if err = db.AutoMigrate(&entities.Attachment{}); err != nil {
+		container.logger.Fatal(stacktrace.Propagate(err, fmt.Sprintf("cannot migrate %T", &entities.Attachment{})))
+	}
+
+	if err = db.AutoMigrate(&entities.ReferralCode{}); err != nil {
+		container.logger.Fatal(stacktrace.Propagate(err, fmt.Sprintf("cannot migrate %T", &entities.ReferralCode{})))
+	}
+
+	if err = db.AutoMigrate(&entities.Referral{}); err != nil {
+		container.logger.Fatal(stacktrace.Propagate(err, fmt.Sprintf("cannot migrate %T", &entities.Referral{})))
+	}
+
+	if err = db.AutoMigrate(&entities.WebhookDelivery{}); err != nil {
+		container.logger.Fatal(stacktrace.Propagate(err, fmt.Sprintf("cannot migrate %T", &entities.WebhookDelivery{})))
+	}
+
+	if err = db.AutoMigrate(&entities.PhoneFailoverPolicy{}); err != nil {
+		container.logger.Fatal(stacktrace.Propagate(err, fmt.Sprintf("cannot migrate %T", &entities.PhoneFailoverPolicy{})))
+	}
+
+	if err = db.AutoMigrate(&entities.UserCredential{}); err != nil {
+		container.logger.Fatal(stacktrace.Propagate(err, fmt.Sprintf("cannot migrate %T", &entities.UserCredential{})))
+	}
+
+	if err = db.AutoMigrate(&entities.AuthSession{}); err != nil {
+		container.logger.Fatal(stacktrace.Propagate(err, fmt.Sprintf("cannot migrate %T", &entities.AuthSession{})))
+	}
+
+	if err = db.AutoMigrate(&entities.TwoFactorAuth{}); err != nil {
+		container.logger.Fatal(stacktrace.Propagate(err, fmt.Sprintf("cannot migrate %T", &entities.TwoFactorAuth{})))
+	}
+
+	if err = db.AutoMigrate(&entities.PhoneRegistration{}); err != nil {
+		container.logger.Fatal(stacktrace.Propagate(err, fmt.Sprintf("cannot migrate %T", &entities.PhoneRegistration{})))
+	}
+
+	if err = db.AutoMigrate(&entities.AccessLog{}); err != nil {
+		container.logger.Fatal(stacktrace.Propagate(err, fmt.Sprintf("cannot migrate %T", &entities.AccessLog{})))
+	}
+
+	if err = db.AutoMigrate(&entities.ForwardingRule{}); err != nil {
+		container.logger.Fatal(stacktrace.Propagate(err, fmt.Sprintf("cannot migrate %T", &entities.ForwardingRule{})))
+	}
+
+	if err = db.AutoMigrate(&entities.AlertChannel{}); err != nil {
+		container.logger.Fatal(stacktrace.Propagate(err, fmt.Sprintf("cannot migrate %T", &entities.AlertChannel{})))
+	}
+
+	if err = db.AutoMigrate(&entities.TwilioStatusCallback{}); err != nil {
+		container.logger.Fatal(stacktrace.Propagate(err, fmt.Sprintf("cannot migrate %T", &entities.TwilioStatusCallback{})))
+	}
+
+	if err = db.AutoMigrate(&entities.Template{}); err != nil {
+		container.logger.Fatal(stacktrace.Propagate(err, fmt.Sprintf("cannot migrate %T", &entities.Template{})))
+	}
+
+	if err = db.AutoMigrate(&entities.TemplateReview{}); err != nil {
+		container.logger.Fatal(stacktrace.Propagate(err, fmt.Sprintf("cannot migrate %T", &entities.TemplateReview{})))
+	}
+
+	if err = db.AutoMigrate(&entities.TemplateStat{}); err != nil {
+		container.logger.Fatal(stacktrace.Propagate(err, fmt.Sprintf("cannot migrate %T", &entities.TemplateStat{})))
+	}
+
+	if err = db.AutoMigrate(&entities.PhoneReputationDay{}); err != nil {
+		container.logger.Fatal(stacktrace.Propagate(err, fmt.Sprintf("cannot migrate %T", &entities.PhoneReputationDay{})))
+	}
+
+	if err = db.AutoMigrate(&entities.PhoneReputation{}); err != nil {
+		container.logger.Fatal(stacktrace.Propagate(err, fmt.Sprintf("cannot migrate %T", &entities.PhoneReputation{})))
+	}
+
 	return container.db
 }
 
+// DatabaseResolver creates an instance of repositories.DatabaseResolver with a connection to the database of each region.
+// The regions are configured with DATABASE_REGIONS e.g. "eu=postgres://eu-host/httpsms;us=postgres://us-host/httpsms"
+func (container *Container) DatabaseResolver() repositories.DatabaseResolver {
+	if container.databaseResolver != nil {
+		return container.databaseResolver
+	}
+
+	container.logger.Debug("creating GORM repositories.DatabaseResolver")
+
+	config := &gorm.Config{}
+	if isLocal() {
+		config = &gorm.Config{Logger: container.GormLogger()}
+	}
+
+	regions := map[string]*gorm.DB{}
+	for _, value := range strings.Split(os.Getenv("DATABASE_REGIONS"), ";") {
+		parts := strings.SplitN(strings.TrimSpace(value), "=", 2)
+		if len(parts) != 2 || strings.TrimSpace(parts[0]) == "" {
+			continue
+		}
+
+		db, err := gorm.Open(postgres.Open(strings.TrimSpace(parts[1])), config)
+		if err != nil {
+			container.logger.Fatal(stacktrace.Propagate(err, fmt.Sprintf("cannot open database of region [%s]", parts[0])))
+		}
+
+		if err = db.Use(container.TenancyGuard()); err != nil {
+			container.logger.Fatal(stacktrace.Propagate(err, fmt.Sprintf("cannot register tenancy guard in region [%s]", parts[0])))
+		}
+
+		if err = db.AutoMigrate(&entities.Message{}); err != nil {
+			container.logger.Fatal(stacktrace.Propagate(err, fmt.Sprintf("cannot migrate %T in region [%s]", &entities.Message{}, parts[0])))
+		}
+
+		if err = db.Exec(messageSearchIndex).Error; err != nil {
+			container.logger.Fatal(stacktrace.Propagate(err, fmt.Sprintf("cannot create full text search index on messages in region [%s]", parts[0])))
+		}
+
+		if err = migrateMessageSequences(db); err != nil {
+			container.logger.Fatal(stacktrace.Propagate(err, fmt.Sprintf("cannot migrate the sequences of messages in region [%s]", parts[0])))
+		}
+
+		if err = db.AutoMigrate(&entities.ArchivedMessage{}); err != nil {
+			container.logger.Fatal(stacktrace.Propagate(err, fmt.Sprintf("cannot migrate %T in region [%s]", &entities.ArchivedMessage{}, parts[0])))
+		}
+
+		regions[strings.TrimSpace(parts[0])] = db
+	}
+
+	container.databaseResolver = repositories.NewGormDatabaseResolver(
+		container.Logger(),
+		container.Tracer(),
+		container.DB(),
+		regions,
+	)
+	return container.databaseResolver
+}
+
+// TenancyGuard creates a new instance of repositories.TenancyGuard
+func (container *Container) TenancyGuard() (guard *repositories.TenancyGuard) {
+	container.logger.Debug(fmt.Sprintf("creating %T", guard))
+	return repositories.NewTenancyGuard(
+		container.Logger(),
+		repositories.TenancyGuardMode(os.Getenv("TENANCY_GUARD")),
+	)
+}
+
 // FirebaseApp creates a new instance of firebase.App
 func (container *Container) FirebaseApp() (app *firebase.App) {
 	container.logger.Debug(fmt.Sprintf("creating %T", app))
@@ -267,6 +692,12 @@ func (container *Container) FirebaseApp() (app *firebase.App) {
 // Cache creates a new instance of cache.Cache
 func (container *Container) Cache() cache.Cache {
 	container.logger.Debug("creating cache.Cache")
+	return cache.NewRedisCache(container.Tracer(), container.RedisClient())
+}
+
+// RedisClient creates a new instance of redis.Client
+func (container *Container) RedisClient() (client *redis.Client) {
+	container.logger.Debug(fmt.Sprintf("creating %T", client))
 	opt, err := redis.ParseURL(os.Getenv("REDIS_URL"))
 	if err != nil {
 		container.logger.Fatal(stacktrace.Propagate(err, fmt.Sprintf("cannot parse redis url [%s]", os.Getenv("REDIS_URL"))))
@@ -275,7 +706,30 @@ func (container *Container) Cache() cache.Cache {
 		MinVersion: tls.VersionTLS12,
 	}
 
-	return cache.NewRedisCache(container.Tracer(), redis.NewClient(opt))
+	return redis.NewClient(opt)
+}
+
+// IdentityProvider creates the identity.Provider configured with the AUTH_PROVIDER environment variable
+func (container *Container) IdentityProvider() identity.Provider {
+	container.logger.Debug("creating identity.Provider")
+
+	switch os.Getenv("AUTH_PROVIDER") {
+	case "jwt":
+		return container.JWTProvider()
+	case "oidc":
+		claim := os.Getenv("AUTH_OIDC_USER_ID_CLAIM")
+		if claim == "" {
+			claim = "sub"
+		}
+
+		provider, err := identity.NewOIDCProvider(context.Background(), container.Tracer(), os.Getenv("AUTH_OIDC_ISSUER_URL"), os.Getenv("AUTH_OIDC_CLIENT_ID"), claim)
+		if err != nil {
+			container.logger.Fatal(stacktrace.Propagate(err, "cannot initialize OIDC identity provider"))
+		}
+		return provider
+	default:
+		return identity.NewFirebaseProvider(container.Tracer(), container.FirebaseAuthClient())
+	}
 }
 
 // FirebaseAuthClient creates a new instance of auth.Client
@@ -321,6 +775,14 @@ func (container *Container) EventsQueue() (queue services.PushQueue) {
 		return container.EmulatorEventsQueue()
 	}
 
+	if os.Getenv("EVENTS_QUEUE_TYPE") == "redis" {
+		return container.RedisEventsQueue()
+	}
+
+	if os.Getenv("EVENTS_QUEUE_TYPE") == "amqp" {
+		return container.AMQPEventsQueue()
+	}
+
 	return container.CloudTaskEventsQueue()
 }
 
@@ -335,6 +797,36 @@ func (container *Container) EmulatorEventsQueue() (queue services.PushQueue) {
 	)
 }
 
+// RedisEventsQueue creates a redis backed instance of events services.PushQueue which pushes due tasks from this process
+func (container *Container) RedisEventsQueue() (queue services.PushQueue) {
+	container.logger.Debug("creating redis events services.PushQueue")
+	redisQueue := services.NewRedisPushQueue(
+		container.Logger(),
+		container.Tracer(),
+		container.HTTPClient("redis_events_queue"),
+		container.RedisClient(),
+		container.EventsQueueConfiguration(),
+	)
+
+	go redisQueue.Consume(context.Background())
+	return redisQueue
+}
+
+// AMQPEventsQueue creates a RabbitMQ backed instance of events services.PushQueue which pushes tasks from this process
+func (container *Container) AMQPEventsQueue() (queue services.PushQueue) {
+	container.logger.Debug("creating AMQP events services.PushQueue")
+	amqpQueue := services.NewAMQPPushQueue(
+		container.Logger(),
+		container.Tracer(),
+		container.HTTPClient("amqp_events_queue"),
+		os.Getenv("AMQP_URL"),
+		container.EventsQueueConfiguration(),
+	)
+
+	go amqpQueue.Consume(context.Background())
+	return amqpQueue
+}
+
 // CloudTaskEventsQueue creates a Google cloud task instance of events services.PushQueue
 func (container *Container) CloudTaskEventsQueue() (queue services.PushQueue) {
 	container.logger.Debug("creating cloud task events services.PushQueue")
@@ -460,6 +952,7 @@ func (container *Container) MessageThreadHandler() (h *handlers.MessageThreadHan
 		container.Tracer(),
 		container.MessageThreadHandlerValidator(),
 		container.MessageThreadService(),
+		container.TagService(),
 	)
 }
 
@@ -487,6 +980,7 @@ func (container *Container) UserHandlerValidator() (validator *validators.UserHa
 	return validators.NewUserHandlerValidator(
 		container.Logger(),
 		container.Tracer(),
+		container.DatabaseResolver().Regions(),
 	)
 }
 
@@ -512,71 +1006,256 @@ func (container *Container) EventDispatcher() (dispatcher *services.EventDispatc
 // MessageRepository creates a new instance of repositories.MessageRepository
 func (container *Container) MessageRepository() (repository repositories.MessageRepository) {
 	container.logger.Debug("creating GORM repositories.MessageRepository")
-	return repositories.NewGormMessageRepository(
+	repository = repositories.NewGormMessageRepository(
 		container.Logger(),
 		container.Tracer(),
-		container.DB(),
+		container.DatabaseResolver(),
 	)
+
+	if service := container.MessageEncryptionService(); service != nil {
+		return repositories.NewEncryptedMessageRepository(container.Tracer(), repository, service)
+	}
+	return repository
 }
 
-// PhoneRepository creates a new instance of repositories.PhoneRepository
-func (container *Container) PhoneRepository() (repository repositories.PhoneRepository) {
-	container.logger.Debug("creating GORM repositories.PhoneRepository")
-	return repositories.NewGormPhoneRepository(
+// ArchivedMessageRepository creates a new instance of repositories.ArchivedMessageRepository
+func (container *Container) ArchivedMessageRepository() (repository repositories.ArchivedMessageRepository) {
+	container.logger.Debug("creating GORM repositories.ArchivedMessageRepository")
+	repository = repositories.NewGormArchivedMessageRepository(
 		container.Logger(),
 		container.Tracer(),
-		container.DB(),
+		container.DatabaseResolver(),
 	)
+
+	if service := container.MessageEncryptionService(); service != nil {
+		return repositories.NewEncryptedArchivedMessageRepository(repository, service)
+	}
+	return repository
 }
 
-// BillingUsageRepository creates a new instance of repositories.BillingUsageRepository
-func (container *Container) BillingUsageRepository() (repository repositories.BillingUsageRepository) {
-	container.logger.Debug("creating GORM repositories.BillingUsageRepository")
-	return repositories.NewGormBillingUsageRepository(
+// EncryptionKeyRepository creates a new instance of repositories.EncryptionKeyRepository
+func (container *Container) EncryptionKeyRepository() (repository repositories.EncryptionKeyRepository) {
+	container.logger.Debug("creating GORM repositories.EncryptionKeyRepository")
+	return repositories.NewGormEncryptionKeyRepository(
 		container.Logger(),
 		container.Tracer(),
 		container.DB(),
 	)
 }
 
-// DiscordRepository creates a new instance of repositories.DiscordRepository
-func (container *Container) DiscordRepository() (repository repositories.DiscordRepository) {
-	container.logger.Debug("creating GORM repositories.DiscordRepository")
-	return repositories.NewGormDiscordRepository(
+// MessageEncryptionMasterKey creates the services.MessageEncryptionMasterKey from MESSAGE_ENCRYPTION_KMS_KEY or MESSAGE_ENCRYPTION_KEY.
+// It is nil when the content of messages is not encrypted.
+func (container *Container) MessageEncryptionMasterKey() (key services.MessageEncryptionMasterKey) {
+	if name := os.Getenv("MESSAGE_ENCRYPTION_KMS_KEY"); name != "" {
+		container.logger.Debug(fmt.Sprintf("creating KMS %T with key [%s]", &key, name))
+		service, err := cloudkms.NewService(context.Background(), option.WithCredentialsJSON(container.FirebaseCredentials()))
+		if err != nil {
+			container.logger.Fatal(stacktrace.Propagate(err, "cannot initialize cloud kms service"))
+		}
+		return services.NewKMSMessageEncryptionMasterKey(service, name)
+	}
+
+	if encoded := os.Getenv("MESSAGE_ENCRYPTION_KEY"); encoded != "" {
+		container.logger.Debug(fmt.Sprintf("creating local %T", &key))
+		key, err := services.NewLocalMessageEncryptionMasterKey(encoded)
+		if err != nil {
+			container.logger.Fatal(stacktrace.Propagate(err, "cannot parse the MESSAGE_ENCRYPTION_KEY environment variable"))
+		}
+		return key
+	}
+
+	return nil
+}
+
+// MessageEncryptionService creates the singleton services.MessageEncryptionService, it is nil when no master key is configured
+func (container *Container) MessageEncryptionService() (service *services.MessageEncryptionService) {
+	if container.messageEncryptionService != nil {
+		return container.messageEncryptionService
+	}
+
+	masterKey := container.MessageEncryptionMasterKey()
+	if masterKey == nil {
+		return nil
+	}
+
+	container.logger.Debug(fmt.Sprintf("creating %T", service))
+	container.messageEncryptionService = services.NewMessageEncryptionService(
 		container.Logger(),
 		container.Tracer(),
-		container.DB(),
+		masterKey,
+		container.EncryptionKeyRepository(),
+		// the messages are encrypted with the repository which persists the content as it is
+		repositories.NewGormMessageRepository(
+			container.Logger(),
+			container.Tracer(),
+			container.DatabaseResolver(),
+		),
 	)
+	return container.messageEncryptionService
 }
 
-// WebhookRepository creates a new instance of repositories.WebhookRepository
-func (container *Container) WebhookRepository() (repository repositories.WebhookRepository) {
-	container.logger.Debug("creating GORM repositories.WebhookRepository")
-	return repositories.NewGormWebhookRepository(
+// MessageArchiveService creates a new instance of services.MessageArchiveService
+func (container *Container) MessageArchiveService() (service *services.MessageArchiveService) {
+	container.logger.Debug(fmt.Sprintf("creating %T", service))
+
+	var retention time.Duration
+	if value, err := time.ParseDuration(os.Getenv("MESSAGE_ARCHIVE_AFTER")); err == nil && value > 0 {
+		retention = value
+	}
+
+	return services.NewMessageArchiveService(
 		container.Logger(),
 		container.Tracer(),
-		container.DB(),
+		container.ArchivedMessageRepository(),
+		retention,
 	)
 }
 
-// PhoneNotificationRepository creates a new instance of repositories.PhoneNotificationRepository
-func (container *Container) PhoneNotificationRepository() (repository repositories.PhoneNotificationRepository) {
-	container.logger.Debug("creating GORM repositories.PhoneNotificationRepository")
-	return repositories.NewGormPhoneNotificationRepository(
+// MessageArchiveScheduler creates a new instance of services.MessageArchiveScheduler
+func (container *Container) MessageArchiveScheduler() (scheduler *services.MessageArchiveScheduler) {
+	container.logger.Debug(fmt.Sprintf("creating %T", scheduler))
+	return services.NewMessageArchiveScheduler(
 		container.Logger(),
 		container.Tracer(),
-		container.DB(),
+		container.MessageArchiveService(),
+		time.Hour,
+	)
+}
+
+// MessageRetentionService creates a new instance of services.MessageRetentionService
+func (container *Container) MessageRetentionService() (service *services.MessageRetentionService) {
+	container.logger.Debug(fmt.Sprintf("creating %T", service))
+	return services.NewMessageRetentionService(
+		container.Logger(),
+		container.Tracer(),
+		container.UserRepository(),
+		container.MessageRepository(),
+		container.ArchivedMessageRepository(),
+		container.EventDispatcher(),
+	)
+}
+
+// MessageRetentionScheduler creates a new instance of services.MessageRetentionScheduler
+func (container *Container) MessageRetentionScheduler() (scheduler *services.MessageRetentionScheduler) {
+	container.logger.Debug(fmt.Sprintf("creating %T", scheduler))
+	return services.NewMessageRetentionScheduler(
+		container.Logger(),
+		container.Tracer(),
+		container.MessageRetentionService(),
+		24*time.Hour,
+	)
+}
+
+// MessageThreadArchiveScheduler creates a new instance of services.MessageThreadArchiveScheduler
+func (container *Container) MessageThreadArchiveScheduler() (scheduler *services.MessageThreadArchiveScheduler) {
+	container.logger.Debug(fmt.Sprintf("creating %T", scheduler))
+	return services.NewMessageThreadArchiveScheduler(
+		container.Logger(),
+		container.Tracer(),
+		container.MessageThreadService(),
+		time.Hour,
+	)
+}
+
+// MessageArchiveHandlerValidator creates a new instance of validators.MessageArchiveHandlerValidator
+func (container *Container) MessageArchiveHandlerValidator() (validator *validators.MessageArchiveHandlerValidator) {
+	container.logger.Debug(fmt.Sprintf("creating %T", validator))
+	return validators.NewMessageArchiveHandlerValidator(
+		container.Logger(),
+		container.Tracer(),
+	)
+}
+
+// MessageArchiveHandler creates a new instance of handlers.MessageArchiveHandler
+func (container *Container) MessageArchiveHandler() (h *handlers.MessageArchiveHandler) {
+	container.logger.Debug(fmt.Sprintf("creating %T", h))
+	return handlers.NewMessageArchiveHandler(
+		container.Logger(),
+		container.Tracer(),
+		container.MessageArchiveService(),
+		container.MessageArchiveHandlerValidator(),
+	)
+}
+
+// RegisterMessageArchiveRoutes registers routes for the /archived-messages prefix
+func (container *Container) RegisterMessageArchiveRoutes() {
+	container.logger.Debug(fmt.Sprintf("registering %T routes", &handlers.MessageArchiveHandler{}))
+	container.MessageArchiveHandler().RegisterRoutes(container.App(), container.AuthenticatedMiddleware())
+}
+
+// PhoneRepository creates a new instance of repositories.PhoneRepository
+func (container *Container) PhoneRepository() (repository repositories.PhoneRepository) {
+	container.logger.Debug("creating GORM repositories.PhoneRepository")
+	return repositories.NewGormPhoneRepository(
+		container.Logger(),
+		container.Tracer(),
+		container.DB(),
+	)
+}
+
+// BillingUsageRepository creates a new instance of repositories.BillingUsageRepository
+func (container *Container) BillingUsageRepository() (repository repositories.BillingUsageRepository) {
+	container.logger.Debug("creating GORM repositories.BillingUsageRepository")
+	return repositories.NewGormBillingUsageRepository(
+		container.Logger(),
+		container.Tracer(),
+		container.DB(),
+	)
+}
+
+// DiscordRepository creates a new instance of repositories.DiscordRepository
+func (container *Container) DiscordRepository() (repository repositories.DiscordRepository) {
+	container.logger.Debug("creating GORM repositories.DiscordRepository")
+	return repositories.NewGormDiscordRepository(
+		container.Logger(),
+		container.Tracer(),
+		container.DB(),
+	)
+}
+
+// WebhookRepository creates a new instance of repositories.WebhookRepository
+func (container *Container) WebhookRepository() (repository repositories.WebhookRepository) {
+	container.logger.Debug("creating GORM repositories.WebhookRepository")
+	return repositories.NewGormWebhookRepository(
+		container.Logger(),
+		container.Tracer(),
+		container.DB(),
+	)
+}
+
+// WebhookDeliveryRepository creates a new instance of repositories.WebhookDeliveryRepository
+func (container *Container) WebhookDeliveryRepository() (repository repositories.WebhookDeliveryRepository) {
+	container.logger.Debug("creating GORM repositories.WebhookDeliveryRepository")
+	return repositories.NewGormWebhookDeliveryRepository(
+		container.Logger(),
+		container.Tracer(),
+		container.DB(),
+	)
+}
+
+// PhoneNotificationRepository creates a new instance of repositories.PhoneNotificationRepository
+func (container *Container) PhoneNotificationRepository() (repository repositories.PhoneNotificationRepository) {
+	container.logger.Debug("creating GORM repositories.PhoneNotificationRepository")
+	return repositories.NewGormPhoneNotificationRepository(
+		container.Logger(),
+		container.Tracer(),
+		container.DB(),
 	)
 }
 
 // MessageThreadRepository creates a new instance of repositories.MessageThreadRepository
 func (container *Container) MessageThreadRepository() (repository repositories.MessageThreadRepository) {
 	container.logger.Debug("creating GORM repositories.MessageThreadRepository")
-	return repositories.NewGormMessageThreadRepository(
+	repository = repositories.NewGormMessageThreadRepository(
 		container.Logger(),
 		container.Tracer(),
 		container.DB(),
 	)
+
+	if service := container.MessageEncryptionService(); service != nil {
+		return repositories.NewEncryptedMessageThreadRepository(repository, service)
+	}
+	return repository
 }
 
 // EventRepository creates a new instance of repositories.EventRepository
@@ -617,6 +1296,7 @@ func (container *Container) HeartbeatService() (service *services.HeartbeatServi
 		container.Tracer(),
 		container.HeartbeatRepository(),
 		container.HeartbeatMonitorRepository(),
+		container.PhoneService(),
 		container.EventDispatcher(),
 	)
 }
@@ -632,6 +1312,8 @@ func (container *Container) BillingService() (service *services.BillingService)
 		container.UserEmailFactory(),
 		container.BillingUsageRepository(),
 		container.UserRepository(),
+		container.CreditRepository(),
+		container.PlanOverrideRepository(),
 	)
 }
 
@@ -653,8 +1335,33 @@ func (container *Container) WebhookService() (service *services.WebhookService)
 	return services.NewWebhookService(
 		container.Logger(),
 		container.Tracer(),
-		container.HTTPClient("webhook"),
+		container.EgressProxy("webhook"),
 		container.WebhookRepository(),
+		container.WebhookDeliveryRepository(),
+		container.UserRepository(),
+		container.EventDispatcher(),
+		container.WebhookMaxAttempts(),
+		os.Getenv("APP_URL"),
+		container.WebhookSigningPrivateKey(),
+	)
+}
+
+// WebhookMaxAttempts is the number of times an event is sent to a webhook before the delivery is moved to the dead-letter queue
+func (container *Container) WebhookMaxAttempts() uint {
+	if attempts, err := strconv.ParseUint(os.Getenv("WEBHOOK_MAX_ATTEMPTS"), 10, 32); err == nil && attempts > 0 {
+		return uint(attempts)
+	}
+	return 5
+}
+
+// WebhookDeliveryScheduler creates a new instance of services.WebhookDeliveryScheduler
+func (container *Container) WebhookDeliveryScheduler() (scheduler *services.WebhookDeliveryScheduler) {
+	container.logger.Debug(fmt.Sprintf("creating %T", scheduler))
+	return services.NewWebhookDeliveryScheduler(
+		container.Logger(),
+		container.Tracer(),
+		container.WebhookService(),
+		30*time.Second,
 	)
 }
 
@@ -697,6 +1404,67 @@ func (container *Container) RetryHTTPRoundTripper() http.RoundTripper {
 	return retryClient.StandardClient().Transport
 }
 
+// EgressProxy creates a new instance of services.EgressProxy for delivering webhooks and integrations through the proxy in EGRESS_PROXY_URL
+func (container *Container) EgressProxy(name string) (proxy *services.EgressProxy) {
+	container.logger.Debug(fmt.Sprintf("creating %s %T", name, proxy))
+	return services.NewEgressProxy(
+		container.Logger(),
+		container.Tracer(),
+		container.HTTPClient(name),
+		container.GlobalEgressProxyURL(),
+		func(proxy *url.URL) *http.Client {
+			return container.ProxyHTTPClient(name, proxy)
+		},
+	)
+}
+
+// GlobalEgressProxyURL parses the EGRESS_PROXY_URL environment variable, it is nil when no global proxy is configured
+func (container *Container) GlobalEgressProxyURL() *url.URL {
+	if os.Getenv("EGRESS_PROXY_URL") == "" {
+		return nil
+	}
+
+	proxy, err := services.ParseEgressProxyURL(os.Getenv("EGRESS_PROXY_URL"))
+	if err != nil {
+		container.logger.Fatal(stacktrace.Propagate(err, "cannot parse the EGRESS_PROXY_URL environment variable"))
+	}
+	return proxy
+}
+
+// WebhookSigningPrivateKey parses the WEBHOOK_SIGNING_PRIVATE_KEY environment variable, webhooks are not signed with Ed25519 when it is empty
+func (container *Container) WebhookSigningPrivateKey() ed25519.PrivateKey {
+	if os.Getenv("WEBHOOK_SIGNING_PRIVATE_KEY") == "" {
+		return nil
+	}
+
+	key, err := services.ParseWebhookSigningPrivateKey(os.Getenv("WEBHOOK_SIGNING_PRIVATE_KEY"))
+	if err != nil {
+		container.logger.Fatal(stacktrace.Propagate(err, "cannot parse the WEBHOOK_SIGNING_PRIVATE_KEY environment variable"))
+	}
+	return key
+}
+
+// ProxyHTTPClient creates a new http.Client which sends the requests through an HTTP(S) or SOCKS5 proxy
+func (container *Container) ProxyHTTPClient(name string, proxy *url.URL) *http.Client {
+	container.logger.Debug(fmt.Sprintf("creating %s %T with proxy [%s]", name, http.DefaultClient, proxy.Redacted()))
+
+	retryClient := retryablehttp.NewClient()
+	retryClient.Logger = container.Logger()
+	if transport, ok := retryClient.HTTPClient.Transport.(*http.Transport); ok {
+		transport.Proxy = http.ProxyURL(proxy)
+	}
+
+	return &http.Client{
+		Timeout: 60 * time.Second,
+		Transport: otelroundtripper.New(
+			otelroundtripper.WithName(name),
+			otelroundtripper.WithParent(retryClient.StandardClient().Transport),
+			otelroundtripper.WithMeter(global.Meter(container.projectID)),
+			otelroundtripper.WithAttributes(container.OtelResources(container.version, container.projectID).Attributes()...),
+		),
+	}
+}
+
 // PhoneService creates a new instance of services.PhoneService
 func (container *Container) PhoneService() (service *services.PhoneService) {
 	container.logger.Debug(fmt.Sprintf("creating %T", service))
@@ -711,10 +1479,16 @@ func (container *Container) PhoneService() (service *services.PhoneService) {
 // MarketingService creates a new instance of services.MarketingService
 func (container *Container) MarketingService() (service *services.MarketingService) {
 	container.logger.Debug(fmt.Sprintf("creating %T", service))
+	// users are only synced with sendgrid when they are stored in firebase
+	var authClient *auth.Client
+	if os.Getenv("AUTH_PROVIDER") == "" || os.Getenv("AUTH_PROVIDER") == "firebase" {
+		authClient = container.FirebaseAuthClient()
+	}
+
 	return services.NewMarketingService(
 		container.Logger(),
 		container.Tracer(),
-		container.FirebaseAuthClient(),
+		authClient,
 		os.Getenv("SENDGRID_API_KEY"),
 		os.Getenv("SENDGRID_LIST_ID"),
 	)
@@ -727,10 +1501,12 @@ func (container *Container) UserService() (service *services.UserService) {
 		container.Logger(),
 		container.Tracer(),
 		container.UserRepository(),
+		container.NotificationDigestRepository(),
+		container.EventDispatcher(),
 		container.Mailer(),
 		container.UserEmailFactory(),
 		container.MarketingService(),
-		container.LemonsqueezyClient(),
+		container.BillingProvider(),
 	)
 }
 
@@ -778,7 +1554,12 @@ func (container *Container) MessageHandler() (handler *handlers.MessageHandler)
 		container.Tracer(),
 		container.MessageHandlerValidator(),
 		container.BillingService(),
+		container.RateLimitService(),
 		container.MessageService(),
+		container.EventService(),
+		container.ContactGroupService(),
+		container.MessageSearchService(),
+		container.TagService(),
 	)
 }
 
@@ -790,6 +1571,7 @@ func (container *Container) UserHandler() (handler *handlers.UserHandler) {
 		container.Tracer(),
 		container.UserHandlerValidator(),
 		container.UserService(),
+		container.OperatorService(),
 	)
 }
 
@@ -813,6 +1595,9 @@ func (container *Container) EventsHandler() (handler *handlers.EventsHandler) {
 		container.Tracer(),
 		container.EventsQueueConfiguration(),
 		container.EventDispatcher(),
+		container.EventService(),
+		container.EventHub(),
+		container.EventsHandlerValidator(),
 	)
 }
 
@@ -961,6 +1746,8 @@ func (container *Container) RegisterUserListeners() {
 		container.Logger(),
 		container.Tracer(),
 		container.UserService(),
+		container.NotificationPreferenceService(),
+		container.PhoneRegistrationService(),
 	)
 
 	for event, handler := range routes {
@@ -975,6 +1762,7 @@ func (container *Container) RegisterBillingListeners() {
 		container.Logger(),
 		container.Tracer(),
 		container.BillingService(),
+		container.EventListenerLogRepository(),
 	)
 
 	for event, handler := range routes {
@@ -989,6 +1777,7 @@ func (container *Container) RegisterDiscordListeners() {
 		container.Logger(),
 		container.Tracer(),
 		container.DiscordService(),
+		container.NotificationPreferenceService(),
 	)
 
 	for event, handler := range routes {
@@ -1003,6 +1792,7 @@ func (container *Container) RegisterWebhookListeners() {
 		container.Logger(),
 		container.Tracer(),
 		container.WebhookService(),
+		container.NotificationPreferenceService(),
 	)
 
 	for event, handler := range routes {
@@ -1017,8 +1807,14 @@ func (container *Container) MessageService() (service *services.MessageService)
 		container.Logger(),
 		container.Tracer(),
 		container.MessageRepository(),
+		container.ArchivedMessageRepository(),
 		container.EventDispatcher(),
 		container.PhoneService(),
+		container.RetryPolicyService(),
+		container.AttachmentService(),
+		container.CreditService(),
+		container.UserRepository(),
+		container.BlocklistRepository(),
 	)
 }
 
@@ -1031,8 +1827,78 @@ func (container *Container) NotificationService() (service *services.PhoneNotifi
 		container.FirebaseMessagingClient(),
 		container.PhoneRepository(),
 		container.PhoneNotificationRepository(),
+		container.MessageRepository(),
 		container.EventDispatcher(),
+		container.NATSPhoneTransport(),
+		container.MQTTPhoneBridge(),
+	)
+}
+
+// NATSPhoneTransport creates a new instance of services.NATSPhoneTransport, it is nil when NATS_URL is not set
+func (container *Container) NATSPhoneTransport() (transport *services.NATSPhoneTransport) {
+	if container.natsPhoneTransport != nil || os.Getenv("NATS_URL") == "" {
+		return container.natsPhoneTransport
+	}
+
+	container.logger.Debug(fmt.Sprintf("creating %T", transport))
+	connection, err := nats.Connect(os.Getenv("NATS_URL"), nats.Name("httpsms-api"), nats.MaxReconnects(-1))
+	if err != nil {
+		container.logger.Fatal(stacktrace.Propagate(err, "cannot connect to NATS"))
+	}
+
+	stream := os.Getenv("NATS_STREAM")
+	if stream == "" {
+		stream = "HTTPSMS_PHONES"
+	}
+
+	prefix := os.Getenv("NATS_SUBJECT_PREFIX")
+	if prefix == "" {
+		prefix = "httpsms"
+	}
+
+	transport, err = services.NewNATSPhoneTransport(container.Logger(), container.Tracer(), connection, stream, prefix)
+	if err != nil {
+		container.logger.Fatal(stacktrace.Propagate(err, "cannot create NATS phone transport"))
+	}
+
+	container.natsPhoneTransport = transport
+	return transport
+}
+
+// MQTTPhoneBridge creates a new instance of services.MQTTPhoneBridge, it is nil when MQTT_URL is not set
+func (container *Container) MQTTPhoneBridge() (bridge *services.MQTTPhoneBridge) {
+	if container.mqttPhoneBridge != nil || os.Getenv("MQTT_URL") == "" {
+		return container.mqttPhoneBridge
+	}
+
+	container.logger.Debug(fmt.Sprintf("creating %T", bridge))
+	options := mqtt.NewClientOptions().
+		AddBroker(os.Getenv("MQTT_URL")).
+		SetClientID("httpsms-api-" + uuid.NewString()).
+		SetUsername(os.Getenv("MQTT_USERNAME")).
+		SetPassword(os.Getenv("MQTT_PASSWORD")).
+		SetCleanSession(true).
+		SetAutoReconnect(true)
+
+	prefix := os.Getenv("MQTT_TOPIC_PREFIX")
+	if prefix == "" {
+		prefix = "httpsms"
+	}
+
+	bridge, err := services.NewMQTTPhoneBridge(
+		container.Logger(),
+		container.Tracer(),
+		options,
+		prefix,
+		container.PhoneRepository(),
+		container.MessageService(),
 	)
+	if err != nil {
+		container.logger.Fatal(stacktrace.Propagate(err, "cannot create MQTT phone bridge"))
+	}
+
+	container.mqttPhoneBridge = bridge
+	return bridge
 }
 
 // RegisterMessageRoutes registers routes for the /messages prefix
@@ -1062,7 +1928,7 @@ func (container *Container) RegisterBillingRoutes() {
 // RegisterWebhookRoutes registers routes for the /webhooks prefix
 func (container *Container) RegisterWebhookRoutes() {
 	container.logger.Debug(fmt.Sprintf("registering %T routes", &handlers.WebhookHandler{}))
-	container.WebhookHandler().RegisterRoutes(container.App(), container.AuthenticatedMiddleware())
+	container.WebhookHandler().RegisterRoutes(container.App(), container.TwoFactorMiddleware(), container.AuthenticatedMiddleware())
 }
 
 // RegisterPhoneRoutes registers routes for the /phone prefix
@@ -1074,7 +1940,7 @@ func (container *Container) RegisterPhoneRoutes() {
 // RegisterUserRoutes registers routes for the /users prefix
 func (container *Container) RegisterUserRoutes() {
 	container.logger.Debug(fmt.Sprintf("registering %T routes", &handlers.UserHandler{}))
-	container.UserHandler().RegisterRoutes(container.AuthRouter())
+	container.UserHandler().RegisterRoutes(container.AuthRouter(), container.TwoFactorMiddleware())
 }
 
 // RegisterEventRoutes registers routes for the /events prefix
@@ -1083,6 +1949,12 @@ func (container *Container) RegisterEventRoutes() {
 	container.EventsHandler().RegisterRoutes(container.AuthRouter())
 }
 
+// RegisterWebSocketRoutes registers routes for the /ws endpoint
+func (container *Container) RegisterWebSocketRoutes() {
+	container.logger.Debug(fmt.Sprintf("registering %T routes", &handlers.WebSocketHandler{}))
+	container.WebSocketHandler().RegisterRoutes(container.AuthRouter())
+}
+
 // RegisterSwaggerRoutes registers routes for swagger
 func (container *Container) RegisterSwaggerRoutes() {
 	container.logger.Debug(fmt.Sprintf("registering %T routes", swagger.HandlerDefault))
@@ -1109,85 +1981,2896 @@ func (container *Container) UserRepository() repositories.UserRepository {
 	)
 }
 
-// InitializeTraceProvider initializes the open telemetry trace provider
-func (container *Container) InitializeTraceProvider() func() {
-	if isLocal() {
-		return container.initializeUptraceProvider(container.version, container.projectID)
-	}
-	return container.initializeGoogleTraceProvider(container.version, container.projectID)
+// UserDataRepository creates a new instance of repositories.UserDataRepository
+func (container *Container) UserDataRepository() (repository repositories.UserDataRepository) {
+	container.logger.Debug("creating GORM repositories.UserDataRepository")
+	return repositories.NewGormUserDataRepository(
+		container.Logger(),
+		container.Tracer(),
+		container.DB(),
+		container.DatabaseResolver(),
+	)
 }
 
-func (container *Container) initializeGoogleTraceProvider(version string, namespace string) func() {
-	container.logger.Debug("initializing google trace meterProvider")
-
-	traceExporter, err := cloudtrace.New(cloudtrace.WithProjectID(os.Getenv("GCP_PROJECT_ID")))
-	if err != nil {
-		container.logger.Fatal(stacktrace.Propagate(err, "cannot create cloud trace traceExporter"))
-	}
-
-	tp := trace.NewTracerProvider(
-		trace.WithBatcher(traceExporter),
-		trace.WithSampler(trace.AlwaysSample()),
-		trace.WithResource(container.OtelResources(version, namespace)),
+// UserDataService creates a new instance of services.UserDataService
+func (container *Container) UserDataService() (service *services.UserDataService) {
+	container.logger.Debug(fmt.Sprintf("creating %T", service))
+	return services.NewUserDataService(
+		container.Logger(),
+		container.Tracer(),
+		container.UserDataRepository(),
+		container.UserRepository(),
+		container.MessageRepository(),
+		container.ArchivedMessageRepository(),
+		container.ContactRepository(),
+		container.WebhookRepository(),
+		container.EventRepository(),
+		container.EventDispatcher(),
 	)
-	otel.SetTracerProvider(tp)
-
-	metricExporter, err := mexporter.New(mexporter.WithProjectID(os.Getenv("GCP_PROJECT_ID")))
-	if err != nil {
-		container.logger.Fatal(stacktrace.Propagate(err, "cannot create cloud metric traceExporter"))
-	}
+}
 
-	meterProvider := metric.NewMeterProvider(
-		metric.WithReader(metric.NewPeriodicReader(metricExporter)),
-		metric.WithResource(container.OtelResources(version, namespace)),
+// UserDataHandler creates a new instance of handlers.UserDataHandler
+func (container *Container) UserDataHandler() (handler *handlers.UserDataHandler) {
+	container.logger.Debug(fmt.Sprintf("creating %T", handler))
+	return handlers.NewUserDataHandler(
+		container.Logger(),
+		container.Tracer(),
+		container.UserDataService(),
 	)
-	global.SetMeterProvider(meterProvider)
+}
 
-	return func() {
-		if err = metricExporter.Shutdown(context.Background()); err != nil {
-			container.logger.Error(stacktrace.Propagate(err, "cannot shutdown cloud metric metric exporter"))
-		}
-		if err = traceExporter.Shutdown(context.Background()); err != nil {
-			container.logger.Error(stacktrace.Propagate(err, "cannot shutdown cloud trace trace exporter"))
-		}
-	}
+// RegisterUserDataRoutes registers the routes which export and delete the data of a user
+func (container *Container) RegisterUserDataRoutes() {
+	container.logger.Debug(fmt.Sprintf("registering %T routes", &handlers.UserDataHandler{}))
+	container.UserDataHandler().RegisterRoutes(container.AuthRouter(), container.TwoFactorMiddleware())
 }
 
-func (container *Container) initializeUptraceProvider(version string, namespace string) (flush func()) {
-	container.logger.Debug("initializing uptrace provider")
-	// Configure OpenTelemetry with sensible defaults.
-	uptrace.ConfigureOpentelemetry(
-		uptrace.WithDSN(os.Getenv("UPTRACE_DSN")),
-		uptrace.WithServiceName(namespace),
-		uptrace.WithServiceVersion(version),
-		uptrace.WithDeploymentEnvironment(os.Getenv("ENV")),
+// RegisterUserDataListeners registers event listeners for listeners.UserDataListener
+func (container *Container) RegisterUserDataListeners() {
+	container.logger.Debug(fmt.Sprintf("registering listeners for %T", listeners.UserDataListener{}))
+	_, routes := listeners.NewUserDataListener(
+		container.Logger(),
+		container.Tracer(),
+		container.UserDataService(),
 	)
 
-	// Send buffered spans and free resources.
-	return func() {
-		err := uptrace.Shutdown(context.Background())
-		if err != nil {
-			container.logger.Error(err)
-		}
+	for event, handler := range routes {
+		container.EventDispatcher().Subscribe(event, handler)
 	}
 }
 
-func logger(skipFrameCount int) telemetry.Logger {
-	fields := map[string]string{
-		"pid":      strconv.Itoa(os.Getpid()),
-		"hostname": hostName(),
-	}
+// ProxySessionRepository creates a new instance of repositories.ProxySessionRepository
+func (container *Container) ProxySessionRepository() (repository repositories.ProxySessionRepository) {
+	container.logger.Debug("creating GORM repositories.ProxySessionRepository")
+	return repositories.NewGormProxySessionRepository(
+		container.Logger(),
+		container.Tracer(),
+		container.DB(),
+	)
+}
 
-	return telemetry.NewZerologLogger(
-		os.Getenv("GCP_PROJECT_ID"),
-		fields,
-		logDriver(skipFrameCount),
-		nil,
+// ProxySessionService creates a new instance of services.ProxySessionService
+func (container *Container) ProxySessionService() (service *services.ProxySessionService) {
+	container.logger.Debug(fmt.Sprintf("creating %T", service))
+	return services.NewProxySessionService(
+		container.Logger(),
+		container.Tracer(),
+		container.ProxySessionRepository(),
+		container.MessageService(),
+		container.NumberLeaseService(),
+		container.EventDispatcher(),
 	)
 }
 
-func logDriver(skipFrameCount int) *zerodriver.Logger {
-	if isLocal() {
+// ProxySessionHandlerValidator creates a new instance of validators.ProxySessionHandlerValidator
+func (container *Container) ProxySessionHandlerValidator() (validator *validators.ProxySessionHandlerValidator) {
+	container.logger.Debug(fmt.Sprintf("creating %T", validator))
+	return validators.NewProxySessionHandlerValidator(
+		container.Logger(),
+		container.Tracer(),
+		container.PhoneService(),
+		container.ProxySessionService(),
+	)
+}
+
+// ProxySessionHandler creates a new instance of handlers.ProxySessionHandler
+func (container *Container) ProxySessionHandler() (h *handlers.ProxySessionHandler) {
+	container.logger.Debug(fmt.Sprintf("creating %T", h))
+	return handlers.NewProxySessionHandler(
+		container.Logger(),
+		container.Tracer(),
+		container.ProxySessionService(),
+		container.ProxySessionHandlerValidator(),
+	)
+}
+
+// RegisterProxySessionRoutes registers routes for the /proxy-sessions prefix
+func (container *Container) RegisterProxySessionRoutes() {
+	container.logger.Debug(fmt.Sprintf("registering %T routes", &handlers.ProxySessionHandler{}))
+	container.ProxySessionHandler().RegisterRoutes(container.App(), container.AuthenticatedMiddleware())
+}
+
+// RegisterProxySessionListeners registers event listeners for listeners.ProxySessionListener
+func (container *Container) RegisterProxySessionListeners() {
+	container.logger.Debug(fmt.Sprintf("registering listeners for %T", listeners.ProxySessionListener{}))
+	_, routes := listeners.NewProxySessionListener(
+		container.Logger(),
+		container.Tracer(),
+		container.ProxySessionService(),
+		container.EventListenerLogRepository(),
+	)
+
+	for event, handler := range routes {
+		container.EventDispatcher().Subscribe(event, handler)
+	}
+}
+
+// NumberLeaseRepository creates a new instance of repositories.NumberLeaseRepository
+func (container *Container) NumberLeaseRepository() (repository repositories.NumberLeaseRepository) {
+	container.logger.Debug("creating GORM repositories.NumberLeaseRepository")
+	return repositories.NewGormNumberLeaseRepository(
+		container.Logger(),
+		container.Tracer(),
+		container.DB(),
+	)
+}
+
+// NumberLeaseService creates a new instance of services.NumberLeaseService
+func (container *Container) NumberLeaseService() (service *services.NumberLeaseService) {
+	container.logger.Debug(fmt.Sprintf("creating %T", service))
+	return services.NewNumberLeaseService(
+		container.Logger(),
+		container.Tracer(),
+		container.NumberLeaseRepository(),
+		container.PhoneRepository(),
+		container.EventDispatcher(),
+	)
+}
+
+// NumberLeaseHandlerValidator creates a new instance of validators.NumberLeaseHandlerValidator
+func (container *Container) NumberLeaseHandlerValidator() (validator *validators.NumberLeaseHandlerValidator) {
+	container.logger.Debug(fmt.Sprintf("creating %T", validator))
+	return validators.NewNumberLeaseHandlerValidator(
+		container.Logger(),
+		container.Tracer(),
+		container.PhoneService(),
+	)
+}
+
+// NumberLeaseHandler creates a new instance of handlers.NumberLeaseHandler
+func (container *Container) NumberLeaseHandler() (h *handlers.NumberLeaseHandler) {
+	container.logger.Debug(fmt.Sprintf("creating %T", h))
+	return handlers.NewNumberLeaseHandler(
+		container.Logger(),
+		container.Tracer(),
+		container.NumberLeaseService(),
+		container.NumberLeaseHandlerValidator(),
+	)
+}
+
+// RegisterNumberLeaseRoutes registers routes for the /number-leases prefix
+func (container *Container) RegisterNumberLeaseRoutes() {
+	container.logger.Debug(fmt.Sprintf("registering %T routes", &handlers.NumberLeaseHandler{}))
+	container.NumberLeaseHandler().RegisterRoutes(container.App(), container.AuthenticatedMiddleware())
+}
+
+// RegisterNumberLeaseListeners registers event listeners for listeners.NumberLeaseListener
+func (container *Container) RegisterNumberLeaseListeners() {
+	container.logger.Debug(fmt.Sprintf("registering listeners for %T", listeners.NumberLeaseListener{}))
+	_, routes := listeners.NewNumberLeaseListener(
+		container.Logger(),
+		container.Tracer(),
+		container.NumberLeaseService(),
+	)
+
+	for event, handler := range routes {
+		container.EventDispatcher().Subscribe(event, handler)
+	}
+}
+
+// RuleRepository creates a new instance of repositories.RuleRepository
+func (container *Container) RuleRepository() (repository repositories.RuleRepository) {
+	container.logger.Debug("creating GORM repositories.RuleRepository")
+	return repositories.NewGormRuleRepository(
+		container.Logger(),
+		container.Tracer(),
+		container.DB(),
+	)
+}
+
+// RuleService creates a new instance of services.RuleService
+func (container *Container) RuleService() (service *services.RuleService) {
+	container.logger.Debug(fmt.Sprintf("creating %T", service))
+	return services.NewRuleService(
+		container.Logger(),
+		container.Tracer(),
+		container.RuleRepository(),
+		container.MessageService(),
+	)
+}
+
+// RuleHandlerValidator creates a new instance of validators.RuleHandlerValidator
+func (container *Container) RuleHandlerValidator() (validator *validators.RuleHandlerValidator) {
+	container.logger.Debug(fmt.Sprintf("creating %T", validator))
+	return validators.NewRuleHandlerValidator(
+		container.Logger(),
+		container.Tracer(),
+		container.PhoneService(),
+	)
+}
+
+// RuleHandler creates a new instance of handlers.RuleHandler
+func (container *Container) RuleHandler() (h *handlers.RuleHandler) {
+	container.logger.Debug(fmt.Sprintf("creating %T", h))
+	return handlers.NewRuleHandler(
+		container.Logger(),
+		container.Tracer(),
+		container.RuleService(),
+		container.RuleHandlerValidator(),
+	)
+}
+
+// RegisterRuleRoutes registers routes for the /rules prefix
+func (container *Container) RegisterRuleRoutes() {
+	container.logger.Debug(fmt.Sprintf("registering %T routes", &handlers.RuleHandler{}))
+	container.RuleHandler().RegisterRoutes(container.App(), container.AuthenticatedMiddleware())
+}
+
+// RegisterRuleListeners registers event listeners for listeners.RuleListener
+func (container *Container) RegisterRuleListeners() {
+	container.logger.Debug(fmt.Sprintf("registering listeners for %T", listeners.RuleListener{}))
+	_, routes := listeners.NewRuleListener(
+		container.Logger(),
+		container.Tracer(),
+		container.RuleService(),
+		container.EventListenerLogRepository(),
+	)
+
+	for event, handler := range routes {
+		container.EventDispatcher().Subscribe(event, handler)
+	}
+}
+
+// AnalyticsRepository creates a new instance of repositories.AnalyticsRepository
+func (container *Container) AnalyticsRepository() (repository repositories.AnalyticsRepository) {
+	container.logger.Debug("creating GORM repositories.AnalyticsRepository")
+	return repositories.NewGormAnalyticsRepository(
+		container.Logger(),
+		container.Tracer(),
+		container.DB(),
+		container.DatabaseResolver(),
+	)
+}
+
+// AnalyticsService creates a new instance of services.AnalyticsService
+func (container *Container) AnalyticsService() (service *services.AnalyticsService) {
+	container.logger.Debug(fmt.Sprintf("creating %T", service))
+	return services.NewAnalyticsService(
+		container.Logger(),
+		container.Tracer(),
+		container.AnalyticsRepository(),
+	)
+}
+
+// CarrierFilteringService creates a new instance of services.CarrierFilteringService
+func (container *Container) CarrierFilteringService() (service *services.CarrierFilteringService) {
+	container.logger.Debug(fmt.Sprintf("creating %T", service))
+	return services.NewCarrierFilteringService(
+		container.Logger(),
+		container.Tracer(),
+		container.AnalyticsRepository(),
+		container.Cache(),
+		container.EventDispatcher(),
+	)
+}
+
+// RegisterCarrierFilteringListeners registers event listeners for listeners.CarrierFilteringListener
+func (container *Container) RegisterCarrierFilteringListeners() {
+	container.logger.Debug(fmt.Sprintf("registering listeners for %T", listeners.CarrierFilteringListener{}))
+	_, routes := listeners.NewCarrierFilteringListener(
+		container.Logger(),
+		container.Tracer(),
+		container.CarrierFilteringService(),
+		container.EventListenerLogRepository(),
+	)
+
+	for event, handler := range routes {
+		container.EventDispatcher().Subscribe(event, handler)
+	}
+}
+
+// AnalyticsHandlerValidator creates a new instance of validators.AnalyticsHandlerValidator
+func (container *Container) AnalyticsHandlerValidator() (validator *validators.AnalyticsHandlerValidator) {
+	container.logger.Debug(fmt.Sprintf("creating %T", validator))
+	return validators.NewAnalyticsHandlerValidator(
+		container.Logger(),
+		container.Tracer(),
+	)
+}
+
+// AnalyticsHandler creates a new instance of handlers.AnalyticsHandler
+func (container *Container) AnalyticsHandler() (h *handlers.AnalyticsHandler) {
+	container.logger.Debug(fmt.Sprintf("creating %T", h))
+	return handlers.NewAnalyticsHandler(
+		container.Logger(),
+		container.Tracer(),
+		container.AnalyticsService(),
+		container.CarrierFilteringService(),
+		container.AnalyticsHandlerValidator(),
+	)
+}
+
+// RegisterAnalyticsRoutes registers routes for the /analytics prefix
+func (container *Container) RegisterAnalyticsRoutes() {
+	container.logger.Debug(fmt.Sprintf("registering %T routes", &handlers.AnalyticsHandler{}))
+	container.AnalyticsHandler().RegisterRoutes(container.App(), container.AuthenticatedMiddleware())
+}
+
+// RetryPolicyRepository creates a new instance of repositories.RetryPolicyRepository
+func (container *Container) RetryPolicyRepository() (repository repositories.RetryPolicyRepository) {
+	container.logger.Debug("creating GORM repositories.RetryPolicyRepository")
+	return repositories.NewGormRetryPolicyRepository(
+		container.Logger(),
+		container.Tracer(),
+		container.DB(),
+	)
+}
+
+// RetryPolicyService creates a new instance of services.RetryPolicyService
+func (container *Container) RetryPolicyService() (service *services.RetryPolicyService) {
+	container.logger.Debug(fmt.Sprintf("creating %T", service))
+	return services.NewRetryPolicyService(
+		container.Logger(),
+		container.Tracer(),
+		container.RetryPolicyRepository(),
+	)
+}
+
+// RetryPolicyHandlerValidator creates a new instance of validators.RetryPolicyHandlerValidator
+func (container *Container) RetryPolicyHandlerValidator() (validator *validators.RetryPolicyHandlerValidator) {
+	container.logger.Debug(fmt.Sprintf("creating %T", validator))
+	return validators.NewRetryPolicyHandlerValidator(
+		container.Logger(),
+		container.Tracer(),
+	)
+}
+
+// RetryPolicyHandler creates a new instance of handlers.RetryPolicyHandler
+func (container *Container) RetryPolicyHandler() (h *handlers.RetryPolicyHandler) {
+	container.logger.Debug(fmt.Sprintf("creating %T", h))
+	return handlers.NewRetryPolicyHandler(
+		container.Logger(),
+		container.Tracer(),
+		container.RetryPolicyService(),
+		container.RetryPolicyHandlerValidator(),
+	)
+}
+
+// RegisterRetryPolicyRoutes registers routes for the /retry-policies prefix
+func (container *Container) RegisterRetryPolicyRoutes() {
+	container.logger.Debug(fmt.Sprintf("registering %T routes", &handlers.RetryPolicyHandler{}))
+	container.RetryPolicyHandler().RegisterRoutes(container.App(), container.AuthenticatedMiddleware())
+}
+
+// NotificationPreferenceRepository creates a new instance of repositories.NotificationPreferenceRepository
+func (container *Container) NotificationPreferenceRepository() (repository repositories.NotificationPreferenceRepository) {
+	container.logger.Debug("creating GORM repositories.NotificationPreferenceRepository")
+	return repositories.NewGormNotificationPreferenceRepository(
+		container.Logger(),
+		container.Tracer(),
+		container.DB(),
+	)
+}
+
+// NotificationPreferenceService creates a new instance of services.NotificationPreferenceService
+func (container *Container) NotificationPreferenceService() (service *services.NotificationPreferenceService) {
+	container.logger.Debug(fmt.Sprintf("creating %T", service))
+	return services.NewNotificationPreferenceService(
+		container.Logger(),
+		container.Tracer(),
+		container.NotificationPreferenceRepository(),
+	)
+}
+
+// NotificationPreferenceHandlerValidator creates a new instance of validators.NotificationPreferenceHandlerValidator
+func (container *Container) NotificationPreferenceHandlerValidator() (validator *validators.NotificationPreferenceHandlerValidator) {
+	container.logger.Debug(fmt.Sprintf("creating %T", validator))
+	return validators.NewNotificationPreferenceHandlerValidator(
+		container.Logger(),
+		container.Tracer(),
+	)
+}
+
+// NotificationPreferenceHandler creates a new instance of handlers.NotificationPreferenceHandler
+func (container *Container) NotificationPreferenceHandler() (h *handlers.NotificationPreferenceHandler) {
+	container.logger.Debug(fmt.Sprintf("creating %T", h))
+	return handlers.NewNotificationPreferenceHandler(
+		container.Logger(),
+		container.Tracer(),
+		container.NotificationPreferenceService(),
+		container.NotificationPreferenceHandlerValidator(),
+	)
+}
+
+// RegisterNotificationPreferenceRoutes registers routes for the /notification-preferences prefix
+func (container *Container) RegisterNotificationPreferenceRoutes() {
+	container.logger.Debug(fmt.Sprintf("registering %T routes", &handlers.NotificationPreferenceHandler{}))
+	container.NotificationPreferenceHandler().RegisterRoutes(container.App(), container.AuthenticatedMiddleware())
+}
+
+// LinkPreviewRepository creates a new instance of repositories.LinkPreviewRepository
+func (container *Container) LinkPreviewRepository() (repository repositories.LinkPreviewRepository) {
+	container.logger.Debug("creating GORM repositories.LinkPreviewRepository")
+	return repositories.NewGormLinkPreviewRepository(
+		container.Logger(),
+		container.Tracer(),
+		container.DB(),
+	)
+}
+
+// LinkPreviewService creates a new instance of services.LinkPreviewService
+func (container *Container) LinkPreviewService() (service *services.LinkPreviewService) {
+	container.logger.Debug(fmt.Sprintf("creating %T", service))
+	return services.NewLinkPreviewService(
+		container.Logger(),
+		container.Tracer(),
+		container.HTTPClient("link_preview"),
+		container.LinkPreviewRepository(),
+	)
+}
+
+// LinkPreviewHandlerValidator creates a new instance of validators.LinkPreviewHandlerValidator
+func (container *Container) LinkPreviewHandlerValidator() (validator *validators.LinkPreviewHandlerValidator) {
+	container.logger.Debug(fmt.Sprintf("creating %T", validator))
+	return validators.NewLinkPreviewHandlerValidator(
+		container.Logger(),
+		container.Tracer(),
+	)
+}
+
+// LinkPreviewHandler creates a new instance of handlers.LinkPreviewHandler
+func (container *Container) LinkPreviewHandler() (h *handlers.LinkPreviewHandler) {
+	container.logger.Debug(fmt.Sprintf("creating %T", h))
+	return handlers.NewLinkPreviewHandler(
+		container.Logger(),
+		container.Tracer(),
+		container.LinkPreviewService(),
+		container.LinkPreviewHandlerValidator(),
+	)
+}
+
+// RegisterLinkPreviewRoutes registers routes for the /link-previews prefix
+func (container *Container) RegisterLinkPreviewRoutes() {
+	container.logger.Debug(fmt.Sprintf("registering %T routes", &handlers.LinkPreviewHandler{}))
+	container.LinkPreviewHandler().RegisterRoutes(container.App(), container.AuthenticatedMiddleware())
+}
+
+// RegisterLinkPreviewListeners registers event listeners for listeners.LinkPreviewListener
+func (container *Container) RegisterLinkPreviewListeners() {
+	container.logger.Debug(fmt.Sprintf("registering listeners for %T", listeners.LinkPreviewListener{}))
+	_, routes := listeners.NewLinkPreviewListener(
+		container.Logger(),
+		container.Tracer(),
+		container.LinkPreviewService(),
+	)
+
+	for event, handler := range routes {
+		container.EventDispatcher().Subscribe(event, handler)
+	}
+}
+
+// AttachmentScanner creates a new instance of services.AttachmentScanner
+func (container *Container) AttachmentScanner() (scanner services.AttachmentScanner) {
+	container.logger.Debug("creating services.AttachmentScanner")
+
+	switch os.Getenv("ATTACHMENT_SCANNER_TYPE") {
+	case "clamav":
+		return services.NewClamAVAttachmentScanner(
+			container.Logger(),
+			container.Tracer(),
+			os.Getenv("ATTACHMENT_SCANNER_ADDRESS"),
+		)
+	case "http":
+		return services.NewHTTPAttachmentScanner(
+			container.Logger(),
+			container.Tracer(),
+			container.HTTPClient("attachment_scanner"),
+			os.Getenv("ATTACHMENT_SCANNER_ADDRESS"),
+			os.Getenv("ATTACHMENT_SCANNER_API_KEY"),
+		)
+	default:
+		return services.NoopAttachmentScanner()
+	}
+}
+
+// AttachmentScanService creates a new instance of services.AttachmentScanService
+func (container *Container) AttachmentScanService() (service *services.AttachmentScanService) {
+	container.logger.Debug(fmt.Sprintf("creating %T", service))
+	return services.NewAttachmentScanService(
+		container.Logger(),
+		container.Tracer(),
+		container.AttachmentScanner(),
+		container.EventDispatcher(),
+	)
+}
+
+// StatusService creates a new instance of services.StatusService
+func (container *Container) StatusService() (service *services.StatusService) {
+	container.logger.Debug(fmt.Sprintf("creating %T", service))
+
+	components := make([]entities.InstanceStatusComponentName, 0)
+	for _, name := range strings.Split(os.Getenv("STATUS_PAGE_COMPONENTS"), ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			components = append(components, entities.InstanceStatusComponentName(name))
+		}
+	}
+
+	if len(components) == 0 {
+		components = []entities.InstanceStatusComponentName{
+			entities.InstanceStatusComponentAPI,
+			entities.InstanceStatusComponentQueue,
+			entities.InstanceStatusComponentDelivery,
+		}
+	}
+
+	return services.NewStatusService(
+		container.Logger(),
+		container.Tracer(),
+		container.Cache(),
+		container.AnalyticsRepository(),
+		components,
+	)
+}
+
+// StatusHandler creates a new instance of handlers.StatusHandler
+func (container *Container) StatusHandler() (h *handlers.StatusHandler) {
+	container.logger.Debug(fmt.Sprintf("creating %T", h))
+	return handlers.NewStatusHandler(
+		container.Logger(),
+		container.Tracer(),
+		container.StatusService(),
+	)
+}
+
+// RegisterStatusRoutes registers routes for the /status prefix
+func (container *Container) RegisterStatusRoutes() {
+	container.logger.Debug(fmt.Sprintf("registering %T routes", &handlers.StatusHandler{}))
+	container.StatusHandler().RegisterRoutes(container.App())
+}
+
+// InstanceIdentityService creates a new instance of services.InstanceIdentityService
+func (container *Container) InstanceIdentityService() (service *services.InstanceIdentityService) {
+	container.logger.Debug(fmt.Sprintf("creating %T", service))
+
+	egressIPs := make([]string, 0)
+	for _, ip := range strings.Split(os.Getenv("EGRESS_IPS"), ",") {
+		if ip = strings.TrimSpace(ip); ip != "" {
+			egressIPs = append(egressIPs, ip)
+		}
+	}
+
+	return services.NewInstanceIdentityService(
+		container.Logger(),
+		container.Tracer(),
+		container.WebhookSigningPrivateKey(),
+		egressIPs,
+		container.GlobalEgressProxyURL(),
+	)
+}
+
+// InstanceIdentityHandler creates a new instance of handlers.InstanceIdentityHandler
+func (container *Container) InstanceIdentityHandler() (h *handlers.InstanceIdentityHandler) {
+	container.logger.Debug(fmt.Sprintf("creating %T", h))
+	return handlers.NewInstanceIdentityHandler(
+		container.Logger(),
+		container.Tracer(),
+		container.InstanceIdentityService(),
+	)
+}
+
+// RegisterInstanceIdentityRoutes registers routes for the /instance prefix
+func (container *Container) RegisterInstanceIdentityRoutes() {
+	container.logger.Debug(fmt.Sprintf("registering %T routes", &handlers.InstanceIdentityHandler{}))
+	container.InstanceIdentityHandler().RegisterRoutes(container.App())
+}
+
+// CanaryRepository creates a new instance of repositories.CanaryRepository
+func (container *Container) CanaryRepository() (repository repositories.CanaryRepository) {
+	container.logger.Debug("creating GORM repositories.CanaryRepository")
+	return repositories.NewGormCanaryRepository(
+		container.Logger(),
+		container.Tracer(),
+		container.DB(),
+	)
+}
+
+// CanaryService creates a new instance of services.CanaryService
+func (container *Container) CanaryService() (service *services.CanaryService) {
+	container.logger.Debug(fmt.Sprintf("creating %T", service))
+	return services.NewCanaryService(
+		container.Logger(),
+		container.Tracer(),
+		container.CanaryRepository(),
+		container.MessageService(),
+		container.EventDispatcher(),
+	)
+}
+
+// CanaryHandlerValidator creates a new instance of validators.CanaryHandlerValidator
+func (container *Container) CanaryHandlerValidator() (validator *validators.CanaryHandlerValidator) {
+	container.logger.Debug(fmt.Sprintf("creating %T", validator))
+	return validators.NewCanaryHandlerValidator(
+		container.Logger(),
+		container.Tracer(),
+		container.PhoneService(),
+	)
+}
+
+// CanaryHandler creates a new instance of handlers.CanaryHandler
+func (container *Container) CanaryHandler() (h *handlers.CanaryHandler) {
+	container.logger.Debug(fmt.Sprintf("creating %T", h))
+	return handlers.NewCanaryHandler(
+		container.Logger(),
+		container.Tracer(),
+		container.CanaryService(),
+		container.CanaryHandlerValidator(),
+	)
+}
+
+// RegisterCanaryRoutes registers routes for the /canaries prefix
+func (container *Container) RegisterCanaryRoutes() {
+	container.logger.Debug(fmt.Sprintf("registering %T routes", &handlers.CanaryHandler{}))
+	container.CanaryHandler().RegisterRoutes(container.App(), container.AuthenticatedMiddleware())
+}
+
+// RegisterCanaryListeners registers event listeners for listeners.CanaryListener
+func (container *Container) RegisterCanaryListeners() {
+	container.logger.Debug(fmt.Sprintf("registering listeners for %T", listeners.CanaryListener{}))
+	_, routes := listeners.NewCanaryListener(
+		container.Logger(),
+		container.Tracer(),
+		container.CanaryService(),
+	)
+
+	for event, handler := range routes {
+		container.EventDispatcher().Subscribe(event, handler)
+	}
+}
+
+// EventService creates a new instance of services.EventService
+func (container *Container) EventService() (service *services.EventService) {
+	container.logger.Debug(fmt.Sprintf("creating %T", service))
+	return services.NewEventService(
+		container.Logger(),
+		container.Tracer(),
+		container.EventRepository(),
+		container.EventDispatcher(),
+	)
+}
+
+// WebSocketHandler creates a new instance of handlers.WebSocketHandler
+func (container *Container) WebSocketHandler() (handler *handlers.WebSocketHandler) {
+	container.logger.Debug(fmt.Sprintf("creating %T", handler))
+	return handlers.NewWebSocketHandler(
+		container.Logger(),
+		container.Tracer(),
+		container.EventHub(),
+		container.EventService(),
+		container.WebSocketHandlerValidator(),
+	)
+}
+
+// WebSocketHandlerValidator creates a new instance of validators.WebSocketHandlerValidator
+func (container *Container) WebSocketHandlerValidator() (validator *validators.WebSocketHandlerValidator) {
+	container.logger.Debug(fmt.Sprintf("creating %T", validator))
+	return validators.NewWebSocketHandlerValidator(
+		container.Logger(),
+		container.Tracer(),
+	)
+}
+
+// EventsHandlerValidator creates a new instance of validators.EventsHandlerValidator
+func (container *Container) EventsHandlerValidator() (validator *validators.EventsHandlerValidator) {
+	container.logger.Debug(fmt.Sprintf("creating %T", validator))
+	return validators.NewEventsHandlerValidator(
+		container.Logger(),
+		container.Tracer(),
+	)
+}
+
+// NotificationDigestRepository creates a new instance of repositories.NotificationDigestRepository
+func (container *Container) NotificationDigestRepository() (repository repositories.NotificationDigestRepository) {
+	container.logger.Debug("creating GORM repositories.NotificationDigestRepository")
+	return repositories.NewGormNotificationDigestRepository(
+		container.Logger(),
+		container.Tracer(),
+		container.DB(),
+	)
+}
+
+// ContactRepository creates a new instance of repositories.ContactRepository
+func (container *Container) ContactRepository() (repository repositories.ContactRepository) {
+	container.logger.Debug("creating GORM repositories.ContactRepository")
+	return repositories.NewGormContactRepository(
+		container.Logger(),
+		container.Tracer(),
+		container.DB(),
+	)
+}
+
+// ContactService creates a new instance of services.ContactService
+func (container *Container) ContactService() (service *services.ContactService) {
+	container.logger.Debug(fmt.Sprintf("creating %T", service))
+	return services.NewContactService(
+		container.Logger(),
+		container.Tracer(),
+		container.ContactRepository(),
+	)
+}
+
+// ContactHandlerValidator creates a new instance of validators.ContactHandlerValidator
+func (container *Container) ContactHandlerValidator() (validator *validators.ContactHandlerValidator) {
+	container.logger.Debug(fmt.Sprintf("creating %T", validator))
+	return validators.NewContactHandlerValidator(
+		container.Logger(),
+		container.Tracer(),
+	)
+}
+
+// ContactHandler creates a new instance of handlers.ContactHandler
+func (container *Container) ContactHandler() (h *handlers.ContactHandler) {
+	container.logger.Debug(fmt.Sprintf("creating %T", h))
+	return handlers.NewContactHandler(
+		container.Logger(),
+		container.Tracer(),
+		container.ContactService(),
+		container.ContactHandlerValidator(),
+		container.TagService(),
+	)
+}
+
+// RegisterContactRoutes registers routes for the /contacts prefix
+func (container *Container) RegisterContactRoutes() {
+	container.logger.Debug(fmt.Sprintf("registering %T routes", &handlers.ContactHandler{}))
+	container.ContactHandler().RegisterRoutes(container.App(), container.AuthenticatedMiddleware())
+}
+
+// RegisterContactListeners registers event listeners for listeners.ContactListener
+func (container *Container) RegisterContactListeners() {
+	container.logger.Debug(fmt.Sprintf("registering listeners for %T", listeners.ContactListener{}))
+	_, routes := listeners.NewContactListener(
+		container.Logger(),
+		container.Tracer(),
+		container.ContactService(),
+		container.EventListenerLogRepository(),
+	)
+
+	for event, handler := range routes {
+		container.EventDispatcher().Subscribe(event, handler)
+	}
+}
+
+// SegmentRepository creates a new instance of repositories.SegmentRepository
+func (container *Container) SegmentRepository() (repository repositories.SegmentRepository) {
+	container.logger.Debug("creating GORM repositories.SegmentRepository")
+	return repositories.NewGormSegmentRepository(
+		container.Logger(),
+		container.Tracer(),
+		container.DB(),
+	)
+}
+
+// SegmentService creates a new instance of services.SegmentService
+func (container *Container) SegmentService() (service *services.SegmentService) {
+	container.logger.Debug(fmt.Sprintf("creating %T", service))
+	return services.NewSegmentService(
+		container.Logger(),
+		container.Tracer(),
+		container.SegmentRepository(),
+		container.ContactRepository(),
+	)
+}
+
+// SegmentHandlerValidator creates a new instance of validators.SegmentHandlerValidator
+func (container *Container) SegmentHandlerValidator() (validator *validators.SegmentHandlerValidator) {
+	container.logger.Debug(fmt.Sprintf("creating %T", validator))
+	return validators.NewSegmentHandlerValidator(
+		container.Logger(),
+		container.Tracer(),
+	)
+}
+
+// SegmentHandler creates a new instance of handlers.SegmentHandler
+func (container *Container) SegmentHandler() (h *handlers.SegmentHandler) {
+	container.logger.Debug(fmt.Sprintf("creating %T", h))
+	return handlers.NewSegmentHandler(
+		container.Logger(),
+		container.Tracer(),
+		container.SegmentService(),
+		container.SegmentHandlerValidator(),
+	)
+}
+
+// RegisterSegmentRoutes registers routes for the /segments prefix
+func (container *Container) RegisterSegmentRoutes() {
+	container.logger.Debug(fmt.Sprintf("registering %T routes", &handlers.SegmentHandler{}))
+	container.SegmentHandler().RegisterRoutes(container.App(), container.AuthenticatedMiddleware())
+}
+
+// TagRepository creates a new instance of repositories.TagRepository
+func (container *Container) TagRepository() (repository repositories.TagRepository) {
+	container.logger.Debug("creating GORM repositories.TagRepository")
+	return repositories.NewGormTagRepository(
+		container.Logger(),
+		container.Tracer(),
+		container.DB(),
+		container.DatabaseResolver(),
+	)
+}
+
+// TagService creates a new instance of services.TagService
+func (container *Container) TagService() (service *services.TagService) {
+	container.logger.Debug(fmt.Sprintf("creating %T", service))
+	return services.NewTagService(
+		container.Logger(),
+		container.Tracer(),
+		container.TagRepository(),
+	)
+}
+
+// TagHandlerValidator creates a new instance of validators.TagHandlerValidator
+func (container *Container) TagHandlerValidator() (validator *validators.TagHandlerValidator) {
+	container.logger.Debug(fmt.Sprintf("creating %T", validator))
+	return validators.NewTagHandlerValidator(
+		container.Logger(),
+		container.Tracer(),
+	)
+}
+
+// TagHandler creates a new instance of handlers.TagHandler
+func (container *Container) TagHandler() (h *handlers.TagHandler) {
+	container.logger.Debug(fmt.Sprintf("creating %T", h))
+	return handlers.NewTagHandler(
+		container.Logger(),
+		container.Tracer(),
+		container.TagService(),
+		container.TagHandlerValidator(),
+	)
+}
+
+// RegisterTagRoutes registers routes for the /tags prefix
+func (container *Container) RegisterTagRoutes() {
+	container.logger.Debug(fmt.Sprintf("registering %T routes", &handlers.TagHandler{}))
+	container.TagHandler().RegisterRoutes(container.App(), container.AuthenticatedMiddleware())
+}
+
+// SavedFilterRepository creates a new instance of repositories.SavedFilterRepository
+func (container *Container) SavedFilterRepository() (repository repositories.SavedFilterRepository) {
+	container.logger.Debug("creating GORM repositories.SavedFilterRepository")
+	return repositories.NewGormSavedFilterRepository(
+		container.Logger(),
+		container.Tracer(),
+		container.DB(),
+	)
+}
+
+// SavedFilterService creates a new instance of services.SavedFilterService
+func (container *Container) SavedFilterService() (service *services.SavedFilterService) {
+	container.logger.Debug(fmt.Sprintf("creating %T", service))
+	return services.NewSavedFilterService(
+		container.Logger(),
+		container.Tracer(),
+		container.SavedFilterRepository(),
+		container.MessageService(),
+		container.MessageThreadService(),
+		container.TagService(),
+	)
+}
+
+// SavedFilterHandlerValidator creates a new instance of validators.SavedFilterHandlerValidator
+func (container *Container) SavedFilterHandlerValidator() (validator *validators.SavedFilterHandlerValidator) {
+	container.logger.Debug(fmt.Sprintf("creating %T", validator))
+	return validators.NewSavedFilterHandlerValidator(
+		container.Logger(),
+		container.Tracer(),
+	)
+}
+
+// SavedFilterHandler creates a new instance of handlers.SavedFilterHandler
+func (container *Container) SavedFilterHandler() (h *handlers.SavedFilterHandler) {
+	container.logger.Debug(fmt.Sprintf("creating %T", h))
+	return handlers.NewSavedFilterHandler(
+		container.Logger(),
+		container.Tracer(),
+		container.SavedFilterService(),
+		container.SavedFilterHandlerValidator(),
+	)
+}
+
+// RegisterSavedFilterRoutes registers routes for the /saved-filters prefix
+func (container *Container) RegisterSavedFilterRoutes() {
+	container.logger.Debug(fmt.Sprintf("registering %T routes", &handlers.SavedFilterHandler{}))
+	container.SavedFilterHandler().RegisterRoutes(container.App(), container.AuthenticatedMiddleware())
+}
+
+// BlocklistRepository creates a new instance of repositories.BlocklistRepository
+func (container *Container) BlocklistRepository() (repository repositories.BlocklistRepository) {
+	container.logger.Debug("creating GORM repositories.BlocklistRepository")
+	return repositories.NewGormBlocklistRepository(
+		container.Logger(),
+		container.Tracer(),
+		container.DB(),
+	)
+}
+
+// BlocklistService creates a new instance of services.BlocklistService
+func (container *Container) BlocklistService() (service *services.BlocklistService) {
+	container.logger.Debug(fmt.Sprintf("creating %T", service))
+	return services.NewBlocklistService(
+		container.Logger(),
+		container.Tracer(),
+		container.BlocklistRepository(),
+		container.UserRepository(),
+	)
+}
+
+// BlocklistHandlerValidator creates a new instance of validators.BlocklistHandlerValidator
+func (container *Container) BlocklistHandlerValidator() (validator *validators.BlocklistHandlerValidator) {
+	container.logger.Debug(fmt.Sprintf("creating %T", validator))
+	return validators.NewBlocklistHandlerValidator(
+		container.Logger(),
+		container.Tracer(),
+	)
+}
+
+// BlocklistHandler creates a new instance of handlers.BlocklistHandler
+func (container *Container) BlocklistHandler() (h *handlers.BlocklistHandler) {
+	container.logger.Debug(fmt.Sprintf("creating %T", h))
+	return handlers.NewBlocklistHandler(
+		container.Logger(),
+		container.Tracer(),
+		container.BlocklistService(),
+		container.BlocklistHandlerValidator(),
+	)
+}
+
+// RegisterBlocklistRoutes registers routes for the /blocklist prefix
+func (container *Container) RegisterBlocklistRoutes() {
+	container.logger.Debug(fmt.Sprintf("registering %T routes", &handlers.BlocklistHandler{}))
+	container.BlocklistHandler().RegisterRoutes(container.App(), container.AuthenticatedMiddleware())
+}
+
+// RegisterBlocklistListeners registers event listeners for listeners.BlocklistListener
+func (container *Container) RegisterBlocklistListeners() {
+	container.logger.Debug(fmt.Sprintf("registering listeners for %T", listeners.BlocklistListener{}))
+	_, routes := listeners.NewBlocklistListener(
+		container.Logger(),
+		container.Tracer(),
+		container.BlocklistService(),
+		container.EventListenerLogRepository(),
+	)
+
+	for event, handler := range routes {
+		container.EventDispatcher().Subscribe(event, handler)
+	}
+}
+
+// SubscriptionRepository creates a new instance of repositories.SubscriptionRepository
+func (container *Container) SubscriptionRepository() (repository repositories.SubscriptionRepository) {
+	container.logger.Debug("creating GORM repositories.SubscriptionRepository")
+	return repositories.NewGormSubscriptionRepository(
+		container.Logger(),
+		container.Tracer(),
+		container.DB(),
+	)
+}
+
+// SubscriptionService creates a new instance of services.SubscriptionService
+func (container *Container) SubscriptionService() (service *services.SubscriptionService) {
+	container.logger.Debug(fmt.Sprintf("creating %T", service))
+	return services.NewSubscriptionService(
+		container.Logger(),
+		container.Tracer(),
+		container.SubscriptionRepository(),
+		container.ContactService(),
+		container.MessageService(),
+	)
+}
+
+// SubscriptionHandlerValidator creates a new instance of validators.SubscriptionHandlerValidator
+func (container *Container) SubscriptionHandlerValidator() (validator *validators.SubscriptionHandlerValidator) {
+	container.logger.Debug(fmt.Sprintf("creating %T", validator))
+	return validators.NewSubscriptionHandlerValidator(
+		container.Logger(),
+		container.Tracer(),
+		container.PhoneService(),
+	)
+}
+
+// SubscriptionHandler creates a new instance of handlers.SubscriptionHandler
+func (container *Container) SubscriptionHandler() (h *handlers.SubscriptionHandler) {
+	container.logger.Debug(fmt.Sprintf("creating %T", h))
+	return handlers.NewSubscriptionHandler(
+		container.Logger(),
+		container.Tracer(),
+		container.SubscriptionService(),
+		container.SubscriptionHandlerValidator(),
+	)
+}
+
+// RegisterSubscriptionRoutes registers routes for the /subscriptions prefix
+func (container *Container) RegisterSubscriptionRoutes() {
+	container.logger.Debug(fmt.Sprintf("registering %T routes", &handlers.SubscriptionHandler{}))
+	container.SubscriptionHandler().RegisterRoutes(container.App(), container.AuthenticatedMiddleware())
+}
+
+// RegisterSubscriptionListeners registers event listeners for listeners.SubscriptionListener
+func (container *Container) RegisterSubscriptionListeners() {
+	container.logger.Debug(fmt.Sprintf("registering listeners for %T", listeners.SubscriptionListener{}))
+	_, routes := listeners.NewSubscriptionListener(
+		container.Logger(),
+		container.Tracer(),
+		container.SubscriptionService(),
+		container.EventListenerLogRepository(),
+	)
+
+	for event, handler := range routes {
+		container.EventDispatcher().Subscribe(event, handler)
+	}
+}
+
+// BroadcastRepository creates a new instance of repositories.BroadcastRepository
+func (container *Container) BroadcastRepository() (repository repositories.BroadcastRepository) {
+	container.logger.Debug("creating GORM repositories.BroadcastRepository")
+	return repositories.NewGormBroadcastRepository(
+		container.Logger(),
+		container.Tracer(),
+		container.DB(),
+	)
+}
+
+// BroadcastService creates a new instance of services.BroadcastService
+func (container *Container) BroadcastService() (service *services.BroadcastService) {
+	container.logger.Debug(fmt.Sprintf("creating %T", service))
+	return services.NewBroadcastService(
+		container.Logger(),
+		container.Tracer(),
+		container.BroadcastRepository(),
+		container.UserRepository(),
+		container.EventDispatcher(),
+	)
+}
+
+// BroadcastHandlerValidator creates a new instance of validators.BroadcastHandlerValidator
+func (container *Container) BroadcastHandlerValidator() (validator *validators.BroadcastHandlerValidator) {
+	container.logger.Debug(fmt.Sprintf("creating %T", validator))
+	return validators.NewBroadcastHandlerValidator(
+		container.Logger(),
+		container.Tracer(),
+	)
+}
+
+// BroadcastHandler creates a new instance of handlers.BroadcastHandler
+func (container *Container) BroadcastHandler() (h *handlers.BroadcastHandler) {
+	container.logger.Debug(fmt.Sprintf("creating %T", h))
+	return handlers.NewBroadcastHandler(
+		container.Logger(),
+		container.Tracer(),
+		container.BroadcastService(),
+		container.OperatorService(),
+		container.BroadcastHandlerValidator(),
+	)
+}
+
+// RegisterBroadcastRoutes registers routes for the /broadcasts and /operator/broadcasts prefixes
+func (container *Container) RegisterBroadcastRoutes() {
+	container.logger.Debug(fmt.Sprintf("registering %T routes", &handlers.BroadcastHandler{}))
+	container.BroadcastHandler().RegisterRoutes(container.App(), container.AuthenticatedMiddleware())
+}
+
+// RunSchedulers starts the background schedulers unless SCHEDULERS_ENABLED is false e.g. on the replicas which only serve the API
+func (container *Container) RunSchedulers(ctx context.Context) {
+	if os.Getenv("SCHEDULERS_ENABLED") == "false" {
+		container.logger.Info("schedulers are not started because SCHEDULERS_ENABLED is false")
+		return
+	}
+
+	go container.MessageScheduler().Run(ctx)
+	go container.CampaignScheduler().Run(ctx)
+	go container.InvoiceScheduler().Run(ctx)
+	go container.WebhookDeliveryScheduler().Run(ctx)
+	go container.AccessLogScheduler().Run(ctx)
+	go container.MessageArchiveScheduler().Run(ctx)
+	go container.MessageThreadArchiveScheduler().Run(ctx)
+	go container.MessageRetentionScheduler().Run(ctx)
+}
+
+// MessageScheduler creates a new instance of services.MessageScheduler
+func (container *Container) MessageScheduler() (scheduler *services.MessageScheduler) {
+	container.logger.Debug(fmt.Sprintf("creating %T", scheduler))
+	return services.NewMessageScheduler(
+		container.Logger(),
+		container.Tracer(),
+		container.MessageService(),
+		15*time.Second,
+	)
+}
+
+// BillingProvider creates a new instance of services.BillingProvider
+func (container *Container) BillingProvider() (provider services.BillingProvider) {
+	container.logger.Debug("creating services.BillingProvider")
+
+	switch os.Getenv("BILLING_PROVIDER") {
+	case "stripe":
+		return services.NewStripeBillingProvider(
+			container.Logger(),
+			container.Tracer(),
+			container.HTTPClient("stripe"),
+			os.Getenv("STRIPE_SECRET_KEY"),
+			container.BillingPlans("STRIPE_PRICE_IDS"),
+			os.Getenv("BILLING_RETURN_URL"),
+		)
+	default:
+		return services.NewLemonsqueezyBillingProvider(
+			container.Logger(),
+			container.Tracer(),
+			container.LemonsqueezyClient(),
+			container.BillingPlans("LEMONSQUEEZY_VARIANT_IDS"),
+			container.BillingPlans("LEMONSQUEEZY_CHECKOUT_URLS"),
+		)
+	}
+}
+
+// BillingPlans creates services.BillingPlans from an environment variable formatted as "pro-monthly=price_1,pro-yearly=price_2"
+func (container *Container) BillingPlans(key string) (plans services.BillingPlans) {
+	plans = services.BillingPlans{}
+	for _, item := range strings.Split(os.Getenv(key), ",") {
+		name, value, found := strings.Cut(strings.TrimSpace(item), "=")
+		if found && strings.TrimSpace(name) != "" && strings.TrimSpace(value) != "" {
+			plans[entities.SubscriptionName(strings.TrimSpace(name))] = strings.TrimSpace(value)
+		}
+	}
+	return plans
+}
+
+// StripeService creates a new instance of services.StripeService
+func (container *Container) StripeService() (service *services.StripeService) {
+	container.logger.Debug(fmt.Sprintf("creating %T", service))
+	return services.NewStripeService(
+		container.Logger(),
+		container.Tracer(),
+		container.UserRepository(),
+		container.EventDispatcher(),
+		container.BillingPlans("STRIPE_PRICE_IDS"),
+	)
+}
+
+// StripeHandlerValidator creates a new instance of validators.StripeHandlerValidator
+func (container *Container) StripeHandlerValidator() (validator *validators.StripeHandlerValidator) {
+	container.logger.Debug(fmt.Sprintf("creating %T", validator))
+	return validators.NewStripeHandlerValidator(
+		container.Logger(),
+		container.Tracer(),
+		os.Getenv("STRIPE_WEBHOOK_SECRET"),
+	)
+}
+
+// StripeHandler creates a new instance of handlers.StripeHandler
+func (container *Container) StripeHandler() (handler *handlers.StripeHandler) {
+	container.logger.Debug(fmt.Sprintf("creating %T", handler))
+	return handlers.NewStripeHandler(
+		container.Logger(),
+		container.Tracer(),
+		container.StripeService(),
+		container.StripeHandlerValidator(),
+	)
+}
+
+// RegisterStripeRoutes registers routes for the /stripe prefix
+func (container *Container) RegisterStripeRoutes() {
+	container.logger.Debug(fmt.Sprintf("registering %T routes", &handlers.StripeHandler{}))
+	container.StripeHandler().RegisterRoutes(container.App())
+}
+
+// CampaignRepository creates a new instance of repositories.CampaignRepository
+func (container *Container) CampaignRepository() (repository repositories.CampaignRepository) {
+	container.logger.Debug("creating GORM repositories.CampaignRepository")
+	return repositories.NewGormCampaignRepository(
+		container.Logger(),
+		container.Tracer(),
+		container.DB(),
+	)
+}
+
+// CampaignService creates a new instance of services.CampaignService
+func (container *Container) CampaignService() (service *services.CampaignService) {
+	container.logger.Debug(fmt.Sprintf("creating %T", service))
+	return services.NewCampaignService(
+		container.Logger(),
+		container.Tracer(),
+		container.CampaignRepository(),
+		container.SegmentRepository(),
+		container.BlocklistRepository(),
+		container.TemplateRepository(),
+		container.UserRepository(),
+		container.SegmentService(),
+		container.ContactGroupService(),
+		container.MessageService(),
+	)
+}
+
+// CampaignScheduler creates a new instance of services.CampaignScheduler
+func (container *Container) CampaignScheduler() (scheduler *services.CampaignScheduler) {
+	container.logger.Debug(fmt.Sprintf("creating %T", scheduler))
+	return services.NewCampaignScheduler(
+		container.Logger(),
+		container.Tracer(),
+		container.CampaignService(),
+		30*time.Second,
+	)
+}
+
+// CampaignHandlerValidator creates a new instance of validators.CampaignHandlerValidator
+func (container *Container) CampaignHandlerValidator() (validator *validators.CampaignHandlerValidator) {
+	container.logger.Debug(fmt.Sprintf("creating %T", validator))
+	return validators.NewCampaignHandlerValidator(
+		container.Logger(),
+		container.Tracer(),
+		container.PhoneService(),
+	)
+}
+
+// CampaignHandler creates a new instance of handlers.CampaignHandler
+func (container *Container) CampaignHandler() (h *handlers.CampaignHandler) {
+	container.logger.Debug(fmt.Sprintf("creating %T", h))
+	return handlers.NewCampaignHandler(
+		container.Logger(),
+		container.Tracer(),
+		container.CampaignService(),
+		container.CampaignHandlerValidator(),
+	)
+}
+
+// RegisterCampaignRoutes registers routes for the /campaigns prefix
+func (container *Container) RegisterCampaignRoutes() {
+	container.logger.Debug(fmt.Sprintf("registering %T routes", &handlers.CampaignHandler{}))
+	container.CampaignHandler().RegisterRoutes(container.App(), container.AuthenticatedMiddleware())
+}
+
+// OperatorService creates a new instance of services.OperatorService
+func (container *Container) OperatorService() (service *services.OperatorService) {
+	container.logger.Debug(fmt.Sprintf("creating %T", service))
+	return services.NewOperatorService(
+		container.Logger(),
+		container.OperatorUserIDs(),
+	)
+}
+
+// OperatorUserIDs returns the IDs of the users who can manage the instance from the OPERATOR_USER_IDS environment variable
+func (container *Container) OperatorUserIDs() (operators []entities.UserID) {
+	operators = make([]entities.UserID, 0)
+	for _, userID := range strings.Split(os.Getenv("OPERATOR_USER_IDS"), ",") {
+		if userID = strings.TrimSpace(userID); userID != "" {
+			operators = append(operators, entities.UserID(userID))
+		}
+	}
+	return operators
+}
+
+// CreditRepository creates a new instance of repositories.CreditRepository
+func (container *Container) CreditRepository() (repository repositories.CreditRepository) {
+	container.logger.Debug("creating GORM repositories.CreditRepository")
+	return repositories.NewGormCreditRepository(
+		container.Logger(),
+		container.Tracer(),
+		container.DB(),
+	)
+}
+
+// CreditRates creates services.CreditRates from the CREDIT_RATES environment variable formatted as "default=1,NG=2"
+func (container *Container) CreditRates() (rates services.CreditRates) {
+	rates = services.CreditRates{}
+	for _, item := range strings.Split(os.Getenv("CREDIT_RATES"), ",") {
+		region, value, found := strings.Cut(strings.TrimSpace(item), "=")
+		if !found {
+			continue
+		}
+
+		rate, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+		if err != nil || rate < 0 {
+			container.logger.Error(stacktrace.Propagate(err, fmt.Sprintf("invalid credit rate [%s] for region [%s]", value, region)))
+			continue
+		}
+		rates[strings.TrimSpace(region)] = rate
+	}
+	return rates
+}
+
+// CreditService creates a new instance of services.CreditService
+func (container *Container) CreditService() (service *services.CreditService) {
+	container.logger.Debug(fmt.Sprintf("creating %T", service))
+	return services.NewCreditService(
+		container.Logger(),
+		container.Tracer(),
+		container.CreditRepository(),
+		container.UserRepository(),
+		container.CreditRates(),
+	)
+}
+
+// CreditHandlerValidator creates a new instance of validators.CreditHandlerValidator
+func (container *Container) CreditHandlerValidator() (validator *validators.CreditHandlerValidator) {
+	container.logger.Debug(fmt.Sprintf("creating %T", validator))
+	return validators.NewCreditHandlerValidator(
+		container.Logger(),
+		container.Tracer(),
+	)
+}
+
+// CreditHandler creates a new instance of handlers.CreditHandler
+func (container *Container) CreditHandler() (h *handlers.CreditHandler) {
+	container.logger.Debug(fmt.Sprintf("creating %T", h))
+	return handlers.NewCreditHandler(
+		container.Logger(),
+		container.Tracer(),
+		container.CreditService(),
+		container.OperatorService(),
+		container.CreditHandlerValidator(),
+	)
+}
+
+// RegisterCreditRoutes registers routes for the /credits prefix
+func (container *Container) RegisterCreditRoutes() {
+	container.logger.Debug(fmt.Sprintf("registering %T routes", &handlers.CreditHandler{}))
+	container.CreditHandler().RegisterRoutes(container.App(), container.AuthenticatedMiddleware())
+}
+
+// InvoiceRepository creates a new instance of repositories.InvoiceRepository
+func (container *Container) InvoiceRepository() (repository repositories.InvoiceRepository) {
+	container.logger.Debug("creating GORM repositories.InvoiceRepository")
+	return repositories.NewGormInvoiceRepository(
+		container.Logger(),
+		container.Tracer(),
+		container.DB(),
+	)
+}
+
+// InvoiceService creates a new instance of services.InvoiceService
+func (container *Container) InvoiceService() (service *services.InvoiceService) {
+	container.logger.Debug(fmt.Sprintf("creating %T", service))
+	return services.NewInvoiceService(
+		container.Logger(),
+		container.Tracer(),
+		container.InvoiceRepository(),
+		container.UserRepository(),
+		container.BillingUsageRepository(),
+		container.CreditRepository(),
+		container.Mailer(),
+		container.UserEmailFactory(),
+		os.Getenv("INVOICE_EMAIL_ENABLED") == "true",
+	)
+}
+
+// InvoiceScheduler creates a new instance of services.InvoiceScheduler
+func (container *Container) InvoiceScheduler() (scheduler *services.InvoiceScheduler) {
+	container.logger.Debug(fmt.Sprintf("creating %T", scheduler))
+	return services.NewInvoiceScheduler(
+		container.Logger(),
+		container.Tracer(),
+		container.InvoiceService(),
+		time.Hour,
+	)
+}
+
+// InvoiceHandlerValidator creates a new instance of validators.InvoiceHandlerValidator
+func (container *Container) InvoiceHandlerValidator() (validator *validators.InvoiceHandlerValidator) {
+	container.logger.Debug(fmt.Sprintf("creating %T", validator))
+	return validators.NewInvoiceHandlerValidator(
+		container.Logger(),
+		container.Tracer(),
+	)
+}
+
+// InvoiceHandler creates a new instance of handlers.InvoiceHandler
+func (container *Container) InvoiceHandler() (h *handlers.InvoiceHandler) {
+	container.logger.Debug(fmt.Sprintf("creating %T", h))
+	return handlers.NewInvoiceHandler(
+		container.Logger(),
+		container.Tracer(),
+		container.InvoiceService(),
+		container.InvoiceHandlerValidator(),
+	)
+}
+
+// RegisterInvoiceRoutes registers routes for the /invoices prefix
+func (container *Container) RegisterInvoiceRoutes() {
+	container.logger.Debug(fmt.Sprintf("registering %T routes", &handlers.InvoiceHandler{}))
+	container.InvoiceHandler().RegisterRoutes(container.App(), container.AuthenticatedMiddleware())
+}
+
+// CouponRepository creates a new instance of repositories.CouponRepository
+func (container *Container) CouponRepository() (repository repositories.CouponRepository) {
+	container.logger.Debug("creating GORM repositories.CouponRepository")
+	return repositories.NewGormCouponRepository(
+		container.Logger(),
+		container.Tracer(),
+		container.DB(),
+	)
+}
+
+// PlanOverrideRepository creates a new instance of repositories.PlanOverrideRepository
+func (container *Container) PlanOverrideRepository() (repository repositories.PlanOverrideRepository) {
+	container.logger.Debug("creating GORM repositories.PlanOverrideRepository")
+	return repositories.NewGormPlanOverrideRepository(
+		container.Logger(),
+		container.Tracer(),
+		container.DB(),
+	)
+}
+
+// PromotionService creates a new instance of services.PromotionService
+func (container *Container) PromotionService() (service *services.PromotionService) {
+	container.logger.Debug(fmt.Sprintf("creating %T", service))
+	return services.NewPromotionService(
+		container.Logger(),
+		container.Tracer(),
+		container.CouponRepository(),
+		container.PlanOverrideRepository(),
+		container.UserRepository(),
+	)
+}
+
+// PromotionHandlerValidator creates a new instance of validators.PromotionHandlerValidator
+func (container *Container) PromotionHandlerValidator() (validator *validators.PromotionHandlerValidator) {
+	container.logger.Debug(fmt.Sprintf("creating %T", validator))
+	return validators.NewPromotionHandlerValidator(
+		container.Logger(),
+		container.Tracer(),
+	)
+}
+
+// PromotionHandler creates a new instance of handlers.PromotionHandler
+func (container *Container) PromotionHandler() (h *handlers.PromotionHandler) {
+	container.logger.Debug(fmt.Sprintf("creating %T", h))
+	return handlers.NewPromotionHandler(
+		container.Logger(),
+		container.Tracer(),
+		container.PromotionService(),
+		container.OperatorService(),
+		container.PromotionHandlerValidator(),
+	)
+}
+
+// RegisterPromotionRoutes registers routes for coupons and plan overrides
+func (container *Container) RegisterPromotionRoutes() {
+	container.logger.Debug(fmt.Sprintf("registering %T routes", &handlers.PromotionHandler{}))
+	container.PromotionHandler().RegisterRoutes(container.App(), container.AuthenticatedMiddleware())
+}
+
+// AttachmentRepository creates a new instance of repositories.AttachmentRepository
+func (container *Container) AttachmentRepository() (repository repositories.AttachmentRepository) {
+	container.logger.Debug("creating GORM repositories.AttachmentRepository")
+	return repositories.NewGormAttachmentRepository(
+		container.Logger(),
+		container.Tracer(),
+		container.DB(),
+	)
+}
+
+// AttachmentStorage creates the services.AttachmentStorage which is selected with ATTACHMENT_STORAGE
+func (container *Container) AttachmentStorage() (store services.AttachmentStorage) {
+	container.logger.Debug("creating services.AttachmentStorage")
+
+	switch os.Getenv("ATTACHMENT_STORAGE") {
+	case "s3":
+		return services.NewS3AttachmentStorage(
+			container.Logger(),
+			container.Tracer(),
+			container.HTTPClient("s3"),
+			services.S3Config{
+				Endpoint:        os.Getenv("S3_ENDPOINT"),
+				Region:          os.Getenv("S3_REGION"),
+				Bucket:          os.Getenv("S3_BUCKET"),
+				AccessKeyID:     os.Getenv("S3_ACCESS_KEY_ID"),
+				SecretAccessKey: os.Getenv("S3_SECRET_ACCESS_KEY"),
+			},
+		)
+	case "gcs":
+		client, err := storage.NewClient(context.Background(), option.WithCredentialsJSON(container.FirebaseCredentials()))
+		if err != nil {
+			container.logger.Fatal(stacktrace.Propagate(err, "cannot initialize google cloud storage client"))
+		}
+		return services.NewGCSAttachmentStorage(
+			container.Logger(),
+			container.Tracer(),
+			client,
+			os.Getenv("GCS_BUCKET"),
+		)
+	default:
+		return services.NewLocalAttachmentStorage(
+			container.Logger(),
+			container.Tracer(),
+			os.Getenv("ATTACHMENT_STORAGE_PATH"),
+			os.Getenv("ATTACHMENT_BASE_URL"),
+			os.Getenv("ATTACHMENT_SIGNING_KEY"),
+		)
+	}
+}
+
+// MessageSearchBackend creates the services.MessageSearchBackend which is selected with MESSAGE_SEARCH_BACKEND
+func (container *Container) MessageSearchBackend() (backend services.MessageSearchBackend) {
+	container.logger.Debug("creating services.MessageSearchBackend")
+
+	switch os.Getenv("MESSAGE_SEARCH_BACKEND") {
+	case "meilisearch":
+		// the content is not sent to an external index when it is encrypted, the postgres backend rejects searches instead
+		if container.MessageEncryptionService() != nil {
+			container.logger.Warn(stacktrace.NewError("MESSAGE_SEARCH_BACKEND=meilisearch is ignored because the content of messages is encrypted"))
+			break
+		}
+		return services.NewMeilisearchMessageSearchBackend(
+			container.Logger(),
+			container.Tracer(),
+			container.HTTPClient("meilisearch"),
+			services.MeilisearchConfig{
+				URL:    os.Getenv("MEILISEARCH_URL"),
+				APIKey: os.Getenv("MEILISEARCH_API_KEY"),
+				Index:  os.Getenv("MEILISEARCH_INDEX"),
+			},
+		)
+	}
+
+	return services.NewPostgresMessageSearchBackend(
+		container.Logger(),
+		container.Tracer(),
+		container.MessageRepository(),
+	)
+}
+
+// MessageSearchService creates a new instance of services.MessageSearchService
+func (container *Container) MessageSearchService() (service *services.MessageSearchService) {
+	container.logger.Debug(fmt.Sprintf("creating %T", service))
+	return services.NewMessageSearchService(
+		container.Logger(),
+		container.Tracer(),
+		container.MessageSearchBackend(),
+		container.MessageRepository(),
+		container.AttachmentService(),
+	)
+}
+
+// RegisterMessageSearchListeners registers event listeners for listeners.MessageSearchListener
+func (container *Container) RegisterMessageSearchListeners() {
+	container.logger.Debug(fmt.Sprintf("registering listeners for %T", listeners.MessageSearchListener{}))
+	_, routes := listeners.NewMessageSearchListener(
+		container.Logger(),
+		container.Tracer(),
+		container.MessageSearchService(),
+	)
+
+	for event, handler := range routes {
+		container.EventDispatcher().Subscribe(event, handler)
+	}
+}
+
+// AttachmentService creates a new instance of services.AttachmentService
+func (container *Container) AttachmentService() (service *services.AttachmentService) {
+	container.logger.Debug(fmt.Sprintf("creating %T", service))
+	return services.NewAttachmentService(
+		container.Logger(),
+		container.Tracer(),
+		container.AttachmentRepository(),
+		container.AttachmentStorage(),
+		container.AttachmentScanService(),
+		os.Getenv("ATTACHMENT_SIGNING_KEY"),
+		24*time.Hour,
+	)
+}
+
+// AttachmentHandler creates a new instance of handlers.AttachmentHandler
+func (container *Container) AttachmentHandler() (h *handlers.AttachmentHandler) {
+	container.logger.Debug(fmt.Sprintf("creating %T", h))
+	return handlers.NewAttachmentHandler(
+		container.Logger(),
+		container.Tracer(),
+		container.AttachmentService(),
+	)
+}
+
+// RegisterAttachmentRoutes registers routes for the /attachments prefix
+func (container *Container) RegisterAttachmentRoutes() {
+	container.logger.Debug(fmt.Sprintf("registering %T routes", &handlers.AttachmentHandler{}))
+	container.AttachmentHandler().RegisterRoutes(container.App())
+}
+
+// ReferralRepository creates a new instance of repositories.ReferralRepository
+func (container *Container) ReferralRepository() (repository repositories.ReferralRepository) {
+	container.logger.Debug("creating GORM repositories.ReferralRepository")
+	return repositories.NewGormReferralRepository(
+		container.Logger(),
+		container.Tracer(),
+		container.DB(),
+	)
+}
+
+// ReferralService creates a new instance of services.ReferralService
+func (container *Container) ReferralService() (service *services.ReferralService) {
+	container.logger.Debug(fmt.Sprintf("creating %T", service))
+
+	reward := services.ReferralReward{
+		Type:             entities.ReferralRewardTypeCredits,
+		SubscriptionName: entities.SubscriptionNameProMonthly,
+		Days:             30,
+		OnSignup:         os.Getenv("REFERRAL_REWARD_ON_SIGNUP") == "true",
+	}
+
+	if os.Getenv("REFERRAL_REWARD_TYPE") == string(entities.ReferralRewardTypeDiscount) {
+		reward.Type = entities.ReferralRewardTypeDiscount
+	}
+
+	if credits, err := strconv.ParseInt(os.Getenv("REFERRAL_REWARD_CREDITS"), 10, 64); err == nil {
+		reward.Credits = credits
+	}
+
+	if days, err := strconv.ParseUint(os.Getenv("REFERRAL_REWARD_DAYS"), 10, 32); err == nil {
+		reward.Days = uint(days)
+	}
+
+	if name := os.Getenv("REFERRAL_REWARD_SUBSCRIPTION"); name != "" {
+		reward.SubscriptionName = entities.SubscriptionName(name)
+	}
+
+	return services.NewReferralService(
+		container.Logger(),
+		container.Tracer(),
+		container.ReferralRepository(),
+		container.UserRepository(),
+		container.CreditRepository(),
+		reward,
+	)
+}
+
+// ReferralHandlerValidator creates a new instance of validators.ReferralHandlerValidator
+func (container *Container) ReferralHandlerValidator() (validator *validators.ReferralHandlerValidator) {
+	container.logger.Debug(fmt.Sprintf("creating %T", validator))
+	return validators.NewReferralHandlerValidator(
+		container.Logger(),
+		container.Tracer(),
+	)
+}
+
+// ReferralHandler creates a new instance of handlers.ReferralHandler
+func (container *Container) ReferralHandler() (h *handlers.ReferralHandler) {
+	container.logger.Debug(fmt.Sprintf("creating %T", h))
+	return handlers.NewReferralHandler(
+		container.Logger(),
+		container.Tracer(),
+		container.ReferralService(),
+		container.ReferralHandlerValidator(),
+	)
+}
+
+// RegisterReferralRoutes registers routes for the /referrals prefix
+func (container *Container) RegisterReferralRoutes() {
+	container.logger.Debug(fmt.Sprintf("registering %T routes", &handlers.ReferralHandler{}))
+	container.ReferralHandler().RegisterRoutes(container.App(), container.AuthenticatedMiddleware())
+}
+
+// RegisterReferralListeners registers event listeners for listeners.ReferralListener
+func (container *Container) RegisterReferralListeners() {
+	container.logger.Debug(fmt.Sprintf("registering listeners for %T", listeners.ReferralListener{}))
+	_, routes := listeners.NewReferralListener(
+		container.Logger(),
+		container.Tracer(),
+		container.ReferralService(),
+	)
+
+	for event, handler := range routes {
+		container.EventDispatcher().Subscribe(event, handler)
+	}
+}
+
+// DashboardService creates a new instance of services.DashboardService
+func (container *Container) DashboardService() (service *services.DashboardService) {
+	container.logger.Debug(fmt.Sprintf("creating %T", service))
+	return services.NewDashboardService(
+		container.Logger(),
+		container.Tracer(),
+		container.AnalyticsRepository(),
+		container.PhoneRepository(),
+		container.HeartbeatRepository(),
+		container.BillingService(),
+	)
+}
+
+// DashboardHandler creates a new instance of handlers.DashboardHandler
+func (container *Container) DashboardHandler() (h *handlers.DashboardHandler) {
+	container.logger.Debug(fmt.Sprintf("creating %T", h))
+	return handlers.NewDashboardHandler(
+		container.Logger(),
+		container.Tracer(),
+		container.DashboardService(),
+	)
+}
+
+// RegisterDashboardRoutes registers routes for the /dashboard prefix
+func (container *Container) RegisterDashboardRoutes() {
+	container.logger.Debug(fmt.Sprintf("registering %T routes", &handlers.DashboardHandler{}))
+	container.DashboardHandler().RegisterRoutes(container.App(), container.AuthenticatedMiddleware())
+}
+
+// ActivityService creates a new instance of services.ActivityService
+func (container *Container) ActivityService() (service *services.ActivityService) {
+	container.logger.Debug(fmt.Sprintf("creating %T", service))
+	return services.NewActivityService(
+		container.Logger(),
+		container.Tracer(),
+		container.EventRepository(),
+	)
+}
+
+// ActivityHandlerValidator creates a new instance of validators.ActivityHandlerValidator
+func (container *Container) ActivityHandlerValidator() (validator *validators.ActivityHandlerValidator) {
+	container.logger.Debug(fmt.Sprintf("creating %T", validator))
+	return validators.NewActivityHandlerValidator(
+		container.Logger(),
+		container.Tracer(),
+	)
+}
+
+// ActivityHandler creates a new instance of handlers.ActivityHandler
+func (container *Container) ActivityHandler() (h *handlers.ActivityHandler) {
+	container.logger.Debug(fmt.Sprintf("creating %T", h))
+	return handlers.NewActivityHandler(
+		container.Logger(),
+		container.Tracer(),
+		container.ActivityService(),
+		container.ActivityHandlerValidator(),
+	)
+}
+
+// RegisterActivityRoutes registers routes for the /activity prefix
+func (container *Container) RegisterActivityRoutes() {
+	container.logger.Debug(fmt.Sprintf("registering %T routes", &handlers.ActivityHandler{}))
+	container.ActivityHandler().RegisterRoutes(container.App(), container.AuthenticatedMiddleware())
+}
+
+// PhonePaceService creates a new instance of services.PhonePaceService
+func (container *Container) PhonePaceService() (service *services.PhonePaceService) {
+	container.logger.Debug(fmt.Sprintf("creating %T", service))
+	return services.NewPhonePaceService(
+		container.Logger(),
+		container.Tracer(),
+		global.Meter(container.projectID),
+		container.PhoneRepository(),
+		container.MessageRepository(),
+	)
+}
+
+// RegisterPhonePaceListeners registers event listeners for listeners.PhonePaceListener
+func (container *Container) RegisterPhonePaceListeners() {
+	container.logger.Debug(fmt.Sprintf("registering listeners for %T", listeners.PhonePaceListener{}))
+	_, routes := listeners.NewPhonePaceListener(
+		container.Logger(),
+		container.Tracer(),
+		container.PhonePaceService(),
+		container.EventListenerLogRepository(),
+	)
+
+	for event, handler := range routes {
+		container.EventDispatcher().Subscribe(event, handler)
+	}
+}
+
+// KafkaEventSinkConfig creates a new instance of services.KafkaEventSinkConfig
+func (container *Container) KafkaEventSinkConfig() (config services.KafkaEventSinkConfig) {
+	brokers := make([]string, 0)
+	for _, broker := range strings.Split(os.Getenv("KAFKA_BROKERS"), ",") {
+		if broker = strings.TrimSpace(broker); broker != "" {
+			brokers = append(brokers, broker)
+		}
+	}
+
+	return services.KafkaEventSinkConfig{
+		Brokers:           brokers,
+		Topic:             os.Getenv("KAFKA_EVENTS_TOPIC"),
+		TopicPerEventType: os.Getenv("KAFKA_EVENTS_TOPIC_PER_EVENT_TYPE") == "true",
+	}
+}
+
+// RegisterEventSinkListeners mirrors every event to kafka when KAFKA_BROKERS is set
+func (container *Container) RegisterEventSinkListeners() {
+	config := container.KafkaEventSinkConfig()
+	if len(config.Brokers) == 0 {
+		return
+	}
+
+	container.logger.Debug(fmt.Sprintf("registering listeners for %T", listeners.EventSinkListener{}))
+	listener := listeners.NewEventSinkListener(
+		container.Logger(),
+		container.Tracer(),
+		services.NewKafkaEventSink(container.Logger(), container.Tracer(), config),
+	)
+
+	container.EventDispatcher().SubscribeAll(listener.OnEvent)
+}
+
+// PhoneFailoverPolicyRepository creates a new instance of repositories.PhoneFailoverPolicyRepository
+func (container *Container) PhoneFailoverPolicyRepository() (repository repositories.PhoneFailoverPolicyRepository) {
+	container.logger.Debug("creating GORM repositories.PhoneFailoverPolicyRepository")
+	return repositories.NewGormPhoneFailoverPolicyRepository(
+		container.Logger(),
+		container.Tracer(),
+		container.DB(),
+	)
+}
+
+// PhoneFailoverService creates a new instance of services.PhoneFailoverService
+func (container *Container) PhoneFailoverService() (service *services.PhoneFailoverService) {
+	container.logger.Debug(fmt.Sprintf("creating %T", service))
+	return services.NewPhoneFailoverService(
+		container.Logger(),
+		container.Tracer(),
+		container.PhoneFailoverPolicyRepository(),
+		container.PhoneRepository(),
+		container.HeartbeatRepository(),
+		container.MessageService(),
+	)
+}
+
+// PhoneFailoverHandlerValidator creates a new instance of validators.PhoneFailoverHandlerValidator
+func (container *Container) PhoneFailoverHandlerValidator() (validator *validators.PhoneFailoverHandlerValidator) {
+	container.logger.Debug(fmt.Sprintf("creating %T", validator))
+	return validators.NewPhoneFailoverHandlerValidator(
+		container.Logger(),
+		container.Tracer(),
+	)
+}
+
+// PhoneFailoverHandler creates a new instance of handlers.PhoneFailoverHandler
+func (container *Container) PhoneFailoverHandler() (h *handlers.PhoneFailoverHandler) {
+	container.logger.Debug(fmt.Sprintf("creating %T", h))
+	return handlers.NewPhoneFailoverHandler(
+		container.Logger(),
+		container.Tracer(),
+		container.PhoneFailoverService(),
+		container.PhoneFailoverHandlerValidator(),
+	)
+}
+
+// RegisterPhoneFailoverRoutes registers routes for the /phone-failover-policies prefix
+func (container *Container) RegisterPhoneFailoverRoutes() {
+	container.logger.Debug(fmt.Sprintf("registering %T routes", &handlers.PhoneFailoverHandler{}))
+	container.PhoneFailoverHandler().RegisterRoutes(container.App(), container.AuthenticatedMiddleware())
+}
+
+// RegisterPhoneFailoverListeners registers event listeners for listeners.PhoneFailoverListener
+func (container *Container) RegisterPhoneFailoverListeners() {
+	container.logger.Debug(fmt.Sprintf("registering listeners for %T", listeners.PhoneFailoverListener{}))
+	_, routes := listeners.NewPhoneFailoverListener(
+		container.Logger(),
+		container.Tracer(),
+		container.PhoneFailoverService(),
+		container.EventListenerLogRepository(),
+	)
+
+	for event, handler := range routes {
+		container.EventDispatcher().Subscribe(event, handler)
+	}
+}
+
+// RateLimitService creates a new instance of services.RateLimitService
+func (container *Container) RateLimitService() (service *services.RateLimitService) {
+	container.logger.Debug(fmt.Sprintf("creating %T", service))
+	return services.NewRateLimitService(
+		container.Logger(),
+		container.Tracer(),
+		container.RedisClient(),
+		container.UserRepository(),
+	)
+}
+
+// JWTProvider creates a new instance of identity.JWTProvider
+func (container *Container) JWTProvider() (provider *identity.JWTProvider) {
+	container.logger.Debug(fmt.Sprintf("creating %T", provider))
+	return identity.NewJWTProvider(
+		container.Tracer(),
+		os.Getenv("AUTH_JWT_SECRET"),
+		os.Getenv("AUTH_JWT_ISSUER"),
+	)
+}
+
+// UserCredentialRepository creates a new instance of repositories.UserCredentialRepository
+func (container *Container) UserCredentialRepository() (repository repositories.UserCredentialRepository) {
+	container.logger.Debug("creating GORM repositories.UserCredentialRepository")
+	return repositories.NewGormUserCredentialRepository(
+		container.Logger(),
+		container.Tracer(),
+		container.DB(),
+	)
+}
+
+// AuthSessionRepository creates a new instance of repositories.AuthSessionRepository
+func (container *Container) AuthSessionRepository() (repository repositories.AuthSessionRepository) {
+	container.logger.Debug("creating GORM repositories.AuthSessionRepository")
+	return repositories.NewGormAuthSessionRepository(
+		container.Logger(),
+		container.Tracer(),
+		container.DB(),
+	)
+}
+
+// AuthService creates a new instance of services.AuthService
+func (container *Container) AuthService() (service *services.AuthService) {
+	container.logger.Debug(fmt.Sprintf("creating %T", service))
+
+	return services.NewAuthService(
+		container.Logger(),
+		container.Tracer(),
+		container.UserCredentialRepository(),
+		container.AuthSessionService(),
+		container.JWTProvider(),
+		container.Mailer(),
+		container.UserEmailFactory(),
+	)
+}
+
+// AuthSessionService creates a new instance of services.AuthSessionService
+func (container *Container) AuthSessionService() (service *services.AuthSessionService) {
+	container.logger.Debug(fmt.Sprintf("creating %T", service))
+
+	sessionTTL := 30 * 24 * time.Hour
+	if ttl, err := time.ParseDuration(os.Getenv("AUTH_SESSION_TTL")); err == nil && ttl > 0 {
+		sessionTTL = ttl
+	}
+
+	return services.NewAuthSessionService(
+		container.Logger(),
+		container.Tracer(),
+		container.AuthSessionRepository(),
+		container.Cache(),
+		sessionTTL,
+	)
+}
+
+// AuthSessionHandlerValidator creates a new instance of validators.AuthSessionHandlerValidator
+func (container *Container) AuthSessionHandlerValidator() (validator *validators.AuthSessionHandlerValidator) {
+	container.logger.Debug(fmt.Sprintf("creating %T", validator))
+	return validators.NewAuthSessionHandlerValidator(
+		container.Logger(),
+		container.Tracer(),
+	)
+}
+
+// AuthSessionHandler creates a new instance of handlers.AuthSessionHandler
+func (container *Container) AuthSessionHandler() (h *handlers.AuthSessionHandler) {
+	container.logger.Debug(fmt.Sprintf("creating %T", h))
+	return handlers.NewAuthSessionHandler(
+		container.Logger(),
+		container.Tracer(),
+		container.AuthSessionService(),
+		container.AuthSessionHandlerValidator(),
+	)
+}
+
+// RegisterAuthSessionRoutes registers routes for the /sessions prefix
+func (container *Container) RegisterAuthSessionRoutes() {
+	container.logger.Debug(fmt.Sprintf("registering %T routes", &handlers.AuthSessionHandler{}))
+	container.AuthSessionHandler().RegisterRoutes(container.App(), container.AuthenticatedMiddleware())
+}
+
+// AuthHandlerValidator creates a new instance of validators.AuthHandlerValidator
+func (container *Container) AuthHandlerValidator() (validator *validators.AuthHandlerValidator) {
+	container.logger.Debug(fmt.Sprintf("creating %T", validator))
+	return validators.NewAuthHandlerValidator(
+		container.Logger(),
+		container.Tracer(),
+	)
+}
+
+// AuthHandler creates a new instance of handlers.AuthHandler
+func (container *Container) AuthHandler() (h *handlers.AuthHandler) {
+	container.logger.Debug(fmt.Sprintf("creating %T", h))
+	return handlers.NewAuthHandler(
+		container.Logger(),
+		container.Tracer(),
+		container.AuthService(),
+		container.AuthHandlerValidator(),
+	)
+}
+
+// RegisterAuthRoutes registers routes for the /auth prefix when users sign up natively with AUTH_PROVIDER=jwt
+func (container *Container) RegisterAuthRoutes() {
+	if os.Getenv("AUTH_PROVIDER") != "jwt" {
+		container.logger.Debug(fmt.Sprintf("skipping %T routes because AUTH_PROVIDER is not [jwt]", &handlers.AuthHandler{}))
+		return
+	}
+
+	container.logger.Debug(fmt.Sprintf("registering %T routes", &handlers.AuthHandler{}))
+	container.AuthHandler().RegisterRoutes(container.App())
+}
+
+// TwoFactorAuthRepository creates a new instance of repositories.TwoFactorAuthRepository
+func (container *Container) TwoFactorAuthRepository() (repository repositories.TwoFactorAuthRepository) {
+	container.logger.Debug("creating GORM repositories.TwoFactorAuthRepository")
+	return repositories.NewGormTwoFactorAuthRepository(
+		container.Logger(),
+		container.Tracer(),
+		container.DB(),
+	)
+}
+
+// TwoFactorService creates a new instance of services.TwoFactorService
+func (container *Container) TwoFactorService() (service *services.TwoFactorService) {
+	container.logger.Debug(fmt.Sprintf("creating %T", service))
+
+	issuer := os.Getenv("APP_NAME")
+	if issuer == "" {
+		issuer = "httpSMS"
+	}
+
+	return services.NewTwoFactorService(
+		container.Logger(),
+		container.Tracer(),
+		container.TwoFactorAuthRepository(),
+		issuer,
+	)
+}
+
+// TwoFactorMiddleware creates a new instance of middlewares.TwoFactor
+func (container *Container) TwoFactorMiddleware() fiber.Handler {
+	container.logger.Debug("creating middlewares.TwoFactor")
+	return middlewares.TwoFactor(container.Logger(), container.Tracer(), container.TwoFactorService())
+}
+
+// TwoFactorHandlerValidator creates a new instance of validators.TwoFactorHandlerValidator
+func (container *Container) TwoFactorHandlerValidator() (validator *validators.TwoFactorHandlerValidator) {
+	container.logger.Debug(fmt.Sprintf("creating %T", validator))
+	return validators.NewTwoFactorHandlerValidator(
+		container.Logger(),
+		container.Tracer(),
+	)
+}
+
+// TwoFactorHandler creates a new instance of handlers.TwoFactorHandler
+func (container *Container) TwoFactorHandler() (h *handlers.TwoFactorHandler) {
+	container.logger.Debug(fmt.Sprintf("creating %T", h))
+	return handlers.NewTwoFactorHandler(
+		container.Logger(),
+		container.Tracer(),
+		container.TwoFactorService(),
+		container.TwoFactorHandlerValidator(),
+	)
+}
+
+// RegisterTwoFactorRoutes registers routes for the /two-factor prefix
+func (container *Container) RegisterTwoFactorRoutes() {
+	container.logger.Debug(fmt.Sprintf("registering %T routes", &handlers.TwoFactorHandler{}))
+	container.TwoFactorHandler().RegisterRoutes(container.App(), container.AuthenticatedMiddleware())
+}
+
+// ConversationService creates a new instance of services.ConversationService
+func (container *Container) ConversationService() (service *services.ConversationService) {
+	container.logger.Debug(fmt.Sprintf("creating %T", service))
+	return services.NewConversationService(
+		container.Logger(),
+		container.Tracer(),
+		container.MessageRepository(),
+		container.EventDispatcher(),
+	)
+}
+
+// ConversationHandlerValidator creates a new instance of validators.ConversationHandlerValidator
+func (container *Container) ConversationHandlerValidator() (validator *validators.ConversationHandlerValidator) {
+	container.logger.Debug(fmt.Sprintf("creating %T", validator))
+	return validators.NewConversationHandlerValidator(
+		container.Logger(),
+		container.Tracer(),
+	)
+}
+
+// ConversationHandler creates a new instance of handlers.ConversationHandler
+func (container *Container) ConversationHandler() (h *handlers.ConversationHandler) {
+	container.logger.Debug(fmt.Sprintf("creating %T", h))
+	return handlers.NewConversationHandler(
+		container.Logger(),
+		container.Tracer(),
+		container.ConversationService(),
+		container.ConversationHandlerValidator(),
+	)
+}
+
+// RegisterConversationRoutes registers routes for the /conversations prefix
+func (container *Container) RegisterConversationRoutes() {
+	container.logger.Debug(fmt.Sprintf("registering %T routes", &handlers.ConversationHandler{}))
+	container.ConversationHandler().RegisterRoutes(container.App(), container.AuthenticatedMiddleware())
+}
+
+// PhoneRegistrationRepository creates a new instance of repositories.PhoneRegistrationRepository
+func (container *Container) PhoneRegistrationRepository() (repository repositories.PhoneRegistrationRepository) {
+	container.logger.Debug("creating GORM repositories.PhoneRegistrationRepository")
+	return repositories.NewGormPhoneRegistrationRepository(
+		container.Logger(),
+		container.Tracer(),
+		container.DB(),
+	)
+}
+
+// PhoneRegistrationService creates a new instance of services.PhoneRegistrationService
+func (container *Container) PhoneRegistrationService() (service *services.PhoneRegistrationService) {
+	container.logger.Debug(fmt.Sprintf("creating %T", service))
+	return services.NewPhoneRegistrationService(
+		container.Logger(),
+		container.Tracer(),
+		container.PhoneRegistrationRepository(),
+		container.PhoneRepository(),
+		container.UserRepository(),
+		container.PhoneService(),
+		container.Mailer(),
+		container.UserEmailFactory(),
+	)
+}
+
+// PhoneRegistrationHandlerValidator creates a new instance of validators.PhoneRegistrationHandlerValidator
+func (container *Container) PhoneRegistrationHandlerValidator() (validator *validators.PhoneRegistrationHandlerValidator) {
+	container.logger.Debug(fmt.Sprintf("creating %T", validator))
+	return validators.NewPhoneRegistrationHandlerValidator(
+		container.Logger(),
+		container.Tracer(),
+	)
+}
+
+// PhoneRegistrationHandler creates a new instance of handlers.PhoneRegistrationHandler
+func (container *Container) PhoneRegistrationHandler() (h *handlers.PhoneRegistrationHandler) {
+	container.logger.Debug(fmt.Sprintf("creating %T", h))
+	return handlers.NewPhoneRegistrationHandler(
+		container.Logger(),
+		container.Tracer(),
+		container.PhoneRegistrationService(),
+		container.PhoneRegistrationHandlerValidator(),
+	)
+}
+
+// RegisterPhoneRegistrationRoutes registers routes for the /phone-registrations prefix
+func (container *Container) RegisterPhoneRegistrationRoutes() {
+	container.logger.Debug(fmt.Sprintf("registering %T routes", &handlers.PhoneRegistrationHandler{}))
+	container.PhoneRegistrationHandler().RegisterRoutes(container.App())
+}
+
+// UserMetricsService creates a new instance of services.UserMetricsService
+func (container *Container) UserMetricsService() (service *services.UserMetricsService) {
+	container.logger.Debug(fmt.Sprintf("creating %T", service))
+	return services.NewUserMetricsService(
+		container.Logger(),
+		container.Tracer(),
+		container.AnalyticsRepository(),
+		container.PhoneRepository(),
+		container.HeartbeatRepository(),
+		container.UserRepository(),
+	)
+}
+
+// UserMetricsHandler creates a new instance of handlers.UserMetricsHandler
+func (container *Container) UserMetricsHandler() (h *handlers.UserMetricsHandler) {
+	container.logger.Debug(fmt.Sprintf("creating %T", h))
+	return handlers.NewUserMetricsHandler(
+		container.Logger(),
+		container.Tracer(),
+		container.UserMetricsService(),
+	)
+}
+
+// RegisterUserMetricsRoutes registers routes for the /metrics prefix
+func (container *Container) RegisterUserMetricsRoutes() {
+	container.logger.Debug(fmt.Sprintf("registering %T routes", &handlers.UserMetricsHandler{}))
+	container.UserMetricsHandler().RegisterRoutes(container.App(), container.AuthenticatedMiddleware())
+}
+
+// ContactGroupRepository creates a new instance of repositories.ContactGroupRepository
+func (container *Container) ContactGroupRepository() (repository repositories.ContactGroupRepository) {
+	container.logger.Debug("creating GORM repositories.ContactGroupRepository")
+	return repositories.NewGormContactGroupRepository(
+		container.Logger(),
+		container.Tracer(),
+		container.DB(),
+	)
+}
+
+// ContactGroupService creates a new instance of services.ContactGroupService
+func (container *Container) ContactGroupService() (service *services.ContactGroupService) {
+	container.logger.Debug(fmt.Sprintf("creating %T", service))
+	return services.NewContactGroupService(
+		container.Logger(),
+		container.Tracer(),
+		container.ContactGroupRepository(),
+		container.ContactRepository(),
+	)
+}
+
+// ContactGroupHandlerValidator creates a new instance of validators.ContactGroupHandlerValidator
+func (container *Container) ContactGroupHandlerValidator() (validator *validators.ContactGroupHandlerValidator) {
+	container.logger.Debug(fmt.Sprintf("creating %T", validator))
+	return validators.NewContactGroupHandlerValidator(
+		container.Logger(),
+		container.Tracer(),
+	)
+}
+
+// ContactGroupHandler creates a new instance of handlers.ContactGroupHandler
+func (container *Container) ContactGroupHandler() (h *handlers.ContactGroupHandler) {
+	container.logger.Debug(fmt.Sprintf("creating %T", h))
+	return handlers.NewContactGroupHandler(
+		container.Logger(),
+		container.Tracer(),
+		container.ContactGroupService(),
+		container.ContactGroupHandlerValidator(),
+	)
+}
+
+// RegisterContactGroupRoutes registers routes for the /contact-groups prefix
+func (container *Container) RegisterContactGroupRoutes() {
+	container.logger.Debug(fmt.Sprintf("registering %T routes", &handlers.ContactGroupHandler{}))
+	container.ContactGroupHandler().RegisterRoutes(container.App(), container.AuthenticatedMiddleware())
+}
+
+// AccessLogRepository creates a new instance of repositories.AccessLogRepository
+func (container *Container) AccessLogRepository() (repository repositories.AccessLogRepository) {
+	container.logger.Debug("creating GORM repositories.AccessLogRepository")
+	return repositories.NewGormAccessLogRepository(
+		container.Logger(),
+		container.Tracer(),
+		container.DB(),
+	)
+}
+
+// AccessLogService creates a new instance of services.AccessLogService, the instance is shared
+// because the access log middleware queues the requests which are stored by the services.AccessLogScheduler
+func (container *Container) AccessLogService() (service *services.AccessLogService) {
+	if container.accessLogService != nil {
+		return container.accessLogService
+	}
+
+	container.logger.Debug(fmt.Sprintf("creating %T", service))
+
+	maxBodyBytes := 2048
+	if value, err := strconv.Atoi(os.Getenv("ACCESS_LOG_MAX_BODY_BYTES")); err == nil && value >= 0 {
+		maxBodyBytes = value
+	}
+
+	retention := 24 * time.Hour
+	if value, err := time.ParseDuration(os.Getenv("ACCESS_LOG_RETENTION")); err == nil && value > 0 {
+		retention = value
+	}
+
+	container.accessLogService = services.NewAccessLogService(
+		container.Logger(),
+		container.Tracer(),
+		container.AccessLogRepository(),
+		maxBodyBytes,
+		retention,
+	)
+	return container.accessLogService
+}
+
+// AccessLogScheduler creates a new instance of services.AccessLogScheduler
+func (container *Container) AccessLogScheduler() (scheduler *services.AccessLogScheduler) {
+	container.logger.Debug(fmt.Sprintf("creating %T", scheduler))
+	return services.NewAccessLogScheduler(
+		container.Logger(),
+		container.Tracer(),
+		container.AccessLogService(),
+		5*time.Second,
+		time.Hour,
+	)
+}
+
+// AccessLogHandlerValidator creates a new instance of validators.AccessLogHandlerValidator
+func (container *Container) AccessLogHandlerValidator() (validator *validators.AccessLogHandlerValidator) {
+	container.logger.Debug(fmt.Sprintf("creating %T", validator))
+	return validators.NewAccessLogHandlerValidator(
+		container.Logger(),
+		container.Tracer(),
+	)
+}
+
+// AccessLogHandler creates a new instance of handlers.AccessLogHandler
+func (container *Container) AccessLogHandler() (h *handlers.AccessLogHandler) {
+	container.logger.Debug(fmt.Sprintf("creating %T", h))
+	return handlers.NewAccessLogHandler(
+		container.Logger(),
+		container.Tracer(),
+		container.AccessLogService(),
+		container.OperatorService(),
+		container.AccessLogHandlerValidator(),
+	)
+}
+
+// RegisterAccessLogRoutes registers routes for the /operator/access-logs prefix
+func (container *Container) RegisterAccessLogRoutes() {
+	container.logger.Debug(fmt.Sprintf("registering %T routes", &handlers.AccessLogHandler{}))
+	container.AccessLogHandler().RegisterRoutes(container.App(), container.AuthenticatedMiddleware())
+}
+
+// ForwardingRuleRepository creates a new instance of repositories.ForwardingRuleRepository
+func (container *Container) ForwardingRuleRepository() (repository repositories.ForwardingRuleRepository) {
+	container.logger.Debug("creating GORM repositories.ForwardingRuleRepository")
+	return repositories.NewGormForwardingRuleRepository(
+		container.Logger(),
+		container.Tracer(),
+		container.DB(),
+	)
+}
+
+// ForwardingRuleService creates a new instance of services.ForwardingRuleService
+func (container *Container) ForwardingRuleService() (service *services.ForwardingRuleService) {
+	container.logger.Debug(fmt.Sprintf("creating %T", service))
+	return services.NewForwardingRuleService(
+		container.Logger(),
+		container.Tracer(),
+		container.ForwardingRuleRepository(),
+		container.UserRepository(),
+		container.Mailer(),
+		container.UserEmailFactory(),
+	)
+}
+
+// ForwardingRuleHandlerValidator creates a new instance of validators.ForwardingRuleHandlerValidator
+func (container *Container) ForwardingRuleHandlerValidator() (validator *validators.ForwardingRuleHandlerValidator) {
+	container.logger.Debug(fmt.Sprintf("creating %T", validator))
+	return validators.NewForwardingRuleHandlerValidator(
+		container.Logger(),
+		container.Tracer(),
+	)
+}
+
+// ForwardingRuleHandler creates a new instance of handlers.ForwardingRuleHandler
+func (container *Container) ForwardingRuleHandler() (h *handlers.ForwardingRuleHandler) {
+	container.logger.Debug(fmt.Sprintf("creating %T", h))
+	return handlers.NewForwardingRuleHandler(
+		container.Logger(),
+		container.Tracer(),
+		container.ForwardingRuleService(),
+		container.ForwardingRuleHandlerValidator(),
+	)
+}
+
+// RegisterForwardingRuleRoutes registers routes for the /forwarding-rules prefix
+func (container *Container) RegisterForwardingRuleRoutes() {
+	container.logger.Debug(fmt.Sprintf("registering %T routes", &handlers.ForwardingRuleHandler{}))
+	container.ForwardingRuleHandler().RegisterRoutes(container.App(), container.AuthenticatedMiddleware())
+}
+
+// RegisterForwardingRuleListeners registers event listeners for listeners.ForwardingRuleListener
+func (container *Container) RegisterForwardingRuleListeners() {
+	container.logger.Debug(fmt.Sprintf("registering listeners for %T", listeners.ForwardingRuleListener{}))
+	_, routes := listeners.NewForwardingRuleListener(
+		container.Logger(),
+		container.Tracer(),
+		container.ForwardingRuleService(),
+		container.EventListenerLogRepository(),
+	)
+
+	for event, handler := range routes {
+		container.EventDispatcher().Subscribe(event, handler)
+	}
+}
+
+// AlertChannelRepository creates a new instance of repositories.AlertChannelRepository
+func (container *Container) AlertChannelRepository() (repository repositories.AlertChannelRepository) {
+	container.logger.Debug("creating GORM repositories.AlertChannelRepository")
+	return repositories.NewGormAlertChannelRepository(
+		container.Logger(),
+		container.Tracer(),
+		container.DB(),
+	)
+}
+
+// AlertChannelService creates a new instance of services.AlertChannelService
+func (container *Container) AlertChannelService() (service *services.AlertChannelService) {
+	container.logger.Debug(fmt.Sprintf("creating %T", service))
+	return services.NewAlertChannelService(
+		container.Logger(),
+		container.Tracer(),
+		container.EgressProxy("alert_channel"),
+		container.AlertChannelRepository(),
+	)
+}
+
+// AlertChannelHandlerValidator creates a new instance of validators.AlertChannelHandlerValidator
+func (container *Container) AlertChannelHandlerValidator() (validator *validators.AlertChannelHandlerValidator) {
+	container.logger.Debug(fmt.Sprintf("creating %T", validator))
+	return validators.NewAlertChannelHandlerValidator(
+		container.Logger(),
+		container.Tracer(),
+	)
+}
+
+// AlertChannelHandler creates a new instance of handlers.AlertChannelHandler
+func (container *Container) AlertChannelHandler() (h *handlers.AlertChannelHandler) {
+	container.logger.Debug(fmt.Sprintf("creating %T", h))
+	return handlers.NewAlertChannelHandler(
+		container.Logger(),
+		container.Tracer(),
+		container.AlertChannelService(),
+		container.AlertChannelHandlerValidator(),
+	)
+}
+
+// RegisterAlertChannelRoutes registers routes for the /alert-channels prefix
+func (container *Container) RegisterAlertChannelRoutes() {
+	container.logger.Debug(fmt.Sprintf("registering %T routes", &handlers.AlertChannelHandler{}))
+	container.AlertChannelHandler().RegisterRoutes(container.App(), container.AuthenticatedMiddleware())
+}
+
+// RegisterAlertChannelListeners registers event listeners for listeners.AlertChannelListener
+func (container *Container) RegisterAlertChannelListeners() {
+	container.logger.Debug(fmt.Sprintf("registering listeners for %T", listeners.AlertChannelListener{}))
+	_, routes := listeners.NewAlertChannelListener(
+		container.Logger(),
+		container.Tracer(),
+		container.AlertChannelService(),
+		container.NotificationPreferenceService(),
+		container.EventListenerLogRepository(),
+	)
+
+	for event, handler := range routes {
+		container.EventDispatcher().Subscribe(event, handler)
+	}
+}
+
+// TwilioStatusCallbackRepository creates a new instance of repositories.TwilioStatusCallbackRepository
+func (container *Container) TwilioStatusCallbackRepository() (repository repositories.TwilioStatusCallbackRepository) {
+	container.logger.Debug("creating GORM repositories.TwilioStatusCallbackRepository")
+	return repositories.NewGormTwilioStatusCallbackRepository(
+		container.Logger(),
+		container.Tracer(),
+		container.DB(),
+	)
+}
+
+// TwilioService creates a new instance of services.TwilioService
+func (container *Container) TwilioService() (service *services.TwilioService) {
+	container.logger.Debug(fmt.Sprintf("creating %T", service))
+	return services.NewTwilioService(
+		container.Logger(),
+		container.Tracer(),
+		container.HTTPClient("twilio"),
+		container.MessageService(),
+		container.TwilioStatusCallbackRepository(),
+		container.UserRepository(),
+	)
+}
+
+// TwilioHandlerValidator creates a new instance of validators.TwilioHandlerValidator
+func (container *Container) TwilioHandlerValidator() (validator *validators.TwilioHandlerValidator) {
+	container.logger.Debug(fmt.Sprintf("creating %T", validator))
+	return validators.NewTwilioHandlerValidator(
+		container.Logger(),
+		container.Tracer(),
+		container.MessageHandlerValidator(),
+	)
+}
+
+// TwilioHandler creates a new instance of handlers.TwilioHandler
+func (container *Container) TwilioHandler() (h *handlers.TwilioHandler) {
+	container.logger.Debug(fmt.Sprintf("creating %T", h))
+	return handlers.NewTwilioHandler(
+		container.Logger(),
+		container.Tracer(),
+		container.TwilioHandlerValidator(),
+		container.BillingService(),
+		container.RateLimitService(),
+		container.TwilioService(),
+	)
+}
+
+// RegisterTwilioRoutes registers routes for the Twilio compatible /2010-04-01 prefix
+func (container *Container) RegisterTwilioRoutes() {
+	container.logger.Debug(fmt.Sprintf("registering %T routes", &handlers.TwilioHandler{}))
+	container.TwilioHandler().RegisterRoutes(container.App(), container.AuthenticatedMiddleware())
+}
+
+// RegisterTwilioListeners registers event listeners for listeners.TwilioListener
+func (container *Container) RegisterTwilioListeners() {
+	container.logger.Debug(fmt.Sprintf("registering listeners for %T", listeners.TwilioListener{}))
+	_, routes := listeners.NewTwilioListener(
+		container.Logger(),
+		container.Tracer(),
+		container.TwilioService(),
+		container.EventListenerLogRepository(),
+	)
+
+	for event, handler := range routes {
+		container.EventDispatcher().Subscribe(event, handler)
+	}
+}
+
+// TemplateRepository creates a new instance of repositories.TemplateRepository
+func (container *Container) TemplateRepository() (repository repositories.TemplateRepository) {
+	container.logger.Debug("creating GORM repositories.TemplateRepository")
+	return repositories.NewGormTemplateRepository(
+		container.Logger(),
+		container.Tracer(),
+		container.DB(),
+	)
+}
+
+// TemplateService creates a new instance of services.TemplateService
+func (container *Container) TemplateService() (service *services.TemplateService) {
+	container.logger.Debug(fmt.Sprintf("creating %T", service))
+	return services.NewTemplateService(
+		container.Logger(),
+		container.Tracer(),
+		container.TemplateRepository(),
+		container.UserRepository(),
+	)
+}
+
+// TemplateHandlerValidator creates a new instance of validators.TemplateHandlerValidator
+func (container *Container) TemplateHandlerValidator() (validator *validators.TemplateHandlerValidator) {
+	container.logger.Debug(fmt.Sprintf("creating %T", validator))
+	return validators.NewTemplateHandlerValidator(
+		container.Logger(),
+		container.Tracer(),
+	)
+}
+
+// TemplateHandler creates a new instance of handlers.TemplateHandler
+func (container *Container) TemplateHandler() (h *handlers.TemplateHandler) {
+	container.logger.Debug(fmt.Sprintf("creating %T", h))
+	return handlers.NewTemplateHandler(
+		container.Logger(),
+		container.Tracer(),
+		container.TemplateService(),
+		container.OperatorService(),
+		container.TemplateStatService(),
+		container.TemplateHandlerValidator(),
+	)
+}
+
+// RegisterTemplateRoutes registers routes for the /templates prefix
+func (container *Container) RegisterTemplateRoutes() {
+	container.logger.Debug(fmt.Sprintf("registering %T routes", &handlers.TemplateHandler{}))
+	container.TemplateHandler().RegisterRoutes(container.App(), container.AuthenticatedMiddleware())
+}
+
+// TemplateStatRepository creates a new instance of repositories.TemplateStatRepository
+func (container *Container) TemplateStatRepository() (repository repositories.TemplateStatRepository) {
+	container.logger.Debug("creating GORM repositories.TemplateStatRepository")
+	return repositories.NewGormTemplateStatRepository(
+		container.Logger(),
+		container.Tracer(),
+		container.DB(),
+	)
+}
+
+// TemplateStatService creates a new instance of services.TemplateStatService
+func (container *Container) TemplateStatService() (service *services.TemplateStatService) {
+	container.logger.Debug(fmt.Sprintf("creating %T", service))
+	return services.NewTemplateStatService(
+		container.Logger(),
+		container.Tracer(),
+		container.TemplateStatRepository(),
+		container.TemplateRepository(),
+		container.MessageRepository(),
+	)
+}
+
+// RegisterTemplateStatListeners registers event listeners for listeners.TemplateStatListener
+func (container *Container) RegisterTemplateStatListeners() {
+	container.logger.Debug(fmt.Sprintf("registering listeners for %T", listeners.TemplateStatListener{}))
+	_, routes := listeners.NewTemplateStatListener(
+		container.Logger(),
+		container.Tracer(),
+		container.TemplateStatService(),
+		container.EventListenerLogRepository(),
+	)
+
+	for event, handler := range routes {
+		container.EventDispatcher().Subscribe(event, handler)
+	}
+}
+
+// PhoneReputationRepository creates a new instance of repositories.PhoneReputationRepository
+func (container *Container) PhoneReputationRepository() (repository repositories.PhoneReputationRepository) {
+	container.logger.Debug("creating GORM repositories.PhoneReputationRepository")
+	return repositories.NewGormPhoneReputationRepository(
+		container.Logger(),
+		container.Tracer(),
+		container.DB(),
+	)
+}
+
+// PhoneReputationService creates a new instance of services.PhoneReputationService
+func (container *Container) PhoneReputationService() (service *services.PhoneReputationService) {
+	container.logger.Debug(fmt.Sprintf("creating %T", service))
+	return services.NewPhoneReputationService(
+		container.Logger(),
+		container.Tracer(),
+		container.PhoneReputationRepository(),
+		container.EventDispatcher(),
+	)
+}
+
+// PhoneReputationHandler creates a new instance of handlers.PhoneReputationHandler
+func (container *Container) PhoneReputationHandler() (h *handlers.PhoneReputationHandler) {
+	container.logger.Debug(fmt.Sprintf("creating %T", h))
+	return handlers.NewPhoneReputationHandler(
+		container.Logger(),
+		container.Tracer(),
+		container.PhoneReputationService(),
+	)
+}
+
+// RegisterPhoneReputationRoutes registers routes for the /phone-reputations prefix
+func (container *Container) RegisterPhoneReputationRoutes() {
+	container.logger.Debug(fmt.Sprintf("registering %T routes", &handlers.PhoneReputationHandler{}))
+	container.PhoneReputationHandler().RegisterRoutes(container.App(), container.AuthenticatedMiddleware())
+}
+
+// RegisterPhoneReputationListeners registers event listeners for listeners.PhoneReputationListener
+func (container *Container) RegisterPhoneReputationListeners() {
+	container.logger.Debug(fmt.Sprintf("registering listeners for %T", listeners.PhoneReputationListener{}))
+	_, routes := listeners.NewPhoneReputationListener(
+		container.Logger(),
+		container.Tracer(),
+		container.PhoneReputationService(),
+		container.EventListenerLogRepository(),
+	)
+
+	for event, handler := range routes {
+		container.EventDispatcher().Subscribe(event, handler)
+	}
+}
+
+// EventHub creates a cached instance of services.EventHub
+func (container *Container) EventHub() (hub *services.EventHub) {
+	if container.eventHub != nil {
+		return container.eventHub
+	}
+
+	container.logger.Debug(fmt.Sprintf("creating %T", hub))
+	container.eventHub = services.NewEventHub(container.Logger(), container.Tracer())
+	return container.eventHub
+}
+
+// RegisterEventHubListeners registers the listener which pushes events to the clients connected to this instance
+func (container *Container) RegisterEventHubListeners() {
+	container.logger.Debug(fmt.Sprintf("registering listeners for %T", listeners.EventHubListener{}))
+	listener := listeners.NewEventHubListener(
+		container.Logger(),
+		container.Tracer(),
+		container.EventHub(),
+	)
+
+	container.EventDispatcher().SubscribeAll(listener.OnEvent)
+}
+
+// MessageServer creates a new instance of rpc.MessageServer
+func (container *Container) MessageServer() (server *rpc.MessageServer) {
+	container.logger.Debug(fmt.Sprintf("creating %T", server))
+	return rpc.NewMessageServer(
+		container.Logger(),
+		container.Tracer(),
+		container.MessageHandlerValidator(),
+		container.BillingService(),
+		container.RateLimitService(),
+		container.MessageService(),
+		container.EventHub(),
+	)
+}
+
+// WebhookServer creates a new instance of rpc.WebhookServer
+func (container *Container) WebhookServer() (server *rpc.WebhookServer) {
+	container.logger.Debug(fmt.Sprintf("creating %T", server))
+	return rpc.NewWebhookServer(
+		container.Logger(),
+		container.Tracer(),
+		container.WebhookHandlerValidator(),
+		container.WebhookService(),
+	)
+}
+
+// PhoneServer creates a new instance of rpc.PhoneServer
+func (container *Container) PhoneServer() (server *rpc.PhoneServer) {
+	container.logger.Debug(fmt.Sprintf("creating %T", server))
+	return rpc.NewPhoneServer(
+		container.Logger(),
+		container.Tracer(),
+		container.PhoneHandlerValidator(),
+		container.PhoneService(),
+	)
+}
+
+// GRPCServer creates a new grpc.Server which serves the gRPC API
+func (container *Container) GRPCServer() (server *grpc.Server) {
+	container.logger.Debug(fmt.Sprintf("creating %T", server))
+	return rpc.NewServer(
+		container.Logger(),
+		container.Tracer(),
+		container.UserRepository(),
+		container.MessageServer(),
+		container.WebhookServer(),
+		container.PhoneServer(),
+	)
+}
+
+// GraphQLResolver creates a new instance of graph.Resolver
+func (container *Container) GraphQLResolver() (resolver *graph.Resolver) {
+	container.logger.Debug(fmt.Sprintf("creating %T", resolver))
+	return graph.NewResolver(
+		container.Logger(),
+		container.Tracer(),
+		container.MessageRepository(),
+		container.ContactRepository(),
+		container.PhoneRepository(),
+	)
+}
+
+// GraphQLSchema creates a new instance of graphql.Schema
+func (container *Container) GraphQLSchema() (schema *graphql.Schema) {
+	container.logger.Debug(fmt.Sprintf("creating %T", schema))
+	schema, err := graph.NewSchema(container.GraphQLResolver())
+	if err != nil {
+		container.logger.Fatal(stacktrace.Propagate(err, "cannot create graphql schema"))
+	}
+	return schema
+}
+
+// GraphQLHandlerValidator creates a new instance of validators.GraphQLHandlerValidator
+func (container *Container) GraphQLHandlerValidator() (validator *validators.GraphQLHandlerValidator) {
+	container.logger.Debug(fmt.Sprintf("creating %T", validator))
+	return validators.NewGraphQLHandlerValidator(
+		container.Logger(),
+		container.Tracer(),
+	)
+}
+
+// GraphQLHandler creates a new instance of handlers.GraphQLHandler
+func (container *Container) GraphQLHandler() (h *handlers.GraphQLHandler) {
+	container.logger.Debug(fmt.Sprintf("creating %T", h))
+	return handlers.NewGraphQLHandler(
+		container.Logger(),
+		container.Tracer(),
+		container.GraphQLSchema(),
+		container.GraphQLHandlerValidator(),
+	)
+}
+
+// RegisterGraphQLRoutes registers routes for the /graphql endpoint
+func (container *Container) RegisterGraphQLRoutes() {
+	container.logger.Debug(fmt.Sprintf("registering %T routes", &handlers.GraphQLHandler{}))
+	container.GraphQLHandler().RegisterRoutes(container.App(), container.AuthenticatedMiddleware())
+}
+
+// InitializeTraceProvider initializes the open telemetry trace provider
+func (container *Container) InitializeTraceProvider() func() {
+	if isLocal() {
+		return container.initializeUptraceProvider(container.version, container.projectID)
+	}
+	return container.initializeGoogleTraceProvider(container.version, container.projectID)
+}
+
+func (container *Container) initializeGoogleTraceProvider(version string, namespace string) func() {
+	container.logger.Debug("initializing google trace meterProvider")
+
+	traceExporter, err := cloudtrace.New(cloudtrace.WithProjectID(os.Getenv("GCP_PROJECT_ID")))
+	if err != nil {
+		container.logger.Fatal(stacktrace.Propagate(err, "cannot create cloud trace traceExporter"))
+	}
+
+	tp := trace.NewTracerProvider(
+		trace.WithBatcher(traceExporter),
+		trace.WithSampler(trace.AlwaysSample()),
+		trace.WithResource(container.OtelResources(version, namespace)),
+	)
+	otel.SetTracerProvider(tp)
+
+	metricExporter, err := mexporter.New(mexporter.WithProjectID(os.Getenv("GCP_PROJECT_ID")))
+	if err != nil {
+		container.logger.Fatal(stacktrace.Propagate(err, "cannot create cloud metric traceExporter"))
+	}
+
+	meterProvider := metric.NewMeterProvider(
+		metric.WithReader(metric.NewPeriodicReader(metricExporter)),
+		metric.WithResource(container.OtelResources(version, namespace)),
+	)
+	global.SetMeterProvider(meterProvider)
+
+	return func() {
+		if err = metricExporter.Shutdown(context.Background()); err != nil {
+			container.logger.Error(stacktrace.Propagate(err, "cannot shutdown cloud metric metric exporter"))
+		}
+		if err = traceExporter.Shutdown(context.Background()); err != nil {
+			container.logger.Error(stacktrace.Propagate(err, "cannot shutdown cloud trace trace exporter"))
+		}
+	}
+}
+
+func (container *Container) initializeUptraceProvider(version string, namespace string) (flush func()) {
+	container.logger.Debug("initializing uptrace provider")
+	// Configure OpenTelemetry with sensible defaults.
+	uptrace.ConfigureOpentelemetry(
+		uptrace.WithDSN(os.Getenv("UPTRACE_DSN")),
+		uptrace.WithServiceName(namespace),
+		uptrace.WithServiceVersion(version),
+		uptrace.WithDeploymentEnvironment(os.Getenv("ENV")),
+	)
+
+	// Send buffered spans and free resources.
+	return func() {
+		err := uptrace.Shutdown(context.Background())
+		if err != nil {
+			container.logger.Error(err)
+		}
+	}
+}
+
+// errorReporter receives the errors which are logged, it is configured by initializeErrorReporter when the Container is created
+var errorReporter = telemetry.NewNoopErrorReporter()
+
+// initializeErrorReporter configures the Sentry compatible error tracking service of the deployment
+func initializeErrorReporter(version string) {
+	dsn := os.Getenv("ERROR_REPORTING_DSN")
+	if dsn == "" {
+		return
+	}
+
+	sampleRate := 1.0
+	if value := os.Getenv("ERROR_REPORTING_SAMPLE_RATE"); value != "" {
+		rate, err := strconv.ParseFloat(value, 64)
+		if err != nil || rate < 0 || rate > 1 {
+			logger(3).Fatal(stacktrace.NewError(fmt.Sprintf("ERROR_REPORTING_SAMPLE_RATE [%s] must be a number between 0 and 1", value)))
+		}
+		sampleRate = rate
+	}
+
+	// the sentry client treats a sample rate of 0 as 1 so errors are not reported at all
+	if sampleRate == 0 {
+		logger(3).Info("error reporting is disabled because ERROR_REPORTING_SAMPLE_RATE is 0")
+		return
+	}
+
+	environment := os.Getenv("ERROR_REPORTING_ENVIRONMENT")
+	if environment == "" {
+		environment = os.Getenv("ENV")
+	}
+
+	reporter, err := telemetry.NewSentryErrorReporter(telemetry.ErrorReporterConfig{
+		DSN:         dsn,
+		Environment: environment,
+		Release:     version,
+		SampleRate:  sampleRate,
+		ScrubPII:    os.Getenv("ERROR_REPORTING_SCRUB_PII") != "false",
+	})
+	if err != nil {
+		logger(3).Fatal(stacktrace.Propagate(err, "cannot create the error reporter"))
+	}
+
+	errorReporter = reporter
+	logger(3).Info(fmt.Sprintf("reporting [%.2f] of errors for environment [%s]", sampleRate, environment))
+}
+
+func logger(skipFrameCount int) telemetry.Logger {
+	fields := map[string]string{
+		"pid":      strconv.Itoa(os.Getpid()),
+		"hostname": hostName(),
+	}
+
+	// the error reporting logger adds a frame between the caller and zerolog
+	return telemetry.NewErrorReportingLogger(
+		telemetry.NewZerologLogger(
+			os.Getenv("GCP_PROJECT_ID"),
+			fields,
+			logDriver(skipFrameCount+1),
+			nil,
+		),
+		errorReporter,
+	)
+}
+
+func logDriver(skipFrameCount int) *zerodriver.Logger {
+	if isLocal() {
 		return consoleLogger(skipFrameCount)
 	}
 	return jsonLogger(skipFrameCount)
@@ -1237,6 +4920,34 @@ func consoleLogger(skipFrameCount int) *zerodriver.Logger {
 	}
 }
 
+// migrateMessageSequences creates the per thread counters of entities.Message.Sequence. Existing messages are numbered
+// and the counters are seeded once, before the unique index on the sequence of a thread is created.
+func migrateMessageSequences(db *gorm.DB) error {
+	if err := db.AutoMigrate(&entities.MessageSequence{}); err != nil {
+		return stacktrace.Propagate(err, fmt.Sprintf("cannot migrate %T", &entities.MessageSequence{}))
+	}
+
+	if db.Migrator().HasIndex(&entities.Message{}, messageSequenceIndex) {
+		return nil
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := repositories.WithoutTenantScope(tx).Exec(messageSequenceBackfill).Error; err != nil {
+			return stacktrace.Propagate(err, "cannot backfill the sequence of messages")
+		}
+
+		if err := repositories.WithoutTenantScope(tx).Exec(messageSequenceSeed).Error; err != nil {
+			return stacktrace.Propagate(err, "cannot seed the sequences of message threads")
+		}
+
+		query := fmt.Sprintf("CREATE UNIQUE INDEX IF NOT EXISTS %s ON messages (user_id, owner, contact, sequence)", messageSequenceIndex)
+		if err := tx.Exec(query).Error; err != nil {
+			return stacktrace.Propagate(err, fmt.Sprintf("cannot create index [%s]", messageSequenceIndex))
+		}
+		return nil
+	})
+}
+
 func isLocal() bool {
 	return os.Getenv("ENV") == "local"
 }
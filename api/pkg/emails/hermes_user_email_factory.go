@@ -2,6 +2,7 @@ package emails
 
 import (
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/NdoleStudio/httpsms/pkg/entities"
@@ -104,6 +105,59 @@ func (factory *hermesUserEmailFactory) UsageLimitAlert(user *entities.User, usag
 	}, nil
 }
 
+// Invoice is the email containing the monthly invoice of a user
+func (factory *hermesUserEmailFactory) Invoice(user *entities.User, invoice *entities.Invoice, attachments []Attachment) (*Email, error) {
+	period := invoice.PeriodStart.Format("January 2006")
+	email := hermes.Email{
+		Body: hermes.Body{
+			Intros: []string{
+				fmt.Sprintf("Your httpSMS invoice for %s is ready and it is attached to this email.", period),
+			},
+			Dictionary: []hermes.Entry{
+				{Key: "Invoice", Value: invoice.Number},
+				{Key: "Plan", Value: string(invoice.SubscriptionName)},
+				{Key: "Sent Messages", Value: fmt.Sprintf("%d", invoice.SentMessages)},
+				{Key: "Received Messages", Value: fmt.Sprintf("%d", invoice.ReceivedMessages)},
+				{Key: "Credits Used", Value: fmt.Sprintf("%d", invoice.CreditsUsed)},
+			},
+			Actions: []hermes.Action{
+				{
+					Instructions: "You can also download all your invoices on the billing page",
+					Button: hermes.Button{
+						Color:     "#329ef4",
+						TextColor: "#FFFFFF",
+						Text:      "VIEW BILLING",
+						Link:      "https://httpsms.com/billing",
+					},
+				},
+			},
+			Title:     "Hey,",
+			Signature: "Cheers",
+			Outros: []string{
+				fmt.Sprintf("Don't hesitate to contact us by replying to this email."),
+			},
+		},
+	}
+
+	html, err := factory.generator.GenerateHTML(email)
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "cannot generate html email")
+	}
+
+	text, err := factory.generator.GeneratePlainText(email)
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "cannot generate text email")
+	}
+
+	return &Email{
+		ToEmail:     user.Email,
+		Subject:     fmt.Sprintf("Your httpSMS invoice for %s", period),
+		HTML:        html,
+		Text:        text,
+		Attachments: attachments,
+	}, nil
+}
+
 // NewHermesUserEmailFactory creates a new instance of the UserEmailFactory
 func NewHermesUserEmailFactory(config *HermesGeneratorConfig) UserEmailFactory {
 	return &hermesUserEmailFactory{
@@ -161,3 +215,390 @@ func (factory *hermesUserEmailFactory) PhoneDead(user *entities.User, lastHeartb
 		Text:    text,
 	}, nil
 }
+
+// CanaryFailed is the email sent to a user when a canary message failed
+func (factory *hermesUserEmailFactory) CanaryFailed(user *entities.User, owner string, contact string, reason string) (*Email, error) {
+	email := hermes.Email{
+		Body: hermes.Body{
+			Intros: []string{
+				fmt.Sprintf("The canary message sent from %s to %s failed because the %s.", owner, contact, strings.TrimPrefix(reason, "canary ")),
+				fmt.Sprintf("Check if both phones are powered on, have network coverage and a stable internet connection."),
+			},
+			Actions: []hermes.Action{
+				{
+					Instructions: "Check your messages on httpSMS",
+					Button: hermes.Button{
+						Color:     "#329ef4",
+						TextColor: "#FFFFFF",
+						Text:      "MESSAGES",
+						Link:      fmt.Sprintf("https://httpsms.com/threads/%s", owner),
+					},
+				},
+			},
+			Title:     "Hey,",
+			Signature: "Cheers",
+			Outros: []string{
+				fmt.Sprintf("Don't hesitate to contact us by replying to this email."),
+			},
+		},
+	}
+
+	html, err := factory.generator.GenerateHTML(email)
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "cannot generate html email")
+	}
+
+	text, err := factory.generator.GeneratePlainText(email)
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "cannot generate text email")
+	}
+
+	return &Email{
+		ToEmail: user.Email,
+		Subject: fmt.Sprintf("⚠ Canary message from [%s] to [%s] failed", owner, contact),
+		HTML:    html,
+		Text:    text,
+	}, nil
+}
+
+// WebhookDeliveryFailed is the email sent to a user when a webhook delivery is moved to the dead-letter queue
+func (factory *hermesUserEmailFactory) WebhookDeliveryFailed(user *entities.User, url string, eventType string, statusCode int, redeliverURL string) (*Email, error) {
+	status := "no response was received"
+	if statusCode != 0 {
+		status = fmt.Sprintf("the last response had the status code %d", statusCode)
+	}
+
+	email := hermes.Email{
+		Body: hermes.Body{
+			Intros: []string{
+				fmt.Sprintf("The %s event could not be sent to your webhook %s after all the attempts because %s.", eventType, url, status),
+				"Check that your server is online and responds with a 2xx status code, then redeliver the event.",
+			},
+			Actions: []hermes.Action{
+				{
+					Instructions: "Redeliver the event on httpSMS",
+					Button: hermes.Button{
+						Color:     "#329ef4",
+						TextColor: "#FFFFFF",
+						Text:      "REDELIVER",
+						Link:      redeliverURL,
+					},
+				},
+			},
+			Title:     "Hey,",
+			Signature: "Cheers",
+			Outros: []string{
+				"Don't hesitate to contact us by replying to this email.",
+			},
+		},
+	}
+
+	html, err := factory.generator.GenerateHTML(email)
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "cannot generate html email")
+	}
+
+	text, err := factory.generator.GeneratePlainText(email)
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "cannot generate text email")
+	}
+
+	return &Email{
+		ToEmail: user.Email,
+		Subject: fmt.Sprintf("⚠ Webhook delivery of [%s] event failed", eventType),
+		HTML:    html,
+		Text:    text,
+	}, nil
+}
+
+// MessageForwarded is the email containing a message which matched a forwarding rule of a user
+func (factory *hermesUserEmailFactory) MessageForwarded(user *entities.User, toEmail string, owner string, contact string, sim entities.SIM, timestamp time.Time, content string) (*Email, error) {
+	location, err := time.LoadLocation(user.Timezone)
+	if err != nil {
+		location = time.UTC
+	}
+
+	email := hermes.Email{
+		Body: hermes.Body{
+			Intros: []string{
+				fmt.Sprintf("A new message was received on %s.", owner),
+			},
+			Dictionary: []hermes.Entry{
+				{Key: "From", Value: contact},
+				{Key: "To", Value: owner},
+				{Key: "SIM", Value: string(sim)},
+				{Key: "Received", Value: timestamp.In(location).Format(time.RFC1123)},
+				{Key: "Message", Value: content},
+			},
+			Actions: []hermes.Action{
+				{
+					Instructions: "Reply to the message on httpSMS",
+					Button: hermes.Button{
+						Color:     "#329ef4",
+						TextColor: "#FFFFFF",
+						Text:      "REPLY",
+						Link:      fmt.Sprintf("https://httpsms.com/threads/%s", owner),
+					},
+				},
+			},
+			Title:     "Hey,",
+			Signature: "Cheers",
+		},
+	}
+
+	html, err := factory.generator.GenerateHTML(email)
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "cannot generate html email")
+	}
+
+	text, err := factory.generator.GeneratePlainText(email)
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "cannot generate text email")
+	}
+
+	return &Email{
+		ToEmail: toEmail,
+		Subject: fmt.Sprintf("✉ New message from [%s] to [%s]", contact, owner),
+		HTML:    html,
+		Text:    text,
+	}, nil
+}
+
+// NotificationDigest is the email containing the alerts collected during the digest window of a user
+func (factory *hermesUserEmailFactory) NotificationDigest(user *entities.User, items []*entities.NotificationDigestItem) (*Email, error) {
+	location, err := time.LoadLocation(user.Timezone)
+	if err != nil {
+		location = time.UTC
+	}
+
+	rows := make([][]hermes.Entry, 0, len(items))
+	for _, item := range items {
+		rows = append(rows, []hermes.Entry{
+			{Key: "Time", Value: item.OccurredAt.In(location).Format(time.RFC1123)},
+			{Key: "Alert", Value: item.Summary},
+		})
+	}
+
+	email := hermes.Email{
+		Body: hermes.Body{
+			Intros: []string{
+				fmt.Sprintf("We collected %d alerts for your account in the last %d minutes.", len(items), user.NotificationDigestMinutes),
+			},
+			Table: hermes.Table{
+				Data: rows,
+			},
+			Actions: []hermes.Action{
+				{
+					Instructions: "You can change how often you receive these alerts in your settings",
+					Button: hermes.Button{
+						Color:     "#329ef4",
+						TextColor: "#FFFFFF",
+						Text:      "SETTINGS",
+						Link:      "https://httpsms.com/settings",
+					},
+				},
+			},
+			Title:     "Hey,",
+			Signature: "Cheers",
+			Outros: []string{
+				fmt.Sprintf("Don't hesitate to contact us by replying to this email."),
+			},
+		},
+	}
+
+	html, err := factory.generator.GenerateHTML(email)
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "cannot generate html email")
+	}
+
+	text, err := factory.generator.GeneratePlainText(email)
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "cannot generate text email")
+	}
+
+	return &Email{
+		ToEmail: user.Email,
+		Subject: fmt.Sprintf("⚠ %d httpSMS alerts", len(items)),
+		HTML:    html,
+		Text:    text,
+	}, nil
+}
+
+// Broadcast is the email containing an announcement from the operators of the instance
+func (factory *hermesUserEmailFactory) Broadcast(user *entities.User, title string, body string, severity entities.BroadcastSeverity) (*Email, error) {
+	email := hermes.Email{
+		Body: hermes.Body{
+			Intros:    strings.Split(strings.TrimSpace(body), "\n\n"),
+			Title:     "Hey,",
+			Signature: "Cheers",
+			Outros: []string{
+				fmt.Sprintf("Don't hesitate to contact us by replying to this email."),
+			},
+		},
+	}
+
+	html, err := factory.generator.GenerateHTML(email)
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "cannot generate html email")
+	}
+
+	text, err := factory.generator.GeneratePlainText(email)
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "cannot generate text email")
+	}
+
+	subject := title
+	if severity != entities.BroadcastSeverityInfo {
+		subject = "⚠ " + title
+	}
+
+	return &Email{
+		ToEmail: user.Email,
+		Subject: subject,
+		HTML:    html,
+		Text:    text,
+	}, nil
+}
+
+// EmailVerification is the email sent after a user signs up with an email and password
+func (factory *hermesUserEmailFactory) EmailVerification(email string, token string) (*Email, error) {
+	mail := hermes.Email{
+		Body: hermes.Body{
+			Intros: []string{
+				fmt.Sprintf("Thanks for signing up to %s, please confirm your email address before you log in.", factory.config.AppName),
+			},
+			Actions: []hermes.Action{
+				{
+					Instructions: "Click the button below to verify your email address",
+					Button: hermes.Button{
+						Color:     "#329ef4",
+						TextColor: "#FFFFFF",
+						Text:      "Verify Email",
+						Link:      fmt.Sprintf("%s/verify-email?token=%s", strings.TrimRight(factory.config.AppURL, "/"), token),
+					},
+				},
+			},
+			Title:     "Hey,",
+			Signature: "Cheers",
+			Outros: []string{
+				"If you did not create an account, you can safely ignore this email.",
+			},
+		},
+	}
+
+	html, err := factory.generator.GenerateHTML(mail)
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "cannot generate html email")
+	}
+
+	text, err := factory.generator.GeneratePlainText(mail)
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "cannot generate text email")
+	}
+
+	return &Email{
+		ToEmail: email,
+		Subject: fmt.Sprintf("Verify your %s email address", factory.config.AppName),
+		HTML:    html,
+		Text:    text,
+	}, nil
+}
+
+// PasswordReset is the email sent when a user forgets the password
+func (factory *hermesUserEmailFactory) PasswordReset(email string, token string, expiresAt time.Time) (*Email, error) {
+	mail := hermes.Email{
+		Body: hermes.Body{
+			Intros: []string{
+				fmt.Sprintf("We received a request to reset the password of your %s account.", factory.config.AppName),
+			},
+			Actions: []hermes.Action{
+				{
+					Instructions: fmt.Sprintf("Click the button below to choose a new password, the link expires on %s.", expiresAt.Format(time.RFC1123)),
+					Button: hermes.Button{
+						Color:     "#329ef4",
+						TextColor: "#FFFFFF",
+						Text:      "Reset Password",
+						Link:      fmt.Sprintf("%s/reset-password?token=%s", strings.TrimRight(factory.config.AppURL, "/"), token),
+					},
+				},
+			},
+			Title:     "Hey,",
+			Signature: "Cheers",
+			Outros: []string{
+				"If you did not request a password reset, you can safely ignore this email and your password will not change.",
+			},
+		},
+	}
+
+	html, err := factory.generator.GenerateHTML(mail)
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "cannot generate html email")
+	}
+
+	text, err := factory.generator.GeneratePlainText(mail)
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "cannot generate text email")
+	}
+
+	return &Email{
+		ToEmail: email,
+		Subject: fmt.Sprintf("Reset your %s password", factory.config.AppName),
+		HTML:    html,
+		Text:    text,
+	}, nil
+}
+
+// PhoneRegistered is the email sent when a new phone or FCM token is registered on the account of a user
+func (factory *hermesUserEmailFactory) PhoneRegistered(user *entities.User, registration *entities.PhoneRegistration, timestamp time.Time, token string) (*Email, error) {
+	location, err := time.LoadLocation(user.Timezone)
+	if err != nil {
+		location = time.UTC
+	}
+
+	intro := fmt.Sprintf("A new android device was registered for the phone %s on your %s account on %s.", registration.Owner, factory.config.AppName, timestamp.In(location).Format(time.RFC1123))
+	if registration.IsNewPhone {
+		intro = fmt.Sprintf("The phone %s was added to your %s account on %s.", registration.Owner, factory.config.AppName, timestamp.In(location).Format(time.RFC1123))
+	}
+
+	mail := hermes.Email{
+		Body: hermes.Body{
+			Intros: []string{
+				intro,
+				"If this was you, you can safely ignore this email.",
+			},
+			Actions: []hermes.Action{
+				{
+					Instructions: fmt.Sprintf("If you did not register this device, click the button below to remove it, the link expires on %s.", registration.ExpiresAt.In(location).Format(time.RFC1123)),
+					Button: hermes.Button{
+						Color:     "#f44336",
+						TextColor: "#FFFFFF",
+						Text:      "Revoke Device",
+						Link:      fmt.Sprintf("%s/revoke-device?token=%s", strings.TrimRight(factory.config.AppURL, "/"), token),
+					},
+				},
+			},
+			Title:     "Hey,",
+			Signature: "Cheers",
+			Outros: []string{
+				"A device can only be registered with your API key, rotate your API key after revoking a device you don't recognize.",
+			},
+		},
+	}
+
+	html, err := factory.generator.GenerateHTML(mail)
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "cannot generate html email")
+	}
+
+	text, err := factory.generator.GeneratePlainText(mail)
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "cannot generate text email")
+	}
+
+	return &Email{
+		ToEmail: user.Email,
+		Subject: fmt.Sprintf("⚠ New device registered for phone [%s]", registration.Owner),
+		HTML:    html,
+		Text:    text,
+	}, nil
+}
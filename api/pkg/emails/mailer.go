@@ -13,6 +13,15 @@ type Email struct {
 	Subject string
 	HTML    string
 	Text    string
+
+	Attachments []Attachment
+}
+
+// Attachment is a file which is sent with an Email
+type Attachment struct {
+	Filename    string
+	ContentType string
+	Content     []byte
 }
 
 func (mail *Email) toAddress() string {
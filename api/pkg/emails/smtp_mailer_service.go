@@ -1,6 +1,7 @@
 package emails
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"net/smtp"
@@ -49,6 +50,12 @@ func (mailer *smtpMailer) Send(ctx context.Context, email *Email) (err error) {
 	e.Text = []byte(email.Text)
 	e.HTML = []byte(email.HTML)
 
+	for _, attachment := range email.Attachments {
+		if _, err = e.Attach(bytes.NewReader(attachment.Content), attachment.Filename, attachment.ContentType); err != nil {
+			return stacktrace.Propagate(err, fmt.Sprintf("cannot attach [%s] to email", attachment.Filename))
+		}
+	}
+
 	err = e.Send(mailer.address, mailer.auth)
 	if err != nil {
 		return stacktrace.Propagate(err, "cannot send email")
@@ -11,9 +11,36 @@ type UserEmailFactory interface {
 	// PhoneDead sends an emails when the user's phone is not sending heartbeats
 	PhoneDead(user *entities.User, lastHeartbeatTimestamp time.Time, owner string) (*Email, error)
 
+	// CanaryFailed sends an email when a canary message did not complete the sent → delivered → received loop
+	CanaryFailed(user *entities.User, owner string, contact string, reason string) (*Email, error)
+
+	// WebhookDeliveryFailed sends an email when an event could not be sent to a webhook after all the attempts
+	WebhookDeliveryFailed(user *entities.User, url string, eventType string, statusCode int, redeliverURL string) (*Email, error)
+
+	// MessageForwarded sends a received message to an email address of a forwarding rule of the user
+	MessageForwarded(user *entities.User, toEmail string, owner string, contact string, sim entities.SIM, timestamp time.Time, content string) (*Email, error)
+
+	// NotificationDigest sends a single email containing the alerts collected during the digest window of the user
+	NotificationDigest(user *entities.User, items []*entities.NotificationDigestItem) (*Email, error)
+
+	// Broadcast sends an announcement from the operators of the instance
+	Broadcast(user *entities.User, title string, body string, severity entities.BroadcastSeverity) (*Email, error)
+
+	// Invoice sends the monthly invoice of a user, the rendered documents are attached to the email
+	Invoice(user *entities.User, invoice *entities.Invoice, attachments []Attachment) (*Email, error)
+
 	// UsageLimitExceeded sends an email when the user's limit is exceeded
 	UsageLimitExceeded(user *entities.User) (*Email, error)
 
 	// UsageLimitAlert sends an email when a user is approaching the limit
 	UsageLimitAlert(user *entities.User, usage *entities.BillingUsage) (*Email, error)
+
+	// EmailVerification sends the link which confirms the email address of a user who signed up with a password
+	EmailVerification(email string, token string) (*Email, error)
+
+	// PasswordReset sends the link which lets a user choose a new password
+	PasswordReset(email string, token string, expiresAt time.Time) (*Email, error)
+
+	// PhoneRegistered sends the link which revokes a device that was registered on the account of a user
+	PhoneRegistered(user *entities.User, registration *entities.PhoneRegistration, timestamp time.Time, token string) (*Email, error)
 }
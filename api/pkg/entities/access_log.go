@@ -0,0 +1,29 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AccessLog is an HTTP request to the API which is kept for a short time to debug the integrations of users
+type AccessLog struct {
+	ID uuid.UUID `json:"id" gorm:"primaryKey;type:uuid;" example:"32343a19-da5e-4b1b-a767-3298a73703cb"`
+	// UserID is empty when the request is not authenticated
+	UserID UserID `json:"user_id" gorm:"index" example:"WB7DRDWrJZRGbYrv2CKGkqbzvqdC"`
+	// APIKey is masked so that only the first and the last characters of the key are stored
+	APIKey    string `json:"api_key" example:"Jk3x...Qqbt"`
+	Method    string `json:"method" example:"POST"`
+	Path      string `json:"path" example:"/v1/messages/send"`
+	Query     string `json:"query" example:"limit=20"`
+	Status    int    `json:"status" example:"422"`
+	LatencyMS int64  `json:"latency_ms" example:"42"`
+	IP        string `json:"ip" example:"203.0.113.10"`
+	UserAgent string `json:"user_agent" example:"curl/8.4.0"`
+	TraceID   string `json:"trace_id" example:"4bf92f3577b34da6a3ce929d0e0e4736"`
+
+	// RequestBody is truncated to the maximum size which is configured for the instance
+	RequestBody        string    `json:"request_body" example:"{\"from\":\"+18005550199\",\"to\":\"+18005550100\"}"`
+	RequestBodyTrimmed bool      `json:"request_body_trimmed" example:"false"`
+	CreatedAt          time.Time `json:"created_at" gorm:"index" example:"2022-06-05T14:26:02.302718+03:00"`
+}
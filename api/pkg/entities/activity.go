@@ -0,0 +1,45 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ActivityCategory groups the items in the activity feed of a user
+type ActivityCategory string
+
+const (
+	// ActivityCategoryMessage is for messages which are sent, delivered, failed or received
+	ActivityCategoryMessage = ActivityCategory("message")
+
+	// ActivityCategoryPhone is for changes to the settings or the connectivity of a phone
+	ActivityCategoryPhone = ActivityCategory("phone")
+
+	// ActivityCategoryWebhook is for events which could not be sent to a webhook
+	ActivityCategoryWebhook = ActivityCategory("webhook")
+
+	// ActivityCategoryAccount is for changes to the subscription or the status of the account
+	ActivityCategoryAccount = ActivityCategory("account")
+)
+
+// ActivityCategories are all the supported ActivityCategory
+var ActivityCategories = []ActivityCategory{
+	ActivityCategoryMessage,
+	ActivityCategoryPhone,
+	ActivityCategoryWebhook,
+	ActivityCategoryAccount,
+}
+
+// ActivityItem is an entry in the activity feed which is derived from an event of the user
+type ActivityItem struct {
+	// ID is the ID of the event
+	ID       string           `json:"id" example:"32343a19-da5e-4b1b-a767-3298a73703cb"`
+	Category ActivityCategory `json:"category" example:"message"`
+	Type     string           `json:"type" example:"message.phone.delivered"`
+	Summary  string           `json:"summary" example:"Message from +18005550199 was delivered to +18005550100"`
+
+	// EntityID is the ID of the message, phone or webhook which the item is about
+	EntityID  *uuid.UUID `json:"entity_id" example:"32343a19-da5e-4b1b-a767-3298a73703cc"`
+	Timestamp time.Time  `json:"timestamp" example:"2022-06-05T14:26:02.302718+03:00"`
+}
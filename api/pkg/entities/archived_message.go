@@ -0,0 +1,39 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+)
+
+// ArchivedMessage is a Message which was moved out of the messages table after the archive retention window.
+// The columns used to filter archived messages are copied from the message and the full message is stored as JSON.
+type ArchivedMessage struct {
+	ID             uuid.UUID     `json:"id" gorm:"primaryKey;type:uuid;" example:"32343a19-da5e-4b1b-a767-3298a73703cb"`
+	UserID         UserID        `json:"user_id" gorm:"index:idx_archived_messages__user_id__order_timestamp,priority:1" example:"WB7DRDWrJZRGbYrv2CKGkqbzvqdC"`
+	Owner          string        `json:"owner" example:"+18005550199"`
+	Contact        string        `json:"contact" example:"+18005550100"`
+	Type           MessageType   `json:"type" example:"mobile-terminated"`
+	Status         MessageStatus `json:"status" example:"delivered"`
+	OrderTimestamp time.Time     `json:"order_timestamp" gorm:"index:idx_archived_messages__user_id__order_timestamp,priority:2" example:"2022-06-05T14:26:09.527976+03:00"`
+
+	// Message is the entities.Message as it was when it was archived
+	Message    datatypes.JSONType[Message] `json:"message" swaggertype:"object"`
+	ArchivedAt time.Time                   `json:"archived_at" example:"2022-09-05T14:26:09.527976+03:00"`
+}
+
+// NewArchivedMessage creates an ArchivedMessage from a Message
+func NewArchivedMessage(message *Message, archivedAt time.Time) *ArchivedMessage {
+	return &ArchivedMessage{
+		ID:             message.ID,
+		UserID:         message.UserID,
+		Owner:          message.Owner,
+		Contact:        message.Contact,
+		Type:           message.Type,
+		Status:         message.Status,
+		OrderTimestamp: message.OrderTimestamp,
+		Message:        datatypes.JSONType[Message]{Data: *message},
+		ArchivedAt:     archivedAt,
+	}
+}
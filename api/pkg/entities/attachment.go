@@ -0,0 +1,31 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Attachment is an image or vCard which is sent or received with a Message as an MMS
+type Attachment struct {
+	ID          uuid.UUID            `json:"id" gorm:"primaryKey;type:uuid;" example:"32343a19-da5e-4b1b-a767-3298a73703cb"`
+	UserID      UserID               `json:"user_id" gorm:"index:idx_attachments__user_id__message_id" example:"WB7DRDWrJZRGbYrv2CKGkqbzvqdC"`
+	MessageID   uuid.UUID            `json:"message_id" gorm:"type:uuid;index:idx_attachments__user_id__message_id" example:"32343a19-da5e-4b1b-a767-3298a73703cc"`
+	Name        string               `json:"name" example:"photo.jpg"`
+	ContentType string               `json:"content_type" example:"image/jpeg"`
+	Size        int                  `json:"size" example:"48213"`
+	ScanStatus  AttachmentScanStatus `json:"scan_status" example:"clean"`
+
+	// StorageKey is the location of the content in the object storage, it is empty when the attachment is quarantined
+	StorageKey string `json:"-"`
+
+	// URL is a signed download URL which expires, it is only set for clean attachments
+	URL       string    `json:"url" gorm:"-" example:"https://storage.example.com/WB7DRDWrJZRGbYrv2CKGkqbzvqdC/32343a19?signature=abc"`
+	CreatedAt time.Time `json:"created_at" example:"2022-06-05T14:26:02.302718+03:00"`
+	UpdatedAt time.Time `json:"updated_at" example:"2022-06-05T14:26:10.303278+03:00"`
+}
+
+// IsDownloadable checks if the content of the attachment can be shared
+func (attachment *Attachment) IsDownloadable() bool {
+	return attachment.ScanStatus == AttachmentScanStatusClean && attachment.StorageKey != ""
+}
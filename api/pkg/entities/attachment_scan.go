@@ -0,0 +1,15 @@
+package entities
+
+// AttachmentScanStatus is the result of scanning an attachment for malware
+type AttachmentScanStatus string
+
+const (
+	// AttachmentScanStatusPending means the attachment has not been scanned and cannot be downloaded
+	AttachmentScanStatusPending = AttachmentScanStatus("pending")
+
+	// AttachmentScanStatusClean means no malware was found and the attachment can be downloaded
+	AttachmentScanStatusClean = AttachmentScanStatus("clean")
+
+	// AttachmentScanStatusQuarantined means malware was found and the attachment cannot be downloaded
+	AttachmentScanStatusQuarantined = AttachmentScanStatus("quarantined")
+)
@@ -0,0 +1,35 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AuthSession is a sign-in of a user with a bearer token, it can be revoked to log out the device which holds the token
+type AuthSession struct {
+	ID     uuid.UUID `json:"id" gorm:"primaryKey;type:uuid;" example:"32343a19-da5e-4b1b-a767-3298a73703cb"`
+	UserID UserID    `json:"user_id" gorm:"index" example:"WB7DRDWrJZRGbYrv2CKGkqbzvqdC"`
+
+	// Key is the entities.AuthUser SessionID of the tokens of the session e.g. the "jti" claim of a token issued at login
+	Key string `json:"-" gorm:"column:session_key;uniqueIndex"`
+
+	// Renewable sessions are tracked from the tokens of an external identity provider, they expire when they are not used
+	Renewable bool `json:"-"`
+
+	IPAddress  string     `json:"ip_address" example:"127.0.0.1"`
+	UserAgent  string     `json:"user_agent" example:"Mozilla/5.0 (Windows NT 10.0; Win64; x64)"`
+	LastSeenAt time.Time  `json:"last_seen_at" example:"2022-06-05T15:26:02.302718+03:00"`
+	ExpiresAt  time.Time  `json:"expires_at" example:"2022-07-05T14:26:02.302718+03:00"`
+	RevokedAt  *time.Time `json:"revoked_at" example:"2022-06-06T14:26:02.302718+03:00"`
+	CreatedAt  time.Time  `json:"created_at" example:"2022-06-05T14:26:02.302718+03:00"`
+	UpdatedAt  time.Time  `json:"updated_at" example:"2022-06-05T14:26:10.303278+03:00"`
+
+	// Current is set when the session is the one which made the request
+	Current bool `json:"current" gorm:"-" example:"true"`
+}
+
+// IsActive checks if the session is neither revoked nor expired
+func (session *AuthSession) IsActive(now time.Time) bool {
+	return session.RevokedAt == nil && now.Before(session.ExpiresAt)
+}
@@ -4,6 +4,9 @@ package entities
 type AuthUser struct {
 	ID    UserID `json:"id"`
 	Email string `json:"email"`
+
+	// SessionID identifies the sign-in of a bearer token, it is empty when the user is authenticated with an API key
+	SessionID string `json:"-"`
 }
 
 // IsNoop checks if a user is empty
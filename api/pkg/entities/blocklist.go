@@ -0,0 +1,44 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// BlocklistReason is the reason why a phone number is on the Blocklist
+type BlocklistReason string
+
+const (
+	// BlocklistReasonOptOut is used when the owner of the phone number opted out of receiving messages
+	BlocklistReasonOptOut = BlocklistReason("opt-out")
+
+	// BlocklistReasonBlocked is used when the user blocked the phone number
+	BlocklistReasonBlocked = BlocklistReason("blocked")
+)
+
+// IsValid checks if the BlocklistReason is supported
+func (reason BlocklistReason) IsValid() bool {
+	return reason == BlocklistReasonOptOut || reason == BlocklistReasonBlocked
+}
+
+// BlocklistSource is how a phone number was added to the Blocklist
+type BlocklistSource string
+
+const (
+	// BlocklistSourceImport is used when the phone number was imported from a CSV file
+	BlocklistSourceImport = BlocklistSource("import")
+
+	// BlocklistSourceKeyword is used when the contact replied with an opt-out keyword e.g. STOP
+	BlocklistSourceKeyword = BlocklistSource("keyword")
+)
+
+// Blocklist is a phone number which must not receive messages from a user
+type Blocklist struct {
+	ID          uuid.UUID       `json:"id" gorm:"primaryKey;type:uuid;" example:"32343a19-da5e-4b1b-a767-3298a73703cb"`
+	UserID      UserID          `json:"user_id" gorm:"uniqueIndex:idx_blocklists__user_id__phone_number" example:"WB7DRDWrJZRGbYrv2CKGkqbzvqdC"`
+	PhoneNumber string          `json:"phone_number" gorm:"uniqueIndex:idx_blocklists__user_id__phone_number" example:"+18005550100"`
+	Reason      BlocklistReason `json:"reason" example:"opt-out"`
+	Source      BlocklistSource `json:"source" example:"import"`
+	CreatedAt   time.Time       `json:"created_at" example:"2022-06-05T14:26:02.302718+03:00"`
+}
@@ -0,0 +1,86 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// BroadcastSeverity is the importance of an entities.Broadcast
+type BroadcastSeverity string
+
+const (
+	// BroadcastSeverityInfo is used for general announcements
+	BroadcastSeverityInfo = BroadcastSeverity("info")
+
+	// BroadcastSeverityWarning is used for scheduled maintenance and upcoming breaking changes
+	BroadcastSeverityWarning = BroadcastSeverity("warning")
+
+	// BroadcastSeverityCritical is used for outages
+	BroadcastSeverityCritical = BroadcastSeverity("critical")
+)
+
+// BroadcastChannel is where an entities.Broadcast is delivered
+type BroadcastChannel string
+
+const (
+	// BroadcastChannelBanner shows the broadcast as a banner in the web app
+	BroadcastChannelBanner = BroadcastChannel("banner")
+
+	// BroadcastChannelEmail sends the broadcast to the email address of the user
+	BroadcastChannelEmail = BroadcastChannel("email")
+
+	// BroadcastChannelWebhook sends the broadcast to the webhooks of the user
+	BroadcastChannelWebhook = BroadcastChannel("webhook")
+)
+
+// BroadcastChannels are all the supported BroadcastChannel values
+var BroadcastChannels = []BroadcastChannel{BroadcastChannelBanner, BroadcastChannelEmail, BroadcastChannelWebhook}
+
+// Broadcast is an announcement sent by an operator of the instance to all users or to a list of users
+type Broadcast struct {
+	ID        uuid.UUID         `json:"id" gorm:"primaryKey;type:uuid;" example:"32343a19-da5e-4b1b-a767-3298a73703cb"`
+	Title     string            `json:"title" example:"Scheduled maintenance"`
+	Body      string            `json:"body" example:"The API will be unavailable on Saturday from 02:00 to 03:00 UTC."`
+	Severity  BroadcastSeverity `json:"severity" example:"warning"`
+	Channels  pq.StringArray    `json:"channels" gorm:"type:text[]" swaggertype:"array,string" example:"banner,email"`
+	UserIDs   pq.StringArray    `json:"user_ids" gorm:"type:text[]" swaggertype:"array,string" example:"WB7DRDWrJZRGbYrv2CKGkqbzvqdC"`
+	CreatedBy UserID            `json:"created_by" example:"WB7DRDWrJZRGbYrv2CKGkqbzvqdC"`
+	StartsAt  time.Time         `json:"starts_at" example:"2022-06-05T14:26:02.302718+03:00"`
+	EndsAt    *time.Time        `json:"ends_at" example:"2022-06-06T14:26:02.302718+03:00"`
+	CreatedAt time.Time         `json:"created_at" example:"2022-06-05T14:26:02.302718+03:00"`
+	UpdatedAt time.Time         `json:"updated_at" example:"2022-06-05T14:26:10.303278+03:00"`
+}
+
+// HasChannel checks if the broadcast is delivered on a BroadcastChannel
+func (broadcast *Broadcast) HasChannel(channel BroadcastChannel) bool {
+	for _, item := range broadcast.Channels {
+		if item == string(channel) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsTargeted checks if a user is in the audience of the broadcast, an empty list of users targets everyone
+func (broadcast *Broadcast) IsTargeted(userID UserID) bool {
+	if len(broadcast.UserIDs) == 0 {
+		return true
+	}
+
+	for _, item := range broadcast.UserIDs {
+		if item == string(userID) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsActive checks if the broadcast should be displayed at a timestamp
+func (broadcast *Broadcast) IsActive(timestamp time.Time) bool {
+	if timestamp.Before(broadcast.StartsAt) {
+		return false
+	}
+	return broadcast.EndsAt == nil || timestamp.Before(*broadcast.EndsAt)
+}
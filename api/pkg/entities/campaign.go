@@ -0,0 +1,67 @@
+package entities
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Campaign sends a templated message to the contacts of a Segment or of a ContactGroup on a recurring schedule.
+// The schedule is a cron expression when Cron is set, otherwise the campaign runs every IntervalMinutes.
+type Campaign struct {
+	ID        uuid.UUID  `json:"id" gorm:"primaryKey;type:uuid;" example:"32343a19-da5e-4b1b-a767-3298a73703cb"`
+	UserID    UserID     `json:"user_id" gorm:"index" example:"WB7DRDWrJZRGbYrv2CKGkqbzvqdC"`
+	Name      string     `json:"name" example:"Weekly reminder"`
+	Owner     string     `json:"owner" example:"+18005550199"`
+	SIM       SIM        `json:"sim" example:"SIM1"`
+	SegmentID *uuid.UUID `json:"segment_id" gorm:"type:uuid;" example:"32343a19-da5e-4b1b-a767-3298a73703cc"`
+	GroupID   *uuid.UUID `json:"group_id" gorm:"type:uuid;" example:"32343a19-da5e-4b1b-a767-3298a73703ce"`
+
+	// TemplateID is the approved Template whose content is sent, the Content is copied from the template on every run
+	TemplateID *uuid.UUID `json:"template_id" gorm:"type:uuid;index" example:"32343a19-da5e-4b1b-a767-3298a73703cf"`
+
+	// Content is rendered for each contact, {phone_number} is replaced with the phone number of the contact
+	// and {attribute.name} is replaced with the value of the "name" attribute of the contact.
+	Content string `json:"content" example:"Hi {attribute.name}, your appointment is tomorrow"`
+
+	// Cron is a 5 field cron expression which is evaluated in the Timezone of the campaign
+	Cron            string `json:"cron" example:"0 9 * * 1"`
+	IntervalMinutes uint   `json:"interval_minutes" example:"0"`
+	Timezone        string `json:"timezone" example:"Europe/Helsinki"`
+
+	IsActive    bool       `json:"is_active" example:"true"`
+	NextRunAt   *time.Time `json:"next_run_at" gorm:"index" example:"2022-06-05T14:26:02.302718+03:00"`
+	LastRunAt   *time.Time `json:"last_run_at" example:"2022-06-05T14:26:02.302718+03:00"`
+	LastBatchID *uuid.UUID `json:"last_batch_id" gorm:"type:uuid;" example:"32343a19-da5e-4b1b-a767-3298a73703cd"`
+	RunCount    uint       `json:"run_count" example:"3"`
+	CreatedAt   time.Time  `json:"created_at" example:"2022-06-05T14:26:02.302718+03:00"`
+	UpdatedAt   time.Time  `json:"updated_at" example:"2022-06-05T14:26:10.303278+03:00"`
+}
+
+// Location returns the time.Location of the Campaign, time.UTC is used when the timezone is invalid
+func (campaign *Campaign) Location() *time.Location {
+	location, err := time.LoadLocation(campaign.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+	return location
+}
+
+// Audience describes the segment or the contact group whose contacts receive the messages of the Campaign
+func (campaign *Campaign) Audience() string {
+	if campaign.GroupID != nil {
+		return fmt.Sprintf("contact group [%s]", campaign.GroupID)
+	}
+	return fmt.Sprintf("segment [%s]", campaign.SegmentID)
+}
+
+// Render the content of the Campaign for a Contact
+func (campaign *Campaign) Render(contact *Contact) string {
+	replacements := []string{"{phone_number}", contact.PhoneNumber}
+	for key, value := range contact.Attributes {
+		replacements = append(replacements, "{attribute."+key+"}", fmt.Sprint(value))
+	}
+	return strings.NewReplacer(replacements...).Replace(campaign.Content)
+}
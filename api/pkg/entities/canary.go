@@ -0,0 +1,93 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// CanaryRunStatus is the status of an entities.CanaryRun
+type CanaryRunStatus string
+
+const (
+	// CanaryRunStatusPending means the canary message is waiting to be sent by the owner phone
+	CanaryRunStatusPending = CanaryRunStatus("pending")
+
+	// CanaryRunStatusSent means the canary message was sent by the owner phone
+	CanaryRunStatusSent = CanaryRunStatus("sent")
+
+	// CanaryRunStatusDelivered means the carrier delivered the canary message to the contact
+	CanaryRunStatusDelivered = CanaryRunStatus("delivered")
+
+	// CanaryRunStatusPassed means the canary message was received by the contact phone
+	CanaryRunStatusPassed = CanaryRunStatus("passed")
+
+	// CanaryRunStatusFailed means the canary message did not complete the loop before the timeout
+	CanaryRunStatusFailed = CanaryRunStatus("failed")
+)
+
+// Canary periodically sends a test message from the owner phone to the contact phone to monitor the full sent → delivered → received loop.
+// The owner and contact are the same number for a loopback canary.
+type Canary struct {
+	ID                   uuid.UUID        `json:"id" gorm:"primaryKey;type:uuid;" example:"32343a19-da5e-4b1b-a767-3298a73703cb"`
+	UserID               UserID           `json:"user_id" gorm:"index:idx_canaries__user_id" example:"WB7DRDWrJZRGbYrv2CKGkqbzvqdC"`
+	Owner                string           `json:"owner" example:"+18005550199"`
+	Contact              string           `json:"contact" example:"+18005550100"`
+	IntervalSeconds      uint             `json:"interval_seconds" example:"3600"`
+	TimeoutSeconds       uint             `json:"timeout_seconds" example:"300"`
+	LastStatus           *CanaryRunStatus `json:"last_status" example:"passed"`
+	LastRoundTripSeconds *float64         `json:"last_round_trip_seconds" example:"12.5"`
+	LastRunAt            *time.Time       `json:"last_run_at" example:"2022-06-05T14:26:09.527976+03:00"`
+	CreatedAt            time.Time        `json:"created_at" example:"2022-06-05T14:26:02.302718+03:00"`
+	UpdatedAt            time.Time        `json:"updated_at" example:"2022-06-05T14:26:10.303278+03:00"`
+}
+
+// Interval is the duration between 2 runs of the canary
+func (canary *Canary) Interval() time.Duration {
+	return time.Duration(canary.IntervalSeconds) * time.Second
+}
+
+// Timeout is the duration after which a run of the canary fails when it has not passed
+func (canary *Canary) Timeout() time.Duration {
+	return time.Duration(canary.TimeoutSeconds) * time.Second
+}
+
+// CanaryRun is a single test message sent by an entities.Canary
+type CanaryRun struct {
+	ID               uuid.UUID       `json:"id" gorm:"primaryKey;type:uuid;" example:"32343a19-da5e-4b1b-a767-3298a73703cb"`
+	CanaryID         uuid.UUID       `json:"canary_id" gorm:"index:idx_canary_runs__canary_id" example:"32343a19-da5e-4b1b-a767-3298a73703cb"`
+	UserID           UserID          `json:"user_id" example:"WB7DRDWrJZRGbYrv2CKGkqbzvqdC"`
+	MessageID        *uuid.UUID      `json:"message_id" gorm:"index:idx_canary_runs__message_id" example:"32343a19-da5e-4b1b-a767-3298a73703cb"`
+	Token            string          `json:"token" gorm:"uniqueIndex" example:"6f1c2a9b"`
+	Status           CanaryRunStatus `json:"status" example:"passed"`
+	FailureReason    *string         `json:"failure_reason" example:"message was not received before the timeout"`
+	SentAt           *time.Time      `json:"sent_at" example:"2022-06-05T14:26:09.527976+03:00"`
+	DeliveredAt      *time.Time      `json:"delivered_at" example:"2022-06-05T14:26:09.527976+03:00"`
+	ReceivedAt       *time.Time      `json:"received_at" example:"2022-06-05T14:26:09.527976+03:00"`
+	RoundTripSeconds *float64        `json:"round_trip_seconds" example:"12.5"`
+	CreatedAt        time.Time       `json:"created_at" example:"2022-06-05T14:26:02.302718+03:00"`
+	UpdatedAt        time.Time       `json:"updated_at" example:"2022-06-05T14:26:10.303278+03:00"`
+}
+
+// IsFinal checks if the run can no longer change status
+func (run *CanaryRun) IsFinal() bool {
+	return run.Status == CanaryRunStatusPassed || run.Status == CanaryRunStatusFailed
+}
+
+// Pass registers the canary message as received by the contact phone
+func (run *CanaryRun) Pass(timestamp time.Time) *CanaryRun {
+	roundTrip := timestamp.Sub(run.CreatedAt).Seconds()
+	run.Status = CanaryRunStatusPassed
+	run.ReceivedAt = &timestamp
+	run.RoundTripSeconds = &roundTrip
+	run.UpdatedAt = time.Now().UTC()
+	return run
+}
+
+// Fail registers the run as failed
+func (run *CanaryRun) Fail(reason string) *CanaryRun {
+	run.Status = CanaryRunStatusFailed
+	run.FailureReason = &reason
+	run.UpdatedAt = time.Now().UTC()
+	return run
+}
@@ -0,0 +1,113 @@
+package entities
+
+import (
+	"sort"
+)
+
+const (
+	// CarrierFilteringPrefixLength is the default number of characters of the contact phone number which identify a carrier e.g. +1800
+	CarrierFilteringPrefixLength = 5
+
+	// carrierFilteringMinSent is the number of messages which must be sent to a prefix before it can be suspected
+	carrierFilteringMinSent = 20
+
+	// carrierFilteringMinUnknownRate is the fraction of messages without a delivery report from which a prefix is suspected
+	carrierFilteringMinUnknownRate = 0.5
+
+	// carrierFilteringMinDeliveryRate is the delivery rate of the other prefixes which shows that the phone receives delivery reports
+	carrierFilteringMinDeliveryRate = 0.8
+)
+
+// PrefixDeliveryCount counts the messages sent by an owner to the contacts with the same phone number prefix
+type PrefixDeliveryCount struct {
+	Owner           string `json:"owner" example:"+18005550199"`
+	Prefix          string `json:"prefix" example:"+1800"`
+	Sent            uint   `json:"sent" example:"120"`
+	Delivered       uint   `json:"delivered" example:"20"`
+	DeliveryUnknown uint   `json:"delivery_unknown" example:"100"`
+}
+
+// CarrierFilteringStat is the delivery of the messages sent by an owner to a phone number prefix.
+// A prefix is suspected of silent carrier filtering when most of its messages never get a delivery report while the messages sent by the owner to other prefixes are delivered.
+type CarrierFilteringStat struct {
+	PrefixDeliveryCount
+
+	// DeliveryUnknownRate is the fraction of the sent messages which did not get a delivery report
+	DeliveryUnknownRate float64 `json:"delivery_unknown_rate" example:"0.83"`
+
+	// OwnerDeliveryRate is the fraction of the messages sent by the owner to other prefixes which were delivered
+	OwnerDeliveryRate float64 `json:"owner_delivery_rate" example:"0.97"`
+
+	Suspected bool `json:"suspected" example:"true"`
+
+	// SuggestedOwner is another phone number of the user which delivers messages to the prefix, send messages to the prefix with it instead
+	SuggestedOwner *string `json:"suggested_owner" example:"+18005550100"`
+}
+
+// DetectCarrierFiltering computes the CarrierFilteringStat of the prefixes with enough sent messages, the suspected prefixes are first
+func DetectCarrierFiltering(counts []*PrefixDeliveryCount) []*CarrierFilteringStat {
+	owners := map[string]*PrefixDeliveryCount{}
+	for _, count := range counts {
+		if _, ok := owners[count.Owner]; !ok {
+			owners[count.Owner] = &PrefixDeliveryCount{Owner: count.Owner}
+		}
+		owners[count.Owner].Sent += count.Sent
+		owners[count.Owner].Delivered += count.Delivered
+	}
+
+	stats := make([]*CarrierFilteringStat, 0)
+	for _, count := range counts {
+		if count.Sent < carrierFilteringMinSent {
+			continue
+		}
+
+		otherSent := owners[count.Owner].Sent - count.Sent
+		stat := &CarrierFilteringStat{
+			PrefixDeliveryCount: *count,
+			DeliveryUnknownRate: carrierFilteringRate(count.DeliveryUnknown, count.Sent),
+			OwnerDeliveryRate:   carrierFilteringRate(owners[count.Owner].Delivered-count.Delivered, otherSent),
+		}
+
+		stat.Suspected = stat.DeliveryUnknownRate >= carrierFilteringMinUnknownRate &&
+			otherSent >= carrierFilteringMinSent &&
+			stat.OwnerDeliveryRate >= carrierFilteringMinDeliveryRate
+
+		if stat.Suspected {
+			stat.SuggestedOwner = carrierFilteringSuggestion(counts, count)
+		}
+		stats = append(stats, stat)
+	}
+
+	sort.SliceStable(stats, func(i, j int) bool {
+		if stats[i].Suspected != stats[j].Suspected {
+			return stats[i].Suspected
+		}
+		return stats[i].DeliveryUnknownRate > stats[j].DeliveryUnknownRate
+	})
+
+	return stats
+}
+
+// carrierFilteringSuggestion finds the other owner with the highest delivery rate to the prefix of a suspected count
+func carrierFilteringSuggestion(counts []*PrefixDeliveryCount, suspected *PrefixDeliveryCount) *string {
+	var suggestion *string
+	bestRate := carrierFilteringMinDeliveryRate
+	for _, count := range counts {
+		if count.Prefix != suspected.Prefix || count.Owner == suspected.Owner || count.Sent < carrierFilteringMinSent {
+			continue
+		}
+
+		if rate := carrierFilteringRate(count.Delivered, count.Sent); rate >= bestRate {
+			owner := count.Owner
+			suggestion, bestRate = &owner, rate
+		}
+	}
+	return suggestion
+}
+
+func carrierFilteringRate(count uint, total uint) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(count) / float64(total)
+}
@@ -0,0 +1,107 @@
+package entities
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"gorm.io/datatypes"
+)
+
+// contactOptOutKeywords are the messages which opt a contact out of receiving messages
+var contactOptOutKeywords = []string{"STOP", "STOPALL", "UNSUBSCRIBE", "UNSUB", "CANCEL", "END", "QUIT"}
+
+// contactOptInKeywords are the messages which opt a contact back in after opting out
+var contactOptInKeywords = []string{"START", "UNSTOP", "YES"}
+
+// IsOptOutKeyword determines if the content of a received message opts the contact out of receiving messages
+func IsOptOutKeyword(content string) bool {
+	keyword := strings.ToUpper(strings.TrimSpace(content))
+	for _, value := range contactOptOutKeywords {
+		if value == keyword {
+			return true
+		}
+	}
+	return false
+}
+
+// Contact stores the engagement metrics of a phone number which exchanged messages with a user
+type Contact struct {
+	ID          uuid.UUID `json:"id" gorm:"primaryKey;type:uuid;" example:"32343a19-da5e-4b1b-a767-3298a73703cb"`
+	UserID      UserID    `json:"user_id" gorm:"uniqueIndex:idx_contacts__user_id__phone_number" example:"WB7DRDWrJZRGbYrv2CKGkqbzvqdC"`
+	PhoneNumber string    `json:"phone_number" gorm:"uniqueIndex:idx_contacts__user_id__phone_number" example:"+18005550100"`
+	Name        string    `json:"name" example:"John Doe"`
+
+	// Tags and Attributes are set by the user to build entities.Segment of contacts
+	Tags       pq.StringArray    `json:"tags" gorm:"type:text[]" example:"[customer]" swaggertype:"array,string"`
+	Attributes datatypes.JSONMap `json:"attributes" swaggertype:"object,string" example:"city:Berlin"`
+
+	MessagesSent     uint       `json:"messages_sent" example:"12"`
+	MessagesReceived uint       `json:"messages_received" example:"4"`
+	Replies          uint       `json:"replies" example:"3"`
+	ReplyRate        float64    `json:"reply_rate" example:"0.25"`
+	LastSentAt       *time.Time `json:"last_sent_at" example:"2022-06-05T14:26:09.527976+03:00"`
+	LastReplyAt      *time.Time `json:"last_reply_at" example:"2022-06-05T14:26:09.527976+03:00"`
+	IsOptedOut       bool       `json:"is_opted_out" example:"false"`
+	OptedOutAt       *time.Time `json:"opted_out_at" example:"2022-06-05T14:26:09.527976+03:00"`
+	CreatedAt        time.Time  `json:"created_at" example:"2022-06-05T14:26:02.302718+03:00"`
+	UpdatedAt        time.Time  `json:"updated_at" example:"2022-06-05T14:26:10.303278+03:00"`
+}
+
+// RecordSent updates the metrics of the Contact when a message is sent to it
+func (contact *Contact) RecordSent(timestamp time.Time) *Contact {
+	contact.MessagesSent++
+	if contact.LastSentAt == nil || contact.LastSentAt.Before(timestamp) {
+		contact.LastSentAt = &timestamp
+	}
+	contact.ReplyRate = contact.replyRate()
+	contact.UpdatedAt = time.Now().UTC()
+	return contact
+}
+
+// RecordReceived updates the metrics of the Contact when a message is received from it.
+// A message is counted as a reply when it is the first message received after a message was sent to the contact.
+func (contact *Contact) RecordReceived(timestamp time.Time, content string) *Contact {
+	contact.MessagesReceived++
+	if contact.LastSentAt != nil && contact.LastSentAt.Before(timestamp) && (contact.LastReplyAt == nil || contact.LastReplyAt.Before(*contact.LastSentAt)) {
+		contact.Replies++
+	}
+
+	if contact.LastReplyAt == nil || contact.LastReplyAt.Before(timestamp) {
+		contact.LastReplyAt = &timestamp
+	}
+
+	keyword := strings.ToUpper(strings.TrimSpace(content))
+	if contact.hasKeyword(contactOptOutKeywords, keyword) && !contact.IsOptedOut {
+		contact.IsOptedOut = true
+		contact.OptedOutAt = &timestamp
+	}
+	if contact.hasKeyword(contactOptInKeywords, keyword) && contact.IsOptedOut {
+		contact.IsOptedOut = false
+		contact.OptedOutAt = nil
+	}
+
+	contact.ReplyRate = contact.replyRate()
+	contact.UpdatedAt = time.Now().UTC()
+	return contact
+}
+
+func (contact *Contact) replyRate() float64 {
+	if contact.MessagesSent == 0 {
+		return 0
+	}
+	if contact.Replies >= contact.MessagesSent {
+		return 1
+	}
+	return float64(contact.Replies) / float64(contact.MessagesSent)
+}
+
+func (contact *Contact) hasKeyword(keywords []string, keyword string) bool {
+	for _, value := range keywords {
+		if value == keyword {
+			return true
+		}
+	}
+	return false
+}
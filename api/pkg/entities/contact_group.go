@@ -0,0 +1,29 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ContactGroup is a named list of Contact which is used to address bulk messages and campaigns.
+// Unlike a Segment, the members of a group are added explicitly and do not depend on filters.
+type ContactGroup struct {
+	ID          uuid.UUID `json:"id" gorm:"primaryKey;type:uuid;" example:"32343a19-da5e-4b1b-a767-3298a73703cb"`
+	UserID      UserID    `json:"user_id" gorm:"index" example:"WB7DRDWrJZRGbYrv2CKGkqbzvqdC"`
+	Name        string    `json:"name" example:"Volunteers"`
+	Description string    `json:"description" example:"People who help at the weekend events"`
+
+	// ContactCount is the number of Contact in the group when it was loaded
+	ContactCount int64     `json:"contact_count" gorm:"->;-:migration" example:"42"`
+	CreatedAt    time.Time `json:"created_at" example:"2022-06-05T14:26:02.302718+03:00"`
+	UpdatedAt    time.Time `json:"updated_at" example:"2022-06-05T14:26:10.303278+03:00"`
+}
+
+// ContactGroupMember adds a Contact to a ContactGroup
+type ContactGroupMember struct {
+	ContactGroupID uuid.UUID `json:"contact_group_id" gorm:"primaryKey;type:uuid;" example:"32343a19-da5e-4b1b-a767-3298a73703cb"`
+	ContactID      uuid.UUID `json:"contact_id" gorm:"primaryKey;type:uuid;index" example:"32343a19-da5e-4b1b-a767-3298a73703cc"`
+	UserID         UserID    `json:"user_id" gorm:"index" example:"WB7DRDWrJZRGbYrv2CKGkqbzvqdC"`
+	CreatedAt      time.Time `json:"created_at" example:"2022-06-05T14:26:02.302718+03:00"`
+}
@@ -0,0 +1,24 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Conversation is the summary of the entities.Message exchanged between an owner and a contact, it is computed from the messages and is not persisted
+type Conversation struct {
+	Owner              string        `json:"owner" example:"+18005550199"`
+	Contact            string        `json:"contact" example:"+18005550100"`
+	LastMessageID      uuid.UUID     `json:"last_message_id" example:"32343a19-da5e-4b1b-a767-3298a73703cb"`
+	LastMessageContent string        `json:"last_message_content" example:"This is a sample text message"`
+	LastMessageType    MessageType   `json:"last_message_type" example:"mobile-originated"`
+	LastMessageStatus  MessageStatus `json:"last_message_status" example:"received"`
+
+	// UnreadCount is the number of messages received from the contact which were not marked as read.
+	// Messages which were followed by a message from the owner to the contact are considered as read.
+	UnreadCount    int64     `json:"unread_count" example:"2"`
+	MessageCount   int64     `json:"message_count" example:"10"`
+	FirstMessageAt time.Time `json:"first_message_at" example:"2022-06-05T14:26:09.527976+03:00"`
+	LastMessageAt  time.Time `json:"last_message_at" example:"2022-06-05T14:26:09.527976+03:00"`
+}
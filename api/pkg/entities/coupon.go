@@ -0,0 +1,35 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Coupon is a promo code which gives the user who redeems it a PlanOverride for a number of days
+type Coupon struct {
+	ID               uuid.UUID        `json:"id" gorm:"primaryKey;type:uuid;" example:"32343a19-da5e-4b1b-a767-3298a73703cb"`
+	Code             string           `json:"code" gorm:"uniqueIndex" example:"LAUNCH2022"`
+	Description      string           `json:"description" example:"Launch week promotion"`
+	SubscriptionName SubscriptionName `json:"subscription_name" example:"pro-monthly"`
+
+	// MessageLimit replaces the monthly limit of SubscriptionName when it is set
+	MessageLimit *uint `json:"message_limit" example:"5000"`
+	DurationDays uint  `json:"duration_days" example:"30"`
+
+	// MaxRedemptions is the number of users who can redeem the coupon, 0 means unlimited
+	MaxRedemptions uint       `json:"max_redemptions" example:"100"`
+	Redemptions    uint       `json:"redemptions" example:"12"`
+	ExpiresAt      *time.Time `json:"expires_at" example:"2022-07-05T14:26:02.302718+03:00"`
+	CreatedBy      UserID     `json:"created_by" example:"WB7DRDWrJZRGbYrv2CKGkqbzvqdC"`
+	CreatedAt      time.Time  `json:"created_at" example:"2022-06-05T14:26:02.302718+03:00"`
+	UpdatedAt      time.Time  `json:"updated_at" example:"2022-06-05T14:26:10.303278+03:00"`
+}
+
+// IsRedeemable checks if the coupon can still be redeemed at a timestamp
+func (coupon *Coupon) IsRedeemable(timestamp time.Time) bool {
+	if coupon.ExpiresAt != nil && !coupon.ExpiresAt.After(timestamp) {
+		return false
+	}
+	return coupon.MaxRedemptions == 0 || coupon.Redemptions < coupon.MaxRedemptions
+}
@@ -0,0 +1,55 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// CreditTransactionType is the reason for a change in the CreditBalance of a user
+type CreditTransactionType string
+
+const (
+	// CreditTransactionTypeTopUp adds credits which were bought by the user
+	CreditTransactionTypeTopUp = CreditTransactionType("top-up")
+
+	// CreditTransactionTypeAdjustment is a manual correction of the balance by an operator
+	CreditTransactionTypeAdjustment = CreditTransactionType("adjustment")
+
+	// CreditTransactionTypeMessageSent debits the credits used by a sent message
+	CreditTransactionTypeMessageSent = CreditTransactionType("message-sent")
+
+	// CreditTransactionTypeReferralBonus adds the credits earned by referring a new user
+	CreditTransactionTypeReferralBonus = CreditTransactionType("referral-bonus")
+)
+
+// CreditBalance is the number of prepaid credits of a user.
+// A user is on prepaid credits once the first CreditTransaction is recorded.
+type CreditBalance struct {
+	UserID    UserID    `json:"user_id" gorm:"primaryKey;type:string;" example:"WB7DRDWrJZRGbYrv2CKGkqbzvqdC"`
+	Balance   int64     `json:"balance" example:"1200"`
+	CreatedAt time.Time `json:"created_at" example:"2022-06-05T14:26:02.302718+03:00"`
+	UpdatedAt time.Time `json:"updated_at" example:"2022-06-05T14:26:10.303278+03:00"`
+}
+
+// HasCredits checks if the user can send a message
+func (balance *CreditBalance) HasCredits() bool {
+	return balance.Balance > 0
+}
+
+// CreditTransaction is an entry in the credit ledger of a user, debits have a negative Amount
+type CreditTransaction struct {
+	ID     uuid.UUID             `json:"id" gorm:"primaryKey;type:uuid;" example:"32343a19-da5e-4b1b-a767-3298a73703cb"`
+	UserID UserID                `json:"user_id" gorm:"index:idx_credit_transactions__user_id__created_at" example:"WB7DRDWrJZRGbYrv2CKGkqbzvqdC"`
+	Type   CreditTransactionType `json:"type" example:"message-sent"`
+	Amount int64                 `json:"amount" example:"-2"`
+
+	// Balance is the CreditBalance of the user after the transaction
+	Balance int64 `json:"balance" example:"1198"`
+
+	// MessageID is set for CreditTransactionTypeMessageSent so that a message is debited only once
+	MessageID   *uuid.UUID `json:"message_id" gorm:"type:uuid;uniqueIndex" example:"32343a19-da5e-4b1b-a767-3298a73703cc"`
+	Description string     `json:"description" example:"2 segments to NG"`
+	CreatedBy   *UserID    `json:"created_by" example:"WB7DRDWrJZRGbYrv2CKGkqbzvqdC"`
+	CreatedAt   time.Time  `json:"created_at" gorm:"index:idx_credit_transactions__user_id__created_at" example:"2022-06-05T14:26:02.302718+03:00"`
+}
@@ -0,0 +1,70 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DashboardPhoneStatus is the connectivity of a phone derived from its last heartbeat
+type DashboardPhoneStatus string
+
+const (
+	// DashboardPhoneStatusOnline means the phone sent a heartbeat recently
+	DashboardPhoneStatusOnline = DashboardPhoneStatus("online")
+
+	// DashboardPhoneStatusOffline means the phone has missed heartbeats
+	DashboardPhoneStatusOffline = DashboardPhoneStatus("offline")
+
+	// DashboardPhoneStatusUnknown means the phone has never sent a heartbeat
+	DashboardPhoneStatusUnknown = DashboardPhoneStatus("unknown")
+)
+
+// DashboardMessageCounts is the number of messages of a user in each state since a timestamp
+type DashboardMessageCounts struct {
+	Sent      uint `json:"sent" example:"120"`
+	Delivered uint `json:"delivered" example:"110"`
+	Failed    uint `json:"failed" example:"3"`
+	Received  uint `json:"received" example:"45"`
+}
+
+// DashboardQueueDepth is the number of messages of a user which are waiting to be sent
+type DashboardQueueDepth struct {
+	Pending             uint `json:"pending" example:"4"`
+	Scheduled           uint `json:"scheduled" example:"1"`
+	Sending             uint `json:"sending" example:"1"`
+	QueuedForFuture     uint `json:"queued_for_future" example:"10"`
+	Paused              uint `json:"paused" example:"0"`
+	AwaitingPredecessor uint `json:"awaiting_predecessor" example:"0"`
+	Total               uint `json:"total" example:"16"`
+}
+
+// DashboardPhone is the status of a phone of a user
+type DashboardPhone struct {
+	ID              uuid.UUID            `json:"id" example:"32343a19-da5e-4b1b-a767-3298a73703cb"`
+	PhoneNumber     string               `json:"phone_number" example:"+18005550199"`
+	Status          DashboardPhoneStatus `json:"status" example:"online"`
+	LastHeartbeatAt *time.Time           `json:"last_heartbeat_at" example:"2022-06-05T14:26:01.520828+03:00"`
+}
+
+// DashboardUsage compares the messages of a user in the current billing period with the limit of the plan
+type DashboardUsage struct {
+	Plan             SubscriptionName `json:"plan" example:"free"`
+	Limit            uint             `json:"limit" example:"200"`
+	SentMessages     uint             `json:"sent_messages" example:"100"`
+	ReceivedMessages uint             `json:"received_messages" example:"20"`
+	TotalMessages    uint             `json:"total_messages" example:"120"`
+	StartTimestamp   time.Time        `json:"start_timestamp" example:"2022-01-01T00:00:00+00:00"`
+	EndTimestamp     time.Time        `json:"end_timestamp" example:"2022-01-31T23:59:59+00:00"`
+}
+
+// Dashboard is the summary of an account which is displayed when the web dashboard is loaded
+type Dashboard struct {
+	// Today counts the messages since the start of the current UTC day
+	Today          DashboardMessageCounts `json:"today"`
+	QueueDepth     DashboardQueueDepth    `json:"queue_depth"`
+	Phones         []*DashboardPhone      `json:"phones"`
+	RecentFailures []*Message             `json:"recent_failures"`
+	Usage          *DashboardUsage        `json:"usage"`
+	GeneratedAt    time.Time              `json:"generated_at" example:"2022-06-05T14:26:10.303278+03:00"`
+}
@@ -0,0 +1,12 @@
+package entities
+
+import "time"
+
+// EncryptionKey is the data key of a user which encrypts the content of the messages of the user.
+// The data key is stored wrapped by the master key of the instance and it is never returned by the API.
+type EncryptionKey struct {
+	UserID      UserID `gorm:"primaryKey"`
+	WrappedKey  []byte `gorm:"type:bytea"`
+	MasterKeyID string `gorm:"index:idx_encryption_keys__master_key_id"`
+	CreatedAt   time.Time
+}
@@ -0,0 +1,45 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// ForwardingRule forwards the messages received on the phones of a user to email addresses
+type ForwardingRule struct {
+	ID     uuid.UUID `json:"id" gorm:"primaryKey;type:uuid;" example:"32343a19-da5e-4b1b-a767-3298a73703cb"`
+	UserID UserID    `json:"user_id" gorm:"index" example:"WB7DRDWrJZRGbYrv2CKGkqbzvqdC"`
+	Name   string    `json:"name" example:"Support inbox"`
+
+	// Owner and Contact restrict the rule to a phone and a sender, the rule matches every phone or sender when they are empty
+	Owner   string `json:"owner" example:"+18005550199"`
+	Contact string `json:"contact" example:"+18005550100"`
+
+	// Match and Pattern restrict the rule to the messages with matching content, every message matches when Pattern is empty
+	Match   RuleMatch `json:"match" example:"regex"`
+	Pattern string    `json:"pattern" example:"(?i)order"`
+
+	Emails    pq.StringArray `json:"emails" gorm:"type:text[]" swaggertype:"array,string" example:"support@example.com"`
+	Enabled   bool           `json:"enabled" example:"true"`
+	CreatedAt time.Time      `json:"created_at" example:"2022-06-05T14:26:02.302718+03:00"`
+	UpdatedAt time.Time      `json:"updated_at" example:"2022-06-05T14:26:10.303278+03:00"`
+}
+
+// Matches checks if a message received on the owner phone from the contact is forwarded by the ForwardingRule
+func (rule *ForwardingRule) Matches(owner string, contact string, content string) bool {
+	if rule.Owner != "" && rule.Owner != owner {
+		return false
+	}
+
+	if rule.Contact != "" && rule.Contact != contact {
+		return false
+	}
+
+	if rule.Pattern == "" {
+		return true
+	}
+
+	return (&Rule{Match: rule.Match, Pattern: rule.Pattern}).Matches(content)
+}
@@ -0,0 +1,19 @@
+package entities
+
+// InstanceIdentity describes how receivers of webhooks and integrations can recognise the requests sent by an instance
+type InstanceIdentity struct {
+	// WebhookSignatureAlgorithm is ed25519 when webhooks have an asymmetric signature, it is null when only the shared secret is used
+	WebhookSignatureAlgorithm *string `json:"webhook_signature_algorithm" example:"ed25519"`
+
+	// WebhookPublicKey is the base64 encoded Ed25519 public key which verifies the X-Httpsms-Signature-Ed25519 header
+	WebhookPublicKey *string `json:"webhook_public_key" example:"11qYAYKxCrfVS/7TyWQHOg7hcvPapiMlrwIaaPcHURo="`
+
+	// WebhookPublicKeyID is the kid in the X-Httpsms-Signature-Ed25519 header
+	WebhookPublicKeyID *string `json:"webhook_public_key_id" example:"1f0e9a2b6c3d4e5f"`
+
+	// EgressIPs are the static IP addresses which requests to webhooks and integrations are sent from so they can be allowed in a firewall
+	EgressIPs []string `json:"egress_ips" example:"203.0.113.10,203.0.113.11"`
+
+	// EgressProxyHost is the host of the global proxy which webhooks and integrations are delivered through
+	EgressProxyHost *string `json:"egress_proxy_host" example:"proxy.example.com:1080"`
+}
@@ -0,0 +1,70 @@
+package entities
+
+import "time"
+
+// InstanceStatusLevel is the health of an instance or one of its components
+type InstanceStatusLevel string
+
+const (
+	// InstanceStatusLevelOperational means the component is working normally
+	InstanceStatusLevelOperational = InstanceStatusLevel("operational")
+
+	// InstanceStatusLevelDegraded means the component is slower than normal
+	InstanceStatusLevelDegraded = InstanceStatusLevel("degraded")
+
+	// InstanceStatusLevelUnknown means the health of the component could not be determined
+	InstanceStatusLevelUnknown = InstanceStatusLevel("unknown")
+)
+
+// InstanceStatusComponentName is the name of a component on the status page
+type InstanceStatusComponentName string
+
+const (
+	// InstanceStatusComponentAPI is the HTTP API
+	InstanceStatusComponentAPI = InstanceStatusComponentName("api")
+
+	// InstanceStatusComponentQueue is the queue of outgoing messages
+	InstanceStatusComponentQueue = InstanceStatusComponentName("queue")
+
+	// InstanceStatusComponentDelivery is the delivery of sent messages
+	InstanceStatusComponentDelivery = InstanceStatusComponentName("delivery")
+)
+
+// InstanceStatus is the anonymized health of the instance which powers a public status page
+type InstanceStatus struct {
+	Status     InstanceStatusLevel        `json:"status" example:"operational"`
+	Components []*InstanceStatusComponent `json:"components"`
+	UpdatedAt  time.Time                  `json:"updated_at" example:"2022-06-05T14:26:02.302718+03:00"`
+}
+
+// InstanceStatusComponent is the health of a component of the instance
+type InstanceStatusComponent struct {
+	Name   InstanceStatusComponentName `json:"name" example:"api"`
+	Status InstanceStatusLevel         `json:"status" example:"operational"`
+
+	// StartedAt is when the API process started, it is set on the api component
+	StartedAt *time.Time `json:"started_at,omitempty" example:"2022-06-05T14:26:02.302718+03:00"`
+	// UptimeSeconds is the number of seconds since the API process started, it is set on the api component
+	UptimeSeconds *int64 `json:"uptime_seconds,omitempty" example:"86400"`
+
+	// QueueLagSeconds is the age of the oldest outstanding message, it is set on the queue component
+	QueueLagSeconds *float64 `json:"queue_lag_seconds,omitempty" example:"12.5"`
+
+	// DeliveryLatencies are the latency percentiles per region, they are set on the delivery component
+	DeliveryLatencies []*RegionDeliveryLatency `json:"delivery_latencies,omitempty"`
+}
+
+// RegionDeliveryLatency are the percentiles of the duration from sent to delivered for a region
+type RegionDeliveryLatency struct {
+	Region     string  `json:"region" example:"US"`
+	Count      int     `json:"count" example:"120"`
+	P50Seconds float64 `json:"p50_seconds" example:"3.2"`
+	P90Seconds float64 `json:"p90_seconds" example:"8.1"`
+	P99Seconds float64 `json:"p99_seconds" example:"30.4"`
+}
+
+// DeliveryLatencySample is the duration between sending and delivering a message to a contact
+type DeliveryLatencySample struct {
+	Contact string
+	Seconds float64
+}
@@ -0,0 +1,34 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Invoice is the monthly statement of the usage and cost of a user
+type Invoice struct {
+	ID               uuid.UUID        `json:"id" gorm:"primaryKey;type:uuid;" example:"32343a19-da5e-4b1b-a767-3298a73703cb"`
+	UserID           UserID           `json:"user_id" gorm:"uniqueIndex:idx_invoices__user_id__period_start" example:"WB7DRDWrJZRGbYrv2CKGkqbzvqdC"`
+	Number           string           `json:"number" example:"202201-32343A19"`
+	PeriodStart      time.Time        `json:"period_start" gorm:"uniqueIndex:idx_invoices__user_id__period_start" example:"2022-01-01T00:00:00+00:00"`
+	PeriodEnd        time.Time        `json:"period_end" example:"2022-01-31T23:59:59+00:00"`
+	SubscriptionName SubscriptionName `json:"subscription_name" example:"pro-monthly"`
+	SentMessages     uint             `json:"sent_messages" example:"321"`
+	ReceivedMessages uint             `json:"received_messages" example:"465"`
+
+	// CreditsAdded is the sum of the top-ups and adjustments which increased the credit balance during the period
+	CreditsAdded int64 `json:"credits_added" example:"1000"`
+
+	// CreditsUsed is the number of credits debited during the period
+	CreditsUsed int64      `json:"credits_used" example:"642"`
+	TotalCost   uint       `json:"total_cost" example:"0"`
+	EmailedAt   *time.Time `json:"emailed_at" example:"2022-02-01T00:10:02.302718+03:00"`
+	CreatedAt   time.Time  `json:"created_at" example:"2022-02-01T00:10:02.302718+03:00"`
+	UpdatedAt   time.Time  `json:"updated_at" example:"2022-02-01T00:10:02.302718+03:00"`
+}
+
+// TotalMessages returns the sum of sent and received messages
+func (invoice *Invoice) TotalMessages() uint {
+	return invoice.SentMessages + invoice.ReceivedMessages
+}
@@ -0,0 +1,37 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// LinkPreviewStatus is the status of fetching the metadata of a link
+type LinkPreviewStatus string
+
+const (
+	// LinkPreviewStatusFetched means the metadata of the link was fetched successfully
+	LinkPreviewStatusFetched = LinkPreviewStatus("fetched")
+
+	// LinkPreviewStatusFailed means the metadata of the link could not be fetched
+	LinkPreviewStatusFailed = LinkPreviewStatus("failed")
+)
+
+// LinkPreview stores the Open Graph metadata of a URL which is found in a message
+type LinkPreview struct {
+	ID          uuid.UUID         `json:"id" gorm:"primaryKey;type:uuid;" example:"32343a19-da5e-4b1b-a767-3298a73703cb"`
+	URL         string            `json:"url" gorm:"uniqueIndex" example:"https://httpsms.com"`
+	Status      LinkPreviewStatus `json:"status" example:"fetched"`
+	Title       *string           `json:"title" example:"httpSMS - Use your android phone as an SMS gateway"`
+	Description *string           `json:"description" example:"Send and receive SMS messages using your android phone"`
+	ImageURL    *string           `json:"image_url" example:"https://httpsms.com/header.png"`
+	SiteName    *string           `json:"site_name" example:"httpSMS"`
+	FetchedAt   time.Time         `json:"fetched_at" example:"2022-06-05T14:26:02.302718+03:00"`
+	CreatedAt   time.Time         `json:"created_at" example:"2022-06-05T14:26:02.302718+03:00"`
+	UpdatedAt   time.Time         `json:"updated_at" example:"2022-06-05T14:26:10.303278+03:00"`
+}
+
+// IsStale checks if the metadata of the link should be fetched again
+func (preview *LinkPreview) IsStale(ttl time.Duration) bool {
+	return preview.FetchedAt.Add(ttl).Before(time.Now().UTC())
+}
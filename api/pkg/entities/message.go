@@ -4,6 +4,7 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
 // MessageType is the type of message if it is incoming or outgoing
@@ -44,6 +45,18 @@ const (
 
 	// MessageStatusExpired means the message could not be sent by the mobile phone after 5 minutes
 	MessageStatusExpired = "expired"
+
+	// MessageStatusDeliveryUnknown means the message was sent but no delivery report was received within the delivery report timeout
+	MessageStatusDeliveryUnknown = "delivery-unknown"
+
+	// MessageStatusQueuedForFuture means the message is stored but it will only be sent to the phone at the SendAt time
+	MessageStatusQueuedForFuture = "queued-for-future"
+
+	// MessageStatusPaused means the message was waiting to be sent when the account of the user was suspended, it is sent once the account is reactivated
+	MessageStatusPaused = "paused"
+
+	// MessageStatusAwaitingPredecessor means the message is stored but it will only be sent after the message with AfterMessageID is sent
+	MessageStatusAwaitingPredecessor = "awaiting-predecessor"
 )
 
 // MessageEventName is the type of event generated by the mobile phone for a message
@@ -58,6 +71,9 @@ const (
 
 	// MessageEventNameFailed is emitted when a message is failed by the mobile phone
 	MessageEventNameFailed = MessageEventName("FAILED")
+
+	// MessageEventNameSkippedExpired is emitted when the mobile phone does not send a message because it received it after it expired
+	MessageEventNameSkippedExpired = MessageEventName("SKIPPED-EXPIRED")
 )
 
 // SIM is the SIM card to use to send the message
@@ -72,11 +88,45 @@ const (
 	SIMDefault = SIM("DEFAULT")
 )
 
+// MessagePriority determines the order in which the outgoing messages of a phone are dispatched
+type MessagePriority string
+
+const (
+	// MessagePriorityHigh is used for time sensitive messages like OTPs which are sent before other messages
+	MessagePriorityHigh = MessagePriority("high")
+
+	// MessagePriorityNormal is the default priority of a message
+	MessagePriorityNormal = MessagePriority("normal")
+
+	// MessagePriorityLow is used for bulk messages which are sent when no other message is waiting
+	MessagePriorityLow = MessagePriority("low")
+)
+
+// OrDefault returns MessagePriorityNormal when the priority is not set e.g. for events emitted before priorities existed
+func (priority MessagePriority) OrDefault() MessagePriority {
+	if priority == "" {
+		return MessagePriorityNormal
+	}
+	return priority
+}
+
+// AtLeast returns the priorities which are dispatched before or together with this priority
+func (priority MessagePriority) AtLeast() []MessagePriority {
+	switch priority.OrDefault() {
+	case MessagePriorityHigh:
+		return []MessagePriority{MessagePriorityHigh}
+	case MessagePriorityNormal:
+		return []MessagePriority{MessagePriorityHigh, MessagePriorityNormal}
+	default:
+		return []MessagePriority{MessagePriorityHigh, MessagePriorityNormal, MessagePriorityLow}
+	}
+}
+
 // Message represents a message sent between 2 phone numbers
 type Message struct {
 	ID      uuid.UUID     `json:"id" gorm:"primaryKey;type:uuid;" example:"32343a19-da5e-4b1b-a767-3298a73703cb"`
 	Owner   string        `json:"owner" gorm:"index:idx_messages_user_id__owner__contact" example:"+18005550199"`
-	UserID  UserID        `json:"user_id" gorm:"index:idx_messages__user_id" example:"WB7DRDWrJZRGbYrv2CKGkqbzvqdC"`
+	UserID  UserID        `json:"user_id" gorm:"index:idx_messages__user_id;index:idx_messages__user_id__order_timestamp,priority:1" example:"WB7DRDWrJZRGbYrv2CKGkqbzvqdC"`
 	Contact string        `json:"contact" gorm:"index:idx_messages_user_id__owner__contact" example:"+18005550100"`
 	Content string        `json:"content" example:"This is a sample text message"`
 	Type    MessageType   `json:"type" example:"mobile-terminated"`
@@ -87,24 +137,70 @@ type Message struct {
 	// * DEFAULT: used the default communication SIM card
 	SIM SIM `json:"sim" example:"DEFAULT"`
 
+	// Priority determines if the message is dispatched to the phone before the other messages which are waiting
+	Priority MessagePriority `json:"priority" gorm:"default:normal" example:"normal"`
+
 	// SendDuration is the number of nanoseconds from when the request was received until when the mobile phone send the message
 	SendDuration *int64 `json:"send_time" example:"133414"`
 
 	RequestReceivedAt       time.Time  `json:"request_received_at" example:"2022-06-05T14:26:01.520828+03:00"`
 	CreatedAt               time.Time  `json:"created_at" example:"2022-06-05T14:26:02.302718+03:00"`
 	UpdatedAt               time.Time  `json:"updated_at" example:"2022-06-05T14:26:10.303278+03:00"`
-	OrderTimestamp          time.Time  `json:"order_timestamp" gorm:"index:idx_messages_order_timestamp" example:"2022-06-05T14:26:09.527976+03:00"`
+	OrderTimestamp          time.Time  `json:"order_timestamp" gorm:"index:idx_messages_order_timestamp;index:idx_messages__user_id__order_timestamp,priority:2" example:"2022-06-05T14:26:09.527976+03:00"`
 	LastAttemptedAt         *time.Time `json:"last_attempted_at" example:"2022-06-05T14:26:09.527976+03:00"`
 	NotificationScheduledAt *time.Time `json:"scheduled_at" example:"2022-06-05T14:26:09.527976+03:00"`
 	SentAt                  *time.Time `json:"sent_at" example:"2022-06-05T14:26:09.527976+03:00"`
 	DeliveredAt             *time.Time `json:"delivered_at" example:"2022-06-05T14:26:09.527976+03:00"`
 	ExpiredAt               *time.Time `json:"expired_at" example:"2022-06-05T14:26:09.527976+03:00"`
+	DeliveryUnknownAt       *time.Time `json:"delivery_unknown_at" example:"2022-06-06T14:26:09.527976+03:00"`
 	FailedAt                *time.Time `json:"failed_at" example:"2022-06-05T14:26:09.527976+03:00"`
 	CanBePolled             bool       `json:"can_be_polled" example:"false"`
 	SendAttemptCount        uint       `json:"send_attempt_count" example:"0"`
 	MaxSendAttempts         uint       `json:"max_send_attempts" example:"1"`
 	ReceivedAt              *time.Time `json:"received_at" example:"2022-06-05T14:26:09.527976+03:00"`
 	FailureReason           *string    `json:"failure_reason" example:"UNKNOWN"`
+
+	// ReadAt is when a mobile-originated message was marked as read, it is null for unread messages
+	ReadAt *time.Time `json:"read_at" example:"2022-06-05T14:30:12.527976+03:00"`
+
+	// FailureCode is the structured reason why the message failed
+	FailureCode *MessageFailureCode `json:"failure_code" example:"no-service"`
+
+	// RetryCount is the number of times the message has been retried after it failed
+	RetryCount uint `json:"retry_count" example:"0"`
+
+	// DuplicateCount is the number of times the carrier delivered this received message again
+	DuplicateCount uint `json:"duplicate_count" example:"0"`
+
+	// SendAt is the time when the message is dispatched to the phone, it is nil when the message is sent immediately
+	SendAt *time.Time `json:"send_at" gorm:"index:idx_messages__send_at" example:"2022-06-05T18:00:00+03:00"`
+
+	// BatchID is set when the message was sent with other messages in a single bulk send request
+	BatchID *uuid.UUID `json:"batch_id" gorm:"type:uuid;index:idx_messages__batch_id" example:"32343a19-da5e-4b1b-a767-3298a73703cc"`
+
+	// AfterMessageID is the message which must be sent or delivered before this message is dispatched to the phone
+	AfterMessageID *uuid.UUID `json:"after_message_id" gorm:"type:uuid;index:idx_messages__after_message_id" example:"32343a19-da5e-4b1b-a767-3298a73703cd"`
+
+	// TemplateID is the Template which was used for the content of a message sent by a campaign
+	TemplateID *uuid.UUID `json:"template_id" gorm:"type:uuid;index:idx_messages__template_id" example:"32343a19-da5e-4b1b-a767-3298a73703ce"`
+
+	// ConversationID is supplied by the client when sending a message, replies from the contact within the conversation window inherit it.
+	ConversationID *string `json:"conversation_id" gorm:"index:idx_messages__conversation_id" example:"bot-session-1234"`
+
+	// ExpiresIn is the duration in seconds after the phone is notified when the message expires, it overrides the message expiration of the phone when set.
+	ExpiresIn *uint `json:"expires_in" example:"300"`
+
+	// Sequence is assigned by the server when the message is stored and increases monotonically within a thread so that the order does not depend on phone timestamps.
+	Sequence uint64 `json:"sequence" example:"42"`
+
+	// AttachmentCount is the number of attachments of an MMS message
+	AttachmentCount uint `json:"attachment_count" example:"0"`
+
+	// Tags are the names of the entities.Tag set on the message by the user
+	Tags pq.StringArray `json:"tags" gorm:"type:text[]" example:"[billing]" swaggertype:"array,string"`
+
+	// Attachments are loaded with signed download URLs when AttachmentCount is not 0
+	Attachments []*Attachment `json:"attachments,omitempty" gorm:"-"`
 }
 
 // IsSending determines if a message is being sent
@@ -122,6 +218,21 @@ func (message *Message) IsPending() bool {
 	return message.Status == MessageStatusPending
 }
 
+// IsPaused checks if a message is waiting for the account of the user to be reactivated
+func (message *Message) IsPaused() bool {
+	return message.Status == MessageStatusPaused
+}
+
+// IsQueuedForFuture checks if a message is waiting for its SendAt time
+func (message *Message) IsQueuedForFuture() bool {
+	return message.Status == MessageStatusQueuedForFuture
+}
+
+// IsAwaitingPredecessor checks if a message is waiting for the message with AfterMessageID to be sent
+func (message *Message) IsAwaitingPredecessor() bool {
+	return message.Status == MessageStatusAwaitingPredecessor
+}
+
 // IsScheduled checks if a message is scheduled
 func (message *Message) IsScheduled() bool {
 	return message.Status == MessageStatusScheduled
@@ -132,11 +243,21 @@ func (message *Message) IsExpired() bool {
 	return message.Status == MessageStatusExpired
 }
 
+// IsDeliveryUnknown checks if a sent message never got a delivery report
+func (message *Message) IsDeliveryUnknown() bool {
+	return message.Status == MessageStatusDeliveryUnknown
+}
+
 // CanBeRescheduled checks if a message can be rescheduled
 func (message *Message) CanBeRescheduled() bool {
 	return message.SendAttemptCount < message.MaxSendAttempts
 }
 
+// IsFailed checks if a message has failed
+func (message *Message) IsFailed() bool {
+	return message.Status == MessageStatusFailed
+}
+
 // IsSent determines if a message has been sent
 func (message *Message) IsSent() bool {
 	return message.Status == MessageStatusSent
@@ -154,9 +275,10 @@ func (message *Message) Sent(timestamp time.Time) *Message {
 }
 
 // Failed registers a message as failed
-func (message *Message) Failed(timestamp time.Time, errorMessage string) *Message {
+func (message *Message) Failed(timestamp time.Time, code MessageFailureCode, errorMessage string) *Message {
 	message.FailedAt = &timestamp
 	message.Status = MessageStatusFailed
+	message.FailureCode = &code
 	message.FailureReason = &errorMessage
 	message.updateOrderTimestamp(timestamp)
 	return message
@@ -181,6 +303,25 @@ func (message *Message) AddSendAttemptCount() *Message {
 	return message
 }
 
+// AddDuplicateCount increments the number of duplicate deliveries of a received message
+func (message *Message) AddDuplicateCount() *Message {
+	message.DuplicateCount++
+	return message
+}
+
+// AddRetryCount increments the number of times a failed message has been retried
+func (message *Message) AddRetryCount() *Message {
+	message.RetryCount++
+	return message
+}
+
+// DeliveryUnknown registers a sent message whose delivery report never arrived
+func (message *Message) DeliveryUnknown(timestamp time.Time) *Message {
+	message.DeliveryUnknownAt = &timestamp
+	message.Status = MessageStatusDeliveryUnknown
+	return message
+}
+
 // Expired registers a message as expired
 func (message *Message) Expired(timestamp time.Time) *Message {
 	message.ExpiredAt = &timestamp
@@ -194,7 +335,7 @@ func (message *Message) Expired(timestamp time.Time) *Message {
 func (message *Message) NotificationScheduled(timestamp time.Time) *Message {
 	message.NotificationScheduledAt = &timestamp
 
-	if message.IsExpired() || message.IsPending() {
+	if message.IsExpired() || message.IsPending() || message.IsFailed() {
 		message.Status = MessageStatusScheduled
 	}
 	message.updateOrderTimestamp(timestamp)
@@ -215,3 +356,21 @@ func (message *Message) updateOrderTimestamp(timestamp time.Time) {
 		message.OrderTimestamp = timestamp
 	}
 }
+
+// ExpirationDuration is the duration after the phone is notified when the message expires, it is 0 when the message does not expire
+func (message *Message) ExpirationDuration(phone *Phone) time.Duration {
+	if message.ExpiresIn != nil {
+		return time.Duration(*message.ExpiresIn) * time.Second
+	}
+	return phone.MessageExpirationDuration()
+}
+
+// ExpiresAt is when the message expires if the phone is notified at notifiedAt, it is nil when the message does not expire
+func (message *Message) ExpiresAt(phone *Phone, notifiedAt time.Time) *time.Time {
+	duration := message.ExpirationDuration(phone)
+	if duration == 0 {
+		return nil
+	}
+	expiresAt := notifiedAt.Add(duration)
+	return &expiresAt
+}
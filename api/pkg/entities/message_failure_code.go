@@ -0,0 +1,83 @@
+package entities
+
+// MessageFailureCode is the structured reason why a message could not be sent
+type MessageFailureCode string
+
+const (
+	// MessageFailureCodeGenericFailure means the phone could not send the message for an unspecified reason
+	MessageFailureCodeGenericFailure = MessageFailureCode("generic-failure")
+
+	// MessageFailureCodeRadioOff means the radio of the phone was turned off e.g. airplane mode
+	MessageFailureCodeRadioOff = MessageFailureCode("radio-off")
+
+	// MessageFailureCodeNoService means the phone had no cellular service or the network rejected the message
+	MessageFailureCodeNoService = MessageFailureCode("no-service")
+
+	// MessageFailureCodeInvalidMessage means the message could not be encoded or the destination is invalid
+	MessageFailureCodeInvalidMessage = MessageFailureCode("invalid-message")
+
+	// MessageFailureCodeQuota means the SMS sending limit of the android phone has been reached
+	MessageFailureCodeQuota = MessageFailureCode("quota")
+
+	// MessageFailureCodeBlocked means the message was blocked by the phone or the carrier e.g. premium short codes
+	MessageFailureCodeBlocked = MessageFailureCode("blocked")
+
+	// MessageFailureCodeNotificationFailed means the push notification could not be delivered to the phone
+	MessageFailureCodeNotificationFailed = MessageFailureCode("notification-failed")
+
+	// MessageFailureCodeOptedOut means the API did not send the message because the contact is on the blocklist, it is never retried
+	MessageFailureCodeOptedOut = MessageFailureCode("opted-out")
+
+	// MessageFailureCodeDependencyFailed means the API did not send the message because the message it was sent after failed, it is never retried
+	MessageFailureCodeDependencyFailed = MessageFailureCode("dependency-failed")
+
+	// MessageFailureCodeInsufficientCredits means the API did not send the message because the prepaid user did not have enough credits, it is never retried
+	MessageFailureCodeInsufficientCredits = MessageFailureCode("insufficient-credits")
+
+	// MessageFailureCodeUnknown means the phone did not report a result code
+	MessageFailureCodeUnknown = MessageFailureCode("unknown")
+)
+
+// MessageFailureCodes are all the valid MessageFailureCode values
+var MessageFailureCodes = []MessageFailureCode{
+	MessageFailureCodeGenericFailure,
+	MessageFailureCodeRadioOff,
+	MessageFailureCodeNoService,
+	MessageFailureCodeInvalidMessage,
+	MessageFailureCodeQuota,
+	MessageFailureCodeBlocked,
+	MessageFailureCodeNotificationFailed,
+	MessageFailureCodeUnknown,
+}
+
+// MessageFailureCodeFromResultCode maps the result code of the android SmsManager to a MessageFailureCode
+// https://developer.android.com/reference/android/telephony/SmsManager#RESULT_ERROR_GENERIC_FAILURE
+func MessageFailureCodeFromResultCode(resultCode *int) MessageFailureCode {
+	if resultCode == nil {
+		return MessageFailureCodeUnknown
+	}
+
+	switch *resultCode {
+	case 2, 9: // RESULT_ERROR_RADIO_OFF, RESULT_RADIO_NOT_AVAILABLE
+		return MessageFailureCodeRadioOff
+	case 4, 10, 17: // RESULT_ERROR_NO_SERVICE, RESULT_NETWORK_REJECT, RESULT_NETWORK_ERROR
+		return MessageFailureCodeNoService
+	case 3, 11, 14, 18, 19: // RESULT_ERROR_NULL_PDU, RESULT_INVALID_ARGUMENTS, RESULT_INVALID_SMS_FORMAT, RESULT_ENCODING_ERROR, RESULT_INVALID_SMSC_ADDRESS
+		return MessageFailureCodeInvalidMessage
+	case 5: // RESULT_ERROR_LIMIT_EXCEEDED
+		return MessageFailureCodeQuota
+	case 6, 7, 8, 20, 29: // RESULT_ERROR_FDN_CHECK_FAILURE, RESULT_ERROR_SHORT_CODE_NOT_ALLOWED, RESULT_ERROR_SHORT_CODE_NEVER_ALLOWED, RESULT_OPERATION_NOT_ALLOWED, RESULT_SMS_BLOCKED_DURING_EMERGENCY
+		return MessageFailureCodeBlocked
+	default:
+		return MessageFailureCodeGenericFailure
+	}
+}
+
+// MessageFailureCodeCount is the number of messages which failed with a MessageFailureCode
+type MessageFailureCodeCount struct {
+	FailureCode MessageFailureCode `json:"failure_code" example:"no-service"`
+
+	// Group is the value of the group e.g. the owner phone number. It is empty when the stats are not grouped
+	Group string `json:"group" example:"+18005550199"`
+	Count int64  `json:"count" example:"42"`
+}
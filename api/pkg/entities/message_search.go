@@ -0,0 +1,56 @@
+package entities
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/google/uuid"
+)
+
+// MessageSearchTerm is a word, a phrase in double quotes or a prefix ending with * in a message search query
+type MessageSearchTerm struct {
+	Words  []string
+	Phrase bool
+	Prefix bool
+}
+
+// MessageSearchHit is a message which matches a search query
+type MessageSearchHit struct {
+	MessageID uuid.UUID `json:"message_id" example:"32343a19-da5e-4b1b-a767-3298a73703cb"`
+
+	// Snippet is the part of the content which matches the query, the matches are wrapped in <mark></mark> tags
+	Snippet string  `json:"snippet" example:"your <mark>verification</mark> code is 123456"`
+	Score   float64 `json:"score" example:"0.0607927"`
+}
+
+// ParseMessageSearchQuery splits a search query into MessageSearchTerm e.g. `"order shipped" refund*` has the phrase "order shipped" and the prefix refund.
+// Punctuation is removed so the terms can be used in the query syntax of a search backend.
+func ParseMessageSearchQuery(query string) []MessageSearchTerm {
+	terms := make([]MessageSearchTerm, 0)
+	for index, part := range strings.Split(query, `"`) {
+		// the odd parts are inside double quotes
+		if index%2 == 1 {
+			if words := messageSearchWords(part); len(words) > 0 {
+				terms = append(terms, MessageSearchTerm{Words: words, Phrase: len(words) > 1})
+			}
+			continue
+		}
+
+		for _, field := range strings.Fields(part) {
+			if words := messageSearchWords(field); len(words) > 0 {
+				for _, word := range words[:len(words)-1] {
+					terms = append(terms, MessageSearchTerm{Words: []string{word}})
+				}
+				terms = append(terms, MessageSearchTerm{Words: words[len(words)-1:], Prefix: strings.HasSuffix(field, "*")})
+			}
+		}
+	}
+	return terms
+}
+
+// messageSearchWords splits text into lower case words of letters and digits
+func messageSearchWords(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
@@ -0,0 +1,12 @@
+package entities
+
+import "time"
+
+// MessageSequence is the counter of the last Message.Sequence which was assigned in a thread between an owner and a contact
+type MessageSequence struct {
+	UserID    UserID    `json:"user_id" gorm:"primaryKey" example:"WB7DRDWrJZRGbYrv2CKGkqbzvqdC"`
+	Owner     string    `json:"owner" gorm:"primaryKey" example:"+18005550199"`
+	Contact   string    `json:"contact" gorm:"primaryKey" example:"+18005550100"`
+	Value     uint64    `json:"value" example:"42"`
+	UpdatedAt time.Time `json:"updated_at" example:"2022-06-05T14:26:10.303278+03:00"`
+}
@@ -4,6 +4,7 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
 // MessageThread represents a message thread between 2 phone numbers
@@ -19,6 +20,12 @@ type MessageThread struct {
 	CreatedAt          time.Time `json:"created_at" example:"2022-06-05T14:26:09.527976+03:00"`
 	UpdatedAt          time.Time `json:"updated_at" example:"2022-06-05T14:26:09.527976+03:00"`
 	OrderTimestamp     time.Time `json:"order_timestamp" gorm:"index:idx_message_threads_order_timestamp" example:"2022-06-05T14:26:09.527976+03:00"`
+
+	// AutoArchivedAt is set when the thread was archived because it had no activity for the auto archive days of the user
+	AutoArchivedAt *time.Time `json:"auto_archived_at" example:"2022-06-05T14:26:09.527976+03:00"`
+
+	// Tags are the names of the entities.Tag set on the thread by the user
+	Tags pq.StringArray `json:"tags" gorm:"type:text[]" example:"[billing]" swaggertype:"array,string"`
 }
 
 // Update a message thread after a message event
@@ -26,11 +33,18 @@ func (thread *MessageThread) Update(timestamp time.Time, messageID uuid.UUID, co
 	thread.OrderTimestamp = timestamp
 	thread.LastMessageID = messageID
 	thread.LastMessageContent = content
+
+	// a thread which was archived automatically goes back to the inbox when there is new activity
+	if thread.AutoArchivedAt != nil {
+		thread.IsArchived = false
+		thread.AutoArchivedAt = nil
+	}
 	return thread
 }
 
 // UpdateArchive sets a message thread as archived
 func (thread *MessageThread) UpdateArchive(isArchived bool) *MessageThread {
 	thread.IsArchived = isArchived
+	thread.AutoArchivedAt = nil
 	return thread
 }
@@ -0,0 +1,28 @@
+package entities
+
+// MessageSendDurationBuckets are the upper bounds in seconds of the buckets of the send duration histogram
+var MessageSendDurationBuckets = []float64{1, 5, 15, 30, 60, 300, 900, 3600}
+
+// MessageTotals are the all time counts of the messages of an owner phone number
+type MessageTotals struct {
+	Owner     string
+	Sent      uint64
+	Delivered uint64
+	Failed    uint64
+	Expired   uint64
+	Received  uint64
+
+	// SendDurationCount is the number of messages with a send duration and SendDurationSeconds is the sum of their durations
+	SendDurationCount   uint64
+	SendDurationSeconds float64
+
+	// SendDurationBuckets are the cumulative number of messages sent within each of the MessageSendDurationBuckets
+	SendDurationBuckets []uint64
+}
+
+// MessageQueueCount is the number of messages of an owner phone number which are waiting to be sent with a status
+type MessageQueueCount struct {
+	Owner  string
+	Status MessageStatus
+	Count  uint64
+}
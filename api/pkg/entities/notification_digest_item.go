@@ -0,0 +1,17 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// NotificationDigestItem is an alert which is waiting to be sent to a user in a digest email
+type NotificationDigestItem struct {
+	ID         uuid.UUID `json:"id" gorm:"primaryKey;type:uuid;" example:"32343a19-da5e-4b1b-a767-3298a73703cb"`
+	UserID     UserID    `json:"user_id" gorm:"index" example:"WB7DRDWrJZRGbYrv2CKGkqbzvqdC"`
+	EventType  string    `json:"event_type" example:"phone.heartbeat.dead"`
+	Summary    string    `json:"summary" example:"Phone +18005550199 stopped sending heartbeats"`
+	OccurredAt time.Time `json:"occurred_at" example:"2022-06-05T14:26:02.302718+03:00"`
+	CreatedAt  time.Time `json:"created_at" example:"2022-06-05T14:26:02.302718+03:00"`
+}
@@ -0,0 +1,47 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// NotificationChannel is the medium used to notify a user about an event
+type NotificationChannel string
+
+const (
+	// NotificationChannelEmail sends an email to the user
+	NotificationChannelEmail = NotificationChannel("email")
+
+	// NotificationChannelWebhook sends the event to the webhooks of the user
+	NotificationChannelWebhook = NotificationChannel("webhook")
+
+	// NotificationChannelIntegration sends the event to the integrations of the user e.g. discord
+	NotificationChannelIntegration = NotificationChannel("integration")
+)
+
+// NotificationPreference determines if an event generates a notification on a NotificationChannel
+type NotificationPreference struct {
+	ID        uuid.UUID           `json:"id" gorm:"primaryKey;type:uuid;" example:"32343a19-da5e-4b1b-a767-3298a73703cb"`
+	UserID    UserID              `json:"user_id" gorm:"uniqueIndex:idx_notification_preferences__user_id__event_type__channel" example:"WB7DRDWrJZRGbYrv2CKGkqbzvqdC"`
+	EventType string              `json:"event_type" gorm:"uniqueIndex:idx_notification_preferences__user_id__event_type__channel" example:"message.phone.received"`
+	Channel   NotificationChannel `json:"channel" gorm:"uniqueIndex:idx_notification_preferences__user_id__event_type__channel" example:"webhook"`
+	Enabled   bool                `json:"enabled" example:"true"`
+
+	// IsDefault is true when the user has not configured a preference for the event and channel
+	IsDefault bool      `json:"is_default" gorm:"-" example:"false"`
+	CreatedAt time.Time `json:"created_at" example:"2022-06-05T14:26:02.302718+03:00"`
+	UpdatedAt time.Time `json:"updated_at" example:"2022-06-05T14:26:10.303278+03:00"`
+}
+
+// DefaultNotificationPreference is the NotificationPreference used when a user has not configured one, every notification is enabled by default
+func DefaultNotificationPreference(userID UserID, eventType string, channel NotificationChannel) *NotificationPreference {
+	return &NotificationPreference{
+		ID:        uuid.Nil,
+		UserID:    userID,
+		EventType: eventType,
+		Channel:   channel,
+		Enabled:   true,
+		IsDefault: true,
+	}
+}
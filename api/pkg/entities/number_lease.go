@@ -0,0 +1,58 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// NumberLeaseStatus is the status of a number lease
+type NumberLeaseStatus string
+
+const (
+	// NumberLeaseStatusActive means the owner number is allocated to the reference
+	NumberLeaseStatusActive = NumberLeaseStatus("active")
+
+	// NumberLeaseStatusReleased means the owner number has been returned to the pool
+	NumberLeaseStatusReleased = NumberLeaseStatus("released")
+)
+
+// NumberLease allocates an owner phone number from the pool of a user to a conversation or campaign
+type NumberLease struct {
+	ID     uuid.UUID `json:"id" gorm:"primaryKey;type:uuid;" example:"32343a19-da5e-4b1b-a767-3298a73703cb"`
+	UserID UserID    `json:"user_id" gorm:"index:idx_number_leases__user_id__status" example:"WB7DRDWrJZRGbYrv2CKGkqbzvqdC"`
+	Owner  string    `json:"owner" example:"+18005550199"`
+
+	// Reference identifies the conversation or campaign which is using the owner number
+	Reference string            `json:"reference" example:"order-1234"`
+	Status    NumberLeaseStatus `json:"status" gorm:"index:idx_number_leases__user_id__status" example:"active"`
+
+	// InactivityTimeoutSeconds is the duration in seconds without messages after which the lease is released
+	InactivityTimeoutSeconds uint       `json:"inactivity_timeout_seconds" example:"86400"`
+	LastActivityAt           time.Time  `json:"last_activity_at" example:"2022-06-05T14:26:09.527976+03:00"`
+	ReleasedAt               *time.Time `json:"released_at" example:"2022-06-05T14:26:09.527976+03:00"`
+	CreatedAt                time.Time  `json:"created_at" example:"2022-06-05T14:26:02.302718+03:00"`
+	UpdatedAt                time.Time  `json:"updated_at" example:"2022-06-05T14:26:10.303278+03:00"`
+}
+
+// InactivityTimeout returns the inactivity timeout as time.Duration
+func (lease *NumberLease) InactivityTimeout() time.Duration {
+	return time.Duration(lease.InactivityTimeoutSeconds) * time.Second
+}
+
+// IsActive checks if the owner number is still allocated
+func (lease *NumberLease) IsActive() bool {
+	return lease.Status == NumberLeaseStatusActive
+}
+
+// IsInactive checks if no message has been exchanged on the lease within the inactivity timeout
+func (lease *NumberLease) IsInactive(timestamp time.Time) bool {
+	return timestamp.Sub(lease.LastActivityAt) >= lease.InactivityTimeout()
+}
+
+// Release returns the owner number to the pool
+func (lease *NumberLease) Release(timestamp time.Time) *NumberLease {
+	lease.Status = NumberLeaseStatusReleased
+	lease.ReleasedAt = &timestamp
+	return lease
+}
@@ -1,9 +1,54 @@
 package entities
 
 import (
+	"math"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"gorm.io/datatypes"
+)
+
+// PhoneTransport is how the server tells a phone to send a message
+type PhoneTransport string
+
+const (
+	// PhoneTransportFCM sends a push notification with firebase cloud messaging
+	PhoneTransportFCM = PhoneTransport("fcm")
+
+	// PhoneTransportNATS publishes a job on the NATS JetStream subject of the phone
+	PhoneTransportNATS = PhoneTransport("nats")
+
+	// PhoneTransportMQTT publishes a command on the MQTT command topic of the phone
+	PhoneTransportMQTT = PhoneTransport("mqtt")
+)
+
+// PhoneReleaseChannel is the release channel of the android app installed on a phone
+type PhoneReleaseChannel string
+
+const (
+	// PhoneReleaseChannelStable receives the releases which are available to everyone
+	PhoneReleaseChannelStable = PhoneReleaseChannel("stable")
+
+	// PhoneReleaseChannelBeta receives releases before they are promoted to stable
+	PhoneReleaseChannelBeta = PhoneReleaseChannel("beta")
+
+	// PhoneReleaseChannelAlpha receives early builds which are still being tested
+	PhoneReleaseChannelAlpha = PhoneReleaseChannel("alpha")
+)
+
+// PhoneSIMBalancing is how messages sent with SIMDefault are spread across the SIM cards of a dual SIM phone
+type PhoneSIMBalancing string
+
+const (
+	// PhoneSIMBalancingNone sends messages with the default communication SIM card of the phone
+	PhoneSIMBalancingNone = PhoneSIMBalancing("none")
+
+	// PhoneSIMBalancingRoundRobin alternates between SIM1 and SIM2
+	PhoneSIMBalancingRoundRobin = PhoneSIMBalancing("round-robin")
+
+	// PhoneSIMBalancingLeastRecentlyUsed sends with the SIM card which has not sent a message for the longest time
+	PhoneSIMBalancingLeastRecentlyUsed = PhoneSIMBalancing("least-recently-used")
 )
 
 // Phone represents an android phone which has installed the http sms app
@@ -18,13 +63,202 @@ type Phone struct {
 	// MaxSendAttempts determines how many times to retry sending an SMS message
 	MaxSendAttempts uint `json:"max_send_attempts" example:"1"`
 
+	// Transport is how the phone is notified about new messages to send
+	Transport PhoneTransport `json:"transport" gorm:"default:fcm" example:"fcm"`
+
+	// ReleaseChannel is the release channel of the android app on the phone
+	ReleaseChannel PhoneReleaseChannel `json:"release_channel" gorm:"default:stable" example:"stable"`
+
+	// KillSwitchEnabledAt is set while the phone is stopped from sending messages e.g. during an incident
+	KillSwitchEnabledAt *time.Time `json:"kill_switch_enabled_at" example:"2022-06-05T14:26:10.303278+03:00"`
+
+	// KillSwitchReason is why the kill switch of the phone was enabled
+	KillSwitchReason *string `json:"kill_switch_reason" example:"messages are sent more than once"`
+
+	// SIMBalancing is how messages sent with the DEFAULT SIM are spread across SIM1 and SIM2 when the phone is dual SIM
+	SIMBalancing PhoneSIMBalancing `json:"sim_balancing" gorm:"default:none" example:"round-robin"`
+
+	// LastBalancedSIM is the SIM card which was assigned to the last message by round-robin balancing
+	LastBalancedSIM SIM `json:"last_balanced_sim" example:"SIM1"`
+
+	// SIM1HourlyLimit is the maximum number of messages sent with SIM1 in an hour, 0 means unlimited
+	SIM1HourlyLimit uint `json:"sim1_hourly_limit" example:"100"`
+
+	// SIM1DailyLimit is the maximum number of messages sent with SIM1 in a day, 0 means unlimited
+	SIM1DailyLimit uint `json:"sim1_daily_limit" example:"1000"`
+
+	// SIM2HourlyLimit is the maximum number of messages sent with SIM2 in an hour, 0 means unlimited
+	SIM2HourlyLimit uint `json:"sim2_hourly_limit" example:"100"`
+
+	// SIM2DailyLimit is the maximum number of messages sent with SIM2 in a day, 0 means unlimited
+	SIM2DailyLimit uint `json:"sim2_daily_limit" example:"1000"`
+
+	// MaxInFlightMessages is the maximum number of messages which the phone is notified about before it reports them as sent, 0 means unlimited.
+	MaxInFlightMessages uint `json:"max_in_flight_messages" example:"1"`
+
 	// MessageExpirationSeconds is the duration in seconds after sending a message when it is considered to be expired.
 	MessageExpirationSeconds uint `json:"message_expiration_seconds"`
 
+	// DeliveryReportTimeoutSeconds is the duration in seconds after a message is sent when it is considered to have an unknown delivery status.
+	DeliveryReportTimeoutSeconds uint `json:"delivery_report_timeout_seconds" example:"86400"`
+
+	// DuplicateCollapseDisabled stops collapsing identical messages which the carrier delivers more than once.
+	DuplicateCollapseDisabled bool `json:"duplicate_collapse_disabled" example:"false"`
+
+	// ClockOffsetMilliseconds is the estimated difference between the server time and the clock of the phone which is added to timestamps reported by the phone.
+	ClockOffsetMilliseconds int64 `json:"clock_offset_milliseconds" example:"0"`
+
+	// ClockOffsetUpdatedAt is when the last clock sample was added
+	ClockOffsetUpdatedAt *time.Time `json:"clock_offset_updated_at" example:"2022-06-05T14:26:10.303278+03:00"`
+
+	// ClockSamples are the samples within the clock sample window which can still be the smallest offset, the oldest first
+	ClockSamples datatypes.JSONType[[]PhoneClockSample] `json:"-" gorm:"default:'[]'"`
+
+	// WarmupStartedAt is when the sending rate of the phone started ramping up, it is nil when warm-up is disabled.
+	WarmupStartedAt *time.Time `json:"warmup_started_at" example:"2022-06-05T14:26:10.303278+03:00"`
+
+	// WarmupSchedule is the maximum number of messages sent on each day of the warm-up, the phone sends at MessagesPerMinute after the last day.
+	WarmupSchedule pq.Int64Array `json:"warmup_schedule" gorm:"type:bigint[]" swaggertype:"array,integer" example:"50,100,200,400"`
+
+	// PaceFactor is the fraction of the configured sending rate which the phone currently uses, it drops when the phone reports failures or slow sends.
+	PaceFactor float64 `json:"pace_factor" gorm:"default:1" example:"1"`
+
+	// PaceFailureRate is the moving average of the failure rate reported by the phone
+	PaceFailureRate float64 `json:"pace_failure_rate" example:"0.05"`
+
+	// PaceSendDurationSeconds is the moving average of the duration between notifying the phone and the phone sending the message
+	PaceSendDurationSeconds float64 `json:"pace_send_duration_seconds" example:"4.5"`
+
+	// PaceUpdatedAt is when the pace of the phone was last adjusted
+	PaceUpdatedAt *time.Time `json:"pace_updated_at" example:"2022-06-05T14:26:10.303278+03:00"`
+
 	CreatedAt time.Time `json:"created_at" example:"2022-06-05T14:26:02.302718+03:00"`
 	UpdatedAt time.Time `json:"updated_at" example:"2022-06-05T14:26:10.303278+03:00"`
 }
 
+const (
+	// phonePaceMinFactor is the lowest fraction of the sending rate a phone is slowed down to
+	phonePaceMinFactor = 0.1
+	// phonePaceRecovery is added to the pace factor after each healthy send
+	phonePaceRecovery = 0.05
+	// phonePaceSmoothing is the weight of the latest send in the moving averages
+	phonePaceSmoothing = 0.2
+	// phonePaceMaxFailureRate is the failure rate above which the phone is slowed down
+	phonePaceMaxFailureRate = 0.2
+	// phonePaceMaxSendDuration is the send duration above which the phone is slowed down
+	phonePaceMaxSendDuration = 30 * time.Second
+)
+
+// DefaultWarmupSchedule ramps a new SIM from 50 messages on the first day by 50% each day for 2 weeks
+func DefaultWarmupSchedule() []int64 {
+	schedule := make([]int64, 0, 14)
+	limit := 50.0
+	for day := 0; day < 14; day++ {
+		schedule = append(schedule, int64(limit))
+		limit *= 1.5
+	}
+	return schedule
+}
+
+// WarmupDailyLimit returns the maximum number of messages the phone can send on the current day of the warm-up
+func (phone *Phone) WarmupDailyLimit(now time.Time) (uint, bool) {
+	if phone.WarmupStartedAt == nil || len(phone.WarmupSchedule) == 0 || now.Before(*phone.WarmupStartedAt) {
+		return 0, false
+	}
+
+	day := int(now.Sub(*phone.WarmupStartedAt) / (24 * time.Hour))
+	if day >= len(phone.WarmupSchedule) || phone.WarmupSchedule[day] <= 0 {
+		return 0, false
+	}
+
+	return uint(phone.WarmupSchedule[day]), true
+}
+
+// SendInterval is the minimum duration between 2 messages sent by the phone, 0 means messages are not rate limited
+func (phone *Phone) SendInterval(now time.Time) time.Duration {
+	var interval time.Duration
+	if phone.MessagesPerMinute > 0 {
+		interval = time.Duration(60/phone.MessagesPerMinute) * time.Second
+	}
+
+	if limit, ok := phone.WarmupDailyLimit(now); ok && (24*time.Hour)/time.Duration(limit) > interval {
+		interval = (24 * time.Hour) / time.Duration(limit)
+	}
+
+	if phone.PaceFactor > 0 && phone.PaceFactor < 1 {
+		// a phone which is not rate limited is paced from 1 message per second
+		if interval == 0 {
+			interval = time.Second
+		}
+		interval = time.Duration(float64(interval) / phone.PaceFactor)
+	}
+
+	return interval
+}
+
+// Pace returns the fraction of the configured sending rate used by the phone
+func (phone *Phone) Pace() float64 {
+	if phone.PaceFactor <= 0 || phone.PaceFactor > 1 {
+		return 1
+	}
+	return phone.PaceFactor
+}
+
+// UpdatePace adjusts the pace of the phone after it sends or fails to send a message.
+// The pace is halved when the phone is unhealthy and recovers slowly after each healthy send.
+func (phone *Phone) UpdatePace(failed bool, sendDuration time.Duration, now time.Time) *Phone {
+	failure := 0.0
+	if failed {
+		failure = 1
+	}
+	phone.PaceFailureRate = phonePaceSmoothing*failure + (1-phonePaceSmoothing)*phone.PaceFailureRate
+
+	if !failed && sendDuration > 0 {
+		phone.PaceSendDurationSeconds = phonePaceSmoothing*sendDuration.Seconds() + (1-phonePaceSmoothing)*phone.PaceSendDurationSeconds
+	}
+
+	pace := phone.Pace()
+	if phone.PaceFailureRate > phonePaceMaxFailureRate || phone.PaceSendDurationSeconds > phonePaceMaxSendDuration.Seconds() {
+		pace = math.Max(phonePaceMinFactor, pace/2)
+	} else {
+		pace = math.Min(1, pace+phonePaceRecovery)
+	}
+
+	phone.PaceFactor = pace
+	phone.PaceUpdatedAt = &now
+	return phone
+}
+
+// SIMLimits returns the hourly and daily send limits of a SIM card, 0 means unlimited
+func (phone *Phone) SIMLimits(sim SIM) (hourly uint, daily uint) {
+	switch sim {
+	case SIM1:
+		return phone.SIM1HourlyLimit, phone.SIM1DailyLimit
+	case SIM2:
+		return phone.SIM2HourlyLimit, phone.SIM2DailyLimit
+	default:
+		return 0, 0
+	}
+}
+
+// IsSIMBalanced checks if messages sent with SIMDefault are spread across the SIM cards of the phone
+func (phone *Phone) IsSIMBalanced() bool {
+	return phone.IsDualSIM && phone.SIMBalancing != "" && phone.SIMBalancing != PhoneSIMBalancingNone
+}
+
+// RoundRobinSIMs returns the SIM cards in the order they are tried for the next round-robin message
+func (phone *Phone) RoundRobinSIMs() []SIM {
+	if phone.LastBalancedSIM == SIM1 {
+		return []SIM{SIM2, SIM1}
+	}
+	return []SIM{SIM1, SIM2}
+}
+
+// IsKillSwitchEnabled checks if the phone is stopped from sending messages
+func (phone *Phone) IsKillSwitchEnabled() bool {
+	return phone.KillSwitchEnabledAt != nil
+}
+
 // MessageExpirationDuration returns the message expiration as time.Duration
 func (phone *Phone) MessageExpirationDuration() time.Duration {
 	return time.Duration(phone.MessageExpirationSeconds) * time.Second
@@ -38,6 +272,22 @@ func (phone *Phone) MessageExpirationSecondsSanitized() uint {
 	return phone.MessageExpirationSeconds
 }
 
+// DeliveryReportTimeoutDuration returns the delivery report timeout as time.Duration with a default of 24 hours
+func (phone *Phone) DeliveryReportTimeoutDuration() time.Duration {
+	if phone.DeliveryReportTimeoutSeconds == 0 {
+		return 24 * time.Hour
+	}
+	return time.Duration(phone.DeliveryReportTimeoutSeconds) * time.Second
+}
+
+// DuplicateMessageWindow is the duration in which an identical received message is considered a carrier duplicate
+func (phone *Phone) DuplicateMessageWindow() time.Duration {
+	if phone.DuplicateCollapseDisabled {
+		return 0
+	}
+	return 2 * time.Minute
+}
+
 // MaxSendAttemptsSanitized returns the max send attempts replacing 0 with 1
 func (phone *Phone) MaxSendAttemptsSanitized() uint {
 	if phone.MaxSendAttempts == 0 {
@@ -45,3 +295,58 @@ func (phone *Phone) MaxSendAttemptsSanitized() uint {
 	}
 	return phone.MaxSendAttempts
 }
+
+const (
+	// phoneClockSampleWindow is how long a clock sample is used to estimate the clock offset of a phone
+	phoneClockSampleWindow = time.Hour
+
+	// phoneClockSampleLimit is the maximum number of clock samples which are stored for a phone
+	phoneClockSampleLimit = 60
+)
+
+// PhoneClockSample is the difference between the server time and the clock of a phone when a heartbeat was received
+type PhoneClockSample struct {
+	Timestamp          time.Time `json:"timestamp"`
+	OffsetMilliseconds int64     `json:"offset_milliseconds"`
+}
+
+// ClockOffset returns the clock offset as time.Duration
+func (phone *Phone) ClockOffset() time.Duration {
+	return time.Duration(phone.ClockOffsetMilliseconds) * time.Millisecond
+}
+
+// NormalizeTimestamp corrects a timestamp reported by the phone with the clock offset.
+// Offsets smaller than the tolerance are ignored because they are within the network latency.
+func (phone *Phone) NormalizeTimestamp(timestamp time.Time) time.Time {
+	if phone.ClockOffset() > -5*time.Second && phone.ClockOffset() < 5*time.Second {
+		return timestamp
+	}
+	return timestamp.Add(phone.ClockOffset())
+}
+
+// AddClockSample adds a clock sample of the time reported by the phone and the time it was received by the server.
+// Network and queueing delays only make the phone look behind so the smallest offset of the samples within the
+// sliding phoneClockSampleWindow is used as the estimate. It returns true when the estimate has changed.
+func (phone *Phone) AddClockSample(deviceTimestamp time.Time, serverTimestamp time.Time) bool {
+	offset := serverTimestamp.Sub(deviceTimestamp).Milliseconds()
+
+	// samples are kept in increasing order of offset so the oldest sample is the smallest offset in the window
+	samples := make([]PhoneClockSample, 0, len(phone.ClockSamples.Data)+1)
+	for _, sample := range phone.ClockSamples.Data {
+		if serverTimestamp.Sub(sample.Timestamp) < phoneClockSampleWindow && sample.OffsetMilliseconds < offset {
+			samples = append(samples, sample)
+		}
+	}
+
+	samples = append(samples, PhoneClockSample{Timestamp: serverTimestamp, OffsetMilliseconds: offset})
+	if len(samples) > phoneClockSampleLimit {
+		samples = samples[len(samples)-phoneClockSampleLimit:]
+	}
+
+	changed := phone.ClockOffsetUpdatedAt == nil || phone.ClockOffsetMilliseconds != samples[0].OffsetMilliseconds
+
+	phone.ClockSamples = datatypes.JSONType[[]PhoneClockSample]{Data: samples}
+	phone.ClockOffsetMilliseconds = samples[0].OffsetMilliseconds
+	phone.ClockOffsetUpdatedAt = &serverTimestamp
+	return changed
+}
@@ -0,0 +1,20 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// PhoneFailoverPolicy determines which phones send the outstanding messages of an owner when its phone stops sending heartbeats
+type PhoneFailoverPolicy struct {
+	ID     uuid.UUID `json:"id" gorm:"primaryKey;type:uuid;" example:"32343a19-da5e-4b1b-a767-3298a73703cb"`
+	UserID UserID    `json:"user_id" gorm:"uniqueIndex:idx_phone_failover_policies__user_id__owner" example:"WB7DRDWrJZRGbYrv2CKGkqbzvqdC"`
+	Owner  string    `json:"owner" gorm:"uniqueIndex:idx_phone_failover_policies__user_id__owner" example:"+18005550199"`
+
+	// BackupOwners are the phone numbers which can send the messages of the Owner in order of preference
+	BackupOwners pq.StringArray `json:"backup_owners" gorm:"type:text[]" swaggertype:"array,string" example:"+18005550100"`
+	CreatedAt    time.Time      `json:"created_at" example:"2022-06-05T14:26:02.302718+03:00"`
+	UpdatedAt    time.Time      `json:"updated_at" example:"2022-06-05T14:26:10.303278+03:00"`
+}
@@ -13,6 +13,8 @@ const (
 	PhoneNotificationStatusSent = "sent"
 	// PhoneNotificationStatusFailed is the status when a notification could not be sent.
 	PhoneNotificationStatusFailed = "failed"
+	// PhoneNotificationStatusWaiting is the status when a notification is held back because the phone has too many messages in flight
+	PhoneNotificationStatusWaiting = "waiting"
 )
 
 // PhoneNotificationStatus is the status of a phone notification
@@ -20,12 +22,14 @@ type PhoneNotificationStatus string
 
 // PhoneNotification represents an FCM notification to a mobile phone
 type PhoneNotification struct {
-	ID          uuid.UUID `json:"id" gorm:"primaryKey;type:uuid;"`
-	MessageID   uuid.UUID `json:"message_id"`
-	UserID      UserID    `json:"user_id"`
-	PhoneID     uuid.UUID `json:"phone_id"`
-	Status      string    `json:"status"`
-	ScheduledAt time.Time `json:"scheduled_at"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ID        uuid.UUID `json:"id" gorm:"primaryKey;type:uuid;"`
+	MessageID uuid.UUID `json:"message_id"`
+	UserID    UserID    `json:"user_id"`
+	PhoneID   uuid.UUID `json:"phone_id"`
+	Status    string    `json:"status"`
+	// Priority is copied from the message so that waiting notifications are released in order of priority
+	Priority    MessagePriority `json:"priority" gorm:"default:normal"`
+	ScheduledAt time.Time       `json:"scheduled_at"`
+	CreatedAt   time.Time       `json:"created_at"`
+	UpdatedAt   time.Time       `json:"updated_at"`
 }
@@ -0,0 +1,36 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PhoneRegistration is a device which registered a phone or an FCM token on an account.
+// The user is emailed a link with the revoke token to remove the device when they did not register it.
+type PhoneRegistration struct {
+	ID         uuid.UUID `json:"id" gorm:"primaryKey;type:uuid;" example:"32343a19-da5e-4b1b-a767-3298a73703cb"`
+	UserID     UserID    `json:"user_id" gorm:"index" example:"WB7DRDWrJZRGbYrv2CKGkqbzvqdC"`
+	PhoneID    uuid.UUID `json:"phone_id" gorm:"type:uuid;" example:"32343a19-da5e-4b1b-a767-3298a73703cb"`
+	Owner      string    `json:"owner" example:"+18005550199"`
+	IsNewPhone bool      `json:"is_new_phone" example:"false"`
+
+	// FcmTokenHash identifies the device so that revoking an old registration does not remove a device which registered later
+	FcmTokenHash    *string `json:"-"`
+	RevokeTokenHash string  `json:"-" gorm:"uniqueIndex"`
+
+	ExpiresAt time.Time  `json:"expires_at" example:"2022-06-12T14:26:02.302718+03:00"`
+	RevokedAt *time.Time `json:"revoked_at" example:"2022-06-05T15:26:02.302718+03:00"`
+	CreatedAt time.Time  `json:"created_at" example:"2022-06-05T14:26:02.302718+03:00"`
+	UpdatedAt time.Time  `json:"updated_at" example:"2022-06-05T14:26:10.303278+03:00"`
+}
+
+// IsRevoked checks if the device of the registration was revoked
+func (registration *PhoneRegistration) IsRevoked() bool {
+	return registration.RevokedAt != nil
+}
+
+// IsExpired checks if the revoke token can no longer be used
+func (registration *PhoneRegistration) IsExpired(now time.Time) bool {
+	return !now.Before(registration.ExpiresAt)
+}
@@ -0,0 +1,184 @@
+package entities
+
+import (
+	"math"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/google/uuid"
+)
+
+// ReplySentiment is the tone of a message received from a contact
+type ReplySentiment string
+
+const (
+	// ReplySentimentNeutral is a reply which does not hurt the reputation of the phone
+	ReplySentimentNeutral = ReplySentiment("neutral")
+
+	// ReplySentimentNegative is a reply where the contact does not want to receive more messages
+	ReplySentimentNegative = ReplySentiment("negative")
+
+	// ReplySentimentComplaint is a reply where the contact complains about spam or is abusive, carriers block numbers with many complaints
+	ReplySentimentComplaint = ReplySentiment("complaint")
+)
+
+// replyComplaintPhrases are phrases of contacts who consider the messages to be spam
+var replyComplaintPhrases = []string{
+	"stop spamming", "spam", "spammer", "stop texting me", "stop messaging me", "stop sending me",
+	"unsolicited", "reported", "report you", "reporting you", "harass", "leave me alone", "do not text", "dont text", "don't text",
+}
+
+// replyProfanityPrefixes are the prefixes of abusive words
+var replyProfanityPrefixes = []string{"fuck", "shit", "bitch", "asshole", "bastard", "wtf", "stfu", "dickhead", "motherf"}
+
+// replyNegativePhrases are phrases of contacts who do not want to receive more messages
+var replyNegativePhrases = []string{
+	"not interested", "no thanks", "no thank you", "remove me", "take me off", "wrong number", "who is this", "annoying", "too many messages",
+}
+
+// ClassifyReply detects the ReplySentiment of the content of a received message using keywords
+func ClassifyReply(content string) ReplySentiment {
+	text := strings.ToLower(strings.Join(strings.FieldsFunc(content, func(r rune) bool {
+		return !unicode.IsLetter(r) && r != '\''
+	}), " "))
+
+	for _, word := range strings.Fields(text) {
+		for _, prefix := range replyProfanityPrefixes {
+			if strings.HasPrefix(word, prefix) {
+				return ReplySentimentComplaint
+			}
+		}
+	}
+
+	padded := " " + text + " "
+	for _, phrase := range replyComplaintPhrases {
+		if strings.Contains(padded, " "+phrase+" ") {
+			return ReplySentimentComplaint
+		}
+	}
+
+	if IsOptOutKeyword(content) {
+		return ReplySentimentNegative
+	}
+
+	for _, phrase := range replyNegativePhrases {
+		if strings.Contains(padded, " "+phrase+" ") {
+			return ReplySentimentNegative
+		}
+	}
+
+	return ReplySentimentNeutral
+}
+
+// PhoneReputationRisk is the risk that carriers block the phone number because of the complaints of contacts
+type PhoneReputationRisk string
+
+const (
+	// PhoneReputationRiskLow means that contacts rarely complain about the messages
+	PhoneReputationRiskLow = PhoneReputationRisk("low")
+
+	// PhoneReputationRiskMedium means that the complaints are high enough for carriers to start filtering messages
+	PhoneReputationRiskMedium = PhoneReputationRisk("medium")
+
+	// PhoneReputationRiskHigh means that the phone number is likely to be blocked by carriers
+	PhoneReputationRiskHigh = PhoneReputationRisk("high")
+)
+
+// IsWorseThan checks if the PhoneReputationRisk is higher than another risk
+func (risk PhoneReputationRisk) IsWorseThan(other PhoneReputationRisk) bool {
+	ranks := map[PhoneReputationRisk]int{PhoneReputationRiskLow: 0, PhoneReputationRiskMedium: 1, PhoneReputationRiskHigh: 2}
+	return ranks[risk] > ranks[other]
+}
+
+// PhoneReputationWindow is the period used to compute the score of a PhoneReputation, the trend compares it with the period before
+const PhoneReputationWindow = 7 * 24 * time.Hour
+
+// PhoneReputationDay counts the messages sent by an owner on a day and the sentiment of the replies
+type PhoneReputationDay struct {
+	ID     uuid.UUID `json:"id" gorm:"primaryKey;type:uuid;" example:"32343a19-da5e-4b1b-a767-3298a73703cb"`
+	UserID UserID    `json:"user_id" gorm:"uniqueIndex:idx_phone_reputation_days__user_id__owner__date" example:"WB7DRDWrJZRGbYrv2CKGkqbzvqdC"`
+	Owner  string    `json:"owner" gorm:"uniqueIndex:idx_phone_reputation_days__user_id__owner__date" example:"+18005550199"`
+
+	// Date is the start of the day in UTC
+	Date time.Time `json:"date" gorm:"uniqueIndex:idx_phone_reputation_days__user_id__owner__date" example:"2022-06-05T00:00:00Z"`
+
+	Sent       uint `json:"sent" example:"120"`
+	Replies    uint `json:"replies" example:"30"`
+	Negative   uint `json:"negative" example:"3"`
+	Complaints uint `json:"complaints" example:"1"`
+
+	CreatedAt time.Time `json:"created_at" example:"2022-06-05T14:26:02.302718+03:00"`
+	UpdatedAt time.Time `json:"updated_at" example:"2022-06-05T14:26:10.303278+03:00"`
+}
+
+// PhoneReputation is the score of an owner phone number based on the sentiment of the replies to its messages.
+// The Score and Risk are stored so that an alert is sent only when the risk increases.
+type PhoneReputation struct {
+	ID     uuid.UUID `json:"id" gorm:"primaryKey;type:uuid;" example:"32343a19-da5e-4b1b-a767-3298a73703cb"`
+	UserID UserID    `json:"user_id" gorm:"uniqueIndex:idx_phone_reputations__user_id__owner" example:"WB7DRDWrJZRGbYrv2CKGkqbzvqdC"`
+	Owner  string    `json:"owner" gorm:"uniqueIndex:idx_phone_reputations__user_id__owner" example:"+18005550199"`
+
+	// Score is between 0 and 100, a higher score is better
+	Score int                 `json:"score" example:"92"`
+	Risk  PhoneReputationRisk `json:"risk" example:"low"`
+
+	// PreviousScore is the score in the PhoneReputationWindow before the current window
+	PreviousScore int `json:"previous_score" gorm:"-" example:"96"`
+
+	// Trend is the change of the score compared to the PreviousScore
+	Trend int `json:"trend" gorm:"-" example:"-4"`
+
+	Sent       uint                  `json:"sent" gorm:"-" example:"250"`
+	Replies    uint                  `json:"replies" gorm:"-" example:"40"`
+	Negative   uint                  `json:"negative" gorm:"-" example:"3"`
+	Complaints uint                  `json:"complaints" gorm:"-" example:"1"`
+	Days       []*PhoneReputationDay `json:"days" gorm:"-"`
+
+	CreatedAt time.Time `json:"created_at" example:"2022-06-05T14:26:02.302718+03:00"`
+	UpdatedAt time.Time `json:"updated_at" example:"2022-06-05T14:26:10.303278+03:00"`
+}
+
+// Compute sets the score, trend and risk of the PhoneReputation from the PhoneReputationDay of the current and the previous PhoneReputationWindow
+func (reputation *PhoneReputation) Compute(windowStart time.Time, days []*PhoneReputationDay) *PhoneReputation {
+	reputation.Days = days
+	reputation.Sent, reputation.Replies, reputation.Negative, reputation.Complaints = 0, 0, 0, 0
+
+	var previousSent, previousNegative, previousComplaints uint
+	for _, day := range days {
+		if day.Date.Before(windowStart) {
+			previousSent += day.Sent
+			previousNegative += day.Negative
+			previousComplaints += day.Complaints
+			continue
+		}
+		reputation.Sent += day.Sent
+		reputation.Replies += day.Replies
+		reputation.Negative += day.Negative
+		reputation.Complaints += day.Complaints
+	}
+
+	reputation.Score = reputation.score(reputation.Sent, reputation.Negative, reputation.Complaints)
+	reputation.PreviousScore = reputation.score(previousSent, previousNegative, previousComplaints)
+	reputation.Trend = reputation.Score - reputation.PreviousScore
+
+	switch {
+	case reputation.Score < 50:
+		reputation.Risk = PhoneReputationRiskHigh
+	case reputation.Score < 80:
+		reputation.Risk = PhoneReputationRiskMedium
+	default:
+		reputation.Risk = PhoneReputationRiskLow
+	}
+
+	return reputation
+}
+
+// score weighs complaints twice as much as negative replies, a complaint rate of 1% of the sent messages reduces the score by 20 points
+func (reputation *PhoneReputation) score(sent uint, negative uint, complaints uint) int {
+	if sent == 0 {
+		sent = 1
+	}
+	rate := (float64(complaints) + float64(negative)/2) / float64(sent)
+	return int(math.Max(0, 100-math.Round(rate*2000)))
+}
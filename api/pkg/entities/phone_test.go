@@ -0,0 +1,141 @@
+package entities
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPhone_AddClockSample(t *testing.T) {
+	start := time.Date(2022, 6, 5, 14, 0, 0, 0, time.UTC)
+
+	t.Run("first sample sets the clock offset", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+		phone := &Phone{}
+
+		// Act
+		changed := phone.AddClockSample(start.Add(-10*time.Second), start)
+
+		// Assert
+		assert.True(t, changed)
+		assert.Equal(t, int64(10_000), phone.ClockOffsetMilliseconds)
+		assert.Equal(t, start, *phone.ClockOffsetUpdatedAt)
+	})
+
+	t.Run("larger offset within the window does not change the clock offset", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+		phone := &Phone{}
+		phone.AddClockSample(start.Add(-10*time.Second), start)
+
+		// Act
+		changed := phone.AddClockSample(start.Add(20*time.Minute).Add(-12*time.Second), start.Add(20*time.Minute))
+
+		// Assert
+		assert.False(t, changed)
+		assert.Equal(t, int64(10_000), phone.ClockOffsetMilliseconds)
+	})
+
+	t.Run("smaller offset replaces the clock offset", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+		phone := &Phone{}
+		phone.AddClockSample(start.Add(-10*time.Second), start)
+
+		// Act
+		changed := phone.AddClockSample(start.Add(20*time.Minute).Add(-8*time.Second), start.Add(20*time.Minute))
+
+		// Assert
+		assert.True(t, changed)
+		assert.Equal(t, int64(8_000), phone.ClockOffsetMilliseconds)
+	})
+
+	t.Run("minimum slides to the smallest offset of the samples within the window", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+		phone := &Phone{}
+		phone.AddClockSample(start.Add(-10*time.Second), start)
+		phone.AddClockSample(start.Add(30*time.Minute).Add(-14*time.Second), start.Add(30*time.Minute))
+		phone.AddClockSample(start.Add(50*time.Minute).Add(-12*time.Second), start.Add(50*time.Minute))
+
+		// Act
+		changed := phone.AddClockSample(start.Add(70*time.Minute).Add(-16*time.Second), start.Add(70*time.Minute))
+
+		// Assert
+		assert.True(t, changed)
+		assert.Equal(t, int64(12_000), phone.ClockOffsetMilliseconds)
+		assert.Equal(t, 2, len(phone.ClockSamples.Data))
+	})
+
+	t.Run("estimate is not reset when the window has passed", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+		phone := &Phone{}
+		phone.AddClockSample(start.Add(-10*time.Second), start)
+		phone.AddClockSample(start.Add(45*time.Minute).Add(-11*time.Second), start.Add(45*time.Minute))
+
+		// Act
+		phone.AddClockSample(start.Add(65*time.Minute).Add(-30*time.Second), start.Add(65*time.Minute))
+
+		// Assert
+		assert.Equal(t, int64(11_000), phone.ClockOffsetMilliseconds)
+	})
+
+	t.Run("number of samples is limited", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+		phone := &Phone{}
+
+		// Act
+		for i := 0; i < phoneClockSampleLimit+10; i++ {
+			timestamp := start.Add(time.Duration(i) * time.Second)
+			phone.AddClockSample(timestamp.Add(-time.Duration(i)*time.Millisecond), timestamp)
+		}
+
+		// Assert
+		assert.Equal(t, phoneClockSampleLimit, len(phone.ClockSamples.Data))
+		assert.Equal(t, int64(10), phone.ClockOffsetMilliseconds)
+	})
+}
+
+func TestPhone_NormalizeTimestamp(t *testing.T) {
+	timestamp := time.Date(2022, 6, 5, 14, 0, 0, 0, time.UTC)
+
+	t.Run("offset within the tolerance is ignored", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+		phone := &Phone{ClockOffsetMilliseconds: 4_999}
+
+		// Act
+		normalized := phone.NormalizeTimestamp(timestamp)
+
+		// Assert
+		assert.Equal(t, timestamp, normalized)
+	})
+
+	t.Run("phone which is behind is moved forward", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+		phone := &Phone{ClockOffsetMilliseconds: 60_000}
+
+		// Act
+		normalized := phone.NormalizeTimestamp(timestamp)
+
+		// Assert
+		assert.Equal(t, timestamp.Add(time.Minute), normalized)
+	})
+
+	t.Run("phone which is ahead is moved backward", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+		phone := &Phone{ClockOffsetMilliseconds: -60_000}
+
+		// Act
+		normalized := phone.NormalizeTimestamp(timestamp)
+
+		// Assert
+		assert.Equal(t, timestamp.Add(-time.Minute), normalized)
+	})
+}
@@ -0,0 +1,51 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PlanOverrideType is the reason why the plan of a user was overridden
+type PlanOverrideType string
+
+const (
+	// PlanOverrideTypeTrial is an extended trial which was granted by an operator
+	PlanOverrideTypeTrial = PlanOverrideType("trial")
+
+	// PlanOverrideTypeCoupon is created when a user redeems a Coupon
+	PlanOverrideTypeCoupon = PlanOverrideType("coupon")
+
+	// PlanOverrideTypeOverride is a manual change of the plan of a user by an operator
+	PlanOverrideTypeOverride = PlanOverrideType("override")
+
+	// PlanOverrideTypeReferral is the upgraded plan earned by referring a new user
+	PlanOverrideTypeReferral = PlanOverrideType("referral")
+)
+
+// PlanOverride replaces the SubscriptionName of a user when enforcing the usage limit until EndsAt
+type PlanOverride struct {
+	ID               uuid.UUID        `json:"id" gorm:"primaryKey;type:uuid;" example:"32343a19-da5e-4b1b-a767-3298a73703cb"`
+	UserID           UserID           `json:"user_id" gorm:"index;uniqueIndex:idx_plan_overrides__user_id__coupon_id" example:"WB7DRDWrJZRGbYrv2CKGkqbzvqdC"`
+	Type             PlanOverrideType `json:"type" example:"trial"`
+	SubscriptionName SubscriptionName `json:"subscription_name" example:"pro-monthly"`
+
+	// MessageLimit replaces the monthly limit of SubscriptionName when it is set
+	MessageLimit *uint `json:"message_limit" example:"5000"`
+
+	// CouponID is the Coupon which was redeemed, a coupon can be redeemed only once per user
+	CouponID  *uuid.UUID `json:"coupon_id" gorm:"type:uuid;uniqueIndex:idx_plan_overrides__user_id__coupon_id" example:"32343a19-da5e-4b1b-a767-3298a73703cc"`
+	StartsAt  time.Time  `json:"starts_at" example:"2022-06-05T14:26:02.302718+03:00"`
+	EndsAt    *time.Time `json:"ends_at" example:"2022-07-05T14:26:02.302718+03:00"`
+	CreatedBy *UserID    `json:"created_by" example:"WB7DRDWrJZRGbYrv2CKGkqbzvqdC"`
+	CreatedAt time.Time  `json:"created_at" example:"2022-06-05T14:26:02.302718+03:00"`
+	UpdatedAt time.Time  `json:"updated_at" example:"2022-06-05T14:26:10.303278+03:00"`
+}
+
+// Limit returns the monthly message limit of the override
+func (override *PlanOverride) Limit() uint {
+	if override.MessageLimit != nil {
+		return *override.MessageLimit
+	}
+	return override.SubscriptionName.Limit()
+}
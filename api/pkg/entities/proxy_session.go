@@ -0,0 +1,77 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ProxySessionStatus is the status of a proxy session
+type ProxySessionStatus string
+
+const (
+	// ProxySessionStatusActive means messages are relayed between the parties
+	ProxySessionStatusActive = ProxySessionStatus("active")
+
+	// ProxySessionStatusExpired means the session reached its expiry time
+	ProxySessionStatusExpired = ProxySessionStatus("expired")
+
+	// ProxySessionStatusClosed means the session was closed by the user
+	ProxySessionStatusClosed = ProxySessionStatus("closed")
+)
+
+// ProxySession links 2 external parties through an owner phone number so that they can text each other without knowing each other's number
+type ProxySession struct {
+	ID            uuid.UUID          `json:"id" gorm:"primaryKey;type:uuid;" example:"32343a19-da5e-4b1b-a767-3298a73703cb"`
+	UserID        UserID             `json:"user_id" gorm:"index:idx_proxy_sessions__user_id__owner" example:"WB7DRDWrJZRGbYrv2CKGkqbzvqdC"`
+	Owner         string             `json:"owner" gorm:"index:idx_proxy_sessions__user_id__owner" example:"+18005550199"`
+	FirstParty    string             `json:"first_party" example:"+18005550100"`
+	SecondParty   string             `json:"second_party" example:"+18005550101"`
+	Status        ProxySessionStatus `json:"status" example:"active"`
+	ExpiresAt     time.Time          `json:"expires_at" example:"2022-06-05T14:26:02.302718+03:00"`
+	ClosedAt      *time.Time         `json:"closed_at" example:"2022-06-05T14:26:09.527976+03:00"`
+	LastMessageAt *time.Time         `json:"last_message_at" example:"2022-06-05T14:26:09.527976+03:00"`
+	CreatedAt     time.Time          `json:"created_at" example:"2022-06-05T14:26:02.302718+03:00"`
+	UpdatedAt     time.Time          `json:"updated_at" example:"2022-06-05T14:26:10.303278+03:00"`
+}
+
+// IsActive checks if messages can still be relayed through the session
+func (session *ProxySession) IsActive(timestamp time.Time) bool {
+	return session.Status == ProxySessionStatusActive && timestamp.Before(session.ExpiresAt)
+}
+
+// OtherParty returns the party which should receive a message sent by the contact
+func (session *ProxySession) OtherParty(contact string) string {
+	if contact == session.FirstParty {
+		return session.SecondParty
+	}
+	return session.FirstParty
+}
+
+// Expire registers the session as expired
+func (session *ProxySession) Expire(timestamp time.Time) *ProxySession {
+	session.Status = ProxySessionStatusExpired
+	session.ClosedAt = &timestamp
+	return session
+}
+
+// Close registers the session as closed
+func (session *ProxySession) Close(timestamp time.Time) *ProxySession {
+	session.Status = ProxySessionStatusClosed
+	session.ClosedAt = &timestamp
+	return session
+}
+
+// ProxySessionMessage is a transcript entry of a message relayed through an entities.ProxySession
+type ProxySessionMessage struct {
+	ID                uuid.UUID `json:"id" gorm:"primaryKey;type:uuid;" example:"32343a19-da5e-4b1b-a767-3298a73703cb"`
+	SessionID         uuid.UUID `json:"session_id" gorm:"index:idx_proxy_session_messages__session_id" example:"32343a19-da5e-4b1b-a767-3298a73703cb"`
+	UserID            UserID    `json:"user_id" example:"WB7DRDWrJZRGbYrv2CKGkqbzvqdC"`
+	From              string    `json:"from" example:"+18005550100"`
+	To                string    `json:"to" example:"+18005550101"`
+	Content           string    `json:"content" example:"This is a sample text message"`
+	InboundMessageID  uuid.UUID `json:"inbound_message_id" example:"32343a19-da5e-4b1b-a767-3298a73703cb"`
+	OutboundMessageID uuid.UUID `json:"outbound_message_id" example:"32343a19-da5e-4b1b-a767-3298a73703cb"`
+	Timestamp         time.Time `json:"timestamp" example:"2022-06-05T14:26:09.527976+03:00"`
+	CreatedAt         time.Time `json:"created_at" example:"2022-06-05T14:26:02.302718+03:00"`
+}
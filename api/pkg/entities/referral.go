@@ -0,0 +1,69 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ReferralCode is the code which a user shares so that new users are attributed to them when they sign up
+type ReferralCode struct {
+	UserID    UserID    `json:"user_id" gorm:"primaryKey;type:string;" example:"WB7DRDWrJZRGbYrv2CKGkqbzvqdC"`
+	Code      string    `json:"code" gorm:"uniqueIndex" example:"K7M2Q9XP"`
+	CreatedAt time.Time `json:"created_at" example:"2022-06-05T14:26:02.302718+03:00"`
+}
+
+// ReferralRewardType is how the referrer is rewarded
+type ReferralRewardType string
+
+const (
+	// ReferralRewardTypeCredits adds bonus credits to the prepaid balance of the referrer
+	ReferralRewardTypeCredits = ReferralRewardType("credits")
+
+	// ReferralRewardTypeDiscount upgrades the plan of the referrer for a number of days
+	ReferralRewardTypeDiscount = ReferralRewardType("discount")
+)
+
+// ReferralStatus is the state of the reward of a Referral
+type ReferralStatus string
+
+const (
+	// ReferralStatusPending means the referred user has not qualified for the reward yet
+	ReferralStatusPending = ReferralStatus("pending")
+
+	// ReferralStatusRewarded means the reward was given to the referrer
+	ReferralStatusRewarded = ReferralStatus("rewarded")
+)
+
+// Referral attributes a new user to the user who referred them
+type Referral struct {
+	ID uuid.UUID `json:"id" gorm:"primaryKey;type:uuid;" example:"32343a19-da5e-4b1b-a767-3298a73703cb"`
+
+	// UserID is the referrer who receives the reward
+	UserID UserID `json:"user_id" gorm:"index" example:"WB7DRDWrJZRGbYrv2CKGkqbzvqdC"`
+
+	// ReferredUserID is the new user, a user can be referred only once
+	ReferredUserID UserID             `json:"referred_user_id" gorm:"uniqueIndex" example:"XB7DRDWrJZRGbYrv2CKGkqbzvqdD"`
+	Code           string             `json:"code" example:"K7M2Q9XP"`
+	Status         ReferralStatus     `json:"status" example:"pending"`
+	RewardType     ReferralRewardType `json:"reward_type" example:"credits"`
+
+	// RewardCredits is the number of credits given for ReferralRewardTypeCredits
+	RewardCredits int64 `json:"reward_credits" example:"100"`
+
+	// RewardDays is the duration of the upgraded plan for ReferralRewardTypeDiscount
+	RewardDays uint       `json:"reward_days" example:"0"`
+	RewardedAt *time.Time `json:"rewarded_at" example:"2022-06-05T14:26:10.303278+03:00"`
+	CreatedAt  time.Time  `json:"created_at" example:"2022-06-05T14:26:02.302718+03:00"`
+	UpdatedAt  time.Time  `json:"updated_at" example:"2022-06-05T14:26:10.303278+03:00"`
+}
+
+// ReferralStats summarises the referrals and rewards of a user
+type ReferralStats struct {
+	Code          string `json:"code" example:"K7M2Q9XP"`
+	Referrals     int64  `json:"referrals" example:"12"`
+	Pending       int64  `json:"pending" example:"4"`
+	Rewarded      int64  `json:"rewarded" example:"8"`
+	CreditsEarned int64  `json:"credits_earned" example:"800"`
+	DaysEarned    int64  `json:"days_earned" example:"0"`
+}
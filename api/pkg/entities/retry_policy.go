@@ -0,0 +1,58 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RetryPolicy determines how a message which failed with a MessageFailureCode is retried
+type RetryPolicy struct {
+	ID          uuid.UUID          `json:"id" gorm:"primaryKey;type:uuid;" example:"32343a19-da5e-4b1b-a767-3298a73703cb"`
+	UserID      UserID             `json:"user_id" gorm:"uniqueIndex:idx_retry_policies__user_id__failure_code" example:"WB7DRDWrJZRGbYrv2CKGkqbzvqdC"`
+	FailureCode MessageFailureCode `json:"failure_code" gorm:"uniqueIndex:idx_retry_policies__user_id__failure_code" example:"no-service"`
+
+	// MaxRetries is the number of times a failed message is retried, 0 means the message is never retried
+	MaxRetries uint `json:"max_retries" example:"3"`
+
+	// DelaySeconds is the duration in seconds to wait before retrying the message
+	DelaySeconds uint `json:"delay_seconds" example:"600"`
+
+	// IsDefault is true when the user has not configured a policy for the failure code
+	IsDefault bool      `json:"is_default" gorm:"-" example:"false"`
+	CreatedAt time.Time `json:"created_at" example:"2022-06-05T14:26:02.302718+03:00"`
+	UpdatedAt time.Time `json:"updated_at" example:"2022-06-05T14:26:10.303278+03:00"`
+}
+
+// Delay returns the DelaySeconds as time.Duration
+func (policy *RetryPolicy) Delay() time.Duration {
+	return time.Duration(policy.DelaySeconds) * time.Second
+}
+
+// CanRetry checks if a message which has already been retried can be retried again
+func (policy *RetryPolicy) CanRetry(retryCount uint) bool {
+	return retryCount < policy.MaxRetries
+}
+
+// DefaultRetryPolicy is the RetryPolicy used when a user has not configured one for the MessageFailureCode
+func DefaultRetryPolicy(userID UserID, code MessageFailureCode) *RetryPolicy {
+	policy := &RetryPolicy{
+		ID:          uuid.Nil,
+		UserID:      userID,
+		FailureCode: code,
+		IsDefault:   true,
+	}
+
+	switch code {
+	case MessageFailureCodeNoService, MessageFailureCodeRadioOff:
+		policy.MaxRetries, policy.DelaySeconds = 3, 10*60
+	case MessageFailureCodeGenericFailure:
+		policy.MaxRetries, policy.DelaySeconds = 1, 5*60
+	case MessageFailureCodeQuota:
+		policy.MaxRetries, policy.DelaySeconds = 2, 60*60
+	case MessageFailureCodeNotificationFailed:
+		policy.MaxRetries, policy.DelaySeconds = 2, 60
+	}
+
+	return policy
+}
@@ -0,0 +1,91 @@
+package entities
+
+import (
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RuleType is the trigger of an automation rule
+type RuleType string
+
+const (
+	// RuleTypeMissedCall sends a message to callers whose calls were missed on the owner phone
+	RuleTypeMissedCall = RuleType("missed-call")
+
+	// RuleTypeAutoReply replies to received messages which match the pattern of the rule
+	RuleTypeAutoReply = RuleType("auto-reply")
+)
+
+// RuleMatch is how the pattern of an auto reply rule is compared with a received message
+type RuleMatch string
+
+const (
+	// RuleMatchKeyword matches messages which are equal to the pattern ignoring the case and surrounding spaces
+	RuleMatchKeyword = RuleMatch("keyword")
+
+	// RuleMatchRegex matches messages which match the pattern as a regular expression
+	RuleMatchRegex = RuleMatch("regex")
+)
+
+// RuleDefaultCooldownMinutes is the cooldown of an auto reply rule when none is configured
+const RuleDefaultCooldownMinutes = 60
+
+// Rule is an automation which sends a message when it is triggered on an owner phone
+type Rule struct {
+	ID      uuid.UUID `json:"id" gorm:"primaryKey;type:uuid;" example:"32343a19-da5e-4b1b-a767-3298a73703cb"`
+	UserID  UserID    `json:"user_id" gorm:"index:idx_rules__user_id__owner" example:"WB7DRDWrJZRGbYrv2CKGkqbzvqdC"`
+	Owner   string    `json:"owner" gorm:"index:idx_rules__user_id__owner" example:"+18005550199"`
+	Type    RuleType  `json:"type" example:"missed-call"`
+	Content string    `json:"content" example:"Sorry we missed your call, how can we help you?"`
+	Enabled bool      `json:"enabled" example:"true"`
+
+	// Match and Pattern select the received messages which trigger an auto reply rule
+	Match   RuleMatch `json:"match,omitempty" example:"keyword"`
+	Pattern string    `json:"pattern,omitempty" example:"HELP"`
+
+	// CooldownMinutes is the minimum time between 2 auto replies to the same contact, it prevents reply loops with other bots
+	CooldownMinutes uint `json:"cooldown_minutes,omitempty" example:"60"`
+
+	CreatedAt time.Time `json:"created_at" example:"2022-06-05T14:26:02.302718+03:00"`
+	UpdatedAt time.Time `json:"updated_at" example:"2022-06-05T14:26:10.303278+03:00"`
+}
+
+// Cooldown is the minimum duration between 2 auto replies to the same contact
+func (rule *Rule) Cooldown() time.Duration {
+	if rule.CooldownMinutes == 0 {
+		return RuleDefaultCooldownMinutes * time.Minute
+	}
+	return time.Duration(rule.CooldownMinutes) * time.Minute
+}
+
+// Matches checks if the content of a received message triggers the auto reply rule
+func (rule *Rule) Matches(content string) bool {
+	switch rule.Match {
+	case RuleMatchKeyword:
+		return strings.EqualFold(strings.TrimSpace(content), strings.TrimSpace(rule.Pattern))
+	case RuleMatchRegex:
+		expression, err := regexp.Compile(rule.Pattern)
+		return err == nil && expression.MatchString(content)
+	default:
+		return false
+	}
+}
+
+// RuleExecution records a message sent to a contact when a Rule was triggered
+type RuleExecution struct {
+	ID     uuid.UUID `json:"id" gorm:"primaryKey;type:uuid;" example:"32343a19-da5e-4b1b-a767-3298a73703cb"`
+	RuleID uuid.UUID `json:"rule_id" gorm:"uniqueIndex:idx_rule_executions__rule_id__contact__day" example:"32343a19-da5e-4b1b-a767-3298a73703ca"`
+	UserID UserID    `json:"user_id" example:"WB7DRDWrJZRGbYrv2CKGkqbzvqdC"`
+
+	// Contact is the phone number which triggered the rule
+	Contact string `json:"contact" gorm:"uniqueIndex:idx_rule_executions__rule_id__contact__day" example:"+18005550100"`
+
+	// Day is the UTC date when a missed call rule was triggered, a missed call rule is executed at most once per contact per day.
+	// It is the timestamp of the received message for auto reply rules which are limited by their cooldown instead.
+	Day       string    `json:"day" gorm:"uniqueIndex:idx_rule_executions__rule_id__contact__day" example:"2022-06-05"`
+	MessageID uuid.UUID `json:"message_id" example:"32343a19-da5e-4b1b-a767-3298a73703cc"`
+	CreatedAt time.Time `json:"created_at" example:"2022-06-05T14:26:02.302718+03:00"`
+}
@@ -0,0 +1,53 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+)
+
+// SavedFilterTarget is the list which is filtered by a SavedFilter
+type SavedFilterTarget string
+
+const (
+	// SavedFilterTargetMessages filters the Message of a user
+	SavedFilterTargetMessages = SavedFilterTarget("messages")
+
+	// SavedFilterTargetMessageThreads filters the MessageThread of a user
+	SavedFilterTargetMessageThreads = SavedFilterTarget("message-threads")
+)
+
+// SavedFilterCriteria are the filters of a SavedFilter, the empty filters are ignored.
+// Owner, Query and Tag are used with every target, the other filters are only used with SavedFilterTargetMessages except IsArchived.
+type SavedFilterCriteria struct {
+	Owner   string `json:"owner,omitempty" example:"+18005550199"`
+	Contact string `json:"contact,omitempty" example:"+18005550100"`
+
+	Statuses []MessageStatus `json:"statuses,omitempty" example:"received"`
+	Type     MessageType     `json:"type,omitempty" example:"mobile-originated"`
+	SIM      SIM             `json:"sim,omitempty" example:"SIM1"`
+	Query    string          `json:"query,omitempty" example:"refund"`
+
+	// Tag matches the items with the tag or one of its descendants
+	Tag string `json:"tag,omitempty" example:"customer"`
+
+	// WithinDays matches the messages of the last number of days when the filter is executed
+	WithinDays int `json:"within_days,omitempty" example:"7"`
+
+	// IsArchived matches the archived message threads instead of the threads in the inbox
+	IsArchived bool `json:"is_archived,omitempty" example:"false"`
+}
+
+// SavedFilter is a named SavedFilterCriteria over the messages or message threads of a user which is shown as a smart view in the sidebar
+type SavedFilter struct {
+	ID       uuid.UUID                               `json:"id" gorm:"primaryKey;type:uuid;" example:"32343a19-da5e-4b1b-a767-3298a73703cb"`
+	UserID   UserID                                  `json:"user_id" gorm:"index" example:"WB7DRDWrJZRGbYrv2CKGkqbzvqdC"`
+	Name     string                                  `json:"name" example:"Unread refunds"`
+	Target   SavedFilterTarget                       `json:"target" example:"messages"`
+	Filters  datatypes.JSONType[SavedFilterCriteria] `json:"filters" swaggertype:"object"`
+	Position int                                     `json:"position" example:"1"`
+
+	CreatedAt time.Time `json:"created_at" example:"2022-06-05T14:26:02.302718+03:00"`
+	UpdatedAt time.Time `json:"updated_at" example:"2022-06-05T14:26:10.303278+03:00"`
+}
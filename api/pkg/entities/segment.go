@@ -0,0 +1,109 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+)
+
+// SegmentFilterField is the attribute of a Contact which is compared by a SegmentFilter
+type SegmentFilterField string
+
+const (
+	// SegmentFilterFieldPhoneNumber filters by Contact.PhoneNumber
+	SegmentFilterFieldPhoneNumber = SegmentFilterField("phone_number")
+
+	// SegmentFilterFieldTag filters by Contact.Tags
+	SegmentFilterFieldTag = SegmentFilterField("tag")
+
+	// SegmentFilterFieldAttribute filters by the value of SegmentFilter.Key in Contact.Attributes
+	SegmentFilterFieldAttribute = SegmentFilterField("attribute")
+
+	// SegmentFilterFieldMessagesSent filters by Contact.MessagesSent
+	SegmentFilterFieldMessagesSent = SegmentFilterField("messages_sent")
+
+	// SegmentFilterFieldMessagesReceived filters by Contact.MessagesReceived
+	SegmentFilterFieldMessagesReceived = SegmentFilterField("messages_received")
+
+	// SegmentFilterFieldReplyRate filters by Contact.ReplyRate
+	SegmentFilterFieldReplyRate = SegmentFilterField("reply_rate")
+
+	// SegmentFilterFieldLastSentAt filters by Contact.LastSentAt
+	SegmentFilterFieldLastSentAt = SegmentFilterField("last_sent_at")
+
+	// SegmentFilterFieldLastReplyAt filters by Contact.LastReplyAt
+	SegmentFilterFieldLastReplyAt = SegmentFilterField("last_reply_at")
+
+	// SegmentFilterFieldIsOptedOut filters by Contact.IsOptedOut
+	SegmentFilterFieldIsOptedOut = SegmentFilterField("is_opted_out")
+)
+
+// SegmentFilterOperator compares the field of a Contact with SegmentFilter.Value
+type SegmentFilterOperator string
+
+const (
+	// SegmentFilterOperatorEqual matches when the field is equal to the value
+	SegmentFilterOperatorEqual = SegmentFilterOperator("eq")
+
+	// SegmentFilterOperatorNotEqual matches when the field is not equal to the value
+	SegmentFilterOperatorNotEqual = SegmentFilterOperator("neq")
+
+	// SegmentFilterOperatorGreaterThan matches when the field is greater than the value
+	SegmentFilterOperatorGreaterThan = SegmentFilterOperator("gt")
+
+	// SegmentFilterOperatorLessThan matches when the field is less than the value
+	SegmentFilterOperatorLessThan = SegmentFilterOperator("lt")
+
+	// SegmentFilterOperatorContains matches when the field contains the value
+	SegmentFilterOperatorContains = SegmentFilterOperator("contains")
+
+	// SegmentFilterOperatorHas matches when the tags of a contact contain the value
+	SegmentFilterOperatorHas = SegmentFilterOperator("has")
+
+	// SegmentFilterOperatorNotHas matches when the tags of a contact do not contain the value
+	SegmentFilterOperatorNotHas = SegmentFilterOperator("not_has")
+)
+
+// SegmentFilterOperators are the SegmentFilterOperator supported by each SegmentFilterField
+var SegmentFilterOperators = map[SegmentFilterField][]SegmentFilterOperator{
+	SegmentFilterFieldPhoneNumber:      {SegmentFilterOperatorEqual, SegmentFilterOperatorNotEqual, SegmentFilterOperatorContains},
+	SegmentFilterFieldTag:              {SegmentFilterOperatorHas, SegmentFilterOperatorNotHas},
+	SegmentFilterFieldAttribute:        {SegmentFilterOperatorEqual, SegmentFilterOperatorNotEqual, SegmentFilterOperatorContains},
+	SegmentFilterFieldMessagesSent:     {SegmentFilterOperatorEqual, SegmentFilterOperatorNotEqual, SegmentFilterOperatorGreaterThan, SegmentFilterOperatorLessThan},
+	SegmentFilterFieldMessagesReceived: {SegmentFilterOperatorEqual, SegmentFilterOperatorNotEqual, SegmentFilterOperatorGreaterThan, SegmentFilterOperatorLessThan},
+	SegmentFilterFieldReplyRate:        {SegmentFilterOperatorGreaterThan, SegmentFilterOperatorLessThan},
+	SegmentFilterFieldLastSentAt:       {SegmentFilterOperatorGreaterThan, SegmentFilterOperatorLessThan},
+	SegmentFilterFieldLastReplyAt:      {SegmentFilterOperatorGreaterThan, SegmentFilterOperatorLessThan},
+	SegmentFilterFieldIsOptedOut:       {SegmentFilterOperatorEqual},
+}
+
+// SegmentFilter is a condition which a Contact must match to be part of a Segment
+type SegmentFilter struct {
+	Field SegmentFilterField `json:"field" example:"tag"`
+
+	// Key is the name of the attribute when Field is SegmentFilterFieldAttribute
+	Key      string                `json:"key,omitempty" example:""`
+	Operator SegmentFilterOperator `json:"operator" example:"has"`
+	Value    string                `json:"value" example:"customer"`
+}
+
+// IsSupported checks if the SegmentFilterOperator can be used with the SegmentFilterField
+func (filter SegmentFilter) IsSupported() bool {
+	for _, operator := range SegmentFilterOperators[filter.Field] {
+		if operator == filter.Operator {
+			return true
+		}
+	}
+	return false
+}
+
+// Segment is a saved list of SegmentFilter which is resolved to the matching contacts when it is used
+type Segment struct {
+	ID        uuid.UUID                           `json:"id" gorm:"primaryKey;type:uuid;" example:"32343a19-da5e-4b1b-a767-3298a73703cb"`
+	UserID    UserID                              `json:"user_id" gorm:"index" example:"WB7DRDWrJZRGbYrv2CKGkqbzvqdC"`
+	Name      string                              `json:"name" example:"Engaged customers"`
+	Filters   datatypes.JSONType[[]SegmentFilter] `json:"filters" swaggertype:"array,object"`
+	CreatedAt time.Time                           `json:"created_at" example:"2022-06-05T14:26:02.302718+03:00"`
+	UpdatedAt time.Time                           `json:"updated_at" example:"2022-06-05T14:26:10.303278+03:00"`
+}
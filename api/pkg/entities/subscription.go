@@ -0,0 +1,95 @@
+package entities
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SubscriptionConfirmationKeywords are the replies which confirm a pending Subscription
+var SubscriptionConfirmationKeywords = []string{"YES", "Y"}
+
+// SubscriptionKeyword subscribes a contact to a list when the contact sends the keyword to the owner phone.
+// The contact is added to the list only after replying YES to the confirmation message.
+type SubscriptionKeyword struct {
+	ID     uuid.UUID `json:"id" gorm:"primaryKey;type:uuid;" example:"32343a19-da5e-4b1b-a767-3298a73703cb"`
+	UserID UserID    `json:"user_id" gorm:"uniqueIndex:idx_subscription_keywords__user_id__owner__keyword" example:"WB7DRDWrJZRGbYrv2CKGkqbzvqdC"`
+	Owner  string    `json:"owner" gorm:"uniqueIndex:idx_subscription_keywords__user_id__owner__keyword" example:"+18005550199"`
+
+	// Keyword is stored in upper case and it is matched against the whole content of a received message
+	Keyword string `json:"keyword" gorm:"uniqueIndex:idx_subscription_keywords__user_id__owner__keyword" example:"JOIN"`
+
+	// List is the tag which is added to the entities.Contact when the subscription is confirmed
+	List string `json:"list" example:"newsletter"`
+
+	// ConfirmationTemplate is sent when the keyword is received, {list} and {keyword} are replaced with the values of the keyword
+	ConfirmationTemplate string `json:"confirmation_template" example:"Reply YES to confirm your subscription to {list}. Reply STOP to opt out."`
+
+	// WelcomeTemplate is sent after the subscription is confirmed, no message is sent when it is empty
+	WelcomeTemplate string    `json:"welcome_template" example:"You are now subscribed to {list}."`
+	Enabled         bool      `json:"enabled" example:"true"`
+	CreatedAt       time.Time `json:"created_at" example:"2022-06-05T14:26:02.302718+03:00"`
+	UpdatedAt       time.Time `json:"updated_at" example:"2022-06-05T14:26:10.303278+03:00"`
+}
+
+// ConfirmationMessage is the content of the message which asks the contact to confirm the subscription
+func (keyword *SubscriptionKeyword) ConfirmationMessage() string {
+	return keyword.render(keyword.ConfirmationTemplate)
+}
+
+// WelcomeMessage is the content of the message sent after the subscription is confirmed
+func (keyword *SubscriptionKeyword) WelcomeMessage() string {
+	return keyword.render(keyword.WelcomeTemplate)
+}
+
+func (keyword *SubscriptionKeyword) render(template string) string {
+	return strings.NewReplacer("{list}", keyword.List, "{keyword}", keyword.Keyword).Replace(template)
+}
+
+// SubscriptionStatus is the state of a Subscription
+type SubscriptionStatus string
+
+const (
+	// SubscriptionStatusPending is used when the confirmation message was sent and the contact has not replied YES
+	SubscriptionStatusPending = SubscriptionStatus("pending")
+
+	// SubscriptionStatusConfirmed is used when the contact replied YES to the confirmation message
+	SubscriptionStatusConfirmed = SubscriptionStatus("confirmed")
+)
+
+// Subscription tracks the consent of a contact which sent a SubscriptionKeyword
+type Subscription struct {
+	ID        uuid.UUID          `json:"id" gorm:"primaryKey;type:uuid;" example:"32343a19-da5e-4b1b-a767-3298a73703cb"`
+	UserID    UserID             `json:"user_id" gorm:"index:idx_subscriptions__user_id__owner__contact" example:"WB7DRDWrJZRGbYrv2CKGkqbzvqdC"`
+	KeywordID uuid.UUID          `json:"keyword_id" gorm:"uniqueIndex:idx_subscriptions__keyword_id__contact;type:uuid" example:"32343a19-da5e-4b1b-a767-3298a73703ca"`
+	Owner     string             `json:"owner" gorm:"index:idx_subscriptions__user_id__owner__contact" example:"+18005550199"`
+	Contact   string             `json:"contact" gorm:"uniqueIndex:idx_subscriptions__keyword_id__contact;index:idx_subscriptions__user_id__owner__contact" example:"+18005550100"`
+	List      string             `json:"list" example:"newsletter"`
+	Status    SubscriptionStatus `json:"status" example:"pending"`
+
+	// ConfirmationMessageID is the ID of the last entities.Message which asked the contact to confirm the subscription
+	ConfirmationMessageID *uuid.UUID `json:"confirmation_message_id" gorm:"type:uuid" example:"32343a19-da5e-4b1b-a767-3298a73703cc"`
+	RequestedAt           time.Time  `json:"requested_at" example:"2022-06-05T14:26:02.302718+03:00"`
+	ConfirmedAt           *time.Time `json:"confirmed_at" example:"2022-06-05T14:26:09.527976+03:00"`
+	CreatedAt             time.Time  `json:"created_at" example:"2022-06-05T14:26:02.302718+03:00"`
+	UpdatedAt             time.Time  `json:"updated_at" example:"2022-06-05T14:26:10.303278+03:00"`
+}
+
+// IsConfirmed checks if the contact confirmed the Subscription
+func (subscription *Subscription) IsConfirmed() bool {
+	return subscription.Status == SubscriptionStatusConfirmed
+}
+
+// IsReservedKeyword checks if a keyword is used to opt in, opt out or confirm a subscription
+func IsReservedKeyword(keyword string) bool {
+	keyword = strings.ToUpper(strings.TrimSpace(keyword))
+	for _, values := range [][]string{contactOptOutKeywords, contactOptInKeywords, SubscriptionConfirmationKeywords} {
+		for _, value := range values {
+			if value == keyword {
+				return true
+			}
+		}
+	}
+	return false
+}
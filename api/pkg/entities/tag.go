@@ -0,0 +1,28 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Tag is a label managed by a user which is set on entities.Contact, entities.Message and entities.MessageThread.
+// Tags form a hierarchy through the ParentID so that filtering by a tag also matches the items with its descendants.
+type Tag struct {
+	ID        uuid.UUID  `json:"id" gorm:"primaryKey;type:uuid;" example:"32343a19-da5e-4b1b-a767-3298a73703cb"`
+	UserID    UserID     `json:"user_id" gorm:"uniqueIndex:idx_tags__user_id__name" example:"WB7DRDWrJZRGbYrv2CKGkqbzvqdC"`
+	Name      string     `json:"name" gorm:"uniqueIndex:idx_tags__user_id__name" example:"customer"`
+	Color     string     `json:"color" example:"#3f51b5"`
+	ParentID  *uuid.UUID `json:"parent_id" gorm:"type:uuid;index:idx_tags__parent_id" example:"32343a19-da5e-4b1b-a767-3298a73703ca"`
+	CreatedAt time.Time  `json:"created_at" example:"2022-06-05T14:26:02.302718+03:00"`
+	UpdatedAt time.Time  `json:"updated_at" example:"2022-06-05T14:26:10.303278+03:00"`
+}
+
+// TagUsage is the number of items of a user which have a Tag
+type TagUsage struct {
+	TagID          uuid.UUID `json:"tag_id" example:"32343a19-da5e-4b1b-a767-3298a73703cb"`
+	Name           string    `json:"name" example:"customer"`
+	Contacts       int64     `json:"contacts" example:"120"`
+	Messages       int64     `json:"messages" example:"1024"`
+	MessageThreads int64     `json:"message_threads" example:"87"`
+}
@@ -0,0 +1,80 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// TemplateStatus is the review status of a Template
+type TemplateStatus string
+
+const (
+	// TemplateStatusDraft means the template is being edited and it has not been submitted for review
+	TemplateStatusDraft = TemplateStatus("draft")
+
+	// TemplateStatusSubmitted means the template is waiting for an operator to review it
+	TemplateStatusSubmitted = TemplateStatus("submitted")
+
+	// TemplateStatusApproved means the template was approved and it can be used by campaigns
+	TemplateStatusApproved = TemplateStatus("approved")
+
+	// TemplateStatusRejected means the template was rejected, it goes back to draft when it is edited
+	TemplateStatusRejected = TemplateStatus("rejected")
+)
+
+// templateTransitions are the statuses which a Template can move to from each status
+var templateTransitions = map[TemplateStatus][]TemplateStatus{
+	TemplateStatusDraft:     {TemplateStatusSubmitted},
+	TemplateStatusSubmitted: {TemplateStatusApproved, TemplateStatusRejected, TemplateStatusDraft},
+	TemplateStatusApproved:  {TemplateStatusDraft},
+	TemplateStatusRejected:  {TemplateStatusDraft, TemplateStatusSubmitted},
+}
+
+// Template is the reviewed content of the messages sent by a Campaign.
+// Users who require template approval can only run campaigns with an approved template.
+type Template struct {
+	ID     uuid.UUID      `json:"id" gorm:"primaryKey;type:uuid;" example:"32343a19-da5e-4b1b-a767-3298a73703cb"`
+	UserID UserID         `json:"user_id" gorm:"index" example:"WB7DRDWrJZRGbYrv2CKGkqbzvqdC"`
+	Name   string         `json:"name" example:"Appointment reminder"`
+	Status TemplateStatus `json:"status" gorm:"index" example:"approved"`
+
+	// Content can contain {phone_number} and {attribute.name} placeholders which are replaced for each contact
+	Content string `json:"content" example:"Hi {attribute.name}, your appointment is tomorrow"`
+
+	// ReviewNote is the reason given by the operator who approved or rejected the template
+	ReviewNote  *string    `json:"review_note" example:"Add the opt out instructions"`
+	ReviewedBy  *UserID    `json:"reviewed_by" example:"WB7DRDWrJZRGbYrv2CKGkqbzvqdC"`
+	SubmittedAt *time.Time `json:"submitted_at" example:"2022-06-05T14:26:02.302718+03:00"`
+	ReviewedAt  *time.Time `json:"reviewed_at" example:"2022-06-05T14:26:02.302718+03:00"`
+	CreatedAt   time.Time  `json:"created_at" example:"2022-06-05T14:26:02.302718+03:00"`
+	UpdatedAt   time.Time  `json:"updated_at" example:"2022-06-05T14:26:10.303278+03:00"`
+}
+
+// IsApproved checks if the Template can be used by campaigns which require approval
+func (template *Template) IsApproved() bool {
+	return template.Status == TemplateStatusApproved
+}
+
+// CanTransition checks if the Template can move to the status
+func (template *Template) CanTransition(status TemplateStatus) bool {
+	for _, next := range templateTransitions[template.Status] {
+		if next == status {
+			return true
+		}
+	}
+	return false
+}
+
+// TemplateReview is the audit history of the status changes of a Template
+type TemplateReview struct {
+	ID         uuid.UUID      `json:"id" gorm:"primaryKey;type:uuid;" example:"32343a19-da5e-4b1b-a767-3298a73703cb"`
+	TemplateID uuid.UUID      `json:"template_id" gorm:"type:uuid;index" example:"32343a19-da5e-4b1b-a767-3298a73703cc"`
+	UserID     UserID         `json:"user_id" gorm:"index" example:"WB7DRDWrJZRGbYrv2CKGkqbzvqdC"`
+	ActorID    UserID         `json:"actor_id" example:"WB7DRDWrJZRGbYrv2CKGkqbzvqdC"`
+	FromStatus TemplateStatus `json:"from_status" example:"submitted"`
+	ToStatus   TemplateStatus `json:"to_status" example:"approved"`
+	Content    string         `json:"content" example:"Hi {attribute.name}, your appointment is tomorrow"`
+	Note       *string        `json:"note" example:"Add the opt out instructions"`
+	CreatedAt  time.Time      `json:"created_at" example:"2022-06-05T14:26:02.302718+03:00"`
+}
@@ -0,0 +1,80 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// TemplateStat counts the messages sent with a Template on a day and the responses of the contacts
+type TemplateStat struct {
+	ID         uuid.UUID `json:"id" gorm:"primaryKey;type:uuid;" example:"32343a19-da5e-4b1b-a767-3298a73703cb"`
+	TemplateID uuid.UUID `json:"template_id" gorm:"type:uuid;uniqueIndex:idx_template_stats__template_id__date" example:"32343a19-da5e-4b1b-a767-3298a73703cc"`
+	UserID     UserID    `json:"user_id" gorm:"index:idx_template_stats__user_id" example:"WB7DRDWrJZRGbYrv2CKGkqbzvqdC"`
+
+	// Date is the start of the day in UTC when the messages were requested, responses are counted on the day of the message they respond to
+	Date time.Time `json:"date" gorm:"uniqueIndex:idx_template_stats__template_id__date" example:"2022-06-05T00:00:00Z"`
+
+	Sent      uint `json:"sent" example:"120"`
+	Delivered uint `json:"delivered" example:"114"`
+	Failed    uint `json:"failed" example:"6"`
+
+	// Replies is the number of sent messages which got a reply before another message was sent to the contact
+	Replies uint `json:"replies" example:"30"`
+
+	// OptOuts is the number of contacts who replied with an opt out keyword to a message sent with the template
+	OptOuts uint `json:"opt_outs" example:"2"`
+
+	CreatedAt time.Time `json:"created_at" example:"2022-06-05T14:26:02.302718+03:00"`
+	UpdatedAt time.Time `json:"updated_at" example:"2022-06-05T14:26:10.303278+03:00"`
+}
+
+// TemplateStats are the totals and the daily TemplateStat of a Template in a period
+type TemplateStats struct {
+	TemplateID uuid.UUID `json:"template_id" example:"32343a19-da5e-4b1b-a767-3298a73703cc"`
+	From       time.Time `json:"from" example:"2022-06-01T00:00:00Z"`
+	To         time.Time `json:"to" example:"2022-07-01T00:00:00Z"`
+	Sent       uint      `json:"sent" example:"120"`
+	Delivered  uint      `json:"delivered" example:"114"`
+	Failed     uint      `json:"failed" example:"6"`
+	Replies    uint      `json:"replies" example:"30"`
+	OptOuts    uint      `json:"opt_outs" example:"2"`
+
+	// DeliveryRate is the fraction of the sent messages which were delivered
+	DeliveryRate float64 `json:"delivery_rate" example:"0.95"`
+
+	// ReplyRate is the fraction of the sent messages which got a reply
+	ReplyRate float64 `json:"reply_rate" example:"0.25"`
+
+	// OptOutRate is the fraction of the sent messages which got an opt out reply
+	OptOutRate float64 `json:"opt_out_rate" example:"0.0167"`
+
+	Days []*TemplateStat `json:"days"`
+}
+
+// NewTemplateStats sums the daily TemplateStat of a Template
+func NewTemplateStats(templateID uuid.UUID, from time.Time, to time.Time, days []*TemplateStat) *TemplateStats {
+	stats := &TemplateStats{TemplateID: templateID, From: from, To: to, Days: days}
+	for _, day := range days {
+		stats.Sent += day.Sent
+		stats.Delivered += day.Delivered
+		stats.Failed += day.Failed
+		stats.Replies += day.Replies
+		stats.OptOuts += day.OptOuts
+	}
+
+	stats.DeliveryRate = stats.rate(stats.Delivered)
+	stats.ReplyRate = stats.rate(stats.Replies)
+	stats.OptOutRate = stats.rate(stats.OptOuts)
+	return stats
+}
+
+func (stats *TemplateStats) rate(count uint) float64 {
+	if stats.Sent == 0 {
+		return 0
+	}
+	if count >= stats.Sent {
+		return 1
+	}
+	return float64(count) / float64(stats.Sent)
+}
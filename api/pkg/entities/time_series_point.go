@@ -0,0 +1,55 @@
+package entities
+
+import "time"
+
+// TimeSeriesMetric is the metric which is aggregated in a time series
+type TimeSeriesMetric string
+
+const (
+	// TimeSeriesMetricSent is the number of messages sent by the phone
+	TimeSeriesMetricSent = TimeSeriesMetric("sent")
+
+	// TimeSeriesMetricDelivered is the number of messages delivered to the contact
+	TimeSeriesMetricDelivered = TimeSeriesMetric("delivered")
+
+	// TimeSeriesMetricFailed is the number of messages which could not be sent by the phone
+	TimeSeriesMetricFailed = TimeSeriesMetric("failed")
+
+	// TimeSeriesMetricReceived is the number of messages received on the phone
+	TimeSeriesMetricReceived = TimeSeriesMetric("received")
+
+	// TimeSeriesMetricLatency is the average duration in milliseconds from when the request was received until the message was sent
+	TimeSeriesMetricLatency = TimeSeriesMetric("latency")
+)
+
+// TimeSeriesGranularity is the size of the buckets in a time series
+type TimeSeriesGranularity string
+
+const (
+	// TimeSeriesGranularityHour aggregates the metric per hour
+	TimeSeriesGranularityHour = TimeSeriesGranularity("hour")
+
+	// TimeSeriesGranularityDay aggregates the metric per day
+	TimeSeriesGranularityDay = TimeSeriesGranularity("day")
+)
+
+// TimeSeriesGroup is the dimension used to split a time series
+type TimeSeriesGroup string
+
+const (
+	// TimeSeriesGroupPhone splits the time series by owner phone number
+	TimeSeriesGroupPhone = TimeSeriesGroup("phone")
+
+	// TimeSeriesGroupSIM splits the time series by SIM card
+	TimeSeriesGroupSIM = TimeSeriesGroup("sim")
+)
+
+// TimeSeriesPoint is the value of a metric in a time bucket
+type TimeSeriesPoint struct {
+	// Timestamp is the start of the time bucket
+	Timestamp time.Time `json:"timestamp" example:"2022-06-05T14:00:00Z"`
+
+	// Group is the value of the group e.g. the owner phone number. It is empty when the time series is not grouped
+	Group string  `json:"group" example:"+18005550199"`
+	Value float64 `json:"value" example:"42"`
+}
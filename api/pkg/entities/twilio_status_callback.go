@@ -0,0 +1,16 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// TwilioStatusCallback is the URL which is notified about the status of a message sent with the Twilio compatible API
+type TwilioStatusCallback struct {
+	MessageID  uuid.UUID `json:"message_id" gorm:"primaryKey;type:uuid;" example:"32343a19-da5e-4b1b-a767-3298a73703cb"`
+	UserID     UserID    `json:"user_id" gorm:"index" example:"WB7DRDWrJZRGbYrv2CKGkqbzvqdC"`
+	AccountSID string    `json:"account_sid" example:"ACXXXXXXXXXXXXXXXXXXXXXXXXXXXXXXXX"`
+	URL        string    `json:"url" example:"https://example.com/twilio/status"`
+	CreatedAt  time.Time `json:"created_at" example:"2022-06-05T14:26:02.302718+03:00"`
+}
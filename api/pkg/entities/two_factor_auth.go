@@ -0,0 +1,42 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// TwoFactorAuth is the TOTP secret of a user who protects sensitive operations with a second factor
+type TwoFactorAuth struct {
+	ID     uuid.UUID `json:"id" gorm:"primaryKey;type:uuid;" example:"32343a19-da5e-4b1b-a767-3298a73703cb"`
+	UserID UserID    `json:"user_id" gorm:"uniqueIndex" example:"WB7DRDWrJZRGbYrv2CKGkqbzvqdC"`
+	Secret string    `json:"-"`
+
+	// RecoveryCodes are the SHA-256 hashes of the unused recovery codes, a code is removed once it is used
+	RecoveryCodes pq.StringArray `json:"-" gorm:"type:text[]"`
+
+	// LastTimeStep is the TOTP time step of the last accepted code, a code of the same or an earlier step is rejected
+	LastTimeStep int64 `json:"-" gorm:"default:0"`
+
+	// FailedAttempts is the number of invalid codes since the last accepted code or lockout
+	FailedAttempts int `json:"-" gorm:"default:0"`
+
+	// LockedUntil is set when too many invalid codes are used, no code is accepted before this time
+	LockedUntil *time.Time `json:"-"`
+
+	// EnabledAt is nil until the user confirms the enrollment with a valid code
+	EnabledAt *time.Time `json:"enabled_at" example:"2022-06-05T14:26:02.302718+03:00"`
+	CreatedAt time.Time  `json:"created_at" example:"2022-06-05T14:26:02.302718+03:00"`
+	UpdatedAt time.Time  `json:"updated_at" example:"2022-06-05T14:26:10.303278+03:00"`
+}
+
+// IsEnabled checks if the second factor is required for sensitive operations
+func (auth *TwoFactorAuth) IsEnabled() bool {
+	return auth.EnabledAt != nil
+}
+
+// IsLocked checks if codes are rejected at a timestamp because too many invalid codes were used
+func (auth *TwoFactorAuth) IsLocked(timestamp time.Time) bool {
+	return auth.LockedUntil != nil && timestamp.Before(*auth.LockedUntil)
+}
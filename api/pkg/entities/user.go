@@ -1,9 +1,11 @@
 package entities
 
 import (
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
 // UserID is the ID of a user
@@ -41,6 +43,15 @@ const SubscriptionNameUltraYearly = SubscriptionName("ultra-yearly")
 // SubscriptionNameProLifetime represents a pro lifetime subscription
 const SubscriptionNameProLifetime = SubscriptionName("pro-lifetime")
 
+// UserSuspensionReason is the reason why the account of a user is suspended
+type UserSuspensionReason string
+
+// UserSuspensionReasonBilling means the payment of the subscription failed, the account is reactivated once a payment succeeds
+const UserSuspensionReasonBilling = UserSuspensionReason("billing")
+
+// UserSuspensionReasonAbuse means the account was suspended by an operator e.g. for sending spam
+const UserSuspensionReasonAbuse = UserSuspensionReason("abuse")
+
 // User stores information about a user
 type User struct {
 	ID                   UserID           `json:"id" gorm:"primaryKey;type:string;" example:"WB7DRDWrJZRGbYrv2CKGkqbzvqdC"`
@@ -53,8 +64,55 @@ type User struct {
 	SubscriptionStatus   *string          `json:"subscription_status" example:"on_trial"`
 	SubscriptionRenewsAt *time.Time       `json:"subscription_renews_at" example:"2022-06-05T14:26:02.302718+03:00"`
 	SubscriptionEndsAt   *time.Time       `json:"subscription_ends_at" example:"2022-06-05T14:26:02.302718+03:00"`
-	CreatedAt            time.Time        `json:"created_at" example:"2022-06-05T14:26:02.302718+03:00"`
-	UpdatedAt            time.Time        `json:"updated_at" example:"2022-06-05T14:26:10.303278+03:00"`
+
+	// NotificationDigestMinutes groups the alert emails sent within this window into a single email, 0 sends every alert immediately
+	NotificationDigestMinutes uint `json:"notification_digest_minutes" example:"15"`
+
+	// MessageExpirationTimeout is the default expires_in in seconds of the messages sent by the user, 0 uses the message expiration of the phone
+	MessageExpirationTimeout uint `json:"message_expiration_timeout" example:"300"`
+
+	// SendRateLimit is the maximum number of messages the user can send in a minute, 0 means unlimited
+	SendRateLimit uint `json:"send_rate_limit" example:"60"`
+
+	// ContactSendRateLimit is the maximum number of messages the user can send to the same contact in a minute, 0 means unlimited
+	ContactSendRateLimit uint `json:"contact_send_rate_limit" example:"5"`
+
+	// OptOutKeywords are the replies which add a contact to the blocklist, the default keywords are used when it is empty
+	OptOutKeywords pq.StringArray `json:"opt_out_keywords" gorm:"type:text[]" swaggertype:"array,string" example:"STOP,UNSUBSCRIBE"`
+
+	// OptInKeywords are the replies which remove an opted out contact from the blocklist, the default keywords are used when it is empty
+	OptInKeywords pq.StringArray `json:"opt_in_keywords" gorm:"type:text[]" swaggertype:"array,string" example:"START"`
+
+	// MessageThreadAutoArchiveDays archives the message threads which have no activity for this number of days, 0 disables auto archiving
+	MessageThreadAutoArchiveDays uint `json:"message_thread_auto_archive_days" example:"90"`
+
+	// MessageRetentionDays deletes the messages which are older than this number of days, 0 keeps the messages forever
+	MessageRetentionDays uint `json:"message_retention_days" example:"365"`
+
+	// MessageAnonymizeDays removes the content of the messages which are older than this number of days, 0 keeps the content
+	MessageAnonymizeDays uint `json:"message_anonymize_days" example:"30"`
+
+	// SuspendedAt is set while the account is suspended, new messages are rejected and webhooks are held until it is reactivated
+	SuspendedAt      *time.Time            `json:"suspended_at" example:"2022-06-05T14:26:02.302718+03:00"`
+	SuspensionReason *UserSuspensionReason `json:"suspension_reason" example:"billing"`
+
+	// TemplateApprovalRequired is set by an operator for regulated senders, their campaigns can only send approved templates
+	TemplateApprovalRequired bool `json:"template_approval_required" example:"false"`
+
+	// Region is the database which stores the messages of the user, it is chosen once and the default database is used when it is empty
+	Region    string    `json:"region" example:"eu"`
+	CreatedAt time.Time `json:"created_at" example:"2022-06-05T14:26:02.302718+03:00"`
+	UpdatedAt time.Time `json:"updated_at" example:"2022-06-05T14:26:10.303278+03:00"`
+}
+
+// NotificationDigestInterval is the duration for which alert emails are grouped before they are sent
+func (user User) NotificationDigestInterval() time.Duration {
+	return time.Duration(user.NotificationDigestMinutes) * time.Minute
+}
+
+// HasRetentionPolicy checks if the messages of the user are deleted or anonymized after some time
+func (user User) HasRetentionPolicy() bool {
+	return user.MessageRetentionDays > 0 || user.MessageAnonymizeDays > 0
 }
 
 // IsOnProPlan checks if a user is on the pro plan
@@ -66,3 +124,31 @@ func (user User) IsOnProPlan() bool {
 func (user User) IsOnUltraPlan() bool {
 	return user.SubscriptionName == SubscriptionNameUltraMonthly || user.SubscriptionName == SubscriptionNameUltraYearly
 }
+
+// IsOptOutKeyword checks if the content of a received message opts the contact out of receiving messages
+func (user User) IsOptOutKeyword(content string) bool {
+	return user.matchesKeyword(user.OptOutKeywords, contactOptOutKeywords, content)
+}
+
+// IsOptInKeyword checks if the content of a received message opts the contact back in after opting out
+func (user User) IsOptInKeyword(content string) bool {
+	return user.matchesKeyword(user.OptInKeywords, contactOptInKeywords, content)
+}
+
+func (user User) matchesKeyword(keywords []string, defaults []string, content string) bool {
+	if len(keywords) == 0 {
+		keywords = defaults
+	}
+	keyword := strings.ToUpper(strings.TrimSpace(content))
+	for _, value := range keywords {
+		if strings.ToUpper(value) == keyword {
+			return true
+		}
+	}
+	return false
+}
+
+// IsSuspended checks if the account of a user is suspended
+func (user User) IsSuspended() bool {
+	return user.SuspendedAt != nil
+}
@@ -0,0 +1,36 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// UserCredential is the email and password of a user who registered natively on a self-hosted instance
+type UserCredential struct {
+	ID           uuid.UUID `json:"id" gorm:"primaryKey;type:uuid;" example:"32343a19-da5e-4b1b-a767-3298a73703cb"`
+	UserID       UserID    `json:"user_id" gorm:"uniqueIndex" example:"WB7DRDWrJZRGbYrv2CKGkqbzvqdC"`
+	Email        string    `json:"email" gorm:"uniqueIndex" example:"name@email.com"`
+	PasswordHash string    `json:"-"`
+
+	// EmailVerifiedAt is set once the user opens the link in the verification email
+	EmailVerifiedAt       *time.Time `json:"email_verified_at" example:"2022-06-05T14:26:02.302718+03:00"`
+	VerificationTokenHash *string    `json:"-" gorm:"index"`
+
+	// ResetTokenHash is the hash of the token sent in the password reset email, it is valid until ResetTokenExpiresAt
+	ResetTokenHash      *string    `json:"-" gorm:"index"`
+	ResetTokenExpiresAt *time.Time `json:"-"`
+
+	CreatedAt time.Time `json:"created_at" example:"2022-06-05T14:26:02.302718+03:00"`
+	UpdatedAt time.Time `json:"updated_at" example:"2022-06-05T14:26:10.303278+03:00"`
+}
+
+// IsEmailVerified checks if the user has verified the email address of the UserCredential
+func (credential *UserCredential) IsEmailVerified() bool {
+	return credential.EmailVerifiedAt != nil
+}
+
+// IsResetTokenExpired checks if the password reset token can no longer be used
+func (credential *UserCredential) IsResetTokenExpired(now time.Time) bool {
+	return credential.ResetTokenHash == nil || credential.ResetTokenExpiresAt == nil || now.After(*credential.ResetTokenExpiresAt)
+}
@@ -5,15 +5,117 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/lib/pq"
+	"gorm.io/datatypes"
 )
 
+// WebhookVersion is the version of the payload which is sent to a webhook
+type WebhookVersion string
+
+const (
+	// WebhookVersionV1 sends the event as a cloud event
+	WebhookVersionV1 = WebhookVersion("v1")
+
+	// WebhookVersionV2 sends the event in a flat envelope with the event data
+	WebhookVersionV2 = WebhookVersion("v2")
+
+	// WebhookVersionLatest is the version pinned by new webhooks which don't specify a version
+	WebhookVersionLatest = WebhookVersionV2
+)
+
+// WebhookVersions are all the supported WebhookVersion
+var WebhookVersions = []WebhookVersion{
+	WebhookVersionV1,
+	WebhookVersionV2,
+}
+
+// WebhookFilterOperator compares the value at WebhookFilter.Path in the event data with the filter
+type WebhookFilterOperator string
+
+const (
+	// WebhookFilterOperatorEqual matches when the value is equal to WebhookFilter.Value
+	WebhookFilterOperatorEqual = WebhookFilterOperator("eq")
+
+	// WebhookFilterOperatorNotEqual matches when the value is not equal to WebhookFilter.Value
+	WebhookFilterOperatorNotEqual = WebhookFilterOperator("neq")
+
+	// WebhookFilterOperatorContains matches when the value contains WebhookFilter.Value
+	WebhookFilterOperatorContains = WebhookFilterOperator("contains")
+
+	// WebhookFilterOperatorMatches matches when the value matches the regular expression in WebhookFilter.Value
+	WebhookFilterOperatorMatches = WebhookFilterOperator("matches")
+
+	// WebhookFilterOperatorIn matches when the value is one of WebhookFilter.Values
+	WebhookFilterOperatorIn = WebhookFilterOperator("in")
+
+	// WebhookFilterOperatorNotIn matches when the value is not one of WebhookFilter.Values
+	WebhookFilterOperatorNotIn = WebhookFilterOperator("not_in")
+
+	// WebhookFilterOperatorExists matches when the event data has a value at WebhookFilter.Path
+	WebhookFilterOperatorExists = WebhookFilterOperator("exists")
+)
+
+// WebhookFilterOperators are all the supported WebhookFilterOperator
+var WebhookFilterOperators = []WebhookFilterOperator{
+	WebhookFilterOperatorEqual,
+	WebhookFilterOperatorNotEqual,
+	WebhookFilterOperatorContains,
+	WebhookFilterOperatorMatches,
+	WebhookFilterOperatorIn,
+	WebhookFilterOperatorNotIn,
+	WebhookFilterOperatorExists,
+}
+
+// WebhookFilter is a condition which the data of an event must match before it is sent to a Webhook
+type WebhookFilter struct {
+	// Event is the type of event which the filter applies to
+	Event string `json:"event" example:"message.phone.received"`
+
+	// Path is a JSONPath expression for a value in the event data e.g. $.content or $.attachments[0].content_type
+	Path     string                `json:"path" example:"$.contact"`
+	Operator WebhookFilterOperator `json:"operator" example:"in"`
+	Value    string                `json:"value,omitempty" example:""`
+	Values   []string              `json:"values,omitempty" example:"+18005550199,+18005550100"`
+}
+
 // Webhook stores the webhooks of a user
 type Webhook struct {
-	ID         uuid.UUID      `json:"id" gorm:"primaryKey;type:uuid;" example:"32343a19-da5e-4b1b-a767-3298a73703cb"`
-	UserID     UserID         `json:"user_id" example:"WB7DRDWrJZRGbYrv2CKGkqbzvqdC"`
-	URL        string         `json:"url" example:"https://example.com"`
-	SigningKey string         `json:"signing_key" example:"DGW8NwQp7mxKaSZ72Xq9v67SLqSbWQvckzzmK8D6rvd7NywSEkdMJtuxKyEkYnCY"`
-	Events     pq.StringArray `json:"events" example:"[message.phone.received]" gorm:"type:text[]" swaggertype:"array,string"`
-	CreatedAt  time.Time      `json:"created_at" example:"2022-06-05T14:26:02.302718+03:00"`
-	UpdatedAt  time.Time      `json:"updated_at" example:"2022-06-05T14:26:10.303278+03:00"`
+	ID         uuid.UUID `json:"id" gorm:"primaryKey;type:uuid;" example:"32343a19-da5e-4b1b-a767-3298a73703cb"`
+	UserID     UserID    `json:"user_id" example:"WB7DRDWrJZRGbYrv2CKGkqbzvqdC"`
+	URL        string    `json:"url" example:"https://example.com"`
+	SigningKey string    `json:"signing_key" example:"DGW8NwQp7mxKaSZ72Xq9v67SLqSbWQvckzzmK8D6rvd7NywSEkdMJtuxKyEkYnCY"`
+
+	// SecondarySigningKey is the previous signing key which is still used to sign payloads and the bearer token until the rotation is confirmed
+	SecondarySigningKey string         `json:"secondary_signing_key" example:"QmH3kM8Zv2dXq7NwR6pLs4TfYc9UbAe5"`
+	SigningKeyRotatedAt *time.Time     `json:"signing_key_rotated_at" example:"2022-06-05T14:26:02.302718+03:00"`
+	Events              pq.StringArray `json:"events" example:"[message.phone.received]" gorm:"type:text[]" swaggertype:"array,string"`
+
+	// Filters must all match the data of an event with the same type before the event is sent to the webhook
+	Filters datatypes.JSONType[[]WebhookFilter] `json:"filters" swaggertype:"array,object"`
+
+	// ProxyURL is the HTTP(S) or SOCKS5 proxy used to send events to the webhook instead of the global proxy
+	ProxyURL *string `json:"proxy_url" example:"socks5://proxy.example.com:1080"`
+
+	// Version is the pinned version of the payload sent to the webhook
+	Version   WebhookVersion `json:"version" gorm:"default:v1" example:"v2"`
+	CreatedAt time.Time      `json:"created_at" example:"2022-06-05T14:26:02.302718+03:00"`
+	UpdatedAt time.Time      `json:"updated_at" example:"2022-06-05T14:26:10.303278+03:00"`
+}
+
+// FiltersFor returns the WebhookFilter which apply to an event type
+func (webhook *Webhook) FiltersFor(eventType string) []WebhookFilter {
+	var filters []WebhookFilter
+	for _, filter := range webhook.Filters.Data {
+		if filter.Event == eventType {
+			filters = append(filters, filter)
+		}
+	}
+	return filters
+}
+
+// SigningKeys are the keys which are used to sign the payloads sent to the webhook
+func (webhook *Webhook) SigningKeys() []string {
+	if webhook.SecondarySigningKey == "" {
+		return []string{webhook.SigningKey}
+	}
+	return []string{webhook.SigningKey, webhook.SecondarySigningKey}
 }
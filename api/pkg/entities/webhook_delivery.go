@@ -0,0 +1,50 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// WebhookDeliveryStatus is the state of a WebhookDelivery
+type WebhookDeliveryStatus string
+
+const (
+	// WebhookDeliveryStatusPending means the event has not been sent yet
+	WebhookDeliveryStatusPending = WebhookDeliveryStatus("pending")
+
+	// WebhookDeliveryStatusRetrying means an attempt failed and the event will be sent again at NextAttemptAt
+	WebhookDeliveryStatusRetrying = WebhookDeliveryStatus("retrying")
+
+	// WebhookDeliveryStatusSucceeded means the webhook responded with a 2xx status code
+	WebhookDeliveryStatusSucceeded = WebhookDeliveryStatus("succeeded")
+
+	// WebhookDeliveryStatusHeld means the event was not sent because the account of the user is suspended, it is sent once the account is reactivated
+	WebhookDeliveryStatusHeld = WebhookDeliveryStatus("held")
+
+	// WebhookDeliveryStatusFailed means all the attempts failed and the delivery is in the dead-letter queue until it is replayed
+	WebhookDeliveryStatusFailed = WebhookDeliveryStatus("failed")
+)
+
+// WebhookDelivery tracks the attempts of sending an event to a Webhook
+type WebhookDelivery struct {
+	ID        uuid.UUID             `json:"id" gorm:"primaryKey;type:uuid;" example:"32343a19-da5e-4b1b-a767-3298a73703cb"`
+	UserID    UserID                `json:"user_id" gorm:"index:idx_webhook_deliveries__user_id__webhook_id" example:"WB7DRDWrJZRGbYrv2CKGkqbzvqdC"`
+	WebhookID uuid.UUID             `json:"webhook_id" gorm:"type:uuid;index:idx_webhook_deliveries__user_id__webhook_id" example:"32343a19-da5e-4b1b-a767-3298a73703cc"`
+	EventID   string                `json:"event_id" example:"32343a19-da5e-4b1b-a767-3298a73703cd"`
+	EventType string                `json:"event_type" example:"message.phone.received"`
+	Status    WebhookDeliveryStatus `json:"status" gorm:"index:idx_webhook_deliveries__status__next_attempt_at" example:"retrying"`
+
+	// Payload is the JSON body which is sent on every attempt
+	Payload     string `json:"payload" example:"{\"type\":\"message.phone.received\"}"`
+	Attempts    uint   `json:"attempts" example:"2"`
+	MaxAttempts uint   `json:"max_attempts" example:"5"`
+
+	// LastStatusCode is the HTTP status code of the last attempt, it is 0 when no response was received
+	LastStatusCode int        `json:"last_status_code" example:"503"`
+	LastError      string     `json:"last_error" example:"unexpected status: 503"`
+	NextAttemptAt  *time.Time `json:"next_attempt_at" gorm:"index:idx_webhook_deliveries__status__next_attempt_at" example:"2022-06-05T14:27:02.302718+03:00"`
+	DeliveredAt    *time.Time `json:"delivered_at" example:"2022-06-05T14:28:02.302718+03:00"`
+	CreatedAt      time.Time  `json:"created_at" example:"2022-06-05T14:26:02.302718+03:00"`
+	UpdatedAt      time.Time  `json:"updated_at" example:"2022-06-05T14:26:10.303278+03:00"`
+}
@@ -0,0 +1,23 @@
+package events
+
+import (
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+
+	"github.com/google/uuid"
+)
+
+// EventTypeAttachmentQuarantined is emitted when malware is detected in an attachment
+const EventTypeAttachmentQuarantined = "attachment.quarantined"
+
+// AttachmentQuarantinedPayload is the payload of the EventTypeAttachmentQuarantined event
+type AttachmentQuarantinedPayload struct {
+	MessageID   uuid.UUID       `json:"message_id"`
+	UserID      entities.UserID `json:"user_id"`
+	Name        string          `json:"name"`
+	ContentType string          `json:"content_type"`
+	Scanner     string          `json:"scanner"`
+	Signature   string          `json:"signature"`
+	Timestamp   time.Time       `json:"timestamp"`
+}
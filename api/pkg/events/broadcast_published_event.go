@@ -0,0 +1,34 @@
+package events
+
+import (
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/google/uuid"
+)
+
+// EventTypeBroadcastPublished is emitted for every user in the audience of an entities.Broadcast
+const EventTypeBroadcastPublished = "broadcast.published"
+
+// BroadcastPublishedPayload is the payload of the EventTypeBroadcastPublished event
+type BroadcastPublishedPayload struct {
+	BroadcastID uuid.UUID                  `json:"broadcast_id"`
+	UserID      entities.UserID            `json:"user_id"`
+	Title       string                     `json:"title"`
+	Body        string                     `json:"body"`
+	Severity    entities.BroadcastSeverity `json:"severity"`
+	Channels    []string                   `json:"channels"`
+	StartsAt    time.Time                  `json:"starts_at"`
+	EndsAt      *time.Time                 `json:"ends_at"`
+	Timestamp   time.Time                  `json:"timestamp"`
+}
+
+// HasChannel checks if the broadcast is delivered on an entities.BroadcastChannel
+func (payload *BroadcastPublishedPayload) HasChannel(channel entities.BroadcastChannel) bool {
+	for _, item := range payload.Channels {
+		if item == string(channel) {
+			return true
+		}
+	}
+	return false
+}
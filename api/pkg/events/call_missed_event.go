@@ -0,0 +1,19 @@
+package events
+
+import (
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+)
+
+// EventTypeCallMissed is emitted when the phone reports a missed call
+const EventTypeCallMissed = "call.missed"
+
+// CallMissedPayload is the payload of the EventTypeCallMissed event
+type CallMissedPayload struct {
+	Owner     string          `json:"owner"`
+	Contact   string          `json:"contact"`
+	UserID    entities.UserID `json:"user_id"`
+	SIM       entities.SIM    `json:"sim"`
+	Timestamp time.Time       `json:"timestamp"`
+}
@@ -0,0 +1,23 @@
+package events
+
+import (
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/google/uuid"
+)
+
+// EventTypeCanaryFailed is emitted when a canary message does not complete the sent → delivered → received loop
+const EventTypeCanaryFailed = "canary.failed"
+
+// CanaryFailedPayload is the payload of the EventTypeCanaryFailed event
+type CanaryFailedPayload struct {
+	CanaryID  uuid.UUID                `json:"canary_id"`
+	RunID     uuid.UUID                `json:"run_id"`
+	UserID    entities.UserID          `json:"user_id"`
+	Owner     string                   `json:"owner"`
+	Contact   string                   `json:"contact"`
+	Status    entities.CanaryRunStatus `json:"status"`
+	Reason    string                   `json:"reason"`
+	Timestamp time.Time                `json:"timestamp"`
+}
@@ -0,0 +1,19 @@
+package events
+
+import (
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/google/uuid"
+)
+
+// EventTypeCanaryRunCheck is emitted when the timeout of an entities.CanaryRun is reached
+const EventTypeCanaryRunCheck = "canary.run.check"
+
+// CanaryRunCheckPayload is the payload of the EventTypeCanaryRunCheck event
+type CanaryRunCheckPayload struct {
+	CanaryID    uuid.UUID       `json:"canary_id"`
+	RunID       uuid.UUID       `json:"run_id"`
+	UserID      entities.UserID `json:"user_id"`
+	ScheduledAt time.Time       `json:"scheduled_at"`
+}
@@ -0,0 +1,18 @@
+package events
+
+import (
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/google/uuid"
+)
+
+// EventTypeCanaryRun is emitted when an entities.Canary should send a test message
+const EventTypeCanaryRun = "canary.run"
+
+// CanaryRunPayload is the payload of the EventTypeCanaryRun event
+type CanaryRunPayload struct {
+	CanaryID    uuid.UUID       `json:"canary_id"`
+	UserID      entities.UserID `json:"user_id"`
+	ScheduledAt time.Time       `json:"scheduled_at"`
+}
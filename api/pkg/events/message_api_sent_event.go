@@ -13,12 +13,23 @@ const EventTypeMessageAPISent = "message.api.sent"
 
 // MessageAPISentPayload is the payload of the EventTypeMessageSent event
 type MessageAPISentPayload struct {
-	MessageID         uuid.UUID       `json:"message_id"`
-	UserID            entities.UserID `json:"user_id"`
-	Owner             string          `json:"owner"`
-	MaxSendAttempts   uint            `json:"max_send_attempts"`
-	Contact           string          `json:"contact"`
-	RequestReceivedAt time.Time       `json:"request_received_at"`
-	Content           string          `json:"content"`
-	SIM               entities.SIM    `json:"sim"`
+	MessageID         uuid.UUID                `json:"message_id"`
+	UserID            entities.UserID          `json:"user_id"`
+	Owner             string                   `json:"owner"`
+	MaxSendAttempts   uint                     `json:"max_send_attempts"`
+	Contact           string                   `json:"contact"`
+	RequestReceivedAt time.Time                `json:"request_received_at"`
+	Content           string                   `json:"content"`
+	SIM               entities.SIM             `json:"sim"`
+	BatchID           *uuid.UUID               `json:"batch_id,omitempty"`
+	SendAt            *time.Time               `json:"send_at,omitempty"`
+	AttachmentCount   uint                     `json:"attachment_count"`
+	ConversationID    *string                  `json:"conversation_id,omitempty"`
+	ExpiresIn         *uint                    `json:"expires_in,omitempty"`
+	Priority          entities.MessagePriority `json:"priority,omitempty"`
+	AfterMessageID    *uuid.UUID               `json:"after_message_id,omitempty"`
+	TemplateID        *uuid.UUID               `json:"template_id,omitempty"`
+
+	// Attachments of an MMS message with signed download URLs
+	Attachments []*entities.Attachment `json:"attachments,omitempty"`
 }
@@ -0,0 +1,19 @@
+package events
+
+import (
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+
+	"github.com/google/uuid"
+)
+
+// EventTypeMessageDeliveryCheck is emitted to trigger checking if a sent message has received a delivery report
+const EventTypeMessageDeliveryCheck = "message.delivery.check"
+
+// MessageDeliveryCheckPayload is the payload of the EventTypeMessageDeliveryCheck event
+type MessageDeliveryCheckPayload struct {
+	MessageID   uuid.UUID       `json:"message_id"`
+	ScheduledAt time.Time       `json:"scheduled_at"`
+	UserID      entities.UserID `json:"user_id"`
+}
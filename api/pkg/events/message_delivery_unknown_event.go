@@ -0,0 +1,24 @@
+package events
+
+import (
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+
+	"github.com/google/uuid"
+)
+
+// EventTypeMessageDeliveryUnknown is emitted when a sent message does not get a delivery report within the delivery report timeout
+const EventTypeMessageDeliveryUnknown = "message.delivery.unknown"
+
+// MessageDeliveryUnknownPayload is the payload of the EventTypeMessageDeliveryUnknown event
+type MessageDeliveryUnknownPayload struct {
+	MessageID uuid.UUID       `json:"message_id"`
+	Owner     string          `json:"owner"`
+	Contact   string          `json:"contact"`
+	UserID    entities.UserID `json:"user_id"`
+	SentAt    time.Time       `json:"sent_at"`
+	Timestamp time.Time       `json:"timestamp"`
+	Content   string          `json:"content"`
+	SIM       entities.SIM    `json:"sim"`
+}
@@ -20,4 +20,10 @@ type MessagePhoneReceivedPayload struct {
 	Timestamp time.Time       `json:"timestamp"`
 	Content   string          `json:"content"`
 	SIM       entities.SIM    `json:"sim"`
+
+	// ConversationID is inherited from the last message sent to the contact within the conversation window
+	ConversationID *string `json:"conversation_id,omitempty"`
+
+	// Attachments of an MMS message with signed download URLs
+	Attachments []*entities.Attachment `json:"attachments,omitempty"`
 }
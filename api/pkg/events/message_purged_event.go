@@ -0,0 +1,27 @@
+package events
+
+import (
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+
+	"github.com/google/uuid"
+)
+
+// EventTypeMessagePurged is emitted when messages are deleted or anonymized by the retention policy of a user
+const EventTypeMessagePurged = "message.purged"
+
+// MessagePurgeActionDeleted means the messages were deleted
+const MessagePurgeActionDeleted = "deleted"
+
+// MessagePurgeActionAnonymized means the content of the messages was removed
+const MessagePurgeActionAnonymized = "anonymized"
+
+// MessagePurgedPayload is the payload of the EventTypeMessagePurged event
+type MessagePurgedPayload struct {
+	MessageIDs []uuid.UUID     `json:"message_ids"`
+	UserID     entities.UserID `json:"user_id"`
+	Action     string          `json:"action"`
+	Before     time.Time       `json:"before"`
+	Timestamp  time.Time       `json:"timestamp"`
+}
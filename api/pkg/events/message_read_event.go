@@ -0,0 +1,21 @@
+package events
+
+import (
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+
+	"github.com/google/uuid"
+)
+
+// EventTypeMessageRead is emitted when received messages are marked as read
+const EventTypeMessageRead = "message.read"
+
+// MessageReadPayload is the payload of the EventTypeMessageRead event
+type MessageReadPayload struct {
+	MessageIDs []uuid.UUID     `json:"message_ids"`
+	Owner      string          `json:"owner"`
+	Contact    string          `json:"contact"`
+	UserID     entities.UserID `json:"user_id"`
+	Timestamp  time.Time       `json:"timestamp"`
+}
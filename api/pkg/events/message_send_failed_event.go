@@ -12,12 +12,13 @@ const EventTypeMessageSendFailed = "message.send.failed"
 
 // MessageSendFailedPayload is the payload of the EventTypeMessageSendFailed event
 type MessageSendFailedPayload struct {
-	ID           uuid.UUID       `json:"id"`
-	ErrorMessage string          `json:"error_message"`
-	UserID       entities.UserID `json:"user_id"`
-	Owner        string          `json:"owner"`
-	Contact      string          `json:"contact"`
-	Timestamp    time.Time       `json:"timestamp"`
-	Content      string          `json:"content"`
-	SIM          entities.SIM    `json:"sim"`
+	ID           uuid.UUID                   `json:"id"`
+	ErrorMessage string                      `json:"error_message"`
+	FailureCode  entities.MessageFailureCode `json:"failure_code"`
+	UserID       entities.UserID             `json:"user_id"`
+	Owner        string                      `json:"owner"`
+	Contact      string                      `json:"contact"`
+	Timestamp    time.Time                   `json:"timestamp"`
+	Content      string                      `json:"content"`
+	SIM          entities.SIM                `json:"sim"`
 }
@@ -13,11 +13,12 @@ const EventTypeMessageSendRetry = "message.send.retry"
 
 // MessageSendRetryPayload is the payload of the EventTypeMessageSendRetry event
 type MessageSendRetryPayload struct {
-	MessageID uuid.UUID       `json:"message_id"`
-	Owner     string          `json:"owner"`
-	Contact   string          `json:"contact"`
-	UserID    entities.UserID `json:"user_id"`
-	Timestamp time.Time       `json:"timestamp"`
-	Content   string          `json:"content"`
-	SIM       entities.SIM    `json:"sim"`
+	MessageID uuid.UUID                `json:"message_id"`
+	Owner     string                   `json:"owner"`
+	Contact   string                   `json:"contact"`
+	UserID    entities.UserID          `json:"user_id"`
+	Timestamp time.Time                `json:"timestamp"`
+	Content   string                   `json:"content"`
+	SIM       entities.SIM             `json:"sim"`
+	Priority  entities.MessagePriority `json:"priority,omitempty"`
 }
@@ -0,0 +1,16 @@
+package events
+
+import (
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+)
+
+// EventTypeNotificationDigestSend is emitted when the alerts collected for a user are to be sent in a digest email
+const EventTypeNotificationDigestSend = "notification.digest.send"
+
+// NotificationDigestSendPayload is the payload of the EventTypeNotificationDigestSend event
+type NotificationDigestSendPayload struct {
+	UserID      entities.UserID `json:"user_id"`
+	ScheduledAt time.Time       `json:"scheduled_at"`
+}
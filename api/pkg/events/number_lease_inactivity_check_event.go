@@ -0,0 +1,19 @@
+package events
+
+import (
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+
+	"github.com/google/uuid"
+)
+
+// EventTypeNumberLeaseInactivityCheck is emitted to trigger checking if a number lease has been inactive
+const EventTypeNumberLeaseInactivityCheck = "number.lease.inactivity.check"
+
+// NumberLeaseInactivityCheckPayload is the payload of the EventTypeNumberLeaseInactivityCheck event
+type NumberLeaseInactivityCheckPayload struct {
+	LeaseID     uuid.UUID       `json:"lease_id"`
+	ScheduledAt time.Time       `json:"scheduled_at"`
+	UserID      entities.UserID `json:"user_id"`
+}
@@ -0,0 +1,20 @@
+package events
+
+import (
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/google/uuid"
+)
+
+// EventTypePhoneDeviceRegistered is emitted when a new phone or a new FCM token is registered on an account
+const EventTypePhoneDeviceRegistered = "phone.device.registered"
+
+// PhoneDeviceRegisteredPayload is the payload of the EventTypePhoneDeviceRegistered event
+type PhoneDeviceRegisteredPayload struct {
+	PhoneID    uuid.UUID       `json:"phone_id"`
+	UserID     entities.UserID `json:"user_id"`
+	Owner      string          `json:"owner"`
+	IsNewPhone bool            `json:"is_new_phone"`
+	Timestamp  time.Time       `json:"timestamp"`
+}
@@ -0,0 +1,23 @@
+package events
+
+import (
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+)
+
+// EventTypePhoneFilteringSuspected is emitted when most messages sent by a phone to a phone number prefix do not get a delivery report, which indicates silent carrier filtering
+const EventTypePhoneFilteringSuspected = "phone.filtering.suspected"
+
+// PhoneFilteringSuspectedPayload is the payload of the EventTypePhoneFilteringSuspected event
+type PhoneFilteringSuspectedPayload struct {
+	UserID              entities.UserID `json:"user_id"`
+	Owner               string          `json:"owner"`
+	Prefix              string          `json:"prefix"`
+	Sent                uint            `json:"sent"`
+	DeliveryUnknown     uint            `json:"delivery_unknown"`
+	DeliveryUnknownRate float64         `json:"delivery_unknown_rate"`
+	OwnerDeliveryRate   float64         `json:"owner_delivery_rate"`
+	SuggestedOwner      *string         `json:"suggested_owner"`
+	Timestamp           time.Time       `json:"timestamp"`
+}
@@ -0,0 +1,21 @@
+package events
+
+import (
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/google/uuid"
+)
+
+// EventTypePhoneKillSwitchUpdated is emitted when the kill switch of a phone is enabled or disabled
+const EventTypePhoneKillSwitchUpdated = "phone.kill-switch.updated"
+
+// PhoneKillSwitchUpdatedPayload is the payload of the EventTypePhoneKillSwitchUpdated event
+type PhoneKillSwitchUpdatedPayload struct {
+	PhoneID   uuid.UUID       `json:"phone_id"`
+	UserID    entities.UserID `json:"user_id"`
+	Owner     string          `json:"owner"`
+	Enabled   bool            `json:"enabled"`
+	Reason    *string         `json:"reason"`
+	Timestamp time.Time       `json:"timestamp"`
+}
@@ -0,0 +1,24 @@
+package events
+
+import (
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+)
+
+// EventTypePhoneReputationDegraded is emitted when the replies of contacts increase the risk of the phone number being blocked by carriers
+const EventTypePhoneReputationDegraded = "phone.reputation.degraded"
+
+// PhoneReputationDegradedPayload is the payload of the EventTypePhoneReputationDegraded event
+type PhoneReputationDegradedPayload struct {
+	UserID        entities.UserID              `json:"user_id"`
+	Owner         string                       `json:"owner"`
+	Score         int                          `json:"score"`
+	PreviousScore int                          `json:"previous_score"`
+	Risk          entities.PhoneReputationRisk `json:"risk"`
+	PreviousRisk  entities.PhoneReputationRisk `json:"previous_risk"`
+	Sent          uint                         `json:"sent"`
+	Negative      uint                         `json:"negative"`
+	Complaints    uint                         `json:"complaints"`
+	Timestamp     time.Time                    `json:"timestamp"`
+}
@@ -0,0 +1,19 @@
+package events
+
+import (
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+
+	"github.com/google/uuid"
+)
+
+// EventTypeProxySessionExpiredCheck is emitted to trigger checking if a proxy session is expired
+const EventTypeProxySessionExpiredCheck = "proxy.session.expired.check"
+
+// ProxySessionExpiredCheckPayload is the payload of the EventTypeProxySessionExpiredCheck event
+type ProxySessionExpiredCheckPayload struct {
+	SessionID   uuid.UUID       `json:"session_id"`
+	ScheduledAt time.Time       `json:"scheduled_at"`
+	UserID      entities.UserID `json:"user_id"`
+}
@@ -0,0 +1,18 @@
+package events
+
+import (
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+)
+
+// UserAccountDeleted is raised when the account and all the data of a user were deleted
+const UserAccountDeleted = "user.account.deleted"
+
+// UserAccountDeletedPayload stores the data for the UserAccountDeleted event
+type UserAccountDeletedPayload struct {
+	UserID      entities.UserID `json:"user_id"`
+	DeletedRows int64           `json:"deleted_rows"`
+	RequestedAt time.Time       `json:"requested_at"`
+	DeletedAt   time.Time       `json:"deleted_at"`
+}
@@ -0,0 +1,16 @@
+package events
+
+import (
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+)
+
+// UserAccountDeletionRequested is raised when a user requests the deletion of their account and all their data
+const UserAccountDeletionRequested = "user.account.deletion.requested"
+
+// UserAccountDeletionRequestedPayload stores the data for the UserAccountDeletionRequested event
+type UserAccountDeletionRequestedPayload struct {
+	UserID      entities.UserID `json:"user_id"`
+	RequestedAt time.Time       `json:"requested_at"`
+}
@@ -0,0 +1,18 @@
+package events
+
+import (
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+)
+
+// UserAccountReactivated is raised when the suspension of a user account is lifted
+const UserAccountReactivated = "user.account.reactivated"
+
+// UserAccountReactivatedPayload stores the data for the UserAccountReactivated event
+type UserAccountReactivatedPayload struct {
+	UserID        entities.UserID               `json:"user_id"`
+	Reason        entities.UserSuspensionReason `json:"reason"`
+	SuspendedAt   time.Time                     `json:"suspended_at"`
+	ReactivatedAt time.Time                     `json:"reactivated_at"`
+}
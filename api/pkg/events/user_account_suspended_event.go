@@ -0,0 +1,17 @@
+package events
+
+import (
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+)
+
+// UserAccountSuspended is raised when the account of a user is suspended
+const UserAccountSuspended = "user.account.suspended"
+
+// UserAccountSuspendedPayload stores the data for the UserAccountSuspended event
+type UserAccountSuspendedPayload struct {
+	UserID      entities.UserID               `json:"user_id"`
+	Reason      entities.UserSuspensionReason `json:"reason"`
+	SuspendedAt time.Time                     `json:"suspended_at"`
+}
@@ -0,0 +1,19 @@
+package events
+
+import (
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+)
+
+// UserSubscriptionPaymentFailed is raised when the renewal payment of a user subscription fails
+const UserSubscriptionPaymentFailed = "user.subscription.payment-failed"
+
+// UserSubscriptionPaymentFailedPayload stores the data for the UserSubscriptionPaymentFailed event
+type UserSubscriptionPaymentFailedPayload struct {
+	UserID             entities.UserID           `json:"user_id"`
+	SubscriptionID     string                    `json:"subscription_id"`
+	SubscriptionName   entities.SubscriptionName `json:"subscription_name"`
+	SubscriptionStatus string                    `json:"subscription_status"`
+	FailedAt           time.Time                 `json:"failed_at"`
+}
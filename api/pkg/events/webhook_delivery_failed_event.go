@@ -0,0 +1,28 @@
+package events
+
+import (
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/google/uuid"
+)
+
+// EventTypeWebhookDeliveryFailed is emitted when an event could not be sent to a webhook after all the attempts
+const EventTypeWebhookDeliveryFailed = "webhook.delivery.failed"
+
+// WebhookDeliveryFailedPayload is the payload of the EventTypeWebhookDeliveryFailed event
+type WebhookDeliveryFailedPayload struct {
+	WebhookID      uuid.UUID       `json:"webhook_id"`
+	DeliveryID     uuid.UUID       `json:"delivery_id"`
+	UserID         entities.UserID `json:"user_id"`
+	URL            string          `json:"url"`
+	EventID        string          `json:"event_id"`
+	EventType      string          `json:"event_type"`
+	Attempts       uint            `json:"attempts"`
+	LastStatusCode int             `json:"last_status_code"`
+	LastError      string          `json:"last_error"`
+	Timestamp      time.Time       `json:"timestamp"`
+
+	// RedeliverURL opens the failed delivery on the website where it can be replayed
+	RedeliverURL string `json:"redeliver_url"`
+}
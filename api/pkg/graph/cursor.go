@@ -0,0 +1,71 @@
+package graph
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	defaultFirst = 20
+	maxFirst     = 100
+)
+
+// offsetCursorPrefix is added to offset cursors so they can't be confused with the cursors of conversations
+const offsetCursorPrefix = "offset:"
+
+// errInvalidCursor is returned when the "after" argument was not returned by the API
+var errInvalidCursor = errors.New("the after cursor is not valid")
+
+// pageSize returns the number of edges which are requested with the "first" argument
+func pageSize(first *int32) (int, error) {
+	if first == nil {
+		return defaultFirst, nil
+	}
+
+	if *first < 1 || *first > maxFirst {
+		return 0, fmt.Errorf("first must be between 1 and %d", maxFirst)
+	}
+	return int(*first), nil
+}
+
+// encodeOffsetCursor encodes the number of rows before an edge into an opaque cursor
+func encodeOffsetCursor(offset int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(offsetCursorPrefix + strconv.Itoa(offset)))
+}
+
+// decodeOffsetCursor decodes a cursor which was encoded with encodeOffsetCursor, the offset is 0 without a cursor
+func decodeOffsetCursor(after *string) (int, error) {
+	if after == nil || *after == "" {
+		return 0, nil
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(*after)
+	if err != nil || !strings.HasPrefix(string(data), offsetCursorPrefix) {
+		return 0, errInvalidCursor
+	}
+
+	offset, err := strconv.Atoi(strings.TrimPrefix(string(data), offsetCursorPrefix))
+	if err != nil || offset < 0 {
+		return 0, errInvalidCursor
+	}
+	return offset, nil
+}
+
+// pageInfoResolver resolves the PageInfo type
+type pageInfoResolver struct {
+	hasNextPage bool
+	endCursor   *string
+}
+
+// HasNextPage is true when there are more edges after the end cursor
+func (r *pageInfoResolver) HasNextPage() bool {
+	return r.hasNextPage
+}
+
+// EndCursor is the cursor of the last edge, it is null when there are no edges
+func (r *pageInfoResolver) EndCursor() *string {
+	return r.endCursor
+}
@@ -0,0 +1,268 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/google/uuid"
+	"github.com/graph-gophers/graphql-go"
+	"github.com/palantir/stacktrace"
+)
+
+// Resolver resolves the fields of the Query type with the repositories of the authenticated user
+type Resolver struct {
+	logger            telemetry.Logger
+	tracer            telemetry.Tracer
+	messageRepository repositories.MessageRepository
+	contactRepository repositories.ContactRepository
+	phoneRepository   repositories.PhoneRepository
+}
+
+// NewResolver creates a new Resolver
+func NewResolver(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	messageRepository repositories.MessageRepository,
+	contactRepository repositories.ContactRepository,
+	phoneRepository repositories.PhoneRepository,
+) (r *Resolver) {
+	return &Resolver{
+		logger:            logger.WithService(fmt.Sprintf("%T", r)),
+		tracer:            tracer,
+		messageRepository: messageRepository,
+		contactRepository: contactRepository,
+		phoneRepository:   phoneRepository,
+	}
+}
+
+// MessagesArgs are the arguments of the messages field
+type MessagesArgs struct {
+	Owner   string
+	Contact string
+	Query   *string
+	First   *int32
+	After   *string
+}
+
+// Messages resolves the messages between an owner and a contact
+func (r *Resolver) Messages(ctx context.Context, args MessagesArgs) (*messageConnectionResolver, error) {
+	return r.messages(ctx, args.Owner, args.Contact, args.Query, args.First, args.After)
+}
+
+func (r *Resolver) messages(ctx context.Context, owner string, contact string, query *string, first *int32, after *string) (*messageConnectionResolver, error) {
+	ctx, span, ctxLogger := r.tracer.StartWithLogger(ctx, r.logger)
+	defer span.End()
+
+	limit, err := pageSize(first)
+	if err != nil {
+		return nil, err
+	}
+
+	offset, err := decodeOffsetCursor(after)
+	if err != nil {
+		return nil, err
+	}
+
+	params := repositories.IndexParams{Skip: offset, Limit: limit + 1, Query: r.value(query)}
+	messages, err := r.messageRepository.Index(ctx, userIDFromContext(ctx), owner, contact, params)
+	if err != nil {
+		ctxLogger.Error(stacktrace.Propagate(err, fmt.Sprintf("cannot fetch messages between [%s] and [%s] with params [%+#v]", owner, contact, params)))
+		return nil, errors.New("cannot fetch the messages")
+	}
+
+	connection := &messageConnectionResolver{edges: make([]*messageEdgeResolver, 0, limit), pageInfo: &pageInfoResolver{hasNextPage: len(*messages) > limit}}
+	for index := range *messages {
+		if index == limit {
+			break
+		}
+		connection.edges = append(connection.edges, &messageEdgeResolver{
+			cursor: encodeOffsetCursor(offset + index + 1),
+			node:   &messageResolver{message: &(*messages)[index]},
+		})
+	}
+
+	if len(connection.edges) > 0 {
+		connection.pageInfo.endCursor = &connection.edges[len(connection.edges)-1].cursor
+	}
+	return connection, nil
+}
+
+// ConversationsArgs are the arguments of the conversations field
+type ConversationsArgs struct {
+	Owner *string
+	First *int32
+	After *string
+}
+
+// Conversations resolves the conversations of the user with the most recent message first
+func (r *Resolver) Conversations(ctx context.Context, args ConversationsArgs) (*conversationConnectionResolver, error) {
+	ctx, span, ctxLogger := r.tracer.StartWithLogger(ctx, r.logger)
+	defer span.End()
+
+	limit, err := pageSize(args.First)
+	if err != nil {
+		return nil, err
+	}
+
+	params := repositories.ConversationIndexParams{Owner: r.value(args.Owner), Limit: limit + 1}
+	if after := r.value(args.After); after != "" {
+		if params.Cursor, err = services.DecodeEventCursor(after); err != nil {
+			return nil, errInvalidCursor
+		}
+	}
+
+	conversations, err := r.messageRepository.IndexConversations(ctx, userIDFromContext(ctx), params)
+	if err != nil {
+		ctxLogger.Error(stacktrace.Propagate(err, fmt.Sprintf("cannot fetch conversations with params [%+#v]", params)))
+		return nil, errors.New("cannot fetch the conversations")
+	}
+
+	connection := &conversationConnectionResolver{edges: make([]*conversationEdgeResolver, 0, limit), pageInfo: &pageInfoResolver{hasNextPage: len(conversations) > limit}}
+	for index, conversation := range conversations {
+		if index == limit {
+			break
+		}
+		connection.edges = append(connection.edges, &conversationEdgeResolver{
+			cursor: services.EncodeEventCursor(repositories.EventCursor{Time: conversation.LastMessageAt, ID: conversation.LastMessageID}),
+			node:   &conversationResolver{root: r, conversation: conversation},
+		})
+	}
+
+	if len(connection.edges) > 0 {
+		connection.pageInfo.endCursor = &connection.edges[len(connection.edges)-1].cursor
+	}
+	return connection, nil
+}
+
+// ContactsArgs are the arguments of the contacts field
+type ContactsArgs struct {
+	Query      *string
+	IsOptedOut *bool
+	GroupID    *graphql.ID
+	SortBy     *string
+	First      *int32
+	After      *string
+}
+
+// Contacts resolves the contacts of the user
+func (r *Resolver) Contacts(ctx context.Context, args ContactsArgs) (*contactConnectionResolver, error) {
+	ctx, span, ctxLogger := r.tracer.StartWithLogger(ctx, r.logger)
+	defer span.End()
+
+	limit, err := pageSize(args.First)
+	if err != nil {
+		return nil, err
+	}
+
+	offset, err := decodeOffsetCursor(args.After)
+	if err != nil {
+		return nil, err
+	}
+
+	params := repositories.ContactIndexParams{
+		IndexParams: repositories.IndexParams{Skip: offset, Limit: limit + 1, Query: r.value(args.Query)},
+		IsOptedOut:  args.IsOptedOut,
+		SortBy:      r.value(args.SortBy),
+	}
+
+	if args.GroupID != nil {
+		groupID, err := uuid.Parse(string(*args.GroupID))
+		if err != nil {
+			return nil, errors.New("groupId must be a valid UUID")
+		}
+		params.GroupID = &groupID
+	}
+
+	contacts, err := r.contactRepository.Index(ctx, userIDFromContext(ctx), params)
+	if err != nil {
+		ctxLogger.Error(stacktrace.Propagate(err, fmt.Sprintf("cannot fetch contacts with params [%+#v]", params)))
+		return nil, errors.New("cannot fetch the contacts")
+	}
+
+	connection := &contactConnectionResolver{root: r, params: params, edges: make([]*contactEdgeResolver, 0, limit), pageInfo: &pageInfoResolver{hasNextPage: len(contacts) > limit}}
+	for index, contact := range contacts {
+		if index == limit {
+			break
+		}
+		connection.edges = append(connection.edges, &contactEdgeResolver{
+			cursor: encodeOffsetCursor(offset + index + 1),
+			node:   &contactResolver{contact: contact},
+		})
+	}
+
+	if len(connection.edges) > 0 {
+		connection.pageInfo.endCursor = &connection.edges[len(connection.edges)-1].cursor
+	}
+	return connection, nil
+}
+
+// PhonesArgs are the arguments of the phones field
+type PhonesArgs struct {
+	Query *string
+	First *int32
+	After *string
+}
+
+// Phones resolves the phones of the user
+func (r *Resolver) Phones(ctx context.Context, args PhonesArgs) (*phoneConnectionResolver, error) {
+	ctx, span, ctxLogger := r.tracer.StartWithLogger(ctx, r.logger)
+	defer span.End()
+
+	limit, err := pageSize(args.First)
+	if err != nil {
+		return nil, err
+	}
+
+	offset, err := decodeOffsetCursor(args.After)
+	if err != nil {
+		return nil, err
+	}
+
+	params := repositories.IndexParams{Skip: offset, Limit: limit + 1, Query: r.value(args.Query)}
+	phones, err := r.phoneRepository.Index(ctx, userIDFromContext(ctx), params)
+	if err != nil {
+		ctxLogger.Error(stacktrace.Propagate(err, fmt.Sprintf("cannot fetch phones with params [%+#v]", params)))
+		return nil, errors.New("cannot fetch the phones")
+	}
+
+	connection := &phoneConnectionResolver{edges: make([]*phoneEdgeResolver, 0, limit), pageInfo: &pageInfoResolver{hasNextPage: len(*phones) > limit}}
+	for index := range *phones {
+		if index == limit {
+			break
+		}
+		connection.edges = append(connection.edges, &phoneEdgeResolver{
+			cursor: encodeOffsetCursor(offset + index + 1),
+			node:   &phoneResolver{phone: &(*phones)[index]},
+		})
+	}
+
+	if len(connection.edges) > 0 {
+		connection.pageInfo.endCursor = &connection.edges[len(connection.edges)-1].cursor
+	}
+	return connection, nil
+}
+
+// contactCount counts the contacts which match the filters of a contacts query
+func (r *Resolver) contactCount(ctx context.Context, params repositories.ContactIndexParams) (int32, error) {
+	ctx, span, ctxLogger := r.tracer.StartWithLogger(ctx, r.logger)
+	defer span.End()
+
+	count, err := r.contactRepository.Count(ctx, userIDFromContext(ctx), params)
+	if err != nil {
+		ctxLogger.Error(stacktrace.Propagate(err, fmt.Sprintf("cannot count contacts with params [%+#v]", params)))
+		return 0, errors.New("cannot count the contacts")
+	}
+	return int32(count), nil
+}
+
+func (r *Resolver) value(input *string) string {
+	if input == nil {
+		return ""
+	}
+	return strings.TrimSpace(*input)
+}
@@ -0,0 +1,44 @@
+package graph
+
+import (
+	"context"
+
+	// embed is used to load the schema.graphql file
+	_ "embed"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/graph-gophers/graphql-go"
+	"github.com/palantir/stacktrace"
+)
+
+// maxDepth is the maximum depth of the selections in a query
+const maxDepth = 6
+
+//go:embed schema.graphql
+var schema string
+
+type contextKey string
+
+const contextKeyUserID = contextKey("user-id")
+
+// NewSchema parses the GraphQL schema and binds it to the Resolver
+func NewSchema(resolver *Resolver) (*graphql.Schema, error) {
+	result, err := graphql.ParseSchema(schema, resolver, graphql.MaxDepth(maxDepth))
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "cannot parse the graphql schema")
+	}
+	return result, nil
+}
+
+// WithUserID sets the entities.UserID whose data is queried
+func WithUserID(ctx context.Context, userID entities.UserID) context.Context {
+	return context.WithValue(ctx, contextKeyUserID, userID)
+}
+
+// userIDFromContext returns the entities.UserID which was set with WithUserID
+func userIDFromContext(ctx context.Context) entities.UserID {
+	if userID, ok := ctx.Value(contextKeyUserID).(entities.UserID); ok {
+		return userID
+	}
+	return ""
+}
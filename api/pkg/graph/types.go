@@ -0,0 +1,336 @@
+package graph
+
+import (
+	"context"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
+	"github.com/graph-gophers/graphql-go"
+)
+
+// toTime converts an optional time.Time to a nullable Time scalar
+func toTime(value *time.Time) *graphql.Time {
+	if value == nil {
+		return nil
+	}
+	return &graphql.Time{Time: *value}
+}
+
+// messageResolver resolves the Message type
+type messageResolver struct {
+	message *entities.Message
+}
+
+// ID of the message
+func (r *messageResolver) ID() graphql.ID { return graphql.ID(r.message.ID.String()) }
+
+// Owner is the phone number of the phone
+func (r *messageResolver) Owner() string { return r.message.Owner }
+
+// Contact is the phone number of the contact
+func (r *messageResolver) Contact() string { return r.message.Contact }
+
+// Content of the message
+func (r *messageResolver) Content() string { return r.message.Content }
+
+// Type of the message
+func (r *messageResolver) Type() string { return string(r.message.Type) }
+
+// Status of the message
+func (r *messageResolver) Status() string { return string(r.message.Status) }
+
+// Sim is the SIM card used to send the message
+func (r *messageResolver) Sim() string { return string(r.message.SIM) }
+
+// Priority of the message
+func (r *messageResolver) Priority() string { return string(r.message.Priority.OrDefault()) }
+
+// FailureReason is why the message failed
+func (r *messageResolver) FailureReason() *string { return r.message.FailureReason }
+
+// FailureCode is the structured reason why the message failed
+func (r *messageResolver) FailureCode() *string {
+	if r.message.FailureCode == nil {
+		return nil
+	}
+	code := string(*r.message.FailureCode)
+	return &code
+}
+
+// SendAttemptCount is the number of times the phone tried to send the message
+func (r *messageResolver) SendAttemptCount() int32 { return int32(r.message.SendAttemptCount) }
+
+// ScheduledAt is when the phone was notified about the message
+func (r *messageResolver) ScheduledAt() *graphql.Time {
+	return toTime(r.message.NotificationScheduledAt)
+}
+
+// SentAt is when the message was sent
+func (r *messageResolver) SentAt() *graphql.Time { return toTime(r.message.SentAt) }
+
+// DeliveredAt is when the message was delivered
+func (r *messageResolver) DeliveredAt() *graphql.Time { return toTime(r.message.DeliveredAt) }
+
+// FailedAt is when the message failed
+func (r *messageResolver) FailedAt() *graphql.Time { return toTime(r.message.FailedAt) }
+
+// ReceivedAt is when the message was received
+func (r *messageResolver) ReceivedAt() *graphql.Time { return toTime(r.message.ReceivedAt) }
+
+// ReadAt is when the message was marked as read
+func (r *messageResolver) ReadAt() *graphql.Time { return toTime(r.message.ReadAt) }
+
+// CreatedAt is when the message was created
+func (r *messageResolver) CreatedAt() graphql.Time { return graphql.Time{Time: r.message.CreatedAt} }
+
+// UpdatedAt is when the message was last updated
+func (r *messageResolver) UpdatedAt() graphql.Time { return graphql.Time{Time: r.message.UpdatedAt} }
+
+// messageEdgeResolver resolves the MessageEdge type
+type messageEdgeResolver struct {
+	cursor string
+	node   *messageResolver
+}
+
+// Cursor of the message
+func (r *messageEdgeResolver) Cursor() string { return r.cursor }
+
+// Node is the message
+func (r *messageEdgeResolver) Node() *messageResolver { return r.node }
+
+// messageConnectionResolver resolves the MessageConnection type
+type messageConnectionResolver struct {
+	edges    []*messageEdgeResolver
+	pageInfo *pageInfoResolver
+}
+
+// Edges of the page
+func (r *messageConnectionResolver) Edges() []*messageEdgeResolver { return r.edges }
+
+// PageInfo of the page
+func (r *messageConnectionResolver) PageInfo() *pageInfoResolver { return r.pageInfo }
+
+// conversationResolver resolves the Conversation type
+type conversationResolver struct {
+	root         *Resolver
+	conversation *entities.Conversation
+}
+
+// Owner is the phone number of the phone
+func (r *conversationResolver) Owner() string { return r.conversation.Owner }
+
+// Contact is the phone number of the contact
+func (r *conversationResolver) Contact() string { return r.conversation.Contact }
+
+// LastMessageID is the ID of the most recent message
+func (r *conversationResolver) LastMessageID() graphql.ID {
+	return graphql.ID(r.conversation.LastMessageID.String())
+}
+
+// LastMessageContent is the content of the most recent message
+func (r *conversationResolver) LastMessageContent() string {
+	return r.conversation.LastMessageContent
+}
+
+// LastMessageType is the type of the most recent message
+func (r *conversationResolver) LastMessageType() string {
+	return string(r.conversation.LastMessageType)
+}
+
+// LastMessageStatus is the status of the most recent message
+func (r *conversationResolver) LastMessageStatus() string {
+	return string(r.conversation.LastMessageStatus)
+}
+
+// UnreadCount is the number of unread messages from the contact
+func (r *conversationResolver) UnreadCount() int32 { return int32(r.conversation.UnreadCount) }
+
+// MessageCount is the number of messages in the conversation
+func (r *conversationResolver) MessageCount() int32 { return int32(r.conversation.MessageCount) }
+
+// FirstMessageAt is the time of the oldest message
+func (r *conversationResolver) FirstMessageAt() graphql.Time {
+	return graphql.Time{Time: r.conversation.FirstMessageAt}
+}
+
+// LastMessageAt is the time of the most recent message
+func (r *conversationResolver) LastMessageAt() graphql.Time {
+	return graphql.Time{Time: r.conversation.LastMessageAt}
+}
+
+// ConversationMessagesArgs are the arguments of the messages field of a conversation
+type ConversationMessagesArgs struct {
+	First *int32
+	After *string
+}
+
+// Messages resolves the messages of the conversation
+func (r *conversationResolver) Messages(ctx context.Context, args ConversationMessagesArgs) (*messageConnectionResolver, error) {
+	return r.root.messages(ctx, r.conversation.Owner, r.conversation.Contact, nil, args.First, args.After)
+}
+
+// conversationEdgeResolver resolves the ConversationEdge type
+type conversationEdgeResolver struct {
+	cursor string
+	node   *conversationResolver
+}
+
+// Cursor of the conversation
+func (r *conversationEdgeResolver) Cursor() string { return r.cursor }
+
+// Node is the conversation
+func (r *conversationEdgeResolver) Node() *conversationResolver { return r.node }
+
+// conversationConnectionResolver resolves the ConversationConnection type
+type conversationConnectionResolver struct {
+	edges    []*conversationEdgeResolver
+	pageInfo *pageInfoResolver
+}
+
+// Edges of the page
+func (r *conversationConnectionResolver) Edges() []*conversationEdgeResolver { return r.edges }
+
+// PageInfo of the page
+func (r *conversationConnectionResolver) PageInfo() *pageInfoResolver { return r.pageInfo }
+
+// contactResolver resolves the Contact type
+type contactResolver struct {
+	contact *entities.Contact
+}
+
+// ID of the contact
+func (r *contactResolver) ID() graphql.ID { return graphql.ID(r.contact.ID.String()) }
+
+// PhoneNumber of the contact
+func (r *contactResolver) PhoneNumber() string { return r.contact.PhoneNumber }
+
+// Name of the contact
+func (r *contactResolver) Name() string { return r.contact.Name }
+
+// Tags of the contact
+func (r *contactResolver) Tags() []string {
+	if r.contact.Tags == nil {
+		return []string{}
+	}
+	return r.contact.Tags
+}
+
+// MessagesSent is the number of messages sent to the contact
+func (r *contactResolver) MessagesSent() int32 { return int32(r.contact.MessagesSent) }
+
+// MessagesReceived is the number of messages received from the contact
+func (r *contactResolver) MessagesReceived() int32 { return int32(r.contact.MessagesReceived) }
+
+// Replies is the number of replies from the contact
+func (r *contactResolver) Replies() int32 { return int32(r.contact.Replies) }
+
+// ReplyRate is the fraction of sent messages which got a reply
+func (r *contactResolver) ReplyRate() float64 { return r.contact.ReplyRate }
+
+// LastSentAt is when the last message was sent to the contact
+func (r *contactResolver) LastSentAt() *graphql.Time { return toTime(r.contact.LastSentAt) }
+
+// LastReplyAt is when the contact last replied
+func (r *contactResolver) LastReplyAt() *graphql.Time { return toTime(r.contact.LastReplyAt) }
+
+// IsOptedOut is true when the contact opted out of messages
+func (r *contactResolver) IsOptedOut() bool { return r.contact.IsOptedOut }
+
+// OptedOutAt is when the contact opted out
+func (r *contactResolver) OptedOutAt() *graphql.Time { return toTime(r.contact.OptedOutAt) }
+
+// CreatedAt is when the contact was created
+func (r *contactResolver) CreatedAt() graphql.Time { return graphql.Time{Time: r.contact.CreatedAt} }
+
+// UpdatedAt is when the contact was last updated
+func (r *contactResolver) UpdatedAt() graphql.Time { return graphql.Time{Time: r.contact.UpdatedAt} }
+
+// contactEdgeResolver resolves the ContactEdge type
+type contactEdgeResolver struct {
+	cursor string
+	node   *contactResolver
+}
+
+// Cursor of the contact
+func (r *contactEdgeResolver) Cursor() string { return r.cursor }
+
+// Node is the contact
+func (r *contactEdgeResolver) Node() *contactResolver { return r.node }
+
+// contactConnectionResolver resolves the ContactConnection type
+type contactConnectionResolver struct {
+	root     *Resolver
+	params   repositories.ContactIndexParams
+	edges    []*contactEdgeResolver
+	pageInfo *pageInfoResolver
+}
+
+// Edges of the page
+func (r *contactConnectionResolver) Edges() []*contactEdgeResolver { return r.edges }
+
+// PageInfo of the page
+func (r *contactConnectionResolver) PageInfo() *pageInfoResolver { return r.pageInfo }
+
+// TotalCount is the number of contacts which match the filters, it is only counted when it is selected
+func (r *contactConnectionResolver) TotalCount(ctx context.Context) (int32, error) {
+	return r.root.contactCount(ctx, r.params)
+}
+
+// phoneResolver resolves the Phone type
+type phoneResolver struct {
+	phone *entities.Phone
+}
+
+// ID of the phone
+func (r *phoneResolver) ID() graphql.ID { return graphql.ID(r.phone.ID.String()) }
+
+// PhoneNumber of the phone
+func (r *phoneResolver) PhoneNumber() string { return r.phone.PhoneNumber }
+
+// MessagesPerMinute is the send rate of the phone
+func (r *phoneResolver) MessagesPerMinute() int32 { return int32(r.phone.MessagesPerMinute) }
+
+// IsDualSim is true when the phone has 2 SIM cards
+func (r *phoneResolver) IsDualSim() bool { return r.phone.IsDualSIM }
+
+// MaxSendAttempts is how many times a message is retried
+func (r *phoneResolver) MaxSendAttempts() int32 { return int32(r.phone.MaxSendAttempts) }
+
+// Transport is how the phone is notified about new messages
+func (r *phoneResolver) Transport() string { return string(r.phone.Transport) }
+
+// KillSwitchEnabledAt is set while the phone is stopped from sending messages
+func (r *phoneResolver) KillSwitchEnabledAt() *graphql.Time {
+	return toTime(r.phone.KillSwitchEnabledAt)
+}
+
+// CreatedAt is when the phone was created
+func (r *phoneResolver) CreatedAt() graphql.Time { return graphql.Time{Time: r.phone.CreatedAt} }
+
+// UpdatedAt is when the phone was last updated
+func (r *phoneResolver) UpdatedAt() graphql.Time { return graphql.Time{Time: r.phone.UpdatedAt} }
+
+// phoneEdgeResolver resolves the PhoneEdge type
+type phoneEdgeResolver struct {
+	cursor string
+	node   *phoneResolver
+}
+
+// Cursor of the phone
+func (r *phoneEdgeResolver) Cursor() string { return r.cursor }
+
+// Node is the phone
+func (r *phoneEdgeResolver) Node() *phoneResolver { return r.node }
+
+// phoneConnectionResolver resolves the PhoneConnection type
+type phoneConnectionResolver struct {
+	edges    []*phoneEdgeResolver
+	pageInfo *pageInfoResolver
+}
+
+// Edges of the page
+func (r *phoneConnectionResolver) Edges() []*phoneEdgeResolver { return r.edges }
+
+// PageInfo of the page
+func (r *phoneConnectionResolver) PageInfo() *pageInfoResolver { return r.pageInfo }
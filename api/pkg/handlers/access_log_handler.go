@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"fmt"
+
+	"github.com/NdoleStudio/httpsms/pkg/requests"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/NdoleStudio/httpsms/pkg/validators"
+	"github.com/davecgh/go-spew/spew"
+	"github.com/gofiber/fiber/v2"
+	"github.com/palantir/stacktrace"
+)
+
+// AccessLogHandler handles access log requests
+type AccessLogHandler struct {
+	handler
+	logger          telemetry.Logger
+	tracer          telemetry.Tracer
+	service         *services.AccessLogService
+	operatorService *services.OperatorService
+	validator       *validators.AccessLogHandlerValidator
+}
+
+// NewAccessLogHandler creates a new AccessLogHandler
+func NewAccessLogHandler(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	service *services.AccessLogService,
+	operatorService *services.OperatorService,
+	validator *validators.AccessLogHandlerValidator,
+) (h *AccessLogHandler) {
+	return &AccessLogHandler{
+		logger:          logger.WithService(fmt.Sprintf("%T", h)),
+		tracer:          tracer,
+		service:         service,
+		operatorService: operatorService,
+		validator:       validator,
+	}
+}
+
+// RegisterRoutes registers the routes for the AccessLogHandler
+func (h *AccessLogHandler) RegisterRoutes(app *fiber.App, middlewares ...fiber.Handler) {
+	router := app.Group("/v1/operator/access-logs")
+	router.Get("/", h.computeRoute(middlewares, h.Index)...)
+}
+
+// Index returns the recent requests made to the API
+// @Summary      Get access logs
+// @Description  Get the recent requests made to the API with their status, latency and truncated request body. Credentials are redacted and the bodies of the /v1/auth and /v1/two-factor requests are not recorded. Requests are only recorded when ACCESS_LOG_ENABLED=true and they are deleted after the retention period. Only operators listed in OPERATOR_USER_IDS can use this endpoint.
+// @Security	 ApiKeyAuth
+// @Tags         AccessLogs
+// @Accept       json
+// @Produce      json
+// @Param        skip		query  int  	false	"number of access logs to skip"			minimum(0)
+// @Param        query		query  string  	false 	"filter access logs whose path contains query"
+// @Param        user_id	query  string  	false 	"filter access logs of a user"
+// @Param        status		query  int  	false 	"filter access logs by HTTP status code"	minimum(100)	maximum(599)
+// @Param        limit		query  int  	false	"number of access logs to return"		minimum(1)	maximum(100)
+// @Success      200 		{object}	responses.AccessLogsResponse
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401	    {object}	responses.Unauthorized
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /operator/access-logs 	[get]
+func (h *AccessLogHandler) Index(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	if !h.operatorService.IsOperator(h.userIDFomContext(c)) {
+		ctxLogger.Warn(stacktrace.NewError(fmt.Sprintf("user [%s] is not an operator and cannot fetch access logs", h.userIDFomContext(c))))
+		return h.responseForbidden(c)
+	}
+
+	var request requests.AccessLogIndex
+	if err := c.QueryParser(&request); err != nil {
+		msg := fmt.Sprintf("cannot marshall URL [%s] into %T", c.OriginalURL(), request)
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return h.responseBadRequest(c, err)
+	}
+
+	if errors := h.validator.ValidateIndex(ctx, request.Sanitize()); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while fetching access logs [%+#v]", spew.Sdump(errors), request)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while fetching access logs")
+	}
+
+	logs, err := h.service.Index(ctx, request.ToIndexParams())
+	if err != nil {
+		msg := fmt.Sprintf("cannot get access logs with params [%+#v]", request)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseOK(c, fmt.Sprintf("fetched %d access %s", len(logs), h.pluralize("log", len(logs))), logs)
+}
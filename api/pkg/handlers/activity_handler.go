@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"fmt"
+
+	"github.com/NdoleStudio/httpsms/pkg/requests"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/NdoleStudio/httpsms/pkg/validators"
+	"github.com/davecgh/go-spew/spew"
+	"github.com/gofiber/fiber/v2"
+	"github.com/palantir/stacktrace"
+)
+
+// ActivityHandler handles activity feed requests
+type ActivityHandler struct {
+	handler
+	logger    telemetry.Logger
+	tracer    telemetry.Tracer
+	service   *services.ActivityService
+	validator *validators.ActivityHandlerValidator
+}
+
+// NewActivityHandler creates a new ActivityHandler
+func NewActivityHandler(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	service *services.ActivityService,
+	validator *validators.ActivityHandlerValidator,
+) (h *ActivityHandler) {
+	return &ActivityHandler{
+		logger:    logger.WithService(fmt.Sprintf("%T", h)),
+		tracer:    tracer,
+		service:   service,
+		validator: validator,
+	}
+}
+
+// RegisterRoutes registers the routes for the ActivityHandler
+func (h *ActivityHandler) RegisterRoutes(app *fiber.App, middlewares ...fiber.Handler) {
+	app.Get("/v1/activity", h.computeRoute(middlewares, h.Index)...)
+}
+
+// Index returns the activity feed of a user
+// @Summary      Get the activity feed
+// @Description  Get a timeline of message events, phone status changes, webhook failures and account changes from the newest to the oldest. Use the next_cursor of a page as the cursor to fetch the next page.
+// @Security	 ApiKeyAuth
+// @Tags         Activity
+// @Produce      json
+// @Param        category	query  string  	false	"comma separated list of categories"	Enums(message, phone, webhook, account)
+// @Param        cursor		query  string  	false	"next_cursor of the previous page"
+// @Param        limit		query  int  	false	"number of items to return"	minimum(1)	maximum(100)
+// @Success      200 		{object}	responses.ActivityResponse
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401	    {object}	responses.Unauthorized
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /activity 	[get]
+func (h *ActivityHandler) Index(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	var request requests.ActivityIndex
+	if err := c.QueryParser(&request); err != nil {
+		msg := fmt.Sprintf("cannot marshall URL [%s] into %T", c.OriginalURL(), request)
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return h.responseBadRequest(c, err)
+	}
+
+	if errors := h.validator.ValidateIndex(ctx, request.Sanitize()); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while fetching activity [%+#v]", spew.Sdump(errors), request)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while fetching activity")
+	}
+
+	page, err := h.service.Index(ctx, h.userIDFomContext(c), request.ToIndexParams())
+	if err != nil {
+		msg := fmt.Sprintf("cannot get activity with params [%+#v]", request)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseOK(c, fmt.Sprintf("fetched %d activity %s", len(page.Items), h.pluralize("item", len(page.Items))), page)
+}
@@ -0,0 +1,220 @@
+package handlers
+
+import (
+	"fmt"
+
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
+	"github.com/NdoleStudio/httpsms/pkg/requests"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/NdoleStudio/httpsms/pkg/validators"
+	"github.com/davecgh/go-spew/spew"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/palantir/stacktrace"
+)
+
+// AlertChannelHandler handles requests for the slack and discord channels which receive notifications about events
+type AlertChannelHandler struct {
+	handler
+	logger    telemetry.Logger
+	tracer    telemetry.Tracer
+	service   *services.AlertChannelService
+	validator *validators.AlertChannelHandlerValidator
+}
+
+// NewAlertChannelHandler creates a new AlertChannelHandler
+func NewAlertChannelHandler(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	service *services.AlertChannelService,
+	validator *validators.AlertChannelHandlerValidator,
+) (h *AlertChannelHandler) {
+	return &AlertChannelHandler{
+		logger:    logger.WithService(fmt.Sprintf("%T", h)),
+		tracer:    tracer,
+		service:   service,
+		validator: validator,
+	}
+}
+
+// RegisterRoutes registers the routes for the AlertChannelHandler
+func (h *AlertChannelHandler) RegisterRoutes(app *fiber.App, middlewares ...fiber.Handler) {
+	router := app.Group("/v1/alert-channels")
+	router.Get("/", h.computeRoute(middlewares, h.Index)...)
+	router.Post("/", h.computeRoute(middlewares, h.Store)...)
+	router.Put("/:channelID", h.computeRoute(middlewares, h.Update)...)
+	router.Delete("/:channelID", h.computeRoute(middlewares, h.Delete)...)
+}
+
+// Index returns the alert channels of a user
+// @Summary      Get alert channels of a user
+// @Description  Get the slack and discord channels which receive notifications about the events of a user
+// @Security	 ApiKeyAuth
+// @Tags         AlertChannels
+// @Accept       json
+// @Produce      json
+// @Param        skip		query  int  	false	"number of alert channels to skip"		minimum(0)
+// @Param        query		query  string  	false 	"filter alert channels containing query"
+// @Param        limit		query  int  	false	"number of alert channels to return"	minimum(1)	maximum(100)
+// @Success      200 		{object}	responses.AlertChannelsResponse
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401	    {object}	responses.Unauthorized
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /alert-channels 	[get]
+func (h *AlertChannelHandler) Index(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	var request requests.AlertChannelIndex
+	if err := c.QueryParser(&request); err != nil {
+		msg := fmt.Sprintf("cannot marshall URL [%s] into %T", c.OriginalURL(), request)
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return h.responseBadRequest(c, err)
+	}
+
+	if errors := h.validator.ValidateIndex(ctx, request.Sanitize()); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while fetching alert channels [%+#v]", spew.Sdump(errors), request)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while fetching alert channels")
+	}
+
+	channels, err := h.service.Index(ctx, h.userIDFomContext(c), request.ToIndexParams())
+	if err != nil {
+		msg := fmt.Sprintf("cannot get alert channels with params [%+#v]", request)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseOK(c, fmt.Sprintf("fetched %d %s", len(channels), h.pluralize("alert channel", len(channels))), channels)
+}
+
+// Store an alert channel
+// @Summary      Store an alert channel
+// @Description  Create a slack or discord channel which receives a notification when one of the events happens. The supported events are message.send.failed, phone.heartbeat.dead and message.phone.received.
+// @Security	 ApiKeyAuth
+// @Tags         AlertChannels
+// @Accept       json
+// @Produce      json
+// @Param        payload   	body 		requests.AlertChannelStore  		true "Payload of the alert channel request"
+// @Success      201 		{object}	responses.AlertChannelResponse
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401	    {object}	responses.Unauthorized
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /alert-channels [post]
+func (h *AlertChannelHandler) Store(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	var request requests.AlertChannelStore
+	if err := c.BodyParser(&request); err != nil {
+		msg := fmt.Sprintf("cannot marshall body [%s] into [%T]", c.Body(), request)
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return h.responseBadRequest(c, err)
+	}
+
+	if errors := h.validator.ValidateStore(ctx, request.Sanitize()); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while storing alert channel [%+#v]", spew.Sdump(errors), request)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while storing alert channel")
+	}
+
+	channel, err := h.service.Store(ctx, request.ToStoreParams(h.userFromContext(c)))
+	if err != nil {
+		msg := fmt.Sprintf("cannot store alert channel with params [%+#v]", request)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseCreated(c, "alert channel created successfully", channel)
+}
+
+// Update an alert channel
+// @Summary      Update an alert channel
+// @Description  Update the webhook URL and events of an alert channel or enable/disable it
+// @Security	 ApiKeyAuth
+// @Tags         AlertChannels
+// @Accept       json
+// @Produce      json
+// @Param 		 channelID 	path		string 							true 	"ID of the alert channel"	default(32343a19-da5e-4b1b-a767-3298a73703ca)
+// @Param        payload   	body 		requests.AlertChannelUpdate  			true "Payload of the alert channel update request"
+// @Success      200 		{object}	responses.AlertChannelResponse
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401	    {object}	responses.Unauthorized
+// @Failure 	 404    	{object}	responses.NotFound
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /alert-channels/{channelID} [put]
+func (h *AlertChannelHandler) Update(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	var request requests.AlertChannelUpdate
+	if err := c.BodyParser(&request); err != nil {
+		msg := fmt.Sprintf("cannot marshall body [%s] into [%T]", c.Body(), request)
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return h.responseBadRequest(c, err)
+	}
+
+	request.ChannelID = c.Params("channelID")
+	if errors := h.validator.ValidateUpdate(ctx, request.Sanitize()); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while updating alert channel [%+#v]", spew.Sdump(errors), request)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while updating alert channel")
+	}
+
+	channel, err := h.service.Update(ctx, request.ToUpdateParams(h.userFromContext(c)))
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		return h.responseNotFound(c, fmt.Sprintf("cannot find alert channel with ID [%s]", request.ChannelID))
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot update alert channel with params [%+#v]", request)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseOK(c, "alert channel updated successfully", channel)
+}
+
+// Delete an alert channel
+// @Summary      Delete an alert channel
+// @Description  Delete an alert channel of a user
+// @Security	 ApiKeyAuth
+// @Tags         AlertChannels
+// @Accept       json
+// @Produce      json
+// @Param 		 channelID 	path		string 							true 	"ID of the alert channel"	default(32343a19-da5e-4b1b-a767-3298a73703ca)
+// @Success      204		{object}    responses.NoContent
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401    	{object}	responses.Unauthorized
+// @Failure 	 404    	{object}	responses.NotFound
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /alert-channels/{channelID} [delete]
+func (h *AlertChannelHandler) Delete(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	channelID := c.Params("channelID")
+	if errors := h.validator.ValidateUUID(ctx, channelID, "channelID"); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while deleting alert channel with ID [%s]", spew.Sdump(errors), channelID)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while deleting alert channel")
+	}
+
+	err := h.service.Delete(ctx, h.userIDFomContext(c), uuid.MustParse(channelID))
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		return h.responseNotFound(c, fmt.Sprintf("cannot find alert channel with ID [%s]", channelID))
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot delete alert channel with ID [%s]", channelID)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseOK(c, "alert channel deleted successfully", nil)
+}
@@ -0,0 +1,179 @@
+package handlers
+
+import (
+	"fmt"
+
+	"github.com/NdoleStudio/httpsms/pkg/requests"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/NdoleStudio/httpsms/pkg/validators"
+	"github.com/davecgh/go-spew/spew"
+	"github.com/gofiber/fiber/v2"
+	"github.com/palantir/stacktrace"
+)
+
+// AnalyticsHandler handles analytics requests
+type AnalyticsHandler struct {
+	handler
+	logger           telemetry.Logger
+	tracer           telemetry.Tracer
+	service          *services.AnalyticsService
+	filteringService *services.CarrierFilteringService
+	validator        *validators.AnalyticsHandlerValidator
+}
+
+// NewAnalyticsHandler creates a new AnalyticsHandler
+func NewAnalyticsHandler(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	service *services.AnalyticsService,
+	filteringService *services.CarrierFilteringService,
+	validator *validators.AnalyticsHandlerValidator,
+) (h *AnalyticsHandler) {
+	return &AnalyticsHandler{
+		logger:           logger.WithService(fmt.Sprintf("%T", h)),
+		tracer:           tracer,
+		service:          service,
+		filteringService: filteringService,
+		validator:        validator,
+	}
+}
+
+// RegisterRoutes registers the routes for the AnalyticsHandler
+func (h *AnalyticsHandler) RegisterRoutes(app *fiber.App, middlewares ...fiber.Handler) {
+	router := app.Group("/v1/analytics")
+	router.Get("/timeseries", h.computeRoute(middlewares, h.TimeSeries)...)
+	router.Get("/failures", h.computeRoute(middlewares, h.FailureStats)...)
+	router.Get("/carrier-filtering", h.computeRoute(middlewares, h.CarrierFiltering)...)
+}
+
+// TimeSeries returns a metric aggregated into time buckets
+// @Summary      Get the time series of a metric
+// @Description  Get the values of a metric aggregated per hour or day and optionally grouped by phone or SIM card. The latency metric is in milliseconds.
+// @Security	 ApiKeyAuth
+// @Tags         Analytics
+// @Accept       json
+// @Produce      json
+// @Param        metric			query  string  	true	"metric to aggregate"		Enums(sent, delivered, failed, received, latency)
+// @Param        granularity	query  string  	false	"size of the time buckets"	Enums(hour, day)	default(day)
+// @Param        group_by		query  string  	false	"dimension used to split the time series"	Enums(phone, sim)
+// @Param        from			query  string  	false	"RFC3339 start of the time range, defaults to 30 days before to"
+// @Param        to				query  string  	false	"RFC3339 end of the time range, defaults to now"
+// @Success      200 		{object}	responses.TimeSeriesResponse
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401	    {object}	responses.Unauthorized
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /analytics/timeseries 	[get]
+func (h *AnalyticsHandler) TimeSeries(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	var request requests.AnalyticsTimeSeries
+	if err := c.QueryParser(&request); err != nil {
+		msg := fmt.Sprintf("cannot marshall URL [%s] into %T", c.OriginalURL(), request)
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return h.responseBadRequest(c, err)
+	}
+
+	if errors := h.validator.ValidateTimeSeries(ctx, request.Sanitize()); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while fetching time series [%+#v]", spew.Sdump(errors), request)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while fetching time series")
+	}
+
+	points, err := h.service.TimeSeries(ctx, h.userIDFomContext(c), request.ToTimeSeriesParams())
+	if err != nil {
+		msg := fmt.Sprintf("cannot get time series with params [%+#v]", request)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseOK(c, fmt.Sprintf("fetched %d time series %s", len(points), h.pluralize("point", len(points))), points)
+}
+
+// FailureStats returns the number of failed messages per failure code
+// @Summary      Get failed message stats
+// @Description  Get the number of messages which failed per failure code e.g. no-service, radio-off, blocked, optionally grouped by phone or SIM card.
+// @Security	 ApiKeyAuth
+// @Tags         Analytics
+// @Accept       json
+// @Produce      json
+// @Param        group_by		query  string  	false	"dimension used to split the stats"	Enums(phone, sim)
+// @Param        from			query  string  	false	"RFC3339 start of the time range, defaults to 30 days before to"
+// @Param        to				query  string  	false	"RFC3339 end of the time range, defaults to now"
+// @Success      200 		{object}	responses.FailureStatsResponse
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401	    {object}	responses.Unauthorized
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /analytics/failures 	[get]
+func (h *AnalyticsHandler) FailureStats(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	var request requests.AnalyticsFailureStats
+	if err := c.QueryParser(&request); err != nil {
+		msg := fmt.Sprintf("cannot marshall URL [%s] into %T", c.OriginalURL(), request)
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return h.responseBadRequest(c, err)
+	}
+
+	if errors := h.validator.ValidateFailureStats(ctx, request.Sanitize()); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while fetching failure stats [%+#v]", spew.Sdump(errors), request)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while fetching failure stats")
+	}
+
+	counts, err := h.service.FailureStats(ctx, h.userIDFomContext(c), request.ToFailureStatsParams())
+	if err != nil {
+		msg := fmt.Sprintf("cannot get failure stats with params [%+#v]", request)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseOK(c, fmt.Sprintf("fetched %d failure %s", len(counts), h.pluralize("code", len(counts))), counts)
+}
+
+// CarrierFiltering returns the delivery of messages by contact phone number prefix
+// @Summary      Detect carrier filtering
+// @Description  Get the number of sent messages which were delivered or did not get a delivery report for every phone and contact phone number prefix with at least 20 sent messages. A prefix is suspected of silent carrier filtering when at least half of its messages do not get a delivery report while the other messages of the phone are delivered, the suggested owner is another phone which delivers messages to the prefix.
+// @Security	 ApiKeyAuth
+// @Tags         Analytics
+// @Accept       json
+// @Produce      json
+// @Param        prefix_length	query  int  	false	"number of characters of the contact phone number in the prefix"	minimum(2)	maximum(8)	default(5)
+// @Param        from			query  string  	false	"RFC3339 start of the time range, defaults to 7 days before to"
+// @Param        to				query  string  	false	"RFC3339 end of the time range, defaults to now"
+// @Success      200 		{object}	responses.CarrierFilteringResponse
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401	    {object}	responses.Unauthorized
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /analytics/carrier-filtering 	[get]
+func (h *AnalyticsHandler) CarrierFiltering(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	var request requests.AnalyticsCarrierFiltering
+	if err := c.QueryParser(&request); err != nil {
+		msg := fmt.Sprintf("cannot marshall URL [%s] into %T", c.OriginalURL(), request)
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return h.responseBadRequest(c, err)
+	}
+
+	if errors := h.validator.ValidateCarrierFiltering(ctx, request.Sanitize()); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while fetching carrier filtering stats [%+#v]", spew.Sdump(errors), request)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while fetching carrier filtering stats")
+	}
+
+	stats, err := h.filteringService.Index(ctx, h.userIDFomContext(c), request.ToPrefixDeliveryParams())
+	if err != nil {
+		msg := fmt.Sprintf("cannot get carrier filtering stats with params [%+#v]", request)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseOK(c, fmt.Sprintf("fetched %d carrier filtering %s", len(stats), h.pluralize("stat", len(stats))), stats)
+}
@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/palantir/stacktrace"
+)
+
+// AttachmentHandler serves the attachments of MMS messages which are stored on the local disk
+type AttachmentHandler struct {
+	handler
+	logger  telemetry.Logger
+	tracer  telemetry.Tracer
+	service *services.AttachmentService
+}
+
+// NewAttachmentHandler creates a new AttachmentHandler
+func NewAttachmentHandler(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	service *services.AttachmentService,
+) (h *AttachmentHandler) {
+	return &AttachmentHandler{
+		logger:  logger.WithService(fmt.Sprintf("%T", h)),
+		tracer:  tracer,
+		service: service,
+	}
+}
+
+// RegisterRoutes registers the routes for the AttachmentHandler, the routes are authenticated with the URL signature
+func (h *AttachmentHandler) RegisterRoutes(app *fiber.App, middlewares ...fiber.Handler) {
+	router := app.Group("/v1/attachments")
+	router.Get("/:userID/:messageID/:attachmentID", h.computeRoute(middlewares, h.Download)...)
+}
+
+// Download the content of an attachment
+// @Summary      Download an attachment
+// @Description  Download the content of an MMS attachment using the signed URL in the attachment
+// @Tags         Attachments
+// @Produce      octet-stream
+// @Param 		 userID 		path		string 	true 	"ID of the user"
+// @Param 		 messageID 		path		string 	true 	"ID of the message"
+// @Param 		 attachmentID 	path		string 	true 	"ID of the attachment"
+// @Param        expires		query  		int  	true	"unix timestamp when the URL expires"
+// @Param        signature		query  		string 	true	"signature of the URL"
+// @Success      200 		{file}		file
+// @Failure 	 404    	{object}	responses.NotFound
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /attachments/{userID}/{messageID}/{attachmentID} [get]
+func (h *AttachmentHandler) Download(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	messageID, messageErr := uuid.Parse(c.Params("messageID"))
+	attachmentID, attachmentErr := uuid.Parse(c.Params("attachmentID"))
+	expires, expiresErr := strconv.ParseInt(c.Query("expires"), 10, 64)
+	if messageErr != nil || attachmentErr != nil || expiresErr != nil {
+		return h.responseNotFound(c, fmt.Sprintf("cannot find attachment with ID [%s]", c.Params("attachmentID")))
+	}
+
+	attachment, content, err := h.service.Content(ctx, &services.AttachmentContentParams{
+		UserID:       entities.UserID(c.Params("userID")),
+		MessageID:    messageID,
+		AttachmentID: attachmentID,
+		Expires:      expires,
+		Signature:    c.Query("signature"),
+	})
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		ctxLogger.Warn(stacktrace.Propagate(err, fmt.Sprintf("cannot download attachment with ID [%s]", attachmentID)))
+		return h.responseNotFound(c, fmt.Sprintf("cannot find attachment with ID [%s]", attachmentID))
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot load content of attachment with ID [%s]", attachmentID)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	c.Attachment(attachment.Name)
+	c.Set(fiber.HeaderContentType, attachment.ContentType)
+	return c.Send(content)
+}
@@ -0,0 +1,303 @@
+package handlers
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/NdoleStudio/httpsms/pkg/requests"
+	"github.com/NdoleStudio/httpsms/pkg/responses"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/NdoleStudio/httpsms/pkg/validators"
+	"github.com/davecgh/go-spew/spew"
+	"github.com/gofiber/fiber/v2"
+	"github.com/palantir/stacktrace"
+)
+
+// AuthHandler handles the native signup and login requests of self-hosted instances
+type AuthHandler struct {
+	handler
+	logger    telemetry.Logger
+	tracer    telemetry.Tracer
+	service   *services.AuthService
+	validator *validators.AuthHandlerValidator
+}
+
+// NewAuthHandler creates a new AuthHandler
+func NewAuthHandler(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	service *services.AuthService,
+	validator *validators.AuthHandlerValidator,
+) (h *AuthHandler) {
+	return &AuthHandler{
+		logger:    logger.WithService(fmt.Sprintf("%T", h)),
+		tracer:    tracer,
+		service:   service,
+		validator: validator,
+	}
+}
+
+// RegisterRoutes registers the routes for the AuthHandler, the routes are public because they issue the credentials
+func (h *AuthHandler) RegisterRoutes(app *fiber.App) {
+	router := app.Group("/v1/auth")
+	router.Post("/signup", h.Signup)
+	router.Post("/login", h.Login)
+	router.Post("/logout", h.Logout)
+	router.Post("/verify-email", h.VerifyEmail)
+	router.Post("/forgot-password", h.ForgotPassword)
+	router.Post("/reset-password", h.ResetPassword)
+}
+
+// Signup registers a user with an email and password
+// @Summary      Sign up with an email and password
+// @Description  Register a user on a self-hosted instance and send a link to verify the email address.
+// @Tags         Auth
+// @Accept       json
+// @Produce      json
+// @Param        payload   		body 		requests.AuthSignup  	true 	"Email and password of the user"
+// @Success      201 		{object}	responses.UserCredentialResponse
+// @Failure      400		{object}	responses.BadRequest
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /auth/signup [post]
+func (h *AuthHandler) Signup(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	var request requests.AuthSignup
+	if err := c.BodyParser(&request); err != nil {
+		msg := fmt.Sprintf("cannot marshall body into [%T]", request)
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return h.responseBadRequest(c, err)
+	}
+
+	if errors := h.validator.ValidateSignup(ctx, request.Sanitize()); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while signing up with email [%s]", spew.Sdump(errors), request.Email)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while signing up")
+	}
+
+	credential, err := h.service.Signup(ctx, request.ToSignupParams())
+	if stacktrace.GetCode(err) == services.ErrCodeEmailTaken {
+		return h.responseUnprocessableEntity(c, url.Values{"email": []string{fmt.Sprintf("a user with email [%s] already exists", request.Email)}}, "validation errors while signing up")
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot sign up with email [%s]", request.Email)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseCreated(c, "signed up successfully, check your inbox to verify your email address", credential)
+}
+
+// Login issues a token for a user with a verified email address
+// @Summary      Log in with an email and password
+// @Description  Start a session and get a bearer token which authenticates the user like a firebase token.
+// @Tags         Auth
+// @Accept       json
+// @Produce      json
+// @Param        payload   		body 		requests.AuthLogin  	true 	"Email and password of the user"
+// @Success      200 		{object}	responses.AuthTokenResponse
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401	    {object}	responses.Unauthorized
+// @Failure 	 403	    {object}	responses.Unauthorized
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /auth/login [post]
+func (h *AuthHandler) Login(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	var request requests.AuthLogin
+	if err := c.BodyParser(&request); err != nil {
+		msg := fmt.Sprintf("cannot marshall body into [%T]", request)
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return h.responseBadRequest(c, err)
+	}
+
+	if errors := h.validator.ValidateLogin(ctx, request.Sanitize()); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while logging in with email [%s]", spew.Sdump(errors), request.Email)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while logging in")
+	}
+
+	result, err := h.service.Login(ctx, request.ToLoginParams(c.IP(), c.Get(fiber.HeaderUserAgent)))
+	if stacktrace.GetCode(err) == services.ErrCodeInvalidCredentials {
+		ctxLogger.Warn(stacktrace.Propagate(err, fmt.Sprintf("invalid credentials for email [%s]", request.Email)))
+		return h.responseInvalidCredentials(c, "The email or password is incorrect.")
+	}
+
+	if stacktrace.GetCode(err) == services.ErrCodeEmailNotVerified {
+		ctxLogger.Warn(stacktrace.Propagate(err, fmt.Sprintf("email [%s] is not verified", request.Email)))
+		return h.responseEmailNotVerified(c)
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot log in with email [%s]", request.Email)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseOK(c, "logged in successfully", &responses.AuthToken{
+		Token:     result.Token,
+		ExpiresAt: result.Session.ExpiresAt,
+		User:      result.User,
+	})
+}
+
+// Logout revokes the session of the bearer token
+// @Summary      Log out
+// @Description  Revoke the session of the bearer token in the [Authorization] header so that it can no longer be used.
+// @Tags         Auth
+// @Produce      json
+// @Success      204		{object}    responses.NoContent
+// @Failure 	 401	    {object}	responses.Unauthorized
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /auth/logout [post]
+func (h *AuthHandler) Logout(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	token := strings.TrimSpace(strings.TrimPrefix(c.Get(fiber.HeaderAuthorization), "Bearer"))
+	if token == "" {
+		return h.responseInvalidCredentials(c, "The [Authorization] header does not contain a bearer token.")
+	}
+
+	err := h.service.Logout(ctx, token)
+	if stacktrace.GetCode(err) == services.ErrCodeInvalidCredentials {
+		ctxLogger.Warn(stacktrace.Propagate(err, "cannot log out with an invalid token"))
+		return h.responseInvalidCredentials(c, "The bearer token is invalid.")
+	}
+
+	if err != nil {
+		ctxLogger.Error(stacktrace.Propagate(err, "cannot log out"))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseNoContent(c, "logged out successfully")
+}
+
+// VerifyEmail confirms the email address of a user
+// @Summary      Verify an email address
+// @Description  Confirm the email address of a user with the token in the verification email.
+// @Tags         Auth
+// @Accept       json
+// @Produce      json
+// @Param        payload   		body 		requests.AuthVerifyEmail  	true 	"Token in the verification email"
+// @Success      200 		{object}	responses.UserCredentialResponse
+// @Failure      400		{object}	responses.BadRequest
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /auth/verify-email [post]
+func (h *AuthHandler) VerifyEmail(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	var request requests.AuthVerifyEmail
+	if err := c.BodyParser(&request); err != nil {
+		msg := fmt.Sprintf("cannot marshall body into [%T]", request)
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return h.responseBadRequest(c, err)
+	}
+
+	if errors := h.validator.ValidateVerifyEmail(ctx, request.Sanitize()); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while verifying email", spew.Sdump(errors))
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while verifying email")
+	}
+
+	credential, err := h.service.VerifyEmail(ctx, request.Token)
+	if stacktrace.GetCode(err) == services.ErrCodeInvalidCredentials {
+		return h.responseUnprocessableEntity(c, url.Values{"token": []string{"the email verification token is invalid or was already used"}}, "validation errors while verifying email")
+	}
+
+	if err != nil {
+		ctxLogger.Error(stacktrace.Propagate(err, "cannot verify email"))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseOK(c, "email verified successfully", credential)
+}
+
+// ForgotPassword sends a password reset email
+// @Summary      Request a password reset
+// @Description  Send a link which can be used to choose a new password. The response is the same whether or not the email address is registered.
+// @Tags         Auth
+// @Accept       json
+// @Produce      json
+// @Param        payload   		body 		requests.AuthForgotPassword  	true 	"Email address of the user"
+// @Success      204		{object}    responses.NoContent
+// @Failure      400		{object}	responses.BadRequest
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /auth/forgot-password [post]
+func (h *AuthHandler) ForgotPassword(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	var request requests.AuthForgotPassword
+	if err := c.BodyParser(&request); err != nil {
+		msg := fmt.Sprintf("cannot marshall body into [%T]", request)
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return h.responseBadRequest(c, err)
+	}
+
+	if errors := h.validator.ValidateForgotPassword(ctx, request.Sanitize()); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while requesting password reset for [%s]", spew.Sdump(errors), request.Email)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while requesting password reset")
+	}
+
+	if err := h.service.ForgotPassword(ctx, request.Email); err != nil {
+		msg := fmt.Sprintf("cannot send password reset email to [%s]", request.Email)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseNoContent(c, "if the email address is registered, a password reset link has been sent")
+}
+
+// ResetPassword chooses a new password
+// @Summary      Reset a password
+// @Description  Choose a new password with the token in the password reset email. All the sessions of the user are revoked.
+// @Tags         Auth
+// @Accept       json
+// @Produce      json
+// @Param        payload   		body 		requests.AuthResetPassword  	true 	"Token in the password reset email and the new password"
+// @Success      204		{object}    responses.NoContent
+// @Failure      400		{object}	responses.BadRequest
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /auth/reset-password [post]
+func (h *AuthHandler) ResetPassword(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	var request requests.AuthResetPassword
+	if err := c.BodyParser(&request); err != nil {
+		msg := fmt.Sprintf("cannot marshall body into [%T]", request)
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return h.responseBadRequest(c, err)
+	}
+
+	if errors := h.validator.ValidateResetPassword(ctx, request.Sanitize()); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while resetting password", spew.Sdump(errors))
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while resetting password")
+	}
+
+	err := h.service.ResetPassword(ctx, request.ToResetPasswordParams())
+	if stacktrace.GetCode(err) == services.ErrCodeInvalidCredentials {
+		return h.responseUnprocessableEntity(c, url.Values{"token": []string{"the password reset token is invalid or expired"}}, "validation errors while resetting password")
+	}
+
+	if err != nil {
+		ctxLogger.Error(stacktrace.Propagate(err, "cannot reset password"))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseNoContent(c, "password reset successfully")
+}
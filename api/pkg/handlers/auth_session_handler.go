@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"fmt"
+
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/NdoleStudio/httpsms/pkg/validators"
+	"github.com/davecgh/go-spew/spew"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/palantir/stacktrace"
+)
+
+// AuthSessionHandler handles the devices which are signed in to the account of a user
+type AuthSessionHandler struct {
+	handler
+	logger    telemetry.Logger
+	tracer    telemetry.Tracer
+	service   *services.AuthSessionService
+	validator *validators.AuthSessionHandlerValidator
+}
+
+// NewAuthSessionHandler creates a new AuthSessionHandler
+func NewAuthSessionHandler(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	service *services.AuthSessionService,
+	validator *validators.AuthSessionHandlerValidator,
+) (h *AuthSessionHandler) {
+	return &AuthSessionHandler{
+		logger:    logger.WithService(fmt.Sprintf("%T", h)),
+		tracer:    tracer,
+		service:   service,
+		validator: validator,
+	}
+}
+
+// RegisterRoutes registers the routes for the AuthSessionHandler
+func (h *AuthSessionHandler) RegisterRoutes(app *fiber.App, middlewares ...fiber.Handler) {
+	router := app.Group("/v1/sessions")
+	router.Get("/", h.computeRoute(middlewares, h.Index)...)
+	router.Delete("/:sessionID", h.computeRoute(middlewares, h.Delete)...)
+}
+
+// Index returns the active sessions of a user
+// @Summary      Get active sessions
+// @Description  Get the devices which are signed in to the account of the authenticated user with the IP address and user agent which last used them.
+// @Security	 ApiKeyAuth
+// @Tags         Sessions
+// @Produce      json
+// @Success      200 		{object}	responses.AuthSessionsResponse
+// @Failure 	 401	    {object}	responses.Unauthorized
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /sessions [get]
+func (h *AuthSessionHandler) Index(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	authUser := h.userFromContext(c)
+	sessions, err := h.service.Index(ctx, authUser.ID, authUser.SessionID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot fetch sessions of user [%s]", authUser.ID)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseOK(c, fmt.Sprintf("fetched %d %s", len(sessions), h.pluralize("session", len(sessions))), sessions)
+}
+
+// Delete revokes a session
+// @Summary      Revoke a session
+// @Description  Revoke a session of the authenticated user, the tokens of the session are rejected immediately.
+// @Security	 ApiKeyAuth
+// @Tags         Sessions
+// @Produce      json
+// @Param 		 sessionID 	path		string 							true 	"ID of the session"	default(32343a19-da5e-4b1b-a767-3298a73703cb)
+// @Success      204		{object}    responses.NoContent
+// @Failure 	 401    	{object}	responses.Unauthorized
+// @Failure      404		{object}	responses.NotFound
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /sessions/{sessionID} [delete]
+func (h *AuthSessionHandler) Delete(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	sessionID := c.Params("sessionID")
+	if errors := h.validator.ValidateUUID(ctx, sessionID, "sessionID"); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while revoking session with ID [%s]", spew.Sdump(errors), sessionID)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while revoking session")
+	}
+
+	err := h.service.Revoke(ctx, h.userIDFomContext(c), uuid.MustParse(sessionID))
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		return h.responseNotFound(c, fmt.Sprintf("cannot find session with ID [%s]", sessionID))
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot revoke session with ID [%s]", sessionID)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseNoContent(c, "session revoked successfully")
+}
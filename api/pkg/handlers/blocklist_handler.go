@@ -0,0 +1,232 @@
+package handlers
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+
+	"github.com/NdoleStudio/httpsms/pkg/requests"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/NdoleStudio/httpsms/pkg/validators"
+	"github.com/davecgh/go-spew/spew"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/palantir/stacktrace"
+)
+
+// BlocklistHandler handles blocklist requests
+type BlocklistHandler struct {
+	handler
+	logger    telemetry.Logger
+	tracer    telemetry.Tracer
+	service   *services.BlocklistService
+	validator *validators.BlocklistHandlerValidator
+}
+
+// NewBlocklistHandler creates a new BlocklistHandler
+func NewBlocklistHandler(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	service *services.BlocklistService,
+	validator *validators.BlocklistHandlerValidator,
+) (h *BlocklistHandler) {
+	return &BlocklistHandler{
+		logger:    logger.WithService(fmt.Sprintf("%T", h)),
+		tracer:    tracer,
+		service:   service,
+		validator: validator,
+	}
+}
+
+// RegisterRoutes registers the routes for the BlocklistHandler
+func (h *BlocklistHandler) RegisterRoutes(app *fiber.App, middlewares ...fiber.Handler) {
+	router := app.Group("/v1/blocklist")
+	router.Get("/", h.computeRoute(middlewares, h.Index)...)
+	router.Post("/import", h.computeRoute(middlewares, h.Import)...)
+	router.Get("/export", h.computeRoute(middlewares, h.Export)...)
+	router.Delete("/:blocklistID", h.computeRoute(middlewares, h.Delete)...)
+}
+
+// Index returns the blocklist of a user
+// @Summary      Get the blocklist of a user
+// @Description  Get the phone numbers which opted out or were blocked by the user
+// @Security	 ApiKeyAuth
+// @Tags         Blocklist
+// @Accept       json
+// @Produce      json
+// @Param        skip		query  int  	false	"number of phone numbers to skip"		minimum(0)
+// @Param        query		query  string  	false 	"filter phone numbers containing query"
+// @Param        limit		query  int  	false	"number of phone numbers to return"	minimum(1)	maximum(100)
+// @Success      200 		{object}	responses.BlocklistsResponse
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401	    {object}	responses.Unauthorized
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /blocklist 	[get]
+func (h *BlocklistHandler) Index(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	var request requests.BlocklistIndex
+	if err := c.QueryParser(&request); err != nil {
+		msg := fmt.Sprintf("cannot marshall URL [%s] into %T", c.OriginalURL(), request)
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return h.responseBadRequest(c, err)
+	}
+
+	if errors := h.validator.ValidateIndex(ctx, request.Sanitize()); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while fetching blocklist [%+#v]", spew.Sdump(errors), request)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while fetching blocklist")
+	}
+
+	entries, err := h.service.Index(ctx, h.userIDFomContext(c), request.ToIndexParams())
+	if err != nil {
+		msg := fmt.Sprintf("cannot get blocklist with params [%+#v]", request)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseOK(c, fmt.Sprintf("fetched %d %s", len(entries), h.pluralize("phone number", len(entries))), entries)
+}
+
+// Import phone numbers into the blocklist
+// @Summary      Import a CSV file into the blocklist
+// @Description  Import the opt-out and blocked phone numbers exported from another provider. The CSV file must have a phone number column and an optional reason column. Duplicate phone numbers and phone numbers which are already on the blocklist are skipped. Set dry_run to validate the file without saving it.
+// @Security	 ApiKeyAuth
+// @Tags         Blocklist
+// @Accept       mpfd
+// @Produce      json
+// @Param        document	formData	file	true	"CSV file containing phone numbers"
+// @Param        dry_run	query		bool	false	"validate the file without importing the phone numbers"
+// @Param        reason		query		string	false	"reason used when a row has no reason"	Enums(opt-out,blocked)
+// @Success      200 		{object}	responses.BlocklistImportResponse
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401	    {object}	responses.Unauthorized
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /blocklist/import [post]
+func (h *BlocklistHandler) Import(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	var request requests.BlocklistImport
+	if err := c.QueryParser(&request); err != nil {
+		msg := fmt.Sprintf("cannot marshall URL [%s] into %T", c.OriginalURL(), request)
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return h.responseBadRequest(c, err)
+	}
+
+	content, err := h.readDocument(c)
+	if err != nil {
+		msg := fmt.Sprintf("cannot read CSV document from request [%s]", c.OriginalURL())
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return h.responseBadRequest(c, err)
+	}
+	request.Content = content
+
+	if errors := h.validator.ValidateImport(ctx, request.Sanitize()); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while importing blocklist with params [dry_run=%s, reason=%s]", spew.Sdump(errors), request.DryRun, request.Reason)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while importing blocklist")
+	}
+
+	result, err := h.service.Import(ctx, request.ToImportParams(h.userIDFomContext(c)))
+	if stacktrace.GetCode(err) == services.ErrCodeInvalidCSV {
+		ctxLogger.Warn(stacktrace.Propagate(err, "cannot parse blocklist CSV document"))
+		return h.responseUnprocessableEntity(c, url.Values{"document": []string{stacktrace.RootCause(err).Error()}}, "validation errors while importing blocklist")
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot import blocklist with params [dry_run=%s, reason=%s]", request.DryRun, request.Reason)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	if result.DryRun {
+		return h.responseOK(c, fmt.Sprintf("validated %d %s", result.Imported, h.pluralize("phone number", result.Imported)), result)
+	}
+
+	return h.responseOK(c, fmt.Sprintf("imported %d %s", result.Imported, h.pluralize("phone number", result.Imported)), result)
+}
+
+// Export the blocklist of a user
+// @Summary      Export the blocklist as a CSV file
+// @Description  Download all the phone numbers on the blocklist with the reason and source of each phone number
+// @Security	 ApiKeyAuth
+// @Tags         Blocklist
+// @Produce      text/csv
+// @Success      200 		{file}		file
+// @Failure 	 401	    {object}	responses.Unauthorized
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /blocklist/export [get]
+func (h *BlocklistHandler) Export(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	content, err := h.service.Export(ctx, h.userIDFomContext(c))
+	if err != nil {
+		msg := fmt.Sprintf("cannot export blocklist for user [%s]", h.userIDFomContext(c))
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	c.Attachment("blocklist.csv")
+	c.Set(fiber.HeaderContentType, "text/csv")
+	return c.Send(content)
+}
+
+// Delete a phone number from the blocklist
+// @Summary      Delete a phone number from the blocklist
+// @Description  Remove a phone number from the blocklist so that it can receive messages again
+// @Security	 ApiKeyAuth
+// @Tags         Blocklist
+// @Accept       json
+// @Produce      json
+// @Param 		 blocklistID 	path		string 	true 	"ID of the blocklist entry"	default(32343a19-da5e-4b1b-a767-3298a73703ca)
+// @Success      204			{object}    responses.NoContent
+// @Failure      400			{object}	responses.BadRequest
+// @Failure 	 401    		{object}	responses.Unauthorized
+// @Failure      422			{object}	responses.UnprocessableEntity
+// @Failure      500			{object}	responses.InternalServerError
+// @Router       /blocklist/{blocklistID} [delete]
+func (h *BlocklistHandler) Delete(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	blocklistID := c.Params("blocklistID")
+	if errors := h.validator.ValidateUUID(ctx, blocklistID, "blocklistID"); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while deleting blocklist entry with ID [%s]", spew.Sdump(errors), blocklistID)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while deleting blocklist entry")
+	}
+
+	if err := h.service.Delete(ctx, h.userIDFomContext(c), uuid.MustParse(blocklistID)); err != nil {
+		msg := fmt.Sprintf("cannot delete blocklist entry with ID [%s]", blocklistID)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseNoContent(c, "phone number removed from the blocklist successfully")
+}
+
+// readDocument returns the CSV file which is uploaded as a multipart form or sent as the request body
+func (h *BlocklistHandler) readDocument(c *fiber.Ctx) ([]byte, error) {
+	header, err := c.FormFile("document")
+	if err != nil {
+		return c.Body(), nil
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		return nil, stacktrace.Propagate(err, fmt.Sprintf("cannot open document [%s]", header.Filename))
+	}
+	defer func() { _ = file.Close() }()
+
+	content, err := io.ReadAll(file)
+	if err != nil {
+		return nil, stacktrace.Propagate(err, fmt.Sprintf("cannot read document [%s]", header.Filename))
+	}
+	return content, nil
+}
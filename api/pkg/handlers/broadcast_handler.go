@@ -0,0 +1,216 @@
+package handlers
+
+import (
+	"fmt"
+
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
+	"github.com/NdoleStudio/httpsms/pkg/requests"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/NdoleStudio/httpsms/pkg/validators"
+	"github.com/davecgh/go-spew/spew"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/palantir/stacktrace"
+)
+
+// BroadcastHandler handles broadcast requests
+type BroadcastHandler struct {
+	handler
+	logger          telemetry.Logger
+	tracer          telemetry.Tracer
+	service         *services.BroadcastService
+	operatorService *services.OperatorService
+	validator       *validators.BroadcastHandlerValidator
+}
+
+// NewBroadcastHandler creates a new BroadcastHandler
+func NewBroadcastHandler(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	service *services.BroadcastService,
+	operatorService *services.OperatorService,
+	validator *validators.BroadcastHandlerValidator,
+) (h *BroadcastHandler) {
+	return &BroadcastHandler{
+		logger:          logger.WithService(fmt.Sprintf("%T", h)),
+		tracer:          tracer,
+		service:         service,
+		operatorService: operatorService,
+		validator:       validator,
+	}
+}
+
+// RegisterRoutes registers the routes for the BroadcastHandler
+func (h *BroadcastHandler) RegisterRoutes(app *fiber.App, middlewares ...fiber.Handler) {
+	app.Get("/v1/broadcasts", h.computeRoute(middlewares, h.Active)...)
+
+	router := app.Group("/v1/operator/broadcasts")
+	router.Get("/", h.computeRoute(middlewares, h.Index)...)
+	router.Post("/", h.computeRoute(middlewares, h.Store)...)
+	router.Delete("/:broadcastID", h.computeRoute(middlewares, h.Delete)...)
+}
+
+// Active returns the broadcasts which should be displayed to a user
+// @Summary      Get active broadcasts
+// @Description  Get the announcements of the operators of the instance which should be displayed as a banner to the authenticated user
+// @Security	 ApiKeyAuth
+// @Tags         Broadcasts
+// @Accept       json
+// @Produce      json
+// @Success      200 		{object}	responses.BroadcastsResponse
+// @Failure 	 401	    {object}	responses.Unauthorized
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /broadcasts 	[get]
+func (h *BroadcastHandler) Active(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	broadcasts, err := h.service.Active(ctx, h.userIDFomContext(c))
+	if err != nil {
+		msg := fmt.Sprintf("cannot get active broadcasts for user [%s]", h.userIDFomContext(c))
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseOK(c, fmt.Sprintf("fetched %d active %s", len(broadcasts), h.pluralize("broadcast", len(broadcasts))), broadcasts)
+}
+
+// Index returns all the broadcasts of the instance
+// @Summary      Get all broadcasts
+// @Description  Get all the announcements published on the instance. Only operators listed in OPERATOR_USER_IDS can use this endpoint.
+// @Security	 ApiKeyAuth
+// @Tags         Broadcasts
+// @Accept       json
+// @Produce      json
+// @Param        skip		query  int  	false	"number of broadcasts to skip"		minimum(0)
+// @Param        query		query  string  	false 	"filter broadcasts containing query"
+// @Param        limit		query  int  	false	"number of broadcasts to return"	minimum(1)	maximum(100)
+// @Success      200 		{object}	responses.BroadcastsResponse
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401	    {object}	responses.Unauthorized
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /operator/broadcasts 	[get]
+func (h *BroadcastHandler) Index(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	if !h.operatorService.IsOperator(h.userIDFomContext(c)) {
+		ctxLogger.Warn(stacktrace.NewError(fmt.Sprintf("user [%s] is not an operator and cannot fetch broadcasts", h.userIDFomContext(c))))
+		return h.responseForbidden(c)
+	}
+
+	var request requests.BroadcastIndex
+	if err := c.QueryParser(&request); err != nil {
+		msg := fmt.Sprintf("cannot marshall URL [%s] into %T", c.OriginalURL(), request)
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return h.responseBadRequest(c, err)
+	}
+
+	if errors := h.validator.ValidateIndex(ctx, request.Sanitize()); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while fetching broadcasts [%+#v]", spew.Sdump(errors), request)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while fetching broadcasts")
+	}
+
+	broadcasts, err := h.service.Index(ctx, request.ToIndexParams())
+	if err != nil {
+		msg := fmt.Sprintf("cannot get broadcasts with params [%+#v]", request)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseOK(c, fmt.Sprintf("fetched %d %s", len(broadcasts), h.pluralize("broadcast", len(broadcasts))), broadcasts)
+}
+
+// Store publishes a broadcast
+// @Summary      Publish a broadcast
+// @Description  Announce maintenance windows or breaking changes to all the users of the instance or to a list of users. The broadcast is displayed as a banner and it can also be sent by email and as a `broadcast.published` webhook event. Only operators listed in OPERATOR_USER_IDS can use this endpoint.
+// @Security	 ApiKeyAuth
+// @Tags         Broadcasts
+// @Accept       json
+// @Produce      json
+// @Param        payload   	body 		requests.BroadcastStore  		true "Payload of the broadcast request"
+// @Success      201 		{object}	responses.BroadcastResponse
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401	    {object}	responses.Unauthorized
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /operator/broadcasts [post]
+func (h *BroadcastHandler) Store(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	if !h.operatorService.IsOperator(h.userIDFomContext(c)) {
+		ctxLogger.Warn(stacktrace.NewError(fmt.Sprintf("user [%s] is not an operator and cannot publish a broadcast", h.userIDFomContext(c))))
+		return h.responseForbidden(c)
+	}
+
+	var request requests.BroadcastStore
+	if err := c.BodyParser(&request); err != nil {
+		msg := fmt.Sprintf("cannot marshall [%s] into %T", c.Body(), request)
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return h.responseBadRequest(c, err)
+	}
+
+	if errors := h.validator.ValidateStore(ctx, request.Sanitize()); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while storing broadcast [%+#v]", spew.Sdump(errors), request)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while storing broadcast")
+	}
+
+	broadcast, err := h.service.Store(ctx, request.ToStoreParams(c.OriginalURL(), h.userIDFomContext(c)))
+	if err != nil {
+		msg := fmt.Sprintf("cannot store broadcast with params [%+#v]", request)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseCreated(c, "broadcast published successfully", broadcast)
+}
+
+// Delete a broadcast
+// @Summary      Delete a broadcast
+// @Description  Delete a broadcast so that it is no longer displayed as a banner. Only operators listed in OPERATOR_USER_IDS can use this endpoint.
+// @Security	 ApiKeyAuth
+// @Tags         Broadcasts
+// @Accept       json
+// @Produce      json
+// @Param 		 broadcastID 	path		string 							true 	"ID of the broadcast"	default(32343a19-da5e-4b1b-a767-3298a73703ca)
+// @Success      204		{object}    responses.NoContent
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401    	{object}	responses.Unauthorized
+// @Failure 	 404    	{object}	responses.NotFound
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /operator/broadcasts/{broadcastID} [delete]
+func (h *BroadcastHandler) Delete(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	if !h.operatorService.IsOperator(h.userIDFomContext(c)) {
+		ctxLogger.Warn(stacktrace.NewError(fmt.Sprintf("user [%s] is not an operator and cannot delete a broadcast", h.userIDFomContext(c))))
+		return h.responseForbidden(c)
+	}
+
+	broadcastID := c.Params("broadcastID")
+	if errors := h.validator.ValidateUUID(ctx, broadcastID, "broadcastID"); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while deleting broadcast with ID [%s]", spew.Sdump(errors), broadcastID)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while deleting broadcast")
+	}
+
+	err := h.service.Delete(ctx, uuid.MustParse(broadcastID))
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		return h.responseNotFound(c, fmt.Sprintf("cannot find broadcast with ID [%s]", broadcastID))
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot delete broadcast with ID [%s]", broadcastID)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseNoContent(c, "broadcast deleted successfully")
+}
@@ -0,0 +1,292 @@
+package handlers
+
+import (
+	"fmt"
+	"net/url"
+
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
+	"github.com/NdoleStudio/httpsms/pkg/requests"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/NdoleStudio/httpsms/pkg/validators"
+	"github.com/davecgh/go-spew/spew"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/palantir/stacktrace"
+)
+
+// CampaignHandler handles campaign requests
+type CampaignHandler struct {
+	handler
+	logger    telemetry.Logger
+	tracer    telemetry.Tracer
+	service   *services.CampaignService
+	validator *validators.CampaignHandlerValidator
+}
+
+// NewCampaignHandler creates a new CampaignHandler
+func NewCampaignHandler(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	service *services.CampaignService,
+	validator *validators.CampaignHandlerValidator,
+) (h *CampaignHandler) {
+	return &CampaignHandler{
+		logger:    logger.WithService(fmt.Sprintf("%T", h)),
+		tracer:    tracer,
+		service:   service,
+		validator: validator,
+	}
+}
+
+// RegisterRoutes registers the routes for the CampaignHandler
+func (h *CampaignHandler) RegisterRoutes(app *fiber.App, middlewares ...fiber.Handler) {
+	router := app.Group("/v1/campaigns")
+	router.Get("/", h.computeRoute(middlewares, h.Index)...)
+	router.Post("/", h.computeRoute(middlewares, h.Store)...)
+	router.Get("/:campaignID", h.computeRoute(middlewares, h.Show)...)
+	router.Put("/:campaignID", h.computeRoute(middlewares, h.Update)...)
+	router.Delete("/:campaignID", h.computeRoute(middlewares, h.Delete)...)
+}
+
+// Index returns the campaigns of a user
+// @Summary      Get campaigns of a user
+// @Description  Get the recurring campaigns which send a templated message to the contacts of a segment
+// @Security	 ApiKeyAuth
+// @Tags         Campaigns
+// @Accept       json
+// @Produce      json
+// @Param        skip		query  int  	false	"number of campaigns to skip"		minimum(0)
+// @Param        query		query  string  	false 	"filter campaigns containing query"
+// @Param        limit		query  int  	false	"number of campaigns to return"	minimum(1)	maximum(100)
+// @Success      200 		{object}	responses.CampaignsResponse
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401	    {object}	responses.Unauthorized
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /campaigns 	[get]
+func (h *CampaignHandler) Index(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	var request requests.CampaignIndex
+	if err := c.QueryParser(&request); err != nil {
+		msg := fmt.Sprintf("cannot marshall URL [%s] into %T", c.OriginalURL(), request)
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return h.responseBadRequest(c, err)
+	}
+
+	if errors := h.validator.ValidateIndex(ctx, request.Sanitize()); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while fetching campaigns [%+#v]", spew.Sdump(errors), request)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while fetching campaigns")
+	}
+
+	campaigns, err := h.service.Index(ctx, h.userIDFomContext(c), request.ToIndexParams())
+	if err != nil {
+		msg := fmt.Sprintf("cannot get campaigns with params [%+#v]", request)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseOK(c, fmt.Sprintf("fetched %d %s", len(campaigns), h.pluralize("campaign", len(campaigns))), campaigns)
+}
+
+// Show returns a campaign
+// @Summary      Get a campaign
+// @Description  Get a campaign including the time of its next run and the batch ID of its last run
+// @Security	 ApiKeyAuth
+// @Tags         Campaigns
+// @Accept       json
+// @Produce      json
+// @Param 		 campaignID 	path		string 	true 	"ID of the campaign"	default(32343a19-da5e-4b1b-a767-3298a73703ca)
+// @Success      200 		{object}	responses.CampaignResponse
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401	    {object}	responses.Unauthorized
+// @Failure 	 404    	{object}	responses.NotFound
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /campaigns/{campaignID} 	[get]
+func (h *CampaignHandler) Show(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	campaignID := c.Params("campaignID")
+	if errors := h.validator.ValidateUUID(ctx, campaignID, "campaignID"); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while fetching campaign with ID [%s]", spew.Sdump(errors), campaignID)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while fetching campaign")
+	}
+
+	campaign, err := h.service.Load(ctx, h.userIDFomContext(c), uuid.MustParse(campaignID))
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		return h.responseNotFound(c, fmt.Sprintf("cannot find campaign with ID [%s]", campaignID))
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot load campaign with ID [%s]", campaignID)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseOK(c, "campaign fetched successfully", campaign)
+}
+
+// Store a campaign
+// @Summary      Store a campaign
+// @Description  Create a campaign which sends a templated message to the contacts of a segment or of a contact group on a cron schedule or every interval_minutes. Contacts which opted out or are on the blocklist are skipped.
+// @Security	 ApiKeyAuth
+// @Tags         Campaigns
+// @Accept       json
+// @Produce      json
+// @Param        payload   	body 		requests.CampaignStore  		true "Payload of the campaign request"
+// @Success      201 		{object}	responses.CampaignResponse
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401	    {object}	responses.Unauthorized
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /campaigns [post]
+func (h *CampaignHandler) Store(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	var request requests.CampaignStore
+	if err := c.BodyParser(&request); err != nil {
+		msg := fmt.Sprintf("cannot marshall [%s] into %T", c.Body(), request)
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return h.responseBadRequest(c, err)
+	}
+
+	if errors := h.validator.ValidateStore(ctx, h.userIDFomContext(c), request.Sanitize()); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while storing campaign [%+#v]", spew.Sdump(errors), request)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while storing campaign")
+	}
+
+	campaign, err := h.service.Store(ctx, request.ToStoreParams(h.userIDFomContext(c)))
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		return h.responseUnprocessableEntity(c, h.audienceNotFound(request), "validation errors while storing campaign")
+	}
+
+	if stacktrace.GetCode(err) == services.ErrCodeTemplateNotApproved {
+		return h.responseUnprocessableEntity(c, h.templateNotApproved(request), "validation errors while storing campaign")
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot store campaign with params [%+#v]", request)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseCreated(c, "campaign created successfully", campaign)
+}
+
+// Update a campaign
+// @Summary      Update a campaign
+// @Description  Change the schedule, segment, contact group or content of a campaign. The next run is scheduled from the time of the update.
+// @Security	 ApiKeyAuth
+// @Tags         Campaigns
+// @Accept       json
+// @Produce      json
+// @Param 		 campaignID 	path		string 					true 	"ID of the campaign"	default(32343a19-da5e-4b1b-a767-3298a73703ca)
+// @Param        payload   	body 		requests.CampaignStore  	true 	"Payload of the campaign request"
+// @Success      200 		{object}	responses.CampaignResponse
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401	    {object}	responses.Unauthorized
+// @Failure 	 404    	{object}	responses.NotFound
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /campaigns/{campaignID} [put]
+func (h *CampaignHandler) Update(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	var request requests.CampaignUpdate
+	if err := c.BodyParser(&request); err != nil {
+		msg := fmt.Sprintf("cannot marshall [%s] into %T", c.Body(), request)
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return h.responseBadRequest(c, err)
+	}
+
+	request.CampaignID = c.Params("campaignID")
+	if errors := h.validator.ValidateUpdate(ctx, h.userIDFomContext(c), request.Sanitize()); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while updating campaign [%+#v]", spew.Sdump(errors), request)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while updating campaign")
+	}
+
+	if _, err := h.service.Load(ctx, h.userIDFomContext(c), uuid.MustParse(request.CampaignID)); stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		return h.responseNotFound(c, fmt.Sprintf("cannot find campaign with ID [%s]", request.CampaignID))
+	}
+
+	campaign, err := h.service.Update(ctx, request.ToUpdateParams(h.userIDFomContext(c)))
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		return h.responseUnprocessableEntity(c, h.audienceNotFound(request.CampaignStore), "validation errors while updating campaign")
+	}
+
+	if stacktrace.GetCode(err) == services.ErrCodeTemplateNotApproved {
+		return h.responseUnprocessableEntity(c, h.templateNotApproved(request.CampaignStore), "validation errors while updating campaign")
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot update campaign with params [%+#v]", request)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseOK(c, "campaign updated successfully", campaign)
+}
+
+// Delete a campaign
+// @Summary      Delete a campaign
+// @Description  Delete a campaign, the messages which were already sent by the campaign are not deleted
+// @Security	 ApiKeyAuth
+// @Tags         Campaigns
+// @Accept       json
+// @Produce      json
+// @Param 		 campaignID 	path		string 							true 	"ID of the campaign"	default(32343a19-da5e-4b1b-a767-3298a73703ca)
+// @Success      204		{object}    responses.NoContent
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401    	{object}	responses.Unauthorized
+// @Failure 	 404    	{object}	responses.NotFound
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /campaigns/{campaignID} [delete]
+func (h *CampaignHandler) Delete(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	campaignID := c.Params("campaignID")
+	if errors := h.validator.ValidateUUID(ctx, campaignID, "campaignID"); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while deleting campaign with ID [%s]", spew.Sdump(errors), campaignID)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while deleting campaign")
+	}
+
+	err := h.service.Delete(ctx, h.userIDFomContext(c), uuid.MustParse(campaignID))
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		return h.responseNotFound(c, fmt.Sprintf("cannot find campaign with ID [%s]", campaignID))
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot delete campaign with ID [%s]", campaignID)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseNoContent(c, "campaign deleted successfully")
+}
+
+func (h *CampaignHandler) audienceNotFound(request requests.CampaignStore) url.Values {
+	if request.GroupID != "" {
+		return url.Values{"group_id": []string{fmt.Sprintf("no contact group found with ID [%s]", request.GroupID)}}
+	}
+	return url.Values{"segment_id": []string{fmt.Sprintf("no segment found with ID [%s]", request.SegmentID)}}
+}
+
+func (h *CampaignHandler) templateNotApproved(request requests.CampaignStore) url.Values {
+	if request.TemplateID == "" {
+		return url.Values{"template_id": []string{"your account requires campaigns to use an approved template, submit a template for review and set the template_id"}}
+	}
+	return url.Values{"template_id": []string{fmt.Sprintf("no approved template found with ID [%s]", request.TemplateID)}}
+}
@@ -0,0 +1,221 @@
+package handlers
+
+import (
+	"fmt"
+
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
+	"github.com/NdoleStudio/httpsms/pkg/requests"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/NdoleStudio/httpsms/pkg/validators"
+	"github.com/davecgh/go-spew/spew"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/palantir/stacktrace"
+)
+
+// CanaryHandler handles canary requests
+type CanaryHandler struct {
+	handler
+	logger    telemetry.Logger
+	tracer    telemetry.Tracer
+	service   *services.CanaryService
+	validator *validators.CanaryHandlerValidator
+}
+
+// NewCanaryHandler creates a new CanaryHandler
+func NewCanaryHandler(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	service *services.CanaryService,
+	validator *validators.CanaryHandlerValidator,
+) (h *CanaryHandler) {
+	return &CanaryHandler{
+		logger:    logger.WithService(fmt.Sprintf("%T", h)),
+		tracer:    tracer,
+		service:   service,
+		validator: validator,
+	}
+}
+
+// RegisterRoutes registers the routes for the CanaryHandler
+func (h *CanaryHandler) RegisterRoutes(app *fiber.App, middlewares ...fiber.Handler) {
+	router := app.Group("/v1/canaries")
+	router.Get("/", h.computeRoute(middlewares, h.Index)...)
+	router.Post("/", h.computeRoute(middlewares, h.Store)...)
+	router.Delete("/:canaryID", h.computeRoute(middlewares, h.Delete)...)
+	router.Get("/:canaryID/runs", h.computeRoute(middlewares, h.Runs)...)
+}
+
+// Index returns the canaries of a user
+// @Summary      Get canaries of a user
+// @Description  Get the canaries which periodically send test messages to monitor the sent, delivered and received loop
+// @Security	 ApiKeyAuth
+// @Tags         Canaries
+// @Accept       json
+// @Produce      json
+// @Param        skip		query  int  	false	"number of canaries to skip"		minimum(0)
+// @Param        query		query  string  	false 	"filter canaries containing query"
+// @Param        limit		query  int  	false	"number of canaries to return"	minimum(1)	maximum(100)
+// @Success      200 		{object}	responses.CanariesResponse
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401	    {object}	responses.Unauthorized
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /canaries 	[get]
+func (h *CanaryHandler) Index(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	var request requests.CanaryIndex
+	if err := c.QueryParser(&request); err != nil {
+		msg := fmt.Sprintf("cannot marshall URL [%s] into %T", c.OriginalURL(), request)
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return h.responseBadRequest(c, err)
+	}
+
+	if errors := h.validator.ValidateIndex(ctx, request.Sanitize()); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while fetching canaries [%+#v]", spew.Sdump(errors), request)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while fetching canaries")
+	}
+
+	canaries, err := h.service.Index(ctx, h.userIDFomContext(c), request.ToIndexParams())
+	if err != nil {
+		msg := fmt.Sprintf("cannot get canaries with params [%+#v]", request)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseOK(c, fmt.Sprintf("fetched %d canaries", len(canaries)), canaries)
+}
+
+// Store a canary
+// @Summary      Store a canary
+// @Description  Create a canary which periodically sends a test message from the owner phone to the contact phone and alerts when it is not received before the timeout. Use the same number as owner and contact for a loopback canary.
+// @Security	 ApiKeyAuth
+// @Tags         Canaries
+// @Accept       json
+// @Produce      json
+// @Param        payload   	body 		requests.CanaryStore  		true "Payload of the canary request"
+// @Success      201 		{object}	responses.CanaryResponse
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401	    {object}	responses.Unauthorized
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /canaries [post]
+func (h *CanaryHandler) Store(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	var request requests.CanaryStore
+	if err := c.BodyParser(&request); err != nil {
+		msg := fmt.Sprintf("cannot marshall body [%s] into [%T]", c.Body(), request)
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return h.responseBadRequest(c, err)
+	}
+
+	if errors := h.validator.ValidateStore(ctx, h.userIDFomContext(c), request.Sanitize()); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while storing canary [%+#v]", spew.Sdump(errors), request)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while storing canary")
+	}
+
+	canary, err := h.service.Store(ctx, request.ToStoreParams(h.userFromContext(c), c.OriginalURL()))
+	if err != nil {
+		msg := fmt.Sprintf("cannot store canary with params [%+#v]", request)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseCreated(c, "canary created successfully", canary)
+}
+
+// Delete a canary
+// @Summary      Delete a canary
+// @Description  Stop sending test messages for a canary and delete its runs
+// @Security	 ApiKeyAuth
+// @Tags         Canaries
+// @Accept       json
+// @Produce      json
+// @Param 		 canaryID 	path		string 							true 	"ID of the canary"	default(32343a19-da5e-4b1b-a767-3298a73703ca)
+// @Success      204		{object}    responses.NoContent
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401    	{object}	responses.Unauthorized
+// @Failure 	 404    	{object}	responses.NotFound
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /canaries/{canaryID} [delete]
+func (h *CanaryHandler) Delete(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	canaryID := c.Params("canaryID")
+	if errors := h.validator.ValidateUUID(ctx, canaryID, "canaryID"); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while deleting canary with ID [%s]", spew.Sdump(errors), canaryID)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while deleting canary")
+	}
+
+	err := h.service.Delete(ctx, h.userIDFomContext(c), uuid.MustParse(canaryID))
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		return h.responseNotFound(c, fmt.Sprintf("cannot find canary with ID [%s]", canaryID))
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot delete canary with ID [%s]", canaryID)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseNoContent(c, "canary deleted successfully")
+}
+
+// Runs returns the test messages sent by a canary
+// @Summary      Get the runs of a canary
+// @Description  Get the test messages sent by a canary with their status and round trip time
+// @Security	 ApiKeyAuth
+// @Tags         Canaries
+// @Accept       json
+// @Produce      json
+// @Param 		 canaryID 	path		string 	true 	"ID of the canary"	default(32343a19-da5e-4b1b-a767-3298a73703ca)
+// @Param        skip		query  int  	false	"number of runs to skip"		minimum(0)
+// @Param        limit		query  int  	false	"number of runs to return"	minimum(1)	maximum(100)
+// @Success      200 		{object}	responses.CanaryRunsResponse
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401	    {object}	responses.Unauthorized
+// @Failure 	 404    	{object}	responses.NotFound
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /canaries/{canaryID}/runs 	[get]
+func (h *CanaryHandler) Runs(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	var request requests.CanaryRunIndex
+	if err := c.QueryParser(&request); err != nil {
+		msg := fmt.Sprintf("cannot marshall URL [%s] into %T", c.OriginalURL(), request)
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return h.responseBadRequest(c, err)
+	}
+
+	request.CanaryID = c.Params("canaryID")
+	if errors := h.validator.ValidateRunIndex(ctx, request.Sanitize()); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while fetching canary runs [%+#v]", spew.Sdump(errors), request)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while fetching canary runs")
+	}
+
+	runs, err := h.service.Runs(ctx, h.userIDFomContext(c), uuid.MustParse(request.CanaryID), request.ToIndexParams())
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		return h.responseNotFound(c, fmt.Sprintf("cannot find canary with ID [%s]", request.CanaryID))
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot get canary runs with params [%+#v]", request)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseOK(c, fmt.Sprintf("fetched %d %s", len(runs), h.pluralize("run", len(runs))), runs)
+}
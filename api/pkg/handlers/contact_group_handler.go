@@ -0,0 +1,356 @@
+package handlers
+
+import (
+	"fmt"
+
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
+	"github.com/NdoleStudio/httpsms/pkg/requests"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/NdoleStudio/httpsms/pkg/validators"
+	"github.com/davecgh/go-spew/spew"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/palantir/stacktrace"
+)
+
+// ContactGroupHandler handles contact group requests
+type ContactGroupHandler struct {
+	handler
+	logger    telemetry.Logger
+	tracer    telemetry.Tracer
+	service   *services.ContactGroupService
+	validator *validators.ContactGroupHandlerValidator
+}
+
+// NewContactGroupHandler creates a new ContactGroupHandler
+func NewContactGroupHandler(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	service *services.ContactGroupService,
+	validator *validators.ContactGroupHandlerValidator,
+) (h *ContactGroupHandler) {
+	return &ContactGroupHandler{
+		logger:    logger.WithService(fmt.Sprintf("%T", h)),
+		tracer:    tracer,
+		service:   service,
+		validator: validator,
+	}
+}
+
+// RegisterRoutes registers the routes for the ContactGroupHandler
+func (h *ContactGroupHandler) RegisterRoutes(app *fiber.App, middlewares ...fiber.Handler) {
+	router := app.Group("/v1/contact-groups")
+	router.Get("/", h.computeRoute(middlewares, h.Index)...)
+	router.Post("/", h.computeRoute(middlewares, h.Store)...)
+	router.Get("/:groupID", h.computeRoute(middlewares, h.Show)...)
+	router.Put("/:groupID", h.computeRoute(middlewares, h.Update)...)
+	router.Delete("/:groupID", h.computeRoute(middlewares, h.Delete)...)
+	router.Post("/:groupID/contacts", h.computeRoute(middlewares, h.AddContacts)...)
+	router.Delete("/:groupID/contacts/:contactID", h.computeRoute(middlewares, h.RemoveContact)...)
+}
+
+// Index returns the contact groups of a user
+// @Summary      Get contact groups of a user
+// @Description  Get the contact groups of a user with the number of contacts in each group
+// @Security	 ApiKeyAuth
+// @Tags         ContactGroups
+// @Accept       json
+// @Produce      json
+// @Param        skip		query  int  	false	"number of contact groups to skip"			minimum(0)
+// @Param        query		query  string  	false 	"filter contact groups containing query"
+// @Param        limit		query  int  	false	"number of contact groups to return"		minimum(1)	maximum(100)
+// @Success      200 		{object}	responses.ContactGroupsResponse
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401	    {object}	responses.Unauthorized
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /contact-groups 	[get]
+func (h *ContactGroupHandler) Index(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	var request requests.ContactGroupIndex
+	if err := c.QueryParser(&request); err != nil {
+		msg := fmt.Sprintf("cannot marshall URL [%s] into %T", c.OriginalURL(), request)
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return h.responseBadRequest(c, err)
+	}
+
+	if errors := h.validator.ValidateIndex(ctx, request.Sanitize()); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while fetching contact groups [%+#v]", spew.Sdump(errors), request)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while fetching contact groups")
+	}
+
+	groups, err := h.service.Index(ctx, h.userIDFomContext(c), request.ToIndexParams())
+	if err != nil {
+		msg := fmt.Sprintf("cannot get contact groups with params [%+#v]", request)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseOK(c, fmt.Sprintf("fetched %d contact %s", len(groups), h.pluralize("group", len(groups))), groups)
+}
+
+// Store a contact group
+// @Summary      Store a contact group
+// @Description  Create a named list of contacts which can be used as the recipients of bulk messages and campaigns
+// @Security	 ApiKeyAuth
+// @Tags         ContactGroups
+// @Accept       json
+// @Produce      json
+// @Param        payload   	body 		requests.ContactGroupStore  	true "Payload of the contact group request"
+// @Success      201 		{object}	responses.ContactGroupResponse
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401	    {object}	responses.Unauthorized
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /contact-groups [post]
+func (h *ContactGroupHandler) Store(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	var request requests.ContactGroupStore
+	if err := c.BodyParser(&request); err != nil {
+		msg := fmt.Sprintf("cannot marshall [%s] into %T", c.Body(), request)
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return h.responseBadRequest(c, err)
+	}
+
+	if errors := h.validator.ValidateStore(ctx, request.Sanitize()); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while storing contact group [%+#v]", spew.Sdump(errors), request)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while storing contact group")
+	}
+
+	group, err := h.service.Store(ctx, request.ToStoreParams(h.userIDFomContext(c)))
+	if err != nil {
+		msg := fmt.Sprintf("cannot store contact group with params [%+#v]", request)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseCreated(c, "contact group created successfully", group)
+}
+
+// Show a contact group
+// @Summary      Get a contact group
+// @Description  Get a contact group with the number of contacts in the group. Use the group_id filter of the contacts API to fetch its members.
+// @Security	 ApiKeyAuth
+// @Tags         ContactGroups
+// @Accept       json
+// @Produce      json
+// @Param 		 groupID 	path		string 	true 	"ID of the contact group"	default(32343a19-da5e-4b1b-a767-3298a73703ca)
+// @Success      200 		{object}	responses.ContactGroupResponse
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401	    {object}	responses.Unauthorized
+// @Failure 	 404    	{object}	responses.NotFound
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /contact-groups/{groupID} [get]
+func (h *ContactGroupHandler) Show(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	groupID := c.Params("groupID")
+	if errors := h.validator.ValidateUUID(ctx, groupID, "groupID"); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while fetching contact group with ID [%s]", spew.Sdump(errors), groupID)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while fetching contact group")
+	}
+
+	group, err := h.service.Load(ctx, h.userIDFomContext(c), uuid.MustParse(groupID))
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		return h.responseNotFound(c, fmt.Sprintf("cannot find contact group with ID [%s]", groupID))
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot fetch contact group with ID [%s]", groupID)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseOK(c, "contact group fetched successfully", group)
+}
+
+// Update a contact group
+// @Summary      Update a contact group
+// @Description  Change the name and the description of a contact group
+// @Security	 ApiKeyAuth
+// @Tags         ContactGroups
+// @Accept       json
+// @Produce      json
+// @Param 		 groupID 	path		string 						true 	"ID of the contact group"	default(32343a19-da5e-4b1b-a767-3298a73703ca)
+// @Param        payload   	body 		requests.ContactGroupStore  true 	"Payload of the contact group request"
+// @Success      200 		{object}	responses.ContactGroupResponse
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401	    {object}	responses.Unauthorized
+// @Failure 	 404    	{object}	responses.NotFound
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /contact-groups/{groupID} [put]
+func (h *ContactGroupHandler) Update(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	var request requests.ContactGroupUpdate
+	if err := c.BodyParser(&request); err != nil {
+		msg := fmt.Sprintf("cannot marshall [%s] into %T", c.Body(), request)
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return h.responseBadRequest(c, err)
+	}
+
+	request.GroupID = c.Params("groupID")
+	if errors := h.validator.ValidateUpdate(ctx, request.Sanitize()); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while updating contact group [%+#v]", spew.Sdump(errors), request)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while updating contact group")
+	}
+
+	group, err := h.service.Update(ctx, request.ToUpdateParams(h.userIDFomContext(c)))
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		return h.responseNotFound(c, fmt.Sprintf("cannot find contact group with ID [%s]", request.GroupID))
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot update contact group with params [%+#v]", request)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseOK(c, "contact group updated successfully", group)
+}
+
+// Delete a contact group
+// @Summary      Delete a contact group
+// @Description  Delete a contact group, the contacts in the group are not deleted
+// @Security	 ApiKeyAuth
+// @Tags         ContactGroups
+// @Accept       json
+// @Produce      json
+// @Param 		 groupID 	path		string 	true 	"ID of the contact group"	default(32343a19-da5e-4b1b-a767-3298a73703ca)
+// @Success      204		{object}    responses.NoContent
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401    	{object}	responses.Unauthorized
+// @Failure 	 404    	{object}	responses.NotFound
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /contact-groups/{groupID} [delete]
+func (h *ContactGroupHandler) Delete(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	groupID := c.Params("groupID")
+	if errors := h.validator.ValidateUUID(ctx, groupID, "groupID"); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while deleting contact group with ID [%s]", spew.Sdump(errors), groupID)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while deleting contact group")
+	}
+
+	err := h.service.Delete(ctx, h.userIDFomContext(c), uuid.MustParse(groupID))
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		return h.responseNotFound(c, fmt.Sprintf("cannot find contact group with ID [%s]", groupID))
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot delete contact group with ID [%s]", groupID)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseNoContent(c, "contact group deleted successfully")
+}
+
+// AddContacts adds contacts to a contact group
+// @Summary      Add contacts to a contact group
+// @Description  Add up to 100 contacts to a contact group. Contacts which are already in the group are ignored.
+// @Security	 ApiKeyAuth
+// @Tags         ContactGroups
+// @Accept       json
+// @Produce      json
+// @Param 		 groupID 	path		string 								true 	"ID of the contact group"	default(32343a19-da5e-4b1b-a767-3298a73703ca)
+// @Param        payload   	body 		requests.ContactGroupAddContacts  	true 	"IDs of the contacts"
+// @Success      200 		{object}	responses.ContactGroupResponse
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401	    {object}	responses.Unauthorized
+// @Failure 	 404    	{object}	responses.NotFound
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /contact-groups/{groupID}/contacts [post]
+func (h *ContactGroupHandler) AddContacts(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	var request requests.ContactGroupAddContacts
+	if err := c.BodyParser(&request); err != nil {
+		msg := fmt.Sprintf("cannot marshall [%s] into %T", c.Body(), request)
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return h.responseBadRequest(c, err)
+	}
+
+	request.GroupID = c.Params("groupID")
+	if errors := h.validator.ValidateAddContacts(ctx, request.Sanitize()); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while adding contacts to group [%+#v]", spew.Sdump(errors), request)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while adding contacts to group")
+	}
+
+	group, err := h.service.AddContacts(ctx, h.userIDFomContext(c), uuid.MustParse(request.GroupID), request.ToContactIDs())
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		return h.responseNotFound(c, fmt.Sprintf("cannot find contact group with ID [%s]", request.GroupID))
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot add contacts to group with params [%+#v]", request)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseOK(c, "contacts added to group successfully", group)
+}
+
+// RemoveContact removes a contact from a contact group
+// @Summary      Remove a contact from a contact group
+// @Description  Remove a contact from a contact group, the contact is not deleted
+// @Security	 ApiKeyAuth
+// @Tags         ContactGroups
+// @Accept       json
+// @Produce      json
+// @Param 		 groupID 	path		string 	true 	"ID of the contact group"	default(32343a19-da5e-4b1b-a767-3298a73703ca)
+// @Param 		 contactID 	path		string 	true 	"ID of the contact"			default(32343a19-da5e-4b1b-a767-3298a73703ca)
+// @Success      204		{object}    responses.NoContent
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401    	{object}	responses.Unauthorized
+// @Failure 	 404    	{object}	responses.NotFound
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /contact-groups/{groupID}/contacts/{contactID} [delete]
+func (h *ContactGroupHandler) RemoveContact(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	groupID, contactID := c.Params("groupID"), c.Params("contactID")
+	errors := h.validator.ValidateUUID(ctx, groupID, "groupID")
+	for key, values := range h.validator.ValidateUUID(ctx, contactID, "contactID") {
+		errors[key] = append(errors[key], values...)
+	}
+	if len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while removing contact [%s] from group [%s]", spew.Sdump(errors), contactID, groupID)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while removing contact from group")
+	}
+
+	err := h.service.RemoveContact(ctx, h.userIDFomContext(c), uuid.MustParse(groupID), uuid.MustParse(contactID))
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		return h.responseNotFound(c, fmt.Sprintf("cannot find contact with ID [%s] in group with ID [%s]", contactID, groupID))
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot remove contact [%s] from group [%s]", contactID, groupID)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseNoContent(c, "contact removed from group successfully")
+}
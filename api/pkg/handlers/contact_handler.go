@@ -0,0 +1,284 @@
+package handlers
+
+import (
+	"fmt"
+	"net/url"
+
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
+	"github.com/NdoleStudio/httpsms/pkg/requests"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/NdoleStudio/httpsms/pkg/validators"
+	"github.com/davecgh/go-spew/spew"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/palantir/stacktrace"
+)
+
+// ContactHandler handles contact requests
+type ContactHandler struct {
+	handler
+	logger     telemetry.Logger
+	tracer     telemetry.Tracer
+	service    *services.ContactService
+	validator  *validators.ContactHandlerValidator
+	tagService *services.TagService
+}
+
+// NewContactHandler creates a new ContactHandler
+func NewContactHandler(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	service *services.ContactService,
+	validator *validators.ContactHandlerValidator,
+	tagService *services.TagService,
+) (h *ContactHandler) {
+	return &ContactHandler{
+		logger:     logger.WithService(fmt.Sprintf("%T", h)),
+		tracer:     tracer,
+		service:    service,
+		validator:  validator,
+		tagService: tagService,
+	}
+}
+
+// RegisterRoutes registers the routes for the ContactHandler
+func (h *ContactHandler) RegisterRoutes(app *fiber.App, middlewares ...fiber.Handler) {
+	router := app.Group("/v1/contacts")
+	router.Get("/", h.computeRoute(middlewares, h.Index)...)
+	router.Post("/", h.computeRoute(middlewares, h.Store)...)
+	router.Get("/:contactID", h.computeRoute(middlewares, h.Show)...)
+	router.Put("/:contactID", h.computeRoute(middlewares, h.Update)...)
+	router.Delete("/:contactID", h.computeRoute(middlewares, h.Delete)...)
+}
+
+// Index returns the contacts of a user
+// @Summary      Get contacts of a user
+// @Description  Get the contacts of a user with their engagement metrics e.g. messages sent, messages received, reply rate and opt-out status
+// @Security	 ApiKeyAuth
+// @Tags         Contacts
+// @Accept       json
+// @Produce      json
+// @Param        skip			query  int  	false	"number of contacts to skip"		minimum(0)
+// @Param        query			query  string  	false 	"filter contacts containing query"
+// @Param        is_opted_out	query  bool  	false 	"filter contacts by opt-out status"
+// @Param        group_id		query  string  	false 	"filter contacts which are members of a contact group"
+// @Param        tag			query  string  	false 	"filter contacts with the tag or one of its descendants"
+// @Param        sort_by		query  string  	false 	"sort contacts in descending order"	Enums(messages_sent,messages_received,reply_rate,last_sent_at,last_reply_at)
+// @Param        limit			query  int  	false	"number of contacts to return"		minimum(1)	maximum(100)
+// @Success      200 			{object}	responses.ContactsResponse
+// @Failure      400			{object}	responses.BadRequest
+// @Failure 	 401	    	{object}	responses.Unauthorized
+// @Failure      422			{object}	responses.UnprocessableEntity
+// @Failure      500			{object}	responses.InternalServerError
+// @Router       /contacts 	[get]
+func (h *ContactHandler) Index(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	var request requests.ContactIndex
+	if err := c.QueryParser(&request); err != nil {
+		msg := fmt.Sprintf("cannot marshall URL [%s] into %T", c.OriginalURL(), request)
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return h.responseBadRequest(c, err)
+	}
+
+	if errors := h.validator.ValidateIndex(ctx, request.Sanitize()); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while fetching contacts [%+#v]", spew.Sdump(errors), request)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while fetching contacts")
+	}
+
+	params := request.ToIndexParams()
+	if request.Tag != "" {
+		tags, err := h.tagService.Expand(ctx, h.userIDFomContext(c), request.Tag)
+		if err != nil {
+			msg := fmt.Sprintf("cannot expand tag [%s] for user [%s]", request.Tag, h.userIDFomContext(c))
+			ctxLogger.Error(stacktrace.Propagate(err, msg))
+			return h.responseInternalServerError(c)
+		}
+		params.Tags = tags
+	}
+
+	contacts, err := h.service.Index(ctx, h.userIDFomContext(c), params)
+	if err != nil {
+		msg := fmt.Sprintf("cannot get contacts with params [%+#v]", request)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseOK(c, fmt.Sprintf("fetched %d %s", len(contacts), h.pluralize("contact", len(contacts))), contacts)
+}
+
+// Store a contact
+// @Summary      Store a contact
+// @Description  Add a phone number to the contact book. Contacts are also created automatically when a message is exchanged with a phone number.
+// @Security	 ApiKeyAuth
+// @Tags         Contacts
+// @Accept       json
+// @Produce      json
+// @Param        payload   	body 		requests.ContactStore  	true "Payload of the contact request"
+// @Success      201 		{object}	responses.ContactResponse
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401	    {object}	responses.Unauthorized
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /contacts [post]
+func (h *ContactHandler) Store(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	var request requests.ContactStore
+	if err := c.BodyParser(&request); err != nil {
+		msg := fmt.Sprintf("cannot marshall [%s] into %T", c.Body(), request)
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return h.responseBadRequest(c, err)
+	}
+
+	if errors := h.validator.ValidateStore(ctx, request.Sanitize()); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while storing contact [%+#v]", spew.Sdump(errors), request)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while storing contact")
+	}
+
+	contact, err := h.service.Store(ctx, request.ToStoreParams(h.userIDFomContext(c)))
+	if stacktrace.GetCode(err) == services.ErrCodeContactExists {
+		return h.responseUnprocessableEntity(c, url.Values{"phone_number": []string{fmt.Sprintf("a contact with phone number [%s] already exists", request.PhoneNumber)}}, "validation errors while storing contact")
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot store contact with params [%+#v]", request)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseCreated(c, "contact created successfully", contact)
+}
+
+// Show a contact
+// @Summary      Get a contact
+// @Description  Get a contact of a user with its engagement metrics
+// @Security	 ApiKeyAuth
+// @Tags         Contacts
+// @Accept       json
+// @Produce      json
+// @Param 		 contactID 	path		string 	true 	"ID of the contact"	default(32343a19-da5e-4b1b-a767-3298a73703ca)
+// @Success      200 		{object}	responses.ContactResponse
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401	    {object}	responses.Unauthorized
+// @Failure 	 404    	{object}	responses.NotFound
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /contacts/{contactID} [get]
+func (h *ContactHandler) Show(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	contactID := c.Params("contactID")
+	if errors := h.validator.ValidateUUID(ctx, contactID, "contactID"); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while fetching contact with ID [%s]", spew.Sdump(errors), contactID)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while fetching contact")
+	}
+
+	contact, err := h.service.Load(ctx, h.userIDFomContext(c), uuid.MustParse(contactID))
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		return h.responseNotFound(c, fmt.Sprintf("cannot find contact with ID [%s]", contactID))
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot fetch contact with ID [%s]", contactID)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseOK(c, "contact fetched successfully", contact)
+}
+
+// Delete a contact
+// @Summary      Delete a contact
+// @Description  Remove a contact from the contact book and from all its contact groups. The messages exchanged with the contact are not deleted.
+// @Security	 ApiKeyAuth
+// @Tags         Contacts
+// @Accept       json
+// @Produce      json
+// @Param 		 contactID 	path		string 	true 	"ID of the contact"	default(32343a19-da5e-4b1b-a767-3298a73703ca)
+// @Success      204		{object}    responses.NoContent
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401    	{object}	responses.Unauthorized
+// @Failure 	 404    	{object}	responses.NotFound
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /contacts/{contactID} [delete]
+func (h *ContactHandler) Delete(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	contactID := c.Params("contactID")
+	if errors := h.validator.ValidateUUID(ctx, contactID, "contactID"); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while deleting contact with ID [%s]", spew.Sdump(errors), contactID)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while deleting contact")
+	}
+
+	err := h.service.Delete(ctx, h.userIDFomContext(c), uuid.MustParse(contactID))
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		return h.responseNotFound(c, fmt.Sprintf("cannot find contact with ID [%s]", contactID))
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot delete contact with ID [%s]", contactID)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseNoContent(c, "contact deleted successfully")
+}
+
+// Update the name, tags and attributes of a contact
+// @Summary      Update a contact
+// @Description  Set the name, tags and attributes of a contact. The tags and attributes are used to build segments.
+// @Security	 ApiKeyAuth
+// @Tags         Contacts
+// @Accept       json
+// @Produce      json
+// @Param 		 contactID 	path		string 					true 	"ID of the contact"	default(32343a19-da5e-4b1b-a767-3298a73703ca)
+// @Param        payload   	body 		requests.ContactUpdate  true 	"Payload of the contact update request"
+// @Success      200 		{object}	responses.ContactResponse
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401    	{object}	responses.Unauthorized
+// @Failure 	 404    	{object}	responses.NotFound
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /contacts/{contactID} [put]
+func (h *ContactHandler) Update(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	var request requests.ContactUpdate
+	if err := c.BodyParser(&request); err != nil {
+		msg := fmt.Sprintf("cannot marshall [%s] into %T", c.Body(), request)
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return h.responseBadRequest(c, err)
+	}
+
+	request.ContactID = c.Params("contactID")
+	if errors := h.validator.ValidateUpdate(ctx, request.Sanitize()); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while updating contact [%+#v]", spew.Sdump(errors), request)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while updating contact")
+	}
+
+	contact, err := h.service.Update(ctx, request.ToUpdateParams(h.userIDFomContext(c)))
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		return h.responseNotFound(c, fmt.Sprintf("cannot find contact with ID [%s]", request.ContactID))
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot update contact with params [%+#v]", request)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseOK(c, "contact updated successfully", contact)
+}
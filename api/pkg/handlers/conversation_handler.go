@@ -0,0 +1,188 @@
+package handlers
+
+import (
+	"fmt"
+	"net/url"
+
+	"github.com/NdoleStudio/httpsms/pkg/requests"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/NdoleStudio/httpsms/pkg/validators"
+	"github.com/davecgh/go-spew/spew"
+	"github.com/gofiber/fiber/v2"
+	"github.com/palantir/stacktrace"
+)
+
+// ConversationHandler handles the conversations which group messages by owner and contact
+type ConversationHandler struct {
+	handler
+	logger    telemetry.Logger
+	tracer    telemetry.Tracer
+	service   *services.ConversationService
+	validator *validators.ConversationHandlerValidator
+}
+
+// NewConversationHandler creates a new ConversationHandler
+func NewConversationHandler(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	service *services.ConversationService,
+	validator *validators.ConversationHandlerValidator,
+) (h *ConversationHandler) {
+	return &ConversationHandler{
+		logger:    logger.WithService(fmt.Sprintf("%T", h)),
+		tracer:    tracer,
+		service:   service,
+		validator: validator,
+	}
+}
+
+// RegisterRoutes registers the routes for the ConversationHandler
+func (h *ConversationHandler) RegisterRoutes(app *fiber.App, middlewares ...fiber.Handler) {
+	router := app.Group("/v1/conversations")
+	router.Get("/", h.computeRoute(middlewares, h.Index)...)
+	router.Get("/:contact/messages", h.computeRoute(middlewares, h.Messages)...)
+	router.Put("/:contact/read", h.computeRoute(middlewares, h.PutRead)...)
+}
+
+// Index returns the conversations of a user
+// @Summary      Get conversations
+// @Description  Get the contacts which the phone numbers of a user have exchanged messages with, with a preview of the last message and the number of unread messages. The conversation with the most recent message is first, use the next_cursor of a page as the cursor to fetch the next page.
+// @Security	 ApiKeyAuth
+// @Tags         Conversations
+// @Produce      json
+// @Param        owner		query  string  	false	"filter the conversations of a phone number"	default(+18005550199)
+// @Param        cursor		query  string  	false	"next_cursor of the previous page"
+// @Param        limit		query  int  	false	"number of conversations to return"	minimum(1)	maximum(100)
+// @Success      200 		{object}	responses.ConversationsResponse
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401	    {object}	responses.Unauthorized
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /conversations [get]
+func (h *ConversationHandler) Index(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	var request requests.ConversationIndex
+	if err := c.QueryParser(&request); err != nil {
+		msg := fmt.Sprintf("cannot marshall URL [%s] into %T", c.OriginalURL(), request)
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return h.responseBadRequest(c, err)
+	}
+
+	if errors := h.validator.ValidateIndex(ctx, request.Sanitize()); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while fetching conversations [%+#v]", spew.Sdump(errors), request)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while fetching conversations")
+	}
+
+	page, err := h.service.Index(ctx, h.userIDFomContext(c), request.ToIndexParams())
+	if err != nil {
+		msg := fmt.Sprintf("cannot get conversations with params [%+#v]", request)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseOK(c, fmt.Sprintf("fetched %d %s", len(page.Conversations), h.pluralize("conversation", len(page.Conversations))), page)
+}
+
+// Messages returns the messages of a conversation
+// @Summary      Get the messages of a conversation
+// @Description  Get the messages exchanged between a phone number and a contact ordered by the sequence which the server assigned to them from the newest to the oldest. Use the next_cursor of a page as the cursor to fetch older messages.
+// @Security	 ApiKeyAuth
+// @Tags         Conversations
+// @Produce      json
+// @Param        contact	path   string  	true	"phone number of the contact"		default(+18005550100)
+// @Param        owner		query  string  	true	"phone number of the owner"			default(+18005550199)
+// @Param        cursor		query  string  	false	"next_cursor of the previous page"
+// @Param        limit		query  int  	false	"number of messages to return"	minimum(1)	maximum(100)
+// @Success      200 		{object}	responses.ConversationMessagesResponse
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401	    {object}	responses.Unauthorized
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /conversations/{contact}/messages [get]
+func (h *ConversationHandler) Messages(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	var request requests.ConversationMessages
+	if err := c.QueryParser(&request); err != nil {
+		msg := fmt.Sprintf("cannot marshall URL [%s] into %T", c.OriginalURL(), request)
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return h.responseBadRequest(c, err)
+	}
+
+	contact, err := url.PathUnescape(c.Params("contact"))
+	if err != nil {
+		msg := fmt.Sprintf("cannot unescape contact [%s] in URL [%s]", c.Params("contact"), c.OriginalURL())
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return h.responseBadRequest(c, err)
+	}
+	request.Contact = contact
+
+	if errors := h.validator.ValidateMessages(ctx, request.Sanitize()); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while fetching conversation messages [%+#v]", spew.Sdump(errors), request)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while fetching conversation messages")
+	}
+
+	page, err := h.service.Messages(ctx, h.userIDFomContext(c), request.ToParams())
+	if err != nil {
+		msg := fmt.Sprintf("cannot get conversation messages with params [%+#v]", request)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseOK(c, fmt.Sprintf("fetched %d %s", len(page.Messages), h.pluralize("message", len(page.Messages))), page)
+}
+
+// PutRead marks the messages of a conversation as read
+// @Summary      Mark a conversation as read
+// @Description  Mark all the messages which an owner received from a contact as read. A message.read event containing the IDs of the messages is emitted so that other clients of the user can update the unread state.
+// @Security	 ApiKeyAuth
+// @Tags         Conversations
+// @Produce      json
+// @Param        contact	path   string  	true	"phone number of the contact"		default(+18005550100)
+// @Param        owner		query  string  	true	"phone number of the owner"			default(+18005550199)
+// @Success      200 		{object}	responses.ConversationReadResponse
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401	    {object}	responses.Unauthorized
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /conversations/{contact}/read [put]
+func (h *ConversationHandler) PutRead(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	var request requests.ConversationRead
+	if err := c.QueryParser(&request); err != nil {
+		msg := fmt.Sprintf("cannot marshall URL [%s] into %T", c.OriginalURL(), request)
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return h.responseBadRequest(c, err)
+	}
+
+	contact, err := url.PathUnescape(c.Params("contact"))
+	if err != nil {
+		msg := fmt.Sprintf("cannot unescape contact [%s] in URL [%s]", c.Params("contact"), c.OriginalURL())
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return h.responseBadRequest(c, err)
+	}
+	request.Contact = contact
+
+	if errors := h.validator.ValidateRead(ctx, request.Sanitize()); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while marking conversation as read [%+#v]", spew.Sdump(errors), request)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while marking conversation as read")
+	}
+
+	result, err := h.service.MarkRead(ctx, request.ToParams(c.OriginalURL(), h.userIDFomContext(c)))
+	if err != nil {
+		msg := fmt.Sprintf("cannot mark conversation as read with params [%+#v]", request)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseOK(c, fmt.Sprintf("marked %d %s as read", len(result.MessageIDs), h.pluralize("message", len(result.MessageIDs))), result)
+}
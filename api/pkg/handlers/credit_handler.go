@@ -0,0 +1,176 @@
+package handlers
+
+import (
+	"fmt"
+
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
+	"github.com/NdoleStudio/httpsms/pkg/requests"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/NdoleStudio/httpsms/pkg/validators"
+	"github.com/davecgh/go-spew/spew"
+	"github.com/gofiber/fiber/v2"
+	"github.com/palantir/stacktrace"
+)
+
+// CreditHandler handles prepaid credit requests
+type CreditHandler struct {
+	handler
+	logger          telemetry.Logger
+	tracer          telemetry.Tracer
+	service         *services.CreditService
+	operatorService *services.OperatorService
+	validator       *validators.CreditHandlerValidator
+}
+
+// NewCreditHandler creates a new CreditHandler
+func NewCreditHandler(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	service *services.CreditService,
+	operatorService *services.OperatorService,
+	validator *validators.CreditHandlerValidator,
+) (h *CreditHandler) {
+	return &CreditHandler{
+		logger:          logger.WithService(fmt.Sprintf("%T", h)),
+		tracer:          tracer,
+		service:         service,
+		operatorService: operatorService,
+		validator:       validator,
+	}
+}
+
+// RegisterRoutes registers the routes for the CreditHandler
+func (h *CreditHandler) RegisterRoutes(app *fiber.App, middlewares ...fiber.Handler) {
+	router := app.Group("/v1/credits")
+	router.Get("/balance", h.computeRoute(middlewares, h.Balance)...)
+	router.Get("/transactions", h.computeRoute(middlewares, h.Index)...)
+
+	app.Post("/v1/operator/credits", h.computeRoute(middlewares, h.TopUp)...)
+}
+
+// Balance returns the prepaid credit balance of a user
+// @Summary      Get the credit balance
+// @Description  Get the prepaid credit balance of the authenticated user. Messages cannot be sent when the balance is zero or less.
+// @Security	 ApiKeyAuth
+// @Tags         Credits
+// @Accept       json
+// @Produce      json
+// @Success      200 		{object}	responses.CreditBalanceResponse
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401	    {object}	responses.Unauthorized
+// @Failure 	 404    	{object}	responses.NotFound
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /credits/balance 	[get]
+func (h *CreditHandler) Balance(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	balance, err := h.service.Balance(ctx, h.userIDFomContext(c))
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		return h.responseNotFound(c, "you are not on prepaid credits")
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot load credit balance for user [%s]", h.userIDFomContext(c))
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseOK(c, "credit balance fetched successfully", balance)
+}
+
+// Index returns the credit ledger of a user
+// @Summary      Get the credit ledger
+// @Description  Get the top-ups, adjustments and message debits of the authenticated user sorted by time in descending order. Use the from and to parameters to fetch the statement of a period.
+// @Security	 ApiKeyAuth
+// @Tags         Credits
+// @Accept       json
+// @Produce      json
+// @Param        skip		query  int  	false	"number of transactions to skip"		minimum(0)
+// @Param        limit		query  int  	false	"number of transactions to return"	minimum(1)	maximum(100)
+// @Param        from		query  string  	false	"RFC3339 start of the statement period"
+// @Param        to			query  string  	false	"RFC3339 end of the statement period"
+// @Success      200 		{object}	responses.CreditTransactionsResponse
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401	    {object}	responses.Unauthorized
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /credits/transactions 	[get]
+func (h *CreditHandler) Index(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	var request requests.CreditTransactionIndex
+	if err := c.QueryParser(&request); err != nil {
+		msg := fmt.Sprintf("cannot marshall URL [%s] into %T", c.OriginalURL(), request)
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return h.responseBadRequest(c, err)
+	}
+
+	if errors := h.validator.ValidateIndex(ctx, request.Sanitize()); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while fetching credit transactions [%+#v]", spew.Sdump(errors), request)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while fetching credit transactions")
+	}
+
+	transactions, err := h.service.Index(ctx, h.userIDFomContext(c), request.ToIndexParams())
+	if err != nil {
+		msg := fmt.Sprintf("cannot get credit transactions with params [%+#v]", request)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseOK(c, fmt.Sprintf("fetched %d credit %s", len(transactions), h.pluralize("transaction", len(transactions))), transactions)
+}
+
+// TopUp adds credits to the balance of a user
+// @Summary      Top up the credits of a user
+// @Description  Add prepaid credits to the balance of a user, a negative amount removes credits as an adjustment. The user is limited by the credit balance instead of the subscription plan after the first top up. Only operators listed in OPERATOR_USER_IDS can use this endpoint.
+// @Security	 ApiKeyAuth
+// @Tags         Credits
+// @Accept       json
+// @Produce      json
+// @Param        payload   	body 		requests.CreditTopUp  		true "Payload of the top up request"
+// @Success      201 		{object}	responses.CreditTransactionResponse
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401	    {object}	responses.Unauthorized
+// @Failure 	 404    	{object}	responses.NotFound
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /operator/credits [post]
+func (h *CreditHandler) TopUp(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	if !h.operatorService.IsOperator(h.userIDFomContext(c)) {
+		ctxLogger.Warn(stacktrace.NewError(fmt.Sprintf("user [%s] is not an operator and cannot top up credits", h.userIDFomContext(c))))
+		return h.responseForbidden(c)
+	}
+
+	var request requests.CreditTopUp
+	if err := c.BodyParser(&request); err != nil {
+		msg := fmt.Sprintf("cannot marshall [%s] into %T", c.Body(), request)
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return h.responseBadRequest(c, err)
+	}
+
+	if errors := h.validator.ValidateTopUp(ctx, request.Sanitize()); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while topping up credits [%+#v]", spew.Sdump(errors), request)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while topping up credits")
+	}
+
+	transaction, err := h.service.TopUp(ctx, request.ToTopUpParams(h.userIDFomContext(c)))
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		return h.responseNotFound(c, fmt.Sprintf("cannot find user with ID [%s]", request.UserID))
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot top up credits with params [%+#v]", request)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseCreated(c, "credits topped up successfully", transaction)
+}
@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"fmt"
+
+	"github.com/NdoleStudio/httpsms/pkg/services"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/gofiber/fiber/v2"
+	"github.com/palantir/stacktrace"
+)
+
+// DashboardHandler handles dashboard requests
+type DashboardHandler struct {
+	handler
+	logger  telemetry.Logger
+	tracer  telemetry.Tracer
+	service *services.DashboardService
+}
+
+// NewDashboardHandler creates a new DashboardHandler
+func NewDashboardHandler(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	service *services.DashboardService,
+) (h *DashboardHandler) {
+	return &DashboardHandler{
+		logger:  logger.WithService(fmt.Sprintf("%T", h)),
+		tracer:  tracer,
+		service: service,
+	}
+}
+
+// RegisterRoutes registers the routes for the DashboardHandler
+func (h *DashboardHandler) RegisterRoutes(app *fiber.App, middlewares ...fiber.Handler) {
+	app.Get("/v1/dashboard", h.computeRoute(middlewares, h.Show)...)
+}
+
+// Show returns the dashboard summary of a user
+// @Summary      Get the dashboard summary
+// @Description  Get the counts of messages today, the status of the phones, the queue depth, the latest failed messages and the usage of the plan in a single request
+// @Security	 ApiKeyAuth
+// @Tags         Dashboard
+// @Produce      json
+// @Success      200 		{object}	responses.DashboardResponse
+// @Failure 	 401	    {object}	responses.Unauthorized
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /dashboard [get]
+func (h *DashboardHandler) Show(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	dashboard, err := h.service.Summary(ctx, h.userIDFomContext(c))
+	if err != nil {
+		msg := fmt.Sprintf("cannot load dashboard for user [%s]", h.userIDFomContext(c))
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseOK(c, "dashboard fetched successfully", dashboard)
+}
@@ -367,7 +367,7 @@ func (h *DiscordHandler) sendSMS(ctx context.Context, c *fiber.Ctx, payload map[
 		)
 	}
 
-	if msg := h.billingService.IsEntitled(ctx, discord.UserID); msg != nil {
+	if msg := h.billingService.IsEntitledToSend(ctx, discord.UserID); msg != nil {
 		ctxLogger.Warn(stacktrace.NewError(fmt.Sprintf("user with ID [%s] can't send a message", discord.UserID)))
 		return c.JSON(
 			fiber.Map{
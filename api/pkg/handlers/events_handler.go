@@ -1,24 +1,38 @@
 package handlers
 
 import (
+	"bufio"
+	"encoding/json"
 	"fmt"
+	"time"
 
 	cloudevents "github.com/cloudevents/sdk-go/v2"
 
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
+	"github.com/NdoleStudio/httpsms/pkg/requests"
 	"github.com/NdoleStudio/httpsms/pkg/services"
 	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/NdoleStudio/httpsms/pkg/validators"
 	"github.com/davecgh/go-spew/spew"
 	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
 	"github.com/palantir/stacktrace"
+	"github.com/valyala/fasthttp"
 )
 
+// eventStreamKeepAlive is the interval of the comments which keep an idle event stream open
+const eventStreamKeepAlive = 15 * time.Second
+
 // EventsHandler handles heartbeat http requests.
 type EventsHandler struct {
 	handler
-	logger      telemetry.Logger
-	tracer      telemetry.Tracer
-	queueConfig services.PushQueueConfig
-	service     *services.EventDispatcher
+	logger       telemetry.Logger
+	tracer       telemetry.Tracer
+	queueConfig  services.PushQueueConfig
+	service      *services.EventDispatcher
+	eventService *services.EventService
+	hub          *services.EventHub
+	validator    *validators.EventsHandlerValidator
 }
 
 // NewEventsHandler creates a new EventsHandler
@@ -27,18 +41,192 @@ func NewEventsHandler(
 	tracer telemetry.Tracer,
 	queueConfig services.PushQueueConfig,
 	service *services.EventDispatcher,
+	eventService *services.EventService,
+	hub *services.EventHub,
+	validator *validators.EventsHandlerValidator,
 ) (h *EventsHandler) {
 	return &EventsHandler{
-		logger:      logger.WithService(fmt.Sprintf("%T", h)),
-		tracer:      tracer,
-		queueConfig: queueConfig,
-		service:     service,
+		logger:       logger.WithService(fmt.Sprintf("%T", h)),
+		tracer:       tracer,
+		queueConfig:  queueConfig,
+		service:      service,
+		eventService: eventService,
+		hub:          hub,
+		validator:    validator,
 	}
 }
 
 // RegisterRoutes registers the routes for the MessageHandler
 func (h *EventsHandler) RegisterRoutes(router fiber.Router) {
 	router.Post("/events", h.Dispatch)
+	router.Get("/events", h.Index)
+	router.Get("/events/stream", h.Stream)
+	router.Post("/events/:eventID/replay", h.Replay)
+}
+
+// Index returns the events of a user
+// @Summary      Get events of a user
+// @Description  Get the events of a user from the newest to the oldest. Use the next_cursor of a page as the cursor to fetch the next page.
+// @Security	 ApiKeyAuth
+// @Tags         Events
+// @Accept       json
+// @Produce      json
+// @Param        type		query  string  	false	"comma separated list of event types"	default(message.phone.sent,message.phone.delivered)
+// @Param        source		query  string  	false	"source of the events"
+// @Param        since		query  string  	false	"RFC3339 timestamp of the oldest event"	default(2022-06-05T14:26:09+03:00)
+// @Param        until		query  string  	false	"RFC3339 timestamp of the newest event"	default(2022-06-06T14:26:09+03:00)
+// @Param        entity_id	query  string  	false	"ID of the message, phone or webhook referenced by the event"
+// @Param        cursor		query  string  	false	"next_cursor of the previous page"
+// @Param        limit		query  int  	false	"number of events to return"	minimum(1)	maximum(100)
+// @Success      200 		{object}	responses.EventsResponse
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401	    {object}	responses.Unauthorized
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /events 	[get]
+func (h *EventsHandler) Index(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	var request requests.EventIndex
+	if err := c.QueryParser(&request); err != nil {
+		msg := fmt.Sprintf("cannot marshall URL [%s] into %T", c.OriginalURL(), request)
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return h.responseBadRequest(c, err)
+	}
+
+	if errors := h.validator.ValidateIndex(ctx, request.Sanitize()); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while fetching events [%+#v]", spew.Sdump(errors), request)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while fetching events")
+	}
+
+	page, err := h.eventService.Index(ctx, h.userIDFomContext(c), request.ToIndexParams())
+	if err != nil {
+		msg := fmt.Sprintf("cannot get events with params [%+#v]", request)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseOK(c, fmt.Sprintf("fetched %d %s", len(page.Events), h.pluralize("event", len(page.Events))), page)
+}
+
+// Stream the events of a user
+// @Summary      Stream events of a user
+// @Description  Stream the events of a user in real time as server-sent events e.g. to show the delivery status of messages without polling. Every event is sent with its type as the SSE event name and the cloudevent as JSON data, a comment is sent every 15 seconds to keep the connection open.
+// @Security	 ApiKeyAuth
+// @Tags         Events
+// @Produce      text/event-stream
+// @Param        type		query  string  	false	"comma separated list of event types, every event is streamed when it is empty"	default(message.phone.sent,message.phone.delivered,message.send.failed)
+// @Success      200 		{string}	string	"stream of server-sent events"
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401	    {object}	responses.Unauthorized
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Router       /events/stream 	[get]
+func (h *EventsHandler) Stream(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	var request requests.EventStream
+	if err := c.QueryParser(&request); err != nil {
+		msg := fmt.Sprintf("cannot marshall URL [%s] into %T", c.OriginalURL(), request)
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return h.responseBadRequest(c, err)
+	}
+
+	if errors := h.validator.ValidateStream(ctx, request.Sanitize()); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while streaming events [%+#v]", spew.Sdump(errors), request)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while streaming events")
+	}
+
+	userID := h.userIDFomContext(c)
+	subscription := h.hub.Subscribe(userID, request.ToEventTypes()...)
+	ctxLogger.Info(fmt.Sprintf("user [%s] subscribed to event stream with types [%s]", userID, request.Type))
+
+	c.Set(fiber.HeaderContentType, "text/event-stream")
+	c.Set(fiber.HeaderCacheControl, "no-cache")
+	c.Set(fiber.HeaderConnection, "keep-alive")
+	c.Set("X-Accel-Buffering", "no")
+
+	c.Context().SetBodyStreamWriter(fasthttp.StreamWriter(func(writer *bufio.Writer) {
+		defer h.hub.Unsubscribe(subscription)
+
+		ticker := time.NewTicker(eventStreamKeepAlive)
+		defer ticker.Stop()
+
+		_, err := writer.WriteString(": connected\n\n")
+		for err == nil {
+			if err = writer.Flush(); err != nil {
+				break
+			}
+
+			select {
+			case event, ok := <-subscription.Events():
+				if !ok {
+					return
+				}
+				err = h.writeStreamEvent(writer, event)
+			case <-ticker.C:
+				_, err = writer.WriteString(": keep-alive\n\n")
+			}
+		}
+
+		ctxLogger.Info(fmt.Sprintf("closed event stream of user [%s] with error [%v]", userID, err))
+	}))
+
+	return nil
+}
+
+// writeStreamEvent writes a cloudevent in the server-sent events format
+func (h *EventsHandler) writeStreamEvent(writer *bufio.Writer, event cloudevents.Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return stacktrace.Propagate(err, fmt.Sprintf("cannot marshal event [%s] into JSON", event.ID()))
+	}
+
+	if _, err = fmt.Fprintf(writer, "id: %s\nevent: %s\ndata: %s\n\n", event.ID(), event.Type(), data); err != nil {
+		return stacktrace.Propagate(err, fmt.Sprintf("cannot write event [%s] to stream", event.ID()))
+	}
+	return nil
+}
+
+// Replay a stored event
+// @Summary      Replay an event
+// @Description  Publish a stored event to the listeners again e.g. to resend it to a webhook after fixing the webhook. Listeners which already handled the event successfully ignore it.
+// @Security	 ApiKeyAuth
+// @Tags         Events
+// @Produce      json
+// @Param 		 eventID 	path		string 	true 	"ID of the event"	default(32343a19-da5e-4b1b-a767-3298a73703cb)
+// @Success      200 		{object}	responses.EventResponse
+// @Failure 	 401	    {object}	responses.Unauthorized
+// @Failure 	 404	    {object}	responses.NotFound
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /events/{eventID}/replay [post]
+func (h *EventsHandler) Replay(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	eventID := c.Params("eventID")
+	if errors := h.validator.ValidateUUID(ctx, eventID, "eventID"); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while replaying event [%s]", spew.Sdump(errors), eventID)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while replaying event")
+	}
+
+	event, err := h.eventService.Replay(ctx, h.userIDFomContext(c), uuid.MustParse(eventID))
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		return h.responseNotFound(c, fmt.Sprintf("cannot find event with ID [%s]", eventID))
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot replay event with ID [%s]", eventID)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseOK(c, "event replayed successfully", event)
 }
 
 // Dispatch a cloud event
@@ -0,0 +1,220 @@
+package handlers
+
+import (
+	"fmt"
+
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
+	"github.com/NdoleStudio/httpsms/pkg/requests"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/NdoleStudio/httpsms/pkg/validators"
+	"github.com/davecgh/go-spew/spew"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/palantir/stacktrace"
+)
+
+// ForwardingRuleHandler handles requests for the rules which forward received messages by email
+type ForwardingRuleHandler struct {
+	handler
+	logger    telemetry.Logger
+	tracer    telemetry.Tracer
+	service   *services.ForwardingRuleService
+	validator *validators.ForwardingRuleHandlerValidator
+}
+
+// NewForwardingRuleHandler creates a new ForwardingRuleHandler
+func NewForwardingRuleHandler(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	service *services.ForwardingRuleService,
+	validator *validators.ForwardingRuleHandlerValidator,
+) (h *ForwardingRuleHandler) {
+	return &ForwardingRuleHandler{
+		logger:    logger.WithService(fmt.Sprintf("%T", h)),
+		tracer:    tracer,
+		service:   service,
+		validator: validator,
+	}
+}
+
+// RegisterRoutes registers the routes for the ForwardingRuleHandler
+func (h *ForwardingRuleHandler) RegisterRoutes(app *fiber.App, middlewares ...fiber.Handler) {
+	router := app.Group("/v1/forwarding-rules")
+	router.Get("/", h.computeRoute(middlewares, h.Index)...)
+	router.Post("/", h.computeRoute(middlewares, h.Store)...)
+	router.Put("/:ruleID", h.computeRoute(middlewares, h.Update)...)
+	router.Delete("/:ruleID", h.computeRoute(middlewares, h.Delete)...)
+}
+
+// Index returns the forwarding rules of a user
+// @Summary      Get forwarding rules of a user
+// @Description  Get the rules which forward received messages to email addresses
+// @Security	 ApiKeyAuth
+// @Tags         ForwardingRules
+// @Accept       json
+// @Produce      json
+// @Param        skip		query  int  	false	"number of forwarding rules to skip"		minimum(0)
+// @Param        query		query  string  	false 	"filter forwarding rules containing query"
+// @Param        limit		query  int  	false	"number of forwarding rules to return"	minimum(1)	maximum(100)
+// @Success      200 		{object}	responses.ForwardingRulesResponse
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401	    {object}	responses.Unauthorized
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /forwarding-rules 	[get]
+func (h *ForwardingRuleHandler) Index(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	var request requests.ForwardingRuleIndex
+	if err := c.QueryParser(&request); err != nil {
+		msg := fmt.Sprintf("cannot marshall URL [%s] into %T", c.OriginalURL(), request)
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return h.responseBadRequest(c, err)
+	}
+
+	if errors := h.validator.ValidateIndex(ctx, request.Sanitize()); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while fetching forwarding rules [%+#v]", spew.Sdump(errors), request)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while fetching forwarding rules")
+	}
+
+	rules, err := h.service.Index(ctx, h.userIDFomContext(c), request.ToIndexParams())
+	if err != nil {
+		msg := fmt.Sprintf("cannot get forwarding rules with params [%+#v]", request)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseOK(c, fmt.Sprintf("fetched %d %s", len(rules), h.pluralize("forwarding rule", len(rules))), rules)
+}
+
+// Store a forwarding rule
+// @Summary      Store a forwarding rule
+// @Description  Create a rule which emails the received messages matching the owner, contact and keyword or regex pattern to the email addresses with the sender, SIM and receive timestamp.
+// @Security	 ApiKeyAuth
+// @Tags         ForwardingRules
+// @Accept       json
+// @Produce      json
+// @Param        payload   	body 		requests.ForwardingRuleStore  		true "Payload of the forwarding rule request"
+// @Success      201 		{object}	responses.ForwardingRuleResponse
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401	    {object}	responses.Unauthorized
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /forwarding-rules [post]
+func (h *ForwardingRuleHandler) Store(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	var request requests.ForwardingRuleStore
+	if err := c.BodyParser(&request); err != nil {
+		msg := fmt.Sprintf("cannot marshall body [%s] into [%T]", c.Body(), request)
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return h.responseBadRequest(c, err)
+	}
+
+	if errors := h.validator.ValidateStore(ctx, request.Sanitize()); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while storing forwarding rule [%+#v]", spew.Sdump(errors), request)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while storing forwarding rule")
+	}
+
+	rule, err := h.service.Store(ctx, request.ToStoreParams(h.userFromContext(c)))
+	if err != nil {
+		msg := fmt.Sprintf("cannot store forwarding rule with params [%+#v]", request)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseCreated(c, "forwarding rule created successfully", rule)
+}
+
+// Update a forwarding rule
+// @Summary      Update a forwarding rule
+// @Description  Update the filters and email addresses of a forwarding rule or enable/disable it
+// @Security	 ApiKeyAuth
+// @Tags         ForwardingRules
+// @Accept       json
+// @Produce      json
+// @Param 		 ruleID 	path		string 							true 	"ID of the forwarding rule"	default(32343a19-da5e-4b1b-a767-3298a73703ca)
+// @Param        payload   	body 		requests.ForwardingRuleUpdate  			true "Payload of the forwarding rule update request"
+// @Success      200 		{object}	responses.ForwardingRuleResponse
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401	    {object}	responses.Unauthorized
+// @Failure 	 404    	{object}	responses.NotFound
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /forwarding-rules/{ruleID} [put]
+func (h *ForwardingRuleHandler) Update(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	var request requests.ForwardingRuleUpdate
+	if err := c.BodyParser(&request); err != nil {
+		msg := fmt.Sprintf("cannot marshall body [%s] into [%T]", c.Body(), request)
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return h.responseBadRequest(c, err)
+	}
+
+	request.RuleID = c.Params("ruleID")
+	if errors := h.validator.ValidateUpdate(ctx, request.Sanitize()); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while updating forwarding rule [%+#v]", spew.Sdump(errors), request)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while updating forwarding rule")
+	}
+
+	rule, err := h.service.Update(ctx, request.ToUpdateParams(h.userFromContext(c)))
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		return h.responseNotFound(c, fmt.Sprintf("cannot find forwarding rule with ID [%s]", request.RuleID))
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot update forwarding rule with params [%+#v]", request)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseOK(c, "forwarding rule updated successfully", rule)
+}
+
+// Delete a forwarding rule
+// @Summary      Delete a forwarding rule
+// @Description  Delete a forwarding rule of a user
+// @Security	 ApiKeyAuth
+// @Tags         ForwardingRules
+// @Accept       json
+// @Produce      json
+// @Param 		 ruleID 	path		string 							true 	"ID of the forwarding rule"	default(32343a19-da5e-4b1b-a767-3298a73703ca)
+// @Success      204		{object}    responses.NoContent
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401    	{object}	responses.Unauthorized
+// @Failure 	 404    	{object}	responses.NotFound
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /forwarding-rules/{ruleID} [delete]
+func (h *ForwardingRuleHandler) Delete(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	ruleID := c.Params("ruleID")
+	if errors := h.validator.ValidateUUID(ctx, ruleID, "ruleID"); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while deleting forwarding rule with ID [%s]", spew.Sdump(errors), ruleID)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while deleting forwarding rule")
+	}
+
+	err := h.service.Delete(ctx, h.userIDFomContext(c), uuid.MustParse(ruleID))
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		return h.responseNotFound(c, fmt.Sprintf("cannot find forwarding rule with ID [%s]", ruleID))
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot delete forwarding rule with ID [%s]", ruleID)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseOK(c, "forwarding rule deleted successfully", nil)
+}
@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"fmt"
+
+	"github.com/NdoleStudio/httpsms/pkg/graph"
+	"github.com/NdoleStudio/httpsms/pkg/requests"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/NdoleStudio/httpsms/pkg/validators"
+	"github.com/davecgh/go-spew/spew"
+	"github.com/gofiber/fiber/v2"
+	"github.com/graph-gophers/graphql-go"
+	"github.com/palantir/stacktrace"
+)
+
+// GraphQLHandler handles GraphQL queries
+type GraphQLHandler struct {
+	handler
+	logger    telemetry.Logger
+	tracer    telemetry.Tracer
+	schema    *graphql.Schema
+	validator *validators.GraphQLHandlerValidator
+}
+
+// NewGraphQLHandler creates a new GraphQLHandler
+func NewGraphQLHandler(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	schema *graphql.Schema,
+	validator *validators.GraphQLHandlerValidator,
+) (h *GraphQLHandler) {
+	return &GraphQLHandler{
+		logger:    logger.WithService(fmt.Sprintf("%T", h)),
+		tracer:    tracer,
+		schema:    schema,
+		validator: validator,
+	}
+}
+
+// RegisterRoutes registers the routes for the GraphQLHandler
+func (h *GraphQLHandler) RegisterRoutes(app *fiber.App, middlewares ...fiber.Handler) {
+	app.Post("/graphql", h.computeRoute(middlewares, h.Query)...)
+}
+
+// Query executes a GraphQL query
+// @Summary      Execute a GraphQL query
+// @Description  Query the messages, conversations, contacts and phones of the user with field selection, filters and cursor pagination. Errors in the query are returned in the errors field with a 200 status code.
+// @Security	 ApiKeyAuth
+// @Tags         GraphQL
+// @Accept       json
+// @Produce      json
+// @Param        payload   	body 		requests.GraphQLQuery  	true 	"GraphQL query"
+// @Success      200 		{object}	responses.GraphQLResponse
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401    	{object}	responses.Unauthorized
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /graphql [post]
+func (h *GraphQLHandler) Query(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	var request requests.GraphQLQuery
+	if err := c.BodyParser(&request); err != nil {
+		msg := fmt.Sprintf("cannot marshall body [%s] into [%T]", c.Body(), request)
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return h.responseBadRequest(c, err)
+	}
+
+	if errors := h.validator.ValidateQuery(ctx, request.Sanitize()); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while executing graphql query [%+#v]", spew.Sdump(errors), request)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while executing graphql query")
+	}
+
+	response := h.schema.Exec(graph.WithUserID(ctx, h.userIDFomContext(c)), request.Query, request.OperationName, request.Variables)
+	if len(response.Errors) != 0 {
+		ctxLogger.Info(fmt.Sprintf("graphql query of user [%s] returned [%d] errors", h.userIDFomContext(c), len(response.Errors)))
+	}
+
+	return c.Status(fiber.StatusOK).JSON(response)
+}
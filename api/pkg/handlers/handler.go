@@ -1,7 +1,11 @@
 package handlers
 
 import (
+	"fmt"
+	"math"
 	"net/url"
+	"strconv"
+	"time"
 
 	"github.com/NdoleStudio/httpsms/pkg/entities"
 	"github.com/NdoleStudio/httpsms/pkg/middlewares"
@@ -35,6 +39,20 @@ func (h *handler) responseUnauthorized(c *fiber.Ctx) error {
 	})
 }
 
+func (h *handler) responseInvalidCredentials(c *fiber.Ctx, message string) error {
+	return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+		"status":  "error",
+		"message": message,
+	})
+}
+
+func (h *handler) responseEmailNotVerified(c *fiber.Ctx) error {
+	return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+		"status":  "error",
+		"message": "Your email address is not verified, open the link in the verification email before you log in.",
+	})
+}
+
 func (h *handler) responseForbidden(c *fiber.Ctx) error {
 	return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
 		"status":  "error",
@@ -42,6 +60,27 @@ func (h *handler) responseForbidden(c *fiber.Ctx) error {
 	})
 }
 
+func (h *handler) responseAccountSuspended(c *fiber.Ctx) error {
+	return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+		"status":  "error",
+		"message": "Your account is suspended, update your payment method or contact support to reactivate it.",
+	})
+}
+
+func (h *handler) responsePhoneKillSwitchEnabled(c *fiber.Ctx) error {
+	return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+		"status":  "error",
+		"message": "The kill switch of the phone is enabled, stop sending messages until it is disabled.",
+	})
+}
+
+func (h *handler) responseTwoFactorLocked(c *fiber.Ctx) error {
+	return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
+		"status":  "error",
+		"message": "Too many invalid two factor authentication codes were used, retry the request later.",
+	})
+}
+
 func (h *handler) responseUnprocessableEntity(c *fiber.Ctx, errors url.Values, message string) error {
 	return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
 		"status":  "error",
@@ -50,6 +89,10 @@ func (h *handler) responseUnprocessableEntity(c *fiber.Ctx, errors url.Values, m
 	})
 }
 
+func (h *handler) responseContentEncrypted(c *fiber.Ctx) error {
+	return h.responseUnprocessableEntity(c, url.Values{"query": []string{"The content of messages cannot be searched because it is encrypted"}}, "validation errors while searching the content of messages")
+}
+
 func (h *handler) responseNotFound(c *fiber.Ctx, message string) error {
 	return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
 		"status":  "error",
@@ -64,6 +107,15 @@ func (h *handler) responsePaymentRequired(c *fiber.Ctx, message string) error {
 	})
 }
 
+func (h *handler) responseTooManyRequests(c *fiber.Ctx, retryAfter time.Duration) error {
+	seconds := int(math.Ceil(retryAfter.Seconds()))
+	c.Set(fiber.HeaderRetryAfter, strconv.Itoa(seconds))
+	return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
+		"status":  "error",
+		"message": fmt.Sprintf("You have exceeded the send rate limit, retry the request after %d %s.", seconds, h.pluralize("second", seconds)),
+	})
+}
+
 func (h *handler) responseNoContent(c *fiber.Ctx, message string) error {
 	return c.Status(fiber.StatusNoContent).JSON(fiber.Map{
 		"status":  "success",
@@ -71,6 +123,13 @@ func (h *handler) responseNoContent(c *fiber.Ctx, message string) error {
 	})
 }
 
+func (h *handler) responseAccepted(c *fiber.Ctx, message string) error {
+	return c.Status(fiber.StatusAccepted).JSON(fiber.Map{
+		"status":  "success",
+		"message": message,
+	})
+}
+
 func (h *handler) responseOK(c *fiber.Ctx, message string, data interface{}) error {
 	return c.Status(fiber.StatusOK).JSON(fiber.Map{
 		"status":  "success",
@@ -0,0 +1,52 @@
+package handlers
+
+import (
+	"fmt"
+
+	"github.com/NdoleStudio/httpsms/pkg/services"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/gofiber/fiber/v2"
+)
+
+// InstanceIdentityHandler handles public requests for the identity of the instance
+type InstanceIdentityHandler struct {
+	handler
+	logger  telemetry.Logger
+	tracer  telemetry.Tracer
+	service *services.InstanceIdentityService
+}
+
+// NewInstanceIdentityHandler creates a new InstanceIdentityHandler
+func NewInstanceIdentityHandler(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	service *services.InstanceIdentityService,
+) (h *InstanceIdentityHandler) {
+	return &InstanceIdentityHandler{
+		logger:  logger.WithService(fmt.Sprintf("%T", h)),
+		tracer:  tracer,
+		service: service,
+	}
+}
+
+// RegisterRoutes registers the routes for the InstanceIdentityHandler
+func (h *InstanceIdentityHandler) RegisterRoutes(app *fiber.App, middlewares ...fiber.Handler) {
+	router := app.Group("/v1/instance")
+	router.Get("/identity", h.computeRoute(middlewares, h.Show)...)
+}
+
+// Show returns the identity of the instance
+// @Summary      Get the identity of the instance
+// @Description  Get the public key which verifies the Ed25519 signature of webhooks in the X-Httpsms-Signature-Ed25519 header and the static egress IP addresses and proxy which webhooks and integrations are sent from. The endpoint is public so receivers can fetch it without an API key.
+// @Tags         Status
+// @Accept       json
+// @Produce      json
+// @Success      200 		{object}	responses.InstanceIdentityResponse
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /instance/identity 	[get]
+func (h *InstanceIdentityHandler) Show(c *fiber.Ctx) error {
+	ctx, span := h.tracer.StartFromFiberCtx(c)
+	defer span.End()
+
+	return h.responseOK(c, "fetched instance identity", h.service.Identity(ctx))
+}
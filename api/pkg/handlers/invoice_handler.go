@@ -0,0 +1,219 @@
+package handlers
+
+import (
+	"fmt"
+
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
+	"github.com/NdoleStudio/httpsms/pkg/requests"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/NdoleStudio/httpsms/pkg/validators"
+	"github.com/davecgh/go-spew/spew"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/palantir/stacktrace"
+)
+
+// InvoiceHandler handles invoice requests
+type InvoiceHandler struct {
+	handler
+	logger    telemetry.Logger
+	tracer    telemetry.Tracer
+	service   *services.InvoiceService
+	validator *validators.InvoiceHandlerValidator
+}
+
+// NewInvoiceHandler creates a new InvoiceHandler
+func NewInvoiceHandler(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	service *services.InvoiceService,
+	validator *validators.InvoiceHandlerValidator,
+) (h *InvoiceHandler) {
+	return &InvoiceHandler{
+		logger:    logger.WithService(fmt.Sprintf("%T", h)),
+		tracer:    tracer,
+		service:   service,
+		validator: validator,
+	}
+}
+
+// RegisterRoutes registers the routes for the InvoiceHandler
+func (h *InvoiceHandler) RegisterRoutes(app *fiber.App, middlewares ...fiber.Handler) {
+	router := app.Group("/v1/invoices")
+	router.Get("/", h.computeRoute(middlewares, h.Index)...)
+	router.Post("/", h.computeRoute(middlewares, h.Store)...)
+	router.Get("/:invoiceID", h.computeRoute(middlewares, h.Show)...)
+	router.Get("/:invoiceID/download", h.computeRoute(middlewares, h.Download)...)
+}
+
+// Index returns the invoices of a user
+// @Summary      Get invoices of a user
+// @Description  Get the monthly invoices of a user with the latest month first
+// @Security	 ApiKeyAuth
+// @Tags         Invoices
+// @Accept       json
+// @Produce      json
+// @Param        skip		query  int  	false	"number of invoices to skip"		minimum(0)
+// @Param        limit		query  int  	false	"number of invoices to return"	minimum(1)	maximum(100)
+// @Success      200 		{object}	responses.InvoicesResponse
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401	    {object}	responses.Unauthorized
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /invoices 	[get]
+func (h *InvoiceHandler) Index(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	var request requests.InvoiceIndex
+	if err := c.QueryParser(&request); err != nil {
+		msg := fmt.Sprintf("cannot marshall URL [%s] into %T", c.OriginalURL(), request)
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return h.responseBadRequest(c, err)
+	}
+
+	if errors := h.validator.ValidateIndex(ctx, request.Sanitize()); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while fetching invoices [%+#v]", spew.Sdump(errors), request)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while fetching invoices")
+	}
+
+	invoices, err := h.service.Index(ctx, h.userIDFomContext(c), request.ToIndexParams())
+	if err != nil {
+		msg := fmt.Sprintf("cannot get invoices with params [%+#v]", request)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseOK(c, fmt.Sprintf("fetched %d %s", len(invoices), h.pluralize("invoice", len(invoices))), invoices)
+}
+
+// Show returns an invoice
+// @Summary      Get an invoice
+// @Description  Get the usage and credit totals of an invoice
+// @Security	 ApiKeyAuth
+// @Tags         Invoices
+// @Accept       json
+// @Produce      json
+// @Param 		 invoiceID 	path		string 	true 	"ID of the invoice"	default(32343a19-da5e-4b1b-a767-3298a73703ca)
+// @Success      200 		{object}	responses.InvoiceResponse
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401	    {object}	responses.Unauthorized
+// @Failure 	 404    	{object}	responses.NotFound
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /invoices/{invoiceID} 	[get]
+func (h *InvoiceHandler) Show(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	invoiceID := c.Params("invoiceID")
+	if errors := h.validator.ValidateUUID(ctx, invoiceID, "invoiceID"); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while fetching invoice with ID [%s]", spew.Sdump(errors), invoiceID)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while fetching invoice")
+	}
+
+	invoice, err := h.service.Load(ctx, h.userIDFomContext(c), uuid.MustParse(invoiceID))
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		return h.responseNotFound(c, fmt.Sprintf("cannot find invoice with ID [%s]", invoiceID))
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot load invoice with ID [%s]", invoiceID)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseOK(c, "invoice fetched successfully", invoice)
+}
+
+// Store generates an invoice
+// @Summary      Generate an invoice
+// @Description  Generate the invoice of a month which has already ended from the usage and credits of the user. An existing invoice for the month is recomputed. Set email to true to also send the invoice to the email address of the user.
+// @Security	 ApiKeyAuth
+// @Tags         Invoices
+// @Accept       json
+// @Produce      json
+// @Param        payload   	body 		requests.InvoiceStore  		true "Payload of the invoice request"
+// @Success      201 		{object}	responses.InvoiceResponse
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401	    {object}	responses.Unauthorized
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /invoices [post]
+func (h *InvoiceHandler) Store(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	var request requests.InvoiceStore
+	if err := c.BodyParser(&request); err != nil {
+		msg := fmt.Sprintf("cannot marshall [%s] into %T", c.Body(), request)
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return h.responseBadRequest(c, err)
+	}
+
+	if errors := h.validator.ValidateStore(ctx, request.Sanitize()); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while generating invoice [%+#v]", spew.Sdump(errors), request)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while generating invoice")
+	}
+
+	invoice, err := h.service.Generate(ctx, request.ToGenerateParams(h.userIDFomContext(c)))
+	if err != nil {
+		msg := fmt.Sprintf("cannot generate invoice with params [%+#v]", request)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseCreated(c, "invoice generated successfully", invoice)
+}
+
+// Download an invoice
+// @Summary      Download an invoice
+// @Description  Download an invoice as a PDF document or as a CSV file containing the line items
+// @Security	 ApiKeyAuth
+// @Tags         Invoices
+// @Produce      application/pdf,text/csv
+// @Param 		 invoiceID 	path		string 	true 	"ID of the invoice"	default(32343a19-da5e-4b1b-a767-3298a73703ca)
+// @Param        format		query  string  	false	"file format of the invoice"	Enums(pdf,csv)
+// @Success      200 		{file}		file
+// @Failure 	 401	    {object}	responses.Unauthorized
+// @Failure 	 404    	{object}	responses.NotFound
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /invoices/{invoiceID}/download [get]
+func (h *InvoiceHandler) Download(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	request := requests.InvoiceDownload{InvoiceID: c.Params("invoiceID"), Format: c.Query("format")}
+	if errors := h.validator.ValidateDownload(ctx, request.Sanitize()); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while downloading invoice [%+#v]", spew.Sdump(errors), request)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while downloading invoice")
+	}
+
+	invoice, err := h.service.Load(ctx, h.userIDFomContext(c), uuid.MustParse(request.InvoiceID))
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		return h.responseNotFound(c, fmt.Sprintf("cannot find invoice with ID [%s]", request.InvoiceID))
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot load invoice with ID [%s]", request.InvoiceID)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	content, err := h.service.Render(ctx, invoice, request.ToFormat())
+	if err != nil {
+		msg := fmt.Sprintf("cannot render invoice with ID [%s] as [%s]", request.InvoiceID, request.Format)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	c.Attachment(fmt.Sprintf("%s.%s", invoice.Number, request.Format))
+	c.Set(fiber.HeaderContentType, request.ToFormat().ContentType())
+	return c.Send(content)
+}
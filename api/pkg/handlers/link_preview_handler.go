@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"fmt"
+
+	"github.com/NdoleStudio/httpsms/pkg/requests"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/NdoleStudio/httpsms/pkg/validators"
+	"github.com/davecgh/go-spew/spew"
+	"github.com/gofiber/fiber/v2"
+	"github.com/palantir/stacktrace"
+)
+
+// LinkPreviewHandler handles link preview requests
+type LinkPreviewHandler struct {
+	handler
+	logger    telemetry.Logger
+	tracer    telemetry.Tracer
+	service   *services.LinkPreviewService
+	validator *validators.LinkPreviewHandlerValidator
+}
+
+// NewLinkPreviewHandler creates a new LinkPreviewHandler
+func NewLinkPreviewHandler(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	service *services.LinkPreviewService,
+	validator *validators.LinkPreviewHandlerValidator,
+) (h *LinkPreviewHandler) {
+	return &LinkPreviewHandler{
+		logger:    logger.WithService(fmt.Sprintf("%T", h)),
+		tracer:    tracer,
+		service:   service,
+		validator: validator,
+	}
+}
+
+// RegisterRoutes registers the routes for the LinkPreviewHandler
+func (h *LinkPreviewHandler) RegisterRoutes(app *fiber.App, middlewares ...fiber.Handler) {
+	router := app.Group("/v1/link-previews")
+	router.Get("/", h.computeRoute(middlewares, h.Show)...)
+}
+
+// Show returns the preview of a link
+// @Summary      Get the preview of a link
+// @Description  Get the Open Graph metadata (title, description and image) of a link so that it can be rendered as a card in the inbox
+// @Security	 ApiKeyAuth
+// @Tags         LinkPreviews
+// @Accept       json
+// @Produce      json
+// @Param        url		query  string  	true 	"the link to preview"
+// @Success      200 		{object}	responses.LinkPreviewResponse
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401	    {object}	responses.Unauthorized
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /link-previews 	[get]
+func (h *LinkPreviewHandler) Show(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	var request requests.LinkPreviewShow
+	if err := c.QueryParser(&request); err != nil {
+		msg := fmt.Sprintf("cannot marshall URL [%s] into %T", c.OriginalURL(), request)
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return h.responseBadRequest(c, err)
+	}
+
+	if errors := h.validator.ValidateShow(ctx, request.Sanitize()); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while fetching link preview [%+#v]", spew.Sdump(errors), request)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while fetching link preview")
+	}
+
+	preview, err := h.service.Preview(ctx, request.URL)
+	if err != nil {
+		msg := fmt.Sprintf("cannot preview link with params [%+#v]", request)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseOK(c, "fetched link preview", preview)
+}
@@ -0,0 +1,130 @@
+package handlers
+
+import (
+	"fmt"
+
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
+	"github.com/NdoleStudio/httpsms/pkg/requests"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/NdoleStudio/httpsms/pkg/validators"
+	"github.com/davecgh/go-spew/spew"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/palantir/stacktrace"
+)
+
+// MessageArchiveHandler handles requests for archived messages
+type MessageArchiveHandler struct {
+	handler
+	logger    telemetry.Logger
+	tracer    telemetry.Tracer
+	service   *services.MessageArchiveService
+	validator *validators.MessageArchiveHandlerValidator
+}
+
+// NewMessageArchiveHandler creates a new MessageArchiveHandler
+func NewMessageArchiveHandler(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	service *services.MessageArchiveService,
+	validator *validators.MessageArchiveHandlerValidator,
+) (h *MessageArchiveHandler) {
+	return &MessageArchiveHandler{
+		logger:    logger.WithService(fmt.Sprintf("%T", h)),
+		tracer:    tracer,
+		service:   service,
+		validator: validator,
+	}
+}
+
+// RegisterRoutes registers the routes for the MessageArchiveHandler
+func (h *MessageArchiveHandler) RegisterRoutes(app *fiber.App, middlewares ...fiber.Handler) {
+	router := app.Group("/v1/archived-messages")
+	router.Get("/", h.computeRoute(middlewares, h.Index)...)
+	router.Get("/:messageID", h.computeRoute(middlewares, h.Show)...)
+}
+
+// Index returns a page of the archived messages of a user
+// @Summary      Get archived messages
+// @Description  Get the messages which were moved to the archive after the retention window from the newest to the oldest. Use the next_cursor of a page as the cursor to fetch the next page.
+// @Security	 ApiKeyAuth
+// @Tags         Messages
+// @Accept       json
+// @Produce      json
+// @Param        owner		query  string  	false	"the owner's phone number" 			default(+18005550199)
+// @Param        contact	query  string  	false	"the contact's phone number" 		default(+18005550100)
+// @Param        cursor		query  string  	false	"next_cursor of the previous page"
+// @Param        limit		query  int  	false	"number of messages to return"		minimum(1)	maximum(100)
+// @Success      200 		{object}	responses.ArchivedMessagePageResponse
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401    	{object}	responses.Unauthorized
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /archived-messages [get]
+func (h *MessageArchiveHandler) Index(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	var request requests.ArchivedMessageIndex
+	if err := c.QueryParser(&request); err != nil {
+		msg := fmt.Sprintf("cannot marshall params [%s] into %T", c.OriginalURL(), request)
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return h.responseBadRequest(c, err)
+	}
+
+	if errors := h.validator.ValidateIndex(ctx, request.Sanitize()); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while fetching archived messages [%+#v]", spew.Sdump(errors), request)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while fetching archived messages")
+	}
+
+	page, err := h.service.Index(ctx, h.userIDFomContext(c), request.ToIndexParams())
+	if err != nil {
+		msg := fmt.Sprintf("cannot get archived messages with params [%+#v]", request)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseOK(c, fmt.Sprintf("fetched %d archived %s", len(page.Messages), h.pluralize("message", len(page.Messages))), page)
+}
+
+// Show returns an archived message
+// @Summary      Get an archived message
+// @Description  Get a message which was moved to the archive after the retention window
+// @Security	 ApiKeyAuth
+// @Tags         Messages
+// @Accept       json
+// @Produce      json
+// @Param 		 messageID 	path		string 	true 	"ID of the message"	default(32343a19-da5e-4b1b-a767-3298a73703ca)
+// @Success      200 		{object}	responses.ArchivedMessageResponse
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401	    {object}	responses.Unauthorized
+// @Failure 	 404    	{object}	responses.NotFound
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /archived-messages/{messageID} 	[get]
+func (h *MessageArchiveHandler) Show(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	messageID := c.Params("messageID")
+	if errors := h.validator.ValidateUUID(ctx, messageID, "messageID"); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while fetching archived message with ID [%s]", spew.Sdump(errors), messageID)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while fetching archived message")
+	}
+
+	message, err := h.service.Load(ctx, h.userIDFomContext(c), uuid.MustParse(messageID))
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		return h.responseNotFound(c, fmt.Sprintf("cannot find archived message with ID [%s]", messageID))
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot load archived message with ID [%s]", messageID)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseOK(c, "archived message fetched successfully", message)
+}
@@ -2,10 +2,9 @@ package handlers
 
 import (
 	"fmt"
+	"net/url"
 	"time"
 
-	"github.com/NdoleStudio/httpsms/pkg/entities"
-
 	"github.com/NdoleStudio/httpsms/pkg/repositories"
 	"github.com/google/uuid"
 
@@ -21,11 +20,16 @@ import (
 // MessageHandler handles message http requests.
 type MessageHandler struct {
 	handler
-	logger         telemetry.Logger
-	tracer         telemetry.Tracer
-	billingService *services.BillingService
-	validator      *validators.MessageHandlerValidator
-	service        *services.MessageService
+	logger              telemetry.Logger
+	tracer              telemetry.Tracer
+	billingService      *services.BillingService
+	rateLimiter         *services.RateLimitService
+	validator           *validators.MessageHandlerValidator
+	service             *services.MessageService
+	eventService        *services.EventService
+	contactGroupService *services.ContactGroupService
+	searchService       *services.MessageSearchService
+	tagService          *services.TagService
 }
 
 // NewMessageHandler creates a new MessageHandler
@@ -34,14 +38,24 @@ func NewMessageHandler(
 	tracer telemetry.Tracer,
 	validator *validators.MessageHandlerValidator,
 	billingService *services.BillingService,
+	rateLimiter *services.RateLimitService,
 	service *services.MessageService,
+	eventService *services.EventService,
+	contactGroupService *services.ContactGroupService,
+	searchService *services.MessageSearchService,
+	tagService *services.TagService,
 ) (h *MessageHandler) {
 	return &MessageHandler{
-		logger:         logger.WithService(fmt.Sprintf("%T", h)),
-		tracer:         tracer,
-		validator:      validator,
-		billingService: billingService,
-		service:        service,
+		logger:              logger.WithService(fmt.Sprintf("%T", h)),
+		tracer:              tracer,
+		validator:           validator,
+		billingService:      billingService,
+		rateLimiter:         rateLimiter,
+		service:             service,
+		eventService:        eventService,
+		contactGroupService: contactGroupService,
+		searchService:       searchService,
+		tagService:          tagService,
 	}
 }
 
@@ -49,15 +63,24 @@ func NewMessageHandler(
 func (h *MessageHandler) RegisterRoutes(router fiber.Router) {
 	router.Post("/messages/send", h.PostSend)
 	router.Post("/messages/bulk-send", h.BulkSend)
+	router.Get("/messages/batches/:batchID", h.GetBatch)
 	router.Post("/messages/receive", h.PostReceive)
 	router.Get("/messages/outstanding", h.GetOutstanding)
 	router.Get("/messages", h.Index)
+	router.Get("/messages/page", h.IndexPage)
+	router.Get("/messages/search", h.Search)
+	router.Post("/messages/events/batch", h.PostEventBatch)
+	router.Post("/messages/reconcile", h.PostReconcile)
 	router.Post("/messages/:messageID/events", h.PostEvent)
+	router.Get("/messages/:messageID/events", h.GetEvents)
+	router.Put("/messages/:messageID/read", h.PutRead)
+	router.Put("/messages/:messageID/tags", h.PutTags)
+	router.Get("/messages/:messageID", h.Show)
 }
 
 // PostSend a new entities.Message
 // @Summary      Send a new SMS message
-// @Description  Add a new SMS message to be sent by the android phone
+// @Description  Add a new SMS message to be sent by the android phone. The message is sent as an MMS when it has attachments. Set the id to a UUID generated by the client to retry the request safely, the message which was already sent is returned when the same id is sent again.
 // @Security	 ApiKeyAuth
 // @Tags         Messages
 // @Accept       json
@@ -67,6 +90,7 @@ func (h *MessageHandler) RegisterRoutes(router fiber.Router) {
 // @Failure      400  {object}  responses.BadRequest
 // @Failure 	 401  {object}	responses.Unauthorized
 // @Failure      422  {object}  responses.UnprocessableEntity
+// @Failure      429  {object}  responses.TooManyRequests
 // @Failure      500  {object}  responses.InternalServerError
 // @Router       /messages/send [post]
 func (h *MessageHandler) PostSend(c *fiber.Ctx) error {
@@ -88,12 +112,49 @@ func (h *MessageHandler) PostSend(c *fiber.Ctx) error {
 		return h.responseUnprocessableEntity(c, errors, "validation errors while sending message")
 	}
 
-	if msg := h.billingService.IsEntitled(ctx, h.userIDFomContext(c)); msg != nil {
+	if msg := h.billingService.IsEntitledToSend(ctx, h.userIDFomContext(c)); msg != nil {
 		ctxLogger.Warn(stacktrace.NewError(fmt.Sprintf("user with ID [%s] can't send a message", h.userIDFomContext(c))))
 		return h.responsePaymentRequired(c, *msg)
 	}
 
+	limit, err := h.rateLimiter.TakeSend(ctx, h.userIDFomContext(c), request.To)
+	if err != nil {
+		msg := fmt.Sprintf("cannot check send rate limit of user [%s]", h.userIDFomContext(c))
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	if !limit.Allowed {
+		ctxLogger.Warn(stacktrace.NewError(fmt.Sprintf("user with ID [%s] exceeded the send rate limit to [%s]", h.userIDFomContext(c), request.To)))
+		return h.responseTooManyRequests(c, limit.RetryAfter)
+	}
+
 	message, err := h.service.SendMessage(ctx, request.ToMessageSendParams(h.userIDFomContext(c), c.OriginalURL()))
+	if stacktrace.GetCode(err) == services.ErrCodeUserSuspended {
+		ctxLogger.Warn(stacktrace.Propagate(err, fmt.Sprintf("suspended user [%s] cannot send a message", h.userIDFomContext(c))))
+		return h.responseAccountSuspended(c)
+	}
+
+	if stacktrace.GetCode(err) == services.ErrCodeAttachmentQuarantined {
+		ctxLogger.Warn(stacktrace.Propagate(err, fmt.Sprintf("cannot send message with quarantined attachment for user [%s]", h.userIDFomContext(c))))
+		return h.responseUnprocessableEntity(c, url.Values{"attachments": []string{"an attachment contains malware and cannot be sent"}}, "validation errors while sending message")
+	}
+
+	if stacktrace.GetCode(err) == services.ErrCodeMessagePredecessorInvalid {
+		ctxLogger.Warn(stacktrace.Propagate(err, fmt.Sprintf("cannot send message after invalid predecessor for user [%s]", h.userIDFomContext(c))))
+		return h.responseUnprocessableEntity(c, url.Values{"after_message_id": []string{"no outgoing message found with the 'after_message_id'"}}, "validation errors while sending message")
+	}
+
+	if stacktrace.GetCode(err) == services.ErrCodeInsufficientCredits {
+		ctxLogger.Warn(stacktrace.Propagate(err, fmt.Sprintf("user [%s] does not have enough credits to send a message", h.userIDFomContext(c))))
+		return h.responsePaymentRequired(c, "You do not have enough credits to send this message. Top up your credits to send more messages.")
+	}
+
+	if stacktrace.GetCode(err) == services.ErrCodeMessageIDExists {
+		ctxLogger.Warn(stacktrace.Propagate(err, fmt.Sprintf("cannot send message with an ID which is used by a different message for user [%s]", h.userIDFomContext(c))))
+		return h.responseUnprocessableEntity(c, url.Values{"id": []string{fmt.Sprintf("the id [%s] is already used by a different message", *request.ID)}}, "validation errors while sending message")
+	}
+
 	if err != nil {
 		msg := fmt.Sprintf("cannot send message with paylod [%s]", c.Body())
 		ctxLogger.Error(stacktrace.Propagate(err, msg))
@@ -105,13 +166,13 @@ func (h *MessageHandler) PostSend(c *fiber.Ctx) error {
 
 // BulkSend a bulk entities.Message
 // @Summary      Send bulk SMS messages
-// @Description  Add bulk SMS messages to be sent by the android phone
+// @Description  Add up to 1,000 SMS messages with the same content to be sent by the android phone. The recipients are the phone numbers in `to` and the members of the contact group `group_id` who have not opted out. The messages share a batch ID which is used to fetch the status of the whole batch.
 // @Security	 ApiKeyAuth
 // @Tags         Messages
 // @Accept       json
 // @Produce      json
 // @Param        payload   body requests.MessageBulkSend  true  "Bulk send message request payload"
-// @Success      200  {object}  []responses.MessagesResponse
+// @Success      200  {object}  responses.MessageBatchResponse
 // @Failure      400  {object}  responses.BadRequest
 // @Failure 	 401  {object}	responses.Unauthorized
 // @Failure      422  {object}  responses.UnprocessableEntity
@@ -130,30 +191,103 @@ func (h *MessageHandler) BulkSend(c *fiber.Ctx) error {
 		return h.responseBadRequest(c, err)
 	}
 
+	if request.Sanitize().GroupID != "" {
+		if errors := h.validator.ValidateUUID(ctx, request.GroupID, "group_id"); len(errors) != 0 {
+			msg := fmt.Sprintf("validation errors [%s], while sending payload [%s]", spew.Sdump(errors), c.Body())
+			ctxLogger.Warn(stacktrace.NewError(msg))
+			return h.responseUnprocessableEntity(c, errors, "validation errors while sending messages")
+		}
+
+		phoneNumbers, err := h.contactGroupService.PhoneNumbers(ctx, h.userIDFomContext(c), uuid.MustParse(request.GroupID))
+		if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+			return h.responseUnprocessableEntity(c, url.Values{"group_id": []string{fmt.Sprintf("cannot find contact group with ID [%s]", request.GroupID)}}, "validation errors while sending messages")
+		}
+
+		if err != nil {
+			msg := fmt.Sprintf("cannot fetch the phone numbers in contact group [%s]", request.GroupID)
+			ctxLogger.Error(stacktrace.Propagate(err, msg))
+			return h.responseInternalServerError(c)
+		}
+
+		if len(phoneNumbers) == 0 && len(request.To) == 0 {
+			return h.responseUnprocessableEntity(c, url.Values{"group_id": []string{fmt.Sprintf("the contact group with ID [%s] has no contacts who can receive messages", request.GroupID)}}, "validation errors while sending messages")
+		}
+		request.AddRecipients(phoneNumbers)
+	}
+
 	if errors := h.validator.ValidateMessageBulkSend(ctx, h.userIDFomContext(c), request.Sanitize()); len(errors) != 0 {
 		msg := fmt.Sprintf("validation errors [%s], while sending payload [%s]", spew.Sdump(errors), c.Body())
 		ctxLogger.Warn(stacktrace.NewError(msg))
 		return h.responseUnprocessableEntity(c, errors, "validation errors while sending messages")
 	}
 
-	var responses []*entities.Message
-	params := request.ToMessageSendParams(h.userIDFomContext(c), c.OriginalURL())
-	for _, param := range params {
-		if msg := h.billingService.IsEntitled(ctx, h.userIDFomContext(c)); msg != nil {
-			ctxLogger.Warn(stacktrace.NewError(fmt.Sprintf("user with ID [%s] can't send a message", h.userIDFomContext(c))))
-			break
-		}
+	if msg := h.billingService.IsEntitledToSend(ctx, h.userIDFomContext(c)); msg != nil {
+		ctxLogger.Warn(stacktrace.NewError(fmt.Sprintf("user with ID [%s] can't send a message", h.userIDFomContext(c))))
+		return h.responsePaymentRequired(c, *msg)
+	}
 
-		message, err := h.service.SendMessage(ctx, param)
-		if err != nil {
-			msg := fmt.Sprintf("cannot send message with paylod [%s]", c.Body())
-			ctxLogger.Error(stacktrace.Propagate(err, msg))
-			break
-		}
-		responses = append(responses, message)
+	batch, err := h.service.BulkSendMessage(ctx, request.ToMessageSendParams(h.userIDFomContext(c), c.OriginalURL()))
+	if stacktrace.GetCode(err) == services.ErrCodeUserSuspended && len(batch.Messages) == 0 {
+		ctxLogger.Warn(stacktrace.Propagate(err, fmt.Sprintf("suspended user [%s] cannot send messages", h.userIDFomContext(c))))
+		return h.responseAccountSuspended(c)
+	}
+
+	if stacktrace.GetCode(err) == services.ErrCodeInsufficientCredits && len(batch.Messages) == 0 {
+		ctxLogger.Warn(stacktrace.Propagate(err, fmt.Sprintf("user [%s] does not have enough credits to send messages", h.userIDFomContext(c))))
+		return h.responsePaymentRequired(c, "You do not have enough credits to send these messages. Top up your credits to send more messages.")
 	}
 
-	return h.responseOK(c, "messages added to queue", responses)
+	if err != nil && len(batch.Messages) == 0 {
+		msg := fmt.Sprintf("cannot send messages with paylod [%s]", c.Body())
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	if err != nil {
+		ctxLogger.Error(stacktrace.Propagate(err, fmt.Sprintf("sent [%d] out of [%d] messages in batch [%s]", len(batch.Messages), len(request.To), batch.BatchID)))
+	}
+
+	return h.responseOK(c, fmt.Sprintf("%d %s added to queue", len(batch.Messages), h.pluralize("message", len(batch.Messages))), batch)
+}
+
+// GetBatch returns the status of the messages sent in a bulk send request
+// @Summary      Get the status of a batch of messages
+// @Description  Get the number of messages in each status and the status of the message sent to each recipient of a bulk send request
+// @Security	 ApiKeyAuth
+// @Tags         Messages
+// @Accept       json
+// @Produce      json
+// @Param 		 batchID 	path		string 	true 	"ID of the batch"	default(32343a19-da5e-4b1b-a767-3298a73703cc)
+// @Success      200  {object}  responses.MessageBatchStatusResponse
+// @Failure      400  {object}  responses.BadRequest
+// @Failure 	 401  {object}	responses.Unauthorized
+// @Failure 	 404  {object}	responses.NotFound
+// @Failure      422  {object}  responses.UnprocessableEntity
+// @Failure      500  {object}  responses.InternalServerError
+// @Router       /messages/batches/{batchID} [get]
+func (h *MessageHandler) GetBatch(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	batchID := c.Params("batchID")
+	if errors := h.validator.ValidateUUID(ctx, batchID, "batchID"); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while fetching batch with ID [%s]", spew.Sdump(errors), batchID)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while fetching batch")
+	}
+
+	status, err := h.service.GetBatchStatus(ctx, h.userIDFomContext(c), uuid.MustParse(batchID))
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		return h.responseNotFound(c, fmt.Sprintf("cannot find batch with ID [%s]", batchID))
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot fetch status of batch with ID [%s]", batchID)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseOK(c, fmt.Sprintf("fetched status of %d %s", status.Total, h.pluralize("message", status.Total)), status)
 }
 
 // GetOutstanding returns an entities.Message which is still to be sent by the mobile phone
@@ -167,6 +301,7 @@ func (h *MessageHandler) BulkSend(c *fiber.Ctx) error {
 // @Success      200 		{object}	responses.MessageResponse
 // @Failure      400		{object}	responses.BadRequest
 // @Failure 	 401    	{object}	responses.Unauthorized
+// @Failure 	 403    	{object}	responses.Unauthorized
 // @Failure      422		{object}	responses.UnprocessableEntity
 // @Failure      500		{object}	responses.InternalServerError
 // @Router       /messages/outstanding [get]
@@ -197,6 +332,11 @@ func (h *MessageHandler) GetOutstanding(c *fiber.Ctx) error {
 		return h.responseNotFound(c, "outstanding message already processed")
 	}
 
+	if stacktrace.GetCode(err) == services.ErrCodePhoneKillSwitchEnabled {
+		ctxLogger.Warn(stacktrace.Propagate(err, fmt.Sprintf("outstanding message with id [%s] is held by the kill switch", request.MessageID)))
+		return h.responsePhoneKillSwitchEnabled(c)
+	}
+
 	if err != nil {
 		msg := fmt.Sprintf("cannot get outstanding messgage with ID [%s]", request.MessageID)
 		ctxLogger.Error(stacktrace.Propagate(err, msg))
@@ -208,7 +348,7 @@ func (h *MessageHandler) GetOutstanding(c *fiber.Ctx) error {
 
 // Index returns messages sent between 2 phone numbers
 // @Summary      Get messages which are sent between 2 phone numbers
-// @Description  Get list of messages which are sent between 2 phone numbers. It will be sorted by timestamp in descending order.
+// @Description  Get list of messages which are sent between 2 phone numbers. It will be sorted by timestamp in descending order. Use /messages/page to paginate large lists with a cursor and filters.
 // @Security	 ApiKeyAuth
 // @Tags         Messages
 // @Accept       json
@@ -244,6 +384,10 @@ func (h *MessageHandler) Index(c *fiber.Ctx) error {
 	}
 
 	messages, err := h.service.GetMessages(ctx, request.ToGetParams(h.userIDFomContext(c)))
+	if stacktrace.GetCode(err) == repositories.ErrCodeContentEncrypted {
+		return h.responseContentEncrypted(c)
+	}
+
 	if err != nil {
 		msg := fmt.Sprintf("cannot get messgaes with params [%+#v]", request)
 		ctxLogger.Error(stacktrace.Propagate(err, msg))
@@ -253,6 +397,121 @@ func (h *MessageHandler) Index(c *fiber.Ctx) error {
 	return h.responseOK(c, fmt.Sprintf("fetched %d %s", len(*messages), h.pluralize("message", len(*messages))), messages)
 }
 
+// IndexPage returns a page of the messages of a user
+// @Summary      Get a page of messages with filters
+// @Description  Get the messages of a user from the newest to the oldest filtered by phone numbers, status, type, SIM, date and content. Use the next_cursor of a page as the cursor to fetch the next page, pages have no total so that they are fast on large accounts.
+// @Security	 ApiKeyAuth
+// @Tags         Messages
+// @Accept       json
+// @Produce      json
+// @Param        owner		query  string  	false	"the owner's phone number" 			default(+18005550199)
+// @Param        contact	query  string  	false	"the contact's phone number" 		default(+18005550100)
+// @Param        status		query  string  	false	"comma separated list of message statuses"	default(sent,delivered)
+// @Param        type		query  string  	false	"type of the messages"	Enums(mobile-terminated,mobile-originated)
+// @Param        sim		query  string  	false	"SIM card of the messages"	Enums(SIM1,SIM2,DEFAULT)
+// @Param        since		query  string  	false	"RFC3339 timestamp of the oldest message"	default(2022-06-05T14:26:09+03:00)
+// @Param        until		query  string  	false	"RFC3339 timestamp of the newest message"	default(2022-06-06T14:26:09+03:00)
+// @Param        query		query  string  	false 	"filter messages containing query"
+// @Param        tag		query  string  	false 	"filter messages with the tag or one of its descendants"
+// @Param        cursor		query  string  	false	"next_cursor of the previous page"
+// @Param        limit		query  int  	false	"number of messages to return"		minimum(1)	maximum(100)
+// @Success      200 		{object}	responses.MessagePageResponse
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401    	{object}	responses.Unauthorized
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /messages/page [get]
+func (h *MessageHandler) IndexPage(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	var request requests.MessagePageIndex
+	if err := c.QueryParser(&request); err != nil {
+		msg := fmt.Sprintf("cannot marshall params [%s] into %T", c.OriginalURL(), request)
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return h.responseBadRequest(c, err)
+	}
+
+	if errors := h.validator.ValidateMessagePageIndex(ctx, request.Sanitize()); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while fetching messages [%+#v]", spew.Sdump(errors), request)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while fetching messages")
+	}
+
+	params := request.ToIndexParams()
+	if request.Tag != "" {
+		tags, err := h.tagService.Expand(ctx, h.userIDFomContext(c), request.Tag)
+		if err != nil {
+			msg := fmt.Sprintf("cannot expand tag [%s] for user [%s]", request.Tag, h.userIDFomContext(c))
+			ctxLogger.Error(stacktrace.Propagate(err, msg))
+			return h.responseInternalServerError(c)
+		}
+		params.Tags = tags
+	}
+
+	page, err := h.service.IndexMessages(ctx, h.userIDFomContext(c), params)
+	if stacktrace.GetCode(err) == repositories.ErrCodeContentEncrypted {
+		return h.responseContentEncrypted(c)
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot get messages with params [%+#v]", request)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseOK(c, fmt.Sprintf("fetched %d %s", len(page.Messages), h.pluralize("message", len(page.Messages))), page)
+}
+
+// Search returns the messages of a user matching a search query
+// @Summary      Search the content of messages
+// @Description  Search the content of the messages of a user, the best match first. Words in double quotes are matched as a phrase e.g. "order shipped" and words ending with * are matched as a prefix e.g. refund*. The matching words in the snippet are wrapped in <mark></mark> tags.
+// @Security	 ApiKeyAuth
+// @Tags         Messages
+// @Accept       json
+// @Produce      json
+// @Param        q			query  string  	true	"search query"						default("order shipped" refund*)
+// @Param        owner		query  string  	false	"the owner's phone number" 			default(+18005550199)
+// @Param        contact	query  string  	false	"the contact's phone number" 		default(+18005550100)
+// @Param        skip		query  int  	false	"number of results to skip"			minimum(0)
+// @Param        limit		query  int  	false	"number of results to return"		minimum(1)	maximum(100)
+// @Success      200 		{object}	responses.MessageSearchResponse
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401    	{object}	responses.Unauthorized
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /messages/search [get]
+func (h *MessageHandler) Search(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	var request requests.MessageSearch
+	if err := c.QueryParser(&request); err != nil {
+		msg := fmt.Sprintf("cannot marshall params [%s] into %T", c.OriginalURL(), request)
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return h.responseBadRequest(c, err)
+	}
+
+	if errors := h.validator.ValidateMessageSearch(ctx, request.Sanitize()); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while searching messages [%+#v]", spew.Sdump(errors), request)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while searching messages")
+	}
+
+	results, err := h.searchService.Search(ctx, request.ToSearchParams(h.userIDFomContext(c)))
+	if stacktrace.GetCode(err) == repositories.ErrCodeContentEncrypted {
+		return h.responseContentEncrypted(c)
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot search messages with params [%+#v]", request)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseOK(c, fmt.Sprintf("found %d %s", len(results), h.pluralize("message", len(results))), results)
+}
+
 // PostEvent registers an event on a message
 // @Summary      Upsert an event for a message on the mobile phone
 // @Description  Use this endpoint to send events for a message when it is failed, sent or delivered by the mobile phone.
@@ -310,6 +569,269 @@ func (h *MessageHandler) PostEvent(c *fiber.Ctx) error {
 	return h.responseOK(c, "message event stored successfully", message)
 }
 
+// PostEventBatch registers many events on messages
+// @Summary      Store a batch of message events from the mobile phone
+// @Description  Use this endpoint to send the events of many messages in one request e.g. after the phone regains connectivity. The whole batch is rejected when an event is invalid, otherwise each event is stored in the order it was emitted and its result is returned at the same index.
+// @Security	 ApiKeyAuth
+// @Tags         Messages
+// @Accept       json
+// @Produce      json
+// @Param        payload   	body 		requests.MessageEventBatch  		true 	"Payload of the events emitted."
+// @Success      200  		{object} 	responses.MessageEventBatchResponse
+// @Failure      400  		{object}  	responses.BadRequest
+// @Failure 	 401    	{object}	responses.Unauthorized
+// @Failure      422  		{object} 	responses.UnprocessableEntity
+// @Failure      500  		{object}  	responses.InternalServerError
+// @Router       /messages/events/batch [post]
+func (h *MessageHandler) PostEventBatch(c *fiber.Ctx) error {
+	ctx, span := h.tracer.StartFromFiberCtx(c)
+	defer span.End()
+
+	ctxLogger := h.tracer.CtxLogger(h.logger, span)
+
+	var request requests.MessageEventBatch
+	if err := c.BodyParser(&request); err != nil {
+		msg := fmt.Sprintf("cannot marshall [%s] into %T", c.Body(), request)
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return h.responseBadRequest(c, err)
+	}
+
+	if errors := h.validator.ValidateMessageEventBatch(ctx, request); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while storing event batch [%s]", spew.Sdump(errors), c.Body())
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while storing event batch")
+	}
+
+	results := h.service.StoreEventBatch(ctx, h.userIDFomContext(c), request.ToMessageStoreEventParams(c.OriginalURL()))
+	return h.responseOK(c, fmt.Sprintf("processed %d message %s", len(results), h.pluralize("event", len(results))), results)
+}
+
+// PostReconcile reconciles the outbox of a phone with the server
+// @Summary      Reconcile the outbox of a mobile phone
+// @Description  The phone calls this endpoint after it was offline with the messages in its outbox. The server stores the states which it did not receive and returns an action for each message so the phone does not send a message which was already handled.
+// @Security	 ApiKeyAuth
+// @Tags         Messages
+// @Accept       json
+// @Produce      json
+// @Param        payload   	body 		requests.MessageReconcile  		true 	"Messages in the outbox of the phone"
+// @Success      200  		{object} 	responses.MessageReconcileResponse
+// @Failure      400  		{object}  	responses.BadRequest
+// @Failure 	 401    	{object}	responses.Unauthorized
+// @Failure      422  		{object} 	responses.UnprocessableEntity
+// @Failure      500  		{object}  	responses.InternalServerError
+// @Router       /messages/reconcile [post]
+func (h *MessageHandler) PostReconcile(c *fiber.Ctx) error {
+	ctx, span := h.tracer.StartFromFiberCtx(c)
+	defer span.End()
+
+	ctxLogger := h.tracer.CtxLogger(h.logger, span)
+
+	var request requests.MessageReconcile
+	if err := c.BodyParser(&request); err != nil {
+		msg := fmt.Sprintf("cannot marshall [%s] into %T", c.Body(), request)
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return h.responseBadRequest(c, err)
+	}
+
+	if errors := h.validator.ValidateMessageReconcile(ctx, request.Sanitize()); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while reconciling outbox [%s]", spew.Sdump(errors), c.Body())
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while reconciling outbox")
+	}
+
+	results := h.service.Reconcile(ctx, request.ToReconcileParams(h.userIDFomContext(c), c.OriginalURL()))
+	return h.responseOK(c, fmt.Sprintf("reconciled %d %s", len(results), h.pluralize("message", len(results))), results)
+}
+
+// Show returns a message
+// @Summary      Get a message
+// @Description  Get a message by its ID including the ID which was generated by the client when the message was sent
+// @Security	 ApiKeyAuth
+// @Tags         Messages
+// @Accept       json
+// @Produce      json
+// @Param 		 messageID 	path		string 	true 	"ID of the message" 	default(32343a19-da5e-4b1b-a767-3298a73703ca)
+// @Success      200  		{object} 	responses.MessageResponse
+// @Failure      400  		{object}  	responses.BadRequest
+// @Failure 	 401    	{object}	responses.Unauthorized
+// @Failure 	 404		{object}	responses.NotFound
+// @Failure      422  		{object} 	responses.UnprocessableEntity
+// @Failure      500  		{object}  	responses.InternalServerError
+// @Router       /messages/{messageID} [get]
+func (h *MessageHandler) Show(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	messageID := c.Params("messageID")
+	if errors := h.validator.ValidateUUID(ctx, messageID, "messageID"); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while fetching message with ID [%s]", spew.Sdump(errors), messageID)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while fetching message")
+	}
+
+	message, err := h.service.LoadMessage(ctx, h.userIDFomContext(c), uuid.MustParse(messageID))
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		return h.responseNotFound(c, fmt.Sprintf("cannot find message with ID [%s]", messageID))
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot load message with ID [%s]", messageID)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseOK(c, "message fetched successfully", message)
+}
+
+// GetEvents returns the events of a message
+// @Summary      Get the events of a message
+// @Description  Get the events which reference a message from the newest to the oldest. Use the next_cursor of a page as the cursor to fetch the next page.
+// @Security	 ApiKeyAuth
+// @Tags         Messages
+// @Accept       json
+// @Produce      json
+// @Param 		 messageID 	path		string 	true 	"ID of the message" 				default(32343a19-da5e-4b1b-a767-3298a73703ca)
+// @Param        cursor		query  		string  false	"next_cursor of the previous page"
+// @Param        limit		query  		int  	false	"number of events to return"	minimum(1)	maximum(100)
+// @Success      200  		{object} 	responses.EventsResponse
+// @Failure      400  		{object}  	responses.BadRequest
+// @Failure 	 401    	{object}	responses.Unauthorized
+// @Failure 	 404		{object}	responses.NotFound
+// @Failure      422  		{object} 	responses.UnprocessableEntity
+// @Failure      500  		{object}  	responses.InternalServerError
+// @Router       /messages/{messageID}/events [get]
+func (h *MessageHandler) GetEvents(c *fiber.Ctx) error {
+	ctx, span := h.tracer.StartFromFiberCtx(c)
+	defer span.End()
+
+	ctxLogger := h.tracer.CtxLogger(h.logger, span)
+
+	var request requests.MessageEventIndex
+	if err := c.QueryParser(&request); err != nil {
+		msg := fmt.Sprintf("cannot marshall params [%s] into %T", c.OriginalURL(), request)
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return h.responseBadRequest(c, err)
+	}
+
+	request.MessageID = c.Params("messageID")
+	if errors := h.validator.ValidateMessageEventIndex(ctx, request.Sanitize()); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while fetching events [%+#v]", spew.Sdump(errors), request)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while fetching message events")
+	}
+
+	_, err := h.service.GetMessage(ctx, h.userIDFomContext(c), uuid.MustParse(request.MessageID))
+	if err != nil && stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		return h.responseNotFound(c, fmt.Sprintf("cannot find message with ID [%s]", request.MessageID))
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot find message with id [%s]", request.MessageID)
+		ctxLogger.Error(h.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg)))
+		return h.responseInternalServerError(c)
+	}
+
+	page, err := h.eventService.Index(ctx, h.userIDFomContext(c), request.ToIndexParams())
+	if err != nil {
+		msg := fmt.Sprintf("cannot get events for message [%s] with params [%+#v]", request.MessageID, request)
+		ctxLogger.Error(h.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg)))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseOK(c, fmt.Sprintf("fetched %d %s", len(page.Events), h.pluralize("event", len(page.Events))), page)
+}
+
+// PutRead marks a received message as read
+// @Summary      Mark a message as read
+// @Description  Mark a message which was received on a mobile phone as read. A message.read event is emitted so that other clients of the user can update the unread state.
+// @Security	 ApiKeyAuth
+// @Tags         Messages
+// @Produce      json
+// @Param 		 messageID 	path		string 							true 	"ID of the message" 			default(32343a19-da5e-4b1b-a767-3298a73703ca)
+// @Success      200  		{object} 	responses.MessageResponse
+// @Failure 	 401    	{object}	responses.Unauthorized
+// @Failure 	 404		{object}	responses.NotFound
+// @Failure      422  		{object} 	responses.UnprocessableEntity
+// @Failure      500  		{object}  	responses.InternalServerError
+// @Router       /messages/{messageID}/read [put]
+func (h *MessageHandler) PutRead(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	messageID := c.Params("messageID")
+	if errors := h.validator.ValidateUUID(ctx, messageID, "messageID"); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while marking message with ID [%s] as read", spew.Sdump(errors), messageID)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while marking message as read")
+	}
+
+	message, err := h.service.MarkRead(ctx, c.OriginalURL(), h.userIDFomContext(c), uuid.MustParse(messageID))
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		return h.responseNotFound(c, fmt.Sprintf("cannot find message with ID [%s]", messageID))
+	}
+
+	if stacktrace.GetCode(err) == services.ErrCodeMessageNotReceived {
+		ctxLogger.Warn(stacktrace.Propagate(err, fmt.Sprintf("message with ID [%s] was not received", messageID)))
+		return h.responseUnprocessableEntity(c, url.Values{"messageID": []string{"only messages which were received by a phone can be marked as read"}}, "validation errors while marking message as read")
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot mark message with ID [%s] as read", messageID)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseOK(c, "message marked as read successfully", message)
+}
+
+// PutTags sets the tags of a message
+// @Summary      Set the tags of a message
+// @Description  Replace the tags of a message. The tags are used to filter messages with the tag query parameter.
+// @Security	 ApiKeyAuth
+// @Tags         Messages
+// @Accept       json
+// @Produce      json
+// @Param 		 messageID 	path		string 				true 	"ID of the message" 	default(32343a19-da5e-4b1b-a767-3298a73703ca)
+// @Param        payload   	body 		requests.TagsUpdate true 	"Tags of the message"
+// @Success      200  		{object} 	responses.MessageResponse
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401    	{object}	responses.Unauthorized
+// @Failure 	 404		{object}	responses.NotFound
+// @Failure      422  		{object} 	responses.UnprocessableEntity
+// @Failure      500  		{object}  	responses.InternalServerError
+// @Router       /messages/{messageID}/tags [put]
+func (h *MessageHandler) PutTags(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	var request requests.TagsUpdate
+	if err := c.BodyParser(&request); err != nil {
+		msg := fmt.Sprintf("cannot marshall [%s] into %T", c.Body(), request)
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return h.responseBadRequest(c, err)
+	}
+
+	messageID := c.Params("messageID")
+	if errors := h.validator.ValidateTagsUpdate(ctx, messageID, request.Sanitize()); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while setting the tags of message with ID [%s]", spew.Sdump(errors), messageID)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while setting the tags of message")
+	}
+
+	message, err := h.service.UpdateTags(ctx, h.userIDFomContext(c), uuid.MustParse(messageID), request.Tags)
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		return h.responseNotFound(c, fmt.Sprintf("cannot find message with ID [%s]", messageID))
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot set the tags of message with ID [%s]", messageID)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseOK(c, "message tags updated successfully", message)
+}
+
 // PostReceive receives a new entities.Message
 // @Summary      Receive a new SMS message from a mobile phone
 // @Description  Add a new message received from a mobile phone
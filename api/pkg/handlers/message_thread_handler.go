@@ -3,22 +3,25 @@ package handlers
 import (
 	"fmt"
 
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
 	"github.com/NdoleStudio/httpsms/pkg/requests"
 	"github.com/NdoleStudio/httpsms/pkg/services"
 	"github.com/NdoleStudio/httpsms/pkg/telemetry"
 	"github.com/NdoleStudio/httpsms/pkg/validators"
 	"github.com/davecgh/go-spew/spew"
 	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
 	"github.com/palantir/stacktrace"
 )
 
 // MessageThreadHandler handles message-thead http requests.
 type MessageThreadHandler struct {
 	handler
-	logger    telemetry.Logger
-	tracer    telemetry.Tracer
-	validator *validators.MessageThreadHandlerValidator
-	service   *services.MessageThreadService
+	logger     telemetry.Logger
+	tracer     telemetry.Tracer
+	validator  *validators.MessageThreadHandlerValidator
+	service    *services.MessageThreadService
+	tagService *services.TagService
 }
 
 // NewMessageThreadHandler creates a new MessageThreadHandler
@@ -27,19 +30,23 @@ func NewMessageThreadHandler(
 	tracer telemetry.Tracer,
 	validator *validators.MessageThreadHandlerValidator,
 	service *services.MessageThreadService,
+	tagService *services.TagService,
 ) (h *MessageThreadHandler) {
 	return &MessageThreadHandler{
-		logger:    logger.WithService(fmt.Sprintf("%T", h)),
-		tracer:    tracer,
-		validator: validator,
-		service:   service,
+		logger:     logger.WithService(fmt.Sprintf("%T", h)),
+		tracer:     tracer,
+		validator:  validator,
+		service:    service,
+		tagService: tagService,
 	}
 }
 
 // RegisterRoutes registers the routes for the MessageHandler
 func (h *MessageThreadHandler) RegisterRoutes(router fiber.Router) {
 	router.Get("/message-threads", h.Index)
+	router.Post("/message-threads/unarchive", h.Unarchive)
 	router.Put("/message-threads/:messageThreadID", h.Update)
+	router.Put("/message-threads/:messageThreadID/tags", h.PutTags)
 }
 
 // Index returns message threads for a phone number
@@ -52,6 +59,7 @@ func (h *MessageThreadHandler) RegisterRoutes(router fiber.Router) {
 // @Param        owner	query  string  	true 	"owner phone number" 						default(+18005550199)
 // @Param        skip	query  int  	false	"number of messages to skip"				minimum(0)
 // @Param        query	query  string  	false 	"filter message threads containing query"
+// @Param        tag	query  string  	false 	"filter message threads with the tag or one of its descendants"
 // @Param        limit	query  int  	false	"number of messages to return"				minimum(1)	maximum(20)
 // @Success      200 	{object}	responses.MessageThreadsResponse
 // @Failure      400	{object}	responses.BadRequest
@@ -80,7 +88,18 @@ func (h *MessageThreadHandler) Index(c *fiber.Ctx) error {
 		return h.responseUnprocessableEntity(c, errors, "validation errors while fetching message threads")
 	}
 
-	threads, err := h.service.GetThreads(ctx, request.ToGetParams(h.userIDFomContext(c)))
+	params := request.ToGetParams(h.userIDFomContext(c))
+	if request.Tag != "" {
+		tags, err := h.tagService.Expand(ctx, params.UserID, request.Tag)
+		if err != nil {
+			msg := fmt.Sprintf("cannot expand tag [%s] for user [%s]", request.Tag, params.UserID)
+			ctxLogger.Error(stacktrace.Propagate(err, msg))
+			return h.responseInternalServerError(c)
+		}
+		params.Tags = tags
+	}
+
+	threads, err := h.service.GetThreads(ctx, params)
 	if err != nil {
 		msg := fmt.Sprintf("cannot get message threads with params [%+#v]", request)
 		ctxLogger.Error(stacktrace.Propagate(err, msg))
@@ -134,3 +153,96 @@ func (h *MessageThreadHandler) Update(c *fiber.Ctx) error {
 
 	return h.responseOK(c, "message thread updated successfully", thread)
 }
+
+// Unarchive moves archived message threads back to the inbox
+// @Summary      Unarchive message threads
+// @Description  Moves archived message threads of an owner back to the inbox. All the archived threads of the owner are unarchived when `message_thread_ids` is empty.
+// @Security	 ApiKeyAuth
+// @Tags         Channel Threads
+// @Accept       json
+// @Produce      json
+// @Param        payload   	body 		requests.MessageThreadUnarchive  	true 	"IDs of the message threads to unarchive"
+// @Success      200 		{object}	responses.MessageThreadUnarchiveResponse
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401    	{object}	responses.Unauthorized
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /message-threads/unarchive [post]
+func (h *MessageThreadHandler) Unarchive(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	var request requests.MessageThreadUnarchive
+	if err := c.BodyParser(&request); err != nil {
+		msg := fmt.Sprintf("cannot marshall params [%s] into %T", c.Body(), request)
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return h.responseBadRequest(c, err)
+	}
+
+	if errors := h.validator.ValidateUnarchive(ctx, request.Sanitize()); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while unarchiving message threads [%+#v]", spew.Sdump(errors), request)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while unarchiving message threads")
+	}
+
+	result, err := h.service.Unarchive(ctx, request.ToUnarchiveParams(h.userIDFomContext(c)))
+	if err != nil {
+		msg := fmt.Sprintf("cannot unarchive message threads with params [%+#v]", request)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseOK(c, fmt.Sprintf("unarchived %d message %s", result.Unarchived, h.pluralize("thread", int(result.Unarchived))), result)
+}
+
+// PutTags sets the tags of an entities.MessageThread
+// @Summary      Set the tags of a message thread
+// @Description  Replace the tags of a message thread. The tags are used to filter message threads with the tag query parameter.
+// @Security	 ApiKeyAuth
+// @Tags         Channel Threads
+// @Accept       json
+// @Produce      json
+// @Param 		 messageThreadID	path		string 				true 	"ID of the message thread" 	default(32343a19-da5e-4b1b-a767-3298a73703ca)
+// @Param        payload   			body 		requests.TagsUpdate true 	"Tags of the message thread"
+// @Success      200 				{object}	responses.MessageThreadResponse
+// @Failure      400				{object}	responses.BadRequest
+// @Failure 	 401    			{object}	responses.Unauthorized
+// @Failure 	 404				{object}	responses.NotFound
+// @Failure      422				{object}	responses.UnprocessableEntity
+// @Failure      500				{object}	responses.InternalServerError
+// @Router       /message-threads/{messageThreadID}/tags [put]
+func (h *MessageThreadHandler) PutTags(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	var request requests.TagsUpdate
+	if err := c.BodyParser(&request); err != nil {
+		msg := fmt.Sprintf("cannot marshall [%s] into %T", c.Body(), request)
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return h.responseBadRequest(c, err)
+	}
+
+	messageThreadID := c.Params("messageThreadID")
+	if errors := h.validator.ValidateTagsUpdate(ctx, messageThreadID, request.Sanitize()); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while setting the tags of message thread with ID [%s]", spew.Sdump(errors), messageThreadID)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while setting the tags of message thread")
+	}
+
+	thread, err := h.service.UpdateTags(ctx, services.MessageThreadTagsParams{
+		UserID:          h.userIDFomContext(c),
+		MessageThreadID: uuid.MustParse(messageThreadID),
+		Tags:            request.Tags,
+	})
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		return h.responseNotFound(c, fmt.Sprintf("cannot find message thread with ID [%s]", messageThreadID))
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot set the tags of message thread with ID [%s]", messageThreadID)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseOK(c, "message thread tags updated successfully", thread)
+}
@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"fmt"
+
+	"github.com/NdoleStudio/httpsms/pkg/requests"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/NdoleStudio/httpsms/pkg/validators"
+	"github.com/davecgh/go-spew/spew"
+	"github.com/gofiber/fiber/v2"
+	"github.com/palantir/stacktrace"
+)
+
+// NotificationPreferenceHandler handles notification preference requests
+type NotificationPreferenceHandler struct {
+	handler
+	logger    telemetry.Logger
+	tracer    telemetry.Tracer
+	service   *services.NotificationPreferenceService
+	validator *validators.NotificationPreferenceHandlerValidator
+}
+
+// NewNotificationPreferenceHandler creates a new NotificationPreferenceHandler
+func NewNotificationPreferenceHandler(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	service *services.NotificationPreferenceService,
+	validator *validators.NotificationPreferenceHandlerValidator,
+) (h *NotificationPreferenceHandler) {
+	return &NotificationPreferenceHandler{
+		logger:    logger.WithService(fmt.Sprintf("%T", h)),
+		tracer:    tracer,
+		service:   service,
+		validator: validator,
+	}
+}
+
+// RegisterRoutes registers the routes for the NotificationPreferenceHandler
+func (h *NotificationPreferenceHandler) RegisterRoutes(app *fiber.App, middlewares ...fiber.Handler) {
+	router := app.Group("/v1/notification-preferences")
+	router.Get("/", h.computeRoute(middlewares, h.Index)...)
+	router.Put("/", h.computeRoute(middlewares, h.Update)...)
+}
+
+// Index returns the notification preferences of a user
+// @Summary      Get notification preferences of a user
+// @Description  Get which events generate emails, webhooks and integration messages. Notifications which have not been configured are enabled by default.
+// @Security	 ApiKeyAuth
+// @Tags         NotificationPreferences
+// @Accept       json
+// @Produce      json
+// @Success      200 		{object}	responses.NotificationPreferencesResponse
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401	    {object}	responses.Unauthorized
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /notification-preferences 	[get]
+func (h *NotificationPreferenceHandler) Index(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	preferences, err := h.service.Index(ctx, h.userIDFomContext(c))
+	if err != nil {
+		msg := fmt.Sprintf("cannot get notification preferences for user [%s]", h.userIDFomContext(c))
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseOK(c, fmt.Sprintf("fetched %d notification %s", len(preferences), h.pluralize("preference", len(preferences))), preferences)
+}
+
+// Update the notification preferences of a user
+// @Summary      Update notification preferences
+// @Description  Enable or disable the notifications generated by events on the email, webhook and integration channels
+// @Security	 ApiKeyAuth
+// @Tags         NotificationPreferences
+// @Accept       json
+// @Produce      json
+// @Param        payload   	body 		requests.NotificationPreferencesUpdate  	true 	"Payload of the notification preferences"
+// @Success      200 		{object}	responses.NotificationPreferencesResponse
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401	    {object}	responses.Unauthorized
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /notification-preferences [put]
+func (h *NotificationPreferenceHandler) Update(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	var request requests.NotificationPreferencesUpdate
+	if err := c.BodyParser(&request); err != nil {
+		msg := fmt.Sprintf("cannot marshall body [%s] into [%T]", c.Body(), request)
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return h.responseBadRequest(c, err)
+	}
+
+	if errors := h.validator.ValidateUpdate(ctx, request.Sanitize()); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while updating notification preferences [%+#v]", spew.Sdump(errors), request)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while updating notification preferences")
+	}
+
+	preferences, err := h.service.Update(ctx, request.ToUpdateParams(h.userFromContext(c)))
+	if err != nil {
+		msg := fmt.Sprintf("cannot update notification preferences with params [%+#v]", request)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseOK(c, "notification preferences updated successfully", preferences)
+}
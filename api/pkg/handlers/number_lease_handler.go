@@ -0,0 +1,177 @@
+package handlers
+
+import (
+	"fmt"
+	"net/url"
+
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
+	"github.com/NdoleStudio/httpsms/pkg/requests"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/NdoleStudio/httpsms/pkg/validators"
+	"github.com/davecgh/go-spew/spew"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/palantir/stacktrace"
+)
+
+// NumberLeaseHandler handles number lease requests
+type NumberLeaseHandler struct {
+	handler
+	logger    telemetry.Logger
+	tracer    telemetry.Tracer
+	service   *services.NumberLeaseService
+	validator *validators.NumberLeaseHandlerValidator
+}
+
+// NewNumberLeaseHandler creates a new NumberLeaseHandler
+func NewNumberLeaseHandler(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	service *services.NumberLeaseService,
+	validator *validators.NumberLeaseHandlerValidator,
+) (h *NumberLeaseHandler) {
+	return &NumberLeaseHandler{
+		logger:    logger.WithService(fmt.Sprintf("%T", h)),
+		tracer:    tracer,
+		service:   service,
+		validator: validator,
+	}
+}
+
+// RegisterRoutes registers the routes for the NumberLeaseHandler
+func (h *NumberLeaseHandler) RegisterRoutes(app *fiber.App, middlewares ...fiber.Handler) {
+	router := app.Group("/v1/number-leases")
+	router.Get("/", h.computeRoute(middlewares, h.Index)...)
+	router.Post("/", h.computeRoute(middlewares, h.Store)...)
+	router.Delete("/:leaseID", h.computeRoute(middlewares, h.Release)...)
+}
+
+// Index returns the number leases of a user
+// @Summary      Get number leases of a user
+// @Description  Get the owner numbers which have been allocated from the pool of phones to conversations or campaigns
+// @Security	 ApiKeyAuth
+// @Tags         NumberLeases
+// @Accept       json
+// @Produce      json
+// @Param        skip		query  int  	false	"number of leases to skip"		minimum(0)
+// @Param        query		query  string  	false 	"filter leases containing query"
+// @Param        limit		query  int  	false	"number of leases to return"	minimum(1)	maximum(100)
+// @Success      200 		{object}	responses.NumberLeasesResponse
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401	    {object}	responses.Unauthorized
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /number-leases 	[get]
+func (h *NumberLeaseHandler) Index(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	var request requests.NumberLeaseIndex
+	if err := c.QueryParser(&request); err != nil {
+		msg := fmt.Sprintf("cannot marshall URL [%s] into %T", c.OriginalURL(), request)
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return h.responseBadRequest(c, err)
+	}
+
+	if errors := h.validator.ValidateIndex(ctx, request.Sanitize()); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while fetching number leases [%+#v]", spew.Sdump(errors), request)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while fetching number leases")
+	}
+
+	leases, err := h.service.Index(ctx, h.userIDFomContext(c), request.ToIndexParams())
+	if err != nil {
+		msg := fmt.Sprintf("cannot get number leases with params [%+#v]", request)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseOK(c, fmt.Sprintf("fetched %d number %s", len(leases), h.pluralize("lease", len(leases))), leases)
+}
+
+// Store allocates an owner number from the pool
+// @Summary      Lease an owner number
+// @Description  Allocate an owner number which is not in use from the pool of phones to a conversation or campaign. The number is released automatically after the inactivity timeout.
+// @Security	 ApiKeyAuth
+// @Tags         NumberLeases
+// @Accept       json
+// @Produce      json
+// @Param        payload   	body 		requests.NumberLeaseStore  		true "Payload of the number lease request"
+// @Success      201 		{object}	responses.NumberLeaseResponse
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401	    {object}	responses.Unauthorized
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /number-leases [post]
+func (h *NumberLeaseHandler) Store(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	var request requests.NumberLeaseStore
+	if err := c.BodyParser(&request); err != nil {
+		msg := fmt.Sprintf("cannot marshall body [%s] into [%T]", c.Body(), request)
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return h.responseBadRequest(c, err)
+	}
+
+	if errors := h.validator.ValidateStore(ctx, h.userIDFomContext(c), request.Sanitize()); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while storing number lease [%+#v]", spew.Sdump(errors), request)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while storing number lease")
+	}
+
+	lease, err := h.service.Allocate(ctx, request.ToAllocateParams(h.userFromContext(c), c.OriginalURL()))
+	if stacktrace.GetCode(err) == services.ErrCodeNumberPoolExhausted {
+		ctxLogger.Warn(stacktrace.Propagate(err, fmt.Sprintf("number pool is exhausted for request [%+#v]", request)))
+		return h.responseUnprocessableEntity(c, url.Values{"pool": []string{"all the owner numbers in the pool are already leased"}}, "validation errors while storing number lease")
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot store number lease with params [%+#v]", request)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseCreated(c, "number leased successfully", lease)
+}
+
+// Release a number lease
+// @Summary      Release a number lease
+// @Description  Return the owner number of a lease to the pool
+// @Security	 ApiKeyAuth
+// @Tags         NumberLeases
+// @Accept       json
+// @Produce      json
+// @Param 		 leaseID 	path		string 							true 	"ID of the number lease"	default(32343a19-da5e-4b1b-a767-3298a73703ca)
+// @Success      200		{object}    responses.NumberLeaseResponse
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401    	{object}	responses.Unauthorized
+// @Failure 	 404    	{object}	responses.NotFound
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /number-leases/{leaseID} [delete]
+func (h *NumberLeaseHandler) Release(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	leaseID := c.Params("leaseID")
+	if errors := h.validator.ValidateUUID(ctx, leaseID, "leaseID"); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while releasing number lease with ID [%s]", spew.Sdump(errors), leaseID)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while releasing number lease")
+	}
+
+	lease, err := h.service.Release(ctx, h.userIDFomContext(c), uuid.MustParse(leaseID))
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		return h.responseNotFound(c, fmt.Sprintf("cannot find number lease with ID [%s]", leaseID))
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot release number lease with ID [%s]", leaseID)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseOK(c, "number lease released successfully", lease)
+}
@@ -0,0 +1,148 @@
+package handlers
+
+import (
+	"fmt"
+
+	"github.com/NdoleStudio/httpsms/pkg/requests"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/NdoleStudio/httpsms/pkg/validators"
+	"github.com/davecgh/go-spew/spew"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/palantir/stacktrace"
+)
+
+// PhoneFailoverHandler handles phone failover policy requests
+type PhoneFailoverHandler struct {
+	handler
+	logger    telemetry.Logger
+	tracer    telemetry.Tracer
+	service   *services.PhoneFailoverService
+	validator *validators.PhoneFailoverHandlerValidator
+}
+
+// NewPhoneFailoverHandler creates a new PhoneFailoverHandler
+func NewPhoneFailoverHandler(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	service *services.PhoneFailoverService,
+	validator *validators.PhoneFailoverHandlerValidator,
+) (h *PhoneFailoverHandler) {
+	return &PhoneFailoverHandler{
+		logger:    logger.WithService(fmt.Sprintf("%T", h)),
+		tracer:    tracer,
+		service:   service,
+		validator: validator,
+	}
+}
+
+// RegisterRoutes registers the routes for the PhoneFailoverHandler
+func (h *PhoneFailoverHandler) RegisterRoutes(app *fiber.App, middlewares ...fiber.Handler) {
+	router := app.Group("/v1/phone-failover-policies")
+	router.Get("/", h.computeRoute(middlewares, h.Index)...)
+	router.Put("/", h.computeRoute(middlewares, h.Upsert)...)
+	router.Delete("/:policyID", h.computeRoute(middlewares, h.Delete)...)
+}
+
+// Index returns the phone failover policies of a user
+// @Summary      Get phone failover policies of a user
+// @Description  Get the backup phones which send the messages of an owner when its phone stops sending heartbeats.
+// @Security	 ApiKeyAuth
+// @Tags         PhoneFailoverPolicies
+// @Accept       json
+// @Produce      json
+// @Success      200 		{object}	responses.PhoneFailoverPoliciesResponse
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401	    {object}	responses.Unauthorized
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /phone-failover-policies 	[get]
+func (h *PhoneFailoverHandler) Index(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	policies, err := h.service.Index(ctx, h.userIDFomContext(c))
+	if err != nil {
+		msg := fmt.Sprintf("cannot get phone failover policies for user [%s]", h.userIDFomContext(c))
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseOK(c, fmt.Sprintf("fetched %d phone failover %s", len(policies), h.pluralize("policy", len(policies))), policies)
+}
+
+// Upsert the phone failover policy of an owner
+// @Summary      Configure a phone failover policy
+// @Description  Configure the backup phones in order of preference which send the messages of an owner when its phone misses heartbeats.
+// @Security	 ApiKeyAuth
+// @Tags         PhoneFailoverPolicies
+// @Accept       json
+// @Produce      json
+// @Param        payload   		body 		requests.PhoneFailoverPolicyUpsert  	true 	"Payload of the phone failover policy"
+// @Success      200 		{object}	responses.PhoneFailoverPolicyResponse
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401	    {object}	responses.Unauthorized
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /phone-failover-policies [put]
+func (h *PhoneFailoverHandler) Upsert(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	var request requests.PhoneFailoverPolicyUpsert
+	if err := c.BodyParser(&request); err != nil {
+		msg := fmt.Sprintf("cannot marshall body [%s] into [%T]", c.Body(), request)
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return h.responseBadRequest(c, err)
+	}
+
+	if errors := h.validator.ValidateUpsert(ctx, request.Sanitize()); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while updating phone failover policy [%+#v]", spew.Sdump(errors), request)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while updating phone failover policy")
+	}
+
+	policy, err := h.service.Upsert(ctx, request.ToUpsertParams(h.userFromContext(c)))
+	if err != nil {
+		msg := fmt.Sprintf("cannot update phone failover policy with params [%+#v]", request)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseOK(c, "phone failover policy updated successfully", policy)
+}
+
+// Delete a phone failover policy
+// @Summary      Delete a phone failover policy
+// @Description  Delete a phone failover policy so that the messages of the owner wait for its phone to come back online
+// @Security	 ApiKeyAuth
+// @Tags         PhoneFailoverPolicies
+// @Accept       json
+// @Produce      json
+// @Param 		 policyID 	path		string 							true 	"ID of the phone failover policy"	default(32343a19-da5e-4b1b-a767-3298a73703cb)
+// @Success      204		{object}    responses.NoContent
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401	    {object}	responses.Unauthorized
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /phone-failover-policies/{policyID} [delete]
+func (h *PhoneFailoverHandler) Delete(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	policyID := c.Params("policyID")
+	if errors := h.validator.ValidateUUID(ctx, policyID, "policyID"); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while deleting phone failover policy with ID [%s]", spew.Sdump(errors), policyID)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while deleting phone failover policy")
+	}
+
+	if err := h.service.Delete(ctx, h.userIDFomContext(c), uuid.MustParse(policyID)); err != nil {
+		msg := fmt.Sprintf("cannot delete phone failover policy with ID [%s]", policyID)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseNoContent(c, "phone failover policy deleted successfully")
+}
@@ -3,6 +3,7 @@ package handlers
 import (
 	"fmt"
 
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
 	"github.com/NdoleStudio/httpsms/pkg/requests"
 	"github.com/NdoleStudio/httpsms/pkg/validators"
 	"github.com/davecgh/go-spew/spew"
@@ -41,7 +42,9 @@ func NewPhoneHandler(
 func (h *PhoneHandler) RegisterRoutes(router fiber.Router) {
 	router.Get("/phones", h.Index)
 	router.Put("/phones", h.Upsert)
+	router.Post("/phones/missed-calls", h.MissedCall)
 	router.Delete("/phones/:phoneID", h.Delete)
+	router.Put("/phones/:phoneID/kill-switch", h.UpdateKillSwitch)
 }
 
 // Index returns the phones of a user
@@ -168,3 +171,91 @@ func (h *PhoneHandler) Delete(c *fiber.Ctx) error {
 
 	return h.responseOK(c, "phone deleted successfully", nil)
 }
+
+// UpdateKillSwitch enables or disables the kill switch of a phone
+// @Summary      Update the kill switch of a phone
+// @Description  Stop a phone from sending messages immediately e.g. during an incident. The phone is notified with a push notification and it cannot fetch messages until the kill switch is disabled.
+// @Security	 ApiKeyAuth
+// @Tags         Phones
+// @Accept       json
+// @Produce      json
+// @Param 		 phoneID 	path		string 							true 	"ID of the phone"	default(32343a19-da5e-4b1b-a767-3298a73703ca)
+// @Param        payload   	body 		requests.PhoneKillSwitch  		true 	"Payload of the kill switch"
+// @Success      200 		{object}	responses.PhoneResponse
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401    	{object}	responses.Unauthorized
+// @Failure 	 404		{object}	responses.NotFound
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /phones/{phoneID}/kill-switch [put]
+func (h *PhoneHandler) UpdateKillSwitch(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	var request requests.PhoneKillSwitch
+	if err := c.BodyParser(&request); err != nil {
+		msg := fmt.Sprintf("cannot marshall params [%s] into %T", c.Body(), request)
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return h.responseBadRequest(c, err)
+	}
+
+	request.PhoneID = c.Params("phoneID")
+	if errors := h.validator.ValidateKillSwitch(ctx, request.Sanitize()); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while updating kill switch [%+#v]", spew.Sdump(errors), request)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while updating kill switch")
+	}
+
+	phone, err := h.service.UpdateKillSwitch(ctx, request.ToKillSwitchParams(h.userIDFomContext(c), c.OriginalURL()))
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		return h.responseNotFound(c, fmt.Sprintf("cannot find phone with ID [%s]", request.PhoneID))
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot update kill switch with params [%+#v]", request)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseOK(c, "kill switch updated successfully", phone)
+}
+
+// MissedCall registers a call which was missed on a phone
+// @Summary      Register a missed call
+// @Description  Register a call which was missed on the android phone. This triggers the missed call rules of the phone.
+// @Security	 ApiKeyAuth
+// @Tags         Phones
+// @Accept       json
+// @Produce      json
+// @Param        payload   	body 		requests.PhoneMissedCall  		true 	"Payload of the missed call"
+// @Success      200 		{object}	responses.NoContent
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401    	{object}	responses.Unauthorized
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /phones/missed-calls [post]
+func (h *PhoneHandler) MissedCall(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	var request requests.PhoneMissedCall
+	if err := c.BodyParser(&request); err != nil {
+		msg := fmt.Sprintf("cannot marshall params [%s] into %T", c.Body(), request)
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return h.responseBadRequest(c, err)
+	}
+
+	if errors := h.validator.ValidateMissedCall(ctx, request.Sanitize()); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while registering missed call [%+#v]", spew.Sdump(errors), request)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while registering missed call")
+	}
+
+	if err := h.service.MissedCall(ctx, request.ToMissedCallParams(h.userIDFomContext(c), c.OriginalURL())); err != nil {
+		msg := fmt.Sprintf("cannot register missed call with params [%+#v]", request)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseOK(c, "missed call registered successfully", nil)
+}
@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"fmt"
+	"net/url"
+
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
+	"github.com/NdoleStudio/httpsms/pkg/requests"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/NdoleStudio/httpsms/pkg/validators"
+	"github.com/davecgh/go-spew/spew"
+	"github.com/gofiber/fiber/v2"
+	"github.com/palantir/stacktrace"
+)
+
+// PhoneRegistrationHandler handles the links in the emails which are sent when a device is registered
+type PhoneRegistrationHandler struct {
+	handler
+	logger    telemetry.Logger
+	tracer    telemetry.Tracer
+	service   *services.PhoneRegistrationService
+	validator *validators.PhoneRegistrationHandlerValidator
+}
+
+// NewPhoneRegistrationHandler creates a new PhoneRegistrationHandler
+func NewPhoneRegistrationHandler(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	service *services.PhoneRegistrationService,
+	validator *validators.PhoneRegistrationHandlerValidator,
+) (h *PhoneRegistrationHandler) {
+	return &PhoneRegistrationHandler{
+		logger:    logger.WithService(fmt.Sprintf("%T", h)),
+		tracer:    tracer,
+		service:   service,
+		validator: validator,
+	}
+}
+
+// RegisterRoutes registers the routes for the PhoneRegistrationHandler, the token in the email authenticates the request
+func (h *PhoneRegistrationHandler) RegisterRoutes(app *fiber.App) {
+	router := app.Group("/v1/phone-registrations")
+	router.Post("/revoke", h.Revoke)
+}
+
+// Revoke removes a device which was registered on the account of a user
+// @Summary      Revoke a registered device
+// @Description  Delete the phone of a device with the token in the email which is sent when a new phone or FCM token is registered. The phone is kept when another device registered on it after the email was sent.
+// @Tags         Phones
+// @Accept       json
+// @Produce      json
+// @Param        payload   		body 		requests.PhoneRegistrationRevoke  	true 	"Token in the phone registered email"
+// @Success      200 		{object}	responses.PhoneRegistrationResponse
+// @Failure      400		{object}	responses.BadRequest
+// @Failure      404		{object}	responses.NotFound
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /phone-registrations/revoke [post]
+func (h *PhoneRegistrationHandler) Revoke(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	var request requests.PhoneRegistrationRevoke
+	if err := c.BodyParser(&request); err != nil {
+		msg := fmt.Sprintf("cannot marshall body into [%T]", request)
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return h.responseBadRequest(c, err)
+	}
+
+	if errors := h.validator.ValidateRevoke(ctx, request.Sanitize()); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while revoking device", spew.Sdump(errors))
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while revoking device")
+	}
+
+	registration, err := h.service.Revoke(ctx, c.OriginalURL(), request.Token)
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		return h.responseNotFound(c, "cannot find a device registration with the token")
+	}
+
+	if stacktrace.GetCode(err) == services.ErrCodePhoneRegistrationExpired {
+		return h.responseUnprocessableEntity(c, url.Values{"token": []string{"the revoke link has expired, delete the phone from your account settings instead"}}, "validation errors while revoking device")
+	}
+
+	if err != nil {
+		ctxLogger.Error(stacktrace.Propagate(err, "cannot revoke device"))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseOK(c, "device revoked successfully", registration)
+}
@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"fmt"
+
+	"github.com/NdoleStudio/httpsms/pkg/services"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/gofiber/fiber/v2"
+	"github.com/palantir/stacktrace"
+)
+
+// PhoneReputationHandler handles phone reputation requests
+type PhoneReputationHandler struct {
+	handler
+	logger  telemetry.Logger
+	tracer  telemetry.Tracer
+	service *services.PhoneReputationService
+}
+
+// NewPhoneReputationHandler creates a new PhoneReputationHandler
+func NewPhoneReputationHandler(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	service *services.PhoneReputationService,
+) (h *PhoneReputationHandler) {
+	return &PhoneReputationHandler{
+		logger:  logger.WithService(fmt.Sprintf("%T", h)),
+		tracer:  tracer,
+		service: service,
+	}
+}
+
+// RegisterRoutes registers the routes for the PhoneReputationHandler
+func (h *PhoneReputationHandler) RegisterRoutes(app *fiber.App, middlewares ...fiber.Handler) {
+	router := app.Group("/v1/phone-reputations")
+	router.Get("/", h.computeRoute(middlewares, h.Index)...)
+}
+
+// Index returns the reputation of the phone numbers of a user
+// @Summary      Get the reputation of phone numbers
+// @Description  Get the reputation score of each phone number which sent or received messages in the last 2 weeks. The score is computed from the complaints and negative replies of contacts in the last 7 days, the trend compares it with the 7 days before. A phone number with a high risk is likely to be blocked by carriers.
+// @Security	 ApiKeyAuth
+// @Tags         PhoneReputations
+// @Accept       json
+// @Produce      json
+// @Success      200 		{object}	responses.PhoneReputationsResponse
+// @Failure 	 401	    {object}	responses.Unauthorized
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /phone-reputations 	[get]
+func (h *PhoneReputationHandler) Index(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	reputations, err := h.service.Index(ctx, h.userIDFomContext(c))
+	if err != nil {
+		msg := fmt.Sprintf("cannot get phone reputations for user [%s]", h.userIDFomContext(c))
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseOK(c, fmt.Sprintf("fetched %d phone %s", len(reputations), h.pluralize("reputation", len(reputations))), reputations)
+}
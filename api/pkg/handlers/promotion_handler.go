@@ -0,0 +1,407 @@
+package handlers
+
+import (
+	"fmt"
+	"net/url"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
+	"github.com/NdoleStudio/httpsms/pkg/requests"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/NdoleStudio/httpsms/pkg/validators"
+	"github.com/davecgh/go-spew/spew"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/palantir/stacktrace"
+)
+
+// PromotionHandler handles coupon and plan override requests
+type PromotionHandler struct {
+	handler
+	logger          telemetry.Logger
+	tracer          telemetry.Tracer
+	service         *services.PromotionService
+	operatorService *services.OperatorService
+	validator       *validators.PromotionHandlerValidator
+}
+
+// NewPromotionHandler creates a new PromotionHandler
+func NewPromotionHandler(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	service *services.PromotionService,
+	operatorService *services.OperatorService,
+	validator *validators.PromotionHandlerValidator,
+) (h *PromotionHandler) {
+	return &PromotionHandler{
+		logger:          logger.WithService(fmt.Sprintf("%T", h)),
+		tracer:          tracer,
+		service:         service,
+		operatorService: operatorService,
+		validator:       validator,
+	}
+}
+
+// RegisterRoutes registers the routes for the PromotionHandler
+func (h *PromotionHandler) RegisterRoutes(app *fiber.App, middlewares ...fiber.Handler) {
+	app.Post("/v1/coupons/redeem", h.computeRoute(middlewares, h.Redeem)...)
+	app.Get("/v1/plan-overrides", h.computeRoute(middlewares, h.IndexPlanOverrides)...)
+
+	router := app.Group("/v1/operator")
+	router.Get("/coupons", h.computeRoute(middlewares, h.IndexCoupons)...)
+	router.Post("/coupons", h.computeRoute(middlewares, h.StoreCoupon)...)
+	router.Delete("/coupons/:couponID", h.computeRoute(middlewares, h.DeleteCoupon)...)
+	router.Get("/users/:userID/plan-overrides", h.computeRoute(middlewares, h.IndexUserPlanOverrides)...)
+	router.Post("/users/:userID/plan-overrides", h.computeRoute(middlewares, h.StorePlanOverride)...)
+	router.Delete("/users/:userID/plan-overrides/:planOverrideID", h.computeRoute(middlewares, h.DeletePlanOverride)...)
+}
+
+// Redeem a coupon
+// @Summary      Redeem a coupon
+// @Description  Redeem a promo code to use the plan of the coupon for the number of days of the coupon. A coupon can be redeemed only once per user.
+// @Security	 ApiKeyAuth
+// @Tags         Promotions
+// @Accept       json
+// @Produce      json
+// @Param        payload   	body 		requests.CouponRedeem  		true "Payload of the redeem request"
+// @Success      201 		{object}	responses.PlanOverrideResponse
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401	    {object}	responses.Unauthorized
+// @Failure 	 404    	{object}	responses.NotFound
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /coupons/redeem [post]
+func (h *PromotionHandler) Redeem(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	var request requests.CouponRedeem
+	if err := c.BodyParser(&request); err != nil {
+		msg := fmt.Sprintf("cannot marshall [%s] into %T", c.Body(), request)
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return h.responseBadRequest(c, err)
+	}
+
+	if errors := h.validator.ValidateCouponRedeem(ctx, request.Sanitize()); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while redeeming coupon [%+#v]", spew.Sdump(errors), request)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while redeeming coupon")
+	}
+
+	override, err := h.service.RedeemCoupon(ctx, h.userIDFomContext(c), request.Code)
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		return h.responseNotFound(c, fmt.Sprintf("cannot find coupon with code [%s]", request.Code))
+	}
+
+	if stacktrace.GetCode(err) == services.ErrCodeCouponNotRedeemable {
+		ctxLogger.Warn(stacktrace.Propagate(err, fmt.Sprintf("coupon [%s] cannot be redeemed by user [%s]", request.Code, h.userIDFomContext(c))))
+		return h.responseUnprocessableEntity(c, url.Values{"code": []string{"the coupon is expired, fully redeemed or you have already redeemed it"}}, "validation errors while redeeming coupon")
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot redeem coupon with params [%+#v]", request)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseCreated(c, "coupon redeemed successfully", override)
+}
+
+// IndexPlanOverrides returns the plan overrides of the authenticated user
+// @Summary      Get plan overrides of a user
+// @Description  Get the coupons, trials and plan overrides which replace the limit of the subscription of the user
+// @Security	 ApiKeyAuth
+// @Tags         Promotions
+// @Accept       json
+// @Produce      json
+// @Param        skip		query  int  	false	"number of plan overrides to skip"		minimum(0)
+// @Param        limit		query  int  	false	"number of plan overrides to return"	minimum(1)	maximum(100)
+// @Success      200 		{object}	responses.PlanOverridesResponse
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401	    {object}	responses.Unauthorized
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /plan-overrides 	[get]
+func (h *PromotionHandler) IndexPlanOverrides(c *fiber.Ctx) error {
+	return h.indexPlanOverrides(c, h.userIDFomContext(c))
+}
+
+// IndexUserPlanOverrides returns the plan overrides of a user
+// @Summary      Get plan overrides of any user
+// @Description  Get the coupons, trials and plan overrides of a user. Only operators of the instance can use this endpoint.
+// @Security	 ApiKeyAuth
+// @Tags         Promotions
+// @Accept       json
+// @Produce      json
+// @Param 		 userID 	path		string 	true 	"ID of the user"	default(WB7DRDWrJZRGbYrv2CKGkqbzvqdC)
+// @Param        skip		query  int  	false	"number of plan overrides to skip"		minimum(0)
+// @Param        limit		query  int  	false	"number of plan overrides to return"	minimum(1)	maximum(100)
+// @Success      200 		{object}	responses.PlanOverridesResponse
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401	    {object}	responses.Unauthorized
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /operator/users/{userID}/plan-overrides 	[get]
+func (h *PromotionHandler) IndexUserPlanOverrides(c *fiber.Ctx) error {
+	if !h.operatorService.IsOperator(h.userIDFomContext(c)) {
+		return h.responseForbidden(c)
+	}
+	return h.indexPlanOverrides(c, entities.UserID(c.Params("userID")))
+}
+
+func (h *PromotionHandler) indexPlanOverrides(c *fiber.Ctx, userID entities.UserID) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	var request requests.PlanOverrideIndex
+	if err := c.QueryParser(&request); err != nil {
+		msg := fmt.Sprintf("cannot marshall URL [%s] into %T", c.OriginalURL(), request)
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return h.responseBadRequest(c, err)
+	}
+
+	if errors := h.validator.ValidatePlanOverrideIndex(ctx, request.Sanitize()); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while fetching plan overrides [%+#v]", spew.Sdump(errors), request)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while fetching plan overrides")
+	}
+
+	overrides, err := h.service.IndexPlanOverrides(ctx, userID, request.ToIndexParams())
+	if err != nil {
+		msg := fmt.Sprintf("cannot get plan overrides for user [%s] with params [%+#v]", userID, request)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseOK(c, fmt.Sprintf("fetched %d %s", len(overrides), h.pluralize("plan override", len(overrides))), overrides)
+}
+
+// IndexCoupons returns the coupons of the instance
+// @Summary      Get coupons
+// @Description  Get all the coupons of the instance with the number of redemptions. Only operators of the instance can use this endpoint.
+// @Security	 ApiKeyAuth
+// @Tags         Promotions
+// @Accept       json
+// @Produce      json
+// @Param        skip		query  int  	false	"number of coupons to skip"		minimum(0)
+// @Param        query		query  string  	false 	"filter coupons with a code containing query"
+// @Param        limit		query  int  	false	"number of coupons to return"	minimum(1)	maximum(100)
+// @Success      200 		{object}	responses.CouponsResponse
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401	    {object}	responses.Unauthorized
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /operator/coupons 	[get]
+func (h *PromotionHandler) IndexCoupons(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	if !h.operatorService.IsOperator(h.userIDFomContext(c)) {
+		ctxLogger.Warn(stacktrace.NewError(fmt.Sprintf("user [%s] is not an operator and cannot fetch coupons", h.userIDFomContext(c))))
+		return h.responseForbidden(c)
+	}
+
+	var request requests.CouponIndex
+	if err := c.QueryParser(&request); err != nil {
+		msg := fmt.Sprintf("cannot marshall URL [%s] into %T", c.OriginalURL(), request)
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return h.responseBadRequest(c, err)
+	}
+
+	if errors := h.validator.ValidateCouponIndex(ctx, request.Sanitize()); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while fetching coupons [%+#v]", spew.Sdump(errors), request)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while fetching coupons")
+	}
+
+	coupons, err := h.service.IndexCoupons(ctx, request.ToIndexParams())
+	if err != nil {
+		msg := fmt.Sprintf("cannot get coupons with params [%+#v]", request)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseOK(c, fmt.Sprintf("fetched %d %s", len(coupons), h.pluralize("coupon", len(coupons))), coupons)
+}
+
+// StoreCoupon creates a coupon
+// @Summary      Store a coupon
+// @Description  Create a promo code which gives the users who redeem it a plan for a number of days. Only operators of the instance can use this endpoint.
+// @Security	 ApiKeyAuth
+// @Tags         Promotions
+// @Accept       json
+// @Produce      json
+// @Param        payload   	body 		requests.CouponStore  		true "Payload of the coupon request"
+// @Success      201 		{object}	responses.CouponResponse
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401	    {object}	responses.Unauthorized
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /operator/coupons [post]
+func (h *PromotionHandler) StoreCoupon(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	if !h.operatorService.IsOperator(h.userIDFomContext(c)) {
+		ctxLogger.Warn(stacktrace.NewError(fmt.Sprintf("user [%s] is not an operator and cannot create coupons", h.userIDFomContext(c))))
+		return h.responseForbidden(c)
+	}
+
+	var request requests.CouponStore
+	if err := c.BodyParser(&request); err != nil {
+		msg := fmt.Sprintf("cannot marshall [%s] into %T", c.Body(), request)
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return h.responseBadRequest(c, err)
+	}
+
+	if errors := h.validator.ValidateCouponStore(ctx, request.Sanitize()); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while storing coupon [%+#v]", spew.Sdump(errors), request)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while storing coupon")
+	}
+
+	coupon, err := h.service.StoreCoupon(ctx, request.ToStoreParams(h.userIDFomContext(c)))
+	if stacktrace.GetCode(err) == services.ErrCodeCouponCodeTaken {
+		return h.responseUnprocessableEntity(c, url.Values{"code": []string{fmt.Sprintf("a coupon with code [%s] already exists", request.Code)}}, "validation errors while storing coupon")
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot store coupon with params [%+#v]", request)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseCreated(c, "coupon created successfully", coupon)
+}
+
+// DeleteCoupon deletes a coupon
+// @Summary      Delete a coupon
+// @Description  Delete a coupon so that it cannot be redeemed anymore. Users who already redeemed the coupon keep their plan override. Only operators of the instance can use this endpoint.
+// @Security	 ApiKeyAuth
+// @Tags         Promotions
+// @Accept       json
+// @Produce      json
+// @Param 		 couponID 	path		string 	true 	"ID of the coupon"	default(32343a19-da5e-4b1b-a767-3298a73703ca)
+// @Success      204  		{object}    responses.NoContent
+// @Failure 	 401	    {object}	responses.Unauthorized
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /operator/coupons/{couponID} [delete]
+func (h *PromotionHandler) DeleteCoupon(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	if !h.operatorService.IsOperator(h.userIDFomContext(c)) {
+		ctxLogger.Warn(stacktrace.NewError(fmt.Sprintf("user [%s] is not an operator and cannot delete coupons", h.userIDFomContext(c))))
+		return h.responseForbidden(c)
+	}
+
+	couponID := c.Params("couponID")
+	if errors := h.validator.ValidateUUID(ctx, couponID, "couponID"); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while deleting coupon with ID [%s]", spew.Sdump(errors), couponID)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while deleting coupon")
+	}
+
+	if err := h.service.DeleteCoupon(ctx, uuid.MustParse(couponID)); err != nil {
+		msg := fmt.Sprintf("cannot delete coupon with ID [%s]", couponID)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseNoContent(c, "coupon deleted successfully")
+}
+
+// StorePlanOverride extends the trial or overrides the plan of a user
+// @Summary      Store a plan override
+// @Description  Extend the trial or override the plan and message limit of a user. The most recent active override replaces the subscription of the user when enforcing the usage limit. Only operators of the instance can use this endpoint.
+// @Security	 ApiKeyAuth
+// @Tags         Promotions
+// @Accept       json
+// @Produce      json
+// @Param 		 userID 	path		string 						true 	"ID of the user"	default(WB7DRDWrJZRGbYrv2CKGkqbzvqdC)
+// @Param        payload   	body 		requests.PlanOverrideStore  true 	"Payload of the plan override request"
+// @Success      201 		{object}	responses.PlanOverrideResponse
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401	    {object}	responses.Unauthorized
+// @Failure 	 404    	{object}	responses.NotFound
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /operator/users/{userID}/plan-overrides [post]
+func (h *PromotionHandler) StorePlanOverride(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	if !h.operatorService.IsOperator(h.userIDFomContext(c)) {
+		ctxLogger.Warn(stacktrace.NewError(fmt.Sprintf("user [%s] is not an operator and cannot override plans", h.userIDFomContext(c))))
+		return h.responseForbidden(c)
+	}
+
+	var request requests.PlanOverrideStore
+	if err := c.BodyParser(&request); err != nil {
+		msg := fmt.Sprintf("cannot marshall [%s] into %T", c.Body(), request)
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return h.responseBadRequest(c, err)
+	}
+
+	request.UserID = c.Params("userID")
+	if errors := h.validator.ValidatePlanOverrideStore(ctx, request.Sanitize()); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while storing plan override [%+#v]", spew.Sdump(errors), request)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while storing plan override")
+	}
+
+	override, err := h.service.StorePlanOverride(ctx, request.ToStoreParams(h.userIDFomContext(c)))
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		return h.responseNotFound(c, fmt.Sprintf("cannot find user with ID [%s]", request.UserID))
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot store plan override with params [%+#v]", request)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseCreated(c, "plan override created successfully", override)
+}
+
+// DeletePlanOverride deletes the plan override of a user
+// @Summary      Delete a plan override
+// @Description  Delete a plan override so that the subscription of the user is enforced again. Only operators of the instance can use this endpoint.
+// @Security	 ApiKeyAuth
+// @Tags         Promotions
+// @Accept       json
+// @Produce      json
+// @Param 		 userID 			path		string 	true 	"ID of the user"			default(WB7DRDWrJZRGbYrv2CKGkqbzvqdC)
+// @Param 		 planOverrideID 	path		string 	true 	"ID of the plan override"	default(32343a19-da5e-4b1b-a767-3298a73703ca)
+// @Success      204  		{object}    responses.NoContent
+// @Failure 	 401	    {object}	responses.Unauthorized
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /operator/users/{userID}/plan-overrides/{planOverrideID} [delete]
+func (h *PromotionHandler) DeletePlanOverride(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	if !h.operatorService.IsOperator(h.userIDFomContext(c)) {
+		ctxLogger.Warn(stacktrace.NewError(fmt.Sprintf("user [%s] is not an operator and cannot delete plan overrides", h.userIDFomContext(c))))
+		return h.responseForbidden(c)
+	}
+
+	planOverrideID := c.Params("planOverrideID")
+	if errors := h.validator.ValidateUUID(ctx, planOverrideID, "planOverrideID"); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while deleting plan override with ID [%s]", spew.Sdump(errors), planOverrideID)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while deleting plan override")
+	}
+
+	userID := entities.UserID(c.Params("userID"))
+	if err := h.service.DeletePlanOverride(ctx, userID, uuid.MustParse(planOverrideID)); err != nil {
+		msg := fmt.Sprintf("cannot delete plan override with ID [%s] for user [%s]", planOverrideID, userID)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseNoContent(c, "plan override deleted successfully")
+}
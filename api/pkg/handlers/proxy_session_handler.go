@@ -0,0 +1,227 @@
+package handlers
+
+import (
+	"fmt"
+	"net/url"
+
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
+	"github.com/NdoleStudio/httpsms/pkg/requests"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/NdoleStudio/httpsms/pkg/validators"
+	"github.com/davecgh/go-spew/spew"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/palantir/stacktrace"
+)
+
+// ProxySessionHandler handles proxy session requests
+type ProxySessionHandler struct {
+	handler
+	logger    telemetry.Logger
+	tracer    telemetry.Tracer
+	service   *services.ProxySessionService
+	validator *validators.ProxySessionHandlerValidator
+}
+
+// NewProxySessionHandler creates a new ProxySessionHandler
+func NewProxySessionHandler(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	service *services.ProxySessionService,
+	validator *validators.ProxySessionHandlerValidator,
+) (h *ProxySessionHandler) {
+	return &ProxySessionHandler{
+		logger:    logger.WithService(fmt.Sprintf("%T", h)),
+		tracer:    tracer,
+		service:   service,
+		validator: validator,
+	}
+}
+
+// RegisterRoutes registers the routes for the ProxySessionHandler
+func (h *ProxySessionHandler) RegisterRoutes(app *fiber.App, middlewares ...fiber.Handler) {
+	router := app.Group("/v1/proxy-sessions")
+	router.Get("/", h.computeRoute(middlewares, h.Index)...)
+	router.Post("/", h.computeRoute(middlewares, h.Store)...)
+	router.Delete("/:sessionID", h.computeRoute(middlewares, h.Close)...)
+	router.Get("/:sessionID/messages", h.computeRoute(middlewares, h.Transcript)...)
+}
+
+// Index returns the proxy sessions of a user
+// @Summary      Get proxy sessions of a user
+// @Description  Get the masked number sessions which relay messages between 2 parties through an owner phone number
+// @Security	 ApiKeyAuth
+// @Tags         ProxySessions
+// @Accept       json
+// @Produce      json
+// @Param        skip		query  int  	false	"number of sessions to skip"		minimum(0)
+// @Param        query		query  string  	false 	"filter sessions containing query"
+// @Param        limit		query  int  	false	"number of sessions to return"	minimum(1)	maximum(100)
+// @Success      200 		{object}	responses.ProxySessionsResponse
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401	    {object}	responses.Unauthorized
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /proxy-sessions 	[get]
+func (h *ProxySessionHandler) Index(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	var request requests.ProxySessionIndex
+	if err := c.QueryParser(&request); err != nil {
+		msg := fmt.Sprintf("cannot marshall URL [%s] into %T", c.OriginalURL(), request)
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return h.responseBadRequest(c, err)
+	}
+
+	if errors := h.validator.ValidateIndex(ctx, request.Sanitize()); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while fetching proxy sessions [%+#v]", spew.Sdump(errors), request)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while fetching proxy sessions")
+	}
+
+	sessions, err := h.service.Index(ctx, h.userIDFomContext(c), request.ToIndexParams())
+	if err != nil {
+		msg := fmt.Sprintf("cannot get proxy sessions with params [%+#v]", request)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseOK(c, fmt.Sprintf("fetched %d proxy %s", len(sessions), h.pluralize("session", len(sessions))), sessions)
+}
+
+// Store a proxy session
+// @Summary      Store a proxy session
+// @Description  Create a session which relays messages between 2 parties through an owner phone number without revealing their numbers
+// @Security	 ApiKeyAuth
+// @Tags         ProxySessions
+// @Accept       json
+// @Produce      json
+// @Param        payload   	body 		requests.ProxySessionStore  		true "Payload of the proxy session request"
+// @Success      201 		{object}	responses.ProxySessionResponse
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401	    {object}	responses.Unauthorized
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /proxy-sessions [post]
+func (h *ProxySessionHandler) Store(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	var request requests.ProxySessionStore
+	if err := c.BodyParser(&request); err != nil {
+		msg := fmt.Sprintf("cannot marshall body [%s] into [%T]", c.Body(), request)
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return h.responseBadRequest(c, err)
+	}
+
+	if errors := h.validator.ValidateStore(ctx, h.userIDFomContext(c), request.Sanitize()); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while storing proxy session [%+#v]", spew.Sdump(errors), request)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while storing proxy session")
+	}
+
+	session, err := h.service.Store(ctx, request.ToStoreParams(h.userFromContext(c), c.OriginalURL()))
+	if stacktrace.GetCode(err) == services.ErrCodeNumberPoolExhausted {
+		ctxLogger.Warn(stacktrace.Propagate(err, fmt.Sprintf("number pool is exhausted for request [%+#v]", request)))
+		return h.responseUnprocessableEntity(c, url.Values{"owner": []string{"all the owner numbers in your pool are already leased"}}, "validation errors while storing proxy session")
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot store proxy session with params [%+#v]", request)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseCreated(c, "proxy session created successfully", session)
+}
+
+// Close a proxy session
+// @Summary      Close a proxy session
+// @Description  Stop relaying messages through a proxy session
+// @Security	 ApiKeyAuth
+// @Tags         ProxySessions
+// @Accept       json
+// @Produce      json
+// @Param 		 sessionID 	path		string 							true 	"ID of the proxy session"	default(32343a19-da5e-4b1b-a767-3298a73703ca)
+// @Success      200		{object}    responses.ProxySessionResponse
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401    	{object}	responses.Unauthorized
+// @Failure 	 404    	{object}	responses.NotFound
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /proxy-sessions/{sessionID} [delete]
+func (h *ProxySessionHandler) Close(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	sessionID := c.Params("sessionID")
+	if errors := h.validator.ValidateUUID(ctx, sessionID, "sessionID"); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while closing proxy session with ID [%s]", spew.Sdump(errors), sessionID)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while closing proxy session")
+	}
+
+	session, err := h.service.Close(ctx, h.userIDFomContext(c), uuid.MustParse(sessionID))
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		return h.responseNotFound(c, fmt.Sprintf("cannot find proxy session with ID [%s]", sessionID))
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot close proxy session with ID [%s]", sessionID)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseOK(c, "proxy session closed successfully", session)
+}
+
+// Transcript returns the messages relayed through a proxy session
+// @Summary      Get the transcript of a proxy session
+// @Description  Get the messages which have been relayed between the parties of a proxy session
+// @Security	 ApiKeyAuth
+// @Tags         ProxySessions
+// @Accept       json
+// @Produce      json
+// @Param 		 sessionID 	path		string 	true 	"ID of the proxy session"	default(32343a19-da5e-4b1b-a767-3298a73703ca)
+// @Param        skip		query  int  	false	"number of messages to skip"		minimum(0)
+// @Param        limit		query  int  	false	"number of messages to return"	minimum(1)	maximum(100)
+// @Success      200 		{object}	responses.ProxySessionMessagesResponse
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401	    {object}	responses.Unauthorized
+// @Failure 	 404    	{object}	responses.NotFound
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /proxy-sessions/{sessionID}/messages 	[get]
+func (h *ProxySessionHandler) Transcript(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	var request requests.ProxySessionTranscript
+	if err := c.QueryParser(&request); err != nil {
+		msg := fmt.Sprintf("cannot marshall URL [%s] into %T", c.OriginalURL(), request)
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return h.responseBadRequest(c, err)
+	}
+
+	request.SessionID = c.Params("sessionID")
+	if errors := h.validator.ValidateTranscript(ctx, request.Sanitize()); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while fetching proxy session transcript [%+#v]", spew.Sdump(errors), request)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while fetching proxy session transcript")
+	}
+
+	messages, err := h.service.Transcript(ctx, h.userIDFomContext(c), uuid.MustParse(request.SessionID), request.ToIndexParams())
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		return h.responseNotFound(c, fmt.Sprintf("cannot find proxy session with ID [%s]", request.SessionID))
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot get transcript with params [%+#v]", request)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseOK(c, fmt.Sprintf("fetched %d %s", len(messages), h.pluralize("message", len(messages))), messages)
+}
@@ -0,0 +1,165 @@
+package handlers
+
+import (
+	"fmt"
+	"net/url"
+
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
+	"github.com/NdoleStudio/httpsms/pkg/requests"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/NdoleStudio/httpsms/pkg/validators"
+	"github.com/davecgh/go-spew/spew"
+	"github.com/gofiber/fiber/v2"
+	"github.com/palantir/stacktrace"
+)
+
+// ReferralHandler handles referral requests
+type ReferralHandler struct {
+	handler
+	logger    telemetry.Logger
+	tracer    telemetry.Tracer
+	service   *services.ReferralService
+	validator *validators.ReferralHandlerValidator
+}
+
+// NewReferralHandler creates a new ReferralHandler
+func NewReferralHandler(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	service *services.ReferralService,
+	validator *validators.ReferralHandlerValidator,
+) (h *ReferralHandler) {
+	return &ReferralHandler{
+		logger:    logger.WithService(fmt.Sprintf("%T", h)),
+		tracer:    tracer,
+		service:   service,
+		validator: validator,
+	}
+}
+
+// RegisterRoutes registers the routes for the ReferralHandler
+func (h *ReferralHandler) RegisterRoutes(app *fiber.App, middlewares ...fiber.Handler) {
+	router := app.Group("/v1/referrals")
+	router.Get("/", h.computeRoute(middlewares, h.Index)...)
+	router.Get("/stats", h.computeRoute(middlewares, h.Stats)...)
+	router.Post("/attribute", h.computeRoute(middlewares, h.Attribute)...)
+}
+
+// Index returns the referrals of a user
+// @Summary      Get referrals of a user
+// @Description  Get the users who signed up with the referral code of the user with the latest first
+// @Security	 ApiKeyAuth
+// @Tags         Referrals
+// @Accept       json
+// @Produce      json
+// @Param        skip		query  int  	false	"number of referrals to skip"		minimum(0)
+// @Param        limit		query  int  	false	"number of referrals to return"	minimum(1)	maximum(100)
+// @Success      200 		{object}	responses.ReferralsResponse
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401	    {object}	responses.Unauthorized
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /referrals 	[get]
+func (h *ReferralHandler) Index(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	var request requests.ReferralIndex
+	if err := c.QueryParser(&request); err != nil {
+		msg := fmt.Sprintf("cannot marshall URL [%s] into %T", c.OriginalURL(), request)
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return h.responseBadRequest(c, err)
+	}
+
+	if errors := h.validator.ValidateIndex(ctx, request.Sanitize()); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while fetching referrals [%+#v]", spew.Sdump(errors), request)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while fetching referrals")
+	}
+
+	referrals, err := h.service.Index(ctx, h.userIDFomContext(c), request.ToIndexParams())
+	if err != nil {
+		msg := fmt.Sprintf("cannot get referrals with params [%+#v]", request)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseOK(c, fmt.Sprintf("fetched %d %s", len(referrals), h.pluralize("referral", len(referrals))), referrals)
+}
+
+// Stats returns the referral code of a user with the referrals and the rewards earned
+// @Summary      Get referral stats
+// @Description  Get the referral code of the user, the number of users referred and the rewards earned. The code is created on the first request.
+// @Security	 ApiKeyAuth
+// @Tags         Referrals
+// @Accept       json
+// @Produce      json
+// @Success      200 		{object}	responses.ReferralStatsResponse
+// @Failure 	 401	    {object}	responses.Unauthorized
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /referrals/stats 	[get]
+func (h *ReferralHandler) Stats(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	stats, err := h.service.Stats(ctx, h.userIDFomContext(c))
+	if err != nil {
+		msg := fmt.Sprintf("cannot get referral stats of user [%s]", h.userIDFomContext(c))
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseOK(c, "fetched referral stats", stats)
+}
+
+// Attribute the authenticated user to the owner of a referral code
+// @Summary      Enter a referral code
+// @Description  Attribute a new user to the user who referred them. The code must be entered within 7 days of signing up and only once.
+// @Security	 ApiKeyAuth
+// @Tags         Referrals
+// @Accept       json
+// @Produce      json
+// @Param        payload   	body 		requests.ReferralAttribute  	true "Payload of the attribute request"
+// @Success      201 		{object}	responses.ReferralResponse
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401	    {object}	responses.Unauthorized
+// @Failure 	 404    	{object}	responses.NotFound
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /referrals/attribute [post]
+func (h *ReferralHandler) Attribute(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	var request requests.ReferralAttribute
+	if err := c.BodyParser(&request); err != nil {
+		msg := fmt.Sprintf("cannot marshall [%s] into %T", c.Body(), request)
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return h.responseBadRequest(c, err)
+	}
+
+	if errors := h.validator.ValidateAttribute(ctx, request.Sanitize()); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while attributing referral [%+#v]", spew.Sdump(errors), request)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while entering referral code")
+	}
+
+	referral, err := h.service.Attribute(ctx, h.userIDFomContext(c), request.Code)
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		return h.responseNotFound(c, fmt.Sprintf("cannot find referral code [%s]", request.Code))
+	}
+
+	if stacktrace.GetCode(err) == services.ErrCodeReferralNotAttributable {
+		ctxLogger.Warn(stacktrace.Propagate(err, fmt.Sprintf("user [%s] cannot use referral code [%s]", h.userIDFomContext(c), request.Code)))
+		return h.responseUnprocessableEntity(c, url.Values{"code": []string{"you cannot use your own code, you were already referred or you signed up more than 7 days ago"}}, "validation errors while entering referral code")
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot attribute referral with params [%+#v]", request)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseCreated(c, "referral code entered successfully", referral)
+}
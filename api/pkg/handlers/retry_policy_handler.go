@@ -0,0 +1,151 @@
+package handlers
+
+import (
+	"fmt"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/requests"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/NdoleStudio/httpsms/pkg/validators"
+	"github.com/davecgh/go-spew/spew"
+	"github.com/gofiber/fiber/v2"
+	"github.com/palantir/stacktrace"
+)
+
+// RetryPolicyHandler handles retry policy requests
+type RetryPolicyHandler struct {
+	handler
+	logger    telemetry.Logger
+	tracer    telemetry.Tracer
+	service   *services.RetryPolicyService
+	validator *validators.RetryPolicyHandlerValidator
+}
+
+// NewRetryPolicyHandler creates a new RetryPolicyHandler
+func NewRetryPolicyHandler(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	service *services.RetryPolicyService,
+	validator *validators.RetryPolicyHandlerValidator,
+) (h *RetryPolicyHandler) {
+	return &RetryPolicyHandler{
+		logger:    logger.WithService(fmt.Sprintf("%T", h)),
+		tracer:    tracer,
+		service:   service,
+		validator: validator,
+	}
+}
+
+// RegisterRoutes registers the routes for the RetryPolicyHandler
+func (h *RetryPolicyHandler) RegisterRoutes(app *fiber.App, middlewares ...fiber.Handler) {
+	router := app.Group("/v1/retry-policies")
+	router.Get("/", h.computeRoute(middlewares, h.Index)...)
+	router.Put("/:failureCode", h.computeRoute(middlewares, h.Upsert)...)
+	router.Delete("/:failureCode", h.computeRoute(middlewares, h.Delete)...)
+}
+
+// Index returns the retry policies of a user
+// @Summary      Get retry policies of a user
+// @Description  Get the retry policy of every failure code. The default policy is returned for failure codes which have not been configured.
+// @Security	 ApiKeyAuth
+// @Tags         RetryPolicies
+// @Accept       json
+// @Produce      json
+// @Success      200 		{object}	responses.RetryPoliciesResponse
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401	    {object}	responses.Unauthorized
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /retry-policies 	[get]
+func (h *RetryPolicyHandler) Index(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	policies, err := h.service.Index(ctx, h.userIDFomContext(c))
+	if err != nil {
+		msg := fmt.Sprintf("cannot get retry policies for user [%s]", h.userIDFomContext(c))
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseOK(c, fmt.Sprintf("fetched %d retry %s", len(policies), h.pluralize("policy", len(policies))), policies)
+}
+
+// Upsert the retry policy of a failure code
+// @Summary      Configure a retry policy
+// @Description  Configure how messages which failed with a failure code are retried e.g. retry no-service after 10 minutes or never retry blocked messages.
+// @Security	 ApiKeyAuth
+// @Tags         RetryPolicies
+// @Accept       json
+// @Produce      json
+// @Param 		 failureCode 	path		string 							true 	"failure code of the policy"	Enums(generic-failure, radio-off, no-service, invalid-message, quota, blocked, notification-failed, unknown)
+// @Param        payload   		body 		requests.RetryPolicyUpsert  	true 	"Payload of the retry policy"
+// @Success      200 		{object}	responses.RetryPolicyResponse
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401	    {object}	responses.Unauthorized
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /retry-policies/{failureCode} [put]
+func (h *RetryPolicyHandler) Upsert(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	var request requests.RetryPolicyUpsert
+	if err := c.BodyParser(&request); err != nil {
+		msg := fmt.Sprintf("cannot marshall body [%s] into [%T]", c.Body(), request)
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return h.responseBadRequest(c, err)
+	}
+
+	request.FailureCode = c.Params("failureCode")
+	if errors := h.validator.ValidateUpsert(ctx, request.Sanitize()); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while updating retry policy [%+#v]", spew.Sdump(errors), request)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while updating retry policy")
+	}
+
+	policy, err := h.service.Upsert(ctx, request.ToUpsertParams(h.userFromContext(c)))
+	if err != nil {
+		msg := fmt.Sprintf("cannot update retry policy with params [%+#v]", request)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseOK(c, "retry policy updated successfully", policy)
+}
+
+// Delete the retry policy of a failure code
+// @Summary      Reset a retry policy
+// @Description  Delete the retry policy configured for a failure code so that the default policy is used
+// @Security	 ApiKeyAuth
+// @Tags         RetryPolicies
+// @Accept       json
+// @Produce      json
+// @Param 		 failureCode 	path		string 							true 	"failure code of the policy"	Enums(generic-failure, radio-off, no-service, invalid-message, quota, blocked, notification-failed, unknown)
+// @Success      200 		{object}	responses.RetryPolicyResponse
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401	    {object}	responses.Unauthorized
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /retry-policies/{failureCode} [delete]
+func (h *RetryPolicyHandler) Delete(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	failureCode := c.Params("failureCode")
+	if errors := h.validator.ValidateFailureCode(ctx, failureCode); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while deleting retry policy with failure code [%s]", spew.Sdump(errors), failureCode)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while deleting retry policy")
+	}
+
+	policy, err := h.service.Delete(ctx, h.userIDFomContext(c), entities.MessageFailureCode(failureCode))
+	if err != nil {
+		msg := fmt.Sprintf("cannot delete retry policy with failure code [%s]", failureCode)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseOK(c, "retry policy reset successfully", policy)
+}
@@ -0,0 +1,220 @@
+package handlers
+
+import (
+	"fmt"
+
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
+	"github.com/NdoleStudio/httpsms/pkg/requests"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/NdoleStudio/httpsms/pkg/validators"
+	"github.com/davecgh/go-spew/spew"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/palantir/stacktrace"
+)
+
+// RuleHandler handles automation rule requests
+type RuleHandler struct {
+	handler
+	logger    telemetry.Logger
+	tracer    telemetry.Tracer
+	service   *services.RuleService
+	validator *validators.RuleHandlerValidator
+}
+
+// NewRuleHandler creates a new RuleHandler
+func NewRuleHandler(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	service *services.RuleService,
+	validator *validators.RuleHandlerValidator,
+) (h *RuleHandler) {
+	return &RuleHandler{
+		logger:    logger.WithService(fmt.Sprintf("%T", h)),
+		tracer:    tracer,
+		service:   service,
+		validator: validator,
+	}
+}
+
+// RegisterRoutes registers the routes for the RuleHandler
+func (h *RuleHandler) RegisterRoutes(app *fiber.App, middlewares ...fiber.Handler) {
+	router := app.Group("/v1/rules")
+	router.Get("/", h.computeRoute(middlewares, h.Index)...)
+	router.Post("/", h.computeRoute(middlewares, h.Store)...)
+	router.Put("/:ruleID", h.computeRoute(middlewares, h.Update)...)
+	router.Delete("/:ruleID", h.computeRoute(middlewares, h.Delete)...)
+}
+
+// Index returns the rules of a user
+// @Summary      Get rules of a user
+// @Description  Get the automation rules which send messages when they are triggered on a phone
+// @Security	 ApiKeyAuth
+// @Tags         Rules
+// @Accept       json
+// @Produce      json
+// @Param        skip		query  int  	false	"number of rules to skip"		minimum(0)
+// @Param        query		query  string  	false 	"filter rules containing query"
+// @Param        limit		query  int  	false	"number of rules to return"	minimum(1)	maximum(100)
+// @Success      200 		{object}	responses.RulesResponse
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401	    {object}	responses.Unauthorized
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /rules 	[get]
+func (h *RuleHandler) Index(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	var request requests.RuleIndex
+	if err := c.QueryParser(&request); err != nil {
+		msg := fmt.Sprintf("cannot marshall URL [%s] into %T", c.OriginalURL(), request)
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return h.responseBadRequest(c, err)
+	}
+
+	if errors := h.validator.ValidateIndex(ctx, request.Sanitize()); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while fetching rules [%+#v]", spew.Sdump(errors), request)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while fetching rules")
+	}
+
+	rules, err := h.service.Index(ctx, h.userIDFomContext(c), request.ToIndexParams())
+	if err != nil {
+		msg := fmt.Sprintf("cannot get rules with params [%+#v]", request)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseOK(c, fmt.Sprintf("fetched %d %s", len(rules), h.pluralize("rule", len(rules))), rules)
+}
+
+// Store a rule
+// @Summary      Store a rule
+// @Description  Create an automation rule on a phone e.g. a rule with type "missed-call" texts the content to callers whose calls were missed at most once per day and a rule with type "auto-reply" replies with the content to received messages which match the keyword or regex pattern at most once per contact within the cooldown.
+// @Security	 ApiKeyAuth
+// @Tags         Rules
+// @Accept       json
+// @Produce      json
+// @Param        payload   	body 		requests.RuleStore  		true "Payload of the rule request"
+// @Success      201 		{object}	responses.RuleResponse
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401	    {object}	responses.Unauthorized
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /rules [post]
+func (h *RuleHandler) Store(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	var request requests.RuleStore
+	if err := c.BodyParser(&request); err != nil {
+		msg := fmt.Sprintf("cannot marshall body [%s] into [%T]", c.Body(), request)
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return h.responseBadRequest(c, err)
+	}
+
+	if errors := h.validator.ValidateStore(ctx, h.userIDFomContext(c), request.Sanitize()); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while storing rule [%+#v]", spew.Sdump(errors), request)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while storing rule")
+	}
+
+	rule, err := h.service.Store(ctx, request.ToStoreParams(h.userFromContext(c)))
+	if err != nil {
+		msg := fmt.Sprintf("cannot store rule with params [%+#v]", request)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseCreated(c, "rule created successfully", rule)
+}
+
+// Update a rule
+// @Summary      Update a rule
+// @Description  Update the content of a rule, the pattern of an auto reply rule or enable/disable it
+// @Security	 ApiKeyAuth
+// @Tags         Rules
+// @Accept       json
+// @Produce      json
+// @Param 		 ruleID 	path		string 							true 	"ID of the rule"	default(32343a19-da5e-4b1b-a767-3298a73703ca)
+// @Param        payload   	body 		requests.RuleUpdate  			true "Payload of the rule update request"
+// @Success      200 		{object}	responses.RuleResponse
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401	    {object}	responses.Unauthorized
+// @Failure 	 404    	{object}	responses.NotFound
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /rules/{ruleID} [put]
+func (h *RuleHandler) Update(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	var request requests.RuleUpdate
+	if err := c.BodyParser(&request); err != nil {
+		msg := fmt.Sprintf("cannot marshall body [%s] into [%T]", c.Body(), request)
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return h.responseBadRequest(c, err)
+	}
+
+	request.RuleID = c.Params("ruleID")
+	if errors := h.validator.ValidateUpdate(ctx, request.Sanitize()); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while updating rule [%+#v]", spew.Sdump(errors), request)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while updating rule")
+	}
+
+	rule, err := h.service.Update(ctx, request.ToUpdateParams(h.userFromContext(c)))
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		return h.responseNotFound(c, fmt.Sprintf("cannot find rule with ID [%s]", request.RuleID))
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot update rule with params [%+#v]", request)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseOK(c, "rule updated successfully", rule)
+}
+
+// Delete a rule
+// @Summary      Delete a rule
+// @Description  Delete an automation rule of a user
+// @Security	 ApiKeyAuth
+// @Tags         Rules
+// @Accept       json
+// @Produce      json
+// @Param 		 ruleID 	path		string 							true 	"ID of the rule"	default(32343a19-da5e-4b1b-a767-3298a73703ca)
+// @Success      204		{object}    responses.NoContent
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401    	{object}	responses.Unauthorized
+// @Failure 	 404    	{object}	responses.NotFound
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /rules/{ruleID} [delete]
+func (h *RuleHandler) Delete(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	ruleID := c.Params("ruleID")
+	if errors := h.validator.ValidateUUID(ctx, ruleID, "ruleID"); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while deleting rule with ID [%s]", spew.Sdump(errors), ruleID)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while deleting rule")
+	}
+
+	err := h.service.Delete(ctx, h.userIDFomContext(c), uuid.MustParse(ruleID))
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		return h.responseNotFound(c, fmt.Sprintf("cannot find rule with ID [%s]", ruleID))
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot delete rule with ID [%s]", ruleID)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseOK(c, "rule deleted successfully", nil)
+}
@@ -0,0 +1,258 @@
+package handlers
+
+import (
+	"fmt"
+
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
+	"github.com/NdoleStudio/httpsms/pkg/requests"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/NdoleStudio/httpsms/pkg/validators"
+	"github.com/davecgh/go-spew/spew"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/palantir/stacktrace"
+)
+
+// SavedFilterHandler handles saved filter requests
+type SavedFilterHandler struct {
+	handler
+	logger    telemetry.Logger
+	tracer    telemetry.Tracer
+	service   *services.SavedFilterService
+	validator *validators.SavedFilterHandlerValidator
+}
+
+// NewSavedFilterHandler creates a new SavedFilterHandler
+func NewSavedFilterHandler(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	service *services.SavedFilterService,
+	validator *validators.SavedFilterHandlerValidator,
+) (h *SavedFilterHandler) {
+	return &SavedFilterHandler{
+		logger:    logger.WithService(fmt.Sprintf("%T", h)),
+		tracer:    tracer,
+		service:   service,
+		validator: validator,
+	}
+}
+
+// RegisterRoutes registers the routes for the SavedFilterHandler
+func (h *SavedFilterHandler) RegisterRoutes(app *fiber.App, middlewares ...fiber.Handler) {
+	router := app.Group("/v1/saved-filters")
+	router.Get("/", h.computeRoute(middlewares, h.Index)...)
+	router.Post("/", h.computeRoute(middlewares, h.Store)...)
+	router.Put("/:savedFilterID", h.computeRoute(middlewares, h.Update)...)
+	router.Delete("/:savedFilterID", h.computeRoute(middlewares, h.Delete)...)
+	router.Get("/:savedFilterID/results", h.computeRoute(middlewares, h.Execute)...)
+}
+
+// Index returns the saved filters of a user
+// @Summary      Get saved filters of a user
+// @Description  Get all the saved filters of a user ordered by position to show them as smart views in a sidebar
+// @Security	 ApiKeyAuth
+// @Tags         SavedFilters
+// @Accept       json
+// @Produce      json
+// @Success      200 		{object}	responses.SavedFiltersResponse
+// @Failure 	 401	    {object}	responses.Unauthorized
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /saved-filters 	[get]
+func (h *SavedFilterHandler) Index(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	filters, err := h.service.Index(ctx, h.userIDFomContext(c))
+	if err != nil {
+		msg := fmt.Sprintf("cannot get saved filters for user [%s]", h.userIDFomContext(c))
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseOK(c, fmt.Sprintf("fetched %d saved %s", len(filters), h.pluralize("filter", len(filters))), filters)
+}
+
+// Store a saved filter
+// @Summary      Store a saved filter
+// @Description  Save a named set of filters over the messages or the message threads of a user. The owner, query and tag filters can be used with both targets, the other filters are for messages except is_archived.
+// @Security	 ApiKeyAuth
+// @Tags         SavedFilters
+// @Accept       json
+// @Produce      json
+// @Param        payload   	body 		requests.SavedFilterStore  		true "Payload of the saved filter request"
+// @Success      201 		{object}	responses.SavedFilterResponse
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401	    {object}	responses.Unauthorized
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /saved-filters [post]
+func (h *SavedFilterHandler) Store(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	var request requests.SavedFilterStore
+	if err := c.BodyParser(&request); err != nil {
+		msg := fmt.Sprintf("cannot marshall [%s] into %T", c.Body(), request)
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return h.responseBadRequest(c, err)
+	}
+
+	if errors := h.validator.ValidateStore(ctx, request.Sanitize()); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while storing saved filter [%+#v]", spew.Sdump(errors), request)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while storing saved filter")
+	}
+
+	filter, err := h.service.Store(ctx, request.ToStoreParams(h.userIDFomContext(c)))
+	if err != nil {
+		msg := fmt.Sprintf("cannot store saved filter with params [%+#v]", request)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseCreated(c, "saved filter created successfully", filter)
+}
+
+// Update a saved filter
+// @Summary      Update a saved filter
+// @Description  Change the name, target, filters and position of a saved filter
+// @Security	 ApiKeyAuth
+// @Tags         SavedFilters
+// @Accept       json
+// @Produce      json
+// @Param 		 savedFilterID 	path		string 						true 	"ID of the saved filter"	default(32343a19-da5e-4b1b-a767-3298a73703ca)
+// @Param        payload   		body 		requests.SavedFilterStore  	true 	"Payload of the saved filter request"
+// @Success      200 			{object}	responses.SavedFilterResponse
+// @Failure      400			{object}	responses.BadRequest
+// @Failure 	 401	    	{object}	responses.Unauthorized
+// @Failure 	 404    		{object}	responses.NotFound
+// @Failure      422			{object}	responses.UnprocessableEntity
+// @Failure      500			{object}	responses.InternalServerError
+// @Router       /saved-filters/{savedFilterID} [put]
+func (h *SavedFilterHandler) Update(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	var request requests.SavedFilterUpdate
+	if err := c.BodyParser(&request); err != nil {
+		msg := fmt.Sprintf("cannot marshall [%s] into %T", c.Body(), request)
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return h.responseBadRequest(c, err)
+	}
+
+	request.SavedFilterID = c.Params("savedFilterID")
+	if errors := h.validator.ValidateUpdate(ctx, request.Sanitize()); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while updating saved filter [%+#v]", spew.Sdump(errors), request)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while updating saved filter")
+	}
+
+	filter, err := h.service.Update(ctx, request.ToUpdateParams(h.userIDFomContext(c)))
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		return h.responseNotFound(c, fmt.Sprintf("cannot find saved filter with ID [%s]", request.SavedFilterID))
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot update saved filter with params [%+#v]", request)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseOK(c, "saved filter updated successfully", filter)
+}
+
+// Delete a saved filter
+// @Summary      Delete a saved filter
+// @Description  Delete a saved filter, the messages and message threads which match it are not deleted
+// @Security	 ApiKeyAuth
+// @Tags         SavedFilters
+// @Accept       json
+// @Produce      json
+// @Param 		 savedFilterID 	path		string 		true 	"ID of the saved filter"	default(32343a19-da5e-4b1b-a767-3298a73703ca)
+// @Success      204			{object}    responses.NoContent
+// @Failure      400			{object}	responses.BadRequest
+// @Failure 	 401    		{object}	responses.Unauthorized
+// @Failure 	 404    		{object}	responses.NotFound
+// @Failure      422			{object}	responses.UnprocessableEntity
+// @Failure      500			{object}	responses.InternalServerError
+// @Router       /saved-filters/{savedFilterID} [delete]
+func (h *SavedFilterHandler) Delete(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	savedFilterID := c.Params("savedFilterID")
+	if errors := h.validator.ValidateUUID(ctx, savedFilterID, "savedFilterID"); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while deleting saved filter with ID [%s]", spew.Sdump(errors), savedFilterID)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while deleting saved filter")
+	}
+
+	err := h.service.Delete(ctx, h.userIDFomContext(c), uuid.MustParse(savedFilterID))
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		return h.responseNotFound(c, fmt.Sprintf("cannot find saved filter with ID [%s]", savedFilterID))
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot delete saved filter with ID [%s]", savedFilterID)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseNoContent(c, "saved filter deleted successfully")
+}
+
+// Execute returns a page of the items which match a saved filter
+// @Summary      Execute a saved filter
+// @Description  Get a page of the messages or message threads which currently match a saved filter. Messages are paginated with the next_cursor of the previous page and message threads with skip.
+// @Security	 ApiKeyAuth
+// @Tags         SavedFilters
+// @Accept       json
+// @Produce      json
+// @Param 		 savedFilterID 	path		string 	true 	"ID of the saved filter"				default(32343a19-da5e-4b1b-a767-3298a73703ca)
+// @Param        cursor			query  		string  false	"next_cursor of the previous page of messages"
+// @Param        skip			query  		int  	false	"number of message threads to skip"		minimum(0)
+// @Param        limit			query  		int  	false	"number of items to return"				minimum(1)	maximum(100)
+// @Success      200 			{object}	responses.SavedFilterResultResponse
+// @Failure      400			{object}	responses.BadRequest
+// @Failure 	 401	    	{object}	responses.Unauthorized
+// @Failure 	 404    		{object}	responses.NotFound
+// @Failure      422			{object}	responses.UnprocessableEntity
+// @Failure      500			{object}	responses.InternalServerError
+// @Router       /saved-filters/{savedFilterID}/results [get]
+func (h *SavedFilterHandler) Execute(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	var request requests.SavedFilterExecute
+	if err := c.QueryParser(&request); err != nil {
+		msg := fmt.Sprintf("cannot marshall params [%s] into %T", c.OriginalURL(), request)
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return h.responseBadRequest(c, err)
+	}
+
+	request.SavedFilterID = c.Params("savedFilterID")
+	if errors := h.validator.ValidateExecute(ctx, request.Sanitize()); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while executing saved filter [%+#v]", spew.Sdump(errors), request)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while executing saved filter")
+	}
+
+	result, err := h.service.Execute(ctx, request.ToExecuteParams(h.userIDFomContext(c)))
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		return h.responseNotFound(c, fmt.Sprintf("cannot find saved filter with ID [%s]", request.SavedFilterID))
+	}
+
+	if stacktrace.GetCode(err) == repositories.ErrCodeContentEncrypted {
+		return h.responseContentEncrypted(c)
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot execute saved filter with params [%+#v]", request)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	count := len(result.Messages) + len(result.MessageThreads)
+	return h.responseOK(c, fmt.Sprintf("fetched %d %s matching the saved filter", count, h.pluralize("item", count)), result)
+}
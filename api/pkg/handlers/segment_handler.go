@@ -0,0 +1,303 @@
+package handlers
+
+import (
+	"fmt"
+
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
+	"github.com/NdoleStudio/httpsms/pkg/requests"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/NdoleStudio/httpsms/pkg/validators"
+	"github.com/davecgh/go-spew/spew"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/palantir/stacktrace"
+)
+
+// SegmentHandler handles segment requests
+type SegmentHandler struct {
+	handler
+	logger    telemetry.Logger
+	tracer    telemetry.Tracer
+	service   *services.SegmentService
+	validator *validators.SegmentHandlerValidator
+}
+
+// NewSegmentHandler creates a new SegmentHandler
+func NewSegmentHandler(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	service *services.SegmentService,
+	validator *validators.SegmentHandlerValidator,
+) (h *SegmentHandler) {
+	return &SegmentHandler{
+		logger:    logger.WithService(fmt.Sprintf("%T", h)),
+		tracer:    tracer,
+		service:   service,
+		validator: validator,
+	}
+}
+
+// RegisterRoutes registers the routes for the SegmentHandler
+func (h *SegmentHandler) RegisterRoutes(app *fiber.App, middlewares ...fiber.Handler) {
+	router := app.Group("/v1/segments")
+	router.Get("/", h.computeRoute(middlewares, h.Index)...)
+	router.Post("/", h.computeRoute(middlewares, h.Store)...)
+	router.Post("/preview", h.computeRoute(middlewares, h.Preview)...)
+	router.Put("/:segmentID", h.computeRoute(middlewares, h.Update)...)
+	router.Delete("/:segmentID", h.computeRoute(middlewares, h.Delete)...)
+	router.Get("/:segmentID/count", h.computeRoute(middlewares, h.Count)...)
+}
+
+// Index returns the segments of a user
+// @Summary      Get segments of a user
+// @Description  Get the saved segments which are resolved into a list of contacts when they are used
+// @Security	 ApiKeyAuth
+// @Tags         Segments
+// @Accept       json
+// @Produce      json
+// @Param        skip		query  int  	false	"number of segments to skip"		minimum(0)
+// @Param        query		query  string  	false 	"filter segments containing query"
+// @Param        limit		query  int  	false	"number of segments to return"	minimum(1)	maximum(100)
+// @Success      200 		{object}	responses.SegmentsResponse
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401	    {object}	responses.Unauthorized
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /segments 	[get]
+func (h *SegmentHandler) Index(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	var request requests.SegmentIndex
+	if err := c.QueryParser(&request); err != nil {
+		msg := fmt.Sprintf("cannot marshall URL [%s] into %T", c.OriginalURL(), request)
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return h.responseBadRequest(c, err)
+	}
+
+	if errors := h.validator.ValidateIndex(ctx, request.Sanitize()); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while fetching segments [%+#v]", spew.Sdump(errors), request)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while fetching segments")
+	}
+
+	segments, err := h.service.Index(ctx, h.userIDFomContext(c), request.ToIndexParams())
+	if err != nil {
+		msg := fmt.Sprintf("cannot get segments with params [%+#v]", request)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseOK(c, fmt.Sprintf("fetched %d %s", len(segments), h.pluralize("segment", len(segments))), segments)
+}
+
+// Store a segment
+// @Summary      Store a segment
+// @Description  Save a list of filters over the tags, attributes, engagement metrics and opt-out status of contacts. All the filters must match for a contact to be part of the segment.
+// @Security	 ApiKeyAuth
+// @Tags         Segments
+// @Accept       json
+// @Produce      json
+// @Param        payload   	body 		requests.SegmentStore  		true "Payload of the segment request"
+// @Success      201 		{object}	responses.SegmentResponse
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401	    {object}	responses.Unauthorized
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /segments [post]
+func (h *SegmentHandler) Store(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	var request requests.SegmentStore
+	if err := c.BodyParser(&request); err != nil {
+		msg := fmt.Sprintf("cannot marshall [%s] into %T", c.Body(), request)
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return h.responseBadRequest(c, err)
+	}
+
+	if errors := h.validator.ValidateStore(ctx, request.Sanitize()); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while storing segment [%+#v]", spew.Sdump(errors), request)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while storing segment")
+	}
+
+	segment, err := h.service.Store(ctx, request.ToStoreParams(h.userIDFomContext(c)))
+	if err != nil {
+		msg := fmt.Sprintf("cannot store segment with params [%+#v]", request)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseCreated(c, "segment created successfully", segment)
+}
+
+// Update a segment
+// @Summary      Update a segment
+// @Description  Change the name and the filters of a segment
+// @Security	 ApiKeyAuth
+// @Tags         Segments
+// @Accept       json
+// @Produce      json
+// @Param 		 segmentID 	path		string 					true 	"ID of the segment"	default(32343a19-da5e-4b1b-a767-3298a73703ca)
+// @Param        payload   	body 		requests.SegmentStore  	true 	"Payload of the segment request"
+// @Success      200 		{object}	responses.SegmentResponse
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401	    {object}	responses.Unauthorized
+// @Failure 	 404    	{object}	responses.NotFound
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /segments/{segmentID} [put]
+func (h *SegmentHandler) Update(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	var request requests.SegmentUpdate
+	if err := c.BodyParser(&request); err != nil {
+		msg := fmt.Sprintf("cannot marshall [%s] into %T", c.Body(), request)
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return h.responseBadRequest(c, err)
+	}
+
+	request.SegmentID = c.Params("segmentID")
+	if errors := h.validator.ValidateUpdate(ctx, request.Sanitize()); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while updating segment [%+#v]", spew.Sdump(errors), request)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while updating segment")
+	}
+
+	segment, err := h.service.Update(ctx, request.ToUpdateParams(h.userIDFomContext(c)))
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		return h.responseNotFound(c, fmt.Sprintf("cannot find segment with ID [%s]", request.SegmentID))
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot update segment with params [%+#v]", request)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseOK(c, "segment updated successfully", segment)
+}
+
+// Delete a segment
+// @Summary      Delete a segment
+// @Description  Delete a saved segment, the contacts in the segment are not deleted
+// @Security	 ApiKeyAuth
+// @Tags         Segments
+// @Accept       json
+// @Produce      json
+// @Param 		 segmentID 	path		string 							true 	"ID of the segment"	default(32343a19-da5e-4b1b-a767-3298a73703ca)
+// @Success      204		{object}    responses.NoContent
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401    	{object}	responses.Unauthorized
+// @Failure 	 404    	{object}	responses.NotFound
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /segments/{segmentID} [delete]
+func (h *SegmentHandler) Delete(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	segmentID := c.Params("segmentID")
+	if errors := h.validator.ValidateUUID(ctx, segmentID, "segmentID"); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while deleting segment with ID [%s]", spew.Sdump(errors), segmentID)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while deleting segment")
+	}
+
+	err := h.service.Delete(ctx, h.userIDFomContext(c), uuid.MustParse(segmentID))
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		return h.responseNotFound(c, fmt.Sprintf("cannot find segment with ID [%s]", segmentID))
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot delete segment with ID [%s]", segmentID)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseNoContent(c, "segment deleted successfully")
+}
+
+// Count returns the number of contacts in a segment
+// @Summary      Count the contacts in a segment
+// @Description  Get the number of contacts which currently match the filters of a segment
+// @Security	 ApiKeyAuth
+// @Tags         Segments
+// @Accept       json
+// @Produce      json
+// @Param 		 segmentID 	path		string 	true 	"ID of the segment"	default(32343a19-da5e-4b1b-a767-3298a73703ca)
+// @Success      200 		{object}	responses.SegmentCountResponse
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401	    {object}	responses.Unauthorized
+// @Failure 	 404    	{object}	responses.NotFound
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /segments/{segmentID}/count 	[get]
+func (h *SegmentHandler) Count(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	segmentID := c.Params("segmentID")
+	if errors := h.validator.ValidateUUID(ctx, segmentID, "segmentID"); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while counting segment with ID [%s]", spew.Sdump(errors), segmentID)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while counting segment")
+	}
+
+	count, err := h.service.CountSegment(ctx, h.userIDFomContext(c), uuid.MustParse(segmentID))
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		return h.responseNotFound(c, fmt.Sprintf("cannot find segment with ID [%s]", segmentID))
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot count contacts in segment with ID [%s]", segmentID)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseOK(c, fmt.Sprintf("segment has %d %s", count.Contacts, h.pluralize("contact", int(count.Contacts))), count)
+}
+
+// Preview returns the number of contacts which match a list of filters
+// @Summary      Preview the size of a segment
+// @Description  Get the number of contacts which match a list of filters before saving them in a segment
+// @Security	 ApiKeyAuth
+// @Tags         Segments
+// @Accept       json
+// @Produce      json
+// @Param        payload   	body 		requests.SegmentPreview  	true "Filters of the segment"
+// @Success      200 		{object}	responses.SegmentCountResponse
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401	    {object}	responses.Unauthorized
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /segments/preview [post]
+func (h *SegmentHandler) Preview(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	var request requests.SegmentPreview
+	if err := c.BodyParser(&request); err != nil {
+		msg := fmt.Sprintf("cannot marshall [%s] into %T", c.Body(), request)
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return h.responseBadRequest(c, err)
+	}
+
+	if errors := h.validator.ValidatePreview(ctx, request.Sanitize()); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while previewing segment [%+#v]", spew.Sdump(errors), request)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while previewing segment")
+	}
+
+	count, err := h.service.Count(ctx, h.userIDFomContext(c), request.Filters)
+	if err != nil {
+		msg := fmt.Sprintf("cannot count contacts with params [%+#v]", request)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseOK(c, fmt.Sprintf("segment has %d %s", count.Contacts, h.pluralize("contact", int(count.Contacts))), count)
+}
@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"fmt"
+
+	"github.com/NdoleStudio/httpsms/pkg/services"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/gofiber/fiber/v2"
+	"github.com/palantir/stacktrace"
+)
+
+// StatusHandler handles public status requests
+type StatusHandler struct {
+	handler
+	logger  telemetry.Logger
+	tracer  telemetry.Tracer
+	service *services.StatusService
+}
+
+// NewStatusHandler creates a new StatusHandler
+func NewStatusHandler(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	service *services.StatusService,
+) (h *StatusHandler) {
+	return &StatusHandler{
+		logger:  logger.WithService(fmt.Sprintf("%T", h)),
+		tracer:  tracer,
+		service: service,
+	}
+}
+
+// RegisterRoutes registers the routes for the StatusHandler
+func (h *StatusHandler) RegisterRoutes(app *fiber.App, middlewares ...fiber.Handler) {
+	router := app.Group("/v1/status")
+	router.Get("/", h.computeRoute(middlewares, h.Show)...)
+}
+
+// Show returns the health of the instance
+// @Summary      Get the status of the instance
+// @Description  Get the anonymized health of the API, the message queue and the delivery latency per region to power a public status page
+// @Tags         Status
+// @Accept       json
+// @Produce      json
+// @Success      200 		{object}	responses.InstanceStatusResponse
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /status 	[get]
+func (h *StatusHandler) Show(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	status, err := h.service.Status(ctx)
+	if err != nil {
+		ctxLogger.Error(stacktrace.Propagate(err, "cannot get the status of the instance"))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseOK(c, fmt.Sprintf("instance is %s", status.Status), status)
+}
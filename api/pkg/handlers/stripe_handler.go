@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/NdoleStudio/httpsms/pkg/services"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/NdoleStudio/httpsms/pkg/validators"
+	"github.com/davecgh/go-spew/spew"
+	"github.com/gofiber/fiber/v2"
+	"github.com/palantir/stacktrace"
+)
+
+// StripeHandler handles stripe events
+type StripeHandler struct {
+	handler
+	logger    telemetry.Logger
+	tracer    telemetry.Tracer
+	service   *services.StripeService
+	validator *validators.StripeHandlerValidator
+}
+
+// NewStripeHandler creates a new StripeHandler
+func NewStripeHandler(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	service *services.StripeService,
+	validator *validators.StripeHandlerValidator,
+) (h *StripeHandler) {
+	return &StripeHandler{
+		logger:    logger.WithService(fmt.Sprintf("%T", h)),
+		tracer:    tracer,
+		service:   service,
+		validator: validator,
+	}
+}
+
+// RegisterRoutes registers the routes for the StripeHandler
+func (h *StripeHandler) RegisterRoutes(app *fiber.App, middlewares ...fiber.Handler) {
+	router := app.Group("stripe")
+	router.Post("/event", h.computeRoute(middlewares, h.Event)...)
+}
+
+// Event consumes a stripe event
+// @Summary      Consume a stripe event
+// @Description  Publish a stripe event to the registered listeners
+// @Tags         Stripe
+// @Accept       json
+// @Produce      json
+// @Success      204 		{object}	responses.NoContent
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401    	{object}	responses.Unauthorized
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /stripe/event [post]
+func (h *StripeHandler) Event(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	signature := c.Get("Stripe-Signature")
+	if errors := h.validator.ValidateEvent(ctx, signature, c.Body()); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while storing request [%s] and signature [%s]", spew.Sdump(errors), c.Body(), signature)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while storing stripe event")
+	}
+
+	var request services.StripeEvent
+	if err := json.Unmarshal(c.Body(), &request); err != nil {
+		msg := fmt.Sprintf("cannot marshall [%s] to [%T]", c.Body(), request)
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return h.responseBadRequest(c, err)
+	}
+
+	if err := h.service.HandleEvent(ctx, c.OriginalURL(), &request); err != nil {
+		msg := fmt.Sprintf("cannot handle stripe event [%s]", c.Body())
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseNoContent(c, "event consumed successfully")
+}
@@ -0,0 +1,266 @@
+package handlers
+
+import (
+	"fmt"
+
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
+	"github.com/NdoleStudio/httpsms/pkg/requests"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/NdoleStudio/httpsms/pkg/validators"
+	"github.com/davecgh/go-spew/spew"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/palantir/stacktrace"
+)
+
+// SubscriptionHandler handles subscription requests
+type SubscriptionHandler struct {
+	handler
+	logger    telemetry.Logger
+	tracer    telemetry.Tracer
+	service   *services.SubscriptionService
+	validator *validators.SubscriptionHandlerValidator
+}
+
+// NewSubscriptionHandler creates a new SubscriptionHandler
+func NewSubscriptionHandler(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	service *services.SubscriptionService,
+	validator *validators.SubscriptionHandlerValidator,
+) (h *SubscriptionHandler) {
+	return &SubscriptionHandler{
+		logger:    logger.WithService(fmt.Sprintf("%T", h)),
+		tracer:    tracer,
+		service:   service,
+		validator: validator,
+	}
+}
+
+// RegisterRoutes registers the routes for the SubscriptionHandler
+func (h *SubscriptionHandler) RegisterRoutes(app *fiber.App, middlewares ...fiber.Handler) {
+	router := app.Group("/v1/subscriptions")
+	router.Get("/", h.computeRoute(middlewares, h.Index)...)
+	router.Get("/keywords", h.computeRoute(middlewares, h.KeywordIndex)...)
+	router.Post("/keywords", h.computeRoute(middlewares, h.KeywordStore)...)
+	router.Put("/keywords/:keywordID", h.computeRoute(middlewares, h.KeywordUpdate)...)
+	router.Delete("/keywords/:keywordID", h.computeRoute(middlewares, h.KeywordDelete)...)
+}
+
+// Index returns the subscriptions of a user
+// @Summary      Get subscriptions of a user
+// @Description  Get the contacts which sent a subscription keyword with the state of their double opt-in
+// @Security	 ApiKeyAuth
+// @Tags         Subscriptions
+// @Accept       json
+// @Produce      json
+// @Param        skip		query  int  	false	"number of subscriptions to skip"		minimum(0)
+// @Param        query		query  string  	false 	"filter subscriptions containing query"
+// @Param        keyword_id	query  string  	false 	"filter subscriptions by keyword ID"
+// @Param        status		query  string  	false 	"filter subscriptions by status"	Enums(pending,confirmed)
+// @Param        limit		query  int  	false	"number of subscriptions to return"	minimum(1)	maximum(100)
+// @Success      200 		{object}	responses.SubscriptionsResponse
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401	    {object}	responses.Unauthorized
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /subscriptions 	[get]
+func (h *SubscriptionHandler) Index(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	var request requests.SubscriptionIndex
+	if err := c.QueryParser(&request); err != nil {
+		msg := fmt.Sprintf("cannot marshall URL [%s] into %T", c.OriginalURL(), request)
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return h.responseBadRequest(c, err)
+	}
+
+	if errors := h.validator.ValidateIndex(ctx, request.Sanitize()); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while fetching subscriptions [%+#v]", spew.Sdump(errors), request)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while fetching subscriptions")
+	}
+
+	subscriptions, err := h.service.Index(ctx, h.userIDFomContext(c), request.ToIndexParams())
+	if err != nil {
+		msg := fmt.Sprintf("cannot get subscriptions with params [%+#v]", request)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseOK(c, fmt.Sprintf("fetched %d %s", len(subscriptions), h.pluralize("subscription", len(subscriptions))), subscriptions)
+}
+
+// KeywordIndex returns the subscription keywords of a user
+// @Summary      Get subscription keywords of a user
+// @Description  Get the keywords which contacts text to an owner phone to subscribe to a list
+// @Security	 ApiKeyAuth
+// @Tags         Subscriptions
+// @Accept       json
+// @Produce      json
+// @Param        skip		query  int  	false	"number of keywords to skip"		minimum(0)
+// @Param        query		query  string  	false 	"filter keywords containing query"
+// @Param        limit		query  int  	false	"number of keywords to return"	minimum(1)	maximum(100)
+// @Success      200 		{object}	responses.SubscriptionKeywordsResponse
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401	    {object}	responses.Unauthorized
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /subscriptions/keywords 	[get]
+func (h *SubscriptionHandler) KeywordIndex(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	var request requests.SubscriptionKeywordIndex
+	if err := c.QueryParser(&request); err != nil {
+		msg := fmt.Sprintf("cannot marshall URL [%s] into %T", c.OriginalURL(), request)
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return h.responseBadRequest(c, err)
+	}
+
+	if errors := h.validator.ValidateKeywordIndex(ctx, request.Sanitize()); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while fetching subscription keywords [%+#v]", spew.Sdump(errors), request)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while fetching subscription keywords")
+	}
+
+	keywords, err := h.service.IndexKeywords(ctx, h.userIDFomContext(c), request.ToIndexParams())
+	if err != nil {
+		msg := fmt.Sprintf("cannot get subscription keywords with params [%+#v]", request)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseOK(c, fmt.Sprintf("fetched %d %s", len(keywords), h.pluralize("keyword", len(keywords))), keywords)
+}
+
+// KeywordStore creates a subscription keyword
+// @Summary      Store a subscription keyword
+// @Description  Create a keyword which subscribes contacts to a list with a double opt-in. When a contact texts the keyword to the owner phone, the confirmation template is sent and the contact is added to the list only after replying YES.
+// @Security	 ApiKeyAuth
+// @Tags         Subscriptions
+// @Accept       json
+// @Produce      json
+// @Param        payload   	body 		requests.SubscriptionKeywordStore  	true "Payload of the subscription keyword request"
+// @Success      201 		{object}	responses.SubscriptionKeywordResponse
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401	    {object}	responses.Unauthorized
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /subscriptions/keywords [post]
+func (h *SubscriptionHandler) KeywordStore(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	var request requests.SubscriptionKeywordStore
+	if err := c.BodyParser(&request); err != nil {
+		msg := fmt.Sprintf("cannot marshall body [%s] into [%T]", c.Body(), request)
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return h.responseBadRequest(c, err)
+	}
+
+	if errors := h.validator.ValidateKeywordStore(ctx, h.userIDFomContext(c), request.Sanitize()); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while storing subscription keyword [%+#v]", spew.Sdump(errors), request)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while storing subscription keyword")
+	}
+
+	keyword, err := h.service.StoreKeyword(ctx, request.ToStoreParams(h.userFromContext(c)))
+	if err != nil {
+		msg := fmt.Sprintf("cannot store subscription keyword with params [%+#v]", request)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseCreated(c, "subscription keyword created successfully", keyword)
+}
+
+// KeywordUpdate updates a subscription keyword
+// @Summary      Update a subscription keyword
+// @Description  Update the list and templates of a subscription keyword or enable/disable it
+// @Security	 ApiKeyAuth
+// @Tags         Subscriptions
+// @Accept       json
+// @Produce      json
+// @Param 		 keywordID 	path		string 								true 	"ID of the keyword"	default(32343a19-da5e-4b1b-a767-3298a73703ca)
+// @Param        payload   	body 		requests.SubscriptionKeywordUpdate  true 	"Payload of the subscription keyword update request"
+// @Success      200 		{object}	responses.SubscriptionKeywordResponse
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401	    {object}	responses.Unauthorized
+// @Failure 	 404    	{object}	responses.NotFound
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /subscriptions/keywords/{keywordID} [put]
+func (h *SubscriptionHandler) KeywordUpdate(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	var request requests.SubscriptionKeywordUpdate
+	if err := c.BodyParser(&request); err != nil {
+		msg := fmt.Sprintf("cannot marshall body [%s] into [%T]", c.Body(), request)
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return h.responseBadRequest(c, err)
+	}
+
+	request.KeywordID = c.Params("keywordID")
+	if errors := h.validator.ValidateKeywordUpdate(ctx, h.userIDFomContext(c), request.Sanitize()); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while updating subscription keyword [%+#v]", spew.Sdump(errors), request)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while updating subscription keyword")
+	}
+
+	keyword, err := h.service.UpdateKeyword(ctx, request.ToUpdateParams(h.userFromContext(c)))
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		return h.responseNotFound(c, fmt.Sprintf("cannot find subscription keyword with ID [%s]", request.KeywordID))
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot update subscription keyword with params [%+#v]", request)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseOK(c, "subscription keyword updated successfully", keyword)
+}
+
+// KeywordDelete deletes a subscription keyword
+// @Summary      Delete a subscription keyword
+// @Description  Delete a subscription keyword and the subscriptions which were started with it. The tags of contacts which confirmed the subscription are not removed.
+// @Security	 ApiKeyAuth
+// @Tags         Subscriptions
+// @Accept       json
+// @Produce      json
+// @Param 		 keywordID 	path		string 	true 	"ID of the keyword"	default(32343a19-da5e-4b1b-a767-3298a73703ca)
+// @Success      204		{object}    responses.NoContent
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401    	{object}	responses.Unauthorized
+// @Failure 	 404    	{object}	responses.NotFound
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /subscriptions/keywords/{keywordID} [delete]
+func (h *SubscriptionHandler) KeywordDelete(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	keywordID := c.Params("keywordID")
+	if errors := h.validator.ValidateUUID(ctx, keywordID, "keywordID"); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while deleting subscription keyword with ID [%s]", spew.Sdump(errors), keywordID)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while deleting subscription keyword")
+	}
+
+	err := h.service.DeleteKeyword(ctx, h.userIDFomContext(c), uuid.MustParse(keywordID))
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		return h.responseNotFound(c, fmt.Sprintf("cannot find subscription keyword with ID [%s]", keywordID))
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot delete subscription keyword with ID [%s]", keywordID)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseNoContent(c, "subscription keyword deleted successfully")
+}
@@ -0,0 +1,251 @@
+package handlers
+
+import (
+	"fmt"
+	"net/url"
+
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
+	"github.com/NdoleStudio/httpsms/pkg/requests"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/NdoleStudio/httpsms/pkg/validators"
+	"github.com/davecgh/go-spew/spew"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/palantir/stacktrace"
+)
+
+// TagHandler handles tag requests
+type TagHandler struct {
+	handler
+	logger    telemetry.Logger
+	tracer    telemetry.Tracer
+	service   *services.TagService
+	validator *validators.TagHandlerValidator
+}
+
+// NewTagHandler creates a new TagHandler
+func NewTagHandler(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	service *services.TagService,
+	validator *validators.TagHandlerValidator,
+) (h *TagHandler) {
+	return &TagHandler{
+		logger:    logger.WithService(fmt.Sprintf("%T", h)),
+		tracer:    tracer,
+		service:   service,
+		validator: validator,
+	}
+}
+
+// RegisterRoutes registers the routes for the TagHandler
+func (h *TagHandler) RegisterRoutes(app *fiber.App, middlewares ...fiber.Handler) {
+	router := app.Group("/v1/tags")
+	router.Get("/", h.computeRoute(middlewares, h.Index)...)
+	router.Post("/", h.computeRoute(middlewares, h.Store)...)
+	router.Get("/usage", h.computeRoute(middlewares, h.Usage)...)
+	router.Put("/:tagID", h.computeRoute(middlewares, h.Update)...)
+	router.Delete("/:tagID", h.computeRoute(middlewares, h.Delete)...)
+}
+
+// Index returns the tags of a user
+// @Summary      Get tags of a user
+// @Description  Get all the tags of a user ordered by name. The parent_id of a tag is used to build the hierarchy of tags.
+// @Security	 ApiKeyAuth
+// @Tags         Tags
+// @Accept       json
+// @Produce      json
+// @Success      200 		{object}	responses.TagsResponse
+// @Failure 	 401	    {object}	responses.Unauthorized
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /tags 		[get]
+func (h *TagHandler) Index(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	tags, err := h.service.Index(ctx, h.userIDFomContext(c))
+	if err != nil {
+		msg := fmt.Sprintf("cannot get tags for user [%s]", h.userIDFomContext(c))
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseOK(c, fmt.Sprintf("fetched %d %s", len(tags), h.pluralize("tag", len(tags))), tags)
+}
+
+// Store a tag
+// @Summary      Store a tag
+// @Description  Create a tag with a color and an optional parent tag. Filtering contacts, messages or message threads by a tag also matches the items with the descendants of the tag.
+// @Security	 ApiKeyAuth
+// @Tags         Tags
+// @Accept       json
+// @Produce      json
+// @Param        payload   	body 		requests.TagStore  		true "Payload of the tag request"
+// @Success      201 		{object}	responses.TagResponse
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401	    {object}	responses.Unauthorized
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /tags [post]
+func (h *TagHandler) Store(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	var request requests.TagStore
+	if err := c.BodyParser(&request); err != nil {
+		msg := fmt.Sprintf("cannot marshall [%s] into %T", c.Body(), request)
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return h.responseBadRequest(c, err)
+	}
+
+	if errors := h.validator.ValidateStore(ctx, request.Sanitize()); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while storing tag [%+#v]", spew.Sdump(errors), request)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while storing tag")
+	}
+
+	tag, err := h.service.Store(ctx, request.ToStoreParams(h.userIDFomContext(c)))
+	if errors := h.serviceErrors(err); len(errors) != 0 {
+		ctxLogger.Warn(stacktrace.Propagate(err, fmt.Sprintf("cannot store tag with params [%+#v]", request)))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while storing tag")
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot store tag with params [%+#v]", request)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseCreated(c, "tag created successfully", tag)
+}
+
+// Update a tag
+// @Summary      Update a tag
+// @Description  Change the name, color and parent of a tag. The new name is set on the contacts, messages and message threads which have the tag.
+// @Security	 ApiKeyAuth
+// @Tags         Tags
+// @Accept       json
+// @Produce      json
+// @Param 		 tagID 		path		string 					true 	"ID of the tag"	default(32343a19-da5e-4b1b-a767-3298a73703ca)
+// @Param        payload   	body 		requests.TagStore  		true 	"Payload of the tag request"
+// @Success      200 		{object}	responses.TagResponse
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401	    {object}	responses.Unauthorized
+// @Failure 	 404    	{object}	responses.NotFound
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /tags/{tagID} [put]
+func (h *TagHandler) Update(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	var request requests.TagUpdate
+	if err := c.BodyParser(&request); err != nil {
+		msg := fmt.Sprintf("cannot marshall [%s] into %T", c.Body(), request)
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return h.responseBadRequest(c, err)
+	}
+
+	request.TagID = c.Params("tagID")
+	if errors := h.validator.ValidateUpdate(ctx, request.Sanitize()); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while updating tag [%+#v]", spew.Sdump(errors), request)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while updating tag")
+	}
+
+	tag, err := h.service.Update(ctx, request.ToUpdateParams(h.userIDFomContext(c)))
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		return h.responseNotFound(c, fmt.Sprintf("cannot find tag with ID [%s]", request.TagID))
+	}
+
+	if errors := h.serviceErrors(err); len(errors) != 0 {
+		ctxLogger.Warn(stacktrace.Propagate(err, fmt.Sprintf("cannot update tag with params [%+#v]", request)))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while updating tag")
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot update tag with params [%+#v]", request)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseOK(c, "tag updated successfully", tag)
+}
+
+// Delete a tag
+// @Summary      Delete a tag
+// @Description  Delete a tag and remove it from the contacts, messages and message threads which have it. The children of the tag are moved to the parent of the tag.
+// @Security	 ApiKeyAuth
+// @Tags         Tags
+// @Accept       json
+// @Produce      json
+// @Param 		 tagID 		path		string 		true 	"ID of the tag"	default(32343a19-da5e-4b1b-a767-3298a73703ca)
+// @Success      204		{object}    responses.NoContent
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401    	{object}	responses.Unauthorized
+// @Failure 	 404    	{object}	responses.NotFound
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /tags/{tagID} [delete]
+func (h *TagHandler) Delete(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	tagID := c.Params("tagID")
+	if errors := h.validator.ValidateUUID(ctx, tagID, "tagID"); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while deleting tag with ID [%s]", spew.Sdump(errors), tagID)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while deleting tag")
+	}
+
+	err := h.service.Delete(ctx, h.userIDFomContext(c), uuid.MustParse(tagID))
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		return h.responseNotFound(c, fmt.Sprintf("cannot find tag with ID [%s]", tagID))
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot delete tag with ID [%s]", tagID)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseNoContent(c, "tag deleted successfully")
+}
+
+// Usage returns the number of items with each tag of a user
+// @Summary      Get the usage of tags
+// @Description  Get the number of contacts, messages and message threads which have each tag of a user. The items of the descendants of a tag are not included in its counts.
+// @Security	 ApiKeyAuth
+// @Tags         Tags
+// @Accept       json
+// @Produce      json
+// @Success      200 		{object}	responses.TagUsageResponse
+// @Failure 	 401	    {object}	responses.Unauthorized
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /tags/usage 	[get]
+func (h *TagHandler) Usage(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	usage, err := h.service.Usage(ctx, h.userIDFomContext(c))
+	if err != nil {
+		msg := fmt.Sprintf("cannot get the usage of the tags of user [%s]", h.userIDFomContext(c))
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseOK(c, fmt.Sprintf("fetched the usage of %d %s", len(usage), h.pluralize("tag", len(usage))), usage)
+}
+
+// serviceErrors converts the errors of the services.TagService which are caused by the request into validation errors
+func (h *TagHandler) serviceErrors(err error) url.Values {
+	switch stacktrace.GetCode(err) {
+	case services.ErrCodeTagExists:
+		return url.Values{"name": []string{"a tag with this name already exists"}}
+	case services.ErrCodeTagParentInvalid:
+		return url.Values{"parent_id": []string{"the parent must be an existing tag which is not the tag itself or one of its descendants"}}
+	default:
+		return url.Values{}
+	}
+}
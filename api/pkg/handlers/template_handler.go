@@ -0,0 +1,565 @@
+package handlers
+
+import (
+	"fmt"
+	"net/url"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
+	"github.com/NdoleStudio/httpsms/pkg/requests"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/NdoleStudio/httpsms/pkg/validators"
+	"github.com/davecgh/go-spew/spew"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/palantir/stacktrace"
+)
+
+// TemplateHandler handles template requests
+type TemplateHandler struct {
+	handler
+	logger          telemetry.Logger
+	tracer          telemetry.Tracer
+	service         *services.TemplateService
+	operatorService *services.OperatorService
+	statService     *services.TemplateStatService
+	validator       *validators.TemplateHandlerValidator
+}
+
+// NewTemplateHandler creates a new TemplateHandler
+func NewTemplateHandler(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	service *services.TemplateService,
+	operatorService *services.OperatorService,
+	statService *services.TemplateStatService,
+	validator *validators.TemplateHandlerValidator,
+) (h *TemplateHandler) {
+	return &TemplateHandler{
+		logger:          logger.WithService(fmt.Sprintf("%T", h)),
+		tracer:          tracer,
+		service:         service,
+		operatorService: operatorService,
+		statService:     statService,
+		validator:       validator,
+	}
+}
+
+// RegisterRoutes registers the routes for the TemplateHandler
+func (h *TemplateHandler) RegisterRoutes(app *fiber.App, middlewares ...fiber.Handler) {
+	router := app.Group("/v1/templates")
+	router.Get("/", h.computeRoute(middlewares, h.Index)...)
+	router.Post("/", h.computeRoute(middlewares, h.Store)...)
+	router.Get("/:templateID", h.computeRoute(middlewares, h.Show)...)
+	router.Put("/:templateID", h.computeRoute(middlewares, h.Update)...)
+	router.Delete("/:templateID", h.computeRoute(middlewares, h.Delete)...)
+	router.Post("/:templateID/submit", h.computeRoute(middlewares, h.Submit)...)
+	router.Get("/:templateID/reviews", h.computeRoute(middlewares, h.Reviews)...)
+	router.Get("/:templateID/stats", h.computeRoute(middlewares, h.Stats)...)
+
+	operator := app.Group("/v1/operator")
+	operator.Get("/templates", h.computeRoute(middlewares, h.IndexSubmitted)...)
+	operator.Post("/templates/:templateID/review", h.computeRoute(middlewares, h.Review)...)
+	operator.Put("/users/:userID/template-approval", h.computeRoute(middlewares, h.TemplateApproval)...)
+}
+
+// Index returns the templates of a user
+// @Summary      Get templates of a user
+// @Description  Get the message templates of a user including their review status
+// @Security	 ApiKeyAuth
+// @Tags         Templates
+// @Accept       json
+// @Produce      json
+// @Param        skip		query  int  	false	"number of templates to skip"		minimum(0)
+// @Param        query		query  string  	false 	"filter templates containing query"
+// @Param        limit		query  int  	false	"number of templates to return"	minimum(1)	maximum(100)
+// @Success      200 		{object}	responses.TemplatesResponse
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401	    {object}	responses.Unauthorized
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /templates 	[get]
+func (h *TemplateHandler) Index(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	var request requests.TemplateIndex
+	if err := c.QueryParser(&request); err != nil {
+		msg := fmt.Sprintf("cannot marshall URL [%s] into %T", c.OriginalURL(), request)
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return h.responseBadRequest(c, err)
+	}
+
+	if errors := h.validator.ValidateIndex(ctx, request.Sanitize()); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while fetching templates [%+#v]", spew.Sdump(errors), request)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while fetching templates")
+	}
+
+	templates, err := h.service.Index(ctx, h.userIDFomContext(c), request.ToIndexParams())
+	if err != nil {
+		msg := fmt.Sprintf("cannot get templates with params [%+#v]", request)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseOK(c, fmt.Sprintf("fetched %d %s", len(templates), h.pluralize("template", len(templates))), templates)
+}
+
+// Show returns a template
+// @Summary      Get a template
+// @Description  Get a message template including its review status and the note of the operator who reviewed it
+// @Security	 ApiKeyAuth
+// @Tags         Templates
+// @Accept       json
+// @Produce      json
+// @Param 		 templateID 	path		string 	true 	"ID of the template"	default(32343a19-da5e-4b1b-a767-3298a73703ca)
+// @Success      200 		{object}	responses.TemplateResponse
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401	    {object}	responses.Unauthorized
+// @Failure 	 404    	{object}	responses.NotFound
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /templates/{templateID} 	[get]
+func (h *TemplateHandler) Show(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	templateID := c.Params("templateID")
+	if errors := h.validator.ValidateUUID(ctx, templateID, "templateID"); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while fetching template with ID [%s]", spew.Sdump(errors), templateID)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while fetching template")
+	}
+
+	template, err := h.service.Load(ctx, h.userIDFomContext(c), uuid.MustParse(templateID))
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		return h.responseNotFound(c, fmt.Sprintf("cannot find template with ID [%s]", templateID))
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot load template with ID [%s]", templateID)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseOK(c, "template fetched successfully", template)
+}
+
+// Store a template
+// @Summary      Store a template
+// @Description  Create a draft message template. Submit the template for review before it can be used by the campaigns of an account which requires template approval.
+// @Security	 ApiKeyAuth
+// @Tags         Templates
+// @Accept       json
+// @Produce      json
+// @Param        payload   	body 		requests.TemplateStore  		true "Payload of the template request"
+// @Success      201 		{object}	responses.TemplateResponse
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401	    {object}	responses.Unauthorized
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /templates [post]
+func (h *TemplateHandler) Store(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	var request requests.TemplateStore
+	if err := c.BodyParser(&request); err != nil {
+		msg := fmt.Sprintf("cannot marshall [%s] into %T", c.Body(), request)
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return h.responseBadRequest(c, err)
+	}
+
+	if errors := h.validator.ValidateStore(ctx, request.Sanitize()); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while storing template [%+#v]", spew.Sdump(errors), request)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while storing template")
+	}
+
+	template, err := h.service.Store(ctx, request.ToStoreParams(h.userIDFomContext(c)))
+	if err != nil {
+		msg := fmt.Sprintf("cannot store template with params [%+#v]", request)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseCreated(c, "template created successfully", template)
+}
+
+// Update a template
+// @Summary      Update a template
+// @Description  Change the name or the content of a template. A submitted, approved or rejected template goes back to draft when its content changes and it must be reviewed again.
+// @Security	 ApiKeyAuth
+// @Tags         Templates
+// @Accept       json
+// @Produce      json
+// @Param 		 templateID 	path		string 					true 	"ID of the template"	default(32343a19-da5e-4b1b-a767-3298a73703ca)
+// @Param        payload   	body 		requests.TemplateStore  	true 	"Payload of the template request"
+// @Success      200 		{object}	responses.TemplateResponse
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401	    {object}	responses.Unauthorized
+// @Failure 	 404    	{object}	responses.NotFound
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /templates/{templateID} [put]
+func (h *TemplateHandler) Update(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	var request requests.TemplateUpdate
+	if err := c.BodyParser(&request); err != nil {
+		msg := fmt.Sprintf("cannot marshall [%s] into %T", c.Body(), request)
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return h.responseBadRequest(c, err)
+	}
+
+	request.TemplateID = c.Params("templateID")
+	if errors := h.validator.ValidateUpdate(ctx, request.Sanitize()); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while updating template [%+#v]", spew.Sdump(errors), request)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while updating template")
+	}
+
+	template, err := h.service.Update(ctx, request.ToUpdateParams(h.userIDFomContext(c)))
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		return h.responseNotFound(c, fmt.Sprintf("cannot find template with ID [%s]", request.TemplateID))
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot update template with params [%+#v]", request)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseOK(c, "template updated successfully", template)
+}
+
+// Delete a template
+// @Summary      Delete a template
+// @Description  Delete a template and its review history. Campaigns which use the template are skipped until they use another template.
+// @Security	 ApiKeyAuth
+// @Tags         Templates
+// @Accept       json
+// @Produce      json
+// @Param 		 templateID 	path		string 							true 	"ID of the template"	default(32343a19-da5e-4b1b-a767-3298a73703ca)
+// @Success      204		{object}    responses.NoContent
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401    	{object}	responses.Unauthorized
+// @Failure 	 404    	{object}	responses.NotFound
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /templates/{templateID} [delete]
+func (h *TemplateHandler) Delete(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	templateID := c.Params("templateID")
+	if errors := h.validator.ValidateUUID(ctx, templateID, "templateID"); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while deleting template with ID [%s]", spew.Sdump(errors), templateID)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while deleting template")
+	}
+
+	err := h.service.Delete(ctx, h.userIDFomContext(c), uuid.MustParse(templateID))
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		return h.responseNotFound(c, fmt.Sprintf("cannot find template with ID [%s]", templateID))
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot delete template with ID [%s]", templateID)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseNoContent(c, "template deleted successfully")
+}
+
+// Submit a template for review
+// @Summary      Submit a template for review
+// @Description  Submit a draft or rejected template so that it is reviewed by an operator
+// @Security	 ApiKeyAuth
+// @Tags         Templates
+// @Produce      json
+// @Param 		 templateID 	path		string 	true 	"ID of the template"	default(32343a19-da5e-4b1b-a767-3298a73703ca)
+// @Success      200 		{object}	responses.TemplateResponse
+// @Failure 	 401	    {object}	responses.Unauthorized
+// @Failure 	 404    	{object}	responses.NotFound
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /templates/{templateID}/submit [post]
+func (h *TemplateHandler) Submit(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	templateID := c.Params("templateID")
+	if errors := h.validator.ValidateUUID(ctx, templateID, "templateID"); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while submitting template with ID [%s]", spew.Sdump(errors), templateID)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while submitting template")
+	}
+
+	template, err := h.service.Submit(ctx, h.userIDFomContext(c), uuid.MustParse(templateID))
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		return h.responseNotFound(c, fmt.Sprintf("cannot find template with ID [%s]", templateID))
+	}
+
+	if stacktrace.GetCode(err) == services.ErrCodeTemplateTransitionInvalid {
+		return h.responseUnprocessableEntity(c, url.Values{"templateID": []string{"only draft or rejected templates can be submitted for review"}}, "validation errors while submitting template")
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot submit template with ID [%s]", templateID)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseOK(c, "template submitted for review successfully", template)
+}
+
+// Reviews returns the audit history of a template
+// @Summary      Get the review history of a template
+// @Description  Get every status change of a template with the user who made it, the reviewed content and the note of the operator
+// @Security	 ApiKeyAuth
+// @Tags         Templates
+// @Produce      json
+// @Param 		 templateID 	path		string 	true 	"ID of the template"	default(32343a19-da5e-4b1b-a767-3298a73703ca)
+// @Success      200 		{object}	responses.TemplateReviewsResponse
+// @Failure 	 401	    {object}	responses.Unauthorized
+// @Failure 	 404    	{object}	responses.NotFound
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /templates/{templateID}/reviews [get]
+func (h *TemplateHandler) Reviews(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	templateID := c.Params("templateID")
+	if errors := h.validator.ValidateUUID(ctx, templateID, "templateID"); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while fetching reviews of template with ID [%s]", spew.Sdump(errors), templateID)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while fetching template reviews")
+	}
+
+	reviews, err := h.service.Reviews(ctx, h.userIDFomContext(c), uuid.MustParse(templateID))
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		return h.responseNotFound(c, fmt.Sprintf("cannot find template with ID [%s]", templateID))
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot fetch reviews of template with ID [%s]", templateID)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseOK(c, fmt.Sprintf("fetched %d %s", len(reviews), h.pluralize("review", len(reviews))), reviews)
+}
+
+// Stats returns the performance of a template
+// @Summary      Get the stats of a template
+// @Description  Get the number of messages sent with a template, the delivery rate, the reply rate and the opt outs for every day in a time range. Responses are counted on the day when the message they respond to was sent.
+// @Security	 ApiKeyAuth
+// @Tags         Templates
+// @Produce      json
+// @Param 		 templateID 	path		string 	true 	"ID of the template"	default(32343a19-da5e-4b1b-a767-3298a73703ca)
+// @Param        from		query  string  	false	"start of the time range in RFC3339 format, defaults to 30 days before the end"	default(2022-06-01T00:00:00Z)
+// @Param        to			query  string  	false	"end of the time range in RFC3339 format, defaults to now"	default(2022-07-01T00:00:00Z)
+// @Success      200 		{object}	responses.TemplateStatsResponse
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401	    {object}	responses.Unauthorized
+// @Failure 	 404    	{object}	responses.NotFound
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /templates/{templateID}/stats [get]
+func (h *TemplateHandler) Stats(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	var request requests.TemplateStats
+	if err := c.QueryParser(&request); err != nil {
+		msg := fmt.Sprintf("cannot marshall URL [%s] into %T", c.OriginalURL(), request)
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return h.responseBadRequest(c, err)
+	}
+
+	request.TemplateID = c.Params("templateID")
+	if errors := h.validator.ValidateStats(ctx, request.Sanitize()); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while fetching stats of template [%+#v]", spew.Sdump(errors), request)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while fetching template stats")
+	}
+
+	stats, err := h.statService.Stats(ctx, request.ToStatsParams(h.userIDFomContext(c)))
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		return h.responseNotFound(c, fmt.Sprintf("cannot find template with ID [%s]", request.TemplateID))
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot fetch stats of template with params [%+#v]", request)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseOK(c, fmt.Sprintf("fetched %d daily %s", len(stats.Days), h.pluralize("stat", len(stats.Days))), stats)
+}
+
+// IndexSubmitted returns the templates which are waiting for a review
+// @Summary      Get submitted templates
+// @Description  Get the templates of all users which are waiting for a review, the oldest submission is first. Only operators can review templates.
+// @Security	 ApiKeyAuth
+// @Tags         Templates
+// @Produce      json
+// @Param        skip		query  int  	false	"number of templates to skip"		minimum(0)
+// @Param        query		query  string  	false 	"filter templates containing query"
+// @Param        limit		query  int  	false	"number of templates to return"	minimum(1)	maximum(100)
+// @Success      200 		{object}	responses.TemplatesResponse
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401	    {object}	responses.Unauthorized
+// @Failure 	 403    	{object}	responses.Unauthorized
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /operator/templates 	[get]
+func (h *TemplateHandler) IndexSubmitted(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	if !h.operatorService.IsOperator(h.userIDFomContext(c)) {
+		ctxLogger.Warn(stacktrace.NewError(fmt.Sprintf("user [%s] is not an operator and cannot review templates", h.userIDFomContext(c))))
+		return h.responseForbidden(c)
+	}
+
+	var request requests.TemplateIndex
+	if err := c.QueryParser(&request); err != nil {
+		msg := fmt.Sprintf("cannot marshall URL [%s] into %T", c.OriginalURL(), request)
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return h.responseBadRequest(c, err)
+	}
+
+	if errors := h.validator.ValidateIndex(ctx, request.Sanitize()); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while fetching submitted templates [%+#v]", spew.Sdump(errors), request)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while fetching submitted templates")
+	}
+
+	templates, err := h.service.IndexSubmitted(ctx, request.ToIndexParams())
+	if err != nil {
+		msg := fmt.Sprintf("cannot get submitted templates with params [%+#v]", request)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseOK(c, fmt.Sprintf("fetched %d %s", len(templates), h.pluralize("template", len(templates))), templates)
+}
+
+// Review approves or rejects a submitted template
+// @Summary      Review a template
+// @Description  Approve or reject a submitted template, a note is required when the template is rejected. Only operators can review templates.
+// @Security	 ApiKeyAuth
+// @Tags         Templates
+// @Accept       json
+// @Produce      json
+// @Param 		 templateID 	path		string 					true 	"ID of the template"	default(32343a19-da5e-4b1b-a767-3298a73703ca)
+// @Param        payload   	body 		requests.TemplateReview  	true 	"Result of the review"
+// @Success      200 		{object}	responses.TemplateResponse
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401	    {object}	responses.Unauthorized
+// @Failure 	 403    	{object}	responses.Unauthorized
+// @Failure 	 404    	{object}	responses.NotFound
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /operator/templates/{templateID}/review [post]
+func (h *TemplateHandler) Review(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	if !h.operatorService.IsOperator(h.userIDFomContext(c)) {
+		ctxLogger.Warn(stacktrace.NewError(fmt.Sprintf("user [%s] is not an operator and cannot review templates", h.userIDFomContext(c))))
+		return h.responseForbidden(c)
+	}
+
+	var request requests.TemplateReview
+	if err := c.BodyParser(&request); err != nil {
+		msg := fmt.Sprintf("cannot marshall [%s] into %T", c.Body(), request)
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return h.responseBadRequest(c, err)
+	}
+
+	request.TemplateID = c.Params("templateID")
+	if errors := h.validator.ValidateReview(ctx, request.Sanitize()); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while reviewing template [%+#v]", spew.Sdump(errors), request)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while reviewing template")
+	}
+
+	template, err := h.service.Review(ctx, request.ToReviewParams(h.userIDFomContext(c)))
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		return h.responseNotFound(c, fmt.Sprintf("cannot find template with ID [%s]", request.TemplateID))
+	}
+
+	if stacktrace.GetCode(err) == services.ErrCodeTemplateTransitionInvalid {
+		return h.responseUnprocessableEntity(c, url.Values{"templateID": []string{"only submitted templates can be approved or rejected"}}, "validation errors while reviewing template")
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot review template with params [%+#v]", request)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseOK(c, fmt.Sprintf("template %s successfully", template.Status), template)
+}
+
+// TemplateApproval sets if the campaigns of a user can only send approved templates
+// @Summary      Require template approval for a user
+// @Description  Require the campaigns of a regulated sender to use templates which were approved by an operator. Only operators can change this setting.
+// @Security	 ApiKeyAuth
+// @Tags         Templates
+// @Accept       json
+// @Produce      json
+// @Param        userID		path		string  						true 	"ID of the user"
+// @Param        payload   	body 		requests.UserTemplateApproval  	true 	"Template approval setting"
+// @Success      200 		{object}	responses.UserResponse
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401    	{object}	responses.Unauthorized
+// @Failure 	 403    	{object}	responses.Unauthorized
+// @Failure 	 404		{object}	responses.NotFound
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /operator/users/{userID}/template-approval [put]
+func (h *TemplateHandler) TemplateApproval(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	if !h.operatorService.IsOperator(h.userIDFomContext(c)) {
+		ctxLogger.Warn(stacktrace.NewError(fmt.Sprintf("user [%s] is not an operator and cannot change template approval", h.userIDFomContext(c))))
+		return h.responseForbidden(c)
+	}
+
+	var request requests.UserTemplateApproval
+	if err := c.BodyParser(&request); err != nil {
+		msg := fmt.Sprintf("cannot marshall [%s] into %T", c.Body(), request)
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return h.responseBadRequest(c, err)
+	}
+
+	request.UserID = c.Params("userID")
+	if errors := h.validator.ValidateTemplateApproval(ctx, request.Sanitize()); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while changing template approval [%+#v]", spew.Sdump(errors), request)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while changing template approval")
+	}
+
+	user, err := h.service.SetApprovalRequired(ctx, entities.UserID(request.UserID), request.Required)
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		return h.responseNotFound(c, fmt.Sprintf("cannot find user with ID [%s]", request.UserID))
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot change template approval with params [%+#v]", request)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseOK(c, "template approval updated successfully", user)
+}
@@ -0,0 +1,196 @@
+package handlers
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
+	"github.com/NdoleStudio/httpsms/pkg/requests"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/NdoleStudio/httpsms/pkg/validators"
+	"github.com/davecgh/go-spew/spew"
+	"github.com/gofiber/fiber/v2"
+	"github.com/palantir/stacktrace"
+)
+
+// twilioErrorCodes maps validation errors to the error codes of the Twilio API
+var twilioErrorCodes = map[string]int{
+	"to":              21211,
+	"from":            21606,
+	"content":         21602,
+	"status_callback": 21609,
+}
+
+// TwilioHandler handles requests made by Twilio SDKs to the Twilio compatible API
+type TwilioHandler struct {
+	handler
+	logger         telemetry.Logger
+	tracer         telemetry.Tracer
+	billingService *services.BillingService
+	rateLimiter    *services.RateLimitService
+	validator      *validators.TwilioHandlerValidator
+	service        *services.TwilioService
+}
+
+// NewTwilioHandler creates a new TwilioHandler
+func NewTwilioHandler(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	validator *validators.TwilioHandlerValidator,
+	billingService *services.BillingService,
+	rateLimiter *services.RateLimitService,
+	service *services.TwilioService,
+) (h *TwilioHandler) {
+	return &TwilioHandler{
+		logger:         logger.WithService(fmt.Sprintf("%T", h)),
+		tracer:         tracer,
+		validator:      validator,
+		billingService: billingService,
+		rateLimiter:    rateLimiter,
+		service:        service,
+	}
+}
+
+// RegisterRoutes registers the routes for the TwilioHandler
+func (h *TwilioHandler) RegisterRoutes(app *fiber.App, middlewares ...fiber.Handler) {
+	router := app.Group("/" + services.TwilioAPIVersion + "/Accounts/:accountSID")
+	router.Post("/Messages.json", h.computeRoute(middlewares, h.PostMessage)...)
+	router.Get("/Messages/:messageSID.json", h.computeRoute(middlewares, h.GetMessage)...)
+}
+
+// PostMessage sends a message with a Twilio SDK
+// @Summary      Send a message with the Twilio compatible API
+// @Description  Send a new SMS message using a form encoded request made by a Twilio SDK. Use your API key as the auth token, the status callback is signed with the API key.
+// @Security	 BasicAuth
+// @Tags         Twilio
+// @Accept       x-www-form-urlencoded
+// @Produce      json
+// @Param        accountSID		path		string  				true 	"the account SID used by the Twilio SDK"
+// @Param        payload		formData	requests.TwilioMessageSend	true 	"form encoded message"
+// @Success      201 		{object}	services.TwilioMessage
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401	    {object}	responses.Unauthorized
+// @Failure      429		{object}	responses.TooManyRequests
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /2010-04-01/Accounts/{accountSID}/Messages.json [post]
+func (h *TwilioHandler) PostMessage(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	var request requests.TwilioMessageSend
+	if err := c.BodyParser(&request); err != nil {
+		msg := fmt.Sprintf("cannot marshall form [%s] into %T", c.Body(), request)
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return h.responseTwilioError(c, fiber.StatusBadRequest, 20001, "The request isn't properly formed")
+	}
+
+	request.AccountSID = c.Params("accountSID")
+	if errors := h.validator.ValidateMessageSend(ctx, h.userIDFomContext(c), request.Sanitize()); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while sending twilio message [%+#v]", spew.Sdump(errors), request)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseTwilioValidationError(c, errors)
+	}
+
+	if msg := h.billingService.IsEntitledToSend(ctx, h.userIDFomContext(c)); msg != nil {
+		ctxLogger.Warn(stacktrace.NewError(fmt.Sprintf("user with ID [%s] can't send a twilio message", h.userIDFomContext(c))))
+		return h.responseTwilioError(c, fiber.StatusPaymentRequired, 20005, *msg)
+	}
+
+	limit, err := h.rateLimiter.TakeSend(ctx, h.userIDFomContext(c), request.To)
+	if err != nil {
+		msg := fmt.Sprintf("cannot check send rate limit of user [%s]", h.userIDFomContext(c))
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseTwilioError(c, fiber.StatusInternalServerError, 20500, "We ran into an internal error while handling the request.")
+	}
+
+	if !limit.Allowed {
+		ctxLogger.Warn(stacktrace.NewError(fmt.Sprintf("user with ID [%s] exceeded the send rate limit to [%s]", h.userIDFomContext(c), request.To)))
+		c.Set(fiber.HeaderRetryAfter, strconv.Itoa(int(limit.RetryAfter.Seconds())+1))
+		return h.responseTwilioError(c, fiber.StatusTooManyRequests, 20429, "Too Many Requests")
+	}
+
+	message, err := h.service.SendMessage(ctx, request.ToTwilioMessageSendParams(h.userIDFomContext(c), c.OriginalURL()))
+	if stacktrace.GetCode(err) == services.ErrCodeUserSuspended {
+		ctxLogger.Warn(stacktrace.Propagate(err, fmt.Sprintf("suspended user [%s] cannot send a twilio message", h.userIDFomContext(c))))
+		return h.responseTwilioError(c, fiber.StatusForbidden, 20005, "The account is suspended")
+	}
+
+	if stacktrace.GetCode(err) == services.ErrCodeInsufficientCredits {
+		ctxLogger.Warn(stacktrace.Propagate(err, fmt.Sprintf("user [%s] does not have enough credits to send a twilio message", h.userIDFomContext(c))))
+		return h.responseTwilioError(c, fiber.StatusPaymentRequired, 20005, "You do not have enough credits to send this message. Top up your credits to send more messages.")
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot send twilio message with payload [%s]", c.Body())
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseTwilioError(c, fiber.StatusInternalServerError, 20500, "We ran into an internal error while handling the request.")
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(message)
+}
+
+// GetMessage fetches a message with a Twilio SDK
+// @Summary      Get a message with the Twilio compatible API
+// @Description  Get a message formatted as a Twilio message resource using the SID returned when it was sent.
+// @Security	 BasicAuth
+// @Tags         Twilio
+// @Produce      json
+// @Param        accountSID		path		string  	true 	"the account SID used by the Twilio SDK"
+// @Param        messageSID		path		string  	true 	"the SID of the message" default(SM32343a19da5e4b1ba7673298a73703cb)
+// @Success      200 		{object}	services.TwilioMessage
+// @Failure 	 401	    {object}	responses.Unauthorized
+// @Failure      404		{object}	responses.NotFound
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /2010-04-01/Accounts/{accountSID}/Messages/{messageSID}.json [get]
+func (h *TwilioHandler) GetMessage(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	messageSID := c.Params("messageSID")
+	messageID, err := services.ParseTwilioMessageSID(messageSID)
+	if err != nil {
+		ctxLogger.Warn(stacktrace.Propagate(err, fmt.Sprintf("cannot parse twilio message SID [%s]", messageSID)))
+		return h.responseTwilioError(c, fiber.StatusNotFound, 20404, fmt.Sprintf("The requested resource /Messages/%s.json was not found", messageSID))
+	}
+
+	message, err := h.service.GetMessage(ctx, h.userIDFomContext(c), c.Params("accountSID"), messageID)
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		return h.responseTwilioError(c, fiber.StatusNotFound, 20404, fmt.Sprintf("The requested resource /Messages/%s.json was not found", messageSID))
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot fetch twilio message with SID [%s]", messageSID)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseTwilioError(c, fiber.StatusInternalServerError, 20500, "We ran into an internal error while handling the request.")
+	}
+
+	return c.Status(fiber.StatusOK).JSON(message)
+}
+
+// responseTwilioValidationError responds with the first validation error in the error format of the Twilio API
+func (h *TwilioHandler) responseTwilioValidationError(c *fiber.Ctx, errors url.Values) error {
+	fields := make([]string, 0, len(errors))
+	for field := range errors {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	code, ok := twilioErrorCodes[fields[0]]
+	if !ok {
+		code = 20001
+	}
+	return h.responseTwilioError(c, fiber.StatusBadRequest, code, errors.Get(fields[0]))
+}
+
+// responseTwilioError responds in the error format of the Twilio API so Twilio SDKs can parse the error
+func (h *TwilioHandler) responseTwilioError(c *fiber.Ctx, status int, code int, message string) error {
+	return c.Status(status).JSON(fiber.Map{
+		"code":      code,
+		"message":   message,
+		"more_info": fmt.Sprintf("https://www.twilio.com/docs/errors/%d", code),
+		"status":    status,
+	})
+}
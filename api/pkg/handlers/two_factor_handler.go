@@ -0,0 +1,270 @@
+package handlers
+
+import (
+	"fmt"
+	"net/url"
+
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
+	"github.com/NdoleStudio/httpsms/pkg/requests"
+	"github.com/NdoleStudio/httpsms/pkg/responses"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/NdoleStudio/httpsms/pkg/validators"
+	"github.com/davecgh/go-spew/spew"
+	"github.com/gofiber/fiber/v2"
+	"github.com/palantir/stacktrace"
+)
+
+// TwoFactorHandler handles the two factor authentication requests of dashboard users
+type TwoFactorHandler struct {
+	handler
+	logger    telemetry.Logger
+	tracer    telemetry.Tracer
+	service   *services.TwoFactorService
+	validator *validators.TwoFactorHandlerValidator
+}
+
+// NewTwoFactorHandler creates a new TwoFactorHandler
+func NewTwoFactorHandler(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	service *services.TwoFactorService,
+	validator *validators.TwoFactorHandlerValidator,
+) (h *TwoFactorHandler) {
+	return &TwoFactorHandler{
+		logger:    logger.WithService(fmt.Sprintf("%T", h)),
+		tracer:    tracer,
+		service:   service,
+		validator: validator,
+	}
+}
+
+// RegisterRoutes registers the routes for the TwoFactorHandler
+func (h *TwoFactorHandler) RegisterRoutes(app *fiber.App, middlewares ...fiber.Handler) {
+	router := app.Group("/v1/two-factor")
+	router.Get("/", h.computeRoute(middlewares, h.Show)...)
+	router.Post("/enroll", h.computeRoute(middlewares, h.Enroll)...)
+	router.Post("/enable", h.computeRoute(middlewares, h.Enable)...)
+	router.Post("/disable", h.computeRoute(middlewares, h.Disable)...)
+	router.Post("/recovery-codes", h.computeRoute(middlewares, h.RecoveryCodes)...)
+}
+
+// Show returns the two factor authentication status of a user
+// @Summary      Get two factor authentication status
+// @Description  Get the two factor authentication of the currently authenticated user, the secret and recovery codes are never returned.
+// @Security	 ApiKeyAuth
+// @Tags         TwoFactor
+// @Produce      json
+// @Success      200 		{object}	responses.TwoFactorAuthResponse
+// @Failure 	 401	    {object}	responses.Unauthorized
+// @Failure      404		{object}	responses.NotFound
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /two-factor [get]
+func (h *TwoFactorHandler) Show(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	auth, err := h.service.Get(ctx, h.userIDFomContext(c))
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		return h.responseNotFound(c, "two factor authentication is not enabled")
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot get two factor auth for user [%s]", h.userIDFomContext(c))
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseOK(c, "two factor authentication fetched successfully", auth)
+}
+
+// Enroll starts the two factor authentication enrollment of a user
+// @Summary      Enroll in two factor authentication
+// @Description  Generate a TOTP secret to add to an authenticator app. Two factor authentication is only enabled after the secret is confirmed with a code.
+// @Security	 ApiKeyAuth
+// @Tags         TwoFactor
+// @Produce      json
+// @Success      200 		{object}	responses.TwoFactorEnrollmentResponse
+// @Failure 	 401	    {object}	responses.Unauthorized
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /two-factor/enroll [post]
+func (h *TwoFactorHandler) Enroll(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	enrollment, err := h.service.Enroll(ctx, h.userFromContext(c))
+	if stacktrace.GetCode(err) == services.ErrCodeTwoFactorAlreadyEnabled {
+		return h.responseUnprocessableEntity(c, url.Values{"two_factor": []string{"two factor authentication is already enabled, disable it before enrolling again"}}, "validation errors while enrolling in two factor authentication")
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot enroll user [%s] in two factor auth", h.userIDFomContext(c))
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseOK(c, "two factor authentication enrollment started successfully", &responses.TwoFactorEnrollment{
+		Secret: enrollment.Secret,
+		URL:    enrollment.URL,
+	})
+}
+
+// Enable confirms the enrollment with a TOTP code
+// @Summary      Enable two factor authentication
+// @Description  Confirm the enrollment with a code from the authenticator app. The recovery codes in the response are only shown once.
+// @Security	 ApiKeyAuth
+// @Tags         TwoFactor
+// @Accept       json
+// @Produce      json
+// @Param        payload   		body 		requests.TwoFactorCode  	true 	"Code from the authenticator app"
+// @Success      200 		{object}	responses.TwoFactorRecoveryCodesResponse
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401	    {object}	responses.Unauthorized
+// @Failure      404		{object}	responses.NotFound
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /two-factor/enable [post]
+func (h *TwoFactorHandler) Enable(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	var request requests.TwoFactorCode
+	if err := c.BodyParser(&request); err != nil {
+		msg := fmt.Sprintf("cannot marshall body into [%T]", request)
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return h.responseBadRequest(c, err)
+	}
+
+	if errors := h.validator.ValidateCode(ctx, request.Sanitize()); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while enabling two factor authentication for user [%s]", spew.Sdump(errors), h.userIDFomContext(c))
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while enabling two factor authentication")
+	}
+
+	codes, err := h.service.Enable(ctx, h.userIDFomContext(c), request.Code)
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		return h.responseNotFound(c, "enroll in two factor authentication before enabling it")
+	}
+
+	if stacktrace.GetCode(err) == services.ErrCodeTwoFactorAlreadyEnabled {
+		return h.responseUnprocessableEntity(c, url.Values{"two_factor": []string{"two factor authentication is already enabled"}}, "validation errors while enabling two factor authentication")
+	}
+
+	if stacktrace.GetCode(err) == services.ErrCodeTwoFactorInvalidCode {
+		return h.responseUnprocessableEntity(c, url.Values{"code": []string{"the code is invalid or expired"}}, "validation errors while enabling two factor authentication")
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot enable two factor auth for user [%s]", h.userIDFomContext(c))
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseOK(c, "two factor authentication enabled successfully, store the recovery codes in a safe place", codes)
+}
+
+// Disable removes two factor authentication
+// @Summary      Disable two factor authentication
+// @Description  Disable two factor authentication with a code from the authenticator app or a recovery code.
+// @Security	 ApiKeyAuth
+// @Tags         TwoFactor
+// @Accept       json
+// @Produce      json
+// @Param        payload   		body 		requests.TwoFactorCode  	true 	"Code from the authenticator app or a recovery code"
+// @Success      204		{object}    responses.NoContent
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401	    {object}	responses.Unauthorized
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      429		{object}	responses.TooManyRequests
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /two-factor/disable [post]
+func (h *TwoFactorHandler) Disable(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	var request requests.TwoFactorCode
+	if err := c.BodyParser(&request); err != nil {
+		msg := fmt.Sprintf("cannot marshall body into [%T]", request)
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return h.responseBadRequest(c, err)
+	}
+
+	if errors := h.validator.ValidateCode(ctx, request.Sanitize()); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while disabling two factor authentication for user [%s]", spew.Sdump(errors), h.userIDFomContext(c))
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while disabling two factor authentication")
+	}
+
+	err := h.service.Disable(ctx, h.userIDFomContext(c), request.Code)
+	if stacktrace.GetCode(err) == services.ErrCodeTwoFactorLocked {
+		return h.responseTwoFactorLocked(c)
+	}
+
+	if stacktrace.GetCode(err) == services.ErrCodeTwoFactorInvalidCode {
+		return h.responseUnprocessableEntity(c, url.Values{"code": []string{"the code is invalid or expired"}}, "validation errors while disabling two factor authentication")
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot disable two factor auth for user [%s]", h.userIDFomContext(c))
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseNoContent(c, "two factor authentication disabled successfully")
+}
+
+// RecoveryCodes replaces the recovery codes of a user
+// @Summary      Regenerate recovery codes
+// @Description  Replace the recovery codes with a code from the authenticator app or an unused recovery code. The previous recovery codes stop working.
+// @Security	 ApiKeyAuth
+// @Tags         TwoFactor
+// @Accept       json
+// @Produce      json
+// @Param        payload   		body 		requests.TwoFactorCode  	true 	"Code from the authenticator app or a recovery code"
+// @Success      200 		{object}	responses.TwoFactorRecoveryCodesResponse
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401	    {object}	responses.Unauthorized
+// @Failure      404		{object}	responses.NotFound
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      429		{object}	responses.TooManyRequests
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /two-factor/recovery-codes [post]
+func (h *TwoFactorHandler) RecoveryCodes(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	var request requests.TwoFactorCode
+	if err := c.BodyParser(&request); err != nil {
+		msg := fmt.Sprintf("cannot marshall body into [%T]", request)
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return h.responseBadRequest(c, err)
+	}
+
+	if errors := h.validator.ValidateCode(ctx, request.Sanitize()); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while generating recovery codes for user [%s]", spew.Sdump(errors), h.userIDFomContext(c))
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while generating recovery codes")
+	}
+
+	codes, err := h.service.RegenerateRecoveryCodes(ctx, h.userIDFomContext(c), request.Code)
+	if stacktrace.GetCode(err) == services.ErrCodeTwoFactorLocked {
+		return h.responseTwoFactorLocked(c)
+	}
+
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		return h.responseNotFound(c, "two factor authentication is not enabled")
+	}
+
+	if stacktrace.GetCode(err) == services.ErrCodeTwoFactorInvalidCode {
+		return h.responseUnprocessableEntity(c, url.Values{"code": []string{"the code is invalid or expired"}}, "validation errors while generating recovery codes")
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot generate recovery codes for user [%s]", h.userIDFomContext(c))
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseOK(c, "recovery codes generated successfully, store them in a safe place", codes)
+}
@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"bufio"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/services"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/gofiber/fiber/v2"
+	"github.com/palantir/stacktrace"
+	"github.com/valyala/fasthttp"
+)
+
+// UserDataHandler handles the export and the deletion of the data of a user
+type UserDataHandler struct {
+	handler
+	logger  telemetry.Logger
+	tracer  telemetry.Tracer
+	service *services.UserDataService
+}
+
+// NewUserDataHandler creates a new UserDataHandler
+func NewUserDataHandler(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	service *services.UserDataService,
+) (h *UserDataHandler) {
+	return &UserDataHandler{
+		logger:  logger.WithService(fmt.Sprintf("%T", h)),
+		tracer:  tracer,
+		service: service,
+	}
+}
+
+// RegisterRoutes registers the routes for the UserDataHandler, the twoFactor middleware protects both routes because they expose or destroy all the data of the user
+func (h *UserDataHandler) RegisterRoutes(router fiber.Router, twoFactor fiber.Handler) {
+	router.Post("/users/me/export", twoFactor, h.Export)
+	router.Delete("/users/me", twoFactor, h.Delete)
+}
+
+// Export the data of the current user
+// @Summary      Export all your data
+// @Description  Download a zip archive with your account, messages, archived messages, contacts, webhooks and events. `user.json` contains your account and the other files contain one JSON object per line.
+// @Security	 ApiKeyAuth
+// @Tags         Users
+// @Produce      application/zip
+// @Success      200 		{file}		file
+// @Failure 	 401	    {object}	responses.Unauthorized
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /users/me/export [post]
+func (h *UserDataHandler) Export(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	userID := h.userIDFomContext(c)
+	ctxLogger.Info(fmt.Sprintf("exporting the data of user [%s]", userID))
+
+	c.Attachment(fmt.Sprintf("httpsms-export-%s.zip", time.Now().UTC().Format("2006-01-02")))
+	c.Set(fiber.HeaderContentType, "application/zip")
+
+	// the archive is written while it is downloaded so that large accounts are never held in memory
+	c.Context().SetBodyStreamWriter(fasthttp.StreamWriter(func(writer *bufio.Writer) {
+		if err := h.service.Export(ctx, userID, writer); err != nil {
+			ctxLogger.Error(stacktrace.Propagate(err, fmt.Sprintf("cannot export the data of user [%s]", userID)))
+			return
+		}
+
+		if err := writer.Flush(); err != nil {
+			ctxLogger.Warn(stacktrace.Propagate(err, fmt.Sprintf("cannot flush the export of user [%s]", userID)))
+		}
+	}))
+
+	return nil
+}
+
+// Delete the account of the current user
+// @Summary      Delete your account
+// @Description  Delete your account and all your data e.g. messages, contacts, phones and webhooks. The data is deleted in the background and a `user.account.deleted` event is emitted when it is done. Cancel your subscription before deleting your account.
+// @Security	 ApiKeyAuth
+// @Tags         Users
+// @Produce      json
+// @Success      202 		{object}	responses.Accepted
+// @Failure 	 401	    {object}	responses.Unauthorized
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /users/me [delete]
+func (h *UserDataHandler) Delete(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	err := h.service.RequestErasure(ctx, h.userIDFomContext(c), c.OriginalURL())
+	if stacktrace.GetCode(err) == services.ErrCodeUserSubscriptionActive {
+		ctxLogger.Warn(stacktrace.Propagate(err, "cannot delete the account of a user with an active subscription"))
+		return h.responseUnprocessableEntity(c, url.Values{"subscription": []string{"Cancel your subscription before deleting your account"}}, "validation errors while deleting user")
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot delete the account of user [%s]", h.userIDFomContext(c))
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseAccepted(c, "your account and all your data will be deleted in a few minutes")
+}
@@ -2,7 +2,10 @@ package handlers
 
 import (
 	"fmt"
+	"net/url"
 
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
 	"github.com/NdoleStudio/httpsms/pkg/requests"
 	"github.com/NdoleStudio/httpsms/pkg/validators"
 	"github.com/davecgh/go-spew/spew"
@@ -16,10 +19,11 @@ import (
 // UserHandler handles user http requests.
 type UserHandler struct {
 	handler
-	logger    telemetry.Logger
-	tracer    telemetry.Tracer
-	validator *validators.UserHandlerValidator
-	service   *services.UserService
+	logger          telemetry.Logger
+	tracer          telemetry.Tracer
+	validator       *validators.UserHandlerValidator
+	service         *services.UserService
+	operatorService *services.OperatorService
 }
 
 // NewUserHandler creates a new UserHandler
@@ -28,21 +32,28 @@ func NewUserHandler(
 	tracer telemetry.Tracer,
 	validator *validators.UserHandlerValidator,
 	service *services.UserService,
+	operatorService *services.OperatorService,
 ) (h *UserHandler) {
 	return &UserHandler{
-		logger:    logger.WithService(fmt.Sprintf("%T", h)),
-		tracer:    tracer,
-		validator: validator,
-		service:   service,
+		logger:          logger.WithService(fmt.Sprintf("%T", h)),
+		tracer:          tracer,
+		validator:       validator,
+		service:         service,
+		operatorService: operatorService,
 	}
 }
 
-// RegisterRoutes registers the routes for the MessageHandler
-func (h *UserHandler) RegisterRoutes(router fiber.Router) {
+// RegisterRoutes registers the routes for the MessageHandler, the twoFactor middleware protects the sensitive routes
+func (h *UserHandler) RegisterRoutes(router fiber.Router, twoFactor fiber.Handler) {
 	router.Get("/users/me", h.Show)
 	router.Put("/users/me", h.Update)
+	router.Post("/users/me/api-key", twoFactor, h.RotateAPIKey)
 	router.Get("/users/subscription-update-url", h.subscriptionUpdateURL)
 	router.Delete("/users/subscription", h.cancelSubscription)
+	router.Post("/users/subscription/checkout", h.subscriptionCheckout)
+	router.Put("/users/subscription", h.changeSubscriptionPlan)
+	router.Post("/operator/users/:userID/suspend", h.suspend)
+	router.Post("/operator/users/:userID/reactivate", h.reactivate)
 }
 
 // Show returns an entities.User
@@ -76,6 +87,32 @@ func (h *UserHandler) Show(c *fiber.Ctx) error {
 	return h.responseOK(c, "user fetched successfully", user)
 }
 
+// RotateAPIKey replaces the API key of an entities.User
+// @Summary      Rotate the API key
+// @Description  Generate a new API key for the currently authenticated user, the previous API key stops working immediately. The [x-2fa-code] header is required when two factor authentication is enabled.
+// @Security	 ApiKeyAuth
+// @Tags         Users
+// @Produce      json
+// @Param        x-2fa-code	header		string							false	"TOTP or recovery code when two factor authentication is enabled"
+// @Success      200 		{object}	responses.UserResponse
+// @Failure 	 401    	{object}	responses.Unauthorized
+// @Failure 	 403    	{object}	responses.Unauthorized
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /users/me/api-key [post]
+func (h *UserHandler) RotateAPIKey(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	user, err := h.service.RotateAPIKey(ctx, h.userIDFomContext(c))
+	if err != nil {
+		msg := fmt.Sprintf("cannot rotate api key of user [%s]", h.userIDFomContext(c))
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseOK(c, "api key rotated successfully", user)
+}
+
 // Update an entities.User
 // @Summary      Update a user
 // @Description  Updates the details of the currently authenticated user
@@ -110,6 +147,11 @@ func (h *UserHandler) Update(c *fiber.Ctx) error {
 	}
 
 	user, err := h.service.Update(ctx, h.userFromContext(c), request.ToUpdateParams())
+	if stacktrace.GetCode(err) == services.ErrCodeUserRegionLocked {
+		ctxLogger.Warn(stacktrace.Propagate(err, "cannot change the region of the user"))
+		return h.responseUnprocessableEntity(c, url.Values{"region": []string{"The region cannot be changed after it has been chosen"}}, "validation errors while updating user")
+	}
+
 	if err != nil {
 		msg := fmt.Sprintf("cannot update user with params [%+#v]", request)
 		ctxLogger.Error(stacktrace.Propagate(err, msg))
@@ -176,3 +218,188 @@ func (h *UserHandler) cancelSubscription(c *fiber.Ctx) error {
 
 	return h.responseNoContent(c, "Subscription cancelled successfully")
 }
+
+// subscriptionCheckout returns the URL where the authenticated entities.User pays for a subscription plan
+// @Summary      Get the checkout URL of a subscription plan
+// @Description  Fetches the URL where the authenticated user pays for a subscription plan on the billing provider.
+// @Security	 ApiKeyAuth
+// @Tags         Users
+// @Accept       json
+// @Produce      json
+// @Param        payload   	body 		requests.UserSubscriptionPlan  	true 	"Subscription plan"
+// @Success      200 		{object}	responses.OkString
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401    	{object}	responses.Unauthorized
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /users/subscription/checkout 	[post]
+func (h *UserHandler) subscriptionCheckout(c *fiber.Ctx) error {
+	ctx, span := h.tracer.StartFromFiberCtx(c)
+	defer span.End()
+
+	ctxLogger := h.tracer.CtxLogger(h.logger, span)
+	authUser := h.userFromContext(c)
+
+	var request requests.UserSubscriptionPlan
+	if err := c.BodyParser(&request); err != nil {
+		msg := fmt.Sprintf("cannot marshall params [%s] into %T", c.OriginalURL(), request)
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return h.responseBadRequest(c, err)
+	}
+
+	if errors := h.validator.ValidateSubscriptionPlan(ctx, request.Sanitize()); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while fetching checkout URL [%+#v]", spew.Sdump(errors), request)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while fetching checkout URL")
+	}
+
+	url, err := h.service.GetSubscriptionCheckoutURL(ctx, authUser.ID, request.SubscriptionName())
+	if err != nil {
+		msg := fmt.Sprintf("cannot get checkout URL for user with ID [%s] and plan [%s]", authUser.ID, request.Plan)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseOK(c, "Subscription checkout URL fetched successfully", url)
+}
+
+// changeSubscriptionPlan changes the subscription plan of the authenticated entities.User
+// @Summary      Change the user's subscription plan
+// @Description  Moves the subscription of the authenticated user to another plan, the difference in price is prorated.
+// @Security	 ApiKeyAuth
+// @Tags         Users
+// @Accept       json
+// @Produce      json
+// @Param        payload   	body 		requests.UserSubscriptionPlan  	true 	"Subscription plan"
+// @Success      204 		{object}	responses.NoContent
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401    	{object}	responses.Unauthorized
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /users/subscription 	[put]
+func (h *UserHandler) changeSubscriptionPlan(c *fiber.Ctx) error {
+	ctx, span := h.tracer.StartFromFiberCtx(c)
+	defer span.End()
+
+	ctxLogger := h.tracer.CtxLogger(h.logger, span)
+	authUser := h.userFromContext(c)
+
+	var request requests.UserSubscriptionPlan
+	if err := c.BodyParser(&request); err != nil {
+		msg := fmt.Sprintf("cannot marshall params [%s] into %T", c.OriginalURL(), request)
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return h.responseBadRequest(c, err)
+	}
+
+	if errors := h.validator.ValidateSubscriptionPlan(ctx, request.Sanitize()); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while changing subscription plan [%+#v]", spew.Sdump(errors), request)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while changing subscription plan")
+	}
+
+	if err := h.service.ChangeSubscriptionPlan(ctx, authUser.ID, request.SubscriptionName()); err != nil {
+		msg := fmt.Sprintf("cannot change subscription plan of user with ID [%s] to [%s]", authUser.ID, request.Plan)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseNoContent(c, "Subscription plan changed successfully")
+}
+
+// suspend the account of an entities.User
+// @Summary      Suspend a user account
+// @Description  Suspends the account of a user. New messages are rejected, queued messages are paused and webhooks are held until the account is reactivated. Only operators can suspend accounts.
+// @Security	 ApiKeyAuth
+// @Tags         Users
+// @Accept       json
+// @Produce      json
+// @Param        userID		path		string  				true 	"ID of the user"
+// @Param        payload   	body 		requests.UserSuspend  	true 	"Reason for the suspension"
+// @Success      200 		{object}	responses.UserResponse
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401    	{object}	responses.Unauthorized
+// @Failure 	 403    	{object}	responses.Unauthorized
+// @Failure 	 404		{object}	responses.NotFound
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /operator/users/{userID}/suspend [post]
+func (h *UserHandler) suspend(c *fiber.Ctx) error {
+	ctx, span := h.tracer.StartFromFiberCtx(c)
+	defer span.End()
+
+	ctxLogger := h.tracer.CtxLogger(h.logger, span)
+
+	if !h.operatorService.IsOperator(h.userIDFomContext(c)) {
+		ctxLogger.Warn(stacktrace.NewError(fmt.Sprintf("user [%s] is not an operator and cannot suspend accounts", h.userIDFomContext(c))))
+		return h.responseForbidden(c)
+	}
+
+	var request requests.UserSuspend
+	if err := c.BodyParser(&request); err != nil {
+		msg := fmt.Sprintf("cannot marshall params [%s] into %T", c.OriginalURL(), request)
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return h.responseBadRequest(c, err)
+	}
+	request.UserID = c.Params("userID")
+
+	if errors := h.validator.ValidateSuspend(ctx, request.Sanitize()); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while suspending user [%+#v]", spew.Sdump(errors), request)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while suspending user")
+	}
+
+	user, err := h.service.Suspend(ctx, request.ToSuspendParams(c.OriginalURL()))
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		return h.responseNotFound(c, fmt.Sprintf("cannot find user with ID [%s]", request.UserID))
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot suspend user with params [%+#v]", request)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseOK(c, "user suspended successfully", user)
+}
+
+// reactivate the suspended account of an entities.User
+// @Summary      Reactivate a user account
+// @Description  Lifts the suspension of a user account. Paused messages are sent and held webhooks are delivered. Only operators can reactivate accounts.
+// @Security	 ApiKeyAuth
+// @Tags         Users
+// @Produce      json
+// @Param        userID		path		string  	true 	"ID of the user"
+// @Success      200 		{object}	responses.UserResponse
+// @Failure 	 401    	{object}	responses.Unauthorized
+// @Failure 	 403    	{object}	responses.Unauthorized
+// @Failure 	 404		{object}	responses.NotFound
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /operator/users/{userID}/reactivate [post]
+func (h *UserHandler) reactivate(c *fiber.Ctx) error {
+	ctx, span := h.tracer.StartFromFiberCtx(c)
+	defer span.End()
+
+	ctxLogger := h.tracer.CtxLogger(h.logger, span)
+
+	if !h.operatorService.IsOperator(h.userIDFomContext(c)) {
+		ctxLogger.Warn(stacktrace.NewError(fmt.Sprintf("user [%s] is not an operator and cannot reactivate accounts", h.userIDFomContext(c))))
+		return h.responseForbidden(c)
+	}
+
+	userID := entities.UserID(c.Params("userID"))
+	user, err := h.service.Reactivate(ctx, &services.UserReactivateParams{
+		Source: c.OriginalURL(),
+		UserID: userID,
+	})
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		return h.responseNotFound(c, fmt.Sprintf("cannot find user with ID [%s]", userID))
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot reactivate user with ID [%s]", userID)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseOK(c, "user reactivated successfully", user)
+}
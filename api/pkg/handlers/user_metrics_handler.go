@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"fmt"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/responses"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/gofiber/fiber/v2"
+	"github.com/palantir/stacktrace"
+)
+
+// UserMetricsHandler exports the metrics of a user for Prometheus
+type UserMetricsHandler struct {
+	handler
+	logger  telemetry.Logger
+	tracer  telemetry.Tracer
+	service *services.UserMetricsService
+}
+
+// NewUserMetricsHandler creates a new UserMetricsHandler
+func NewUserMetricsHandler(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	service *services.UserMetricsService,
+) (h *UserMetricsHandler) {
+	return &UserMetricsHandler{
+		logger:  logger.WithService(fmt.Sprintf("%T", h)),
+		tracer:  tracer,
+		service: service,
+	}
+}
+
+// RegisterRoutes registers the routes for the UserMetricsHandler, the signed scrape URL does not use the middlewares
+func (h *UserMetricsHandler) RegisterRoutes(app *fiber.App, middlewares ...fiber.Handler) {
+	router := app.Group("/v1/metrics")
+	router.Get("/", h.computeRoute(middlewares, h.Index)...)
+	router.Get("/url", h.computeRoute(middlewares, h.URL)...)
+	router.Get("/:userID", h.Scrape)
+}
+
+// Index exports the metrics of the authenticated user
+// @Summary      Export metrics
+// @Description  Export the message and phone metrics of the authenticated user in the OpenMetrics text format so that they can be scraped by Prometheus.
+// @Security	 ApiKeyAuth
+// @Tags         Metrics
+// @Produce      plain
+// @Success      200 		{string}	string
+// @Failure 	 401	    {object}	responses.Unauthorized
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /metrics [get]
+func (h *UserMetricsHandler) Index(c *fiber.Ctx) error {
+	return h.render(c, h.userIDFomContext(c))
+}
+
+// URL returns the signed scrape URL of the authenticated user
+// @Summary      Get the metrics scrape URL
+// @Description  Get a URL which exports the metrics of the authenticated user without the x-api-key header for scrapers which cannot set headers. The URL stops working when the API key is rotated.
+// @Security	 ApiKeyAuth
+// @Tags         Metrics
+// @Produce      json
+// @Success      200 		{object}	responses.UserMetricsURLResponse
+// @Failure 	 401	    {object}	responses.Unauthorized
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /metrics/url [get]
+func (h *UserMetricsHandler) URL(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	scrapeURL, err := h.service.ScrapeURL(ctx, c.BaseURL(), h.userIDFomContext(c))
+	if err != nil {
+		msg := fmt.Sprintf("cannot create metrics scrape URL for user [%s]", h.userIDFomContext(c))
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseOK(c, "metrics scrape URL created successfully", responses.UserMetricsURL{URL: scrapeURL})
+}
+
+// Scrape exports the metrics of a user with a signed scrape URL
+// @Summary      Scrape metrics with a signed URL
+// @Description  Export the metrics of a user in the OpenMetrics text format with the URL returned by the /metrics/url endpoint.
+// @Tags         Metrics
+// @Produce      plain
+// @Param        userID		path   string  	true	"ID of the user"
+// @Param        signature	query  string  	true	"signature of the scrape URL"
+// @Success      200 		{string}	string
+// @Failure 	 401	    {object}	responses.Unauthorized
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /metrics/{userID} [get]
+func (h *UserMetricsHandler) Scrape(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	userID := entities.UserID(c.Params("userID"))
+	valid, err := h.service.Verify(ctx, userID, c.Query("signature"))
+	if err != nil {
+		msg := fmt.Sprintf("cannot verify metrics scrape URL of user [%s]", userID)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	if !valid {
+		ctxLogger.Warn(stacktrace.NewError(fmt.Sprintf("invalid signature for metrics scrape URL of user [%s]", userID)))
+		return h.responseUnauthorized(c)
+	}
+
+	return h.render(c, userID)
+}
+
+func (h *UserMetricsHandler) render(c *fiber.Ctx, userID entities.UserID) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	metrics, err := h.service.Render(ctx, userID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot export metrics of user [%s]", userID)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	c.Set(fiber.HeaderContentType, services.UserMetricsContentType)
+	return c.Status(fiber.StatusOK).SendString(metrics)
+}
@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"fmt"
+	"net/url"
 
 	"github.com/NdoleStudio/httpsms/pkg/repositories"
 
@@ -40,13 +41,22 @@ func NewWebhookHandler(
 	}
 }
 
-// RegisterRoutes registers the routes for the WebhookHandler
-func (h *WebhookHandler) RegisterRoutes(app *fiber.App, middlewares ...fiber.Handler) {
+// RegisterRoutes registers the routes for the WebhookHandler, the twoFactor middleware protects the routes which change a webhook
+func (h *WebhookHandler) RegisterRoutes(app *fiber.App, twoFactor fiber.Handler, middlewares ...fiber.Handler) {
+	sensitive := h.computeRoute(middlewares, twoFactor)
+
 	router := app.Group("/v1/webhooks")
 	router.Get("/", h.computeRoute(middlewares, h.Index)...)
-	router.Post("/", h.computeRoute(middlewares, h.Store)...)
-	router.Put("/:webhookID", h.computeRoute(middlewares, h.Update)...)
-	router.Delete("/:webhookID", h.computeRoute(middlewares, h.Delete)...)
+	router.Get("/failures", h.computeRoute(middlewares, h.Failures)...)
+	router.Get("/fixtures", h.computeRoute(middlewares, h.Fixtures)...)
+	router.Post("/", h.computeRoute(sensitive, h.Store)...)
+	router.Put("/:webhookID", h.computeRoute(sensitive, h.Update)...)
+	router.Delete("/:webhookID", h.computeRoute(sensitive, h.Delete)...)
+	router.Post("/:webhookID/rotate-key", h.computeRoute(sensitive, h.RotateKey)...)
+	router.Post("/:webhookID/rotate-key/confirm", h.computeRoute(sensitive, h.ConfirmKeyRotation)...)
+	router.Get("/:webhookID/deliveries", h.computeRoute(middlewares, h.Deliveries)...)
+	router.Post("/:webhookID/deliveries/:deliveryID/replay", h.computeRoute(middlewares, h.Replay)...)
+	router.Post("/:webhookID/contract-test", h.computeRoute(middlewares, h.ContractTest)...)
 }
 
 // Index returns the webhooks of a user
@@ -100,6 +110,7 @@ func (h *WebhookHandler) Index(c *fiber.Ctx) error {
 // @Accept       json
 // @Produce      json
 // @Param 		 webhookID 	path		string 							true 	"ID of the webhook"	default(32343a19-da5e-4b1b-a767-3298a73703ca)
+// @Param        x-2fa-code	header		string							false	"TOTP or recovery code when two factor authentication is enabled"
 // @Success      204		{object}    responses.NoContent
 // @Failure      400		{object}	responses.BadRequest
 // @Failure 	 401    	{object}	responses.Unauthorized
@@ -135,6 +146,7 @@ func (h *WebhookHandler) Delete(c *fiber.Ctx) error {
 // @Accept       json
 // @Produce      json
 // @Param        payload   	body 		requests.WebhookStore  		true "Payload of the webhook request"
+// @Param        x-2fa-code	header		string							false	"TOTP or recovery code when two factor authentication is enabled"
 // @Success      200 		{object}	responses.WebhookResponse
 // @Failure      400		{object}	responses.BadRequest
 // @Failure 	 401	    {object}	responses.Unauthorized
@@ -190,6 +202,7 @@ func (h *WebhookHandler) Store(c *fiber.Ctx) error {
 // @Produce      json
 // @Param 		 webhookID	path		string 							true 	"ID of the webhook" 					default(32343a19-da5e-4b1b-a767-3298a73703ca)
 // @Param        payload   	body 		requests.WebhookUpdate  		true 	"Payload of webhook details to update"
+// @Param        x-2fa-code	header		string							false	"TOTP or recovery code when two factor authentication is enabled"
 // @Success      200 		{object}	responses.WebhookResponse
 // @Failure      400		{object}	responses.BadRequest
 // @Failure 	 401    	{object}	responses.Unauthorized
@@ -223,3 +236,327 @@ func (h *WebhookHandler) Update(c *fiber.Ctx) error {
 
 	return h.responseOK(c, "webhook updated successfully", user)
 }
+
+// Deliveries returns the deliveries of a webhook
+// @Summary      Get the deliveries of a webhook
+// @Description  Get the attempts of sending events to a webhook, deliveries with the "failed" status are in the dead-letter queue
+// @Security	 ApiKeyAuth
+// @Tags         Webhooks
+// @Accept       json
+// @Produce      json
+// @Param 		 webhookID 	path		string 	true 	"ID of the webhook"	default(32343a19-da5e-4b1b-a767-3298a73703ca)
+// @Param        skip		query  		int  	false	"number of deliveries to skip"		minimum(0)
+// @Param        limit		query  		int  	false	"number of deliveries to return"	minimum(1)	maximum(100)
+// @Param        status		query  		string 	false	"filter deliveries by status"	Enums(pending, retrying, succeeded, held, failed)
+// @Success      200 		{object}	responses.WebhookDeliveriesResponse
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401	    {object}	responses.Unauthorized
+// @Failure 	 404	    {object}	responses.NotFound
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /webhooks/{webhookID}/deliveries [get]
+func (h *WebhookHandler) Deliveries(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	var request requests.WebhookDeliveryIndex
+	if err := c.QueryParser(&request); err != nil {
+		msg := fmt.Sprintf("cannot marshall URL [%s] into %T", c.OriginalURL(), request)
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return h.responseBadRequest(c, err)
+	}
+
+	request.WebhookID = c.Params("webhookID")
+	if errors := h.validator.ValidateDeliveryIndex(ctx, request.Sanitize()); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while fetching webhook deliveries [%+#v]", spew.Sdump(errors), request)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while fetching webhook deliveries")
+	}
+
+	deliveries, err := h.service.IndexDeliveries(ctx, request.ToIndexParams(h.userIDFomContext(c)))
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		return h.responseNotFound(c, fmt.Sprintf("cannot find webhook with ID [%s]", request.WebhookID))
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot get webhook deliveries with params [%+#v]", request)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseOK(c, fmt.Sprintf("fetched %d webhook deliveries", len(deliveries)), deliveries)
+}
+
+// Failures returns the unresolved webhook failures of a user
+// @Summary      Get unresolved webhook failures
+// @Description  Get the deliveries of all the webhooks of a user which failed after all the attempts and were not replayed successfully
+// @Security	 ApiKeyAuth
+// @Tags         Webhooks
+// @Accept       json
+// @Produce      json
+// @Param        skip		query  		int  	false	"number of failures to skip"		minimum(0)
+// @Param        limit		query  		int  	false	"number of failures to return"	minimum(1)	maximum(100)
+// @Success      200 		{object}	responses.WebhookDeliveriesResponse
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401	    {object}	responses.Unauthorized
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /webhooks/failures [get]
+func (h *WebhookHandler) Failures(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	var request requests.WebhookFailureIndex
+	if err := c.QueryParser(&request); err != nil {
+		msg := fmt.Sprintf("cannot marshall URL [%s] into %T", c.OriginalURL(), request)
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return h.responseBadRequest(c, err)
+	}
+
+	if errors := h.validator.ValidateFailureIndex(ctx, request.Sanitize()); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while fetching webhook failures [%+#v]", spew.Sdump(errors), request)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while fetching webhook failures")
+	}
+
+	deliveries, err := h.service.IndexFailures(ctx, h.userIDFomContext(c), request.ToIndexParams())
+	if err != nil {
+		msg := fmt.Sprintf("cannot get webhook failures with params [%+#v]", request)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseOK(c, fmt.Sprintf("fetched %d webhook %s", len(deliveries), h.pluralize("failure", len(deliveries))), deliveries)
+}
+
+// Replay a webhook delivery
+// @Summary      Replay a webhook delivery
+// @Description  Send the payload of a delivery to the webhook again, this is used to recover failed deliveries from the dead-letter queue
+// @Security	 ApiKeyAuth
+// @Tags         Webhooks
+// @Accept       json
+// @Produce      json
+// @Param 		 webhookID 	path		string 	true 	"ID of the webhook"		default(32343a19-da5e-4b1b-a767-3298a73703ca)
+// @Param 		 deliveryID path		string 	true 	"ID of the delivery"	default(32343a19-da5e-4b1b-a767-3298a73703cb)
+// @Success      200 		{object}	responses.WebhookDeliveryResponse
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401	    {object}	responses.Unauthorized
+// @Failure 	 403	    {object}	responses.Unauthorized
+// @Failure 	 404	    {object}	responses.NotFound
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /webhooks/{webhookID}/deliveries/{deliveryID}/replay [post]
+func (h *WebhookHandler) Replay(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	webhookID, deliveryID := c.Params("webhookID"), c.Params("deliveryID")
+	for name, ID := range map[string]string{"webhookID": webhookID, "deliveryID": deliveryID} {
+		if errors := h.validator.ValidateUUID(ctx, ID, name); len(errors) != 0 {
+			msg := fmt.Sprintf("validation errors [%s], while replaying delivery [%s] of webhook [%s]", spew.Sdump(errors), deliveryID, webhookID)
+			ctxLogger.Warn(stacktrace.NewError(msg))
+			return h.responseUnprocessableEntity(c, errors, "validation errors while replaying webhook delivery")
+		}
+	}
+
+	delivery, err := h.service.ReplayDelivery(ctx, h.userIDFomContext(c), uuid.MustParse(webhookID), uuid.MustParse(deliveryID))
+	if stacktrace.GetCode(err) == services.ErrCodeUserSuspended {
+		ctxLogger.Warn(stacktrace.Propagate(err, fmt.Sprintf("suspended user [%s] cannot replay delivery [%s]", h.userIDFomContext(c), deliveryID)))
+		return h.responseAccountSuspended(c)
+	}
+
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		return h.responseNotFound(c, fmt.Sprintf("cannot find delivery with ID [%s] for webhook [%s]", deliveryID, webhookID))
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot replay delivery [%s] of webhook [%s]", deliveryID, webhookID)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseOK(c, fmt.Sprintf("webhook delivery replayed with status [%s]", delivery.Status), delivery)
+}
+
+// RotateKey rotates the signing key of a webhook
+// @Summary      Rotate the signing key of a webhook
+// @Description  Makes a new signing key the primary key of the webhook and keeps the current key as the secondary key. Payloads are signed with both keys in the X-Httpsms-Signature header and the bearer token is signed with the secondary key until the rotation is confirmed. The key cannot be rotated again until the rotation is confirmed. A random key is generated when the signing_key is empty, a supplied signing_key must have at least 32 characters.
+// @Security	 ApiKeyAuth
+// @Tags         Webhooks
+// @Accept       json
+// @Produce      json
+// @Param 		 webhookID 	path		string 						true 	"ID of the webhook"		default(32343a19-da5e-4b1b-a767-3298a73703ca)
+// @Param        payload   	body 		requests.WebhookRotateKey  	false 	"New signing key"
+// @Param        x-2fa-code	header		string							false	"TOTP or recovery code when two factor authentication is enabled"
+// @Success      200 		{object}	responses.WebhookResponse
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401	    {object}	responses.Unauthorized
+// @Failure 	 404	    {object}	responses.NotFound
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /webhooks/{webhookID}/rotate-key [post]
+func (h *WebhookHandler) RotateKey(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	var request requests.WebhookRotateKey
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&request); err != nil {
+			msg := fmt.Sprintf("cannot marshall params [%s] into %T", c.OriginalURL(), request)
+			ctxLogger.Warn(stacktrace.Propagate(err, msg))
+			return h.responseBadRequest(c, err)
+		}
+	}
+
+	request.WebhookID = c.Params("webhookID")
+	if errors := h.validator.ValidateRotateKey(ctx, request.Sanitize()); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while rotating webhook key [%s]", spew.Sdump(errors), request.WebhookID)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while rotating webhook signing key")
+	}
+
+	webhook, err := h.service.RotateKey(ctx, request.ToRotateKeyParams(h.userIDFomContext(c)))
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		return h.responseNotFound(c, fmt.Sprintf("cannot find webhook with ID [%s]", request.WebhookID))
+	}
+
+	if stacktrace.GetCode(err) == services.ErrCodeWebhookRotationPending {
+		return h.responseUnprocessableEntity(c, url.Values{"signing_key": []string{"confirm the previous key rotation before rotating the signing key again"}}, "validation errors while rotating webhook signing key")
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot rotate signing key of webhook [%s]", request.WebhookID)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseOK(c, "webhook signing key rotated successfully", webhook)
+}
+
+// ConfirmKeyRotation confirms the rotation of the signing key of a webhook
+// @Summary      Confirm the rotation of the signing key of a webhook
+// @Description  Removes the secondary signing key of the webhook after the receiver verifies requests with the primary key. The payloads and the bearer token are then signed with the primary key only.
+// @Security	 ApiKeyAuth
+// @Tags         Webhooks
+// @Accept       json
+// @Produce      json
+// @Param 		 webhookID 	path		string 							true 	"ID of the webhook"		default(32343a19-da5e-4b1b-a767-3298a73703ca)
+// @Param        x-2fa-code	header		string							false	"TOTP or recovery code when two factor authentication is enabled"
+// @Success      200 		{object}	responses.WebhookResponse
+// @Failure 	 401	    {object}	responses.Unauthorized
+// @Failure 	 404	    {object}	responses.NotFound
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /webhooks/{webhookID}/rotate-key/confirm [post]
+func (h *WebhookHandler) ConfirmKeyRotation(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	webhookID := c.Params("webhookID")
+	if errors := h.validator.ValidateUUID(ctx, webhookID, "webhookID"); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while confirming key rotation of webhook [%s]", spew.Sdump(errors), webhookID)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while confirming webhook key rotation")
+	}
+
+	webhook, err := h.service.ConfirmKeyRotation(ctx, h.userIDFomContext(c), uuid.MustParse(webhookID))
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		return h.responseNotFound(c, fmt.Sprintf("cannot find webhook with ID [%s]", webhookID))
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot confirm key rotation of webhook [%s]", webhookID)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseOK(c, "webhook key rotation confirmed successfully", webhook)
+}
+
+// Fixtures returns the canonical payloads of the webhook events
+// @Summary      Get webhook fixtures
+// @Description  Get a sample payload of every event type which webhooks can subscribe to in every webhook version. The IDs and timestamps never change so the fixtures can be stored and replayed in the tests of a webhook consumer.
+// @Security	 ApiKeyAuth
+// @Tags         Webhooks
+// @Accept       json
+// @Produce      json
+// @Success      200 		{object}	responses.WebhookFixturesResponse
+// @Failure 	 401	    {object}	responses.Unauthorized
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /webhooks/fixtures [get]
+func (h *WebhookHandler) Fixtures(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	fixtures, err := h.service.Fixtures(ctx, h.userIDFomContext(c))
+	if err != nil {
+		msg := fmt.Sprintf("cannot get webhook fixtures for user [%s]", h.userIDFomContext(c))
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseOK(c, fmt.Sprintf("fetched %d webhook %s", len(fixtures), h.pluralize("fixture", len(fixtures))), fixtures)
+}
+
+// ContractTest sends the webhook fixtures to a consumer
+// @Summary      Run a webhook contract test
+// @Description  Send the signed fixtures of every event type and webhook version to the URL of the webhook one after the other and report which ones the consumer accepted with a 2xx status code. The fixtures are sent to the url in the payload instead when it is set. The deliveries are not retried and they are not stored in the delivery log.
+// @Security	 ApiKeyAuth
+// @Tags         Webhooks
+// @Accept       json
+// @Produce      json
+// @Param 		 webhookID 	path		string 							true 	"ID of the webhook"		default(32343a19-da5e-4b1b-a767-3298a73703ca)
+// @Param        payload   	body 		requests.WebhookContractTest  	false 	"Contract test request payload"
+// @Success      200 		{object}	responses.WebhookContractResultsResponse
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401	    {object}	responses.Unauthorized
+// @Failure 	 403	    {object}	responses.Unauthorized
+// @Failure 	 404	    {object}	responses.NotFound
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /webhooks/{webhookID}/contract-test [post]
+func (h *WebhookHandler) ContractTest(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	var request requests.WebhookContractTest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&request); err != nil {
+			msg := fmt.Sprintf("cannot marshall params [%s] into %T", c.OriginalURL(), request)
+			ctxLogger.Warn(stacktrace.Propagate(err, msg))
+			return h.responseBadRequest(c, err)
+		}
+	}
+
+	request.WebhookID = c.Params("webhookID")
+	if errors := h.validator.ValidateContractTest(ctx, request.Sanitize()); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while running contract test for webhook [%s]", spew.Sdump(errors), request.WebhookID)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while running webhook contract test")
+	}
+
+	results, err := h.service.ContractTest(ctx, request.ToContractTestParams(h.userIDFomContext(c)))
+	if stacktrace.GetCode(err) == services.ErrCodeUserSuspended {
+		ctxLogger.Warn(stacktrace.Propagate(err, fmt.Sprintf("suspended user [%s] cannot run contract test for webhook [%s]", h.userIDFomContext(c), request.WebhookID)))
+		return h.responseAccountSuspended(c)
+	}
+
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		return h.responseNotFound(c, fmt.Sprintf("cannot find webhook with ID [%s]", request.WebhookID))
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot run contract test for webhook [%s]", request.WebhookID)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	accepted := 0
+	for _, result := range results {
+		if result.Accepted {
+			accepted++
+		}
+	}
+
+	return h.responseOK(c, fmt.Sprintf("consumer accepted %d out of %d webhook %s", accepted, len(results), h.pluralize("fixture", len(results))), results)
+}
@@ -0,0 +1,294 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/middlewares"
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
+	"github.com/NdoleStudio/httpsms/pkg/requests"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/NdoleStudio/httpsms/pkg/validators"
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/davecgh/go-spew/spew"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/websocket/v2"
+	"github.com/palantir/stacktrace"
+)
+
+const (
+	// webSocketPingInterval is the interval of the pings which keep an idle connection open
+	webSocketPingInterval = 30 * time.Second
+
+	// webSocketPongTimeout is the time to wait for a pong or a message before the connection is closed
+	webSocketPongTimeout = 60 * time.Second
+
+	// webSocketWriteTimeout is the time to wait for a message to be written to the connection
+	webSocketWriteTimeout = 10 * time.Second
+
+	// webSocketResumeLimit is the maximum number of missed events which are sent when a connection resumes
+	webSocketResumeLimit = 500
+
+	// webSocketReadLimit is the maximum size in bytes of a message sent by the client
+	webSocketReadLimit = 8 * 1024
+
+	// contextKeyWebSocketRequest is the fiber local used to pass the requests.WebSocketConnect to the connection
+	contextKeyWebSocketRequest = "websocket.request"
+)
+
+// webSocketMessage is a message sent by the server which is not an event e.g. the topics after a requests.WebSocketCommand
+type webSocketMessage struct {
+	Type    string     `json:"type"`
+	Topics  []string   `json:"topics,omitempty"`
+	Message string     `json:"message,omitempty"`
+	Errors  url.Values `json:"errors,omitempty"`
+}
+
+// WebSocketHandler pushes the events of a user to mobile apps and dashboards over a WebSocket connection
+type WebSocketHandler struct {
+	handler
+	logger       telemetry.Logger
+	tracer       telemetry.Tracer
+	hub          *services.EventHub
+	eventService *services.EventService
+	validator    *validators.WebSocketHandlerValidator
+}
+
+// NewWebSocketHandler creates a new WebSocketHandler
+func NewWebSocketHandler(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	hub *services.EventHub,
+	eventService *services.EventService,
+	validator *validators.WebSocketHandlerValidator,
+) (h *WebSocketHandler) {
+	return &WebSocketHandler{
+		logger:       logger.WithService(fmt.Sprintf("%T", h)),
+		tracer:       tracer,
+		hub:          hub,
+		eventService: eventService,
+		validator:    validator,
+	}
+}
+
+// RegisterRoutes registers the routes for the WebSocketHandler
+func (h *WebSocketHandler) RegisterRoutes(router fiber.Router) {
+	router.Get("/ws", h.Upgrade, websocket.New(h.Connect))
+}
+
+// Upgrade validates a WebSocket connection before the HTTP connection is upgraded
+// @Summary      Receive events over a WebSocket
+// @Description  Open a WebSocket connection to receive the events of a user in real time. Every event is sent as a cloudevent in a JSON text message, the server pings the connection every 30 seconds.
+// @Description  Send {"action": "subscribe", "topics": ["message.phone.received"]} or {"action": "unsubscribe", ...} to change the event types, and use the ID of the last event you received as the last_event_id when reconnecting to receive the events you missed.
+// @Description  Browsers which cannot set the x-api-key header can pass the API key in the api_key query parameter.
+// @Security	 ApiKeyAuth
+// @Tags         Events
+// @Param        topics			query  string  	false	"comma separated list of event types, every event is sent when it is empty"	default(message.phone.sent,message.phone.delivered,message.send.failed)
+// @Param        last_event_id	query  string  	false	"ID of the last event received before the connection was lost"	default(32343a19-da5e-4b1b-a767-3298a73703cb)
+// @Success      101 		{string}	string	"switching protocols"
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401	    {object}	responses.Unauthorized
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      426		{object}	responses.BadRequest
+// @Router       /ws 	[get]
+func (h *WebSocketHandler) Upgrade(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	if !websocket.IsWebSocketUpgrade(c) {
+		return c.Status(fiber.StatusUpgradeRequired).JSON(fiber.Map{
+			"status":  "error",
+			"message": "The request must be a WebSocket upgrade request",
+		})
+	}
+
+	var request requests.WebSocketConnect
+	if err := c.QueryParser(&request); err != nil {
+		msg := fmt.Sprintf("cannot marshall URL [%s] into %T", c.OriginalURL(), request)
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return h.responseBadRequest(c, err)
+	}
+
+	if errors := h.validator.ValidateConnect(ctx, request.Sanitize()); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while opening websocket [%+#v]", spew.Sdump(errors), request)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while opening websocket")
+	}
+
+	c.Locals(contextKeyWebSocketRequest, request)
+	return c.Next()
+}
+
+// Connect sends the events of the user to an upgraded WebSocket connection until it is closed
+func (h *WebSocketHandler) Connect(conn *websocket.Conn) {
+	ctx, span, ctxLogger := h.tracer.StartWithLogger(context.Background(), h.logger)
+	defer span.End()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	userID := conn.Locals(middlewares.ContextKeyAuthUserID).(entities.AuthUser).ID
+	request := conn.Locals(contextKeyWebSocketRequest).(requests.WebSocketConnect)
+
+	// subscribe before fetching the missed events so that no event is lost between the two
+	subscription := h.hub.Subscribe(userID)
+	defer h.hub.Unsubscribe(subscription)
+	defer func() { _ = conn.Close() }()
+
+	ctxLogger.Info(fmt.Sprintf("user [%s] connected to websocket with topics [%s]", userID, request.Topics))
+
+	topics := map[string]bool{}
+	for _, topic := range request.ToTopics() {
+		topics[topic] = true
+	}
+
+	commands := make(chan requests.WebSocketCommand)
+	closed := make(chan error, 1)
+	go h.read(ctx, conn, commands, closed)
+
+	sent := map[string]bool{}
+	err := h.resume(ctx, conn, userID, request, topics, sent)
+
+	ticker := time.NewTicker(webSocketPingInterval)
+	defer ticker.Stop()
+
+	for err == nil {
+		select {
+		case event, ok := <-subscription.Events():
+			if !ok {
+				err = stacktrace.NewError(fmt.Sprintf("subscription of user [%s] was closed", userID))
+				break
+			}
+			if sent[event.ID()] || !h.accepts(topics, event.Type()) {
+				continue
+			}
+			err = h.writeEvent(conn, event)
+		case command := <-commands:
+			err = h.apply(ctx, conn, command, topics)
+		case <-ticker.C:
+			err = conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(webSocketWriteTimeout))
+		case err = <-closed:
+		}
+	}
+
+	if websocket.IsUnexpectedCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway, websocket.CloseNoStatusReceived) {
+		ctxLogger.Warn(stacktrace.Propagate(err, fmt.Sprintf("websocket of user [%s] closed unexpectedly", userID)))
+	}
+	ctxLogger.Info(fmt.Sprintf("closed websocket of user [%s] with error [%v]", userID, err))
+}
+
+// read parses the commands sent by the client and extends the read deadline when a pong is received.
+// Only the Connect loop writes to the connection so invalid commands are passed to it to reply with the errors.
+func (h *WebSocketHandler) read(ctx context.Context, conn *websocket.Conn, commands chan<- requests.WebSocketCommand, closed chan<- error) {
+	conn.SetReadLimit(webSocketReadLimit)
+	_ = conn.SetReadDeadline(time.Now().Add(webSocketPongTimeout))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(webSocketPongTimeout))
+	})
+
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			closed <- err
+			return
+		}
+		_ = conn.SetReadDeadline(time.Now().Add(webSocketPongTimeout))
+
+		var command requests.WebSocketCommand
+		if err = json.Unmarshal(data, &command); err != nil {
+			h.logger.Warn(stacktrace.Propagate(err, fmt.Sprintf("cannot unmarshal [%s] into [%T]", data, command)))
+		}
+
+		select {
+		case commands <- command.Sanitize():
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// resume sends the events which happened after the requests.WebSocketConnect LastEventID and remembers their IDs in sent
+func (h *WebSocketHandler) resume(ctx context.Context, conn *websocket.Conn, userID entities.UserID, request requests.WebSocketConnect, topics map[string]bool, sent map[string]bool) error {
+	lastEventID := request.ToLastEventID()
+	if lastEventID == nil {
+		return nil
+	}
+
+	events, err := h.eventService.Missed(ctx, userID, *lastEventID, request.ToTopics(), webSocketResumeLimit)
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		return h.writeMessage(conn, webSocketMessage{Type: "websocket.error", Message: fmt.Sprintf("cannot find event with ID [%s] to resume from", lastEventID)})
+	}
+
+	if err != nil {
+		h.logger.Error(stacktrace.Propagate(err, fmt.Sprintf("cannot fetch events after [%s] for user [%s]", lastEventID, userID)))
+		return h.writeMessage(conn, webSocketMessage{Type: "websocket.error", Message: "We ran into an internal error while fetching the missed events."})
+	}
+
+	for _, event := range events {
+		if !h.accepts(topics, event.Type()) {
+			continue
+		}
+		if err = h.writeEvent(conn, event); err != nil {
+			return err
+		}
+		sent[event.ID()] = true
+	}
+
+	return nil
+}
+
+// apply adds or removes the topics of a requests.WebSocketCommand and replies with the topics of the connection
+func (h *WebSocketHandler) apply(ctx context.Context, conn *websocket.Conn, command requests.WebSocketCommand, topics map[string]bool) error {
+	if errors := h.validator.ValidateCommand(ctx, command); len(errors) != 0 {
+		return h.writeMessage(conn, webSocketMessage{Type: "websocket.error", Message: "validation errors while applying command", Errors: errors})
+	}
+
+	for _, topic := range command.Topics {
+		if command.Action == "subscribe" {
+			topics[topic] = true
+		} else {
+			delete(topics, topic)
+		}
+	}
+
+	current := make([]string, 0, len(topics))
+	for topic := range topics {
+		current = append(current, topic)
+	}
+	return h.writeMessage(conn, webSocketMessage{Type: "websocket.topics", Topics: current})
+}
+
+// accepts checks if a connection receives an event type, a connection without topics receives every event
+func (h *WebSocketHandler) accepts(topics map[string]bool, eventType string) bool {
+	return len(topics) == 0 || topics[eventType]
+}
+
+// writeEvent writes a cloudevent as a JSON text message
+func (h *WebSocketHandler) writeEvent(conn *websocket.Conn, event cloudevents.Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return stacktrace.Propagate(err, fmt.Sprintf("cannot marshal event [%s] into JSON", event.ID()))
+	}
+	return h.write(conn, data)
+}
+
+// writeMessage writes a webSocketMessage as a JSON text message
+func (h *WebSocketHandler) writeMessage(conn *websocket.Conn, message webSocketMessage) error {
+	data, err := json.Marshal(message)
+	if err != nil {
+		return stacktrace.Propagate(err, fmt.Sprintf("cannot marshal [%T] into JSON", message))
+	}
+	return h.write(conn, data)
+}
+
+func (h *WebSocketHandler) write(conn *websocket.Conn, data []byte) error {
+	if err := conn.SetWriteDeadline(time.Now().Add(webSocketWriteTimeout)); err != nil {
+		return stacktrace.Propagate(err, "cannot set write deadline of websocket")
+	}
+	return conn.WriteMessage(websocket.TextMessage, data)
+}
@@ -0,0 +1,45 @@
+package identity
+
+import (
+	"context"
+	"fmt"
+
+	"firebase.google.com/go/auth"
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/palantir/stacktrace"
+)
+
+// FirebaseProvider verifies Firebase ID tokens
+type FirebaseProvider struct {
+	tracer telemetry.Tracer
+	client *auth.Client
+}
+
+// NewFirebaseProvider creates a new instance of FirebaseProvider
+func NewFirebaseProvider(tracer telemetry.Tracer, client *auth.Client) *FirebaseProvider {
+	return &FirebaseProvider{
+		tracer: tracer,
+		client: client,
+	}
+}
+
+// Verify a Firebase ID token
+func (provider *FirebaseProvider) Verify(ctx context.Context, token string) (entities.AuthUser, error) {
+	ctx, span := provider.tracer.Start(ctx)
+	defer span.End()
+
+	idToken, err := provider.client.VerifyIDToken(ctx, token)
+	if err != nil {
+		return entities.AuthUser{}, provider.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, fmt.Sprintf("invalid firebase id token [%s]", token)))
+	}
+
+	email, _ := idToken.Claims["email"].(string)
+	userID, _ := idToken.Claims["user_id"].(string)
+
+	return entities.AuthUser{
+		Email:     email,
+		ID:        entities.UserID(userID),
+		SessionID: fmt.Sprintf("firebase:%s:%d", userID, idToken.AuthTime),
+	}, nil
+}
@@ -0,0 +1,13 @@
+package identity
+
+import (
+	"context"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+)
+
+// Provider verifies the bearer tokens issued to users by an identity backend e.g. Firebase
+type Provider interface {
+	// Verify a bearer token and return the entities.AuthUser which it was issued to
+	Verify(ctx context.Context, token string) (entities.AuthUser, error)
+}
@@ -0,0 +1,96 @@
+package identity
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/golang-jwt/jwt"
+	"github.com/palantir/stacktrace"
+)
+
+// JWTProvider issues and verifies HS256 tokens signed with a shared secret.
+// It is used by self-hosted deployments which do not have an external identity backend.
+type JWTProvider struct {
+	tracer telemetry.Tracer
+	secret []byte
+	issuer string
+}
+
+// JWTClaims are the claims of a token issued by the JWTProvider
+type JWTClaims struct {
+	jwt.StandardClaims
+	Email string `json:"email"`
+}
+
+// NewJWTProvider creates a new instance of JWTProvider
+func NewJWTProvider(tracer telemetry.Tracer, secret string, issuer string) *JWTProvider {
+	return &JWTProvider{
+		tracer: tracer,
+		secret: []byte(secret),
+		issuer: issuer,
+	}
+}
+
+// Issue a token for an entities.AuthUser which is valid for as long as the entities.AuthSession is active
+func (provider *JWTProvider) Issue(user entities.AuthUser, session *entities.AuthSession) (string, error) {
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, JWTClaims{
+		StandardClaims: jwt.StandardClaims{
+			Id:        session.ID.String(),
+			Subject:   string(user.ID),
+			Issuer:    provider.issuer,
+			IssuedAt:  session.CreatedAt.Unix(),
+			ExpiresAt: session.ExpiresAt.Unix(),
+		},
+		Email: user.Email,
+	})
+
+	signed, err := token.SignedString(provider.secret)
+	if err != nil {
+		return "", stacktrace.Propagate(err, fmt.Sprintf("cannot sign token for user [%s]", user.ID))
+	}
+
+	return signed, nil
+}
+
+// Verify a token which was issued by the JWTProvider.
+// The "jti" claim is the ID of the entities.AuthSession created at login, it is checked by the auth middleware.
+func (provider *JWTProvider) Verify(ctx context.Context, token string) (entities.AuthUser, error) {
+	ctx, span := provider.tracer.Start(ctx)
+	defer span.End()
+
+	claims, err := provider.Parse(ctx, token)
+	if err != nil {
+		return entities.AuthUser{}, provider.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, "cannot parse jwt token"))
+	}
+
+	return entities.AuthUser{
+		Email:     claims.Email,
+		ID:        entities.UserID(claims.Subject),
+		SessionID: claims.Id,
+	}, nil
+}
+
+// Parse validates the signature and issuer of a token and returns its JWTClaims
+func (provider *JWTProvider) Parse(ctx context.Context, token string) (*JWTClaims, error) {
+	_, span := provider.tracer.Start(ctx)
+	defer span.End()
+
+	claims := new(JWTClaims)
+	_, err := jwt.ParseWithClaims(token, claims, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, stacktrace.NewError(fmt.Sprintf("unexpected signing method [%s]", token.Header["alg"]))
+		}
+		return provider.secret, nil
+	})
+	if err != nil {
+		return nil, provider.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, "cannot parse jwt token"))
+	}
+
+	if !claims.VerifyIssuer(provider.issuer, true) {
+		return nil, provider.tracer.WrapErrorSpan(span, stacktrace.NewError(fmt.Sprintf("token was issued by [%s] instead of [%s]", claims.Issuer, provider.issuer)))
+	}
+
+	return claims, nil
+}
@@ -0,0 +1,67 @@
+package identity
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/coreos/go-oidc/v3/oidc"
+	"github.com/palantir/stacktrace"
+)
+
+// OIDCProvider verifies the ID tokens of an OpenID Connect issuer e.g. Keycloak, Authentik or Dex.
+// LDAP directories are supported through an issuer which federates them since LDAP does not issue bearer tokens.
+type OIDCProvider struct {
+	tracer   telemetry.Tracer
+	verifier *oidc.IDTokenVerifier
+	claim    string
+}
+
+// NewOIDCProvider discovers the signing keys of the issuer and creates a new instance of OIDCProvider.
+// The claim is the name of the ID token claim which contains the ID of the user e.g. "sub".
+func NewOIDCProvider(ctx context.Context, tracer telemetry.Tracer, issuerURL string, clientID string, claim string) (*OIDCProvider, error) {
+	provider, err := oidc.NewProvider(ctx, issuerURL)
+	if err != nil {
+		return nil, stacktrace.Propagate(err, fmt.Sprintf("cannot discover OIDC issuer [%s]", issuerURL))
+	}
+
+	return &OIDCProvider{
+		tracer:   tracer,
+		verifier: provider.Verifier(&oidc.Config{ClientID: clientID}),
+		claim:    claim,
+	}, nil
+}
+
+// Verify an ID token issued by the OIDC issuer
+func (provider *OIDCProvider) Verify(ctx context.Context, token string) (entities.AuthUser, error) {
+	ctx, span := provider.tracer.Start(ctx)
+	defer span.End()
+
+	idToken, err := provider.verifier.Verify(ctx, token)
+	if err != nil {
+		return entities.AuthUser{}, provider.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, "cannot verify OIDC id token"))
+	}
+
+	claims := map[string]interface{}{}
+	if err = idToken.Claims(&claims); err != nil {
+		return entities.AuthUser{}, provider.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, fmt.Sprintf("cannot decode claims of token for subject [%s]", idToken.Subject)))
+	}
+
+	userID, _ := claims[provider.claim].(string)
+	email, _ := claims["email"].(string)
+
+	// the "sid" claim is only set by issuers which support session management, "auth_time" identifies the sign-in otherwise
+	sessionID := ""
+	if sid, ok := claims["sid"].(string); ok && sid != "" {
+		sessionID = fmt.Sprintf("oidc:%s", sid)
+	} else if authTime, ok := claims["auth_time"].(float64); ok {
+		sessionID = fmt.Sprintf("oidc:%s:%d", userID, int64(authTime))
+	}
+
+	return entities.AuthUser{
+		Email:     email,
+		ID:        entities.UserID(userID),
+		SessionID: sessionID,
+	}, nil
+}
@@ -0,0 +1,229 @@
+package listeners
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/events"
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/palantir/stacktrace"
+)
+
+// AlertChannelListener posts events to the slack and discord entities.AlertChannel of users
+type AlertChannelListener struct {
+	listener
+	logger            telemetry.Logger
+	tracer            telemetry.Tracer
+	service           *services.AlertChannelService
+	preferenceService *services.NotificationPreferenceService
+}
+
+// NewAlertChannelListener creates a new instance of AlertChannelListener
+func NewAlertChannelListener(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	service *services.AlertChannelService,
+	preferenceService *services.NotificationPreferenceService,
+	repository repositories.EventListenerLogRepository,
+) (l *AlertChannelListener, routes map[string]events.EventListener) {
+	l = &AlertChannelListener{
+		logger:            logger.WithService(fmt.Sprintf("%T", l)),
+		tracer:            tracer,
+		service:           service,
+		preferenceService: preferenceService,
+		listener: listener{
+			repository: repository,
+		},
+	}
+
+	return l, map[string]events.EventListener{
+		events.EventTypeMessageSendFailed:       l.onMessageSendFailed,
+		events.EventTypePhoneHeartbeatDead:      l.onPhoneHeartbeatDead,
+		events.EventTypeMessagePhoneReceived:    l.onMessagePhoneReceived,
+		events.EventTypePhoneReputationDegraded: l.onPhoneReputationDegraded,
+		events.EventTypePhoneFilteringSuspected: l.onPhoneFilteringSuspected,
+	}
+}
+
+// onMessageSendFailed handles the events.EventTypeMessageSendFailed event
+func (listener *AlertChannelListener) onMessageSendFailed(ctx context.Context, event cloudevents.Event) error {
+	ctx, span, ctxLogger := listener.tracer.StartWithLogger(ctx, listener.logger)
+	defer span.End()
+
+	handled, err := listener.repository.Has(ctx, event.ID(), listener.signature(event))
+	if err != nil {
+		msg := fmt.Sprintf("cannot verify if event [%s] has been handled by [%T]", event.ID(), listener.signature(event))
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if handled {
+		ctxLogger.Info(fmt.Sprintf("event [%s] has already been handled by [%s]", event.ID(), listener.signature(event)))
+		return nil
+	}
+
+	var payload events.MessageSendFailedPayload
+	if err = event.DataAs(&payload); err != nil {
+		msg := fmt.Sprintf("cannot decode [%s] into [%T]", event.Data(), payload)
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if !listener.preferenceService.IsEnabled(ctx, payload.UserID, event.Type(), entities.NotificationChannelIntegration) {
+		ctxLogger.Info(fmt.Sprintf("[%s] notifications for event [%s] are disabled by user [%s]", entities.NotificationChannelIntegration, event.Type(), payload.UserID))
+		return nil
+	}
+
+	if err = listener.service.HandleMessageSendFailed(ctx, &payload); err != nil {
+		msg := fmt.Sprintf("cannot post [%s] event with ID [%s] to alert channels", event.Type(), event.ID())
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return listener.storeEventListenerLog(ctx, listener.signature(event), event)
+}
+
+// onPhoneHeartbeatDead handles the events.EventTypePhoneHeartbeatDead event
+func (listener *AlertChannelListener) onPhoneHeartbeatDead(ctx context.Context, event cloudevents.Event) error {
+	ctx, span, ctxLogger := listener.tracer.StartWithLogger(ctx, listener.logger)
+	defer span.End()
+
+	handled, err := listener.repository.Has(ctx, event.ID(), listener.signature(event))
+	if err != nil {
+		msg := fmt.Sprintf("cannot verify if event [%s] has been handled by [%T]", event.ID(), listener.signature(event))
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if handled {
+		ctxLogger.Info(fmt.Sprintf("event [%s] has already been handled by [%s]", event.ID(), listener.signature(event)))
+		return nil
+	}
+
+	var payload events.PhoneHeartbeatDeadPayload
+	if err = event.DataAs(&payload); err != nil {
+		msg := fmt.Sprintf("cannot decode [%s] into [%T]", event.Data(), payload)
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if !listener.preferenceService.IsEnabled(ctx, payload.UserID, event.Type(), entities.NotificationChannelIntegration) {
+		ctxLogger.Info(fmt.Sprintf("[%s] notifications for event [%s] are disabled by user [%s]", entities.NotificationChannelIntegration, event.Type(), payload.UserID))
+		return nil
+	}
+
+	if err = listener.service.HandlePhoneHeartbeatDead(ctx, &payload); err != nil {
+		msg := fmt.Sprintf("cannot post [%s] event with ID [%s] to alert channels", event.Type(), event.ID())
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return listener.storeEventListenerLog(ctx, listener.signature(event), event)
+}
+
+// onMessagePhoneReceived handles the events.EventTypeMessagePhoneReceived event
+func (listener *AlertChannelListener) onMessagePhoneReceived(ctx context.Context, event cloudevents.Event) error {
+	ctx, span, ctxLogger := listener.tracer.StartWithLogger(ctx, listener.logger)
+	defer span.End()
+
+	handled, err := listener.repository.Has(ctx, event.ID(), listener.signature(event))
+	if err != nil {
+		msg := fmt.Sprintf("cannot verify if event [%s] has been handled by [%T]", event.ID(), listener.signature(event))
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if handled {
+		ctxLogger.Info(fmt.Sprintf("event [%s] has already been handled by [%s]", event.ID(), listener.signature(event)))
+		return nil
+	}
+
+	var payload events.MessagePhoneReceivedPayload
+	if err = event.DataAs(&payload); err != nil {
+		msg := fmt.Sprintf("cannot decode [%s] into [%T]", event.Data(), payload)
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if !listener.preferenceService.IsEnabled(ctx, payload.UserID, event.Type(), entities.NotificationChannelIntegration) {
+		ctxLogger.Info(fmt.Sprintf("[%s] notifications for event [%s] are disabled by user [%s]", entities.NotificationChannelIntegration, event.Type(), payload.UserID))
+		return nil
+	}
+
+	if err = listener.service.HandleMessageReceived(ctx, &payload); err != nil {
+		msg := fmt.Sprintf("cannot post [%s] event with ID [%s] to alert channels", event.Type(), event.ID())
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return listener.storeEventListenerLog(ctx, listener.signature(event), event)
+}
+
+// onPhoneReputationDegraded handles the events.EventTypePhoneReputationDegraded event
+func (listener *AlertChannelListener) onPhoneReputationDegraded(ctx context.Context, event cloudevents.Event) error {
+	ctx, span, ctxLogger := listener.tracer.StartWithLogger(ctx, listener.logger)
+	defer span.End()
+
+	handled, err := listener.repository.Has(ctx, event.ID(), listener.signature(event))
+	if err != nil {
+		msg := fmt.Sprintf("cannot verify if event [%s] has been handled by [%T]", event.ID(), listener.signature(event))
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if handled {
+		ctxLogger.Info(fmt.Sprintf("event [%s] has already been handled by [%s]", event.ID(), listener.signature(event)))
+		return nil
+	}
+
+	var payload events.PhoneReputationDegradedPayload
+	if err = event.DataAs(&payload); err != nil {
+		msg := fmt.Sprintf("cannot decode [%s] into [%T]", event.Data(), payload)
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if !listener.preferenceService.IsEnabled(ctx, payload.UserID, event.Type(), entities.NotificationChannelIntegration) {
+		ctxLogger.Info(fmt.Sprintf("[%s] notifications for event [%s] are disabled by user [%s]", entities.NotificationChannelIntegration, event.Type(), payload.UserID))
+		return nil
+	}
+
+	if err = listener.service.HandlePhoneReputationDegraded(ctx, &payload); err != nil {
+		msg := fmt.Sprintf("cannot post [%s] event with ID [%s] to alert channels", event.Type(), event.ID())
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return listener.storeEventListenerLog(ctx, listener.signature(event), event)
+}
+
+// onPhoneFilteringSuspected handles the events.EventTypePhoneFilteringSuspected event
+func (listener *AlertChannelListener) onPhoneFilteringSuspected(ctx context.Context, event cloudevents.Event) error {
+	ctx, span, ctxLogger := listener.tracer.StartWithLogger(ctx, listener.logger)
+	defer span.End()
+
+	handled, err := listener.repository.Has(ctx, event.ID(), listener.signature(event))
+	if err != nil {
+		msg := fmt.Sprintf("cannot verify if event [%s] has been handled by [%T]", event.ID(), listener.signature(event))
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if handled {
+		ctxLogger.Info(fmt.Sprintf("event [%s] has already been handled by [%s]", event.ID(), listener.signature(event)))
+		return nil
+	}
+
+	var payload events.PhoneFilteringSuspectedPayload
+	if err = event.DataAs(&payload); err != nil {
+		msg := fmt.Sprintf("cannot decode [%s] into [%T]", event.Data(), payload)
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if !listener.preferenceService.IsEnabled(ctx, payload.UserID, event.Type(), entities.NotificationChannelIntegration) {
+		ctxLogger.Info(fmt.Sprintf("[%s] notifications for event [%s] are disabled by user [%s]", entities.NotificationChannelIntegration, event.Type(), payload.UserID))
+		return nil
+	}
+
+	if err = listener.service.HandlePhoneFilteringSuspected(ctx, &payload); err != nil {
+		msg := fmt.Sprintf("cannot post [%s] event with ID [%s] to alert channels", event.Type(), event.ID())
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return listener.storeEventListenerLog(ctx, listener.signature(event), event)
+}
+
+func (listener *AlertChannelListener) signature(event cloudevents.Event) string {
+	return listener.handlerSignature(listener, event)
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/davecgh/go-spew/spew"
 
 	"github.com/NdoleStudio/httpsms/pkg/events"
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
 	"github.com/NdoleStudio/httpsms/pkg/services"
 	"github.com/NdoleStudio/httpsms/pkg/telemetry"
 	cloudevents "github.com/cloudevents/sdk-go/v2"
@@ -15,6 +16,7 @@ import (
 
 // BillingListener handles cloud events which affect billing
 type BillingListener struct {
+	listener
 	logger  telemetry.Logger
 	tracer  telemetry.Tracer
 	service *services.BillingService
@@ -25,11 +27,15 @@ func NewBillingListener(
 	logger telemetry.Logger,
 	tracer telemetry.Tracer,
 	service *services.BillingService,
+	repository repositories.EventListenerLogRepository,
 ) (l *BillingListener, routes map[string]events.EventListener) {
 	l = &BillingListener{
 		logger:  logger.WithService(fmt.Sprintf("%T", l)),
 		tracer:  tracer,
 		service: service,
+		listener: listener{
+			repository: repository,
+		},
 	}
 
 	return l, map[string]events.EventListener{
@@ -43,18 +49,32 @@ func (listener *BillingListener) OnMessageAPISent(ctx context.Context, event clo
 	ctx, span := listener.tracer.Start(ctx)
 	defer span.End()
 
+	handled, err := listener.repository.Has(ctx, event.ID(), listener.signature(event))
+	if err != nil {
+		msg := fmt.Sprintf("cannot verify if event [%s] has been handled by [%T]", event.ID(), listener.signature(event))
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger := listener.tracer.CtxLogger(listener.logger, span)
+
+	// usage is incremented without a reference to the message so an event must not be counted twice when it is replayed
+	if handled {
+		ctxLogger.Info(fmt.Sprintf("event [%s] has already been handled by [%s]", event.ID(), listener.signature(event)))
+		return nil
+	}
+
 	var payload events.MessageAPISentPayload
-	if err := event.DataAs(&payload); err != nil {
+	if err = event.DataAs(&payload); err != nil {
 		msg := fmt.Sprintf("cannot decode [%s] into [%T]", event.Data(), payload)
 		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
 	}
 
-	if err := listener.service.RegisterSentMessage(ctx, payload.MessageID, payload.RequestReceivedAt, payload.UserID); err != nil {
+	if err = listener.service.RegisterSentMessage(ctx, payload.MessageID, payload.RequestReceivedAt, payload.UserID); err != nil {
 		msg := fmt.Sprintf("cannot register sent message for event [%s] for event with ID [%s]", spew.Sdump(payload), event.ID())
 		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
 	}
 
-	return nil
+	return listener.storeEventListenerLog(ctx, listener.signature(event), event)
 }
 
 // OnMessagePhoneReceived handles the events.EventTypeMessagePhoneReceived event
@@ -62,16 +82,33 @@ func (listener *BillingListener) OnMessagePhoneReceived(ctx context.Context, eve
 	ctx, span := listener.tracer.Start(ctx)
 	defer span.End()
 
+	handled, err := listener.repository.Has(ctx, event.ID(), listener.signature(event))
+	if err != nil {
+		msg := fmt.Sprintf("cannot verify if event [%s] has been handled by [%T]", event.ID(), listener.signature(event))
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger := listener.tracer.CtxLogger(listener.logger, span)
+
+	if handled {
+		ctxLogger.Info(fmt.Sprintf("event [%s] has already been handled by [%s]", event.ID(), listener.signature(event)))
+		return nil
+	}
+
 	var payload events.MessagePhoneReceivedPayload
-	if err := event.DataAs(&payload); err != nil {
+	if err = event.DataAs(&payload); err != nil {
 		msg := fmt.Sprintf("cannot decode [%s] into [%T]", event.Data(), payload)
 		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
 	}
 
-	if err := listener.service.RegisterReceivedMessage(ctx, payload.MessageID, payload.Timestamp, payload.UserID); err != nil {
+	if err = listener.service.RegisterReceivedMessage(ctx, payload.MessageID, payload.Timestamp, payload.UserID); err != nil {
 		msg := fmt.Sprintf("cannot register received message for event [%s] for event with ID [%s]", spew.Sdump(payload), event.ID())
 		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
 	}
 
-	return nil
+	return listener.storeEventListenerLog(ctx, listener.signature(event), event)
+}
+
+func (listener *BillingListener) signature(event cloudevents.Event) string {
+	return listener.handlerSignature(listener, event)
 }
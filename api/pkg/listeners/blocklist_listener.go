@@ -0,0 +1,83 @@
+package listeners
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/NdoleStudio/httpsms/pkg/events"
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/palantir/stacktrace"
+)
+
+// BlocklistListener adds contacts who reply with an opt-out keyword to the entities.Blocklist
+type BlocklistListener struct {
+	listener
+	logger  telemetry.Logger
+	tracer  telemetry.Tracer
+	service *services.BlocklistService
+}
+
+// NewBlocklistListener creates a new instance of BlocklistListener
+func NewBlocklistListener(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	service *services.BlocklistService,
+	repository repositories.EventListenerLogRepository,
+) (l *BlocklistListener, routes map[string]events.EventListener) {
+	l = &BlocklistListener{
+		logger:  logger.WithService(fmt.Sprintf("%T", l)),
+		tracer:  tracer,
+		service: service,
+		listener: listener{
+			repository: repository,
+		},
+	}
+
+	return l, map[string]events.EventListener{
+		events.EventTypeMessagePhoneReceived: l.onMessagePhoneReceived,
+	}
+}
+
+// onMessagePhoneReceived handles the events.EventTypeMessagePhoneReceived event
+func (listener *BlocklistListener) onMessagePhoneReceived(ctx context.Context, event cloudevents.Event) error {
+	ctx, span, ctxLogger := listener.tracer.StartWithLogger(ctx, listener.logger)
+	defer span.End()
+
+	handled, err := listener.repository.Has(ctx, event.ID(), listener.signature(event))
+	if err != nil {
+		msg := fmt.Sprintf("cannot verify if event [%s] has been handled by [%T]", event.ID(), listener.signature(event))
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if handled {
+		ctxLogger.Info(fmt.Sprintf("event [%s] has already been handled by [%s]", event.ID(), listener.signature(event)))
+		return nil
+	}
+
+	var payload events.MessagePhoneReceivedPayload
+	if err = event.DataAs(&payload); err != nil {
+		msg := fmt.Sprintf("cannot decode [%s] into [%T]", event.Data(), payload)
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	params := &services.BlocklistKeywordParams{
+		UserID:    payload.UserID,
+		Contact:   payload.Contact,
+		Content:   payload.Content,
+		Timestamp: payload.Timestamp,
+	}
+
+	if err = listener.service.HandleKeyword(ctx, params); err != nil {
+		msg := fmt.Sprintf("cannot handle opt-out keyword of message [%s] for event with ID [%s]", payload.MessageID, event.ID())
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return listener.storeEventListenerLog(ctx, listener.signature(event), event)
+}
+
+func (listener *BlocklistListener) signature(event cloudevents.Event) string {
+	return listener.handlerSignature(listener, event)
+}
@@ -0,0 +1,185 @@
+package listeners
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/events"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/palantir/stacktrace"
+)
+
+// CanaryListener handles cloud events which need to update entities.CanaryRun
+type CanaryListener struct {
+	logger  telemetry.Logger
+	tracer  telemetry.Tracer
+	service *services.CanaryService
+}
+
+// NewCanaryListener creates a new instance of CanaryListener
+func NewCanaryListener(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	service *services.CanaryService,
+) (l *CanaryListener, routes map[string]events.EventListener) {
+	l = &CanaryListener{
+		logger:  logger.WithService(fmt.Sprintf("%T", l)),
+		tracer:  tracer,
+		service: service,
+	}
+
+	return l, map[string]events.EventListener{
+		events.EventTypeCanaryRun:             l.onCanaryRun,
+		events.EventTypeCanaryRunCheck:        l.onCanaryRunCheck,
+		events.EventTypeMessagePhoneSent:      l.onMessagePhoneSent,
+		events.EventTypeMessagePhoneDelivered: l.onMessagePhoneDelivered,
+		events.EventTypeMessageSendFailed:     l.onMessageSendFailed,
+		events.EventTypeMessagePhoneReceived:  l.onMessagePhoneReceived,
+	}
+}
+
+// onCanaryRun handles the events.EventTypeCanaryRun event
+func (listener *CanaryListener) onCanaryRun(ctx context.Context, event cloudevents.Event) error {
+	ctx, span := listener.tracer.Start(ctx)
+	defer span.End()
+
+	var payload events.CanaryRunPayload
+	if err := event.DataAs(&payload); err != nil {
+		msg := fmt.Sprintf("cannot decode [%s] into [%T]", event.Data(), payload)
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	params := &services.CanaryRunParams{
+		CanaryID: payload.CanaryID,
+		UserID:   payload.UserID,
+		Source:   event.Source(),
+	}
+
+	if err := listener.service.Run(ctx, params); err != nil {
+		msg := fmt.Sprintf("cannot run canary with ID [%s] for event with ID [%s]", payload.CanaryID, event.ID())
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+// onCanaryRunCheck handles the events.EventTypeCanaryRunCheck event
+func (listener *CanaryListener) onCanaryRunCheck(ctx context.Context, event cloudevents.Event) error {
+	ctx, span := listener.tracer.Start(ctx)
+	defer span.End()
+
+	var payload events.CanaryRunCheckPayload
+	if err := event.DataAs(&payload); err != nil {
+		msg := fmt.Sprintf("cannot decode [%s] into [%T]", event.Data(), payload)
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	params := &services.CanaryRunCheckParams{
+		CanaryID: payload.CanaryID,
+		RunID:    payload.RunID,
+		UserID:   payload.UserID,
+		Source:   event.Source(),
+	}
+
+	if err := listener.service.CheckRun(ctx, params); err != nil {
+		msg := fmt.Sprintf("cannot check run [%s] of canary [%s] for event with ID [%s]", payload.RunID, payload.CanaryID, event.ID())
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+// onMessagePhoneSent handles the events.EventTypeMessagePhoneSent event
+func (listener *CanaryListener) onMessagePhoneSent(ctx context.Context, event cloudevents.Event) error {
+	ctx, span := listener.tracer.Start(ctx)
+	defer span.End()
+
+	var payload events.MessagePhoneSentPayload
+	if err := event.DataAs(&payload); err != nil {
+		msg := fmt.Sprintf("cannot decode [%s] into [%T]", event.Data(), payload)
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return listener.handleMessageStatus(ctx, event, &services.CanaryMessageStatusParams{
+		MessageID: payload.ID,
+		UserID:    payload.UserID,
+		Status:    entities.MessageStatusSent,
+		Timestamp: payload.Timestamp,
+		Source:    event.Source(),
+	})
+}
+
+// onMessagePhoneDelivered handles the events.EventTypeMessagePhoneDelivered event
+func (listener *CanaryListener) onMessagePhoneDelivered(ctx context.Context, event cloudevents.Event) error {
+	ctx, span := listener.tracer.Start(ctx)
+	defer span.End()
+
+	var payload events.MessagePhoneDeliveredPayload
+	if err := event.DataAs(&payload); err != nil {
+		msg := fmt.Sprintf("cannot decode [%s] into [%T]", event.Data(), payload)
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return listener.handleMessageStatus(ctx, event, &services.CanaryMessageStatusParams{
+		MessageID: payload.ID,
+		UserID:    payload.UserID,
+		Status:    entities.MessageStatusDelivered,
+		Timestamp: payload.Timestamp,
+		Source:    event.Source(),
+	})
+}
+
+// onMessageSendFailed handles the events.EventTypeMessageSendFailed event
+func (listener *CanaryListener) onMessageSendFailed(ctx context.Context, event cloudevents.Event) error {
+	ctx, span := listener.tracer.Start(ctx)
+	defer span.End()
+
+	var payload events.MessageSendFailedPayload
+	if err := event.DataAs(&payload); err != nil {
+		msg := fmt.Sprintf("cannot decode [%s] into [%T]", event.Data(), payload)
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return listener.handleMessageStatus(ctx, event, &services.CanaryMessageStatusParams{
+		MessageID:    payload.ID,
+		UserID:       payload.UserID,
+		Status:       entities.MessageStatusFailed,
+		ErrorMessage: payload.ErrorMessage,
+		Timestamp:    payload.Timestamp,
+		Source:       event.Source(),
+	})
+}
+
+// onMessagePhoneReceived handles the events.EventTypeMessagePhoneReceived event
+func (listener *CanaryListener) onMessagePhoneReceived(ctx context.Context, event cloudevents.Event) error {
+	ctx, span := listener.tracer.Start(ctx)
+	defer span.End()
+
+	var payload events.MessagePhoneReceivedPayload
+	if err := event.DataAs(&payload); err != nil {
+		msg := fmt.Sprintf("cannot decode [%s] into [%T]", event.Data(), payload)
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if err := listener.service.HandleMessageReceived(ctx, &payload); err != nil {
+		msg := fmt.Sprintf("cannot handle received message [%s] for event with ID [%s]", payload.MessageID, event.ID())
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+func (listener *CanaryListener) handleMessageStatus(ctx context.Context, event cloudevents.Event, params *services.CanaryMessageStatusParams) error {
+	ctx, span := listener.tracer.Start(ctx)
+	defer span.End()
+
+	if err := listener.service.HandleMessageStatus(ctx, params); err != nil {
+		msg := fmt.Sprintf("cannot handle status [%s] of message [%s] for event with ID [%s]", params.Status, params.MessageID, event.ID())
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
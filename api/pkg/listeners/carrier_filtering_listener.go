@@ -0,0 +1,82 @@
+package listeners
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/NdoleStudio/httpsms/pkg/events"
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/palantir/stacktrace"
+)
+
+// CarrierFilteringListener checks for carrier filtering when messages do not get a delivery report
+type CarrierFilteringListener struct {
+	listener
+	logger  telemetry.Logger
+	tracer  telemetry.Tracer
+	service *services.CarrierFilteringService
+}
+
+// NewCarrierFilteringListener creates a new instance of CarrierFilteringListener
+func NewCarrierFilteringListener(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	service *services.CarrierFilteringService,
+	repository repositories.EventListenerLogRepository,
+) (l *CarrierFilteringListener, routes map[string]events.EventListener) {
+	l = &CarrierFilteringListener{
+		logger:  logger.WithService(fmt.Sprintf("%T", l)),
+		tracer:  tracer,
+		service: service,
+		listener: listener{
+			repository: repository,
+		},
+	}
+
+	return l, map[string]events.EventListener{
+		events.EventTypeMessageDeliveryUnknown: l.onMessageDeliveryUnknown,
+	}
+}
+
+// onMessageDeliveryUnknown handles the events.EventTypeMessageDeliveryUnknown event
+func (listener *CarrierFilteringListener) onMessageDeliveryUnknown(ctx context.Context, event cloudevents.Event) error {
+	ctx, span, ctxLogger := listener.tracer.StartWithLogger(ctx, listener.logger)
+	defer span.End()
+
+	handled, err := listener.repository.Has(ctx, event.ID(), listener.signature(event))
+	if err != nil {
+		msg := fmt.Sprintf("cannot verify if event [%s] has been handled by [%T]", event.ID(), listener.signature(event))
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if handled {
+		ctxLogger.Info(fmt.Sprintf("event [%s] has already been handled by [%s]", event.ID(), listener.signature(event)))
+		return nil
+	}
+
+	var payload events.MessageDeliveryUnknownPayload
+	if err = event.DataAs(&payload); err != nil {
+		msg := fmt.Sprintf("cannot decode [%s] into [%T]", event.Data(), payload)
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	err = listener.service.Check(ctx, &services.CarrierFilteringCheckParams{
+		UserID:  payload.UserID,
+		Owner:   payload.Owner,
+		Contact: payload.Contact,
+		Source:  event.Source(),
+	})
+	if err != nil {
+		msg := fmt.Sprintf("cannot check carrier filtering for [%s] event with ID [%s]", event.Type(), event.ID())
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return listener.storeEventListenerLog(ctx, listener.signature(event), event)
+}
+
+func (listener *CarrierFilteringListener) signature(event cloudevents.Event) string {
+	return listener.handlerSignature(listener, event)
+}
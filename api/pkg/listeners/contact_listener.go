@@ -0,0 +1,121 @@
+package listeners
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/NdoleStudio/httpsms/pkg/events"
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/palantir/stacktrace"
+)
+
+// ContactListener updates the engagement metrics of entities.Contact when messages are exchanged
+type ContactListener struct {
+	listener
+	logger  telemetry.Logger
+	tracer  telemetry.Tracer
+	service *services.ContactService
+}
+
+// NewContactListener creates a new instance of ContactListener
+func NewContactListener(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	service *services.ContactService,
+	repository repositories.EventListenerLogRepository,
+) (l *ContactListener, routes map[string]events.EventListener) {
+	l = &ContactListener{
+		logger:  logger.WithService(fmt.Sprintf("%T", l)),
+		tracer:  tracer,
+		service: service,
+		listener: listener{
+			repository: repository,
+		},
+	}
+
+	return l, map[string]events.EventListener{
+		events.EventTypeMessagePhoneSent:     l.onMessagePhoneSent,
+		events.EventTypeMessagePhoneReceived: l.onMessagePhoneReceived,
+	}
+}
+
+// onMessagePhoneSent handles the events.EventTypeMessagePhoneSent event
+func (listener *ContactListener) onMessagePhoneSent(ctx context.Context, event cloudevents.Event) error {
+	ctx, span, ctxLogger := listener.tracer.StartWithLogger(ctx, listener.logger)
+	defer span.End()
+
+	handled, err := listener.repository.Has(ctx, event.ID(), listener.signature(event))
+	if err != nil {
+		msg := fmt.Sprintf("cannot verify if event [%s] has been handled by [%T]", event.ID(), listener.signature(event))
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if handled {
+		ctxLogger.Info(fmt.Sprintf("event [%s] has already been handled by [%s]", event.ID(), listener.signature(event)))
+		return nil
+	}
+
+	var payload events.MessagePhoneSentPayload
+	if err = event.DataAs(&payload); err != nil {
+		msg := fmt.Sprintf("cannot decode [%s] into [%T]", event.Data(), payload)
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	params := &services.ContactRecordParams{
+		UserID:    payload.UserID,
+		Contact:   payload.Contact,
+		Content:   payload.Content,
+		Timestamp: payload.Timestamp,
+	}
+
+	if err = listener.service.RecordSent(ctx, params); err != nil {
+		msg := fmt.Sprintf("cannot record sent message [%s] for event with ID [%s]", payload.ID, event.ID())
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return listener.storeEventListenerLog(ctx, listener.signature(event), event)
+}
+
+// onMessagePhoneReceived handles the events.EventTypeMessagePhoneReceived event
+func (listener *ContactListener) onMessagePhoneReceived(ctx context.Context, event cloudevents.Event) error {
+	ctx, span, ctxLogger := listener.tracer.StartWithLogger(ctx, listener.logger)
+	defer span.End()
+
+	handled, err := listener.repository.Has(ctx, event.ID(), listener.signature(event))
+	if err != nil {
+		msg := fmt.Sprintf("cannot verify if event [%s] has been handled by [%T]", event.ID(), listener.signature(event))
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if handled {
+		ctxLogger.Info(fmt.Sprintf("event [%s] has already been handled by [%s]", event.ID(), listener.signature(event)))
+		return nil
+	}
+
+	var payload events.MessagePhoneReceivedPayload
+	if err = event.DataAs(&payload); err != nil {
+		msg := fmt.Sprintf("cannot decode [%s] into [%T]", event.Data(), payload)
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	params := &services.ContactRecordParams{
+		UserID:    payload.UserID,
+		Contact:   payload.Contact,
+		Content:   payload.Content,
+		Timestamp: payload.Timestamp,
+	}
+
+	if err = listener.service.RecordReceived(ctx, params); err != nil {
+		msg := fmt.Sprintf("cannot record received message [%s] for event with ID [%s]", payload.MessageID, event.ID())
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return listener.storeEventListenerLog(ctx, listener.signature(event), event)
+}
+
+func (listener *ContactListener) signature(event cloudevents.Event) string {
+	return listener.handlerSignature(listener, event)
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 
+	"github.com/NdoleStudio/httpsms/pkg/entities"
 	"github.com/NdoleStudio/httpsms/pkg/events"
 	"github.com/NdoleStudio/httpsms/pkg/services"
 	"github.com/NdoleStudio/httpsms/pkg/telemetry"
@@ -13,9 +14,10 @@ import (
 
 // DiscordListener sends messages to discord
 type DiscordListener struct {
-	logger  telemetry.Logger
-	tracer  telemetry.Tracer
-	service *services.DiscordService
+	logger            telemetry.Logger
+	tracer            telemetry.Tracer
+	service           *services.DiscordService
+	preferenceService *services.NotificationPreferenceService
 }
 
 // NewDiscordListener creates a new instance of DiscordListener
@@ -23,15 +25,18 @@ func NewDiscordListener(
 	logger telemetry.Logger,
 	tracer telemetry.Tracer,
 	service *services.DiscordService,
+	preferenceService *services.NotificationPreferenceService,
 ) (l *DiscordListener, routes map[string]events.EventListener) {
 	l = &DiscordListener{
-		logger:  logger.WithService(fmt.Sprintf("%T", l)),
-		tracer:  tracer,
-		service: service,
+		logger:            logger.WithService(fmt.Sprintf("%T", l)),
+		tracer:            tracer,
+		service:           service,
+		preferenceService: preferenceService,
 	}
 
 	return l, map[string]events.EventListener{
-		events.EventTypeMessagePhoneReceived: l.OnMessagePhoneReceived,
+		events.EventTypeMessagePhoneReceived:  l.OnMessagePhoneReceived,
+		events.EventTypeWebhookDeliveryFailed: l.onWebhookDeliveryFailed,
 	}
 }
 
@@ -46,6 +51,11 @@ func (listener *DiscordListener) OnMessagePhoneReceived(ctx context.Context, eve
 		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
 	}
 
+	if !listener.preferenceService.IsEnabled(ctx, payload.UserID, event.Type(), entities.NotificationChannelIntegration) {
+		listener.tracer.CtxLogger(listener.logger, span).Info(fmt.Sprintf("[%s] notifications for event [%s] are disabled by user [%s]", entities.NotificationChannelIntegration, event.Type(), payload.UserID))
+		return nil
+	}
+
 	if err := listener.service.HandleMessageReceived(ctx, payload.UserID, event); err != nil {
 		msg := fmt.Sprintf("cannot process [%s] event with ID [%s]", event.Type(), event.ID())
 		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
@@ -53,3 +63,27 @@ func (listener *DiscordListener) OnMessagePhoneReceived(ctx context.Context, eve
 
 	return nil
 }
+
+// onWebhookDeliveryFailed handles the events.EventTypeWebhookDeliveryFailed event
+func (listener *DiscordListener) onWebhookDeliveryFailed(ctx context.Context, event cloudevents.Event) error {
+	ctx, span := listener.tracer.Start(ctx)
+	defer span.End()
+
+	var payload events.WebhookDeliveryFailedPayload
+	if err := event.DataAs(&payload); err != nil {
+		msg := fmt.Sprintf("cannot decode [%s] into [%T]", event.Data(), payload)
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if !listener.preferenceService.IsEnabled(ctx, payload.UserID, event.Type(), entities.NotificationChannelIntegration) {
+		listener.tracer.CtxLogger(listener.logger, span).Info(fmt.Sprintf("[%s] notifications for event [%s] are disabled by user [%s]", entities.NotificationChannelIntegration, event.Type(), payload.UserID))
+		return nil
+	}
+
+	if err := listener.service.HandleWebhookDeliveryFailed(ctx, &payload); err != nil {
+		msg := fmt.Sprintf("cannot process [%s] event with ID [%s]", event.Type(), event.ID())
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
@@ -0,0 +1,44 @@
+package listeners
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/NdoleStudio/httpsms/pkg/services"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/palantir/stacktrace"
+)
+
+// EventHubListener publishes every dispatched event to the services.EventHub of this instance
+type EventHubListener struct {
+	logger telemetry.Logger
+	tracer telemetry.Tracer
+	hub    *services.EventHub
+}
+
+// NewEventHubListener creates a new instance of EventHubListener
+func NewEventHubListener(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	hub *services.EventHub,
+) (l *EventHubListener) {
+	return &EventHubListener{
+		logger: logger.WithService(fmt.Sprintf("%T", l)),
+		tracer: tracer,
+		hub:    hub,
+	}
+}
+
+// OnEvent handles every event type
+func (listener *EventHubListener) OnEvent(ctx context.Context, event cloudevents.Event) error {
+	ctx, span := listener.tracer.Start(ctx)
+	defer span.End()
+
+	if err := listener.hub.Publish(ctx, event); err != nil {
+		msg := fmt.Sprintf("cannot publish event [%s] with ID [%s] to the event hub", event.Type(), event.ID())
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
@@ -0,0 +1,44 @@
+package listeners
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/NdoleStudio/httpsms/pkg/services"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/palantir/stacktrace"
+)
+
+// EventSinkListener mirrors every dispatched event to the services.KafkaEventSink
+type EventSinkListener struct {
+	logger telemetry.Logger
+	tracer telemetry.Tracer
+	sink   *services.KafkaEventSink
+}
+
+// NewEventSinkListener creates a new instance of EventSinkListener
+func NewEventSinkListener(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	sink *services.KafkaEventSink,
+) (l *EventSinkListener) {
+	return &EventSinkListener{
+		logger: logger.WithService(fmt.Sprintf("%T", l)),
+		tracer: tracer,
+		sink:   sink,
+	}
+}
+
+// OnEvent handles every event type
+func (listener *EventSinkListener) OnEvent(ctx context.Context, event cloudevents.Event) error {
+	ctx, span := listener.tracer.Start(ctx)
+	defer span.End()
+
+	if err := listener.sink.Publish(ctx, event); err != nil {
+		msg := fmt.Sprintf("cannot mirror event [%s] with ID [%s] to the event sink", event.Type(), event.ID())
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
@@ -0,0 +1,76 @@
+package listeners
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/NdoleStudio/httpsms/pkg/events"
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/palantir/stacktrace"
+)
+
+// ForwardingRuleListener forwards received messages which match an entities.ForwardingRule
+type ForwardingRuleListener struct {
+	listener
+	logger  telemetry.Logger
+	tracer  telemetry.Tracer
+	service *services.ForwardingRuleService
+}
+
+// NewForwardingRuleListener creates a new instance of ForwardingRuleListener
+func NewForwardingRuleListener(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	service *services.ForwardingRuleService,
+	repository repositories.EventListenerLogRepository,
+) (l *ForwardingRuleListener, routes map[string]events.EventListener) {
+	l = &ForwardingRuleListener{
+		logger:  logger.WithService(fmt.Sprintf("%T", l)),
+		tracer:  tracer,
+		service: service,
+		listener: listener{
+			repository: repository,
+		},
+	}
+
+	return l, map[string]events.EventListener{
+		events.EventTypeMessagePhoneReceived: l.onMessagePhoneReceived,
+	}
+}
+
+// onMessagePhoneReceived handles the events.EventTypeMessagePhoneReceived event
+func (listener *ForwardingRuleListener) onMessagePhoneReceived(ctx context.Context, event cloudevents.Event) error {
+	ctx, span, ctxLogger := listener.tracer.StartWithLogger(ctx, listener.logger)
+	defer span.End()
+
+	handled, err := listener.repository.Has(ctx, event.ID(), listener.signature(event))
+	if err != nil {
+		msg := fmt.Sprintf("cannot verify if event [%s] has been handled by [%T]", event.ID(), listener.signature(event))
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if handled {
+		ctxLogger.Info(fmt.Sprintf("event [%s] has already been handled by [%s]", event.ID(), listener.signature(event)))
+		return nil
+	}
+
+	var payload events.MessagePhoneReceivedPayload
+	if err = event.DataAs(&payload); err != nil {
+		msg := fmt.Sprintf("cannot decode [%s] into [%T]", event.Data(), payload)
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if err = listener.service.HandleReceived(ctx, &payload); err != nil {
+		msg := fmt.Sprintf("cannot forward message [%s] for event with ID [%s]", payload.MessageID, event.ID())
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return listener.storeEventListenerLog(ctx, listener.signature(event), event)
+}
+
+func (listener *ForwardingRuleListener) signature(event cloudevents.Event) string {
+	return listener.handlerSignature(listener, event)
+}
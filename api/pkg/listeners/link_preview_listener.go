@@ -0,0 +1,75 @@
+package listeners
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/NdoleStudio/httpsms/pkg/events"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/palantir/stacktrace"
+)
+
+// LinkPreviewListener fetches the previews of links in messages
+type LinkPreviewListener struct {
+	logger  telemetry.Logger
+	tracer  telemetry.Tracer
+	service *services.LinkPreviewService
+}
+
+// NewLinkPreviewListener creates a new instance of LinkPreviewListener
+func NewLinkPreviewListener(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	service *services.LinkPreviewService,
+) (l *LinkPreviewListener, routes map[string]events.EventListener) {
+	l = &LinkPreviewListener{
+		logger:  logger.WithService(fmt.Sprintf("%T", l)),
+		tracer:  tracer,
+		service: service,
+	}
+
+	return l, map[string]events.EventListener{
+		events.EventTypeMessageAPISent:       l.OnMessageAPISent,
+		events.EventTypeMessagePhoneReceived: l.OnMessagePhoneReceived,
+	}
+}
+
+// OnMessageAPISent handles the events.EventTypeMessageAPISent event
+func (listener *LinkPreviewListener) OnMessageAPISent(ctx context.Context, event cloudevents.Event) error {
+	ctx, span := listener.tracer.Start(ctx)
+	defer span.End()
+
+	var payload events.MessageAPISentPayload
+	if err := event.DataAs(&payload); err != nil {
+		msg := fmt.Sprintf("cannot decode [%s] into [%T]", event.Data(), payload)
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if err := listener.service.Enrich(ctx, payload.Content); err != nil {
+		msg := fmt.Sprintf("cannot preview links in message with ID [%s] for event with ID [%s]", payload.MessageID, event.ID())
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+// OnMessagePhoneReceived handles the events.EventTypeMessagePhoneReceived event
+func (listener *LinkPreviewListener) OnMessagePhoneReceived(ctx context.Context, event cloudevents.Event) error {
+	ctx, span := listener.tracer.Start(ctx)
+	defer span.End()
+
+	var payload events.MessagePhoneReceivedPayload
+	if err := event.DataAs(&payload); err != nil {
+		msg := fmt.Sprintf("cannot decode [%s] into [%T]", event.Data(), payload)
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if err := listener.service.Enrich(ctx, payload.Content); err != nil {
+		msg := fmt.Sprintf("cannot preview links in message with ID [%s] for event with ID [%s]", payload.MessageID, event.ID())
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
@@ -47,6 +47,9 @@ func NewMessageListener(
 		events.EventTypeMessageSendExpiredCheck:      l.onMessageSendExpiredCheck,
 		events.EventTypeMessageSendExpired:           l.onMessageSendExpired,
 		events.EventTypeMessageNotificationScheduled: l.onMessageNotificationScheduled,
+		events.EventTypeMessageDeliveryCheck:         l.onMessageDeliveryCheck,
+		events.UserAccountSuspended:                  l.onUserAccountSuspended,
+		events.UserAccountReactivated:                l.onUserAccountReactivated,
 	}
 }
 
@@ -194,7 +197,9 @@ func (listener *MessageListener) OnMessagePhoneFailed(ctx context.Context, event
 		ID:           payload.ID,
 		UserID:       payload.UserID,
 		ErrorMessage: payload.ErrorMessage,
+		FailureCode:  payload.FailureCode,
 		Timestamp:    payload.Timestamp,
+		Source:       event.Source(),
 	}
 
 	if err = listener.service.HandleMessageFailed(ctx, handleParams); err != nil {
@@ -227,6 +232,7 @@ func (listener *MessageListener) onMessageNotificationFailed(ctx context.Context
 		EventName:    entities.MessageEventNameFailed,
 		Timestamp:    payload.NotificationFailedAt,
 		ErrorMessage: &payload.ErrorMessage,
+		FailureCode:  entities.MessageFailureCodeNotificationFailed,
 		Source:       event.Source(),
 	}
 	if _, err = listener.service.StoreEvent(ctx, message, storeParams); err != nil {
@@ -324,6 +330,30 @@ func (listener *MessageListener) onMessageSendExpired(ctx context.Context, event
 	return nil
 }
 
+// onMessageDeliveryCheck handles the events.EventTypeMessageDeliveryCheck event
+func (listener *MessageListener) onMessageDeliveryCheck(ctx context.Context, event cloudevents.Event) error {
+	ctx, span := listener.tracer.Start(ctx)
+	defer span.End()
+
+	var payload events.MessageDeliveryCheckPayload
+	if err := event.DataAs(&payload); err != nil {
+		msg := fmt.Sprintf("cannot decode [%s] into [%T]", event.Data(), payload)
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	checkParams := services.MessageCheckDelivery{
+		MessageID: payload.MessageID,
+		UserID:    payload.UserID,
+		Source:    event.Source(),
+	}
+	if err := listener.service.CheckDelivery(ctx, checkParams); err != nil {
+		msg := fmt.Sprintf("cannot check delivery for ID [%s] and userID [%s]", checkParams.MessageID, checkParams.UserID)
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
 // onMessageNotificationScheduled handles the events.EventTypeMessageSendExpired event
 func (listener *MessageListener) onMessageNotificationScheduled(ctx context.Context, event cloudevents.Event) error {
 	ctx, span := listener.tracer.Start(ctx)
@@ -349,6 +379,44 @@ func (listener *MessageListener) onMessageNotificationScheduled(ctx context.Cont
 	return nil
 }
 
+// onUserAccountSuspended handles the events.UserAccountSuspended event
+func (listener *MessageListener) onUserAccountSuspended(ctx context.Context, event cloudevents.Event) error {
+	ctx, span := listener.tracer.Start(ctx)
+	defer span.End()
+
+	var payload events.UserAccountSuspendedPayload
+	if err := event.DataAs(&payload); err != nil {
+		msg := fmt.Sprintf("cannot decode [%s] into [%T]", event.Data(), payload)
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if err := listener.service.PauseMessages(ctx, payload.UserID); err != nil {
+		msg := fmt.Sprintf("cannot pause messages of user [%s] for event with ID [%s]", payload.UserID, event.ID())
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+// onUserAccountReactivated handles the events.UserAccountReactivated event
+func (listener *MessageListener) onUserAccountReactivated(ctx context.Context, event cloudevents.Event) error {
+	ctx, span := listener.tracer.Start(ctx)
+	defer span.End()
+
+	var payload events.UserAccountReactivatedPayload
+	if err := event.DataAs(&payload); err != nil {
+		msg := fmt.Sprintf("cannot decode [%s] into [%T]", event.Data(), payload)
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if err := listener.service.ResumeMessages(ctx, event.Source(), payload.UserID); err != nil {
+		msg := fmt.Sprintf("cannot resume messages of user [%s] for event with ID [%s]", payload.UserID, event.ID())
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
 func (listener *MessageListener) signature(event cloudevents.Event) string {
 	return listener.handlerSignature(listener, event)
 }
@@ -0,0 +1,117 @@
+package listeners
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/NdoleStudio/httpsms/pkg/events"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/palantir/stacktrace"
+)
+
+// MessageSearchListener adds new messages to the search index and removes the purged messages
+type MessageSearchListener struct {
+	logger  telemetry.Logger
+	tracer  telemetry.Tracer
+	service *services.MessageSearchService
+}
+
+// NewMessageSearchListener creates a new instance of MessageSearchListener
+func NewMessageSearchListener(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	service *services.MessageSearchService,
+) (l *MessageSearchListener, routes map[string]events.EventListener) {
+	l = &MessageSearchListener{
+		logger:  logger.WithService(fmt.Sprintf("%T", l)),
+		tracer:  tracer,
+		service: service,
+	}
+
+	return l, map[string]events.EventListener{
+		events.EventTypeMessageAPISent:       l.OnMessageAPISent,
+		events.EventTypeMessagePhoneReceived: l.OnMessagePhoneReceived,
+		events.EventTypeMessagePurged:        l.OnMessagePurged,
+		events.UserAccountDeleted:            l.onUserAccountDeleted,
+	}
+}
+
+// OnMessageAPISent handles the events.EventTypeMessageAPISent event
+func (listener *MessageSearchListener) OnMessageAPISent(ctx context.Context, event cloudevents.Event) error {
+	ctx, span := listener.tracer.Start(ctx)
+	defer span.End()
+
+	var payload events.MessageAPISentPayload
+	if err := event.DataAs(&payload); err != nil {
+		msg := fmt.Sprintf("cannot decode [%s] into [%T]", event.Data(), payload)
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	document := services.NewMessageSearchDocument(payload.MessageID, payload.UserID, payload.Owner, payload.Contact, payload.Content, payload.RequestReceivedAt)
+	if err := listener.service.Index(ctx, document); err != nil {
+		msg := fmt.Sprintf("cannot index message with ID [%s] for event with ID [%s]", payload.MessageID, event.ID())
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+// OnMessagePhoneReceived handles the events.EventTypeMessagePhoneReceived event
+func (listener *MessageSearchListener) OnMessagePhoneReceived(ctx context.Context, event cloudevents.Event) error {
+	ctx, span := listener.tracer.Start(ctx)
+	defer span.End()
+
+	var payload events.MessagePhoneReceivedPayload
+	if err := event.DataAs(&payload); err != nil {
+		msg := fmt.Sprintf("cannot decode [%s] into [%T]", event.Data(), payload)
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	document := services.NewMessageSearchDocument(payload.MessageID, payload.UserID, payload.Owner, payload.Contact, payload.Content, payload.Timestamp)
+	if err := listener.service.Index(ctx, document); err != nil {
+		msg := fmt.Sprintf("cannot index message with ID [%s] for event with ID [%s]", payload.MessageID, event.ID())
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+// OnMessagePurged handles the events.EventTypeMessagePurged event
+func (listener *MessageSearchListener) OnMessagePurged(ctx context.Context, event cloudevents.Event) error {
+	ctx, span := listener.tracer.Start(ctx)
+	defer span.End()
+
+	var payload events.MessagePurgedPayload
+	if err := event.DataAs(&payload); err != nil {
+		msg := fmt.Sprintf("cannot decode [%s] into [%T]", event.Data(), payload)
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if err := listener.service.Delete(ctx, payload.UserID, payload.MessageIDs); err != nil {
+		msg := fmt.Sprintf("cannot remove [%d] purged messages from the search index for event with ID [%s]", len(payload.MessageIDs), event.ID())
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+// onUserAccountDeleted handles the events.UserAccountDeleted event
+func (listener *MessageSearchListener) onUserAccountDeleted(ctx context.Context, event cloudevents.Event) error {
+	ctx, span := listener.tracer.Start(ctx)
+	defer span.End()
+
+	var payload events.UserAccountDeletedPayload
+	if err := event.DataAs(&payload); err != nil {
+		msg := fmt.Sprintf("cannot decode [%s] into [%T]", event.Data(), payload)
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if err := listener.service.DeleteUser(ctx, payload.UserID); err != nil {
+		msg := fmt.Sprintf("cannot remove the messages of user [%s] from the search index for event with ID [%s]", payload.UserID, event.ID())
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
@@ -44,6 +44,7 @@ func NewMessageThreadListener(
 		events.EventTypeMessageSendFailed:            l.OnMessagePhoneFailed,
 		events.EventTypeMessagePhoneReceived:         l.OnMessagePhoneReceived,
 		events.EventTypeMessageNotificationScheduled: l.onMessageNotificationScheduled,
+		events.EventTypeMessagePurged:                l.onMessagePurged,
 	}
 }
 
@@ -246,3 +247,22 @@ func (listener *MessageThreadListener) onMessageNotificationScheduled(ctx contex
 func (listener *MessageThreadListener) updateThread(ctx context.Context, params services.MessageThreadUpdateParams) error {
 	return listener.service.UpdateThread(ctx, params)
 }
+
+// onMessagePurged handles the events.EventTypeMessagePurged event
+func (listener *MessageThreadListener) onMessagePurged(ctx context.Context, event cloudevents.Event) error {
+	ctx, span := listener.tracer.Start(ctx)
+	defer span.End()
+
+	var payload events.MessagePurgedPayload
+	if err := event.DataAs(&payload); err != nil {
+		msg := fmt.Sprintf("cannot decode [%s] into [%T]", event.Data(), payload)
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if err := listener.service.ClearContent(ctx, payload.UserID, payload.MessageIDs); err != nil {
+		msg := fmt.Sprintf("cannot clear the content of threads for event with ID [%s]", event.ID())
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
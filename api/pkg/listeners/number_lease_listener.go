@@ -0,0 +1,101 @@
+package listeners
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/NdoleStudio/httpsms/pkg/events"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/palantir/stacktrace"
+)
+
+// NumberLeaseListener handles cloud events which need to update entities.NumberLease
+type NumberLeaseListener struct {
+	logger  telemetry.Logger
+	tracer  telemetry.Tracer
+	service *services.NumberLeaseService
+}
+
+// NewNumberLeaseListener creates a new instance of NumberLeaseListener
+func NewNumberLeaseListener(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	service *services.NumberLeaseService,
+) (l *NumberLeaseListener, routes map[string]events.EventListener) {
+	l = &NumberLeaseListener{
+		logger:  logger.WithService(fmt.Sprintf("%T", l)),
+		tracer:  tracer,
+		service: service,
+	}
+
+	return l, map[string]events.EventListener{
+		events.EventTypeMessageAPISent:             l.onMessageAPISent,
+		events.EventTypeMessagePhoneReceived:       l.onMessagePhoneReceived,
+		events.EventTypeNumberLeaseInactivityCheck: l.onNumberLeaseInactivityCheck,
+	}
+}
+
+// onMessageAPISent handles the events.EventTypeMessageAPISent event
+func (listener *NumberLeaseListener) onMessageAPISent(ctx context.Context, event cloudevents.Event) error {
+	ctx, span := listener.tracer.Start(ctx)
+	defer span.End()
+
+	var payload events.MessageAPISentPayload
+	if err := event.DataAs(&payload); err != nil {
+		msg := fmt.Sprintf("cannot decode [%s] into [%T]", event.Data(), payload)
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if err := listener.service.Touch(ctx, payload.UserID, payload.Owner, payload.RequestReceivedAt); err != nil {
+		msg := fmt.Sprintf("cannot record activity on owner [%s] for event with ID [%s]", payload.Owner, event.ID())
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+// onMessagePhoneReceived handles the events.EventTypeMessagePhoneReceived event
+func (listener *NumberLeaseListener) onMessagePhoneReceived(ctx context.Context, event cloudevents.Event) error {
+	ctx, span := listener.tracer.Start(ctx)
+	defer span.End()
+
+	var payload events.MessagePhoneReceivedPayload
+	if err := event.DataAs(&payload); err != nil {
+		msg := fmt.Sprintf("cannot decode [%s] into [%T]", event.Data(), payload)
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if err := listener.service.Touch(ctx, payload.UserID, payload.Owner, payload.Timestamp); err != nil {
+		msg := fmt.Sprintf("cannot record activity on owner [%s] for event with ID [%s]", payload.Owner, event.ID())
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+// onNumberLeaseInactivityCheck handles the events.EventTypeNumberLeaseInactivityCheck event
+func (listener *NumberLeaseListener) onNumberLeaseInactivityCheck(ctx context.Context, event cloudevents.Event) error {
+	ctx, span := listener.tracer.Start(ctx)
+	defer span.End()
+
+	var payload events.NumberLeaseInactivityCheckPayload
+	if err := event.DataAs(&payload); err != nil {
+		msg := fmt.Sprintf("cannot decode [%s] into [%T]", event.Data(), payload)
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	checkParams := services.NumberLeaseCheckInactivityParams{
+		LeaseID: payload.LeaseID,
+		UserID:  payload.UserID,
+		Source:  event.Source(),
+	}
+
+	if err := listener.service.CheckInactivity(ctx, checkParams); err != nil {
+		msg := fmt.Sprintf("cannot check inactivity of number lease with ID [%s] for event with ID [%s]", payload.LeaseID, event.ID())
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
@@ -0,0 +1,81 @@
+package listeners
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/NdoleStudio/httpsms/pkg/events"
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/palantir/stacktrace"
+)
+
+// PhoneFailoverListener reschedules the messages of a phone on a backup phone when it goes offline
+type PhoneFailoverListener struct {
+	listener
+	logger  telemetry.Logger
+	tracer  telemetry.Tracer
+	service *services.PhoneFailoverService
+}
+
+// NewPhoneFailoverListener creates a new instance of PhoneFailoverListener
+func NewPhoneFailoverListener(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	service *services.PhoneFailoverService,
+	repository repositories.EventListenerLogRepository,
+) (l *PhoneFailoverListener, routes map[string]events.EventListener) {
+	l = &PhoneFailoverListener{
+		logger:  logger.WithService(fmt.Sprintf("%T", l)),
+		tracer:  tracer,
+		service: service,
+		listener: listener{
+			repository: repository,
+		},
+	}
+
+	return l, map[string]events.EventListener{
+		events.EventTypePhoneHeartbeatDead: l.onPhoneHeartbeatDead,
+	}
+}
+
+// onPhoneHeartbeatDead handles the events.EventTypePhoneHeartbeatDead event
+func (listener *PhoneFailoverListener) onPhoneHeartbeatDead(ctx context.Context, event cloudevents.Event) error {
+	ctx, span, ctxLogger := listener.tracer.StartWithLogger(ctx, listener.logger)
+	defer span.End()
+
+	handled, err := listener.repository.Has(ctx, event.ID(), listener.signature(event))
+	if err != nil {
+		msg := fmt.Sprintf("cannot verify if event [%s] has been handled by [%T]", event.ID(), listener.signature(event))
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if handled {
+		ctxLogger.Info(fmt.Sprintf("event [%s] has already been handled by [%s]", event.ID(), listener.signature(event)))
+		return nil
+	}
+
+	var payload events.PhoneHeartbeatDeadPayload
+	if err = event.DataAs(&payload); err != nil {
+		msg := fmt.Sprintf("cannot decode [%s] into [%T]", event.Data(), payload)
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	err = listener.service.Failover(ctx, &services.PhoneFailoverParams{
+		Source: event.Source(),
+		UserID: payload.UserID,
+		Owner:  payload.Owner,
+	})
+	if err != nil {
+		msg := fmt.Sprintf("cannot failover messages of owner [%s] for event with ID [%s]", payload.Owner, event.ID())
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return listener.storeEventListenerLog(ctx, listener.signature(event), event)
+}
+
+func (listener *PhoneFailoverListener) signature(event cloudevents.Event) string {
+	return listener.handlerSignature(listener, event)
+}
@@ -10,6 +10,7 @@ import (
 	"github.com/NdoleStudio/httpsms/pkg/services"
 	"github.com/NdoleStudio/httpsms/pkg/telemetry"
 	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/google/uuid"
 	"github.com/palantir/stacktrace"
 )
 
@@ -37,6 +38,11 @@ func NewNotificationListener(
 		events.EventTypeMessageSendRetry:        l.onMessageSendRetry,
 		events.EventTypeMessageNotificationSend: l.onMessageNotificationSend,
 		events.PhoneHeartbeatMissed:             l.onPhoneHeartbeatMissed,
+		events.EventTypeMessagePhoneSent:        l.onMessagePhoneSent,
+		events.EventTypeMessageSendFailed:       l.onMessageSendFailed,
+		events.EventTypeMessageSendExpired:      l.onMessageSendExpired,
+		events.EventTypePhoneUpdated:            l.onPhoneUpdated,
+		events.EventTypePhoneKillSwitchUpdated:  l.onPhoneKillSwitchUpdated,
 	}
 }
 
@@ -59,6 +65,7 @@ func (listener *PhoneNotificationListener) onMessageAPISent(ctx context.Context,
 		SIM:       payload.SIM,
 		Source:    event.Source(),
 		MessageID: payload.MessageID,
+		Priority:  payload.Priority,
 	}
 
 	if err := listener.service.Schedule(ctx, sendParams); err != nil {
@@ -88,6 +95,7 @@ func (listener *PhoneNotificationListener) onMessageSendRetry(ctx context.Contex
 		SIM:       payload.SIM,
 		Source:    event.Source(),
 		MessageID: payload.MessageID,
+		Priority:  payload.Priority,
 	}
 
 	if err := listener.service.Schedule(ctx, sendParams); err != nil {
@@ -144,3 +152,142 @@ func (listener *PhoneNotificationListener) onMessageNotificationSend(ctx context
 
 	return nil
 }
+
+// onMessagePhoneSent handles the events.EventTypeMessagePhoneSent event
+func (listener *PhoneNotificationListener) onMessagePhoneSent(ctx context.Context, event cloudevents.Event) error {
+	ctx, span := listener.tracer.Start(ctx)
+	defer span.End()
+
+	var payload events.MessagePhoneSentPayload
+	if err := event.DataAs(&payload); err != nil {
+		msg := fmt.Sprintf("cannot decode [%s] into [%T]", event.Data(), payload)
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	releaseParams := &services.PhoneNotificationReleaseParams{
+		UserID:    payload.UserID,
+		Owner:     payload.Owner,
+		Source:    event.Source(),
+		MessageID: payload.ID,
+	}
+
+	if err := listener.service.ReleaseWaiting(ctx, releaseParams); err != nil {
+		msg := fmt.Sprintf("cannot release waiting notifications with params [%s] for event with ID [%s]", spew.Sdump(releaseParams), event.ID())
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+// onMessageSendFailed handles the events.EventTypeMessageSendFailed event
+func (listener *PhoneNotificationListener) onMessageSendFailed(ctx context.Context, event cloudevents.Event) error {
+	ctx, span := listener.tracer.Start(ctx)
+	defer span.End()
+
+	var payload events.MessageSendFailedPayload
+	if err := event.DataAs(&payload); err != nil {
+		msg := fmt.Sprintf("cannot decode [%s] into [%T]", event.Data(), payload)
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	releaseParams := &services.PhoneNotificationReleaseParams{
+		UserID:    payload.UserID,
+		Owner:     payload.Owner,
+		Source:    event.Source(),
+		MessageID: payload.ID,
+	}
+
+	if err := listener.service.ReleaseWaiting(ctx, releaseParams); err != nil {
+		msg := fmt.Sprintf("cannot release waiting notifications with params [%s] for event with ID [%s]", spew.Sdump(releaseParams), event.ID())
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+// onMessageSendExpired handles the events.EventTypeMessageSendExpired event
+func (listener *PhoneNotificationListener) onMessageSendExpired(ctx context.Context, event cloudevents.Event) error {
+	ctx, span := listener.tracer.Start(ctx)
+	defer span.End()
+
+	var payload events.MessageSendExpiredPayload
+	if err := event.DataAs(&payload); err != nil {
+		msg := fmt.Sprintf("cannot decode [%s] into [%T]", event.Data(), payload)
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	releaseParams := &services.PhoneNotificationReleaseParams{
+		UserID:    payload.UserID,
+		Owner:     payload.Owner,
+		Source:    event.Source(),
+		MessageID: payload.MessageID,
+	}
+
+	if err := listener.service.ReleaseWaiting(ctx, releaseParams); err != nil {
+		msg := fmt.Sprintf("cannot release waiting notifications with params [%s] for event with ID [%s]", spew.Sdump(releaseParams), event.ID())
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+// onPhoneUpdated handles the events.EventTypePhoneUpdated event
+func (listener *PhoneNotificationListener) onPhoneUpdated(ctx context.Context, event cloudevents.Event) error {
+	ctx, span := listener.tracer.Start(ctx)
+	defer span.End()
+
+	var payload events.PhoneUpdatedPayload
+	if err := event.DataAs(&payload); err != nil {
+		msg := fmt.Sprintf("cannot decode [%s] into [%T]", event.Data(), payload)
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	releaseParams := &services.PhoneNotificationReleaseParams{
+		UserID:    payload.UserID,
+		Owner:     payload.Owner,
+		Source:    event.Source(),
+		MessageID: uuid.Nil,
+	}
+
+	if err := listener.service.ReleaseWaiting(ctx, releaseParams); err != nil {
+		msg := fmt.Sprintf("cannot release waiting notifications with params [%s] for event with ID [%s]", spew.Sdump(releaseParams), event.ID())
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+// onPhoneKillSwitchUpdated handles the events.EventTypePhoneKillSwitchUpdated event
+func (listener *PhoneNotificationListener) onPhoneKillSwitchUpdated(ctx context.Context, event cloudevents.Event) error {
+	ctx, span := listener.tracer.Start(ctx)
+	defer span.End()
+
+	var payload events.PhoneKillSwitchUpdatedPayload
+	if err := event.DataAs(&payload); err != nil {
+		msg := fmt.Sprintf("cannot decode [%s] into [%T]", event.Data(), payload)
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if err := listener.service.SendKillSwitch(ctx, &payload); err != nil {
+		msg := fmt.Sprintf("cannot send kill switch to phone [%s] for event with ID [%s]", payload.PhoneID, event.ID())
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if payload.Enabled {
+		return nil
+	}
+
+	releaseParams := &services.PhoneNotificationReleaseParams{
+		UserID:    payload.UserID,
+		Owner:     payload.Owner,
+		Source:    event.Source(),
+		MessageID: uuid.Nil,
+	}
+
+	if err := listener.service.ReleaseWaiting(ctx, releaseParams); err != nil {
+		msg := fmt.Sprintf("cannot release waiting notifications with params [%s] for event with ID [%s]", spew.Sdump(releaseParams), event.ID())
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
@@ -0,0 +1,110 @@
+package listeners
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/NdoleStudio/httpsms/pkg/events"
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/palantir/stacktrace"
+)
+
+// PhonePaceListener adjusts the pace of a phone when it reports the outcome of a message
+type PhonePaceListener struct {
+	listener
+	logger  telemetry.Logger
+	tracer  telemetry.Tracer
+	service *services.PhonePaceService
+}
+
+// NewPhonePaceListener creates a new instance of PhonePaceListener
+func NewPhonePaceListener(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	service *services.PhonePaceService,
+	repository repositories.EventListenerLogRepository,
+) (l *PhonePaceListener, routes map[string]events.EventListener) {
+	l = &PhonePaceListener{
+		logger:  logger.WithService(fmt.Sprintf("%T", l)),
+		tracer:  tracer,
+		service: service,
+		listener: listener{
+			repository: repository,
+		},
+	}
+
+	return l, map[string]events.EventListener{
+		events.EventTypeMessagePhoneSent:  l.onMessagePhoneSent,
+		events.EventTypeMessageSendFailed: l.onMessageSendFailed,
+	}
+}
+
+// onMessagePhoneSent handles the events.EventTypeMessagePhoneSent event
+func (listener *PhonePaceListener) onMessagePhoneSent(ctx context.Context, event cloudevents.Event) error {
+	ctx, span := listener.tracer.Start(ctx)
+	defer span.End()
+
+	var payload events.MessagePhoneSentPayload
+	if err := event.DataAs(&payload); err != nil {
+		msg := fmt.Sprintf("cannot decode [%s] into [%T]", event.Data(), payload)
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return listener.record(ctx, event, &services.PhonePaceRecordParams{
+		UserID:    payload.UserID,
+		Owner:     payload.Owner,
+		MessageID: payload.ID,
+		Failed:    false,
+		Timestamp: payload.Timestamp,
+	})
+}
+
+// onMessageSendFailed handles the events.EventTypeMessageSendFailed event
+func (listener *PhonePaceListener) onMessageSendFailed(ctx context.Context, event cloudevents.Event) error {
+	ctx, span := listener.tracer.Start(ctx)
+	defer span.End()
+
+	var payload events.MessageSendFailedPayload
+	if err := event.DataAs(&payload); err != nil {
+		msg := fmt.Sprintf("cannot decode [%s] into [%T]", event.Data(), payload)
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return listener.record(ctx, event, &services.PhonePaceRecordParams{
+		UserID:    payload.UserID,
+		Owner:     payload.Owner,
+		MessageID: payload.ID,
+		Failed:    true,
+		Timestamp: payload.Timestamp,
+	})
+}
+
+func (listener *PhonePaceListener) record(ctx context.Context, event cloudevents.Event, params *services.PhonePaceRecordParams) error {
+	ctx, span, ctxLogger := listener.tracer.StartWithLogger(ctx, listener.logger)
+	defer span.End()
+
+	handled, err := listener.repository.Has(ctx, event.ID(), listener.signature(event))
+	if err != nil {
+		msg := fmt.Sprintf("cannot verify if event [%s] has been handled by [%T]", event.ID(), listener.signature(event))
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if handled {
+		ctxLogger.Info(fmt.Sprintf("event [%s] has already been handled by [%s]", event.ID(), listener.signature(event)))
+		return nil
+	}
+
+	if err = listener.service.Record(ctx, params); err != nil {
+		msg := fmt.Sprintf("cannot record pace of message [%s] for event with ID [%s]", params.MessageID, event.ID())
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return listener.storeEventListenerLog(ctx, listener.signature(event), event)
+}
+
+func (listener *PhonePaceListener) signature(event cloudevents.Event) string {
+	return listener.handlerSignature(listener, event)
+}
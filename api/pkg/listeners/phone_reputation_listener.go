@@ -0,0 +1,109 @@
+package listeners
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/NdoleStudio/httpsms/pkg/events"
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/palantir/stacktrace"
+)
+
+// PhoneReputationListener scores the reputation of owner phone numbers from the messages they send and receive
+type PhoneReputationListener struct {
+	listener
+	logger  telemetry.Logger
+	tracer  telemetry.Tracer
+	service *services.PhoneReputationService
+}
+
+// NewPhoneReputationListener creates a new instance of PhoneReputationListener
+func NewPhoneReputationListener(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	service *services.PhoneReputationService,
+	repository repositories.EventListenerLogRepository,
+) (l *PhoneReputationListener, routes map[string]events.EventListener) {
+	l = &PhoneReputationListener{
+		logger:  logger.WithService(fmt.Sprintf("%T", l)),
+		tracer:  tracer,
+		service: service,
+		listener: listener{
+			repository: repository,
+		},
+	}
+
+	return l, map[string]events.EventListener{
+		events.EventTypeMessagePhoneSent:     l.onMessagePhoneSent,
+		events.EventTypeMessagePhoneReceived: l.onMessagePhoneReceived,
+	}
+}
+
+// onMessagePhoneSent handles the events.EventTypeMessagePhoneSent event
+func (listener *PhoneReputationListener) onMessagePhoneSent(ctx context.Context, event cloudevents.Event) error {
+	ctx, span := listener.tracer.Start(ctx)
+	defer span.End()
+
+	var payload events.MessagePhoneSentPayload
+	if err := event.DataAs(&payload); err != nil {
+		msg := fmt.Sprintf("cannot decode [%s] into [%T]", event.Data(), payload)
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return listener.handle(ctx, event, func(ctx context.Context) error {
+		return listener.service.RecordSent(ctx, payload.UserID, payload.Owner, payload.Timestamp)
+	})
+}
+
+// onMessagePhoneReceived handles the events.EventTypeMessagePhoneReceived event
+func (listener *PhoneReputationListener) onMessagePhoneReceived(ctx context.Context, event cloudevents.Event) error {
+	ctx, span := listener.tracer.Start(ctx)
+	defer span.End()
+
+	var payload events.MessagePhoneReceivedPayload
+	if err := event.DataAs(&payload); err != nil {
+		msg := fmt.Sprintf("cannot decode [%s] into [%T]", event.Data(), payload)
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return listener.handle(ctx, event, func(ctx context.Context) error {
+		return listener.service.RecordReply(ctx, &services.PhoneReplyParams{
+			UserID:    payload.UserID,
+			Owner:     payload.Owner,
+			Content:   payload.Content,
+			Timestamp: payload.Timestamp,
+			Source:    event.Source(),
+		})
+	})
+}
+
+// handle records an event once
+func (listener *PhoneReputationListener) handle(ctx context.Context, event cloudevents.Event, record func(ctx context.Context) error) error {
+	ctx, span, ctxLogger := listener.tracer.StartWithLogger(ctx, listener.logger)
+	defer span.End()
+
+	handled, err := listener.repository.Has(ctx, event.ID(), listener.signature(event))
+	if err != nil {
+		msg := fmt.Sprintf("cannot verify if event [%s] has been handled by [%T]", event.ID(), listener.signature(event))
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if handled {
+		ctxLogger.Info(fmt.Sprintf("event [%s] has already been handled by [%s]", event.ID(), listener.signature(event)))
+		return nil
+	}
+
+	if err = record(ctx); err != nil {
+		msg := fmt.Sprintf("cannot record reputation for [%s] event with ID [%s]", event.Type(), event.ID())
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return listener.storeEventListenerLog(ctx, listener.signature(event), event)
+}
+
+func (listener *PhoneReputationListener) signature(event cloudevents.Event) string {
+	return listener.handlerSignature(listener, event)
+}
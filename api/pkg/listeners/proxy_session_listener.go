@@ -0,0 +1,102 @@
+package listeners
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/NdoleStudio/httpsms/pkg/events"
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/palantir/stacktrace"
+)
+
+// ProxySessionListener handles cloud events which need to update entities.ProxySession
+type ProxySessionListener struct {
+	listener
+	logger  telemetry.Logger
+	tracer  telemetry.Tracer
+	service *services.ProxySessionService
+}
+
+// NewProxySessionListener creates a new instance of ProxySessionListener
+func NewProxySessionListener(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	service *services.ProxySessionService,
+	repository repositories.EventListenerLogRepository,
+) (l *ProxySessionListener, routes map[string]events.EventListener) {
+	l = &ProxySessionListener{
+		logger:  logger.WithService(fmt.Sprintf("%T", l)),
+		tracer:  tracer,
+		service: service,
+		listener: listener{
+			repository: repository,
+		},
+	}
+
+	return l, map[string]events.EventListener{
+		events.EventTypeMessagePhoneReceived:     l.OnMessagePhoneReceived,
+		events.EventTypeProxySessionExpiredCheck: l.onProxySessionExpiredCheck,
+	}
+}
+
+// OnMessagePhoneReceived handles the events.EventTypeMessagePhoneReceived event
+func (listener *ProxySessionListener) OnMessagePhoneReceived(ctx context.Context, event cloudevents.Event) error {
+	ctx, span, ctxLogger := listener.tracer.StartWithLogger(ctx, listener.logger)
+	defer span.End()
+
+	handled, err := listener.repository.Has(ctx, event.ID(), listener.signature(event))
+	if err != nil {
+		msg := fmt.Sprintf("cannot verify if event [%s] has been handled by [%T]", event.ID(), listener.signature(event))
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if handled {
+		ctxLogger.Info(fmt.Sprintf("event [%s] has already been handled by [%s]", event.ID(), listener.signature(event)))
+		return nil
+	}
+
+	var payload events.MessagePhoneReceivedPayload
+	if err = event.DataAs(&payload); err != nil {
+		msg := fmt.Sprintf("cannot decode [%s] into [%T]", event.Data(), payload)
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if err = listener.service.Relay(ctx, event.Source(), &payload); err != nil {
+		msg := fmt.Sprintf("cannot relay message with ID [%s] for event with ID [%s]", payload.MessageID, event.ID())
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return listener.storeEventListenerLog(ctx, listener.signature(event), event)
+}
+
+// onProxySessionExpiredCheck handles the events.EventTypeProxySessionExpiredCheck event
+func (listener *ProxySessionListener) onProxySessionExpiredCheck(ctx context.Context, event cloudevents.Event) error {
+	ctx, span := listener.tracer.Start(ctx)
+	defer span.End()
+
+	var payload events.ProxySessionExpiredCheckPayload
+	if err := event.DataAs(&payload); err != nil {
+		msg := fmt.Sprintf("cannot decode [%s] into [%T]", event.Data(), payload)
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	checkParams := services.ProxySessionCheckExpiredParams{
+		SessionID: payload.SessionID,
+		UserID:    payload.UserID,
+		Source:    event.Source(),
+	}
+
+	if err := listener.service.CheckExpired(ctx, checkParams); err != nil {
+		msg := fmt.Sprintf("cannot check expiry of proxy session with ID [%s] for event with ID [%s]", payload.SessionID, event.ID())
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+func (listener *ProxySessionListener) signature(event cloudevents.Event) string {
+	return listener.handlerSignature(listener, event)
+}
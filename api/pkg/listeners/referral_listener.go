@@ -0,0 +1,55 @@
+package listeners
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/NdoleStudio/httpsms/pkg/events"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/palantir/stacktrace"
+)
+
+// ReferralListener rewards referrers when the users they referred start a subscription
+type ReferralListener struct {
+	logger  telemetry.Logger
+	tracer  telemetry.Tracer
+	service *services.ReferralService
+}
+
+// NewReferralListener creates a new instance of ReferralListener
+func NewReferralListener(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	service *services.ReferralService,
+) (l *ReferralListener, routes map[string]events.EventListener) {
+	l = &ReferralListener{
+		logger:  logger.WithService(fmt.Sprintf("%T", l)),
+		tracer:  tracer,
+		service: service,
+	}
+
+	return l, map[string]events.EventListener{
+		events.UserSubscriptionCreated: l.OnUserSubscriptionCreated,
+	}
+}
+
+// OnUserSubscriptionCreated handles the events.UserSubscriptionCreated event
+func (listener *ReferralListener) OnUserSubscriptionCreated(ctx context.Context, event cloudevents.Event) error {
+	ctx, span := listener.tracer.Start(ctx)
+	defer span.End()
+
+	var payload events.UserSubscriptionCreatedPayload
+	if err := event.DataAs(&payload); err != nil {
+		msg := fmt.Sprintf("cannot decode [%s] into [%T]", event.Data(), payload)
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if err := listener.service.RewardSubscription(ctx, &payload); err != nil {
+		msg := fmt.Sprintf("cannot reward referral of user [%s] with event ID [%s]", payload.UserID, event.ID())
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
@@ -0,0 +1,155 @@
+package listeners
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/NdoleStudio/httpsms/pkg/events"
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/palantir/stacktrace"
+)
+
+// TemplateStatListener records the performance of the messages sent with a template
+type TemplateStatListener struct {
+	listener
+	logger  telemetry.Logger
+	tracer  telemetry.Tracer
+	service *services.TemplateStatService
+}
+
+// NewTemplateStatListener creates a new instance of TemplateStatListener
+func NewTemplateStatListener(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	service *services.TemplateStatService,
+	repository repositories.EventListenerLogRepository,
+) (l *TemplateStatListener, routes map[string]events.EventListener) {
+	l = &TemplateStatListener{
+		logger:  logger.WithService(fmt.Sprintf("%T", l)),
+		tracer:  tracer,
+		service: service,
+		listener: listener{
+			repository: repository,
+		},
+	}
+
+	return l, map[string]events.EventListener{
+		events.EventTypeMessagePhoneSent:      l.onMessagePhoneSent,
+		events.EventTypeMessagePhoneDelivered: l.onMessagePhoneDelivered,
+		events.EventTypeMessageSendFailed:     l.onMessageSendFailed,
+		events.EventTypeMessagePhoneReceived:  l.onMessagePhoneReceived,
+	}
+}
+
+// onMessagePhoneSent handles the events.EventTypeMessagePhoneSent event
+func (listener *TemplateStatListener) onMessagePhoneSent(ctx context.Context, event cloudevents.Event) error {
+	ctx, span := listener.tracer.Start(ctx)
+	defer span.End()
+
+	var payload events.MessagePhoneSentPayload
+	if err := event.DataAs(&payload); err != nil {
+		msg := fmt.Sprintf("cannot decode [%s] into [%T]", event.Data(), payload)
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return listener.handle(ctx, event, func(ctx context.Context) error {
+		return listener.service.RecordOutcome(ctx, &services.TemplateOutcomeParams{
+			UserID:    payload.UserID,
+			MessageID: payload.ID,
+			Outcome:   services.TemplateOutcomeSent,
+		})
+	})
+}
+
+// onMessagePhoneDelivered handles the events.EventTypeMessagePhoneDelivered event
+func (listener *TemplateStatListener) onMessagePhoneDelivered(ctx context.Context, event cloudevents.Event) error {
+	ctx, span := listener.tracer.Start(ctx)
+	defer span.End()
+
+	var payload events.MessagePhoneDeliveredPayload
+	if err := event.DataAs(&payload); err != nil {
+		msg := fmt.Sprintf("cannot decode [%s] into [%T]", event.Data(), payload)
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return listener.handle(ctx, event, func(ctx context.Context) error {
+		return listener.service.RecordOutcome(ctx, &services.TemplateOutcomeParams{
+			UserID:    payload.UserID,
+			MessageID: payload.ID,
+			Outcome:   services.TemplateOutcomeDelivered,
+		})
+	})
+}
+
+// onMessageSendFailed handles the events.EventTypeMessageSendFailed event
+func (listener *TemplateStatListener) onMessageSendFailed(ctx context.Context, event cloudevents.Event) error {
+	ctx, span := listener.tracer.Start(ctx)
+	defer span.End()
+
+	var payload events.MessageSendFailedPayload
+	if err := event.DataAs(&payload); err != nil {
+		msg := fmt.Sprintf("cannot decode [%s] into [%T]", event.Data(), payload)
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return listener.handle(ctx, event, func(ctx context.Context) error {
+		return listener.service.RecordOutcome(ctx, &services.TemplateOutcomeParams{
+			UserID:    payload.UserID,
+			MessageID: payload.ID,
+			Outcome:   services.TemplateOutcomeFailed,
+		})
+	})
+}
+
+// onMessagePhoneReceived handles the events.EventTypeMessagePhoneReceived event
+func (listener *TemplateStatListener) onMessagePhoneReceived(ctx context.Context, event cloudevents.Event) error {
+	ctx, span := listener.tracer.Start(ctx)
+	defer span.End()
+
+	var payload events.MessagePhoneReceivedPayload
+	if err := event.DataAs(&payload); err != nil {
+		msg := fmt.Sprintf("cannot decode [%s] into [%T]", event.Data(), payload)
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return listener.handle(ctx, event, func(ctx context.Context) error {
+		return listener.service.RecordReply(ctx, &services.TemplateReplyParams{
+			UserID:    payload.UserID,
+			Owner:     payload.Owner,
+			Contact:   payload.Contact,
+			Content:   payload.Content,
+			Timestamp: payload.Timestamp,
+		})
+	})
+}
+
+// handle records an event once so that retried events are not counted twice
+func (listener *TemplateStatListener) handle(ctx context.Context, event cloudevents.Event, record func(ctx context.Context) error) error {
+	ctx, span, ctxLogger := listener.tracer.StartWithLogger(ctx, listener.logger)
+	defer span.End()
+
+	handled, err := listener.repository.Has(ctx, event.ID(), listener.signature(event))
+	if err != nil {
+		msg := fmt.Sprintf("cannot verify if event [%s] has been handled by [%T]", event.ID(), listener.signature(event))
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if handled {
+		ctxLogger.Info(fmt.Sprintf("event [%s] has already been handled by [%s]", event.ID(), listener.signature(event)))
+		return nil
+	}
+
+	if err = record(ctx); err != nil {
+		msg := fmt.Sprintf("cannot record template stat for event [%s] with ID [%s]", event.Type(), event.ID())
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return listener.storeEventListenerLog(ctx, listener.signature(event), event)
+}
+
+func (listener *TemplateStatListener) signature(event cloudevents.Event) string {
+	return listener.handlerSignature(listener, event)
+}
@@ -0,0 +1,126 @@
+package listeners
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/NdoleStudio/httpsms/pkg/events"
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/palantir/stacktrace"
+)
+
+// TwilioListener notifies the status callbacks of messages sent with the Twilio compatible API
+type TwilioListener struct {
+	listener
+	logger  telemetry.Logger
+	tracer  telemetry.Tracer
+	service *services.TwilioService
+}
+
+// NewTwilioListener creates a new instance of TwilioListener
+func NewTwilioListener(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	service *services.TwilioService,
+	repository repositories.EventListenerLogRepository,
+) (l *TwilioListener, routes map[string]events.EventListener) {
+	l = &TwilioListener{
+		logger:  logger.WithService(fmt.Sprintf("%T", l)),
+		tracer:  tracer,
+		service: service,
+		listener: listener{
+			repository: repository,
+		},
+	}
+
+	return l, map[string]events.EventListener{
+		events.EventTypeMessagePhoneSent:      l.onMessagePhoneSent,
+		events.EventTypeMessagePhoneDelivered: l.onMessagePhoneDelivered,
+		events.EventTypeMessageSendFailed:     l.onMessageSendFailed,
+	}
+}
+
+// onMessagePhoneSent handles the events.EventTypeMessagePhoneSent event
+func (listener *TwilioListener) onMessagePhoneSent(ctx context.Context, event cloudevents.Event) error {
+	ctx, span := listener.tracer.Start(ctx)
+	defer span.End()
+
+	var payload events.MessagePhoneSentPayload
+	if err := event.DataAs(&payload); err != nil {
+		msg := fmt.Sprintf("cannot decode [%s] into [%T]", event.Data(), payload)
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return listener.handle(ctx, event, services.TwilioMessageStatusParams{
+		UserID:    payload.UserID,
+		MessageID: payload.ID,
+		Status:    services.TwilioMessageStatusSent,
+	})
+}
+
+// onMessagePhoneDelivered handles the events.EventTypeMessagePhoneDelivered event
+func (listener *TwilioListener) onMessagePhoneDelivered(ctx context.Context, event cloudevents.Event) error {
+	ctx, span := listener.tracer.Start(ctx)
+	defer span.End()
+
+	var payload events.MessagePhoneDeliveredPayload
+	if err := event.DataAs(&payload); err != nil {
+		msg := fmt.Sprintf("cannot decode [%s] into [%T]", event.Data(), payload)
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return listener.handle(ctx, event, services.TwilioMessageStatusParams{
+		UserID:    payload.UserID,
+		MessageID: payload.ID,
+		Status:    services.TwilioMessageStatusDelivered,
+	})
+}
+
+// onMessageSendFailed handles the events.EventTypeMessageSendFailed event
+func (listener *TwilioListener) onMessageSendFailed(ctx context.Context, event cloudevents.Event) error {
+	ctx, span := listener.tracer.Start(ctx)
+	defer span.End()
+
+	var payload events.MessageSendFailedPayload
+	if err := event.DataAs(&payload); err != nil {
+		msg := fmt.Sprintf("cannot decode [%s] into [%T]", event.Data(), payload)
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return listener.handle(ctx, event, services.TwilioMessageStatusParams{
+		UserID:    payload.UserID,
+		MessageID: payload.ID,
+		Status:    services.TwilioMessageStatusFailed,
+	})
+}
+
+// handle posts the status of a message once for every event
+func (listener *TwilioListener) handle(ctx context.Context, event cloudevents.Event, params services.TwilioMessageStatusParams) error {
+	ctx, span, ctxLogger := listener.tracer.StartWithLogger(ctx, listener.logger)
+	defer span.End()
+
+	handled, err := listener.repository.Has(ctx, event.ID(), listener.signature(event))
+	if err != nil {
+		msg := fmt.Sprintf("cannot verify if event [%s] has been handled by [%T]", event.ID(), listener.signature(event))
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if handled {
+		ctxLogger.Info(fmt.Sprintf("event [%s] has already been handled by [%s]", event.ID(), listener.signature(event)))
+		return nil
+	}
+
+	if err = listener.service.HandleMessageStatus(ctx, params); err != nil {
+		msg := fmt.Sprintf("cannot post status [%s] of message [%s] for event with ID [%s]", params.Status, params.MessageID, event.ID())
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return listener.storeEventListenerLog(ctx, listener.signature(event), event)
+}
+
+func (listener *TwilioListener) signature(event cloudevents.Event) string {
+	return listener.handlerSignature(listener, event)
+}
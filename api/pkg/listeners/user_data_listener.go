@@ -0,0 +1,55 @@
+package listeners
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/NdoleStudio/httpsms/pkg/events"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/palantir/stacktrace"
+)
+
+// UserDataListener erases the data of users who deleted their account
+type UserDataListener struct {
+	logger  telemetry.Logger
+	tracer  telemetry.Tracer
+	service *services.UserDataService
+}
+
+// NewUserDataListener creates a new instance of UserDataListener
+func NewUserDataListener(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	service *services.UserDataService,
+) (l *UserDataListener, routes map[string]events.EventListener) {
+	l = &UserDataListener{
+		logger:  logger.WithService(fmt.Sprintf("%T", l)),
+		tracer:  tracer,
+		service: service,
+	}
+
+	return l, map[string]events.EventListener{
+		events.UserAccountDeletionRequested: l.onUserAccountDeletionRequested,
+	}
+}
+
+// onUserAccountDeletionRequested handles the events.UserAccountDeletionRequested event
+func (listener *UserDataListener) onUserAccountDeletionRequested(ctx context.Context, event cloudevents.Event) error {
+	ctx, span := listener.tracer.Start(ctx)
+	defer span.End()
+
+	var payload events.UserAccountDeletionRequestedPayload
+	if err := event.DataAs(&payload); err != nil {
+		msg := fmt.Sprintf("cannot decode [%s] into [%T]", event.Data(), payload)
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if err := listener.service.Erase(ctx, event.Source(), &payload); err != nil {
+		msg := fmt.Sprintf("cannot erase the data of user [%s] for event with ID [%s]", payload.UserID, event.ID())
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
@@ -6,6 +6,7 @@ import (
 
 	"github.com/davecgh/go-spew/spew"
 
+	"github.com/NdoleStudio/httpsms/pkg/entities"
 	"github.com/NdoleStudio/httpsms/pkg/events"
 	"github.com/NdoleStudio/httpsms/pkg/services"
 	"github.com/NdoleStudio/httpsms/pkg/telemetry"
@@ -15,9 +16,11 @@ import (
 
 // UserListener handles cloud events which sends notifications
 type UserListener struct {
-	logger  telemetry.Logger
-	tracer  telemetry.Tracer
-	service *services.UserService
+	logger              telemetry.Logger
+	tracer              telemetry.Tracer
+	service             *services.UserService
+	preferenceService   *services.NotificationPreferenceService
+	registrationService *services.PhoneRegistrationService
 }
 
 // NewUserListener creates a new instance of UserListener
@@ -25,17 +28,27 @@ func NewUserListener(
 	logger telemetry.Logger,
 	tracer telemetry.Tracer,
 	service *services.UserService,
+	preferenceService *services.NotificationPreferenceService,
+	registrationService *services.PhoneRegistrationService,
 ) (l *UserListener, routes map[string]events.EventListener) {
 	l = &UserListener{
-		logger:  logger.WithService(fmt.Sprintf("%T", l)),
-		tracer:  tracer,
-		service: service,
+		logger:              logger.WithService(fmt.Sprintf("%T", l)),
+		tracer:              tracer,
+		service:             service,
+		preferenceService:   preferenceService,
+		registrationService: registrationService,
 	}
 
 	return l, map[string]events.EventListener{
-		events.EventTypePhoneHeartbeatDead: l.onPhoneHeartbeatDead,
-		events.UserSubscriptionCreated:     l.OnUserSubscriptionCreated,
-		events.UserSubscriptionCancelled:   l.OnUserSubscriptionCancelled,
+		events.EventTypePhoneHeartbeatDead:     l.onPhoneHeartbeatDead,
+		events.EventTypeCanaryFailed:           l.onCanaryFailed,
+		events.EventTypeWebhookDeliveryFailed:  l.onWebhookDeliveryFailed,
+		events.EventTypePhoneDeviceRegistered:  l.onPhoneDeviceRegistered,
+		events.EventTypeBroadcastPublished:     l.onBroadcastPublished,
+		events.EventTypeNotificationDigestSend: l.onNotificationDigestSend,
+		events.UserSubscriptionCreated:         l.OnUserSubscriptionCreated,
+		events.UserSubscriptionCancelled:       l.OnUserSubscriptionCancelled,
+		events.UserSubscriptionPaymentFailed:   l.onUserSubscriptionPaymentFailed,
 	}
 }
 
@@ -50,7 +63,13 @@ func (listener *UserListener) onPhoneHeartbeatDead(ctx context.Context, event cl
 		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
 	}
 
+	if !listener.preferenceService.IsEnabled(ctx, payload.UserID, event.Type(), entities.NotificationChannelEmail) {
+		listener.tracer.CtxLogger(listener.logger, span).Info(fmt.Sprintf("[%s] notifications for event [%s] are disabled by user [%s]", entities.NotificationChannelEmail, event.Type(), payload.UserID))
+		return nil
+	}
+
 	sendParams := &services.UserSendPhoneDeadEmailParams{
+		Source:                 event.Source(),
 		UserID:                 payload.UserID,
 		PhoneID:                payload.PhoneID,
 		Owner:                  payload.Owner,
@@ -65,6 +84,159 @@ func (listener *UserListener) onPhoneHeartbeatDead(ctx context.Context, event cl
 	return nil
 }
 
+// onCanaryFailed handles the events.EventTypeCanaryFailed event
+func (listener *UserListener) onCanaryFailed(ctx context.Context, event cloudevents.Event) error {
+	ctx, span := listener.tracer.Start(ctx)
+	defer span.End()
+
+	var payload events.CanaryFailedPayload
+	if err := event.DataAs(&payload); err != nil {
+		msg := fmt.Sprintf("cannot decode [%s] into [%T]", event.Data(), payload)
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if !listener.preferenceService.IsEnabled(ctx, payload.UserID, event.Type(), entities.NotificationChannelEmail) {
+		listener.tracer.CtxLogger(listener.logger, span).Info(fmt.Sprintf("[%s] notifications for event [%s] are disabled by user [%s]", entities.NotificationChannelEmail, event.Type(), payload.UserID))
+		return nil
+	}
+
+	sendParams := &services.UserSendCanaryFailedEmailParams{
+		Source:    event.Source(),
+		UserID:    payload.UserID,
+		Owner:     payload.Owner,
+		Contact:   payload.Contact,
+		Reason:    payload.Reason,
+		Timestamp: payload.Timestamp,
+	}
+
+	if err := listener.service.SendCanaryFailedEmail(ctx, sendParams); err != nil {
+		msg := fmt.Sprintf("cannot send notification with params [%s] for event with ID [%s]", spew.Sdump(sendParams), event.ID())
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+// onWebhookDeliveryFailed handles the events.EventTypeWebhookDeliveryFailed event
+func (listener *UserListener) onWebhookDeliveryFailed(ctx context.Context, event cloudevents.Event) error {
+	ctx, span := listener.tracer.Start(ctx)
+	defer span.End()
+
+	var payload events.WebhookDeliveryFailedPayload
+	if err := event.DataAs(&payload); err != nil {
+		msg := fmt.Sprintf("cannot decode [%s] into [%T]", event.Data(), payload)
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if !listener.preferenceService.IsEnabled(ctx, payload.UserID, event.Type(), entities.NotificationChannelEmail) {
+		listener.tracer.CtxLogger(listener.logger, span).Info(fmt.Sprintf("[%s] notifications for event [%s] are disabled by user [%s]", entities.NotificationChannelEmail, event.Type(), payload.UserID))
+		return nil
+	}
+
+	sendParams := &services.UserSendWebhookDeliveryFailedEmailParams{
+		Source:       event.Source(),
+		UserID:       payload.UserID,
+		URL:          payload.URL,
+		EventType:    payload.EventType,
+		StatusCode:   payload.LastStatusCode,
+		RedeliverURL: payload.RedeliverURL,
+		Timestamp:    payload.Timestamp,
+	}
+
+	if err := listener.service.SendWebhookDeliveryFailedEmail(ctx, sendParams); err != nil {
+		msg := fmt.Sprintf("cannot send notification with params [%s] for event with ID [%s]", spew.Sdump(sendParams), event.ID())
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+// onPhoneDeviceRegistered handles the events.EventTypePhoneDeviceRegistered event
+func (listener *UserListener) onPhoneDeviceRegistered(ctx context.Context, event cloudevents.Event) error {
+	ctx, span := listener.tracer.Start(ctx)
+	defer span.End()
+
+	var payload events.PhoneDeviceRegisteredPayload
+	if err := event.DataAs(&payload); err != nil {
+		msg := fmt.Sprintf("cannot decode [%s] into [%T]", event.Data(), payload)
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if !listener.preferenceService.IsEnabled(ctx, payload.UserID, event.Type(), entities.NotificationChannelEmail) {
+		listener.tracer.CtxLogger(listener.logger, span).Info(fmt.Sprintf("[%s] notifications for event [%s] are disabled by user [%s]", entities.NotificationChannelEmail, event.Type(), payload.UserID))
+		return nil
+	}
+
+	notifyParams := &services.PhoneRegistrationNotifyParams{
+		UserID:     payload.UserID,
+		PhoneID:    payload.PhoneID,
+		IsNewPhone: payload.IsNewPhone,
+		Timestamp:  payload.Timestamp,
+	}
+
+	if err := listener.registrationService.Notify(ctx, notifyParams); err != nil {
+		msg := fmt.Sprintf("cannot send notification with params [%s] for event with ID [%s]", spew.Sdump(notifyParams), event.ID())
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+// onBroadcastPublished handles the events.EventTypeBroadcastPublished event
+func (listener *UserListener) onBroadcastPublished(ctx context.Context, event cloudevents.Event) error {
+	ctx, span := listener.tracer.Start(ctx)
+	defer span.End()
+
+	var payload events.BroadcastPublishedPayload
+	if err := event.DataAs(&payload); err != nil {
+		msg := fmt.Sprintf("cannot decode [%s] into [%T]", event.Data(), payload)
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if !payload.HasChannel(entities.BroadcastChannelEmail) {
+		return nil
+	}
+
+	if !listener.preferenceService.IsEnabled(ctx, payload.UserID, event.Type(), entities.NotificationChannelEmail) {
+		listener.tracer.CtxLogger(listener.logger, span).Info(fmt.Sprintf("[%s] notifications for event [%s] are disabled by user [%s]", entities.NotificationChannelEmail, event.Type(), payload.UserID))
+		return nil
+	}
+
+	sendParams := &services.UserSendBroadcastEmailParams{
+		UserID:      payload.UserID,
+		BroadcastID: payload.BroadcastID,
+		Title:       payload.Title,
+		Body:        payload.Body,
+		Severity:    payload.Severity,
+	}
+
+	if err := listener.service.SendBroadcastEmail(ctx, sendParams); err != nil {
+		msg := fmt.Sprintf("cannot send broadcast with params [%s] for event with ID [%s]", spew.Sdump(sendParams), event.ID())
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+// onNotificationDigestSend handles the events.EventTypeNotificationDigestSend event
+func (listener *UserListener) onNotificationDigestSend(ctx context.Context, event cloudevents.Event) error {
+	ctx, span := listener.tracer.Start(ctx)
+	defer span.End()
+
+	var payload events.NotificationDigestSendPayload
+	if err := event.DataAs(&payload); err != nil {
+		msg := fmt.Sprintf("cannot decode [%s] into [%T]", event.Data(), payload)
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if err := listener.service.SendDigestEmail(ctx, payload.UserID); err != nil {
+		msg := fmt.Sprintf("cannot send notification digest to user [%s] for event with ID [%s]", payload.UserID, event.ID())
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
 // OnUserSubscriptionCreated handles the events.UserSubscriptionCreated event
 func (listener *UserListener) OnUserSubscriptionCreated(ctx context.Context, event cloudevents.Event) error {
 	ctx, span := listener.tracer.Start(ctx)
@@ -81,6 +253,12 @@ func (listener *UserListener) OnUserSubscriptionCreated(ctx context.Context, eve
 		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
 	}
 
+	reason := entities.UserSuspensionReasonBilling
+	if _, err := listener.service.Reactivate(ctx, &services.UserReactivateParams{Source: event.Source(), UserID: payload.UserID, Reason: &reason}); err != nil {
+		msg := fmt.Sprintf("cannot lift billing suspension of user with ID [%s] for event with ID [%s]", payload.UserID, event.ID())
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
 	return nil
 }
 
@@ -102,3 +280,28 @@ func (listener *UserListener) OnUserSubscriptionCancelled(ctx context.Context, e
 
 	return nil
 }
+
+// onUserSubscriptionPaymentFailed handles the events.UserSubscriptionPaymentFailed event
+func (listener *UserListener) onUserSubscriptionPaymentFailed(ctx context.Context, event cloudevents.Event) error {
+	ctx, span := listener.tracer.Start(ctx)
+	defer span.End()
+
+	var payload events.UserSubscriptionPaymentFailedPayload
+	if err := event.DataAs(&payload); err != nil {
+		msg := fmt.Sprintf("cannot decode [%s] into [%T]", event.Data(), payload)
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	params := &services.UserSuspendParams{
+		Source: event.Source(),
+		UserID: payload.UserID,
+		Reason: entities.UserSuspensionReasonBilling,
+	}
+
+	if _, err := listener.service.Suspend(ctx, params); err != nil {
+		msg := fmt.Sprintf("cannot suspend user with ID [%s] for event with ID [%s]", payload.UserID, event.ID())
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 
+	"github.com/NdoleStudio/httpsms/pkg/entities"
 	"github.com/NdoleStudio/httpsms/pkg/events"
 	"github.com/NdoleStudio/httpsms/pkg/services"
 	"github.com/NdoleStudio/httpsms/pkg/telemetry"
@@ -13,9 +14,10 @@ import (
 
 // WebhookListener sends webhook events to users
 type WebhookListener struct {
-	logger  telemetry.Logger
-	tracer  telemetry.Tracer
-	service *services.WebhookService
+	logger            telemetry.Logger
+	tracer            telemetry.Tracer
+	service           *services.WebhookService
+	preferenceService *services.NotificationPreferenceService
 }
 
 // NewWebhookListener creates a new instance of WebhookListener
@@ -23,15 +25,21 @@ func NewWebhookListener(
 	logger telemetry.Logger,
 	tracer telemetry.Tracer,
 	service *services.WebhookService,
+	preferenceService *services.NotificationPreferenceService,
 ) (l *WebhookListener, routes map[string]events.EventListener) {
 	l = &WebhookListener{
-		logger:  logger.WithService(fmt.Sprintf("%T", l)),
-		tracer:  tracer,
-		service: service,
+		logger:            logger.WithService(fmt.Sprintf("%T", l)),
+		tracer:            tracer,
+		service:           service,
+		preferenceService: preferenceService,
 	}
 
 	return l, map[string]events.EventListener{
 		events.EventTypeMessagePhoneReceived: l.OnMessagePhoneReceived,
+		events.EventTypeMessageRead:          l.OnMessageRead,
+		events.EventTypeMessagePurged:        l.OnMessagePurged,
+		events.EventTypeBroadcastPublished:   l.OnBroadcastPublished,
+		events.UserAccountReactivated:        l.onUserAccountReactivated,
 	}
 }
 
@@ -46,6 +54,87 @@ func (listener *WebhookListener) OnMessagePhoneReceived(ctx context.Context, eve
 		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
 	}
 
+	if !listener.preferenceService.IsEnabled(ctx, payload.UserID, event.Type(), entities.NotificationChannelWebhook) {
+		listener.tracer.CtxLogger(listener.logger, span).Info(fmt.Sprintf("[%s] notifications for event [%s] are disabled by user [%s]", entities.NotificationChannelWebhook, event.Type(), payload.UserID))
+		return nil
+	}
+
+	if err := listener.service.Send(ctx, payload.UserID, event); err != nil {
+		msg := fmt.Sprintf("cannot process [%s] event with ID [%s]", event.Type(), event.ID())
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+// OnMessageRead handles the events.EventTypeMessageRead event
+func (listener *WebhookListener) OnMessageRead(ctx context.Context, event cloudevents.Event) error {
+	ctx, span := listener.tracer.Start(ctx)
+	defer span.End()
+
+	var payload events.MessageReadPayload
+	if err := event.DataAs(&payload); err != nil {
+		msg := fmt.Sprintf("cannot decode [%s] into [%T]", event.Data(), payload)
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if !listener.preferenceService.IsEnabled(ctx, payload.UserID, event.Type(), entities.NotificationChannelWebhook) {
+		listener.tracer.CtxLogger(listener.logger, span).Info(fmt.Sprintf("[%s] notifications for event [%s] are disabled by user [%s]", entities.NotificationChannelWebhook, event.Type(), payload.UserID))
+		return nil
+	}
+
+	if err := listener.service.Send(ctx, payload.UserID, event); err != nil {
+		msg := fmt.Sprintf("cannot process [%s] event with ID [%s]", event.Type(), event.ID())
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+// OnMessagePurged handles the events.EventTypeMessagePurged event
+func (listener *WebhookListener) OnMessagePurged(ctx context.Context, event cloudevents.Event) error {
+	ctx, span := listener.tracer.Start(ctx)
+	defer span.End()
+
+	var payload events.MessagePurgedPayload
+	if err := event.DataAs(&payload); err != nil {
+		msg := fmt.Sprintf("cannot decode [%s] into [%T]", event.Data(), payload)
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if !listener.preferenceService.IsEnabled(ctx, payload.UserID, event.Type(), entities.NotificationChannelWebhook) {
+		listener.tracer.CtxLogger(listener.logger, span).Info(fmt.Sprintf("[%s] notifications for event [%s] are disabled by user [%s]", entities.NotificationChannelWebhook, event.Type(), payload.UserID))
+		return nil
+	}
+
+	if err := listener.service.Send(ctx, payload.UserID, event); err != nil {
+		msg := fmt.Sprintf("cannot process [%s] event with ID [%s]", event.Type(), event.ID())
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+// OnBroadcastPublished handles the events.EventTypeBroadcastPublished event
+func (listener *WebhookListener) OnBroadcastPublished(ctx context.Context, event cloudevents.Event) error {
+	ctx, span := listener.tracer.Start(ctx)
+	defer span.End()
+
+	var payload events.BroadcastPublishedPayload
+	if err := event.DataAs(&payload); err != nil {
+		msg := fmt.Sprintf("cannot decode [%s] into [%T]", event.Data(), payload)
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if !payload.HasChannel(entities.BroadcastChannelWebhook) {
+		return nil
+	}
+
+	if !listener.preferenceService.IsEnabled(ctx, payload.UserID, event.Type(), entities.NotificationChannelWebhook) {
+		listener.tracer.CtxLogger(listener.logger, span).Info(fmt.Sprintf("[%s] notifications for event [%s] are disabled by user [%s]", entities.NotificationChannelWebhook, event.Type(), payload.UserID))
+		return nil
+	}
+
 	if err := listener.service.Send(ctx, payload.UserID, event); err != nil {
 		msg := fmt.Sprintf("cannot process [%s] event with ID [%s]", event.Type(), event.ID())
 		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
@@ -53,3 +142,22 @@ func (listener *WebhookListener) OnMessagePhoneReceived(ctx context.Context, eve
 
 	return nil
 }
+
+// onUserAccountReactivated handles the events.UserAccountReactivated event
+func (listener *WebhookListener) onUserAccountReactivated(ctx context.Context, event cloudevents.Event) error {
+	ctx, span := listener.tracer.Start(ctx)
+	defer span.End()
+
+	var payload events.UserAccountReactivatedPayload
+	if err := event.DataAs(&payload); err != nil {
+		msg := fmt.Sprintf("cannot decode [%s] into [%T]", event.Data(), payload)
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if err := listener.service.ReleaseHeld(ctx, payload.UserID); err != nil {
+		msg := fmt.Sprintf("cannot release held webhooks of user [%s] for event with ID [%s]", payload.UserID, event.ID())
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
@@ -0,0 +1,63 @@
+package middlewares
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// AccessLog records the requests to the /v1 API with their status and latency so that they can be inspected by operators.
+// It must be registered after the authentication middlewares so that the user of the request is known.
+func AccessLog(service *services.AccessLogService) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if !strings.HasPrefix(c.Path(), "/v1/") {
+			return c.Next()
+		}
+
+		start := time.Now().UTC()
+		err := c.Next()
+
+		status := c.Response().StatusCode()
+		var fiberError *fiber.Error
+		if errors.As(err, &fiberError) {
+			status = fiberError.Code
+		} else if err != nil {
+			status = fiber.StatusInternalServerError
+		}
+
+		params := &services.AccessLogRecordParams{
+			APIKey:      utils.CopyString(c.Get(authHeaderAPIKey)),
+			Method:      utils.CopyString(c.Method()),
+			Path:        utils.CopyString(c.Path()),
+			Query:       string(c.Request().URI().QueryString()),
+			Status:      status,
+			Latency:     time.Since(start),
+			IP:          c.IP(),
+			UserAgent:   utils.CopyString(c.Get(fiber.HeaderUserAgent)),
+			ContentType: utils.CopyString(c.Get(fiber.HeaderContentType)),
+			Body:        c.Body(),
+			Timestamp:   start,
+		}
+
+		if authUser, ok := c.Locals(ContextKeyAuthUserID).(entities.AuthUser); ok {
+			params.UserID = authUser.ID
+		}
+
+		if ctx, ok := c.Locals(telemetry.TracerContextKey).(context.Context); ok {
+			if spanContext := trace.SpanContextFromContext(ctx); spanContext.IsValid() {
+				params.TraceID = spanContext.TraceID().String()
+			}
+		}
+
+		service.Record(params)
+		return err
+	}
+}
@@ -0,0 +1,121 @@
+package middlewares
+
+import (
+	"context"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type accessLogTestLogger struct{}
+
+func (logger *accessLogTestLogger) Error(error)                                 {}
+func (logger *accessLogTestLogger) WithService(string) telemetry.Logger         { return logger }
+func (logger *accessLogTestLogger) WithString(string, string) telemetry.Logger  { return logger }
+func (logger *accessLogTestLogger) WithSpan(trace.SpanContext) telemetry.Logger { return logger }
+func (logger *accessLogTestLogger) Trace(string)                                {}
+func (logger *accessLogTestLogger) Info(string)                                 {}
+func (logger *accessLogTestLogger) Warn(error)                                  {}
+func (logger *accessLogTestLogger) Debug(string)                                {}
+func (logger *accessLogTestLogger) Fatal(error)                                 {}
+func (logger *accessLogTestLogger) Printf(string, ...interface{})               {}
+
+type accessLogTestRepository struct {
+	logs []*entities.AccessLog
+}
+
+func (repository *accessLogTestRepository) StoreBatch(_ context.Context, logs []*entities.AccessLog) error {
+	repository.logs = append(repository.logs, logs...)
+	return nil
+}
+
+func (repository *accessLogTestRepository) Index(context.Context, repositories.AccessLogIndexParams) ([]*entities.AccessLog, error) {
+	return nil, nil
+}
+
+func (repository *accessLogTestRepository) DeleteBefore(context.Context, time.Time) (int64, error) {
+	return 0, nil
+}
+
+func accessLogTestRequest(t *testing.T, path string, body string) *entities.AccessLog {
+	logger := new(accessLogTestLogger)
+	repository := new(accessLogTestRepository)
+	service := services.NewAccessLogService(logger, telemetry.NewOtelLogger("test", logger), repository, 1024, time.Hour)
+
+	app := fiber.New()
+	app.Use(AccessLog(service))
+	app.Post(path, func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusOK)
+	})
+
+	request := httptest.NewRequest(fiber.MethodPost, path, strings.NewReader(body))
+	request.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+
+	_, err := app.Test(request)
+	assert.Nil(t, err)
+
+	count, err := service.Flush(context.Background(), 10)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, count)
+
+	return repository.logs[0]
+}
+
+func TestAccessLog(t *testing.T) {
+	t.Run("body of a login request is never stored", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+
+		// Act
+		log := accessLogTestRequest(t, "/v1/auth/login", `{"email":"name@example.com","password":"correct horse battery staple"}`)
+
+		// Assert
+		assert.Equal(t, "", log.RequestBody)
+		assert.True(t, log.RequestBodyTrimmed)
+		assert.Equal(t, "/v1/auth/login", log.Path)
+	})
+
+	t.Run("body of a two factor request is never stored", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+
+		// Act
+		log := accessLogTestRequest(t, "/v1/two-factor/enable", `{"code":"123456"}`)
+
+		// Assert
+		assert.Equal(t, "", log.RequestBody)
+	})
+
+	t.Run("sensitive fields are redacted", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+
+		// Act
+		log := accessLogTestRequest(t, "/v1/webhooks", `{"url":"https://example.com","signing_key":"secret-key","nested":[{"api_key":"key","code":"123456","country_code":"CM"}]}`)
+
+		// Assert
+		assert.Equal(t, `{"nested":[{"api_key":"[REDACTED]","code":"[REDACTED]","country_code":"CM"}],"signing_key":"[REDACTED]","url":"https://example.com"}`, log.RequestBody)
+		assert.False(t, log.RequestBodyTrimmed)
+	})
+
+	t.Run("body which is not valid JSON is not stored", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+
+		// Act
+		log := accessLogTestRequest(t, "/v1/messages/send", `{"password":"secret"`)
+
+		// Assert
+		assert.Equal(t, "", log.RequestBody)
+		assert.True(t, log.RequestBodyTrimmed)
+	})
+}
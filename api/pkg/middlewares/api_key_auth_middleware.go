@@ -2,6 +2,7 @@ package middlewares
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/NdoleStudio/httpsms/pkg/repositories"
 	"github.com/NdoleStudio/httpsms/pkg/telemetry"
@@ -9,7 +10,8 @@ import (
 	"github.com/palantir/stacktrace"
 )
 
-// APIKeyAuth authenticates a user from the X-API-Key header
+// APIKeyAuth authenticates a user from the X-API-Key header.
+// WebSocket upgrade requests can use the api_key query parameter because browsers cannot set headers on WebSocket connections.
 func APIKeyAuth(logger telemetry.Logger, tracer telemetry.Tracer, userRepository repositories.UserRepository) fiber.Handler {
 	logger = logger.WithService("middlewares.APIKeyAuth")
 
@@ -20,6 +22,10 @@ func APIKeyAuth(logger telemetry.Logger, tracer telemetry.Tracer, userRepository
 		ctxLogger := tracer.CtxLogger(logger, span)
 
 		apiKey := c.Get(authHeaderAPIKey)
+		if len(apiKey) == 0 && strings.EqualFold(c.Get(fiber.HeaderUpgrade), "websocket") {
+			apiKey = c.Query(queryParamAPIKey)
+		}
+
 		if len(apiKey) == 0 {
 			span.AddEvent(fmt.Sprintf("the request header has no [%s] api key", authHeaderAPIKey))
 			return c.Next()
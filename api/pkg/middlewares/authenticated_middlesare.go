@@ -10,6 +10,8 @@ const (
 	authHeaderBearer = "Authorization"
 	authHeaderAPIKey = "x-api-key"
 	bearerScheme     = "Bearer"
+	basicScheme      = "Basic"
+	queryParamAPIKey = "api_key"
 )
 
 const (
@@ -0,0 +1,55 @@
+package middlewares
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/gofiber/fiber/v2"
+	"github.com/palantir/stacktrace"
+)
+
+// BasicAuth authenticates a user from the password of the HTTP basic authorization header.
+// Twilio SDKs send the account SID as the username and the auth token as the password so the API key is used as the password.
+func BasicAuth(logger telemetry.Logger, tracer telemetry.Tracer, userRepository repositories.UserRepository) fiber.Handler {
+	logger = logger.WithService("middlewares.BasicAuth")
+
+	return func(c *fiber.Ctx) error {
+		ctx, span := tracer.StartFromFiberCtx(c, "middlewares.BasicAuth")
+		defer span.End()
+
+		ctxLogger := tracer.CtxLogger(logger, span)
+
+		authToken := c.Get(authHeaderBearer)
+		if !strings.HasPrefix(authToken, basicScheme+" ") {
+			span.AddEvent(fmt.Sprintf("The request header has no [%s] credentials", basicScheme))
+			return c.Next()
+		}
+
+		credentials, err := base64.StdEncoding.DecodeString(authToken[len(basicScheme)+1:])
+		if err != nil {
+			ctxLogger.Warn(stacktrace.Propagate(err, fmt.Sprintf("cannot decode [%s] credentials", basicScheme)))
+			return c.Next()
+		}
+
+		_, apiKey, _ := strings.Cut(string(credentials), ":")
+		if len(apiKey) == 0 {
+			span.AddEvent(fmt.Sprintf("The [%s] credentials have no password", basicScheme))
+			return c.Next()
+		}
+
+		authUser, err := userRepository.LoadAuthUser(ctx, apiKey)
+		if err != nil {
+			ctxLogger.Error(stacktrace.Propagate(err, fmt.Sprintf("cannot load user with api key [%s]", apiKey)))
+			return c.Next()
+		}
+
+		c.Locals(ContextKeyAuthUserID, authUser)
+
+		ctxLogger.Info(fmt.Sprintf("[%T] set successfully for user with ID [%s]", authUser, authUser.ID))
+
+		return c.Next()
+	}
+}
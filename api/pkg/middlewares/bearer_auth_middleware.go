@@ -1,22 +1,22 @@
 package middlewares
 
 import (
-	"context"
 	"fmt"
 	"strings"
 
-	"firebase.google.com/go/auth"
-	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/identity"
+	"github.com/NdoleStudio/httpsms/pkg/services"
 	"github.com/NdoleStudio/httpsms/pkg/telemetry"
 	"github.com/gofiber/fiber/v2"
 	"github.com/palantir/stacktrace"
 )
 
-// BearerAuth authenticates a user based on the bearer token
-func BearerAuth(logger telemetry.Logger, tracer telemetry.Tracer, authClient *auth.Client) fiber.Handler {
+// BearerAuth authenticates a user based on the bearer token issued by the identity.Provider.
+// Tokens which belong to a revoked session are rejected.
+func BearerAuth(logger telemetry.Logger, tracer telemetry.Tracer, provider identity.Provider, sessionService *services.AuthSessionService) fiber.Handler {
 	logger = logger.WithService("middlewares.BearerAuth")
 	return func(c *fiber.Ctx) error {
-		_, span := tracer.StartFromFiberCtx(c, "middlewares.BearerAuth")
+		ctx, span := tracer.StartFromFiberCtx(c, "middlewares.BearerAuth")
 		defer span.End()
 
 		authToken := c.Get(authHeaderBearer)
@@ -31,20 +31,26 @@ func BearerAuth(logger telemetry.Logger, tracer telemetry.Tracer, authClient *au
 
 		ctxLogger := tracer.CtxLogger(logger, span)
 
-		token, err := authClient.VerifyIDToken(context.Background(), authToken)
+		authUser, err := provider.Verify(ctx, authToken)
 		if err != nil {
-			msg := fmt.Sprintf("invalid firebase id token %s", authToken)
+			msg := fmt.Sprintf("invalid [%s] token %s", bearerScheme, authToken)
 			ctxLogger.Error(tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg)))
 			return c.Next()
 		}
 
-		span.AddEvent(fmt.Sprintf("[%s] token is valid", bearerScheme))
+		if authUser.IsNoop() {
+			ctxLogger.Warn(stacktrace.NewError(fmt.Sprintf("[%s] token has no user ID or email for user [%s]", bearerScheme, authUser.ID)))
+			return c.Next()
+		}
 
-		authUser := entities.AuthUser{
-			Email: token.Claims["email"].(string),
-			ID:    entities.UserID(token.Claims["user_id"].(string)),
+		if err = sessionService.Validate(ctx, authUser, c.IP(), c.Get(fiber.HeaderUserAgent)); err != nil {
+			msg := fmt.Sprintf("cannot validate session [%s] of user [%s]", authUser.SessionID, authUser.ID)
+			ctxLogger.Warn(tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg)))
+			return c.Next()
 		}
 
+		span.AddEvent(fmt.Sprintf("[%s] token is valid", bearerScheme))
+
 		c.Locals(ContextKeyAuthUserID, authUser)
 
 		ctxLogger.Info(fmt.Sprintf("[%T] set successfully for user with ID [%s]", authUser, authUser.ID))
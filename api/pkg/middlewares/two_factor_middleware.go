@@ -0,0 +1,61 @@
+package middlewares
+
+import (
+	"fmt"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/gofiber/fiber/v2"
+	"github.com/palantir/stacktrace"
+)
+
+const (
+	// HeaderTwoFactorCode is the header containing the TOTP or recovery code of a sensitive request
+	HeaderTwoFactorCode = "x-2fa-code"
+)
+
+// TwoFactor requires the code in the HeaderTwoFactorCode header when the authenticated user has enabled two factor authentication
+func TwoFactor(logger telemetry.Logger, tracer telemetry.Tracer, service *services.TwoFactorService) fiber.Handler {
+	logger = logger.WithService("middlewares.TwoFactor")
+
+	return func(c *fiber.Ctx) error {
+		ctx, span := tracer.StartFromFiberCtx(c, "middlewares.TwoFactor")
+		defer span.End()
+
+		ctxLogger := tracer.CtxLogger(logger, span)
+
+		authUser, ok := c.Locals(ContextKeyAuthUserID).(entities.AuthUser)
+		if !ok {
+			return c.Next()
+		}
+
+		err := service.Check(ctx, authUser.ID, c.Get(HeaderTwoFactorCode))
+		if stacktrace.GetCode(err) == services.ErrCodeTwoFactorRequired || stacktrace.GetCode(err) == services.ErrCodeTwoFactorInvalidCode {
+			ctxLogger.Warn(stacktrace.Propagate(err, fmt.Sprintf("two factor check failed for user [%s]", authUser.ID)))
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"status":  "error",
+				"message": "This request requires a valid two factor authentication code.",
+				"data":    fmt.Sprintf("Set the code from your authenticator app or a recovery code in the [%s] header", HeaderTwoFactorCode),
+			})
+		}
+
+		if stacktrace.GetCode(err) == services.ErrCodeTwoFactorLocked {
+			ctxLogger.Warn(stacktrace.Propagate(err, fmt.Sprintf("two factor check is locked for user [%s]", authUser.ID)))
+			return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
+				"status":  "error",
+				"message": "Too many invalid two factor authentication codes were used, retry the request later.",
+			})
+		}
+
+		if err != nil {
+			ctxLogger.Error(stacktrace.Propagate(err, fmt.Sprintf("cannot check two factor code for user [%s]", authUser.ID)))
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"status":  "error",
+				"message": "We ran into an internal error while handling the request.",
+			})
+		}
+
+		return c.Next()
+	}
+}
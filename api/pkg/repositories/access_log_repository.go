@@ -0,0 +1,27 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+)
+
+// AccessLogIndexParams are parameters for fetching entities.AccessLog
+type AccessLogIndexParams struct {
+	IndexParams
+	UserID *entities.UserID
+	Status *int
+}
+
+// AccessLogRepository loads and persists an entities.AccessLog
+type AccessLogRepository interface {
+	// StoreBatch stores a list of entities.AccessLog
+	StoreBatch(ctx context.Context, logs []*entities.AccessLog) error
+
+	// Index the entities.AccessLog of all users
+	Index(ctx context.Context, params AccessLogIndexParams) ([]*entities.AccessLog, error)
+
+	// DeleteBefore deletes the entities.AccessLog which were created before a timestamp
+	DeleteBefore(ctx context.Context, timestamp time.Time) (int64, error)
+}
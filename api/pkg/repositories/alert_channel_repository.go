@@ -0,0 +1,29 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/google/uuid"
+)
+
+// AlertChannelRepository loads and persists an entities.AlertChannel
+type AlertChannelRepository interface {
+	// Store a new entities.AlertChannel
+	Store(ctx context.Context, channel *entities.AlertChannel) error
+
+	// Update an entities.AlertChannel
+	Update(ctx context.Context, channel *entities.AlertChannel) error
+
+	// Load an entities.AlertChannel by ID
+	Load(ctx context.Context, userID entities.UserID, channelID uuid.UUID) (*entities.AlertChannel, error)
+
+	// Index entities.AlertChannel of a user
+	Index(ctx context.Context, userID entities.UserID, params IndexParams) ([]*entities.AlertChannel, error)
+
+	// FetchEnabledByEvent fetches the enabled entities.AlertChannel of a user which are subscribed to an event
+	FetchEnabledByEvent(ctx context.Context, userID entities.UserID, eventType string) ([]*entities.AlertChannel, error)
+
+	// Delete an entities.AlertChannel
+	Delete(ctx context.Context, userID entities.UserID, channelID uuid.UUID) error
+}
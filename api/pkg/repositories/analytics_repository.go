@@ -0,0 +1,64 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+)
+
+// TimeSeriesParams are parameters for aggregating a time series
+type TimeSeriesParams struct {
+	Metric      entities.TimeSeriesMetric
+	Granularity entities.TimeSeriesGranularity
+	GroupBy     entities.TimeSeriesGroup
+	From        time.Time
+	To          time.Time
+}
+
+// FailureStatsParams are parameters for counting failed messages by entities.MessageFailureCode
+type FailureStatsParams struct {
+	GroupBy entities.TimeSeriesGroup
+	From    time.Time
+	To      time.Time
+}
+
+// PrefixDeliveryParams are parameters for counting the delivery of messages by the prefix of the contact phone number
+type PrefixDeliveryParams struct {
+	PrefixLength int
+	From         time.Time
+	To           time.Time
+}
+
+// AnalyticsRepository aggregates metrics about entities.Message
+type AnalyticsRepository interface {
+	// TimeSeries aggregates a metric of a user into time buckets
+	TimeSeries(ctx context.Context, userID entities.UserID, params TimeSeriesParams) ([]*entities.TimeSeriesPoint, error)
+
+	// FailureStats counts the failed messages of a user by entities.MessageFailureCode
+	FailureStats(ctx context.Context, userID entities.UserID, params FailureStatsParams) ([]*entities.MessageFailureCodeCount, error)
+
+	// PrefixDeliveryCounts counts the messages of a user which were sent, delivered or did not get a delivery report by owner and contact phone number prefix
+	PrefixDeliveryCounts(ctx context.Context, userID entities.UserID, params PrefixDeliveryParams) ([]*entities.PrefixDeliveryCount, error)
+
+	// OldestOutstanding returns when the oldest outstanding entities.Message of all users was requested since a timestamp
+	OldestOutstanding(ctx context.Context, since time.Time) (*time.Time, error)
+
+	// DeliveryLatencies fetches the latest delivery durations of all users since a timestamp
+	DeliveryLatencies(ctx context.Context, since time.Time, limit int) ([]*entities.DeliveryLatencySample, error)
+
+	// MessageCounts counts the messages of a user which were sent, delivered, failed or received since a timestamp
+	MessageCounts(ctx context.Context, userID entities.UserID, since time.Time) (*entities.DashboardMessageCounts, error)
+
+	// QueueDepth counts the messages of a user which are waiting to be sent by status
+	QueueDepth(ctx context.Context, userID entities.UserID) (*entities.DashboardQueueDepth, error)
+
+	// RecentFailures fetches the latest failed messages of a user
+	RecentFailures(ctx context.Context, userID entities.UserID, limit int) ([]*entities.Message, error)
+
+	// MessageTotals counts all the messages of a user by owner phone number
+	MessageTotals(ctx context.Context, userID entities.UserID) ([]*entities.MessageTotals, error)
+
+	// QueueDepthByOwner counts the messages of a user which are waiting to be sent by owner phone number and status
+	QueueDepthByOwner(ctx context.Context, userID entities.UserID) ([]*entities.MessageQueueCount, error)
+}
@@ -0,0 +1,36 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/google/uuid"
+)
+
+// ArchivedMessageIndexParams are parameters for fetching the entities.ArchivedMessage of a user
+type ArchivedMessageIndexParams struct {
+	Owner   string
+	Contact string
+	Cursor  *EventCursor
+	Limit   int
+}
+
+// ArchivedMessageRepository moves old entities.Message into the archive and loads the entities.ArchivedMessage
+type ArchivedMessageRepository interface {
+	// Archive moves the entities.Message of all users with one of the statuses and an order timestamp before a time into the archive.
+	// At most limit messages are moved from every database and the number of messages which were moved is returned.
+	Archive(ctx context.Context, before time.Time, statuses []entities.MessageStatus, limit int) (int, error)
+
+	// Index fetches the entities.ArchivedMessage of a user from the newest to the oldest
+	Index(ctx context.Context, userID entities.UserID, params ArchivedMessageIndexParams) ([]*entities.ArchivedMessage, error)
+
+	// Load an entities.ArchivedMessage of a user by ID
+	Load(ctx context.Context, userID entities.UserID, messageID uuid.UUID) (*entities.ArchivedMessage, error)
+
+	// Purge deletes at most limit entities.ArchivedMessage of a user with an order timestamp before a time and returns their IDs
+	Purge(ctx context.Context, userID entities.UserID, before time.Time, limit int) ([]uuid.UUID, error)
+
+	// Anonymize removes the content of at most limit entities.ArchivedMessage of a user with an order timestamp before a time and returns their IDs
+	Anonymize(ctx context.Context, userID entities.UserID, before time.Time, limit int) ([]uuid.UUID, error)
+}
@@ -0,0 +1,20 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/google/uuid"
+)
+
+// AttachmentRepository loads and persists an entities.Attachment
+type AttachmentRepository interface {
+	// Store a new entities.Attachment
+	Store(ctx context.Context, attachment *entities.Attachment) error
+
+	// Load an entities.Attachment by ID
+	Load(ctx context.Context, userID entities.UserID, attachmentID uuid.UUID) (*entities.Attachment, error)
+
+	// FetchByMessageIDs fetches the entities.Attachment of messages ordered by creation date
+	FetchByMessageIDs(ctx context.Context, userID entities.UserID, messageIDs []uuid.UUID) ([]*entities.Attachment, error)
+}
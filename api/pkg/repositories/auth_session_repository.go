@@ -0,0 +1,30 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/google/uuid"
+)
+
+// AuthSessionRepository loads and persists an entities.AuthSession
+type AuthSessionRepository interface {
+	// Store a new entities.AuthSession
+	Store(ctx context.Context, session *entities.AuthSession) error
+
+	// Update an entities.AuthSession
+	Update(ctx context.Context, session *entities.AuthSession) error
+
+	// Load an entities.AuthSession of a user by ID
+	Load(ctx context.Context, userID entities.UserID, sessionID uuid.UUID) (*entities.AuthSession, error)
+
+	// LoadByKey loads an entities.AuthSession by the SessionID of an entities.AuthUser
+	LoadByKey(ctx context.Context, key string) (*entities.AuthSession, error)
+
+	// IndexActive fetches the sessions of a user which are neither revoked nor expired, the most recently used first
+	IndexActive(ctx context.Context, userID entities.UserID, timestamp time.Time) ([]*entities.AuthSession, error)
+
+	// Revoke an entities.AuthSession of a user
+	Revoke(ctx context.Context, userID entities.UserID, sessionID uuid.UUID, timestamp time.Time) error
+}
@@ -20,4 +20,7 @@ type BillingUsageRepository interface {
 
 	// GetHistory returns past billing usage by entities.UserID
 	GetHistory(ctx context.Context, userID entities.UserID, params IndexParams) (*[]entities.BillingUsage, error)
+
+	// LoadByPeriod returns the billing usage of a user for the month which starts at periodStart
+	LoadByPeriod(ctx context.Context, userID entities.UserID, periodStart time.Time) (*entities.BillingUsage, error)
 }
@@ -0,0 +1,29 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/google/uuid"
+)
+
+// BlocklistRepository loads and persists an entities.Blocklist
+type BlocklistRepository interface {
+	// Store new entities.Blocklist, phone numbers which are already on the blocklist are ignored
+	Store(ctx context.Context, entries []*entities.Blocklist) error
+
+	// Index entities.Blocklist of a user
+	Index(ctx context.Context, userID entities.UserID, params IndexParams) ([]*entities.Blocklist, error)
+
+	// FetchAll returns every entities.Blocklist of a user
+	FetchAll(ctx context.Context, userID entities.UserID) ([]*entities.Blocklist, error)
+
+	// Existing returns the phone numbers which are already on the blocklist of a user
+	Existing(ctx context.Context, userID entities.UserID, phoneNumbers []string) ([]string, error)
+
+	// Delete an entities.Blocklist
+	Delete(ctx context.Context, userID entities.UserID, blocklistID uuid.UUID) error
+
+	// DeleteByPhoneNumber removes a phone number from the blocklist of a user if it was added with the entities.BlocklistReason
+	DeleteByPhoneNumber(ctx context.Context, userID entities.UserID, phoneNumber string, reason entities.BlocklistReason) error
+}
@@ -0,0 +1,27 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/google/uuid"
+)
+
+// BroadcastRepository loads and persists an entities.Broadcast
+type BroadcastRepository interface {
+	// Store a new entities.Broadcast
+	Store(ctx context.Context, broadcast *entities.Broadcast) error
+
+	// Load an entities.Broadcast by ID
+	Load(ctx context.Context, broadcastID uuid.UUID) (*entities.Broadcast, error)
+
+	// Index all entities.Broadcast of the instance
+	Index(ctx context.Context, params IndexParams) ([]*entities.Broadcast, error)
+
+	// FetchActive fetches the entities.Broadcast which are displayed at a timestamp
+	FetchActive(ctx context.Context, timestamp time.Time) ([]*entities.Broadcast, error)
+
+	// Delete an entities.Broadcast
+	Delete(ctx context.Context, broadcastID uuid.UUID) error
+}
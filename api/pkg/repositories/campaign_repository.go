@@ -0,0 +1,34 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/google/uuid"
+)
+
+// CampaignRepository loads and persists an entities.Campaign
+type CampaignRepository interface {
+	// Store a new entities.Campaign
+	Store(ctx context.Context, campaign *entities.Campaign) error
+
+	// Update an entities.Campaign
+	Update(ctx context.Context, campaign *entities.Campaign) error
+
+	// Load an entities.Campaign by ID
+	Load(ctx context.Context, userID entities.UserID, campaignID uuid.UUID) (*entities.Campaign, error)
+
+	// Index entities.Campaign of a user
+	Index(ctx context.Context, userID entities.UserID, params IndexParams) ([]*entities.Campaign, error)
+
+	// Delete an entities.Campaign
+	Delete(ctx context.Context, userID entities.UserID, campaignID uuid.UUID) error
+
+	// FetchDue fetches the active entities.Campaign of all users which have a NextRunAt before a timestamp
+	FetchDue(ctx context.Context, timestamp time.Time, limit int) ([]*entities.Campaign, error)
+
+	// Claim moves the NextRunAt of an entities.Campaign forward if it has not been changed by another instance.
+	// It returns false when the run was already claimed.
+	Claim(ctx context.Context, campaign *entities.Campaign, nextRunAt *time.Time) (bool, error)
+}
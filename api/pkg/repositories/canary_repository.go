@@ -0,0 +1,44 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/google/uuid"
+)
+
+// CanaryRepository loads and persists an entities.Canary and its entities.CanaryRun
+type CanaryRepository interface {
+	// Store a new entities.Canary
+	Store(ctx context.Context, canary *entities.Canary) error
+
+	// Update an entities.Canary
+	Update(ctx context.Context, canary *entities.Canary) error
+
+	// Load an entities.Canary by ID
+	Load(ctx context.Context, userID entities.UserID, canaryID uuid.UUID) (*entities.Canary, error)
+
+	// Index entities.Canary of a user
+	Index(ctx context.Context, userID entities.UserID, params IndexParams) ([]*entities.Canary, error)
+
+	// Delete an entities.Canary and its runs
+	Delete(ctx context.Context, userID entities.UserID, canaryID uuid.UUID) error
+
+	// StoreRun stores a new entities.CanaryRun
+	StoreRun(ctx context.Context, run *entities.CanaryRun) error
+
+	// UpdateRun updates an entities.CanaryRun
+	UpdateRun(ctx context.Context, run *entities.CanaryRun) error
+
+	// LoadRun loads an entities.CanaryRun by ID
+	LoadRun(ctx context.Context, userID entities.UserID, runID uuid.UUID) (*entities.CanaryRun, error)
+
+	// LoadRunByMessageID loads the entities.CanaryRun which sent a message
+	LoadRunByMessageID(ctx context.Context, userID entities.UserID, messageID uuid.UUID) (*entities.CanaryRun, error)
+
+	// LoadRunByToken loads the entities.CanaryRun with a token
+	LoadRunByToken(ctx context.Context, userID entities.UserID, token string) (*entities.CanaryRun, error)
+
+	// IndexRuns fetches the runs of an entities.Canary
+	IndexRuns(ctx context.Context, userID entities.UserID, canaryID uuid.UUID, params IndexParams) ([]*entities.CanaryRun, error)
+}
@@ -0,0 +1,32 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/google/uuid"
+)
+
+// ContactGroupRepository loads and persists an entities.ContactGroup and its members
+type ContactGroupRepository interface {
+	// Store a new entities.ContactGroup
+	Store(ctx context.Context, group *entities.ContactGroup) error
+
+	// Update an entities.ContactGroup
+	Update(ctx context.Context, group *entities.ContactGroup) error
+
+	// Load an entities.ContactGroup by ID
+	Load(ctx context.Context, userID entities.UserID, groupID uuid.UUID) (*entities.ContactGroup, error)
+
+	// Index entities.ContactGroup of a user
+	Index(ctx context.Context, userID entities.UserID, params IndexParams) ([]*entities.ContactGroup, error)
+
+	// Delete an entities.ContactGroup and its members, the entities.Contact are not deleted
+	Delete(ctx context.Context, userID entities.UserID, groupID uuid.UUID) error
+
+	// AddContacts adds the entities.Contact of the user with the IDs to a group and returns the number of contacts which were added
+	AddContacts(ctx context.Context, userID entities.UserID, groupID uuid.UUID, contactIDs []uuid.UUID) (int64, error)
+
+	// RemoveContact removes an entities.Contact from a group, it returns false when the contact is not in the group
+	RemoveContact(ctx context.Context, userID entities.UserID, groupID uuid.UUID, contactID uuid.UUID) (bool, error)
+}
@@ -0,0 +1,48 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/google/uuid"
+)
+
+// ContactIndexParams are parameters for filtering the entities.Contact of a user
+type ContactIndexParams struct {
+	IndexParams
+	IsOptedOut *bool
+	SortBy     string
+
+	// Filters are the conditions of an entities.Segment which the contacts must match
+	Filters []entities.SegmentFilter
+
+	// GroupID limits the contacts to the members of an entities.ContactGroup
+	GroupID *uuid.UUID
+
+	// Tags limits the contacts to the ones with at least one of the tag names
+	Tags []string
+}
+
+// ContactRepository loads and persists an entities.Contact
+type ContactRepository interface {
+	// Store a new entities.Contact
+	Store(ctx context.Context, contact *entities.Contact) error
+
+	// Update an entities.Contact
+	Update(ctx context.Context, contact *entities.Contact) error
+
+	// Load the entities.Contact of a user by phone number
+	Load(ctx context.Context, userID entities.UserID, phoneNumber string) (*entities.Contact, error)
+
+	// LoadByID loads an entities.Contact of a user by ID
+	LoadByID(ctx context.Context, userID entities.UserID, contactID uuid.UUID) (*entities.Contact, error)
+
+	// Index fetches the entities.Contact of a user
+	Index(ctx context.Context, userID entities.UserID, params ContactIndexParams) ([]*entities.Contact, error)
+
+	// Count returns the number of entities.Contact of a user which match the params
+	Count(ctx context.Context, userID entities.UserID, params ContactIndexParams) (int64, error)
+
+	// Delete an entities.Contact and remove it from all the entities.ContactGroup of the user
+	Delete(ctx context.Context, userID entities.UserID, contactID uuid.UUID) error
+}
@@ -0,0 +1,28 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/google/uuid"
+)
+
+// CouponRepository loads and persists an entities.Coupon
+type CouponRepository interface {
+	// Store a new entities.Coupon
+	Store(ctx context.Context, coupon *entities.Coupon) error
+
+	// LoadByCode loads an entities.Coupon by its case-insensitive code
+	LoadByCode(ctx context.Context, code string) (*entities.Coupon, error)
+
+	// Index fetches all the entities.Coupon of the instance
+	Index(ctx context.Context, params IndexParams) ([]*entities.Coupon, error)
+
+	// Delete an entities.Coupon, the overrides of users who already redeemed it are not affected
+	Delete(ctx context.Context, couponID uuid.UUID) error
+
+	// Redeem increments the redemptions of an entities.Coupon and stores the entities.PlanOverride of the user in a single transaction.
+	// It returns false when the coupon is expired or has no redemptions left at the timestamp.
+	Redeem(ctx context.Context, coupon *entities.Coupon, override *entities.PlanOverride, timestamp time.Time) (bool, error)
+}
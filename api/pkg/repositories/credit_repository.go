@@ -0,0 +1,41 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/palantir/stacktrace"
+)
+
+// ErrCodeInsufficientCredits is returned when a message debit would make the entities.CreditBalance of a user negative
+const ErrCodeInsufficientCredits = stacktrace.ErrorCode(1003)
+
+// CreditTransactionIndexParams are parameters for fetching the credit ledger of a user
+type CreditTransactionIndexParams struct {
+	IndexParams
+	From *time.Time
+	To   *time.Time
+}
+
+// CreditTotals are the credits added to and removed from a balance during a period
+type CreditTotals struct {
+	Added int64
+	Used  int64
+}
+
+// CreditRepository loads and persists the entities.CreditBalance and entities.CreditTransaction of a user
+type CreditRepository interface {
+	// LoadBalance loads the entities.CreditBalance of a user
+	LoadBalance(ctx context.Context, userID entities.UserID) (*entities.CreditBalance, error)
+
+	// Apply stores an entities.CreditTransaction and adds its amount to the entities.CreditBalance of the user in a single transaction.
+	// It returns false when a transaction for the same message already exists and ErrCodeInsufficientCredits when a message debit is larger than the balance.
+	Apply(ctx context.Context, transaction *entities.CreditTransaction) (bool, error)
+
+	// Index fetches the entities.CreditTransaction of a user
+	Index(ctx context.Context, userID entities.UserID, params CreditTransactionIndexParams) ([]*entities.CreditTransaction, error)
+
+	// Totals sums the entities.CreditTransaction of a user created between from and to
+	Totals(ctx context.Context, userID entities.UserID, from time.Time, to time.Time) (*CreditTotals, error)
+}
@@ -0,0 +1,20 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"gorm.io/gorm"
+)
+
+// DatabaseResolver resolves the database which stores the messages of a user
+type DatabaseResolver interface {
+	// Resolve returns the database of the entities.User.Region, the default database is returned when the user has no region
+	Resolve(ctx context.Context, userID entities.UserID) (*gorm.DB, error)
+
+	// Regions returns the names of the regions which can be chosen by a user
+	Regions() []string
+
+	// All returns the default database and the database of every region
+	All() []*gorm.DB
+}
@@ -0,0 +1,62 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/google/uuid"
+	"github.com/palantir/stacktrace"
+)
+
+// encryptedArchivedMessageRepository decrypts the content of the entities.Message in an entities.ArchivedMessage.
+// The messages are archived with the encrypted content which is in the messages table.
+type encryptedArchivedMessageRepository struct {
+	ArchivedMessageRepository
+	cipher MessageContentCipher
+}
+
+// NewEncryptedArchivedMessageRepository creates an ArchivedMessageRepository which decrypts the content of the messages loaded by next
+func NewEncryptedArchivedMessageRepository(next ArchivedMessageRepository, cipher MessageContentCipher) ArchivedMessageRepository {
+	return &encryptedArchivedMessageRepository{
+		ArchivedMessageRepository: next,
+		cipher:                    cipher,
+	}
+}
+
+// Index fetches the entities.ArchivedMessage of a user from the newest to the oldest
+func (repository *encryptedArchivedMessageRepository) Index(ctx context.Context, userID entities.UserID, params ArchivedMessageIndexParams) ([]*entities.ArchivedMessage, error) {
+	messages, err := repository.ArchivedMessageRepository.Index(ctx, userID, params)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, message := range messages {
+		if err = repository.decrypt(ctx, message); err != nil {
+			return nil, err
+		}
+	}
+	return messages, nil
+}
+
+// Load an entities.ArchivedMessage of a user by ID
+func (repository *encryptedArchivedMessageRepository) Load(ctx context.Context, userID entities.UserID, messageID uuid.UUID) (*entities.ArchivedMessage, error) {
+	message, err := repository.ArchivedMessageRepository.Load(ctx, userID, messageID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = repository.decrypt(ctx, message); err != nil {
+		return nil, err
+	}
+	return message, nil
+}
+
+func (repository *encryptedArchivedMessageRepository) decrypt(ctx context.Context, message *entities.ArchivedMessage) error {
+	content, err := repository.cipher.Decrypt(ctx, message.UserID, message.Message.Data.Content)
+	if err != nil {
+		return stacktrace.Propagate(err, fmt.Sprintf("cannot decrypt the content of archived message with ID [%s]", message.ID))
+	}
+	message.Message.Data.Content = content
+	return nil
+}
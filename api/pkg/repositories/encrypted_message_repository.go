@@ -0,0 +1,247 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/google/uuid"
+	"github.com/palantir/stacktrace"
+)
+
+// ErrCodeContentEncrypted is returned when the content of messages is filtered or searched while it is encrypted
+const ErrCodeContentEncrypted = stacktrace.ErrorCode(1002)
+
+// MessageContentCipher encrypts and decrypts the content of the entities.Message of a user
+type MessageContentCipher interface {
+	// Encrypt the content of a message of a user, empty content is returned as it is
+	Encrypt(ctx context.Context, userID entities.UserID, content string) (string, error)
+
+	// Decrypt the content of a message of a user, content which is not encrypted is returned as it is
+	Decrypt(ctx context.Context, userID entities.UserID, content string) (string, error)
+}
+
+// encryptedMessageRepository encrypts the content of an entities.Message before it is persisted by the MessageRepository and decrypts it after it is loaded
+type encryptedMessageRepository struct {
+	MessageRepository
+	tracer telemetry.Tracer
+	cipher MessageContentCipher
+}
+
+// NewEncryptedMessageRepository creates a MessageRepository which encrypts the content of the messages persisted by next
+func NewEncryptedMessageRepository(
+	tracer telemetry.Tracer,
+	next MessageRepository,
+	cipher MessageContentCipher,
+) MessageRepository {
+	return &encryptedMessageRepository{
+		MessageRepository: next,
+		tracer:            tracer,
+		cipher:            cipher,
+	}
+}
+
+// Store a new entities.Message
+func (repository *encryptedMessageRepository) Store(ctx context.Context, message *entities.Message) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	err := repository.persist(ctx, message, repository.MessageRepository.Store)
+	if err != nil {
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, fmt.Sprintf("cannot store encrypted message with ID [%s]", message.ID)))
+	}
+	return nil
+}
+
+// Update an entities.Message
+func (repository *encryptedMessageRepository) Update(ctx context.Context, message *entities.Message) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	err := repository.persist(ctx, message, repository.MessageRepository.Update)
+	if err != nil {
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, fmt.Sprintf("cannot update encrypted message with ID [%s]", message.ID)))
+	}
+	return nil
+}
+
+// Load an entities.Message by ID
+func (repository *encryptedMessageRepository) Load(ctx context.Context, userID entities.UserID, messageID uuid.UUID) (*entities.Message, error) {
+	return repository.one(ctx)(repository.MessageRepository.Load(ctx, userID, messageID))
+}
+
+// Index entities.Message between 2 phone numbers
+func (repository *encryptedMessageRepository) Index(ctx context.Context, userID entities.UserID, owner string, contact string, params IndexParams) (*[]entities.Message, error) {
+	if len(params.Query) > 0 {
+		msg := fmt.Sprintf("cannot filter the messages of user [%s] by content because it is encrypted", userID)
+		return nil, stacktrace.NewErrorWithCode(ErrCodeContentEncrypted, msg)
+	}
+
+	messages, err := repository.MessageRepository.Index(ctx, userID, owner, contact, params)
+	if err != nil {
+		return nil, err
+	}
+
+	for index := range *messages {
+		if err = repository.decrypt(ctx, &(*messages)[index]); err != nil {
+			return nil, err
+		}
+	}
+	return messages, nil
+}
+
+// IndexPage fetches the entities.Message of a user matching the MessageIndexParams from the newest to the oldest
+func (repository *encryptedMessageRepository) IndexPage(ctx context.Context, userID entities.UserID, params MessageIndexParams) ([]*entities.Message, error) {
+	if len(params.Query) > 0 {
+		msg := fmt.Sprintf("cannot filter the messages of user [%s] by content because it is encrypted", userID)
+		return nil, stacktrace.NewErrorWithCode(ErrCodeContentEncrypted, msg)
+	}
+
+	return repository.many(ctx)(repository.MessageRepository.IndexPage(ctx, userID, params))
+}
+
+// Search is not supported because the encrypted content cannot be indexed by the full text search of the database
+func (repository *encryptedMessageRepository) Search(_ context.Context, userID entities.UserID, _ MessageSearchParams) ([]*entities.MessageSearchHit, error) {
+	msg := fmt.Sprintf("cannot search the messages of user [%s] because the content is encrypted", userID)
+	return nil, stacktrace.NewErrorWithCode(ErrCodeContentEncrypted, msg)
+}
+
+// FetchByIDs fetches the entities.Message of a user with the IDs
+func (repository *encryptedMessageRepository) FetchByIDs(ctx context.Context, userID entities.UserID, messageIDs []uuid.UUID) ([]*entities.Message, error) {
+	return repository.many(ctx)(repository.MessageRepository.FetchByIDs(ctx, userID, messageIDs))
+}
+
+// LoadLastInConversation fetches the latest entities.Message sent to a contact with a conversation ID since a timestamp
+func (repository *encryptedMessageRepository) LoadLastInConversation(ctx context.Context, userID entities.UserID, owner string, contact string, since time.Time) (*entities.Message, error) {
+	return repository.one(ctx)(repository.MessageRepository.LoadLastInConversation(ctx, userID, owner, contact, since))
+}
+
+// LoadReceivedDuplicate fetches the latest received entities.Message with the same content from a contact since a timestamp.
+// The encrypted content cannot be compared in the database so all the messages received from the contact since the timestamp are decrypted and compared.
+func (repository *encryptedMessageRepository) LoadReceivedDuplicate(ctx context.Context, userID entities.UserID, owner string, contact string, content string, since time.Time) (*entities.Message, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	messages, err := repository.many(ctx)(repository.MessageRepository.FetchReceivedSince(ctx, userID, owner, contact, since))
+	if err != nil {
+		msg := fmt.Sprintf("cannot fetch messages from contact [%s] to owner [%s] since [%s]", contact, owner, since)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	for _, message := range messages {
+		if message.Content == content {
+			return message, nil
+		}
+	}
+
+	msg := fmt.Sprintf("no duplicate message from contact [%s] to owner [%s] since [%s]", contact, owner, since)
+	return nil, repository.tracer.WrapErrorSpan(span, stacktrace.NewErrorWithCode(ErrCodeNotFound, msg))
+}
+
+// LoadLastSent fetches the latest entities.Message which was sent to a contact before a timestamp
+func (repository *encryptedMessageRepository) LoadLastSent(ctx context.Context, userID entities.UserID, owner string, contact string, before time.Time) (*entities.Message, error) {
+	return repository.one(ctx)(repository.MessageRepository.LoadLastSent(ctx, userID, owner, contact, before))
+}
+
+// GetOutstanding fetches an entities.Message which is outstanding
+func (repository *encryptedMessageRepository) GetOutstanding(ctx context.Context, userID entities.UserID, messageID uuid.UUID) (*entities.Message, error) {
+	return repository.one(ctx)(repository.MessageRepository.GetOutstanding(ctx, userID, messageID))
+}
+
+// IndexBatch fetches the entities.Message which were sent in the same bulk send request
+func (repository *encryptedMessageRepository) IndexBatch(ctx context.Context, userID entities.UserID, batchID uuid.UUID) ([]*entities.Message, error) {
+	return repository.many(ctx)(repository.MessageRepository.IndexBatch(ctx, userID, batchID))
+}
+
+// FetchDue fetches the entities.Message of all users which are queued for a SendAt time before a timestamp
+func (repository *encryptedMessageRepository) FetchDue(ctx context.Context, timestamp time.Time, limit int) ([]*entities.Message, error) {
+	return repository.many(ctx)(repository.MessageRepository.FetchDue(ctx, timestamp, limit))
+}
+
+// FetchAwaiting fetches the entities.Message of a user which are waiting for the message with ID to be sent
+func (repository *encryptedMessageRepository) FetchAwaiting(ctx context.Context, userID entities.UserID, messageID uuid.UUID) ([]*entities.Message, error) {
+	return repository.many(ctx)(repository.MessageRepository.FetchAwaiting(ctx, userID, messageID))
+}
+
+// FetchPaused fetches the paused entities.Message of a user with the oldest first
+func (repository *encryptedMessageRepository) FetchPaused(ctx context.Context, userID entities.UserID, limit int) ([]*entities.Message, error) {
+	return repository.many(ctx)(repository.MessageRepository.FetchPaused(ctx, userID, limit))
+}
+
+// FetchWaiting fetches the entities.Message of an owner which are waiting for the phone with the oldest first
+func (repository *encryptedMessageRepository) FetchWaiting(ctx context.Context, userID entities.UserID, owner string, limit int) ([]*entities.Message, error) {
+	return repository.many(ctx)(repository.MessageRepository.FetchWaiting(ctx, userID, owner, limit))
+}
+
+// IndexConversations fetches the entities.Conversation of a user with the most recent message first
+func (repository *encryptedMessageRepository) IndexConversations(ctx context.Context, userID entities.UserID, params ConversationIndexParams) ([]*entities.Conversation, error) {
+	conversations, err := repository.MessageRepository.IndexConversations(ctx, userID, params)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, conversation := range conversations {
+		content, err := repository.cipher.Decrypt(ctx, userID, conversation.LastMessageContent)
+		if err != nil {
+			return nil, stacktrace.Propagate(err, fmt.Sprintf("cannot decrypt the last message with ID [%s]", conversation.LastMessageID))
+		}
+		conversation.LastMessageContent = content
+	}
+	return conversations, nil
+}
+
+// IndexConversation fetches the entities.Message between an owner and a contact ordered by their sequence from the newest to the oldest
+func (repository *encryptedMessageRepository) IndexConversation(ctx context.Context, userID entities.UserID, owner string, contact string, beforeSequence *uint64, limit int) ([]*entities.Message, error) {
+	return repository.many(ctx)(repository.MessageRepository.IndexConversation(ctx, userID, owner, contact, beforeSequence, limit))
+}
+
+// persist encrypts the content of a message while it is saved, the caller keeps the plain content
+func (repository *encryptedMessageRepository) persist(ctx context.Context, message *entities.Message, save func(ctx context.Context, message *entities.Message) error) error {
+	content := message.Content
+	encrypted, err := repository.cipher.Encrypt(ctx, message.UserID, content)
+	if err != nil {
+		return stacktrace.Propagate(err, fmt.Sprintf("cannot encrypt the content of message with ID [%s]", message.ID))
+	}
+
+	message.Content = encrypted
+	defer func() { message.Content = content }()
+
+	return save(ctx, message)
+}
+
+func (repository *encryptedMessageRepository) decrypt(ctx context.Context, message *entities.Message) error {
+	content, err := repository.cipher.Decrypt(ctx, message.UserID, message.Content)
+	if err != nil {
+		return stacktrace.Propagate(err, fmt.Sprintf("cannot decrypt the content of message with ID [%s]", message.ID))
+	}
+	message.Content = content
+	return nil
+}
+
+func (repository *encryptedMessageRepository) one(ctx context.Context) func(message *entities.Message, err error) (*entities.Message, error) {
+	return func(message *entities.Message, err error) (*entities.Message, error) {
+		if err != nil {
+			return nil, err
+		}
+		if err = repository.decrypt(ctx, message); err != nil {
+			return nil, err
+		}
+		return message, nil
+	}
+}
+
+func (repository *encryptedMessageRepository) many(ctx context.Context) func(messages []*entities.Message, err error) ([]*entities.Message, error) {
+	return func(messages []*entities.Message, err error) ([]*entities.Message, error) {
+		if err != nil {
+			return nil, err
+		}
+		for _, message := range messages {
+			if err = repository.decrypt(ctx, message); err != nil {
+				return nil, err
+			}
+		}
+		return messages, nil
+	}
+}
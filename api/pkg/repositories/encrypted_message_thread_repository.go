@@ -0,0 +1,91 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/google/uuid"
+	"github.com/palantir/stacktrace"
+)
+
+// encryptedMessageThreadRepository encrypts the last message content of an entities.MessageThread before it is persisted and decrypts it after it is loaded.
+// The query of Index still matches the owner and the contact of a thread but it cannot match the encrypted content.
+type encryptedMessageThreadRepository struct {
+	MessageThreadRepository
+	cipher MessageContentCipher
+}
+
+// NewEncryptedMessageThreadRepository creates a MessageThreadRepository which encrypts the last message content of the threads persisted by next
+func NewEncryptedMessageThreadRepository(next MessageThreadRepository, cipher MessageContentCipher) MessageThreadRepository {
+	return &encryptedMessageThreadRepository{
+		MessageThreadRepository: next,
+		cipher:                  cipher,
+	}
+}
+
+// Store a new entities.MessageThread
+func (repository *encryptedMessageThreadRepository) Store(ctx context.Context, thread *entities.MessageThread) error {
+	return repository.persist(ctx, thread, repository.MessageThreadRepository.Store)
+}
+
+// Update an entities.MessageThread
+func (repository *encryptedMessageThreadRepository) Update(ctx context.Context, thread *entities.MessageThread) error {
+	return repository.persist(ctx, thread, repository.MessageThreadRepository.Update)
+}
+
+// LoadByOwnerContact fetches a thread between owner and contact
+func (repository *encryptedMessageThreadRepository) LoadByOwnerContact(ctx context.Context, userID entities.UserID, owner string, contact string) (*entities.MessageThread, error) {
+	thread, err := repository.MessageThreadRepository.LoadByOwnerContact(ctx, userID, owner, contact)
+	if err != nil {
+		return nil, err
+	}
+	return thread, repository.decrypt(ctx, thread)
+}
+
+// Load a thread by ID
+func (repository *encryptedMessageThreadRepository) Load(ctx context.Context, userID entities.UserID, ID uuid.UUID) (*entities.MessageThread, error) {
+	thread, err := repository.MessageThreadRepository.Load(ctx, userID, ID)
+	if err != nil {
+		return nil, err
+	}
+	return thread, repository.decrypt(ctx, thread)
+}
+
+// Index message threads for an owner
+func (repository *encryptedMessageThreadRepository) Index(ctx context.Context, userID entities.UserID, owner string, archived bool, tags []string, params IndexParams) (*[]entities.MessageThread, error) {
+	threads, err := repository.MessageThreadRepository.Index(ctx, userID, owner, archived, tags, params)
+	if err != nil {
+		return nil, err
+	}
+
+	for index := range *threads {
+		if err = repository.decrypt(ctx, &(*threads)[index]); err != nil {
+			return nil, err
+		}
+	}
+	return threads, nil
+}
+
+// persist encrypts the last message content of a thread while it is saved, the caller keeps the plain content
+func (repository *encryptedMessageThreadRepository) persist(ctx context.Context, thread *entities.MessageThread, save func(ctx context.Context, thread *entities.MessageThread) error) error {
+	content := thread.LastMessageContent
+	encrypted, err := repository.cipher.Encrypt(ctx, thread.UserID, content)
+	if err != nil {
+		return stacktrace.Propagate(err, fmt.Sprintf("cannot encrypt the last message content of thread with ID [%s]", thread.ID))
+	}
+
+	thread.LastMessageContent = encrypted
+	defer func() { thread.LastMessageContent = content }()
+
+	return save(ctx, thread)
+}
+
+func (repository *encryptedMessageThreadRepository) decrypt(ctx context.Context, thread *entities.MessageThread) error {
+	content, err := repository.cipher.Decrypt(ctx, thread.UserID, thread.LastMessageContent)
+	if err != nil {
+		return stacktrace.Propagate(err, fmt.Sprintf("cannot decrypt the last message content of thread with ID [%s]", thread.ID))
+	}
+	thread.LastMessageContent = content
+	return nil
+}
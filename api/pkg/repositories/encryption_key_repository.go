@@ -0,0 +1,16 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+)
+
+// EncryptionKeyRepository loads and persists an entities.EncryptionKey
+type EncryptionKeyRepository interface {
+	// Store a new entities.EncryptionKey, it returns false when the user already has a key
+	Store(ctx context.Context, key *entities.EncryptionKey) (bool, error)
+
+	// Load the entities.EncryptionKey of a user
+	Load(ctx context.Context, userID entities.UserID) (*entities.EncryptionKey, error)
+}
@@ -2,10 +2,34 @@ package repositories
 
 import (
 	"context"
+	"time"
 
+	"github.com/NdoleStudio/httpsms/pkg/entities"
 	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/google/uuid"
 )
 
+// EventCursor is the position of a cloudevents.Event when paginating events from the newest to the oldest
+type EventCursor struct {
+	Time time.Time
+	ID   uuid.UUID
+}
+
+// EventIndexParams are parameters for filtering the cloudevents.Event of a user
+type EventIndexParams struct {
+	Types     []string
+	Source    string
+	Since     *time.Time
+	Until     *time.Time
+	EntityID  *uuid.UUID
+	MessageID *uuid.UUID
+	Cursor    *EventCursor
+
+	// After fetches the events which happened after the cursor from the oldest to the newest, it is used to resume a stream of events
+	After *EventCursor
+	Limit int
+}
+
 // EventRepository is responsible for persisting cloudevents.Event
 type EventRepository interface {
 	// Create a new entities.Message
@@ -16,4 +40,10 @@ type EventRepository interface {
 
 	// FetchAll returns all cloudevents.Event ordered by time in ascending order
 	FetchAll(ctx context.Context) (*[]cloudevents.Event, error)
+
+	// Load a cloudevents.Event of a user by ID
+	Load(ctx context.Context, userID entities.UserID, eventID uuid.UUID) (*cloudevents.Event, error)
+
+	// Index returns the cloudevents.Event of a user ordered by time in descending order, or in ascending order when EventIndexParams.After is set
+	Index(ctx context.Context, userID entities.UserID, params EventIndexParams) ([]cloudevents.Event, error)
 }
@@ -0,0 +1,29 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/google/uuid"
+)
+
+// ForwardingRuleRepository loads and persists an entities.ForwardingRule
+type ForwardingRuleRepository interface {
+	// Store a new entities.ForwardingRule
+	Store(ctx context.Context, rule *entities.ForwardingRule) error
+
+	// Update an entities.ForwardingRule
+	Update(ctx context.Context, rule *entities.ForwardingRule) error
+
+	// Load an entities.ForwardingRule by ID
+	Load(ctx context.Context, userID entities.UserID, ruleID uuid.UUID) (*entities.ForwardingRule, error)
+
+	// Index entities.ForwardingRule of a user
+	Index(ctx context.Context, userID entities.UserID, params IndexParams) ([]*entities.ForwardingRule, error)
+
+	// FetchEnabled fetches the enabled entities.ForwardingRule of a user
+	FetchEnabled(ctx context.Context, userID entities.UserID) ([]*entities.ForwardingRule, error)
+
+	// Delete an entities.ForwardingRule
+	Delete(ctx context.Context, userID entities.UserID, ruleID uuid.UUID) error
+}
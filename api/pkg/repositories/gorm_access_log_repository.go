@@ -0,0 +1,88 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/palantir/stacktrace"
+	"gorm.io/gorm"
+)
+
+const accessLogBatchSize = 500
+
+// gormAccessLogRepository is responsible for persisting entities.AccessLog
+type gormAccessLogRepository struct {
+	logger telemetry.Logger
+	tracer telemetry.Tracer
+	db     *gorm.DB
+}
+
+// NewGormAccessLogRepository creates the GORM version of the AccessLogRepository
+func NewGormAccessLogRepository(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	db *gorm.DB,
+) AccessLogRepository {
+	return &gormAccessLogRepository{
+		logger: logger.WithService(fmt.Sprintf("%T", &gormAccessLogRepository{})),
+		tracer: tracer,
+		db:     db,
+	}
+}
+
+// StoreBatch stores a list of entities.AccessLog
+func (repository *gormAccessLogRepository) StoreBatch(ctx context.Context, logs []*entities.AccessLog) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	if err := repository.db.WithContext(ctx).CreateInBatches(logs, accessLogBatchSize).Error; err != nil {
+		msg := fmt.Sprintf("cannot save [%d] access logs", len(logs))
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+// Index the entities.AccessLog of all users
+func (repository *gormAccessLogRepository) Index(ctx context.Context, params AccessLogIndexParams) ([]*entities.AccessLog, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	query := WithoutTenantScope(repository.db.WithContext(ctx))
+	if params.UserID != nil {
+		query = query.Where("user_id = ?", *params.UserID)
+	}
+	if params.Status != nil {
+		query = query.Where("status = ?", *params.Status)
+	}
+	if len(params.Query) > 0 {
+		query = query.Where("path ILIKE ?", "%"+params.Query+"%")
+	}
+
+	logs := make([]*entities.AccessLog, 0)
+	if err := query.Order("created_at DESC").Limit(params.Limit).Offset(params.Skip).Find(&logs).Error; err != nil {
+		msg := fmt.Sprintf("cannot fetch access logs with params [%+#v]", params)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return logs, nil
+}
+
+// DeleteBefore deletes the entities.AccessLog which were created before a timestamp
+func (repository *gormAccessLogRepository) DeleteBefore(ctx context.Context, timestamp time.Time) (int64, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	result := WithoutTenantScope(repository.db.WithContext(ctx)).
+		Where("created_at < ?", timestamp).
+		Delete(&entities.AccessLog{})
+	if result.Error != nil {
+		msg := fmt.Sprintf("cannot delete access logs created before [%s]", timestamp)
+		return 0, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(result.Error, msg))
+	}
+
+	return result.RowsAffected, nil
+}
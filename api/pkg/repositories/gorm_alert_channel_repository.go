@@ -0,0 +1,137 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/google/uuid"
+	"github.com/palantir/stacktrace"
+	"gorm.io/gorm"
+)
+
+// gormAlertChannelRepository is responsible for persisting entities.AlertChannel
+type gormAlertChannelRepository struct {
+	logger telemetry.Logger
+	tracer telemetry.Tracer
+	db     *gorm.DB
+}
+
+// NewGormAlertChannelRepository creates the GORM version of the AlertChannelRepository
+func NewGormAlertChannelRepository(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	db *gorm.DB,
+) AlertChannelRepository {
+	return &gormAlertChannelRepository{
+		logger: logger.WithService(fmt.Sprintf("%T", &gormAlertChannelRepository{})),
+		tracer: tracer,
+		db:     db,
+	}
+}
+
+// Store a new entities.AlertChannel
+func (repository *gormAlertChannelRepository) Store(ctx context.Context, channel *entities.AlertChannel) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	if err := repository.db.WithContext(ctx).Create(channel).Error; err != nil {
+		msg := fmt.Sprintf("cannot save alert channel with ID [%s]", channel.ID)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+// Update an entities.AlertChannel
+func (repository *gormAlertChannelRepository) Update(ctx context.Context, channel *entities.AlertChannel) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	if err := repository.db.WithContext(ctx).Save(channel).Error; err != nil {
+		msg := fmt.Sprintf("cannot update alert channel with ID [%s]", channel.ID)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+// Load an entities.AlertChannel by ID
+func (repository *gormAlertChannelRepository) Load(ctx context.Context, userID entities.UserID, channelID uuid.UUID) (*entities.AlertChannel, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	channel := new(entities.AlertChannel)
+	err := repository.db.WithContext(ctx).Where("user_id = ?", userID).Where("id = ?", channelID).First(channel).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		msg := fmt.Sprintf("alert channel with ID [%s] for user [%s] does not exist", channelID, userID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, ErrCodeNotFound, msg))
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot load alert channel with ID [%s] for user [%s]", channelID, userID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return channel, nil
+}
+
+// Index entities.AlertChannel of a user
+func (repository *gormAlertChannelRepository) Index(ctx context.Context, userID entities.UserID, params IndexParams) ([]*entities.AlertChannel, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	query := repository.db.WithContext(ctx).Where("user_id = ?", userID)
+	if len(params.Query) > 0 {
+		queryPattern := "%" + params.Query + "%"
+		query.Where(repository.db.Where("name ILIKE ?", queryPattern).Or("type ILIKE ?", queryPattern))
+	}
+
+	channels := make([]*entities.AlertChannel, 0)
+	if err := query.Order("created_at DESC").Limit(params.Limit).Offset(params.Skip).Find(&channels).Error; err != nil {
+		msg := fmt.Sprintf("cannot fetch alert channels for user [%s] and params [%+#v]", userID, params)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return channels, nil
+}
+
+// FetchEnabledByEvent fetches the enabled entities.AlertChannel of a user which are subscribed to an event
+func (repository *gormAlertChannelRepository) FetchEnabledByEvent(ctx context.Context, userID entities.UserID, eventType string) ([]*entities.AlertChannel, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	channels := make([]*entities.AlertChannel, 0)
+	err := repository.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Where("enabled = ?", true).
+		Where("CAST(? as TEXT) = ANY(events)", eventType).
+		Order("created_at ASC").
+		Find(&channels).
+		Error
+	if err != nil {
+		msg := fmt.Sprintf("cannot fetch enabled alert channels for user [%s] and event [%s]", userID, eventType)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return channels, nil
+}
+
+// Delete an entities.AlertChannel
+func (repository *gormAlertChannelRepository) Delete(ctx context.Context, userID entities.UserID, channelID uuid.UUID) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	err := repository.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Where("id = ?", channelID).
+		Delete(&entities.AlertChannel{}).Error
+	if err != nil {
+		msg := fmt.Sprintf("cannot delete alert channel with ID [%s] and userID [%s]", channelID, userID)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
@@ -0,0 +1,407 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/palantir/stacktrace"
+	"gorm.io/gorm"
+)
+
+// queuedMessageStatuses are the statuses of the messages which are waiting to be sent
+var queuedMessageStatuses = []entities.MessageStatus{
+	entities.MessageStatusPending,
+	entities.MessageStatusScheduled,
+	entities.MessageStatusSending,
+	entities.MessageStatusQueuedForFuture,
+	entities.MessageStatusPaused,
+	entities.MessageStatusAwaitingPredecessor,
+}
+
+// gormAnalyticsRepository aggregates metrics with GORM
+type gormAnalyticsRepository struct {
+	logger telemetry.Logger
+	tracer telemetry.Tracer
+	db     *gorm.DB
+
+	// resolver returns the database of a user, metrics of all users are aggregated on the default database
+	resolver DatabaseResolver
+}
+
+// NewGormAnalyticsRepository creates the GORM version of the AnalyticsRepository
+func NewGormAnalyticsRepository(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	db *gorm.DB,
+	resolver DatabaseResolver,
+) AnalyticsRepository {
+	return &gormAnalyticsRepository{
+		logger:   logger.WithService(fmt.Sprintf("%T", &gormAnalyticsRepository{})),
+		tracer:   tracer,
+		db:       db,
+		resolver: resolver,
+	}
+}
+
+// TimeSeries aggregates a metric of a user into time buckets
+func (repository *gormAnalyticsRepository) TimeSeries(ctx context.Context, userID entities.UserID, params TimeSeriesParams) ([]*entities.TimeSeriesPoint, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	column, value := repository.metricColumns(params.Metric)
+	group := repository.groupColumn(params.GroupBy)
+
+	query := fmt.Sprintf(
+		"SELECT date_trunc(?, %[1]s) AS timestamp, %[2]s AS \"group\", %[3]s AS value FROM messages WHERE user_id = ? AND %[1]s >= ? AND %[1]s < ? GROUP BY 1, 2 ORDER BY 1 ASC, 2 ASC",
+		column,
+		group,
+		value,
+	)
+
+	db, err := repository.resolver.Resolve(ctx, userID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot resolve database of user [%s]", userID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	points := make([]*entities.TimeSeriesPoint, 0)
+	err = db.WithContext(ctx).
+		Raw(query, string(params.Granularity), userID, params.From, params.To).
+		Scan(&points).
+		Error
+	if err != nil {
+		msg := fmt.Sprintf("cannot aggregate time series for user [%s] with params [%+#v]", userID, params)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return points, nil
+}
+
+// FailureStats counts the failed messages of a user by entities.MessageFailureCode
+func (repository *gormAnalyticsRepository) FailureStats(ctx context.Context, userID entities.UserID, params FailureStatsParams) ([]*entities.MessageFailureCodeCount, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	query := fmt.Sprintf(
+		"SELECT COALESCE(failure_code, ?) AS failure_code, %s AS \"group\", COUNT(*) AS count FROM messages WHERE user_id = ? AND status = ? AND failed_at >= ? AND failed_at < ? GROUP BY 1, 2 ORDER BY 3 DESC",
+		repository.groupColumn(params.GroupBy),
+	)
+
+	db, err := repository.resolver.Resolve(ctx, userID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot resolve database of user [%s]", userID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	counts := make([]*entities.MessageFailureCodeCount, 0)
+	err = db.WithContext(ctx).
+		Raw(query, entities.MessageFailureCodeUnknown, userID, entities.MessageStatusFailed, params.From, params.To).
+		Scan(&counts).
+		Error
+	if err != nil {
+		msg := fmt.Sprintf("cannot count failure codes for user [%s] with params [%+#v]", userID, params)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return counts, nil
+}
+
+// PrefixDeliveryCounts counts the messages of a user which were sent, delivered or did not get a delivery report by owner and contact phone number prefix
+func (repository *gormAnalyticsRepository) PrefixDeliveryCounts(ctx context.Context, userID entities.UserID, params PrefixDeliveryParams) ([]*entities.PrefixDeliveryCount, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	db, err := repository.resolver.Resolve(ctx, userID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot resolve database of user [%s]", userID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	counts := make([]*entities.PrefixDeliveryCount, 0)
+	err = db.WithContext(ctx).
+		Raw(
+			"SELECT owner, LEFT(contact, ?) AS prefix, COUNT(*) AS sent, COUNT(*) FILTER (WHERE delivered_at IS NOT NULL) AS delivered, COUNT(*) FILTER (WHERE delivery_unknown_at IS NOT NULL AND delivered_at IS NULL) AS delivery_unknown FROM messages WHERE user_id = ? AND sent_at >= ? AND sent_at < ? GROUP BY 1, 2 ORDER BY 1 ASC, 2 ASC",
+			params.PrefixLength,
+			userID,
+			params.From,
+			params.To,
+		).
+		Scan(&counts).
+		Error
+	if err != nil {
+		msg := fmt.Sprintf("cannot count delivery by prefix for user [%s] with params [%+#v]", userID, params)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return counts, nil
+}
+
+// OldestOutstanding returns when the oldest outstanding entities.Message of all users was requested since a timestamp
+func (repository *gormAnalyticsRepository) OldestOutstanding(ctx context.Context, since time.Time) (*time.Time, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	var oldest *time.Time
+	err := WithoutTenantScope(repository.db.WithContext(ctx)).
+		Model(&entities.Message{}).
+		Select("MIN(request_received_at)").
+		Where("status IN ?", []entities.MessageStatus{entities.MessageStatusPending, entities.MessageStatusScheduled, entities.MessageStatusSending}).
+		Where("request_received_at >= ?", since).
+		Scan(&oldest).
+		Error
+	if err != nil {
+		msg := fmt.Sprintf("cannot load oldest outstanding message since [%s]", since)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return oldest, nil
+}
+
+// DeliveryLatencies fetches the latest delivery durations of all users since a timestamp
+func (repository *gormAnalyticsRepository) DeliveryLatencies(ctx context.Context, since time.Time, limit int) ([]*entities.DeliveryLatencySample, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	samples := make([]*entities.DeliveryLatencySample, 0)
+	err := repository.db.WithContext(ctx).
+		Raw(
+			"SELECT contact, EXTRACT(EPOCH FROM (delivered_at - sent_at)) AS seconds FROM messages WHERE delivered_at >= ? AND sent_at IS NOT NULL AND delivered_at >= sent_at ORDER BY delivered_at DESC LIMIT ?",
+			since,
+			limit,
+		).
+		Scan(&samples).
+		Error
+	if err != nil {
+		msg := fmt.Sprintf("cannot fetch delivery latencies since [%s]", since)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return samples, nil
+}
+
+// MessageCounts counts the messages of a user which were sent, delivered, failed or received since a timestamp
+func (repository *gormAnalyticsRepository) MessageCounts(ctx context.Context, userID entities.UserID, since time.Time) (*entities.DashboardMessageCounts, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	db, err := repository.resolver.Resolve(ctx, userID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot resolve database of user [%s]", userID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	counts := new(entities.DashboardMessageCounts)
+	err = db.WithContext(ctx).
+		Raw(
+			"SELECT COUNT(*) FILTER (WHERE sent_at >= ?) AS sent, COUNT(*) FILTER (WHERE delivered_at >= ?) AS delivered, COUNT(*) FILTER (WHERE failed_at >= ?) AS failed, COUNT(*) FILTER (WHERE received_at >= ?) AS received FROM messages WHERE user_id = ? AND GREATEST(sent_at, delivered_at, failed_at, received_at) >= ?",
+			since, since, since, since,
+			userID,
+			since,
+		).
+		Scan(counts).
+		Error
+	if err != nil {
+		msg := fmt.Sprintf("cannot count messages for user [%s] since [%s]", userID, since)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return counts, nil
+}
+
+// QueueDepth counts the messages of a user which are waiting to be sent by status
+func (repository *gormAnalyticsRepository) QueueDepth(ctx context.Context, userID entities.UserID) (*entities.DashboardQueueDepth, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	db, err := repository.resolver.Resolve(ctx, userID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot resolve database of user [%s]", userID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	var rows []struct {
+		Status entities.MessageStatus
+		Count  uint
+	}
+	err = db.WithContext(ctx).
+		Model(&entities.Message{}).
+		Select("status, COUNT(*) AS count").
+		Where("user_id = ?", userID).
+		Where("status IN ?", queuedMessageStatuses).
+		Group("status").
+		Scan(&rows).
+		Error
+	if err != nil {
+		msg := fmt.Sprintf("cannot count queued messages for user [%s]", userID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	queue := new(entities.DashboardQueueDepth)
+	for _, row := range rows {
+		queue.Total += row.Count
+		switch row.Status {
+		case entities.MessageStatusPending:
+			queue.Pending = row.Count
+		case entities.MessageStatusScheduled:
+			queue.Scheduled = row.Count
+		case entities.MessageStatusSending:
+			queue.Sending = row.Count
+		case entities.MessageStatusQueuedForFuture:
+			queue.QueuedForFuture = row.Count
+		case entities.MessageStatusPaused:
+			queue.Paused = row.Count
+		case entities.MessageStatusAwaitingPredecessor:
+			queue.AwaitingPredecessor = row.Count
+		}
+	}
+
+	return queue, nil
+}
+
+// MessageTotals counts all the messages of a user by owner phone number
+func (repository *gormAnalyticsRepository) MessageTotals(ctx context.Context, userID entities.UserID) ([]*entities.MessageTotals, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	db, err := repository.resolver.Resolve(ctx, userID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot resolve database of user [%s]", userID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	columns := []string{
+		"owner",
+		"COUNT(*) FILTER (WHERE sent_at IS NOT NULL)",
+		"COUNT(*) FILTER (WHERE delivered_at IS NOT NULL)",
+		"COUNT(*) FILTER (WHERE failed_at IS NOT NULL)",
+		"COUNT(*) FILTER (WHERE expired_at IS NOT NULL)",
+		"COUNT(*) FILTER (WHERE received_at IS NOT NULL)",
+		"COUNT(send_duration)",
+		"COALESCE(SUM(send_duration), 0) / 1e9",
+	}
+	values := make([]any, 0, len(entities.MessageSendDurationBuckets)+1)
+	for _, bucket := range entities.MessageSendDurationBuckets {
+		columns = append(columns, "COUNT(*) FILTER (WHERE send_duration <= ?)")
+		values = append(values, int64(bucket*float64(time.Second)))
+	}
+	values = append(values, userID)
+
+	rows, err := db.WithContext(ctx).
+		Raw(fmt.Sprintf("SELECT %s FROM messages WHERE user_id = ? GROUP BY owner ORDER BY owner", strings.Join(columns, ", ")), values...).
+		Rows()
+	if err != nil {
+		msg := fmt.Sprintf("cannot count messages of user [%s] by owner", userID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+	defer func() { _ = rows.Close() }()
+
+	results := make([]*entities.MessageTotals, 0)
+	for rows.Next() {
+		totals := &entities.MessageTotals{SendDurationBuckets: make([]uint64, len(entities.MessageSendDurationBuckets))}
+		destinations := []any{&totals.Owner, &totals.Sent, &totals.Delivered, &totals.Failed, &totals.Expired, &totals.Received, &totals.SendDurationCount, &totals.SendDurationSeconds}
+		for i := range totals.SendDurationBuckets {
+			destinations = append(destinations, &totals.SendDurationBuckets[i])
+		}
+
+		if err = rows.Scan(destinations...); err != nil {
+			msg := fmt.Sprintf("cannot scan message totals of user [%s]", userID)
+			return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+		}
+		results = append(results, totals)
+	}
+
+	if err = rows.Err(); err != nil {
+		msg := fmt.Sprintf("cannot iterate message totals of user [%s]", userID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return results, nil
+}
+
+// QueueDepthByOwner counts the messages of a user which are waiting to be sent by owner phone number and status
+func (repository *gormAnalyticsRepository) QueueDepthByOwner(ctx context.Context, userID entities.UserID) ([]*entities.MessageQueueCount, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	db, err := repository.resolver.Resolve(ctx, userID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot resolve database of user [%s]", userID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	counts := make([]*entities.MessageQueueCount, 0)
+	err = db.WithContext(ctx).
+		Model(&entities.Message{}).
+		Select("owner, status, COUNT(*) AS count").
+		Where("user_id = ?", userID).
+		Where("status IN ?", queuedMessageStatuses).
+		Group("owner").
+		Group("status").
+		Order("owner").
+		Scan(&counts).
+		Error
+	if err != nil {
+		msg := fmt.Sprintf("cannot count queued messages of user [%s] by owner", userID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return counts, nil
+}
+
+// RecentFailures fetches the latest failed messages of a user
+func (repository *gormAnalyticsRepository) RecentFailures(ctx context.Context, userID entities.UserID, limit int) ([]*entities.Message, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	db, err := repository.resolver.Resolve(ctx, userID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot resolve database of user [%s]", userID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	messages := make([]*entities.Message, 0, limit)
+	err = db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Where("status = ?", entities.MessageStatusFailed).
+		Order("failed_at DESC").
+		Limit(limit).
+		Find(&messages).
+		Error
+	if err != nil {
+		msg := fmt.Sprintf("cannot fetch recent failed messages for user [%s]", userID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return messages, nil
+}
+
+func (repository *gormAnalyticsRepository) groupColumn(group entities.TimeSeriesGroup) string {
+	switch group {
+	case entities.TimeSeriesGroupPhone:
+		return "owner"
+	case entities.TimeSeriesGroupSIM:
+		return "sim"
+	default:
+		return "''"
+	}
+}
+
+// metricColumns returns the timestamp column used to bucket a metric and the expression which aggregates it
+func (repository *gormAnalyticsRepository) metricColumns(metric entities.TimeSeriesMetric) (string, string) {
+	switch metric {
+	case entities.TimeSeriesMetricDelivered:
+		return "delivered_at", "COUNT(*)"
+	case entities.TimeSeriesMetricFailed:
+		return "failed_at", "COUNT(*)"
+	case entities.TimeSeriesMetricReceived:
+		return "received_at", "COUNT(*)"
+	case entities.TimeSeriesMetricLatency:
+		return "sent_at", "COALESCE(AVG(send_duration) / 1000000, 0)"
+	default:
+		return "sent_at", "COUNT(*)"
+	}
+}
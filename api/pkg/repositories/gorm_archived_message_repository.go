@@ -0,0 +1,201 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/cockroachdb/cockroach-go/v2/crdb/crdbgorm"
+	"github.com/google/uuid"
+	"github.com/palantir/stacktrace"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// gormArchivedMessageRepository is responsible for persisting entities.ArchivedMessage in the database of the user region
+type gormArchivedMessageRepository struct {
+	logger   telemetry.Logger
+	tracer   telemetry.Tracer
+	resolver DatabaseResolver
+}
+
+// NewGormArchivedMessageRepository creates the GORM version of the ArchivedMessageRepository
+func NewGormArchivedMessageRepository(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	resolver DatabaseResolver,
+) ArchivedMessageRepository {
+	return &gormArchivedMessageRepository{
+		logger:   logger.WithService(fmt.Sprintf("%T", &gormArchivedMessageRepository{})),
+		tracer:   tracer,
+		resolver: resolver,
+	}
+}
+
+// Archive moves old entities.Message into the archived_messages table of the same database so that messages never leave the region of the user
+func (repository *gormArchivedMessageRepository) Archive(ctx context.Context, before time.Time, statuses []entities.MessageStatus, limit int) (int, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	count := 0
+	for _, db := range repository.resolver.All() {
+		moved := 0
+		err := crdbgorm.ExecuteTx(ctx, db, nil, func(tx *gorm.DB) error {
+			messages := make([]*entities.Message, 0, limit)
+			err := WithoutTenantScope(tx.WithContext(ctx)).
+				Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+				Where("order_timestamp < ?", before).
+				Where("status IN ?", statuses).
+				Order("order_timestamp ASC").
+				Limit(limit).
+				Find(&messages).
+				Error
+			if err != nil {
+				return stacktrace.Propagate(err, fmt.Sprintf("cannot fetch messages older than [%s]", before))
+			}
+
+			if len(messages) == 0 {
+				return nil
+			}
+
+			archivedAt := time.Now().UTC()
+			archived := make([]*entities.ArchivedMessage, 0, len(messages))
+			messageIDs := make([]uuid.UUID, 0, len(messages))
+			for _, message := range messages {
+				archived = append(archived, entities.NewArchivedMessage(message, archivedAt))
+				messageIDs = append(messageIDs, message.ID)
+			}
+
+			if err = tx.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&archived).Error; err != nil {
+				return stacktrace.Propagate(err, fmt.Sprintf("cannot store [%d] archived messages", len(archived)))
+			}
+
+			if err = WithoutTenantScope(tx.WithContext(ctx)).Where("id IN ?", messageIDs).Delete(&entities.Message{}).Error; err != nil {
+				return stacktrace.Propagate(err, fmt.Sprintf("cannot delete [%d] archived messages", len(messageIDs)))
+			}
+
+			moved = len(messages)
+			return nil
+		})
+		if err != nil {
+			msg := fmt.Sprintf("cannot archive messages older than [%s]", before)
+			return count, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+		}
+		count += moved
+	}
+
+	return count, nil
+}
+
+// Index fetches the entities.ArchivedMessage of a user from the newest to the oldest
+func (repository *gormArchivedMessageRepository) Index(ctx context.Context, userID entities.UserID, params ArchivedMessageIndexParams) ([]*entities.ArchivedMessage, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	db, err := repository.resolver.Resolve(ctx, userID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot resolve database of user [%s]", userID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	query := db.WithContext(ctx).Where("user_id = ?", userID)
+	if params.Owner != "" {
+		query.Where("owner = ?", params.Owner)
+	}
+	if params.Contact != "" {
+		query.Where("contact = ?", params.Contact)
+	}
+	if params.Cursor != nil {
+		query.Where(
+			db.Where("order_timestamp < ?", params.Cursor.Time).
+				Or(db.Where("order_timestamp = ?", params.Cursor.Time).Where("id < ?", params.Cursor.ID)),
+		)
+	}
+
+	messages := make([]*entities.ArchivedMessage, 0, params.Limit)
+	if err = query.Order("order_timestamp DESC").Order("id DESC").Limit(params.Limit).Find(&messages).Error; err != nil {
+		msg := fmt.Sprintf("cannot fetch archived messages for user [%s] with params [%+#v]", userID, params)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return messages, nil
+}
+
+// Load an entities.ArchivedMessage of a user by ID
+func (repository *gormArchivedMessageRepository) Load(ctx context.Context, userID entities.UserID, messageID uuid.UUID) (*entities.ArchivedMessage, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	db, err := repository.resolver.Resolve(ctx, userID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot resolve database of user [%s]", userID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	message := new(entities.ArchivedMessage)
+	err = db.WithContext(ctx).Where("user_id = ?", userID).Where("id = ?", messageID).First(message).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		msg := fmt.Sprintf("archived message with ID [%s] for user [%s] does not exist", messageID, userID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, ErrCodeNotFound, msg))
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot load archived message with ID [%s] for user [%s]", messageID, userID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return message, nil
+}
+
+// Purge deletes at most limit entities.ArchivedMessage of a user with an order timestamp before a time and returns their IDs
+func (repository *gormArchivedMessageRepository) Purge(ctx context.Context, userID entities.UserID, before time.Time, limit int) ([]uuid.UUID, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	db, err := repository.resolver.Resolve(ctx, userID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot resolve database of user [%s]", userID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	query := `
+DELETE FROM archived_messages WHERE user_id = ? AND id IN (
+    SELECT id FROM archived_messages WHERE user_id = ? AND order_timestamp < ? ORDER BY order_timestamp ASC LIMIT ?
+) RETURNING id`
+
+	ids := make([]uuid.UUID, 0, limit)
+	if err = db.WithContext(ctx).Raw(query, userID, userID, before, limit).Scan(&ids).Error; err != nil {
+		msg := fmt.Sprintf("cannot delete archived messages of user [%s] older than [%s]", userID, before)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return ids, nil
+}
+
+// Anonymize removes the content from the JSON of at most limit entities.ArchivedMessage of a user with an order timestamp before a time and returns their IDs
+func (repository *gormArchivedMessageRepository) Anonymize(ctx context.Context, userID entities.UserID, before time.Time, limit int) ([]uuid.UUID, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	db, err := repository.resolver.Resolve(ctx, userID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot resolve database of user [%s]", userID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	query := `
+UPDATE archived_messages SET message = jsonb_set(message, '{content}', '""') WHERE user_id = ? AND id IN (
+    SELECT id FROM archived_messages WHERE user_id = ? AND order_timestamp < ? AND message->>'content' <> '' ORDER BY order_timestamp ASC LIMIT ?
+) RETURNING id`
+
+	ids := make([]uuid.UUID, 0, limit)
+	if err = db.WithContext(ctx).Raw(query, userID, userID, before, limit).Scan(&ids).Error; err != nil {
+		msg := fmt.Sprintf("cannot anonymize archived messages of user [%s] older than [%s]", userID, before)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return ids, nil
+}
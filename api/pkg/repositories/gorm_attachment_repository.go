@@ -0,0 +1,86 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/google/uuid"
+	"github.com/palantir/stacktrace"
+	"gorm.io/gorm"
+)
+
+// gormAttachmentRepository is responsible for persisting entities.Attachment
+type gormAttachmentRepository struct {
+	logger telemetry.Logger
+	tracer telemetry.Tracer
+	db     *gorm.DB
+}
+
+// NewGormAttachmentRepository creates the GORM version of the AttachmentRepository
+func NewGormAttachmentRepository(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	db *gorm.DB,
+) AttachmentRepository {
+	return &gormAttachmentRepository{
+		logger: logger.WithService(fmt.Sprintf("%T", &gormAttachmentRepository{})),
+		tracer: tracer,
+		db:     db,
+	}
+}
+
+// Store a new entities.Attachment
+func (repository *gormAttachmentRepository) Store(ctx context.Context, attachment *entities.Attachment) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	if err := repository.db.WithContext(ctx).Create(attachment).Error; err != nil {
+		msg := fmt.Sprintf("cannot save attachment with ID [%s] for message [%s]", attachment.ID, attachment.MessageID)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+// Load an entities.Attachment by ID
+func (repository *gormAttachmentRepository) Load(ctx context.Context, userID entities.UserID, attachmentID uuid.UUID) (*entities.Attachment, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	attachment := new(entities.Attachment)
+	err := repository.db.WithContext(ctx).Where("user_id = ?", userID).Where("id = ?", attachmentID).First(attachment).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		msg := fmt.Sprintf("attachment with ID [%s] for user [%s] does not exist", attachmentID, userID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, ErrCodeNotFound, msg))
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot load attachment with ID [%s] for user [%s]", attachmentID, userID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return attachment, nil
+}
+
+// FetchByMessageIDs fetches the entities.Attachment of messages
+func (repository *gormAttachmentRepository) FetchByMessageIDs(ctx context.Context, userID entities.UserID, messageIDs []uuid.UUID) ([]*entities.Attachment, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	attachments := make([]*entities.Attachment, 0)
+	err := repository.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Where("message_id IN ?", messageIDs).
+		Order("created_at ASC").
+		Find(&attachments).
+		Error
+	if err != nil {
+		msg := fmt.Sprintf("cannot fetch attachments of [%d] messages for user [%s]", len(messageIDs), userID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return attachments, nil
+}
@@ -0,0 +1,141 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/google/uuid"
+	"github.com/palantir/stacktrace"
+	"gorm.io/gorm"
+)
+
+// gormAuthSessionRepository is responsible for persisting entities.AuthSession
+type gormAuthSessionRepository struct {
+	logger telemetry.Logger
+	tracer telemetry.Tracer
+	db     *gorm.DB
+}
+
+// NewGormAuthSessionRepository creates the GORM version of the AuthSessionRepository
+func NewGormAuthSessionRepository(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	db *gorm.DB,
+) AuthSessionRepository {
+	return &gormAuthSessionRepository{
+		logger: logger.WithService(fmt.Sprintf("%T", &gormAuthSessionRepository{})),
+		tracer: tracer,
+		db:     db,
+	}
+}
+
+// Store a new entities.AuthSession
+func (repository *gormAuthSessionRepository) Store(ctx context.Context, session *entities.AuthSession) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	if err := repository.db.WithContext(ctx).Create(session).Error; err != nil {
+		msg := fmt.Sprintf("cannot store session with ID [%s] for user [%s]", session.ID, session.UserID)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+// Update an entities.AuthSession
+func (repository *gormAuthSessionRepository) Update(ctx context.Context, session *entities.AuthSession) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	if err := repository.db.WithContext(ctx).Save(session).Error; err != nil {
+		msg := fmt.Sprintf("cannot update session with ID [%s] for user [%s]", session.ID, session.UserID)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+// Load an entities.AuthSession of a user by ID
+func (repository *gormAuthSessionRepository) Load(ctx context.Context, userID entities.UserID, sessionID uuid.UUID) (*entities.AuthSession, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	session := new(entities.AuthSession)
+	err := repository.db.WithContext(ctx).Where("user_id = ?", userID).Where("id = ?", sessionID).First(session).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		msg := fmt.Sprintf("session with ID [%s] for user [%s] does not exist", sessionID, userID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, ErrCodeNotFound, msg))
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot load session with ID [%s] for user [%s]", sessionID, userID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return session, nil
+}
+
+// LoadByKey loads an entities.AuthSession by the SessionID of an entities.AuthUser
+func (repository *gormAuthSessionRepository) LoadByKey(ctx context.Context, key string) (*entities.AuthSession, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	session := new(entities.AuthSession)
+	err := WithoutTenantScope(repository.db.WithContext(ctx)).Where("session_key = ?", key).First(session).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		msg := fmt.Sprintf("session with key [%s] does not exist", key)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, ErrCodeNotFound, msg))
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot load session with key [%s]", key)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return session, nil
+}
+
+// IndexActive fetches the sessions of a user which are neither revoked nor expired, the most recently used first
+func (repository *gormAuthSessionRepository) IndexActive(ctx context.Context, userID entities.UserID, timestamp time.Time) ([]*entities.AuthSession, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	sessions := make([]*entities.AuthSession, 0)
+	err := repository.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Where("revoked_at IS NULL").
+		Where("expires_at > ?", timestamp).
+		Order("last_seen_at DESC").
+		Find(&sessions).
+		Error
+	if err != nil {
+		msg := fmt.Sprintf("cannot fetch active sessions of user [%s]", userID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return sessions, nil
+}
+
+// Revoke an entities.AuthSession of a user
+func (repository *gormAuthSessionRepository) Revoke(ctx context.Context, userID entities.UserID, sessionID uuid.UUID, timestamp time.Time) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	err := repository.db.WithContext(ctx).
+		Model(&entities.AuthSession{}).
+		Where("user_id = ?", userID).
+		Where("id = ?", sessionID).
+		Where("revoked_at IS NULL").
+		Updates(map[string]any{"revoked_at": timestamp, "updated_at": timestamp}).
+		Error
+	if err != nil {
+		msg := fmt.Sprintf("cannot revoke session with ID [%s] for user [%s]", sessionID, userID)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
@@ -131,6 +131,30 @@ func (repository *gormBillingUsageRepository) GetHistory(ctx context.Context, us
 	return usages, err
 }
 
+// LoadByPeriod returns the billing usage of a user for the month which starts at periodStart
+func (repository *gormBillingUsageRepository) LoadByPeriod(ctx context.Context, userID entities.UserID, periodStart time.Time) (*entities.BillingUsage, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	usage := new(entities.BillingUsage)
+	err := repository.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Where("start_timestamp = ?", now.New(periodStart).BeginningOfMonth()).
+		First(usage).
+		Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		msg := fmt.Sprintf("billing usage for period [%s] and user [%s] does not exist", periodStart, userID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, ErrCodeNotFound, msg))
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot load billing usage for period [%s] and user [%s]", periodStart, userID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return usage, nil
+}
+
 func (repository *gormBillingUsageRepository) createBillingUsage(userID entities.UserID, timestamp time.Time, sent uint, received uint) *entities.BillingUsage {
 	return &entities.BillingUsage{
 		ID:               uuid.New(),
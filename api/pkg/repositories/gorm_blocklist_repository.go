@@ -0,0 +1,151 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/google/uuid"
+	"github.com/palantir/stacktrace"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// blocklistBatchSize is the maximum number of rows inserted or queried at once
+const blocklistBatchSize = 1000
+
+// gormBlocklistRepository is responsible for persisting entities.Blocklist
+type gormBlocklistRepository struct {
+	logger telemetry.Logger
+	tracer telemetry.Tracer
+	db     *gorm.DB
+}
+
+// NewGormBlocklistRepository creates the GORM version of the BlocklistRepository
+func NewGormBlocklistRepository(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	db *gorm.DB,
+) BlocklistRepository {
+	return &gormBlocklistRepository{
+		logger: logger.WithService(fmt.Sprintf("%T", &gormBlocklistRepository{})),
+		tracer: tracer,
+		db:     db,
+	}
+}
+
+// Store new entities.Blocklist, phone numbers which are already on the blocklist are ignored
+func (repository *gormBlocklistRepository) Store(ctx context.Context, entries []*entities.Blocklist) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	if len(entries) == 0 {
+		return nil
+	}
+
+	err := repository.db.WithContext(ctx).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		CreateInBatches(entries, blocklistBatchSize).
+		Error
+	if err != nil {
+		msg := fmt.Sprintf("cannot save [%d] blocklist entries for user [%s]", len(entries), entries[0].UserID)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+// Index entities.Blocklist of a user
+func (repository *gormBlocklistRepository) Index(ctx context.Context, userID entities.UserID, params IndexParams) ([]*entities.Blocklist, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	query := repository.db.WithContext(ctx).Where("user_id = ?", userID)
+	if len(params.Query) > 0 {
+		query.Where("phone_number ILIKE ?", "%"+params.Query+"%")
+	}
+
+	entries := make([]*entities.Blocklist, 0)
+	if err := query.Order("created_at DESC").Limit(params.Limit).Offset(params.Skip).Find(&entries).Error; err != nil {
+		msg := fmt.Sprintf("cannot fetch blocklist for user [%s] and params [%+#v]", userID, params)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return entries, nil
+}
+
+// FetchAll returns every entities.Blocklist of a user
+func (repository *gormBlocklistRepository) FetchAll(ctx context.Context, userID entities.UserID) ([]*entities.Blocklist, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	entries := make([]*entities.Blocklist, 0)
+	if err := repository.db.WithContext(ctx).Where("user_id = ?", userID).Order("created_at ASC").Find(&entries).Error; err != nil {
+		msg := fmt.Sprintf("cannot fetch all blocklist entries for user [%s]", userID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return entries, nil
+}
+
+// Existing returns the phone numbers which are already on the blocklist of a user
+func (repository *gormBlocklistRepository) Existing(ctx context.Context, userID entities.UserID, phoneNumbers []string) ([]string, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	existing := make([]string, 0, len(phoneNumbers))
+	for start := 0; start < len(phoneNumbers); start += blocklistBatchSize {
+		end := start + blocklistBatchSize
+		if end > len(phoneNumbers) {
+			end = len(phoneNumbers)
+		}
+
+		var batch []string
+		err := repository.db.WithContext(ctx).
+			Model(&entities.Blocklist{}).
+			Where("user_id = ?", userID).
+			Where("phone_number IN ?", phoneNumbers[start:end]).
+			Pluck("phone_number", &batch).
+			Error
+		if err != nil {
+			msg := fmt.Sprintf("cannot fetch existing blocklist phone numbers for user [%s]", userID)
+			return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+		}
+		existing = append(existing, batch...)
+	}
+
+	return existing, nil
+}
+
+// Delete an entities.Blocklist
+func (repository *gormBlocklistRepository) Delete(ctx context.Context, userID entities.UserID, blocklistID uuid.UUID) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	if err := repository.db.WithContext(ctx).Where("user_id = ?", userID).Where("id = ?", blocklistID).Delete(&entities.Blocklist{}).Error; err != nil {
+		msg := fmt.Sprintf("cannot delete blocklist entry with ID [%s] and userID [%s]", blocklistID, userID)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+// DeleteByPhoneNumber removes a phone number from the blocklist of a user if it was added with the entities.BlocklistReason
+func (repository *gormBlocklistRepository) DeleteByPhoneNumber(ctx context.Context, userID entities.UserID, phoneNumber string, reason entities.BlocklistReason) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	err := repository.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Where("phone_number = ?", phoneNumber).
+		Where("reason = ?", reason).
+		Delete(&entities.Blocklist{}).
+		Error
+	if err != nil {
+		msg := fmt.Sprintf("cannot delete phone number [%s] with reason [%s] from the blocklist of user [%s]", phoneNumber, reason, userID)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
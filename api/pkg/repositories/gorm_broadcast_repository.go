@@ -0,0 +1,120 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/google/uuid"
+	"github.com/palantir/stacktrace"
+	"gorm.io/gorm"
+)
+
+// gormBroadcastRepository is responsible for persisting entities.Broadcast
+type gormBroadcastRepository struct {
+	logger telemetry.Logger
+	tracer telemetry.Tracer
+	db     *gorm.DB
+}
+
+// NewGormBroadcastRepository creates the GORM version of the BroadcastRepository
+func NewGormBroadcastRepository(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	db *gorm.DB,
+) BroadcastRepository {
+	return &gormBroadcastRepository{
+		logger: logger.WithService(fmt.Sprintf("%T", &gormBroadcastRepository{})),
+		tracer: tracer,
+		db:     db,
+	}
+}
+
+// Store a new entities.Broadcast
+func (repository *gormBroadcastRepository) Store(ctx context.Context, broadcast *entities.Broadcast) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	if err := repository.db.WithContext(ctx).Create(broadcast).Error; err != nil {
+		msg := fmt.Sprintf("cannot save broadcast with ID [%s]", broadcast.ID)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+// Load an entities.Broadcast by ID
+func (repository *gormBroadcastRepository) Load(ctx context.Context, broadcastID uuid.UUID) (*entities.Broadcast, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	broadcast := new(entities.Broadcast)
+	err := repository.db.WithContext(ctx).Where("id = ?", broadcastID).First(broadcast).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		msg := fmt.Sprintf("broadcast with ID [%s] does not exist", broadcastID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, ErrCodeNotFound, msg))
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot load broadcast with ID [%s]", broadcastID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return broadcast, nil
+}
+
+// Index all entities.Broadcast of the instance
+func (repository *gormBroadcastRepository) Index(ctx context.Context, params IndexParams) ([]*entities.Broadcast, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	query := repository.db.WithContext(ctx)
+	if len(params.Query) > 0 {
+		queryPattern := "%" + params.Query + "%"
+		query = query.Where(repository.db.Where("title ILIKE ?", queryPattern).Or("body ILIKE ?", queryPattern))
+	}
+
+	broadcasts := make([]*entities.Broadcast, 0)
+	if err := query.Order("created_at DESC").Limit(params.Limit).Offset(params.Skip).Find(&broadcasts).Error; err != nil {
+		msg := fmt.Sprintf("cannot fetch broadcasts with params [%+#v]", params)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return broadcasts, nil
+}
+
+// FetchActive fetches the entities.Broadcast which are displayed at a timestamp
+func (repository *gormBroadcastRepository) FetchActive(ctx context.Context, timestamp time.Time) ([]*entities.Broadcast, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	broadcasts := make([]*entities.Broadcast, 0)
+	err := repository.db.WithContext(ctx).
+		Where("starts_at <= ?", timestamp).
+		Where(repository.db.Where("ends_at IS NULL").Or("ends_at > ?", timestamp)).
+		Order("starts_at DESC").
+		Find(&broadcasts).
+		Error
+	if err != nil {
+		msg := fmt.Sprintf("cannot fetch broadcasts active at [%s]", timestamp)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return broadcasts, nil
+}
+
+// Delete an entities.Broadcast
+func (repository *gormBroadcastRepository) Delete(ctx context.Context, broadcastID uuid.UUID) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	if err := repository.db.WithContext(ctx).Where("id = ?", broadcastID).Delete(&entities.Broadcast{}).Error; err != nil {
+		msg := fmt.Sprintf("cannot delete broadcast with ID [%s]", broadcastID)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
@@ -0,0 +1,155 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/google/uuid"
+	"github.com/palantir/stacktrace"
+	"gorm.io/gorm"
+)
+
+// gormCampaignRepository is responsible for persisting entities.Campaign
+type gormCampaignRepository struct {
+	logger telemetry.Logger
+	tracer telemetry.Tracer
+	db     *gorm.DB
+}
+
+// NewGormCampaignRepository creates the GORM version of the CampaignRepository
+func NewGormCampaignRepository(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	db *gorm.DB,
+) CampaignRepository {
+	return &gormCampaignRepository{
+		logger: logger.WithService(fmt.Sprintf("%T", &gormCampaignRepository{})),
+		tracer: tracer,
+		db:     db,
+	}
+}
+
+// Store a new entities.Campaign
+func (repository *gormCampaignRepository) Store(ctx context.Context, campaign *entities.Campaign) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	if err := repository.db.WithContext(ctx).Create(campaign).Error; err != nil {
+		msg := fmt.Sprintf("cannot save campaign with ID [%s]", campaign.ID)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+// Update an entities.Campaign
+func (repository *gormCampaignRepository) Update(ctx context.Context, campaign *entities.Campaign) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	if err := repository.db.WithContext(ctx).Save(campaign).Error; err != nil {
+		msg := fmt.Sprintf("cannot update campaign with ID [%s]", campaign.ID)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+// Load an entities.Campaign by ID
+func (repository *gormCampaignRepository) Load(ctx context.Context, userID entities.UserID, campaignID uuid.UUID) (*entities.Campaign, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	campaign := new(entities.Campaign)
+	err := repository.db.WithContext(ctx).Where("user_id = ?", userID).Where("id = ?", campaignID).First(campaign).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		msg := fmt.Sprintf("campaign with ID [%s] for user [%s] does not exist", campaignID, userID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, ErrCodeNotFound, msg))
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot load campaign with ID [%s] for user [%s]", campaignID, userID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return campaign, nil
+}
+
+// Index entities.Campaign of a user
+func (repository *gormCampaignRepository) Index(ctx context.Context, userID entities.UserID, params IndexParams) ([]*entities.Campaign, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	query := repository.db.WithContext(ctx).Where("user_id = ?", userID)
+	if len(params.Query) > 0 {
+		query.Where("name ILIKE ?", "%"+params.Query+"%")
+	}
+
+	campaigns := make([]*entities.Campaign, 0)
+	if err := query.Order("created_at DESC").Limit(params.Limit).Offset(params.Skip).Find(&campaigns).Error; err != nil {
+		msg := fmt.Sprintf("cannot fetch campaigns for user [%s] and params [%+#v]", userID, params)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return campaigns, nil
+}
+
+// Delete an entities.Campaign
+func (repository *gormCampaignRepository) Delete(ctx context.Context, userID entities.UserID, campaignID uuid.UUID) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	if err := repository.db.WithContext(ctx).Where("user_id = ?", userID).Where("id = ?", campaignID).Delete(&entities.Campaign{}).Error; err != nil {
+		msg := fmt.Sprintf("cannot delete campaign with ID [%s] and userID [%s]", campaignID, userID)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+// FetchDue fetches the active entities.Campaign of all users which have a NextRunAt before a timestamp
+func (repository *gormCampaignRepository) FetchDue(ctx context.Context, timestamp time.Time, limit int) ([]*entities.Campaign, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	campaigns := make([]*entities.Campaign, 0)
+	err := WithoutTenantScope(repository.db.WithContext(ctx)).
+		Where("is_active = ?", true).
+		Where("next_run_at <= ?", timestamp).
+		Order("next_run_at ASC").
+		Limit(limit).
+		Find(&campaigns).
+		Error
+	if err != nil {
+		msg := fmt.Sprintf("cannot fetch campaigns which are due at [%s]", timestamp)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return campaigns, nil
+}
+
+// Claim moves the NextRunAt of an entities.Campaign forward if it has not been changed by another instance
+func (repository *gormCampaignRepository) Claim(ctx context.Context, campaign *entities.Campaign, nextRunAt *time.Time) (bool, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	result := repository.db.WithContext(ctx).
+		Model(&entities.Campaign{}).
+		Where("user_id = ?", campaign.UserID).
+		Where("id = ?", campaign.ID).
+		Where("next_run_at = ?", campaign.NextRunAt).
+		Updates(map[string]any{
+			"next_run_at": nextRunAt,
+			"updated_at":  time.Now().UTC(),
+		})
+	if result.Error != nil {
+		msg := fmt.Sprintf("cannot claim run of campaign with ID [%s] for user [%s]", campaign.ID, campaign.UserID)
+		return false, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(result.Error, msg))
+	}
+
+	return result.RowsAffected == 1, nil
+}
@@ -0,0 +1,207 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/google/uuid"
+	"github.com/palantir/stacktrace"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+)
+
+// gormCanaryRepository is responsible for persisting entities.Canary
+type gormCanaryRepository struct {
+	logger telemetry.Logger
+	tracer telemetry.Tracer
+	db     *gorm.DB
+}
+
+// NewGormCanaryRepository creates the GORM version of the CanaryRepository
+func NewGormCanaryRepository(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	db *gorm.DB,
+) CanaryRepository {
+	return &gormCanaryRepository{
+		logger: logger.WithService(fmt.Sprintf("%T", &gormCanaryRepository{})),
+		tracer: tracer,
+		db:     db,
+	}
+}
+
+// Store a new entities.Canary
+func (repository *gormCanaryRepository) Store(ctx context.Context, canary *entities.Canary) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	if err := repository.db.WithContext(ctx).Create(canary).Error; err != nil {
+		msg := fmt.Sprintf("cannot save canary with ID [%s]", canary.ID)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+// Update an entities.Canary
+func (repository *gormCanaryRepository) Update(ctx context.Context, canary *entities.Canary) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	if err := repository.db.WithContext(ctx).Save(canary).Error; err != nil {
+		msg := fmt.Sprintf("cannot update canary with ID [%s]", canary.ID)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+// Load an entities.Canary by ID
+func (repository *gormCanaryRepository) Load(ctx context.Context, userID entities.UserID, canaryID uuid.UUID) (*entities.Canary, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	canary := new(entities.Canary)
+	err := repository.db.WithContext(ctx).Where("user_id = ?", userID).Where("id = ?", canaryID).First(canary).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		msg := fmt.Sprintf("canary with ID [%s] for user [%s] does not exist", canaryID, userID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, ErrCodeNotFound, msg))
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot load canary with ID [%s] for user [%s]", canaryID, userID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return canary, nil
+}
+
+// Index entities.Canary of a user
+func (repository *gormCanaryRepository) Index(ctx context.Context, userID entities.UserID, params IndexParams) ([]*entities.Canary, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	query := repository.db.WithContext(ctx).Where("user_id = ?", userID)
+	if len(params.Query) > 0 {
+		queryPattern := "%" + params.Query + "%"
+		query.Where(repository.db.Where("owner ILIKE ?", queryPattern).Or("contact ILIKE ?", queryPattern))
+	}
+
+	canaries := make([]*entities.Canary, 0)
+	if err := query.Order("created_at DESC").Limit(params.Limit).Offset(params.Skip).Find(&canaries).Error; err != nil {
+		msg := fmt.Sprintf("cannot fetch canaries for user [%s] and params [%+#v]", userID, params)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return canaries, nil
+}
+
+// Delete an entities.Canary and its runs
+func (repository *gormCanaryRepository) Delete(ctx context.Context, userID entities.UserID, canaryID uuid.UUID) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	err := repository.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Where("canary_id = ?", canaryID).Delete(&entities.CanaryRun{}).Error; err != nil {
+			return stacktrace.Propagate(err, fmt.Sprintf("cannot delete runs of canary with ID [%s]", canaryID))
+		}
+		return tx.Where("user_id = ?", userID).Where("id = ?", canaryID).Delete(&entities.Canary{}).Error
+	})
+	if err != nil {
+		msg := fmt.Sprintf("cannot delete canary with ID [%s] and userID [%s]", canaryID, userID)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+// StoreRun stores a new entities.CanaryRun
+func (repository *gormCanaryRepository) StoreRun(ctx context.Context, run *entities.CanaryRun) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	if err := repository.db.WithContext(ctx).Create(run).Error; err != nil {
+		msg := fmt.Sprintf("cannot save canary run with ID [%s]", run.ID)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+// UpdateRun updates an entities.CanaryRun
+func (repository *gormCanaryRepository) UpdateRun(ctx context.Context, run *entities.CanaryRun) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	if err := repository.db.WithContext(ctx).Save(run).Error; err != nil {
+		msg := fmt.Sprintf("cannot update canary run with ID [%s]", run.ID)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+// LoadRun loads an entities.CanaryRun by ID
+func (repository *gormCanaryRepository) LoadRun(ctx context.Context, userID entities.UserID, runID uuid.UUID) (*entities.CanaryRun, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	return repository.loadRun(ctx, span, repository.db.Where("user_id = ?", userID).Where("id = ?", runID), fmt.Sprintf("with ID [%s]", runID))
+}
+
+// LoadRunByMessageID loads the entities.CanaryRun which sent a message
+func (repository *gormCanaryRepository) LoadRunByMessageID(ctx context.Context, userID entities.UserID, messageID uuid.UUID) (*entities.CanaryRun, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	return repository.loadRun(ctx, span, repository.db.Where("user_id = ?", userID).Where("message_id = ?", messageID), fmt.Sprintf("with message ID [%s]", messageID))
+}
+
+// LoadRunByToken loads the entities.CanaryRun with a token
+func (repository *gormCanaryRepository) LoadRunByToken(ctx context.Context, userID entities.UserID, token string) (*entities.CanaryRun, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	return repository.loadRun(ctx, span, repository.db.Where("user_id = ?", userID).Where("token = ?", token), fmt.Sprintf("with token [%s]", token))
+}
+
+// IndexRuns fetches the runs of an entities.Canary
+func (repository *gormCanaryRepository) IndexRuns(ctx context.Context, userID entities.UserID, canaryID uuid.UUID, params IndexParams) ([]*entities.CanaryRun, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	runs := make([]*entities.CanaryRun, 0)
+	err := repository.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Where("canary_id = ?", canaryID).
+		Order("created_at DESC").
+		Limit(params.Limit).
+		Offset(params.Skip).
+		Find(&runs).
+		Error
+	if err != nil {
+		msg := fmt.Sprintf("cannot fetch runs for canary [%s] and params [%+#v]", canaryID, params)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return runs, nil
+}
+
+func (repository *gormCanaryRepository) loadRun(ctx context.Context, span trace.Span, query *gorm.DB, description string) (*entities.CanaryRun, error) {
+	run := new(entities.CanaryRun)
+	err := query.WithContext(ctx).First(run).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		msg := fmt.Sprintf("canary run %s does not exist", description)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, ErrCodeNotFound, msg))
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot load canary run %s", description)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return run, nil
+}
@@ -0,0 +1,165 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/cockroachdb/cockroach-go/v2/crdb/crdbgorm"
+	"github.com/google/uuid"
+	"github.com/palantir/stacktrace"
+	"gorm.io/gorm"
+)
+
+// gormContactGroupRepository is responsible for persisting entities.ContactGroup
+type gormContactGroupRepository struct {
+	logger telemetry.Logger
+	tracer telemetry.Tracer
+	db     *gorm.DB
+}
+
+// NewGormContactGroupRepository creates the GORM version of the ContactGroupRepository
+func NewGormContactGroupRepository(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	db *gorm.DB,
+) ContactGroupRepository {
+	return &gormContactGroupRepository{
+		logger: logger.WithService(fmt.Sprintf("%T", &gormContactGroupRepository{})),
+		tracer: tracer,
+		db:     db,
+	}
+}
+
+// Store a new entities.ContactGroup
+func (repository *gormContactGroupRepository) Store(ctx context.Context, group *entities.ContactGroup) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	if err := repository.db.WithContext(ctx).Create(group).Error; err != nil {
+		msg := fmt.Sprintf("cannot save contact group with ID [%s]", group.ID)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+// Update an entities.ContactGroup
+func (repository *gormContactGroupRepository) Update(ctx context.Context, group *entities.ContactGroup) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	if err := repository.db.WithContext(ctx).Save(group).Error; err != nil {
+		msg := fmt.Sprintf("cannot update contact group with ID [%s]", group.ID)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+// Load an entities.ContactGroup by ID
+func (repository *gormContactGroupRepository) Load(ctx context.Context, userID entities.UserID, groupID uuid.UUID) (*entities.ContactGroup, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	group := new(entities.ContactGroup)
+	err := repository.query(ctx, userID).Where("id = ?", groupID).First(group).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		msg := fmt.Sprintf("contact group with ID [%s] and user [%s] does not exist", groupID, userID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, ErrCodeNotFound, msg))
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot load contact group with ID [%s] and user [%s]", groupID, userID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return group, nil
+}
+
+// Index entities.ContactGroup of a user
+func (repository *gormContactGroupRepository) Index(ctx context.Context, userID entities.UserID, params IndexParams) ([]*entities.ContactGroup, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	query := repository.query(ctx, userID)
+	if len(params.Query) > 0 {
+		query.Where("name ILIKE ?", "%"+params.Query+"%")
+	}
+
+	groups := make([]*entities.ContactGroup, 0)
+	if err := query.Order("created_at DESC").Limit(params.Limit).Offset(params.Skip).Find(&groups).Error; err != nil {
+		msg := fmt.Sprintf("cannot fetch contact groups for user [%s] and params [%+#v]", userID, params)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return groups, nil
+}
+
+// Delete an entities.ContactGroup and its members
+func (repository *gormContactGroupRepository) Delete(ctx context.Context, userID entities.UserID, groupID uuid.UUID) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	err := crdbgorm.ExecuteTx(ctx, repository.db, nil, func(tx *gorm.DB) error {
+		if err := tx.WithContext(ctx).Where("user_id = ?", userID).Where("contact_group_id = ?", groupID).Delete(&entities.ContactGroupMember{}).Error; err != nil {
+			return stacktrace.Propagate(err, fmt.Sprintf("cannot delete the members of contact group with ID [%s]", groupID))
+		}
+		return tx.WithContext(ctx).Where("user_id = ?", userID).Where("id = ?", groupID).Delete(&entities.ContactGroup{}).Error
+	})
+	if err != nil {
+		msg := fmt.Sprintf("cannot delete contact group with ID [%s] and userID [%s]", groupID, userID)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+// AddContacts adds the entities.Contact of the user with the IDs to a group, contacts of other users and existing members are ignored
+func (repository *gormContactGroupRepository) AddContacts(ctx context.Context, userID entities.UserID, groupID uuid.UUID, contactIDs []uuid.UUID) (int64, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	result := repository.db.WithContext(ctx).Exec(
+		"INSERT INTO contact_group_members (contact_group_id, contact_id, user_id, created_at) SELECT ?, id, user_id, ? FROM contacts WHERE user_id = ? AND id IN ? ON CONFLICT DO NOTHING",
+		groupID,
+		time.Now().UTC(),
+		userID,
+		contactIDs,
+	)
+	if result.Error != nil {
+		msg := fmt.Sprintf("cannot add [%d] contacts to group with ID [%s] for user [%s]", len(contactIDs), groupID, userID)
+		return 0, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(result.Error, msg))
+	}
+
+	return result.RowsAffected, nil
+}
+
+// RemoveContact removes an entities.Contact from a group
+func (repository *gormContactGroupRepository) RemoveContact(ctx context.Context, userID entities.UserID, groupID uuid.UUID, contactID uuid.UUID) (bool, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	result := repository.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Where("contact_group_id = ?", groupID).
+		Where("contact_id = ?", contactID).
+		Delete(&entities.ContactGroupMember{})
+	if result.Error != nil {
+		msg := fmt.Sprintf("cannot remove contact [%s] from group with ID [%s] for user [%s]", contactID, groupID, userID)
+		return false, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(result.Error, msg))
+	}
+
+	return result.RowsAffected == 1, nil
+}
+
+// query selects the entities.ContactGroup of a user with the number of contacts in each group
+func (repository *gormContactGroupRepository) query(ctx context.Context, userID entities.UserID) *gorm.DB {
+	return repository.db.WithContext(ctx).
+		Model(&entities.ContactGroup{}).
+		Select("contact_groups.*, (SELECT COUNT(*) FROM contact_group_members WHERE contact_group_members.contact_group_id = contact_groups.id) AS contact_count").
+		Where("user_id = ?", userID)
+}
@@ -0,0 +1,265 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/cockroachdb/cockroach-go/v2/crdb/crdbgorm"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/palantir/stacktrace"
+	"gorm.io/gorm"
+)
+
+// gormContactRepository is responsible for persisting entities.Contact
+type gormContactRepository struct {
+	logger telemetry.Logger
+	tracer telemetry.Tracer
+	db     *gorm.DB
+}
+
+// NewGormContactRepository creates the GORM version of the ContactRepository
+func NewGormContactRepository(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	db *gorm.DB,
+) ContactRepository {
+	return &gormContactRepository{
+		logger: logger.WithService(fmt.Sprintf("%T", &gormContactRepository{})),
+		tracer: tracer,
+		db:     db,
+	}
+}
+
+// Store a new entities.Contact
+func (repository *gormContactRepository) Store(ctx context.Context, contact *entities.Contact) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	if err := repository.db.WithContext(ctx).Create(contact).Error; err != nil {
+		msg := fmt.Sprintf("cannot save contact with ID [%s]", contact.ID)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+// Update an entities.Contact
+func (repository *gormContactRepository) Update(ctx context.Context, contact *entities.Contact) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	if err := repository.db.WithContext(ctx).Save(contact).Error; err != nil {
+		msg := fmt.Sprintf("cannot update contact with ID [%s]", contact.ID)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+// Load the entities.Contact of a user by phone number
+func (repository *gormContactRepository) Load(ctx context.Context, userID entities.UserID, phoneNumber string) (*entities.Contact, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	contact := new(entities.Contact)
+	err := repository.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Where("phone_number = ?", phoneNumber).
+		First(contact).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		msg := fmt.Sprintf("contact with phone number [%s] and user [%s] does not exist", phoneNumber, userID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, ErrCodeNotFound, msg))
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot load contact with phone number [%s] and user [%s]", phoneNumber, userID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return contact, nil
+}
+
+// LoadByID loads an entities.Contact of a user by ID
+func (repository *gormContactRepository) LoadByID(ctx context.Context, userID entities.UserID, contactID uuid.UUID) (*entities.Contact, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	contact := new(entities.Contact)
+	err := repository.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Where("id = ?", contactID).
+		First(contact).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		msg := fmt.Sprintf("contact with ID [%s] and user [%s] does not exist", contactID, userID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, ErrCodeNotFound, msg))
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot load contact with ID [%s] and user [%s]", contactID, userID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return contact, nil
+}
+
+// Index fetches the entities.Contact of a user
+func (repository *gormContactRepository) Index(ctx context.Context, userID entities.UserID, params ContactIndexParams) ([]*entities.Contact, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	query, err := repository.query(ctx, userID, params)
+	if err != nil {
+		msg := fmt.Sprintf("cannot build query for contacts of user [%s] with params [%+#v]", userID, params)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	contacts := make([]*entities.Contact, 0)
+	err = query.Order(repository.order(params.SortBy)).
+		Order("id DESC").
+		Limit(params.Limit).
+		Offset(params.Skip).
+		Find(&contacts).Error
+	if err != nil {
+		msg := fmt.Sprintf("cannot fetch contacts for user [%s] with params [%+#v]", userID, params)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return contacts, nil
+}
+
+// Count returns the number of entities.Contact of a user which match the params
+func (repository *gormContactRepository) Count(ctx context.Context, userID entities.UserID, params ContactIndexParams) (count int64, err error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	query, err := repository.query(ctx, userID, params)
+	if err != nil {
+		msg := fmt.Sprintf("cannot build query for contacts of user [%s] with params [%+#v]", userID, params)
+		return 0, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if err = query.Model(&entities.Contact{}).Count(&count).Error; err != nil {
+		msg := fmt.Sprintf("cannot count contacts for user [%s] with params [%+#v]", userID, params)
+		return 0, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return count, nil
+}
+
+// Delete an entities.Contact and remove it from all the entities.ContactGroup of the user
+func (repository *gormContactRepository) Delete(ctx context.Context, userID entities.UserID, contactID uuid.UUID) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	err := crdbgorm.ExecuteTx(ctx, repository.db, nil, func(tx *gorm.DB) error {
+		if err := tx.WithContext(ctx).Where("user_id = ?", userID).Where("contact_id = ?", contactID).Delete(&entities.ContactGroupMember{}).Error; err != nil {
+			return stacktrace.Propagate(err, fmt.Sprintf("cannot remove contact with ID [%s] from its groups", contactID))
+		}
+		return tx.WithContext(ctx).Where("user_id = ?", userID).Where("id = ?", contactID).Delete(&entities.Contact{}).Error
+	})
+	if err != nil {
+		msg := fmt.Sprintf("cannot delete contact with ID [%s] and userID [%s]", contactID, userID)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+func (repository *gormContactRepository) query(ctx context.Context, userID entities.UserID, params ContactIndexParams) (*gorm.DB, error) {
+	query := repository.db.WithContext(ctx).Where("user_id = ?", userID)
+	if len(params.Query) > 0 {
+		query.Where(repository.db.Where("phone_number ILIKE ?", "%"+params.Query+"%").Or("name ILIKE ?", "%"+params.Query+"%"))
+	}
+	if params.GroupID != nil {
+		query.Where(
+			"id IN (?)",
+			repository.db.Model(&entities.ContactGroupMember{}).Select("contact_id").Where("user_id = ?", userID).Where("contact_group_id = ?", *params.GroupID),
+		)
+	}
+	if params.IsOptedOut != nil {
+		query.Where("is_opted_out = ?", *params.IsOptedOut)
+	}
+	if len(params.Tags) > 0 {
+		query.Where("tags && ?", pq.StringArray(params.Tags))
+	}
+
+	for _, filter := range params.Filters {
+		if err := repository.applyFilter(query, filter); err != nil {
+			return nil, stacktrace.Propagate(err, fmt.Sprintf("cannot apply segment filter [%+#v]", filter))
+		}
+	}
+
+	return query, nil
+}
+
+// applyFilter adds an entities.SegmentFilter to the query, column names are never taken from user input
+func (repository *gormContactRepository) applyFilter(query *gorm.DB, filter entities.SegmentFilter) error {
+	if !filter.IsSupported() {
+		return stacktrace.NewError(fmt.Sprintf("segment filter operator [%s] is not supported for field [%s]", filter.Operator, filter.Field))
+	}
+
+	comparisons := map[entities.SegmentFilterOperator]string{
+		entities.SegmentFilterOperatorEqual:       "=",
+		entities.SegmentFilterOperatorNotEqual:    "<>",
+		entities.SegmentFilterOperatorGreaterThan: ">",
+		entities.SegmentFilterOperatorLessThan:    "<",
+	}
+
+	switch filter.Field {
+	case entities.SegmentFilterFieldTag:
+		if filter.Operator == entities.SegmentFilterOperatorNotHas {
+			query.Where("NOT (? = ANY(tags))", filter.Value)
+			return nil
+		}
+		query.Where("? = ANY(tags)", filter.Value)
+	case entities.SegmentFilterFieldPhoneNumber:
+		if filter.Operator == entities.SegmentFilterOperatorContains {
+			query.Where("phone_number LIKE ?", "%"+filter.Value+"%")
+			return nil
+		}
+		query.Where(fmt.Sprintf("phone_number %s ?", comparisons[filter.Operator]), filter.Value)
+	case entities.SegmentFilterFieldAttribute:
+		if filter.Operator == entities.SegmentFilterOperatorContains {
+			query.Where("attributes->>? ILIKE ?", filter.Key, "%"+filter.Value+"%")
+			return nil
+		}
+		if filter.Operator == entities.SegmentFilterOperatorNotEqual {
+			query.Where(repository.db.Where("attributes->>? <> ?", filter.Key, filter.Value).Or("attributes->>? IS NULL", filter.Key))
+			return nil
+		}
+		query.Where("attributes->>? = ?", filter.Key, filter.Value)
+	case entities.SegmentFilterFieldMessagesSent, entities.SegmentFilterFieldMessagesReceived, entities.SegmentFilterFieldReplyRate:
+		value, err := strconv.ParseFloat(filter.Value, 64)
+		if err != nil {
+			return stacktrace.Propagate(err, fmt.Sprintf("cannot parse [%s] as a number", filter.Value))
+		}
+		query.Where(fmt.Sprintf("%s %s ?", filter.Field, comparisons[filter.Operator]), value)
+	case entities.SegmentFilterFieldLastSentAt, entities.SegmentFilterFieldLastReplyAt:
+		value, err := time.Parse(time.RFC3339, filter.Value)
+		if err != nil {
+			return stacktrace.Propagate(err, fmt.Sprintf("cannot parse [%s] as an RFC3339 timestamp", filter.Value))
+		}
+		query.Where(fmt.Sprintf("%s %s ?", filter.Field, comparisons[filter.Operator]), value)
+	case entities.SegmentFilterFieldIsOptedOut:
+		query.Where("is_opted_out = ?", filter.Value == "true")
+	}
+
+	return nil
+}
+
+func (repository *gormContactRepository) order(sortBy string) string {
+	switch sortBy {
+	case "messages_sent", "messages_received", "reply_rate":
+		return sortBy + " DESC"
+	case "last_sent_at", "last_reply_at":
+		return sortBy + " DESC NULLS LAST"
+	default:
+		return "updated_at DESC"
+	}
+}
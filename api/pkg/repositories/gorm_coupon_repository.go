@@ -0,0 +1,146 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/google/uuid"
+	"github.com/palantir/stacktrace"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// errCouponNotRedeemed rolls back the redemption of an entities.Coupon
+var errCouponNotRedeemed = errors.New("coupon was not redeemed")
+
+// gormCouponRepository is responsible for persisting entities.Coupon
+type gormCouponRepository struct {
+	logger telemetry.Logger
+	tracer telemetry.Tracer
+	db     *gorm.DB
+}
+
+// NewGormCouponRepository creates the GORM version of the CouponRepository
+func NewGormCouponRepository(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	db *gorm.DB,
+) CouponRepository {
+	return &gormCouponRepository{
+		logger: logger.WithService(fmt.Sprintf("%T", &gormCouponRepository{})),
+		tracer: tracer,
+		db:     db,
+	}
+}
+
+// Store a new entities.Coupon
+func (repository *gormCouponRepository) Store(ctx context.Context, coupon *entities.Coupon) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	if err := repository.db.WithContext(ctx).Create(coupon).Error; err != nil {
+		msg := fmt.Sprintf("cannot save coupon with ID [%s]", coupon.ID)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+// LoadByCode loads an entities.Coupon by its case-insensitive code
+func (repository *gormCouponRepository) LoadByCode(ctx context.Context, code string) (*entities.Coupon, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	coupon := new(entities.Coupon)
+	err := repository.db.WithContext(ctx).Where("code = ?", strings.ToUpper(code)).First(coupon).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		msg := fmt.Sprintf("coupon with code [%s] does not exist", code)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, ErrCodeNotFound, msg))
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot load coupon with code [%s]", code)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return coupon, nil
+}
+
+// Index fetches all the entities.Coupon of the instance
+func (repository *gormCouponRepository) Index(ctx context.Context, params IndexParams) ([]*entities.Coupon, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	query := repository.db.WithContext(ctx)
+	if len(params.Query) > 0 {
+		query = query.Where("code ILIKE ?", "%"+params.Query+"%")
+	}
+
+	coupons := make([]*entities.Coupon, 0)
+	if err := query.Order("created_at DESC").Limit(params.Limit).Offset(params.Skip).Find(&coupons).Error; err != nil {
+		msg := fmt.Sprintf("cannot fetch coupons with params [%+#v]", params)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return coupons, nil
+}
+
+// Delete an entities.Coupon
+func (repository *gormCouponRepository) Delete(ctx context.Context, couponID uuid.UUID) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	if err := repository.db.WithContext(ctx).Where("id = ?", couponID).Delete(&entities.Coupon{}).Error; err != nil {
+		msg := fmt.Sprintf("cannot delete coupon with ID [%s]", couponID)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+// Redeem increments the redemptions of an entities.Coupon and stores the entities.PlanOverride of the user
+func (repository *gormCouponRepository) Redeem(ctx context.Context, coupon *entities.Coupon, override *entities.PlanOverride, timestamp time.Time) (bool, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	err := repository.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(coupon).
+			Where("max_redemptions = 0 OR redemptions < max_redemptions").
+			Where("expires_at IS NULL OR expires_at > ?", timestamp).
+			UpdateColumn("redemptions", gorm.Expr("redemptions + ?", 1))
+		if result.Error != nil {
+			return stacktrace.Propagate(result.Error, fmt.Sprintf("cannot increment redemptions of coupon [%s]", coupon.ID))
+		}
+
+		if result.RowsAffected == 0 {
+			return errCouponNotRedeemed
+		}
+
+		// the unique index on the user and coupon prevents a user from redeeming the same coupon twice
+		result = tx.Clauses(clause.OnConflict{DoNothing: true}).Create(override)
+		if result.Error != nil {
+			return stacktrace.Propagate(result.Error, fmt.Sprintf("cannot save plan override [%s] for user [%s]", override.ID, override.UserID))
+		}
+
+		if result.RowsAffected == 0 {
+			return errCouponNotRedeemed
+		}
+
+		return nil
+	})
+	if errors.Is(err, errCouponNotRedeemed) {
+		return false, nil
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot redeem coupon [%s] for user [%s]", coupon.ID, override.UserID)
+		return false, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return true, nil
+}
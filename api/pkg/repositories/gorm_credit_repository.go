@@ -0,0 +1,185 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/palantir/stacktrace"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// gormCreditRepository is responsible for persisting entities.CreditBalance and entities.CreditTransaction
+type gormCreditRepository struct {
+	logger telemetry.Logger
+	tracer telemetry.Tracer
+	db     *gorm.DB
+}
+
+// NewGormCreditRepository creates the GORM version of the CreditRepository
+func NewGormCreditRepository(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	db *gorm.DB,
+) CreditRepository {
+	return &gormCreditRepository{
+		logger: logger.WithService(fmt.Sprintf("%T", &gormCreditRepository{})),
+		tracer: tracer,
+		db:     db,
+	}
+}
+
+// LoadBalance loads the entities.CreditBalance of a user
+func (repository *gormCreditRepository) LoadBalance(ctx context.Context, userID entities.UserID) (*entities.CreditBalance, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	balance := new(entities.CreditBalance)
+	err := repository.db.WithContext(ctx).Where("user_id = ?", userID).First(balance).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		msg := fmt.Sprintf("credit balance for user [%s] does not exist", userID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, ErrCodeNotFound, msg))
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot load credit balance for user [%s]", userID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return balance, nil
+}
+
+// Apply stores an entities.CreditTransaction and adds its amount to the entities.CreditBalance of the user
+func (repository *gormCreditRepository) Apply(ctx context.Context, transaction *entities.CreditTransaction) (bool, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	applied := false
+	err := repository.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(transaction)
+		if result.Error != nil {
+			return stacktrace.Propagate(result.Error, fmt.Sprintf("cannot save credit transaction with ID [%s]", transaction.ID))
+		}
+
+		if result.RowsAffected == 0 {
+			return nil
+		}
+
+		balance := &entities.CreditBalance{
+			UserID:    transaction.UserID,
+			Balance:   transaction.Amount,
+			CreatedAt: time.Now().UTC(),
+			UpdatedAt: time.Now().UTC(),
+		}
+
+		if transaction.Type == entities.CreditTransactionTypeMessageSent {
+			if err := repository.debit(tx, transaction, balance); err != nil {
+				return stacktrace.Propagate(err, fmt.Sprintf("cannot debit credit balance of user [%s]", transaction.UserID))
+			}
+		} else if err := repository.credit(tx, transaction, balance); err != nil {
+			return stacktrace.Propagate(err, fmt.Sprintf("cannot update credit balance of user [%s]", transaction.UserID))
+		}
+
+		transaction.Balance = balance.Balance
+		err := tx.Model(transaction).
+			Where("user_id = ?", transaction.UserID).
+			Update("balance", transaction.Balance).
+			Error
+		if err != nil {
+			return stacktrace.Propagate(err, fmt.Sprintf("cannot set balance of credit transaction [%s]", transaction.ID))
+		}
+
+		applied = true
+		return nil
+	})
+	if err != nil {
+		msg := fmt.Sprintf("cannot apply credit transaction [%s] for user [%s]", transaction.ID, transaction.UserID)
+		return false, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return applied, nil
+}
+
+// debit removes the credits of a message from the entities.CreditBalance of a user without making the balance negative
+func (repository *gormCreditRepository) debit(tx *gorm.DB, transaction *entities.CreditTransaction, balance *entities.CreditBalance) error {
+	result := tx.Model(balance).
+		Clauses(clause.Returning{Columns: []clause.Column{{Name: "balance"}}}).
+		Where("user_id = ?", transaction.UserID).
+		Where("balance >= ?", -transaction.Amount).
+		Updates(map[string]any{
+			"balance":    gorm.Expr("balance + ?", transaction.Amount),
+			"updated_at": balance.UpdatedAt,
+		})
+	if result.Error != nil {
+		return stacktrace.Propagate(result.Error, fmt.Sprintf("cannot debit [%d] credits from the balance of user [%s]", -transaction.Amount, transaction.UserID))
+	}
+
+	if result.RowsAffected == 0 {
+		msg := fmt.Sprintf("the balance of user [%s] is less than the [%d] credits of message [%s]", transaction.UserID, -transaction.Amount, transaction.MessageID)
+		return stacktrace.NewErrorWithCode(ErrCodeInsufficientCredits, msg)
+	}
+
+	return nil
+}
+
+// credit adds the amount of an entities.CreditTransaction to the entities.CreditBalance of a user, an adjustment can make the balance negative
+func (repository *gormCreditRepository) credit(tx *gorm.DB, transaction *entities.CreditTransaction, balance *entities.CreditBalance) error {
+	return tx.Clauses(
+		clause.OnConflict{
+			Columns: []clause.Column{{Name: "user_id"}},
+			DoUpdates: clause.Assignments(map[string]any{
+				"balance":    gorm.Expr("credit_balances.balance + ?", transaction.Amount),
+				"updated_at": balance.UpdatedAt,
+			}),
+		},
+		clause.Returning{Columns: []clause.Column{{Name: "balance"}}},
+	).Create(balance).Error
+}
+
+// Index fetches the entities.CreditTransaction of a user
+func (repository *gormCreditRepository) Index(ctx context.Context, userID entities.UserID, params CreditTransactionIndexParams) ([]*entities.CreditTransaction, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	query := repository.db.WithContext(ctx).Where("user_id = ?", userID)
+	if params.From != nil {
+		query.Where("created_at >= ?", params.From)
+	}
+	if params.To != nil {
+		query.Where("created_at <= ?", params.To)
+	}
+
+	transactions := make([]*entities.CreditTransaction, 0)
+	if err := query.Order("created_at DESC").Limit(params.Limit).Offset(params.Skip).Find(&transactions).Error; err != nil {
+		msg := fmt.Sprintf("cannot fetch credit transactions for user [%s] and params [%+#v]", userID, params)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return transactions, nil
+}
+
+// Totals sums the entities.CreditTransaction of a user created between from and to
+func (repository *gormCreditRepository) Totals(ctx context.Context, userID entities.UserID, from time.Time, to time.Time) (*CreditTotals, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	totals := new(CreditTotals)
+	err := repository.db.WithContext(ctx).
+		Model(&entities.CreditTransaction{}).
+		Select("COALESCE(SUM(CASE WHEN amount > 0 THEN amount ELSE 0 END), 0) AS added, COALESCE(SUM(CASE WHEN amount < 0 THEN -amount ELSE 0 END), 0) AS used").
+		Where("user_id = ?", userID).
+		Where("created_at >= ?", from).
+		Where("created_at <= ?", to).
+		Scan(totals).
+		Error
+	if err != nil {
+		msg := fmt.Sprintf("cannot sum credit transactions for user [%s] between [%s] and [%s]", userID, from, to)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return totals, nil
+}
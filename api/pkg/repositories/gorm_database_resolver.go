@@ -0,0 +1,97 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/palantir/stacktrace"
+	"gorm.io/gorm"
+)
+
+// gormDatabaseResolver resolves the database of a user using the region stored on the users table of the default database
+type gormDatabaseResolver struct {
+	logger  telemetry.Logger
+	tracer  telemetry.Tracer
+	db      *gorm.DB
+	regions map[string]*gorm.DB
+
+	// userRegions caches the region of users, the region of a user cannot change once it is set
+	userRegions sync.Map
+}
+
+// NewGormDatabaseResolver creates the GORM version of the DatabaseResolver
+func NewGormDatabaseResolver(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	db *gorm.DB,
+	regions map[string]*gorm.DB,
+) DatabaseResolver {
+	return &gormDatabaseResolver{
+		logger:  logger.WithService(fmt.Sprintf("%T", &gormDatabaseResolver{})),
+		tracer:  tracer,
+		db:      db,
+		regions: regions,
+	}
+}
+
+// Resolve returns the database of the entities.User.Region, the default database is returned when the user has no region
+func (resolver *gormDatabaseResolver) Resolve(ctx context.Context, userID entities.UserID) (*gorm.DB, error) {
+	if len(resolver.regions) == 0 {
+		return resolver.db, nil
+	}
+
+	if region, ok := resolver.userRegions.Load(userID); ok {
+		return resolver.regions[region.(string)], nil
+	}
+
+	ctx, span := resolver.tracer.Start(ctx)
+	defer span.End()
+
+	var region string
+	err := resolver.db.WithContext(ctx).
+		Model(&entities.User{}).
+		Select("region").
+		Where("id = ?", userID).
+		Scan(&region).
+		Error
+	if err != nil {
+		msg := fmt.Sprintf("cannot load region of user [%s]", userID)
+		return nil, resolver.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if region == "" {
+		return resolver.db, nil
+	}
+
+	db, ok := resolver.regions[region]
+	if !ok {
+		msg := fmt.Sprintf("user [%s] has region [%s] which is not configured", userID, region)
+		return nil, resolver.tracer.WrapErrorSpan(span, stacktrace.NewError(msg))
+	}
+
+	resolver.userRegions.Store(userID, region)
+	return db, nil
+}
+
+// Regions returns the names of the regions which can be chosen by a user
+func (resolver *gormDatabaseResolver) Regions() []string {
+	regions := make([]string, 0, len(resolver.regions))
+	for region := range resolver.regions {
+		regions = append(regions, region)
+	}
+	sort.Strings(regions)
+	return regions
+}
+
+// All returns the default database and the database of every region
+func (resolver *gormDatabaseResolver) All() []*gorm.DB {
+	databases := []*gorm.DB{resolver.db}
+	for _, region := range resolver.Regions() {
+		databases = append(databases, resolver.regions[region])
+	}
+	return databases
+}
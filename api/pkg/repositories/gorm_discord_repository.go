@@ -107,7 +107,7 @@ func (repository *gormDiscordRepository) FindByServerID(ctx context.Context, ser
 	defer span.End()
 
 	discord := new(entities.Discord)
-	err := repository.db.WithContext(ctx).Where("server_id = ?", serverID).First(&discord).Error
+	err := WithoutTenantScope(repository.db.WithContext(ctx)).Where("server_id = ?", serverID).First(&discord).Error
 	if errors.Is(err, gorm.ErrRecordNotFound) {
 		msg := fmt.Sprintf("discord integration with server ID [%s] does not exist", serverID)
 		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, ErrCodeNotFound, msg))
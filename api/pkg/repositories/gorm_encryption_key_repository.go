@@ -0,0 +1,67 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/palantir/stacktrace"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// gormEncryptionKeyRepository is responsible for persisting entities.EncryptionKey
+type gormEncryptionKeyRepository struct {
+	logger telemetry.Logger
+	tracer telemetry.Tracer
+	db     *gorm.DB
+}
+
+// NewGormEncryptionKeyRepository creates the GORM version of the EncryptionKeyRepository
+func NewGormEncryptionKeyRepository(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	db *gorm.DB,
+) EncryptionKeyRepository {
+	return &gormEncryptionKeyRepository{
+		logger: logger.WithService(fmt.Sprintf("%T", &gormEncryptionKeyRepository{})),
+		tracer: tracer,
+		db:     db,
+	}
+}
+
+// Store a new entities.EncryptionKey, it returns false when the user already has a key
+func (repository *gormEncryptionKeyRepository) Store(ctx context.Context, key *entities.EncryptionKey) (bool, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	result := repository.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(key)
+	if result.Error != nil {
+		msg := fmt.Sprintf("cannot save encryption key for user [%s]", key.UserID)
+		return false, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(result.Error, msg))
+	}
+
+	return result.RowsAffected == 1, nil
+}
+
+// Load the entities.EncryptionKey of a user
+func (repository *gormEncryptionKeyRepository) Load(ctx context.Context, userID entities.UserID) (*entities.EncryptionKey, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	key := new(entities.EncryptionKey)
+	err := repository.db.WithContext(ctx).Where("user_id = ?", userID).First(key).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		msg := fmt.Sprintf("encryption key for user [%s] does not exist", userID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, ErrCodeNotFound, msg))
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot load encryption key for user [%s]", userID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return key, nil
+}
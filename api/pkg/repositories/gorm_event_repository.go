@@ -3,9 +3,12 @@ package repositories
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"strings"
 	"time"
 
+	"github.com/NdoleStudio/httpsms/pkg/entities"
 	"github.com/NdoleStudio/httpsms/pkg/telemetry"
 	cloudevents "github.com/cloudevents/sdk-go/v2"
 	"github.com/google/uuid"
@@ -16,8 +19,12 @@ import (
 
 // GormEvent is a serialized version of cloudevents.Event
 type GormEvent struct {
-	ID        uuid.UUID `json:"id" gorm:"primaryKey;type:uuid;"`
-	Time      time.Time
+	ID        uuid.UUID        `json:"id" gorm:"primaryKey;type:uuid;"`
+	UserID    *entities.UserID `gorm:"index:idx_events__user_id__time"`
+	Time      time.Time        `gorm:"index:idx_events__user_id__time"`
+	MessageID *uuid.UUID       `gorm:"type:uuid;index"`
+	PhoneID   *uuid.UUID       `gorm:"type:uuid;index"`
+	WebhookID *uuid.UUID       `gorm:"type:uuid;index"`
 	CreatedAt time.Time
 	Source    string
 	Type      string
@@ -54,7 +61,7 @@ func (repository *gormEventRepository) FetchAll(ctx context.Context) (*[]cloudev
 	defer span.End()
 
 	var events []GormEvent
-	if err := repository.db.WithContext(ctx).Order("time ASC").Find(&events).Error; err != nil {
+	if err := WithoutTenantScope(repository.db.WithContext(ctx)).Order("time ASC").Find(&events).Error; err != nil {
 		msg := fmt.Sprintf("cannot fetch all cloudevents")
 		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
 	}
@@ -71,23 +78,102 @@ func (repository *gormEventRepository) FetchAll(ctx context.Context) (*[]cloudev
 	return &results, nil
 }
 
-// Create creates a new cloudevents.Event
-func (repository *gormEventRepository) Create(ctx context.Context, event cloudevents.Event) error {
+// Load a cloudevents.Event of a user by ID
+func (repository *gormEventRepository) Load(ctx context.Context, userID entities.UserID, eventID uuid.UUID) (*cloudevents.Event, error) {
 	ctx, span := repository.tracer.Start(ctx)
 	defer span.End()
 
-	data, err := event.MarshalJSON()
+	event := new(GormEvent)
+	err := repository.db.WithContext(ctx).Where("user_id = ?", userID).Where("id = ?", eventID).First(event).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		msg := fmt.Sprintf("event with ID [%s] for user [%s] does not exist", eventID, userID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, ErrCodeNotFound, msg))
+	}
+
 	if err != nil {
-		return stacktrace.Propagate(err, fmt.Sprintf("cannot marshall event [%s]  and type [%s] into JSON", event.ID(), event.Type()))
+		msg := fmt.Sprintf("cannot load event with ID [%s] for user [%s]", eventID, userID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
 	}
 
-	gormEvent := GormEvent{
-		ID:        uuid.MustParse(event.ID()),
-		Time:      event.Time(),
-		Source:    event.Source(),
-		CreatedAt: event.Time().UTC(),
-		Type:      event.Type(),
-		Data:      datatypes.JSON(data),
+	cloudevent := new(cloudevents.Event)
+	if err = json.Unmarshal(event.Data, cloudevent); err != nil {
+		msg := fmt.Sprintf("cannot unmarshal [%s] into [%T]", event.Data, cloudevent)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return cloudevent, nil
+}
+
+// Index returns the cloudevents.Event of a user ordered by time in descending order, or in ascending order when EventIndexParams.After is set
+func (repository *gormEventRepository) Index(ctx context.Context, userID entities.UserID, params EventIndexParams) ([]cloudevents.Event, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	query := repository.db.WithContext(ctx).Where("user_id = ?", userID)
+	if len(params.Types) > 0 {
+		query.Where("type IN ?", params.Types)
+	}
+	if params.Source != "" {
+		query.Where("source = ?", params.Source)
+	}
+	if params.Since != nil {
+		query.Where("time >= ?", *params.Since)
+	}
+	if params.Until != nil {
+		query.Where("time <= ?", *params.Until)
+	}
+	if params.EntityID != nil {
+		query.Where(
+			repository.db.Where("message_id = ?", *params.EntityID).
+				Or("phone_id = ?", *params.EntityID).
+				Or("webhook_id = ?", *params.EntityID),
+		)
+	}
+	if params.MessageID != nil {
+		query.Where("message_id = ?", *params.MessageID)
+	}
+	if params.Cursor != nil {
+		query.Where(
+			repository.db.Where("time < ?", params.Cursor.Time).
+				Or(repository.db.Where("time = ?", params.Cursor.Time).Where("id < ?", params.Cursor.ID)),
+		)
+	}
+
+	order := "DESC"
+	if params.After != nil {
+		order = "ASC"
+		query.Where(
+			repository.db.Where("time > ?", params.After.Time).
+				Or(repository.db.Where("time = ?", params.After.Time).Where("id > ?", params.After.ID)),
+		)
+	}
+
+	var events []GormEvent
+	if err := query.Order("time " + order).Order("id " + order).Limit(params.Limit).Find(&events).Error; err != nil {
+		msg := fmt.Sprintf("cannot fetch events for user [%s] with params [%+#v]", userID, params)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	results := make([]cloudevents.Event, 0, len(events))
+	for _, event := range events {
+		var cloudevent cloudevents.Event
+		if err := json.Unmarshal(event.Data, &cloudevent); err != nil {
+			msg := fmt.Sprintf("cannot unmarshal [%s] into [%T]", event.Data, cloudevent)
+			return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+		}
+		results = append(results, cloudevent)
+	}
+	return results, nil
+}
+
+// Create creates a new cloudevents.Event
+func (repository *gormEventRepository) Create(ctx context.Context, event cloudevents.Event) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	gormEvent, err := repository.toGormEvent(event)
+	if err != nil {
+		return stacktrace.Propagate(err, fmt.Sprintf("cannot convert event [%s] and type [%s] into [%T]", event.ID(), event.Type(), gormEvent))
 	}
 
 	if err = repository.db.WithContext(ctx).Create(gormEvent).Error; err != nil {
@@ -102,23 +188,65 @@ func (repository *gormEventRepository) Save(ctx context.Context, event cloudeven
 	ctx, span := repository.tracer.Start(ctx)
 	defer span.End()
 
+	gormEvent, err := repository.toGormEvent(event)
+	if err != nil {
+		return stacktrace.Propagate(err, fmt.Sprintf("cannot convert event [%s] and type [%s] into [%T]", event.ID(), event.Type(), gormEvent))
+	}
+
+	if err = repository.db.WithContext(ctx).Save(gormEvent).Error; err != nil {
+		return stacktrace.Propagate(err, fmt.Sprintf("cannot save event [%s] and type [%s]", event.ID(), event.Type()))
+	}
+
+	return nil
+}
+
+// toGormEvent serializes a cloudevents.Event together with the IDs of the entities referenced by its payload
+func (repository *gormEventRepository) toGormEvent(event cloudevents.Event) (*GormEvent, error) {
 	data, err := event.MarshalJSON()
 	if err != nil {
-		return stacktrace.Propagate(err, fmt.Sprintf("cannot marshall event [%s]  and type [%s] into JSON", event.ID(), event.Type()))
+		return nil, stacktrace.Propagate(err, fmt.Sprintf("cannot marshall event [%s]  and type [%s] into JSON", event.ID(), event.Type()))
+	}
+
+	payload := struct {
+		ID        string `json:"id"`
+		UserID    string `json:"user_id"`
+		MessageID string `json:"message_id"`
+		PhoneID   string `json:"phone_id"`
+		WebhookID string `json:"webhook_id"`
+	}{}
+	if err = event.DataAs(&payload); err != nil {
+		repository.logger.Warn(stacktrace.Propagate(err, fmt.Sprintf("cannot extract references from event [%s] and type [%s]", event.ID(), event.Type())))
 	}
 
-	gormEvent := GormEvent{
+	// the payload of message events references the message with the "id" key
+	if payload.MessageID == "" && strings.HasPrefix(event.Type(), "message.") {
+		payload.MessageID = payload.ID
+	}
+
+	gormEvent := &GormEvent{
 		ID:        uuid.MustParse(event.ID()),
 		Time:      event.Time(),
+		MessageID: repository.parseUUID(payload.MessageID),
+		PhoneID:   repository.parseUUID(payload.PhoneID),
+		WebhookID: repository.parseUUID(payload.WebhookID),
 		Source:    event.Source(),
 		CreatedAt: event.Time().UTC(),
 		Type:      event.Type(),
 		Data:      datatypes.JSON(data),
 	}
 
-	if err = repository.db.WithContext(ctx).Save(gormEvent).Error; err != nil {
-		return stacktrace.Propagate(err, fmt.Sprintf("cannot save event [%s] and type [%s]", event.ID(), event.Type()))
+	if payload.UserID != "" {
+		userID := entities.UserID(payload.UserID)
+		gormEvent.UserID = &userID
 	}
 
-	return nil
+	return gormEvent, nil
+}
+
+func (repository *gormEventRepository) parseUUID(value string) *uuid.UUID {
+	id, err := uuid.Parse(value)
+	if err != nil || id == uuid.Nil {
+		return nil
+	}
+	return &id
 }
@@ -0,0 +1,136 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/google/uuid"
+	"github.com/palantir/stacktrace"
+	"gorm.io/gorm"
+)
+
+// gormForwardingRuleRepository is responsible for persisting entities.ForwardingRule
+type gormForwardingRuleRepository struct {
+	logger telemetry.Logger
+	tracer telemetry.Tracer
+	db     *gorm.DB
+}
+
+// NewGormForwardingRuleRepository creates the GORM version of the ForwardingRuleRepository
+func NewGormForwardingRuleRepository(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	db *gorm.DB,
+) ForwardingRuleRepository {
+	return &gormForwardingRuleRepository{
+		logger: logger.WithService(fmt.Sprintf("%T", &gormForwardingRuleRepository{})),
+		tracer: tracer,
+		db:     db,
+	}
+}
+
+// Store a new entities.ForwardingRule
+func (repository *gormForwardingRuleRepository) Store(ctx context.Context, rule *entities.ForwardingRule) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	if err := repository.db.WithContext(ctx).Create(rule).Error; err != nil {
+		msg := fmt.Sprintf("cannot save forwarding rule with ID [%s]", rule.ID)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+// Update an entities.ForwardingRule
+func (repository *gormForwardingRuleRepository) Update(ctx context.Context, rule *entities.ForwardingRule) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	if err := repository.db.WithContext(ctx).Save(rule).Error; err != nil {
+		msg := fmt.Sprintf("cannot update forwarding rule with ID [%s]", rule.ID)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+// Load an entities.ForwardingRule by ID
+func (repository *gormForwardingRuleRepository) Load(ctx context.Context, userID entities.UserID, ruleID uuid.UUID) (*entities.ForwardingRule, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	rule := new(entities.ForwardingRule)
+	err := repository.db.WithContext(ctx).Where("user_id = ?", userID).Where("id = ?", ruleID).First(rule).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		msg := fmt.Sprintf("forwarding rule with ID [%s] for user [%s] does not exist", ruleID, userID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, ErrCodeNotFound, msg))
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot load forwarding rule with ID [%s] for user [%s]", ruleID, userID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return rule, nil
+}
+
+// Index entities.ForwardingRule of a user
+func (repository *gormForwardingRuleRepository) Index(ctx context.Context, userID entities.UserID, params IndexParams) ([]*entities.ForwardingRule, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	query := repository.db.WithContext(ctx).Where("user_id = ?", userID)
+	if len(params.Query) > 0 {
+		queryPattern := "%" + params.Query + "%"
+		query.Where(repository.db.Where("name ILIKE ?", queryPattern).Or("owner ILIKE ?", queryPattern).Or("contact ILIKE ?", queryPattern))
+	}
+
+	rules := make([]*entities.ForwardingRule, 0)
+	if err := query.Order("created_at DESC").Limit(params.Limit).Offset(params.Skip).Find(&rules).Error; err != nil {
+		msg := fmt.Sprintf("cannot fetch forwarding rules for user [%s] and params [%+#v]", userID, params)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return rules, nil
+}
+
+// FetchEnabled fetches the enabled entities.ForwardingRule of a user
+func (repository *gormForwardingRuleRepository) FetchEnabled(ctx context.Context, userID entities.UserID) ([]*entities.ForwardingRule, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	rules := make([]*entities.ForwardingRule, 0)
+	err := repository.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Where("enabled = ?", true).
+		Order("created_at ASC").
+		Find(&rules).
+		Error
+	if err != nil {
+		msg := fmt.Sprintf("cannot fetch enabled forwarding rules for user [%s]", userID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return rules, nil
+}
+
+// Delete an entities.ForwardingRule
+func (repository *gormForwardingRuleRepository) Delete(ctx context.Context, userID entities.UserID, ruleID uuid.UUID) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	err := repository.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Where("id = ?", ruleID).
+		Delete(&entities.ForwardingRule{}).Error
+	if err != nil {
+		msg := fmt.Sprintf("cannot delete forwarding rule with ID [%s] and userID [%s]", ruleID, userID)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
@@ -26,7 +26,7 @@ func (repository *gormHeartbeatMonitorRepository) UpdateQueueID(ctx context.Cont
 	ctx, span := repository.tracer.Start(ctx)
 	defer span.End()
 
-	err := repository.db.
+	err := WithoutTenantScope(repository.db.WithContext(ctx)).
 		Model(&entities.HeartbeatMonitor{}).
 		Where("id = ?", monitorID).
 		UpdateColumn("queue_id", queueID).
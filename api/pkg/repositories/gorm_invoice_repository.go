@@ -0,0 +1,108 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/google/uuid"
+	"github.com/palantir/stacktrace"
+	"gorm.io/gorm"
+)
+
+// gormInvoiceRepository is responsible for persisting entities.Invoice
+type gormInvoiceRepository struct {
+	logger telemetry.Logger
+	tracer telemetry.Tracer
+	db     *gorm.DB
+}
+
+// NewGormInvoiceRepository creates the GORM version of the InvoiceRepository
+func NewGormInvoiceRepository(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	db *gorm.DB,
+) InvoiceRepository {
+	return &gormInvoiceRepository{
+		logger: logger.WithService(fmt.Sprintf("%T", &gormInvoiceRepository{})),
+		tracer: tracer,
+		db:     db,
+	}
+}
+
+// Save an entities.Invoice
+func (repository *gormInvoiceRepository) Save(ctx context.Context, invoice *entities.Invoice) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	if err := repository.db.WithContext(ctx).Save(invoice).Error; err != nil {
+		msg := fmt.Sprintf("cannot save invoice with ID [%s] for user [%s]", invoice.ID, invoice.UserID)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+// Load an entities.Invoice by ID
+func (repository *gormInvoiceRepository) Load(ctx context.Context, userID entities.UserID, invoiceID uuid.UUID) (*entities.Invoice, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	invoice := new(entities.Invoice)
+	err := repository.db.WithContext(ctx).Where("user_id = ?", userID).Where("id = ?", invoiceID).First(invoice).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		msg := fmt.Sprintf("invoice with ID [%s] for user [%s] does not exist", invoiceID, userID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, ErrCodeNotFound, msg))
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot load invoice with ID [%s] for user [%s]", invoiceID, userID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return invoice, nil
+}
+
+// LoadByPeriod loads the entities.Invoice of a user which starts at periodStart
+func (repository *gormInvoiceRepository) LoadByPeriod(ctx context.Context, userID entities.UserID, periodStart time.Time) (*entities.Invoice, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	invoice := new(entities.Invoice)
+	err := repository.db.WithContext(ctx).Where("user_id = ?", userID).Where("period_start = ?", periodStart).First(invoice).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		msg := fmt.Sprintf("invoice for period [%s] and user [%s] does not exist", periodStart, userID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, ErrCodeNotFound, msg))
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot load invoice for period [%s] and user [%s]", periodStart, userID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return invoice, nil
+}
+
+// Index entities.Invoice of a user
+func (repository *gormInvoiceRepository) Index(ctx context.Context, userID entities.UserID, params IndexParams) ([]*entities.Invoice, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	invoices := make([]*entities.Invoice, 0)
+	err := repository.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Order("period_start DESC").
+		Limit(params.Limit).
+		Offset(params.Skip).
+		Find(&invoices).
+		Error
+	if err != nil {
+		msg := fmt.Sprintf("cannot fetch invoices for user [%s] and params [%+#v]", userID, params)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return invoices, nil
+}
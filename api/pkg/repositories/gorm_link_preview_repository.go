@@ -0,0 +1,65 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/palantir/stacktrace"
+	"gorm.io/gorm"
+)
+
+// gormLinkPreviewRepository is responsible for persisting entities.LinkPreview
+type gormLinkPreviewRepository struct {
+	logger telemetry.Logger
+	tracer telemetry.Tracer
+	db     *gorm.DB
+}
+
+// NewGormLinkPreviewRepository creates the GORM version of the LinkPreviewRepository
+func NewGormLinkPreviewRepository(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	db *gorm.DB,
+) LinkPreviewRepository {
+	return &gormLinkPreviewRepository{
+		logger: logger.WithService(fmt.Sprintf("%T", &gormLinkPreviewRepository{})),
+		tracer: tracer,
+		db:     db,
+	}
+}
+
+// Save an entities.LinkPreview
+func (repository *gormLinkPreviewRepository) Save(ctx context.Context, preview *entities.LinkPreview) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	if err := repository.db.WithContext(ctx).Save(preview).Error; err != nil {
+		msg := fmt.Sprintf("cannot save link preview with ID [%s] and URL [%s]", preview.ID, preview.URL)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+// Load an entities.LinkPreview by URL
+func (repository *gormLinkPreviewRepository) Load(ctx context.Context, url string) (*entities.LinkPreview, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	preview := new(entities.LinkPreview)
+	err := repository.db.WithContext(ctx).Where("url = ?", url).First(preview).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		msg := fmt.Sprintf("link preview with URL [%s] does not exist", url)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, ErrCodeNotFound, msg))
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot load link preview with URL [%s]", url)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return preview, nil
+}
@@ -4,6 +4,9 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sort"
+	"strings"
+	"time"
 
 	"gorm.io/gorm/clause"
 
@@ -11,27 +14,34 @@ import (
 	"github.com/NdoleStudio/httpsms/pkg/telemetry"
 	"github.com/cockroachdb/cockroach-go/v2/crdb/crdbgorm"
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 	"github.com/palantir/stacktrace"
 	"gorm.io/gorm"
 )
 
-// gormMessageRepository is responsible for persisting entities.Message
+// messageSequenceIncrement increments the counter of the entities.MessageSequence of a thread and returns the new value
+const messageSequenceIncrement = `
+INSERT INTO message_sequences (user_id, owner, contact, value, updated_at) VALUES (?, ?, ?, 1, ?)
+ON CONFLICT (user_id, owner, contact) DO UPDATE SET value = message_sequences.value + 1, updated_at = excluded.updated_at
+RETURNING value`
+
+// gormMessageRepository is responsible for persisting entities.Message in the database of the user region
 type gormMessageRepository struct {
-	logger telemetry.Logger
-	tracer telemetry.Tracer
-	db     *gorm.DB
+	logger   telemetry.Logger
+	tracer   telemetry.Tracer
+	resolver DatabaseResolver
 }
 
 // NewGormMessageRepository creates the GORM version of the MessageRepository
 func NewGormMessageRepository(
 	logger telemetry.Logger,
 	tracer telemetry.Tracer,
-	db *gorm.DB,
+	resolver DatabaseResolver,
 ) MessageRepository {
 	return &gormMessageRepository{
-		logger: logger.WithService(fmt.Sprintf("%T", &gormMessageRepository{})),
-		tracer: tracer,
-		db:     db,
+		logger:   logger.WithService(fmt.Sprintf("%T", &gormMessageRepository{})),
+		tracer:   tracer,
+		resolver: resolver,
 	}
 }
 
@@ -40,7 +50,13 @@ func (repository *gormMessageRepository) Index(ctx context.Context, userID entit
 	ctx, span := repository.tracer.Start(ctx)
 	defer span.End()
 
-	query := repository.db.
+	db, err := repository.resolver.Resolve(ctx, userID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot resolve database of user [%s]", userID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	query := db.
 		WithContext(ctx).
 		Where("user_id = ?", userID).
 		Where("owner = ?", owner).
@@ -51,7 +67,7 @@ func (repository *gormMessageRepository) Index(ctx context.Context, userID entit
 	}
 
 	messages := new([]entities.Message)
-	if err := query.Order("order_timestamp DESC").Limit(params.Limit).Offset(params.Skip).Find(&messages).Error; err != nil {
+	if err = query.Order("sequence DESC").Order("order_timestamp DESC").Limit(params.Limit).Offset(params.Skip).Find(&messages).Error; err != nil {
 		msg := fmt.Sprintf("cannot fetch messges with owner [%s] and contact [%s] and params [%+#v]", owner, contact, params)
 		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
 	}
@@ -59,12 +75,171 @@ func (repository *gormMessageRepository) Index(ctx context.Context, userID entit
 	return messages, nil
 }
 
+// IndexPage fetches the entities.Message of a user matching the MessageIndexParams from the newest to the oldest
+func (repository *gormMessageRepository) IndexPage(ctx context.Context, userID entities.UserID, params MessageIndexParams) ([]*entities.Message, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	db, err := repository.resolver.Resolve(ctx, userID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot resolve database of user [%s]", userID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	query := db.WithContext(ctx).Where("user_id = ?", userID)
+	if params.Owner != "" {
+		query.Where("owner = ?", params.Owner)
+	}
+	if params.Contact != "" {
+		query.Where("contact = ?", params.Contact)
+	}
+	if len(params.Statuses) > 0 {
+		query.Where("status IN ?", params.Statuses)
+	}
+	if len(params.Types) > 0 {
+		query.Where("type IN ?", params.Types)
+	}
+	if params.SIM != "" {
+		query.Where("sim = ?", params.SIM)
+	}
+	if params.Since != nil {
+		query.Where("order_timestamp >= ?", *params.Since)
+	}
+	if params.Until != nil {
+		query.Where("order_timestamp <= ?", *params.Until)
+	}
+	if params.Query != "" {
+		query.Where("content ILIKE ?", "%"+params.Query+"%")
+	}
+	if len(params.Tags) > 0 {
+		query.Where("tags && ?", pq.StringArray(params.Tags))
+	}
+	if params.Cursor != nil {
+		query.Where(
+			db.Where("order_timestamp < ?", params.Cursor.Time).
+				Or(db.Where("order_timestamp = ?", params.Cursor.Time).Where("id < ?", params.Cursor.ID)),
+		)
+	}
+
+	var messages []*entities.Message
+	if err = query.Order("order_timestamp DESC").Order("id DESC").Limit(params.Limit).Find(&messages).Error; err != nil {
+		msg := fmt.Sprintf("cannot fetch messages for user [%s] with params [%+#v]", userID, params)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return messages, nil
+}
+
+// FetchByIDs fetches the entities.Message of a user with the IDs
+func (repository *gormMessageRepository) FetchByIDs(ctx context.Context, userID entities.UserID, messageIDs []uuid.UUID) ([]*entities.Message, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	db, err := repository.resolver.Resolve(ctx, userID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot resolve database of user [%s]", userID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	messages := make([]*entities.Message, 0, len(messageIDs))
+	if len(messageIDs) == 0 {
+		return messages, nil
+	}
+
+	if err = db.WithContext(ctx).Where("user_id = ?", userID).Where("id IN ?", messageIDs).Find(&messages).Error; err != nil {
+		msg := fmt.Sprintf("cannot fetch [%d] messages for user [%s]", len(messageIDs), userID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return messages, nil
+}
+
+// Search fetches the entities.MessageSearchHit with content matching the MessageSearchParams, the best match first
+func (repository *gormMessageRepository) Search(ctx context.Context, userID entities.UserID, params MessageSearchParams) ([]*entities.MessageSearchHit, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	db, err := repository.resolver.Resolve(ctx, userID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot resolve database of user [%s]", userID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	conditions := []string{"user_id = ?", "to_tsvector('simple', content) @@ search.query"}
+	values := []interface{}{repository.tsQuery(params.Terms), userID}
+	if params.Owner != "" {
+		conditions = append(conditions, "owner = ?")
+		values = append(values, params.Owner)
+	}
+	if params.Contact != "" {
+		conditions = append(conditions, "contact = ?")
+		values = append(values, params.Contact)
+	}
+
+	hits := make([]*entities.MessageSearchHit, 0)
+	err = db.WithContext(ctx).
+		Raw(
+			"SELECT id AS message_id, ts_headline('simple', content, search.query, 'StartSel=<mark>, StopSel=</mark>, MaxWords=20, MinWords=5, MaxFragments=2') AS snippet, ts_rank(to_tsvector('simple', content), search.query) AS score "+
+				"FROM messages, to_tsquery('simple', ?) AS search(query) WHERE "+strings.Join(conditions, " AND ")+" ORDER BY score DESC, order_timestamp DESC LIMIT ? OFFSET ?",
+			append(values, params.Limit, params.Skip)...,
+		).
+		Scan(&hits).
+		Error
+	if err != nil {
+		msg := fmt.Sprintf("cannot search messages for user [%s] with params [%+#v]", userID, params)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return hits, nil
+}
+
+// tsQuery converts entities.MessageSearchTerm into a postgres tsquery, the words only contain letters and digits
+func (repository *gormMessageRepository) tsQuery(terms []entities.MessageSearchTerm) string {
+	parts := make([]string, 0, len(terms))
+	for _, term := range terms {
+		query := strings.Join(term.Words, " <-> ")
+		if term.Prefix {
+			query += ":*"
+		}
+		parts = append(parts, query)
+	}
+	return strings.Join(parts, " & ")
+}
+
 // Store a new entities.Message
 func (repository *gormMessageRepository) Store(ctx context.Context, message *entities.Message) error {
 	ctx, span := repository.tracer.Start(ctx)
 	defer span.End()
 
-	if err := repository.db.WithContext(ctx).Create(message).Error; err != nil {
+	db, err := repository.resolver.Resolve(ctx, message.UserID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot resolve database of user [%s]", message.UserID)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	err = crdbgorm.ExecuteTx(ctx, db, nil, func(tx *gorm.DB) error {
+		// the row of the counter is locked until the transaction commits so concurrent messages in a thread get distinct sequences
+		var sequence uint64
+		err := tx.WithContext(ctx).
+			Raw(messageSequenceIncrement, message.UserID, message.Owner, message.Contact, time.Now().UTC()).
+			Scan(&sequence).
+			Error
+		if err != nil {
+			return stacktrace.Propagate(err, fmt.Sprintf("cannot increment the sequence of thread with owner [%s] and contact [%s]", message.Owner, message.Contact))
+		}
+
+		message.Sequence = sequence
+		result := tx.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(message)
+		if result.Error != nil {
+			return result.Error
+		}
+
+		if result.RowsAffected == 0 {
+			return stacktrace.NewErrorWithCode(ErrCodeMessageExists, fmt.Sprintf("a message with ID [%s] already exists", message.ID))
+		}
+		return nil
+	})
+	if err != nil {
 		msg := fmt.Sprintf("cannot save message with ID [%s]", message.ID)
 		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
 	}
@@ -77,8 +252,14 @@ func (repository *gormMessageRepository) Load(ctx context.Context, userID entiti
 	ctx, span := repository.tracer.Start(ctx)
 	defer span.End()
 
+	db, err := repository.resolver.Resolve(ctx, userID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot resolve database of user [%s]", userID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
 	message := new(entities.Message)
-	err := repository.db.WithContext(ctx).Where("user_id = ?", userID).Where("id = ?", messageID).First(message).Error
+	err = db.WithContext(ctx).Where("user_id = ?", userID).Where("id = ?", messageID).First(message).Error
 	if errors.Is(err, gorm.ErrRecordNotFound) {
 		msg := fmt.Sprintf("message with ID [%s] and userID [%s] does not exist", messageID, userID)
 		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, ErrCodeNotFound, msg))
@@ -92,12 +273,114 @@ func (repository *gormMessageRepository) Load(ctx context.Context, userID entiti
 	return message, nil
 }
 
+// LoadLastInConversation fetches the latest entities.Message sent to a contact with a conversation ID since a timestamp
+func (repository *gormMessageRepository) LoadLastInConversation(ctx context.Context, userID entities.UserID, owner string, contact string, since time.Time) (*entities.Message, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	db, err := repository.resolver.Resolve(ctx, userID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot resolve database of user [%s]", userID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	message := new(entities.Message)
+	err = db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Where("owner = ?", owner).
+		Where("contact = ?", contact).
+		Where("type = ?", entities.MessageTypeMobileTerminated).
+		Where("conversation_id IS NOT NULL").
+		Where("request_received_at >= ?", since).
+		Order("request_received_at DESC").
+		First(message).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		msg := fmt.Sprintf("no message in a conversation from owner [%s] to contact [%s] since [%s]", owner, contact, since)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, ErrCodeNotFound, msg))
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot load message in a conversation from owner [%s] to contact [%s]", owner, contact)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return message, nil
+}
+
+// LoadReceivedDuplicate fetches the latest received entities.Message with the same content from a contact since a timestamp
+func (repository *gormMessageRepository) LoadReceivedDuplicate(ctx context.Context, userID entities.UserID, owner string, contact string, content string, since time.Time) (*entities.Message, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	db, err := repository.resolver.Resolve(ctx, userID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot resolve database of user [%s]", userID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	message := new(entities.Message)
+	err = db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Where("owner = ?", owner).
+		Where("contact = ?", contact).
+		Where("type = ?", entities.MessageTypeMobileOriginated).
+		Where("content = ?", content).
+		Where("received_at >= ?", since).
+		Order("received_at DESC").
+		First(message).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		msg := fmt.Sprintf("no duplicate message from contact [%s] to owner [%s] since [%s]", contact, owner, since)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, ErrCodeNotFound, msg))
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot load duplicate message from contact [%s] to owner [%s]", contact, owner)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return message, nil
+}
+
+// FetchReceivedSince fetches the entities.Message received from a contact since a timestamp with the latest first
+func (repository *gormMessageRepository) FetchReceivedSince(ctx context.Context, userID entities.UserID, owner string, contact string, since time.Time) ([]*entities.Message, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	db, err := repository.resolver.Resolve(ctx, userID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot resolve database of user [%s]", userID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	messages := make([]*entities.Message, 0)
+	err = db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Where("owner = ?", owner).
+		Where("contact = ?", contact).
+		Where("type = ?", entities.MessageTypeMobileOriginated).
+		Where("received_at >= ?", since).
+		Order("received_at DESC").
+		Find(&messages).Error
+	if err != nil {
+		msg := fmt.Sprintf("cannot fetch messages from contact [%s] to owner [%s] since [%s]", contact, owner, since)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return messages, nil
+}
+
 // Update an entities.Message
 func (repository *gormMessageRepository) Update(ctx context.Context, message *entities.Message) error {
 	ctx, span := repository.tracer.Start(ctx)
 	defer span.End()
 
-	if err := repository.db.WithContext(ctx).Save(message).Error; err != nil {
+	db, err := repository.resolver.Resolve(ctx, message.UserID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot resolve database of user [%s]", message.UserID)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if err = db.WithContext(ctx).Save(message).Error; err != nil {
 		msg := fmt.Sprintf("cannot update message with ID [%s]", message.ID)
 		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
 	}
@@ -105,19 +388,86 @@ func (repository *gormMessageRepository) Update(ctx context.Context, message *en
 	return nil
 }
 
+// LoadLastSent fetches the latest entities.Message which was sent to a contact before a timestamp
+func (repository *gormMessageRepository) LoadLastSent(ctx context.Context, userID entities.UserID, owner string, contact string, before time.Time) (*entities.Message, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	db, err := repository.resolver.Resolve(ctx, userID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot resolve database of user [%s]", userID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	message := new(entities.Message)
+	err = db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Where("owner = ?", owner).
+		Where("contact = ?", contact).
+		Where("type = ?", entities.MessageTypeMobileTerminated).
+		Where("sent_at < ?", before).
+		Order("sent_at DESC").
+		First(message).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		msg := fmt.Sprintf("no message sent from owner [%s] to contact [%s] before [%s]", owner, contact, before)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, ErrCodeNotFound, msg))
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot load last message sent from owner [%s] to contact [%s]", owner, contact)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return message, nil
+}
+
+// CountReceived counts the entities.Message received from a contact after a timestamp and before another timestamp
+func (repository *gormMessageRepository) CountReceived(ctx context.Context, userID entities.UserID, owner string, contact string, after time.Time, before time.Time) (count int64, err error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	db, err := repository.resolver.Resolve(ctx, userID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot resolve database of user [%s]", userID)
+		return 0, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	err = db.WithContext(ctx).
+		Model(&entities.Message{}).
+		Where("user_id = ?", userID).
+		Where("owner = ?", owner).
+		Where("contact = ?", contact).
+		Where("type = ?", entities.MessageTypeMobileOriginated).
+		Where("received_at > ?", after).
+		Where("received_at < ?", before).
+		Count(&count).Error
+	if err != nil {
+		msg := fmt.Sprintf("cannot count messages received from contact [%s] by owner [%s] between [%s] and [%s]", contact, owner, after, before)
+		return 0, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return count, nil
+}
+
 // GetOutstanding fetches messages that still to be sent to the phone
 func (repository *gormMessageRepository) GetOutstanding(ctx context.Context, userID entities.UserID, messageID uuid.UUID) (*entities.Message, error) {
 	ctx, span := repository.tracer.Start(ctx)
 	defer span.End()
 
+	db, err := repository.resolver.Resolve(ctx, userID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot resolve database of user [%s]", userID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
 	message := new(entities.Message)
-	err := crdbgorm.ExecuteTx(ctx, repository.db, nil,
+	err = crdbgorm.ExecuteTx(ctx, db, nil,
 		func(tx *gorm.DB) error {
 			return tx.WithContext(ctx).Model(message).
 				Clauses(clause.Returning{}).
 				Where("user_id = ?", userID).
 				Where("id = ?", messageID).
-				Where(repository.db.Where("status = ?", entities.MessageStatusScheduled).Or("status = ?", entities.MessageStatusPending).Or("status = ?", entities.MessageStatusExpired)).
+				Where(db.Where("status = ?", entities.MessageStatusScheduled).Or("status = ?", entities.MessageStatusPending).Or("status = ?", entities.MessageStatusExpired)).
 				Update("status", entities.MessageStatusSending).Error
 		},
 	)
@@ -138,3 +488,717 @@ func (repository *gormMessageRepository) GetOutstanding(ctx context.Context, use
 
 	return message, nil
 }
+
+// IndexBatch fetches the entities.Message which were sent in the same bulk send request
+func (repository *gormMessageRepository) IndexBatch(ctx context.Context, userID entities.UserID, batchID uuid.UUID) ([]*entities.Message, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	db, err := repository.resolver.Resolve(ctx, userID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot resolve database of user [%s]", userID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	messages := make([]*entities.Message, 0)
+	err = db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Where("batch_id = ?", batchID).
+		Order("created_at ASC").
+		Find(&messages).
+		Error
+	if err != nil {
+		msg := fmt.Sprintf("cannot fetch messages with batch ID [%s] for user [%s]", batchID, userID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return messages, nil
+}
+
+// FetchDue fetches the entities.Message of all users which are queued for a SendAt time before a timestamp
+func (repository *gormMessageRepository) FetchDue(ctx context.Context, timestamp time.Time, limit int) ([]*entities.Message, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	messages := make([]*entities.Message, 0)
+	for _, db := range repository.resolver.All() {
+		due := make([]*entities.Message, 0)
+		err := WithoutTenantScope(db.WithContext(ctx)).
+			Where("status = ?", entities.MessageStatusQueuedForFuture).
+			Where("send_at <= ?", timestamp).
+			Order(priorityOrder("priority")).
+			Order("send_at ASC").
+			Limit(limit).
+			Find(&due).
+			Error
+		if err != nil {
+			msg := fmt.Sprintf("cannot fetch messages which are due at [%s]", timestamp)
+			return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+		}
+		messages = append(messages, due...)
+	}
+
+	return messages, nil
+}
+
+// Promote changes the status of an entities.Message which is queued for the future to pending
+func (repository *gormMessageRepository) Promote(ctx context.Context, userID entities.UserID, messageID uuid.UUID) (bool, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	db, err := repository.resolver.Resolve(ctx, userID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot resolve database of user [%s]", userID)
+		return false, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	result := db.WithContext(ctx).
+		Model(&entities.Message{}).
+		Where("user_id = ?", userID).
+		Where("id = ?", messageID).
+		Where("status = ?", entities.MessageStatusQueuedForFuture).
+		Updates(map[string]any{
+			"status":     entities.MessageStatusPending,
+			"updated_at": time.Now().UTC(),
+		})
+	if result.Error != nil {
+		msg := fmt.Sprintf("cannot promote message with ID [%s] for user [%s]", messageID, userID)
+		return false, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(result.Error, msg))
+	}
+
+	return result.RowsAffected == 1, nil
+}
+
+// Demote changes the status of a pending entities.Message back to queued for the future
+func (repository *gormMessageRepository) Demote(ctx context.Context, userID entities.UserID, messageID uuid.UUID) (bool, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	db, err := repository.resolver.Resolve(ctx, userID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot resolve database of user [%s]", userID)
+		return false, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	result := db.WithContext(ctx).
+		Model(&entities.Message{}).
+		Where("user_id = ?", userID).
+		Where("id = ?", messageID).
+		Where("status = ?", entities.MessageStatusPending).
+		Updates(map[string]any{
+			"status":     entities.MessageStatusQueuedForFuture,
+			"updated_at": time.Now().UTC(),
+		})
+	if result.Error != nil {
+		msg := fmt.Sprintf("cannot demote message with ID [%s] for user [%s]", messageID, userID)
+		return false, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(result.Error, msg))
+	}
+
+	return result.RowsAffected == 1, nil
+}
+
+// FetchAwaiting fetches the entities.Message of a user which are waiting for the message with ID to be sent
+func (repository *gormMessageRepository) FetchAwaiting(ctx context.Context, userID entities.UserID, messageID uuid.UUID) ([]*entities.Message, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	db, err := repository.resolver.Resolve(ctx, userID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot resolve database of user [%s]", userID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	messages := make([]*entities.Message, 0)
+	err = db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Where("after_message_id = ?", messageID).
+		Where("status = ?", entities.MessageStatusAwaitingPredecessor).
+		Order("request_received_at ASC").
+		Find(&messages).
+		Error
+	if err != nil {
+		msg := fmt.Sprintf("cannot fetch messages of user [%s] which are waiting for message [%s]", userID, messageID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return messages, nil
+}
+
+// Release changes the status of an entities.Message which is waiting for its predecessor
+func (repository *gormMessageRepository) Release(ctx context.Context, userID entities.UserID, messageID uuid.UUID, status entities.MessageStatus) (bool, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	db, err := repository.resolver.Resolve(ctx, userID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot resolve database of user [%s]", userID)
+		return false, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	result := db.WithContext(ctx).
+		Model(&entities.Message{}).
+		Where("user_id = ?", userID).
+		Where("id = ?", messageID).
+		Where("status = ?", entities.MessageStatusAwaitingPredecessor).
+		Updates(map[string]any{
+			"status":     status,
+			"updated_at": time.Now().UTC(),
+		})
+	if result.Error != nil {
+		msg := fmt.Sprintf("cannot release message with ID [%s] for user [%s] to status [%s]", messageID, userID, status)
+		return false, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(result.Error, msg))
+	}
+
+	return result.RowsAffected == 1, nil
+}
+
+// Pause changes the status of the entities.Message of a user which are waiting for the phone to paused
+func (repository *gormMessageRepository) Pause(ctx context.Context, userID entities.UserID) (int64, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	db, err := repository.resolver.Resolve(ctx, userID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot resolve database of user [%s]", userID)
+		return 0, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	result := db.WithContext(ctx).
+		Model(&entities.Message{}).
+		Where("user_id = ?", userID).
+		Where("status IN ?", []entities.MessageStatus{entities.MessageStatusPending, entities.MessageStatusScheduled}).
+		Updates(map[string]any{
+			"status":     entities.MessageStatusPaused,
+			"updated_at": time.Now().UTC(),
+		})
+	if result.Error != nil {
+		msg := fmt.Sprintf("cannot pause messages of user [%s]", userID)
+		return 0, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(result.Error, msg))
+	}
+
+	return result.RowsAffected, nil
+}
+
+// FetchPaused fetches the paused entities.Message of a user with the oldest first
+func (repository *gormMessageRepository) FetchPaused(ctx context.Context, userID entities.UserID, limit int) ([]*entities.Message, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	db, err := repository.resolver.Resolve(ctx, userID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot resolve database of user [%s]", userID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	messages := make([]*entities.Message, 0)
+	err = db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Where("status = ?", entities.MessageStatusPaused).
+		Order("request_received_at ASC").
+		Limit(limit).
+		Find(&messages).
+		Error
+	if err != nil {
+		msg := fmt.Sprintf("cannot fetch paused messages of user [%s]", userID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return messages, nil
+}
+
+// Resume changes the status of a paused entities.Message to pending
+func (repository *gormMessageRepository) Resume(ctx context.Context, userID entities.UserID, messageID uuid.UUID) (bool, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	db, err := repository.resolver.Resolve(ctx, userID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot resolve database of user [%s]", userID)
+		return false, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	result := db.WithContext(ctx).
+		Model(&entities.Message{}).
+		Where("user_id = ?", userID).
+		Where("id = ?", messageID).
+		Where("status = ?", entities.MessageStatusPaused).
+		Updates(map[string]any{
+			"status":     entities.MessageStatusPending,
+			"updated_at": time.Now().UTC(),
+		})
+	if result.Error != nil {
+		msg := fmt.Sprintf("cannot resume message with ID [%s] for user [%s]", messageID, userID)
+		return false, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(result.Error, msg))
+	}
+
+	return result.RowsAffected == 1, nil
+}
+
+// FetchWaiting fetches the entities.Message of an owner which are waiting for the phone with the oldest first
+func (repository *gormMessageRepository) FetchWaiting(ctx context.Context, userID entities.UserID, owner string, limit int) ([]*entities.Message, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	db, err := repository.resolver.Resolve(ctx, userID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot resolve database of user [%s]", userID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	messages := make([]*entities.Message, 0)
+	err = db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Where("owner = ?", owner).
+		Where("status IN ?", []entities.MessageStatus{entities.MessageStatusPending, entities.MessageStatusScheduled}).
+		Order("request_received_at ASC").
+		Limit(limit).
+		Find(&messages).
+		Error
+	if err != nil {
+		msg := fmt.Sprintf("cannot fetch waiting messages of owner [%s] for user [%s]", owner, userID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return messages, nil
+}
+
+// Reassign changes the owner of an entities.Message which is waiting for the phone
+func (repository *gormMessageRepository) Reassign(ctx context.Context, userID entities.UserID, messageID uuid.UUID, from string, to string) (bool, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	db, err := repository.resolver.Resolve(ctx, userID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot resolve database of user [%s]", userID)
+		return false, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	result := db.WithContext(ctx).
+		Model(&entities.Message{}).
+		Where("user_id = ?", userID).
+		Where("id = ?", messageID).
+		Where("owner = ?", from).
+		Where("status IN ?", []entities.MessageStatus{entities.MessageStatusPending, entities.MessageStatusScheduled}).
+		Updates(map[string]any{
+			"owner":      to,
+			"status":     entities.MessageStatusPending,
+			"updated_at": time.Now().UTC(),
+		})
+	if result.Error != nil {
+		msg := fmt.Sprintf("cannot reassign message with ID [%s] from [%s] to [%s] for user [%s]", messageID, from, to, userID)
+		return false, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(result.Error, msg))
+	}
+
+	return result.RowsAffected == 1, nil
+}
+
+// CountAttemptedBySIM returns the number of entities.Message which an owner attempted to send with a SIM since a timestamp
+func (repository *gormMessageRepository) CountAttemptedBySIM(ctx context.Context, userID entities.UserID, owner string, sim entities.SIM, since time.Time) (int64, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	db, err := repository.resolver.Resolve(ctx, userID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot resolve database of user [%s]", userID)
+		return 0, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	var count int64
+	err = db.WithContext(ctx).
+		Model(&entities.Message{}).
+		Where("user_id = ?", userID).
+		Where("owner = ?", owner).
+		Where("sim = ?", sim).
+		Where("last_attempted_at >= ?", since).
+		Count(&count).
+		Error
+	if err != nil {
+		msg := fmt.Sprintf("cannot count messages sent by owner [%s] with SIM [%s] since [%s]", owner, sim, since)
+		return 0, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return count, nil
+}
+
+// LastAttemptedBySIM returns when an owner last attempted to send an entities.Message with a SIM
+func (repository *gormMessageRepository) LastAttemptedBySIM(ctx context.Context, userID entities.UserID, owner string, sim entities.SIM) (*time.Time, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	db, err := repository.resolver.Resolve(ctx, userID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot resolve database of user [%s]", userID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	messages := make([]*entities.Message, 0, 1)
+	err = db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Where("owner = ?", owner).
+		Where("sim = ?", sim).
+		Where("last_attempted_at IS NOT NULL").
+		Order("last_attempted_at DESC").
+		Limit(1).
+		Find(&messages).
+		Error
+	if err != nil {
+		msg := fmt.Sprintf("cannot fetch last message sent by owner [%s] with SIM [%s]", owner, sim)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if len(messages) == 0 {
+		return nil, nil
+	}
+
+	return messages[0].LastAttemptedAt, nil
+}
+
+// AssignSIM changes the SIM which is used to send an entities.Message
+func (repository *gormMessageRepository) AssignSIM(ctx context.Context, userID entities.UserID, messageID uuid.UUID, sim entities.SIM) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	db, err := repository.resolver.Resolve(ctx, userID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot resolve database of user [%s]", userID)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	err = db.WithContext(ctx).
+		Model(&entities.Message{}).
+		Where("user_id = ?", userID).
+		Where("id = ?", messageID).
+		Updates(map[string]any{
+			"sim":        sim,
+			"updated_at": time.Now().UTC(),
+		}).Error
+	if err != nil {
+		msg := fmt.Sprintf("cannot assign SIM [%s] to message with ID [%s] for user [%s]", sim, messageID, userID)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+// IndexConversations fetches the entities.Conversation of a user with the most recent message first
+func (repository *gormMessageRepository) IndexConversations(ctx context.Context, userID entities.UserID, params ConversationIndexParams) ([]*entities.Conversation, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	db, err := repository.resolver.Resolve(ctx, userID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot resolve database of user [%s]", userID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	latest := db.
+		Model(&entities.Message{}).
+		Select(
+			"DISTINCT ON (owner, contact) user_id, owner, contact, "+
+				"id AS last_message_id, content AS last_message_content, type AS last_message_type, status AS last_message_status, order_timestamp AS last_message_at, "+
+				"MIN(order_timestamp) OVER (PARTITION BY owner, contact) AS first_message_at, "+
+				"COUNT(*) OVER (PARTITION BY owner, contact) AS message_count",
+		).
+		Where("user_id = ?", userID).
+		Order("owner").
+		Order("contact").
+		Order("order_timestamp DESC").
+		Order("id DESC")
+	if params.Owner != "" {
+		latest.Where("owner = ?", params.Owner)
+	}
+
+	// messages received from the contact which were not read and not followed by a message from the owner
+	unread := db.
+		Table("messages AS received").
+		Select("COUNT(*)").
+		Where("received.user_id = conversations.user_id").
+		Where("received.owner = conversations.owner").
+		Where("received.contact = conversations.contact").
+		Where("received.type = ?", entities.MessageTypeMobileOriginated).
+		Where("received.read_at IS NULL").
+		Where(
+			"NOT EXISTS (?)",
+			db.Table("messages AS sent").
+				Select("1").
+				Where("sent.user_id = received.user_id").
+				Where("sent.owner = received.owner").
+				Where("sent.contact = received.contact").
+				Where("sent.type = ?", entities.MessageTypeMobileTerminated).
+				Where("sent.order_timestamp > received.order_timestamp"),
+		)
+
+	query := db.
+		WithContext(ctx).
+		Table("(?) AS conversations", latest).
+		Select("conversations.*, (?) AS unread_count", unread)
+	if params.Cursor != nil {
+		query.Where(
+			db.Where("conversations.last_message_at < ?", params.Cursor.Time).
+				Or(db.Where("conversations.last_message_at = ?", params.Cursor.Time).Where("conversations.last_message_id < ?", params.Cursor.ID)),
+		)
+	}
+
+	var conversations []*entities.Conversation
+	if err = query.Order("conversations.last_message_at DESC").Order("conversations.last_message_id DESC").Limit(params.Limit).Scan(&conversations).Error; err != nil {
+		msg := fmt.Sprintf("cannot fetch conversations of user [%s] with params [%+#v]", userID, params)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return conversations, nil
+}
+
+// IndexConversation fetches the entities.Message between an owner and a contact ordered by their sequence from the newest to the oldest
+func (repository *gormMessageRepository) IndexConversation(ctx context.Context, userID entities.UserID, owner string, contact string, beforeSequence *uint64, limit int) ([]*entities.Message, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	db, err := repository.resolver.Resolve(ctx, userID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot resolve database of user [%s]", userID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	query := db.
+		WithContext(ctx).
+		Where("user_id = ?", userID).
+		Where("owner = ?", owner).
+		Where("contact = ?", contact)
+	if beforeSequence != nil {
+		query.Where("sequence < ?", *beforeSequence)
+	}
+
+	var messages []*entities.Message
+	if err = query.Order("sequence DESC").Limit(limit).Find(&messages).Error; err != nil {
+		msg := fmt.Sprintf("cannot fetch messages between owner [%s] and contact [%s] for user [%s]", owner, contact, userID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return messages, nil
+}
+
+// MarkRead sets the read timestamp of a received entities.Message
+func (repository *gormMessageRepository) MarkRead(ctx context.Context, userID entities.UserID, messageID uuid.UUID, timestamp time.Time) (bool, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	db, err := repository.resolver.Resolve(ctx, userID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot resolve database of user [%s]", userID)
+		return false, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	result := db.WithContext(ctx).
+		Model(&entities.Message{}).
+		Where("user_id = ?", userID).
+		Where("id = ?", messageID).
+		Where("type = ?", entities.MessageTypeMobileOriginated).
+		Where("read_at IS NULL").
+		Updates(map[string]any{
+			"read_at":    timestamp,
+			"updated_at": time.Now().UTC(),
+		})
+	if result.Error != nil {
+		msg := fmt.Sprintf("cannot mark message with ID [%s] as read for user [%s]", messageID, userID)
+		return false, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(result.Error, msg))
+	}
+
+	return result.RowsAffected == 1, nil
+}
+
+// UpdateTags sets the tags of an entities.Message
+func (repository *gormMessageRepository) UpdateTags(ctx context.Context, userID entities.UserID, messageID uuid.UUID, tags []string) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	db, err := repository.resolver.Resolve(ctx, userID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot resolve database of user [%s]", userID)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	err = db.WithContext(ctx).
+		Model(&entities.Message{}).
+		Where("user_id = ?", userID).
+		Where("id = ?", messageID).
+		Updates(map[string]any{
+			"tags":       pq.StringArray(tags),
+			"updated_at": time.Now().UTC(),
+		}).Error
+	if err != nil {
+		msg := fmt.Sprintf("cannot update the tags of message with ID [%s] for user [%s]", messageID, userID)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+// MarkConversationRead sets the read timestamp of the unread entities.Message received by an owner from a contact
+func (repository *gormMessageRepository) MarkConversationRead(ctx context.Context, userID entities.UserID, owner string, contact string, timestamp time.Time) ([]uuid.UUID, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	db, err := repository.resolver.Resolve(ctx, userID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot resolve database of user [%s]", userID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	var messages []entities.Message
+	err = db.WithContext(ctx).
+		Model(&messages).
+		Clauses(clause.Returning{Columns: []clause.Column{{Name: "id"}}}).
+		Where("user_id = ?", userID).
+		Where("owner = ?", owner).
+		Where("contact = ?", contact).
+		Where("type = ?", entities.MessageTypeMobileOriginated).
+		Where("read_at IS NULL").
+		Updates(map[string]any{
+			"read_at":    timestamp,
+			"updated_at": time.Now().UTC(),
+		}).Error
+	if err != nil {
+		msg := fmt.Sprintf("cannot mark messages from [%s] to [%s] as read for user [%s]", contact, owner, userID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ids := make([]uuid.UUID, 0, len(messages))
+	for _, message := range messages {
+		ids = append(ids, message.ID)
+	}
+	return ids, nil
+}
+
+// Purge deletes at most limit entities.Message of a user with one of the statuses and an order timestamp before a time and returns their IDs
+func (repository *gormMessageRepository) Purge(ctx context.Context, userID entities.UserID, before time.Time, statuses []entities.MessageStatus, limit int) ([]uuid.UUID, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	db, err := repository.resolver.Resolve(ctx, userID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot resolve database of user [%s]", userID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	var messages []entities.Message
+	err = db.WithContext(ctx).
+		Clauses(clause.Returning{Columns: []clause.Column{{Name: "id"}}}).
+		Where("user_id = ?", userID).
+		Where("id IN (?)", repository.expired(db.WithContext(ctx), userID, before, statuses, limit)).
+		Delete(&messages).
+		Error
+	if err != nil {
+		msg := fmt.Sprintf("cannot delete messages of user [%s] older than [%s]", userID, before)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return repository.ids(messages), nil
+}
+
+// Anonymize removes the content of at most limit entities.Message of a user with one of the statuses and an order timestamp before a time and returns their IDs
+func (repository *gormMessageRepository) Anonymize(ctx context.Context, userID entities.UserID, before time.Time, statuses []entities.MessageStatus, limit int) ([]uuid.UUID, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	db, err := repository.resolver.Resolve(ctx, userID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot resolve database of user [%s]", userID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	var messages []entities.Message
+	err = db.WithContext(ctx).
+		Model(&messages).
+		Clauses(clause.Returning{Columns: []clause.Column{{Name: "id"}}}).
+		Where("user_id = ?", userID).
+		Where("id IN (?)", repository.expired(db.WithContext(ctx), userID, before, statuses, limit).Where("content <> ?", "")).
+		Updates(map[string]any{
+			"content":    "",
+			"updated_at": time.Now().UTC(),
+		}).Error
+	if err != nil {
+		msg := fmt.Sprintf("cannot anonymize messages of user [%s] older than [%s]", userID, before)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return repository.ids(messages), nil
+}
+
+// FetchUnencrypted fetches the entities.Message of all users with content which does not start with the prefix of encrypted content and an ID after the cursor, ordered by ID
+func (repository *gormMessageRepository) FetchUnencrypted(ctx context.Context, prefix string, after uuid.UUID, limit int) ([]*entities.Message, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	messages := make([]*entities.Message, 0)
+	for _, db := range repository.resolver.All() {
+		unencrypted := make([]*entities.Message, 0)
+		err := WithoutTenantScope(db.WithContext(ctx)).
+			Where("id > ?", after).
+			Where("content <> ?", "").
+			Where("content NOT LIKE ?", prefix+"%").
+			Order("id ASC").
+			Limit(limit).
+			Find(&unencrypted).
+			Error
+		if err != nil {
+			msg := fmt.Sprintf("cannot fetch unencrypted messages after ID [%s]", after)
+			return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+		}
+		messages = append(messages, unencrypted...)
+	}
+
+	// the messages of every database are merged so the cursor moves forward in all of them
+	sort.Slice(messages, func(i, j int) bool {
+		return messages[i].ID.String() < messages[j].ID.String()
+	})
+	if len(messages) > limit {
+		messages = messages[:limit]
+	}
+
+	return messages, nil
+}
+
+// UpdateContent changes the content of an entities.Message without changing the other columns
+func (repository *gormMessageRepository) UpdateContent(ctx context.Context, userID entities.UserID, messageID uuid.UUID, from string, to string) (bool, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	db, err := repository.resolver.Resolve(ctx, userID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot resolve database of user [%s]", userID)
+		return false, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	result := db.WithContext(ctx).
+		Model(&entities.Message{}).
+		Where("user_id = ?", userID).
+		Where("id = ?", messageID).
+		Where("content = ?", from).
+		UpdateColumn("content", to)
+	if result.Error != nil {
+		msg := fmt.Sprintf("cannot update the content of message with ID [%s] for user [%s]", messageID, userID)
+		return false, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(result.Error, msg))
+	}
+
+	return result.RowsAffected == 1, nil
+}
+
+// expired is a sub query which selects the IDs of the oldest entities.Message of a user which are older than a time
+func (repository *gormMessageRepository) expired(db *gorm.DB, userID entities.UserID, before time.Time, statuses []entities.MessageStatus, limit int) *gorm.DB {
+	return db.
+		Model(&entities.Message{}).
+		Select("id").
+		Where("user_id = ?", userID).
+		Where("order_timestamp < ?", before).
+		Where("status IN ?", statuses).
+		Order("order_timestamp ASC").
+		Limit(limit)
+}
+
+func (repository *gormMessageRepository) ids(messages []entities.Message) []uuid.UUID {
+	ids := make([]uuid.UUID, 0, len(messages))
+	for _, message := range messages {
+		ids = append(ids, message.ID)
+	}
+	return ids
+}
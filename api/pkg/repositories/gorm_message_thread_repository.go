@@ -4,8 +4,10 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 
 	"gorm.io/gorm/clause"
 
@@ -115,7 +117,7 @@ func (repository *gormMessageThreadRepository) Load(ctx context.Context, userID
 }
 
 // Index message threads for an owner
-func (repository *gormMessageThreadRepository) Index(ctx context.Context, userID entities.UserID, owner string, isArchived bool, params IndexParams) (*[]entities.MessageThread, error) {
+func (repository *gormMessageThreadRepository) Index(ctx context.Context, userID entities.UserID, owner string, isArchived bool, tags []string, params IndexParams) (*[]entities.MessageThread, error) {
 	ctx, span := repository.tracer.Start(ctx)
 	defer span.End()
 
@@ -139,6 +141,10 @@ func (repository *gormMessageThreadRepository) Index(ctx context.Context, userID
 		)
 	}
 
+	if len(tags) > 0 {
+		query.Where("tags && ?", pq.StringArray(tags))
+	}
+
 	threads := new([]entities.MessageThread)
 	if err := query.Order("order_timestamp DESC").Limit(params.Limit).Offset(params.Skip).Find(&threads).Error; err != nil {
 		msg := fmt.Sprintf("cannot fetch message threads with owner [%s] and params [%+#v]", owner, params)
@@ -147,3 +153,76 @@ func (repository *gormMessageThreadRepository) Index(ctx context.Context, userID
 
 	return threads, nil
 }
+
+// AutoArchive archives the threads which had no activity for the entities.User.MessageThreadAutoArchiveDays of their user
+func (repository *gormMessageThreadRepository) AutoArchive(ctx context.Context, timestamp time.Time) (int64, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	query := `
+UPDATE message_threads SET is_archived = true, auto_archived_at = ?, updated_at = ?
+FROM users
+WHERE users.id = message_threads.user_id
+  AND users.message_thread_auto_archive_days > 0
+  AND (message_threads.is_archived = false OR message_threads.is_archived IS NULL)
+  AND message_threads.order_timestamp < CAST(? AS timestamptz) - users.message_thread_auto_archive_days * INTERVAL '1 day'`
+
+	result := WithoutTenantScope(repository.db.WithContext(ctx)).Exec(query, timestamp, timestamp, timestamp)
+	if result.Error != nil {
+		msg := fmt.Sprintf("cannot auto archive message threads at [%s]", timestamp)
+		return 0, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(result.Error, msg))
+	}
+
+	return result.RowsAffected, nil
+}
+
+// Unarchive the threads of an owner with the given IDs or all the archived threads of the owner when no IDs are given
+func (repository *gormMessageThreadRepository) Unarchive(ctx context.Context, userID entities.UserID, owner string, IDs []uuid.UUID) (int64, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	query := repository.db.
+		WithContext(ctx).
+		Model(&entities.MessageThread{}).
+		Where("user_id = ?", userID).
+		Where("owner = ?", owner).
+		Where("is_archived = ?", true)
+
+	if len(IDs) > 0 {
+		query.Where("id IN ?", IDs)
+	}
+
+	result := query.Updates(map[string]any{
+		"is_archived":      false,
+		"auto_archived_at": nil,
+		"updated_at":       time.Now().UTC(),
+	})
+	if result.Error != nil {
+		msg := fmt.Sprintf("cannot unarchive message threads of user [%s] with owner [%s] and IDs [%v]", userID, owner, IDs)
+		return 0, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(result.Error, msg))
+	}
+
+	return result.RowsAffected, nil
+}
+
+// ClearLastMessageContent removes the last message content of the threads whose last message is one of the given messages
+func (repository *gormMessageThreadRepository) ClearLastMessageContent(ctx context.Context, userID entities.UserID, messageIDs []uuid.UUID) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	err := repository.db.
+		WithContext(ctx).
+		Model(&entities.MessageThread{}).
+		Where("user_id = ?", userID).
+		Where("last_message_id IN ?", messageIDs).
+		Updates(map[string]any{
+			"last_message_content": "",
+			"updated_at":           time.Now().UTC(),
+		}).Error
+	if err != nil {
+		msg := fmt.Sprintf("cannot clear the last message content of threads of user [%s]", userID)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
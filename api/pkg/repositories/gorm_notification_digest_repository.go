@@ -0,0 +1,87 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/cockroachdb/cockroach-go/v2/crdb/crdbgorm"
+	"github.com/google/uuid"
+	"github.com/palantir/stacktrace"
+	"gorm.io/gorm"
+)
+
+// gormNotificationDigestRepository is responsible for persisting entities.NotificationDigestItem
+type gormNotificationDigestRepository struct {
+	logger telemetry.Logger
+	tracer telemetry.Tracer
+	db     *gorm.DB
+}
+
+// NewGormNotificationDigestRepository creates the GORM version of the NotificationDigestRepository
+func NewGormNotificationDigestRepository(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	db *gorm.DB,
+) NotificationDigestRepository {
+	return &gormNotificationDigestRepository{
+		logger: logger.WithService(fmt.Sprintf("%T", &gormNotificationDigestRepository{})),
+		tracer: tracer,
+		db:     db,
+	}
+}
+
+// Store a new entities.NotificationDigestItem and return the number of items waiting to be sent to the user
+func (repository *gormNotificationDigestRepository) Store(ctx context.Context, item *entities.NotificationDigestItem) (pending int64, err error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	err = crdbgorm.ExecuteTx(ctx, repository.db, nil, func(tx *gorm.DB) error {
+		if err = tx.Create(item).Error; err != nil {
+			return stacktrace.Propagate(err, fmt.Sprintf("cannot save notification digest item with ID [%s]", item.ID))
+		}
+		return tx.Model(item).Where("user_id = ?", item.UserID).Count(&pending).Error
+	})
+	if err != nil {
+		msg := fmt.Sprintf("cannot store notification digest item with ID [%s] for user [%s]", item.ID, item.UserID)
+		return 0, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return pending, nil
+}
+
+// Index fetches the entities.NotificationDigestItem of a user ordered by the time they occurred
+func (repository *gormNotificationDigestRepository) Index(ctx context.Context, userID entities.UserID) ([]*entities.NotificationDigestItem, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	items := make([]*entities.NotificationDigestItem, 0)
+	err := repository.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Order("occurred_at ASC").
+		Find(&items).Error
+	if err != nil {
+		msg := fmt.Sprintf("cannot fetch notification digest items for user [%s]", userID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return items, nil
+}
+
+// Delete the entities.NotificationDigestItem of a user after they have been sent
+func (repository *gormNotificationDigestRepository) Delete(ctx context.Context, userID entities.UserID, itemIDs []uuid.UUID) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	err := repository.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Where("id IN ?", itemIDs).
+		Delete(&entities.NotificationDigestItem{}).Error
+	if err != nil {
+		msg := fmt.Sprintf("cannot delete [%d] notification digest items for user [%s]", len(itemIDs), userID)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
@@ -0,0 +1,83 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/palantir/stacktrace"
+	"gorm.io/gorm"
+)
+
+// gormNotificationPreferenceRepository is responsible for persisting entities.NotificationPreference
+type gormNotificationPreferenceRepository struct {
+	logger telemetry.Logger
+	tracer telemetry.Tracer
+	db     *gorm.DB
+}
+
+// NewGormNotificationPreferenceRepository creates the GORM version of the NotificationPreferenceRepository
+func NewGormNotificationPreferenceRepository(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	db *gorm.DB,
+) NotificationPreferenceRepository {
+	return &gormNotificationPreferenceRepository{
+		logger: logger.WithService(fmt.Sprintf("%T", &gormNotificationPreferenceRepository{})),
+		tracer: tracer,
+		db:     db,
+	}
+}
+
+// Save an entities.NotificationPreference
+func (repository *gormNotificationPreferenceRepository) Save(ctx context.Context, preference *entities.NotificationPreference) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	if err := repository.db.WithContext(ctx).Save(preference).Error; err != nil {
+		msg := fmt.Sprintf("cannot save notification preference with ID [%s]", preference.ID)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+// Index fetches all the entities.NotificationPreference configured by a user
+func (repository *gormNotificationPreferenceRepository) Index(ctx context.Context, userID entities.UserID) ([]*entities.NotificationPreference, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	preferences := make([]*entities.NotificationPreference, 0)
+	if err := repository.db.WithContext(ctx).Where("user_id = ?", userID).Find(&preferences).Error; err != nil {
+		msg := fmt.Sprintf("cannot fetch notification preferences for user [%s]", userID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return preferences, nil
+}
+
+// Load the entities.NotificationPreference of a user for an event and entities.NotificationChannel
+func (repository *gormNotificationPreferenceRepository) Load(ctx context.Context, userID entities.UserID, eventType string, channel entities.NotificationChannel) (*entities.NotificationPreference, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	preference := new(entities.NotificationPreference)
+	err := repository.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Where("event_type = ?", eventType).
+		Where("channel = ?", channel).
+		First(preference).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		msg := fmt.Sprintf("notification preference for event [%s] and channel [%s] of user [%s] does not exist", eventType, channel, userID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, ErrCodeNotFound, msg))
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot load notification preference for event [%s] and channel [%s] of user [%s]", eventType, channel, userID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return preference, nil
+}
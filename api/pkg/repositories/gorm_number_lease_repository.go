@@ -0,0 +1,137 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/google/uuid"
+	"github.com/palantir/stacktrace"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+)
+
+// gormNumberLeaseRepository is responsible for persisting entities.NumberLease
+type gormNumberLeaseRepository struct {
+	logger telemetry.Logger
+	tracer telemetry.Tracer
+	db     *gorm.DB
+}
+
+// NewGormNumberLeaseRepository creates the GORM version of the NumberLeaseRepository
+func NewGormNumberLeaseRepository(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	db *gorm.DB,
+) NumberLeaseRepository {
+	return &gormNumberLeaseRepository{
+		logger: logger.WithService(fmt.Sprintf("%T", &gormNumberLeaseRepository{})),
+		tracer: tracer,
+		db:     db,
+	}
+}
+
+// Store a new entities.NumberLease
+func (repository *gormNumberLeaseRepository) Store(ctx context.Context, lease *entities.NumberLease) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	if err := repository.db.WithContext(ctx).Create(lease).Error; err != nil {
+		msg := fmt.Sprintf("cannot save number lease with ID [%s]", lease.ID)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+// Update an entities.NumberLease
+func (repository *gormNumberLeaseRepository) Update(ctx context.Context, lease *entities.NumberLease) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	if err := repository.db.WithContext(ctx).Save(lease).Error; err != nil {
+		msg := fmt.Sprintf("cannot update number lease with ID [%s]", lease.ID)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+// Load an entities.NumberLease by ID
+func (repository *gormNumberLeaseRepository) Load(ctx context.Context, userID entities.UserID, leaseID uuid.UUID) (*entities.NumberLease, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	lease := new(entities.NumberLease)
+	err := repository.db.WithContext(ctx).Where("user_id = ?", userID).Where("id = ?", leaseID).First(lease).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		msg := fmt.Sprintf("number lease with ID [%s] for user [%s] does not exist", leaseID, userID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, ErrCodeNotFound, msg))
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot load number lease with ID [%s] for user [%s]", leaseID, userID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return lease, nil
+}
+
+// LoadActiveByReference loads the active entities.NumberLease of a conversation or campaign
+func (repository *gormNumberLeaseRepository) LoadActiveByReference(ctx context.Context, userID entities.UserID, reference string) (*entities.NumberLease, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	return repository.loadActive(ctx, span, userID, "reference", reference)
+}
+
+// LoadActiveByOwner loads the active entities.NumberLease of an owner phone number
+func (repository *gormNumberLeaseRepository) LoadActiveByOwner(ctx context.Context, userID entities.UserID, owner string) (*entities.NumberLease, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	return repository.loadActive(ctx, span, userID, "owner", owner)
+}
+
+// Index entities.NumberLease of a user
+func (repository *gormNumberLeaseRepository) Index(ctx context.Context, userID entities.UserID, params IndexParams) ([]*entities.NumberLease, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	query := repository.db.WithContext(ctx).Where("user_id = ?", userID)
+	if len(params.Query) > 0 {
+		queryPattern := "%" + params.Query + "%"
+		query.Where(repository.db.Where("owner ILIKE ?", queryPattern).Or("reference ILIKE ?", queryPattern))
+	}
+
+	leases := make([]*entities.NumberLease, 0)
+	if err := query.Order("created_at DESC").Limit(params.Limit).Offset(params.Skip).Find(&leases).Error; err != nil {
+		msg := fmt.Sprintf("cannot fetch number leases for user [%s] and params [%+#v]", userID, params)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return leases, nil
+}
+
+func (repository *gormNumberLeaseRepository) loadActive(ctx context.Context, span trace.Span, userID entities.UserID, column string, value string) (*entities.NumberLease, error) {
+	lease := new(entities.NumberLease)
+	err := repository.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Where("status = ?", entities.NumberLeaseStatusActive).
+		Where(fmt.Sprintf("%s = ?", column), value).
+		First(lease).
+		Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		msg := fmt.Sprintf("active number lease with %s [%s] for user [%s] does not exist", column, value, userID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, ErrCodeNotFound, msg))
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot load active number lease with %s [%s] for user [%s]", column, value, userID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return lease, nil
+}
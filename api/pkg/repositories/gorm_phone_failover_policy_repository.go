@@ -0,0 +1,102 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/google/uuid"
+	"github.com/palantir/stacktrace"
+	"gorm.io/gorm"
+)
+
+// gormPhoneFailoverPolicyRepository is responsible for persisting entities.PhoneFailoverPolicy
+type gormPhoneFailoverPolicyRepository struct {
+	logger telemetry.Logger
+	tracer telemetry.Tracer
+	db     *gorm.DB
+}
+
+// NewGormPhoneFailoverPolicyRepository creates the GORM version of the PhoneFailoverPolicyRepository
+func NewGormPhoneFailoverPolicyRepository(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	db *gorm.DB,
+) PhoneFailoverPolicyRepository {
+	return &gormPhoneFailoverPolicyRepository{
+		logger: logger.WithService(fmt.Sprintf("%T", &gormPhoneFailoverPolicyRepository{})),
+		tracer: tracer,
+		db:     db,
+	}
+}
+
+// Save an entities.PhoneFailoverPolicy
+func (repository *gormPhoneFailoverPolicyRepository) Save(ctx context.Context, policy *entities.PhoneFailoverPolicy) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	if err := repository.db.WithContext(ctx).Save(policy).Error; err != nil {
+		msg := fmt.Sprintf("cannot save phone failover policy with ID [%s]", policy.ID)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+// Index fetches all the entities.PhoneFailoverPolicy configured by a user
+func (repository *gormPhoneFailoverPolicyRepository) Index(ctx context.Context, userID entities.UserID) ([]*entities.PhoneFailoverPolicy, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	policies := make([]*entities.PhoneFailoverPolicy, 0)
+	err := repository.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Order("created_at ASC").
+		Find(&policies).
+		Error
+	if err != nil {
+		msg := fmt.Sprintf("cannot fetch phone failover policies for user [%s]", userID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return policies, nil
+}
+
+// Load the entities.PhoneFailoverPolicy of a user for an owner
+func (repository *gormPhoneFailoverPolicyRepository) Load(ctx context.Context, userID entities.UserID, owner string) (*entities.PhoneFailoverPolicy, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	policy := new(entities.PhoneFailoverPolicy)
+	err := repository.db.WithContext(ctx).Where("user_id = ?", userID).Where("owner = ?", owner).First(policy).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		msg := fmt.Sprintf("phone failover policy with owner [%s] for user [%s] does not exist", owner, userID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, ErrCodeNotFound, msg))
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot load phone failover policy with owner [%s] for user [%s]", owner, userID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return policy, nil
+}
+
+// Delete an entities.PhoneFailoverPolicy of a user by ID
+func (repository *gormPhoneFailoverPolicyRepository) Delete(ctx context.Context, userID entities.UserID, policyID uuid.UUID) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	err := repository.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Where("id = ?", policyID).
+		Delete(&entities.PhoneFailoverPolicy{}).Error
+	if err != nil {
+		msg := fmt.Sprintf("cannot delete phone failover policy with ID [%s] for user [%s]", policyID, userID)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
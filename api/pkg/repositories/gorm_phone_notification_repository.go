@@ -40,8 +40,7 @@ func (repository gormPhoneNotificationRepository) UpdateStatus(ctx context.Conte
 	ctx, span := repository.tracer.Start(ctx)
 	defer span.End()
 
-	err := repository.db.
-		WithContext(ctx).
+	err := WithoutTenantScope(repository.db.WithContext(ctx)).
 		Model(&entities.PhoneNotification{ID: notificationID}).
 		Update("status", status).
 		Error
@@ -53,19 +52,92 @@ func (repository gormPhoneNotificationRepository) UpdateStatus(ctx context.Conte
 	return nil
 }
 
+// CountInFlight returns the number of messages notified to a phone which the phone has not finished sending
+func (repository gormPhoneNotificationRepository) CountInFlight(ctx context.Context, phoneID uuid.UUID, excludeMessageID uuid.UUID) (int64, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	var count int64
+	err := WithoutTenantScope(repository.db.WithContext(ctx)).
+		Model(&entities.PhoneNotification{}).
+		Joins("JOIN messages ON messages.id = phone_notifications.message_id").
+		Where("phone_notifications.phone_id = ?", phoneID).
+		Where("phone_notifications.status = ?", entities.PhoneNotificationStatusSent).
+		Where("phone_notifications.message_id <> ?", excludeMessageID).
+		Where("messages.status IN ?", []entities.MessageStatus{
+			entities.MessageStatusPending,
+			entities.MessageStatusScheduled,
+			entities.MessageStatusSending,
+		}).
+		Distinct("phone_notifications.message_id").
+		Count(&count).
+		Error
+	if err != nil {
+		msg := fmt.Sprintf("cannot count in flight messages for phone [%s]", phoneID)
+		return 0, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return count, nil
+}
+
+// FetchWaiting returns the waiting notifications of a phone with the highest priority first
+func (repository gormPhoneNotificationRepository) FetchWaiting(ctx context.Context, phoneID uuid.UUID, limit int) ([]entities.PhoneNotification, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	notifications := new([]entities.PhoneNotification)
+	err := WithoutTenantScope(repository.db.WithContext(ctx)).
+		Where("phone_id = ?", phoneID).
+		Where("status = ?", entities.PhoneNotificationStatusWaiting).
+		Order(priorityOrder("priority")).
+		Order("scheduled_at ASC").
+		Limit(limit).
+		Find(notifications).
+		Error
+	if err != nil {
+		msg := fmt.Sprintf("cannot fetch waiting notifications for phone [%s]", phoneID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return *notifications, nil
+}
+
+// Release a waiting entities.PhoneNotification so that it can be sent again
+func (repository gormPhoneNotificationRepository) Release(ctx context.Context, notificationID uuid.UUID) (bool, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	result := WithoutTenantScope(repository.db.WithContext(ctx)).
+		Model(&entities.PhoneNotification{}).
+		Where("id = ?", notificationID).
+		Where("status = ?", entities.PhoneNotificationStatusWaiting).
+		Updates(map[string]any{
+			"status":     entities.PhoneNotificationStatusPending,
+			"updated_at": time.Now().UTC(),
+		})
+	if result.Error != nil {
+		msg := fmt.Sprintf("cannot release notification [%s]", notificationID)
+		return false, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(result.Error, msg))
+	}
+
+	return result.RowsAffected > 0, nil
+}
+
 // Schedule a notification to be sent in the future
-func (repository gormPhoneNotificationRepository) Schedule(ctx context.Context, messagesPerMinute uint, notification *entities.PhoneNotification) error {
+func (repository gormPhoneNotificationRepository) Schedule(ctx context.Context, interval time.Duration, notification *entities.PhoneNotification) error {
 	ctx, span := repository.tracer.Start(ctx)
 	defer span.End()
 
-	if messagesPerMinute == 0 {
+	if interval == 0 {
 		return repository.insert(ctx, notification)
 	}
 
 	err := crdbgorm.ExecuteTx(ctx, repository.db, nil, func(tx *gorm.DB) error {
+		// lower priority notifications do not delay the slot, so a high priority message skips the queue of a bulk send
 		lastNotification := new(entities.PhoneNotification)
-		err := tx.WithContext(ctx).
+		err := WithoutTenantScope(tx.WithContext(ctx)).
 			Where("phone_id = ?", notification.PhoneID).
+			Where("priority IN ?", notification.Priority.AtLeast()).
 			Order("scheduled_at desc").
 			First(lastNotification).
 			Error
@@ -78,7 +150,7 @@ func (repository gormPhoneNotificationRepository) Schedule(ctx context.Context,
 		if err == nil {
 			notification.ScheduledAt = repository.maxTime(
 				time.Now().UTC(),
-				lastNotification.ScheduledAt.Add(time.Duration(60/messagesPerMinute)*time.Second),
+				lastNotification.ScheduledAt.Add(interval),
 			)
 		}
 
@@ -0,0 +1,78 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/palantir/stacktrace"
+	"gorm.io/gorm"
+)
+
+// gormPhoneRegistrationRepository is responsible for persisting entities.PhoneRegistration
+type gormPhoneRegistrationRepository struct {
+	logger telemetry.Logger
+	tracer telemetry.Tracer
+	db     *gorm.DB
+}
+
+// NewGormPhoneRegistrationRepository creates the GORM version of the PhoneRegistrationRepository
+func NewGormPhoneRegistrationRepository(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	db *gorm.DB,
+) PhoneRegistrationRepository {
+	return &gormPhoneRegistrationRepository{
+		logger: logger.WithService(fmt.Sprintf("%T", &gormPhoneRegistrationRepository{})),
+		tracer: tracer,
+		db:     db,
+	}
+}
+
+// Store a new entities.PhoneRegistration
+func (repository *gormPhoneRegistrationRepository) Store(ctx context.Context, registration *entities.PhoneRegistration) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	if err := repository.db.WithContext(ctx).Create(registration).Error; err != nil {
+		msg := fmt.Sprintf("cannot store phone registration with ID [%s] for user [%s]", registration.ID, registration.UserID)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+// Update an entities.PhoneRegistration
+func (repository *gormPhoneRegistrationRepository) Update(ctx context.Context, registration *entities.PhoneRegistration) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	if err := repository.db.WithContext(ctx).Save(registration).Error; err != nil {
+		msg := fmt.Sprintf("cannot update phone registration with ID [%s] for user [%s]", registration.ID, registration.UserID)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+// LoadByRevokeToken loads an entities.PhoneRegistration by the hash of the revoke token
+func (repository *gormPhoneRegistrationRepository) LoadByRevokeToken(ctx context.Context, tokenHash string) (*entities.PhoneRegistration, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	registration := new(entities.PhoneRegistration)
+	err := WithoutTenantScope(repository.db.WithContext(ctx)).Where("revoke_token_hash = ?", tokenHash).First(registration).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		msg := "phone registration with revoke token does not exist"
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, ErrCodeNotFound, msg))
+	}
+
+	if err != nil {
+		msg := "cannot load phone registration with revoke token"
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return registration, nil
+}
@@ -86,6 +86,42 @@ func (repository *gormPhoneRepository) Save(ctx context.Context, phone *entities
 	return nil
 }
 
+// UpdatePace stores the pace of an entities.Phone
+func (repository *gormPhoneRepository) UpdatePace(ctx context.Context, phone *entities.Phone) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	err := repository.db.WithContext(ctx).
+		Model(phone).
+		Select("pace_factor", "pace_failure_rate", "pace_send_duration_seconds", "pace_updated_at").
+		Updates(phone).
+		Error
+	if err != nil {
+		msg := fmt.Sprintf("cannot update pace of phone with ID [%s]", phone.ID)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+// UpdateClock stores the clock offset and clock samples of an entities.Phone
+func (repository *gormPhoneRepository) UpdateClock(ctx context.Context, phone *entities.Phone) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	err := repository.db.WithContext(ctx).
+		Model(phone).
+		Select("clock_offset_milliseconds", "clock_offset_updated_at", "clock_samples").
+		Updates(phone).
+		Error
+	if err != nil {
+		msg := fmt.Sprintf("cannot update clock of phone with ID [%s]", phone.ID)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
 // Load a phone based on entities.UserID and phoneNumber
 func (repository *gormPhoneRepository) Load(ctx context.Context, userID entities.UserID, phoneNumber string) (*entities.Phone, error) {
 	ctx, span := repository.tracer.Start(ctx)
@@ -0,0 +1,123 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/palantir/stacktrace"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// gormPhoneReputationRepository is responsible for persisting entities.PhoneReputation
+type gormPhoneReputationRepository struct {
+	logger telemetry.Logger
+	tracer telemetry.Tracer
+	db     *gorm.DB
+}
+
+// NewGormPhoneReputationRepository creates the GORM version of the PhoneReputationRepository
+func NewGormPhoneReputationRepository(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	db *gorm.DB,
+) PhoneReputationRepository {
+	return &gormPhoneReputationRepository{
+		logger: logger.WithService(fmt.Sprintf("%T", &gormPhoneReputationRepository{})),
+		tracer: tracer,
+		db:     db,
+	}
+}
+
+// IncrementDay adds the counts of an entities.PhoneReputationDay to the counts of the owner on the same day
+func (repository *gormPhoneReputationRepository) IncrementDay(ctx context.Context, day *entities.PhoneReputationDay) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	err := repository.db.WithContext(ctx).Clauses(
+		clause.OnConflict{
+			Columns: []clause.Column{{Name: "user_id"}, {Name: "owner"}, {Name: "date"}},
+			DoUpdates: clause.Assignments(map[string]any{
+				"sent":       gorm.Expr("phone_reputation_days.sent + ?", day.Sent),
+				"replies":    gorm.Expr("phone_reputation_days.replies + ?", day.Replies),
+				"negative":   gorm.Expr("phone_reputation_days.negative + ?", day.Negative),
+				"complaints": gorm.Expr("phone_reputation_days.complaints + ?", day.Complaints),
+				"updated_at": day.UpdatedAt,
+			}),
+		},
+	).Create(day).Error
+	if err != nil {
+		msg := fmt.Sprintf("cannot increment reputation of owner [%s] on [%s] for user [%s]", day.Owner, day.Date, day.UserID)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+// IndexDays fetches the entities.PhoneReputationDay of a user from a day until before another day, all owners are fetched when the owner is empty
+func (repository *gormPhoneReputationRepository) IndexDays(ctx context.Context, userID entities.UserID, owner string, from time.Time, to time.Time) ([]*entities.PhoneReputationDay, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	query := repository.db.WithContext(ctx).Where("user_id = ?", userID)
+	if owner != "" {
+		query = query.Where("owner = ?", owner)
+	}
+
+	days := make([]*entities.PhoneReputationDay, 0)
+	err := query.
+		Where("date >= ?", from).
+		Where("date < ?", to).
+		Order("owner ASC").
+		Order("date ASC").
+		Find(&days).Error
+	if err != nil {
+		msg := fmt.Sprintf("cannot fetch reputation of owner [%s] for user [%s] from [%s] to [%s]", owner, userID, from, to)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return days, nil
+}
+
+// Load the entities.PhoneReputation of an owner
+func (repository *gormPhoneReputationRepository) Load(ctx context.Context, userID entities.UserID, owner string) (*entities.PhoneReputation, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	reputation := new(entities.PhoneReputation)
+	err := repository.db.WithContext(ctx).Where("user_id = ?", userID).Where("owner = ?", owner).First(reputation).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		msg := fmt.Sprintf("reputation of owner [%s] for user [%s] does not exist", owner, userID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, ErrCodeNotFound, msg))
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot load reputation of owner [%s] for user [%s]", owner, userID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return reputation, nil
+}
+
+// Save creates or updates the entities.PhoneReputation of an owner
+func (repository *gormPhoneReputationRepository) Save(ctx context.Context, reputation *entities.PhoneReputation) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	err := repository.db.WithContext(ctx).Clauses(
+		clause.OnConflict{
+			Columns:   []clause.Column{{Name: "user_id"}, {Name: "owner"}},
+			DoUpdates: clause.AssignmentColumns([]string{"score", "risk", "updated_at"}),
+		},
+	).Create(reputation).Error
+	if err != nil {
+		msg := fmt.Sprintf("cannot save reputation of owner [%s] for user [%s]", reputation.Owner, reputation.UserID)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
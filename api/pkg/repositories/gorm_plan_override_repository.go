@@ -0,0 +1,112 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/google/uuid"
+	"github.com/palantir/stacktrace"
+	"gorm.io/gorm"
+)
+
+// gormPlanOverrideRepository is responsible for persisting entities.PlanOverride
+type gormPlanOverrideRepository struct {
+	logger telemetry.Logger
+	tracer telemetry.Tracer
+	db     *gorm.DB
+}
+
+// NewGormPlanOverrideRepository creates the GORM version of the PlanOverrideRepository
+func NewGormPlanOverrideRepository(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	db *gorm.DB,
+) PlanOverrideRepository {
+	return &gormPlanOverrideRepository{
+		logger: logger.WithService(fmt.Sprintf("%T", &gormPlanOverrideRepository{})),
+		tracer: tracer,
+		db:     db,
+	}
+}
+
+// Store a new entities.PlanOverride
+func (repository *gormPlanOverrideRepository) Store(ctx context.Context, override *entities.PlanOverride) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	if err := repository.db.WithContext(ctx).Create(override).Error; err != nil {
+		msg := fmt.Sprintf("cannot save plan override with ID [%s] for user [%s]", override.ID, override.UserID)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+// Index fetches the entities.PlanOverride of a user
+func (repository *gormPlanOverrideRepository) Index(ctx context.Context, userID entities.UserID, params IndexParams) ([]*entities.PlanOverride, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	overrides := make([]*entities.PlanOverride, 0)
+	err := repository.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Order("created_at DESC").
+		Limit(params.Limit).
+		Offset(params.Skip).
+		Find(&overrides).
+		Error
+	if err != nil {
+		msg := fmt.Sprintf("cannot fetch plan overrides for user [%s] and params [%+#v]", userID, params)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return overrides, nil
+}
+
+// LoadActive loads the most recent entities.PlanOverride of a user which is active at the timestamp
+func (repository *gormPlanOverrideRepository) LoadActive(ctx context.Context, userID entities.UserID, timestamp time.Time) (*entities.PlanOverride, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	override := new(entities.PlanOverride)
+	err := repository.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Where("starts_at <= ?", timestamp).
+		Where("ends_at IS NULL OR ends_at > ?", timestamp).
+		Order("created_at DESC").
+		First(override).
+		Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		msg := fmt.Sprintf("user [%s] has no active plan override at [%s]", userID, timestamp)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, ErrCodeNotFound, msg))
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot load active plan override for user [%s] at [%s]", userID, timestamp)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return override, nil
+}
+
+// Delete an entities.PlanOverride
+func (repository *gormPlanOverrideRepository) Delete(ctx context.Context, userID entities.UserID, overrideID uuid.UUID) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	err := repository.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Where("id = ?", overrideID).
+		Delete(&entities.PlanOverride{}).
+		Error
+	if err != nil {
+		msg := fmt.Sprintf("cannot delete plan override with ID [%s] for user [%s]", overrideID, userID)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
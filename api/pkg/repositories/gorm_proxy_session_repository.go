@@ -0,0 +1,165 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/google/uuid"
+	"github.com/palantir/stacktrace"
+	"gorm.io/gorm"
+)
+
+// gormProxySessionRepository is responsible for persisting entities.ProxySession
+type gormProxySessionRepository struct {
+	logger telemetry.Logger
+	tracer telemetry.Tracer
+	db     *gorm.DB
+}
+
+// NewGormProxySessionRepository creates the GORM version of the ProxySessionRepository
+func NewGormProxySessionRepository(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	db *gorm.DB,
+) ProxySessionRepository {
+	return &gormProxySessionRepository{
+		logger: logger.WithService(fmt.Sprintf("%T", &gormProxySessionRepository{})),
+		tracer: tracer,
+		db:     db,
+	}
+}
+
+// Store a new entities.ProxySession
+func (repository *gormProxySessionRepository) Store(ctx context.Context, session *entities.ProxySession) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	if err := repository.db.WithContext(ctx).Create(session).Error; err != nil {
+		msg := fmt.Sprintf("cannot save proxy session with ID [%s]", session.ID)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+// Update an entities.ProxySession
+func (repository *gormProxySessionRepository) Update(ctx context.Context, session *entities.ProxySession) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	if err := repository.db.WithContext(ctx).Save(session).Error; err != nil {
+		msg := fmt.Sprintf("cannot update proxy session with ID [%s]", session.ID)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+// Load an entities.ProxySession by ID
+func (repository *gormProxySessionRepository) Load(ctx context.Context, userID entities.UserID, sessionID uuid.UUID) (*entities.ProxySession, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	session := new(entities.ProxySession)
+	err := repository.db.WithContext(ctx).Where("user_id = ?", userID).Where("id = ?", sessionID).First(session).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		msg := fmt.Sprintf("proxy session with ID [%s] for user [%s] does not exist", sessionID, userID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, ErrCodeNotFound, msg))
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot load proxy session with ID [%s] for user [%s]", sessionID, userID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return session, nil
+}
+
+// LoadActive loads the active entities.ProxySession on an owner which has the contact as a party
+func (repository *gormProxySessionRepository) LoadActive(ctx context.Context, userID entities.UserID, owner string, contact string) (*entities.ProxySession, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	session := new(entities.ProxySession)
+	err := repository.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Where("owner = ?", owner).
+		Where("status = ?", entities.ProxySessionStatusActive).
+		Where(repository.db.Where("first_party = ?", contact).Or("second_party = ?", contact)).
+		Order("created_at DESC").
+		First(session).
+		Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		msg := fmt.Sprintf("active proxy session with owner [%s] and contact [%s] does not exist", owner, contact)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, ErrCodeNotFound, msg))
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot load active proxy session with owner [%s] and contact [%s]", owner, contact)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return session, nil
+}
+
+// Index entities.ProxySession of a user
+func (repository *gormProxySessionRepository) Index(ctx context.Context, userID entities.UserID, params IndexParams) ([]*entities.ProxySession, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	query := repository.db.WithContext(ctx).Where("user_id = ?", userID)
+	if len(params.Query) > 0 {
+		queryPattern := "%" + params.Query + "%"
+		query.Where(
+			repository.db.Where("owner ILIKE ?", queryPattern).
+				Or("first_party ILIKE ?", queryPattern).
+				Or("second_party ILIKE ?", queryPattern),
+		)
+	}
+
+	sessions := make([]*entities.ProxySession, 0)
+	if err := query.Order("created_at DESC").Limit(params.Limit).Offset(params.Skip).Find(&sessions).Error; err != nil {
+		msg := fmt.Sprintf("cannot fetch proxy sessions for user [%s] and params [%+#v]", userID, params)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return sessions, nil
+}
+
+// StoreMessage stores a new entities.ProxySessionMessage
+func (repository *gormProxySessionRepository) StoreMessage(ctx context.Context, message *entities.ProxySessionMessage) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	if err := repository.db.WithContext(ctx).Create(message).Error; err != nil {
+		msg := fmt.Sprintf("cannot save proxy session message with ID [%s]", message.ID)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+// IndexMessages fetches the transcript of an entities.ProxySession
+func (repository *gormProxySessionRepository) IndexMessages(ctx context.Context, userID entities.UserID, sessionID uuid.UUID, params IndexParams) ([]*entities.ProxySessionMessage, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	messages := make([]*entities.ProxySessionMessage, 0)
+	err := repository.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Where("session_id = ?", sessionID).
+		Order("timestamp ASC").
+		Limit(params.Limit).
+		Offset(params.Skip).
+		Find(&messages).
+		Error
+	if err != nil {
+		msg := fmt.Sprintf("cannot fetch messages for proxy session [%s] and params [%+#v]", sessionID, params)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return messages, nil
+}
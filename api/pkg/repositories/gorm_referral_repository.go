@@ -0,0 +1,223 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/palantir/stacktrace"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// errReferralNotRewarded rolls back the reward of an entities.Referral
+var errReferralNotRewarded = errors.New("referral was not rewarded")
+
+// gormReferralRepository is responsible for persisting entities.Referral
+type gormReferralRepository struct {
+	logger telemetry.Logger
+	tracer telemetry.Tracer
+	db     *gorm.DB
+}
+
+// NewGormReferralRepository creates the GORM version of the ReferralRepository
+func NewGormReferralRepository(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	db *gorm.DB,
+) ReferralRepository {
+	return &gormReferralRepository{
+		logger: logger.WithService(fmt.Sprintf("%T", &gormReferralRepository{})),
+		tracer: tracer,
+		db:     db,
+	}
+}
+
+// StoreCode stores a new entities.ReferralCode
+func (repository *gormReferralRepository) StoreCode(ctx context.Context, code *entities.ReferralCode) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	if err := repository.db.WithContext(ctx).Create(code).Error; err != nil {
+		msg := fmt.Sprintf("cannot save referral code [%s] for user [%s]", code.Code, code.UserID)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+// LoadCode loads the entities.ReferralCode of a user
+func (repository *gormReferralRepository) LoadCode(ctx context.Context, userID entities.UserID) (*entities.ReferralCode, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	code := new(entities.ReferralCode)
+	err := repository.db.WithContext(ctx).Where("user_id = ?", userID).First(code).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		msg := fmt.Sprintf("referral code for user [%s] does not exist", userID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, ErrCodeNotFound, msg))
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot load referral code for user [%s]", userID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return code, nil
+}
+
+// LoadCodeByCode loads an entities.ReferralCode by its case-insensitive code
+func (repository *gormReferralRepository) LoadCodeByCode(ctx context.Context, code string) (*entities.ReferralCode, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	referralCode := new(entities.ReferralCode)
+	err := WithoutTenantScope(repository.db.WithContext(ctx)).Where("code = ?", strings.ToUpper(code)).First(referralCode).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		msg := fmt.Sprintf("referral code [%s] does not exist", code)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, ErrCodeNotFound, msg))
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot load referral code [%s]", code)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return referralCode, nil
+}
+
+// Store a new entities.Referral
+func (repository *gormReferralRepository) Store(ctx context.Context, referral *entities.Referral) (bool, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	result := repository.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(referral)
+	if result.Error != nil {
+		msg := fmt.Sprintf("cannot save referral of user [%s] by user [%s]", referral.ReferredUserID, referral.UserID)
+		return false, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(result.Error, msg))
+	}
+
+	return result.RowsAffected > 0, nil
+}
+
+// LoadByReferredUserID loads the entities.Referral which attributed a user
+func (repository *gormReferralRepository) LoadByReferredUserID(ctx context.Context, referredUserID entities.UserID) (*entities.Referral, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	referral := new(entities.Referral)
+	err := WithoutTenantScope(repository.db.WithContext(ctx)).Where("referred_user_id = ?", referredUserID).First(referral).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		msg := fmt.Sprintf("referral of user [%s] does not exist", referredUserID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, ErrCodeNotFound, msg))
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot load referral of user [%s]", referredUserID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return referral, nil
+}
+
+// Index fetches the entities.Referral made by a user
+func (repository *gormReferralRepository) Index(ctx context.Context, userID entities.UserID, params IndexParams) ([]*entities.Referral, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	referrals := make([]*entities.Referral, 0)
+	err := repository.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Order("created_at DESC").
+		Limit(params.Limit).
+		Offset(params.Skip).
+		Find(&referrals).
+		Error
+	if err != nil {
+		msg := fmt.Sprintf("cannot fetch referrals for user [%s] and params [%+#v]", userID, params)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return referrals, nil
+}
+
+// Stats counts the referrals and rewards of a user
+func (repository *gormReferralRepository) Stats(ctx context.Context, userID entities.UserID) (*entities.ReferralStats, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	stats := new(entities.ReferralStats)
+	err := repository.db.WithContext(ctx).
+		Model(&entities.Referral{}).
+		Select(
+			"COUNT(*) AS referrals, "+
+				"COALESCE(SUM(CASE WHEN status = ? THEN 1 ELSE 0 END), 0) AS pending, "+
+				"COALESCE(SUM(CASE WHEN status = ? THEN 1 ELSE 0 END), 0) AS rewarded, "+
+				"COALESCE(SUM(CASE WHEN status = ? THEN reward_credits ELSE 0 END), 0) AS credits_earned, "+
+				"COALESCE(SUM(CASE WHEN status = ? THEN reward_days ELSE 0 END), 0) AS days_earned",
+			entities.ReferralStatusPending,
+			entities.ReferralStatusRewarded,
+			entities.ReferralStatusRewarded,
+			entities.ReferralStatusRewarded,
+		).
+		Where("user_id = ?", userID).
+		Scan(stats).
+		Error
+	if err != nil {
+		msg := fmt.Sprintf("cannot compute referral stats for user [%s]", userID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return stats, nil
+}
+
+// Reward marks a pending entities.Referral as rewarded and stores the entities.PlanOverride of the reward
+func (repository *gormReferralRepository) Reward(ctx context.Context, referral *entities.Referral, override *entities.PlanOverride) (bool, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	timestamp := time.Now().UTC()
+	err := repository.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(referral).
+			Where("user_id = ?", referral.UserID).
+			Where("status = ?", entities.ReferralStatusPending).
+			Updates(map[string]any{
+				"status":      entities.ReferralStatusRewarded,
+				"rewarded_at": timestamp,
+				"updated_at":  timestamp,
+			})
+		if result.Error != nil {
+			return stacktrace.Propagate(result.Error, fmt.Sprintf("cannot update status of referral [%s]", referral.ID))
+		}
+
+		if result.RowsAffected == 0 {
+			return errReferralNotRewarded
+		}
+
+		if override == nil {
+			return nil
+		}
+
+		if err := tx.Create(override).Error; err != nil {
+			return stacktrace.Propagate(err, fmt.Sprintf("cannot save plan override [%s] for referral [%s]", override.ID, referral.ID))
+		}
+		return nil
+	})
+	if errors.Is(err, errReferralNotRewarded) {
+		return false, nil
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot reward referral [%s] of user [%s]", referral.ID, referral.UserID)
+		return false, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	referral.Status = entities.ReferralStatusRewarded
+	referral.RewardedAt = &timestamp
+	referral.UpdatedAt = timestamp
+	return true, nil
+}
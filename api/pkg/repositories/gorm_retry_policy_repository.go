@@ -0,0 +1,96 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/palantir/stacktrace"
+	"gorm.io/gorm"
+)
+
+// gormRetryPolicyRepository is responsible for persisting entities.RetryPolicy
+type gormRetryPolicyRepository struct {
+	logger telemetry.Logger
+	tracer telemetry.Tracer
+	db     *gorm.DB
+}
+
+// NewGormRetryPolicyRepository creates the GORM version of the RetryPolicyRepository
+func NewGormRetryPolicyRepository(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	db *gorm.DB,
+) RetryPolicyRepository {
+	return &gormRetryPolicyRepository{
+		logger: logger.WithService(fmt.Sprintf("%T", &gormRetryPolicyRepository{})),
+		tracer: tracer,
+		db:     db,
+	}
+}
+
+// Save an entities.RetryPolicy
+func (repository *gormRetryPolicyRepository) Save(ctx context.Context, policy *entities.RetryPolicy) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	if err := repository.db.WithContext(ctx).Save(policy).Error; err != nil {
+		msg := fmt.Sprintf("cannot save retry policy with ID [%s]", policy.ID)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+// Index fetches all the entities.RetryPolicy configured by a user
+func (repository *gormRetryPolicyRepository) Index(ctx context.Context, userID entities.UserID) ([]*entities.RetryPolicy, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	policies := make([]*entities.RetryPolicy, 0)
+	if err := repository.db.WithContext(ctx).Where("user_id = ?", userID).Find(&policies).Error; err != nil {
+		msg := fmt.Sprintf("cannot fetch retry policies for user [%s]", userID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return policies, nil
+}
+
+// Load the entities.RetryPolicy of a user for an entities.MessageFailureCode
+func (repository *gormRetryPolicyRepository) Load(ctx context.Context, userID entities.UserID, code entities.MessageFailureCode) (*entities.RetryPolicy, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	policy := new(entities.RetryPolicy)
+	err := repository.db.WithContext(ctx).Where("user_id = ?", userID).Where("failure_code = ?", code).First(policy).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		msg := fmt.Sprintf("retry policy with failure code [%s] for user [%s] does not exist", code, userID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, ErrCodeNotFound, msg))
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot load retry policy with failure code [%s] for user [%s]", code, userID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return policy, nil
+}
+
+// Delete the entities.RetryPolicy of a user for an entities.MessageFailureCode
+func (repository *gormRetryPolicyRepository) Delete(ctx context.Context, userID entities.UserID, code entities.MessageFailureCode) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	err := repository.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Where("failure_code = ?", code).
+		Delete(&entities.RetryPolicy{}).Error
+	if err != nil {
+		msg := fmt.Sprintf("cannot delete retry policy with failure code [%s] for user [%s]", code, userID)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
@@ -0,0 +1,196 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/google/uuid"
+	"github.com/palantir/stacktrace"
+	"gorm.io/gorm"
+)
+
+// gormRuleRepository is responsible for persisting entities.Rule
+type gormRuleRepository struct {
+	logger telemetry.Logger
+	tracer telemetry.Tracer
+	db     *gorm.DB
+}
+
+// NewGormRuleRepository creates the GORM version of the RuleRepository
+func NewGormRuleRepository(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	db *gorm.DB,
+) RuleRepository {
+	return &gormRuleRepository{
+		logger: logger.WithService(fmt.Sprintf("%T", &gormRuleRepository{})),
+		tracer: tracer,
+		db:     db,
+	}
+}
+
+// Store a new entities.Rule
+func (repository *gormRuleRepository) Store(ctx context.Context, rule *entities.Rule) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	if err := repository.db.WithContext(ctx).Create(rule).Error; err != nil {
+		msg := fmt.Sprintf("cannot save rule with ID [%s]", rule.ID)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+// Update an entities.Rule
+func (repository *gormRuleRepository) Update(ctx context.Context, rule *entities.Rule) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	if err := repository.db.WithContext(ctx).Save(rule).Error; err != nil {
+		msg := fmt.Sprintf("cannot update rule with ID [%s]", rule.ID)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+// Load an entities.Rule by ID
+func (repository *gormRuleRepository) Load(ctx context.Context, userID entities.UserID, ruleID uuid.UUID) (*entities.Rule, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	rule := new(entities.Rule)
+	err := repository.db.WithContext(ctx).Where("user_id = ?", userID).Where("id = ?", ruleID).First(rule).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		msg := fmt.Sprintf("rule with ID [%s] for user [%s] does not exist", ruleID, userID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, ErrCodeNotFound, msg))
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot load rule with ID [%s] for user [%s]", ruleID, userID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return rule, nil
+}
+
+// Index entities.Rule of a user
+func (repository *gormRuleRepository) Index(ctx context.Context, userID entities.UserID, params IndexParams) ([]*entities.Rule, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	query := repository.db.WithContext(ctx).Where("user_id = ?", userID)
+	if len(params.Query) > 0 {
+		queryPattern := "%" + params.Query + "%"
+		query.Where(repository.db.Where("owner ILIKE ?", queryPattern).Or("content ILIKE ?", queryPattern))
+	}
+
+	rules := make([]*entities.Rule, 0)
+	if err := query.Order("created_at DESC").Limit(params.Limit).Offset(params.Skip).Find(&rules).Error; err != nil {
+		msg := fmt.Sprintf("cannot fetch rules for user [%s] and params [%+#v]", userID, params)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return rules, nil
+}
+
+// FetchEnabled fetches the enabled entities.Rule of an owner with the given type
+func (repository *gormRuleRepository) FetchEnabled(ctx context.Context, userID entities.UserID, owner string, ruleType entities.RuleType) ([]*entities.Rule, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	rules := make([]*entities.Rule, 0)
+	err := repository.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Where("owner = ?", owner).
+		Where("type = ?", ruleType).
+		Where("enabled = ?", true).
+		Order("created_at ASC").
+		Find(&rules).
+		Error
+	if err != nil {
+		msg := fmt.Sprintf("cannot fetch enabled rules of type [%s] for owner [%s] and user [%s]", ruleType, owner, userID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return rules, nil
+}
+
+// Delete an entities.Rule
+func (repository *gormRuleRepository) Delete(ctx context.Context, userID entities.UserID, ruleID uuid.UUID) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	err := repository.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Where("id = ?", ruleID).
+		Delete(&entities.Rule{}).Error
+	if err != nil {
+		msg := fmt.Sprintf("cannot delete rule with ID [%s] and userID [%s]", ruleID, userID)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+// StoreExecution stores a new entities.RuleExecution
+func (repository *gormRuleRepository) StoreExecution(ctx context.Context, execution *entities.RuleExecution) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	if err := repository.db.WithContext(ctx).Create(execution).Error; err != nil {
+		msg := fmt.Sprintf("cannot save execution with ID [%s] for rule [%s]", execution.ID, execution.RuleID)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+// HasExecution checks if an entities.Rule has been executed for a contact on a day
+func (repository *gormRuleRepository) HasExecution(ctx context.Context, ruleID uuid.UUID, contact string, day string) (bool, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	var exists bool
+	err := WithoutTenantScope(repository.db.WithContext(ctx)).
+		Model(&entities.RuleExecution{}).
+		Select("count(*) > 0").
+		Where("rule_id = ?", ruleID).
+		Where("contact = ?", contact).
+		Where("day = ?", day).
+		Find(&exists).
+		Error
+	if err != nil {
+		msg := fmt.Sprintf("cannot check execution of rule [%s] for contact [%s] on day [%s]", ruleID, contact, day)
+		return false, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return exists, nil
+}
+
+// HasExecutionSince checks if an entities.Rule has been executed for a contact after a timestamp
+func (repository *gormRuleRepository) HasExecutionSince(ctx context.Context, ruleID uuid.UUID, contact string, since time.Time) (bool, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	var exists bool
+	err := WithoutTenantScope(repository.db.WithContext(ctx)).
+		Model(&entities.RuleExecution{}).
+		Select("count(*) > 0").
+		Where("rule_id = ?", ruleID).
+		Where("contact = ?", contact).
+		Where("created_at > ?", since).
+		Find(&exists).
+		Error
+	if err != nil {
+		msg := fmt.Sprintf("cannot check execution of rule [%s] for contact [%s] since [%s]", ruleID, contact, since)
+		return false, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return exists, nil
+}
@@ -0,0 +1,112 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/google/uuid"
+	"github.com/palantir/stacktrace"
+	"gorm.io/gorm"
+)
+
+// gormSavedFilterRepository is responsible for persisting entities.SavedFilter
+type gormSavedFilterRepository struct {
+	logger telemetry.Logger
+	tracer telemetry.Tracer
+	db     *gorm.DB
+}
+
+// NewGormSavedFilterRepository creates the GORM version of the SavedFilterRepository
+func NewGormSavedFilterRepository(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	db *gorm.DB,
+) SavedFilterRepository {
+	return &gormSavedFilterRepository{
+		logger: logger.WithService(fmt.Sprintf("%T", &gormSavedFilterRepository{})),
+		tracer: tracer,
+		db:     db,
+	}
+}
+
+// Store a new entities.SavedFilter
+func (repository *gormSavedFilterRepository) Store(ctx context.Context, filter *entities.SavedFilter) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	if err := repository.db.WithContext(ctx).Create(filter).Error; err != nil {
+		msg := fmt.Sprintf("cannot save saved filter with ID [%s]", filter.ID)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+// Update an entities.SavedFilter
+func (repository *gormSavedFilterRepository) Update(ctx context.Context, filter *entities.SavedFilter) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	if err := repository.db.WithContext(ctx).Save(filter).Error; err != nil {
+		msg := fmt.Sprintf("cannot update saved filter with ID [%s]", filter.ID)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+// Load an entities.SavedFilter by ID
+func (repository *gormSavedFilterRepository) Load(ctx context.Context, userID entities.UserID, savedFilterID uuid.UUID) (*entities.SavedFilter, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	filter := new(entities.SavedFilter)
+	err := repository.db.WithContext(ctx).Where("user_id = ?", userID).Where("id = ?", savedFilterID).First(filter).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		msg := fmt.Sprintf("saved filter with ID [%s] for user [%s] does not exist", savedFilterID, userID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, ErrCodeNotFound, msg))
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot load saved filter with ID [%s] for user [%s]", savedFilterID, userID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return filter, nil
+}
+
+// Index fetches all the entities.SavedFilter of a user ordered by position
+func (repository *gormSavedFilterRepository) Index(ctx context.Context, userID entities.UserID) ([]*entities.SavedFilter, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	filters := make([]*entities.SavedFilter, 0)
+	err := repository.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Order("position ASC").
+		Order("created_at ASC").
+		Find(&filters).
+		Error
+	if err != nil {
+		msg := fmt.Sprintf("cannot fetch saved filters for user [%s]", userID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return filters, nil
+}
+
+// Delete an entities.SavedFilter
+func (repository *gormSavedFilterRepository) Delete(ctx context.Context, userID entities.UserID, savedFilterID uuid.UUID) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	if err := repository.db.WithContext(ctx).Where("user_id = ?", userID).Where("id = ?", savedFilterID).Delete(&entities.SavedFilter{}).Error; err != nil {
+		msg := fmt.Sprintf("cannot delete saved filter with ID [%s] and userID [%s]", savedFilterID, userID)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
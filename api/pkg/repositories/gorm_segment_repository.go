@@ -0,0 +1,111 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/google/uuid"
+	"github.com/palantir/stacktrace"
+	"gorm.io/gorm"
+)
+
+// gormSegmentRepository is responsible for persisting entities.Segment
+type gormSegmentRepository struct {
+	logger telemetry.Logger
+	tracer telemetry.Tracer
+	db     *gorm.DB
+}
+
+// NewGormSegmentRepository creates the GORM version of the SegmentRepository
+func NewGormSegmentRepository(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	db *gorm.DB,
+) SegmentRepository {
+	return &gormSegmentRepository{
+		logger: logger.WithService(fmt.Sprintf("%T", &gormSegmentRepository{})),
+		tracer: tracer,
+		db:     db,
+	}
+}
+
+// Store a new entities.Segment
+func (repository *gormSegmentRepository) Store(ctx context.Context, segment *entities.Segment) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	if err := repository.db.WithContext(ctx).Create(segment).Error; err != nil {
+		msg := fmt.Sprintf("cannot save segment with ID [%s]", segment.ID)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+// Update an entities.Segment
+func (repository *gormSegmentRepository) Update(ctx context.Context, segment *entities.Segment) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	if err := repository.db.WithContext(ctx).Save(segment).Error; err != nil {
+		msg := fmt.Sprintf("cannot update segment with ID [%s]", segment.ID)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+// Load an entities.Segment by ID
+func (repository *gormSegmentRepository) Load(ctx context.Context, userID entities.UserID, segmentID uuid.UUID) (*entities.Segment, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	segment := new(entities.Segment)
+	err := repository.db.WithContext(ctx).Where("user_id = ?", userID).Where("id = ?", segmentID).First(segment).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		msg := fmt.Sprintf("segment with ID [%s] for user [%s] does not exist", segmentID, userID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, ErrCodeNotFound, msg))
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot load segment with ID [%s] for user [%s]", segmentID, userID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return segment, nil
+}
+
+// Index entities.Segment of a user
+func (repository *gormSegmentRepository) Index(ctx context.Context, userID entities.UserID, params IndexParams) ([]*entities.Segment, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	query := repository.db.WithContext(ctx).Where("user_id = ?", userID)
+	if len(params.Query) > 0 {
+		query.Where("name ILIKE ?", "%"+params.Query+"%")
+	}
+
+	segments := make([]*entities.Segment, 0)
+	if err := query.Order("created_at DESC").Limit(params.Limit).Offset(params.Skip).Find(&segments).Error; err != nil {
+		msg := fmt.Sprintf("cannot fetch segments for user [%s] and params [%+#v]", userID, params)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return segments, nil
+}
+
+// Delete an entities.Segment
+func (repository *gormSegmentRepository) Delete(ctx context.Context, userID entities.UserID, segmentID uuid.UUID) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	if err := repository.db.WithContext(ctx).Where("user_id = ?", userID).Where("id = ?", segmentID).Delete(&entities.Segment{}).Error; err != nil {
+		msg := fmt.Sprintf("cannot delete segment with ID [%s] and userID [%s]", segmentID, userID)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
@@ -0,0 +1,243 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/cockroachdb/cockroach-go/v2/crdb/crdbgorm"
+	"github.com/google/uuid"
+	"github.com/palantir/stacktrace"
+	"gorm.io/gorm"
+)
+
+// gormSubscriptionRepository is responsible for persisting entities.SubscriptionKeyword and entities.Subscription
+type gormSubscriptionRepository struct {
+	logger telemetry.Logger
+	tracer telemetry.Tracer
+	db     *gorm.DB
+}
+
+// NewGormSubscriptionRepository creates the GORM version of the SubscriptionRepository
+func NewGormSubscriptionRepository(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	db *gorm.DB,
+) SubscriptionRepository {
+	return &gormSubscriptionRepository{
+		logger: logger.WithService(fmt.Sprintf("%T", &gormSubscriptionRepository{})),
+		tracer: tracer,
+		db:     db,
+	}
+}
+
+// StoreKeyword stores a new entities.SubscriptionKeyword
+func (repository *gormSubscriptionRepository) StoreKeyword(ctx context.Context, keyword *entities.SubscriptionKeyword) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	if err := repository.db.WithContext(ctx).Create(keyword).Error; err != nil {
+		msg := fmt.Sprintf("cannot save subscription keyword with ID [%s]", keyword.ID)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+// UpdateKeyword updates an entities.SubscriptionKeyword
+func (repository *gormSubscriptionRepository) UpdateKeyword(ctx context.Context, keyword *entities.SubscriptionKeyword) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	if err := repository.db.WithContext(ctx).Save(keyword).Error; err != nil {
+		msg := fmt.Sprintf("cannot update subscription keyword with ID [%s]", keyword.ID)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+// LoadKeyword loads an entities.SubscriptionKeyword by ID
+func (repository *gormSubscriptionRepository) LoadKeyword(ctx context.Context, userID entities.UserID, keywordID uuid.UUID) (*entities.SubscriptionKeyword, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	keyword := new(entities.SubscriptionKeyword)
+	err := repository.db.WithContext(ctx).Where("user_id = ?", userID).Where("id = ?", keywordID).First(keyword).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		msg := fmt.Sprintf("subscription keyword with ID [%s] for user [%s] does not exist", keywordID, userID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, ErrCodeNotFound, msg))
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot load subscription keyword with ID [%s] for user [%s]", keywordID, userID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return keyword, nil
+}
+
+// FindKeyword loads the enabled entities.SubscriptionKeyword of an owner which matches the keyword
+func (repository *gormSubscriptionRepository) FindKeyword(ctx context.Context, userID entities.UserID, owner string, keyword string) (*entities.SubscriptionKeyword, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	result := new(entities.SubscriptionKeyword)
+	err := repository.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Where("owner = ?", owner).
+		Where("keyword = ?", keyword).
+		Where("enabled = ?", true).
+		First(result).
+		Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		msg := fmt.Sprintf("subscription keyword [%s] for owner [%s] does not exist", keyword, owner)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, ErrCodeNotFound, msg))
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot load subscription keyword [%s] for owner [%s]", keyword, owner)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return result, nil
+}
+
+// IndexKeywords fetches the entities.SubscriptionKeyword of a user
+func (repository *gormSubscriptionRepository) IndexKeywords(ctx context.Context, userID entities.UserID, params IndexParams) ([]*entities.SubscriptionKeyword, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	query := repository.db.WithContext(ctx).Where("user_id = ?", userID)
+	if len(params.Query) > 0 {
+		queryPattern := "%" + params.Query + "%"
+		query.Where("keyword ILIKE ? OR list ILIKE ? OR owner ILIKE ?", queryPattern, queryPattern, queryPattern)
+	}
+
+	keywords := make([]*entities.SubscriptionKeyword, 0)
+	if err := query.Order("created_at DESC").Limit(params.Limit).Offset(params.Skip).Find(&keywords).Error; err != nil {
+		msg := fmt.Sprintf("cannot fetch subscription keywords for user [%s] and params [%+#v]", userID, params)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return keywords, nil
+}
+
+// DeleteKeyword deletes an entities.SubscriptionKeyword and its entities.Subscription
+func (repository *gormSubscriptionRepository) DeleteKeyword(ctx context.Context, userID entities.UserID, keywordID uuid.UUID) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	err := crdbgorm.ExecuteTx(ctx, repository.db, nil, func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Where("keyword_id = ?", keywordID).Delete(&entities.Subscription{}).Error; err != nil {
+			return stacktrace.Propagate(err, fmt.Sprintf("cannot delete subscriptions with keyword ID [%s]", keywordID))
+		}
+		return tx.Where("user_id = ?", userID).Where("id = ?", keywordID).Delete(&entities.SubscriptionKeyword{}).Error
+	})
+	if err != nil {
+		msg := fmt.Sprintf("cannot delete subscription keyword with ID [%s] and userID [%s]", keywordID, userID)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+// Store a new entities.Subscription
+func (repository *gormSubscriptionRepository) Store(ctx context.Context, subscription *entities.Subscription) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	if err := repository.db.WithContext(ctx).Create(subscription).Error; err != nil {
+		msg := fmt.Sprintf("cannot save subscription with ID [%s]", subscription.ID)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+// Update an entities.Subscription
+func (repository *gormSubscriptionRepository) Update(ctx context.Context, subscription *entities.Subscription) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	if err := repository.db.WithContext(ctx).Save(subscription).Error; err != nil {
+		msg := fmt.Sprintf("cannot update subscription with ID [%s]", subscription.ID)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+// Load the entities.Subscription of a contact to an entities.SubscriptionKeyword
+func (repository *gormSubscriptionRepository) Load(ctx context.Context, userID entities.UserID, keywordID uuid.UUID, contact string) (*entities.Subscription, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	subscription := new(entities.Subscription)
+	err := repository.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Where("keyword_id = ?", keywordID).
+		Where("contact = ?", contact).
+		First(subscription).
+		Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		msg := fmt.Sprintf("subscription of contact [%s] to keyword [%s] does not exist", contact, keywordID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, ErrCodeNotFound, msg))
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot load subscription of contact [%s] to keyword [%s]", contact, keywordID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return subscription, nil
+}
+
+// FetchPending fetches the pending entities.Subscription of a contact on an owner phone
+func (repository *gormSubscriptionRepository) FetchPending(ctx context.Context, userID entities.UserID, owner string, contact string) ([]*entities.Subscription, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	subscriptions := make([]*entities.Subscription, 0)
+	err := repository.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Where("owner = ?", owner).
+		Where("contact = ?", contact).
+		Where("status = ?", entities.SubscriptionStatusPending).
+		Find(&subscriptions).
+		Error
+	if err != nil {
+		msg := fmt.Sprintf("cannot fetch pending subscriptions of contact [%s] on owner [%s]", contact, owner)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return subscriptions, nil
+}
+
+// Index fetches the entities.Subscription of a user
+func (repository *gormSubscriptionRepository) Index(ctx context.Context, userID entities.UserID, params SubscriptionIndexParams) ([]*entities.Subscription, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	query := repository.db.WithContext(ctx).Where("user_id = ?", userID)
+	if params.KeywordID != nil {
+		query.Where("keyword_id = ?", *params.KeywordID)
+	}
+	if params.Status != nil {
+		query.Where("status = ?", *params.Status)
+	}
+	if len(params.Query) > 0 {
+		queryPattern := "%" + params.Query + "%"
+		query.Where("contact ILIKE ? OR list ILIKE ?", queryPattern, queryPattern)
+	}
+
+	subscriptions := make([]*entities.Subscription, 0)
+	if err := query.Order("requested_at DESC").Limit(params.Limit).Offset(params.Skip).Find(&subscriptions).Error; err != nil {
+		msg := fmt.Sprintf("cannot fetch subscriptions for user [%s] and params [%+#v]", userID, params)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return subscriptions, nil
+}
@@ -0,0 +1,242 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/cockroachdb/cockroach-go/v2/crdb/crdbgorm"
+	"github.com/google/uuid"
+	"github.com/palantir/stacktrace"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// gormTagRepository is responsible for persisting entities.Tag.
+// Contacts and message threads are in the default database and messages are in the database of the region of the user.
+type gormTagRepository struct {
+	logger   telemetry.Logger
+	tracer   telemetry.Tracer
+	db       *gorm.DB
+	resolver DatabaseResolver
+}
+
+// NewGormTagRepository creates the GORM version of the TagRepository
+func NewGormTagRepository(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	db *gorm.DB,
+	resolver DatabaseResolver,
+) TagRepository {
+	return &gormTagRepository{
+		logger:   logger.WithService(fmt.Sprintf("%T", &gormTagRepository{})),
+		tracer:   tracer,
+		db:       db,
+		resolver: resolver,
+	}
+}
+
+// Store a new entities.Tag
+func (repository *gormTagRepository) Store(ctx context.Context, tag *entities.Tag) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	if err := repository.db.WithContext(ctx).Create(tag).Error; err != nil {
+		msg := fmt.Sprintf("cannot save tag with ID [%s]", tag.ID)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+// Update an entities.Tag
+func (repository *gormTagRepository) Update(ctx context.Context, tag *entities.Tag) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	if err := repository.db.WithContext(ctx).Save(tag).Error; err != nil {
+		msg := fmt.Sprintf("cannot update tag with ID [%s]", tag.ID)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+// Load an entities.Tag by ID
+func (repository *gormTagRepository) Load(ctx context.Context, userID entities.UserID, tagID uuid.UUID) (*entities.Tag, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	tag := new(entities.Tag)
+	err := repository.db.WithContext(ctx).Where("user_id = ?", userID).Where("id = ?", tagID).First(tag).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		msg := fmt.Sprintf("tag with ID [%s] for user [%s] does not exist", tagID, userID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, ErrCodeNotFound, msg))
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot load tag with ID [%s] for user [%s]", tagID, userID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return tag, nil
+}
+
+// LoadByName loads an entities.Tag of a user by name
+func (repository *gormTagRepository) LoadByName(ctx context.Context, userID entities.UserID, name string) (*entities.Tag, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	tag := new(entities.Tag)
+	err := repository.db.WithContext(ctx).Where("user_id = ?", userID).Where("name = ?", name).First(tag).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		msg := fmt.Sprintf("tag with name [%s] for user [%s] does not exist", name, userID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, ErrCodeNotFound, msg))
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot load tag with name [%s] for user [%s]", name, userID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return tag, nil
+}
+
+// Index fetches all the entities.Tag of a user ordered by name
+func (repository *gormTagRepository) Index(ctx context.Context, userID entities.UserID) ([]*entities.Tag, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	tags := make([]*entities.Tag, 0)
+	if err := repository.db.WithContext(ctx).Where("user_id = ?", userID).Order("name ASC").Find(&tags).Error; err != nil {
+		msg := fmt.Sprintf("cannot fetch tags for user [%s]", userID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return tags, nil
+}
+
+// Delete an entities.Tag, the children of the tag are moved to the parent of the tag
+func (repository *gormTagRepository) Delete(ctx context.Context, tag *entities.Tag) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	err := crdbgorm.ExecuteTx(ctx, repository.db, nil, func(tx *gorm.DB) error {
+		err := tx.WithContext(ctx).
+			Model(&entities.Tag{}).
+			Where("user_id = ?", tag.UserID).
+			Where("parent_id = ?", tag.ID).
+			Updates(map[string]any{"parent_id": tag.ParentID, "updated_at": time.Now().UTC()}).
+			Error
+		if err != nil {
+			return stacktrace.Propagate(err, fmt.Sprintf("cannot move the children of tag with ID [%s]", tag.ID))
+		}
+		return tx.WithContext(ctx).Where("user_id = ?", tag.UserID).Where("id = ?", tag.ID).Delete(&entities.Tag{}).Error
+	})
+	if err != nil {
+		msg := fmt.Sprintf("cannot delete tag with ID [%s] and userID [%s]", tag.ID, tag.UserID)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+// Rename replaces a tag name on the contacts, messages and message threads of a user
+func (repository *gormTagRepository) Rename(ctx context.Context, userID entities.UserID, from string, to string) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	// array_remove drops the new name first so that an item which already has both names keeps it only once
+	query := "UPDATE ? SET tags = array_replace(array_remove(tags, ?), ?, ?) WHERE user_id = ? AND ? = ANY(tags)"
+	err := repository.exec(ctx, userID, func(db *gorm.DB, table string) error {
+		return db.WithContext(ctx).Exec(query, clause.Table{Name: table}, to, from, to, userID, from).Error
+	})
+	if err != nil {
+		msg := fmt.Sprintf("cannot rename tag [%s] to [%s] for user [%s]", from, to, userID)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+// Remove removes a tag name from the contacts, messages and message threads of a user
+func (repository *gormTagRepository) Remove(ctx context.Context, userID entities.UserID, name string) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	query := "UPDATE ? SET tags = array_remove(tags, ?) WHERE user_id = ? AND ? = ANY(tags)"
+	err := repository.exec(ctx, userID, func(db *gorm.DB, table string) error {
+		return db.WithContext(ctx).Exec(query, clause.Table{Name: table}, name, userID, name).Error
+	})
+	if err != nil {
+		msg := fmt.Sprintf("cannot remove tag [%s] for user [%s]", name, userID)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+// Usage counts the contacts, messages and message threads of a user with each tag name
+func (repository *gormTagRepository) Usage(ctx context.Context, userID entities.UserID) (map[string]*entities.TagUsage, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	usage := map[string]*entities.TagUsage{}
+	err := repository.exec(ctx, userID, func(db *gorm.DB, table string) error {
+		var rows []struct {
+			Name  string
+			Count int64
+		}
+
+		query := "SELECT tag AS name, COUNT(*) AS count FROM ?, unnest(tags) AS tag WHERE user_id = ? GROUP BY tag"
+		if err := db.WithContext(ctx).Raw(query, clause.Table{Name: table}, userID).Scan(&rows).Error; err != nil {
+			return err
+		}
+
+		for _, row := range rows {
+			if _, ok := usage[row.Name]; !ok {
+				usage[row.Name] = &entities.TagUsage{Name: row.Name}
+			}
+			switch table {
+			case "contacts":
+				usage[row.Name].Contacts = row.Count
+			case "messages":
+				usage[row.Name].Messages = row.Count
+			case "message_threads":
+				usage[row.Name].MessageThreads = row.Count
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		msg := fmt.Sprintf("cannot count the usage of the tags of user [%s]", userID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return usage, nil
+}
+
+// exec runs a query on each table with tags in the database which stores the table for the user
+func (repository *gormTagRepository) exec(ctx context.Context, userID entities.UserID, query func(db *gorm.DB, table string) error) error {
+	messagesDB, err := repository.resolver.Resolve(ctx, userID)
+	if err != nil {
+		return stacktrace.Propagate(err, fmt.Sprintf("cannot resolve database of user [%s]", userID))
+	}
+
+	tables := map[string]*gorm.DB{
+		"contacts":        repository.db,
+		"message_threads": repository.db,
+		"messages":        messagesDB,
+	}
+
+	for table, db := range tables {
+		if err = query(db, table); err != nil {
+			return stacktrace.Propagate(err, fmt.Sprintf("cannot query the tags in table [%s]", table))
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,191 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/google/uuid"
+	"github.com/palantir/stacktrace"
+	"gorm.io/gorm"
+)
+
+// gormTemplateRepository is responsible for persisting entities.Template
+type gormTemplateRepository struct {
+	logger telemetry.Logger
+	tracer telemetry.Tracer
+	db     *gorm.DB
+}
+
+// NewGormTemplateRepository creates the GORM version of the TemplateRepository
+func NewGormTemplateRepository(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	db *gorm.DB,
+) TemplateRepository {
+	return &gormTemplateRepository{
+		logger: logger.WithService(fmt.Sprintf("%T", &gormTemplateRepository{})),
+		tracer: tracer,
+		db:     db,
+	}
+}
+
+// Store a new entities.Template
+func (repository *gormTemplateRepository) Store(ctx context.Context, template *entities.Template) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	if err := repository.db.WithContext(ctx).Create(template).Error; err != nil {
+		msg := fmt.Sprintf("cannot save template with ID [%s]", template.ID)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+// Update an entities.Template
+func (repository *gormTemplateRepository) Update(ctx context.Context, template *entities.Template) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	if err := repository.db.WithContext(ctx).Save(template).Error; err != nil {
+		msg := fmt.Sprintf("cannot update template with ID [%s]", template.ID)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+// Load an entities.Template of a user by ID
+func (repository *gormTemplateRepository) Load(ctx context.Context, userID entities.UserID, templateID uuid.UUID) (*entities.Template, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	template := new(entities.Template)
+	err := repository.db.WithContext(ctx).Where("user_id = ?", userID).Where("id = ?", templateID).First(template).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		msg := fmt.Sprintf("template with ID [%s] for user [%s] does not exist", templateID, userID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, ErrCodeNotFound, msg))
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot load template with ID [%s] for user [%s]", templateID, userID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return template, nil
+}
+
+// LoadByID loads an entities.Template of any user
+func (repository *gormTemplateRepository) LoadByID(ctx context.Context, templateID uuid.UUID) (*entities.Template, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	template := new(entities.Template)
+	err := WithoutTenantScope(repository.db.WithContext(ctx)).Where("id = ?", templateID).First(template).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		msg := fmt.Sprintf("template with ID [%s] does not exist", templateID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, ErrCodeNotFound, msg))
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot load template with ID [%s]", templateID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return template, nil
+}
+
+// Index entities.Template of a user
+func (repository *gormTemplateRepository) Index(ctx context.Context, userID entities.UserID, params IndexParams) ([]*entities.Template, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	query := repository.db.WithContext(ctx).Where("user_id = ?", userID)
+	if len(params.Query) > 0 {
+		queryPattern := "%" + params.Query + "%"
+		query.Where(repository.db.Where("name ILIKE ?", queryPattern).Or("content ILIKE ?", queryPattern).Or("status ILIKE ?", queryPattern))
+	}
+
+	templates := make([]*entities.Template, 0)
+	if err := query.Order("created_at DESC").Limit(params.Limit).Offset(params.Skip).Find(&templates).Error; err != nil {
+		msg := fmt.Sprintf("cannot fetch templates for user [%s] and params [%+#v]", userID, params)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return templates, nil
+}
+
+// IndexByStatus fetches the entities.Template of all users with a status, the oldest submission is first
+func (repository *gormTemplateRepository) IndexByStatus(ctx context.Context, status entities.TemplateStatus, params IndexParams) ([]*entities.Template, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	query := WithoutTenantScope(repository.db.WithContext(ctx)).Where("status = ?", status)
+	if len(params.Query) > 0 {
+		queryPattern := "%" + params.Query + "%"
+		query.Where(repository.db.Where("name ILIKE ?", queryPattern).Or("content ILIKE ?", queryPattern).Or("user_id ILIKE ?", queryPattern))
+	}
+
+	templates := make([]*entities.Template, 0)
+	if err := query.Order("updated_at ASC").Limit(params.Limit).Offset(params.Skip).Find(&templates).Error; err != nil {
+		msg := fmt.Sprintf("cannot fetch templates with status [%s] and params [%+#v]", status, params)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return templates, nil
+}
+
+// Delete an entities.Template and its entities.TemplateReview
+func (repository *gormTemplateRepository) Delete(ctx context.Context, userID entities.UserID, templateID uuid.UUID) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	err := repository.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Where("template_id = ?", templateID).Delete(&entities.TemplateReview{}).Error; err != nil {
+			return stacktrace.Propagate(err, fmt.Sprintf("cannot delete reviews of template with ID [%s]", templateID))
+		}
+		return tx.Where("user_id = ?", userID).Where("id = ?", templateID).Delete(&entities.Template{}).Error
+	})
+	if err != nil {
+		msg := fmt.Sprintf("cannot delete template with ID [%s] and userID [%s]", templateID, userID)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+// StoreReview stores an entities.TemplateReview
+func (repository *gormTemplateRepository) StoreReview(ctx context.Context, review *entities.TemplateReview) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	if err := repository.db.WithContext(ctx).Create(review).Error; err != nil {
+		msg := fmt.Sprintf("cannot save review with ID [%s] of template [%s]", review.ID, review.TemplateID)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+// IndexReviews fetches the entities.TemplateReview of a template
+func (repository *gormTemplateRepository) IndexReviews(ctx context.Context, userID entities.UserID, templateID uuid.UUID) ([]*entities.TemplateReview, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	reviews := make([]*entities.TemplateReview, 0)
+	err := repository.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Where("template_id = ?", templateID).
+		Order("created_at DESC").
+		Find(&reviews).
+		Error
+	if err != nil {
+		msg := fmt.Sprintf("cannot fetch reviews of template [%s] for user [%s]", templateID, userID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return reviews, nil
+}
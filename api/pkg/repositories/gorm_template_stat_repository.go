@@ -0,0 +1,81 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/google/uuid"
+	"github.com/palantir/stacktrace"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// gormTemplateStatRepository is responsible for persisting entities.TemplateStat
+type gormTemplateStatRepository struct {
+	logger telemetry.Logger
+	tracer telemetry.Tracer
+	db     *gorm.DB
+}
+
+// NewGormTemplateStatRepository creates the GORM version of the TemplateStatRepository
+func NewGormTemplateStatRepository(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	db *gorm.DB,
+) TemplateStatRepository {
+	return &gormTemplateStatRepository{
+		logger: logger.WithService(fmt.Sprintf("%T", &gormTemplateStatRepository{})),
+		tracer: tracer,
+		db:     db,
+	}
+}
+
+// Increment adds the counts of an entities.TemplateStat to the stat of the template on the same day
+func (repository *gormTemplateStatRepository) Increment(ctx context.Context, stat *entities.TemplateStat) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	err := repository.db.WithContext(ctx).Clauses(
+		clause.OnConflict{
+			Columns: []clause.Column{{Name: "template_id"}, {Name: "date"}},
+			DoUpdates: clause.Assignments(map[string]any{
+				"sent":       gorm.Expr("template_stats.sent + ?", stat.Sent),
+				"delivered":  gorm.Expr("template_stats.delivered + ?", stat.Delivered),
+				"failed":     gorm.Expr("template_stats.failed + ?", stat.Failed),
+				"replies":    gorm.Expr("template_stats.replies + ?", stat.Replies),
+				"opt_outs":   gorm.Expr("template_stats.opt_outs + ?", stat.OptOuts),
+				"updated_at": stat.UpdatedAt,
+			}),
+		},
+	).Create(stat).Error
+	if err != nil {
+		msg := fmt.Sprintf("cannot increment stat of template [%s] on [%s]", stat.TemplateID, stat.Date)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+// Index fetches the entities.TemplateStat of a template from a day until before another day
+func (repository *gormTemplateStatRepository) Index(ctx context.Context, userID entities.UserID, templateID uuid.UUID, from time.Time, to time.Time) ([]*entities.TemplateStat, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	stats := make([]*entities.TemplateStat, 0)
+	err := repository.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Where("template_id = ?", templateID).
+		Where("date >= ?", from).
+		Where("date < ?", to).
+		Order("date ASC").
+		Find(&stats).Error
+	if err != nil {
+		msg := fmt.Sprintf("cannot fetch stats of template [%s] from [%s] to [%s]", templateID, from, to)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return stats, nil
+}
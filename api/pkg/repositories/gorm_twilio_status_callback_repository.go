@@ -0,0 +1,66 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/google/uuid"
+	"github.com/palantir/stacktrace"
+	"gorm.io/gorm"
+)
+
+// gormTwilioStatusCallbackRepository is responsible for persisting entities.TwilioStatusCallback
+type gormTwilioStatusCallbackRepository struct {
+	logger telemetry.Logger
+	tracer telemetry.Tracer
+	db     *gorm.DB
+}
+
+// NewGormTwilioStatusCallbackRepository creates the GORM version of the TwilioStatusCallbackRepository
+func NewGormTwilioStatusCallbackRepository(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	db *gorm.DB,
+) TwilioStatusCallbackRepository {
+	return &gormTwilioStatusCallbackRepository{
+		logger: logger.WithService(fmt.Sprintf("%T", &gormTwilioStatusCallbackRepository{})),
+		tracer: tracer,
+		db:     db,
+	}
+}
+
+// Store a new entities.TwilioStatusCallback
+func (repository *gormTwilioStatusCallbackRepository) Store(ctx context.Context, callback *entities.TwilioStatusCallback) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	if err := repository.db.WithContext(ctx).Create(callback).Error; err != nil {
+		msg := fmt.Sprintf("cannot save twilio status callback for message with ID [%s]", callback.MessageID)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+// Load the entities.TwilioStatusCallback of a message
+func (repository *gormTwilioStatusCallbackRepository) Load(ctx context.Context, userID entities.UserID, messageID uuid.UUID) (*entities.TwilioStatusCallback, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	callback := new(entities.TwilioStatusCallback)
+	err := repository.db.WithContext(ctx).Where("user_id = ?", userID).Where("message_id = ?", messageID).First(callback).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		msg := fmt.Sprintf("twilio status callback for message with ID [%s] and user [%s] does not exist", messageID, userID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, ErrCodeNotFound, msg))
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot load twilio status callback for message with ID [%s] and user [%s]", messageID, userID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return callback, nil
+}
@@ -0,0 +1,146 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/palantir/stacktrace"
+	"gorm.io/gorm"
+)
+
+// gormTwoFactorAuthRepository is responsible for persisting entities.TwoFactorAuth
+type gormTwoFactorAuthRepository struct {
+	logger telemetry.Logger
+	tracer telemetry.Tracer
+	db     *gorm.DB
+}
+
+// NewGormTwoFactorAuthRepository creates the GORM version of the TwoFactorAuthRepository
+func NewGormTwoFactorAuthRepository(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	db *gorm.DB,
+) TwoFactorAuthRepository {
+	return &gormTwoFactorAuthRepository{
+		logger: logger.WithService(fmt.Sprintf("%T", &gormTwoFactorAuthRepository{})),
+		tracer: tracer,
+		db:     db,
+	}
+}
+
+// Save an entities.TwoFactorAuth
+func (repository *gormTwoFactorAuthRepository) Save(ctx context.Context, auth *entities.TwoFactorAuth) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	if err := repository.db.WithContext(ctx).Save(auth).Error; err != nil {
+		msg := fmt.Sprintf("cannot save two factor auth with ID [%s] for user [%s]", auth.ID, auth.UserID)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+// Load the entities.TwoFactorAuth of a user
+func (repository *gormTwoFactorAuthRepository) Load(ctx context.Context, userID entities.UserID) (*entities.TwoFactorAuth, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	auth := new(entities.TwoFactorAuth)
+	err := repository.db.WithContext(ctx).Where("user_id = ?", userID).First(auth).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		msg := fmt.Sprintf("two factor auth for user [%s] does not exist", userID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, ErrCodeNotFound, msg))
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot load two factor auth for user [%s]", userID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return auth, nil
+}
+
+// ClaimTimeStep stores the TOTP time step of an accepted code if it is after the last accepted time step
+func (repository *gormTwoFactorAuthRepository) ClaimTimeStep(ctx context.Context, auth *entities.TwoFactorAuth, timeStep int64) (bool, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	result := repository.db.WithContext(ctx).
+		Model(&entities.TwoFactorAuth{}).
+		Where("user_id = ?", auth.UserID).
+		Where("last_time_step < ?", timeStep).
+		Updates(map[string]any{
+			"last_time_step":  timeStep,
+			"failed_attempts": 0,
+			"updated_at":      time.Now().UTC(),
+		})
+	if result.Error != nil {
+		msg := fmt.Sprintf("cannot claim TOTP time step [%d] of two factor auth with ID [%s] for user [%s]", timeStep, auth.ID, auth.UserID)
+		return false, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(result.Error, msg))
+	}
+
+	return result.RowsAffected == 1, nil
+}
+
+// ConsumeRecoveryCode removes the hash of a recovery code if it has not been used
+func (repository *gormTwoFactorAuthRepository) ConsumeRecoveryCode(ctx context.Context, auth *entities.TwoFactorAuth, hash string) (bool, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	result := repository.db.WithContext(ctx).
+		Model(&entities.TwoFactorAuth{}).
+		Where("user_id = ?", auth.UserID).
+		Where("? = ANY(recovery_codes)", hash).
+		Updates(map[string]any{
+			"recovery_codes":  gorm.Expr("array_remove(recovery_codes, ?)", hash),
+			"failed_attempts": 0,
+			"updated_at":      time.Now().UTC(),
+		})
+	if result.Error != nil {
+		msg := fmt.Sprintf("cannot consume recovery code of two factor auth with ID [%s] for user [%s]", auth.ID, auth.UserID)
+		return false, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(result.Error, msg))
+	}
+
+	return result.RowsAffected == 1, nil
+}
+
+// RecordFailedAttempt counts an invalid code and locks the entities.TwoFactorAuth until lockedUntil once maxAttempts is reached
+func (repository *gormTwoFactorAuthRepository) RecordFailedAttempt(ctx context.Context, auth *entities.TwoFactorAuth, maxAttempts int, lockedUntil time.Time) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	// the counter starts again once the entities.TwoFactorAuth is locked
+	err := repository.db.WithContext(ctx).
+		Model(&entities.TwoFactorAuth{}).
+		Where("user_id = ?", auth.UserID).
+		Updates(map[string]any{
+			"failed_attempts": gorm.Expr("CASE WHEN failed_attempts + 1 >= ? THEN 0 ELSE failed_attempts + 1 END", maxAttempts),
+			"locked_until":    gorm.Expr("CASE WHEN failed_attempts + 1 >= ? THEN ? ELSE locked_until END", maxAttempts, lockedUntil),
+			"updated_at":      time.Now().UTC(),
+		}).
+		Error
+	if err != nil {
+		msg := fmt.Sprintf("cannot record failed attempt of two factor auth with ID [%s] for user [%s]", auth.ID, auth.UserID)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+// Delete the entities.TwoFactorAuth of a user
+func (repository *gormTwoFactorAuthRepository) Delete(ctx context.Context, userID entities.UserID) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	if err := repository.db.WithContext(ctx).Where("user_id = ?", userID).Delete(&entities.TwoFactorAuth{}).Error; err != nil {
+		msg := fmt.Sprintf("cannot delete two factor auth for user [%s]", userID)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
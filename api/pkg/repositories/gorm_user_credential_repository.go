@@ -0,0 +1,99 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/palantir/stacktrace"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+)
+
+// gormUserCredentialRepository is responsible for persisting entities.UserCredential
+type gormUserCredentialRepository struct {
+	logger telemetry.Logger
+	tracer telemetry.Tracer
+	db     *gorm.DB
+}
+
+// NewGormUserCredentialRepository creates the GORM version of the UserCredentialRepository
+func NewGormUserCredentialRepository(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	db *gorm.DB,
+) UserCredentialRepository {
+	return &gormUserCredentialRepository{
+		logger: logger.WithService(fmt.Sprintf("%T", &gormUserCredentialRepository{})),
+		tracer: tracer,
+		db:     db,
+	}
+}
+
+// Store a new entities.UserCredential
+func (repository *gormUserCredentialRepository) Store(ctx context.Context, credential *entities.UserCredential) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	if err := repository.db.WithContext(ctx).Create(credential).Error; err != nil {
+		msg := fmt.Sprintf("cannot store credential with ID [%s] for user [%s]", credential.ID, credential.UserID)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+// Update an entities.UserCredential
+func (repository *gormUserCredentialRepository) Update(ctx context.Context, credential *entities.UserCredential) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	if err := repository.db.WithContext(ctx).Save(credential).Error; err != nil {
+		msg := fmt.Sprintf("cannot update credential with ID [%s] for user [%s]", credential.ID, credential.UserID)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+// LoadByEmail loads an entities.UserCredential by the email address
+func (repository *gormUserCredentialRepository) LoadByEmail(ctx context.Context, email string) (*entities.UserCredential, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	return repository.load(ctx, span, "email = ?", email)
+}
+
+// LoadByVerificationToken loads an entities.UserCredential by the hash of the email verification token
+func (repository *gormUserCredentialRepository) LoadByVerificationToken(ctx context.Context, tokenHash string) (*entities.UserCredential, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	return repository.load(ctx, span, "verification_token_hash = ?", tokenHash)
+}
+
+// LoadByResetToken loads an entities.UserCredential by the hash of the password reset token
+func (repository *gormUserCredentialRepository) LoadByResetToken(ctx context.Context, tokenHash string) (*entities.UserCredential, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	return repository.load(ctx, span, "reset_token_hash = ?", tokenHash)
+}
+
+func (repository *gormUserCredentialRepository) load(ctx context.Context, span trace.Span, query string, value string) (*entities.UserCredential, error) {
+	credential := new(entities.UserCredential)
+	err := WithoutTenantScope(repository.db.WithContext(ctx)).Where(query, value).First(credential).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		msg := fmt.Sprintf("credential with [%s] does not exist", query)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, ErrCodeNotFound, msg))
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot load credential with [%s]", query)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return credential, nil
+}
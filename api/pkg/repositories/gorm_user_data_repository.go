@@ -0,0 +1,82 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/palantir/stacktrace"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// gormUserDataRepository erases the data of an entities.User from the default database and the database of every region
+type gormUserDataRepository struct {
+	logger   telemetry.Logger
+	tracer   telemetry.Tracer
+	db       *gorm.DB
+	resolver DatabaseResolver
+}
+
+// NewGormUserDataRepository creates the GORM version of the UserDataRepository
+func NewGormUserDataRepository(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	db *gorm.DB,
+	resolver DatabaseResolver,
+) UserDataRepository {
+	return &gormUserDataRepository{
+		logger:   logger.WithService(fmt.Sprintf("%T", &gormUserDataRepository{})),
+		tracer:   tracer,
+		db:       db,
+		resolver: resolver,
+	}
+}
+
+// Erase deletes the rows of a user from every table with a user_id column so that tables which are added later are erased too
+func (repository *gormUserDataRepository) Erase(ctx context.Context, userID entities.UserID) (int64, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	count := int64(0)
+	for _, db := range repository.resolver.All() {
+		tables, err := repository.tables(ctx, db)
+		if err != nil {
+			msg := fmt.Sprintf("cannot fetch the tables which store the data of user [%s]", userID)
+			return count, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+		}
+
+		for _, table := range tables {
+			result := db.WithContext(ctx).Exec("DELETE FROM ? WHERE user_id = ?", clause.Table{Name: table}, userID)
+			if result.Error != nil {
+				msg := fmt.Sprintf("cannot delete the rows of user [%s] from table [%s]", userID, table)
+				return count, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(result.Error, msg))
+			}
+			count += result.RowsAffected
+		}
+	}
+
+	result := repository.db.WithContext(ctx).Where("id = ?", userID).Delete(&entities.User{})
+	if result.Error != nil {
+		msg := fmt.Sprintf("cannot delete user [%s]", userID)
+		return count, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(result.Error, msg))
+	}
+
+	return count + result.RowsAffected, nil
+}
+
+// tables fetches the names of the tables in a database which have a user_id column
+func (repository *gormUserDataRepository) tables(ctx context.Context, db *gorm.DB) ([]string, error) {
+	query := `
+SELECT columns.table_name FROM information_schema.columns AS columns
+JOIN information_schema.tables AS tables ON tables.table_schema = columns.table_schema AND tables.table_name = columns.table_name
+WHERE columns.column_name = ? AND columns.table_schema = current_schema() AND tables.table_type = 'BASE TABLE'
+ORDER BY columns.table_name`
+
+	tables := make([]string, 0)
+	if err := db.WithContext(ctx).Raw(query, tenancyGuardColumn).Scan(&tables).Error; err != nil {
+		return nil, stacktrace.Propagate(err, fmt.Sprintf("cannot fetch the tables with a [%s] column", tenancyGuardColumn))
+	}
+	return tables, nil
+}
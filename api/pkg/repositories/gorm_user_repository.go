@@ -189,3 +189,73 @@ func (repository *gormUserRepository) generateAPIKey(n int) (string, error) {
 	b, err := repository.generateRandomBytes(n)
 	return base64.URLEncoding.EncodeToString(b)[0:n], stacktrace.Propagate(err, "cannot generate random bytes")
 }
+
+// RotateAPIKey replaces the API key of an entities.User
+func (repository *gormUserRepository) RotateAPIKey(ctx context.Context, userID entities.UserID) (*entities.User, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	user, err := repository.Load(ctx, userID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot load user with ID [%s]", userID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
+	}
+
+	apiKey, err := repository.generateAPIKey(64)
+	if err != nil {
+		msg := fmt.Sprintf("cannot generate apiKey for user [%s]", userID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	err = repository.db.WithContext(ctx).
+		Model(user).
+		Updates(map[string]any{"api_key": apiKey, "updated_at": time.Now().UTC()}).
+		Error
+	if err != nil {
+		msg := fmt.Sprintf("cannot rotate api key of user with ID [%s]", userID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return user, nil
+}
+
+// IndexIDs fetches the IDs of all the users of the instance ordered by creation date
+func (repository *gormUserRepository) IndexIDs(ctx context.Context, params IndexParams) ([]entities.UserID, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	userIDs := make([]entities.UserID, 0)
+	err := repository.db.WithContext(ctx).
+		Model(&entities.User{}).
+		Order("created_at ASC").
+		Order("id ASC").
+		Limit(params.Limit).
+		Offset(params.Skip).
+		Pluck("id", &userIDs).
+		Error
+	if err != nil {
+		msg := fmt.Sprintf("cannot fetch user IDs with params [%+#v]", params)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return userIDs, nil
+}
+
+// FetchWithRetentionPolicy fetches the entities.User which delete or anonymize their messages after some time
+func (repository *gormUserRepository) FetchWithRetentionPolicy(ctx context.Context) ([]*entities.User, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	users := make([]*entities.User, 0)
+	err := repository.db.WithContext(ctx).
+		Where(repository.db.Where("message_retention_days > ?", 0).Or("message_anonymize_days > ?", 0)).
+		Order("created_at ASC").
+		Find(&users).
+		Error
+	if err != nil {
+		msg := "cannot fetch users with a message retention policy"
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return users, nil
+}
@@ -0,0 +1,191 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/google/uuid"
+	"github.com/palantir/stacktrace"
+	"gorm.io/gorm"
+)
+
+// gormWebhookDeliveryRepository is responsible for persisting entities.WebhookDelivery
+type gormWebhookDeliveryRepository struct {
+	logger telemetry.Logger
+	tracer telemetry.Tracer
+	db     *gorm.DB
+}
+
+// NewGormWebhookDeliveryRepository creates the GORM version of the WebhookDeliveryRepository
+func NewGormWebhookDeliveryRepository(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	db *gorm.DB,
+) WebhookDeliveryRepository {
+	return &gormWebhookDeliveryRepository{
+		logger: logger.WithService(fmt.Sprintf("%T", &gormWebhookDeliveryRepository{})),
+		tracer: tracer,
+		db:     db,
+	}
+}
+
+// Store a new entities.WebhookDelivery
+func (repository *gormWebhookDeliveryRepository) Store(ctx context.Context, delivery *entities.WebhookDelivery) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	if err := repository.db.WithContext(ctx).Create(delivery).Error; err != nil {
+		msg := fmt.Sprintf("cannot save webhook delivery with ID [%s] for webhook [%s]", delivery.ID, delivery.WebhookID)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+// Update the attempts and status of an entities.WebhookDelivery
+func (repository *gormWebhookDeliveryRepository) Update(ctx context.Context, delivery *entities.WebhookDelivery) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	if err := repository.db.WithContext(ctx).Where("user_id = ?", delivery.UserID).Save(delivery).Error; err != nil {
+		msg := fmt.Sprintf("cannot update webhook delivery with ID [%s] for user [%s]", delivery.ID, delivery.UserID)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+// Load an entities.WebhookDelivery by ID
+func (repository *gormWebhookDeliveryRepository) Load(ctx context.Context, userID entities.UserID, deliveryID uuid.UUID) (*entities.WebhookDelivery, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	delivery := new(entities.WebhookDelivery)
+	err := repository.db.WithContext(ctx).Where("user_id = ?", userID).Where("id = ?", deliveryID).First(delivery).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		msg := fmt.Sprintf("webhook delivery with ID [%s] for user [%s] does not exist", deliveryID, userID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, ErrCodeNotFound, msg))
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot load webhook delivery with ID [%s] for user [%s]", deliveryID, userID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return delivery, nil
+}
+
+// Index fetches the entities.WebhookDelivery of a webhook
+func (repository *gormWebhookDeliveryRepository) Index(ctx context.Context, userID entities.UserID, params WebhookDeliveryIndexParams) ([]*entities.WebhookDelivery, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	query := repository.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Where("webhook_id = ?", params.WebhookID)
+	if params.Status != nil {
+		query = query.Where("status = ?", *params.Status)
+	}
+
+	deliveries := make([]*entities.WebhookDelivery, 0)
+	err := query.Order("created_at DESC").Limit(params.Limit).Offset(params.Skip).Find(&deliveries).Error
+	if err != nil {
+		msg := fmt.Sprintf("cannot fetch webhook deliveries for user [%s] and params [%+#v]", userID, params)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return deliveries, nil
+}
+
+// IndexFailed fetches the failed entities.WebhookDelivery of all the webhooks of a user with the latest first
+func (repository *gormWebhookDeliveryRepository) IndexFailed(ctx context.Context, userID entities.UserID, params IndexParams) ([]*entities.WebhookDelivery, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	deliveries := make([]*entities.WebhookDelivery, 0)
+	err := repository.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Where("status = ?", entities.WebhookDeliveryStatusFailed).
+		Order("updated_at DESC").
+		Limit(params.Limit).
+		Offset(params.Skip).
+		Find(&deliveries).
+		Error
+	if err != nil {
+		msg := fmt.Sprintf("cannot fetch failed webhook deliveries for user [%s] and params [%+#v]", userID, params)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return deliveries, nil
+}
+
+// FetchDue fetches the entities.WebhookDelivery of all users which should be retried at the timestamp
+func (repository *gormWebhookDeliveryRepository) FetchDue(ctx context.Context, timestamp time.Time, limit int) ([]*entities.WebhookDelivery, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	deliveries := make([]*entities.WebhookDelivery, 0)
+	err := WithoutTenantScope(repository.db.WithContext(ctx)).
+		Where("status = ?", entities.WebhookDeliveryStatusRetrying).
+		Where("next_attempt_at <= ?", timestamp).
+		Order("next_attempt_at ASC").
+		Limit(limit).
+		Find(&deliveries).
+		Error
+	if err != nil {
+		msg := fmt.Sprintf("cannot fetch webhook deliveries which are due at [%s]", timestamp)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return deliveries, nil
+}
+
+// Claim moves the next attempt of a due entities.WebhookDelivery to leaseUntil
+func (repository *gormWebhookDeliveryRepository) Claim(ctx context.Context, delivery *entities.WebhookDelivery, leaseUntil time.Time) (bool, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	result := repository.db.WithContext(ctx).
+		Model(delivery).
+		Where("user_id = ?", delivery.UserID).
+		Where("status = ?", entities.WebhookDeliveryStatusRetrying).
+		Where("next_attempt_at = ?", delivery.NextAttemptAt).
+		UpdateColumn("next_attempt_at", leaseUntil)
+	if result.Error != nil {
+		msg := fmt.Sprintf("cannot claim webhook delivery with ID [%s] for user [%s]", delivery.ID, delivery.UserID)
+		return false, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(result.Error, msg))
+	}
+
+	if result.RowsAffected == 0 {
+		return false, nil
+	}
+
+	delivery.NextAttemptAt = &leaseUntil
+	return true, nil
+}
+
+// Release schedules the held entities.WebhookDelivery of a user to be retried at the timestamp
+func (repository *gormWebhookDeliveryRepository) Release(ctx context.Context, userID entities.UserID, timestamp time.Time) (int64, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	result := repository.db.WithContext(ctx).
+		Model(&entities.WebhookDelivery{}).
+		Where("user_id = ?", userID).
+		Where("status = ?", entities.WebhookDeliveryStatusHeld).
+		Updates(map[string]any{
+			"status":          entities.WebhookDeliveryStatusRetrying,
+			"next_attempt_at": timestamp,
+			"updated_at":      time.Now().UTC(),
+		})
+	if result.Error != nil {
+		msg := fmt.Sprintf("cannot release held webhook deliveries of user [%s]", userID)
+		return 0, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(result.Error, msg))
+	}
+
+	return result.RowsAffected, nil
+}
@@ -0,0 +1,24 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/google/uuid"
+)
+
+// InvoiceRepository loads and persists an entities.Invoice
+type InvoiceRepository interface {
+	// Save stores an entities.Invoice or replaces the invoice of the same user and period
+	Save(ctx context.Context, invoice *entities.Invoice) error
+
+	// Load an entities.Invoice by ID
+	Load(ctx context.Context, userID entities.UserID, invoiceID uuid.UUID) (*entities.Invoice, error)
+
+	// LoadByPeriod loads the entities.Invoice of a user which starts at periodStart
+	LoadByPeriod(ctx context.Context, userID entities.UserID, periodStart time.Time) (*entities.Invoice, error)
+
+	// Index fetches the entities.Invoice of a user with the latest period first
+	Index(ctx context.Context, userID entities.UserID, params IndexParams) ([]*entities.Invoice, error)
+}
@@ -0,0 +1,16 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+)
+
+// LinkPreviewRepository loads and persists an entities.LinkPreview
+type LinkPreviewRepository interface {
+	// Save an entities.LinkPreview
+	Save(ctx context.Context, preview *entities.LinkPreview) error
+
+	// Load an entities.LinkPreview by URL
+	Load(ctx context.Context, url string) (*entities.LinkPreview, error)
+}
@@ -2,14 +2,59 @@ package repositories
 
 import (
 	"context"
+	"time"
 
 	"github.com/NdoleStudio/httpsms/pkg/entities"
 	"github.com/google/uuid"
+	"github.com/palantir/stacktrace"
 )
 
+// ErrCodeMessageExists is returned when a message is stored with the ID of an existing entities.Message
+const ErrCodeMessageExists = stacktrace.ErrorCode(1004)
+
+// ConversationIndexParams are parameters for fetching the entities.Conversation of a user
+type ConversationIndexParams struct {
+	// Owner filters the conversations of a phone number, all phone numbers are included when it is empty
+	Owner string
+
+	// Cursor is the last message of the previous page
+	Cursor *EventCursor
+	Limit  int
+}
+
+// MessageIndexParams are parameters for fetching a page of the entities.Message of a user, the empty filters are ignored
+type MessageIndexParams struct {
+	Owner    string
+	Contact  string
+	Statuses []entities.MessageStatus
+	Types    []entities.MessageType
+	SIM      entities.SIM
+	Since    *time.Time
+	Until    *time.Time
+
+	// Query filters the messages with content containing the query
+	Query string
+
+	// Tags filters the messages with at least one of the tag names
+	Tags []string
+
+	// Cursor is the last message of the previous page
+	Cursor *EventCursor
+	Limit  int
+}
+
+// MessageSearchParams are parameters for searching the content of the entities.Message of a user
+type MessageSearchParams struct {
+	Terms   []entities.MessageSearchTerm
+	Owner   string
+	Contact string
+	Skip    int
+	Limit   int
+}
+
 // MessageRepository loads and persists an entities.Message
 type MessageRepository interface {
-	// Store a new entities.Message
+	// Store a new entities.Message, ErrCodeMessageExists is returned when a message with the same ID exists
 	Store(ctx context.Context, message *entities.Message) error
 
 	// Update a new entities.Message
@@ -21,6 +66,107 @@ type MessageRepository interface {
 	// Index entities.Message between 2 phone numbers
 	Index(ctx context.Context, userID entities.UserID, owner string, contact string, params IndexParams) (*[]entities.Message, error)
 
+	// IndexPage fetches the entities.Message of a user matching the MessageIndexParams from the newest to the oldest
+	IndexPage(ctx context.Context, userID entities.UserID, params MessageIndexParams) ([]*entities.Message, error)
+
+	// FetchByIDs fetches the entities.Message of a user with the IDs, the IDs of messages which do not exist are ignored
+	FetchByIDs(ctx context.Context, userID entities.UserID, messageIDs []uuid.UUID) ([]*entities.Message, error)
+
+	// Search fetches the entities.MessageSearchHit with content matching the MessageSearchParams using the postgres full text search, the best match first
+	Search(ctx context.Context, userID entities.UserID, params MessageSearchParams) ([]*entities.MessageSearchHit, error)
+
+	// LoadLastInConversation fetches the latest entities.Message sent to a contact with a conversation ID since a timestamp
+	LoadLastInConversation(ctx context.Context, userID entities.UserID, owner string, contact string, since time.Time) (*entities.Message, error)
+
+	// LoadReceivedDuplicate fetches the latest received entities.Message with the same content from a contact since a timestamp
+	LoadReceivedDuplicate(ctx context.Context, userID entities.UserID, owner string, contact string, content string, since time.Time) (*entities.Message, error)
+
+	// FetchReceivedSince fetches the entities.Message received from a contact since a timestamp with the latest first
+	FetchReceivedSince(ctx context.Context, userID entities.UserID, owner string, contact string, since time.Time) ([]*entities.Message, error)
+
+	// LoadLastSent fetches the latest entities.Message which was sent to a contact before a timestamp
+	LoadLastSent(ctx context.Context, userID entities.UserID, owner string, contact string, before time.Time) (*entities.Message, error)
+
+	// CountReceived counts the entities.Message received from a contact after a timestamp and before another timestamp
+	CountReceived(ctx context.Context, userID entities.UserID, owner string, contact string, after time.Time, before time.Time) (int64, error)
+
 	// GetOutstanding fetches an entities.Message which is outstanding
 	GetOutstanding(ctx context.Context, userID entities.UserID, messageID uuid.UUID) (*entities.Message, error)
+
+	// IndexBatch fetches the entities.Message which were sent in the same bulk send request
+	IndexBatch(ctx context.Context, userID entities.UserID, batchID uuid.UUID) ([]*entities.Message, error)
+
+	// FetchDue fetches the entities.Message of all users which are queued for a SendAt time before a timestamp, the highest priority first
+	FetchDue(ctx context.Context, timestamp time.Time, limit int) ([]*entities.Message, error)
+
+	// Promote changes the status of an entities.Message which is queued for the future to pending.
+	// It returns false when the message was already promoted by another scheduler.
+	Promote(ctx context.Context, userID entities.UserID, messageID uuid.UUID) (bool, error)
+
+	// Demote changes the status of a pending entities.Message back to queued for the future so that it is promoted again.
+	// It returns false when the message is no longer pending.
+	Demote(ctx context.Context, userID entities.UserID, messageID uuid.UUID) (bool, error)
+
+	// FetchAwaiting fetches the entities.Message of a user which are waiting for the message with ID to be sent
+	FetchAwaiting(ctx context.Context, userID entities.UserID, messageID uuid.UUID) ([]*entities.Message, error)
+
+	// Release changes the status of an entities.Message which is waiting for its predecessor to status.
+	// It returns false when the message is no longer waiting.
+	Release(ctx context.Context, userID entities.UserID, messageID uuid.UUID, status entities.MessageStatus) (bool, error)
+
+	// Pause changes the status of the entities.Message of a user which are waiting for the phone to paused
+	Pause(ctx context.Context, userID entities.UserID) (int64, error)
+
+	// FetchPaused fetches the paused entities.Message of a user with the oldest first
+	FetchPaused(ctx context.Context, userID entities.UserID, limit int) ([]*entities.Message, error)
+
+	// Resume changes the status of a paused entities.Message to pending.
+	// It returns false when the message is no longer paused.
+	Resume(ctx context.Context, userID entities.UserID, messageID uuid.UUID) (bool, error)
+
+	// FetchWaiting fetches the entities.Message of an owner which are waiting for the phone with the oldest first
+	FetchWaiting(ctx context.Context, userID entities.UserID, owner string, limit int) ([]*entities.Message, error)
+
+	// Reassign changes the owner of an entities.Message which is waiting for the phone.
+	// It returns false when the message is no longer waiting for the phone of the previous owner.
+	Reassign(ctx context.Context, userID entities.UserID, messageID uuid.UUID, from string, to string) (bool, error)
+
+	// CountAttemptedBySIM returns the number of entities.Message which an owner attempted to send with a SIM since a timestamp
+	CountAttemptedBySIM(ctx context.Context, userID entities.UserID, owner string, sim entities.SIM, since time.Time) (int64, error)
+
+	// LastAttemptedBySIM returns when an owner last attempted to send an entities.Message with a SIM, it is nil when the SIM has not been used
+	LastAttemptedBySIM(ctx context.Context, userID entities.UserID, owner string, sim entities.SIM) (*time.Time, error)
+
+	// AssignSIM changes the SIM which is used to send an entities.Message
+	AssignSIM(ctx context.Context, userID entities.UserID, messageID uuid.UUID, sim entities.SIM) error
+
+	// IndexConversations fetches the entities.Conversation of a user with the most recent message first
+	IndexConversations(ctx context.Context, userID entities.UserID, params ConversationIndexParams) ([]*entities.Conversation, error)
+
+	// IndexConversation fetches the entities.Message between an owner and a contact ordered by their sequence from the newest to the oldest.
+	// Only the messages with a sequence lower than beforeSequence are fetched when it is not nil.
+	IndexConversation(ctx context.Context, userID entities.UserID, owner string, contact string, beforeSequence *uint64, limit int) ([]*entities.Message, error)
+
+	// MarkRead sets the read timestamp of a received entities.Message.
+	// It returns false when the message was already read.
+	MarkRead(ctx context.Context, userID entities.UserID, messageID uuid.UUID, timestamp time.Time) (bool, error)
+
+	// UpdateTags sets the tags of an entities.Message without changing the other columns which are updated by the phone
+	UpdateTags(ctx context.Context, userID entities.UserID, messageID uuid.UUID, tags []string) error
+
+	// MarkConversationRead sets the read timestamp of the unread entities.Message received by an owner from a contact and returns their IDs
+	MarkConversationRead(ctx context.Context, userID entities.UserID, owner string, contact string, timestamp time.Time) ([]uuid.UUID, error)
+
+	// Purge deletes at most limit entities.Message of a user with one of the statuses and an order timestamp before a time and returns their IDs
+	Purge(ctx context.Context, userID entities.UserID, before time.Time, statuses []entities.MessageStatus, limit int) ([]uuid.UUID, error)
+
+	// Anonymize removes the content of at most limit entities.Message of a user with one of the statuses and an order timestamp before a time and returns their IDs
+	Anonymize(ctx context.Context, userID entities.UserID, before time.Time, statuses []entities.MessageStatus, limit int) ([]uuid.UUID, error)
+
+	// FetchUnencrypted fetches the entities.Message of all users with content which does not start with the prefix of encrypted content and an ID after the cursor, ordered by ID
+	FetchUnencrypted(ctx context.Context, prefix string, after uuid.UUID, limit int) ([]*entities.Message, error)
+
+	// UpdateContent changes the content of an entities.Message without changing the other columns.
+	// It returns false when the content of the message is no longer from.
+	UpdateContent(ctx context.Context, userID entities.UserID, messageID uuid.UUID, from string, to string) (bool, error)
 }
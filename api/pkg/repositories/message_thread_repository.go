@@ -2,6 +2,7 @@ package repositories
 
 import (
 	"context"
+	"time"
 
 	"github.com/google/uuid"
 
@@ -22,6 +23,15 @@ type MessageThreadRepository interface {
 	// Load a thread by ID
 	Load(ctx context.Context, userID entities.UserID, ID uuid.UUID) (*entities.MessageThread, error)
 
-	// Index message threads for an owner
-	Index(ctx context.Context, userID entities.UserID, owner string, archived bool, params IndexParams) (*[]entities.MessageThread, error)
+	// Index message threads for an owner, the threads are limited to the ones with at least one of the tags when tags is not empty
+	Index(ctx context.Context, userID entities.UserID, owner string, archived bool, tags []string, params IndexParams) (*[]entities.MessageThread, error)
+
+	// AutoArchive archives the threads which had no activity for the entities.User.MessageThreadAutoArchiveDays of their user
+	AutoArchive(ctx context.Context, timestamp time.Time) (int64, error)
+
+	// Unarchive the threads of an owner with the given IDs or all the archived threads of the owner when no IDs are given
+	Unarchive(ctx context.Context, userID entities.UserID, owner string, IDs []uuid.UUID) (int64, error)
+
+	// ClearLastMessageContent removes the last message content of the threads whose last message is one of the given messages
+	ClearLastMessageContent(ctx context.Context, userID entities.UserID, messageIDs []uuid.UUID) error
 }
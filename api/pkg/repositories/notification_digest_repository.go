@@ -0,0 +1,20 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/google/uuid"
+)
+
+// NotificationDigestRepository loads and persists an entities.NotificationDigestItem
+type NotificationDigestRepository interface {
+	// Store a new entities.NotificationDigestItem and return the number of items waiting to be sent to the user
+	Store(ctx context.Context, item *entities.NotificationDigestItem) (int64, error)
+
+	// Index fetches the entities.NotificationDigestItem of a user ordered by the time they occurred
+	Index(ctx context.Context, userID entities.UserID) ([]*entities.NotificationDigestItem, error)
+
+	// Delete the entities.NotificationDigestItem of a user after they have been sent
+	Delete(ctx context.Context, userID entities.UserID, itemIDs []uuid.UUID) error
+}
@@ -0,0 +1,19 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+)
+
+// NotificationPreferenceRepository loads and persists an entities.NotificationPreference
+type NotificationPreferenceRepository interface {
+	// Save an entities.NotificationPreference
+	Save(ctx context.Context, preference *entities.NotificationPreference) error
+
+	// Index fetches all the entities.NotificationPreference configured by a user
+	Index(ctx context.Context, userID entities.UserID) ([]*entities.NotificationPreference, error)
+
+	// Load the entities.NotificationPreference of a user for an event and entities.NotificationChannel
+	Load(ctx context.Context, userID entities.UserID, eventType string, channel entities.NotificationChannel) (*entities.NotificationPreference, error)
+}
@@ -0,0 +1,29 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/google/uuid"
+)
+
+// NumberLeaseRepository loads and persists an entities.NumberLease
+type NumberLeaseRepository interface {
+	// Store a new entities.NumberLease
+	Store(ctx context.Context, lease *entities.NumberLease) error
+
+	// Update an entities.NumberLease
+	Update(ctx context.Context, lease *entities.NumberLease) error
+
+	// Load an entities.NumberLease by ID
+	Load(ctx context.Context, userID entities.UserID, leaseID uuid.UUID) (*entities.NumberLease, error)
+
+	// LoadActiveByReference loads the active entities.NumberLease of a conversation or campaign
+	LoadActiveByReference(ctx context.Context, userID entities.UserID, reference string) (*entities.NumberLease, error)
+
+	// LoadActiveByOwner loads the active entities.NumberLease of an owner phone number
+	LoadActiveByOwner(ctx context.Context, userID entities.UserID, owner string) (*entities.NumberLease, error)
+
+	// Index entities.NumberLease of a user
+	Index(ctx context.Context, userID entities.UserID, params IndexParams) ([]*entities.NumberLease, error)
+}
@@ -0,0 +1,23 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/google/uuid"
+)
+
+// PhoneFailoverPolicyRepository loads and persists an entities.PhoneFailoverPolicy
+type PhoneFailoverPolicyRepository interface {
+	// Save an entities.PhoneFailoverPolicy
+	Save(ctx context.Context, policy *entities.PhoneFailoverPolicy) error
+
+	// Index fetches all the entities.PhoneFailoverPolicy configured by a user
+	Index(ctx context.Context, userID entities.UserID) ([]*entities.PhoneFailoverPolicy, error)
+
+	// Load the entities.PhoneFailoverPolicy of a user for an owner
+	Load(ctx context.Context, userID entities.UserID, owner string) (*entities.PhoneFailoverPolicy, error)
+
+	// Delete an entities.PhoneFailoverPolicy of a user by ID
+	Delete(ctx context.Context, userID entities.UserID, policyID uuid.UUID) error
+}
@@ -2,6 +2,7 @@ package repositories
 
 import (
 	"context"
+	"time"
 
 	"github.com/google/uuid"
 
@@ -10,9 +11,18 @@ import (
 
 // PhoneNotificationRepository loads and persists an entities.PhoneNotification
 type PhoneNotificationRepository interface {
-	// Schedule a new entities.PhoneNotification
-	Schedule(ctx context.Context, messagesPerMinute uint, notification *entities.PhoneNotification) error
+	// Schedule a new entities.PhoneNotification at least interval after the last notification of the phone with the same or a higher priority
+	Schedule(ctx context.Context, interval time.Duration, notification *entities.PhoneNotification) error
 
 	// UpdateStatus of a notification
 	UpdateStatus(ctx context.Context, notificationID uuid.UUID, status entities.PhoneNotificationStatus) error
+
+	// CountInFlight returns the number of messages other than excludeMessageID which have been notified to the phone but are not yet sent by the phone
+	CountInFlight(ctx context.Context, phoneID uuid.UUID, excludeMessageID uuid.UUID) (int64, error)
+
+	// FetchWaiting returns the notifications of a phone with status entities.PhoneNotificationStatusWaiting, the highest priority first
+	FetchWaiting(ctx context.Context, phoneID uuid.UUID, limit int) ([]entities.PhoneNotification, error)
+
+	// Release moves a waiting notification back to entities.PhoneNotificationStatusPending, it returns false if the notification is no longer waiting
+	Release(ctx context.Context, notificationID uuid.UUID) (bool, error)
 }
@@ -0,0 +1,19 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+)
+
+// PhoneRegistrationRepository loads and persists an entities.PhoneRegistration
+type PhoneRegistrationRepository interface {
+	// Store a new entities.PhoneRegistration
+	Store(ctx context.Context, registration *entities.PhoneRegistration) error
+
+	// Update an entities.PhoneRegistration
+	Update(ctx context.Context, registration *entities.PhoneRegistration) error
+
+	// LoadByRevokeToken loads an entities.PhoneRegistration by the hash of the revoke token
+	LoadByRevokeToken(ctx context.Context, tokenHash string) (*entities.PhoneRegistration, error)
+}
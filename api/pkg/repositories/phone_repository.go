@@ -13,6 +13,12 @@ type PhoneRepository interface {
 	// Save Upsert a new entities.Phone
 	Save(ctx context.Context, phone *entities.Phone) error
 
+	// UpdatePace stores the pace fields of an entities.Phone without overwriting the other fields
+	UpdatePace(ctx context.Context, phone *entities.Phone) error
+
+	// UpdateClock stores the clock fields of an entities.Phone without overwriting the other fields
+	UpdateClock(ctx context.Context, phone *entities.Phone) error
+
 	// Index entities.Phone of a user
 	Index(ctx context.Context, userID entities.UserID, params IndexParams) (*[]entities.Phone, error)
 
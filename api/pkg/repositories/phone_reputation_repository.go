@@ -0,0 +1,23 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+)
+
+// PhoneReputationRepository loads and persists an entities.PhoneReputation and its entities.PhoneReputationDay
+type PhoneReputationRepository interface {
+	// IncrementDay adds the counts of an entities.PhoneReputationDay to the counts of the owner on the same day
+	IncrementDay(ctx context.Context, day *entities.PhoneReputationDay) error
+
+	// IndexDays fetches the entities.PhoneReputationDay of a user from a day until before another day, all owners are fetched when the owner is empty
+	IndexDays(ctx context.Context, userID entities.UserID, owner string, from time.Time, to time.Time) ([]*entities.PhoneReputationDay, error)
+
+	// Load the entities.PhoneReputation of an owner
+	Load(ctx context.Context, userID entities.UserID, owner string) (*entities.PhoneReputation, error)
+
+	// Save creates or updates the entities.PhoneReputation of an owner
+	Save(ctx context.Context, reputation *entities.PhoneReputation) error
+}
@@ -0,0 +1,24 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/google/uuid"
+)
+
+// PlanOverrideRepository loads and persists an entities.PlanOverride
+type PlanOverrideRepository interface {
+	// Store a new entities.PlanOverride
+	Store(ctx context.Context, override *entities.PlanOverride) error
+
+	// Index fetches the entities.PlanOverride of a user
+	Index(ctx context.Context, userID entities.UserID, params IndexParams) ([]*entities.PlanOverride, error)
+
+	// LoadActive loads the most recent entities.PlanOverride of a user which is active at the timestamp
+	LoadActive(ctx context.Context, userID entities.UserID, timestamp time.Time) (*entities.PlanOverride, error)
+
+	// Delete an entities.PlanOverride
+	Delete(ctx context.Context, userID entities.UserID, overrideID uuid.UUID) error
+}
@@ -0,0 +1,32 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/google/uuid"
+)
+
+// ProxySessionRepository loads and persists an entities.ProxySession
+type ProxySessionRepository interface {
+	// Store a new entities.ProxySession
+	Store(ctx context.Context, session *entities.ProxySession) error
+
+	// Update an entities.ProxySession
+	Update(ctx context.Context, session *entities.ProxySession) error
+
+	// Load an entities.ProxySession by ID
+	Load(ctx context.Context, userID entities.UserID, sessionID uuid.UUID) (*entities.ProxySession, error)
+
+	// LoadActive loads the active entities.ProxySession on an owner which has the contact as a party
+	LoadActive(ctx context.Context, userID entities.UserID, owner string, contact string) (*entities.ProxySession, error)
+
+	// Index entities.ProxySession of a user
+	Index(ctx context.Context, userID entities.UserID, params IndexParams) ([]*entities.ProxySession, error)
+
+	// StoreMessage stores a new entities.ProxySessionMessage
+	StoreMessage(ctx context.Context, message *entities.ProxySessionMessage) error
+
+	// IndexMessages fetches the transcript of an entities.ProxySession
+	IndexMessages(ctx context.Context, userID entities.UserID, sessionID uuid.UUID, params IndexParams) ([]*entities.ProxySessionMessage, error)
+}
@@ -0,0 +1,35 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+)
+
+// ReferralRepository loads and persists an entities.Referral and entities.ReferralCode
+type ReferralRepository interface {
+	// StoreCode stores a new entities.ReferralCode
+	StoreCode(ctx context.Context, code *entities.ReferralCode) error
+
+	// LoadCode loads the entities.ReferralCode of a user
+	LoadCode(ctx context.Context, userID entities.UserID) (*entities.ReferralCode, error)
+
+	// LoadCodeByCode loads an entities.ReferralCode by its case-insensitive code
+	LoadCodeByCode(ctx context.Context, code string) (*entities.ReferralCode, error)
+
+	// Store a new entities.Referral, it returns false when the referred user was already attributed
+	Store(ctx context.Context, referral *entities.Referral) (bool, error)
+
+	// LoadByReferredUserID loads the entities.Referral which attributed a user
+	LoadByReferredUserID(ctx context.Context, referredUserID entities.UserID) (*entities.Referral, error)
+
+	// Index fetches the entities.Referral made by a user with the latest first
+	Index(ctx context.Context, userID entities.UserID, params IndexParams) ([]*entities.Referral, error)
+
+	// Stats counts the referrals and rewards of a user
+	Stats(ctx context.Context, userID entities.UserID) (*entities.ReferralStats, error)
+
+	// Reward marks a pending entities.Referral as rewarded and stores the entities.PlanOverride of the reward when it is set in a single transaction.
+	// It returns false when the referral was already rewarded.
+	Reward(ctx context.Context, referral *entities.Referral, override *entities.PlanOverride) (bool, error)
+}
@@ -1,6 +1,11 @@
 package repositories
 
-import "github.com/palantir/stacktrace"
+import (
+	"fmt"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/palantir/stacktrace"
+)
 
 // IndexParams parameters for indexing a database table
 type IndexParams struct {
@@ -13,3 +18,13 @@ const (
 	// ErrCodeNotFound is thrown when an entity does not exist in storage
 	ErrCodeNotFound = stacktrace.ErrorCode(1000)
 )
+
+// priorityOrder is an ORDER BY expression which sorts rows with an entities.MessagePriority column from high to low
+func priorityOrder(column string) string {
+	return fmt.Sprintf(
+		"CASE %s WHEN '%s' THEN 0 WHEN '%s' THEN 2 ELSE 1 END ASC",
+		column,
+		entities.MessagePriorityHigh,
+		entities.MessagePriorityLow,
+	)
+}
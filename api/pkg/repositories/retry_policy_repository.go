@@ -0,0 +1,22 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+)
+
+// RetryPolicyRepository loads and persists an entities.RetryPolicy
+type RetryPolicyRepository interface {
+	// Save an entities.RetryPolicy
+	Save(ctx context.Context, policy *entities.RetryPolicy) error
+
+	// Index fetches all the entities.RetryPolicy configured by a user
+	Index(ctx context.Context, userID entities.UserID) ([]*entities.RetryPolicy, error)
+
+	// Load the entities.RetryPolicy of a user for an entities.MessageFailureCode
+	Load(ctx context.Context, userID entities.UserID, code entities.MessageFailureCode) (*entities.RetryPolicy, error)
+
+	// Delete the entities.RetryPolicy of a user for an entities.MessageFailureCode
+	Delete(ctx context.Context, userID entities.UserID, code entities.MessageFailureCode) error
+}
@@ -0,0 +1,39 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/google/uuid"
+)
+
+// RuleRepository loads and persists an entities.Rule
+type RuleRepository interface {
+	// Store a new entities.Rule
+	Store(ctx context.Context, rule *entities.Rule) error
+
+	// Update an entities.Rule
+	Update(ctx context.Context, rule *entities.Rule) error
+
+	// Load an entities.Rule by ID
+	Load(ctx context.Context, userID entities.UserID, ruleID uuid.UUID) (*entities.Rule, error)
+
+	// Index entities.Rule of a user
+	Index(ctx context.Context, userID entities.UserID, params IndexParams) ([]*entities.Rule, error)
+
+	// FetchEnabled fetches the enabled entities.Rule of an owner with the given type
+	FetchEnabled(ctx context.Context, userID entities.UserID, owner string, ruleType entities.RuleType) ([]*entities.Rule, error)
+
+	// Delete an entities.Rule
+	Delete(ctx context.Context, userID entities.UserID, ruleID uuid.UUID) error
+
+	// StoreExecution stores a new entities.RuleExecution
+	StoreExecution(ctx context.Context, execution *entities.RuleExecution) error
+
+	// HasExecution checks if an entities.Rule has been executed for a contact on a day
+	HasExecution(ctx context.Context, ruleID uuid.UUID, contact string, day string) (bool, error)
+
+	// HasExecutionSince checks if an entities.Rule has been executed for a contact after a timestamp
+	HasExecutionSince(ctx context.Context, ruleID uuid.UUID, contact string, since time.Time) (bool, error)
+}
@@ -0,0 +1,26 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/google/uuid"
+)
+
+// SavedFilterRepository loads and persists an entities.SavedFilter
+type SavedFilterRepository interface {
+	// Store a new entities.SavedFilter
+	Store(ctx context.Context, filter *entities.SavedFilter) error
+
+	// Update an entities.SavedFilter
+	Update(ctx context.Context, filter *entities.SavedFilter) error
+
+	// Load an entities.SavedFilter by ID
+	Load(ctx context.Context, userID entities.UserID, savedFilterID uuid.UUID) (*entities.SavedFilter, error)
+
+	// Index fetches all the entities.SavedFilter of a user ordered by position
+	Index(ctx context.Context, userID entities.UserID) ([]*entities.SavedFilter, error)
+
+	// Delete an entities.SavedFilter
+	Delete(ctx context.Context, userID entities.UserID, savedFilterID uuid.UUID) error
+}
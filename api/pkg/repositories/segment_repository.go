@@ -0,0 +1,26 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/google/uuid"
+)
+
+// SegmentRepository loads and persists an entities.Segment
+type SegmentRepository interface {
+	// Store a new entities.Segment
+	Store(ctx context.Context, segment *entities.Segment) error
+
+	// Update an entities.Segment
+	Update(ctx context.Context, segment *entities.Segment) error
+
+	// Load an entities.Segment by ID
+	Load(ctx context.Context, userID entities.UserID, segmentID uuid.UUID) (*entities.Segment, error)
+
+	// Index entities.Segment of a user
+	Index(ctx context.Context, userID entities.UserID, params IndexParams) ([]*entities.Segment, error)
+
+	// Delete an entities.Segment
+	Delete(ctx context.Context, userID entities.UserID, segmentID uuid.UUID) error
+}
@@ -0,0 +1,51 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/google/uuid"
+)
+
+// SubscriptionIndexParams are parameters for fetching entities.Subscription
+type SubscriptionIndexParams struct {
+	IndexParams
+	KeywordID *uuid.UUID
+	Status    *entities.SubscriptionStatus
+}
+
+// SubscriptionRepository loads and persists an entities.SubscriptionKeyword and entities.Subscription
+type SubscriptionRepository interface {
+	// StoreKeyword stores a new entities.SubscriptionKeyword
+	StoreKeyword(ctx context.Context, keyword *entities.SubscriptionKeyword) error
+
+	// UpdateKeyword updates an entities.SubscriptionKeyword
+	UpdateKeyword(ctx context.Context, keyword *entities.SubscriptionKeyword) error
+
+	// LoadKeyword loads an entities.SubscriptionKeyword by ID
+	LoadKeyword(ctx context.Context, userID entities.UserID, keywordID uuid.UUID) (*entities.SubscriptionKeyword, error)
+
+	// FindKeyword loads the enabled entities.SubscriptionKeyword of an owner which matches the keyword
+	FindKeyword(ctx context.Context, userID entities.UserID, owner string, keyword string) (*entities.SubscriptionKeyword, error)
+
+	// IndexKeywords fetches the entities.SubscriptionKeyword of a user
+	IndexKeywords(ctx context.Context, userID entities.UserID, params IndexParams) ([]*entities.SubscriptionKeyword, error)
+
+	// DeleteKeyword deletes an entities.SubscriptionKeyword and its entities.Subscription
+	DeleteKeyword(ctx context.Context, userID entities.UserID, keywordID uuid.UUID) error
+
+	// Store a new entities.Subscription
+	Store(ctx context.Context, subscription *entities.Subscription) error
+
+	// Update an entities.Subscription
+	Update(ctx context.Context, subscription *entities.Subscription) error
+
+	// Load the entities.Subscription of a contact to an entities.SubscriptionKeyword
+	Load(ctx context.Context, userID entities.UserID, keywordID uuid.UUID, contact string) (*entities.Subscription, error)
+
+	// FetchPending fetches the pending entities.Subscription of a contact on an owner phone
+	FetchPending(ctx context.Context, userID entities.UserID, owner string, contact string) ([]*entities.Subscription, error)
+
+	// Index fetches the entities.Subscription of a user
+	Index(ctx context.Context, userID entities.UserID, params SubscriptionIndexParams) ([]*entities.Subscription, error)
+}
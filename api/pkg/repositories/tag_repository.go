@@ -0,0 +1,38 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/google/uuid"
+)
+
+// TagRepository loads and persists an entities.Tag and the tags set on contacts, messages and message threads
+type TagRepository interface {
+	// Store a new entities.Tag
+	Store(ctx context.Context, tag *entities.Tag) error
+
+	// Update an entities.Tag
+	Update(ctx context.Context, tag *entities.Tag) error
+
+	// Load an entities.Tag by ID
+	Load(ctx context.Context, userID entities.UserID, tagID uuid.UUID) (*entities.Tag, error)
+
+	// LoadByName loads an entities.Tag of a user by name
+	LoadByName(ctx context.Context, userID entities.UserID, name string) (*entities.Tag, error)
+
+	// Index fetches all the entities.Tag of a user ordered by name
+	Index(ctx context.Context, userID entities.UserID) ([]*entities.Tag, error)
+
+	// Delete an entities.Tag, the children of the tag are moved to the parent of the tag
+	Delete(ctx context.Context, tag *entities.Tag) error
+
+	// Rename replaces a tag name on the contacts, messages and message threads of a user
+	Rename(ctx context.Context, userID entities.UserID, from string, to string) error
+
+	// Remove removes a tag name from the contacts, messages and message threads of a user
+	Remove(ctx context.Context, userID entities.UserID, name string) error
+
+	// Usage counts the contacts, messages and message threads of a user with each tag name
+	Usage(ctx context.Context, userID entities.UserID) (map[string]*entities.TagUsage, error)
+}
@@ -0,0 +1,38 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/google/uuid"
+)
+
+// TemplateRepository loads and persists an entities.Template and its entities.TemplateReview
+type TemplateRepository interface {
+	// Store a new entities.Template
+	Store(ctx context.Context, template *entities.Template) error
+
+	// Update an entities.Template
+	Update(ctx context.Context, template *entities.Template) error
+
+	// Load an entities.Template of a user by ID
+	Load(ctx context.Context, userID entities.UserID, templateID uuid.UUID) (*entities.Template, error)
+
+	// LoadByID loads an entities.Template of any user so that it can be reviewed by an operator
+	LoadByID(ctx context.Context, templateID uuid.UUID) (*entities.Template, error)
+
+	// Index entities.Template of a user
+	Index(ctx context.Context, userID entities.UserID, params IndexParams) ([]*entities.Template, error)
+
+	// IndexByStatus fetches the entities.Template of all users with a status
+	IndexByStatus(ctx context.Context, status entities.TemplateStatus, params IndexParams) ([]*entities.Template, error)
+
+	// Delete an entities.Template and its entities.TemplateReview
+	Delete(ctx context.Context, userID entities.UserID, templateID uuid.UUID) error
+
+	// StoreReview stores an entities.TemplateReview in the audit history of a template
+	StoreReview(ctx context.Context, review *entities.TemplateReview) error
+
+	// IndexReviews fetches the entities.TemplateReview of a template, the most recent review is first
+	IndexReviews(ctx context.Context, userID entities.UserID, templateID uuid.UUID) ([]*entities.TemplateReview, error)
+}
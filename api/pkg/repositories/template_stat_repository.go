@@ -0,0 +1,18 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/google/uuid"
+)
+
+// TemplateStatRepository loads and persists an entities.TemplateStat
+type TemplateStatRepository interface {
+	// Increment adds the counts of an entities.TemplateStat to the stat of the template on the same day
+	Increment(ctx context.Context, stat *entities.TemplateStat) error
+
+	// Index fetches the entities.TemplateStat of a template from a day until before another day
+	Index(ctx context.Context, userID entities.UserID, templateID uuid.UUID, from time.Time, to time.Time) ([]*entities.TemplateStat, error)
+}
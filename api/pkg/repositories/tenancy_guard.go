@@ -0,0 +1,223 @@
+package repositories
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/palantir/stacktrace"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// ErrCodeMissingTenantScope is thrown when a query on a table with a user_id column is not filtered by user_id
+	ErrCodeMissingTenantScope = stacktrace.ErrorCode(1001)
+
+	tenancyGuardColumn  = "user_id"
+	tenancyGuardSkipKey = "tenancy_guard:skip"
+)
+
+// TenancyGuardMode is how the TenancyGuard reacts to a query which is not scoped to a user
+type TenancyGuardMode string
+
+const (
+	// TenancyGuardModeEnforce fails the query with ErrCodeMissingTenantScope, this is the default mode
+	TenancyGuardModeEnforce = TenancyGuardMode("enforce")
+
+	// TenancyGuardModeLog logs an error and runs the query, it must be enabled explicitly
+	TenancyGuardModeLog = TenancyGuardMode("log")
+
+	// TenancyGuardModeOff disables the TenancyGuard
+	TenancyGuardModeOff = TenancyGuardMode("off")
+)
+
+var (
+	// tenancyGuardConditionPattern matches an equality or IN condition between exactly the user_id column and a bound variable
+	tenancyGuardConditionPattern = regexp.MustCompile(`(?i)(^|[^\w."])("?\w+"?\.)?"?user_id"?\s*(=|\bIN\b\s*\(?)\s*(\?|\$\d+|@\w+)`)
+
+	// tenancyGuardTablePattern matches the tables which are read, updated or deleted by raw SQL, inserted rows carry their own user_id
+	tenancyGuardTablePattern = regexp.MustCompile(`(?i)\b(?:FROM|JOIN|UPDATE)\s+((?:"?\w+"?\.)?"?\w+"?)`)
+)
+
+// TenancyGuard is a gorm.Plugin which checks that queries, updates, deletes and raw SQL on tables with a user_id column
+// are scoped to a user so that a missing filter in a repository cannot leak the data of another user.
+type TenancyGuard struct {
+	logger telemetry.Logger
+	mode   TenancyGuardMode
+}
+
+// NewTenancyGuard creates a new TenancyGuard
+func NewTenancyGuard(logger telemetry.Logger, mode TenancyGuardMode) *TenancyGuard {
+	if mode != TenancyGuardModeLog && mode != TenancyGuardModeOff {
+		mode = TenancyGuardModeEnforce
+	}
+	return &TenancyGuard{
+		logger: logger.WithService(fmt.Sprintf("%T", &TenancyGuard{})),
+		mode:   mode,
+	}
+}
+
+// WithoutTenantScope marks a query which intentionally reads or writes the rows of all users
+func WithoutTenantScope(db *gorm.DB) *gorm.DB {
+	return db.Set(tenancyGuardSkipKey, true)
+}
+
+// Name is the name of the gorm.Plugin
+func (guard *TenancyGuard) Name() string {
+	return "httpsms:tenancy_guard"
+}
+
+// Initialize registers the callbacks of the TenancyGuard
+func (guard *TenancyGuard) Initialize(db *gorm.DB) error {
+	if guard.mode == TenancyGuardModeOff {
+		return nil
+	}
+
+	if err := db.Callback().Query().Before("gorm:query").Register("tenancy_guard:query", guard.check); err != nil {
+		return stacktrace.Propagate(err, "cannot register query callback")
+	}
+
+	if err := db.Callback().Update().Before("gorm:update").Register("tenancy_guard:update", guard.check); err != nil {
+		return stacktrace.Propagate(err, "cannot register update callback")
+	}
+
+	if err := db.Callback().Delete().Before("gorm:delete").Register("tenancy_guard:delete", guard.check); err != nil {
+		return stacktrace.Propagate(err, "cannot register delete callback")
+	}
+
+	if err := db.Callback().Raw().Before("gorm:raw").Register("tenancy_guard:raw", guard.check); err != nil {
+		return stacktrace.Propagate(err, "cannot register raw callback")
+	}
+
+	if err := db.Callback().Row().Before("gorm:row").Register("tenancy_guard:row", guard.check); err != nil {
+		return stacktrace.Propagate(err, "cannot register row callback")
+	}
+
+	return nil
+}
+
+func (guard *TenancyGuard) check(db *gorm.DB) {
+	statement := db.Statement
+	if db.Error != nil || !guard.isTenantStatement(statement) {
+		return
+	}
+
+	if skip, ok := db.Get(tenancyGuardSkipKey); ok && skip == true {
+		return
+	}
+
+	if guard.isScoped(statement) {
+		return
+	}
+
+	err := stacktrace.NewErrorWithCode(ErrCodeMissingTenantScope, fmt.Sprintf("query on table [%s] is not scoped by [%s]", statement.Table, tenancyGuardColumn))
+	if guard.mode == TenancyGuardModeLog {
+		guard.logger.Error(err)
+		return
+	}
+
+	_ = db.AddError(err)
+}
+
+// isTenantStatement checks if the statement runs on a model with a user_id column or it is raw SQL on application tables
+func (guard *TenancyGuard) isTenantStatement(statement *gorm.Statement) bool {
+	if statement.Schema != nil {
+		return statement.Schema.LookUpField(tenancyGuardColumn) != nil
+	}
+
+	if statement.SQL.Len() == 0 {
+		return false
+	}
+
+	for _, match := range tenancyGuardTablePattern.FindAllStringSubmatch(statement.SQL.String(), -1) {
+		// the UPDATE of an ON CONFLICT DO UPDATE SET clause updates the row which was inserted
+		if !strings.EqualFold(match[1], "SET") && !guard.isSystemTable(match[1]) {
+			return true
+		}
+	}
+	return false
+}
+
+// isSystemTable checks if a table belongs to the catalog of the database e.g. the queries run by the gorm migrator
+func (guard *TenancyGuard) isSystemTable(table string) bool {
+	table = strings.ToLower(strings.ReplaceAll(table, `"`, ""))
+	return strings.HasPrefix(table, "information_schema.") || strings.HasPrefix(table, "pg_catalog.") || strings.HasPrefix(table, "pg_")
+}
+
+// isScoped checks if the statement filters by user_id or it updates an entity which has a user
+func (guard *TenancyGuard) isScoped(statement *gorm.Statement) bool {
+	if statement.SQL.Len() > 0 {
+		return tenancyGuardConditionPattern.MatchString(statement.SQL.String())
+	}
+
+	if where, ok := statement.Clauses["WHERE"]; ok && guard.hasCondition(where.Expression) {
+		return true
+	}
+
+	if statement.ReflectValue.Kind() != reflect.Struct {
+		return false
+	}
+
+	field := statement.Schema.LookUpField(tenancyGuardColumn)
+	_, isZero := field.ValueOf(statement.Context, statement.ReflectValue)
+	return !isZero
+}
+
+func (guard *TenancyGuard) hasCondition(expression clause.Expression) bool {
+	switch value := expression.(type) {
+	case clause.Where:
+		for _, item := range value.Exprs {
+			if _, ok := item.(clause.OrConditions); ok {
+				return guard.hasAllConditions(value.Exprs)
+			}
+		}
+		return guard.hasAnyCondition(value.Exprs)
+	case clause.AndConditions:
+		return guard.hasAnyCondition(value.Exprs)
+	case clause.OrConditions:
+		return guard.hasAllConditions(value.Exprs)
+	case clause.Expr:
+		return tenancyGuardConditionPattern.MatchString(value.SQL)
+	case clause.NamedExpr:
+		return tenancyGuardConditionPattern.MatchString(value.SQL)
+	case clause.Eq:
+		return guard.isColumn(value.Column)
+	case clause.IN:
+		return guard.isColumn(value.Column)
+	default:
+		return false
+	}
+}
+
+func (guard *TenancyGuard) hasAnyCondition(expressions []clause.Expression) bool {
+	for _, expression := range expressions {
+		if guard.hasCondition(expression) {
+			return true
+		}
+	}
+	return false
+}
+
+func (guard *TenancyGuard) hasAllConditions(expressions []clause.Expression) bool {
+	for _, expression := range expressions {
+		if !guard.hasCondition(expression) {
+			return false
+		}
+	}
+	return len(expressions) > 0
+}
+
+func (guard *TenancyGuard) isColumn(column any) bool {
+	switch value := column.(type) {
+	case string:
+		value = strings.ReplaceAll(value, `"`, "")
+		return value == tenancyGuardColumn || strings.HasSuffix(value, "."+tenancyGuardColumn)
+	case clause.Column:
+		return value.Name == tenancyGuardColumn
+	default:
+		return false
+	}
+}
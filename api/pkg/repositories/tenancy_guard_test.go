@@ -0,0 +1,391 @@
+package repositories
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/google/uuid"
+	"github.com/palantir/stacktrace"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type tenancyGuardTestLogger struct {
+	errors []error
+}
+
+func (logger *tenancyGuardTestLogger) Error(err error)                             { logger.errors = append(logger.errors, err) }
+func (logger *tenancyGuardTestLogger) WithService(string) telemetry.Logger         { return logger }
+func (logger *tenancyGuardTestLogger) WithString(string, string) telemetry.Logger  { return logger }
+func (logger *tenancyGuardTestLogger) WithSpan(trace.SpanContext) telemetry.Logger { return logger }
+func (logger *tenancyGuardTestLogger) Trace(string)                                {}
+func (logger *tenancyGuardTestLogger) Info(string)                                 {}
+func (logger *tenancyGuardTestLogger) Warn(error)                                  {}
+func (logger *tenancyGuardTestLogger) Debug(string)                                {}
+func (logger *tenancyGuardTestLogger) Fatal(error)                                 {}
+func (logger *tenancyGuardTestLogger) Printf(string, ...interface{})               {}
+
+func tenancyGuardTestDB(t *testing.T, mode TenancyGuardMode) (*gorm.DB, *tenancyGuardTestLogger) {
+	logger := new(tenancyGuardTestLogger)
+
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=localhost"}), &gorm.Config{DryRun: true, DisableAutomaticPing: true})
+	assert.Nil(t, err)
+	assert.Nil(t, db.Use(NewTenancyGuard(logger, mode)))
+
+	return db, logger
+}
+
+func isMissingTenantScope(err error) bool {
+	return err != nil && stacktrace.GetCode(err) == ErrCodeMissingTenantScope
+}
+
+func TestNewTenancyGuard(t *testing.T) {
+	t.Run("mode is enforce when the mode is empty", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+
+		// Act
+		guard := NewTenancyGuard(new(tenancyGuardTestLogger), "")
+
+		// Assert
+		assert.Equal(t, TenancyGuardModeEnforce, guard.mode)
+	})
+
+	t.Run("mode is enforce when the mode is unknown", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+
+		// Act
+		guard := NewTenancyGuard(new(tenancyGuardTestLogger), "logging")
+
+		// Assert
+		assert.Equal(t, TenancyGuardModeEnforce, guard.mode)
+	})
+
+	t.Run("mode is log when the mode is log", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+
+		// Act
+		guard := NewTenancyGuard(new(tenancyGuardTestLogger), TenancyGuardModeLog)
+
+		// Assert
+		assert.Equal(t, TenancyGuardModeLog, guard.mode)
+	})
+}
+
+func TestTenancyGuard_Query(t *testing.T) {
+	t.Run("query filtered by user_id is allowed", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+		db, _ := tenancyGuardTestDB(t, "")
+
+		// Act
+		err := db.Where("user_id = ?", "user-1").Find(&[]entities.Message{}).Error
+
+		// Assert
+		assert.Nil(t, err)
+	})
+
+	t.Run("query filtered by a struct condition on user_id is allowed", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+		db, _ := tenancyGuardTestDB(t, "")
+
+		// Act
+		err := db.Where(clause.IN{Column: clause.Column{Table: "messages", Name: "user_id"}, Values: []any{"user-1", "user-2"}}).Find(&[]entities.Message{}).Error
+
+		// Assert
+		assert.Nil(t, err)
+	})
+
+	t.Run("query filtered by another column which ends in user_id is rejected", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+		db, _ := tenancyGuardTestDB(t, "")
+
+		// Act
+		err := db.Where("referred_user_id = ?", "user-1").Find(&[]entities.Message{}).Error
+
+		// Assert
+		assert.True(t, isMissingTenantScope(err))
+	})
+
+	t.Run("query which only mentions user_id without a condition is rejected", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+		db, _ := tenancyGuardTestDB(t, "")
+
+		// Act
+		err := db.Where("user_id IS NOT NULL").Find(&[]entities.Message{}).Error
+
+		// Assert
+		assert.True(t, isMissingTenantScope(err))
+	})
+
+	t.Run("query with an OR condition without user_id on every branch is rejected", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+		db, _ := tenancyGuardTestDB(t, "")
+
+		// Act
+		err := db.Where("user_id = ?", "user-1").Or("owner = ?", "+18005550199").Find(&[]entities.Message{}).Error
+
+		// Assert
+		assert.True(t, isMissingTenantScope(err))
+	})
+
+	t.Run("query without a user_id filter is allowed with WithoutTenantScope", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+		db, _ := tenancyGuardTestDB(t, "")
+
+		// Act
+		err := WithoutTenantScope(db).Where("owner = ?", "+18005550199").Find(&[]entities.Message{}).Error
+
+		// Assert
+		assert.Nil(t, err)
+	})
+
+	t.Run("query on a table without a user_id column is allowed", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+		db, _ := tenancyGuardTestDB(t, "")
+
+		// Act
+		err := db.Where("email = ?", "name@example.com").Find(&[]entities.User{}).Error
+
+		// Assert
+		assert.Nil(t, err)
+	})
+
+	t.Run("query without a user_id filter is logged in log mode", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+		db, logger := tenancyGuardTestDB(t, TenancyGuardModeLog)
+
+		// Act
+		err := db.Where("owner = ?", "+18005550199").Find(&[]entities.Message{}).Error
+
+		// Assert
+		assert.Nil(t, err)
+		assert.Equal(t, 1, len(logger.errors))
+		assert.True(t, isMissingTenantScope(logger.errors[0]))
+	})
+}
+
+func TestTenancyGuard_Raw(t *testing.T) {
+	t.Run("raw SQL filtered by user_id is allowed", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+		db, _ := tenancyGuardTestDB(t, "")
+
+		// Act
+		err := db.Exec("DELETE FROM ? WHERE user_id = ?", clause.Table{Name: "messages"}, "user-1").Error
+
+		// Assert
+		assert.Nil(t, err)
+	})
+
+	t.Run("raw SQL filtered by a qualified user_id IN condition is allowed", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+		db, _ := tenancyGuardTestDB(t, "")
+
+		// Act
+		err := db.Exec("UPDATE messages SET status = ? WHERE messages.user_id IN ?", "expired", []string{"user-1", "user-2"}).Error
+
+		// Assert
+		assert.Nil(t, err)
+	})
+
+	t.Run("raw SQL which only joins on user_id is rejected", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+		db, _ := tenancyGuardTestDB(t, "")
+
+		// Act
+		err := db.Exec("UPDATE message_threads SET is_archived = true FROM users WHERE users.id = message_threads.user_id").Error
+
+		// Assert
+		assert.True(t, isMissingTenantScope(err))
+	})
+
+	t.Run("raw SQL without a user_id filter is allowed with WithoutTenantScope", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+		db, _ := tenancyGuardTestDB(t, "")
+
+		// Act
+		err := WithoutTenantScope(db).Exec("UPDATE message_threads SET is_archived = true FROM users WHERE users.id = message_threads.user_id").Error
+
+		// Assert
+		assert.Nil(t, err)
+	})
+
+	t.Run("raw SQL on the database catalog is allowed", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+		db, _ := tenancyGuardTestDB(t, "")
+
+		// Act
+		err := db.Exec("SELECT count(*) FROM information_schema.tables WHERE table_name = ?", "messages").Error
+
+		// Assert
+		assert.Nil(t, err)
+	})
+
+	t.Run("raw SQL which inserts rows with a user_id is allowed", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+		db, _ := tenancyGuardTestDB(t, "")
+
+		// Act
+		err := db.Exec("INSERT INTO message_sequences (user_id, owner, contact, value) VALUES (?, ?, ?, 1) ON CONFLICT (user_id, owner, contact) DO UPDATE SET value = message_sequences.value + 1", "user-1", "+18005550199", "+18005550100").Error
+
+		// Assert
+		assert.Nil(t, err)
+	})
+
+	t.Run("raw SQL which inserts rows selected without a user_id filter is rejected", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+		db, _ := tenancyGuardTestDB(t, "")
+
+		// Act
+		err := db.Exec("INSERT INTO contact_group_members (contact_group_id, contact_id, user_id) SELECT ?, id, user_id FROM contacts WHERE id IN ?", "group-1", []string{"contact-1"}).Error
+
+		// Assert
+		assert.True(t, isMissingTenantScope(err))
+	})
+
+	t.Run("raw row query without a user_id filter is rejected", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+		db, _ := tenancyGuardTestDB(t, "")
+
+		// Act
+		_, err := db.Raw("SELECT id FROM messages WHERE owner = ?", "+18005550199").Rows()
+
+		// Assert
+		assert.True(t, isMissingTenantScope(err))
+	})
+
+	t.Run("raw row query filtered by user_id is allowed", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+		db, _ := tenancyGuardTestDB(t, "")
+
+		// Act
+		_, err := db.Raw(`SELECT id FROM messages WHERE "messages"."user_id" = ?`, "user-1").Rows()
+
+		// Assert
+		assert.False(t, isMissingTenantScope(err))
+	})
+}
+
+func TestTenancyGuard_Repositories(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("user credential is loaded by email without a user", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+		db, logger := tenancyGuardTestDB(t, "")
+		repository := NewGormUserCredentialRepository(logger, telemetry.NewOtelLogger("test", logger), db)
+
+		// Act
+		_, err := repository.LoadByEmail(ctx, "name@example.com")
+
+		// Assert
+		assert.False(t, isMissingTenantScope(err))
+	})
+
+	t.Run("auth session is loaded by key without a user", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+		db, logger := tenancyGuardTestDB(t, "")
+		repository := NewGormAuthSessionRepository(logger, telemetry.NewOtelLogger("test", logger), db)
+
+		// Act
+		_, err := repository.LoadByKey(ctx, "session-key")
+
+		// Assert
+		assert.False(t, isMissingTenantScope(err))
+	})
+
+	t.Run("phone registration is loaded by revoke token without a user", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+		db, logger := tenancyGuardTestDB(t, "")
+		repository := NewGormPhoneRegistrationRepository(logger, telemetry.NewOtelLogger("test", logger), db)
+
+		// Act
+		_, err := repository.LoadByRevokeToken(ctx, "token-hash")
+
+		// Assert
+		assert.False(t, isMissingTenantScope(err))
+	})
+
+	t.Run("templates are loaded by ID and status without a user", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+		db, logger := tenancyGuardTestDB(t, "")
+		repository := NewGormTemplateRepository(logger, telemetry.NewOtelLogger("test", logger), db)
+
+		// Act
+		_, loadErr := repository.LoadByID(ctx, uuid.New())
+		_, indexErr := repository.IndexByStatus(ctx, entities.TemplateStatusSubmitted, IndexParams{Limit: 10})
+
+		// Assert
+		assert.False(t, isMissingTenantScope(loadErr))
+		assert.False(t, isMissingTenantScope(indexErr))
+	})
+
+	t.Run("message threads are archived for all users", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+		db, logger := tenancyGuardTestDB(t, "")
+		repository := NewGormMessageThreadRepository(logger, telemetry.NewOtelLogger("test", logger), db)
+
+		// Act
+		_, err := repository.AutoArchive(ctx, time.Now())
+
+		// Assert
+		assert.False(t, isMissingTenantScope(err))
+	})
+
+	t.Run("message thread is updated with its user", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+		db, logger := tenancyGuardTestDB(t, "")
+		repository := NewGormMessageThreadRepository(logger, telemetry.NewOtelLogger("test", logger), db)
+
+		// Act
+		err := repository.Update(ctx, &entities.MessageThread{ID: uuid.New(), UserID: "user-1"})
+
+		// Assert
+		assert.False(t, isMissingTenantScope(err))
+	})
+
+	t.Run("two factor codes are consumed and counted with their user", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+		db, logger := tenancyGuardTestDB(t, "")
+		repository := NewGormTwoFactorAuthRepository(logger, telemetry.NewOtelLogger("test", logger), db)
+		auth := &entities.TwoFactorAuth{ID: uuid.New(), UserID: "user-1"}
+
+		// Act
+		_, consumeErr := repository.ConsumeRecoveryCode(ctx, auth, "hash")
+		attemptErr := repository.RecordFailedAttempt(ctx, auth, 5, time.Now())
+
+		// Assert
+		assert.False(t, isMissingTenantScope(consumeErr))
+		assert.False(t, isMissingTenantScope(attemptErr))
+	})
+}
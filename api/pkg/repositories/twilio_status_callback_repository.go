@@ -0,0 +1,17 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/google/uuid"
+)
+
+// TwilioStatusCallbackRepository loads and persists an entities.TwilioStatusCallback
+type TwilioStatusCallbackRepository interface {
+	// Store a new entities.TwilioStatusCallback
+	Store(ctx context.Context, callback *entities.TwilioStatusCallback) error
+
+	// Load the entities.TwilioStatusCallback of a message
+	Load(ctx context.Context, userID entities.UserID, messageID uuid.UUID) (*entities.TwilioStatusCallback, error)
+}
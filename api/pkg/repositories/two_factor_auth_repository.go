@@ -0,0 +1,31 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+)
+
+// TwoFactorAuthRepository loads and persists an entities.TwoFactorAuth
+type TwoFactorAuthRepository interface {
+	// Save an entities.TwoFactorAuth
+	Save(ctx context.Context, auth *entities.TwoFactorAuth) error
+
+	// Load the entities.TwoFactorAuth of a user
+	Load(ctx context.Context, userID entities.UserID) (*entities.TwoFactorAuth, error)
+
+	// ClaimTimeStep stores the TOTP time step of an accepted code if it is after the last accepted time step.
+	// It returns false when a code of the same or a later time step was already accepted.
+	ClaimTimeStep(ctx context.Context, auth *entities.TwoFactorAuth, timeStep int64) (bool, error)
+
+	// ConsumeRecoveryCode removes the hash of a recovery code if it has not been used.
+	// It returns false when the recovery code was already used by a concurrent request.
+	ConsumeRecoveryCode(ctx context.Context, auth *entities.TwoFactorAuth, hash string) (bool, error)
+
+	// RecordFailedAttempt counts an invalid code and locks the entities.TwoFactorAuth until lockedUntil once maxAttempts is reached
+	RecordFailedAttempt(ctx context.Context, auth *entities.TwoFactorAuth, maxAttempts int, lockedUntil time.Time) error
+
+	// Delete the entities.TwoFactorAuth of a user
+	Delete(ctx context.Context, userID entities.UserID) error
+}
@@ -0,0 +1,25 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+)
+
+// UserCredentialRepository loads and persists an entities.UserCredential
+type UserCredentialRepository interface {
+	// Store a new entities.UserCredential
+	Store(ctx context.Context, credential *entities.UserCredential) error
+
+	// Update an entities.UserCredential
+	Update(ctx context.Context, credential *entities.UserCredential) error
+
+	// LoadByEmail loads an entities.UserCredential by the email address
+	LoadByEmail(ctx context.Context, email string) (*entities.UserCredential, error)
+
+	// LoadByVerificationToken loads an entities.UserCredential by the hash of the email verification token
+	LoadByVerificationToken(ctx context.Context, tokenHash string) (*entities.UserCredential, error)
+
+	// LoadByResetToken loads an entities.UserCredential by the hash of the password reset token
+	LoadByResetToken(ctx context.Context, tokenHash string) (*entities.UserCredential, error)
+}
@@ -0,0 +1,14 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+)
+
+// UserDataRepository erases all the data of an entities.User
+type UserDataRepository interface {
+	// Erase deletes the rows of a user from every table with a user_id column in every database and then the entities.User.
+	// It returns the number of rows which were deleted.
+	Erase(ctx context.Context, userID entities.UserID) (int64, error)
+}
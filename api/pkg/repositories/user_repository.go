@@ -25,4 +25,13 @@ type UserRepository interface {
 
 	// LoadBySubscriptionID loads a user based on the lemonsqueezy subscriptionID
 	LoadBySubscriptionID(ctx context.Context, subscriptionID string) (*entities.User, error)
+
+	// RotateAPIKey replaces the API key of an entities.User
+	RotateAPIKey(ctx context.Context, userID entities.UserID) (*entities.User, error)
+
+	// IndexIDs fetches the IDs of all the users of the instance ordered by creation date
+	IndexIDs(ctx context.Context, params IndexParams) ([]entities.UserID, error)
+
+	// FetchWithRetentionPolicy fetches the entities.User which delete or anonymize their messages after some time
+	FetchWithRetentionPolicy(ctx context.Context) ([]*entities.User, error)
 }
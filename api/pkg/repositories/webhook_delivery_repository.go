@@ -0,0 +1,44 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/google/uuid"
+)
+
+// WebhookDeliveryIndexParams are parameters for fetching the entities.WebhookDelivery of a webhook
+type WebhookDeliveryIndexParams struct {
+	IndexParams
+	WebhookID uuid.UUID
+	Status    *entities.WebhookDeliveryStatus
+}
+
+// WebhookDeliveryRepository loads and persists an entities.WebhookDelivery
+type WebhookDeliveryRepository interface {
+	// Store a new entities.WebhookDelivery
+	Store(ctx context.Context, delivery *entities.WebhookDelivery) error
+
+	// Update the attempts and status of an entities.WebhookDelivery
+	Update(ctx context.Context, delivery *entities.WebhookDelivery) error
+
+	// Load an entities.WebhookDelivery by ID
+	Load(ctx context.Context, userID entities.UserID, deliveryID uuid.UUID) (*entities.WebhookDelivery, error)
+
+	// Index fetches the entities.WebhookDelivery of a webhook with the latest first
+	Index(ctx context.Context, userID entities.UserID, params WebhookDeliveryIndexParams) ([]*entities.WebhookDelivery, error)
+
+	// IndexFailed fetches the failed entities.WebhookDelivery of all the webhooks of a user with the latest first
+	IndexFailed(ctx context.Context, userID entities.UserID, params IndexParams) ([]*entities.WebhookDelivery, error)
+
+	// FetchDue fetches the entities.WebhookDelivery of all users which should be retried at the timestamp
+	FetchDue(ctx context.Context, timestamp time.Time, limit int) ([]*entities.WebhookDelivery, error)
+
+	// Claim moves the next attempt of a due entities.WebhookDelivery to leaseUntil so that it is retried by a single worker.
+	// It returns false when the delivery was claimed by another worker.
+	Claim(ctx context.Context, delivery *entities.WebhookDelivery, leaseUntil time.Time) (bool, error)
+
+	// Release schedules the held entities.WebhookDelivery of a user to be retried at the timestamp
+	Release(ctx context.Context, userID entities.UserID, timestamp time.Time) (int64, error)
+}
@@ -0,0 +1,56 @@
+package requests
+
+import (
+	"strings"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
+)
+
+// AccessLogIndex is the payload for fetching entities.AccessLog of the instance
+type AccessLogIndex struct {
+	request
+	Skip   string `json:"skip" query:"skip"`
+	Query  string `json:"query" query:"query"`
+	UserID string `json:"user_id" query:"user_id"`
+	Status string `json:"status" query:"status"`
+	Limit  string `json:"limit" query:"limit"`
+}
+
+// Sanitize sets defaults to AccessLogIndex
+func (input *AccessLogIndex) Sanitize() AccessLogIndex {
+	if strings.TrimSpace(input.Limit) == "" {
+		input.Limit = "20"
+	}
+	input.Query = strings.TrimSpace(input.Query)
+	input.UserID = strings.TrimSpace(input.UserID)
+	input.Status = strings.TrimSpace(input.Status)
+	input.Skip = strings.TrimSpace(input.Skip)
+	if input.Skip == "" {
+		input.Skip = "0"
+	}
+	return *input
+}
+
+// ToIndexParams converts AccessLogIndex to repositories.AccessLogIndexParams
+func (input *AccessLogIndex) ToIndexParams() repositories.AccessLogIndexParams {
+	params := repositories.AccessLogIndexParams{
+		IndexParams: repositories.IndexParams{
+			Skip:  input.getInt(input.Skip),
+			Query: input.Query,
+			Limit: input.getInt(input.Limit),
+		},
+	}
+
+	if input.UserID != "" {
+		userID := entities.UserID(input.UserID)
+		params.UserID = &userID
+	}
+
+	if input.Status != "" {
+		status := input.getInt(input.Status)
+		params.Status = &status
+	}
+
+	return params
+}
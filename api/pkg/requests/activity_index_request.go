@@ -0,0 +1,54 @@
+package requests
+
+import (
+	"strings"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+)
+
+// ActivityIndex is the payload for fetching the activity feed of a user
+type ActivityIndex struct {
+	request
+	// Category is a comma separated list of activity categories e.g. message,webhook
+	Category string `json:"category" query:"category"`
+	Cursor   string `json:"cursor" query:"cursor"`
+	Limit    string `json:"limit" query:"limit"`
+}
+
+// Sanitize sets defaults to ActivityIndex
+func (input *ActivityIndex) Sanitize() ActivityIndex {
+	if strings.TrimSpace(input.Limit) == "" {
+		input.Limit = "20"
+	}
+
+	categories := make([]string, 0)
+	for _, category := range strings.Split(input.Category, ",") {
+		if category = strings.ToLower(strings.TrimSpace(category)); category != "" {
+			categories = append(categories, category)
+		}
+	}
+	input.Category = strings.Join(input.removeStringDuplicates(categories), ",")
+
+	input.Cursor = strings.TrimSpace(input.Cursor)
+	return *input
+}
+
+// ToIndexParams converts ActivityIndex to services.ActivityIndexParams
+func (input *ActivityIndex) ToIndexParams() *services.ActivityIndexParams {
+	params := &services.ActivityIndexParams{
+		Limit: input.getInt(input.Limit),
+	}
+
+	if input.Category != "" {
+		for _, category := range strings.Split(input.Category, ",") {
+			params.Categories = append(params.Categories, entities.ActivityCategory(category))
+		}
+	}
+
+	if cursor, err := services.DecodeEventCursor(input.Cursor); err == nil && input.Cursor != "" {
+		params.Cursor = cursor
+	}
+
+	return params
+}
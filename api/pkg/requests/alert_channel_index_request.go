@@ -0,0 +1,37 @@
+package requests
+
+import (
+	"strings"
+
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
+)
+
+// AlertChannelIndex is the payload for fetching entities.AlertChannel of a user
+type AlertChannelIndex struct {
+	request
+	Skip  string `json:"skip" query:"skip"`
+	Query string `json:"query" query:"query"`
+	Limit string `json:"limit" query:"limit"`
+}
+
+// Sanitize sets defaults to AlertChannelIndex
+func (input *AlertChannelIndex) Sanitize() AlertChannelIndex {
+	if strings.TrimSpace(input.Limit) == "" {
+		input.Limit = "20"
+	}
+	input.Query = strings.TrimSpace(input.Query)
+	input.Skip = strings.TrimSpace(input.Skip)
+	if input.Skip == "" {
+		input.Skip = "0"
+	}
+	return *input
+}
+
+// ToIndexParams converts AlertChannelIndex to repositories.IndexParams
+func (input *AlertChannelIndex) ToIndexParams() repositories.IndexParams {
+	return repositories.IndexParams{
+		Skip:  input.getInt(input.Skip),
+		Query: input.Query,
+		Limit: input.getInt(input.Limit),
+	}
+}
@@ -0,0 +1,33 @@
+package requests
+
+import (
+	"strings"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+	"github.com/google/uuid"
+)
+
+// AlertChannelUpdate is the payload for updating an entities.AlertChannel
+type AlertChannelUpdate struct {
+	AlertChannelStore
+	Enabled bool `json:"enabled" example:"true"`
+
+	ChannelID string `json:"channelID" swaggerignore:"true"` // used internally for validation
+}
+
+// Sanitize sets defaults to AlertChannelUpdate
+func (input *AlertChannelUpdate) Sanitize() AlertChannelUpdate {
+	input.AlertChannelStore.Sanitize()
+	input.ChannelID = strings.TrimSpace(input.ChannelID)
+	return *input
+}
+
+// ToUpdateParams converts AlertChannelUpdate to services.AlertChannelUpdateParams
+func (input *AlertChannelUpdate) ToUpdateParams(user entities.AuthUser) *services.AlertChannelUpdateParams {
+	return &services.AlertChannelUpdateParams{
+		AlertChannelStoreParams: *input.ToStoreParams(user),
+		ChannelID:               uuid.MustParse(input.ChannelID),
+		Enabled:                 input.Enabled,
+	}
+}
@@ -0,0 +1,52 @@
+package requests
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
+)
+
+// AnalyticsCarrierFiltering is the payload for detecting carrier filtering by contact phone number prefix
+type AnalyticsCarrierFiltering struct {
+	request
+	PrefixLength string `json:"prefix_length" query:"prefix_length"`
+	From         string `json:"from" query:"from"`
+	To           string `json:"to" query:"to"`
+}
+
+// Sanitize sets defaults to AnalyticsCarrierFiltering
+func (input *AnalyticsCarrierFiltering) Sanitize() AnalyticsCarrierFiltering {
+	input.PrefixLength = strings.TrimSpace(input.PrefixLength)
+	if input.PrefixLength == "" {
+		input.PrefixLength = strconv.Itoa(entities.CarrierFilteringPrefixLength)
+	}
+
+	input.To = strings.TrimSpace(input.To)
+	if input.To == "" {
+		input.To = time.Now().UTC().Format(time.RFC3339)
+	}
+
+	input.From = strings.TrimSpace(input.From)
+	if input.From == "" {
+		to, err := time.Parse(time.RFC3339, input.To)
+		if err == nil {
+			input.From = to.Add(-7 * 24 * time.Hour).Format(time.RFC3339)
+		}
+	}
+
+	return *input
+}
+
+// ToPrefixDeliveryParams converts AnalyticsCarrierFiltering to repositories.PrefixDeliveryParams
+func (input *AnalyticsCarrierFiltering) ToPrefixDeliveryParams() repositories.PrefixDeliveryParams {
+	from, _ := time.Parse(time.RFC3339, input.From)
+	to, _ := time.Parse(time.RFC3339, input.To)
+	return repositories.PrefixDeliveryParams{
+		PrefixLength: input.getInt(input.PrefixLength),
+		From:         from.UTC(),
+		To:           to.UTC(),
+	}
+}
@@ -0,0 +1,48 @@
+package requests
+
+import (
+	"strings"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
+)
+
+// AnalyticsFailureStats is the payload for counting failed messages by failure code
+type AnalyticsFailureStats struct {
+	request
+	GroupBy string `json:"group_by" query:"group_by"`
+	From    string `json:"from" query:"from"`
+	To      string `json:"to" query:"to"`
+}
+
+// Sanitize sets defaults to AnalyticsFailureStats
+func (input *AnalyticsFailureStats) Sanitize() AnalyticsFailureStats {
+	input.GroupBy = strings.TrimSpace(input.GroupBy)
+
+	input.To = strings.TrimSpace(input.To)
+	if input.To == "" {
+		input.To = time.Now().UTC().Format(time.RFC3339)
+	}
+
+	input.From = strings.TrimSpace(input.From)
+	if input.From == "" {
+		to, err := time.Parse(time.RFC3339, input.To)
+		if err == nil {
+			input.From = to.Add(-30 * 24 * time.Hour).Format(time.RFC3339)
+		}
+	}
+
+	return *input
+}
+
+// ToFailureStatsParams converts AnalyticsFailureStats to repositories.FailureStatsParams
+func (input *AnalyticsFailureStats) ToFailureStatsParams() repositories.FailureStatsParams {
+	from, _ := time.Parse(time.RFC3339, input.From)
+	to, _ := time.Parse(time.RFC3339, input.To)
+	return repositories.FailureStatsParams{
+		GroupBy: entities.TimeSeriesGroup(input.GroupBy),
+		From:    from.UTC(),
+		To:      to.UTC(),
+	}
+}
@@ -0,0 +1,58 @@
+package requests
+
+import (
+	"strings"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
+)
+
+// AnalyticsTimeSeries is the payload for fetching a time series of a metric
+type AnalyticsTimeSeries struct {
+	request
+	Metric      string `json:"metric" query:"metric"`
+	Granularity string `json:"granularity" query:"granularity"`
+	GroupBy     string `json:"group_by" query:"group_by"`
+	From        string `json:"from" query:"from"`
+	To          string `json:"to" query:"to"`
+}
+
+// Sanitize sets defaults to AnalyticsTimeSeries
+func (input *AnalyticsTimeSeries) Sanitize() AnalyticsTimeSeries {
+	input.Metric = strings.TrimSpace(input.Metric)
+	input.GroupBy = strings.TrimSpace(input.GroupBy)
+
+	input.Granularity = strings.TrimSpace(input.Granularity)
+	if input.Granularity == "" {
+		input.Granularity = string(entities.TimeSeriesGranularityDay)
+	}
+
+	input.To = strings.TrimSpace(input.To)
+	if input.To == "" {
+		input.To = time.Now().UTC().Format(time.RFC3339)
+	}
+
+	input.From = strings.TrimSpace(input.From)
+	if input.From == "" {
+		to, err := time.Parse(time.RFC3339, input.To)
+		if err == nil {
+			input.From = to.Add(-30 * 24 * time.Hour).Format(time.RFC3339)
+		}
+	}
+
+	return *input
+}
+
+// ToTimeSeriesParams converts AnalyticsTimeSeries to repositories.TimeSeriesParams
+func (input *AnalyticsTimeSeries) ToTimeSeriesParams() repositories.TimeSeriesParams {
+	from, _ := time.Parse(time.RFC3339, input.From)
+	to, _ := time.Parse(time.RFC3339, input.To)
+	return repositories.TimeSeriesParams{
+		Metric:      entities.TimeSeriesMetric(input.Metric),
+		Granularity: entities.TimeSeriesGranularity(input.Granularity),
+		GroupBy:     entities.TimeSeriesGroup(input.GroupBy),
+		From:        from.UTC(),
+		To:          to.UTC(),
+	}
+}
@@ -0,0 +1,49 @@
+package requests
+
+import (
+	"strings"
+
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+)
+
+// ArchivedMessageIndex is the payload for fetching the entities.ArchivedMessage of a user
+type ArchivedMessageIndex struct {
+	request
+	Owner   string `json:"owner" query:"owner"`
+	Contact string `json:"contact" query:"contact"`
+	Cursor  string `json:"cursor" query:"cursor"`
+	Limit   string `json:"limit" query:"limit"`
+}
+
+// Sanitize sets defaults to ArchivedMessageIndex
+func (input *ArchivedMessageIndex) Sanitize() ArchivedMessageIndex {
+	if strings.TrimSpace(input.Limit) == "" {
+		input.Limit = "20"
+	}
+
+	if input.Owner != "" {
+		input.Owner = input.sanitizeAddress(input.Owner)
+	}
+	if input.Contact != "" {
+		input.Contact = input.sanitizeAddress(input.Contact)
+	}
+
+	input.Cursor = strings.TrimSpace(input.Cursor)
+	return *input
+}
+
+// ToIndexParams converts ArchivedMessageIndex to repositories.ArchivedMessageIndexParams
+func (input *ArchivedMessageIndex) ToIndexParams() repositories.ArchivedMessageIndexParams {
+	params := repositories.ArchivedMessageIndexParams{
+		Owner:   input.Owner,
+		Contact: input.Contact,
+		Limit:   input.getInt(input.Limit),
+	}
+
+	if cursor, err := services.DecodeEventCursor(input.Cursor); err == nil && input.Cursor != "" {
+		params.Cursor = cursor
+	}
+
+	return params
+}
@@ -0,0 +1,96 @@
+package requests
+
+import (
+	"strings"
+
+	"github.com/NdoleStudio/httpsms/pkg/services"
+)
+
+// AuthSignup is the payload for registering with an email and password
+type AuthSignup struct {
+	request
+	Email    string `json:"email" example:"name@email.com"`
+	Password string `json:"password" example:"correct-horse-battery-staple"`
+}
+
+// Sanitize sets defaults to AuthSignup
+func (input *AuthSignup) Sanitize() AuthSignup {
+	input.Email = strings.ToLower(strings.TrimSpace(input.Email))
+	return *input
+}
+
+// ToSignupParams converts AuthSignup to services.AuthSignupParams
+func (input *AuthSignup) ToSignupParams() *services.AuthSignupParams {
+	return &services.AuthSignupParams{
+		Email:    input.Email,
+		Password: input.Password,
+	}
+}
+
+// AuthLogin is the payload for logging in with an email and password
+type AuthLogin struct {
+	request
+	Email    string `json:"email" example:"name@email.com"`
+	Password string `json:"password" example:"correct-horse-battery-staple"`
+}
+
+// Sanitize sets defaults to AuthLogin
+func (input *AuthLogin) Sanitize() AuthLogin {
+	input.Email = strings.ToLower(strings.TrimSpace(input.Email))
+	return *input
+}
+
+// ToLoginParams converts AuthLogin to services.AuthLoginParams
+func (input *AuthLogin) ToLoginParams(ipAddress string, userAgent string) *services.AuthLoginParams {
+	return &services.AuthLoginParams{
+		Email:     input.Email,
+		Password:  input.Password,
+		IPAddress: ipAddress,
+		UserAgent: userAgent,
+	}
+}
+
+// AuthVerifyEmail is the payload for confirming an email address with the token in the verification email
+type AuthVerifyEmail struct {
+	request
+	Token string `json:"token" example:"bq6hT3Pm1bJb7uW3kqGm0xXyQ4n8jvBl2Y0cKpQeT9M"`
+}
+
+// Sanitize sets defaults to AuthVerifyEmail
+func (input *AuthVerifyEmail) Sanitize() AuthVerifyEmail {
+	input.Token = strings.TrimSpace(input.Token)
+	return *input
+}
+
+// AuthForgotPassword is the payload for requesting a password reset email
+type AuthForgotPassword struct {
+	request
+	Email string `json:"email" example:"name@email.com"`
+}
+
+// Sanitize sets defaults to AuthForgotPassword
+func (input *AuthForgotPassword) Sanitize() AuthForgotPassword {
+	input.Email = strings.ToLower(strings.TrimSpace(input.Email))
+	return *input
+}
+
+// AuthResetPassword is the payload for choosing a new password with the token in the password reset email
+type AuthResetPassword struct {
+	request
+	Token    string `json:"token" example:"bq6hT3Pm1bJb7uW3kqGm0xXyQ4n8jvBl2Y0cKpQeT9M"`
+	Password string `json:"password" example:"correct-horse-battery-staple"`
+}
+
+// Sanitize sets defaults to AuthResetPassword
+func (input *AuthResetPassword) Sanitize() AuthResetPassword {
+	input.Token = strings.TrimSpace(input.Token)
+	return *input
+}
+
+// ToResetPasswordParams converts AuthResetPassword to services.AuthResetPasswordParams
+func (input *AuthResetPassword) ToResetPasswordParams() *services.AuthResetPasswordParams {
+	return &services.AuthResetPasswordParams{
+		Token:    input.Token,
+		Password: input.Password,
+	}
+}
@@ -0,0 +1,43 @@
+package requests
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+)
+
+// BlocklistImport is the payload for importing a CSV file into the entities.Blocklist of a user
+type BlocklistImport struct {
+	request
+	DryRun string `json:"dry_run" query:"dry_run"`
+	Reason string `json:"reason" query:"reason"`
+
+	// Content is the CSV file, it is used internally for validation
+	Content []byte `json:"-" swaggerignore:"true"`
+}
+
+// Sanitize sets defaults to BlocklistImport
+func (input *BlocklistImport) Sanitize() BlocklistImport {
+	input.DryRun = input.sanitizeBool(input.DryRun)
+	if input.DryRun == "" {
+		input.DryRun = "false"
+	}
+
+	input.Reason = strings.ToLower(strings.TrimSpace(input.Reason))
+	if input.Reason == "" {
+		input.Reason = string(entities.BlocklistReasonOptOut)
+	}
+	return *input
+}
+
+// ToImportParams converts BlocklistImport to services.BlocklistImportParams
+func (input *BlocklistImport) ToImportParams(userID entities.UserID) *services.BlocklistImportParams {
+	return &services.BlocklistImportParams{
+		UserID: userID,
+		Reason: entities.BlocklistReason(input.Reason),
+		DryRun: input.getBool(input.DryRun),
+		Reader: bytes.NewReader(input.Content),
+	}
+}
@@ -0,0 +1,37 @@
+package requests
+
+import (
+	"strings"
+
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
+)
+
+// BlocklistIndex is the payload for fetching entities.Blocklist of a user
+type BlocklistIndex struct {
+	request
+	Skip  string `json:"skip" query:"skip"`
+	Query string `json:"query" query:"query"`
+	Limit string `json:"limit" query:"limit"`
+}
+
+// Sanitize sets defaults to BlocklistIndex
+func (input *BlocklistIndex) Sanitize() BlocklistIndex {
+	if strings.TrimSpace(input.Limit) == "" {
+		input.Limit = "20"
+	}
+	input.Query = strings.TrimSpace(input.Query)
+	input.Skip = strings.TrimSpace(input.Skip)
+	if input.Skip == "" {
+		input.Skip = "0"
+	}
+	return *input
+}
+
+// ToIndexParams converts BlocklistIndex to repositories.IndexParams
+func (input *BlocklistIndex) ToIndexParams() repositories.IndexParams {
+	return repositories.IndexParams{
+		Skip:  input.getInt(input.Skip),
+		Query: input.Query,
+		Limit: input.getInt(input.Limit),
+	}
+}
@@ -0,0 +1,92 @@
+package requests
+
+import (
+	"strings"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+)
+
+// BroadcastStore is the payload for publishing an entities.Broadcast
+type BroadcastStore struct {
+	request
+	Title    string   `json:"title" example:"Scheduled maintenance"`
+	Body     string   `json:"body" example:"The API will be unavailable on Saturday from 02:00 to 03:00 UTC."`
+	Severity string   `json:"severity" example:"warning"`
+	Channels []string `json:"channels" example:"banner,email,webhook"`
+
+	// UserIDs is the audience of the broadcast, the broadcast is sent to every user when it is empty
+	UserIDs []string `json:"user_ids" example:"WB7DRDWrJZRGbYrv2CKGkqbzvqdC"`
+
+	StartsAt *time.Time `json:"starts_at" example:"2022-06-05T14:26:09.527976+03:00"`
+	EndsAt   *time.Time `json:"ends_at" example:"2022-06-06T14:26:09.527976+03:00"`
+}
+
+// Sanitize sets defaults to BroadcastStore
+func (input *BroadcastStore) Sanitize() BroadcastStore {
+	input.Title = strings.TrimSpace(input.Title)
+	input.Body = strings.TrimSpace(input.Body)
+
+	input.Severity = strings.ToLower(strings.TrimSpace(input.Severity))
+	if input.Severity == "" {
+		input.Severity = string(entities.BroadcastSeverityInfo)
+	}
+
+	channels := make([]string, 0, len(input.Channels))
+	for _, channel := range input.Channels {
+		if channel = strings.ToLower(strings.TrimSpace(channel)); channel != "" {
+			channels = append(channels, channel)
+		}
+	}
+	if len(channels) == 0 {
+		channels = append(channels, string(entities.BroadcastChannelBanner))
+	}
+	input.Channels = input.removeStringDuplicates(channels)
+
+	userIDs := make([]string, 0, len(input.UserIDs))
+	for _, userID := range input.UserIDs {
+		if userID = strings.TrimSpace(userID); userID != "" {
+			userIDs = append(userIDs, userID)
+		}
+	}
+	input.UserIDs = input.removeStringDuplicates(userIDs)
+
+	return *input
+}
+
+// ToStoreParams converts BroadcastStore to services.BroadcastStoreParams
+func (input *BroadcastStore) ToStoreParams(source string, userID entities.UserID) *services.BroadcastStoreParams {
+	startsAt := time.Now().UTC()
+	if input.StartsAt != nil {
+		startsAt = input.StartsAt.UTC()
+	}
+
+	var endsAt *time.Time
+	if input.EndsAt != nil {
+		value := input.EndsAt.UTC()
+		endsAt = &value
+	}
+
+	channels := make([]entities.BroadcastChannel, 0, len(input.Channels))
+	for _, channel := range input.Channels {
+		channels = append(channels, entities.BroadcastChannel(channel))
+	}
+
+	userIDs := make([]entities.UserID, 0, len(input.UserIDs))
+	for _, item := range input.UserIDs {
+		userIDs = append(userIDs, entities.UserID(item))
+	}
+
+	return &services.BroadcastStoreParams{
+		Source:    source,
+		CreatedBy: userID,
+		Title:     input.Title,
+		Body:      input.Body,
+		Severity:  entities.BroadcastSeverity(input.Severity),
+		Channels:  channels,
+		UserIDs:   userIDs,
+		StartsAt:  startsAt,
+		EndsAt:    endsAt,
+	}
+}
@@ -0,0 +1,103 @@
+package requests
+
+import (
+	"strings"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+	"github.com/google/uuid"
+)
+
+// CampaignStore is the payload for creating an entities.Campaign
+type CampaignStore struct {
+	request
+	Name string `json:"name" example:"Weekly reminder"`
+	From string `json:"from" example:"+18005550199"`
+	SIM  string `json:"sim" example:"SIM1"`
+
+	// SegmentID or GroupID selects the contacts who receive the messages, exactly one of them must be set
+	SegmentID string `json:"segment_id" example:"32343a19-da5e-4b1b-a767-3298a73703cc"`
+	GroupID   string `json:"group_id" example:""`
+
+	// Content can contain {phone_number} and {attribute.name} placeholders which are replaced for each contact
+	Content string `json:"content" example:"Hi {attribute.name}, your appointment is tomorrow"`
+
+	// TemplateID is an approved template whose content is sent instead of the content field
+	TemplateID string `json:"template_id" example:""`
+
+	// Cron is a 5 field cron expression, IntervalMinutes is used when it is empty
+	Cron            string `json:"cron" example:"0 9 * * 1"`
+	IntervalMinutes uint   `json:"interval_minutes" example:"0"`
+	Timezone        string `json:"timezone" example:"Europe/Helsinki"`
+	IsActive        bool   `json:"is_active" example:"true"`
+}
+
+// Sanitize sets defaults to CampaignStore
+func (input *CampaignStore) Sanitize() CampaignStore {
+	input.Name = strings.TrimSpace(input.Name)
+	input.From = input.sanitizeAddress(input.From)
+	input.SIM = strings.ToUpper(strings.TrimSpace(input.SIM))
+	if input.SIM == "" {
+		input.SIM = string(entities.SIMDefault)
+	}
+	input.SegmentID = strings.TrimSpace(input.SegmentID)
+	input.GroupID = strings.TrimSpace(input.GroupID)
+	input.TemplateID = strings.TrimSpace(input.TemplateID)
+	input.Cron = strings.Join(strings.Fields(input.Cron), " ")
+	input.Timezone = strings.TrimSpace(input.Timezone)
+	if input.Timezone == "" {
+		input.Timezone = "UTC"
+	}
+	return *input
+}
+
+// ToStoreParams converts CampaignStore to services.CampaignStoreParams
+func (input *CampaignStore) ToStoreParams(userID entities.UserID) *services.CampaignStoreParams {
+	params := &services.CampaignStoreParams{
+		UserID:          userID,
+		Name:            input.Name,
+		Owner:           input.From,
+		SIM:             entities.SIM(input.SIM),
+		Content:         input.Content,
+		Cron:            input.Cron,
+		IntervalMinutes: input.IntervalMinutes,
+		Timezone:        input.Timezone,
+		IsActive:        input.IsActive,
+	}
+
+	if input.GroupID != "" {
+		groupID := uuid.MustParse(input.GroupID)
+		params.GroupID = &groupID
+	} else {
+		segmentID := uuid.MustParse(input.SegmentID)
+		params.SegmentID = &segmentID
+	}
+
+	if input.TemplateID != "" {
+		templateID := uuid.MustParse(input.TemplateID)
+		params.TemplateID = &templateID
+	}
+
+	return params
+}
+
+// CampaignUpdate is the payload for updating an entities.Campaign
+type CampaignUpdate struct {
+	CampaignStore
+	CampaignID string `json:"campaignID" swaggerignore:"true"` // used internally for validation
+}
+
+// Sanitize sets defaults to CampaignUpdate
+func (input *CampaignUpdate) Sanitize() CampaignUpdate {
+	input.CampaignStore.Sanitize()
+	input.CampaignID = strings.TrimSpace(input.CampaignID)
+	return *input
+}
+
+// ToUpdateParams converts CampaignUpdate to services.CampaignUpdateParams
+func (input *CampaignUpdate) ToUpdateParams(userID entities.UserID) *services.CampaignUpdateParams {
+	return &services.CampaignUpdateParams{
+		CampaignStoreParams: *input.ToStoreParams(userID),
+		CampaignID:          uuid.MustParse(input.CampaignID),
+	}
+}
@@ -0,0 +1,35 @@
+package requests
+
+import (
+	"strings"
+
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
+)
+
+// CanaryRunIndex is the payload for fetching the entities.CanaryRun of a canary
+type CanaryRunIndex struct {
+	request
+	Skip     string `json:"skip" query:"skip"`
+	Limit    string `json:"limit" query:"limit"`
+	CanaryID string `json:"canaryID" swaggerignore:"true"` // used internally for validation
+}
+
+// Sanitize sets defaults to CanaryRunIndex
+func (input *CanaryRunIndex) Sanitize() CanaryRunIndex {
+	if strings.TrimSpace(input.Limit) == "" {
+		input.Limit = "20"
+	}
+	input.Skip = strings.TrimSpace(input.Skip)
+	if input.Skip == "" {
+		input.Skip = "0"
+	}
+	return *input
+}
+
+// ToIndexParams converts CanaryRunIndex to repositories.IndexParams
+func (input *CanaryRunIndex) ToIndexParams() repositories.IndexParams {
+	return repositories.IndexParams{
+		Skip:  input.getInt(input.Skip),
+		Limit: input.getInt(input.Limit),
+	}
+}
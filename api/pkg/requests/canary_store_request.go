@@ -0,0 +1,53 @@
+package requests
+
+import (
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+)
+
+// CanaryStore is the payload for creating a new entities.Canary
+type CanaryStore struct {
+	request
+	Owner string `json:"owner" example:"+18005550199"`
+
+	// Contact is another registered phone which receives the canary message, use the owner number for a loopback canary
+	Contact string `json:"contact" example:"+18005550100"`
+
+	// IntervalSeconds is the duration in seconds between 2 canary messages
+	IntervalSeconds uint `json:"interval_seconds" example:"3600"`
+
+	// TimeoutSeconds is the duration in seconds after which a canary message which was not received fails
+	TimeoutSeconds uint `json:"timeout_seconds" example:"300"`
+}
+
+// Sanitize sets defaults to CanaryStore
+func (input *CanaryStore) Sanitize() CanaryStore {
+	input.Owner = input.sanitizeAddress(input.Owner)
+	input.Contact = input.sanitizeAddress(input.Contact)
+	if input.Contact == "" {
+		input.Contact = input.Owner
+	}
+
+	if input.IntervalSeconds == 0 {
+		input.IntervalSeconds = 60 * 60
+	}
+
+	if input.TimeoutSeconds == 0 {
+		input.TimeoutSeconds = 5 * 60
+	}
+	return *input
+}
+
+// ToStoreParams converts CanaryStore to services.CanaryStoreParams
+func (input *CanaryStore) ToStoreParams(user entities.AuthUser, source string) *services.CanaryStoreParams {
+	return &services.CanaryStoreParams{
+		UserID:   user.ID,
+		Owner:    input.Owner,
+		Contact:  input.Contact,
+		Interval: time.Duration(input.IntervalSeconds) * time.Second,
+		Timeout:  time.Duration(input.TimeoutSeconds) * time.Second,
+		Source:   source,
+	}
+}
@@ -0,0 +1,84 @@
+package requests
+
+import (
+	"strings"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+	"github.com/google/uuid"
+)
+
+// ContactGroupStore is the payload for creating an entities.ContactGroup
+type ContactGroupStore struct {
+	request
+	Name        string `json:"name" example:"Football team"`
+	Description string `json:"description" example:"Players and coaches of the football team"`
+}
+
+// Sanitize sets defaults to ContactGroupStore
+func (input *ContactGroupStore) Sanitize() ContactGroupStore {
+	input.Name = strings.TrimSpace(input.Name)
+	input.Description = strings.TrimSpace(input.Description)
+	return *input
+}
+
+// ToStoreParams converts ContactGroupStore to services.ContactGroupStoreParams
+func (input *ContactGroupStore) ToStoreParams(userID entities.UserID) *services.ContactGroupStoreParams {
+	return &services.ContactGroupStoreParams{
+		UserID:      userID,
+		Name:        input.Name,
+		Description: input.Description,
+	}
+}
+
+// ContactGroupUpdate is the payload for updating an entities.ContactGroup
+type ContactGroupUpdate struct {
+	ContactGroupStore
+	GroupID string `json:"groupID" swaggerignore:"true"` // used internally for validation
+}
+
+// Sanitize sets defaults to ContactGroupUpdate
+func (input *ContactGroupUpdate) Sanitize() ContactGroupUpdate {
+	input.ContactGroupStore.Sanitize()
+	input.GroupID = strings.TrimSpace(input.GroupID)
+	return *input
+}
+
+// ToUpdateParams converts ContactGroupUpdate to services.ContactGroupUpdateParams
+func (input *ContactGroupUpdate) ToUpdateParams(userID entities.UserID) *services.ContactGroupUpdateParams {
+	return &services.ContactGroupUpdateParams{
+		UserID:      userID,
+		GroupID:     uuid.MustParse(input.GroupID),
+		Name:        input.Name,
+		Description: input.Description,
+	}
+}
+
+// ContactGroupAddContacts is the payload for adding entities.Contact to an entities.ContactGroup
+type ContactGroupAddContacts struct {
+	request
+	GroupID    string   `json:"groupID" swaggerignore:"true"` // used internally for validation
+	ContactIDs []string `json:"contact_ids" example:"32343a19-da5e-4b1b-a767-3298a73703ca"`
+}
+
+// Sanitize sets defaults to ContactGroupAddContacts
+func (input *ContactGroupAddContacts) Sanitize() ContactGroupAddContacts {
+	input.GroupID = strings.TrimSpace(input.GroupID)
+
+	contactIDs := make([]string, 0, len(input.ContactIDs))
+	for _, contactID := range input.ContactIDs {
+		contactIDs = append(contactIDs, strings.TrimSpace(contactID))
+	}
+	input.ContactIDs = input.removeStringDuplicates(contactIDs)
+
+	return *input
+}
+
+// ToContactIDs converts the contact IDs of ContactGroupAddContacts to uuid.UUID
+func (input *ContactGroupAddContacts) ToContactIDs() []uuid.UUID {
+	contactIDs := make([]uuid.UUID, 0, len(input.ContactIDs))
+	for _, contactID := range input.ContactIDs {
+		contactIDs = append(contactIDs, uuid.MustParse(contactID))
+	}
+	return contactIDs
+}
@@ -0,0 +1,61 @@
+package requests
+
+import (
+	"strings"
+
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
+	"github.com/google/uuid"
+)
+
+// ContactIndex is the payload for fetching entities.Contact of a user
+type ContactIndex struct {
+	request
+	Skip       string `json:"skip" query:"skip"`
+	Query      string `json:"query" query:"query"`
+	IsOptedOut string `json:"is_opted_out" query:"is_opted_out"`
+	GroupID    string `json:"group_id" query:"group_id"`
+	Tag        string `json:"tag" query:"tag"`
+	SortBy     string `json:"sort_by" query:"sort_by"`
+	Limit      string `json:"limit" query:"limit"`
+}
+
+// Sanitize sets defaults to ContactIndex
+func (input *ContactIndex) Sanitize() ContactIndex {
+	if strings.TrimSpace(input.Limit) == "" {
+		input.Limit = "20"
+	}
+	input.Query = strings.TrimSpace(input.Query)
+	input.IsOptedOut = strings.ToLower(strings.TrimSpace(input.IsOptedOut))
+	input.GroupID = strings.TrimSpace(input.GroupID)
+	input.Tag = strings.ToLower(strings.TrimSpace(input.Tag))
+	input.SortBy = strings.TrimSpace(input.SortBy)
+	input.Skip = strings.TrimSpace(input.Skip)
+	if input.Skip == "" {
+		input.Skip = "0"
+	}
+	return *input
+}
+
+// ToIndexParams converts ContactIndex to repositories.ContactIndexParams
+func (input *ContactIndex) ToIndexParams() repositories.ContactIndexParams {
+	params := repositories.ContactIndexParams{
+		IndexParams: repositories.IndexParams{
+			Skip:  input.getInt(input.Skip),
+			Query: input.Query,
+			Limit: input.getInt(input.Limit),
+		},
+		SortBy: input.SortBy,
+	}
+
+	if input.IsOptedOut != "" {
+		isOptedOut := input.IsOptedOut == "true"
+		params.IsOptedOut = &isOptedOut
+	}
+
+	if input.GroupID != "" {
+		groupID := uuid.MustParse(input.GroupID)
+		params.GroupID = &groupID
+	}
+
+	return params
+}
@@ -0,0 +1,37 @@
+package requests
+
+import (
+	"strings"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+)
+
+// ContactStore is the payload for adding an entities.Contact to the contact book
+type ContactStore struct {
+	request
+	PhoneNumber string            `json:"phone_number" example:"+18005550100"`
+	Name        string            `json:"name" example:"John Doe"`
+	Tags        []string          `json:"tags" example:"customer,berlin"`
+	Attributes  map[string]string `json:"attributes" swaggertype:"object,string" example:"city:Berlin"`
+}
+
+// Sanitize sets defaults to ContactStore
+func (input *ContactStore) Sanitize() ContactStore {
+	input.PhoneNumber = input.sanitizeAddress(input.PhoneNumber)
+	input.Name = strings.TrimSpace(input.Name)
+	input.Tags = sanitizeContactTags(input.Tags)
+	input.Attributes = sanitizeContactAttributes(input.Attributes)
+	return *input
+}
+
+// ToStoreParams converts ContactStore to services.ContactStoreParams
+func (input *ContactStore) ToStoreParams(userID entities.UserID) *services.ContactStoreParams {
+	return &services.ContactStoreParams{
+		UserID:      userID,
+		PhoneNumber: input.PhoneNumber,
+		Name:        input.Name,
+		Tags:        input.Tags,
+		Attributes:  contactAttributes(input.Attributes),
+	}
+}
@@ -0,0 +1,67 @@
+package requests
+
+import (
+	"strings"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+	"github.com/google/uuid"
+)
+
+// ContactUpdate is the payload for updating the name, tags and attributes of an entities.Contact
+type ContactUpdate struct {
+	request
+	ContactID  string            `json:"contactID" swaggerignore:"true"` // used internally for validation
+	Name       string            `json:"name" example:"John Doe"`
+	Tags       []string          `json:"tags" example:"customer,berlin"`
+	Attributes map[string]string `json:"attributes" swaggertype:"object,string" example:"city:Berlin"`
+}
+
+// Sanitize sets defaults to ContactUpdate
+func (input *ContactUpdate) Sanitize() ContactUpdate {
+	input.ContactID = strings.TrimSpace(input.ContactID)
+	input.Name = strings.TrimSpace(input.Name)
+	input.Tags = sanitizeContactTags(input.Tags)
+	input.Attributes = sanitizeContactAttributes(input.Attributes)
+	return *input
+}
+
+// ToUpdateParams converts ContactUpdate to services.ContactUpdateParams
+func (input *ContactUpdate) ToUpdateParams(userID entities.UserID) *services.ContactUpdateParams {
+	return &services.ContactUpdateParams{
+		UserID:     userID,
+		ContactID:  uuid.MustParse(input.ContactID),
+		Name:       input.Name,
+		Tags:       input.Tags,
+		Attributes: contactAttributes(input.Attributes),
+	}
+}
+
+func sanitizeContactTags(values []string) []string {
+	tags := make([]string, 0, len(values))
+	seen := map[string]bool{}
+	for _, tag := range values {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag != "" && !seen[tag] {
+			seen[tag] = true
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+func sanitizeContactAttributes(values map[string]string) map[string]string {
+	attributes := make(map[string]string, len(values))
+	for key, value := range values {
+		attributes[strings.TrimSpace(key)] = strings.TrimSpace(value)
+	}
+	return attributes
+}
+
+func contactAttributes(values map[string]string) map[string]any {
+	attributes := make(map[string]any, len(values))
+	for key, value := range values {
+		attributes[key] = value
+	}
+	return attributes
+}
@@ -0,0 +1,100 @@
+package requests
+
+import (
+	"strings"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+)
+
+// ConversationIndex is the payload for fetching the entities.Conversation of a user
+type ConversationIndex struct {
+	request
+	Owner  string `json:"owner" query:"owner"`
+	Cursor string `json:"cursor" query:"cursor"`
+	Limit  string `json:"limit" query:"limit"`
+}
+
+// Sanitize sets defaults to ConversationIndex
+func (input *ConversationIndex) Sanitize() ConversationIndex {
+	if strings.TrimSpace(input.Limit) == "" {
+		input.Limit = "20"
+	}
+	input.Owner = input.sanitizeAddress(input.Owner)
+	input.Cursor = strings.TrimSpace(input.Cursor)
+	return *input
+}
+
+// ToIndexParams converts ConversationIndex to repositories.ConversationIndexParams
+func (input *ConversationIndex) ToIndexParams() repositories.ConversationIndexParams {
+	params := repositories.ConversationIndexParams{
+		Owner: input.Owner,
+		Limit: input.getInt(input.Limit),
+	}
+
+	if cursor, err := services.DecodeEventCursor(input.Cursor); err == nil && input.Cursor != "" {
+		params.Cursor = cursor
+	}
+
+	return params
+}
+
+// ConversationMessages is the payload for fetching the entities.Message between an owner and a contact
+type ConversationMessages struct {
+	request
+	Owner   string `json:"owner" query:"owner"`
+	Contact string `json:"contact" query:"contact"`
+	Cursor  string `json:"cursor" query:"cursor"`
+	Limit   string `json:"limit" query:"limit"`
+}
+
+// Sanitize sets defaults to ConversationMessages
+func (input *ConversationMessages) Sanitize() ConversationMessages {
+	if strings.TrimSpace(input.Limit) == "" {
+		input.Limit = "20"
+	}
+	input.Owner = input.sanitizeAddress(input.Owner)
+	input.Contact = input.sanitizeAddress(input.Contact)
+	input.Cursor = strings.TrimSpace(input.Cursor)
+	return *input
+}
+
+// ToParams converts ConversationMessages to services.ConversationMessagesParams
+func (input *ConversationMessages) ToParams() *services.ConversationMessagesParams {
+	params := &services.ConversationMessagesParams{
+		Owner:   input.Owner,
+		Contact: input.Contact,
+		Limit:   input.getInt(input.Limit),
+	}
+
+	if cursor, err := services.DecodeSequenceCursor(input.Cursor); err == nil && input.Cursor != "" {
+		params.Cursor = &cursor
+	}
+
+	return params
+}
+
+// ConversationRead is the payload for marking the entities.Message received from a contact as read
+type ConversationRead struct {
+	request
+	Owner   string `json:"owner" query:"owner"`
+	Contact string `json:"contact" query:"contact"`
+}
+
+// Sanitize sets defaults to ConversationRead
+func (input *ConversationRead) Sanitize() ConversationRead {
+	input.Owner = input.sanitizeAddress(input.Owner)
+	input.Contact = input.sanitizeAddress(input.Contact)
+	return *input
+}
+
+// ToParams converts ConversationRead to services.ConversationMarkReadParams
+func (input *ConversationRead) ToParams(source string, userID entities.UserID) *services.ConversationMarkReadParams {
+	return &services.ConversationMarkReadParams{
+		Source:  source,
+		UserID:  userID,
+		Owner:   input.Owner,
+		Contact: input.Contact,
+	}
+}
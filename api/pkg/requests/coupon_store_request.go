@@ -0,0 +1,57 @@
+package requests
+
+import (
+	"strings"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+)
+
+// CouponStore is the payload for creating an entities.Coupon
+type CouponStore struct {
+	request
+	Code             string `json:"code" example:"LAUNCH2022"`
+	Description      string `json:"description" example:"Launch week promotion"`
+	SubscriptionName string `json:"subscription_name" example:"pro-monthly"`
+
+	// MessageLimit replaces the monthly limit of the subscription when it is set
+	MessageLimit   *uint      `json:"message_limit" example:"5000"`
+	DurationDays   uint       `json:"duration_days" example:"30"`
+	MaxRedemptions uint       `json:"max_redemptions" example:"100"`
+	ExpiresAt      *time.Time `json:"expires_at" example:"2022-07-05T14:26:09.527976+03:00"`
+}
+
+// Sanitize sets defaults to CouponStore
+func (input *CouponStore) Sanitize() CouponStore {
+	input.Code = strings.ToUpper(strings.TrimSpace(input.Code))
+	input.Description = strings.TrimSpace(input.Description)
+	input.SubscriptionName = strings.TrimSpace(input.SubscriptionName)
+	return *input
+}
+
+// ToStoreParams converts CouponStore to services.CouponStoreParams
+func (input *CouponStore) ToStoreParams(operatorID entities.UserID) *services.CouponStoreParams {
+	return &services.CouponStoreParams{
+		Code:             input.Code,
+		Description:      input.Description,
+		SubscriptionName: entities.SubscriptionName(input.SubscriptionName),
+		MessageLimit:     input.MessageLimit,
+		DurationDays:     input.DurationDays,
+		MaxRedemptions:   input.MaxRedemptions,
+		ExpiresAt:        input.ExpiresAt,
+		CreatedBy:        operatorID,
+	}
+}
+
+// CouponRedeem is the payload for redeeming an entities.Coupon
+type CouponRedeem struct {
+	request
+	Code string `json:"code" example:"LAUNCH2022"`
+}
+
+// Sanitize sets defaults to CouponRedeem
+func (input *CouponRedeem) Sanitize() CouponRedeem {
+	input.Code = strings.ToUpper(strings.TrimSpace(input.Code))
+	return *input
+}
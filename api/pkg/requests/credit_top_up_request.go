@@ -0,0 +1,35 @@
+package requests
+
+import (
+	"strings"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+)
+
+// CreditTopUp is the payload for adding credits to the balance of a user
+type CreditTopUp struct {
+	request
+	UserID string `json:"user_id" example:"WB7DRDWrJZRGbYrv2CKGkqbzvqdC"`
+
+	// Amount is the number of credits to add, a negative amount removes credits as an adjustment
+	Amount      int64  `json:"amount" example:"1000"`
+	Description string `json:"description" example:"Invoice 2022-0042"`
+}
+
+// Sanitize sets defaults to CreditTopUp
+func (input *CreditTopUp) Sanitize() CreditTopUp {
+	input.UserID = strings.TrimSpace(input.UserID)
+	input.Description = strings.TrimSpace(input.Description)
+	return *input
+}
+
+// ToTopUpParams converts CreditTopUp to services.CreditTopUpParams
+func (input *CreditTopUp) ToTopUpParams(operatorID entities.UserID) *services.CreditTopUpParams {
+	return &services.CreditTopUpParams{
+		UserID:      entities.UserID(input.UserID),
+		Amount:      input.Amount,
+		Description: input.Description,
+		CreatedBy:   operatorID,
+	}
+}
@@ -0,0 +1,53 @@
+package requests
+
+import (
+	"strings"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
+)
+
+// CreditTransactionIndex is the payload for fetching the credit ledger of a user
+type CreditTransactionIndex struct {
+	request
+	Skip  string `json:"skip" query:"skip"`
+	Limit string `json:"limit" query:"limit"`
+
+	// From and To are optional RFC3339 timestamps which limit the ledger to a statement period
+	From string `json:"from" query:"from"`
+	To   string `json:"to" query:"to"`
+}
+
+// Sanitize sets defaults to CreditTransactionIndex
+func (input *CreditTransactionIndex) Sanitize() CreditTransactionIndex {
+	if strings.TrimSpace(input.Limit) == "" {
+		input.Limit = "20"
+	}
+	input.Skip = strings.TrimSpace(input.Skip)
+	if input.Skip == "" {
+		input.Skip = "0"
+	}
+	input.From = strings.TrimSpace(input.From)
+	input.To = strings.TrimSpace(input.To)
+	return *input
+}
+
+// ToIndexParams converts CreditTransactionIndex to repositories.CreditTransactionIndexParams
+func (input *CreditTransactionIndex) ToIndexParams() repositories.CreditTransactionIndexParams {
+	return repositories.CreditTransactionIndexParams{
+		IndexParams: repositories.IndexParams{
+			Skip:  input.getInt(input.Skip),
+			Limit: input.getInt(input.Limit),
+		},
+		From: input.getTime(input.From),
+		To:   input.getTime(input.To),
+	}
+}
+
+func (input *CreditTransactionIndex) getTime(value string) *time.Time {
+	timestamp, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil
+	}
+	return &timestamp
+}
@@ -0,0 +1,79 @@
+package requests
+
+import (
+	"strings"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+	"github.com/google/uuid"
+)
+
+// EventIndex is the payload for fetching the events of a user
+type EventIndex struct {
+	request
+	// Type is a comma separated list of event types e.g. message.phone.sent,message.phone.delivered
+	Type   string `json:"type" query:"type"`
+	Source string `json:"source" query:"source"`
+	Since  string `json:"since" query:"since"`
+	Until  string `json:"until" query:"until"`
+
+	// EntityID is the ID of a message, phone or webhook which is referenced by the event
+	EntityID string `json:"entity_id" query:"entity_id"`
+	Cursor   string `json:"cursor" query:"cursor"`
+	Limit    string `json:"limit" query:"limit"`
+}
+
+// Sanitize sets defaults to EventIndex
+func (input *EventIndex) Sanitize() EventIndex {
+	if strings.TrimSpace(input.Limit) == "" {
+		input.Limit = "50"
+	}
+
+	types := make([]string, 0)
+	for _, eventType := range strings.Split(input.Type, ",") {
+		if eventType = strings.TrimSpace(eventType); eventType != "" {
+			types = append(types, eventType)
+		}
+	}
+	input.Type = strings.Join(types, ",")
+
+	input.Source = strings.TrimSpace(input.Source)
+	input.Since = strings.TrimSpace(input.Since)
+	input.Until = strings.TrimSpace(input.Until)
+	input.EntityID = strings.TrimSpace(input.EntityID)
+	input.Cursor = strings.TrimSpace(input.Cursor)
+	return *input
+}
+
+// ToIndexParams converts EventIndex to repositories.EventIndexParams
+func (input *EventIndex) ToIndexParams() repositories.EventIndexParams {
+	params := repositories.EventIndexParams{
+		Source: input.Source,
+		Limit:  input.getInt(input.Limit),
+	}
+
+	if entityID, err := uuid.Parse(input.EntityID); err == nil {
+		params.EntityID = &entityID
+	}
+
+	if input.Type != "" {
+		params.Types = strings.Split(input.Type, ",")
+	}
+
+	if since, err := time.Parse(time.RFC3339, input.Since); err == nil {
+		since = since.UTC()
+		params.Since = &since
+	}
+
+	if until, err := time.Parse(time.RFC3339, input.Until); err == nil {
+		until = until.UTC()
+		params.Until = &until
+	}
+
+	if cursor, err := services.DecodeEventCursor(input.Cursor); err == nil && input.Cursor != "" {
+		params.Cursor = cursor
+	}
+
+	return params
+}
@@ -0,0 +1,32 @@
+package requests
+
+import (
+	"strings"
+)
+
+// EventStream is the payload for streaming the events of a user
+type EventStream struct {
+	request
+	// Type is a comma separated list of event types e.g. message.phone.sent,message.phone.delivered
+	Type string `json:"type" query:"type"`
+}
+
+// Sanitize sets defaults to EventStream
+func (input *EventStream) Sanitize() EventStream {
+	types := make([]string, 0)
+	for _, eventType := range strings.Split(input.Type, ",") {
+		if eventType = strings.TrimSpace(eventType); eventType != "" {
+			types = append(types, eventType)
+		}
+	}
+	input.Type = strings.Join(types, ",")
+	return *input
+}
+
+// ToEventTypes returns the event types of the EventStream, every event type is streamed when it is empty
+func (input *EventStream) ToEventTypes() []string {
+	if input.Type == "" {
+		return nil
+	}
+	return strings.Split(input.Type, ",")
+}
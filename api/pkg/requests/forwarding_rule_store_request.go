@@ -0,0 +1,62 @@
+package requests
+
+import (
+	"strings"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+)
+
+// ForwardingRuleStore is the payload for creating a new entities.ForwardingRule
+type ForwardingRuleStore struct {
+	request
+	Name string `json:"name" example:"Support inbox"`
+
+	// Owner and Contact are optional, the rule forwards messages received on every phone or from every sender when they are empty
+	Owner   string `json:"owner" example:"+18005550199"`
+	Contact string `json:"contact" example:"+18005550100"`
+
+	// Pattern is optional, the rule forwards every message when it is empty
+	Match   string `json:"match" example:"keyword"`
+	Pattern string `json:"pattern" example:"ORDER"`
+
+	Emails []string `json:"emails" example:"support@example.com"`
+}
+
+// Sanitize sets defaults to ForwardingRuleStore
+func (input *ForwardingRuleStore) Sanitize() ForwardingRuleStore {
+	input.Name = strings.TrimSpace(input.Name)
+	if input.Owner = strings.TrimSpace(input.Owner); input.Owner != "" {
+		input.Owner = input.sanitizeAddress(input.Owner)
+	}
+	if input.Contact = strings.TrimSpace(input.Contact); input.Contact != "" {
+		input.Contact = input.sanitizeAddress(input.Contact)
+	}
+
+	input.Match = strings.ToLower(strings.TrimSpace(input.Match))
+	if input.Match == "" {
+		input.Match = string(entities.RuleMatchKeyword)
+	}
+
+	emails := make([]string, 0, len(input.Emails))
+	for _, email := range input.Emails {
+		if email = strings.ToLower(strings.TrimSpace(email)); email != "" {
+			emails = append(emails, email)
+		}
+	}
+	input.Emails = input.removeStringDuplicates(emails)
+	return *input
+}
+
+// ToStoreParams converts ForwardingRuleStore to services.ForwardingRuleStoreParams
+func (input *ForwardingRuleStore) ToStoreParams(user entities.AuthUser) *services.ForwardingRuleStoreParams {
+	return &services.ForwardingRuleStoreParams{
+		UserID:  user.ID,
+		Name:    input.Name,
+		Owner:   input.Owner,
+		Contact: input.Contact,
+		Match:   entities.RuleMatch(input.Match),
+		Pattern: input.Pattern,
+		Emails:  input.Emails,
+	}
+}
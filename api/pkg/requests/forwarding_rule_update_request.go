@@ -0,0 +1,33 @@
+package requests
+
+import (
+	"strings"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+	"github.com/google/uuid"
+)
+
+// ForwardingRuleUpdate is the payload for updating an entities.ForwardingRule
+type ForwardingRuleUpdate struct {
+	ForwardingRuleStore
+	Enabled bool `json:"enabled" example:"true"`
+
+	RuleID string `json:"ruleID" swaggerignore:"true"` // used internally for validation
+}
+
+// Sanitize sets defaults to ForwardingRuleUpdate
+func (input *ForwardingRuleUpdate) Sanitize() ForwardingRuleUpdate {
+	input.ForwardingRuleStore.Sanitize()
+	input.RuleID = strings.TrimSpace(input.RuleID)
+	return *input
+}
+
+// ToUpdateParams converts ForwardingRuleUpdate to services.ForwardingRuleUpdateParams
+func (input *ForwardingRuleUpdate) ToUpdateParams(user entities.AuthUser) *services.ForwardingRuleUpdateParams {
+	return &services.ForwardingRuleUpdateParams{
+		ForwardingRuleStoreParams: *input.ToStoreParams(user),
+		RuleID:                    uuid.MustParse(input.RuleID),
+		Enabled:                   input.Enabled,
+	}
+}
@@ -0,0 +1,20 @@
+package requests
+
+import (
+	"strings"
+)
+
+// GraphQLQuery is the payload of a GraphQL query
+type GraphQLQuery struct {
+	request
+	Query         string                 `json:"query" example:"{ phones(first: 10) { edges { node { id phoneNumber } } } }"`
+	OperationName string                 `json:"operationName" example:""`
+	Variables     map[string]interface{} `json:"variables" swaggertype:"object"`
+}
+
+// Sanitize sets defaults to GraphQLQuery
+func (input *GraphQLQuery) Sanitize() GraphQLQuery {
+	input.Query = strings.TrimSpace(input.Query)
+	input.OperationName = strings.TrimSpace(input.OperationName)
+	return *input
+}
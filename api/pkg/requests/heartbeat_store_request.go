@@ -11,6 +11,9 @@ import (
 type HeartbeatStore struct {
 	request
 	Owner string `json:"owner"`
+
+	// Timestamp is the time on the phone when the heartbeat was sent, it is used to detect the clock skew of the phone
+	Timestamp *time.Time `json:"timestamp" example:"2022-06-05T14:26:09.527976+03:00"`
 }
 
 // Sanitize sets defaults to MessageOutstanding
@@ -22,8 +25,9 @@ func (input *HeartbeatStore) Sanitize() HeartbeatStore {
 // ToStoreParams converts HeartbeatIndex to repositories.IndexParams
 func (input *HeartbeatStore) ToStoreParams(user entities.AuthUser) services.HeartbeatStoreParams {
 	return services.HeartbeatStoreParams{
-		Owner:     input.Owner,
-		Timestamp: time.Now().UTC(),
-		UserID:    user.ID,
+		Owner:           input.Owner,
+		Timestamp:       time.Now().UTC(),
+		DeviceTimestamp: input.Timestamp,
+		UserID:          user.ID,
 	}
 }
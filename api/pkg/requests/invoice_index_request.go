@@ -0,0 +1,34 @@
+package requests
+
+import (
+	"strings"
+
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
+)
+
+// InvoiceIndex is the payload for fetching entities.Invoice of a user
+type InvoiceIndex struct {
+	request
+	Skip  string `json:"skip" query:"skip"`
+	Limit string `json:"limit" query:"limit"`
+}
+
+// Sanitize sets defaults to InvoiceIndex
+func (input *InvoiceIndex) Sanitize() InvoiceIndex {
+	if strings.TrimSpace(input.Limit) == "" {
+		input.Limit = "12"
+	}
+	input.Skip = strings.TrimSpace(input.Skip)
+	if input.Skip == "" {
+		input.Skip = "0"
+	}
+	return *input
+}
+
+// ToIndexParams converts InvoiceIndex to repositories.IndexParams
+func (input *InvoiceIndex) ToIndexParams() repositories.IndexParams {
+	return repositories.IndexParams{
+		Skip:  input.getInt(input.Skip),
+		Limit: input.getInt(input.Limit),
+	}
+}
@@ -0,0 +1,57 @@
+package requests
+
+import (
+	"strings"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+)
+
+// InvoicePeriodLayout is the format of the month of an invoice e.g. 2022-01
+const InvoicePeriodLayout = "2006-01"
+
+// InvoiceStore is the payload for generating the entities.Invoice of a month
+type InvoiceStore struct {
+	request
+	Period string `json:"period" example:"2022-01"`
+	Email  bool   `json:"email" example:"false"`
+}
+
+// Sanitize sets defaults to InvoiceStore
+func (input *InvoiceStore) Sanitize() InvoiceStore {
+	input.Period = strings.TrimSpace(input.Period)
+	return *input
+}
+
+// ToGenerateParams converts InvoiceStore to services.InvoiceGenerateParams
+func (input *InvoiceStore) ToGenerateParams(userID entities.UserID) *services.InvoiceGenerateParams {
+	period, _ := time.Parse(InvoicePeriodLayout, input.Period)
+	return &services.InvoiceGenerateParams{
+		UserID: userID,
+		Period: period,
+		Email:  input.Email,
+	}
+}
+
+// InvoiceDownload is the payload for downloading an entities.Invoice as a file
+type InvoiceDownload struct {
+	request
+	InvoiceID string `json:"invoiceID" swaggerignore:"true"` // used internally for validation
+	Format    string `json:"format" query:"format"`
+}
+
+// Sanitize sets defaults to InvoiceDownload
+func (input *InvoiceDownload) Sanitize() InvoiceDownload {
+	input.InvoiceID = strings.TrimSpace(input.InvoiceID)
+	input.Format = strings.ToLower(strings.TrimSpace(input.Format))
+	if input.Format == "" {
+		input.Format = string(services.InvoiceFormatPDF)
+	}
+	return *input
+}
+
+// ToFormat returns the services.InvoiceFormat of the file
+func (input *InvoiceDownload) ToFormat() services.InvoiceFormat {
+	return services.InvoiceFormat(input.Format)
+}
@@ -0,0 +1,17 @@
+package requests
+
+import (
+	"strings"
+)
+
+// LinkPreviewShow is the payload for fetching the preview of a link
+type LinkPreviewShow struct {
+	request
+	URL string `json:"url" query:"url"`
+}
+
+// Sanitize sets defaults to LinkPreviewShow
+func (input *LinkPreviewShow) Sanitize() LinkPreviewShow {
+	input.URL = strings.TrimSpace(input.URL)
+	return *input
+}
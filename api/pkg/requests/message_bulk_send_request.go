@@ -17,8 +17,12 @@ type MessageBulkSend struct {
 	From    string   `json:"from" example:"+18005550199"`
 	To      []string `json:"to" example:"+18005550100,+18005550100"`
 	Content string   `json:"content" example:"This is a sample text message"`
+	// GroupID adds the members of a contact group who have not opted out to the recipients
+	GroupID string `json:"group_id" example:"32343a19-da5e-4b1b-a767-3298a73703ca"`
 	// sim card to use to send the message
 	SIM entities.SIM `json:"sim" example:"DEFAULT"`
+	// SendAt schedules the messages to be dispatched to the phone at a future time
+	SendAt *time.Time `json:"send_at" example:"2022-06-05T18:00:00+03:00"`
 }
 
 // Sanitize sets defaults to MessageReceive
@@ -29,12 +33,28 @@ func (input *MessageBulkSend) Sanitize() MessageBulkSend {
 	}
 	input.To = to
 	input.From = input.sanitizeAddress(input.From)
+	input.GroupID = strings.TrimSpace(input.GroupID)
 	if strings.TrimSpace(string(input.SIM)) == "" {
 		input.SIM = entities.SIMDefault
 	}
 	return *input
 }
 
+// AddRecipients adds phone numbers to the recipients which are not already in the list
+func (input *MessageBulkSend) AddRecipients(phoneNumbers []string) {
+	existing := make(map[string]bool, len(input.To))
+	for _, to := range input.To {
+		existing[to] = true
+	}
+
+	for _, phoneNumber := range phoneNumbers {
+		if !existing[phoneNumber] {
+			existing[phoneNumber] = true
+			input.To = append(input.To, phoneNumber)
+		}
+	}
+}
+
 // ToMessageSendParams converts MessageSend to services.MessageSendParams
 func (input *MessageBulkSend) ToMessageSendParams(userID entities.UserID, source string) []services.MessageSendParams {
 	from, _ := phonenumbers.Parse(input.From, phonenumbers.UNKNOWN_REGION)
@@ -48,6 +68,7 @@ func (input *MessageBulkSend) ToMessageSendParams(userID entities.UserID, source
 			Contact:           to,
 			Content:           input.Content,
 			SIM:               input.SIM,
+			SendAt:            input.SendAt,
 		})
 	}
 
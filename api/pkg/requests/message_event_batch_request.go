@@ -0,0 +1,50 @@
+package requests
+
+import (
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/services"
+)
+
+// MessageEventBatch is the payload for storing many message events at once e.g. when the phone regains connectivity
+type MessageEventBatch struct {
+	Events []MessageEventBatchItem `json:"events"`
+}
+
+// MessageEventBatchItem is an event of a message in a MessageEventBatch
+type MessageEventBatchItem struct {
+	// MessageID is the ID of the message which emitted the event
+	MessageID string `json:"message_id" example:"32343a19-da5e-4b1b-a767-3298a73703ca"`
+
+	// Timestamp is the time when the event was emitted, Please send the timestamp in UTC with as much precision as possible
+	Timestamp time.Time `json:"timestamp" example:"2022-06-05T14:26:09.527976+03:00"`
+
+	// EventName is the type of event e.g. SENT, FAILED, DELIVERED or SKIPPED-EXPIRED
+	EventName string `json:"event_name" example:"SENT"`
+
+	// Reason is the exact error message in case the event is an error
+	Reason *string `json:"reason"`
+
+	// ResultCode is the result code of the android SmsManager in case the event is an error e.g. 4 for RESULT_ERROR_NO_SERVICE
+	ResultCode *int `json:"result_code" example:"4"`
+}
+
+// ToMessageEvent converts MessageEventBatchItem to MessageEvent
+func (input MessageEventBatchItem) ToMessageEvent() MessageEvent {
+	return MessageEvent{
+		Timestamp:  input.Timestamp,
+		EventName:  input.EventName,
+		Reason:     input.Reason,
+		ResultCode: input.ResultCode,
+		MessageID:  input.MessageID,
+	}
+}
+
+// ToMessageStoreEventParams converts MessageEventBatch to []services.MessageStoreEventParams
+func (input MessageEventBatch) ToMessageStoreEventParams(source string) []services.MessageStoreEventParams {
+	result := make([]services.MessageStoreEventParams, 0, len(input.Events))
+	for _, event := range input.Events {
+		result = append(result, event.ToMessageEvent().ToMessageStoreEventParams(source))
+	}
+	return result
+}
@@ -0,0 +1,42 @@
+package requests
+
+import (
+	"strings"
+
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+	"github.com/google/uuid"
+)
+
+// MessageEventIndex is the payload for fetching the events of an entities.Message
+type MessageEventIndex struct {
+	request
+	MessageID string `json:"messageID" swaggerignore:"true"` // used internally for validation
+	Cursor    string `json:"cursor" query:"cursor"`
+	Limit     string `json:"limit" query:"limit"`
+}
+
+// Sanitize sets defaults to MessageEventIndex
+func (input *MessageEventIndex) Sanitize() MessageEventIndex {
+	if strings.TrimSpace(input.Limit) == "" {
+		input.Limit = "50"
+	}
+	input.MessageID = strings.TrimSpace(input.MessageID)
+	input.Cursor = strings.TrimSpace(input.Cursor)
+	return *input
+}
+
+// ToIndexParams converts MessageEventIndex to repositories.EventIndexParams
+func (input *MessageEventIndex) ToIndexParams() repositories.EventIndexParams {
+	messageID := uuid.MustParse(input.MessageID)
+	params := repositories.EventIndexParams{
+		MessageID: &messageID,
+		Limit:     input.getInt(input.Limit),
+	}
+
+	if cursor, err := services.DecodeEventCursor(input.Cursor); err == nil && input.Cursor != "" {
+		params.Cursor = cursor
+	}
+
+	return params
+}
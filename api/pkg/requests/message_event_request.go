@@ -18,11 +18,15 @@ type MessageEvent struct {
 	// * SENT: is emitted when a message is sent by the mobile phone
 	// * FAILED: is event is emitted when the message could not be sent by the mobile phone
 	// * DELIVERED: is event is emitted when a delivery report has been received by the mobile phone
+	// * SKIPPED-EXPIRED: is emitted when the mobile phone did not send the message because it was received after KEY_MESSAGE_EXPIRES_AT
 	EventName string `json:"event_name" example:"SENT"`
 
 	// Reason is the exact error message in case the event is an error
 	Reason *string `json:"reason"`
 
+	// ResultCode is the result code of the android SmsManager in case the event is an error e.g. 4 for RESULT_ERROR_NO_SERVICE
+	ResultCode *int `json:"result_code" example:"4"`
+
 	MessageID string `json:"messageID" swaggerignore:"true"` // used internally for validation
 }
 
@@ -32,6 +36,7 @@ func (input MessageEvent) ToMessageStoreEventParams(source string) services.Mess
 		MessageID:    uuid.MustParse(input.MessageID),
 		Source:       source,
 		ErrorMessage: input.Reason,
+		FailureCode:  entities.MessageFailureCodeFromResultCode(input.ResultCode),
 		EventName:    entities.MessageEventName(input.EventName),
 		Timestamp:    input.Timestamp,
 	}
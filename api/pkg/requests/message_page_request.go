@@ -0,0 +1,96 @@
+package requests
+
+import (
+	"strings"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+)
+
+// MessagePageIndex is the payload for fetching a page of the entities.Message of a user with filters
+type MessagePageIndex struct {
+	request
+	Owner   string `json:"owner" query:"owner"`
+	Contact string `json:"contact" query:"contact"`
+
+	// Status is a comma separated list of message statuses e.g. sent,delivered
+	Status string `json:"status" query:"status"`
+	Type   string `json:"type" query:"type"`
+	SIM    string `json:"sim" query:"sim"`
+	Since  string `json:"since" query:"since"`
+	Until  string `json:"until" query:"until"`
+	Query  string `json:"query" query:"query"`
+	Tag    string `json:"tag" query:"tag"`
+	Cursor string `json:"cursor" query:"cursor"`
+	Limit  string `json:"limit" query:"limit"`
+}
+
+// Sanitize sets defaults to MessagePageIndex
+func (input *MessagePageIndex) Sanitize() MessagePageIndex {
+	if strings.TrimSpace(input.Limit) == "" {
+		input.Limit = "20"
+	}
+
+	statuses := make([]string, 0)
+	for _, status := range strings.Split(input.Status, ",") {
+		if status = strings.ToLower(strings.TrimSpace(status)); status != "" {
+			statuses = append(statuses, status)
+		}
+	}
+	input.Status = strings.Join(statuses, ",")
+
+	if input.Owner != "" {
+		input.Owner = input.sanitizeAddress(input.Owner)
+	}
+	if input.Contact != "" {
+		input.Contact = input.sanitizeAddress(input.Contact)
+	}
+
+	input.Type = strings.ToLower(strings.TrimSpace(input.Type))
+	input.SIM = strings.ToUpper(strings.TrimSpace(input.SIM))
+	input.Since = strings.TrimSpace(input.Since)
+	input.Until = strings.TrimSpace(input.Until)
+	input.Query = strings.TrimSpace(input.Query)
+	input.Tag = strings.ToLower(strings.TrimSpace(input.Tag))
+	input.Cursor = strings.TrimSpace(input.Cursor)
+	return *input
+}
+
+// ToIndexParams converts MessagePageIndex to repositories.MessageIndexParams
+func (input *MessagePageIndex) ToIndexParams() repositories.MessageIndexParams {
+	params := repositories.MessageIndexParams{
+		Owner:   input.Owner,
+		Contact: input.Contact,
+		SIM:     entities.SIM(input.SIM),
+		Query:   input.Query,
+		Limit:   input.getInt(input.Limit),
+	}
+
+	if input.Status != "" {
+		for _, status := range strings.Split(input.Status, ",") {
+			params.Statuses = append(params.Statuses, entities.MessageStatus(status))
+		}
+	}
+
+	if input.Type != "" {
+		params.Types = []entities.MessageType{entities.MessageType(input.Type)}
+	}
+
+	if since, err := time.Parse(time.RFC3339, input.Since); err == nil {
+		since = since.UTC()
+		params.Since = &since
+	}
+
+	if until, err := time.Parse(time.RFC3339, input.Until); err == nil {
+		until = until.UTC()
+		params.Until = &until
+	}
+
+	if cursor, err := services.DecodeEventCursor(input.Cursor); err == nil && input.Cursor != "" {
+		params.Cursor = cursor
+	}
+
+	return params
+}
@@ -21,6 +21,8 @@ type MessageReceive struct {
 	SIM entities.SIM `json:"sim" example:"DEFAULT"`
 	// Timestamp is the time when the event was emitted, Please send the timestamp in UTC with as much precision as possible
 	Timestamp time.Time `json:"timestamp" example:"2022-06-05T14:26:09.527976+03:00"`
+	// Attachments are the images or vCards of an MMS message
+	Attachments []MessageAttachment `json:"attachments"`
 }
 
 // Sanitize sets defaults to MessageReceive
@@ -37,12 +39,13 @@ func (input *MessageReceive) Sanitize() MessageReceive {
 func (input *MessageReceive) ToMessageReceiveParams(userID entities.UserID, source string) services.MessageReceiveParams {
 	phone, _ := phonenumbers.Parse(input.To, phonenumbers.UNKNOWN_REGION)
 	return services.MessageReceiveParams{
-		Source:    source,
-		Contact:   input.From,
-		UserID:    userID,
-		Timestamp: input.Timestamp,
-		Owner:     *phone,
-		Content:   input.Content,
-		SIM:       input.SIM,
+		Source:      source,
+		Contact:     input.From,
+		UserID:      userID,
+		Timestamp:   input.Timestamp,
+		Owner:       *phone,
+		Content:     input.Content,
+		SIM:         input.SIM,
+		Attachments: toAttachmentUploads(input.Attachments),
 	}
 }
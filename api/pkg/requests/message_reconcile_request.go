@@ -0,0 +1,71 @@
+package requests
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+)
+
+// MessageReconcile is the payload sent by a phone to reconcile its outbox after it was offline
+type MessageReconcile struct {
+	request
+	// Owner is the phone number of the phone which sends the messages
+	Owner    string                 `json:"owner" example:"+18005550199"`
+	Messages []MessageReconcileItem `json:"messages"`
+}
+
+// MessageReconcileItem is a message in the outbox of the phone
+type MessageReconcileItem struct {
+	MessageID string `json:"message_id" example:"32343a19-da5e-4b1b-a767-3298a73703ca"`
+
+	// State is what the phone did with the message
+	// * pending: the message is in the outbox and has not been sent
+	// * sent: the message was sent by the phone
+	// * delivered: the phone received a delivery report for the message
+	// * failed: the phone could not send the message
+	State string `json:"state" example:"sent"`
+
+	// Timestamp is the time when the phone handled the message
+	Timestamp time.Time `json:"timestamp" example:"2022-06-05T14:26:09.527976+03:00"`
+
+	// Reason is the exact error message in case the message failed
+	Reason *string `json:"reason"`
+
+	// ResultCode is the result code of the android SmsManager in case the message failed e.g. 4 for RESULT_ERROR_NO_SERVICE
+	ResultCode *int `json:"result_code" example:"4"`
+}
+
+// Sanitize sets defaults to MessageReconcile
+func (input *MessageReconcile) Sanitize() MessageReconcile {
+	input.Owner = input.sanitizeAddress(input.Owner)
+	for index := range input.Messages {
+		input.Messages[index].MessageID = strings.TrimSpace(input.Messages[index].MessageID)
+		input.Messages[index].State = strings.ToLower(strings.TrimSpace(input.Messages[index].State))
+	}
+	return *input
+}
+
+// ToReconcileParams converts MessageReconcile to services.MessageReconcileParams
+func (input *MessageReconcile) ToReconcileParams(userID entities.UserID, source string) *services.MessageReconcileParams {
+	items := make([]services.MessageReconcileItem, 0, len(input.Messages))
+	for _, message := range input.Messages {
+		items = append(items, services.MessageReconcileItem{
+			MessageID:    uuid.MustParse(message.MessageID),
+			State:        services.MessageReconcileState(message.State),
+			Timestamp:    message.Timestamp,
+			ErrorMessage: message.Reason,
+			FailureCode:  entities.MessageFailureCodeFromResultCode(message.ResultCode),
+		})
+	}
+
+	return &services.MessageReconcileParams{
+		UserID: userID,
+		Owner:  input.Owner,
+		Source: source,
+		Items:  items,
+	}
+}
@@ -0,0 +1,54 @@
+package requests
+
+import (
+	"strings"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+)
+
+// MessageSearch is the payload for searching the content of the entities.Message of a user
+type MessageSearch struct {
+	request
+
+	// Q is the search query, words in double quotes are matched as a phrase and words ending with * are matched as a prefix
+	Q       string `json:"q" query:"q"`
+	Owner   string `json:"owner" query:"owner"`
+	Contact string `json:"contact" query:"contact"`
+	Skip    string `json:"skip" query:"skip"`
+	Limit   string `json:"limit" query:"limit"`
+}
+
+// Sanitize sets defaults to MessageSearch
+func (input *MessageSearch) Sanitize() MessageSearch {
+	if strings.TrimSpace(input.Limit) == "" {
+		input.Limit = "20"
+	}
+
+	input.Skip = strings.TrimSpace(input.Skip)
+	if input.Skip == "" {
+		input.Skip = "0"
+	}
+
+	if input.Owner != "" {
+		input.Owner = input.sanitizeAddress(input.Owner)
+	}
+	if input.Contact != "" {
+		input.Contact = input.sanitizeAddress(input.Contact)
+	}
+
+	input.Q = strings.TrimSpace(input.Q)
+	return *input
+}
+
+// ToSearchParams converts MessageSearch to services.MessageSearchParams
+func (input *MessageSearch) ToSearchParams(userID entities.UserID) *services.MessageSearchParams {
+	return &services.MessageSearchParams{
+		UserID:  userID,
+		Query:   input.Q,
+		Owner:   input.Owner,
+		Contact: input.Contact,
+		Skip:    input.getInt(input.Skip),
+		Limit:   input.getInt(input.Limit),
+	}
+}
@@ -1,10 +1,12 @@
 package requests
 
 import (
+	"encoding/base64"
 	"strings"
 	"time"
 
 	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/google/uuid"
 
 	"github.com/nyaruka/phonenumbers"
 
@@ -14,11 +16,47 @@ import (
 // MessageSend is the payload for sending and SMS message
 type MessageSend struct {
 	request
-	From    string `json:"from" example:"+18005550199"`
-	To      string `json:"to" example:"+18005550100"`
-	Content string `json:"content" example:"This is a sample text message"`
+	// ID is an optional UUID generated by the client so that the request can be retried safely, the message which was already sent is returned when the ID is reused
+	ID      *string `json:"id" example:"32343a19-da5e-4b1b-a767-3298a73703ce"`
+	From    string  `json:"from" example:"+18005550199"`
+	To      string  `json:"to" example:"+18005550100"`
+	Content string  `json:"content" example:"This is a sample text message"`
 	// sim card to use to send the message
 	SIM entities.SIM `json:"sim" example:"DEFAULT"`
+	// SendAt schedules the message to be dispatched to the phone at a future time
+	SendAt *time.Time `json:"send_at" example:"2022-06-05T18:00:00+03:00"`
+	// Attachments are images or vCards which are sent as an MMS
+	Attachments []MessageAttachment `json:"attachments"`
+	// ConversationID groups the message with the replies received from the contact within 24 hours
+	ConversationID *string `json:"conversation_id" example:"bot-session-1234"`
+	// ExpiresIn is the duration in seconds after the phone is notified when the message is marked as expired, it overrides the message expiration of the phone
+	ExpiresIn *uint `json:"expires_in" example:"300"`
+	// Priority of the message, high priority messages like OTPs are dispatched to the phone before normal and low priority messages
+	Priority entities.MessagePriority `json:"priority" example:"normal"`
+	// AfterMessageID is the ID of a message which must be sent before this message is dispatched to the phone
+	AfterMessageID *string `json:"after_message_id" example:"32343a19-da5e-4b1b-a767-3298a73703cb"`
+}
+
+// MessageAttachment is an image or vCard of an MMS message
+type MessageAttachment struct {
+	Name        string `json:"name" example:"photo.jpg"`
+	ContentType string `json:"content_type" example:"image/jpeg"`
+	// Content is the base64 encoded content of the file
+	Content string `json:"content" example:"iVBORw0KGgoAAAANSUhEUgAAAAEAAAABCAYAAAAfFcSJAAAADUlEQVR42mNk+M9QDwADhgGAWjR9awAAAABJRU5ErkJggg=="`
+}
+
+// toAttachmentUploads decodes the attachments, the content is validated before it is decoded
+func toAttachmentUploads(attachments []MessageAttachment) []services.AttachmentUpload {
+	uploads := make([]services.AttachmentUpload, 0, len(attachments))
+	for _, attachment := range attachments {
+		content, _ := base64.StdEncoding.DecodeString(attachment.Content)
+		uploads = append(uploads, services.AttachmentUpload{
+			Name:        strings.TrimSpace(attachment.Name),
+			ContentType: strings.ToLower(strings.TrimSpace(attachment.ContentType)),
+			Content:     content,
+		})
+	}
+	return uploads
 }
 
 // Sanitize sets defaults to MessageReceive
@@ -28,6 +66,28 @@ func (input *MessageSend) Sanitize() MessageSend {
 	if strings.TrimSpace(string(input.SIM)) == "" {
 		input.SIM = entities.SIMDefault
 	}
+	input.Priority = entities.MessagePriority(strings.ToLower(strings.TrimSpace(string(input.Priority)))).OrDefault()
+	if input.ConversationID != nil {
+		conversationID := strings.TrimSpace(*input.ConversationID)
+		input.ConversationID = &conversationID
+		if conversationID == "" {
+			input.ConversationID = nil
+		}
+	}
+	if input.AfterMessageID != nil {
+		afterMessageID := strings.TrimSpace(*input.AfterMessageID)
+		input.AfterMessageID = &afterMessageID
+		if afterMessageID == "" {
+			input.AfterMessageID = nil
+		}
+	}
+	if input.ID != nil {
+		id := strings.ToLower(strings.TrimSpace(*input.ID))
+		input.ID = &id
+		if id == "" {
+			input.ID = nil
+		}
+	}
 	return *input
 }
 
@@ -35,6 +95,7 @@ func (input *MessageSend) Sanitize() MessageSend {
 func (input *MessageSend) ToMessageSendParams(userID entities.UserID, source string) services.MessageSendParams {
 	from, _ := phonenumbers.Parse(input.From, phonenumbers.UNKNOWN_REGION)
 	return services.MessageSendParams{
+		MessageID:         input.messageID(),
 		Source:            source,
 		Owner:             *from,
 		UserID:            userID,
@@ -42,5 +103,29 @@ func (input *MessageSend) ToMessageSendParams(userID entities.UserID, source str
 		Contact:           input.sanitizeAddress(input.To),
 		Content:           input.Content,
 		SIM:               input.SIM,
+		SendAt:            input.SendAt,
+		Attachments:       toAttachmentUploads(input.Attachments),
+		ConversationID:    input.ConversationID,
+		ExpiresIn:         input.ExpiresIn,
+		Priority:          input.Priority,
+		AfterMessageID:    input.afterMessageID(),
+	}
+}
+
+// afterMessageID parses the AfterMessageID, it is validated before it is parsed
+func (input *MessageSend) afterMessageID() *uuid.UUID {
+	if input.AfterMessageID == nil {
+		return nil
+	}
+	afterMessageID := uuid.MustParse(*input.AfterMessageID)
+	return &afterMessageID
+}
+
+// messageID parses the client generated ID, it is validated before it is parsed
+func (input *MessageSend) messageID() *uuid.UUID {
+	if input.ID == nil {
+		return nil
 	}
+	messageID := uuid.MustParse(*input.ID)
+	return &messageID
 }
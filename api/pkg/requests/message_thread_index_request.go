@@ -18,6 +18,7 @@ type MessageThreadIndex struct {
 	Query      string `json:"query" query:"query"`
 	Limit      string `json:"limit" query:"limit"`
 	Owner      string `json:"owner" query:"owner"`
+	Tag        string `json:"tag" query:"tag"`
 }
 
 // Sanitize sets defaults to MessageOutstanding
@@ -33,6 +34,7 @@ func (input *MessageThreadIndex) Sanitize() MessageThreadIndex {
 	input.IsArchived = input.sanitizeBool(input.IsArchived)
 	input.Query = strings.TrimSpace(input.Query)
 	input.Owner = input.sanitizeAddress(input.Owner)
+	input.Tag = strings.ToLower(strings.TrimSpace(input.Tag))
 
 	input.Skip = strings.TrimSpace(input.Skip)
 	if input.Skip == "" {
@@ -0,0 +1,42 @@
+package requests
+
+import (
+	"strings"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/google/uuid"
+
+	"github.com/NdoleStudio/httpsms/pkg/services"
+)
+
+// MessageThreadUnarchive is the payload for moving archived message threads back to the inbox
+type MessageThreadUnarchive struct {
+	request
+	Owner string `json:"owner" example:"+18005550199"`
+
+	// MessageThreadIDs are the IDs of the threads to unarchive, all the archived threads of the owner are unarchived when it is empty
+	MessageThreadIDs []string `json:"message_thread_ids" example:"32343a19-da5e-4b1b-a767-3298a73703ca"`
+}
+
+// Sanitize sets defaults to MessageThreadUnarchive
+func (input *MessageThreadUnarchive) Sanitize() MessageThreadUnarchive {
+	input.Owner = input.sanitizeAddress(input.Owner)
+	for index := range input.MessageThreadIDs {
+		input.MessageThreadIDs[index] = strings.TrimSpace(input.MessageThreadIDs[index])
+	}
+	return *input
+}
+
+// ToUnarchiveParams converts MessageThreadUnarchive to services.MessageThreadUnarchiveParams
+func (input *MessageThreadUnarchive) ToUnarchiveParams(userID entities.UserID) services.MessageThreadUnarchiveParams {
+	IDs := make([]uuid.UUID, 0, len(input.MessageThreadIDs))
+	for _, ID := range input.MessageThreadIDs {
+		IDs = append(IDs, uuid.MustParse(ID))
+	}
+
+	return services.MessageThreadUnarchiveParams{
+		UserID:           userID,
+		Owner:            input.Owner,
+		MessageThreadIDs: IDs,
+	}
+}
@@ -0,0 +1,47 @@
+package requests
+
+import (
+	"strings"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+)
+
+// NotificationPreference is the preference for an event on a notification channel
+type NotificationPreference struct {
+	EventType string `json:"event_type" example:"message.phone.received"`
+	Channel   string `json:"channel" example:"webhook"`
+	Enabled   bool   `json:"enabled" example:"false"`
+}
+
+// NotificationPreferencesUpdate is the payload for updating the notification preferences of a user
+type NotificationPreferencesUpdate struct {
+	request
+	Preferences []NotificationPreference `json:"preferences"`
+}
+
+// Sanitize sets defaults to NotificationPreferencesUpdate
+func (input *NotificationPreferencesUpdate) Sanitize() NotificationPreferencesUpdate {
+	for index := range input.Preferences {
+		input.Preferences[index].EventType = strings.TrimSpace(input.Preferences[index].EventType)
+		input.Preferences[index].Channel = strings.ToLower(strings.TrimSpace(input.Preferences[index].Channel))
+	}
+	return *input
+}
+
+// ToUpdateParams converts NotificationPreferencesUpdate to services.NotificationPreferencesUpdateParams
+func (input *NotificationPreferencesUpdate) ToUpdateParams(user entities.AuthUser) *services.NotificationPreferencesUpdateParams {
+	preferences := make([]services.NotificationPreferenceParams, 0, len(input.Preferences))
+	for _, preference := range input.Preferences {
+		preferences = append(preferences, services.NotificationPreferenceParams{
+			EventType: preference.EventType,
+			Channel:   entities.NotificationChannel(preference.Channel),
+			Enabled:   preference.Enabled,
+		})
+	}
+
+	return &services.NotificationPreferencesUpdateParams{
+		UserID:      user.ID,
+		Preferences: preferences,
+	}
+}
@@ -0,0 +1,50 @@
+package requests
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+)
+
+// NumberLeaseStore is the payload for allocating an owner number to a conversation or campaign
+type NumberLeaseStore struct {
+	request
+	Reference string `json:"reference" example:"order-1234"`
+
+	// Pool restricts the owner numbers which can be leased. All the phones of the user are used when it is empty.
+	Pool []string `json:"pool" example:"+18005550199,+18005550198"`
+
+	// InactivityTimeoutSeconds is the duration in seconds without messages after which the lease is released
+	InactivityTimeoutSeconds string `json:"inactivity_timeout_seconds" example:"86400"`
+}
+
+// Sanitize sets defaults to NumberLeaseStore
+func (input *NumberLeaseStore) Sanitize() NumberLeaseStore {
+	input.Reference = strings.TrimSpace(input.Reference)
+
+	var pool []string
+	for _, owner := range input.Pool {
+		pool = append(pool, input.sanitizeAddress(owner))
+	}
+	input.Pool = input.removeStringDuplicates(pool)
+
+	input.InactivityTimeoutSeconds = strings.TrimSpace(input.InactivityTimeoutSeconds)
+	if input.InactivityTimeoutSeconds == "" {
+		input.InactivityTimeoutSeconds = strconv.Itoa(24 * 60 * 60)
+	}
+	return *input
+}
+
+// ToAllocateParams converts NumberLeaseStore to services.NumberLeaseAllocateParams
+func (input *NumberLeaseStore) ToAllocateParams(user entities.AuthUser, source string) *services.NumberLeaseAllocateParams {
+	return &services.NumberLeaseAllocateParams{
+		UserID:            user.ID,
+		Reference:         input.Reference,
+		Pool:              input.Pool,
+		InactivityTimeout: time.Duration(input.getInt(input.InactivityTimeoutSeconds)) * time.Second,
+		Source:            source,
+	}
+}
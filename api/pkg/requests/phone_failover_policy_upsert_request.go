@@ -0,0 +1,46 @@
+package requests
+
+import (
+	"strings"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+)
+
+// PhoneFailoverPolicyUpsert is the payload for configuring the backup phones of an owner
+type PhoneFailoverPolicyUpsert struct {
+	request
+	// Owner is the phone number whose messages are moved when its phone goes offline
+	Owner string `json:"owner" example:"+18005550199"`
+
+	// BackupOwners are the phone numbers which send the messages of the owner in order of preference
+	BackupOwners []string `json:"backup_owners" example:"+18005550100"`
+}
+
+// Sanitize sets defaults to PhoneFailoverPolicyUpsert
+func (input *PhoneFailoverPolicyUpsert) Sanitize() PhoneFailoverPolicyUpsert {
+	input.Owner = input.sanitizeAddress(input.Owner)
+
+	// duplicates are removed without changing the order of preference
+	seen := map[string]bool{}
+	owners := make([]string, 0, len(input.BackupOwners))
+	for _, owner := range input.BackupOwners {
+		owner = input.sanitizeAddress(strings.TrimSpace(owner))
+		if owner != "" && !seen[owner] {
+			seen[owner] = true
+			owners = append(owners, owner)
+		}
+	}
+	input.BackupOwners = owners
+
+	return *input
+}
+
+// ToUpsertParams converts PhoneFailoverPolicyUpsert to services.PhoneFailoverPolicyUpsertParams
+func (input *PhoneFailoverPolicyUpsert) ToUpsertParams(user entities.AuthUser) *services.PhoneFailoverPolicyUpsertParams {
+	return &services.PhoneFailoverPolicyUpsertParams{
+		UserID:       user.ID,
+		Owner:        input.Owner,
+		BackupOwners: input.BackupOwners,
+	}
+}
@@ -0,0 +1,44 @@
+package requests
+
+import (
+	"strings"
+
+	"github.com/google/uuid"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+)
+
+// PhoneKillSwitch is the payload for enabling or disabling the kill switch of a phone
+type PhoneKillSwitch struct {
+	request
+	PhoneID string `json:"phoneID" swaggerignore:"true"` // used internally for validation
+
+	// Enabled stops the phone from sending messages until it is disabled
+	Enabled bool `json:"enabled" example:"true"`
+
+	// Reason is why the kill switch is enabled
+	Reason string `json:"reason" example:"messages are sent more than once"`
+}
+
+// Sanitize sets defaults to PhoneKillSwitch
+func (input *PhoneKillSwitch) Sanitize() PhoneKillSwitch {
+	input.Reason = strings.TrimSpace(input.Reason)
+	return *input
+}
+
+// ToKillSwitchParams converts PhoneKillSwitch to services.PhoneKillSwitchParams
+func (input *PhoneKillSwitch) ToKillSwitchParams(userID entities.UserID, source string) *services.PhoneKillSwitchParams {
+	var reason *string
+	if input.Reason != "" {
+		reason = &input.Reason
+	}
+
+	return &services.PhoneKillSwitchParams{
+		Source:  source,
+		UserID:  userID,
+		PhoneID: uuid.MustParse(input.PhoneID),
+		Enabled: input.Enabled,
+		Reason:  reason,
+	}
+}
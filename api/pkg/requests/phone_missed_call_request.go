@@ -0,0 +1,42 @@
+package requests
+
+import (
+	"strings"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+)
+
+// PhoneMissedCall is the payload for registering a call which was missed on a phone
+type PhoneMissedCall struct {
+	request
+	From string `json:"from" example:"+18005550100"`
+	To   string `json:"to" example:"+18005550199"`
+	// SIM card that received the call
+	SIM entities.SIM `json:"sim" example:"DEFAULT"`
+	// Timestamp is the time when the call was missed, Please send the timestamp in UTC with as much precision as possible
+	Timestamp time.Time `json:"timestamp" example:"2022-06-05T14:26:09.527976+03:00"`
+}
+
+// Sanitize sets defaults to PhoneMissedCall
+func (input *PhoneMissedCall) Sanitize() PhoneMissedCall {
+	input.To = input.sanitizeAddress(input.To)
+	input.From = input.sanitizeAddress(input.From)
+	if strings.TrimSpace(string(input.SIM)) == "" {
+		input.SIM = entities.SIMDefault
+	}
+	return *input
+}
+
+// ToMissedCallParams converts PhoneMissedCall to services.PhoneMissedCallParams
+func (input *PhoneMissedCall) ToMissedCallParams(userID entities.UserID, source string) services.PhoneMissedCallParams {
+	return services.PhoneMissedCallParams{
+		Source:    source,
+		Owner:     input.To,
+		Contact:   input.From,
+		SIM:       input.SIM,
+		UserID:    userID,
+		Timestamp: input.Timestamp,
+	}
+}
@@ -0,0 +1,15 @@
+package requests
+
+import "strings"
+
+// PhoneRegistrationRevoke is the payload for revoking a device with the token in the phone registered email
+type PhoneRegistrationRevoke struct {
+	request
+	Token string `json:"token" example:"bq6hT3Pm1bJb7uW3kqGm0xXyQ4n8jvBl2Y0cKpQeT9M"`
+}
+
+// Sanitize sets defaults to PhoneRegistrationRevoke
+func (input *PhoneRegistrationRevoke) Sanitize() PhoneRegistrationRevoke {
+	input.Token = strings.TrimSpace(input.Token)
+	return *input
+}
@@ -19,18 +19,57 @@ type PhoneUpsert struct {
 	// MessageExpirationSeconds is the duration in seconds after sending a message when it is considered to be expired.
 	MessageExpirationSeconds uint `json:"message_expiration_seconds" example:"12345"`
 
+	// DeliveryReportTimeoutSeconds is the duration in seconds after sending a message when it is considered to have an unknown delivery status.
+	DeliveryReportTimeoutSeconds uint `json:"delivery_report_timeout_seconds" example:"86400"`
+
+	// DuplicateCollapseDisabled stops collapsing identical messages which the carrier delivers more than once.
+	DuplicateCollapseDisabled *bool `json:"duplicate_collapse_disabled" example:"false"`
+
 	// MaxSendAttempts is the number of attempts when sending an SMS message to handle the case where the phone is offline.
 	MaxSendAttempts uint `json:"max_send_attempts" example:"2"`
 
+	// MaxInFlightMessages is the maximum number of messages sent to the phone at the same time, 0 means unlimited.
+	MaxInFlightMessages *uint `json:"max_in_flight_messages" example:"1"`
+
+	// Transport is how the phone is notified about new messages, it is either "fcm", "nats" or "mqtt"
+	Transport string `json:"transport" example:"fcm"`
+
+	// ReleaseChannel is the release channel of the android app, it is either "stable", "beta" or "alpha"
+	ReleaseChannel string `json:"release_channel" example:"stable"`
+
 	FcmToken string `json:"fcm_token" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9.eyJzd....."`
 
+	// WarmupEnabled gradually ramps up the sending rate of a new SIM to avoid carrier spam filters
+	WarmupEnabled *bool `json:"warmup_enabled" example:"true"`
+
+	// WarmupSchedule is the maximum number of messages sent on each day of the warm-up
+	WarmupSchedule []int64 `json:"warmup_schedule" example:"50,100,200,400"`
+
 	// IsDualSIM is true if the phone has more than one SIM active
 	IsDualSIM bool `json:"is_dual_sim" example:"false"`
+
+	// SIMBalancing is how messages sent with the DEFAULT SIM are spread across SIM1 and SIM2, it is either "none", "round-robin" or "least-recently-used"
+	SIMBalancing string `json:"sim_balancing" example:"round-robin"`
+
+	// SIM1HourlyLimit is the maximum number of messages sent with SIM1 in an hour, 0 means unlimited
+	SIM1HourlyLimit *uint `json:"sim1_hourly_limit" example:"100"`
+
+	// SIM1DailyLimit is the maximum number of messages sent with SIM1 in a day, 0 means unlimited
+	SIM1DailyLimit *uint `json:"sim1_daily_limit" example:"1000"`
+
+	// SIM2HourlyLimit is the maximum number of messages sent with SIM2 in an hour, 0 means unlimited
+	SIM2HourlyLimit *uint `json:"sim2_hourly_limit" example:"100"`
+
+	// SIM2DailyLimit is the maximum number of messages sent with SIM2 in a day, 0 means unlimited
+	SIM2DailyLimit *uint `json:"sim2_daily_limit" example:"1000"`
 }
 
 // Sanitize sets defaults to MessageOutstanding
 func (input *PhoneUpsert) Sanitize() PhoneUpsert {
 	input.FcmToken = strings.TrimSpace(input.FcmToken)
+	input.Transport = strings.ToLower(strings.TrimSpace(input.Transport))
+	input.ReleaseChannel = strings.ToLower(strings.TrimSpace(input.ReleaseChannel))
+	input.SIMBalancing = strings.ToLower(strings.TrimSpace(input.SIMBalancing))
 	input.PhoneNumber = input.sanitizeAddress(input.PhoneNumber)
 	return *input
 }
@@ -58,6 +97,30 @@ func (input *PhoneUpsert) ToUpsertParams(user entities.AuthUser, source string)
 		timeout = &duration
 	}
 
+	var deliveryReportTimeout *time.Duration
+	if input.DeliveryReportTimeoutSeconds != 0 {
+		duration := time.Duration(input.DeliveryReportTimeoutSeconds) * time.Second
+		deliveryReportTimeout = &duration
+	}
+
+	var transport *entities.PhoneTransport
+	if input.Transport != "" {
+		value := entities.PhoneTransport(input.Transport)
+		transport = &value
+	}
+
+	var releaseChannel *entities.PhoneReleaseChannel
+	if input.ReleaseChannel != "" {
+		value := entities.PhoneReleaseChannel(input.ReleaseChannel)
+		releaseChannel = &value
+	}
+
+	var simBalancing *entities.PhoneSIMBalancing
+	if input.SIMBalancing != "" {
+		value := entities.PhoneSIMBalancing(input.SIMBalancing)
+		simBalancing = &value
+	}
+
 	var maxSendAttempts *uint
 	if input.MaxSendAttempts != 0 {
 		maxSendAttempts = &input.MaxSendAttempts
@@ -68,9 +131,21 @@ func (input *PhoneUpsert) ToUpsertParams(user entities.AuthUser, source string)
 		PhoneNumber:               *phone,
 		MessagesPerMinute:         messagesPerMinute,
 		MessageExpirationDuration: timeout,
+		DeliveryReportTimeout:     deliveryReportTimeout,
+		DuplicateCollapseDisabled: input.DuplicateCollapseDisabled,
+		WarmupEnabled:             input.WarmupEnabled,
+		WarmupSchedule:            input.WarmupSchedule,
 		MaxSendAttempts:           maxSendAttempts,
+		MaxInFlightMessages:       input.MaxInFlightMessages,
+		Transport:                 transport,
+		ReleaseChannel:            releaseChannel,
 		FcmToken:                  fcmToken,
 		UserID:                    user.ID,
 		IsDualSIM:                 input.IsDualSIM,
+		SIMBalancing:              simBalancing,
+		SIM1HourlyLimit:           input.SIM1HourlyLimit,
+		SIM1DailyLimit:            input.SIM1DailyLimit,
+		SIM2HourlyLimit:           input.SIM2HourlyLimit,
+		SIM2DailyLimit:            input.SIM2DailyLimit,
 	}
 }
@@ -0,0 +1,71 @@
+package requests
+
+import (
+	"strings"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+)
+
+// PlanOverrideStore is the payload for extending the trial or overriding the plan of a user
+type PlanOverrideStore struct {
+	request
+	UserID           string `json:"userID" swaggerignore:"true"` // used internally for validation
+	Type             string `json:"type" example:"trial"`
+	SubscriptionName string `json:"subscription_name" example:"pro-monthly"`
+
+	// MessageLimit replaces the monthly limit of the subscription when it is set
+	MessageLimit *uint `json:"message_limit" example:"5000"`
+
+	// EndsAt is required for a trial, an override without EndsAt applies until it is deleted
+	EndsAt *time.Time `json:"ends_at" example:"2022-07-05T14:26:09.527976+03:00"`
+}
+
+// Sanitize sets defaults to PlanOverrideStore
+func (input *PlanOverrideStore) Sanitize() PlanOverrideStore {
+	input.UserID = strings.TrimSpace(input.UserID)
+	input.Type = strings.ToLower(strings.TrimSpace(input.Type))
+	input.SubscriptionName = strings.TrimSpace(input.SubscriptionName)
+	return *input
+}
+
+// ToStoreParams converts PlanOverrideStore to services.PlanOverrideStoreParams
+func (input *PlanOverrideStore) ToStoreParams(operatorID entities.UserID) *services.PlanOverrideStoreParams {
+	return &services.PlanOverrideStoreParams{
+		UserID:           entities.UserID(input.UserID),
+		Type:             entities.PlanOverrideType(input.Type),
+		SubscriptionName: entities.SubscriptionName(input.SubscriptionName),
+		MessageLimit:     input.MessageLimit,
+		EndsAt:           input.EndsAt,
+		CreatedBy:        operatorID,
+	}
+}
+
+// PlanOverrideIndex is the payload for fetching entities.PlanOverride of a user
+type PlanOverrideIndex struct {
+	request
+	Skip  string `json:"skip" query:"skip"`
+	Limit string `json:"limit" query:"limit"`
+}
+
+// Sanitize sets defaults to PlanOverrideIndex
+func (input *PlanOverrideIndex) Sanitize() PlanOverrideIndex {
+	if strings.TrimSpace(input.Limit) == "" {
+		input.Limit = "20"
+	}
+	input.Skip = strings.TrimSpace(input.Skip)
+	if input.Skip == "" {
+		input.Skip = "0"
+	}
+	return *input
+}
+
+// ToIndexParams converts PlanOverrideIndex to repositories.IndexParams
+func (input *PlanOverrideIndex) ToIndexParams() repositories.IndexParams {
+	return repositories.IndexParams{
+		Skip:  input.getInt(input.Skip),
+		Limit: input.getInt(input.Limit),
+	}
+}
@@ -0,0 +1,48 @@
+package requests
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+)
+
+// ProxySessionStore is the payload for creating a new entities.ProxySession
+type ProxySessionStore struct {
+	request
+
+	// Owner is leased from the pool of phones when it is empty
+	Owner       string `json:"owner" example:"+18005550199"`
+	FirstParty  string `json:"first_party" example:"+18005550100"`
+	SecondParty string `json:"second_party" example:"+18005550101"`
+
+	// TTLSeconds is the duration in seconds after which the session expires
+	TTLSeconds string `json:"ttl_seconds" example:"86400"`
+}
+
+// Sanitize sets defaults to ProxySessionStore
+func (input *ProxySessionStore) Sanitize() ProxySessionStore {
+	input.Owner = input.sanitizeAddress(input.Owner)
+	input.FirstParty = input.sanitizeAddress(input.FirstParty)
+	input.SecondParty = input.sanitizeAddress(input.SecondParty)
+
+	input.TTLSeconds = strings.TrimSpace(input.TTLSeconds)
+	if input.TTLSeconds == "" {
+		input.TTLSeconds = strconv.Itoa(24 * 60 * 60)
+	}
+	return *input
+}
+
+// ToStoreParams converts ProxySessionStore to services.ProxySessionStoreParams
+func (input *ProxySessionStore) ToStoreParams(user entities.AuthUser, source string) *services.ProxySessionStoreParams {
+	return &services.ProxySessionStoreParams{
+		UserID:      user.ID,
+		Owner:       input.Owner,
+		FirstParty:  input.FirstParty,
+		SecondParty: input.SecondParty,
+		TTL:         time.Duration(input.getInt(input.TTLSeconds)) * time.Second,
+		Source:      source,
+	}
+}
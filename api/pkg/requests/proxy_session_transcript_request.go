@@ -0,0 +1,35 @@
+package requests
+
+import (
+	"strings"
+
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
+)
+
+// ProxySessionTranscript is the payload for fetching the entities.ProxySessionMessage of a session
+type ProxySessionTranscript struct {
+	request
+	Skip      string `json:"skip" query:"skip"`
+	Limit     string `json:"limit" query:"limit"`
+	SessionID string `json:"sessionID" swaggerignore:"true"` // used internally for validation
+}
+
+// Sanitize sets defaults to ProxySessionTranscript
+func (input *ProxySessionTranscript) Sanitize() ProxySessionTranscript {
+	if strings.TrimSpace(input.Limit) == "" {
+		input.Limit = "100"
+	}
+	input.Skip = strings.TrimSpace(input.Skip)
+	if input.Skip == "" {
+		input.Skip = "0"
+	}
+	return *input
+}
+
+// ToIndexParams converts ProxySessionTranscript to repositories.IndexParams
+func (input *ProxySessionTranscript) ToIndexParams() repositories.IndexParams {
+	return repositories.IndexParams{
+		Skip:  input.getInt(input.Skip),
+		Limit: input.getInt(input.Limit),
+	}
+}
@@ -0,0 +1,46 @@
+package requests
+
+import (
+	"strings"
+
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
+)
+
+// ReferralAttribute is the payload for attributing a new user to the owner of a referral code
+type ReferralAttribute struct {
+	request
+	Code string `json:"code" example:"K7M2Q9XP"`
+}
+
+// Sanitize sets defaults to ReferralAttribute
+func (input *ReferralAttribute) Sanitize() ReferralAttribute {
+	input.Code = strings.ToUpper(strings.TrimSpace(input.Code))
+	return *input
+}
+
+// ReferralIndex is the payload for fetching entities.Referral of a user
+type ReferralIndex struct {
+	request
+	Skip  string `json:"skip" query:"skip"`
+	Limit string `json:"limit" query:"limit"`
+}
+
+// Sanitize sets defaults to ReferralIndex
+func (input *ReferralIndex) Sanitize() ReferralIndex {
+	if strings.TrimSpace(input.Limit) == "" {
+		input.Limit = "20"
+	}
+	input.Skip = strings.TrimSpace(input.Skip)
+	if input.Skip == "" {
+		input.Skip = "0"
+	}
+	return *input
+}
+
+// ToIndexParams converts ReferralIndex to repositories.IndexParams
+func (input *ReferralIndex) ToIndexParams() repositories.IndexParams {
+	return repositories.IndexParams{
+		Skip:  input.getInt(input.Skip),
+		Limit: input.getInt(input.Limit),
+	}
+}
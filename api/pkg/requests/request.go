@@ -70,6 +70,14 @@ func (input *request) getInt(value string) int {
 	return val
 }
 
+// getStringPointer returns nil when the value is empty
+func (input *request) getStringPointer(value string) *string {
+	if value == "" {
+		return nil
+	}
+	return &value
+}
+
 func (input *request) isDigits(value string) bool {
 	for _, c := range value {
 		if !unicode.IsDigit(c) {
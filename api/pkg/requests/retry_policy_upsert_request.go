@@ -0,0 +1,37 @@
+package requests
+
+import (
+	"strings"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+)
+
+// RetryPolicyUpsert is the payload for configuring the retry policy of a failure code
+type RetryPolicyUpsert struct {
+	request
+	// MaxRetries is the number of times a failed message is retried, use 0 to never retry
+	MaxRetries uint `json:"max_retries" example:"3"`
+
+	// DelaySeconds is the duration in seconds to wait before retrying the message
+	DelaySeconds uint `json:"delay_seconds" example:"600"`
+
+	FailureCode string `json:"failureCode" swaggerignore:"true"` // used internally for validation
+}
+
+// Sanitize sets defaults to RetryPolicyUpsert
+func (input *RetryPolicyUpsert) Sanitize() RetryPolicyUpsert {
+	input.FailureCode = strings.TrimSpace(input.FailureCode)
+	return *input
+}
+
+// ToUpsertParams converts RetryPolicyUpsert to services.RetryPolicyUpsertParams
+func (input *RetryPolicyUpsert) ToUpsertParams(user entities.AuthUser) *services.RetryPolicyUpsertParams {
+	return &services.RetryPolicyUpsertParams{
+		UserID:      user.ID,
+		FailureCode: entities.MessageFailureCode(input.FailureCode),
+		MaxRetries:  input.MaxRetries,
+		Delay:       time.Duration(input.DelaySeconds) * time.Second,
+	}
+}
@@ -0,0 +1,46 @@
+package requests
+
+import (
+	"strings"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+)
+
+// RuleStore is the payload for creating a new entities.Rule
+type RuleStore struct {
+	request
+	Owner   string `json:"owner" example:"+18005550199"`
+	Type    string `json:"type" example:"missed-call"`
+	Content string `json:"content" example:"Sorry we missed your call, how can we help you?"`
+
+	// Match, Pattern and CooldownMinutes are only used by rules with type "auto-reply"
+	Match           string `json:"match" example:"keyword"`
+	Pattern         string `json:"pattern" example:"HELP"`
+	CooldownMinutes uint   `json:"cooldown_minutes" example:"60"`
+}
+
+// Sanitize sets defaults to RuleStore
+func (input *RuleStore) Sanitize() RuleStore {
+	input.Owner = input.sanitizeAddress(input.Owner)
+	input.Type = strings.TrimSpace(input.Type)
+	input.Content = strings.TrimSpace(input.Content)
+	input.Match = strings.ToLower(strings.TrimSpace(input.Match))
+	if input.Type == string(entities.RuleTypeAutoReply) && input.Match == "" {
+		input.Match = string(entities.RuleMatchKeyword)
+	}
+	return *input
+}
+
+// ToStoreParams converts RuleStore to services.RuleStoreParams
+func (input *RuleStore) ToStoreParams(user entities.AuthUser) *services.RuleStoreParams {
+	return &services.RuleStoreParams{
+		UserID:          user.ID,
+		Owner:           input.Owner,
+		Type:            entities.RuleType(input.Type),
+		Content:         input.Content,
+		Match:           entities.RuleMatch(input.Match),
+		Pattern:         input.Pattern,
+		CooldownMinutes: input.CooldownMinutes,
+	}
+}
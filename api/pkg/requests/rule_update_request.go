@@ -0,0 +1,53 @@
+package requests
+
+import (
+	"strings"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+	"github.com/google/uuid"
+)
+
+// RuleUpdate is the payload for updating an entities.Rule
+type RuleUpdate struct {
+	request
+	Content string `json:"content" example:"Sorry we missed your call, how can we help you?"`
+	Enabled bool   `json:"enabled" example:"true"`
+
+	// Match, Pattern and CooldownMinutes are only updated on rules with type "auto-reply"
+	Match           *string `json:"match" example:"regex"`
+	Pattern         *string `json:"pattern" example:"^(HELP|INFO)$"`
+	CooldownMinutes *uint   `json:"cooldown_minutes" example:"60"`
+
+	RuleID string `json:"ruleID" swaggerignore:"true"` // used internally for validation
+}
+
+// Sanitize sets defaults to RuleUpdate
+func (input *RuleUpdate) Sanitize() RuleUpdate {
+	input.Content = strings.TrimSpace(input.Content)
+	input.RuleID = strings.TrimSpace(input.RuleID)
+	if input.Match != nil {
+		match := strings.ToLower(strings.TrimSpace(*input.Match))
+		input.Match = &match
+	}
+	return *input
+}
+
+// ToUpdateParams converts RuleUpdate to services.RuleUpdateParams
+func (input *RuleUpdate) ToUpdateParams(user entities.AuthUser) *services.RuleUpdateParams {
+	var match *entities.RuleMatch
+	if input.Match != nil {
+		value := entities.RuleMatch(*input.Match)
+		match = &value
+	}
+
+	return &services.RuleUpdateParams{
+		UserID:          user.ID,
+		RuleID:          uuid.MustParse(input.RuleID),
+		Content:         input.Content,
+		Enabled:         input.Enabled,
+		Match:           match,
+		Pattern:         input.Pattern,
+		CooldownMinutes: input.CooldownMinutes,
+	}
+}
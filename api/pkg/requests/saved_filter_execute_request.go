@@ -0,0 +1,54 @@
+package requests
+
+import (
+	"strings"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+	"github.com/google/uuid"
+)
+
+// SavedFilterExecute is the payload for fetching a page of the items which match an entities.SavedFilter
+type SavedFilterExecute struct {
+	request
+	SavedFilterID string `json:"savedFilterID" swaggerignore:"true"` // used internally for validation
+
+	// Cursor is the next_cursor of the previous page of messages
+	Cursor string `json:"cursor" query:"cursor"`
+
+	// Skip is the number of message threads to skip
+	Skip  string `json:"skip" query:"skip"`
+	Limit string `json:"limit" query:"limit"`
+}
+
+// Sanitize sets defaults to SavedFilterExecute
+func (input *SavedFilterExecute) Sanitize() SavedFilterExecute {
+	if strings.TrimSpace(input.Limit) == "" {
+		input.Limit = "20"
+	}
+
+	input.Skip = strings.TrimSpace(input.Skip)
+	if input.Skip == "" {
+		input.Skip = "0"
+	}
+
+	input.SavedFilterID = strings.TrimSpace(input.SavedFilterID)
+	input.Cursor = strings.TrimSpace(input.Cursor)
+	return *input
+}
+
+// ToExecuteParams converts SavedFilterExecute to services.SavedFilterExecuteParams
+func (input *SavedFilterExecute) ToExecuteParams(userID entities.UserID) *services.SavedFilterExecuteParams {
+	params := &services.SavedFilterExecuteParams{
+		UserID:        userID,
+		SavedFilterID: uuid.MustParse(input.SavedFilterID),
+		Skip:          input.getInt(input.Skip),
+		Limit:         input.getInt(input.Limit),
+	}
+
+	if cursor, err := services.DecodeEventCursor(input.Cursor); err == nil && input.Cursor != "" {
+		params.Cursor = cursor
+	}
+
+	return params
+}
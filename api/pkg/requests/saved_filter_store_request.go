@@ -0,0 +1,77 @@
+package requests
+
+import (
+	"strings"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+	"github.com/google/uuid"
+)
+
+// SavedFilterStore is the payload for creating an entities.SavedFilter
+type SavedFilterStore struct {
+	request
+	Name     string                       `json:"name" example:"Unread refunds"`
+	Target   string                       `json:"target" example:"messages"`
+	Filters  entities.SavedFilterCriteria `json:"filters"`
+	Position int                          `json:"position" example:"1"`
+}
+
+// Sanitize sets defaults to SavedFilterStore
+func (input *SavedFilterStore) Sanitize() SavedFilterStore {
+	input.Name = strings.TrimSpace(input.Name)
+	input.Target = strings.ToLower(strings.TrimSpace(input.Target))
+
+	if input.Filters.Owner != "" {
+		input.Filters.Owner = input.sanitizeAddress(input.Filters.Owner)
+	}
+	if input.Filters.Contact != "" {
+		input.Filters.Contact = input.sanitizeAddress(input.Filters.Contact)
+	}
+
+	statuses := make([]entities.MessageStatus, 0, len(input.Filters.Statuses))
+	for _, status := range input.Filters.Statuses {
+		if status = entities.MessageStatus(strings.ToLower(strings.TrimSpace(string(status)))); status != "" {
+			statuses = append(statuses, status)
+		}
+	}
+	input.Filters.Statuses = statuses
+
+	input.Filters.Type = entities.MessageType(strings.ToLower(strings.TrimSpace(string(input.Filters.Type))))
+	input.Filters.SIM = entities.SIM(strings.ToUpper(strings.TrimSpace(string(input.Filters.SIM))))
+	input.Filters.Query = strings.TrimSpace(input.Filters.Query)
+	input.Filters.Tag = strings.ToLower(strings.TrimSpace(input.Filters.Tag))
+	return *input
+}
+
+// ToStoreParams converts SavedFilterStore to services.SavedFilterStoreParams
+func (input *SavedFilterStore) ToStoreParams(userID entities.UserID) *services.SavedFilterStoreParams {
+	return &services.SavedFilterStoreParams{
+		UserID:   userID,
+		Name:     input.Name,
+		Target:   entities.SavedFilterTarget(input.Target),
+		Filters:  input.Filters,
+		Position: input.Position,
+	}
+}
+
+// SavedFilterUpdate is the payload for updating an entities.SavedFilter
+type SavedFilterUpdate struct {
+	SavedFilterStore
+	SavedFilterID string `json:"savedFilterID" swaggerignore:"true"` // used internally for validation
+}
+
+// Sanitize sets defaults to SavedFilterUpdate
+func (input *SavedFilterUpdate) Sanitize() SavedFilterUpdate {
+	input.SavedFilterStore.Sanitize()
+	input.SavedFilterID = strings.TrimSpace(input.SavedFilterID)
+	return *input
+}
+
+// ToUpdateParams converts SavedFilterUpdate to services.SavedFilterUpdateParams
+func (input *SavedFilterUpdate) ToUpdateParams(userID entities.UserID) *services.SavedFilterUpdateParams {
+	return &services.SavedFilterUpdateParams{
+		SavedFilterStoreParams: *input.ToStoreParams(userID),
+		SavedFilterID:          uuid.MustParse(input.SavedFilterID),
+	}
+}
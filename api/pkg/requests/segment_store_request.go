@@ -0,0 +1,85 @@
+package requests
+
+import (
+	"strings"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+	"github.com/google/uuid"
+)
+
+// SegmentStore is the payload for creating an entities.Segment
+type SegmentStore struct {
+	request
+	Name    string                   `json:"name" example:"Engaged customers"`
+	Filters []entities.SegmentFilter `json:"filters"`
+}
+
+// Sanitize sets defaults to SegmentStore
+func (input *SegmentStore) Sanitize() SegmentStore {
+	input.Name = strings.TrimSpace(input.Name)
+	input.Filters = sanitizeSegmentFilters(input.Filters)
+	return *input
+}
+
+// ToStoreParams converts SegmentStore to services.SegmentStoreParams
+func (input *SegmentStore) ToStoreParams(userID entities.UserID) *services.SegmentStoreParams {
+	return &services.SegmentStoreParams{
+		UserID:  userID,
+		Name:    input.Name,
+		Filters: input.Filters,
+	}
+}
+
+// SegmentUpdate is the payload for updating an entities.Segment
+type SegmentUpdate struct {
+	SegmentStore
+	SegmentID string `json:"segmentID" swaggerignore:"true"` // used internally for validation
+}
+
+// Sanitize sets defaults to SegmentUpdate
+func (input *SegmentUpdate) Sanitize() SegmentUpdate {
+	input.SegmentStore.Sanitize()
+	input.SegmentID = strings.TrimSpace(input.SegmentID)
+	return *input
+}
+
+// ToUpdateParams converts SegmentUpdate to services.SegmentUpdateParams
+func (input *SegmentUpdate) ToUpdateParams(userID entities.UserID) *services.SegmentUpdateParams {
+	return &services.SegmentUpdateParams{
+		UserID:    userID,
+		SegmentID: uuid.MustParse(input.SegmentID),
+		Name:      input.Name,
+		Filters:   input.Filters,
+	}
+}
+
+// SegmentPreview is the payload for counting the contacts which match filters before saving them in an entities.Segment
+type SegmentPreview struct {
+	request
+	Filters []entities.SegmentFilter `json:"filters"`
+}
+
+// Sanitize sets defaults to SegmentPreview
+func (input *SegmentPreview) Sanitize() SegmentPreview {
+	input.Filters = sanitizeSegmentFilters(input.Filters)
+	return *input
+}
+
+func sanitizeSegmentFilters(filters []entities.SegmentFilter) []entities.SegmentFilter {
+	result := make([]entities.SegmentFilter, 0, len(filters))
+	for _, filter := range filters {
+		filter.Field = entities.SegmentFilterField(strings.TrimSpace(string(filter.Field)))
+		filter.Operator = entities.SegmentFilterOperator(strings.TrimSpace(string(filter.Operator)))
+		filter.Key = strings.TrimSpace(filter.Key)
+		filter.Value = strings.TrimSpace(filter.Value)
+		if filter.Field == entities.SegmentFilterFieldTag {
+			filter.Value = strings.ToLower(filter.Value)
+		}
+		if filter.Field == entities.SegmentFilterFieldIsOptedOut {
+			filter.Value = strings.ToLower(filter.Value)
+		}
+		result = append(result, filter)
+	}
+	return result
+}
@@ -0,0 +1,57 @@
+package requests
+
+import (
+	"strings"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
+	"github.com/google/uuid"
+)
+
+// SubscriptionIndex is the payload for fetching entities.Subscription of a user
+type SubscriptionIndex struct {
+	request
+	Skip      string `json:"skip" query:"skip"`
+	Query     string `json:"query" query:"query"`
+	KeywordID string `json:"keyword_id" query:"keyword_id"`
+	Status    string `json:"status" query:"status"`
+	Limit     string `json:"limit" query:"limit"`
+}
+
+// Sanitize sets defaults to SubscriptionIndex
+func (input *SubscriptionIndex) Sanitize() SubscriptionIndex {
+	if strings.TrimSpace(input.Limit) == "" {
+		input.Limit = "20"
+	}
+	input.Query = strings.TrimSpace(input.Query)
+	input.KeywordID = strings.TrimSpace(input.KeywordID)
+	input.Status = strings.ToLower(strings.TrimSpace(input.Status))
+	input.Skip = strings.TrimSpace(input.Skip)
+	if input.Skip == "" {
+		input.Skip = "0"
+	}
+	return *input
+}
+
+// ToIndexParams converts SubscriptionIndex to repositories.SubscriptionIndexParams
+func (input *SubscriptionIndex) ToIndexParams() repositories.SubscriptionIndexParams {
+	params := repositories.SubscriptionIndexParams{
+		IndexParams: repositories.IndexParams{
+			Skip:  input.getInt(input.Skip),
+			Query: input.Query,
+			Limit: input.getInt(input.Limit),
+		},
+	}
+
+	if input.KeywordID != "" {
+		keywordID := uuid.MustParse(input.KeywordID)
+		params.KeywordID = &keywordID
+	}
+
+	if input.Status != "" {
+		status := entities.SubscriptionStatus(input.Status)
+		params.Status = &status
+	}
+
+	return params
+}
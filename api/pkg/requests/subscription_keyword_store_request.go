@@ -0,0 +1,47 @@
+package requests
+
+import (
+	"strings"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+)
+
+// subscriptionDefaultConfirmationTemplate is sent when a keyword has no confirmation template
+const subscriptionDefaultConfirmationTemplate = "Reply YES to confirm your subscription to {list}. Reply STOP to opt out."
+
+// SubscriptionKeywordStore is the payload for creating a new entities.SubscriptionKeyword
+type SubscriptionKeywordStore struct {
+	request
+	Owner                string `json:"owner" example:"+18005550199"`
+	Keyword              string `json:"keyword" example:"JOIN"`
+	List                 string `json:"list" example:"newsletter"`
+	ConfirmationTemplate string `json:"confirmation_template" example:"Reply YES to confirm your subscription to {list}. Reply STOP to opt out."`
+	WelcomeTemplate      string `json:"welcome_template" example:"You are now subscribed to {list}."`
+}
+
+// Sanitize sets defaults to SubscriptionKeywordStore
+func (input *SubscriptionKeywordStore) Sanitize() SubscriptionKeywordStore {
+	input.Owner = input.sanitizeAddress(input.Owner)
+	input.Keyword = strings.ToUpper(strings.TrimSpace(input.Keyword))
+	input.List = strings.TrimSpace(input.List)
+	input.ConfirmationTemplate = strings.TrimSpace(input.ConfirmationTemplate)
+	if input.ConfirmationTemplate == "" {
+		input.ConfirmationTemplate = subscriptionDefaultConfirmationTemplate
+	}
+	input.WelcomeTemplate = strings.TrimSpace(input.WelcomeTemplate)
+	return *input
+}
+
+// ToStoreParams converts SubscriptionKeywordStore to services.SubscriptionKeywordStoreParams
+func (input *SubscriptionKeywordStore) ToStoreParams(user entities.AuthUser) *services.SubscriptionKeywordStoreParams {
+	return &services.SubscriptionKeywordStoreParams{
+		UserID:               user.ID,
+		Owner:                input.Owner,
+		Keyword:              input.Keyword,
+		List:                 input.List,
+		ConfirmationTemplate: input.ConfirmationTemplate,
+		WelcomeTemplate:      input.WelcomeTemplate,
+		Enabled:              true,
+	}
+}
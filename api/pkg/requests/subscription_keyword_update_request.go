@@ -0,0 +1,34 @@
+package requests
+
+import (
+	"strings"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+	"github.com/google/uuid"
+)
+
+// SubscriptionKeywordUpdate is the payload for updating an entities.SubscriptionKeyword
+type SubscriptionKeywordUpdate struct {
+	SubscriptionKeywordStore
+	Enabled bool `json:"enabled" example:"true"`
+
+	KeywordID string `json:"keywordID" swaggerignore:"true"` // used internally for validation
+}
+
+// Sanitize sets defaults to SubscriptionKeywordUpdate
+func (input *SubscriptionKeywordUpdate) Sanitize() SubscriptionKeywordUpdate {
+	input.SubscriptionKeywordStore.Sanitize()
+	input.KeywordID = strings.TrimSpace(input.KeywordID)
+	return *input
+}
+
+// ToUpdateParams converts SubscriptionKeywordUpdate to services.SubscriptionKeywordUpdateParams
+func (input *SubscriptionKeywordUpdate) ToUpdateParams(user entities.AuthUser) *services.SubscriptionKeywordUpdateParams {
+	params := input.ToStoreParams(user)
+	params.Enabled = input.Enabled
+	return &services.SubscriptionKeywordUpdateParams{
+		SubscriptionKeywordStoreParams: *params,
+		KeywordID:                      uuid.MustParse(input.KeywordID),
+	}
+}
@@ -0,0 +1,67 @@
+package requests
+
+import (
+	"strings"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+	"github.com/google/uuid"
+)
+
+// TagStore is the payload for creating an entities.Tag
+type TagStore struct {
+	request
+	Name     string `json:"name" example:"customer"`
+	Color    string `json:"color" example:"#3f51b5"`
+	ParentID string `json:"parent_id" example:"32343a19-da5e-4b1b-a767-3298a73703ca"`
+}
+
+// Sanitize sets defaults to TagStore
+func (input *TagStore) Sanitize() TagStore {
+	input.Name = strings.ToLower(strings.TrimSpace(input.Name))
+	input.Color = strings.ToLower(strings.TrimSpace(input.Color))
+	input.ParentID = strings.TrimSpace(input.ParentID)
+	return *input
+}
+
+// ToStoreParams converts TagStore to services.TagStoreParams
+func (input *TagStore) ToStoreParams(userID entities.UserID) *services.TagStoreParams {
+	return &services.TagStoreParams{
+		UserID:   userID,
+		Name:     input.Name,
+		Color:    input.Color,
+		ParentID: input.parentID(),
+	}
+}
+
+func (input *TagStore) parentID() *uuid.UUID {
+	if input.ParentID == "" {
+		return nil
+	}
+	parentID := uuid.MustParse(input.ParentID)
+	return &parentID
+}
+
+// TagUpdate is the payload for updating an entities.Tag
+type TagUpdate struct {
+	TagStore
+	TagID string `json:"tagID" swaggerignore:"true"` // used internally for validation
+}
+
+// Sanitize sets defaults to TagUpdate
+func (input *TagUpdate) Sanitize() TagUpdate {
+	input.TagStore.Sanitize()
+	input.TagID = strings.TrimSpace(input.TagID)
+	return *input
+}
+
+// ToUpdateParams converts TagUpdate to services.TagUpdateParams
+func (input *TagUpdate) ToUpdateParams(userID entities.UserID) *services.TagUpdateParams {
+	return &services.TagUpdateParams{
+		UserID:   userID,
+		TagID:    uuid.MustParse(input.TagID),
+		Name:     input.Name,
+		Color:    input.Color,
+		ParentID: input.parentID(),
+	}
+}
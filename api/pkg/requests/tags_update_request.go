@@ -0,0 +1,13 @@
+package requests
+
+// TagsUpdate is the payload for setting the tags of an entities.Message or an entities.MessageThread
+type TagsUpdate struct {
+	request
+	Tags []string `json:"tags" example:"billing,urgent"`
+}
+
+// Sanitize sets defaults to TagsUpdate
+func (input *TagsUpdate) Sanitize() TagsUpdate {
+	input.Tags = sanitizeContactTags(input.Tags)
+	return *input
+}
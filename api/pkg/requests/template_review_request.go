@@ -0,0 +1,51 @@
+package requests
+
+import (
+	"strings"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+	"github.com/google/uuid"
+)
+
+// TemplateReview is the payload for approving or rejecting an entities.Template
+type TemplateReview struct {
+	request
+	TemplateID string `json:"templateID" swaggerignore:"true"` // used internally for validation
+	Status     string `json:"status" example:"approved"`
+	Note       string `json:"note" example:"Add the opt out instructions"`
+}
+
+// Sanitize sets defaults to TemplateReview
+func (input *TemplateReview) Sanitize() TemplateReview {
+	input.TemplateID = strings.TrimSpace(input.TemplateID)
+	input.Status = strings.ToLower(strings.TrimSpace(input.Status))
+	input.Note = strings.TrimSpace(input.Note)
+	return *input
+}
+
+// ToReviewParams converts TemplateReview to services.TemplateReviewParams
+func (input *TemplateReview) ToReviewParams(actorID entities.UserID) *services.TemplateReviewParams {
+	params := &services.TemplateReviewParams{
+		ActorID:    actorID,
+		TemplateID: uuid.MustParse(input.TemplateID),
+		Status:     entities.TemplateStatus(input.Status),
+	}
+	if input.Note != "" {
+		params.Note = &input.Note
+	}
+	return params
+}
+
+// UserTemplateApproval is the payload for requiring approved templates for the campaigns of a user
+type UserTemplateApproval struct {
+	request
+	UserID   string `json:"userID" swaggerignore:"true"` // used internally for validation
+	Required bool   `json:"required" example:"true"`
+}
+
+// Sanitize sets defaults to UserTemplateApproval
+func (input *UserTemplateApproval) Sanitize() UserTemplateApproval {
+	input.UserID = strings.TrimSpace(input.UserID)
+	return *input
+}
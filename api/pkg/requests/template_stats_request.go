@@ -0,0 +1,50 @@
+package requests
+
+import (
+	"strings"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+	"github.com/google/uuid"
+)
+
+// TemplateStats is the payload for fetching the entities.TemplateStats of a template
+type TemplateStats struct {
+	request
+	TemplateID string `json:"templateID" swaggerignore:"true"` // used internally for validation
+	From       string `json:"from" query:"from"`
+	To         string `json:"to" query:"to"`
+}
+
+// Sanitize sets defaults to TemplateStats
+func (input *TemplateStats) Sanitize() TemplateStats {
+	input.TemplateID = strings.TrimSpace(input.TemplateID)
+
+	input.To = strings.TrimSpace(input.To)
+	if input.To == "" {
+		input.To = time.Now().UTC().Format(time.RFC3339)
+	}
+
+	input.From = strings.TrimSpace(input.From)
+	if input.From == "" {
+		to, err := time.Parse(time.RFC3339, input.To)
+		if err == nil {
+			input.From = to.Add(-30 * 24 * time.Hour).Format(time.RFC3339)
+		}
+	}
+
+	return *input
+}
+
+// ToStatsParams converts TemplateStats to services.TemplateStatsParams, the from time is rounded down to the start of the day
+func (input *TemplateStats) ToStatsParams(userID entities.UserID) *services.TemplateStatsParams {
+	from, _ := time.Parse(time.RFC3339, input.From)
+	to, _ := time.Parse(time.RFC3339, input.To)
+	return &services.TemplateStatsParams{
+		UserID:     userID,
+		TemplateID: uuid.MustParse(input.TemplateID),
+		From:       from.UTC().Truncate(24 * time.Hour),
+		To:         to.UTC(),
+	}
+}
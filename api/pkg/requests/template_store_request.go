@@ -0,0 +1,54 @@
+package requests
+
+import (
+	"strings"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+	"github.com/google/uuid"
+)
+
+// TemplateStore is the payload for creating an entities.Template
+type TemplateStore struct {
+	request
+	Name string `json:"name" example:"Appointment reminder"`
+
+	// Content can contain {phone_number} and {attribute.name} placeholders which are replaced for each contact
+	Content string `json:"content" example:"Hi {attribute.name}, your appointment is tomorrow"`
+}
+
+// Sanitize sets defaults to TemplateStore
+func (input *TemplateStore) Sanitize() TemplateStore {
+	input.Name = strings.TrimSpace(input.Name)
+	return *input
+}
+
+// ToStoreParams converts TemplateStore to services.TemplateStoreParams
+func (input *TemplateStore) ToStoreParams(userID entities.UserID) *services.TemplateStoreParams {
+	return &services.TemplateStoreParams{
+		UserID:  userID,
+		Name:    input.Name,
+		Content: input.Content,
+	}
+}
+
+// TemplateUpdate is the payload for updating an entities.Template
+type TemplateUpdate struct {
+	TemplateStore
+	TemplateID string `json:"templateID" swaggerignore:"true"` // used internally for validation
+}
+
+// Sanitize sets defaults to TemplateUpdate
+func (input *TemplateUpdate) Sanitize() TemplateUpdate {
+	input.TemplateStore.Sanitize()
+	input.TemplateID = strings.TrimSpace(input.TemplateID)
+	return *input
+}
+
+// ToUpdateParams converts TemplateUpdate to services.TemplateUpdateParams
+func (input *TemplateUpdate) ToUpdateParams(userID entities.UserID) *services.TemplateUpdateParams {
+	return &services.TemplateUpdateParams{
+		TemplateStoreParams: *input.ToStoreParams(userID),
+		TemplateID:          uuid.MustParse(input.TemplateID),
+	}
+}
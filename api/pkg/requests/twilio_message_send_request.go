@@ -0,0 +1,48 @@
+package requests
+
+import (
+	"strings"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+)
+
+// TwilioMessageSend is the form encoded payload for sending a message with the Twilio compatible API
+type TwilioMessageSend struct {
+	request
+	AccountSID     string `json:"account_sid" form:"-" swaggerignore:"true"`
+	From           string `json:"from" form:"From" example:"+18005550199"`
+	To             string `json:"to" form:"To" example:"+18005550100"`
+	Body           string `json:"body" form:"Body" example:"This is a sample text message"`
+	StatusCallback string `json:"status_callback" form:"StatusCallback" example:"https://example.com/twilio/status"`
+}
+
+// Sanitize sets defaults to TwilioMessageSend
+func (input *TwilioMessageSend) Sanitize() TwilioMessageSend {
+	input.AccountSID = strings.TrimSpace(input.AccountSID)
+	input.To = input.sanitizeAddress(input.To)
+	input.From = input.sanitizeAddress(input.From)
+	input.StatusCallback = strings.TrimSpace(input.StatusCallback)
+	return *input
+}
+
+// ToMessageSend converts TwilioMessageSend to MessageSend so it is validated like every other message
+func (input *TwilioMessageSend) ToMessageSend() MessageSend {
+	return MessageSend{
+		From:     input.From,
+		To:       input.To,
+		Content:  input.Body,
+		SIM:      entities.SIMDefault,
+		Priority: entities.MessagePriorityNormal,
+	}
+}
+
+// ToTwilioMessageSendParams converts TwilioMessageSend to services.TwilioMessageSendParams
+func (input *TwilioMessageSend) ToTwilioMessageSendParams(userID entities.UserID, source string) services.TwilioMessageSendParams {
+	message := input.ToMessageSend()
+	return services.TwilioMessageSendParams{
+		AccountSID:     input.AccountSID,
+		StatusCallback: input.StatusCallback,
+		Message:        message.ToMessageSendParams(userID, source),
+	}
+}
@@ -0,0 +1,16 @@
+package requests
+
+import "strings"
+
+// TwoFactorCode is the payload containing a TOTP or recovery code
+type TwoFactorCode struct {
+	request
+	// Code is the 6 digit code from the authenticator app or a recovery code
+	Code string `json:"code" example:"123456"`
+}
+
+// Sanitize sets defaults to TwoFactorCode
+func (input *TwoFactorCode) Sanitize() TwoFactorCode {
+	input.Code = strings.ReplaceAll(strings.TrimSpace(input.Code), " ", "")
+	return *input
+}
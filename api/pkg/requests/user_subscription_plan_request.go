@@ -0,0 +1,24 @@
+package requests
+
+import (
+	"strings"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+)
+
+// UserSubscriptionPlan is the payload for paying for or changing to a subscription plan
+type UserSubscriptionPlan struct {
+	request
+	Plan string `json:"plan" example:"pro-monthly"`
+}
+
+// Sanitize sets defaults to UserSubscriptionPlan
+func (input *UserSubscriptionPlan) Sanitize() UserSubscriptionPlan {
+	input.Plan = strings.ToLower(strings.TrimSpace(input.Plan))
+	return *input
+}
+
+// SubscriptionName returns the plan as an entities.SubscriptionName
+func (input *UserSubscriptionPlan) SubscriptionName() entities.SubscriptionName {
+	return entities.SubscriptionName(input.Plan)
+}
@@ -0,0 +1,34 @@
+package requests
+
+import (
+	"strings"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+)
+
+// UserSuspend is the payload for suspending the account of a user
+type UserSuspend struct {
+	request
+	UserID string `json:"userID" swaggerignore:"true"` // used internally for validation
+	Reason string `json:"reason" example:"abuse"`
+}
+
+// Sanitize sets defaults to UserSuspend
+func (input *UserSuspend) Sanitize() UserSuspend {
+	input.UserID = strings.TrimSpace(input.UserID)
+	input.Reason = strings.ToLower(strings.TrimSpace(input.Reason))
+	if input.Reason == "" {
+		input.Reason = string(entities.UserSuspensionReasonAbuse)
+	}
+	return *input
+}
+
+// ToSuspendParams converts UserSuspend to services.UserSuspendParams
+func (input *UserSuspend) ToSuspendParams(source string) *services.UserSuspendParams {
+	return &services.UserSuspendParams{
+		Source: source,
+		UserID: entities.UserID(input.UserID),
+		Reason: entities.UserSuspensionReason(input.Reason),
+	}
+}
@@ -14,12 +14,48 @@ type UserUpdate struct {
 	request
 	Timezone      string `json:"timezone" example:"Europe/Helsinki"`
 	ActivePhoneID string `json:"active_phone_id" example:"32343a19-da5e-4b1b-a767-3298a73703cb"`
+
+	// NotificationDigestMinutes groups alert emails into a single email sent every N minutes, 0 disables the digest
+	NotificationDigestMinutes *uint `json:"notification_digest_minutes" example:"15"`
+
+	// MessageExpirationTimeout is the duration in seconds after the phone is notified when a message expires unless the message has an expires_in, 0 uses the setting of the phone
+	MessageExpirationTimeout *uint `json:"message_expiration_timeout" example:"300"`
+
+	// SendRateLimit is the maximum number of messages sent in a minute, 0 means unlimited
+	SendRateLimit *uint `json:"send_rate_limit" example:"60"`
+
+	// ContactSendRateLimit is the maximum number of messages sent to the same contact in a minute, 0 means unlimited
+	ContactSendRateLimit *uint `json:"contact_send_rate_limit" example:"5"`
+
+	// OptOutKeywords are the replies which add a contact to the blocklist, an empty list restores the default keywords
+	OptOutKeywords *[]string `json:"opt_out_keywords" example:"STOP,UNSUBSCRIBE"`
+
+	// OptInKeywords are the replies which remove an opted out contact from the blocklist, an empty list restores the default keywords
+	OptInKeywords *[]string `json:"opt_in_keywords" example:"START"`
+
+	// MessageThreadAutoArchiveDays archives the message threads which have no activity for this number of days, 0 disables auto archiving
+	MessageThreadAutoArchiveDays *uint `json:"message_thread_auto_archive_days" example:"90"`
+
+	// MessageRetentionDays deletes the messages which are older than this number of days, 0 keeps the messages forever
+	MessageRetentionDays *uint `json:"message_retention_days" example:"365"`
+
+	// MessageAnonymizeDays removes the content of the messages which are older than this number of days, 0 keeps the content
+	MessageAnonymizeDays *uint `json:"message_anonymize_days" example:"30"`
+
+	// Region is the database which stores the messages of the user, it can only be chosen once
+	Region *string `json:"region" example:"eu"`
 }
 
 // Sanitize sets defaults to MessageOutstanding
 func (input *UserUpdate) Sanitize() UserUpdate {
 	input.ActivePhoneID = strings.TrimSpace(input.ActivePhoneID)
 	input.Timezone = strings.TrimSpace(input.Timezone)
+	if input.Region != nil {
+		region := strings.ToLower(strings.TrimSpace(*input.Region))
+		input.Region = &region
+	}
+	input.OptOutKeywords = input.sanitizeKeywords(input.OptOutKeywords)
+	input.OptInKeywords = input.sanitizeKeywords(input.OptInKeywords)
 	return *input
 }
 
@@ -30,7 +66,30 @@ func (input *UserUpdate) ToUpdateParams() services.UserUpdateParams {
 		location = time.UTC
 	}
 	return services.UserUpdateParams{
-		ActivePhoneID: uuid.MustParse(input.ActivePhoneID),
-		Timezone:      location,
+		ActivePhoneID:                uuid.MustParse(input.ActivePhoneID),
+		Timezone:                     location,
+		NotificationDigestMinutes:    input.NotificationDigestMinutes,
+		MessageExpirationTimeout:     input.MessageExpirationTimeout,
+		SendRateLimit:                input.SendRateLimit,
+		ContactSendRateLimit:         input.ContactSendRateLimit,
+		OptOutKeywords:               input.OptOutKeywords,
+		OptInKeywords:                input.OptInKeywords,
+		MessageThreadAutoArchiveDays: input.MessageThreadAutoArchiveDays,
+		MessageRetentionDays:         input.MessageRetentionDays,
+		MessageAnonymizeDays:         input.MessageAnonymizeDays,
+		Region:                       input.Region,
+	}
+}
+
+func (input *UserUpdate) sanitizeKeywords(keywords *[]string) *[]string {
+	if keywords == nil {
+		return nil
+	}
+	result := make([]string, 0, len(*keywords))
+	for _, keyword := range *keywords {
+		if keyword = strings.ToUpper(strings.TrimSpace(keyword)); keyword != "" {
+			result = append(result, keyword)
+		}
 	}
+	return &result
 }
@@ -0,0 +1,34 @@
+package requests
+
+import (
+	"strings"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+	"github.com/google/uuid"
+)
+
+// WebhookContractTest is the payload for sending the webhook fixtures to a consumer
+type WebhookContractTest struct {
+	request
+	WebhookID string `json:"webhookID" swaggerignore:"true"` // used internally for validation
+
+	// URL receives the fixtures instead of the URL of the webhook e.g. a staging deployment of the consumer
+	URL string `json:"url" example:"https://staging.example.com/webhooks/httpsms"`
+}
+
+// Sanitize sets defaults to WebhookContractTest
+func (input *WebhookContractTest) Sanitize() WebhookContractTest {
+	input.WebhookID = strings.TrimSpace(input.WebhookID)
+	input.URL = strings.TrimSpace(input.URL)
+	return *input
+}
+
+// ToContractTestParams converts WebhookContractTest to services.WebhookContractTestParams
+func (input *WebhookContractTest) ToContractTestParams(userID entities.UserID) *services.WebhookContractTestParams {
+	return &services.WebhookContractTestParams{
+		UserID:    userID,
+		WebhookID: uuid.MustParse(input.WebhookID),
+		URL:       input.URL,
+	}
+}
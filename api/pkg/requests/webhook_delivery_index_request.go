@@ -0,0 +1,53 @@
+package requests
+
+import (
+	"strings"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+	"github.com/google/uuid"
+)
+
+// WebhookDeliveryIndex is the payload for fetching the entities.WebhookDelivery of a webhook
+type WebhookDeliveryIndex struct {
+	request
+	WebhookID string `json:"webhookID" swaggerignore:"true"` // used internally for validation
+	Skip      string `json:"skip" query:"skip"`
+	Limit     string `json:"limit" query:"limit"`
+	Status    string `json:"status" query:"status"`
+}
+
+// Sanitize sets defaults to WebhookDeliveryIndex
+func (input *WebhookDeliveryIndex) Sanitize() WebhookDeliveryIndex {
+	input.Limit = strings.TrimSpace(input.Limit)
+	if input.Limit == "" {
+		input.Limit = "20"
+	}
+	input.Skip = strings.TrimSpace(input.Skip)
+	if input.Skip == "" {
+		input.Skip = "0"
+	}
+	input.Status = strings.ToLower(strings.TrimSpace(input.Status))
+	return *input
+}
+
+// ToIndexParams converts WebhookDeliveryIndex to services.WebhookDeliveryIndexParams
+func (input *WebhookDeliveryIndex) ToIndexParams(userID entities.UserID) *services.WebhookDeliveryIndexParams {
+	params := &services.WebhookDeliveryIndexParams{
+		UserID: userID,
+		WebhookDeliveryIndexParams: repositories.WebhookDeliveryIndexParams{
+			IndexParams: repositories.IndexParams{
+				Skip:  input.getInt(input.Skip),
+				Limit: input.getInt(input.Limit),
+			},
+			WebhookID: uuid.MustParse(input.WebhookID),
+		},
+	}
+
+	if input.Status != "" {
+		status := entities.WebhookDeliveryStatus(input.Status)
+		params.Status = &status
+	}
+	return params
+}
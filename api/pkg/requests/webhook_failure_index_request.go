@@ -0,0 +1,35 @@
+package requests
+
+import (
+	"strings"
+
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
+)
+
+// WebhookFailureIndex is the payload for fetching the failed entities.WebhookDelivery of a user
+type WebhookFailureIndex struct {
+	request
+	Skip  string `json:"skip" query:"skip"`
+	Limit string `json:"limit" query:"limit"`
+}
+
+// Sanitize sets defaults to WebhookFailureIndex
+func (input *WebhookFailureIndex) Sanitize() WebhookFailureIndex {
+	input.Limit = strings.TrimSpace(input.Limit)
+	if input.Limit == "" {
+		input.Limit = "20"
+	}
+	input.Skip = strings.TrimSpace(input.Skip)
+	if input.Skip == "" {
+		input.Skip = "0"
+	}
+	return *input
+}
+
+// ToIndexParams converts WebhookFailureIndex to repositories.IndexParams
+func (input *WebhookFailureIndex) ToIndexParams() repositories.IndexParams {
+	return repositories.IndexParams{
+		Skip:  input.getInt(input.Skip),
+		Limit: input.getInt(input.Limit),
+	}
+}
@@ -0,0 +1,34 @@
+package requests
+
+import (
+	"strings"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+	"github.com/google/uuid"
+)
+
+// WebhookRotateKey is the payload for rotating the signing key of an entities.Webhook
+type WebhookRotateKey struct {
+	request
+	WebhookID string `json:"webhookID" swaggerignore:"true"` // used internally for validation
+
+	// SigningKey is the new primary key with at least 32 characters, a random key is generated when it is empty
+	SigningKey string `json:"signing_key" example:"DGW8NwQp7mxKaSZ72Xq9v67SLqSbWQvckzzmK8D6rvd7NywSEkdMJtuxKyEkYnCY"`
+}
+
+// Sanitize sets defaults to WebhookRotateKey
+func (input *WebhookRotateKey) Sanitize() WebhookRotateKey {
+	input.WebhookID = strings.TrimSpace(input.WebhookID)
+	input.SigningKey = strings.TrimSpace(input.SigningKey)
+	return *input
+}
+
+// ToRotateKeyParams converts WebhookRotateKey to services.WebhookRotateKeyParams
+func (input *WebhookRotateKey) ToRotateKeyParams(userID entities.UserID) *services.WebhookRotateKeyParams {
+	return &services.WebhookRotateKeyParams{
+		UserID:     userID,
+		WebhookID:  uuid.MustParse(input.WebhookID),
+		SigningKey: input.SigningKey,
+	}
+}
@@ -13,12 +13,27 @@ type WebhookStore struct {
 	SigningKey string   `json:"signing_key"`
 	URL        string   `json:"url"`
 	Events     []string `json:"events"`
+
+	// Filters must all match the data of an event before it is sent to the webhook
+	Filters []entities.WebhookFilter `json:"filters"`
+
+	// Version is the version of the payload sent to the webhook, it defaults to the latest version
+	Version string `json:"version" example:"v2"`
+
+	// ProxyURL is the HTTP(S) or SOCKS5 proxy used to send events to the webhook, the global proxy is used when it is empty
+	ProxyURL string `json:"proxy_url" example:"socks5://proxy.example.com:1080"`
 }
 
 // Sanitize sets defaults to WebhookStore
 func (input *WebhookStore) Sanitize() WebhookStore {
 	input.URL = strings.TrimSpace(input.URL)
 	input.Events = input.removeStringDuplicates(input.Events)
+	input.Filters = sanitizeWebhookFilters(input.Filters)
+	input.ProxyURL = strings.TrimSpace(input.ProxyURL)
+	input.Version = strings.ToLower(strings.TrimSpace(input.Version))
+	if input.Version == "" {
+		input.Version = string(entities.WebhookVersionLatest)
+	}
 	return *input
 }
 
@@ -29,5 +44,24 @@ func (input *WebhookStore) ToStoreParams(user entities.AuthUser) *services.Webho
 		SigningKey: input.SigningKey,
 		URL:        input.URL,
 		Events:     input.Events,
+		Filters:    input.Filters,
+		Version:    entities.WebhookVersion(input.Version),
+		ProxyURL:   input.getStringPointer(input.ProxyURL),
+	}
+}
+
+func sanitizeWebhookFilters(filters []entities.WebhookFilter) []entities.WebhookFilter {
+	result := make([]entities.WebhookFilter, 0, len(filters))
+	for _, filter := range filters {
+		filter.Event = strings.TrimSpace(filter.Event)
+		filter.Path = strings.TrimSpace(filter.Path)
+		filter.Operator = entities.WebhookFilterOperator(strings.ToLower(strings.TrimSpace(string(filter.Operator))))
+		values := make([]string, 0, len(filter.Values))
+		for _, value := range filter.Values {
+			values = append(values, strings.TrimSpace(value))
+		}
+		filter.Values = values
+		result = append(result, filter)
 	}
+	return result
 }
@@ -18,6 +18,9 @@ type WebhookUpdate struct {
 func (input *WebhookUpdate) Sanitize() WebhookUpdate {
 	input.URL = strings.TrimSpace(input.URL)
 	input.Events = input.removeStringDuplicates(input.Events)
+	input.Filters = sanitizeWebhookFilters(input.Filters)
+	input.ProxyURL = strings.TrimSpace(input.ProxyURL)
+	input.Version = strings.ToLower(strings.TrimSpace(input.Version))
 	return *input
 }
 
@@ -29,5 +32,8 @@ func (input *WebhookUpdate) ToUpdateParams(user entities.AuthUser) *services.Web
 		SigningKey: input.SigningKey,
 		URL:        input.URL,
 		Events:     input.Events,
+		Filters:    input.Filters,
+		Version:    entities.WebhookVersion(input.Version),
+		ProxyURL:   input.getStringPointer(input.ProxyURL),
 	}
 }
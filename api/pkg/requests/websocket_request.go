@@ -0,0 +1,69 @@
+package requests
+
+import (
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// WebSocketConnect is the payload for opening a WebSocket connection to receive the events of a user
+type WebSocketConnect struct {
+	request
+	// Topics is a comma separated list of event types e.g. message.phone.sent,message.phone.delivered
+	Topics string `json:"topics" query:"topics"`
+
+	// LastEventID is the ID of the last event received before the connection was lost, the events after it are sent first
+	LastEventID string `json:"last_event_id" query:"last_event_id"`
+}
+
+// Sanitize sets defaults to WebSocketConnect
+func (input *WebSocketConnect) Sanitize() WebSocketConnect {
+	input.Topics = strings.Join(sanitizeTopics(strings.Split(input.Topics, ",")), ",")
+	input.LastEventID = strings.TrimSpace(input.LastEventID)
+	return *input
+}
+
+// ToTopics returns the event types of the WebSocketConnect, every event type is sent when it is empty
+func (input *WebSocketConnect) ToTopics() []string {
+	if input.Topics == "" {
+		return nil
+	}
+	return strings.Split(input.Topics, ",")
+}
+
+// ToLastEventID returns the LastEventID as a uuid.UUID, it is nil when the connection does not resume
+func (input *WebSocketConnect) ToLastEventID() *uuid.UUID {
+	if input.LastEventID == "" {
+		return nil
+	}
+	id := uuid.MustParse(input.LastEventID)
+	return &id
+}
+
+// WebSocketCommand is a message sent by the client on a WebSocket connection to change its topics
+type WebSocketCommand struct {
+	request
+	// Action is either "subscribe" or "unsubscribe"
+	Action string   `json:"action" example:"subscribe"`
+	Topics []string `json:"topics" example:"message.phone.received"`
+}
+
+// Sanitize sets defaults to WebSocketCommand
+func (input *WebSocketCommand) Sanitize() WebSocketCommand {
+	input.Action = strings.ToLower(strings.TrimSpace(input.Action))
+	input.Topics = sanitizeTopics(input.Topics)
+	return *input
+}
+
+// sanitizeTopics removes the empty and duplicate topics
+func sanitizeTopics(values []string) []string {
+	topics := make([]string, 0, len(values))
+	seen := map[string]bool{}
+	for _, topic := range values {
+		if topic = strings.TrimSpace(topic); topic != "" && !seen[topic] {
+			seen[topic] = true
+			topics = append(topics, topic)
+		}
+	}
+	return topics
+}
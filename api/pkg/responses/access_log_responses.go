@@ -0,0 +1,9 @@
+package responses
+
+import "github.com/NdoleStudio/httpsms/pkg/entities"
+
+// AccessLogsResponse is the payload containing []entities.AccessLog
+type AccessLogsResponse struct {
+	response
+	Data []entities.AccessLog `json:"data"`
+}
@@ -0,0 +1,9 @@
+package responses
+
+import "github.com/NdoleStudio/httpsms/pkg/services"
+
+// ActivityResponse is the payload containing a services.ActivityPage
+type ActivityResponse struct {
+	response
+	Data services.ActivityPage `json:"data"`
+}
@@ -0,0 +1,21 @@
+package responses
+
+import "github.com/NdoleStudio/httpsms/pkg/entities"
+
+// TimeSeriesResponse is the payload containing []entities.TimeSeriesPoint
+type TimeSeriesResponse struct {
+	response
+	Data []entities.TimeSeriesPoint `json:"data"`
+}
+
+// FailureStatsResponse is the payload containing []entities.MessageFailureCodeCount
+type FailureStatsResponse struct {
+	response
+	Data []entities.MessageFailureCodeCount `json:"data"`
+}
+
+// CarrierFilteringResponse is the payload containing []entities.CarrierFilteringStat
+type CarrierFilteringResponse struct {
+	response
+	Data []entities.CarrierFilteringStat `json:"data"`
+}
@@ -0,0 +1,32 @@
+package responses
+
+import (
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+)
+
+// AuthToken is the token issued after logging in with an email and password
+type AuthToken struct {
+	Token     string            `json:"token" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9"`
+	ExpiresAt time.Time         `json:"expires_at" example:"2022-07-05T14:26:02.302718+03:00"`
+	User      entities.AuthUser `json:"user"`
+}
+
+// AuthTokenResponse is the payload containing an AuthToken
+type AuthTokenResponse struct {
+	response
+	Data AuthToken `json:"data"`
+}
+
+// UserCredentialResponse is the payload containing entities.UserCredential
+type UserCredentialResponse struct {
+	response
+	Data entities.UserCredential `json:"data"`
+}
+
+// AuthSessionsResponse is the payload containing []entities.AuthSession
+type AuthSessionsResponse struct {
+	response
+	Data []entities.AuthSession `json:"data"`
+}
@@ -0,0 +1,18 @@
+package responses
+
+import (
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+)
+
+// BlocklistsResponse is the payload containing []entities.Blocklist
+type BlocklistsResponse struct {
+	response
+	Data []entities.Blocklist `json:"data"`
+}
+
+// BlocklistImportResponse is the payload containing services.BlocklistImport
+type BlocklistImportResponse struct {
+	response
+	Data services.BlocklistImport `json:"data"`
+}
@@ -0,0 +1,15 @@
+package responses
+
+import "github.com/NdoleStudio/httpsms/pkg/entities"
+
+// BroadcastResponse is the payload containing entities.Broadcast
+type BroadcastResponse struct {
+	response
+	Data entities.Broadcast `json:"data"`
+}
+
+// BroadcastsResponse is the payload containing []entities.Broadcast
+type BroadcastsResponse struct {
+	response
+	Data []entities.Broadcast `json:"data"`
+}
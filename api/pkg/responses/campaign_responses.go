@@ -0,0 +1,15 @@
+package responses
+
+import "github.com/NdoleStudio/httpsms/pkg/entities"
+
+// CampaignResponse is the payload containing entities.Campaign
+type CampaignResponse struct {
+	response
+	Data entities.Campaign `json:"data"`
+}
+
+// CampaignsResponse is the payload containing []entities.Campaign
+type CampaignsResponse struct {
+	response
+	Data []entities.Campaign `json:"data"`
+}
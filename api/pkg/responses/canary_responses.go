@@ -0,0 +1,21 @@
+package responses
+
+import "github.com/NdoleStudio/httpsms/pkg/entities"
+
+// CanaryResponse is the payload containing entities.Canary
+type CanaryResponse struct {
+	response
+	Data entities.Canary `json:"data"`
+}
+
+// CanariesResponse is the payload containing []entities.Canary
+type CanariesResponse struct {
+	response
+	Data []entities.Canary `json:"data"`
+}
+
+// CanaryRunsResponse is the payload containing []entities.CanaryRun
+type CanaryRunsResponse struct {
+	response
+	Data []entities.CanaryRun `json:"data"`
+}
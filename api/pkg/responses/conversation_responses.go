@@ -0,0 +1,21 @@
+package responses
+
+import "github.com/NdoleStudio/httpsms/pkg/services"
+
+// ConversationsResponse is the payload containing a services.ConversationPage
+type ConversationsResponse struct {
+	response
+	Data services.ConversationPage `json:"data"`
+}
+
+// ConversationMessagesResponse is the payload containing a services.ConversationMessagePage
+type ConversationMessagesResponse struct {
+	response
+	Data services.ConversationMessagePage `json:"data"`
+}
+
+// ConversationReadResponse is the payload containing a services.ConversationReadResult
+type ConversationReadResponse struct {
+	response
+	Data services.ConversationReadResult `json:"data"`
+}
@@ -0,0 +1,21 @@
+package responses
+
+import "github.com/NdoleStudio/httpsms/pkg/entities"
+
+// CreditBalanceResponse is the payload containing entities.CreditBalance
+type CreditBalanceResponse struct {
+	response
+	Data entities.CreditBalance `json:"data"`
+}
+
+// CreditTransactionResponse is the payload containing entities.CreditTransaction
+type CreditTransactionResponse struct {
+	response
+	Data entities.CreditTransaction `json:"data"`
+}
+
+// CreditTransactionsResponse is the payload containing []entities.CreditTransaction
+type CreditTransactionsResponse struct {
+	response
+	Data []entities.CreditTransaction `json:"data"`
+}
@@ -0,0 +1,9 @@
+package responses
+
+import "github.com/NdoleStudio/httpsms/pkg/entities"
+
+// DashboardResponse is the payload containing entities.Dashboard
+type DashboardResponse struct {
+	response
+	Data entities.Dashboard `json:"data"`
+}
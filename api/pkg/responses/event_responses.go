@@ -0,0 +1,18 @@
+package responses
+
+import (
+	"github.com/NdoleStudio/httpsms/pkg/services"
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+)
+
+// EventsResponse is the payload containing a services.EventPage
+type EventsResponse struct {
+	response
+	Data services.EventPage `json:"data"`
+}
+
+// EventResponse is the payload containing a cloudevents.Event
+type EventResponse struct {
+	response
+	Data cloudevents.Event `json:"data" swaggertype:"object"`
+}
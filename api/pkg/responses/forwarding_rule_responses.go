@@ -0,0 +1,15 @@
+package responses
+
+import "github.com/NdoleStudio/httpsms/pkg/entities"
+
+// ForwardingRuleResponse is the payload containing entities.ForwardingRule
+type ForwardingRuleResponse struct {
+	response
+	Data entities.ForwardingRule `json:"data"`
+}
+
+// ForwardingRulesResponse is the payload containing []entities.ForwardingRule
+type ForwardingRulesResponse struct {
+	response
+	Data []entities.ForwardingRule `json:"data"`
+}
@@ -0,0 +1,13 @@
+package responses
+
+// GraphQLError is an error returned by a GraphQL query
+type GraphQLError struct {
+	Message string   `json:"message" example:"first must be between 1 and 100"`
+	Path    []string `json:"path" example:"messages"`
+}
+
+// GraphQLResponse is the payload of a GraphQL query, the data contains the fields which were selected in the query
+type GraphQLResponse struct {
+	Data   map[string]interface{} `json:"data"`
+	Errors []GraphQLError         `json:"errors"`
+}
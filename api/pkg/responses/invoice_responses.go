@@ -0,0 +1,15 @@
+package responses
+
+import "github.com/NdoleStudio/httpsms/pkg/entities"
+
+// InvoiceResponse is the payload containing entities.Invoice
+type InvoiceResponse struct {
+	response
+	Data entities.Invoice `json:"data"`
+}
+
+// InvoicesResponse is the payload containing []entities.Invoice
+type InvoicesResponse struct {
+	response
+	Data []entities.Invoice `json:"data"`
+}
@@ -0,0 +1,9 @@
+package responses
+
+import "github.com/NdoleStudio/httpsms/pkg/entities"
+
+// LinkPreviewResponse is the payload containing entities.LinkPreview
+type LinkPreviewResponse struct {
+	response
+	Data entities.LinkPreview `json:"data"`
+}
@@ -1,6 +1,9 @@
 package responses
 
-import "github.com/NdoleStudio/httpsms/pkg/entities"
+import (
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+)
 
 // MessageResponse is the payload containing an entities.Message
 type MessageResponse struct {
@@ -13,3 +16,51 @@ type MessagesResponse struct {
 	response
 	Data []entities.Message `json:"data"`
 }
+
+// MessagePageResponse is the payload containing a services.MessagePage
+type MessagePageResponse struct {
+	response
+	Data services.MessagePage `json:"data"`
+}
+
+// MessageSearchResponse is the payload containing []services.MessageSearchResult
+type MessageSearchResponse struct {
+	response
+	Data []services.MessageSearchResult `json:"data"`
+}
+
+// MessageBatchResponse is the payload containing services.MessageBatch
+type MessageBatchResponse struct {
+	response
+	Data services.MessageBatch `json:"data"`
+}
+
+// MessageBatchStatusResponse is the payload containing services.MessageBatchStatus
+type MessageBatchStatusResponse struct {
+	response
+	Data services.MessageBatchStatus `json:"data"`
+}
+
+// MessageEventBatchResponse is the payload containing []services.MessageEventBatchResult
+type MessageEventBatchResponse struct {
+	response
+	Data []services.MessageEventBatchResult `json:"data"`
+}
+
+// MessageReconcileResponse is the payload containing []services.MessageReconcileResult
+type MessageReconcileResponse struct {
+	response
+	Data []services.MessageReconcileResult `json:"data"`
+}
+
+// ArchivedMessagePageResponse is the payload containing a services.ArchivedMessagePage
+type ArchivedMessagePageResponse struct {
+	response
+	Data services.ArchivedMessagePage `json:"data"`
+}
+
+// ArchivedMessageResponse is the payload containing an entities.ArchivedMessage
+type ArchivedMessageResponse struct {
+	response
+	Data entities.ArchivedMessage `json:"data"`
+}
@@ -1,9 +1,24 @@
 package responses
 
-import "github.com/NdoleStudio/httpsms/pkg/entities"
+import (
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+)
 
 // MessageThreadsResponse is the payload containing []entities.MessageThread
 type MessageThreadsResponse struct {
 	response
 	Data []entities.MessageThread `json:"data"`
 }
+
+// MessageThreadResponse is the payload containing entities.MessageThread
+type MessageThreadResponse struct {
+	response
+	Data entities.MessageThread `json:"data"`
+}
+
+// MessageThreadUnarchiveResponse is the payload containing services.MessageThreadUnarchiveResult
+type MessageThreadUnarchiveResponse struct {
+	response
+	Data services.MessageThreadUnarchiveResult `json:"data"`
+}
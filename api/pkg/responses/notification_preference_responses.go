@@ -0,0 +1,9 @@
+package responses
+
+import "github.com/NdoleStudio/httpsms/pkg/entities"
+
+// NotificationPreferencesResponse is the payload containing []entities.NotificationPreference
+type NotificationPreferencesResponse struct {
+	response
+	Data []entities.NotificationPreference `json:"data"`
+}
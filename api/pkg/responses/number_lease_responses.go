@@ -0,0 +1,15 @@
+package responses
+
+import "github.com/NdoleStudio/httpsms/pkg/entities"
+
+// NumberLeaseResponse is the payload containing entities.NumberLease
+type NumberLeaseResponse struct {
+	response
+	Data entities.NumberLease `json:"data"`
+}
+
+// NumberLeasesResponse is the payload containing []entities.NumberLease
+type NumberLeasesResponse struct {
+	response
+	Data []entities.NumberLease `json:"data"`
+}
@@ -0,0 +1,15 @@
+package responses
+
+import "github.com/NdoleStudio/httpsms/pkg/entities"
+
+// PhoneFailoverPolicyResponse is the payload containing entities.PhoneFailoverPolicy
+type PhoneFailoverPolicyResponse struct {
+	response
+	Data entities.PhoneFailoverPolicy `json:"data"`
+}
+
+// PhoneFailoverPoliciesResponse is the payload containing []entities.PhoneFailoverPolicy
+type PhoneFailoverPoliciesResponse struct {
+	response
+	Data []entities.PhoneFailoverPolicy `json:"data"`
+}
@@ -0,0 +1,9 @@
+package responses
+
+import "github.com/NdoleStudio/httpsms/pkg/entities"
+
+// PhoneRegistrationResponse is the payload containing entities.PhoneRegistration
+type PhoneRegistrationResponse struct {
+	response
+	Data entities.PhoneRegistration `json:"data"`
+}
@@ -0,0 +1,9 @@
+package responses
+
+import "github.com/NdoleStudio/httpsms/pkg/entities"
+
+// PhoneReputationsResponse is the payload containing []entities.PhoneReputation
+type PhoneReputationsResponse struct {
+	response
+	Data []entities.PhoneReputation `json:"data"`
+}
@@ -0,0 +1,27 @@
+package responses
+
+import "github.com/NdoleStudio/httpsms/pkg/entities"
+
+// CouponResponse is the payload containing entities.Coupon
+type CouponResponse struct {
+	response
+	Data entities.Coupon `json:"data"`
+}
+
+// CouponsResponse is the payload containing []entities.Coupon
+type CouponsResponse struct {
+	response
+	Data []entities.Coupon `json:"data"`
+}
+
+// PlanOverrideResponse is the payload containing entities.PlanOverride
+type PlanOverrideResponse struct {
+	response
+	Data entities.PlanOverride `json:"data"`
+}
+
+// PlanOverridesResponse is the payload containing []entities.PlanOverride
+type PlanOverridesResponse struct {
+	response
+	Data []entities.PlanOverride `json:"data"`
+}
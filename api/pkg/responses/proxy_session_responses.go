@@ -0,0 +1,21 @@
+package responses
+
+import "github.com/NdoleStudio/httpsms/pkg/entities"
+
+// ProxySessionResponse is the payload containing entities.ProxySession
+type ProxySessionResponse struct {
+	response
+	Data entities.ProxySession `json:"data"`
+}
+
+// ProxySessionsResponse is the payload containing []entities.ProxySession
+type ProxySessionsResponse struct {
+	response
+	Data []entities.ProxySession `json:"data"`
+}
+
+// ProxySessionMessagesResponse is the payload containing []entities.ProxySessionMessage
+type ProxySessionMessagesResponse struct {
+	response
+	Data []entities.ProxySessionMessage `json:"data"`
+}
@@ -0,0 +1,21 @@
+package responses
+
+import "github.com/NdoleStudio/httpsms/pkg/entities"
+
+// ReferralResponse is the payload containing entities.Referral
+type ReferralResponse struct {
+	response
+	Data entities.Referral `json:"data"`
+}
+
+// ReferralsResponse is the payload containing []entities.Referral
+type ReferralsResponse struct {
+	response
+	Data []entities.Referral `json:"data"`
+}
+
+// ReferralStatsResponse is the payload containing entities.ReferralStats
+type ReferralStatsResponse struct {
+	response
+	Data entities.ReferralStats `json:"data"`
+}
@@ -38,12 +38,24 @@ type Unauthorized struct {
 	Data    string `json:"data" example:"Make sure your API key is set in the [X-API-Key] header in the request"`
 }
 
+// TooManyRequests is the response with status code is 429
+type TooManyRequests struct {
+	Status  string `json:"status" example:"error"`
+	Message string `json:"message" example:"You have exceeded the send rate limit, retry the request after 12 seconds."`
+}
+
 // NoContent is the response when status code is 204
 type NoContent struct {
 	Status  string `json:"status" example:"success"`
 	Message string `json:"message" example:"phone deleted successfully"`
 }
 
+// Accepted is the response when status code is 202
+type Accepted struct {
+	Status  string `json:"status" example:"success"`
+	Message string `json:"message" example:"your account will be deleted in a few minutes"`
+}
+
 // Ok is the response with status code is 200
 type Ok[T any] struct {
 	Status  string `json:"status" example:"success"`
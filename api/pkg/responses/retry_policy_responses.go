@@ -0,0 +1,15 @@
+package responses
+
+import "github.com/NdoleStudio/httpsms/pkg/entities"
+
+// RetryPolicyResponse is the payload containing entities.RetryPolicy
+type RetryPolicyResponse struct {
+	response
+	Data entities.RetryPolicy `json:"data"`
+}
+
+// RetryPoliciesResponse is the payload containing []entities.RetryPolicy
+type RetryPoliciesResponse struct {
+	response
+	Data []entities.RetryPolicy `json:"data"`
+}
@@ -0,0 +1,15 @@
+package responses
+
+import "github.com/NdoleStudio/httpsms/pkg/entities"
+
+// RuleResponse is the payload containing entities.Rule
+type RuleResponse struct {
+	response
+	Data entities.Rule `json:"data"`
+}
+
+// RulesResponse is the payload containing []entities.Rule
+type RulesResponse struct {
+	response
+	Data []entities.Rule `json:"data"`
+}
@@ -0,0 +1,24 @@
+package responses
+
+import (
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+)
+
+// SavedFilterResponse is the payload containing entities.SavedFilter
+type SavedFilterResponse struct {
+	response
+	Data entities.SavedFilter `json:"data"`
+}
+
+// SavedFiltersResponse is the payload containing []entities.SavedFilter
+type SavedFiltersResponse struct {
+	response
+	Data []entities.SavedFilter `json:"data"`
+}
+
+// SavedFilterResultResponse is the payload containing services.SavedFilterResult
+type SavedFilterResultResponse struct {
+	response
+	Data services.SavedFilterResult `json:"data"`
+}
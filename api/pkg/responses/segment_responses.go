@@ -0,0 +1,24 @@
+package responses
+
+import (
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+)
+
+// SegmentResponse is the payload containing entities.Segment
+type SegmentResponse struct {
+	response
+	Data entities.Segment `json:"data"`
+}
+
+// SegmentsResponse is the payload containing []entities.Segment
+type SegmentsResponse struct {
+	response
+	Data []entities.Segment `json:"data"`
+}
+
+// SegmentCountResponse is the payload containing services.SegmentCount
+type SegmentCountResponse struct {
+	response
+	Data services.SegmentCount `json:"data"`
+}
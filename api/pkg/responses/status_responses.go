@@ -0,0 +1,15 @@
+package responses
+
+import "github.com/NdoleStudio/httpsms/pkg/entities"
+
+// InstanceStatusResponse is the payload containing entities.InstanceStatus
+type InstanceStatusResponse struct {
+	response
+	Data entities.InstanceStatus `json:"data"`
+}
+
+// InstanceIdentityResponse is the payload containing entities.InstanceIdentity
+type InstanceIdentityResponse struct {
+	response
+	Data entities.InstanceIdentity `json:"data"`
+}
@@ -0,0 +1,21 @@
+package responses
+
+import "github.com/NdoleStudio/httpsms/pkg/entities"
+
+// SubscriptionKeywordResponse is the payload containing entities.SubscriptionKeyword
+type SubscriptionKeywordResponse struct {
+	response
+	Data entities.SubscriptionKeyword `json:"data"`
+}
+
+// SubscriptionKeywordsResponse is the payload containing []entities.SubscriptionKeyword
+type SubscriptionKeywordsResponse struct {
+	response
+	Data []entities.SubscriptionKeyword `json:"data"`
+}
+
+// SubscriptionsResponse is the payload containing []entities.Subscription
+type SubscriptionsResponse struct {
+	response
+	Data []entities.Subscription `json:"data"`
+}
@@ -0,0 +1,23 @@
+package responses
+
+import (
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+)
+
+// TagResponse is the payload containing entities.Tag
+type TagResponse struct {
+	response
+	Data entities.Tag `json:"data"`
+}
+
+// TagsResponse is the payload containing []entities.Tag
+type TagsResponse struct {
+	response
+	Data []entities.Tag `json:"data"`
+}
+
+// TagUsageResponse is the payload containing []entities.TagUsage
+type TagUsageResponse struct {
+	response
+	Data []entities.TagUsage `json:"data"`
+}
@@ -0,0 +1,27 @@
+package responses
+
+import "github.com/NdoleStudio/httpsms/pkg/entities"
+
+// TemplateResponse is the payload containing entities.Template
+type TemplateResponse struct {
+	response
+	Data entities.Template `json:"data"`
+}
+
+// TemplatesResponse is the payload containing []entities.Template
+type TemplatesResponse struct {
+	response
+	Data []entities.Template `json:"data"`
+}
+
+// TemplateReviewsResponse is the payload containing []entities.TemplateReview
+type TemplateReviewsResponse struct {
+	response
+	Data []entities.TemplateReview `json:"data"`
+}
+
+// TemplateStatsResponse is the payload containing entities.TemplateStats
+type TemplateStatsResponse struct {
+	response
+	Data entities.TemplateStats `json:"data"`
+}
@@ -0,0 +1,28 @@
+package responses
+
+import "github.com/NdoleStudio/httpsms/pkg/entities"
+
+// TwoFactorAuthResponse is the payload containing entities.TwoFactorAuth
+type TwoFactorAuthResponse struct {
+	response
+	Data entities.TwoFactorAuth `json:"data"`
+}
+
+// TwoFactorEnrollment is the TOTP secret which is added to an authenticator app
+type TwoFactorEnrollment struct {
+	Secret string `json:"secret" example:"JBSWY3DPEHPK3PXP"`
+	// URL is the otpauth:// URL which is encoded in the QR code scanned by the authenticator app
+	URL string `json:"url" example:"otpauth://totp/httpSMS:name@email.com?issuer=httpSMS&secret=JBSWY3DPEHPK3PXP"`
+}
+
+// TwoFactorEnrollmentResponse is the payload containing TwoFactorEnrollment
+type TwoFactorEnrollmentResponse struct {
+	response
+	Data TwoFactorEnrollment `json:"data"`
+}
+
+// TwoFactorRecoveryCodesResponse is the payload containing the recovery codes which are only shown once
+type TwoFactorRecoveryCodesResponse struct {
+	response
+	Data []string `json:"data" example:"abcde-fghij"`
+}
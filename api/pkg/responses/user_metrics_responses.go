@@ -0,0 +1,12 @@
+package responses
+
+// UserMetricsURL is the URL which is scraped by Prometheus to export the metrics of a user
+type UserMetricsURL struct {
+	URL string `json:"url" example:"https://api.httpsms.com/v1/metrics/WB7DRDWrJZRGbYrv2CKGkqbzvqdC?signature=4b1b"`
+}
+
+// UserMetricsURLResponse is the payload containing a UserMetricsURL
+type UserMetricsURLResponse struct {
+	response
+	Data UserMetricsURL `json:"data"`
+}
@@ -1,6 +1,9 @@
 package responses
 
-import "github.com/NdoleStudio/httpsms/pkg/entities"
+import (
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+)
 
 // WebhookResponse is the payload containing entities.Webhook
 type WebhookResponse struct {
@@ -13,3 +16,27 @@ type WebhooksResponse struct {
 	response
 	Data []entities.Webhook `json:"data"`
 }
+
+// WebhookDeliveryResponse is the payload containing entities.WebhookDelivery
+type WebhookDeliveryResponse struct {
+	response
+	Data entities.WebhookDelivery `json:"data"`
+}
+
+// WebhookDeliveriesResponse is the payload containing []entities.WebhookDelivery
+type WebhookDeliveriesResponse struct {
+	response
+	Data []entities.WebhookDelivery `json:"data"`
+}
+
+// WebhookFixturesResponse is the payload containing []services.WebhookFixture
+type WebhookFixturesResponse struct {
+	response
+	Data []services.WebhookFixture `json:"data"`
+}
+
+// WebhookContractResultsResponse is the payload containing []services.WebhookContractResult
+type WebhookContractResultsResponse struct {
+	response
+	Data []services.WebhookContractResult `json:"data"`
+}
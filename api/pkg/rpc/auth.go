@@ -0,0 +1,86 @@
+package rpc
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/palantir/stacktrace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// metadataAPIKey is the metadata key of the API key, it is the same as the X-API-Key header of the HTTP API
+const metadataAPIKey = "x-api-key"
+
+type contextKey string
+
+const contextKeyAuthUser = contextKey("auth-user")
+
+// authenticator loads the entities.AuthUser of a call from the API key in the metadata
+type authenticator struct {
+	logger         telemetry.Logger
+	tracer         telemetry.Tracer
+	userRepository repositories.UserRepository
+}
+
+// Unary authenticates unary calls
+func (auth *authenticator) Unary(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	ctx, err := auth.authenticate(ctx, info.FullMethod)
+	if err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+// Stream authenticates streaming calls
+func (auth *authenticator) Stream(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx, err := auth.authenticate(stream.Context(), info.FullMethod)
+	if err != nil {
+		return err
+	}
+	return handler(srv, &authenticatedStream{ServerStream: stream, ctx: ctx})
+}
+
+func (auth *authenticator) authenticate(ctx context.Context, method string) (context.Context, error) {
+	ctx, span, ctxLogger := auth.tracer.StartWithLogger(ctx, auth.logger)
+	defer span.End()
+
+	md, _ := metadata.FromIncomingContext(ctx)
+	apiKeys := md.Get(metadataAPIKey)
+	if len(apiKeys) == 0 || apiKeys[0] == "" {
+		ctxLogger.Warn(stacktrace.NewError(fmt.Sprintf("call to [%s] has no [%s] metadata", method, metadataAPIKey)))
+		return ctx, status.Error(codes.Unauthenticated, fmt.Sprintf("the %s metadata is required", metadataAPIKey))
+	}
+
+	authUser, err := auth.userRepository.LoadAuthUser(ctx, apiKeys[0])
+	if err != nil {
+		ctxLogger.Warn(stacktrace.Propagate(err, fmt.Sprintf("cannot load user with api key [%s] for call to [%s]", apiKeys[0], method)))
+		return ctx, status.Error(codes.Unauthenticated, "the api key is invalid")
+	}
+
+	return context.WithValue(ctx, contextKeyAuthUser, authUser), nil
+}
+
+// authUserFromContext returns the entities.AuthUser which was set by the authenticator
+func authUserFromContext(ctx context.Context) entities.AuthUser {
+	if authUser, ok := ctx.Value(contextKeyAuthUser).(entities.AuthUser); ok {
+		return authUser
+	}
+	return entities.AuthUser{}
+}
+
+// authenticatedStream replaces the context of a grpc.ServerStream with the authenticated context
+type authenticatedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+// Context returns the authenticated context of the stream
+func (stream *authenticatedStream) Context() context.Context {
+	return stream.ctx
+}
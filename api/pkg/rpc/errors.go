@@ -0,0 +1,55 @@
+package rpc
+
+import (
+	"net/url"
+	"sort"
+
+	"github.com/NdoleStudio/httpsms/pkg/rpc/httpsmsv1"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// invalidArgument converts validation errors into an InvalidArgument status with the errdetails.BadRequest details
+func invalidArgument(message string, errors url.Values) error {
+	violations := make([]*errdetails.BadRequest_FieldViolation, 0, len(errors))
+	for _, field := range sortedFields(errors) {
+		for _, description := range errors[field] {
+			violations = append(violations, &errdetails.BadRequest_FieldViolation{Field: field, Description: description})
+		}
+	}
+
+	result, err := status.New(codes.InvalidArgument, message).WithDetails(&errdetails.BadRequest{FieldViolations: violations})
+	if err != nil {
+		return status.Error(codes.InvalidArgument, message)
+	}
+	return result.Err()
+}
+
+// toError converts an error returned to a client into the httpsmsv1.Error of a stream result
+func toError(err error) *httpsmsv1.Error {
+	result := status.Convert(err)
+	response := &httpsmsv1.Error{
+		Code:    result.Code().String(),
+		Message: result.Message(),
+	}
+
+	for _, detail := range result.Details() {
+		if request, ok := detail.(*errdetails.BadRequest); ok {
+			for _, violation := range request.FieldViolations {
+				response.Violations = append(response.Violations, &httpsmsv1.FieldViolation{Field: violation.Field, Description: violation.Description})
+			}
+		}
+	}
+
+	return response
+}
+
+func sortedFields(errors url.Values) []string {
+	fields := make([]string, 0, len(errors))
+	for field := range errors {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	return fields
+}
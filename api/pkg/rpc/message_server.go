@@ -0,0 +1,330 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/events"
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
+	"github.com/NdoleStudio/httpsms/pkg/requests"
+	"github.com/NdoleStudio/httpsms/pkg/rpc/httpsmsv1"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/NdoleStudio/httpsms/pkg/validators"
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/davecgh/go-spew/spew"
+	"github.com/google/uuid"
+	"github.com/palantir/stacktrace"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// messageEventTypes are the events which change the status of a message and are pushed by WatchMessages
+var messageEventTypes = []string{
+	events.EventTypeMessageAPISent,
+	events.EventTypeMessagePhoneSending,
+	events.EventTypeMessagePhoneSent,
+	events.EventTypeMessagePhoneDelivered,
+	events.EventTypeMessageSendFailed,
+	events.EventTypeMessageSendExpired,
+	events.EventTypeMessagePhoneReceived,
+	events.EventTypeMessageDeliveryUnknown,
+}
+
+// MessageServer implements httpsmsv1.MessageServiceServer with the services.MessageService
+type MessageServer struct {
+	httpsmsv1.UnimplementedMessageServiceServer
+	logger         telemetry.Logger
+	tracer         telemetry.Tracer
+	validator      *validators.MessageHandlerValidator
+	billingService *services.BillingService
+	rateLimiter    *services.RateLimitService
+	service        *services.MessageService
+	hub            *services.EventHub
+}
+
+// NewMessageServer creates a new MessageServer
+func NewMessageServer(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	validator *validators.MessageHandlerValidator,
+	billingService *services.BillingService,
+	rateLimiter *services.RateLimitService,
+	service *services.MessageService,
+	hub *services.EventHub,
+) (s *MessageServer) {
+	return &MessageServer{
+		logger:         logger.WithService(fmt.Sprintf("%T", s)),
+		tracer:         tracer,
+		validator:      validator,
+		billingService: billingService,
+		rateLimiter:    rateLimiter,
+		service:        service,
+		hub:            hub,
+	}
+}
+
+// SendMessage adds a message to the queue of the phone which owns the "from" number
+func (server *MessageServer) SendMessage(ctx context.Context, request *httpsmsv1.SendMessageRequest) (*httpsmsv1.Message, error) {
+	ctx, span := server.tracer.Start(ctx)
+	defer span.End()
+
+	message, err := server.send(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	return toMessage(message), nil
+}
+
+// StreamSendMessages sends a message for every request on the stream, errors are returned in the result of the request
+func (server *MessageServer) StreamSendMessages(stream httpsmsv1.MessageService_StreamSendMessagesServer) error {
+	ctx, span, ctxLogger := server.tracer.StartWithLogger(stream.Context(), server.logger)
+	defer span.End()
+
+	count := 0
+	for {
+		request, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			ctxLogger.Info(fmt.Sprintf("stream of user [%s] closed after sending [%d] messages", authUserFromContext(ctx).ID, count))
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		result := &httpsmsv1.SendMessageResult{RequestId: request.GetRequestId()}
+		message, err := server.send(ctx, request)
+		if err != nil {
+			result.Error = toError(err)
+		} else {
+			result.Message = toMessage(message)
+			count++
+		}
+
+		if err = stream.Send(result); err != nil {
+			msg := fmt.Sprintf("cannot send result of request [%s] to the stream of user [%s]", request.GetRequestId(), authUserFromContext(ctx).ID)
+			return server.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+		}
+	}
+}
+
+// GetMessage fetches a message of the user by ID
+func (server *MessageServer) GetMessage(ctx context.Context, request *httpsmsv1.GetMessageRequest) (*httpsmsv1.Message, error) {
+	ctx, span, ctxLogger := server.tracer.StartWithLogger(ctx, server.logger)
+	defer span.End()
+
+	messageID, err := uuid.Parse(request.GetId())
+	if err != nil {
+		return nil, invalidArgument("validation errors while fetching message", url.Values{"id": []string{"The id field must be a valid UUID"}})
+	}
+
+	message, err := server.service.GetMessage(ctx, authUserFromContext(ctx).ID, messageID)
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		return nil, status.Error(codes.NotFound, fmt.Sprintf("cannot find message with ID [%s]", messageID))
+	}
+
+	if err != nil {
+		ctxLogger.Error(stacktrace.Propagate(err, fmt.Sprintf("cannot fetch message with ID [%s]", messageID)))
+		return nil, status.Error(codes.Internal, "cannot fetch the message")
+	}
+
+	return toMessage(message), nil
+}
+
+// WatchMessages pushes the messages of the user every time their status changes until the client closes the stream.
+// The events which are handled by this instance are pushed and events are dropped if the client does not keep up.
+func (server *MessageServer) WatchMessages(request *httpsmsv1.WatchMessagesRequest, stream httpsmsv1.MessageService_WatchMessagesServer) error {
+	ctx, span, ctxLogger := server.tracer.StartWithLogger(stream.Context(), server.logger)
+	defer span.End()
+
+	owners := map[string]bool{}
+	for _, owner := range request.GetOwners() {
+		owners[strings.TrimSpace(owner)] = true
+	}
+
+	userID := authUserFromContext(ctx).ID
+	subscription := server.hub.Subscribe(userID, messageEventTypes...)
+	defer server.hub.Unsubscribe(subscription)
+
+	ctxLogger.Info(fmt.Sprintf("user [%s] is watching messages of [%d] owners", userID, len(owners)))
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event, ok := <-subscription.Events():
+			if !ok {
+				return status.Error(codes.Unavailable, "the server is shutting down")
+			}
+
+			message, err := server.eventMessage(ctx, userID, event)
+			if err != nil {
+				ctxLogger.Error(stacktrace.Propagate(err, fmt.Sprintf("cannot load the message of event [%s] with ID [%s]", event.Type(), event.ID())))
+				continue
+			}
+
+			if len(owners) != 0 && !owners[message.Owner] {
+				continue
+			}
+
+			err = stream.Send(&httpsmsv1.MessageEvent{
+				EventId:   event.ID(),
+				EventType: event.Type(),
+				Message:   toMessage(message),
+				Timestamp: timestamppb.New(event.Time()),
+			})
+			if err != nil {
+				msg := fmt.Sprintf("cannot push event [%s] with ID [%s] to user [%s]", event.Type(), event.ID(), userID)
+				return server.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+			}
+		}
+	}
+}
+
+// eventMessage loads the message of an event, the events use either "id" or "message_id" for the ID of the message
+func (server *MessageServer) eventMessage(ctx context.Context, userID entities.UserID, event cloudevents.Event) (*entities.Message, error) {
+	var payload struct {
+		ID        uuid.UUID `json:"id"`
+		MessageID uuid.UUID `json:"message_id"`
+	}
+	if err := event.DataAs(&payload); err != nil {
+		return nil, stacktrace.Propagate(err, fmt.Sprintf("cannot decode [%s] into [%T]", event.Data(), payload))
+	}
+
+	messageID := payload.MessageID
+	if messageID == uuid.Nil {
+		messageID = payload.ID
+	}
+	return server.service.GetMessage(ctx, userID, messageID)
+}
+
+// send validates a request and sends the message with the same checks as the HTTP API
+func (server *MessageServer) send(ctx context.Context, input *httpsmsv1.SendMessageRequest) (*entities.Message, error) {
+	ctx, span, ctxLogger := server.tracer.StartWithLogger(ctx, server.logger)
+	defer span.End()
+
+	userID := authUserFromContext(ctx).ID
+	request := toMessageSend(input)
+	if errors := server.validator.ValidateMessageSend(ctx, userID, request.Sanitize()); len(errors) != 0 {
+		ctxLogger.Warn(stacktrace.NewError(fmt.Sprintf("validation errors [%s], while sending message [%+#v]", spew.Sdump(errors), request)))
+		return nil, invalidArgument("validation errors while sending message", errors)
+	}
+
+	if msg := server.billingService.IsEntitledToSend(ctx, userID); msg != nil {
+		ctxLogger.Warn(stacktrace.NewError(fmt.Sprintf("user with ID [%s] can't send a message", userID)))
+		return nil, status.Error(codes.ResourceExhausted, *msg)
+	}
+
+	limit, err := server.rateLimiter.TakeSend(ctx, userID, request.To)
+	if err != nil {
+		ctxLogger.Error(stacktrace.Propagate(err, fmt.Sprintf("cannot check send rate limit of user [%s]", userID)))
+		return nil, status.Error(codes.Internal, "cannot check the send rate limit")
+	}
+
+	if !limit.Allowed {
+		ctxLogger.Warn(stacktrace.NewError(fmt.Sprintf("user with ID [%s] exceeded the send rate limit to [%s]", userID, request.To)))
+		return nil, status.Error(codes.ResourceExhausted, fmt.Sprintf("send rate limit exceeded, retry after %s", limit.RetryAfter))
+	}
+
+	message, err := server.service.SendMessage(ctx, request.ToMessageSendParams(userID, "/"+httpsmsv1.MessageService_ServiceDesc.ServiceName+"/SendMessage"))
+	if stacktrace.GetCode(err) == services.ErrCodeUserSuspended {
+		return nil, status.Error(codes.PermissionDenied, "the account is suspended")
+	}
+
+	if stacktrace.GetCode(err) == services.ErrCodeAttachmentQuarantined {
+		return nil, invalidArgument("validation errors while sending message", url.Values{"attachments": []string{"an attachment contains malware and cannot be sent"}})
+	}
+
+	if stacktrace.GetCode(err) == services.ErrCodeInsufficientCredits {
+		return nil, status.Error(codes.ResourceExhausted, "You do not have enough credits to send this message. Top up your credits to send more messages.")
+	}
+
+	if stacktrace.GetCode(err) == services.ErrCodeMessagePredecessorInvalid {
+		return nil, invalidArgument("validation errors while sending message", url.Values{"after_message_id": []string{"no outgoing message found with the 'after_message_id'"}})
+	}
+
+	if err != nil {
+		ctxLogger.Error(stacktrace.Propagate(err, fmt.Sprintf("cannot send message with request [%+#v]", request)))
+		return nil, status.Error(codes.Internal, "cannot send the message")
+	}
+
+	return message, nil
+}
+
+// toMessageSend converts a httpsmsv1.SendMessageRequest to the requests.MessageSend of the HTTP API
+func toMessageSend(input *httpsmsv1.SendMessageRequest) requests.MessageSend {
+	request := requests.MessageSend{
+		From:     input.GetFrom(),
+		To:       input.GetTo(),
+		Content:  input.GetContent(),
+		SIM:      entities.SIM(strings.ToUpper(input.GetSim())),
+		Priority: entities.MessagePriority(input.GetPriority()),
+	}
+
+	if input.GetSendAt() != nil {
+		sendAt := input.GetSendAt().AsTime()
+		request.SendAt = &sendAt
+	}
+
+	if input.GetConversationId() != "" {
+		conversationID := input.GetConversationId()
+		request.ConversationID = &conversationID
+	}
+
+	if input.GetExpiresIn() != 0 {
+		expiresIn := uint(input.GetExpiresIn())
+		request.ExpiresIn = &expiresIn
+	}
+
+	if input.GetAfterMessageId() != "" {
+		afterMessageID := input.GetAfterMessageId()
+		request.AfterMessageID = &afterMessageID
+	}
+
+	return request
+}
+
+// toMessage converts an entities.Message to a httpsmsv1.Message
+func toMessage(message *entities.Message) *httpsmsv1.Message {
+	result := &httpsmsv1.Message{
+		Id:          message.ID.String(),
+		Owner:       message.Owner,
+		Contact:     message.Contact,
+		Content:     message.Content,
+		Type:        string(message.Type),
+		Status:      string(message.Status),
+		Sim:         string(message.SIM),
+		Priority:    string(message.Priority),
+		ScheduledAt: toTimestamp(message.NotificationScheduledAt),
+		SentAt:      toTimestamp(message.SentAt),
+		DeliveredAt: toTimestamp(message.DeliveredAt),
+		FailedAt:    toTimestamp(message.FailedAt),
+		ReceivedAt:  toTimestamp(message.ReceivedAt),
+		CreatedAt:   timestamppb.New(message.CreatedAt),
+		UpdatedAt:   timestamppb.New(message.UpdatedAt),
+	}
+
+	if message.FailureReason != nil {
+		result.FailureReason = *message.FailureReason
+	}
+
+	if message.FailureCode != nil {
+		result.FailureCode = string(*message.FailureCode)
+	}
+
+	return result
+}
+
+// toTimestamp converts an optional time.Time to a timestamppb.Timestamp which is nil when the time is not set
+func toTimestamp(value *time.Time) *timestamppb.Timestamp {
+	if value == nil {
+		return nil
+	}
+	return timestamppb.New(*value)
+}
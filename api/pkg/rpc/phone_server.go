@@ -0,0 +1,110 @@
+package rpc
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/requests"
+	"github.com/NdoleStudio/httpsms/pkg/rpc/httpsmsv1"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/NdoleStudio/httpsms/pkg/validators"
+	"github.com/davecgh/go-spew/spew"
+	"github.com/palantir/stacktrace"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// PhoneServer implements httpsmsv1.PhoneServiceServer with the services.PhoneService
+type PhoneServer struct {
+	httpsmsv1.UnimplementedPhoneServiceServer
+	logger    telemetry.Logger
+	tracer    telemetry.Tracer
+	validator *validators.PhoneHandlerValidator
+	service   *services.PhoneService
+}
+
+// NewPhoneServer creates a new PhoneServer
+func NewPhoneServer(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	validator *validators.PhoneHandlerValidator,
+	service *services.PhoneService,
+) (s *PhoneServer) {
+	return &PhoneServer{
+		logger:    logger.WithService(fmt.Sprintf("%T", s)),
+		tracer:    tracer,
+		validator: validator,
+		service:   service,
+	}
+}
+
+// ListPhones fetches the phones of the user
+func (server *PhoneServer) ListPhones(ctx context.Context, input *httpsmsv1.ListPhonesRequest) (*httpsmsv1.ListPhonesResponse, error) {
+	ctx, span, ctxLogger := server.tracer.StartWithLogger(ctx, server.logger)
+	defer span.End()
+
+	request := requests.PhoneIndex{
+		Skip:  strconv.FormatUint(uint64(input.GetSkip()), 10),
+		Limit: strconv.FormatUint(uint64(input.GetLimit()), 10),
+		Query: input.GetQuery(),
+	}
+	if input.GetLimit() == 0 {
+		request.Limit = ""
+	}
+
+	if errors := server.validator.ValidateIndex(ctx, request.Sanitize()); len(errors) != 0 {
+		ctxLogger.Warn(stacktrace.NewError(fmt.Sprintf("validation errors [%s], while fetching phones [%+#v]", spew.Sdump(errors), request)))
+		return nil, invalidArgument("validation errors while fetching phones", errors)
+	}
+
+	phones, err := server.service.Index(ctx, authUserFromContext(ctx), request.ToIndexParams())
+	if err != nil {
+		ctxLogger.Error(stacktrace.Propagate(err, fmt.Sprintf("cannot index phones with params [%+#v]", request)))
+		return nil, status.Error(codes.Internal, "cannot fetch the phones")
+	}
+
+	response := &httpsmsv1.ListPhonesResponse{Phones: make([]*httpsmsv1.Phone, 0, len(*phones))}
+	for index := range *phones {
+		response.Phones = append(response.Phones, toPhone(&(*phones)[index]))
+	}
+	return response, nil
+}
+
+// DeletePhone deletes a phone of the user
+func (server *PhoneServer) DeletePhone(ctx context.Context, input *httpsmsv1.DeletePhoneRequest) (*httpsmsv1.DeletePhoneResponse, error) {
+	ctx, span, ctxLogger := server.tracer.StartWithLogger(ctx, server.logger)
+	defer span.End()
+
+	request := requests.PhoneDelete{PhoneID: input.GetId()}
+	if errors := server.validator.ValidateDelete(ctx, request); len(errors) != 0 {
+		ctxLogger.Warn(stacktrace.NewError(fmt.Sprintf("validation errors [%s], while deleting phone [%+#v]", spew.Sdump(errors), request)))
+		return nil, invalidArgument("validation errors while deleting phone", errors)
+	}
+
+	err := server.service.Delete(ctx, "/"+httpsmsv1.PhoneService_ServiceDesc.ServiceName+"/DeletePhone", authUserFromContext(ctx).ID, request.PhoneIDUuid())
+	if err != nil {
+		ctxLogger.Error(stacktrace.Propagate(err, fmt.Sprintf("cannot delete phones with params [%+#v]", request)))
+		return nil, status.Error(codes.Internal, "cannot delete the phone")
+	}
+
+	return &httpsmsv1.DeletePhoneResponse{}, nil
+}
+
+// toPhone converts an entities.Phone to a httpsmsv1.Phone
+func toPhone(phone *entities.Phone) *httpsmsv1.Phone {
+	return &httpsmsv1.Phone{
+		Id:                  phone.ID.String(),
+		PhoneNumber:         phone.PhoneNumber,
+		MessagesPerMinute:   uint32(phone.MessagesPerMinute),
+		IsDualSim:           phone.IsDualSIM,
+		MaxSendAttempts:     uint32(phone.MaxSendAttempts),
+		Transport:           string(phone.Transport),
+		KillSwitchEnabledAt: toTimestamp(phone.KillSwitchEnabledAt),
+		CreatedAt:           timestamppb.New(phone.CreatedAt),
+		UpdatedAt:           timestamppb.New(phone.UpdatedAt),
+	}
+}
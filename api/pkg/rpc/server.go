@@ -0,0 +1,35 @@
+package rpc
+
+import (
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
+	"github.com/NdoleStudio/httpsms/pkg/rpc/httpsmsv1"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"google.golang.org/grpc"
+)
+
+// NewServer creates a grpc.Server which authenticates calls with the API key of the user and serves the httpsmsv1 services
+func NewServer(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	userRepository repositories.UserRepository,
+	messageServer *MessageServer,
+	webhookServer *WebhookServer,
+	phoneServer *PhoneServer,
+) *grpc.Server {
+	auth := &authenticator{
+		logger:         logger.WithService("rpc.authenticator"),
+		tracer:         tracer,
+		userRepository: userRepository,
+	}
+
+	server := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(auth.Unary),
+		grpc.ChainStreamInterceptor(auth.Stream),
+	)
+
+	httpsmsv1.RegisterMessageServiceServer(server, messageServer)
+	httpsmsv1.RegisterWebhookServiceServer(server, webhookServer)
+	httpsmsv1.RegisterPhoneServiceServer(server, phoneServer)
+
+	return server
+}
@@ -0,0 +1,145 @@
+package rpc
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
+	"github.com/NdoleStudio/httpsms/pkg/requests"
+	"github.com/NdoleStudio/httpsms/pkg/rpc/httpsmsv1"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/NdoleStudio/httpsms/pkg/validators"
+	"github.com/davecgh/go-spew/spew"
+	"github.com/google/uuid"
+	"github.com/palantir/stacktrace"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// WebhookServer implements httpsmsv1.WebhookServiceServer with the services.WebhookService
+type WebhookServer struct {
+	httpsmsv1.UnimplementedWebhookServiceServer
+	logger    telemetry.Logger
+	tracer    telemetry.Tracer
+	validator *validators.WebhookHandlerValidator
+	service   *services.WebhookService
+}
+
+// NewWebhookServer creates a new WebhookServer
+func NewWebhookServer(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	validator *validators.WebhookHandlerValidator,
+	service *services.WebhookService,
+) (s *WebhookServer) {
+	return &WebhookServer{
+		logger:    logger.WithService(fmt.Sprintf("%T", s)),
+		tracer:    tracer,
+		validator: validator,
+		service:   service,
+	}
+}
+
+// ListWebhooks fetches the webhooks of the user
+func (server *WebhookServer) ListWebhooks(ctx context.Context, input *httpsmsv1.ListWebhooksRequest) (*httpsmsv1.ListWebhooksResponse, error) {
+	ctx, span, ctxLogger := server.tracer.StartWithLogger(ctx, server.logger)
+	defer span.End()
+
+	request := requests.WebhookIndex{
+		Skip:  strconv.FormatUint(uint64(input.GetSkip()), 10),
+		Limit: strconv.FormatUint(uint64(input.GetLimit()), 10),
+		Query: input.GetQuery(),
+	}
+	if input.GetLimit() == 0 {
+		request.Limit = ""
+	}
+
+	if errors := server.validator.ValidateIndex(ctx, request.Sanitize()); len(errors) != 0 {
+		ctxLogger.Warn(stacktrace.NewError(fmt.Sprintf("validation errors [%s], while fetching webhooks [%+#v]", spew.Sdump(errors), request)))
+		return nil, invalidArgument("validation errors while fetching webhooks", errors)
+	}
+
+	webhooks, err := server.service.Index(ctx, authUserFromContext(ctx).ID, request.ToIndexParams())
+	if err != nil {
+		ctxLogger.Error(stacktrace.Propagate(err, fmt.Sprintf("cannot get webhooks with params [%+#v]", request)))
+		return nil, status.Error(codes.Internal, "cannot fetch the webhooks")
+	}
+
+	response := &httpsmsv1.ListWebhooksResponse{Webhooks: make([]*httpsmsv1.Webhook, 0, len(webhooks))}
+	for _, webhook := range webhooks {
+		response.Webhooks = append(response.Webhooks, toWebhook(webhook))
+	}
+	return response, nil
+}
+
+// CreateWebhook stores a webhook for the user, a user can only have 1 webhook like in the HTTP API
+func (server *WebhookServer) CreateWebhook(ctx context.Context, input *httpsmsv1.CreateWebhookRequest) (*httpsmsv1.Webhook, error) {
+	ctx, span, ctxLogger := server.tracer.StartWithLogger(ctx, server.logger)
+	defer span.End()
+
+	request := requests.WebhookStore{
+		SigningKey: input.GetSigningKey(),
+		URL:        input.GetUrl(),
+		Events:     input.GetEvents(),
+		Version:    input.GetVersion(),
+	}
+
+	if errors := server.validator.ValidateStore(ctx, request.Sanitize()); len(errors) != 0 {
+		ctxLogger.Warn(stacktrace.NewError(fmt.Sprintf("validation errors [%s], while storing webhook [%+#v]", spew.Sdump(errors), request)))
+		return nil, invalidArgument("validation errors while storing webhook", errors)
+	}
+
+	authUser := authUserFromContext(ctx)
+	webhooks, err := server.service.Index(ctx, authUser.ID, repositories.IndexParams{Skip: 0, Limit: 1})
+	if err != nil {
+		ctxLogger.Error(stacktrace.Propagate(err, fmt.Sprintf("cannot index webhooks for user [%s]", authUser.ID)))
+		return nil, status.Error(codes.Internal, "cannot store the webhook")
+	}
+
+	if len(webhooks) > 0 {
+		ctxLogger.Warn(stacktrace.NewError(fmt.Sprintf("user with ID [%s] wants to create more than 1 webhook", authUser.ID)))
+		return nil, status.Error(codes.ResourceExhausted, "You can't create more than 1 webhook contact us to upgrade your account.")
+	}
+
+	webhook, err := server.service.Store(ctx, request.ToStoreParams(authUser))
+	if err != nil {
+		ctxLogger.Error(stacktrace.Propagate(err, fmt.Sprintf("cannot store webhoook with params [%+#v]", request)))
+		return nil, status.Error(codes.Internal, "cannot store the webhook")
+	}
+
+	return toWebhook(webhook), nil
+}
+
+// DeleteWebhook deletes a webhook of the user
+func (server *WebhookServer) DeleteWebhook(ctx context.Context, input *httpsmsv1.DeleteWebhookRequest) (*httpsmsv1.DeleteWebhookResponse, error) {
+	ctx, span, ctxLogger := server.tracer.StartWithLogger(ctx, server.logger)
+	defer span.End()
+
+	if errors := server.validator.ValidateUUID(ctx, input.GetId(), "id"); len(errors) != 0 {
+		ctxLogger.Warn(stacktrace.NewError(fmt.Sprintf("validation errors [%s], while deleting webhook with ID [%s]", spew.Sdump(errors), input.GetId())))
+		return nil, invalidArgument("validation errors while deleting webhook", errors)
+	}
+
+	if err := server.service.Delete(ctx, authUserFromContext(ctx).ID, uuid.MustParse(input.GetId())); err != nil {
+		ctxLogger.Error(stacktrace.Propagate(err, fmt.Sprintf("cannot delete webhook with ID [%s]", input.GetId())))
+		return nil, status.Error(codes.Internal, "cannot delete the webhook")
+	}
+
+	return &httpsmsv1.DeleteWebhookResponse{}, nil
+}
+
+// toWebhook converts an entities.Webhook to a httpsmsv1.Webhook
+func toWebhook(webhook *entities.Webhook) *httpsmsv1.Webhook {
+	return &httpsmsv1.Webhook{
+		Id:        webhook.ID.String(),
+		Url:       webhook.URL,
+		Events:    webhook.Events,
+		Version:   string(webhook.Version),
+		CreatedAt: timestamppb.New(webhook.CreatedAt),
+		UpdatedAt: timestamppb.New(webhook.UpdatedAt),
+	}
+}
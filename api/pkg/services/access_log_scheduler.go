@@ -0,0 +1,86 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/palantir/stacktrace"
+)
+
+const accessLogSchedulerBatchSize = 500
+
+// AccessLogScheduler periodically stores the queued access logs and deletes the access logs which are older than the retention period
+type AccessLogScheduler struct {
+	logger        telemetry.Logger
+	tracer        telemetry.Tracer
+	service       *AccessLogService
+	interval      time.Duration
+	purgeInterval time.Duration
+	// purgedAt is when the expired access logs were last purged
+	purgedAt time.Time
+}
+
+// NewAccessLogScheduler creates a new AccessLogScheduler
+func NewAccessLogScheduler(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	service *AccessLogService,
+	interval time.Duration,
+	purgeInterval time.Duration,
+) (s *AccessLogScheduler) {
+	return &AccessLogScheduler{
+		logger:        logger.WithService(fmt.Sprintf("%T", s)),
+		tracer:        tracer,
+		service:       service,
+		interval:      interval,
+		purgeInterval: purgeInterval,
+	}
+}
+
+// Run flushes the access logs on every tick and purges the expired access logs until the context is cancelled
+func (scheduler *AccessLogScheduler) Run(ctx context.Context) {
+	scheduler.purgedAt = time.Now().UTC()
+	runTicker(ctx, scheduler.logger, "access log scheduler", scheduler.interval, scheduler.tick)
+
+	// the logs which were queued after the last tick are stored before the scheduler stops
+	scheduler.flush(context.Background())
+}
+
+func (scheduler *AccessLogScheduler) tick(ctx context.Context) {
+	scheduler.flush(ctx)
+
+	if time.Since(scheduler.purgedAt) < scheduler.purgeInterval {
+		return
+	}
+
+	scheduler.purge(ctx)
+	scheduler.purgedAt = time.Now().UTC()
+}
+
+func (scheduler *AccessLogScheduler) flush(ctx context.Context) {
+	ctx, span, ctxLogger := scheduler.tracer.StartWithLogger(ctx, scheduler.logger)
+	defer span.End()
+
+	for {
+		count, err := scheduler.service.Flush(ctx, accessLogSchedulerBatchSize)
+		if err != nil {
+			ctxLogger.Error(stacktrace.Propagate(err, "cannot flush the queued access logs"))
+			return
+		}
+
+		if count < accessLogSchedulerBatchSize {
+			return
+		}
+	}
+}
+
+func (scheduler *AccessLogScheduler) purge(ctx context.Context) {
+	ctx, span, ctxLogger := scheduler.tracer.StartWithLogger(ctx, scheduler.logger)
+	defer span.End()
+
+	if _, err := scheduler.service.Purge(ctx, time.Now().UTC()); err != nil {
+		ctxLogger.Error(stacktrace.Propagate(err, "cannot purge the expired access logs"))
+	}
+}
@@ -0,0 +1,275 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/google/uuid"
+	"github.com/palantir/stacktrace"
+)
+
+// accessLogQueueSize is the number of access logs which are kept in memory before they are stored
+const accessLogQueueSize = 10_000
+
+// accessLogRedacted replaces the value of a sensitive field in a request body
+const accessLogRedacted = "[REDACTED]"
+
+// accessLogSkippedBodyPaths are the path prefixes of the requests which contain credentials, their body is never stored
+var accessLogSkippedBodyPaths = []string{"/v1/auth/", "/v1/two-factor/"}
+
+// accessLogSensitiveFields are the fields of a request body which are redacted, a field is redacted when its name contains one of these words
+var accessLogSensitiveFields = []string{"password", "token", "secret", "api_key", "apikey", "signing_key", "recovery_code"}
+
+// accessLogSensitiveCodeFields are redacted only when they match the whole name of a field so that fields like country_code are kept
+var accessLogSensitiveCodeFields = map[string]bool{"code": true, "otp": true, "totp": true}
+
+// AccessLogService records the requests made to the API and deletes them once the retention period is over
+type AccessLogService struct {
+	service
+	logger       telemetry.Logger
+	tracer       telemetry.Tracer
+	repository   repositories.AccessLogRepository
+	maxBodyBytes int
+	retention    time.Duration
+	queue        chan *entities.AccessLog
+	dropped      uint64
+}
+
+// NewAccessLogService creates a new AccessLogService
+func NewAccessLogService(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	repository repositories.AccessLogRepository,
+	maxBodyBytes int,
+	retention time.Duration,
+) (s *AccessLogService) {
+	return &AccessLogService{
+		logger:       logger.WithService(fmt.Sprintf("%T", s)),
+		tracer:       tracer,
+		repository:   repository,
+		maxBodyBytes: maxBodyBytes,
+		retention:    retention,
+		queue:        make(chan *entities.AccessLog, accessLogQueueSize),
+	}
+}
+
+// AccessLogRecordParams are parameters for recording an HTTP request
+type AccessLogRecordParams struct {
+	UserID      entities.UserID
+	APIKey      string
+	Method      string
+	Path        string
+	Query       string
+	Status      int
+	Latency     time.Duration
+	IP          string
+	UserAgent   string
+	TraceID     string
+	ContentType string
+	Body        []byte
+	Timestamp   time.Time
+}
+
+// Record queues an HTTP request to be stored by Flush.
+// The request is dropped when the queue is full so that a slow database does not slow down the API.
+func (service *AccessLogService) Record(params *AccessLogRecordParams) {
+	body, trimmed := service.body(params.Path, params.ContentType, params.Body)
+	log := &entities.AccessLog{
+		ID:                 uuid.New(),
+		UserID:             params.UserID,
+		APIKey:             service.maskAPIKey(params.APIKey),
+		Method:             params.Method,
+		Path:               params.Path,
+		Query:              params.Query,
+		Status:             params.Status,
+		LatencyMS:          params.Latency.Milliseconds(),
+		IP:                 params.IP,
+		UserAgent:          params.UserAgent,
+		TraceID:            params.TraceID,
+		RequestBody:        body,
+		RequestBodyTrimmed: trimmed,
+		CreatedAt:          params.Timestamp,
+	}
+
+	select {
+	case service.queue <- log:
+	default:
+		atomic.AddUint64(&service.dropped, 1)
+	}
+}
+
+// Flush stores the queued access logs and returns the number of logs which were stored
+func (service *AccessLogService) Flush(ctx context.Context, limit int) (int, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	if dropped := atomic.SwapUint64(&service.dropped, 0); dropped > 0 {
+		ctxLogger.Warn(stacktrace.NewError(fmt.Sprintf("dropped [%d] access logs because the queue was full", dropped)))
+	}
+
+	logs := make([]*entities.AccessLog, 0, limit)
+queue:
+	for len(logs) < limit {
+		select {
+		case log := <-service.queue:
+			logs = append(logs, log)
+		default:
+			break queue
+		}
+	}
+
+	if len(logs) == 0 {
+		return 0, nil
+	}
+
+	if err := service.repository.StoreBatch(ctx, logs); err != nil {
+		msg := fmt.Sprintf("cannot store [%d] access logs", len(logs))
+		return 0, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return len(logs), nil
+}
+
+// Purge deletes the access logs which are older than the retention period
+func (service *AccessLogService) Purge(ctx context.Context, timestamp time.Time) (int64, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	count, err := service.repository.DeleteBefore(ctx, timestamp.Add(-service.retention))
+	if err != nil {
+		msg := fmt.Sprintf("cannot delete access logs older than [%s]", service.retention)
+		return 0, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if count > 0 {
+		ctxLogger.Info(fmt.Sprintf("deleted [%d] access logs older than [%s]", count, service.retention))
+	}
+	return count, nil
+}
+
+// Index fetches the access logs of all users
+func (service *AccessLogService) Index(ctx context.Context, params repositories.AccessLogIndexParams) ([]*entities.AccessLog, error) {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	logs, err := service.repository.Index(ctx, params)
+	if err != nil {
+		msg := fmt.Sprintf("could not fetch access logs with params [%+#v]", params)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return logs, nil
+}
+
+// body keeps the textual request bodies up to the maximum size, binary uploads and the bodies of credential requests are not stored.
+// Sensitive fields are redacted before the body is truncated.
+func (service *AccessLogService) body(path string, contentType string, body []byte) (string, bool) {
+	for _, prefix := range accessLogSkippedBodyPaths {
+		if strings.HasPrefix(path, prefix) {
+			return "", len(body) > 0
+		}
+	}
+
+	contentType = strings.ToLower(contentType)
+	switch {
+	case len(body) == 0:
+		return "", false
+	case strings.Contains(contentType, "json"):
+		redacted, err := service.redactJSON(body)
+		if err != nil {
+			return "", true
+		}
+		body = redacted
+	case strings.Contains(contentType, "x-www-form-urlencoded"):
+		redacted, err := service.redactForm(body)
+		if err != nil {
+			return "", true
+		}
+		body = redacted
+	case !strings.Contains(contentType, "text"):
+		return "", true
+	}
+
+	if len(body) <= service.maxBodyBytes {
+		return strings.ToValidUTF8(string(body), ""), false
+	}
+	return strings.ToValidUTF8(string(body[:service.maxBodyBytes]), ""), true
+}
+
+// redactJSON replaces the values of the sensitive fields in a JSON body
+func (service *AccessLogService) redactJSON(body []byte) ([]byte, error) {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return nil, stacktrace.Propagate(err, "cannot decode JSON request body")
+	}
+
+	return json.Marshal(service.redactValue(value))
+}
+
+func (service *AccessLogService) redactValue(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		for key, item := range v {
+			if service.isSensitiveField(key) {
+				v[key] = accessLogRedacted
+				continue
+			}
+			v[key] = service.redactValue(item)
+		}
+	case []any:
+		for index, item := range v {
+			v[index] = service.redactValue(item)
+		}
+	}
+	return value
+}
+
+// redactForm replaces the values of the sensitive fields in a URL encoded body
+func (service *AccessLogService) redactForm(body []byte) ([]byte, error) {
+	values, err := url.ParseQuery(string(body))
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "cannot decode URL encoded request body")
+	}
+
+	for key := range values {
+		if service.isSensitiveField(key) {
+			values[key] = []string{accessLogRedacted}
+		}
+	}
+	return []byte(values.Encode()), nil
+}
+
+func (service *AccessLogService) isSensitiveField(key string) bool {
+	key = strings.ToLower(strings.ReplaceAll(key, "-", "_"))
+	if accessLogSensitiveCodeFields[key] {
+		return true
+	}
+	for _, field := range accessLogSensitiveFields {
+		if strings.Contains(key, field) {
+			return true
+		}
+	}
+	return false
+}
+
+func (service *AccessLogService) maskAPIKey(apiKey string) string {
+	if apiKey == "" {
+		return ""
+	}
+	if len(apiKey) <= 12 {
+		return "..."
+	}
+	return apiKey[:4] + "..." + apiKey[len(apiKey)-4:]
+}
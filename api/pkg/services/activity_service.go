@@ -0,0 +1,217 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/events"
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/google/uuid"
+	"github.com/palantir/stacktrace"
+)
+
+// activityData are the fields of event payloads which are used to describe an entities.ActivityItem
+type activityData struct {
+	ID               string   `json:"id"`
+	MessageID        string   `json:"message_id"`
+	PhoneID          string   `json:"phone_id"`
+	WebhookID        string   `json:"webhook_id"`
+	Owner            string   `json:"owner"`
+	Contact          string   `json:"contact"`
+	ErrorMessage     string   `json:"error_message"`
+	Reason           string   `json:"reason"`
+	URL              string   `json:"url"`
+	EventType        string   `json:"event_type"`
+	Attempts         uint     `json:"attempts"`
+	LastStatusCode   int      `json:"last_status_code"`
+	SubscriptionName string   `json:"subscription_name"`
+	Risk             string   `json:"risk"`
+	Score            int      `json:"score"`
+	Prefix           string   `json:"prefix"`
+	Action           string   `json:"action"`
+	MessageIDs       []string `json:"message_ids"`
+}
+
+// activityType describes how an event type is displayed in the activity feed
+type activityType struct {
+	category entities.ActivityCategory
+	summary  func(data activityData) string
+}
+
+// activityTypes are the event types which are displayed in the activity feed
+var activityTypes = map[string]activityType{
+	events.EventTypeMessageAPISent: {entities.ActivityCategoryMessage, func(data activityData) string {
+		return fmt.Sprintf("Message from %s to %s was queued", data.Owner, data.Contact)
+	}},
+	events.EventTypeMessagePhoneSent: {entities.ActivityCategoryMessage, func(data activityData) string {
+		return fmt.Sprintf("Message from %s was sent to %s", data.Owner, data.Contact)
+	}},
+	events.EventTypeMessagePhoneDelivered: {entities.ActivityCategoryMessage, func(data activityData) string {
+		return fmt.Sprintf("Message from %s was delivered to %s", data.Owner, data.Contact)
+	}},
+	events.EventTypeMessageSendFailed: {entities.ActivityCategoryMessage, func(data activityData) string {
+		return fmt.Sprintf("Message from %s to %s failed: %s", data.Owner, data.Contact, data.ErrorMessage)
+	}},
+	events.EventTypeMessageSendExpired: {entities.ActivityCategoryMessage, func(data activityData) string {
+		return fmt.Sprintf("Message from %s to %s expired before it was sent", data.Owner, data.Contact)
+	}},
+	events.EventTypeMessagePhoneReceived: {entities.ActivityCategoryMessage, func(data activityData) string {
+		return fmt.Sprintf("Message from %s was received on %s", data.Contact, data.Owner)
+	}},
+	events.EventTypeMessageRead: {entities.ActivityCategoryMessage, func(data activityData) string {
+		return fmt.Sprintf("Messages from %s to %s were marked as read", data.Contact, data.Owner)
+	}},
+	events.EventTypeMessagePurged: {entities.ActivityCategoryMessage, func(data activityData) string {
+		return fmt.Sprintf("%d messages were %s by the retention policy", len(data.MessageIDs), data.Action)
+	}},
+	events.EventTypeCallMissed: {entities.ActivityCategoryPhone, func(data activityData) string {
+		return fmt.Sprintf("Missed call from %s on %s", data.Contact, data.Owner)
+	}},
+	events.EventTypePhoneUpdated: {entities.ActivityCategoryPhone, func(data activityData) string {
+		return fmt.Sprintf("Settings of phone %s were updated", data.Owner)
+	}},
+	events.EventTypePhoneDeviceRegistered: {entities.ActivityCategoryPhone, func(data activityData) string {
+		return fmt.Sprintf("A new device was registered for phone %s", data.Owner)
+	}},
+	events.EventTypePhoneDeleted: {entities.ActivityCategoryPhone, func(data activityData) string {
+		return fmt.Sprintf("Phone %s was deleted", data.Owner)
+	}},
+	events.PhoneHeartbeatMissed: {entities.ActivityCategoryPhone, func(data activityData) string {
+		return fmt.Sprintf("Phone %s missed a heartbeat", data.Owner)
+	}},
+	events.EventTypePhoneHeartbeatDead: {entities.ActivityCategoryPhone, func(data activityData) string {
+		return fmt.Sprintf("Phone %s is offline", data.Owner)
+	}},
+	events.EventTypePhoneReputationDegraded: {entities.ActivityCategoryPhone, func(data activityData) string {
+		return fmt.Sprintf("Phone %s has a %s risk of being blocked by carriers with a reputation score of %d", data.Owner, data.Risk, data.Score)
+	}},
+	events.EventTypePhoneFilteringSuspected: {entities.ActivityCategoryPhone, func(data activityData) string {
+		return fmt.Sprintf("Messages from %s to %s are suspected of being filtered by the carrier", data.Owner, data.Prefix)
+	}},
+	events.EventTypeWebhookDeliveryFailed: {entities.ActivityCategoryWebhook, func(data activityData) string {
+		return fmt.Sprintf("Event %s could not be sent to %s after %d attempts", data.EventType, data.URL, data.Attempts)
+	}},
+	events.UserSubscriptionCreated: {entities.ActivityCategoryAccount, func(data activityData) string {
+		return fmt.Sprintf("Subscribed to the %s plan", data.SubscriptionName)
+	}},
+	events.UserSubscriptionCancelled: {entities.ActivityCategoryAccount, func(data activityData) string {
+		return fmt.Sprintf("Subscription to the %s plan was cancelled", data.SubscriptionName)
+	}},
+	events.UserSubscriptionPaymentFailed: {entities.ActivityCategoryAccount, func(data activityData) string {
+		return fmt.Sprintf("Payment for the %s plan failed", data.SubscriptionName)
+	}},
+	events.UserAccountSuspended: {entities.ActivityCategoryAccount, func(data activityData) string {
+		return fmt.Sprintf("Account was suspended because of %s", data.Reason)
+	}},
+	events.UserAccountReactivated: {entities.ActivityCategoryAccount, func(data activityData) string {
+		return "Account was reactivated"
+	}},
+}
+
+// ActivityService builds the activity feed of a user from the stored events
+type ActivityService struct {
+	service
+	logger     telemetry.Logger
+	tracer     telemetry.Tracer
+	repository repositories.EventRepository
+}
+
+// NewActivityService creates a new ActivityService
+func NewActivityService(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	repository repositories.EventRepository,
+) (s *ActivityService) {
+	return &ActivityService{
+		logger:     logger.WithService(fmt.Sprintf("%T", s)),
+		tracer:     tracer,
+		repository: repository,
+	}
+}
+
+// ActivityIndexParams are parameters for fetching the activity feed of a user
+type ActivityIndexParams struct {
+	Categories []entities.ActivityCategory
+	Cursor     *repositories.EventCursor
+	Limit      int
+}
+
+// ActivityPage is a page of the activity feed
+type ActivityPage struct {
+	Items []*entities.ActivityItem `json:"items"`
+
+	// NextCursor is used to fetch the next page, it is null when there are no more items
+	NextCursor *string `json:"next_cursor" example:"MTY1NDQzMjM2OTUyNzk3NjAwMDozMjM0M2ExOS1kYTVlLTRiMWItYTc2Ny0zMjk4YTczNzAzY2I"`
+}
+
+// Index fetches a page of the activity feed of a user from the newest to the oldest
+func (service *ActivityService) Index(ctx context.Context, userID entities.UserID, params *ActivityIndexParams) (*ActivityPage, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	records, err := service.repository.Index(ctx, userID, repositories.EventIndexParams{
+		Types:  service.types(params.Categories),
+		Cursor: params.Cursor,
+		Limit:  params.Limit,
+	})
+	if err != nil {
+		msg := fmt.Sprintf("could not fetch activity events for user [%s] with params [%+#v]", userID, params)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	page := &ActivityPage{Items: make([]*entities.ActivityItem, 0, len(records))}
+	for _, event := range records {
+		page.Items = append(page.Items, service.toItem(ctxLogger, event))
+	}
+
+	if len(records) == params.Limit && len(records) > 0 {
+		last := records[len(records)-1]
+		cursor := EncodeEventCursor(repositories.EventCursor{Time: last.Time(), ID: uuid.MustParse(last.ID())})
+		page.NextCursor = &cursor
+	}
+
+	ctxLogger.Info(fmt.Sprintf("fetched [%d] activity items for user [%s]", len(page.Items), userID))
+	return page, nil
+}
+
+// types returns the event types of the activity categories, all categories are included when none is selected
+func (service *ActivityService) types(categories []entities.ActivityCategory) []string {
+	selected := map[entities.ActivityCategory]bool{}
+	for _, category := range categories {
+		selected[category] = true
+	}
+
+	types := make([]string, 0, len(activityTypes))
+	for eventType, item := range activityTypes {
+		if len(selected) == 0 || selected[item.category] {
+			types = append(types, eventType)
+		}
+	}
+	return types
+}
+
+func (service *ActivityService) toItem(ctxLogger telemetry.Logger, event cloudevents.Event) *entities.ActivityItem {
+	var data activityData
+	if err := event.DataAs(&data); err != nil {
+		ctxLogger.Warn(stacktrace.Propagate(err, fmt.Sprintf("cannot decode data of event [%s] with type [%s]", event.ID(), event.Type())))
+	}
+
+	item := &entities.ActivityItem{
+		ID:        event.ID(),
+		Category:  activityTypes[event.Type()].category,
+		Type:      event.Type(),
+		Summary:   activityTypes[event.Type()].summary(data),
+		Timestamp: event.Time(),
+	}
+
+	for _, value := range []string{data.MessageID, data.PhoneID, data.WebhookID, data.ID} {
+		if entityID, err := uuid.Parse(value); err == nil {
+			item.EntityID = &entityID
+			break
+		}
+	}
+	return item
+}
@@ -0,0 +1,337 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/events"
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/carlmjohnson/requests"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/palantir/stacktrace"
+)
+
+// AlertChannelEvents are the events which can be posted to an entities.AlertChannel
+var AlertChannelEvents = []string{
+	events.EventTypeMessageSendFailed,
+	events.EventTypePhoneHeartbeatDead,
+	events.EventTypeMessagePhoneReceived,
+	events.EventTypePhoneReputationDegraded,
+	events.EventTypePhoneFilteringSuspected,
+}
+
+// AlertChannelService manages the entities.AlertChannel of a user and posts notifications to slack and discord
+type AlertChannelService struct {
+	service
+	logger     telemetry.Logger
+	tracer     telemetry.Tracer
+	proxy      *EgressProxy
+	repository repositories.AlertChannelRepository
+}
+
+// NewAlertChannelService creates a new AlertChannelService
+func NewAlertChannelService(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	proxy *EgressProxy,
+	repository repositories.AlertChannelRepository,
+) (s *AlertChannelService) {
+	return &AlertChannelService{
+		logger:     logger.WithService(fmt.Sprintf("%T", s)),
+		tracer:     tracer,
+		proxy:      proxy,
+		repository: repository,
+	}
+}
+
+// Index fetches the entities.AlertChannel of a user
+func (service *AlertChannelService) Index(ctx context.Context, userID entities.UserID, params repositories.IndexParams) ([]*entities.AlertChannel, error) {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	channels, err := service.repository.Index(ctx, userID, params)
+	if err != nil {
+		msg := fmt.Sprintf("could not fetch alert channels with params [%+#v]", params)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return channels, nil
+}
+
+// AlertChannelStoreParams are parameters for creating a new entities.AlertChannel
+type AlertChannelStoreParams struct {
+	UserID     entities.UserID
+	Name       string
+	Type       entities.AlertChannelType
+	WebhookURL string
+	Events     []string
+	ProxyURL   *string
+}
+
+// Store a new entities.AlertChannel
+func (service *AlertChannelService) Store(ctx context.Context, params *AlertChannelStoreParams) (*entities.AlertChannel, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	channel := &entities.AlertChannel{
+		ID:         uuid.New(),
+		UserID:     params.UserID,
+		Name:       params.Name,
+		Type:       params.Type,
+		WebhookURL: params.WebhookURL,
+		Events:     params.Events,
+		ProxyURL:   params.ProxyURL,
+		Enabled:    true,
+		CreatedAt:  time.Now().UTC(),
+		UpdatedAt:  time.Now().UTC(),
+	}
+
+	if err := service.repository.Store(ctx, channel); err != nil {
+		msg := fmt.Sprintf("cannot save alert channel with id [%s]", channel.ID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("alert channel saved with id [%s] in the [%T]", channel.ID, service.repository))
+	return channel, nil
+}
+
+// AlertChannelUpdateParams are parameters for updating an entities.AlertChannel
+type AlertChannelUpdateParams struct {
+	AlertChannelStoreParams
+	ChannelID uuid.UUID
+	Enabled   bool
+}
+
+// Update an entities.AlertChannel
+func (service *AlertChannelService) Update(ctx context.Context, params *AlertChannelUpdateParams) (*entities.AlertChannel, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	channel, err := service.repository.Load(ctx, params.UserID, params.ChannelID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot load alert channel with ID [%s] for user [%s]", params.ChannelID, params.UserID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
+	}
+
+	channel.Name = params.Name
+	channel.Type = params.Type
+	channel.WebhookURL = params.WebhookURL
+	channel.ProxyURL = params.ProxyURL
+	channel.Events = params.Events
+	channel.Enabled = params.Enabled
+	channel.UpdatedAt = time.Now().UTC()
+
+	if err = service.repository.Update(ctx, channel); err != nil {
+		msg := fmt.Sprintf("cannot update alert channel with id [%s]", channel.ID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("alert channel updated with id [%s] in the [%T]", channel.ID, service.repository))
+	return channel, nil
+}
+
+// Delete an entities.AlertChannel
+func (service *AlertChannelService) Delete(ctx context.Context, userID entities.UserID, channelID uuid.UUID) error {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	if _, err := service.repository.Load(ctx, userID, channelID); err != nil {
+		msg := fmt.Sprintf("cannot load alert channel with ID [%s] for user [%s]", channelID, userID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
+	}
+
+	if err := service.repository.Delete(ctx, userID, channelID); err != nil {
+		msg := fmt.Sprintf("cannot delete alert channel with id [%s] and user id [%s]", channelID, userID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("deleted alert channel with id [%s] and user id [%s]", channelID, userID))
+	return nil
+}
+
+// alertField is a labelled value in an alert
+type alertField struct {
+	name  string
+	value string
+}
+
+// HandleMessageSendFailed posts the events.EventTypeMessageSendFailed event to the alert channels of the user
+func (service *AlertChannelService) HandleMessageSendFailed(ctx context.Context, payload *events.MessageSendFailedPayload) error {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	fields := []alertField{
+		{name: "From", value: service.getFormattedNumber(ctxLogger, payload.Owner)},
+		{name: "To", value: service.getFormattedNumber(ctxLogger, payload.Contact)},
+		{name: "Reason", value: fmt.Sprintf("%s (%s)", payload.ErrorMessage, payload.FailureCode)},
+		{name: "MessageID", value: payload.ID.String()},
+	}
+
+	if err := service.notify(ctx, payload.UserID, events.EventTypeMessageSendFailed, "❌ message failed", fields); err != nil {
+		msg := fmt.Sprintf("cannot post failure of message [%s] to alert channels", payload.ID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+	return nil
+}
+
+// HandlePhoneHeartbeatDead posts the events.EventTypePhoneHeartbeatDead event to the alert channels of the user
+func (service *AlertChannelService) HandlePhoneHeartbeatDead(ctx context.Context, payload *events.PhoneHeartbeatDeadPayload) error {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	fields := []alertField{
+		{name: "Phone", value: service.getFormattedNumber(ctxLogger, payload.Owner)},
+		{name: "Last Heartbeat", value: payload.LastHeartbeatTimestamp.Format(time.RFC1123)},
+	}
+
+	if err := service.notify(ctx, payload.UserID, events.EventTypePhoneHeartbeatDead, "📵 phone is offline", fields); err != nil {
+		msg := fmt.Sprintf("cannot post offline phone [%s] to alert channels", payload.PhoneID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+	return nil
+}
+
+// HandleMessageReceived posts the events.EventTypeMessagePhoneReceived event to the alert channels of the user
+func (service *AlertChannelService) HandleMessageReceived(ctx context.Context, payload *events.MessagePhoneReceivedPayload) error {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	fields := []alertField{
+		{name: "From", value: service.getFormattedNumber(ctxLogger, payload.Contact)},
+		{name: "To", value: service.getFormattedNumber(ctxLogger, payload.Owner)},
+		{name: "Content", value: payload.Content},
+		{name: "MessageID", value: payload.MessageID.String()},
+	}
+
+	if err := service.notify(ctx, payload.UserID, events.EventTypeMessagePhoneReceived, "✉ new message received", fields); err != nil {
+		msg := fmt.Sprintf("cannot post received message [%s] to alert channels", payload.MessageID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+	return nil
+}
+
+// HandlePhoneReputationDegraded posts the events.EventTypePhoneReputationDegraded event to the alert channels of the user
+func (service *AlertChannelService) HandlePhoneReputationDegraded(ctx context.Context, payload *events.PhoneReputationDegradedPayload) error {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	fields := []alertField{
+		{name: "Phone", value: service.getFormattedNumber(ctxLogger, payload.Owner)},
+		{name: "Risk", value: fmt.Sprintf("%s (was %s)", payload.Risk, payload.PreviousRisk)},
+		{name: "Score", value: fmt.Sprintf("%d (%+d over the previous week)", payload.Score, payload.Score-payload.PreviousScore)},
+		{name: "Complaints", value: fmt.Sprintf("%d complaints and %d negative replies to %d messages", payload.Complaints, payload.Negative, payload.Sent)},
+	}
+
+	if err := service.notify(ctx, payload.UserID, events.EventTypePhoneReputationDegraded, "⚠ phone number at risk of carrier blocking", fields); err != nil {
+		msg := fmt.Sprintf("cannot post degraded reputation of phone [%s] to alert channels", payload.Owner)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+	return nil
+}
+
+// HandlePhoneFilteringSuspected posts the events.EventTypePhoneFilteringSuspected event to the alert channels of the user
+func (service *AlertChannelService) HandlePhoneFilteringSuspected(ctx context.Context, payload *events.PhoneFilteringSuspectedPayload) error {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	suggestion := "no other phone delivers messages to this prefix"
+	if payload.SuggestedOwner != nil {
+		suggestion = fmt.Sprintf("send messages to this prefix with %s", service.getFormattedNumber(ctxLogger, *payload.SuggestedOwner))
+	}
+
+	fields := []alertField{
+		{name: "Phone", value: service.getFormattedNumber(ctxLogger, payload.Owner)},
+		{name: "Prefix", value: payload.Prefix},
+		{name: "Without Delivery Report", value: fmt.Sprintf("%d of %d messages (%.0f%%)", payload.DeliveryUnknown, payload.Sent, payload.DeliveryUnknownRate*100)},
+		{name: "Suggestion", value: suggestion},
+	}
+
+	if err := service.notify(ctx, payload.UserID, events.EventTypePhoneFilteringSuspected, "🚧 messages may be filtered by the carrier", fields); err != nil {
+		msg := fmt.Sprintf("cannot post suspected carrier filtering of prefix [%s] for phone [%s] to alert channels", payload.Prefix, payload.Owner)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+	return nil
+}
+
+// notify posts an alert to the enabled channels of the user which are subscribed to the event, a channel which cannot be reached does not stop the others
+func (service *AlertChannelService) notify(ctx context.Context, userID entities.UserID, eventType string, title string, fields []alertField) error {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	channels, err := service.repository.FetchEnabledByEvent(ctx, userID, eventType)
+	if err != nil {
+		msg := fmt.Sprintf("cannot fetch alert channels of user [%s] for event [%s]", userID, eventType)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	for _, channel := range channels {
+		client, err := service.proxy.Client(channel.ProxyURL)
+		if err != nil {
+			msg := fmt.Sprintf("cannot create http client for alert channel [%s] of user [%s]", channel.ID, userID)
+			ctxLogger.Error(service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg)))
+			continue
+		}
+
+		var response string
+		err = requests.URL(channel.WebhookURL).
+			Client(client).
+			BodyJSON(service.createPayload(channel.Type, title, fields)).
+			ToString(&response).
+			Fetch(ctx)
+		if err != nil {
+			msg := fmt.Sprintf("cannot post [%s] event to [%s] alert channel [%s] of user [%s] with response [%s]", eventType, channel.Type, channel.ID, userID, response)
+			ctxLogger.Error(service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg)))
+			continue
+		}
+
+		ctxLogger.Info(fmt.Sprintf("posted [%s] event to [%s] alert channel [%s] of user [%s]", eventType, channel.Type, channel.ID, userID))
+	}
+
+	return nil
+}
+
+// createPayload formats the alert as a slack or discord message
+func (service *AlertChannelService) createPayload(channelType entities.AlertChannelType, title string, fields []alertField) fiber.Map {
+	if channelType == entities.AlertChannelTypeSlack {
+		slackFields := make([]fiber.Map, 0, len(fields))
+		for _, field := range fields {
+			slackFields = append(slackFields, fiber.Map{
+				"type": "mrkdwn",
+				"text": fmt.Sprintf("*%s:*\n%s", field.name, field.value),
+			})
+		}
+		return fiber.Map{
+			"text": title,
+			"blocks": []fiber.Map{
+				{
+					"type": "section",
+					"text": fiber.Map{"type": "mrkdwn", "text": fmt.Sprintf("*%s*", title)},
+				},
+				{
+					"type":   "section",
+					"fields": slackFields,
+				},
+			},
+		}
+	}
+
+	discordFields := make([]fiber.Map, 0, len(fields))
+	for _, field := range fields {
+		discordFields = append(discordFields, fiber.Map{
+			"name":  field.name + ":",
+			"value": field.value,
+		})
+	}
+	return fiber.Map{
+		"avatar_url": "https://httpsms.com/avatar.png",
+		"username":   "httpsms.com",
+		"content":    title,
+		"embeds": []fiber.Map{
+			{"fields": discordFields},
+		},
+	}
+}
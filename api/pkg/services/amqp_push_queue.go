@@ -0,0 +1,369 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/carlmjohnson/requests"
+	"github.com/google/uuid"
+	"github.com/palantir/stacktrace"
+	amqp "github.com/rabbitmq/amqp091-go"
+
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+)
+
+const (
+	amqpHeaderMethod      = "x-httpsms-method"
+	amqpHeaderURL         = "x-httpsms-url"
+	amqpHeaderScheduledAt = "x-httpsms-scheduled-at"
+	amqpHeaderAttempts    = "x-httpsms-attempts"
+	amqpHeaderDelayBucket = "httpsms-delay-bucket" // headers exchanges ignore headers starting with "x-" when matching
+	amqpHeaderHTTPPrefix  = "x-httpsms-http-"
+
+	amqpMaxAttempts       = 10
+	amqpPrefetchCount     = 20
+	amqpReconnectInterval = 5 * time.Second
+)
+
+// amqpDelayBuckets are the TTLs of the delay queues, a task hops through the buckets until it is due because RabbitMQ only expires messages at the head of a queue.
+var amqpDelayBuckets = []time.Duration{
+	time.Second,
+	5 * time.Second,
+	30 * time.Second,
+	time.Minute,
+	5 * time.Minute,
+	30 * time.Minute,
+	time.Hour,
+	6 * time.Hour,
+}
+
+// AMQPPushQueue is a PushQueue which publishes tasks to a RabbitMQ exchange with the RoutingKey of the task.
+//
+// Delayed tasks are published to the "<name>.delay" exchange where they wait in queues with a fixed TTL before they are dead-lettered back to the "<name>" exchange.
+type AMQPPushQueue struct {
+	config PushQueueConfig
+	url    string
+	client *http.Client
+	logger telemetry.Logger
+	tracer telemetry.Tracer
+
+	mutex      sync.Mutex
+	connection *amqp.Connection
+	channel    *amqp.Channel
+}
+
+// NewAMQPPushQueue creates a new AMQPPushQueue
+func NewAMQPPushQueue(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	client *http.Client,
+	url string,
+	config PushQueueConfig,
+) *AMQPPushQueue {
+	return &AMQPPushQueue{
+		tracer: tracer,
+		logger: logger.WithService(fmt.Sprintf("%T", &AMQPPushQueue{})),
+		client: client,
+		url:    url,
+		config: config,
+	}
+}
+
+// Enqueue a task to the queue
+func (queue *AMQPPushQueue) Enqueue(ctx context.Context, task *PushQueueTask, timeout time.Duration) (queueID string, err error) {
+	ctx, span, ctxLogger := queue.tracer.StartWithLogger(ctx, queue.logger)
+	defer span.End()
+
+	queueID = uuid.New().String()
+	scheduledAt := time.Now().UTC().Add(timeout)
+
+	headers := amqp.Table{
+		amqpHeaderMethod:      task.Method,
+		amqpHeaderURL:         task.URL,
+		amqpHeaderScheduledAt: scheduledAt.UnixMilli(),
+		amqpHeaderAttempts:    int32(0),
+	}
+	for key, value := range task.Headers {
+		headers[amqpHeaderHTTPPrefix+strings.ToLower(key)] = value
+	}
+
+	message := amqp.Publishing{
+		MessageId:    queueID,
+		ContentType:  "application/json",
+		DeliveryMode: amqp.Persistent,
+		Timestamp:    time.Now().UTC(),
+		Headers:      headers,
+		Body:         task.Body,
+	}
+
+	if err = queue.publish(ctx, task.RoutingKey, scheduledAt, message); err != nil {
+		msg := fmt.Sprintf("cannot publish task [%s] with routing key [%s]", queueID, task.RoutingKey)
+		return queueID, queue.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf(
+		"task added to [%s] exchange with ID [%s], routing key [%s] and scheduled at [%s]",
+		queue.config.Name,
+		queueID,
+		task.RoutingKey,
+		scheduledAt,
+	))
+
+	return queueID, nil
+}
+
+// Consume pushes the tasks in the "<name>.consumer" queue to their URL until the context is cancelled
+func (queue *AMQPPushQueue) Consume(ctx context.Context) {
+	for {
+		if err := queue.consume(ctx); err != nil {
+			queue.logger.Error(stacktrace.Propagate(err, fmt.Sprintf("cannot consume tasks from [%s] exchange, reconnecting in [%s]", queue.config.Name, amqpReconnectInterval)))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(amqpReconnectInterval):
+		}
+	}
+}
+
+func (queue *AMQPPushQueue) consume(ctx context.Context) error {
+	connection, err := amqp.Dial(queue.url)
+	if err != nil {
+		return stacktrace.Propagate(err, "cannot connect to AMQP broker")
+	}
+	defer connection.Close()
+
+	channel, err := connection.Channel()
+	if err != nil {
+		return stacktrace.Propagate(err, "cannot open AMQP channel")
+	}
+
+	if err = queue.declare(channel); err != nil {
+		return stacktrace.Propagate(err, "cannot declare AMQP topology")
+	}
+
+	if err = channel.Qos(amqpPrefetchCount, 0, false); err != nil {
+		return stacktrace.Propagate(err, fmt.Sprintf("cannot set prefetch count to [%d]", amqpPrefetchCount))
+	}
+
+	deliveries, err := channel.Consume(queue.consumerQueue(), "", false, false, false, false, nil)
+	if err != nil {
+		return stacktrace.Propagate(err, fmt.Sprintf("cannot consume from queue [%s]", queue.consumerQueue()))
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case delivery, ok := <-deliveries:
+			if !ok {
+				return stacktrace.NewError(fmt.Sprintf("delivery channel of queue [%s] was closed", queue.consumerQueue()))
+			}
+			queue.handle(ctx, delivery)
+		}
+	}
+}
+
+func (queue *AMQPPushQueue) handle(ctx context.Context, delivery amqp.Delivery) {
+	scheduledAt := time.UnixMilli(queue.int64Header(delivery.Headers, amqpHeaderScheduledAt))
+	if time.Until(scheduledAt) >= amqpDelayBuckets[0] {
+		queue.republish(ctx, delivery, scheduledAt)
+		return
+	}
+
+	err := queue.push(ctx, delivery)
+	if err == nil {
+		queue.logger.Info(fmt.Sprintf("queue task [%s] sent to URL [%s]", delivery.MessageId, delivery.Headers[amqpHeaderURL]))
+		queue.ack(delivery)
+		return
+	}
+
+	attempts := queue.int64Header(delivery.Headers, amqpHeaderAttempts) + 1
+	if attempts >= amqpMaxAttempts {
+		queue.logger.Error(stacktrace.Propagate(err, fmt.Sprintf("dropping queue task [%s] to URL [%s] after [%d] attempts", delivery.MessageId, delivery.Headers[amqpHeaderURL], attempts)))
+		queue.ack(delivery)
+		return
+	}
+
+	queue.logger.Warn(stacktrace.Propagate(err, fmt.Sprintf("cannot send queue task [%s] to URL [%s] on attempt [%d]", delivery.MessageId, delivery.Headers[amqpHeaderURL], attempts)))
+	delivery.Headers[amqpHeaderAttempts] = int32(attempts)
+	queue.republish(ctx, delivery, time.Now().UTC().Add(time.Duration(1<<attempts)*time.Second))
+}
+
+// republish moves a delivery back to the delay queues so that it is consumed again at scheduledAt
+func (queue *AMQPPushQueue) republish(ctx context.Context, delivery amqp.Delivery, scheduledAt time.Time) {
+	delivery.Headers[amqpHeaderScheduledAt] = scheduledAt.UnixMilli()
+
+	err := queue.publish(ctx, delivery.RoutingKey, scheduledAt, amqp.Publishing{
+		MessageId:    delivery.MessageId,
+		ContentType:  delivery.ContentType,
+		DeliveryMode: amqp.Persistent,
+		Timestamp:    delivery.Timestamp,
+		Headers:      delivery.Headers,
+		Body:         delivery.Body,
+	})
+	if err != nil {
+		queue.logger.Error(stacktrace.Propagate(err, fmt.Sprintf("cannot republish queue task [%s], it will be redelivered", delivery.MessageId)))
+		if err = delivery.Nack(false, true); err != nil {
+			queue.logger.Error(stacktrace.Propagate(err, fmt.Sprintf("cannot nack queue task [%s]", delivery.MessageId)))
+		}
+		return
+	}
+
+	queue.ack(delivery)
+}
+
+func (queue *AMQPPushQueue) ack(delivery amqp.Delivery) {
+	if err := delivery.Ack(false); err != nil {
+		queue.logger.Error(stacktrace.Propagate(err, fmt.Sprintf("cannot ack queue task [%s]", delivery.MessageId)))
+	}
+}
+
+func (queue *AMQPPushQueue) push(ctx context.Context, delivery amqp.Delivery) error {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	method, _ := delivery.Headers[amqpHeaderMethod].(string)
+	url, _ := delivery.Headers[amqpHeaderURL].(string)
+
+	request := requests.
+		URL(url).
+		Client(queue.client).
+		Method(method).
+		BodyBytes(delivery.Body)
+
+	// add headers
+	for key, value := range delivery.Headers {
+		if header, ok := value.(string); ok && strings.HasPrefix(key, amqpHeaderHTTPPrefix) {
+			request.Header(strings.TrimPrefix(key, amqpHeaderHTTPPrefix), header)
+		}
+	}
+
+	// add content type
+	request.Header("Content-Type", "application/json")
+
+	return request.Fetch(ctx)
+}
+
+// publish sends the message to the exchange when it is due or to the largest delay bucket which expires before scheduledAt
+func (queue *AMQPPushQueue) publish(ctx context.Context, routingKey string, scheduledAt time.Time, message amqp.Publishing) error {
+	queue.mutex.Lock()
+	defer queue.mutex.Unlock()
+
+	channel, err := queue.publishChannel()
+	if err != nil {
+		return stacktrace.Propagate(err, "cannot open AMQP channel for publishing")
+	}
+
+	exchange := queue.config.Name
+	if bucket, ok := queue.delayBucket(time.Until(scheduledAt)); ok {
+		exchange = queue.delayExchange()
+		message.Headers[amqpHeaderDelayBucket] = bucket.String()
+	}
+
+	if err = channel.PublishWithContext(ctx, exchange, routingKey, false, false, message); err != nil {
+		return stacktrace.Propagate(err, fmt.Sprintf("cannot publish message to exchange [%s]", exchange))
+	}
+
+	return nil
+}
+
+// publishChannel returns the channel used for publishing and reconnects when the connection was closed
+func (queue *AMQPPushQueue) publishChannel() (*amqp.Channel, error) {
+	if queue.channel != nil && !queue.channel.IsClosed() {
+		return queue.channel, nil
+	}
+
+	if queue.connection == nil || queue.connection.IsClosed() {
+		connection, err := amqp.Dial(queue.url)
+		if err != nil {
+			return nil, stacktrace.Propagate(err, "cannot connect to AMQP broker")
+		}
+		queue.connection = connection
+	}
+
+	channel, err := queue.connection.Channel()
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "cannot open AMQP channel")
+	}
+
+	if err = queue.declare(channel); err != nil {
+		return nil, stacktrace.Propagate(err, "cannot declare AMQP topology")
+	}
+
+	queue.channel = channel
+	return channel, nil
+}
+
+// declare creates the exchanges and queues used by the push queue
+func (queue *AMQPPushQueue) declare(channel *amqp.Channel) error {
+	if err := channel.ExchangeDeclare(queue.config.Name, amqp.ExchangeTopic, true, false, false, false, nil); err != nil {
+		return stacktrace.Propagate(err, fmt.Sprintf("cannot declare exchange [%s]", queue.config.Name))
+	}
+
+	if err := channel.ExchangeDeclare(queue.delayExchange(), amqp.ExchangeHeaders, true, false, false, false, nil); err != nil {
+		return stacktrace.Propagate(err, fmt.Sprintf("cannot declare exchange [%s]", queue.delayExchange()))
+	}
+
+	if _, err := channel.QueueDeclare(queue.consumerQueue(), true, false, false, false, nil); err != nil {
+		return stacktrace.Propagate(err, fmt.Sprintf("cannot declare queue [%s]", queue.consumerQueue()))
+	}
+
+	if err := channel.QueueBind(queue.consumerQueue(), "#", queue.config.Name, false, nil); err != nil {
+		return stacktrace.Propagate(err, fmt.Sprintf("cannot bind queue [%s] to exchange [%s]", queue.consumerQueue(), queue.config.Name))
+	}
+
+	for _, bucket := range amqpDelayBuckets {
+		name := queue.delayExchange() + "." + bucket.String()
+		_, err := channel.QueueDeclare(name, true, false, false, false, amqp.Table{
+			"x-message-ttl":          bucket.Milliseconds(),
+			"x-dead-letter-exchange": queue.config.Name,
+		})
+		if err != nil {
+			return stacktrace.Propagate(err, fmt.Sprintf("cannot declare delay queue [%s]", name))
+		}
+
+		err = channel.QueueBind(name, "", queue.delayExchange(), false, amqp.Table{
+			"x-match":             "all",
+			amqpHeaderDelayBucket: bucket.String(),
+		})
+		if err != nil {
+			return stacktrace.Propagate(err, fmt.Sprintf("cannot bind delay queue [%s] to exchange [%s]", name, queue.delayExchange()))
+		}
+	}
+
+	return nil
+}
+
+func (queue *AMQPPushQueue) delayBucket(delay time.Duration) (time.Duration, bool) {
+	for index := len(amqpDelayBuckets) - 1; index >= 0; index-- {
+		if delay >= amqpDelayBuckets[index] {
+			return amqpDelayBuckets[index], true
+		}
+	}
+	return 0, false
+}
+
+func (queue *AMQPPushQueue) int64Header(headers amqp.Table, key string) int64 {
+	switch value := headers[key].(type) {
+	case int32:
+		return int64(value)
+	case int64:
+		return value
+	default:
+		return 0
+	}
+}
+
+func (queue *AMQPPushQueue) delayExchange() string {
+	return queue.config.Name + ".delay"
+}
+
+func (queue *AMQPPushQueue) consumerQueue() string {
+	return queue.config.Name + ".consumer"
+}
@@ -0,0 +1,62 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/palantir/stacktrace"
+)
+
+// AnalyticsService computes metrics for the dashboard charts
+type AnalyticsService struct {
+	service
+	logger     telemetry.Logger
+	tracer     telemetry.Tracer
+	repository repositories.AnalyticsRepository
+}
+
+// NewAnalyticsService creates a new AnalyticsService
+func NewAnalyticsService(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	repository repositories.AnalyticsRepository,
+) (s *AnalyticsService) {
+	return &AnalyticsService{
+		logger:     logger.WithService(fmt.Sprintf("%T", s)),
+		tracer:     tracer,
+		repository: repository,
+	}
+}
+
+// TimeSeries aggregates a metric of a user into time buckets
+func (service *AnalyticsService) TimeSeries(ctx context.Context, userID entities.UserID, params repositories.TimeSeriesParams) ([]*entities.TimeSeriesPoint, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	points, err := service.repository.TimeSeries(ctx, userID, params)
+	if err != nil {
+		msg := fmt.Sprintf("could not fetch time series with params [%+#v]", params)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("fetched [%d] time series points with prams [%+#v]", len(points), params))
+	return points, nil
+}
+
+// FailureStats counts the failed messages of a user by entities.MessageFailureCode
+func (service *AnalyticsService) FailureStats(ctx context.Context, userID entities.UserID, params repositories.FailureStatsParams) ([]*entities.MessageFailureCodeCount, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	counts, err := service.repository.FailureStats(ctx, userID, params)
+	if err != nil {
+		msg := fmt.Sprintf("could not fetch failure stats with params [%+#v]", params)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("fetched [%d] failure stats with prams [%+#v]", len(counts), params))
+	return counts, nil
+}
@@ -0,0 +1,91 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/events"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/google/uuid"
+	"github.com/palantir/stacktrace"
+)
+
+// AttachmentScanService scans attachments before they are made downloadable
+type AttachmentScanService struct {
+	service
+	logger     telemetry.Logger
+	tracer     telemetry.Tracer
+	scanner    AttachmentScanner
+	dispatcher *EventDispatcher
+}
+
+// NewAttachmentScanService creates a new AttachmentScanService
+func NewAttachmentScanService(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	scanner AttachmentScanner,
+	dispatcher *EventDispatcher,
+) (s *AttachmentScanService) {
+	return &AttachmentScanService{
+		logger:     logger.WithService(fmt.Sprintf("%T", s)),
+		tracer:     tracer,
+		scanner:    scanner,
+		dispatcher: dispatcher,
+	}
+}
+
+// AttachmentScanParams are parameters for scanning an attachment
+type AttachmentScanParams struct {
+	Source      string
+	UserID      entities.UserID
+	MessageID   uuid.UUID
+	Name        string
+	ContentType string
+	Content     []byte
+}
+
+// Scan an attachment and dispatch the events.EventTypeAttachmentQuarantined event when malware is found
+func (service *AttachmentScanService) Scan(ctx context.Context, params *AttachmentScanParams) (*AttachmentScanResult, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	result, err := service.scanner.Scan(ctx, params.Name, params.Content)
+	if err != nil {
+		msg := fmt.Sprintf("cannot scan attachment [%s] of message [%s] with scanner [%s]", params.Name, params.MessageID, service.scanner.Name())
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if result.Status != entities.AttachmentScanStatusQuarantined {
+		ctxLogger.Info(fmt.Sprintf("attachment [%s] of message [%s] is [%s]", params.Name, params.MessageID, result.Status))
+		return result, nil
+	}
+
+	event, err := service.createAttachmentQuarantinedEvent(params.Source, events.AttachmentQuarantinedPayload{
+		MessageID:   params.MessageID,
+		UserID:      params.UserID,
+		Name:        params.Name,
+		ContentType: params.ContentType,
+		Scanner:     service.scanner.Name(),
+		Signature:   result.Signature,
+		Timestamp:   time.Now().UTC(),
+	})
+	if err != nil {
+		msg := fmt.Sprintf("cannot create event when attachment [%s] of message [%s] is quarantined", params.Name, params.MessageID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if err = service.dispatcher.Dispatch(ctx, event); err != nil {
+		msg := fmt.Sprintf("cannot dispatch event [%s] for message [%s]", event.Type(), params.MessageID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Warn(stacktrace.NewError(fmt.Sprintf("attachment [%s] of message [%s] quarantined with signature [%s]", params.Name, params.MessageID, result.Signature)))
+	return result, nil
+}
+
+func (service *AttachmentScanService) createAttachmentQuarantinedEvent(source string, payload events.AttachmentQuarantinedPayload) (cloudevents.Event, error) {
+	return service.createEvent(events.EventTypeAttachmentQuarantined, source, payload)
+}
@@ -0,0 +1,40 @@
+package services
+
+import (
+	"context"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+)
+
+// AttachmentScanResult is the result of scanning an attachment
+type AttachmentScanResult struct {
+	Status entities.AttachmentScanStatus
+	// Signature is the name of the malware which was detected
+	Signature string
+}
+
+// AttachmentScanner scans attachments for malware before they can be downloaded
+type AttachmentScanner interface {
+	// Name of the scanner e.g. clamav
+	Name() string
+
+	// Scan the content of an attachment
+	Scan(ctx context.Context, name string, content []byte) (*AttachmentScanResult, error)
+}
+
+type noopAttachmentScanner struct{}
+
+// NoopAttachmentScanner creates an AttachmentScanner which marks every attachment as clean
+func NoopAttachmentScanner() AttachmentScanner {
+	return &noopAttachmentScanner{}
+}
+
+// Name of the scanner
+func (scanner *noopAttachmentScanner) Name() string {
+	return "noop"
+}
+
+// Scan the content of an attachment
+func (scanner *noopAttachmentScanner) Scan(_ context.Context, _ string, _ []byte) (*AttachmentScanResult, error) {
+	return &AttachmentScanResult{Status: entities.AttachmentScanStatusClean}, nil
+}
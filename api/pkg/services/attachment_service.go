@@ -0,0 +1,223 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"fmt"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/google/uuid"
+	"github.com/palantir/stacktrace"
+)
+
+// ErrCodeAttachmentQuarantined is returned when an attachment of a message which is being sent contains malware
+const ErrCodeAttachmentQuarantined = stacktrace.ErrorCode(2005)
+
+// AttachmentService stores the attachments of MMS messages and creates their download URLs
+type AttachmentService struct {
+	service
+	logger      telemetry.Logger
+	tracer      telemetry.Tracer
+	repository  repositories.AttachmentRepository
+	storage     AttachmentStorage
+	scanService *AttachmentScanService
+	signingKey  string
+	urlTTL      time.Duration
+}
+
+// NewAttachmentService creates a new AttachmentService
+func NewAttachmentService(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	repository repositories.AttachmentRepository,
+	storage AttachmentStorage,
+	scanService *AttachmentScanService,
+	signingKey string,
+	urlTTL time.Duration,
+) (s *AttachmentService) {
+	return &AttachmentService{
+		logger:      logger.WithService(fmt.Sprintf("%T", s)),
+		tracer:      tracer,
+		repository:  repository,
+		storage:     storage,
+		scanService: scanService,
+		signingKey:  signingKey,
+		urlTTL:      urlTTL,
+	}
+}
+
+// AttachmentUpload is the content of an attachment which is sent or received with a message
+type AttachmentUpload struct {
+	Name        string
+	ContentType string
+	Content     []byte
+}
+
+// AttachmentStoreParams are parameters for storing the attachments of a message
+type AttachmentStoreParams struct {
+	Source    string
+	UserID    entities.UserID
+	MessageID uuid.UUID
+	Uploads   []AttachmentUpload
+
+	// RejectQuarantined fails with ErrCodeAttachmentQuarantined instead of storing quarantined attachments without content
+	RejectQuarantined bool
+}
+
+// Store scans the attachments of a message and saves the clean ones in the AttachmentStorage
+func (service *AttachmentService) Store(ctx context.Context, params *AttachmentStoreParams) ([]*entities.Attachment, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	results := make([]*AttachmentScanResult, 0, len(params.Uploads))
+	for _, upload := range params.Uploads {
+		result, err := service.scanService.Scan(ctx, &AttachmentScanParams{
+			Source:      params.Source,
+			UserID:      params.UserID,
+			MessageID:   params.MessageID,
+			Name:        upload.Name,
+			ContentType: upload.ContentType,
+			Content:     upload.Content,
+		})
+		if err != nil {
+			msg := fmt.Sprintf("cannot scan attachment [%s] of message [%s]", upload.Name, params.MessageID)
+			return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+		}
+
+		if params.RejectQuarantined && result.Status == entities.AttachmentScanStatusQuarantined {
+			msg := fmt.Sprintf("attachment [%s] of message [%s] is quarantined", upload.Name, params.MessageID)
+			return nil, service.tracer.WrapErrorSpan(span, stacktrace.NewErrorWithCode(ErrCodeAttachmentQuarantined, msg))
+		}
+		results = append(results, result)
+	}
+
+	attachments := make([]*entities.Attachment, 0, len(params.Uploads))
+	for index, upload := range params.Uploads {
+		attachment := &entities.Attachment{
+			ID:          uuid.New(),
+			UserID:      params.UserID,
+			MessageID:   params.MessageID,
+			Name:        upload.Name,
+			ContentType: upload.ContentType,
+			Size:        len(upload.Content),
+			ScanStatus:  results[index].Status,
+			CreatedAt:   time.Now().UTC(),
+			UpdatedAt:   time.Now().UTC(),
+		}
+
+		if attachment.ScanStatus != entities.AttachmentScanStatusQuarantined {
+			key := fmt.Sprintf("%s/%s/%s", attachment.UserID, attachment.MessageID, attachment.ID)
+			if err := service.storage.Store(ctx, key, attachment.ContentType, upload.Content); err != nil {
+				msg := fmt.Sprintf("cannot store attachment [%s] of message [%s] in [%s] storage", attachment.ID, params.MessageID, service.storage.Name())
+				return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+			}
+			attachment.StorageKey = key
+		}
+
+		if err := service.repository.Store(ctx, attachment); err != nil {
+			msg := fmt.Sprintf("cannot save attachment [%s] of message [%s]", attachment.ID, params.MessageID)
+			return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+		}
+		attachments = append(attachments, attachment)
+	}
+
+	ctxLogger.Info(fmt.Sprintf("stored [%d] attachments of message [%s] in [%s] storage", len(attachments), params.MessageID, service.storage.Name()))
+	return attachments, service.sign(ctx, attachments)
+}
+
+// LoadForMessages sets the attachments with signed download URLs on messages which have attachments
+func (service *AttachmentService) LoadForMessages(ctx context.Context, userID entities.UserID, messages ...*entities.Message) error {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	lookup := map[uuid.UUID]*entities.Message{}
+	messageIDs := make([]uuid.UUID, 0, len(messages))
+	for _, message := range messages {
+		if message.AttachmentCount == 0 {
+			continue
+		}
+		lookup[message.ID] = message
+		messageIDs = append(messageIDs, message.ID)
+		message.Attachments = make([]*entities.Attachment, 0, message.AttachmentCount)
+	}
+
+	if len(messageIDs) == 0 {
+		return nil
+	}
+
+	attachments, err := service.repository.FetchByMessageIDs(ctx, userID, messageIDs)
+	if err != nil {
+		msg := fmt.Sprintf("cannot fetch attachments of [%d] messages for user [%s]", len(messageIDs), userID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if err = service.sign(ctx, attachments); err != nil {
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, fmt.Sprintf("cannot sign attachments for user [%s]", userID)))
+	}
+
+	for _, attachment := range attachments {
+		lookup[attachment.MessageID].Attachments = append(lookup[attachment.MessageID].Attachments, attachment)
+	}
+
+	return nil
+}
+
+// AttachmentContentParams are parameters for downloading an attachment with a signed URL
+type AttachmentContentParams struct {
+	UserID       entities.UserID
+	MessageID    uuid.UUID
+	AttachmentID uuid.UUID
+	Expires      int64
+	Signature    string
+}
+
+// Content loads an attachment and its content after validating the signature of the download URL
+func (service *AttachmentService) Content(ctx context.Context, params *AttachmentContentParams) (*entities.Attachment, []byte, error) {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	key := fmt.Sprintf("%s/%s/%s", params.UserID, params.MessageID, params.AttachmentID)
+	if params.Expires < time.Now().UTC().Unix() || !hmac.Equal([]byte(params.Signature), []byte(AttachmentURLSignature(service.signingKey, key, params.Expires))) {
+		msg := fmt.Sprintf("the download URL of attachment [%s] is expired or has an invalid signature", key)
+		return nil, nil, service.tracer.WrapErrorSpan(span, stacktrace.NewErrorWithCode(repositories.ErrCodeNotFound, msg))
+	}
+
+	attachment, err := service.repository.Load(ctx, params.UserID, params.AttachmentID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot load attachment [%s] for user [%s]", params.AttachmentID, params.UserID)
+		return nil, nil, service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
+	}
+
+	if attachment.MessageID != params.MessageID || attachment.StorageKey != key || !attachment.IsDownloadable() {
+		msg := fmt.Sprintf("attachment [%s] of message [%s] cannot be downloaded", attachment.ID, attachment.MessageID)
+		return nil, nil, service.tracer.WrapErrorSpan(span, stacktrace.NewErrorWithCode(repositories.ErrCodeNotFound, msg))
+	}
+
+	content, err := service.storage.Load(ctx, attachment.StorageKey)
+	if err != nil {
+		msg := fmt.Sprintf("cannot load content of attachment [%s] from [%s] storage", attachment.ID, service.storage.Name())
+		return nil, nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return attachment, content, nil
+}
+
+// sign sets the download URL of attachments which can be downloaded
+func (service *AttachmentService) sign(ctx context.Context, attachments []*entities.Attachment) error {
+	expiresAt := time.Now().UTC().Add(service.urlTTL)
+	for _, attachment := range attachments {
+		if !attachment.IsDownloadable() {
+			continue
+		}
+
+		url, err := service.storage.SignedURL(ctx, attachment.StorageKey, expiresAt)
+		if err != nil {
+			return stacktrace.Propagate(err, fmt.Sprintf("cannot sign URL of attachment [%s] with [%s] storage", attachment.ID, service.storage.Name()))
+		}
+		attachment.URL = url
+	}
+	return nil
+}
@@ -0,0 +1,32 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"time"
+)
+
+// AttachmentStorage stores the content of attachments in an object storage
+type AttachmentStorage interface {
+	// Name of the storage e.g. s3
+	Name() string
+
+	// Store the content of an attachment with a key
+	Store(ctx context.Context, key string, contentType string, content []byte) error
+
+	// Load the content of an attachment
+	Load(ctx context.Context, key string) ([]byte, error)
+
+	// SignedURL creates a URL which can be used without authentication to download an attachment until expiresAt
+	SignedURL(ctx context.Context, key string, expiresAt time.Time) (string, error)
+}
+
+// AttachmentURLSignature is the signature of the download URL of attachments which are served by the API
+func AttachmentURLSignature(signingKey string, key string, expires int64) string {
+	mac := hmac.New(sha256.New, []byte(signingKey))
+	mac.Write([]byte(fmt.Sprintf("%s:%d", key, expires)))
+	return hex.EncodeToString(mac.Sum(nil))
+}
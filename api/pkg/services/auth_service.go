@@ -0,0 +1,343 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/emails"
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/identity"
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/google/uuid"
+	"github.com/palantir/stacktrace"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	// ErrCodeEmailTaken is returned when signing up with the email address of an existing credential
+	ErrCodeEmailTaken = stacktrace.ErrorCode(2009)
+
+	// ErrCodeInvalidCredentials is returned when the email and password do not match or an auth token is invalid
+	ErrCodeInvalidCredentials = stacktrace.ErrorCode(2010)
+
+	// ErrCodeEmailNotVerified is returned when logging in before the email address is verified
+	ErrCodeEmailNotVerified = stacktrace.ErrorCode(2011)
+)
+
+// passwordResetTokenTTL is how long the link in the password reset email can be used
+const passwordResetTokenTTL = time.Hour
+
+// AuthService registers and logs in users with an email and password when the instance is not backed by firebase
+type AuthService struct {
+	service
+	logger               telemetry.Logger
+	tracer               telemetry.Tracer
+	credentialRepository repositories.UserCredentialRepository
+	sessionService       *AuthSessionService
+	provider             *identity.JWTProvider
+	mailer               emails.Mailer
+	emailFactory         emails.UserEmailFactory
+}
+
+// NewAuthService creates a new AuthService
+func NewAuthService(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	credentialRepository repositories.UserCredentialRepository,
+	sessionService *AuthSessionService,
+	provider *identity.JWTProvider,
+	mailer emails.Mailer,
+	emailFactory emails.UserEmailFactory,
+) (s *AuthService) {
+	return &AuthService{
+		logger:               logger.WithService(fmt.Sprintf("%T", s)),
+		tracer:               tracer,
+		credentialRepository: credentialRepository,
+		sessionService:       sessionService,
+		provider:             provider,
+		mailer:               mailer,
+		emailFactory:         emailFactory,
+	}
+}
+
+// AuthSignupParams are parameters for registering a user
+type AuthSignupParams struct {
+	Email    string
+	Password string
+}
+
+// Signup stores the credential of a new user and sends the email verification link.
+// The entities.User is created on the first authenticated request like users who log in with firebase.
+func (service *AuthService) Signup(ctx context.Context, params *AuthSignupParams) (*entities.UserCredential, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	_, err := service.credentialRepository.LoadByEmail(ctx, params.Email)
+	if err == nil {
+		msg := fmt.Sprintf("a credential with email [%s] already exists", params.Email)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.NewErrorWithCode(ErrCodeEmailTaken, msg))
+	}
+	if stacktrace.GetCode(err) != repositories.ErrCodeNotFound {
+		msg := fmt.Sprintf("cannot load credential with email [%s]", params.Email)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(params.Password), bcrypt.DefaultCost)
+	if err != nil {
+		msg := fmt.Sprintf("cannot hash password for email [%s]", params.Email)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	token, tokenHash, err := service.generateToken()
+	if err != nil {
+		msg := fmt.Sprintf("cannot generate verification token for email [%s]", params.Email)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	credential := &entities.UserCredential{
+		ID:                    uuid.New(),
+		UserID:                entities.UserID(uuid.NewString()),
+		Email:                 params.Email,
+		PasswordHash:          string(hash),
+		VerificationTokenHash: &tokenHash,
+		CreatedAt:             time.Now().UTC(),
+		UpdatedAt:             time.Now().UTC(),
+	}
+
+	if err = service.credentialRepository.Store(ctx, credential); err != nil {
+		msg := fmt.Sprintf("cannot store credential for email [%s]", params.Email)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	email, err := service.emailFactory.EmailVerification(credential.Email, token)
+	if err != nil {
+		msg := fmt.Sprintf("cannot create verification email for user [%s]", credential.UserID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if err = service.mailer.Send(ctx, email); err != nil {
+		msg := fmt.Sprintf("cannot send verification email to user [%s]", credential.UserID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("user [%s] signed up successfully with email [%s]", credential.UserID, credential.Email))
+	return credential, nil
+}
+
+// VerifyEmail confirms the email address of the credential which was sent the token
+func (service *AuthService) VerifyEmail(ctx context.Context, token string) (*entities.UserCredential, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	credential, err := service.credentialRepository.LoadByVerificationToken(ctx, service.hashToken(token))
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, ErrCodeInvalidCredentials, "the email verification token is invalid"))
+	}
+	if err != nil {
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, "cannot load credential by verification token"))
+	}
+
+	timestamp := time.Now().UTC()
+	credential.EmailVerifiedAt = &timestamp
+	credential.VerificationTokenHash = nil
+	credential.UpdatedAt = timestamp
+
+	if err = service.credentialRepository.Update(ctx, credential); err != nil {
+		msg := fmt.Sprintf("cannot verify email of user [%s]", credential.UserID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("email [%s] of user [%s] verified successfully", credential.Email, credential.UserID))
+	return credential, nil
+}
+
+// AuthLoginParams are parameters for logging in with an email and password
+type AuthLoginParams struct {
+	Email     string
+	Password  string
+	IPAddress string
+	UserAgent string
+}
+
+// AuthLoginResult is the token issued after a successful login
+type AuthLoginResult struct {
+	Token   string
+	User    entities.AuthUser
+	Session *entities.AuthSession
+}
+
+// Login checks the password of a verified user and starts an entities.AuthSession
+func (service *AuthService) Login(ctx context.Context, params *AuthLoginParams) (*AuthLoginResult, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	credential, err := service.credentialRepository.LoadByEmail(ctx, params.Email)
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		msg := fmt.Sprintf("no credential exists with email [%s]", params.Email)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, ErrCodeInvalidCredentials, msg))
+	}
+	if err != nil {
+		msg := fmt.Sprintf("cannot load credential with email [%s]", params.Email)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if err = bcrypt.CompareHashAndPassword([]byte(credential.PasswordHash), []byte(params.Password)); err != nil {
+		msg := fmt.Sprintf("invalid password for user [%s]", credential.UserID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, ErrCodeInvalidCredentials, msg))
+	}
+
+	if !credential.IsEmailVerified() {
+		msg := fmt.Sprintf("email [%s] of user [%s] is not verified", credential.Email, credential.UserID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.NewErrorWithCode(ErrCodeEmailNotVerified, msg))
+	}
+
+	session, err := service.sessionService.Create(ctx, &AuthSessionCreateParams{
+		UserID:    credential.UserID,
+		IPAddress: params.IPAddress,
+		UserAgent: params.UserAgent,
+	})
+	if err != nil {
+		msg := fmt.Sprintf("cannot store session for user [%s]", credential.UserID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	authUser := entities.AuthUser{ID: credential.UserID, Email: credential.Email}
+	token, err := service.provider.Issue(authUser, session)
+	if err != nil {
+		msg := fmt.Sprintf("cannot issue token for session [%s] of user [%s]", session.ID, credential.UserID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("user [%s] logged in successfully with session [%s]", credential.UserID, session.ID))
+	return &AuthLoginResult{Token: token, User: authUser, Session: session}, nil
+}
+
+// Logout revokes the entities.AuthSession of a token issued by Login
+func (service *AuthService) Logout(ctx context.Context, token string) error {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	claims, err := service.provider.Parse(ctx, token)
+	if err != nil {
+		return service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, ErrCodeInvalidCredentials, "cannot parse auth token"))
+	}
+
+	sessionID, err := uuid.Parse(claims.Id)
+	if err != nil {
+		msg := fmt.Sprintf("auth token of user [%s] has no valid session ID [%s]", claims.Subject, claims.Id)
+		return service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, ErrCodeInvalidCredentials, msg))
+	}
+
+	if err = service.sessionService.Revoke(ctx, entities.UserID(claims.Subject), sessionID); err != nil {
+		msg := fmt.Sprintf("cannot revoke session [%s] of user [%s]", sessionID, claims.Subject)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("user [%s] logged out of session [%s]", claims.Subject, sessionID))
+	return nil
+}
+
+// ForgotPassword sends a password reset link to the email address when it belongs to a credential.
+// Unknown email addresses are ignored so that the response does not reveal which users exist.
+func (service *AuthService) ForgotPassword(ctx context.Context, emailAddress string) error {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	credential, err := service.credentialRepository.LoadByEmail(ctx, emailAddress)
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		ctxLogger.Info(fmt.Sprintf("no credential exists with email [%s], skipping password reset", emailAddress))
+		return nil
+	}
+	if err != nil {
+		msg := fmt.Sprintf("cannot load credential with email [%s]", emailAddress)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	token, tokenHash, err := service.generateToken()
+	if err != nil {
+		msg := fmt.Sprintf("cannot generate password reset token for user [%s]", credential.UserID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	expiresAt := time.Now().UTC().Add(passwordResetTokenTTL)
+	credential.ResetTokenHash = &tokenHash
+	credential.ResetTokenExpiresAt = &expiresAt
+	credential.UpdatedAt = time.Now().UTC()
+
+	if err = service.credentialRepository.Update(ctx, credential); err != nil {
+		msg := fmt.Sprintf("cannot store password reset token for user [%s]", credential.UserID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	email, err := service.emailFactory.PasswordReset(credential.Email, token, expiresAt)
+	if err != nil {
+		msg := fmt.Sprintf("cannot create password reset email for user [%s]", credential.UserID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if err = service.mailer.Send(ctx, email); err != nil {
+		msg := fmt.Sprintf("cannot send password reset email to user [%s]", credential.UserID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("password reset email sent successfully to user [%s]", credential.UserID))
+	return nil
+}
+
+// AuthResetPasswordParams are parameters for choosing a new password
+type AuthResetPasswordParams struct {
+	Token    string
+	Password string
+}
+
+// ResetPassword sets a new password and revokes all the sessions of the user
+func (service *AuthService) ResetPassword(ctx context.Context, params *AuthResetPasswordParams) error {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	credential, err := service.credentialRepository.LoadByResetToken(ctx, service.hashToken(params.Token))
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		return service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, ErrCodeInvalidCredentials, "the password reset token is invalid"))
+	}
+	if err != nil {
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, "cannot load credential by password reset token"))
+	}
+
+	if credential.IsResetTokenExpired(time.Now().UTC()) {
+		msg := fmt.Sprintf("the password reset token of user [%s] expired at [%s]", credential.UserID, credential.ResetTokenExpiresAt)
+		return service.tracer.WrapErrorSpan(span, stacktrace.NewErrorWithCode(ErrCodeInvalidCredentials, msg))
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(params.Password), bcrypt.DefaultCost)
+	if err != nil {
+		msg := fmt.Sprintf("cannot hash password for user [%s]", credential.UserID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	timestamp := time.Now().UTC()
+	credential.PasswordHash = string(hash)
+	credential.ResetTokenHash = nil
+	credential.ResetTokenExpiresAt = nil
+	credential.UpdatedAt = timestamp
+
+	// the reset link proves ownership of the inbox, so the email is verified as well
+	if credential.EmailVerifiedAt == nil {
+		credential.EmailVerifiedAt = &timestamp
+		credential.VerificationTokenHash = nil
+	}
+
+	if err = service.credentialRepository.Update(ctx, credential); err != nil {
+		msg := fmt.Sprintf("cannot update password of user [%s]", credential.UserID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if err = service.sessionService.RevokeAll(ctx, credential.UserID); err != nil {
+		msg := fmt.Sprintf("cannot revoke sessions of user [%s] after password reset", credential.UserID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("password of user [%s] reset successfully", credential.UserID))
+	return nil
+}
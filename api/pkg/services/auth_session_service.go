@@ -0,0 +1,221 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/cache"
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/google/uuid"
+	"github.com/palantir/stacktrace"
+)
+
+// ErrCodeSessionRevoked is returned when a bearer token belongs to a session which was revoked or expired
+const ErrCodeSessionRevoked = stacktrace.ErrorCode(2015)
+
+const (
+	// authSessionCacheTTL is how long the result of a session validation is cached, the last seen time is updated at most once per TTL
+	authSessionCacheTTL = time.Minute
+
+	authSessionCacheActive   = "active"
+	authSessionCacheInactive = "inactive"
+)
+
+// AuthSessionService tracks the devices which are signed in with a bearer token so that users can revoke them
+type AuthSessionService struct {
+	service
+	logger     telemetry.Logger
+	tracer     telemetry.Tracer
+	repository repositories.AuthSessionRepository
+	cache      cache.Cache
+	ttl        time.Duration
+}
+
+// NewAuthSessionService creates a new AuthSessionService
+func NewAuthSessionService(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	repository repositories.AuthSessionRepository,
+	cache cache.Cache,
+	ttl time.Duration,
+) (s *AuthSessionService) {
+	return &AuthSessionService{
+		logger:     logger.WithService(fmt.Sprintf("%T", s)),
+		tracer:     tracer,
+		repository: repository,
+		cache:      cache,
+		ttl:        ttl,
+	}
+}
+
+// AuthSessionCreateParams are parameters for starting a session when a user logs in
+type AuthSessionCreateParams struct {
+	UserID    entities.UserID
+	IPAddress string
+	UserAgent string
+}
+
+// Create a session which expires after the session TTL, the ID of the session is the key of the tokens issued for it
+func (service *AuthSessionService) Create(ctx context.Context, params *AuthSessionCreateParams) (*entities.AuthSession, error) {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	timestamp := time.Now().UTC()
+	session := &entities.AuthSession{
+		ID:         uuid.New(),
+		UserID:     params.UserID,
+		IPAddress:  params.IPAddress,
+		UserAgent:  params.UserAgent,
+		LastSeenAt: timestamp,
+		ExpiresAt:  timestamp.Add(service.ttl),
+		CreatedAt:  timestamp,
+		UpdatedAt:  timestamp,
+	}
+	session.Key = session.ID.String()
+
+	if err := service.repository.Store(ctx, session); err != nil {
+		msg := fmt.Sprintf("cannot store session for user [%s]", params.UserID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return session, nil
+}
+
+// Validate checks that the session of a bearer token is active and records the device which used it.
+// The result is cached so that the database is queried at most once per authSessionCacheTTL for each session.
+func (service *AuthSessionService) Validate(ctx context.Context, user entities.AuthUser, ipAddress string, userAgent string) error {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	if user.SessionID == "" {
+		return nil
+	}
+
+	if status, err := service.cache.Get(ctx, service.cacheKey(user.SessionID)); err == nil {
+		if status == authSessionCacheInactive {
+			msg := fmt.Sprintf("session [%s] of user [%s] is not active", user.SessionID, user.ID)
+			return service.tracer.WrapErrorSpan(span, stacktrace.NewErrorWithCode(ErrCodeSessionRevoked, msg))
+		}
+		return nil
+	}
+
+	timestamp := time.Now().UTC()
+	session, err := service.repository.LoadByKey(ctx, user.SessionID)
+	if err != nil && stacktrace.GetCode(err) != repositories.ErrCodeNotFound {
+		msg := fmt.Sprintf("cannot load session [%s] of user [%s]", user.SessionID, user.ID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if err != nil {
+		// sessions of external identity providers are tracked the first time their tokens are used
+		session = &entities.AuthSession{
+			ExpiresAt: timestamp.Add(service.ttl),
+			ID:        uuid.New(),
+			UserID:    user.ID,
+			Key:       user.SessionID,
+			Renewable: true,
+			CreatedAt: timestamp,
+		}
+	}
+
+	if session.UserID != user.ID || !session.IsActive(timestamp) {
+		service.setCache(ctx, user.SessionID, authSessionCacheInactive)
+		msg := fmt.Sprintf("session [%s] of user [%s] was revoked or expired", session.ID, user.ID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.NewErrorWithCode(ErrCodeSessionRevoked, msg))
+	}
+
+	session.IPAddress = ipAddress
+	session.UserAgent = userAgent
+	session.LastSeenAt = timestamp
+	session.UpdatedAt = timestamp
+	if session.Renewable {
+		session.ExpiresAt = timestamp.Add(service.ttl)
+	}
+
+	if err = service.repository.Update(ctx, session); err != nil {
+		msg := fmt.Sprintf("cannot update session [%s] of user [%s]", session.ID, user.ID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	service.setCache(ctx, user.SessionID, authSessionCacheActive)
+	ctxLogger.Info(fmt.Sprintf("session [%s] of user [%s] was seen from [%s]", session.ID, user.ID, ipAddress))
+	return nil
+}
+
+// Index returns the active sessions of a user, the session with the currentKey is marked as current
+func (service *AuthSessionService) Index(ctx context.Context, userID entities.UserID, currentKey string) ([]*entities.AuthSession, error) {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	sessions, err := service.repository.IndexActive(ctx, userID, time.Now().UTC())
+	if err != nil {
+		msg := fmt.Sprintf("cannot fetch active sessions of user [%s]", userID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	for _, session := range sessions {
+		session.Current = currentKey != "" && session.Key == currentKey
+	}
+
+	return sessions, nil
+}
+
+// Revoke a session of a user, the cached status of the session is evicted so that its tokens are rejected immediately
+func (service *AuthSessionService) Revoke(ctx context.Context, userID entities.UserID, sessionID uuid.UUID) error {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	session, err := service.repository.Load(ctx, userID, sessionID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot load session [%s] of user [%s]", sessionID, userID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
+	}
+
+	if err = service.repository.Revoke(ctx, userID, sessionID, time.Now().UTC()); err != nil {
+		msg := fmt.Sprintf("cannot revoke session [%s] of user [%s]", sessionID, userID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if err = service.cache.Delete(ctx, service.cacheKey(session.Key)); err != nil {
+		msg := fmt.Sprintf("cannot evict cached status of session [%s] of user [%s]", sessionID, userID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("session [%s] of user [%s] revoked successfully", sessionID, userID))
+	return nil
+}
+
+// RevokeAll revokes the active sessions of a user e.g. after the password is changed
+func (service *AuthSessionService) RevokeAll(ctx context.Context, userID entities.UserID) error {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	sessions, err := service.repository.IndexActive(ctx, userID, time.Now().UTC())
+	if err != nil {
+		msg := fmt.Sprintf("cannot fetch active sessions of user [%s]", userID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	for _, session := range sessions {
+		if err = service.Revoke(ctx, userID, session.ID); err != nil {
+			msg := fmt.Sprintf("cannot revoke session [%s] of user [%s]", session.ID, userID)
+			return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+		}
+	}
+
+	return nil
+}
+
+func (service *AuthSessionService) setCache(ctx context.Context, key string, status string) {
+	if err := service.cache.Set(ctx, service.cacheKey(key), status, authSessionCacheTTL); err != nil {
+		ctxLogger := service.tracer.CtxLogger(service.logger, service.tracer.Span(ctx))
+		ctxLogger.Error(stacktrace.Propagate(err, fmt.Sprintf("cannot cache status [%s] of session [%s]", status, key)))
+	}
+}
+
+func (service *AuthSessionService) cacheKey(key string) string {
+	return fmt.Sprintf("auth-session:%s", key)
+}
@@ -0,0 +1,38 @@
+package services
+
+import (
+	"context"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+)
+
+// BillingProvider charges users for their subscription so that self-hosters can use the payment provider available in their country
+type BillingProvider interface {
+	// Name of the provider e.g. stripe
+	Name() string
+
+	// CheckoutURL returns the URL where a user pays for a plan
+	CheckoutURL(ctx context.Context, user *entities.User, plan entities.SubscriptionName) (string, error)
+
+	// UpdatePaymentMethodURL returns the URL where a user changes the payment method of their subscription
+	UpdatePaymentMethodURL(ctx context.Context, user *entities.User) (string, error)
+
+	// ChangePlan moves the subscription of a user to another plan, the difference in price is prorated
+	ChangePlan(ctx context.Context, user *entities.User, plan entities.SubscriptionName) error
+
+	// Cancel the subscription of a user at the end of the current billing period
+	Cancel(ctx context.Context, user *entities.User) error
+}
+
+// BillingPlans maps an entities.SubscriptionName to the ID of the price on the BillingProvider
+type BillingPlans map[entities.SubscriptionName]string
+
+// Name returns the entities.SubscriptionName of a price on the BillingProvider
+func (plans BillingPlans) Name(priceID string) (entities.SubscriptionName, bool) {
+	for name, id := range plans {
+		if id == priceID {
+			return name, true
+		}
+	}
+	return entities.SubscriptionNameFree, false
+}
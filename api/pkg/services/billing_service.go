@@ -25,6 +25,8 @@ type BillingService struct {
 	mailer                 emails.Mailer
 	userRepository         repositories.UserRepository
 	billingUsageRepository repositories.BillingUsageRepository
+	creditRepository       repositories.CreditRepository
+	planOverrideRepository repositories.PlanOverrideRepository
 }
 
 // NewBillingService creates a new BillingService
@@ -36,6 +38,8 @@ func NewBillingService(
 	emailFactory emails.UserEmailFactory,
 	usageRepository repositories.BillingUsageRepository,
 	userRepository repositories.UserRepository,
+	creditRepository repositories.CreditRepository,
+	planOverrideRepository repositories.PlanOverrideRepository,
 ) (s *BillingService) {
 	return &BillingService{
 		logger:                 logger.WithService(fmt.Sprintf("%T", s)),
@@ -45,14 +49,43 @@ func NewBillingService(
 		mailer:                 mailer,
 		userRepository:         userRepository,
 		billingUsageRepository: usageRepository,
+		creditRepository:       creditRepository,
+		planOverrideRepository: planOverrideRepository,
 	}
 }
 
-// IsEntitled checks if a user can send or receive and SMS message
+// IsEntitled checks if a user can receive an SMS message.
+// Users with prepaid credits are not limited by their subscription and an active entities.PlanOverride replaces the limit of the subscription.
 func (service *BillingService) IsEntitled(ctx context.Context, userID entities.UserID) *string {
+	return service.isEntitled(ctx, userID, false)
+}
+
+// IsEntitledToSend checks if a user can send an SMS message.
+// Users with prepaid credits are limited by their entities.CreditBalance instead of the limit of their subscription,
+// the credits of each message are debited by the MessageService before it is dispatched to the phone.
+func (service *BillingService) IsEntitledToSend(ctx context.Context, userID entities.UserID) *string {
+	return service.isEntitled(ctx, userID, true)
+}
+
+func (service *BillingService) isEntitled(ctx context.Context, userID entities.UserID, send bool) *string {
 	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
 	defer span.End()
 
+	balance, err := service.creditRepository.LoadBalance(ctx, userID)
+	if err == nil && send && !balance.HasCredits() {
+		message := "You have no credits left. Top up your credits to send more messages."
+		return &message
+	}
+
+	if err == nil {
+		return nil
+	}
+
+	if stacktrace.GetCode(err) != repositories.ErrCodeNotFound {
+		msg := fmt.Sprintf("cannot load credit balance for user with ID [%s], checking subscription limit", userID)
+		ctxLogger.Error(service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg)))
+	}
+
 	user, err := service.userRepository.Load(ctx, userID)
 	if err != nil {
 		msg := fmt.Sprintf("cannot load user with ID [%s], entitlement successfull", userID)
@@ -67,14 +100,33 @@ func (service *BillingService) IsEntitled(ctx context.Context, userID entities.U
 		return nil
 	}
 
-	if billingUsage.TotalMessages() >= user.SubscriptionName.Limit() {
-		return service.handleLimitExceeded(ctx, user, billingUsage)
+	plan, limit := service.plan(ctx, user)
+	if billingUsage.TotalMessages() >= limit {
+		return service.handleLimitExceeded(ctx, user, billingUsage, plan, limit)
 	}
 
 	return nil
 }
 
-func (service *BillingService) handleLimitExceeded(ctx context.Context, user *entities.User, usage *entities.BillingUsage) *string {
+// plan returns the subscription and the monthly limit which are enforced for a user
+func (service *BillingService) plan(ctx context.Context, user *entities.User) (entities.SubscriptionName, uint) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	override, err := service.planOverrideRepository.LoadActive(ctx, user.ID, time.Now().UTC())
+	if err == nil {
+		return override.SubscriptionName, override.Limit()
+	}
+
+	if stacktrace.GetCode(err) != repositories.ErrCodeNotFound {
+		msg := fmt.Sprintf("cannot load plan override for user with ID [%s], using subscription [%s]", user.ID, user.SubscriptionName)
+		ctxLogger.Error(service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg)))
+	}
+
+	return user.SubscriptionName, user.SubscriptionName.Limit()
+}
+
+func (service *BillingService) handleLimitExceeded(ctx context.Context, user *entities.User, usage *entities.BillingUsage, plan entities.SubscriptionName, limit uint) *string {
 	ctx, span := service.tracer.Start(ctx)
 	defer span.End()
 
@@ -82,8 +134,8 @@ func (service *BillingService) handleLimitExceeded(ctx context.Context, user *en
 
 	message := fmt.Sprintf(
 		"You have exceeded your limit of [%d] messages on your [%s] plan. Upgrade to send more messages on https://httpsms.com/billing",
-		user.SubscriptionName.Limit(),
-		user.SubscriptionName,
+		limit,
+		plan,
 	)
 	return &message
 }
@@ -123,6 +175,21 @@ func (service *BillingService) GetCurrentUsage(ctx context.Context, userID entit
 	return service.billingUsageRepository.GetCurrent(ctx, userID)
 }
 
+// GetPlan returns the subscription and the monthly limit which are enforced for a user
+func (service *BillingService) GetPlan(ctx context.Context, userID entities.UserID) (entities.SubscriptionName, uint, error) {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	user, err := service.userRepository.Load(ctx, userID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot load user with ID [%s]", userID)
+		return "", 0, service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
+	}
+
+	plan, limit := service.plan(ctx, user)
+	return plan, limit, nil
+}
+
 // GetUsageHistory gets the billing usage history for a user
 func (service *BillingService) GetUsageHistory(ctx context.Context, userID entities.UserID, params repositories.IndexParams) (*[]entities.BillingUsage, error) {
 	ctx, span := service.tracer.Start(ctx)
@@ -0,0 +1,356 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/google/uuid"
+	"github.com/nyaruka/phonenumbers"
+	"github.com/palantir/stacktrace"
+)
+
+// ErrCodeInvalidCSV is thrown when an imported CSV file cannot be read
+const ErrCodeInvalidCSV = stacktrace.ErrorCode(2001)
+
+// blocklistPhoneNumberHeaders are the CSV column names which contain the phone number
+var blocklistPhoneNumberHeaders = []string{"phone_number", "phone number", "phonenumber", "phone", "number", "to", "msisdn"}
+
+// blocklistReasonAliases maps the reasons used by other providers to an entities.BlocklistReason
+var blocklistReasonAliases = map[string]entities.BlocklistReason{
+	"opt-out":      entities.BlocklistReasonOptOut,
+	"optout":       entities.BlocklistReasonOptOut,
+	"opt_out":      entities.BlocklistReasonOptOut,
+	"opted-out":    entities.BlocklistReasonOptOut,
+	"unsubscribed": entities.BlocklistReasonOptOut,
+	"stop":         entities.BlocklistReasonOptOut,
+	"blocked":      entities.BlocklistReasonBlocked,
+	"block":        entities.BlocklistReasonBlocked,
+	"blocklist":    entities.BlocklistReasonBlocked,
+	"blacklist":    entities.BlocklistReasonBlocked,
+}
+
+// BlocklistService manages the entities.Blocklist of a user
+type BlocklistService struct {
+	service
+	logger         telemetry.Logger
+	tracer         telemetry.Tracer
+	repository     repositories.BlocklistRepository
+	userRepository repositories.UserRepository
+}
+
+// NewBlocklistService creates a new BlocklistService
+func NewBlocklistService(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	repository repositories.BlocklistRepository,
+	userRepository repositories.UserRepository,
+) (s *BlocklistService) {
+	return &BlocklistService{
+		logger:         logger.WithService(fmt.Sprintf("%T", s)),
+		tracer:         tracer,
+		repository:     repository,
+		userRepository: userRepository,
+	}
+}
+
+// Index fetches the entities.Blocklist of a user
+func (service *BlocklistService) Index(ctx context.Context, userID entities.UserID, params repositories.IndexParams) ([]*entities.Blocklist, error) {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	entries, err := service.repository.Index(ctx, userID, params)
+	if err != nil {
+		msg := fmt.Sprintf("could not fetch blocklist with params [%+#v]", params)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return entries, nil
+}
+
+// Delete an entities.Blocklist
+func (service *BlocklistService) Delete(ctx context.Context, userID entities.UserID, blocklistID uuid.UUID) error {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	if err := service.repository.Delete(ctx, userID, blocklistID); err != nil {
+		msg := fmt.Sprintf("could not delete blocklist entry with ID [%s] for user [%s]", blocklistID, userID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+// BlocklistKeywordParams are parameters for handling an opt-out or opt-in keyword sent by a contact
+type BlocklistKeywordParams struct {
+	UserID    entities.UserID
+	Contact   string
+	Content   string
+	Timestamp time.Time
+}
+
+// HandleKeyword adds the contact to the blocklist when the received message is an opt-out keyword of the user
+// and removes the contact from the blocklist when it is an opt-in keyword.
+func (service *BlocklistService) HandleKeyword(ctx context.Context, params *BlocklistKeywordParams) error {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	user, err := service.userRepository.Load(ctx, params.UserID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot load user with ID [%s]", params.UserID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if user.IsOptInKeyword(params.Content) {
+		if err = service.repository.DeleteByPhoneNumber(ctx, params.UserID, params.Contact, entities.BlocklistReasonOptOut); err != nil {
+			msg := fmt.Sprintf("cannot remove contact [%s] from the blocklist of user [%s]", params.Contact, params.UserID)
+			return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+		}
+		ctxLogger.Info(fmt.Sprintf("contact [%s] opted in to the messages of user [%s]", params.Contact, params.UserID))
+		return nil
+	}
+
+	if !user.IsOptOutKeyword(params.Content) {
+		return nil
+	}
+
+	entry := &entities.Blocklist{
+		ID:          uuid.New(),
+		UserID:      params.UserID,
+		PhoneNumber: params.Contact,
+		Reason:      entities.BlocklistReasonOptOut,
+		Source:      entities.BlocklistSourceKeyword,
+		CreatedAt:   params.Timestamp,
+	}
+
+	if err = service.repository.Store(ctx, []*entities.Blocklist{entry}); err != nil {
+		msg := fmt.Sprintf("cannot add contact [%s] to the blocklist of user [%s]", params.Contact, params.UserID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("contact [%s] opted out of the messages of user [%s]", params.Contact, params.UserID))
+	return nil
+}
+
+// BlocklistImportParams are parameters for importing a CSV file into the entities.Blocklist of a user
+type BlocklistImportParams struct {
+	UserID entities.UserID
+	Reason entities.BlocklistReason
+	DryRun bool
+	Reader io.Reader
+}
+
+// BlocklistImportError is a CSV row which could not be imported
+type BlocklistImportError struct {
+	Row   int    `json:"row" example:"3"`
+	Value string `json:"value" example:"+1800"`
+	Error string `json:"error" example:"the phone number is not a valid E.164 phone number"`
+}
+
+// BlocklistImport is the result of importing a CSV file into the entities.Blocklist
+type BlocklistImport struct {
+	DryRun     bool                   `json:"dry_run" example:"true"`
+	Rows       int                    `json:"rows" example:"120"`
+	Imported   int                    `json:"imported" example:"100"`
+	Duplicates int                    `json:"duplicates" example:"10"`
+	Existing   int                    `json:"existing" example:"9"`
+	Invalid    []BlocklistImportError `json:"invalid"`
+}
+
+// Import phone numbers from a CSV file into the entities.Blocklist of a user.
+// When params.DryRun is true, the file is only validated and nothing is saved.
+func (service *BlocklistService) Import(ctx context.Context, params *BlocklistImportParams) (*BlocklistImport, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	result, entries, err := service.parseCSV(params)
+	if err != nil {
+		msg := fmt.Sprintf("cannot parse blocklist CSV for user [%s]", params.UserID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, ErrCodeInvalidCSV, msg))
+	}
+
+	phoneNumbers := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		phoneNumbers = append(phoneNumbers, entry.PhoneNumber)
+	}
+
+	existing, err := service.repository.Existing(ctx, params.UserID, phoneNumbers)
+	if err != nil {
+		msg := fmt.Sprintf("cannot fetch existing blocklist phone numbers for user [%s]", params.UserID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	cache := make(map[string]struct{}, len(existing))
+	for _, phoneNumber := range existing {
+		cache[phoneNumber] = struct{}{}
+	}
+
+	newEntries := make([]*entities.Blocklist, 0, len(entries))
+	for _, entry := range entries {
+		if _, ok := cache[entry.PhoneNumber]; !ok {
+			newEntries = append(newEntries, entry)
+		}
+	}
+
+	result.Existing = len(entries) - len(newEntries)
+	result.Imported = len(newEntries)
+
+	if params.DryRun {
+		ctxLogger.Info(fmt.Sprintf("validated blocklist CSV for user [%s] with [%d] new phone numbers", params.UserID, result.Imported))
+		return result, nil
+	}
+
+	if err = service.repository.Store(ctx, newEntries); err != nil {
+		msg := fmt.Sprintf("cannot store [%d] blocklist entries for user [%s]", len(newEntries), params.UserID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("imported [%d] phone numbers into the blocklist of user [%s]", result.Imported, params.UserID))
+	return result, nil
+}
+
+// Export the entities.Blocklist of a user as a CSV file
+func (service *BlocklistService) Export(ctx context.Context, userID entities.UserID) ([]byte, error) {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	entries, err := service.repository.FetchAll(ctx, userID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot fetch blocklist for user [%s]", userID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	buffer := new(bytes.Buffer)
+	writer := csv.NewWriter(buffer)
+	_ = writer.Write([]string{"phone_number", "reason", "source", "created_at"})
+	for _, entry := range entries {
+		_ = writer.Write([]string{entry.PhoneNumber, string(entry.Reason), string(entry.Source), entry.CreatedAt.Format(time.RFC3339)})
+	}
+
+	writer.Flush()
+	if err = writer.Error(); err != nil {
+		msg := fmt.Sprintf("cannot write [%d] blocklist entries as CSV for user [%s]", len(entries), userID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return buffer.Bytes(), nil
+}
+
+// parseCSV reads the rows of a CSV file, the phone numbers are normalized to E.164 and duplicates are removed
+func (service *BlocklistService) parseCSV(params *BlocklistImportParams) (*BlocklistImport, []*entities.Blocklist, error) {
+	reader := csv.NewReader(params.Reader)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	result := &BlocklistImport{DryRun: params.DryRun, Invalid: []BlocklistImportError{}}
+	entries := make([]*entities.Blocklist, 0)
+	seen := map[string]struct{}{}
+
+	phoneColumn, reasonColumn := 0, 1
+	for row := 1; ; row++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, nil, stacktrace.Propagate(err, fmt.Sprintf("cannot read row [%d] of the CSV file", row))
+		}
+
+		if row == 1 {
+			if phone, reason, ok := service.parseHeader(record); ok {
+				phoneColumn, reasonColumn = phone, reason
+				continue
+			}
+		}
+
+		if len(record) <= phoneColumn || strings.TrimSpace(record[phoneColumn]) == "" {
+			continue
+		}
+
+		result.Rows++
+		value := strings.TrimSpace(record[phoneColumn])
+
+		phoneNumber, err := service.normalizePhoneNumber(value)
+		if err != nil {
+			result.Invalid = append(result.Invalid, BlocklistImportError{Row: row, Value: value, Error: err.Error()})
+			continue
+		}
+
+		reason := params.Reason
+		if reasonColumn >= 0 && len(record) > reasonColumn && strings.TrimSpace(record[reasonColumn]) != "" {
+			alias, ok := blocklistReasonAliases[strings.ToLower(strings.TrimSpace(record[reasonColumn]))]
+			if !ok {
+				result.Invalid = append(result.Invalid, BlocklistImportError{Row: row, Value: record[reasonColumn], Error: "the reason must be one of [opt-out, blocked]"})
+				continue
+			}
+			reason = alias
+		}
+
+		if _, ok := seen[phoneNumber]; ok {
+			result.Duplicates++
+			continue
+		}
+		seen[phoneNumber] = struct{}{}
+
+		entries = append(entries, &entities.Blocklist{
+			ID:          uuid.New(),
+			UserID:      params.UserID,
+			PhoneNumber: phoneNumber,
+			Reason:      reason,
+			Source:      entities.BlocklistSourceImport,
+			CreatedAt:   time.Now().UTC(),
+		})
+	}
+
+	return result, entries, nil
+}
+
+// parseHeader returns the position of the phone number and reason columns if the record is a header row
+func (service *BlocklistService) parseHeader(record []string) (phoneColumn int, reasonColumn int, isHeader bool) {
+	phoneColumn, reasonColumn = -1, -1
+	for index, value := range record {
+		value = strings.ToLower(strings.TrimSpace(value))
+		if phoneColumn == -1 && service.contains(blocklistPhoneNumberHeaders, value) {
+			phoneColumn = index
+		}
+		if reasonColumn == -1 && (value == "reason" || value == "status" || value == "type") {
+			reasonColumn = index
+		}
+	}
+	return phoneColumn, reasonColumn, phoneColumn != -1
+}
+
+// normalizePhoneNumber formats a phone number in E.164 format
+func (service *BlocklistService) normalizePhoneNumber(value string) (string, error) {
+	value = strings.NewReplacer(" ", "", "-", "", "(", "", ")", "", ".", "").Replace(value)
+	if strings.HasPrefix(value, "00") {
+		value = "+" + strings.TrimPrefix(value, "00")
+	}
+	if !strings.HasPrefix(value, "+") {
+		value = "+" + value
+	}
+
+	number, err := phonenumbers.Parse(value, phonenumbers.UNKNOWN_REGION)
+	if err != nil || !phonenumbers.IsPossibleNumber(number) {
+		return "", errors.New("the phone number is not a valid E.164 phone number")
+	}
+
+	return phonenumbers.Format(number, phonenumbers.E164), nil
+}
+
+func (service *BlocklistService) contains(values []string, value string) bool {
+	for _, item := range values {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
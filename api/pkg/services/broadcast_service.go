@@ -0,0 +1,219 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/events"
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/palantir/stacktrace"
+)
+
+// broadcastAudienceBatchSize is the number of users loaded per query when a broadcast targets every user
+const broadcastAudienceBatchSize = 500
+
+// BroadcastService manages the announcements sent by the operators of the instance
+type BroadcastService struct {
+	service
+	logger         telemetry.Logger
+	tracer         telemetry.Tracer
+	repository     repositories.BroadcastRepository
+	userRepository repositories.UserRepository
+	dispatcher     *EventDispatcher
+}
+
+// NewBroadcastService creates a new BroadcastService
+func NewBroadcastService(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	repository repositories.BroadcastRepository,
+	userRepository repositories.UserRepository,
+	dispatcher *EventDispatcher,
+) (s *BroadcastService) {
+	return &BroadcastService{
+		logger:         logger.WithService(fmt.Sprintf("%T", s)),
+		tracer:         tracer,
+		repository:     repository,
+		userRepository: userRepository,
+		dispatcher:     dispatcher,
+	}
+}
+
+// Index fetches all the entities.Broadcast of the instance
+func (service *BroadcastService) Index(ctx context.Context, params repositories.IndexParams) ([]*entities.Broadcast, error) {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	broadcasts, err := service.repository.Index(ctx, params)
+	if err != nil {
+		msg := fmt.Sprintf("could not fetch broadcasts with params [%+#v]", params)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return broadcasts, nil
+}
+
+// Active fetches the entities.Broadcast which should be displayed as a banner to a user
+func (service *BroadcastService) Active(ctx context.Context, userID entities.UserID) ([]*entities.Broadcast, error) {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	broadcasts, err := service.repository.FetchActive(ctx, time.Now().UTC())
+	if err != nil {
+		msg := fmt.Sprintf("could not fetch active broadcasts for user [%s]", userID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	result := make([]*entities.Broadcast, 0, len(broadcasts))
+	for _, broadcast := range broadcasts {
+		if broadcast.HasChannel(entities.BroadcastChannelBanner) && broadcast.IsTargeted(userID) {
+			result = append(result, broadcast)
+		}
+	}
+
+	return result, nil
+}
+
+// BroadcastStoreParams are parameters for publishing an entities.Broadcast
+type BroadcastStoreParams struct {
+	Source    string
+	CreatedBy entities.UserID
+	Title     string
+	Body      string
+	Severity  entities.BroadcastSeverity
+	Channels  []entities.BroadcastChannel
+	UserIDs   []entities.UserID
+	StartsAt  time.Time
+	EndsAt    *time.Time
+}
+
+// Store a new entities.Broadcast and notify its audience on the email and webhook channels
+func (service *BroadcastService) Store(ctx context.Context, params *BroadcastStoreParams) (*entities.Broadcast, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	broadcast := &entities.Broadcast{
+		ID:        uuid.New(),
+		Title:     params.Title,
+		Body:      params.Body,
+		Severity:  params.Severity,
+		Channels:  pq.StringArray{},
+		UserIDs:   pq.StringArray{},
+		CreatedBy: params.CreatedBy,
+		StartsAt:  params.StartsAt,
+		EndsAt:    params.EndsAt,
+		CreatedAt: time.Now().UTC(),
+		UpdatedAt: time.Now().UTC(),
+	}
+
+	for _, channel := range params.Channels {
+		broadcast.Channels = append(broadcast.Channels, string(channel))
+	}
+
+	for _, userID := range params.UserIDs {
+		broadcast.UserIDs = append(broadcast.UserIDs, string(userID))
+	}
+
+	if err := service.repository.Store(ctx, broadcast); err != nil {
+		msg := fmt.Sprintf("cannot save broadcast with id [%s]", broadcast.ID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("broadcast saved with id [%s] in the [%T]", broadcast.ID, service.repository))
+
+	if !broadcast.HasChannel(entities.BroadcastChannelEmail) && !broadcast.HasChannel(entities.BroadcastChannelWebhook) {
+		return broadcast, nil
+	}
+
+	count, err := service.publish(ctx, params.Source, broadcast)
+	if err != nil {
+		msg := fmt.Sprintf("cannot publish broadcast with id [%s]", broadcast.ID)
+		return broadcast, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("broadcast with id [%s] published to [%d] users", broadcast.ID, count))
+	return broadcast, nil
+}
+
+// Delete an entities.Broadcast
+func (service *BroadcastService) Delete(ctx context.Context, broadcastID uuid.UUID) error {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	if _, err := service.repository.Load(ctx, broadcastID); err != nil {
+		msg := fmt.Sprintf("cannot load broadcast with ID [%s]", broadcastID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
+	}
+
+	if err := service.repository.Delete(ctx, broadcastID); err != nil {
+		msg := fmt.Sprintf("cannot delete broadcast with ID [%s]", broadcastID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("broadcast with ID [%s] deleted", broadcastID))
+	return nil
+}
+
+// publish dispatches an events.EventTypeBroadcastPublished event for every user in the audience of the broadcast
+func (service *BroadcastService) publish(ctx context.Context, source string, broadcast *entities.Broadcast) (int, error) {
+	if len(broadcast.UserIDs) > 0 {
+		userIDs := make([]entities.UserID, 0, len(broadcast.UserIDs))
+		for _, userID := range broadcast.UserIDs {
+			userIDs = append(userIDs, entities.UserID(userID))
+		}
+		return len(userIDs), service.dispatchAll(ctx, source, broadcast, userIDs)
+	}
+
+	count := 0
+	for skip := 0; ; skip += broadcastAudienceBatchSize {
+		userIDs, err := service.userRepository.IndexIDs(ctx, repositories.IndexParams{Skip: skip, Limit: broadcastAudienceBatchSize})
+		if err != nil {
+			return count, stacktrace.Propagate(err, fmt.Sprintf("cannot fetch users after [%d]", skip))
+		}
+
+		if err = service.dispatchAll(ctx, source, broadcast, userIDs); err != nil {
+			return count, stacktrace.Propagate(err, fmt.Sprintf("cannot publish broadcast to users after [%d]", skip))
+		}
+
+		count += len(userIDs)
+		if len(userIDs) < broadcastAudienceBatchSize {
+			return count, nil
+		}
+	}
+}
+
+func (service *BroadcastService) dispatchAll(ctx context.Context, source string, broadcast *entities.Broadcast, userIDs []entities.UserID) error {
+	for _, userID := range userIDs {
+		event, err := service.createEvent(events.EventTypeBroadcastPublished, source, &events.BroadcastPublishedPayload{
+			BroadcastID: broadcast.ID,
+			UserID:      userID,
+			Title:       broadcast.Title,
+			Body:        broadcast.Body,
+			Severity:    broadcast.Severity,
+			Channels:    broadcast.Channels,
+			StartsAt:    broadcast.StartsAt,
+			EndsAt:      broadcast.EndsAt,
+			Timestamp:   time.Now().UTC(),
+		})
+		if err != nil {
+			return stacktrace.Propagate(err, fmt.Sprintf("cannot create [%s] event for user [%s]", events.EventTypeBroadcastPublished, userID))
+		}
+
+		if delay := time.Until(broadcast.StartsAt); delay > 0 {
+			if _, err = service.dispatcher.DispatchWithTimeout(ctx, event, delay); err != nil {
+				return stacktrace.Propagate(err, fmt.Sprintf("cannot schedule event [%s] for user [%s]", event.Type(), userID))
+			}
+			continue
+		}
+
+		if err = service.dispatcher.Dispatch(ctx, event); err != nil {
+			return stacktrace.Propagate(err, fmt.Sprintf("cannot dispatch event [%s] for user [%s]", event.Type(), userID))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/palantir/stacktrace"
+)
+
+const (
+	campaignSchedulerSource    = "/campaign-scheduler"
+	campaignSchedulerBatchSize = 20
+)
+
+// CampaignScheduler periodically runs the entities.Campaign which are due
+type CampaignScheduler struct {
+	logger   telemetry.Logger
+	tracer   telemetry.Tracer
+	service  *CampaignService
+	interval time.Duration
+}
+
+// NewCampaignScheduler creates a new CampaignScheduler
+func NewCampaignScheduler(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	service *CampaignService,
+	interval time.Duration,
+) (s *CampaignScheduler) {
+	return &CampaignScheduler{
+		logger:   logger.WithService(fmt.Sprintf("%T", s)),
+		tracer:   tracer,
+		service:  service,
+		interval: interval,
+	}
+}
+
+// Run runs the due campaigns on every tick until the context is cancelled
+func (scheduler *CampaignScheduler) Run(ctx context.Context) {
+	runTicker(ctx, scheduler.logger, "campaign scheduler", scheduler.interval, scheduler.tick)
+}
+
+func (scheduler *CampaignScheduler) tick(ctx context.Context) {
+	ctx, span, ctxLogger := scheduler.tracer.StartWithLogger(ctx, scheduler.logger)
+	defer span.End()
+
+	for {
+		count, err := scheduler.service.RunDue(ctx, campaignSchedulerSource, time.Now().UTC(), campaignSchedulerBatchSize)
+		if err != nil {
+			ctxLogger.Error(stacktrace.Propagate(err, "cannot run campaigns which are due"))
+			return
+		}
+
+		if count > 0 {
+			ctxLogger.Info(fmt.Sprintf("ran [%d] campaigns", count))
+		}
+
+		if count < campaignSchedulerBatchSize {
+			return
+		}
+	}
+}
@@ -0,0 +1,481 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/google/uuid"
+	"github.com/nyaruka/phonenumbers"
+	"github.com/palantir/stacktrace"
+)
+
+// CampaignService manages entities.Campaign and sends their messages when they are due
+type CampaignService struct {
+	service
+	logger              telemetry.Logger
+	tracer              telemetry.Tracer
+	repository          repositories.CampaignRepository
+	segmentRepository   repositories.SegmentRepository
+	blocklistRepository repositories.BlocklistRepository
+	templateRepository  repositories.TemplateRepository
+	userRepository      repositories.UserRepository
+	segmentService      *SegmentService
+	contactGroupService *ContactGroupService
+	messageService      *MessageService
+}
+
+// NewCampaignService creates a new CampaignService
+func NewCampaignService(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	repository repositories.CampaignRepository,
+	segmentRepository repositories.SegmentRepository,
+	blocklistRepository repositories.BlocklistRepository,
+	templateRepository repositories.TemplateRepository,
+	userRepository repositories.UserRepository,
+	segmentService *SegmentService,
+	contactGroupService *ContactGroupService,
+	messageService *MessageService,
+) (s *CampaignService) {
+	return &CampaignService{
+		logger:              logger.WithService(fmt.Sprintf("%T", s)),
+		tracer:              tracer,
+		repository:          repository,
+		segmentRepository:   segmentRepository,
+		blocklistRepository: blocklistRepository,
+		templateRepository:  templateRepository,
+		userRepository:      userRepository,
+		segmentService:      segmentService,
+		contactGroupService: contactGroupService,
+		messageService:      messageService,
+	}
+}
+
+// Index fetches the entities.Campaign of a user
+func (service *CampaignService) Index(ctx context.Context, userID entities.UserID, params repositories.IndexParams) ([]*entities.Campaign, error) {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	campaigns, err := service.repository.Index(ctx, userID, params)
+	if err != nil {
+		msg := fmt.Sprintf("could not fetch campaigns with params [%+#v]", params)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return campaigns, nil
+}
+
+// Load an entities.Campaign of a user
+func (service *CampaignService) Load(ctx context.Context, userID entities.UserID, campaignID uuid.UUID) (*entities.Campaign, error) {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	campaign, err := service.repository.Load(ctx, userID, campaignID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot load campaign with ID [%s] for user [%s]", campaignID, userID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
+	}
+
+	return campaign, nil
+}
+
+// CampaignStoreParams are parameters for creating an entities.Campaign
+type CampaignStoreParams struct {
+	UserID          entities.UserID
+	Name            string
+	Owner           string
+	SIM             entities.SIM
+	SegmentID       *uuid.UUID
+	GroupID         *uuid.UUID
+	TemplateID      *uuid.UUID
+	Content         string
+	Cron            string
+	IntervalMinutes uint
+	Timezone        string
+	IsActive        bool
+}
+
+// Store a new entities.Campaign, the first run is scheduled from the current time
+func (service *CampaignService) Store(ctx context.Context, params *CampaignStoreParams) (*entities.Campaign, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	if err := service.loadAudience(ctx, params); err != nil {
+		msg := fmt.Sprintf("cannot load the audience of campaign [%s] for user [%s]", params.Name, params.UserID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
+	}
+
+	if err := service.loadTemplate(ctx, params); err != nil {
+		msg := fmt.Sprintf("cannot load the template of campaign [%s] for user [%s]", params.Name, params.UserID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
+	}
+
+	campaign := &entities.Campaign{
+		ID:              uuid.New(),
+		UserID:          params.UserID,
+		Name:            params.Name,
+		Owner:           params.Owner,
+		SIM:             params.SIM,
+		SegmentID:       params.SegmentID,
+		GroupID:         params.GroupID,
+		TemplateID:      params.TemplateID,
+		Content:         params.Content,
+		Cron:            params.Cron,
+		IntervalMinutes: params.IntervalMinutes,
+		Timezone:        params.Timezone,
+		IsActive:        params.IsActive,
+		CreatedAt:       time.Now().UTC(),
+		UpdatedAt:       time.Now().UTC(),
+	}
+
+	if err := service.schedule(campaign, time.Now().UTC()); err != nil {
+		msg := fmt.Sprintf("cannot schedule campaign with ID [%s]", campaign.ID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if err := service.repository.Store(ctx, campaign); err != nil {
+		msg := fmt.Sprintf("cannot save campaign with id [%s]", campaign.ID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("campaign saved with id [%s] in the [%T]", campaign.ID, service.repository))
+	return campaign, nil
+}
+
+// CampaignUpdateParams are parameters for updating an entities.Campaign
+type CampaignUpdateParams struct {
+	CampaignStoreParams
+	CampaignID uuid.UUID
+}
+
+// Update an entities.Campaign, the next run is rescheduled from the current time
+func (service *CampaignService) Update(ctx context.Context, params *CampaignUpdateParams) (*entities.Campaign, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	campaign, err := service.repository.Load(ctx, params.UserID, params.CampaignID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot load campaign with ID [%s] for user [%s]", params.CampaignID, params.UserID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
+	}
+
+	if err = service.loadAudience(ctx, &params.CampaignStoreParams); err != nil {
+		msg := fmt.Sprintf("cannot load the audience of campaign [%s] for user [%s]", params.CampaignID, params.UserID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
+	}
+
+	if err = service.loadTemplate(ctx, &params.CampaignStoreParams); err != nil {
+		msg := fmt.Sprintf("cannot load the template of campaign [%s] for user [%s]", params.CampaignID, params.UserID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
+	}
+
+	campaign.Name = params.Name
+	campaign.Owner = params.Owner
+	campaign.SIM = params.SIM
+	campaign.SegmentID = params.SegmentID
+	campaign.GroupID = params.GroupID
+	campaign.TemplateID = params.TemplateID
+	campaign.Content = params.Content
+	campaign.Cron = params.Cron
+	campaign.IntervalMinutes = params.IntervalMinutes
+	campaign.Timezone = params.Timezone
+	campaign.IsActive = params.IsActive
+	campaign.UpdatedAt = time.Now().UTC()
+
+	if err = service.schedule(campaign, time.Now().UTC()); err != nil {
+		msg := fmt.Sprintf("cannot schedule campaign with ID [%s]", campaign.ID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if err = service.repository.Update(ctx, campaign); err != nil {
+		msg := fmt.Sprintf("cannot update campaign with id [%s]", campaign.ID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("campaign updated with id [%s] in the [%T]", campaign.ID, service.repository))
+	return campaign, nil
+}
+
+// Delete an entities.Campaign, the messages which were already sent are not deleted
+func (service *CampaignService) Delete(ctx context.Context, userID entities.UserID, campaignID uuid.UUID) error {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	if _, err := service.repository.Load(ctx, userID, campaignID); err != nil {
+		msg := fmt.Sprintf("cannot load campaign with ID [%s] for user [%s]", campaignID, userID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
+	}
+
+	if err := service.repository.Delete(ctx, userID, campaignID); err != nil {
+		msg := fmt.Sprintf("cannot delete campaign with ID [%s] for user [%s]", campaignID, userID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("campaign with ID [%s] deleted for user [%s]", campaignID, userID))
+	return nil
+}
+
+// RunDue sends the messages of the campaigns which are due at the timestamp and returns the number of campaigns which were run.
+// A run is claimed before the messages are sent so that a campaign is not run twice when there are multiple instances.
+func (service *CampaignService) RunDue(ctx context.Context, source string, timestamp time.Time, limit int) (int, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	campaigns, err := service.repository.FetchDue(ctx, timestamp, limit)
+	if err != nil {
+		msg := fmt.Sprintf("cannot fetch campaigns which are due at [%s]", timestamp)
+		return 0, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	for _, campaign := range campaigns {
+		previousRunAt := campaign.NextRunAt
+		if err = service.schedule(campaign, timestamp); err != nil {
+			ctxLogger.Error(stacktrace.Propagate(err, fmt.Sprintf("cannot schedule the next run of campaign [%s], it will be deactivated", campaign.ID)))
+			campaign.IsActive = false
+			campaign.NextRunAt = nil
+		}
+
+		nextRunAt := campaign.NextRunAt
+		campaign.NextRunAt = previousRunAt
+		claimed, err := service.repository.Claim(ctx, campaign, nextRunAt)
+		if err != nil {
+			msg := fmt.Sprintf("cannot claim run of campaign [%s] for user [%s]", campaign.ID, campaign.UserID)
+			return 0, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+		}
+
+		if !claimed {
+			ctxLogger.Info(fmt.Sprintf("run of campaign [%s] was already claimed", campaign.ID))
+			continue
+		}
+
+		campaign.NextRunAt = nextRunAt
+		if err = service.run(ctx, source, campaign, timestamp); err != nil {
+			ctxLogger.Error(stacktrace.Propagate(err, fmt.Sprintf("cannot run campaign [%s] for user [%s]", campaign.ID, campaign.UserID)))
+		}
+	}
+
+	return len(campaigns), nil
+}
+
+func (service *CampaignService) run(ctx context.Context, source string, campaign *entities.Campaign, timestamp time.Time) error {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	if err := service.syncTemplate(ctx, campaign); stacktrace.GetCode(err) == ErrCodeTemplateNotApproved {
+		ctxLogger.Warn(stacktrace.Propagate(err, fmt.Sprintf("run of campaign [%s] is skipped because it does not use an approved template", campaign.ID)))
+		return service.save(ctx, campaign)
+	} else if err != nil {
+		msg := fmt.Sprintf("cannot load the template of campaign [%s]", campaign.ID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	contacts, err := service.resolveAudience(ctx, campaign)
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		ctxLogger.Warn(stacktrace.Propagate(err, fmt.Sprintf("%s of campaign [%s] does not exist, the campaign is deactivated", campaign.Audience(), campaign.ID)))
+		campaign.IsActive = false
+		campaign.NextRunAt = nil
+		return service.save(ctx, campaign)
+	}
+	if err != nil {
+		msg := fmt.Sprintf("cannot resolve %s of campaign [%s]", campaign.Audience(), campaign.ID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	owner, err := phonenumbers.Parse(campaign.Owner, phonenumbers.UNKNOWN_REGION)
+	if err != nil {
+		msg := fmt.Sprintf("cannot parse owner [%s] of campaign [%s]", campaign.Owner, campaign.ID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	recipients, err := service.recipients(ctx, campaign.UserID, contacts)
+	if err != nil {
+		msg := fmt.Sprintf("cannot filter [%d] contacts of campaign [%s]", len(contacts), campaign.ID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	campaign.LastRunAt = &timestamp
+	campaign.RunCount++
+	if len(recipients) == 0 {
+		ctxLogger.Info(fmt.Sprintf("campaign [%s] has no recipients in %s", campaign.ID, campaign.Audience()))
+		return service.save(ctx, campaign)
+	}
+
+	params := make([]MessageSendParams, 0, len(recipients))
+	for _, contact := range recipients {
+		params = append(params, MessageSendParams{
+			Owner:             *owner,
+			Contact:           contact.PhoneNumber,
+			Content:           campaign.Render(contact),
+			Source:            source,
+			SIM:               campaign.SIM,
+			UserID:            campaign.UserID,
+			RequestReceivedAt: timestamp,
+			TemplateID:        campaign.TemplateID,
+		})
+	}
+
+	batch, err := service.messageService.BulkSendMessage(ctx, params)
+	if batch != nil {
+		campaign.LastBatchID = &batch.BatchID
+	}
+	if saveErr := service.save(ctx, campaign); saveErr != nil {
+		ctxLogger.Error(stacktrace.Propagate(saveErr, fmt.Sprintf("cannot save run of campaign [%s]", campaign.ID)))
+	}
+	if stacktrace.GetCode(err) == ErrCodeInsufficientCredits {
+		ctxLogger.Warn(stacktrace.Propagate(err, fmt.Sprintf("campaign [%s] stopped after [%d] messages because user [%s] does not have enough credits", campaign.ID, len(batch.Messages), campaign.UserID)))
+		return nil
+	}
+	if err != nil {
+		msg := fmt.Sprintf("cannot send messages to [%d] contacts for campaign [%s]", len(params), campaign.ID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("campaign [%s] sent [%d] messages in batch [%s]", campaign.ID, len(batch.Messages), batch.BatchID))
+	return nil
+}
+
+// loadAudience checks that the segment or the contact group of a campaign exists
+func (service *CampaignService) loadAudience(ctx context.Context, params *CampaignStoreParams) error {
+	if params.GroupID != nil {
+		_, err := service.contactGroupService.Load(ctx, params.UserID, *params.GroupID)
+		return err
+	}
+
+	if params.SegmentID == nil {
+		return stacktrace.NewError(fmt.Sprintf("campaign [%s] has neither a segment nor a contact group", params.Name))
+	}
+
+	if _, err := service.segmentRepository.Load(ctx, params.UserID, *params.SegmentID); err != nil {
+		return stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), fmt.Sprintf("cannot load segment with ID [%s] for user [%s]", params.SegmentID, params.UserID))
+	}
+	return nil
+}
+
+// loadTemplate copies the content of the template of a campaign, the template must be approved when the user requires template approval
+func (service *CampaignService) loadTemplate(ctx context.Context, params *CampaignStoreParams) error {
+	user, err := service.userRepository.Load(ctx, params.UserID)
+	if err != nil {
+		return stacktrace.Propagate(err, fmt.Sprintf("cannot load user with ID [%s]", params.UserID))
+	}
+
+	if params.TemplateID == nil {
+		if user.TemplateApprovalRequired {
+			return stacktrace.NewErrorWithCode(ErrCodeTemplateNotApproved, fmt.Sprintf("user [%s] requires an approved template for campaign [%s]", params.UserID, params.Name))
+		}
+		return nil
+	}
+
+	template, err := service.templateRepository.Load(ctx, params.UserID, *params.TemplateID)
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		return stacktrace.PropagateWithCode(err, ErrCodeTemplateNotApproved, fmt.Sprintf("template with ID [%s] for user [%s] does not exist", params.TemplateID, params.UserID))
+	}
+	if err != nil {
+		return stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), fmt.Sprintf("cannot load template with ID [%s] for user [%s]", params.TemplateID, params.UserID))
+	}
+
+	if user.TemplateApprovalRequired && !template.IsApproved() {
+		return stacktrace.NewErrorWithCode(ErrCodeTemplateNotApproved, fmt.Sprintf("template [%s] has status [%s] and user [%s] requires an approved template", template.ID, template.Status, params.UserID))
+	}
+
+	params.Content = template.Content
+	return nil
+}
+
+// syncTemplate copies the current content of the template of a campaign before it is run.
+// ErrCodeTemplateNotApproved is returned when the user requires template approval and the campaign has no approved template.
+func (service *CampaignService) syncTemplate(ctx context.Context, campaign *entities.Campaign) error {
+	params := &CampaignStoreParams{UserID: campaign.UserID, Name: campaign.Name, TemplateID: campaign.TemplateID}
+	if err := service.loadTemplate(ctx, params); err != nil {
+		return stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), fmt.Sprintf("cannot load the template of campaign [%s]", campaign.ID))
+	}
+
+	if campaign.TemplateID != nil {
+		campaign.Content = params.Content
+	}
+	return nil
+}
+
+// resolveAudience returns the contacts in the segment or in the contact group of a campaign
+func (service *CampaignService) resolveAudience(ctx context.Context, campaign *entities.Campaign) ([]*entities.Contact, error) {
+	if campaign.GroupID != nil {
+		return service.contactGroupService.Resolve(ctx, campaign.UserID, *campaign.GroupID)
+	}
+
+	if campaign.SegmentID == nil {
+		return nil, stacktrace.NewErrorWithCode(repositories.ErrCodeNotFound, fmt.Sprintf("campaign [%s] has neither a segment nor a contact group", campaign.ID))
+	}
+	return service.segmentService.Resolve(ctx, campaign.UserID, *campaign.SegmentID)
+}
+
+// recipients removes the contacts which opted out or which are on the blocklist of the user
+func (service *CampaignService) recipients(ctx context.Context, userID entities.UserID, contacts []*entities.Contact) ([]*entities.Contact, error) {
+	phoneNumbers := make([]string, 0, len(contacts))
+	for _, contact := range contacts {
+		phoneNumbers = append(phoneNumbers, contact.PhoneNumber)
+	}
+
+	blocked := map[string]struct{}{}
+	for start := 0; start < len(phoneNumbers); start += segmentResolveBatchSize {
+		end := start + segmentResolveBatchSize
+		if end > len(phoneNumbers) {
+			end = len(phoneNumbers)
+		}
+
+		existing, err := service.blocklistRepository.Existing(ctx, userID, phoneNumbers[start:end])
+		if err != nil {
+			return nil, stacktrace.Propagate(err, fmt.Sprintf("cannot check [%d] phone numbers against the blocklist of user [%s]", end-start, userID))
+		}
+		for _, phoneNumber := range existing {
+			blocked[phoneNumber] = struct{}{}
+		}
+	}
+
+	recipients := make([]*entities.Contact, 0, len(contacts))
+	for _, contact := range contacts {
+		if _, ok := blocked[contact.PhoneNumber]; ok || contact.IsOptedOut {
+			continue
+		}
+		recipients = append(recipients, contact)
+	}
+	return recipients, nil
+}
+
+func (service *CampaignService) save(ctx context.Context, campaign *entities.Campaign) error {
+	campaign.UpdatedAt = time.Now().UTC()
+	if err := service.repository.Update(ctx, campaign); err != nil {
+		return stacktrace.Propagate(err, fmt.Sprintf("cannot update campaign with ID [%s]", campaign.ID))
+	}
+	return nil
+}
+
+// schedule sets the NextRunAt of an entities.Campaign to the first run after the timestamp
+func (service *CampaignService) schedule(campaign *entities.Campaign, timestamp time.Time) error {
+	if !campaign.IsActive {
+		campaign.NextRunAt = nil
+		return nil
+	}
+
+	if campaign.Cron == "" {
+		nextRunAt := timestamp.Add(time.Duration(campaign.IntervalMinutes) * time.Minute)
+		campaign.NextRunAt = &nextRunAt
+		return nil
+	}
+
+	schedule, err := ParseCronSchedule(campaign.Cron)
+	if err != nil {
+		return stacktrace.Propagate(err, fmt.Sprintf("cannot parse cron expression [%s] of campaign [%s]", campaign.Cron, campaign.ID))
+	}
+
+	nextRunAt, err := schedule.Next(timestamp.In(campaign.Location()))
+	if err != nil {
+		return stacktrace.Propagate(err, fmt.Sprintf("cannot find next run of campaign [%s]", campaign.ID))
+	}
+
+	nextRunAt = nextRunAt.UTC()
+	campaign.NextRunAt = &nextRunAt
+	return nil
+}
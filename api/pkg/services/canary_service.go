@@ -0,0 +1,468 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/events"
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/google/uuid"
+	"github.com/nyaruka/phonenumbers"
+	"github.com/palantir/stacktrace"
+)
+
+// canaryContentRegex matches the token in the content of a canary message
+var canaryContentRegex = regexp.MustCompile(`httpSMS canary ([0-9a-f]{12})`)
+
+// CanaryService sends synthetic messages to monitor the sent → delivered → received loop end-to-end
+type CanaryService struct {
+	service
+	logger         telemetry.Logger
+	tracer         telemetry.Tracer
+	repository     repositories.CanaryRepository
+	messageService *MessageService
+	dispatcher     *EventDispatcher
+}
+
+// NewCanaryService creates a new CanaryService
+func NewCanaryService(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	repository repositories.CanaryRepository,
+	messageService *MessageService,
+	dispatcher *EventDispatcher,
+) (s *CanaryService) {
+	return &CanaryService{
+		logger:         logger.WithService(fmt.Sprintf("%T", s)),
+		tracer:         tracer,
+		repository:     repository,
+		messageService: messageService,
+		dispatcher:     dispatcher,
+	}
+}
+
+// Index fetches the entities.Canary of a user
+func (service *CanaryService) Index(ctx context.Context, userID entities.UserID, params repositories.IndexParams) ([]*entities.Canary, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	canaries, err := service.repository.Index(ctx, userID, params)
+	if err != nil {
+		msg := fmt.Sprintf("could not fetch canaries with params [%+#v]", params)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("fetched [%d] canaries with prams [%+#v]", len(canaries), params))
+	return canaries, nil
+}
+
+// Runs fetches the entities.CanaryRun of an entities.Canary
+func (service *CanaryService) Runs(ctx context.Context, userID entities.UserID, canaryID uuid.UUID, params repositories.IndexParams) ([]*entities.CanaryRun, error) {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	if _, err := service.repository.Load(ctx, userID, canaryID); err != nil {
+		msg := fmt.Sprintf("cannot load canary with ID [%s] for user [%s]", canaryID, userID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
+	}
+
+	runs, err := service.repository.IndexRuns(ctx, userID, canaryID, params)
+	if err != nil {
+		msg := fmt.Sprintf("cannot fetch runs of canary with ID [%s]", canaryID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return runs, nil
+}
+
+// CanaryStoreParams are parameters for creating a new entities.Canary
+type CanaryStoreParams struct {
+	UserID   entities.UserID
+	Owner    string
+	Contact  string
+	Interval time.Duration
+	Timeout  time.Duration
+	Source   string
+}
+
+// Store a new entities.Canary and schedules its first run
+func (service *CanaryService) Store(ctx context.Context, params *CanaryStoreParams) (*entities.Canary, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	canary := &entities.Canary{
+		ID:              uuid.New(),
+		UserID:          params.UserID,
+		Owner:           params.Owner,
+		Contact:         params.Contact,
+		IntervalSeconds: uint(params.Interval.Seconds()),
+		TimeoutSeconds:  uint(params.Timeout.Seconds()),
+		CreatedAt:       time.Now().UTC(),
+		UpdatedAt:       time.Now().UTC(),
+	}
+
+	if err := service.repository.Store(ctx, canary); err != nil {
+		msg := fmt.Sprintf("cannot save canary with id [%s]", canary.ID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("canary saved with id [%s] in the [%T]", canary.ID, service.repository))
+
+	if err := service.scheduleRun(ctx, params.Source, canary, 0); err != nil {
+		msg := fmt.Sprintf("cannot schedule first run of canary with id [%s]", canary.ID)
+		ctxLogger.Error(service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg)))
+	}
+
+	return canary, nil
+}
+
+// Delete an entities.Canary so that it is no longer scheduled
+func (service *CanaryService) Delete(ctx context.Context, userID entities.UserID, canaryID uuid.UUID) error {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	if _, err := service.repository.Load(ctx, userID, canaryID); err != nil {
+		msg := fmt.Sprintf("cannot load canary with ID [%s] for user [%s]", canaryID, userID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
+	}
+
+	if err := service.repository.Delete(ctx, userID, canaryID); err != nil {
+		msg := fmt.Sprintf("cannot delete canary with ID [%s] for user [%s]", canaryID, userID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("canary with ID [%s] deleted for user [%s]", canaryID, userID))
+	return nil
+}
+
+// CanaryRunParams are parameters for running an entities.Canary
+type CanaryRunParams struct {
+	CanaryID uuid.UUID
+	UserID   entities.UserID
+	Source   string
+}
+
+// Run sends a test message for an entities.Canary and schedules the timeout check and the next run
+func (service *CanaryService) Run(ctx context.Context, params *CanaryRunParams) error {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	canary, err := service.repository.Load(ctx, params.UserID, params.CanaryID)
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		ctxLogger.Info(fmt.Sprintf("canary with ID [%s] has been deleted and will not run", params.CanaryID))
+		return nil
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot load canary with ID [%s] for user [%s]", params.CanaryID, params.UserID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if err = service.scheduleRun(ctx, params.Source, canary, canary.Interval()); err != nil {
+		msg := fmt.Sprintf("cannot schedule next run of canary with ID [%s]", canary.ID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	run := &entities.CanaryRun{
+		ID:        uuid.New(),
+		CanaryID:  canary.ID,
+		UserID:    canary.UserID,
+		Token:     strings.ReplaceAll(uuid.NewString(), "-", "")[:12],
+		Status:    entities.CanaryRunStatusPending,
+		CreatedAt: time.Now().UTC(),
+		UpdatedAt: time.Now().UTC(),
+	}
+
+	owner, err := phonenumbers.Parse(canary.Owner, phonenumbers.UNKNOWN_REGION)
+	if err != nil {
+		msg := fmt.Sprintf("cannot parse owner [%s] of canary [%s]", canary.Owner, canary.ID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	// the run is stored before sending so that fast status events can find it
+	if err = service.repository.StoreRun(ctx, run); err != nil {
+		msg := fmt.Sprintf("cannot store run for canary with ID [%s]", canary.ID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	message, err := service.messageService.SendMessage(ctx, MessageSendParams{
+		Owner:             *owner,
+		Contact:           canary.Contact,
+		Content:           fmt.Sprintf("httpSMS canary %s", run.Token),
+		Source:            params.Source,
+		SIM:               entities.SIM1,
+		UserID:            canary.UserID,
+		RequestReceivedAt: run.CreatedAt,
+	})
+	if err != nil {
+		msg := fmt.Sprintf("cannot send message for run [%s] of canary [%s]", run.ID, canary.ID)
+		ctxLogger.Error(service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg)))
+		return service.fail(ctx, params.Source, canary, run.Fail("canary message could not be sent"))
+	}
+
+	run.MessageID = &message.ID
+	if err = service.repository.UpdateRun(ctx, run); err != nil {
+		msg := fmt.Sprintf("cannot update run [%s] with message ID [%s]", run.ID, message.ID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("sent message [%s] for run [%s] of canary [%s]", message.ID, run.ID, canary.ID))
+	return service.scheduleRunCheck(ctx, params.Source, canary, run)
+}
+
+// CanaryRunCheckParams are parameters for checking an entities.CanaryRun after its timeout
+type CanaryRunCheckParams struct {
+	CanaryID uuid.UUID
+	RunID    uuid.UUID
+	UserID   entities.UserID
+	Source   string
+}
+
+// CheckRun fails an entities.CanaryRun which has not passed before the timeout
+func (service *CanaryService) CheckRun(ctx context.Context, params *CanaryRunCheckParams) error {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	canary, err := service.repository.Load(ctx, params.UserID, params.CanaryID)
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		ctxLogger.Info(fmt.Sprintf("canary with ID [%s] has been deleted", params.CanaryID))
+		return nil
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot load canary with ID [%s] for user [%s]", params.CanaryID, params.UserID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	run, err := service.repository.LoadRun(ctx, params.UserID, params.RunID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot load run with ID [%s] for canary [%s]", params.RunID, params.CanaryID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if run.IsFinal() {
+		ctxLogger.Info(fmt.Sprintf("run [%s] of canary [%s] already has status [%s]", run.ID, canary.ID, run.Status))
+		return nil
+	}
+
+	reason := map[entities.CanaryRunStatus]string{
+		entities.CanaryRunStatusPending:   "canary message was not sent by the owner phone before the timeout",
+		entities.CanaryRunStatusSent:      "canary message was not delivered to the contact before the timeout",
+		entities.CanaryRunStatusDelivered: "canary message was not received by the contact phone before the timeout",
+	}[run.Status]
+
+	return service.fail(ctx, params.Source, canary, run.Fail(reason))
+}
+
+// CanaryMessageStatusParams are parameters for updating an entities.CanaryRun when its message changes status
+type CanaryMessageStatusParams struct {
+	MessageID    uuid.UUID
+	UserID       entities.UserID
+	Status       entities.MessageStatus
+	ErrorMessage string
+	Timestamp    time.Time
+	Source       string
+}
+
+// HandleMessageStatus updates the entities.CanaryRun which sent a message
+func (service *CanaryService) HandleMessageStatus(ctx context.Context, params *CanaryMessageStatusParams) error {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	run, err := service.repository.LoadRunByMessageID(ctx, params.UserID, params.MessageID)
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		return nil
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot load canary run for message [%s]", params.MessageID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if run.IsFinal() {
+		return nil
+	}
+
+	switch params.Status {
+	case entities.MessageStatusSent:
+		run.Status = entities.CanaryRunStatusSent
+		run.SentAt = &params.Timestamp
+	case entities.MessageStatusDelivered:
+		run.Status = entities.CanaryRunStatusDelivered
+		run.DeliveredAt = &params.Timestamp
+	case entities.MessageStatusFailed:
+		canary, err := service.repository.Load(ctx, run.UserID, run.CanaryID)
+		if err != nil {
+			msg := fmt.Sprintf("cannot load canary with ID [%s] for run [%s]", run.CanaryID, run.ID)
+			return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+		}
+		return service.fail(ctx, params.Source, canary, run.Fail(fmt.Sprintf("canary message failed: %s", params.ErrorMessage)))
+	}
+
+	run.UpdatedAt = time.Now().UTC()
+	if err = service.repository.UpdateRun(ctx, run); err != nil {
+		msg := fmt.Sprintf("cannot update canary run [%s] with status [%s]", run.ID, run.Status)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+// HandleMessageReceived passes the entities.CanaryRun when its message is received by the contact phone
+func (service *CanaryService) HandleMessageReceived(ctx context.Context, payload *events.MessagePhoneReceivedPayload) error {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	match := canaryContentRegex.FindStringSubmatch(payload.Content)
+	if match == nil {
+		return nil
+	}
+
+	run, err := service.repository.LoadRunByToken(ctx, payload.UserID, match[1])
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		ctxLogger.Info(fmt.Sprintf("no canary run found for token [%s] in message [%s]", match[1], payload.MessageID))
+		return nil
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot load canary run with token [%s]", match[1])
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if run.IsFinal() {
+		ctxLogger.Info(fmt.Sprintf("run [%s] already has status [%s] message [%s] is ignored", run.ID, run.Status, payload.MessageID))
+		return nil
+	}
+
+	if err = service.repository.UpdateRun(ctx, run.Pass(payload.Timestamp)); err != nil {
+		msg := fmt.Sprintf("cannot pass canary run [%s]", run.ID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("run [%s] of canary [%s] passed in [%.2f] seconds", run.ID, run.CanaryID, *run.RoundTripSeconds))
+	return service.updateLastRun(ctx, run)
+}
+
+func (service *CanaryService) fail(ctx context.Context, source string, canary *entities.Canary, run *entities.CanaryRun) error {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	if err := service.repository.UpdateRun(ctx, run); err != nil {
+		msg := fmt.Sprintf("cannot fail canary run [%s]", run.ID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Warn(stacktrace.NewError(fmt.Sprintf("run [%s] of canary [%s] failed: %s", run.ID, canary.ID, *run.FailureReason)))
+
+	if err := service.updateLastRun(ctx, run); err != nil {
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, fmt.Sprintf("cannot update last run of canary [%s]", canary.ID)))
+	}
+
+	event, err := service.createCanaryFailedEvent(source, &events.CanaryFailedPayload{
+		CanaryID:  canary.ID,
+		RunID:     run.ID,
+		UserID:    canary.UserID,
+		Owner:     canary.Owner,
+		Contact:   canary.Contact,
+		Status:    run.Status,
+		Reason:    *run.FailureReason,
+		Timestamp: time.Now().UTC(),
+	})
+	if err != nil {
+		msg := fmt.Sprintf("cannot create event [%s] for canary run [%s]", events.EventTypeCanaryFailed, run.ID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if err = service.dispatcher.Dispatch(ctx, event); err != nil {
+		msg := fmt.Sprintf("cannot dispatch event [%s] for canary run [%s]", event.Type(), run.ID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+func (service *CanaryService) updateLastRun(ctx context.Context, run *entities.CanaryRun) error {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	canary, err := service.repository.Load(ctx, run.UserID, run.CanaryID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot load canary with ID [%s]", run.CanaryID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	canary.LastStatus = &run.Status
+	canary.LastRoundTripSeconds = run.RoundTripSeconds
+	canary.LastRunAt = &run.CreatedAt
+	canary.UpdatedAt = time.Now().UTC()
+
+	if err = service.repository.Update(ctx, canary); err != nil {
+		msg := fmt.Sprintf("cannot update last run of canary with ID [%s]", canary.ID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+func (service *CanaryService) scheduleRun(ctx context.Context, source string, canary *entities.Canary, delay time.Duration) error {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	event, err := service.createCanaryRunEvent(source, &events.CanaryRunPayload{
+		CanaryID:    canary.ID,
+		UserID:      canary.UserID,
+		ScheduledAt: time.Now().UTC().Add(delay),
+	})
+	if err != nil {
+		msg := fmt.Sprintf("cannot create event [%s] for canary with id [%s]", events.EventTypeCanaryRun, canary.ID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if _, err = service.dispatcher.DispatchWithTimeout(ctx, event, delay); err != nil {
+		msg := fmt.Sprintf("cannot dispatch event [%s] for canary with ID [%s]", event.Type(), canary.ID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+func (service *CanaryService) scheduleRunCheck(ctx context.Context, source string, canary *entities.Canary, run *entities.CanaryRun) error {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	event, err := service.createCanaryRunCheckEvent(source, &events.CanaryRunCheckPayload{
+		CanaryID:    canary.ID,
+		RunID:       run.ID,
+		UserID:      canary.UserID,
+		ScheduledAt: run.CreatedAt.Add(canary.Timeout()),
+	})
+	if err != nil {
+		msg := fmt.Sprintf("cannot create event [%s] for canary run with id [%s]", events.EventTypeCanaryRunCheck, run.ID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if _, err = service.dispatcher.DispatchWithTimeout(ctx, event, canary.Timeout()); err != nil {
+		msg := fmt.Sprintf("cannot dispatch event [%s] for canary run with ID [%s]", event.Type(), run.ID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+func (service *CanaryService) createCanaryRunEvent(source string, payload *events.CanaryRunPayload) (cloudevents.Event, error) {
+	return service.createEvent(events.EventTypeCanaryRun, source, payload)
+}
+
+func (service *CanaryService) createCanaryRunCheckEvent(source string, payload *events.CanaryRunCheckPayload) (cloudevents.Event, error) {
+	return service.createEvent(events.EventTypeCanaryRunCheck, source, payload)
+}
+
+func (service *CanaryService) createCanaryFailedEvent(source string, payload *events.CanaryFailedPayload) (cloudevents.Event, error) {
+	return service.createEvent(events.EventTypeCanaryFailed, source, payload)
+}
@@ -0,0 +1,148 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/cache"
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/events"
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/palantir/stacktrace"
+)
+
+// carrierFilteringCheckWindow is the period of sent messages which is checked when a message does not get a delivery report, a prefix is alerted at most once in this period
+const carrierFilteringCheckWindow = 24 * time.Hour
+
+// CarrierFilteringService detects phone number prefixes which are silently filtered by carriers
+type CarrierFilteringService struct {
+	service
+	logger     telemetry.Logger
+	tracer     telemetry.Tracer
+	repository repositories.AnalyticsRepository
+	cache      cache.Cache
+	dispatcher *EventDispatcher
+}
+
+// NewCarrierFilteringService creates a new CarrierFilteringService
+func NewCarrierFilteringService(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	repository repositories.AnalyticsRepository,
+	cache cache.Cache,
+	dispatcher *EventDispatcher,
+) (s *CarrierFilteringService) {
+	return &CarrierFilteringService{
+		logger:     logger.WithService(fmt.Sprintf("%T", s)),
+		tracer:     tracer,
+		repository: repository,
+		cache:      cache,
+		dispatcher: dispatcher,
+	}
+}
+
+// Index computes the entities.CarrierFilteringStat of the owners of a user by contact phone number prefix
+func (service *CarrierFilteringService) Index(ctx context.Context, userID entities.UserID, params repositories.PrefixDeliveryParams) ([]*entities.CarrierFilteringStat, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	counts, err := service.repository.PrefixDeliveryCounts(ctx, userID, params)
+	if err != nil {
+		msg := fmt.Sprintf("could not count delivery by prefix with params [%+#v]", params)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	stats := entities.DetectCarrierFiltering(counts)
+	ctxLogger.Info(fmt.Sprintf("computed [%d] carrier filtering stats with params [%+#v]", len(stats), params))
+	return stats, nil
+}
+
+// CarrierFilteringCheckParams are parameters for checking the prefix of a message which did not get a delivery report
+type CarrierFilteringCheckParams struct {
+	UserID  entities.UserID
+	Owner   string
+	Contact string
+	Source  string
+}
+
+// Check dispatches the events.EventTypePhoneFilteringSuspected event when the prefix of the contact is suspected of carrier filtering for the owner
+func (service *CarrierFilteringService) Check(ctx context.Context, params *CarrierFilteringCheckParams) error {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	prefix := params.Contact
+	if len(prefix) > entities.CarrierFilteringPrefixLength {
+		prefix = prefix[:entities.CarrierFilteringPrefixLength]
+	}
+
+	key := fmt.Sprintf("carrier.filtering.%s.%s.%s", params.UserID, params.Owner, prefix)
+	if _, err := service.cache.Get(ctx, key); err == nil {
+		ctxLogger.Info(fmt.Sprintf("carrier filtering of prefix [%s] for owner [%s] has already been alerted", prefix, params.Owner))
+		return nil
+	}
+
+	now := time.Now().UTC()
+	stats, err := service.Index(ctx, params.UserID, repositories.PrefixDeliveryParams{
+		PrefixLength: entities.CarrierFilteringPrefixLength,
+		From:         now.Add(-carrierFilteringCheckWindow),
+		To:           now,
+	})
+	if err != nil {
+		msg := fmt.Sprintf("cannot compute carrier filtering stats for user [%s]", params.UserID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	for _, stat := range stats {
+		if stat.Owner != params.Owner || stat.Prefix != prefix || !stat.Suspected {
+			continue
+		}
+
+		if err = service.dispatchSuspected(ctx, params, stat); err != nil {
+			msg := fmt.Sprintf("cannot dispatch suspected carrier filtering of prefix [%s] for owner [%s]", prefix, params.Owner)
+			return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+		}
+
+		if err = service.cache.Set(ctx, key, "", carrierFilteringCheckWindow); err != nil {
+			ctxLogger.Error(stacktrace.Propagate(err, fmt.Sprintf("cannot set item in cache with key [%s]", key)))
+		}
+		return nil
+	}
+
+	return nil
+}
+
+func (service *CarrierFilteringService) dispatchSuspected(ctx context.Context, params *CarrierFilteringCheckParams, stat *entities.CarrierFilteringStat) error {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	event, err := service.createPhoneFilteringSuspectedEvent(params.Source, &events.PhoneFilteringSuspectedPayload{
+		UserID:              params.UserID,
+		Owner:               stat.Owner,
+		Prefix:              stat.Prefix,
+		Sent:                stat.Sent,
+		DeliveryUnknown:     stat.DeliveryUnknown,
+		DeliveryUnknownRate: stat.DeliveryUnknownRate,
+		OwnerDeliveryRate:   stat.OwnerDeliveryRate,
+		SuggestedOwner:      stat.SuggestedOwner,
+		Timestamp:           time.Now().UTC(),
+	})
+	if err != nil {
+		msg := fmt.Sprintf("cannot create [%s] event for prefix [%s] of owner [%s]", events.EventTypePhoneFilteringSuspected, stat.Prefix, stat.Owner)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if err = service.dispatcher.Dispatch(ctx, event); err != nil {
+		msg := fmt.Sprintf("cannot dispatch event [%s] for prefix [%s] of owner [%s]", event.Type(), stat.Prefix, stat.Owner)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("suspected carrier filtering of prefix [%s] for owner [%s] of user [%s] with [%d/%d] messages without delivery report", stat.Prefix, stat.Owner, params.UserID, stat.DeliveryUnknown, stat.Sent))
+	return nil
+}
+
+func (service *CarrierFilteringService) createPhoneFilteringSuspectedEvent(source string, payload *events.PhoneFilteringSuspectedPayload) (cloudevents.Event, error) {
+	return service.createEvent(events.EventTypePhoneFilteringSuspected, source, payload)
+}
@@ -0,0 +1,110 @@
+package services
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/palantir/stacktrace"
+)
+
+const clamAVChunkSize = 64 * 1024
+
+type clamAVAttachmentScanner struct {
+	logger  telemetry.Logger
+	tracer  telemetry.Tracer
+	address string
+}
+
+// NewClamAVAttachmentScanner creates an AttachmentScanner which streams attachments to a clamd daemon e.g. "localhost:3310"
+func NewClamAVAttachmentScanner(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	address string,
+) AttachmentScanner {
+	return &clamAVAttachmentScanner{
+		logger:  logger.WithService(fmt.Sprintf("%T", &clamAVAttachmentScanner{})),
+		tracer:  tracer,
+		address: address,
+	}
+}
+
+// Name of the scanner
+func (scanner *clamAVAttachmentScanner) Name() string {
+	return "clamav"
+}
+
+// Scan the content of an attachment using the clamd INSTREAM command
+func (scanner *clamAVAttachmentScanner) Scan(ctx context.Context, name string, content []byte) (*AttachmentScanResult, error) {
+	ctx, span, ctxLogger := scanner.tracer.StartWithLogger(ctx, scanner.logger)
+	defer span.End()
+
+	dialer := net.Dialer{Timeout: 10 * time.Second}
+	conn, err := dialer.DialContext(ctx, "tcp", scanner.address)
+	if err != nil {
+		msg := fmt.Sprintf("cannot connect to clamd on [%s]", scanner.address)
+		return nil, scanner.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+	defer func() { _ = conn.Close() }()
+
+	_ = conn.SetDeadline(time.Now().Add(time.Minute))
+
+	if err = scanner.stream(conn, content); err != nil {
+		msg := fmt.Sprintf("cannot stream attachment [%s] to clamd on [%s]", name, scanner.address)
+		return nil, scanner.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	reply, err := bufio.NewReader(conn).ReadString(0)
+	if err != nil {
+		msg := fmt.Sprintf("cannot read clamd reply for attachment [%s]", name)
+		return nil, scanner.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	// replies are "stream: OK" or "stream: <signature> FOUND"
+	reply = strings.TrimSpace(strings.TrimSuffix(strings.TrimPrefix(reply, "stream:"), "\x00"))
+	ctxLogger.Info(fmt.Sprintf("clamd scanned attachment [%s] with reply [%s]", name, reply))
+
+	switch {
+	case reply == "OK":
+		return &AttachmentScanResult{Status: entities.AttachmentScanStatusClean}, nil
+	case strings.HasSuffix(reply, "FOUND"):
+		return &AttachmentScanResult{
+			Status:    entities.AttachmentScanStatusQuarantined,
+			Signature: strings.TrimSpace(strings.TrimSuffix(reply, "FOUND")),
+		}, nil
+	default:
+		msg := fmt.Sprintf("clamd could not scan attachment [%s] with reply [%s]", name, reply)
+		return nil, scanner.tracer.WrapErrorSpan(span, stacktrace.NewError(msg))
+	}
+}
+
+func (scanner *clamAVAttachmentScanner) stream(conn net.Conn, content []byte) error {
+	if _, err := conn.Write([]byte("zINSTREAM\x00")); err != nil {
+		return stacktrace.Propagate(err, "cannot send INSTREAM command")
+	}
+
+	size := make([]byte, 4)
+	for start := 0; start < len(content); start += clamAVChunkSize {
+		end := start + clamAVChunkSize
+		if end > len(content) {
+			end = len(content)
+		}
+
+		binary.BigEndian.PutUint32(size, uint32(end-start))
+		if _, err := conn.Write(append(size, content[start:end]...)); err != nil {
+			return stacktrace.Propagate(err, fmt.Sprintf("cannot send chunk starting at byte [%d]", start))
+		}
+	}
+
+	binary.BigEndian.PutUint32(size, 0)
+	if _, err := conn.Write(size); err != nil {
+		return stacktrace.Propagate(err, "cannot terminate stream")
+	}
+	return nil
+}
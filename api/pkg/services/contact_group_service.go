@@ -0,0 +1,238 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/google/uuid"
+	"github.com/palantir/stacktrace"
+)
+
+// ContactGroupService manages entities.ContactGroup and their members
+type ContactGroupService struct {
+	service
+	logger            telemetry.Logger
+	tracer            telemetry.Tracer
+	repository        repositories.ContactGroupRepository
+	contactRepository repositories.ContactRepository
+}
+
+// NewContactGroupService creates a new ContactGroupService
+func NewContactGroupService(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	repository repositories.ContactGroupRepository,
+	contactRepository repositories.ContactRepository,
+) (s *ContactGroupService) {
+	return &ContactGroupService{
+		logger:            logger.WithService(fmt.Sprintf("%T", s)),
+		tracer:            tracer,
+		repository:        repository,
+		contactRepository: contactRepository,
+	}
+}
+
+// Index fetches the entities.ContactGroup of a user
+func (service *ContactGroupService) Index(ctx context.Context, userID entities.UserID, params repositories.IndexParams) ([]*entities.ContactGroup, error) {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	groups, err := service.repository.Index(ctx, userID, params)
+	if err != nil {
+		msg := fmt.Sprintf("could not fetch contact groups with params [%+#v]", params)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return groups, nil
+}
+
+// Load an entities.ContactGroup of a user by ID
+func (service *ContactGroupService) Load(ctx context.Context, userID entities.UserID, groupID uuid.UUID) (*entities.ContactGroup, error) {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	group, err := service.repository.Load(ctx, userID, groupID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot load contact group with ID [%s] for user [%s]", groupID, userID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
+	}
+
+	return group, nil
+}
+
+// ContactGroupStoreParams are parameters for creating an entities.ContactGroup
+type ContactGroupStoreParams struct {
+	UserID      entities.UserID
+	Name        string
+	Description string
+}
+
+// Store a new entities.ContactGroup
+func (service *ContactGroupService) Store(ctx context.Context, params *ContactGroupStoreParams) (*entities.ContactGroup, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	group := &entities.ContactGroup{
+		ID:          uuid.New(),
+		UserID:      params.UserID,
+		Name:        params.Name,
+		Description: params.Description,
+		CreatedAt:   time.Now().UTC(),
+		UpdatedAt:   time.Now().UTC(),
+	}
+
+	if err := service.repository.Store(ctx, group); err != nil {
+		msg := fmt.Sprintf("cannot save contact group with id [%s]", group.ID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("contact group saved with id [%s] in the [%T]", group.ID, service.repository))
+	return group, nil
+}
+
+// ContactGroupUpdateParams are parameters for updating an entities.ContactGroup
+type ContactGroupUpdateParams struct {
+	UserID      entities.UserID
+	GroupID     uuid.UUID
+	Name        string
+	Description string
+}
+
+// Update the name and description of an entities.ContactGroup
+func (service *ContactGroupService) Update(ctx context.Context, params *ContactGroupUpdateParams) (*entities.ContactGroup, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	group, err := service.repository.Load(ctx, params.UserID, params.GroupID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot load contact group with ID [%s] for user [%s]", params.GroupID, params.UserID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
+	}
+
+	group.Name = params.Name
+	group.Description = params.Description
+	group.UpdatedAt = time.Now().UTC()
+
+	if err = service.repository.Update(ctx, group); err != nil {
+		msg := fmt.Sprintf("cannot update contact group with id [%s]", group.ID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("contact group updated with id [%s] in the [%T]", group.ID, service.repository))
+	return group, nil
+}
+
+// Delete an entities.ContactGroup, the contacts in the group are not deleted
+func (service *ContactGroupService) Delete(ctx context.Context, userID entities.UserID, groupID uuid.UUID) error {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	if _, err := service.repository.Load(ctx, userID, groupID); err != nil {
+		msg := fmt.Sprintf("cannot load contact group with ID [%s] for user [%s]", groupID, userID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
+	}
+
+	if err := service.repository.Delete(ctx, userID, groupID); err != nil {
+		msg := fmt.Sprintf("cannot delete contact group with ID [%s] for user [%s]", groupID, userID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("contact group with ID [%s] deleted for user [%s]", groupID, userID))
+	return nil
+}
+
+// AddContacts adds entities.Contact to an entities.ContactGroup, the IDs which do not belong to contacts of the user are ignored
+func (service *ContactGroupService) AddContacts(ctx context.Context, userID entities.UserID, groupID uuid.UUID, contactIDs []uuid.UUID) (*entities.ContactGroup, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	if _, err := service.repository.Load(ctx, userID, groupID); err != nil {
+		msg := fmt.Sprintf("cannot load contact group with ID [%s] for user [%s]", groupID, userID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
+	}
+
+	added, err := service.repository.AddContacts(ctx, userID, groupID, contactIDs)
+	if err != nil {
+		msg := fmt.Sprintf("cannot add [%d] contacts to group with ID [%s]", len(contactIDs), groupID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("added [%d] out of [%d] contacts to group [%s] for user [%s]", added, len(contactIDs), groupID, userID))
+	return service.Load(ctx, userID, groupID)
+}
+
+// RemoveContact removes an entities.Contact from an entities.ContactGroup
+func (service *ContactGroupService) RemoveContact(ctx context.Context, userID entities.UserID, groupID uuid.UUID, contactID uuid.UUID) error {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	removed, err := service.repository.RemoveContact(ctx, userID, groupID, contactID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot remove contact [%s] from group with ID [%s]", contactID, groupID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if !removed {
+		msg := fmt.Sprintf("contact [%s] is not in group [%s] of user [%s]", contactID, groupID, userID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.NewErrorWithCode(repositories.ErrCodeNotFound, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("removed contact [%s] from group [%s] for user [%s]", contactID, groupID, userID))
+	return nil
+}
+
+// Resolve returns the entities.Contact which are members of an entities.ContactGroup
+func (service *ContactGroupService) Resolve(ctx context.Context, userID entities.UserID, groupID uuid.UUID) ([]*entities.Contact, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	group, err := service.repository.Load(ctx, userID, groupID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot load contact group with ID [%s] for user [%s]", groupID, userID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
+	}
+
+	contacts := make([]*entities.Contact, 0, group.ContactCount)
+	for {
+		batch, err := service.contactRepository.Index(ctx, userID, repositories.ContactIndexParams{
+			IndexParams: repositories.IndexParams{Skip: len(contacts), Limit: segmentResolveBatchSize},
+			GroupID:     &group.ID,
+		})
+		if err != nil {
+			msg := fmt.Sprintf("cannot fetch contacts for group [%s] after [%d] contacts", group.ID, len(contacts))
+			return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+		}
+
+		contacts = append(contacts, batch...)
+		if len(batch) < segmentResolveBatchSize {
+			break
+		}
+	}
+
+	ctxLogger.Info(fmt.Sprintf("resolved group [%s] into [%d] contacts for user [%s]", group.ID, len(contacts), userID))
+	return contacts, nil
+}
+
+// PhoneNumbers returns the phone numbers of the members of an entities.ContactGroup which have not opted out of receiving messages
+func (service *ContactGroupService) PhoneNumbers(ctx context.Context, userID entities.UserID, groupID uuid.UUID) ([]string, error) {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	contacts, err := service.Resolve(ctx, userID, groupID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot resolve contacts in group [%s] for user [%s]", groupID, userID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
+	}
+
+	phoneNumbers := make([]string, 0, len(contacts))
+	for _, contact := range contacts {
+		if !contact.IsOptedOut {
+			phoneNumbers = append(phoneNumbers, contact.PhoneNumber)
+		}
+	}
+	return phoneNumbers, nil
+}
@@ -0,0 +1,255 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/google/uuid"
+	"github.com/palantir/stacktrace"
+)
+
+// ErrCodeContactExists is returned when an entities.Contact is created with a phone number which is already in the contact book
+const ErrCodeContactExists = stacktrace.ErrorCode(2018)
+
+// ContactService maintains the engagement metrics of an entities.Contact
+type ContactService struct {
+	service
+	logger     telemetry.Logger
+	tracer     telemetry.Tracer
+	repository repositories.ContactRepository
+}
+
+// NewContactService creates a new ContactService
+func NewContactService(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	repository repositories.ContactRepository,
+) (s *ContactService) {
+	return &ContactService{
+		logger:     logger.WithService(fmt.Sprintf("%T", s)),
+		tracer:     tracer,
+		repository: repository,
+	}
+}
+
+// Index fetches the entities.Contact of a user
+func (service *ContactService) Index(ctx context.Context, userID entities.UserID, params repositories.ContactIndexParams) ([]*entities.Contact, error) {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	contacts, err := service.repository.Index(ctx, userID, params)
+	if err != nil {
+		msg := fmt.Sprintf("could not fetch contacts with params [%+#v]", params)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return contacts, nil
+}
+
+// Load an entities.Contact of a user by ID
+func (service *ContactService) Load(ctx context.Context, userID entities.UserID, contactID uuid.UUID) (*entities.Contact, error) {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	contact, err := service.repository.LoadByID(ctx, userID, contactID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot load contact with ID [%s] for user [%s]", contactID, userID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
+	}
+
+	return contact, nil
+}
+
+// ContactStoreParams are parameters for adding an entities.Contact to the contact book
+type ContactStoreParams struct {
+	UserID      entities.UserID
+	PhoneNumber string
+	Name        string
+	Tags        []string
+	Attributes  map[string]any
+}
+
+// Store adds a new entities.Contact to the contact book of a user
+func (service *ContactService) Store(ctx context.Context, params *ContactStoreParams) (*entities.Contact, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	contact, isNew, err := service.loadOrCreate(ctx, params.UserID, params.PhoneNumber)
+	if err != nil {
+		msg := fmt.Sprintf("cannot load contact [%s] for user [%s]", params.PhoneNumber, params.UserID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if !isNew {
+		msg := fmt.Sprintf("contact [%s] already exists with ID [%s] for user [%s]", params.PhoneNumber, contact.ID, params.UserID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.NewErrorWithCode(ErrCodeContactExists, msg))
+	}
+
+	contact.Name = params.Name
+	contact.Tags = params.Tags
+	contact.Attributes = params.Attributes
+
+	if err = service.repository.Store(ctx, contact); err != nil {
+		msg := fmt.Sprintf("cannot save contact with ID [%s]", contact.ID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("contact saved with id [%s] in the [%T]", contact.ID, service.repository))
+	return contact, nil
+}
+
+// Delete an entities.Contact, it is created again when a message is exchanged with the phone number
+func (service *ContactService) Delete(ctx context.Context, userID entities.UserID, contactID uuid.UUID) error {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	if _, err := service.repository.LoadByID(ctx, userID, contactID); err != nil {
+		msg := fmt.Sprintf("cannot load contact with ID [%s] for user [%s]", contactID, userID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
+	}
+
+	if err := service.repository.Delete(ctx, userID, contactID); err != nil {
+		msg := fmt.Sprintf("cannot delete contact with ID [%s] for user [%s]", contactID, userID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("contact with ID [%s] deleted for user [%s]", contactID, userID))
+	return nil
+}
+
+// ContactUpdateParams are parameters for updating an entities.Contact
+type ContactUpdateParams struct {
+	UserID     entities.UserID
+	ContactID  uuid.UUID
+	Name       string
+	Tags       []string
+	Attributes map[string]any
+}
+
+// Update the name, tags and attributes of an entities.Contact
+func (service *ContactService) Update(ctx context.Context, params *ContactUpdateParams) (*entities.Contact, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	contact, err := service.repository.LoadByID(ctx, params.UserID, params.ContactID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot load contact with ID [%s] for user [%s]", params.ContactID, params.UserID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
+	}
+
+	contact.Name = params.Name
+	contact.Tags = params.Tags
+	contact.Attributes = params.Attributes
+	contact.UpdatedAt = time.Now().UTC()
+
+	if err = service.repository.Update(ctx, contact); err != nil {
+		msg := fmt.Sprintf("cannot update contact with ID [%s]", contact.ID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("contact updated with id [%s] in the [%T]", contact.ID, service.repository))
+	return contact, nil
+}
+
+// AddTag adds a tag to the entities.Contact with the phone number, the contact is created if it does not exist
+func (service *ContactService) AddTag(ctx context.Context, userID entities.UserID, phoneNumber string, tag string) error {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	contact, isNew, err := service.loadOrCreate(ctx, userID, phoneNumber)
+	if err != nil {
+		msg := fmt.Sprintf("cannot load contact [%s] for user [%s]", phoneNumber, userID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	for _, value := range contact.Tags {
+		if value == tag {
+			ctxLogger.Info(fmt.Sprintf("contact [%s] already has the tag [%s]", contact.ID, tag))
+			return nil
+		}
+	}
+
+	contact.Tags = append(contact.Tags, tag)
+	contact.UpdatedAt = time.Now().UTC()
+
+	if err = service.save(ctx, contact, isNew); err != nil {
+		msg := fmt.Sprintf("cannot save contact [%s] after adding the tag [%s]", contact.ID, tag)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("added tag [%s] to contact [%s] of user [%s]", tag, contact.ID, userID))
+	return nil
+}
+
+// ContactRecordParams are parameters for recording a message exchanged with an entities.Contact
+type ContactRecordParams struct {
+	UserID    entities.UserID
+	Contact   string
+	Content   string
+	Timestamp time.Time
+}
+
+// RecordSent updates the metrics of an entities.Contact after a message is sent to it
+func (service *ContactService) RecordSent(ctx context.Context, params *ContactRecordParams) error {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	contact, isNew, err := service.loadOrCreate(ctx, params.UserID, params.Contact)
+	if err != nil {
+		msg := fmt.Sprintf("cannot load contact [%s] for user [%s]", params.Contact, params.UserID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if err = service.save(ctx, contact.RecordSent(params.Timestamp), isNew); err != nil {
+		msg := fmt.Sprintf("cannot save contact [%s] after recording a sent message", contact.ID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("recorded sent message for contact [%s] of user [%s]", contact.ID, contact.UserID))
+	return nil
+}
+
+// RecordReceived updates the metrics of an entities.Contact after a message is received from it
+func (service *ContactService) RecordReceived(ctx context.Context, params *ContactRecordParams) error {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	contact, isNew, err := service.loadOrCreate(ctx, params.UserID, params.Contact)
+	if err != nil {
+		msg := fmt.Sprintf("cannot load contact [%s] for user [%s]", params.Contact, params.UserID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if err = service.save(ctx, contact.RecordReceived(params.Timestamp, params.Content), isNew); err != nil {
+		msg := fmt.Sprintf("cannot save contact [%s] after recording a received message", contact.ID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("recorded received message for contact [%s] of user [%s] with opt out status [%t]", contact.ID, contact.UserID, contact.IsOptedOut))
+	return nil
+}
+
+func (service *ContactService) loadOrCreate(ctx context.Context, userID entities.UserID, phoneNumber string) (*entities.Contact, bool, error) {
+	contact, err := service.repository.Load(ctx, userID, phoneNumber)
+	if err != nil && stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		return &entities.Contact{
+			ID:          uuid.New(),
+			UserID:      userID,
+			PhoneNumber: phoneNumber,
+			CreatedAt:   time.Now().UTC(),
+			UpdatedAt:   time.Now().UTC(),
+		}, true, nil
+	}
+	return contact, false, err
+}
+
+func (service *ContactService) save(ctx context.Context, contact *entities.Contact, isNew bool) error {
+	if isNew {
+		return service.repository.Store(ctx, contact)
+	}
+	return service.repository.Update(ctx, contact)
+}
@@ -0,0 +1,183 @@
+package services
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/events"
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/google/uuid"
+	"github.com/palantir/stacktrace"
+)
+
+// ConversationService groups the entities.Message of a user by owner and contact for chat interfaces
+type ConversationService struct {
+	service
+	logger          telemetry.Logger
+	tracer          telemetry.Tracer
+	repository      repositories.MessageRepository
+	eventDispatcher *EventDispatcher
+}
+
+// NewConversationService creates a new ConversationService
+func NewConversationService(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	repository repositories.MessageRepository,
+	eventDispatcher *EventDispatcher,
+) (s *ConversationService) {
+	return &ConversationService{
+		logger:          logger.WithService(fmt.Sprintf("%T", s)),
+		tracer:          tracer,
+		repository:      repository,
+		eventDispatcher: eventDispatcher,
+	}
+}
+
+// ConversationPage is a page of entities.Conversation
+type ConversationPage struct {
+	Conversations []*entities.Conversation `json:"conversations"`
+
+	// NextCursor is used to fetch the next page, it is null when there are no more conversations
+	NextCursor *string `json:"next_cursor" example:"MTY1NDQzMjM2OTUyNzk3NjAwMDozMjM0M2ExOS1kYTVlLTRiMWItYTc2Ny0zMjk4YTczNzAzY2I"`
+}
+
+// Index fetches a page of the entities.Conversation of a user with the most recent message first
+func (service *ConversationService) Index(ctx context.Context, userID entities.UserID, params repositories.ConversationIndexParams) (*ConversationPage, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	conversations, err := service.repository.IndexConversations(ctx, userID, params)
+	if err != nil {
+		msg := fmt.Sprintf("cannot fetch conversations for user [%s] with params [%+#v]", userID, params)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	page := &ConversationPage{Conversations: conversations}
+	if len(conversations) == params.Limit && len(conversations) > 0 {
+		last := conversations[len(conversations)-1]
+		cursor := EncodeEventCursor(repositories.EventCursor{Time: last.LastMessageAt, ID: last.LastMessageID})
+		page.NextCursor = &cursor
+	}
+
+	ctxLogger.Info(fmt.Sprintf("fetched [%d] conversations for user [%s]", len(conversations), userID))
+	return page, nil
+}
+
+// ConversationMessagesParams are parameters for fetching the entities.Message of a conversation
+type ConversationMessagesParams struct {
+	Owner   string
+	Contact string
+	Cursor  *uint64
+	Limit   int
+}
+
+// ConversationMessagePage is a page of the entities.Message in a conversation
+type ConversationMessagePage struct {
+	Messages []*entities.Message `json:"messages"`
+
+	// NextCursor is used to fetch older messages, it is null when there are no more messages
+	NextCursor *string `json:"next_cursor" example:"NDI"`
+}
+
+// EncodeSequenceCursor encodes the entities.Message.Sequence of the last message in a page into an opaque string
+func EncodeSequenceCursor(sequence uint64) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatUint(sequence, 10)))
+}
+
+// DecodeSequenceCursor decodes a cursor which was encoded with EncodeSequenceCursor
+func DecodeSequenceCursor(value string) (uint64, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return 0, stacktrace.Propagate(err, fmt.Sprintf("cannot decode cursor [%s]", value))
+	}
+
+	sequence, err := strconv.ParseUint(string(data), 10, 64)
+	if err != nil {
+		return 0, stacktrace.Propagate(err, fmt.Sprintf("cannot parse sequence of cursor [%s]", value))
+	}
+
+	return sequence, nil
+}
+
+// Messages fetches a page of the entities.Message between an owner and a contact ordered by their sequence from the newest to the oldest
+func (service *ConversationService) Messages(ctx context.Context, userID entities.UserID, params *ConversationMessagesParams) (*ConversationMessagePage, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	messages, err := service.repository.IndexConversation(ctx, userID, params.Owner, params.Contact, params.Cursor, params.Limit)
+	if err != nil {
+		msg := fmt.Sprintf("cannot fetch messages for user [%s] with params [%+#v]", userID, params)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	page := &ConversationMessagePage{Messages: messages}
+	if len(messages) == params.Limit && len(messages) > 0 {
+		last := messages[len(messages)-1]
+		cursor := EncodeSequenceCursor(last.Sequence)
+		page.NextCursor = &cursor
+	}
+
+	ctxLogger.Info(fmt.Sprintf("fetched [%d] messages between owner [%s] and contact [%s] for user [%s]", len(messages), params.Owner, params.Contact, userID))
+	return page, nil
+}
+
+// ConversationMarkReadParams are parameters for marking the entities.Message of a conversation as read
+type ConversationMarkReadParams struct {
+	Source  string
+	UserID  entities.UserID
+	Owner   string
+	Contact string
+}
+
+// ConversationReadResult contains the entities.Message which were marked as read in a conversation
+type ConversationReadResult struct {
+	Owner      string      `json:"owner" example:"+18005550199"`
+	Contact    string      `json:"contact" example:"+18005550100"`
+	MessageIDs []uuid.UUID `json:"message_ids" example:"32343a19-da5e-4b1b-a767-3298a73703cb"`
+	ReadAt     time.Time   `json:"read_at" example:"2022-06-05T14:30:12.527976+03:00"`
+}
+
+// MarkRead marks all the unread messages received by an owner from a contact as read
+func (service *ConversationService) MarkRead(ctx context.Context, params *ConversationMarkReadParams) (*ConversationReadResult, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	timestamp := time.Now().UTC()
+	messageIDs, err := service.repository.MarkConversationRead(ctx, params.UserID, params.Owner, params.Contact, timestamp)
+	if err != nil {
+		msg := fmt.Sprintf("cannot mark messages from [%s] to [%s] as read for user [%s]", params.Contact, params.Owner, params.UserID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	result := &ConversationReadResult{Owner: params.Owner, Contact: params.Contact, MessageIDs: messageIDs, ReadAt: timestamp}
+	if len(messageIDs) == 0 {
+		ctxLogger.Info(fmt.Sprintf("no unread messages from [%s] to [%s] for user [%s]", params.Contact, params.Owner, params.UserID))
+		return result, nil
+	}
+
+	event, err := service.createEvent(events.EventTypeMessageRead, params.Source, &events.MessageReadPayload{
+		MessageIDs: messageIDs,
+		Owner:      params.Owner,
+		Contact:    params.Contact,
+		UserID:     params.UserID,
+		Timestamp:  timestamp,
+	})
+	if err != nil {
+		msg := fmt.Sprintf("cannot create event [%s] for conversation between [%s] and [%s]", events.EventTypeMessageRead, params.Owner, params.Contact)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if err = service.eventDispatcher.Dispatch(ctx, event); err != nil {
+		msg := fmt.Sprintf("cannot dispatch event type [%s] and id [%s]", event.Type(), event.ID())
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("marked [%d] messages from [%s] to [%s] as read for user [%s]", len(messageIDs), params.Contact, params.Owner, params.UserID))
+	return result, nil
+}
@@ -0,0 +1,187 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/events"
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/google/uuid"
+	"github.com/nyaruka/phonenumbers"
+	"github.com/palantir/stacktrace"
+)
+
+// ErrCodeInsufficientCredits is returned when a prepaid user does not have enough credits to send a message
+const ErrCodeInsufficientCredits = stacktrace.ErrorCode(2026)
+
+// CreditRates is the number of credits debited per SMS segment keyed by the region code of the destination e.g. NG.
+// The rate of the "default" key is used for destinations without a rate.
+type CreditRates map[string]int64
+
+// Rate returns the credits per SMS segment to a phone number
+func (rates CreditRates) Rate(phoneNumber string) int64 {
+	if number, err := phonenumbers.Parse(phoneNumber, phonenumbers.UNKNOWN_REGION); err == nil {
+		if rate, ok := rates[phonenumbers.GetRegionCodeForNumber(number)]; ok {
+			return rate
+		}
+	}
+	if rate, ok := rates["default"]; ok {
+		return rate
+	}
+	return 1
+}
+
+// CreditService manages the prepaid credit ledger of users
+type CreditService struct {
+	service
+	logger         telemetry.Logger
+	tracer         telemetry.Tracer
+	repository     repositories.CreditRepository
+	userRepository repositories.UserRepository
+	rates          CreditRates
+}
+
+// NewCreditService creates a new CreditService
+func NewCreditService(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	repository repositories.CreditRepository,
+	userRepository repositories.UserRepository,
+	rates CreditRates,
+) (s *CreditService) {
+	return &CreditService{
+		logger:         logger.WithService(fmt.Sprintf("%T", s)),
+		tracer:         tracer,
+		repository:     repository,
+		userRepository: userRepository,
+		rates:          rates,
+	}
+}
+
+// Balance loads the entities.CreditBalance of a user
+func (service *CreditService) Balance(ctx context.Context, userID entities.UserID) (*entities.CreditBalance, error) {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	balance, err := service.repository.LoadBalance(ctx, userID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot load credit balance for user [%s]", userID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
+	}
+
+	return balance, nil
+}
+
+// Index fetches the credit ledger of a user
+func (service *CreditService) Index(ctx context.Context, userID entities.UserID, params repositories.CreditTransactionIndexParams) ([]*entities.CreditTransaction, error) {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	transactions, err := service.repository.Index(ctx, userID, params)
+	if err != nil {
+		msg := fmt.Sprintf("could not fetch credit transactions with params [%+#v]", params)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return transactions, nil
+}
+
+// CreditTopUpParams are parameters for adding credits to the balance of a user
+type CreditTopUpParams struct {
+	UserID      entities.UserID
+	Amount      int64
+	Description string
+	CreatedBy   entities.UserID
+}
+
+// TopUp adds credits to the balance of a user, a negative amount is recorded as an adjustment
+func (service *CreditService) TopUp(ctx context.Context, params *CreditTopUpParams) (*entities.CreditTransaction, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	if _, err := service.userRepository.Load(ctx, params.UserID); err != nil {
+		msg := fmt.Sprintf("cannot load user with ID [%s]", params.UserID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
+	}
+
+	transactionType := entities.CreditTransactionTypeTopUp
+	if params.Amount < 0 {
+		transactionType = entities.CreditTransactionTypeAdjustment
+	}
+
+	transaction := &entities.CreditTransaction{
+		ID:          uuid.New(),
+		UserID:      params.UserID,
+		Type:        transactionType,
+		Amount:      params.Amount,
+		Description: params.Description,
+		CreatedBy:   &params.CreatedBy,
+		CreatedAt:   time.Now().UTC(),
+	}
+
+	if _, err := service.repository.Apply(ctx, transaction); err != nil {
+		msg := fmt.Sprintf("cannot apply [%s] of [%d] credits for user [%s]", transaction.Type, transaction.Amount, transaction.UserID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("[%s] of [%d] credits applied for user [%s] by [%s] with new balance [%d]", transaction.Type, transaction.Amount, transaction.UserID, params.CreatedBy, transaction.Balance))
+	return transaction, nil
+}
+
+// DebitMessage debits the credits of a message from the balance of a prepaid user before it is dispatched to the phone.
+// The message is debited once even when it is dispatched multiple times and nothing is debited for users without a balance.
+// It returns ErrCodeInsufficientCredits when the balance is less than the credits of the message.
+func (service *CreditService) DebitMessage(ctx context.Context, payload *events.MessageAPISentPayload) error {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	_, err := service.repository.LoadBalance(ctx, payload.UserID)
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		return nil
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot load credit balance for user [%s]", payload.UserID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	segments := SMSSegments(payload.Content)
+	transaction := &entities.CreditTransaction{
+		ID:          uuid.New(),
+		UserID:      payload.UserID,
+		Type:        entities.CreditTransactionTypeMessageSent,
+		Amount:      -int64(segments) * service.rates.Rate(payload.Contact),
+		MessageID:   &payload.MessageID,
+		Description: fmt.Sprintf("%d %s to %s", segments, service.pluralize("segment", segments), payload.Contact),
+		CreatedAt:   time.Now().UTC(),
+	}
+
+	applied, err := service.repository.Apply(ctx, transaction)
+	if stacktrace.GetCode(err) == repositories.ErrCodeInsufficientCredits {
+		msg := fmt.Sprintf("user [%s] does not have [%d] credits to send message [%s]", payload.UserID, -transaction.Amount, payload.MessageID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, ErrCodeInsufficientCredits, msg))
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot debit [%d] credits for message [%s] of user [%s]", -transaction.Amount, payload.MessageID, payload.UserID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if !applied {
+		ctxLogger.Info(fmt.Sprintf("message [%s] of user [%s] was already debited", payload.MessageID, payload.UserID))
+		return nil
+	}
+
+	ctxLogger.Info(fmt.Sprintf("debited [%d] credits for message [%s] of user [%s] with new balance [%d]", -transaction.Amount, payload.MessageID, payload.UserID, transaction.Balance))
+	return nil
+}
+
+func (service *CreditService) pluralize(value string, count int) string {
+	if count == 1 {
+		return value
+	}
+	return value + "s"
+}
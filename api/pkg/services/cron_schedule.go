@@ -0,0 +1,148 @@
+package services
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/palantir/stacktrace"
+)
+
+// cronScheduleHorizon is how far ahead CronSchedule.Next looks for a matching time
+const cronScheduleHorizon = 5 * 366 * 24 * time.Hour
+
+// CronSchedule is a parsed 5 field cron expression "minute hour day-of-month month day-of-week".
+// Each field supports "*", lists "1,2", ranges "1-5" and steps "*/15" or "1-30/5".
+type CronSchedule struct {
+	minutes    uint64
+	hours      uint64
+	days       uint64
+	months     uint64
+	weekdays   uint64
+	anyDay     bool
+	anyWeekday bool
+}
+
+// ParseCronSchedule parses a 5 field cron expression
+func ParseCronSchedule(expression string) (*CronSchedule, error) {
+	fields := strings.Fields(expression)
+	if len(fields) != 5 {
+		return nil, stacktrace.NewError(fmt.Sprintf("cron expression [%s] must have 5 fields but it has [%d]", expression, len(fields)))
+	}
+
+	bounds := [][2]int{{0, 59}, {0, 23}, {1, 31}, {1, 12}, {0, 7}}
+	values := make([]uint64, len(fields))
+	for index, field := range fields {
+		value, err := parseCronField(field, bounds[index][0], bounds[index][1])
+		if err != nil {
+			return nil, stacktrace.Propagate(err, fmt.Sprintf("cannot parse field [%d] of cron expression [%s]", index+1, expression))
+		}
+		values[index] = value
+	}
+
+	// 7 is an alias of sunday
+	if values[4]&(1<<7) != 0 {
+		values[4] = values[4]&^(1<<7) | 1
+	}
+
+	return &CronSchedule{
+		minutes:    values[0],
+		hours:      values[1],
+		days:       values[2],
+		months:     values[3],
+		weekdays:   values[4],
+		anyDay:     strings.HasPrefix(fields[2], "*"),
+		anyWeekday: strings.HasPrefix(fields[4], "*"),
+	}, nil
+}
+
+// Next returns the first time after the timestamp which matches the schedule in the location of the timestamp
+func (schedule *CronSchedule) Next(after time.Time) (time.Time, error) {
+	t := after.Truncate(time.Minute).Add(time.Minute)
+	horizon := after.Add(cronScheduleHorizon)
+
+	for t.Before(horizon) {
+		if !schedule.has(schedule.months, int(t.Month())) {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if !schedule.matchesDay(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if !schedule.has(schedule.hours, t.Hour()) {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+			continue
+		}
+		if !schedule.has(schedule.minutes, t.Minute()) {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t, nil
+	}
+
+	return time.Time{}, stacktrace.NewError(fmt.Sprintf("cron schedule does not match any time between [%s] and [%s]", after, horizon))
+}
+
+// matchesDay follows the cron convention where a time matches either restricted day field when both are restricted
+func (schedule *CronSchedule) matchesDay(t time.Time) bool {
+	day := schedule.has(schedule.days, t.Day())
+	weekday := schedule.has(schedule.weekdays, int(t.Weekday()))
+	if !schedule.anyDay && !schedule.anyWeekday {
+		return day || weekday
+	}
+	return day && weekday
+}
+
+func (schedule *CronSchedule) has(field uint64, value int) bool {
+	return field&(1<<uint(value)) != 0
+}
+
+func parseCronField(field string, min int, max int) (uint64, error) {
+	var result uint64
+	for _, part := range strings.Split(field, ",") {
+		rangePart, stepPart, hasStep := strings.Cut(part, "/")
+
+		step := 1
+		if hasStep {
+			value, err := strconv.Atoi(stepPart)
+			if err != nil || value <= 0 {
+				return 0, stacktrace.NewError(fmt.Sprintf("invalid step [%s] in [%s]", stepPart, part))
+			}
+			step = value
+		}
+
+		start, end := min, max
+		switch {
+		case rangePart == "*":
+		case strings.Contains(rangePart, "-"):
+			from, to, _ := strings.Cut(rangePart, "-")
+			var err error
+			if start, err = strconv.Atoi(from); err != nil {
+				return 0, stacktrace.Propagate(err, fmt.Sprintf("invalid range [%s] in [%s]", rangePart, part))
+			}
+			if end, err = strconv.Atoi(to); err != nil {
+				return 0, stacktrace.Propagate(err, fmt.Sprintf("invalid range [%s] in [%s]", rangePart, part))
+			}
+		default:
+			value, err := strconv.Atoi(rangePart)
+			if err != nil {
+				return 0, stacktrace.Propagate(err, fmt.Sprintf("invalid value [%s] in [%s]", rangePart, part))
+			}
+			start = value
+			if !hasStep {
+				end = value
+			}
+		}
+
+		if start < min || end > max || start > end {
+			return 0, stacktrace.NewError(fmt.Sprintf("[%s] is outside the range [%d-%d]", part, min, max))
+		}
+
+		for value := start; value <= end; value += step {
+			result |= 1 << uint(value)
+		}
+	}
+	return result, nil
+}
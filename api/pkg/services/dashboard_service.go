@@ -0,0 +1,165 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/palantir/stacktrace"
+)
+
+const (
+	// dashboardRecentFailuresLimit is the number of failed messages displayed on the dashboard
+	dashboardRecentFailuresLimit = 5
+
+	// dashboardPhonesLimit is the maximum number of phones displayed on the dashboard
+	dashboardPhonesLimit = 100
+)
+
+// DashboardService aggregates the summary of an account for the web dashboard
+type DashboardService struct {
+	service
+	logger              telemetry.Logger
+	tracer              telemetry.Tracer
+	analyticsRepository repositories.AnalyticsRepository
+	phoneRepository     repositories.PhoneRepository
+	heartbeatRepository repositories.HeartbeatRepository
+	billingService      *BillingService
+}
+
+// NewDashboardService creates a new DashboardService
+func NewDashboardService(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	analyticsRepository repositories.AnalyticsRepository,
+	phoneRepository repositories.PhoneRepository,
+	heartbeatRepository repositories.HeartbeatRepository,
+	billingService *BillingService,
+) (s *DashboardService) {
+	return &DashboardService{
+		logger:              logger.WithService(fmt.Sprintf("%T", s)),
+		tracer:              tracer,
+		analyticsRepository: analyticsRepository,
+		phoneRepository:     phoneRepository,
+		heartbeatRepository: heartbeatRepository,
+		billingService:      billingService,
+	}
+}
+
+// Summary loads the sections of the entities.Dashboard of a user concurrently
+func (service *DashboardService) Summary(ctx context.Context, userID entities.UserID) (*entities.Dashboard, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	now := time.Now().UTC()
+	dashboard := &entities.Dashboard{GeneratedAt: now}
+
+	sections := map[string]func(ctx context.Context) error{
+		"today": func(ctx context.Context) error {
+			counts, err := service.analyticsRepository.MessageCounts(ctx, userID, now.Truncate(24*time.Hour))
+			if err == nil {
+				dashboard.Today = *counts
+			}
+			return err
+		},
+		"queue_depth": func(ctx context.Context) error {
+			queue, err := service.analyticsRepository.QueueDepth(ctx, userID)
+			if err == nil {
+				dashboard.QueueDepth = *queue
+			}
+			return err
+		},
+		"recent_failures": func(ctx context.Context) (err error) {
+			dashboard.RecentFailures, err = service.analyticsRepository.RecentFailures(ctx, userID, dashboardRecentFailuresLimit)
+			return err
+		},
+		"phones": func(ctx context.Context) (err error) {
+			dashboard.Phones, err = service.phones(ctx, userID, now)
+			return err
+		},
+		"usage": func(ctx context.Context) (err error) {
+			dashboard.Usage, err = service.usage(ctx, userID)
+			return err
+		},
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, len(sections))
+	for name, section := range sections {
+		wg.Add(1)
+		go func(name string, section func(ctx context.Context) error) {
+			defer wg.Done()
+			if err := section(ctx); err != nil {
+				errs <- stacktrace.Propagate(err, fmt.Sprintf("cannot load dashboard section [%s] for user [%s]", name, userID))
+			}
+		}(name, section)
+	}
+	wg.Wait()
+	close(errs)
+
+	if err := <-errs; err != nil {
+		return nil, service.tracer.WrapErrorSpan(span, err)
+	}
+
+	ctxLogger.Info(fmt.Sprintf("loaded dashboard for user [%s] with [%d] phones", userID, len(dashboard.Phones)))
+	return dashboard, nil
+}
+
+// phones derives the status of each phone of a user from its last heartbeat
+func (service *DashboardService) phones(ctx context.Context, userID entities.UserID, now time.Time) ([]*entities.DashboardPhone, error) {
+	phones, err := service.phoneRepository.Index(ctx, userID, repositories.IndexParams{Limit: dashboardPhonesLimit})
+	if err != nil {
+		return nil, stacktrace.Propagate(err, fmt.Sprintf("cannot fetch phones of user [%s]", userID))
+	}
+
+	result := make([]*entities.DashboardPhone, 0, len(*phones))
+	for _, phone := range *phones {
+		item := &entities.DashboardPhone{
+			ID:          phone.ID,
+			PhoneNumber: phone.PhoneNumber,
+			Status:      entities.DashboardPhoneStatusUnknown,
+		}
+
+		heartbeat, err := service.heartbeatRepository.Last(ctx, userID, phone.PhoneNumber)
+		if err != nil && stacktrace.GetCode(err) != repositories.ErrCodeNotFound {
+			return nil, stacktrace.Propagate(err, fmt.Sprintf("cannot load last heartbeat of phone [%s]", phone.ID))
+		}
+
+		if err == nil {
+			item.LastHeartbeatAt = &heartbeat.Timestamp
+			item.Status = entities.DashboardPhoneStatusOffline
+			if now.Sub(heartbeat.Timestamp) <= heartbeatCheckInterval {
+				item.Status = entities.DashboardPhoneStatusOnline
+			}
+		}
+		result = append(result, item)
+	}
+	return result, nil
+}
+
+// usage compares the current entities.BillingUsage of a user with the limit of the plan
+func (service *DashboardService) usage(ctx context.Context, userID entities.UserID) (*entities.DashboardUsage, error) {
+	plan, limit, err := service.billingService.GetPlan(ctx, userID)
+	if err != nil {
+		return nil, stacktrace.Propagate(err, fmt.Sprintf("cannot load plan of user [%s]", userID))
+	}
+
+	usage, err := service.billingService.GetCurrentUsage(ctx, userID)
+	if err != nil {
+		return nil, stacktrace.Propagate(err, fmt.Sprintf("cannot load billing usage of user [%s]", userID))
+	}
+
+	return &entities.DashboardUsage{
+		Plan:             plan,
+		Limit:            limit,
+		SentMessages:     usage.SentMessages,
+		ReceivedMessages: usage.ReceivedMessages,
+		TotalMessages:    usage.TotalMessages(),
+		StartTimestamp:   usage.StartTimestamp,
+		EndTimestamp:     usage.EndTimestamp,
+	}, nil
+}
@@ -3,6 +3,7 @@ package services
 import (
 	"context"
 	"fmt"
+	"strconv"
 	"sync"
 	"time"
 
@@ -233,6 +234,63 @@ func (service *DiscordService) HandleMessageReceived(ctx context.Context, userID
 	return nil
 }
 
+// HandleWebhookDeliveryFailed notifies the discord channels of a user when an event could not be sent to a webhook
+func (service *DiscordService) HandleWebhookDeliveryFailed(ctx context.Context, payload *events.WebhookDeliveryFailedPayload) error {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	discordIntegrations, err := service.repository.FetchHavingIncomingChannel(ctx, payload.UserID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot load discord integrations for user with ID [%s]", payload.UserID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
+	}
+
+	request := service.createWebhookDeliveryFailedMessage(payload)
+	for _, discord := range discordIntegrations {
+		if _, _, err = service.client.Channel.CreateMessage(ctx, discord.IncomingChannelID, request); err != nil {
+			msg := fmt.Sprintf("cannot send failure of webhook delivery [%s] to discord channel [%s] for user [%s]", payload.DeliveryID, discord.IncomingChannelID, discord.UserID)
+			ctxLogger.Error(service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg)))
+		}
+	}
+
+	return nil
+}
+
+func (service *DiscordService) createWebhookDeliveryFailedMessage(payload *events.WebhookDeliveryFailedPayload) fiber.Map {
+	status := "no response"
+	if payload.LastStatusCode != 0 {
+		status = strconv.Itoa(payload.LastStatusCode)
+	}
+
+	return fiber.Map{
+		"content": "⚠ webhook delivery failed",
+		"embeds": []fiber.Map{
+			{
+				"fields": []fiber.Map{
+					{
+						"name":   "Event:",
+						"value":  payload.EventType,
+						"inline": true,
+					},
+					{
+						"name":   "Status:",
+						"value":  status,
+						"inline": true,
+					},
+					{
+						"name":  "Webhook:",
+						"value": payload.URL,
+					},
+					{
+						"name":  "Redeliver:",
+						"value": payload.RedeliverURL,
+					},
+				},
+			},
+		},
+	}
+}
+
 func (service *DiscordService) sendMessage(ctx context.Context, event cloudevents.Event, discord *entities.Discord) {
 	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
 	defer span.End()
@@ -21,6 +21,7 @@ type EventDispatcher struct {
 	tracer      telemetry.Tracer
 	repository  repositories.EventRepository
 	listeners   map[string][]events.EventListener
+	sinks       []events.EventListener
 	queue       PushQueue
 	queueConfig PushQueueConfig
 }
@@ -103,6 +104,11 @@ func (dispatcher *EventDispatcher) Subscribe(eventType string, listener events.E
 	dispatcher.listeners[eventType] = append(dispatcher.listeners[eventType], listener)
 }
 
+// SubscribeAll subscribes a listener to every event type e.g. to mirror events to an external system
+func (dispatcher *EventDispatcher) SubscribeAll(listener events.EventListener) {
+	dispatcher.sinks = append(dispatcher.sinks, listener)
+}
+
 // Publish an event to subscribers
 func (dispatcher *EventDispatcher) Publish(ctx context.Context, event cloudevents.Event) {
 	ctx, span := dispatcher.tracer.Start(ctx)
@@ -110,8 +116,8 @@ func (dispatcher *EventDispatcher) Publish(ctx context.Context, event cloudevent
 
 	ctxLogger := dispatcher.tracer.CtxLogger(dispatcher.logger, span)
 
-	subscribers, ok := dispatcher.listeners[event.Type()]
-	if !ok {
+	subscribers := append(append([]events.EventListener{}, dispatcher.listeners[event.Type()]...), dispatcher.sinks...)
+	if len(subscribers) == 0 {
 		ctxLogger.Info(fmt.Sprintf("no listener is configured for event type [%s]", event.Type()))
 		return
 	}
@@ -144,5 +150,6 @@ func (dispatcher *EventDispatcher) createCloudTask(event cloudevents.Event) (*Pu
 		Headers: map[string]string{
 			"x-api-key": dispatcher.queueConfig.UserAPIKey,
 		},
+		RoutingKey: event.Type(),
 	}, nil
 }
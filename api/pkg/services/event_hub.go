@@ -0,0 +1,122 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/palantir/stacktrace"
+)
+
+// eventHubBufferSize is the number of events buffered for a subscription before new events are dropped
+const eventHubBufferSize = 100
+
+// EventSubscription receives the events of a user which are published to the EventHub
+type EventSubscription struct {
+	userID     entities.UserID
+	eventTypes map[string]bool
+	events     chan cloudevents.Event
+}
+
+// Events returns the channel of the events of the subscription, it is closed when the subscription is removed
+func (subscription *EventSubscription) Events() <-chan cloudevents.Event {
+	return subscription.events
+}
+
+// accepts checks if the subscription receives an event type, a subscription without event types receives every event
+func (subscription *EventSubscription) accepts(eventType string) bool {
+	return len(subscription.eventTypes) == 0 || subscription.eventTypes[eventType]
+}
+
+// EventHub fans out the events which are handled by this instance to the streaming connections of the user e.g. gRPC streams.
+// Events are dropped for a subscription which does not keep up instead of blocking the event dispatcher.
+type EventHub struct {
+	logger        telemetry.Logger
+	tracer        telemetry.Tracer
+	mutex         sync.RWMutex
+	subscriptions map[entities.UserID]map[*EventSubscription]struct{}
+}
+
+// NewEventHub creates a new EventHub
+func NewEventHub(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+) (hub *EventHub) {
+	return &EventHub{
+		logger:        logger.WithService(fmt.Sprintf("%T", hub)),
+		tracer:        tracer,
+		subscriptions: map[entities.UserID]map[*EventSubscription]struct{}{},
+	}
+}
+
+// Subscribe to the events of a user, every event type is received when eventTypes is empty
+func (hub *EventHub) Subscribe(userID entities.UserID, eventTypes ...string) *EventSubscription {
+	subscription := &EventSubscription{
+		userID:     userID,
+		eventTypes: map[string]bool{},
+		events:     make(chan cloudevents.Event, eventHubBufferSize),
+	}
+	for _, eventType := range eventTypes {
+		subscription.eventTypes[eventType] = true
+	}
+
+	hub.mutex.Lock()
+	defer hub.mutex.Unlock()
+
+	if _, ok := hub.subscriptions[userID]; !ok {
+		hub.subscriptions[userID] = map[*EventSubscription]struct{}{}
+	}
+	hub.subscriptions[userID][subscription] = struct{}{}
+
+	return subscription
+}
+
+// Unsubscribe removes a subscription and closes its channel
+func (hub *EventHub) Unsubscribe(subscription *EventSubscription) {
+	hub.mutex.Lock()
+	defer hub.mutex.Unlock()
+
+	if _, ok := hub.subscriptions[subscription.userID][subscription]; !ok {
+		return
+	}
+
+	delete(hub.subscriptions[subscription.userID], subscription)
+	if len(hub.subscriptions[subscription.userID]) == 0 {
+		delete(hub.subscriptions, subscription.userID)
+	}
+	close(subscription.events)
+}
+
+// Publish an event to the subscriptions of the user in the "user_id" of the event data
+func (hub *EventHub) Publish(ctx context.Context, event cloudevents.Event) error {
+	ctx, span, ctxLogger := hub.tracer.StartWithLogger(ctx, hub.logger)
+	defer span.End()
+
+	var payload struct {
+		UserID entities.UserID `json:"user_id"`
+	}
+	if err := event.DataAs(&payload); err != nil {
+		msg := fmt.Sprintf("cannot decode [%s] into [%T]", event.Data(), payload)
+		return hub.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	hub.mutex.RLock()
+	defer hub.mutex.RUnlock()
+
+	for subscription := range hub.subscriptions[payload.UserID] {
+		if !subscription.accepts(event.Type()) {
+			continue
+		}
+
+		select {
+		case subscription.events <- event:
+		default:
+			ctxLogger.Warn(stacktrace.NewError(fmt.Sprintf("dropped event [%s] with ID [%s] for user [%s] because the subscription is full", event.Type(), event.ID(), payload.UserID)))
+		}
+	}
+
+	return nil
+}
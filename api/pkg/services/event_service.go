@@ -0,0 +1,145 @@
+package services
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/google/uuid"
+	"github.com/palantir/stacktrace"
+)
+
+// EventService fetches the cloudevents.Event of a user
+type EventService struct {
+	service
+	logger     telemetry.Logger
+	tracer     telemetry.Tracer
+	repository repositories.EventRepository
+	dispatcher *EventDispatcher
+}
+
+// NewEventService creates a new EventService
+func NewEventService(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	repository repositories.EventRepository,
+	dispatcher *EventDispatcher,
+) (s *EventService) {
+	return &EventService{
+		logger:     logger.WithService(fmt.Sprintf("%T", s)),
+		tracer:     tracer,
+		repository: repository,
+		dispatcher: dispatcher,
+	}
+}
+
+// EventPage is a page of cloudevents.Event
+type EventPage struct {
+	Events []cloudevents.Event `json:"events"`
+
+	// NextCursor is used to fetch the next page, it is null when there are no more events
+	NextCursor *string `json:"next_cursor" example:"MTY1NDQzMjM2OTUyNzk3NjAwMDozMjM0M2ExOS1kYTVlLTRiMWItYTc2Ny0zMjk4YTczNzAzY2I"`
+}
+
+// Index fetches a page of the cloudevents.Event of a user from the newest to the oldest
+func (service *EventService) Index(ctx context.Context, userID entities.UserID, params repositories.EventIndexParams) (*EventPage, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	events, err := service.repository.Index(ctx, userID, params)
+	if err != nil {
+		msg := fmt.Sprintf("could not fetch events for user [%s] with params [%+#v]", userID, params)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	page := &EventPage{Events: events}
+	if len(events) == params.Limit && len(events) > 0 {
+		last := events[len(events)-1]
+		cursor := EncodeEventCursor(repositories.EventCursor{Time: last.Time(), ID: uuid.MustParse(last.ID())})
+		page.NextCursor = &cursor
+	}
+
+	ctxLogger.Info(fmt.Sprintf("fetched [%d] events for user [%s]", len(events), userID))
+	return page, nil
+}
+
+// Replay publishes a stored cloudevents.Event of a user to the listeners again.
+// Listeners which keep an entities.EventListenerLog skip the event when they already handled it so only the listeners which failed do the work again.
+func (service *EventService) Replay(ctx context.Context, userID entities.UserID, eventID uuid.UUID) (*cloudevents.Event, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	event, err := service.repository.Load(ctx, userID, eventID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot load event with ID [%s] for user [%s]", eventID, userID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
+	}
+
+	service.dispatcher.Publish(ctx, *event)
+
+	ctxLogger.Info(fmt.Sprintf("replayed event [%s] with type [%s] for user [%s]", event.ID(), event.Type(), userID))
+	return event, nil
+}
+
+// Missed fetches the cloudevents.Event of a user which happened after the event with the given ID from the oldest to the newest.
+// It is used by clients which reconnect to a stream to fetch the events they missed while they were disconnected.
+func (service *EventService) Missed(ctx context.Context, userID entities.UserID, eventID uuid.UUID, types []string, limit int) ([]cloudevents.Event, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	event, err := service.repository.Load(ctx, userID, eventID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot load event with ID [%s] for user [%s]", eventID, userID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
+	}
+
+	events, err := service.repository.Index(ctx, userID, repositories.EventIndexParams{
+		Types: types,
+		After: &repositories.EventCursor{Time: event.Time(), ID: eventID},
+		Limit: limit,
+	})
+	if err != nil {
+		msg := fmt.Sprintf("cannot fetch events after [%s] for user [%s]", eventID, userID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("fetched [%d] events after [%s] for user [%s]", len(events), eventID, userID))
+	return events, nil
+}
+
+// EncodeEventCursor encodes a repositories.EventCursor into an opaque string
+func EncodeEventCursor(cursor repositories.EventCursor) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf("%d:%s", cursor.Time.UnixNano(), cursor.ID)))
+}
+
+// DecodeEventCursor decodes a cursor which was encoded with EncodeEventCursor
+func DecodeEventCursor(value string) (*repositories.EventCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, stacktrace.Propagate(err, fmt.Sprintf("cannot decode cursor [%s]", value))
+	}
+
+	parts := strings.SplitN(string(data), ":", 2)
+	if len(parts) != 2 {
+		return nil, stacktrace.NewError(fmt.Sprintf("cursor [%s] is not valid", value))
+	}
+
+	nanoseconds, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return nil, stacktrace.Propagate(err, fmt.Sprintf("cannot parse time of cursor [%s]", value))
+	}
+
+	id, err := uuid.Parse(parts[1])
+	if err != nil {
+		return nil, stacktrace.Propagate(err, fmt.Sprintf("cannot parse ID of cursor [%s]", value))
+	}
+
+	return &repositories.EventCursor{Time: time.Unix(0, nanoseconds).UTC(), ID: id}, nil
+}
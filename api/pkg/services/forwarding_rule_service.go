@@ -0,0 +1,206 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/emails"
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/events"
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/google/uuid"
+	"github.com/palantir/stacktrace"
+)
+
+// ForwardingRuleService manages the entities.ForwardingRule of a user and forwards received messages by email
+type ForwardingRuleService struct {
+	service
+	logger         telemetry.Logger
+	tracer         telemetry.Tracer
+	repository     repositories.ForwardingRuleRepository
+	userRepository repositories.UserRepository
+	mailer         emails.Mailer
+	emailFactory   emails.UserEmailFactory
+}
+
+// NewForwardingRuleService creates a new ForwardingRuleService
+func NewForwardingRuleService(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	repository repositories.ForwardingRuleRepository,
+	userRepository repositories.UserRepository,
+	mailer emails.Mailer,
+	emailFactory emails.UserEmailFactory,
+) (s *ForwardingRuleService) {
+	return &ForwardingRuleService{
+		logger:         logger.WithService(fmt.Sprintf("%T", s)),
+		tracer:         tracer,
+		repository:     repository,
+		userRepository: userRepository,
+		mailer:         mailer,
+		emailFactory:   emailFactory,
+	}
+}
+
+// Index fetches the entities.ForwardingRule of a user
+func (service *ForwardingRuleService) Index(ctx context.Context, userID entities.UserID, params repositories.IndexParams) ([]*entities.ForwardingRule, error) {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	rules, err := service.repository.Index(ctx, userID, params)
+	if err != nil {
+		msg := fmt.Sprintf("could not fetch forwarding rules with params [%+#v]", params)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return rules, nil
+}
+
+// ForwardingRuleStoreParams are parameters for creating a new entities.ForwardingRule
+type ForwardingRuleStoreParams struct {
+	UserID  entities.UserID
+	Name    string
+	Owner   string
+	Contact string
+	Match   entities.RuleMatch
+	Pattern string
+	Emails  []string
+}
+
+// Store a new entities.ForwardingRule
+func (service *ForwardingRuleService) Store(ctx context.Context, params *ForwardingRuleStoreParams) (*entities.ForwardingRule, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	rule := &entities.ForwardingRule{
+		ID:        uuid.New(),
+		UserID:    params.UserID,
+		Name:      params.Name,
+		Owner:     params.Owner,
+		Contact:   params.Contact,
+		Match:     params.Match,
+		Pattern:   params.Pattern,
+		Emails:    params.Emails,
+		Enabled:   true,
+		CreatedAt: time.Now().UTC(),
+		UpdatedAt: time.Now().UTC(),
+	}
+
+	if err := service.repository.Store(ctx, rule); err != nil {
+		msg := fmt.Sprintf("cannot save forwarding rule with id [%s]", rule.ID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("forwarding rule saved with id [%s] in the [%T]", rule.ID, service.repository))
+	return rule, nil
+}
+
+// ForwardingRuleUpdateParams are parameters for updating an entities.ForwardingRule
+type ForwardingRuleUpdateParams struct {
+	ForwardingRuleStoreParams
+	RuleID  uuid.UUID
+	Enabled bool
+}
+
+// Update an entities.ForwardingRule
+func (service *ForwardingRuleService) Update(ctx context.Context, params *ForwardingRuleUpdateParams) (*entities.ForwardingRule, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	rule, err := service.repository.Load(ctx, params.UserID, params.RuleID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot load forwarding rule with ID [%s] for user [%s]", params.RuleID, params.UserID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
+	}
+
+	rule.Name = params.Name
+	rule.Owner = params.Owner
+	rule.Contact = params.Contact
+	rule.Match = params.Match
+	rule.Pattern = params.Pattern
+	rule.Emails = params.Emails
+	rule.Enabled = params.Enabled
+	rule.UpdatedAt = time.Now().UTC()
+
+	if err = service.repository.Update(ctx, rule); err != nil {
+		msg := fmt.Sprintf("cannot update forwarding rule with id [%s]", rule.ID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("forwarding rule updated with id [%s] in the [%T]", rule.ID, service.repository))
+	return rule, nil
+}
+
+// Delete an entities.ForwardingRule
+func (service *ForwardingRuleService) Delete(ctx context.Context, userID entities.UserID, ruleID uuid.UUID) error {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	if _, err := service.repository.Load(ctx, userID, ruleID); err != nil {
+		msg := fmt.Sprintf("cannot load forwarding rule with ID [%s] for user [%s]", ruleID, userID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
+	}
+
+	if err := service.repository.Delete(ctx, userID, ruleID); err != nil {
+		msg := fmt.Sprintf("cannot delete forwarding rule with id [%s] and user id [%s]", ruleID, userID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("deleted forwarding rule with id [%s] and user id [%s]", ruleID, userID))
+	return nil
+}
+
+// HandleReceived emails a received message to the addresses of the matching entities.ForwardingRule of the user.
+// An address which is on multiple matching rules receives the message only once.
+func (service *ForwardingRuleService) HandleReceived(ctx context.Context, payload *events.MessagePhoneReceivedPayload) error {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	rules, err := service.repository.FetchEnabled(ctx, payload.UserID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot fetch forwarding rules of user [%s]", payload.UserID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	recipients := make([]string, 0)
+	seen := map[string]bool{}
+	for _, rule := range rules {
+		if !rule.Matches(payload.Owner, payload.Contact, payload.Content) {
+			continue
+		}
+		for _, email := range rule.Emails {
+			if !seen[email] {
+				seen[email] = true
+				recipients = append(recipients, email)
+			}
+		}
+	}
+
+	if len(recipients) == 0 {
+		return nil
+	}
+
+	user, err := service.userRepository.Load(ctx, payload.UserID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot load user with ID [%s]", payload.UserID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	for _, recipient := range recipients {
+		email, err := service.emailFactory.MessageForwarded(user, recipient, payload.Owner, payload.Contact, payload.SIM, payload.Timestamp, payload.Content)
+		if err != nil {
+			msg := fmt.Sprintf("cannot create forwarding email for message [%s]", payload.MessageID)
+			return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+		}
+
+		if err = service.mailer.Send(ctx, email); err != nil {
+			msg := fmt.Sprintf("cannot forward message [%s] to [%s]", payload.MessageID, recipient)
+			ctxLogger.Error(service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg)))
+		}
+	}
+
+	ctxLogger.Info(fmt.Sprintf("forwarded message [%s] to [%d] email addresses", payload.MessageID, len(recipients)))
+	return nil
+}
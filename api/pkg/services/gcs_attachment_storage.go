@@ -0,0 +1,97 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"cloud.google.com/go/storage"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/palantir/stacktrace"
+)
+
+type gcsAttachmentStorage struct {
+	logger telemetry.Logger
+	tracer telemetry.Tracer
+	bucket *storage.BucketHandle
+	name   string
+}
+
+// NewGCSAttachmentStorage creates an AttachmentStorage which keeps attachments in a Google Cloud Storage bucket.
+// The client must use service account credentials so that download URLs can be signed.
+func NewGCSAttachmentStorage(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	client *storage.Client,
+	bucket string,
+) AttachmentStorage {
+	return &gcsAttachmentStorage{
+		logger: logger.WithService(fmt.Sprintf("%T", &gcsAttachmentStorage{})),
+		tracer: tracer,
+		bucket: client.Bucket(bucket),
+		name:   bucket,
+	}
+}
+
+// Name of the storage
+func (store *gcsAttachmentStorage) Name() string {
+	return "gcs"
+}
+
+// Store the content of an attachment as an object in the bucket
+func (store *gcsAttachmentStorage) Store(ctx context.Context, key string, contentType string, content []byte) error {
+	ctx, span := store.tracer.Start(ctx)
+	defer span.End()
+
+	writer := store.bucket.Object(key).NewWriter(ctx)
+	writer.ContentType = contentType
+
+	if _, err := writer.Write(content); err != nil {
+		_ = writer.Close()
+		msg := fmt.Sprintf("cannot write [%d] bytes of attachment [%s] to bucket [%s]", len(content), key, store.name)
+		return store.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if err := writer.Close(); err != nil {
+		msg := fmt.Sprintf("cannot upload attachment [%s] to bucket [%s]", key, store.name)
+		return store.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+// Load the content of an attachment from the bucket
+func (store *gcsAttachmentStorage) Load(ctx context.Context, key string) ([]byte, error) {
+	ctx, span := store.tracer.Start(ctx)
+	defer span.End()
+
+	reader, err := store.bucket.Object(key).NewReader(ctx)
+	if err != nil {
+		msg := fmt.Sprintf("cannot open attachment [%s] in bucket [%s]", key, store.name)
+		return nil, store.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+	defer func() { _ = reader.Close() }()
+
+	content, err := io.ReadAll(reader)
+	if err != nil {
+		msg := fmt.Sprintf("cannot read attachment [%s] from bucket [%s]", key, store.name)
+		return nil, store.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return content, nil
+}
+
+// SignedURL creates a V4 signed GET URL of the object
+func (store *gcsAttachmentStorage) SignedURL(_ context.Context, key string, expiresAt time.Time) (string, error) {
+	link, err := store.bucket.SignedURL(key, &storage.SignedURLOptions{
+		Scheme:  storage.SigningSchemeV4,
+		Method:  http.MethodGet,
+		Expires: expiresAt,
+	})
+	if err != nil {
+		return "", stacktrace.Propagate(err, fmt.Sprintf("cannot sign URL for attachment [%s] in bucket [%s]", key, store.name))
+	}
+	return link, nil
+}
@@ -29,6 +29,7 @@ type HeartbeatService struct {
 	tracer            telemetry.Tracer
 	repository        repositories.HeartbeatRepository
 	monitorRepository repositories.HeartbeatMonitorRepository
+	phoneService      *PhoneService
 	dispatcher        *EventDispatcher
 }
 
@@ -38,6 +39,7 @@ func NewHeartbeatService(
 	tracer telemetry.Tracer,
 	repository repositories.HeartbeatRepository,
 	monitorRepository repositories.HeartbeatMonitorRepository,
+	phoneService *PhoneService,
 	dispatcher *EventDispatcher,
 ) (s *HeartbeatService) {
 	return &HeartbeatService{
@@ -45,6 +47,7 @@ func NewHeartbeatService(
 		tracer:            tracer,
 		repository:        repository,
 		monitorRepository: monitorRepository,
+		phoneService:      phoneService,
 		dispatcher:        dispatcher,
 	}
 }
@@ -68,9 +71,10 @@ func (service *HeartbeatService) Index(ctx context.Context, userID entities.User
 
 // HeartbeatStoreParams are parameters for creating a new entities.Heartbeat
 type HeartbeatStoreParams struct {
-	Owner     string
-	Timestamp time.Time
-	UserID    entities.UserID
+	Owner           string
+	Timestamp       time.Time
+	DeviceTimestamp *time.Time
+	UserID          entities.UserID
 }
 
 // Store a new entities.Heartbeat
@@ -93,6 +97,20 @@ func (service *HeartbeatService) Store(ctx context.Context, params HeartbeatStor
 	}
 
 	ctxLogger.Info(fmt.Sprintf("heartbeat saved with id [%s] in the userRepository", heartbeat.ID))
+
+	if params.DeviceTimestamp != nil {
+		syncParams := &PhoneSyncClockParams{
+			UserID:     params.UserID,
+			Owner:      params.Owner,
+			Timestamp:  *params.DeviceTimestamp,
+			ReceivedAt: params.Timestamp,
+		}
+		if _, err := service.phoneService.SyncClock(ctx, syncParams); err != nil {
+			msg := fmt.Sprintf("cannot sync clock of phone with owner [%s] for user [%s]", params.Owner, params.UserID)
+			ctxLogger.Error(stacktrace.Propagate(err, msg))
+		}
+	}
+
 	return heartbeat, nil
 }
 
@@ -0,0 +1,75 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/carlmjohnson/requests"
+	"github.com/palantir/stacktrace"
+)
+
+type httpAttachmentScanner struct {
+	logger   telemetry.Logger
+	tracer   telemetry.Tracer
+	client   *http.Client
+	endpoint string
+	apiKey   string
+}
+
+// httpAttachmentScanResponse is the response expected from the external scanning API
+type httpAttachmentScanResponse struct {
+	Infected  bool   `json:"infected"`
+	Signature string `json:"signature"`
+}
+
+// NewHTTPAttachmentScanner creates an AttachmentScanner which uploads attachments to an external scanning API
+func NewHTTPAttachmentScanner(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	client *http.Client,
+	endpoint string,
+	apiKey string,
+) AttachmentScanner {
+	return &httpAttachmentScanner{
+		logger:   logger.WithService(fmt.Sprintf("%T", &httpAttachmentScanner{})),
+		tracer:   tracer,
+		client:   client,
+		endpoint: endpoint,
+		apiKey:   apiKey,
+	}
+}
+
+// Name of the scanner
+func (scanner *httpAttachmentScanner) Name() string {
+	return "http"
+}
+
+// Scan the content of an attachment
+func (scanner *httpAttachmentScanner) Scan(ctx context.Context, name string, content []byte) (*AttachmentScanResult, error) {
+	ctx, span := scanner.tracer.Start(ctx)
+	defer span.End()
+
+	response := new(httpAttachmentScanResponse)
+	err := requests.URL(scanner.endpoint).
+		Client(scanner.client).
+		Bearer(scanner.apiKey).
+		Header("X-File-Name", name).
+		ContentType("application/octet-stream").
+		BodyReader(bytes.NewReader(content)).
+		ToJSON(response).
+		Fetch(ctx)
+	if err != nil {
+		msg := fmt.Sprintf("cannot scan attachment [%s] using [%s]", name, scanner.endpoint)
+		return nil, scanner.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if response.Infected {
+		return &AttachmentScanResult{Status: entities.AttachmentScanStatusQuarantined, Signature: response.Signature}, nil
+	}
+
+	return &AttachmentScanResult{Status: entities.AttachmentScanStatusClean}, nil
+}
@@ -0,0 +1,64 @@
+package services
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"fmt"
+	"net/url"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+)
+
+// InstanceIdentityService returns the identity which receivers use to verify the requests sent by an instance
+type InstanceIdentityService struct {
+	service
+	logger      telemetry.Logger
+	tracer      telemetry.Tracer
+	signingKey  ed25519.PrivateKey
+	egressIPs   []string
+	egressProxy *url.URL
+}
+
+// NewInstanceIdentityService creates a new InstanceIdentityService
+func NewInstanceIdentityService(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	signingKey ed25519.PrivateKey,
+	egressIPs []string,
+	egressProxy *url.URL,
+) (s *InstanceIdentityService) {
+	return &InstanceIdentityService{
+		logger:      logger.WithService(fmt.Sprintf("%T", s)),
+		tracer:      tracer,
+		signingKey:  signingKey,
+		egressIPs:   egressIPs,
+		egressProxy: egressProxy,
+	}
+}
+
+// Identity returns the entities.InstanceIdentity of the instance, the credentials of the egress proxy are never returned
+func (service *InstanceIdentityService) Identity(ctx context.Context) *entities.InstanceIdentity {
+	_, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	identity := &entities.InstanceIdentity{EgressIPs: append(make([]string, 0, len(service.egressIPs)), service.egressIPs...)}
+
+	if service.signingKey != nil {
+		publicKey := service.signingKey.Public().(ed25519.PublicKey)
+		identity.WebhookSignatureAlgorithm = service.stringPointer("ed25519")
+		identity.WebhookPublicKey = service.stringPointer(base64.StdEncoding.EncodeToString(publicKey))
+		identity.WebhookPublicKeyID = service.stringPointer(WebhookPublicKeyID(publicKey))
+	}
+
+	if service.egressProxy != nil {
+		identity.EgressProxyHost = service.stringPointer(service.egressProxy.Host)
+	}
+
+	return identity
+}
+
+func (service *InstanceIdentityService) stringPointer(value string) *string {
+	return &value
+}
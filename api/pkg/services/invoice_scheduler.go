@@ -0,0 +1,68 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/jinzhu/now"
+	"github.com/palantir/stacktrace"
+)
+
+const invoiceSchedulerBatchSize = 100
+
+// InvoiceScheduler generates the invoices of the previous month for all users once a new month starts
+type InvoiceScheduler struct {
+	logger   telemetry.Logger
+	tracer   telemetry.Tracer
+	service  *InvoiceService
+	interval time.Duration
+	// completed is the month for which all the users were processed
+	completed time.Time
+}
+
+// NewInvoiceScheduler creates a new InvoiceScheduler
+func NewInvoiceScheduler(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	service *InvoiceService,
+	interval time.Duration,
+) (s *InvoiceScheduler) {
+	return &InvoiceScheduler{
+		logger:   logger.WithService(fmt.Sprintf("%T", s)),
+		tracer:   tracer,
+		service:  service,
+		interval: interval,
+	}
+}
+
+// Run generates the missing invoices on every tick until the context is cancelled
+func (scheduler *InvoiceScheduler) Run(ctx context.Context) {
+	runTicker(ctx, scheduler.logger, "invoice scheduler", scheduler.interval, scheduler.tick)
+}
+
+func (scheduler *InvoiceScheduler) tick(ctx context.Context) {
+	ctx, span, ctxLogger := scheduler.tracer.StartWithLogger(ctx, scheduler.logger)
+	defer span.End()
+
+	timestamp := time.Now().UTC()
+	month := now.New(timestamp).BeginningOfMonth()
+	if scheduler.completed.Equal(month) {
+		return
+	}
+
+	for skip := 0; ; skip += invoiceSchedulerBatchSize {
+		count, err := scheduler.service.GenerateDue(ctx, timestamp, skip, invoiceSchedulerBatchSize)
+		if err != nil {
+			ctxLogger.Error(stacktrace.Propagate(err, fmt.Sprintf("cannot generate invoices with skip [%d]", skip)))
+			return
+		}
+
+		if count < invoiceSchedulerBatchSize {
+			ctxLogger.Info(fmt.Sprintf("processed invoices of [%d] users for [%s]", skip+count, month.AddDate(0, -1, 0).Format("2006-01")))
+			scheduler.completed = month
+			return
+		}
+	}
+}
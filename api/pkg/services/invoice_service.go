@@ -0,0 +1,337 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/emails"
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/google/uuid"
+	"github.com/jinzhu/now"
+	"github.com/palantir/stacktrace"
+)
+
+// InvoiceFormat is the file format of a rendered entities.Invoice
+type InvoiceFormat string
+
+const (
+	// InvoiceFormatPDF renders the invoice as a printable document
+	InvoiceFormatPDF = InvoiceFormat("pdf")
+
+	// InvoiceFormatCSV renders the line items of the invoice as a spreadsheet
+	InvoiceFormatCSV = InvoiceFormat("csv")
+)
+
+// ContentType returns the MIME type of the format
+func (format InvoiceFormat) ContentType() string {
+	if format == InvoiceFormatCSV {
+		return "text/csv"
+	}
+	return "application/pdf"
+}
+
+// InvoiceService generates the monthly entities.Invoice of users
+type InvoiceService struct {
+	service
+	logger                 telemetry.Logger
+	tracer                 telemetry.Tracer
+	repository             repositories.InvoiceRepository
+	userRepository         repositories.UserRepository
+	billingUsageRepository repositories.BillingUsageRepository
+	creditRepository       repositories.CreditRepository
+	mailer                 emails.Mailer
+	emailFactory           emails.UserEmailFactory
+	emailInvoices          bool
+}
+
+// NewInvoiceService creates a new InvoiceService
+func NewInvoiceService(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	repository repositories.InvoiceRepository,
+	userRepository repositories.UserRepository,
+	billingUsageRepository repositories.BillingUsageRepository,
+	creditRepository repositories.CreditRepository,
+	mailer emails.Mailer,
+	emailFactory emails.UserEmailFactory,
+	emailInvoices bool,
+) (s *InvoiceService) {
+	return &InvoiceService{
+		logger:                 logger.WithService(fmt.Sprintf("%T", s)),
+		tracer:                 tracer,
+		repository:             repository,
+		userRepository:         userRepository,
+		billingUsageRepository: billingUsageRepository,
+		creditRepository:       creditRepository,
+		mailer:                 mailer,
+		emailFactory:           emailFactory,
+		emailInvoices:          emailInvoices,
+	}
+}
+
+// Index fetches the entities.Invoice of a user
+func (service *InvoiceService) Index(ctx context.Context, userID entities.UserID, params repositories.IndexParams) ([]*entities.Invoice, error) {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	invoices, err := service.repository.Index(ctx, userID, params)
+	if err != nil {
+		msg := fmt.Sprintf("could not fetch invoices with params [%+#v]", params)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return invoices, nil
+}
+
+// Load an entities.Invoice of a user
+func (service *InvoiceService) Load(ctx context.Context, userID entities.UserID, invoiceID uuid.UUID) (*entities.Invoice, error) {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	invoice, err := service.repository.Load(ctx, userID, invoiceID)
+	if err != nil {
+		msg := fmt.Sprintf("could not load invoice with ID [%s] for user [%s]", invoiceID, userID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
+	}
+
+	return invoice, nil
+}
+
+// InvoiceGenerateParams are parameters for generating an entities.Invoice
+type InvoiceGenerateParams struct {
+	UserID entities.UserID
+	Period time.Time
+	Email  bool
+}
+
+// Generate the entities.Invoice of a user for the month containing params.Period.
+// The invoice of a period which was already generated is recomputed and keeps its ID and number.
+func (service *InvoiceService) Generate(ctx context.Context, params *InvoiceGenerateParams) (*entities.Invoice, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	user, err := service.userRepository.Load(ctx, params.UserID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot load user with ID [%s]", params.UserID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
+	}
+
+	periodStart := now.New(params.Period.UTC()).BeginningOfMonth()
+	invoice, err := service.repository.LoadByPeriod(ctx, params.UserID, periodStart)
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		invoice = &entities.Invoice{ID: uuid.New(), UserID: params.UserID, PeriodStart: periodStart}
+	} else if err != nil {
+		msg := fmt.Sprintf("cannot load invoice for period [%s] and user [%s]", periodStart, params.UserID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if err = service.compute(ctx, user, invoice); err != nil {
+		msg := fmt.Sprintf("cannot compute invoice [%s] for user [%s]", invoice.ID, params.UserID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if err = service.repository.Save(ctx, invoice); err != nil {
+		msg := fmt.Sprintf("cannot save invoice [%s] for user [%s]", invoice.ID, params.UserID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("generated invoice [%s] for period [%s] and user [%s]", invoice.Number, periodStart.Format("2006-01"), params.UserID))
+
+	if !params.Email {
+		return invoice, nil
+	}
+
+	if err = service.email(ctx, user, invoice); err != nil {
+		msg := fmt.Sprintf("cannot email invoice [%s] to user [%s]", invoice.ID, params.UserID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return invoice, nil
+}
+
+// GenerateDue generates the invoices of the month before timestamp for a page of users which don't have one yet.
+// It returns the number of users in the page so that the caller can fetch the next page.
+func (service *InvoiceService) GenerateDue(ctx context.Context, timestamp time.Time, skip int, limit int) (int, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	userIDs, err := service.userRepository.IndexIDs(ctx, repositories.IndexParams{Skip: skip, Limit: limit})
+	if err != nil {
+		msg := fmt.Sprintf("cannot fetch users with skip [%d] and limit [%d]", skip, limit)
+		return 0, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	periodStart := now.New(timestamp.UTC()).BeginningOfMonth().AddDate(0, -1, 0)
+	for _, userID := range userIDs {
+		_, err = service.repository.LoadByPeriod(ctx, userID, periodStart)
+		if err == nil {
+			continue
+		}
+
+		if stacktrace.GetCode(err) != repositories.ErrCodeNotFound {
+			ctxLogger.Error(stacktrace.Propagate(err, fmt.Sprintf("cannot load invoice for period [%s] and user [%s]", periodStart, userID)))
+			continue
+		}
+
+		_, err = service.Generate(ctx, &InvoiceGenerateParams{UserID: userID, Period: periodStart, Email: service.emailInvoices})
+		if err != nil {
+			ctxLogger.Error(stacktrace.Propagate(err, fmt.Sprintf("cannot generate invoice for period [%s] and user [%s]", periodStart, userID)))
+		}
+	}
+
+	return len(userIDs), nil
+}
+
+// Render an entities.Invoice as a file
+func (service *InvoiceService) Render(ctx context.Context, invoice *entities.Invoice, format InvoiceFormat) ([]byte, error) {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	user, err := service.userRepository.Load(ctx, invoice.UserID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot load user with ID [%s]", invoice.UserID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if format == InvoiceFormatCSV {
+		content, err := service.renderCSV(invoice)
+		if err != nil {
+			msg := fmt.Sprintf("cannot render invoice [%s] as CSV", invoice.ID)
+			return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+		}
+		return content, nil
+	}
+
+	return service.renderPDF(user, invoice), nil
+}
+
+func (service *InvoiceService) compute(ctx context.Context, user *entities.User, invoice *entities.Invoice) error {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	invoice.PeriodEnd = now.New(invoice.PeriodStart).EndOfMonth()
+	invoice.Number = fmt.Sprintf("%s-%s", invoice.PeriodStart.Format("200601"), strings.ToUpper(invoice.ID.String()[:8]))
+	invoice.SubscriptionName = user.SubscriptionName
+
+	usage, err := service.billingUsageRepository.LoadByPeriod(ctx, user.ID, invoice.PeriodStart)
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		usage = &entities.BillingUsage{}
+	} else if err != nil {
+		msg := fmt.Sprintf("cannot load billing usage for period [%s] and user [%s]", invoice.PeriodStart, user.ID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	totals, err := service.creditRepository.Totals(ctx, user.ID, invoice.PeriodStart, invoice.PeriodEnd)
+	if err != nil {
+		msg := fmt.Sprintf("cannot sum credits for period [%s] and user [%s]", invoice.PeriodStart, user.ID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	invoice.SentMessages = usage.SentMessages
+	invoice.ReceivedMessages = usage.ReceivedMessages
+	invoice.TotalCost = usage.TotalCost
+	invoice.CreditsAdded = totals.Added
+	invoice.CreditsUsed = totals.Used
+	return nil
+}
+
+func (service *InvoiceService) email(ctx context.Context, user *entities.User, invoice *entities.Invoice) error {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	content, err := service.renderCSV(invoice)
+	if err != nil {
+		msg := fmt.Sprintf("cannot render invoice [%s] as CSV", invoice.ID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	attachments := []emails.Attachment{
+		{Filename: invoice.Number + ".pdf", ContentType: InvoiceFormatPDF.ContentType(), Content: service.renderPDF(user, invoice)},
+		{Filename: invoice.Number + ".csv", ContentType: InvoiceFormatCSV.ContentType(), Content: content},
+	}
+
+	email, err := service.emailFactory.Invoice(user, invoice, attachments)
+	if err != nil {
+		msg := fmt.Sprintf("cannot create invoice email for user [%s]", user.ID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if err = service.mailer.Send(ctx, email); err != nil {
+		msg := fmt.Sprintf("cannot send invoice email to user [%s]", user.ID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	emailedAt := time.Now().UTC()
+	invoice.EmailedAt = &emailedAt
+	if err = service.repository.Save(ctx, invoice); err != nil {
+		msg := fmt.Sprintf("cannot save invoice [%s] after emailing it to user [%s]", invoice.ID, user.ID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+// lineItems are the rows of the invoice shown in the rendered documents
+func (service *InvoiceService) lineItems(invoice *entities.Invoice) [][2]string {
+	return [][2]string{
+		{"Subscription plan", string(invoice.SubscriptionName)},
+		{"Sent messages", fmt.Sprintf("%d", invoice.SentMessages)},
+		{"Received messages", fmt.Sprintf("%d", invoice.ReceivedMessages)},
+		{"Credits added", fmt.Sprintf("%d", invoice.CreditsAdded)},
+		{"Credits used", fmt.Sprintf("%d", invoice.CreditsUsed)},
+		{"Total cost", fmt.Sprintf("%d", invoice.TotalCost)},
+	}
+}
+
+func (service *InvoiceService) renderCSV(invoice *entities.Invoice) ([]byte, error) {
+	buffer := new(bytes.Buffer)
+	writer := csv.NewWriter(buffer)
+	_ = writer.Write([]string{"invoice_number", "period_start", "period_end", "description", "value"})
+	for _, item := range service.lineItems(invoice) {
+		_ = writer.Write([]string{invoice.Number, invoice.PeriodStart.Format("2006-01-02"), invoice.PeriodEnd.Format("2006-01-02"), item[0], item[1]})
+	}
+
+	writer.Flush()
+	return buffer.Bytes(), writer.Error()
+}
+
+func (service *InvoiceService) renderPDF(user *entities.User, invoice *entities.Invoice) []byte {
+	document := new(pdfDocument)
+	document.Text(50, 70, 22, true, "httpSMS Invoice")
+
+	details := [][2]string{
+		{"Invoice number", invoice.Number},
+		{"Period", fmt.Sprintf("%s - %s", invoice.PeriodStart.Format("2 Jan 2006"), invoice.PeriodEnd.Format("2 Jan 2006"))},
+		{"Billed to", user.Email},
+		{"Account ID", string(user.ID)},
+		{"Issued on", invoice.UpdatedAt.UTC().Format("2 Jan 2006")},
+	}
+
+	y := 110.0
+	for _, detail := range details {
+		document.Text(50, y, 10, true, detail[0])
+		document.Text(180, y, 10, false, detail[1])
+		y += 18
+	}
+
+	y += 20
+	document.Text(50, y, 11, true, "Description")
+	document.Text(400, y, 11, true, "Value")
+	document.Line(50, 545, y+6)
+	y += 24
+
+	for _, item := range service.lineItems(invoice) {
+		document.Text(50, y, 10, false, item[0])
+		document.Text(400, y, 10, false, item[1])
+		y += 18
+	}
+
+	document.Line(50, 545, y-8)
+	return document.Bytes()
+}
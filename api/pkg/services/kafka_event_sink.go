@@ -0,0 +1,109 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/palantir/stacktrace"
+	"github.com/segmentio/kafka-go"
+
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+)
+
+// KafkaEventSinkConfig configures the kafka topics of the KafkaEventSink
+type KafkaEventSinkConfig struct {
+	Brokers []string
+
+	// Topic is the topic of all events, it is the prefix of the topic when TopicPerEventType is true
+	Topic string
+
+	// TopicPerEventType publishes each event type to the "<Topic>.<event type>" topic
+	TopicPerEventType bool
+}
+
+// KafkaEventSink mirrors cloudevents onto kafka topics for downstream consumers
+type KafkaEventSink struct {
+	logger telemetry.Logger
+	tracer telemetry.Tracer
+	config KafkaEventSinkConfig
+	writer *kafka.Writer
+}
+
+// NewKafkaEventSink creates a new KafkaEventSink
+func NewKafkaEventSink(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	config KafkaEventSinkConfig,
+) (s *KafkaEventSink) {
+	return &KafkaEventSink{
+		logger: logger.WithService(fmt.Sprintf("%T", s)),
+		tracer: tracer,
+		config: config,
+		writer: &kafka.Writer{
+			Addr:                   kafka.TCP(config.Brokers...),
+			Balancer:               &kafka.Hash{},
+			RequiredAcks:           kafka.RequireOne,
+			BatchTimeout:           10 * time.Millisecond,
+			AllowAutoTopicCreation: true,
+		},
+	}
+}
+
+// Publish writes an event to its kafka topic, events of the same user are written to the same partition to keep them in order
+func (sink *KafkaEventSink) Publish(ctx context.Context, event cloudevents.Event) error {
+	ctx, span := sink.tracer.Start(ctx)
+	defer span.End()
+
+	value, err := json.Marshal(event)
+	if err != nil {
+		msg := fmt.Sprintf("cannot marshal event [%s] with ID [%s]", event.Type(), event.ID())
+		return sink.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	message := kafka.Message{
+		Topic: sink.topic(event),
+		Key:   []byte(sink.key(event)),
+		Value: value,
+		Headers: []kafka.Header{
+			{Key: "content-type", Value: []byte(cloudevents.ApplicationCloudEventsJSON)},
+			{Key: "ce_id", Value: []byte(event.ID())},
+			{Key: "ce_type", Value: []byte(event.Type())},
+			{Key: "ce_source", Value: []byte(event.Source())},
+		},
+		Time: event.Time(),
+	}
+
+	if err = sink.writer.WriteMessages(ctx, message); err != nil {
+		msg := fmt.Sprintf("cannot write event [%s] with ID [%s] to kafka topic [%s]", event.Type(), event.ID(), message.Topic)
+		return sink.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+// Close flushes the pending messages and closes the connections to the brokers
+func (sink *KafkaEventSink) Close() error {
+	return sink.writer.Close()
+}
+
+func (sink *KafkaEventSink) topic(event cloudevents.Event) string {
+	if !sink.config.TopicPerEventType {
+		return sink.config.Topic
+	}
+	return strings.Trim(sink.config.Topic+"."+event.Type(), ".")
+}
+
+// key is the user ID of the event payload, events without a user are distributed by their ID
+func (sink *KafkaEventSink) key(event cloudevents.Event) string {
+	payload := new(struct {
+		UserID string `json:"user_id"`
+	})
+	if err := event.DataAs(payload); err == nil && payload.UserID != "" {
+		return payload.UserID
+	}
+	return event.ID()
+}
@@ -0,0 +1,120 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	lemonsqueezy "github.com/NdoleStudio/lemonsqueezy-go"
+	"github.com/palantir/stacktrace"
+)
+
+type lemonsqueezyBillingProvider struct {
+	logger       telemetry.Logger
+	tracer       telemetry.Tracer
+	client       *lemonsqueezy.Client
+	variants     BillingPlans
+	checkoutURLs BillingPlans
+}
+
+// NewLemonsqueezyBillingProvider creates a BillingProvider which uses lemonsqueezy.
+// The variants are the IDs of the product variants and the checkoutURLs are the buy links of the variants.
+func NewLemonsqueezyBillingProvider(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	client *lemonsqueezy.Client,
+	variants BillingPlans,
+	checkoutURLs BillingPlans,
+) BillingProvider {
+	return &lemonsqueezyBillingProvider{
+		logger:       logger.WithService(fmt.Sprintf("%T", &lemonsqueezyBillingProvider{})),
+		tracer:       tracer,
+		client:       client,
+		variants:     variants,
+		checkoutURLs: checkoutURLs,
+	}
+}
+
+// Name of the provider
+func (provider *lemonsqueezyBillingProvider) Name() string {
+	return "lemonsqueezy"
+}
+
+// CheckoutURL returns the buy link of the variant with the ID of the user as custom data
+func (provider *lemonsqueezyBillingProvider) CheckoutURL(_ context.Context, user *entities.User, plan entities.SubscriptionName) (string, error) {
+	checkoutURL, ok := provider.checkoutURLs[plan]
+	if !ok {
+		return "", stacktrace.NewError(fmt.Sprintf("no lemonsqueezy checkout URL is configured for plan [%s]", plan))
+	}
+
+	query := url.Values{}
+	query.Set("checkout[email]", user.Email)
+	query.Set("checkout[custom][user_id]", string(user.ID))
+	return checkoutURL + "?" + query.Encode(), nil
+}
+
+// UpdatePaymentMethodURL returns the URL where a user changes the payment method of their subscription
+func (provider *lemonsqueezyBillingProvider) UpdatePaymentMethodURL(ctx context.Context, user *entities.User) (string, error) {
+	ctx, span := provider.tracer.Start(ctx)
+	defer span.End()
+
+	if user.SubscriptionID == nil {
+		return "", provider.tracer.WrapErrorSpan(span, stacktrace.NewError(fmt.Sprintf("user [%s] has no subscription", user.ID)))
+	}
+
+	subscription, _, err := provider.client.Subscriptions.Get(ctx, *user.SubscriptionID)
+	if err != nil {
+		msg := fmt.Sprintf("could not get subscription [%s] for user [%s]", *user.SubscriptionID, user.ID)
+		return "", provider.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return subscription.Data.Attributes.Urls.UpdatePaymentMethod, nil
+}
+
+// ChangePlan moves the subscription to the variant of the plan, lemonsqueezy prorates the change by default
+func (provider *lemonsqueezyBillingProvider) ChangePlan(ctx context.Context, user *entities.User, plan entities.SubscriptionName) error {
+	ctx, span := provider.tracer.Start(ctx)
+	defer span.End()
+
+	if user.SubscriptionID == nil {
+		return provider.tracer.WrapErrorSpan(span, stacktrace.NewError(fmt.Sprintf("user [%s] has no subscription", user.ID)))
+	}
+
+	variantID, err := strconv.Atoi(provider.variants[plan])
+	if err != nil {
+		msg := fmt.Sprintf("no valid lemonsqueezy variant is configured for plan [%s]", plan)
+		return provider.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	_, _, err = provider.client.Subscriptions.Update(ctx, &lemonsqueezy.SubscriptionUpdateParams{
+		Type:       "subscriptions",
+		ID:         *user.SubscriptionID,
+		Attributes: lemonsqueezy.SubscriptionUpdateParamsAttributes{VariantID: variantID},
+	})
+	if err != nil {
+		msg := fmt.Sprintf("could not change subscription [%s] of user [%s] to plan [%s]", *user.SubscriptionID, user.ID, plan)
+		return provider.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+// Cancel the subscription of a user at the end of the current billing period
+func (provider *lemonsqueezyBillingProvider) Cancel(ctx context.Context, user *entities.User) error {
+	ctx, span := provider.tracer.Start(ctx)
+	defer span.End()
+
+	if user.SubscriptionID == nil {
+		return provider.tracer.WrapErrorSpan(span, stacktrace.NewError(fmt.Sprintf("user [%s] has no subscription", user.ID)))
+	}
+
+	if _, _, err := provider.client.Subscriptions.Cancel(ctx, *user.SubscriptionID); err != nil {
+		msg := fmt.Sprintf("could not cancel subscription [%s] for user [%s]", *user.SubscriptionID, user.ID)
+		return provider.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
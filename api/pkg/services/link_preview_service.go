@@ -0,0 +1,238 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/google/uuid"
+	"github.com/palantir/stacktrace"
+)
+
+const (
+	linkPreviewTTL          = 7 * 24 * time.Hour
+	linkPreviewMaxBodyBytes = 512 * 1024
+	linkPreviewMaxLinks     = 3
+)
+
+var (
+	linkPreviewURLRegex  = regexp.MustCompile(`https?://[^\s<>"']+`)
+	linkPreviewMetaRegex = regexp.MustCompile(`(?is)<meta\s+[^>]*>`)
+	linkPreviewAttrRegex = regexp.MustCompile(`(?is)(property|name|content)\s*=\s*("([^"]*)"|'([^']*)')`)
+	linkPreviewTitle     = regexp.MustCompile(`(?is)<title[^>]*>(.*?)</title>`)
+)
+
+// LinkPreviewService fetches the Open Graph metadata of links in messages
+type LinkPreviewService struct {
+	service
+	logger     telemetry.Logger
+	tracer     telemetry.Tracer
+	client     *http.Client
+	repository repositories.LinkPreviewRepository
+}
+
+// NewLinkPreviewService creates a new LinkPreviewService
+func NewLinkPreviewService(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	client *http.Client,
+	repository repositories.LinkPreviewRepository,
+) (s *LinkPreviewService) {
+	return &LinkPreviewService{
+		logger:     logger.WithService(fmt.Sprintf("%T", s)),
+		tracer:     tracer,
+		client:     client,
+		repository: repository,
+	}
+}
+
+// Preview returns the entities.LinkPreview of a URL, the metadata is fetched when it is not cached or is stale
+func (service *LinkPreviewService) Preview(ctx context.Context, link string) (*entities.LinkPreview, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	preview, err := service.repository.Load(ctx, link)
+	if err == nil && !preview.IsStale(linkPreviewTTL) {
+		return preview, nil
+	}
+
+	if err != nil && stacktrace.GetCode(err) != repositories.ErrCodeNotFound {
+		msg := fmt.Sprintf("cannot load link preview for URL [%s]", link)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if preview == nil {
+		preview = &entities.LinkPreview{
+			ID:        uuid.New(),
+			URL:       link,
+			CreatedAt: time.Now().UTC(),
+		}
+	}
+
+	if err = service.fetch(ctx, preview); err != nil {
+		ctxLogger.Warn(stacktrace.Propagate(err, fmt.Sprintf("cannot fetch metadata for URL [%s]", link)))
+		preview.Status = entities.LinkPreviewStatusFailed
+	}
+
+	preview.FetchedAt = time.Now().UTC()
+	preview.UpdatedAt = time.Now().UTC()
+
+	if err = service.repository.Save(ctx, preview); err != nil {
+		msg := fmt.Sprintf("cannot save link preview with ID [%s] for URL [%s]", preview.ID, link)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("link preview for URL [%s] saved with status [%s]", link, preview.Status))
+	return preview, nil
+}
+
+// Enrich fetches the entities.LinkPreview of the links in the content of a message
+func (service *LinkPreviewService) Enrich(ctx context.Context, content string) error {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	for _, link := range service.Links(content) {
+		if _, err := service.Preview(ctx, link); err != nil {
+			msg := fmt.Sprintf("cannot preview URL [%s]", link)
+			return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+		}
+	}
+
+	return nil
+}
+
+// Links returns the unique links in the content of a message
+func (service *LinkPreviewService) Links(content string) []string {
+	result := make([]string, 0)
+	seen := map[string]bool{}
+	for _, link := range linkPreviewURLRegex.FindAllString(content, -1) {
+		link = strings.TrimRight(link, ".,!?;:)")
+		if seen[link] {
+			continue
+		}
+		seen[link] = true
+		result = append(result, link)
+		if len(result) == linkPreviewMaxLinks {
+			break
+		}
+	}
+	return result
+}
+
+func (service *LinkPreviewService) fetch(ctx context.Context, preview *entities.LinkPreview) error {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	if err := service.validateHost(preview.URL); err != nil {
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, fmt.Sprintf("cannot fetch URL [%s]", preview.URL)))
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, preview.URL, nil)
+	if err != nil {
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, fmt.Sprintf("cannot create request for URL [%s]", preview.URL)))
+	}
+	request.Header.Set("User-Agent", "httpSMS-LinkPreview/1.0")
+	request.Header.Set("Accept", "text/html")
+
+	client := *service.client
+	client.CheckRedirect = func(request *http.Request, via []*http.Request) error {
+		if len(via) >= 5 {
+			return stacktrace.NewError(fmt.Sprintf("stopped after [%d] redirects", len(via)))
+		}
+		return service.validateHost(request.URL.String())
+	}
+
+	response, err := client.Do(request)
+	if err != nil {
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, fmt.Sprintf("cannot fetch URL [%s]", preview.URL)))
+	}
+	defer func() { _ = response.Body.Close() }()
+
+	if response.StatusCode >= http.StatusBadRequest {
+		return service.tracer.WrapErrorSpan(span, stacktrace.NewError(fmt.Sprintf("URL [%s] returned status code [%d]", preview.URL, response.StatusCode)))
+	}
+
+	body, err := io.ReadAll(io.LimitReader(response.Body, linkPreviewMaxBodyBytes))
+	if err != nil {
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, fmt.Sprintf("cannot read body of URL [%s]", preview.URL)))
+	}
+
+	service.parse(string(body), preview)
+	preview.Status = entities.LinkPreviewStatusFetched
+	return nil
+}
+
+// validateHost prevents fetching links which point to internal addresses
+func (service *LinkPreviewService) validateHost(link string) error {
+	parsed, err := url.Parse(link)
+	if err != nil {
+		return stacktrace.Propagate(err, fmt.Sprintf("cannot parse URL [%s]", link))
+	}
+
+	if parsed.Scheme != "http" && parsed.Scheme != "https" {
+		return stacktrace.NewError(fmt.Sprintf("URL [%s] has an unsupported scheme [%s]", link, parsed.Scheme))
+	}
+
+	ips, err := net.LookupIP(parsed.Hostname())
+	if err != nil {
+		return stacktrace.Propagate(err, fmt.Sprintf("cannot resolve host [%s]", parsed.Hostname()))
+	}
+
+	for _, ip := range ips {
+		if ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsUnspecified() {
+			return stacktrace.NewError(fmt.Sprintf("host [%s] resolves to the internal address [%s]", parsed.Hostname(), ip))
+		}
+	}
+
+	return nil
+}
+
+func (service *LinkPreviewService) parse(body string, preview *entities.LinkPreview) {
+	properties := map[string]string{}
+	for _, tag := range linkPreviewMetaRegex.FindAllString(body, -1) {
+		var key, content string
+		for _, attribute := range linkPreviewAttrRegex.FindAllStringSubmatch(tag, -1) {
+			value := attribute[3] + attribute[4]
+			if strings.EqualFold(attribute[1], "content") {
+				content = value
+				continue
+			}
+			key = strings.ToLower(value)
+		}
+		if _, ok := properties[key]; key != "" && !ok {
+			properties[key] = strings.TrimSpace(html.UnescapeString(content))
+		}
+	}
+
+	preview.Title = service.first(properties["og:title"], properties["twitter:title"])
+	if preview.Title == nil {
+		if match := linkPreviewTitle.FindStringSubmatch(body); len(match) == 2 {
+			preview.Title = service.first(strings.TrimSpace(html.UnescapeString(match[1])))
+		}
+	}
+	preview.Description = service.first(properties["og:description"], properties["twitter:description"], properties["description"])
+	preview.ImageURL = service.first(properties["og:image"], properties["twitter:image"])
+	preview.SiteName = service.first(properties["og:site_name"])
+}
+
+func (service *LinkPreviewService) first(values ...string) *string {
+	for _, value := range values {
+		if value != "" {
+			return &value
+		}
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/palantir/stacktrace"
+)
+
+type localAttachmentStorage struct {
+	logger     telemetry.Logger
+	tracer     telemetry.Tracer
+	directory  string
+	baseURL    string
+	signingKey string
+}
+
+// NewLocalAttachmentStorage creates an AttachmentStorage which keeps attachments in a directory on the disk.
+// The download URLs point to the API at baseURL e.g. https://api.httpsms.com which serves the files.
+func NewLocalAttachmentStorage(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	directory string,
+	baseURL string,
+	signingKey string,
+) AttachmentStorage {
+	return &localAttachmentStorage{
+		logger:     logger.WithService(fmt.Sprintf("%T", &localAttachmentStorage{})),
+		tracer:     tracer,
+		directory:  directory,
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		signingKey: signingKey,
+	}
+}
+
+// Name of the storage
+func (storage *localAttachmentStorage) Name() string {
+	return "local"
+}
+
+// Store the content of an attachment in a file
+func (storage *localAttachmentStorage) Store(ctx context.Context, key string, _ string, content []byte) error {
+	_, span := storage.tracer.Start(ctx)
+	defer span.End()
+
+	path := storage.path(key)
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		msg := fmt.Sprintf("cannot create directory for attachment [%s]", key)
+		return storage.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if err := os.WriteFile(path, content, 0o640); err != nil {
+		msg := fmt.Sprintf("cannot write [%d] bytes of attachment [%s] to [%s]", len(content), key, path)
+		return storage.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+// Load the content of an attachment from a file
+func (storage *localAttachmentStorage) Load(ctx context.Context, key string) ([]byte, error) {
+	_, span := storage.tracer.Start(ctx)
+	defer span.End()
+
+	content, err := os.ReadFile(storage.path(key))
+	if err != nil {
+		msg := fmt.Sprintf("cannot read attachment [%s]", key)
+		return nil, storage.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return content, nil
+}
+
+// SignedURL creates a URL to the API which is signed with an HMAC of the key and the expiry time
+func (storage *localAttachmentStorage) SignedURL(_ context.Context, key string, expiresAt time.Time) (string, error) {
+	query := url.Values{}
+	query.Set("expires", fmt.Sprintf("%d", expiresAt.Unix()))
+	query.Set("signature", AttachmentURLSignature(storage.signingKey, key, expiresAt.Unix()))
+	return fmt.Sprintf("%s/v1/attachments/%s?%s", storage.baseURL, key, query.Encode()), nil
+}
+
+// path of the file for a key, the key is cleaned so that it cannot escape the directory
+func (storage *localAttachmentStorage) path(key string) string {
+	return filepath.Join(storage.directory, filepath.Clean("/"+key))
+}
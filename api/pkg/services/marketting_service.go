@@ -57,6 +57,11 @@ func (service *MarketingService) AddToList(ctx context.Context, user *entities.U
 	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
 	defer span.End()
 
+	if service.authClient == nil {
+		ctxLogger.Info(fmt.Sprintf("cannot add user [%s] to list [%s] without a firebase auth client", user.ID, service.sendgridListID))
+		return
+	}
+
 	userRecord, err := service.authClient.GetUser(ctx, string(user.ID))
 	if err != nil {
 		msg := fmt.Sprintf("cannot get auth user with id [%s]", user.ID)
@@ -0,0 +1,192 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/carlmjohnson/requests"
+	"github.com/google/uuid"
+	"github.com/palantir/stacktrace"
+)
+
+// MeilisearchConfig is the configuration of a meilisearch server
+type MeilisearchConfig struct {
+	URL    string
+	APIKey string
+	Index  string
+}
+
+type meilisearchMessageSearchBackend struct {
+	logger   telemetry.Logger
+	tracer   telemetry.Tracer
+	client   *http.Client
+	config   MeilisearchConfig
+	settings sync.Once
+}
+
+type meilisearchSearchResponse struct {
+	Hits []struct {
+		ID           uuid.UUID                `json:"id"`
+		Formatted    struct{ Content string } `json:"_formatted"`
+		RankingScore float64                  `json:"_rankingScore"`
+	} `json:"hits"`
+}
+
+// NewMeilisearchMessageSearchBackend creates a MessageSearchBackend which indexes messages in a meilisearch server
+func NewMeilisearchMessageSearchBackend(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	client *http.Client,
+	config MeilisearchConfig,
+) MessageSearchBackend {
+	return &meilisearchMessageSearchBackend{
+		logger: logger.WithService(fmt.Sprintf("%T", &meilisearchMessageSearchBackend{})),
+		tracer: tracer,
+		client: client,
+		config: config,
+	}
+}
+
+// Name of the backend
+func (backend *meilisearchMessageSearchBackend) Name() string {
+	return "meilisearch"
+}
+
+// Index adds or replaces a message in the meilisearch index
+func (backend *meilisearchMessageSearchBackend) Index(ctx context.Context, document *MessageSearchDocument) error {
+	ctx, span, ctxLogger := backend.tracer.StartWithLogger(ctx, backend.logger)
+	defer span.End()
+
+	backend.settings.Do(func() { backend.updateSettings(ctx, ctxLogger) })
+
+	err := backend.request("/indexes/%s/documents").
+		Param("primaryKey", "id").
+		BodyJSON([]*MessageSearchDocument{document}).
+		Fetch(ctx)
+	if err != nil {
+		msg := fmt.Sprintf("cannot index message [%s] in meilisearch index [%s]", document.ID, backend.config.Index)
+		return backend.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+// Delete removes messages from the meilisearch index
+func (backend *meilisearchMessageSearchBackend) Delete(ctx context.Context, messageIDs []uuid.UUID) error {
+	ctx, span := backend.tracer.Start(ctx)
+	defer span.End()
+
+	err := backend.request("/indexes/%s/documents/delete-batch").
+		BodyJSON(messageIDs).
+		Fetch(ctx)
+	if err != nil {
+		msg := fmt.Sprintf("cannot delete [%d] messages from meilisearch index [%s]", len(messageIDs), backend.config.Index)
+		return backend.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+// DeleteUser removes all the messages of a user from the meilisearch index
+func (backend *meilisearchMessageSearchBackend) DeleteUser(ctx context.Context, userID entities.UserID) error {
+	ctx, span := backend.tracer.Start(ctx)
+	defer span.End()
+
+	err := backend.request("/indexes/%s/documents/delete").
+		BodyJSON(map[string]any{"filter": backend.filter("user_id", string(userID))}).
+		Fetch(ctx)
+	if err != nil {
+		msg := fmt.Sprintf("cannot delete the messages of user [%s] from meilisearch index [%s]", userID, backend.config.Index)
+		return backend.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+// Search the messages of a user in the meilisearch index
+func (backend *meilisearchMessageSearchBackend) Search(ctx context.Context, userID entities.UserID, params repositories.MessageSearchParams) ([]*entities.MessageSearchHit, error) {
+	ctx, span := backend.tracer.Start(ctx)
+	defer span.End()
+
+	filters := []string{backend.filter("user_id", string(userID))}
+	if params.Owner != "" {
+		filters = append(filters, backend.filter("owner", params.Owner))
+	}
+	if params.Contact != "" {
+		filters = append(filters, backend.filter("contact", params.Contact))
+	}
+
+	response := new(meilisearchSearchResponse)
+	err := backend.request("/indexes/%s/search").
+		BodyJSON(map[string]any{
+			"q":                     backend.query(params.Terms),
+			"filter":                strings.Join(filters, " AND "),
+			"limit":                 params.Limit,
+			"offset":                params.Skip,
+			"attributesToHighlight": []string{"content"},
+			"attributesToCrop":      []string{"content"},
+			"cropLength":            20,
+			"highlightPreTag":       "<mark>",
+			"highlightPostTag":      "</mark>",
+			"showRankingScore":      true,
+		}).
+		ToJSON(response).
+		Fetch(ctx)
+	if err != nil {
+		msg := fmt.Sprintf("cannot search messages of user [%s] in meilisearch index [%s]", userID, backend.config.Index)
+		return nil, backend.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	hits := make([]*entities.MessageSearchHit, 0, len(response.Hits))
+	for _, hit := range response.Hits {
+		hits = append(hits, &entities.MessageSearchHit{
+			MessageID: hit.ID,
+			Snippet:   hit.Formatted.Content,
+			Score:     hit.RankingScore,
+		})
+	}
+	return hits, nil
+}
+
+// updateSettings makes the attributes used in the search filters filterable, a failure is logged because searches still work without filters
+func (backend *meilisearchMessageSearchBackend) updateSettings(ctx context.Context, ctxLogger telemetry.Logger) {
+	err := backend.request("/indexes/%s/settings").
+		Patch().
+		BodyJSON(map[string]any{
+			"filterableAttributes": []string{"user_id", "owner", "contact"},
+			"sortableAttributes":   []string{"timestamp"},
+		}).
+		Fetch(ctx)
+	if err != nil {
+		ctxLogger.Error(stacktrace.Propagate(err, fmt.Sprintf("cannot update settings of meilisearch index [%s]", backend.config.Index)))
+	}
+}
+
+func (backend *meilisearchMessageSearchBackend) request(path string) *requests.Builder {
+	return requests.URL(strings.TrimRight(backend.config.URL, "/") + fmt.Sprintf(path, backend.config.Index)).
+		Client(backend.client).
+		Bearer(backend.config.APIKey)
+}
+
+// query converts the terms to the meilisearch syntax, phrases are wrapped in double quotes and the last word is always matched as a prefix
+func (backend *meilisearchMessageSearchBackend) query(terms []entities.MessageSearchTerm) string {
+	parts := make([]string, 0, len(terms))
+	for _, term := range terms {
+		if term.Phrase {
+			parts = append(parts, `"`+strings.Join(term.Words, " ")+`"`)
+			continue
+		}
+		parts = append(parts, strings.Join(term.Words, " "))
+	}
+	return strings.Join(parts, " ")
+}
+
+func (backend *meilisearchMessageSearchBackend) filter(attribute string, value string) string {
+	return fmt.Sprintf(`%s = "%s"`, attribute, strings.ReplaceAll(value, `"`, `\"`))
+}
@@ -0,0 +1,68 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/palantir/stacktrace"
+)
+
+const messageArchiveSchedulerBatchSize = 500
+
+// MessageArchiveScheduler periodically moves the messages which are older than the retention window into the archive
+type MessageArchiveScheduler struct {
+	logger   telemetry.Logger
+	tracer   telemetry.Tracer
+	service  *MessageArchiveService
+	interval time.Duration
+}
+
+// NewMessageArchiveScheduler creates a new MessageArchiveScheduler
+func NewMessageArchiveScheduler(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	service *MessageArchiveService,
+	interval time.Duration,
+) (s *MessageArchiveScheduler) {
+	return &MessageArchiveScheduler{
+		logger:   logger.WithService(fmt.Sprintf("%T", s)),
+		tracer:   tracer,
+		service:  service,
+		interval: interval,
+	}
+}
+
+// Run archives the old messages on every tick until the context is cancelled, it returns immediately when archiving is disabled
+func (scheduler *MessageArchiveScheduler) Run(ctx context.Context) {
+	if !scheduler.service.Enabled() {
+		scheduler.logger.Info("message archive scheduler is disabled because no retention window is configured")
+		return
+	}
+
+	runTicker(ctx, scheduler.logger, "message archive scheduler", scheduler.interval, scheduler.tick)
+}
+
+func (scheduler *MessageArchiveScheduler) tick(ctx context.Context) {
+	ctx, span, ctxLogger := scheduler.tracer.StartWithLogger(ctx, scheduler.logger)
+	defer span.End()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		default:
+		}
+
+		count, err := scheduler.service.Archive(ctx, time.Now().UTC(), messageArchiveSchedulerBatchSize)
+		if err != nil {
+			ctxLogger.Error(stacktrace.Propagate(err, "cannot archive the old messages"))
+			return
+		}
+
+		if count < messageArchiveSchedulerBatchSize {
+			return
+		}
+	}
+}
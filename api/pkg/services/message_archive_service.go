@@ -0,0 +1,117 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/google/uuid"
+	"github.com/palantir/stacktrace"
+)
+
+// messageFinalStatuses are the final statuses of a message, messages which can still change are never archived or purged
+var messageFinalStatuses = []entities.MessageStatus{
+	entities.MessageStatusSent,
+	entities.MessageStatusReceived,
+	entities.MessageStatusFailed,
+	entities.MessageStatusDelivered,
+	entities.MessageStatusExpired,
+	entities.MessageStatusDeliveryUnknown,
+}
+
+// MessageArchiveService moves old entities.Message into the archive to keep the messages table small
+type MessageArchiveService struct {
+	service
+	logger     telemetry.Logger
+	tracer     telemetry.Tracer
+	repository repositories.ArchivedMessageRepository
+	retention  time.Duration
+}
+
+// NewMessageArchiveService creates a new MessageArchiveService, messages are never archived when the retention is 0
+func NewMessageArchiveService(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	repository repositories.ArchivedMessageRepository,
+	retention time.Duration,
+) (s *MessageArchiveService) {
+	return &MessageArchiveService{
+		logger:     logger.WithService(fmt.Sprintf("%T", s)),
+		tracer:     tracer,
+		repository: repository,
+		retention:  retention,
+	}
+}
+
+// ArchivedMessagePage is a page of entities.ArchivedMessage
+type ArchivedMessagePage struct {
+	Messages []*entities.ArchivedMessage `json:"messages"`
+
+	// NextCursor is used to fetch the next page, it is null when there are no more messages
+	NextCursor *string `json:"next_cursor" example:"MTY1NDQzMjM2OTUyNzk3NjAwMDozMjM0M2ExOS1kYTVlLTRiMWItYTc2Ny0zMjk4YTczNzAzY2I"`
+}
+
+// Enabled is true when a retention window is configured
+func (service *MessageArchiveService) Enabled() bool {
+	return service.retention > 0
+}
+
+// Archive moves at most limit messages per database which are older than the retention window into the archive
+func (service *MessageArchiveService) Archive(ctx context.Context, timestamp time.Time, limit int) (int, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	if !service.Enabled() {
+		return 0, nil
+	}
+
+	count, err := service.repository.Archive(ctx, timestamp.Add(-service.retention), messageFinalStatuses, limit)
+	if err != nil {
+		msg := fmt.Sprintf("cannot archive messages older than [%s]", service.retention)
+		return count, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if count > 0 {
+		ctxLogger.Info(fmt.Sprintf("archived [%d] messages older than [%s]", count, service.retention))
+	}
+	return count, nil
+}
+
+// Index fetches a page of the entities.ArchivedMessage of a user from the newest to the oldest
+func (service *MessageArchiveService) Index(ctx context.Context, userID entities.UserID, params repositories.ArchivedMessageIndexParams) (*ArchivedMessagePage, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	messages, err := service.repository.Index(ctx, userID, params)
+	if err != nil {
+		msg := fmt.Sprintf("cannot fetch archived messages for user [%s] with params [%+#v]", userID, params)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	page := &ArchivedMessagePage{Messages: messages}
+	if len(messages) == params.Limit && len(messages) > 0 {
+		last := messages[len(messages)-1]
+		cursor := EncodeEventCursor(repositories.EventCursor{Time: last.OrderTimestamp, ID: last.ID})
+		page.NextCursor = &cursor
+	}
+
+	ctxLogger.Info(fmt.Sprintf("fetched [%d] archived messages for user [%s]", len(messages), userID))
+	return page, nil
+}
+
+// Load an entities.ArchivedMessage of a user
+func (service *MessageArchiveService) Load(ctx context.Context, userID entities.UserID, messageID uuid.UUID) (*entities.ArchivedMessage, error) {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	message, err := service.repository.Load(ctx, userID, messageID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot load archived message with ID [%s] for user [%s]", messageID, userID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
+	}
+
+	return message, nil
+}
@@ -0,0 +1,137 @@
+package services
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/palantir/stacktrace"
+	"google.golang.org/api/cloudkms/v1"
+)
+
+// MessageEncryptionMasterKey wraps the data keys which encrypt the content of the messages of each user
+type MessageEncryptionMasterKey interface {
+	// ID identifies the master key so data keys wrapped by another master key are detected
+	ID() string
+
+	// Wrap encrypts a data key
+	Wrap(ctx context.Context, key []byte) ([]byte, error)
+
+	// Unwrap decrypts a data key which was encrypted with Wrap
+	Unwrap(ctx context.Context, wrapped []byte) ([]byte, error)
+}
+
+// localMasterKey is a MessageEncryptionMasterKey which is an AES-256 key in the environment of the API
+type localMasterKey struct {
+	id   string
+	aead cipher.AEAD
+}
+
+// NewLocalMessageEncryptionMasterKey creates a MessageEncryptionMasterKey from a base64 encoded 32 byte key
+func NewLocalMessageEncryptionMasterKey(encoded string) (MessageEncryptionMasterKey, error) {
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "cannot decode the base64 master key")
+	}
+
+	if len(key) != 32 {
+		return nil, stacktrace.NewError(fmt.Sprintf("the master key must have 32 bytes but it has [%d] bytes", len(key)))
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "cannot create the AES cipher of the master key")
+	}
+
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "cannot create the GCM cipher of the master key")
+	}
+
+	hash := sha256.Sum256(key)
+	return &localMasterKey{id: "local:" + hex.EncodeToString(hash[:8]), aead: aead}, nil
+}
+
+// ID identifies the master key with a hash of the key
+func (key *localMasterKey) ID() string {
+	return key.id
+}
+
+// Wrap encrypts a data key
+func (key *localMasterKey) Wrap(_ context.Context, dataKey []byte) ([]byte, error) {
+	nonce := make([]byte, key.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, stacktrace.Propagate(err, "cannot generate nonce to wrap data key")
+	}
+	return key.aead.Seal(nonce, nonce, dataKey, nil), nil
+}
+
+// Unwrap decrypts a data key which was encrypted with Wrap
+func (key *localMasterKey) Unwrap(_ context.Context, wrapped []byte) ([]byte, error) {
+	if len(wrapped) < key.aead.NonceSize() {
+		return nil, stacktrace.NewError(fmt.Sprintf("the wrapped data key has [%d] bytes which is less than the nonce", len(wrapped)))
+	}
+
+	dataKey, err := key.aead.Open(nil, wrapped[:key.aead.NonceSize()], wrapped[key.aead.NonceSize():], nil)
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "cannot unwrap data key with the local master key")
+	}
+	return dataKey, nil
+}
+
+// kmsMasterKey is a MessageEncryptionMasterKey which is a crypto key in Google Cloud KMS, it never leaves KMS
+type kmsMasterKey struct {
+	service *cloudkms.Service
+	name    string
+}
+
+// NewKMSMessageEncryptionMasterKey creates a MessageEncryptionMasterKey with the resource name of a Google Cloud KMS crypto key
+// e.g. projects/httpsms/locations/global/keyRings/httpsms/cryptoKeys/messages
+func NewKMSMessageEncryptionMasterKey(service *cloudkms.Service, name string) MessageEncryptionMasterKey {
+	return &kmsMasterKey{service: service, name: strings.TrimSpace(name)}
+}
+
+// ID identifies the master key with the name of the crypto key
+func (key *kmsMasterKey) ID() string {
+	return "kms:" + key.name
+}
+
+// Wrap encrypts a data key with the primary version of the crypto key
+func (key *kmsMasterKey) Wrap(ctx context.Context, dataKey []byte) ([]byte, error) {
+	response, err := key.service.Projects.Locations.KeyRings.CryptoKeys.
+		Encrypt(key.name, &cloudkms.EncryptRequest{Plaintext: base64.StdEncoding.EncodeToString(dataKey)}).
+		Context(ctx).
+		Do()
+	if err != nil {
+		return nil, stacktrace.Propagate(err, fmt.Sprintf("cannot wrap data key with KMS key [%s]", key.name))
+	}
+
+	wrapped, err := base64.StdEncoding.DecodeString(response.Ciphertext)
+	if err != nil {
+		return nil, stacktrace.Propagate(err, fmt.Sprintf("cannot decode the ciphertext of KMS key [%s]", key.name))
+	}
+	return wrapped, nil
+}
+
+// Unwrap decrypts a data key which was encrypted with any version of the crypto key
+func (key *kmsMasterKey) Unwrap(ctx context.Context, wrapped []byte) ([]byte, error) {
+	response, err := key.service.Projects.Locations.KeyRings.CryptoKeys.
+		Decrypt(key.name, &cloudkms.DecryptRequest{Ciphertext: base64.StdEncoding.EncodeToString(wrapped)}).
+		Context(ctx).
+		Do()
+	if err != nil {
+		return nil, stacktrace.Propagate(err, fmt.Sprintf("cannot unwrap data key with KMS key [%s]", key.name))
+	}
+
+	dataKey, err := base64.StdEncoding.DecodeString(response.Plaintext)
+	if err != nil {
+		return nil, stacktrace.Propagate(err, fmt.Sprintf("cannot decode the plaintext of KMS key [%s]", key.name))
+	}
+	return dataKey, nil
+}
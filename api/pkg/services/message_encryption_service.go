@@ -0,0 +1,238 @@
+package services
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/google/uuid"
+	"github.com/palantir/stacktrace"
+)
+
+// EncryptedContentPrefix is the prefix of the content of an entities.Message which is encrypted.
+// It is followed by the base64 encoded nonce and ciphertext of the AES-256-GCM data key of the user.
+const EncryptedContentPrefix = "enc:v1:"
+
+// MessageEncryptionService encrypts the content of the entities.Message of each user with a data key which is wrapped by a MessageEncryptionMasterKey.
+// It implements repositories.MessageContentCipher so the content is encrypted when it is persisted and decrypted when it is loaded.
+type MessageEncryptionService struct {
+	service
+	logger            telemetry.Logger
+	tracer            telemetry.Tracer
+	masterKey         MessageEncryptionMasterKey
+	repository        repositories.EncryptionKeyRepository
+	messageRepository repositories.MessageRepository
+	mutex             sync.Mutex
+	keys              map[entities.UserID]cipher.AEAD
+}
+
+// NewMessageEncryptionService creates a new MessageEncryptionService, the messageRepository must persist the content as it is
+func NewMessageEncryptionService(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	masterKey MessageEncryptionMasterKey,
+	repository repositories.EncryptionKeyRepository,
+	messageRepository repositories.MessageRepository,
+) (s *MessageEncryptionService) {
+	return &MessageEncryptionService{
+		logger:            logger.WithService(fmt.Sprintf("%T", s)),
+		tracer:            tracer,
+		masterKey:         masterKey,
+		repository:        repository,
+		messageRepository: messageRepository,
+		keys:              map[entities.UserID]cipher.AEAD{},
+	}
+}
+
+// Encrypt the content of a message of a user, empty content is returned as it is.
+// Content which starts with the EncryptedContentPrefix is also encrypted so that it is not mistaken for ciphertext when it is loaded.
+func (service *MessageEncryptionService) Encrypt(ctx context.Context, userID entities.UserID, content string) (string, error) {
+	if content == "" {
+		return content, nil
+	}
+
+	aead, err := service.dataKey(ctx, userID, true)
+	if err != nil {
+		return "", stacktrace.Propagate(err, fmt.Sprintf("cannot load the data key of user [%s]", userID))
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err = rand.Read(nonce); err != nil {
+		return "", stacktrace.Propagate(err, "cannot generate nonce to encrypt message content")
+	}
+
+	// the user ID is authenticated so the content cannot be copied to the message of another user
+	sealed := aead.Seal(nonce, nonce, []byte(content), []byte(userID))
+	return EncryptedContentPrefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt the content of a message of a user, content which is not encrypted is returned as it is
+func (service *MessageEncryptionService) Decrypt(ctx context.Context, userID entities.UserID, content string) (string, error) {
+	if !strings.HasPrefix(content, EncryptedContentPrefix) {
+		return content, nil
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(content, EncryptedContentPrefix))
+	if err != nil {
+		return "", stacktrace.Propagate(err, "cannot decode encrypted message content")
+	}
+
+	aead, err := service.dataKey(ctx, userID, false)
+	if err != nil {
+		return "", stacktrace.Propagate(err, fmt.Sprintf("cannot load the data key of user [%s]", userID))
+	}
+
+	if len(sealed) < aead.NonceSize() {
+		return "", stacktrace.NewError(fmt.Sprintf("the encrypted content has [%d] bytes which is less than the nonce", len(sealed)))
+	}
+
+	plain, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], []byte(userID))
+	if err != nil {
+		return "", stacktrace.Propagate(err, fmt.Sprintf("cannot decrypt message content of user [%s]", userID))
+	}
+	return string(plain), nil
+}
+
+// EncryptExisting encrypts the content of the entities.Message of all users which were stored before encryption was enabled and returns the number of messages encrypted.
+// The messages are encrypted in batches so it can be stopped and run again.
+func (service *MessageEncryptionService) EncryptExisting(ctx context.Context, batchSize int) (int, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	count := 0
+	after := uuid.Nil
+	for {
+		messages, err := service.messageRepository.FetchUnencrypted(ctx, EncryptedContentPrefix, after, batchSize)
+		if err != nil {
+			msg := fmt.Sprintf("cannot fetch unencrypted messages after ID [%s]", after)
+			return count, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+		}
+
+		for _, message := range messages {
+			after = message.ID
+
+			encrypted, err := service.Encrypt(ctx, message.UserID, message.Content)
+			if err != nil {
+				msg := fmt.Sprintf("cannot encrypt the content of message with ID [%s]", message.ID)
+				return count, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+			}
+
+			// the content is only changed when it was not changed since it was fetched
+			updated, err := service.messageRepository.UpdateContent(ctx, message.UserID, message.ID, message.Content, encrypted)
+			if err != nil {
+				msg := fmt.Sprintf("cannot update the content of message with ID [%s]", message.ID)
+				return count, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+			}
+
+			if updated {
+				count++
+			}
+		}
+
+		ctxLogger.Info(fmt.Sprintf("encrypted [%d] messages up to ID [%s]", count, after))
+		if len(messages) < batchSize {
+			return count, nil
+		}
+	}
+}
+
+// dataKey returns the cipher of the data key of a user, the data key is created when create is true and the user has no data key
+func (service *MessageEncryptionService) dataKey(ctx context.Context, userID entities.UserID, create bool) (cipher.AEAD, error) {
+	service.mutex.Lock()
+	aead, ok := service.keys[userID]
+	service.mutex.Unlock()
+	if ok {
+		return aead, nil
+	}
+
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	key, err := service.repository.Load(ctx, userID)
+	if create && stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		key, err = service.createDataKey(ctx, userID)
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot load encryption key of user [%s]", userID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
+	}
+
+	if key.MasterKeyID != service.masterKey.ID() {
+		msg := fmt.Sprintf("the data key of user [%s] is wrapped by master key [%s] and not [%s]", userID, key.MasterKeyID, service.masterKey.ID())
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.NewError(msg))
+	}
+
+	dataKey, err := service.masterKey.Unwrap(ctx, key.WrappedKey)
+	if err != nil {
+		msg := fmt.Sprintf("cannot unwrap the data key of user [%s]", userID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	block, err := aes.NewCipher(dataKey)
+	if err != nil {
+		msg := fmt.Sprintf("cannot create the AES cipher of the data key of user [%s]", userID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if aead, err = cipher.NewGCM(block); err != nil {
+		msg := fmt.Sprintf("cannot create the GCM cipher of the data key of user [%s]", userID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	service.mutex.Lock()
+	service.keys[userID] = aead
+	service.mutex.Unlock()
+
+	return aead, nil
+}
+
+// createDataKey generates and stores a new data key for a user, the key stored by a concurrent request is returned when there is a race
+func (service *MessageEncryptionService) createDataKey(ctx context.Context, userID entities.UserID) (*entities.EncryptionKey, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	dataKey := make([]byte, 32)
+	if _, err := rand.Read(dataKey); err != nil {
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, "cannot generate data key"))
+	}
+
+	wrapped, err := service.masterKey.Wrap(ctx, dataKey)
+	if err != nil {
+		msg := fmt.Sprintf("cannot wrap the data key of user [%s]", userID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	key := &entities.EncryptionKey{
+		UserID:      userID,
+		WrappedKey:  wrapped,
+		MasterKeyID: service.masterKey.ID(),
+		CreatedAt:   time.Now().UTC(),
+	}
+
+	stored, err := service.repository.Store(ctx, key)
+	if err != nil {
+		msg := fmt.Sprintf("cannot store the data key of user [%s]", userID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if stored {
+		ctxLogger.Info(fmt.Sprintf("created data key for user [%s] with master key [%s]", userID, key.MasterKeyID))
+		return key, nil
+	}
+
+	if key, err = service.repository.Load(ctx, userID); err != nil {
+		msg := fmt.Sprintf("cannot load the data key of user [%s] which was created by another request", userID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+	return key, nil
+}
@@ -0,0 +1,47 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/palantir/stacktrace"
+)
+
+// MessageRetentionScheduler periodically applies the message retention policy of the users
+type MessageRetentionScheduler struct {
+	logger   telemetry.Logger
+	tracer   telemetry.Tracer
+	service  *MessageRetentionService
+	interval time.Duration
+}
+
+// NewMessageRetentionScheduler creates a new MessageRetentionScheduler
+func NewMessageRetentionScheduler(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	service *MessageRetentionService,
+	interval time.Duration,
+) (s *MessageRetentionScheduler) {
+	return &MessageRetentionScheduler{
+		logger:   logger.WithService(fmt.Sprintf("%T", s)),
+		tracer:   tracer,
+		service:  service,
+		interval: interval,
+	}
+}
+
+// Run purges the expired messages on every tick until the context is cancelled
+func (scheduler *MessageRetentionScheduler) Run(ctx context.Context) {
+	runTicker(ctx, scheduler.logger, "message retention scheduler", scheduler.interval, scheduler.tick)
+}
+
+func (scheduler *MessageRetentionScheduler) tick(ctx context.Context) {
+	ctx, span, ctxLogger := scheduler.tracer.StartWithLogger(ctx, scheduler.logger)
+	defer span.End()
+
+	if err := scheduler.service.Purge(ctx, time.Now().UTC()); err != nil {
+		ctxLogger.Error(stacktrace.Propagate(err, "cannot apply the message retention policies"))
+	}
+}
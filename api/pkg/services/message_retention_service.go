@@ -0,0 +1,160 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/events"
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/google/uuid"
+	"github.com/palantir/stacktrace"
+)
+
+// messageRetentionSource is the source of the events which are emitted by the MessageRetentionService
+const messageRetentionSource = "/message-retention"
+
+// messageRetentionBatchSize is the maximum number of messages which are purged in one statement and listed in one events.EventTypeMessagePurged event
+const messageRetentionBatchSize = 500
+
+// MessageRetentionService deletes and anonymizes the entities.Message of users according to their retention policy
+type MessageRetentionService struct {
+	service
+	logger            telemetry.Logger
+	tracer            telemetry.Tracer
+	userRepository    repositories.UserRepository
+	messageRepository repositories.MessageRepository
+	archiveRepository repositories.ArchivedMessageRepository
+	eventDispatcher   *EventDispatcher
+}
+
+// NewMessageRetentionService creates a new MessageRetentionService
+func NewMessageRetentionService(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	userRepository repositories.UserRepository,
+	messageRepository repositories.MessageRepository,
+	archiveRepository repositories.ArchivedMessageRepository,
+	eventDispatcher *EventDispatcher,
+) (s *MessageRetentionService) {
+	return &MessageRetentionService{
+		logger:            logger.WithService(fmt.Sprintf("%T", s)),
+		tracer:            tracer,
+		userRepository:    userRepository,
+		messageRepository: messageRepository,
+		archiveRepository: archiveRepository,
+		eventDispatcher:   eventDispatcher,
+	}
+}
+
+// Purge applies the retention policy of every user, a failure for one user is logged so that the policies of the other users are still applied
+func (service *MessageRetentionService) Purge(ctx context.Context, timestamp time.Time) error {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	users, err := service.userRepository.FetchWithRetentionPolicy(ctx)
+	if err != nil {
+		msg := "cannot fetch the users with a message retention policy"
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	for _, user := range users {
+		if err = service.purgeUser(ctx, user, timestamp); err != nil {
+			ctxLogger.Error(stacktrace.Propagate(err, fmt.Sprintf("cannot apply the message retention policy of user [%s]", user.ID)))
+		}
+	}
+
+	ctxLogger.Info(fmt.Sprintf("applied the message retention policy of [%d] users", len(users)))
+	return nil
+}
+
+func (service *MessageRetentionService) purgeUser(ctx context.Context, user *entities.User, timestamp time.Time) error {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	if user.MessageRetentionDays > 0 {
+		before := timestamp.AddDate(0, 0, -int(user.MessageRetentionDays))
+		if err := service.purge(ctx, user.ID, events.MessagePurgeActionDeleted, before, timestamp); err != nil {
+			msg := fmt.Sprintf("cannot delete the messages of user [%s] older than [%s]", user.ID, before)
+			return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+		}
+	}
+
+	if user.MessageAnonymizeDays > 0 {
+		before := timestamp.AddDate(0, 0, -int(user.MessageAnonymizeDays))
+		if err := service.purge(ctx, user.ID, events.MessagePurgeActionAnonymized, before, timestamp); err != nil {
+			msg := fmt.Sprintf("cannot anonymize the messages of user [%s] older than [%s]", user.ID, before)
+			return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+		}
+	}
+
+	return nil
+}
+
+// purge deletes or anonymizes the messages and archived messages of a user in batches and emits an events.EventTypeMessagePurged event for every batch
+func (service *MessageRetentionService) purge(ctx context.Context, userID entities.UserID, action string, before time.Time, timestamp time.Time) error {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	batches := []func() ([]uuid.UUID, error){
+		func() ([]uuid.UUID, error) {
+			if action == events.MessagePurgeActionDeleted {
+				return service.messageRepository.Purge(ctx, userID, before, messageFinalStatuses, messageRetentionBatchSize)
+			}
+			return service.messageRepository.Anonymize(ctx, userID, before, messageFinalStatuses, messageRetentionBatchSize)
+		},
+		func() ([]uuid.UUID, error) {
+			if action == events.MessagePurgeActionDeleted {
+				return service.archiveRepository.Purge(ctx, userID, before, messageRetentionBatchSize)
+			}
+			return service.archiveRepository.Anonymize(ctx, userID, before, messageRetentionBatchSize)
+		},
+	}
+
+	count := 0
+	for _, batch := range batches {
+		for {
+			messageIDs, err := batch()
+			if err != nil {
+				msg := fmt.Sprintf("cannot [%s] messages of user [%s] older than [%s]", action, userID, before)
+				return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+			}
+
+			if len(messageIDs) > 0 {
+				if err = service.dispatchPurged(ctx, userID, action, messageIDs, before, timestamp); err != nil {
+					return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, fmt.Sprintf("cannot dispatch [%s] event for user [%s]", events.EventTypeMessagePurged, userID)))
+				}
+			}
+
+			count += len(messageIDs)
+			if len(messageIDs) < messageRetentionBatchSize {
+				break
+			}
+		}
+	}
+
+	if count > 0 {
+		ctxLogger.Info(fmt.Sprintf("[%s] [%d] messages of user [%s] older than [%s]", action, count, userID, before))
+	}
+	return nil
+}
+
+func (service *MessageRetentionService) dispatchPurged(ctx context.Context, userID entities.UserID, action string, messageIDs []uuid.UUID, before time.Time, timestamp time.Time) error {
+	event, err := service.createEvent(events.EventTypeMessagePurged, messageRetentionSource, &events.MessagePurgedPayload{
+		MessageIDs: messageIDs,
+		UserID:     userID,
+		Action:     action,
+		Before:     before,
+		Timestamp:  timestamp,
+	})
+	if err != nil {
+		return stacktrace.Propagate(err, fmt.Sprintf("cannot create [%s] event for user [%s]", events.EventTypeMessagePurged, userID))
+	}
+
+	if err = service.eventDispatcher.Dispatch(ctx, event); err != nil {
+		return stacktrace.Propagate(err, fmt.Sprintf("cannot dispatch event type [%s] and id [%s]", event.Type(), event.ID()))
+	}
+	return nil
+}
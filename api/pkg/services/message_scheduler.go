@@ -0,0 +1,64 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/palantir/stacktrace"
+)
+
+const (
+	messageSchedulerSource    = "/message-scheduler"
+	messageSchedulerBatchSize = 100
+)
+
+// MessageScheduler periodically dispatches the messages which are queued for a future SendAt time
+type MessageScheduler struct {
+	logger   telemetry.Logger
+	tracer   telemetry.Tracer
+	service  *MessageService
+	interval time.Duration
+}
+
+// NewMessageScheduler creates a new MessageScheduler
+func NewMessageScheduler(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	service *MessageService,
+	interval time.Duration,
+) (s *MessageScheduler) {
+	return &MessageScheduler{
+		logger:   logger.WithService(fmt.Sprintf("%T", s)),
+		tracer:   tracer,
+		service:  service,
+		interval: interval,
+	}
+}
+
+// Run dispatches the due messages on every tick until the context is cancelled
+func (scheduler *MessageScheduler) Run(ctx context.Context) {
+	runTicker(ctx, scheduler.logger, "message scheduler", scheduler.interval, scheduler.tick)
+}
+
+func (scheduler *MessageScheduler) tick(ctx context.Context) {
+	ctx, span, ctxLogger := scheduler.tracer.StartWithLogger(ctx, scheduler.logger)
+	defer span.End()
+
+	for {
+		count, err := scheduler.service.DispatchDue(ctx, messageSchedulerSource, time.Now().UTC(), messageSchedulerBatchSize)
+		if err != nil {
+			ctxLogger.Error(stacktrace.Propagate(err, "cannot dispatch messages which are due"))
+			return
+		}
+
+		if count > 0 {
+			ctxLogger.Info(fmt.Sprintf("dispatched [%d] scheduled messages", count))
+		}
+
+		if count < messageSchedulerBatchSize {
+			return
+		}
+	}
+}
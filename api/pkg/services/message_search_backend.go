@@ -0,0 +1,50 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
+	"github.com/google/uuid"
+)
+
+// MessageSearchDocument is the content of an entities.Message which is added to a search index
+type MessageSearchDocument struct {
+	ID        uuid.UUID       `json:"id"`
+	UserID    entities.UserID `json:"user_id"`
+	Owner     string          `json:"owner"`
+	Contact   string          `json:"contact"`
+	Content   string          `json:"content"`
+	Timestamp int64           `json:"timestamp"`
+}
+
+// NewMessageSearchDocument creates a MessageSearchDocument, the timestamp is stored as a unix timestamp so that it can be sorted
+func NewMessageSearchDocument(messageID uuid.UUID, userID entities.UserID, owner string, contact string, content string, timestamp time.Time) *MessageSearchDocument {
+	return &MessageSearchDocument{
+		ID:        messageID,
+		UserID:    userID,
+		Owner:     owner,
+		Contact:   contact,
+		Content:   content,
+		Timestamp: timestamp.Unix(),
+	}
+}
+
+// MessageSearchBackend searches the content of the entities.Message of a user
+type MessageSearchBackend interface {
+	// Name of the backend e.g. postgres
+	Name() string
+
+	// Index adds a message to the search index, backends which search the database directly ignore it
+	Index(ctx context.Context, document *MessageSearchDocument) error
+
+	// Delete removes messages from the search index, backends which search the database directly ignore it
+	Delete(ctx context.Context, messageIDs []uuid.UUID) error
+
+	// DeleteUser removes all the messages of a user from the search index, backends which search the database directly ignore it
+	DeleteUser(ctx context.Context, userID entities.UserID) error
+
+	// Search fetches the entities.MessageSearchHit matching the params, the best match first
+	Search(ctx context.Context, userID entities.UserID, params repositories.MessageSearchParams) ([]*entities.MessageSearchHit, error)
+}
@@ -0,0 +1,151 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/google/uuid"
+	"github.com/palantir/stacktrace"
+)
+
+// MessageSearchService searches the content of the entities.Message of a user using a MessageSearchBackend
+type MessageSearchService struct {
+	service
+	logger            telemetry.Logger
+	tracer            telemetry.Tracer
+	backend           MessageSearchBackend
+	repository        repositories.MessageRepository
+	attachmentService *AttachmentService
+}
+
+// NewMessageSearchService creates a new MessageSearchService
+func NewMessageSearchService(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	backend MessageSearchBackend,
+	repository repositories.MessageRepository,
+	attachmentService *AttachmentService,
+) (s *MessageSearchService) {
+	return &MessageSearchService{
+		logger:            logger.WithService(fmt.Sprintf("%T", s)),
+		tracer:            tracer,
+		backend:           backend,
+		repository:        repository,
+		attachmentService: attachmentService,
+	}
+}
+
+// MessageSearchParams are parameters for searching messages
+type MessageSearchParams struct {
+	UserID  entities.UserID
+	Query   string
+	Owner   string
+	Contact string
+	Skip    int
+	Limit   int
+}
+
+// MessageSearchResult is an entities.Message which matches a search query
+type MessageSearchResult struct {
+	Message *entities.Message `json:"message"`
+
+	// Snippet is the part of the content which matches the query, the matches are wrapped in <mark></mark> tags
+	Snippet string  `json:"snippet" example:"your <mark>verification</mark> code is 123456"`
+	Score   float64 `json:"score" example:"0.0607927"`
+}
+
+// Search fetches the entities.Message of a user matching a query, the best match first
+func (service *MessageSearchService) Search(ctx context.Context, params *MessageSearchParams) ([]*MessageSearchResult, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	results := make([]*MessageSearchResult, 0)
+	terms := entities.ParseMessageSearchQuery(params.Query)
+	if len(terms) == 0 {
+		return results, nil
+	}
+
+	hits, err := service.backend.Search(ctx, params.UserID, repositories.MessageSearchParams{
+		Terms:   terms,
+		Owner:   params.Owner,
+		Contact: params.Contact,
+		Skip:    params.Skip,
+		Limit:   params.Limit,
+	})
+	if err != nil {
+		msg := fmt.Sprintf("cannot search messages of user [%s] with query [%s] using [%s] backend", params.UserID, params.Query, service.backend.Name())
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	messageIDs := make([]uuid.UUID, 0, len(hits))
+	for _, hit := range hits {
+		messageIDs = append(messageIDs, hit.MessageID)
+	}
+
+	messages, err := service.repository.FetchByIDs(ctx, params.UserID, messageIDs)
+	if err != nil {
+		msg := fmt.Sprintf("cannot fetch [%d] messages matching query [%s] for user [%s]", len(messageIDs), params.Query, params.UserID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if err = service.attachmentService.LoadForMessages(ctx, params.UserID, messages...); err != nil {
+		msg := fmt.Sprintf("cannot load attachments of [%d] messages for user [%s]", len(messages), params.UserID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	// an external index can have messages which were deleted from the database so they are skipped
+	messageMap := make(map[uuid.UUID]*entities.Message, len(messages))
+	for _, message := range messages {
+		messageMap[message.ID] = message
+	}
+	for _, hit := range hits {
+		if message, ok := messageMap[hit.MessageID]; ok {
+			results = append(results, &MessageSearchResult{Message: message, Snippet: hit.Snippet, Score: hit.Score})
+		}
+	}
+
+	ctxLogger.Info(fmt.Sprintf("found [%d] messages matching query [%s] for user [%s] using [%s] backend", len(results), params.Query, params.UserID, service.backend.Name()))
+	return results, nil
+}
+
+// Index adds a message to the search index of the MessageSearchBackend
+func (service *MessageSearchService) Index(ctx context.Context, document *MessageSearchDocument) error {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	if err := service.backend.Index(ctx, document); err != nil {
+		msg := fmt.Sprintf("cannot index message [%s] of user [%s] using [%s] backend", document.ID, document.UserID, service.backend.Name())
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+// Delete removes the messages of a user from the search index
+func (service *MessageSearchService) Delete(ctx context.Context, userID entities.UserID, messageIDs []uuid.UUID) error {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	if err := service.backend.Delete(ctx, messageIDs); err != nil {
+		msg := fmt.Sprintf("cannot delete [%d] messages of user [%s] using [%s] backend", len(messageIDs), userID, service.backend.Name())
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+// DeleteUser removes all the messages of a user from the search index
+func (service *MessageSearchService) DeleteUser(ctx context.Context, userID entities.UserID) error {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	if err := service.backend.DeleteUser(ctx, userID); err != nil {
+		msg := fmt.Sprintf("cannot delete the messages of user [%s] using [%s] backend", userID, service.backend.Name())
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
@@ -3,6 +3,8 @@ package services
 import (
 	"context"
 	"fmt"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/davecgh/go-spew/spew"
@@ -19,14 +21,32 @@ import (
 	"github.com/NdoleStudio/httpsms/pkg/telemetry"
 )
 
+// messageConversationWindow is the duration after sending a message with a conversation ID during which the replies of the contact inherit it
+const messageConversationWindow = 24 * time.Hour
+
+// ErrCodeMessageNotReceived is returned when an operation which is only valid for mobile-originated messages is performed on an outgoing message
+const ErrCodeMessageNotReceived = stacktrace.ErrorCode(2017)
+
+// ErrCodeMessagePredecessorInvalid is returned when a message is sent after a message which does not exist or is not an outgoing message
+const ErrCodeMessagePredecessorInvalid = stacktrace.ErrorCode(2019)
+
+// ErrCodeMessageIDExists is returned when a message is sent with a client generated ID which is used by a different message
+const ErrCodeMessageIDExists = stacktrace.ErrorCode(2022)
+
 // MessageService is handles message requests
 type MessageService struct {
 	service
-	logger          telemetry.Logger
-	tracer          telemetry.Tracer
-	eventDispatcher *EventDispatcher
-	phoneService    *PhoneService
-	repository      repositories.MessageRepository
+	logger                    telemetry.Logger
+	tracer                    telemetry.Tracer
+	eventDispatcher           *EventDispatcher
+	phoneService              *PhoneService
+	retryPolicyService        *RetryPolicyService
+	attachmentService         *AttachmentService
+	creditService             *CreditService
+	repository                repositories.MessageRepository
+	archivedMessageRepository repositories.ArchivedMessageRepository
+	userRepository            repositories.UserRepository
+	blocklistRepository       repositories.BlocklistRepository
 }
 
 // NewMessageService creates a new MessageService
@@ -34,15 +54,27 @@ func NewMessageService(
 	logger telemetry.Logger,
 	tracer telemetry.Tracer,
 	repository repositories.MessageRepository,
+	archivedMessageRepository repositories.ArchivedMessageRepository,
 	eventDispatcher *EventDispatcher,
 	phoneService *PhoneService,
+	retryPolicyService *RetryPolicyService,
+	attachmentService *AttachmentService,
+	creditService *CreditService,
+	userRepository repositories.UserRepository,
+	blocklistRepository repositories.BlocklistRepository,
 ) (s *MessageService) {
 	return &MessageService{
-		logger:          logger.WithService(fmt.Sprintf("%T", s)),
-		tracer:          tracer,
-		repository:      repository,
-		phoneService:    phoneService,
-		eventDispatcher: eventDispatcher,
+		logger:                    logger.WithService(fmt.Sprintf("%T", s)),
+		tracer:                    tracer,
+		repository:                repository,
+		archivedMessageRepository: archivedMessageRepository,
+		phoneService:              phoneService,
+		retryPolicyService:        retryPolicyService,
+		attachmentService:         attachmentService,
+		creditService:             creditService,
+		userRepository:            userRepository,
+		blocklistRepository:       blocklistRepository,
+		eventDispatcher:           eventDispatcher,
 	}
 }
 
@@ -61,12 +93,27 @@ func (service *MessageService) GetOutstanding(ctx context.Context, params Messag
 
 	ctxLogger := service.tracer.CtxLogger(service.logger, span)
 
+	if err := service.pauseIfSuspended(ctx, params.UserID); err != nil {
+		msg := fmt.Sprintf("cannot fetch outstanding message [%s] for user [%s]", params.MessageID, params.UserID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
+	}
+
+	if err := service.checkKillSwitch(ctx, params.UserID, params.MessageID); err != nil {
+		msg := fmt.Sprintf("cannot fetch outstanding message [%s] for user [%s]", params.MessageID, params.UserID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
+	}
+
 	message, err := service.repository.GetOutstanding(ctx, params.UserID, params.MessageID)
 	if err != nil {
 		msg := fmt.Sprintf("could not fetch outstanding messages with params [%s]", spew.Sdump(params))
 		return nil, service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
 	}
 
+	if err = service.attachmentService.LoadForMessages(ctx, message.UserID, message); err != nil {
+		msg := fmt.Sprintf("cannot load attachments of outstanding message with ID [%s]", message.ID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
 	event, err := service.createMessagePhoneSendingEvent(params.Source, events.MessagePhoneSendingPayload{
 		ID:        message.ID,
 		Owner:     message.Owner,
@@ -113,10 +160,54 @@ func (service *MessageService) GetMessages(ctx context.Context, params MessageGe
 		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
 	}
 
+	pointers := make([]*entities.Message, 0, len(*messages))
+	for index := range *messages {
+		pointers = append(pointers, &(*messages)[index])
+	}
+	if err = service.attachmentService.LoadForMessages(ctx, params.UserID, pointers...); err != nil {
+		msg := fmt.Sprintf("cannot load attachments of [%d] messages for user [%s]", len(pointers), params.UserID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
 	ctxLogger.Info(fmt.Sprintf("fetched [%d] messages with prams [%+#v]", len(*messages), params))
 	return messages, nil
 }
 
+// MessagePage is a page of entities.Message, it has no total so that the query does not count the messages of large accounts
+type MessagePage struct {
+	Messages []*entities.Message `json:"messages"`
+
+	// NextCursor is used to fetch older messages, it is null when there are no more messages
+	NextCursor *string `json:"next_cursor" example:"MTY1NDQzMjM2OTUyNzk3NjAwMDozMjM0M2ExOS1kYTVlLTRiMWItYTc2Ny0zMjk4YTczNzAzY2I"`
+}
+
+// IndexMessages fetches a page of the entities.Message of a user matching the filters from the newest to the oldest
+func (service *MessageService) IndexMessages(ctx context.Context, userID entities.UserID, params repositories.MessageIndexParams) (*MessagePage, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	messages, err := service.repository.IndexPage(ctx, userID, params)
+	if err != nil {
+		msg := fmt.Sprintf("cannot fetch messages for user [%s] with params [%+#v]", userID, params)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if err = service.attachmentService.LoadForMessages(ctx, userID, messages...); err != nil {
+		msg := fmt.Sprintf("cannot load attachments of [%d] messages for user [%s]", len(messages), userID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	page := &MessagePage{Messages: messages}
+	if len(messages) == params.Limit && len(messages) > 0 {
+		last := messages[len(messages)-1]
+		cursor := EncodeEventCursor(repositories.EventCursor{Time: last.OrderTimestamp, ID: last.ID})
+		page.NextCursor = &cursor
+	}
+
+	ctxLogger.Info(fmt.Sprintf("fetched [%d] messages for user [%s]", len(messages), userID))
+	return page, nil
+}
+
 // GetMessage fetches a message by the ID
 func (service *MessageService) GetMessage(ctx context.Context, userID entities.UserID, messageID uuid.UUID) (*entities.Message, error) {
 	ctx, span := service.tracer.Start(ctx)
@@ -131,12 +222,108 @@ func (service *MessageService) GetMessage(ctx context.Context, userID entities.U
 	return message, nil
 }
 
+// UpdateTags sets the names of the entities.Tag of an entities.Message
+func (service *MessageService) UpdateTags(ctx context.Context, userID entities.UserID, messageID uuid.UUID, tags []string) (*entities.Message, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	message, err := service.repository.Load(ctx, userID, messageID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot load message with ID [%s] for user [%s]", messageID, userID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
+	}
+
+	if err = service.repository.UpdateTags(ctx, userID, messageID, tags); err != nil {
+		msg := fmt.Sprintf("cannot update the tags of message with ID [%s]", messageID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	message.Tags = tags
+	ctxLogger.Info(fmt.Sprintf("set [%d] tags on message [%s] for user [%s]", len(tags), messageID, userID))
+	return message, nil
+}
+
+// LoadMessage fetches a message by the ID with the signed download URLs of its attachments
+func (service *MessageService) LoadMessage(ctx context.Context, userID entities.UserID, messageID uuid.UUID) (*entities.Message, error) {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	message, err := service.repository.Load(ctx, userID, messageID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot load message with ID [%s] for user [%s]", messageID, userID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
+	}
+
+	if err = service.attachmentService.LoadForMessages(ctx, userID, message); err != nil {
+		msg := fmt.Sprintf("cannot load attachments of message with ID [%s] for user [%s]", messageID, userID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return message, nil
+}
+
+// MarkRead marks a received entities.Message as read and emits the events.EventTypeMessageRead event so that other clients are updated
+func (service *MessageService) MarkRead(ctx context.Context, source string, userID entities.UserID, messageID uuid.UUID) (*entities.Message, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	message, err := service.repository.Load(ctx, userID, messageID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot load message with ID [%s] for user [%s]", messageID, userID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
+	}
+
+	if message.Type != entities.MessageTypeMobileOriginated {
+		msg := fmt.Sprintf("message with ID [%s] has type [%s] and cannot be marked as read", message.ID, message.Type)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.NewErrorWithCode(ErrCodeMessageNotReceived, msg))
+	}
+
+	if message.ReadAt != nil {
+		ctxLogger.Info(fmt.Sprintf("message [%s] was already read at [%s]", message.ID, message.ReadAt))
+		return message, nil
+	}
+
+	timestamp := time.Now().UTC()
+	updated, err := service.repository.MarkRead(ctx, userID, messageID, timestamp)
+	if err != nil {
+		msg := fmt.Sprintf("cannot mark message with ID [%s] as read", messageID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if !updated {
+		ctxLogger.Info(fmt.Sprintf("message [%s] was marked as read by another request", message.ID))
+		return service.GetMessage(ctx, userID, messageID)
+	}
+
+	message.ReadAt = &timestamp
+	event, err := service.createEvent(events.EventTypeMessageRead, source, &events.MessageReadPayload{
+		MessageIDs: []uuid.UUID{message.ID},
+		Owner:      message.Owner,
+		Contact:    message.Contact,
+		UserID:     message.UserID,
+		Timestamp:  timestamp,
+	})
+	if err != nil {
+		msg := fmt.Sprintf("cannot create event [%s] for message [%s]", events.EventTypeMessageRead, message.ID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if err = service.eventDispatcher.Dispatch(ctx, event); err != nil {
+		msg := fmt.Sprintf("cannot dispatch event type [%s] and id [%s]", event.Type(), event.ID())
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("marked message [%s] as read for user [%s]", message.ID, userID))
+	return message, nil
+}
+
 // MessageStoreEventParams parameters registering a message event
 type MessageStoreEventParams struct {
 	MessageID    uuid.UUID
 	EventName    entities.MessageEventName
 	Timestamp    time.Time
 	ErrorMessage *string
+	FailureCode  entities.MessageFailureCode
 	Source       string
 }
 
@@ -146,6 +333,7 @@ func (service *MessageService) StoreEvent(ctx context.Context, message *entities
 	defer span.End()
 
 	var err error
+	params.Timestamp = service.normalizeTimestamp(ctx, message.UserID, message.Owner, params.Timestamp)
 
 	switch params.EventName {
 	case entities.MessageEventNameSent:
@@ -154,6 +342,8 @@ func (service *MessageService) StoreEvent(ctx context.Context, message *entities
 		err = service.handleMessageDeliveredEvent(ctx, params, message)
 	case entities.MessageEventNameFailed:
 		err = service.handleMessageFailedEvent(ctx, params, message)
+	case entities.MessageEventNameSkippedExpired:
+		err = service.handleMessageSkippedExpiredEvent(ctx, params, message)
 	default:
 		return nil, service.tracer.WrapErrorSpan(span, stacktrace.NewError(fmt.Sprintf("cannot handle message event [%s]", params.EventName)))
 	}
@@ -166,6 +356,253 @@ func (service *MessageService) StoreEvent(ctx context.Context, message *entities
 	return service.repository.Load(ctx, message.UserID, params.MessageID)
 }
 
+// MessageEventBatchResultStatus is the outcome of storing an event in a batch
+type MessageEventBatchResultStatus string
+
+const (
+	// MessageEventBatchResultStatusStored means the event was stored
+	MessageEventBatchResultStatusStored = MessageEventBatchResultStatus("stored")
+
+	// MessageEventBatchResultStatusNotFound means the message of the event does not exist
+	MessageEventBatchResultStatusNotFound = MessageEventBatchResultStatus("not_found")
+
+	// MessageEventBatchResultStatusFailed means the event could not be stored and can be retried
+	MessageEventBatchResultStatusFailed = MessageEventBatchResultStatus("failed")
+)
+
+// MessageEventBatchResult is the result of storing an event from a batch
+type MessageEventBatchResult struct {
+	Index         int                           `json:"index" example:"0"`
+	MessageID     uuid.UUID                     `json:"message_id" example:"32343a19-da5e-4b1b-a767-3298a73703ca"`
+	EventName     entities.MessageEventName     `json:"event_name" example:"SENT"`
+	Status        MessageEventBatchResultStatus `json:"status" example:"stored"`
+	MessageStatus *entities.MessageStatus       `json:"message_status" example:"sent"`
+}
+
+// StoreEventBatch stores the events reported by a mobile phone in a single request.
+// The events are stored in the order in which they were emitted so a SENT event is handled before the DELIVERED event of the same message,
+// an event which cannot be stored does not stop the other events from being stored.
+func (service *MessageService) StoreEventBatch(ctx context.Context, userID entities.UserID, params []MessageStoreEventParams) []*MessageEventBatchResult {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	order := make([]int, len(params))
+	for index := range params {
+		order[index] = index
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return params[order[i]].Timestamp.Before(params[order[j]].Timestamp)
+	})
+
+	results := make([]*MessageEventBatchResult, len(params))
+	for _, index := range order {
+		results[index] = service.storeBatchEvent(ctx, userID, index, params[index])
+	}
+
+	ctxLogger.Info(fmt.Sprintf("stored batch of [%d] message events for user [%s]", len(params), userID))
+	return results
+}
+
+func (service *MessageService) storeBatchEvent(ctx context.Context, userID entities.UserID, index int, params MessageStoreEventParams) *MessageEventBatchResult {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	result := &MessageEventBatchResult{
+		Index:     index,
+		MessageID: params.MessageID,
+		EventName: params.EventName,
+		Status:    MessageEventBatchResultStatusFailed,
+	}
+
+	message, err := service.repository.Load(ctx, userID, params.MessageID)
+	if err != nil && stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		result.Status = MessageEventBatchResultStatusNotFound
+		return result
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot load message [%s] for event [%s] in index [%d]", params.MessageID, params.EventName, index)
+		ctxLogger.Error(service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg)))
+		return result
+	}
+
+	message, err = service.StoreEvent(ctx, message, params)
+	if err != nil {
+		msg := fmt.Sprintf("cannot store event [%s] for message [%s] in index [%d]", params.EventName, params.MessageID, index)
+		ctxLogger.Error(service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg)))
+		return result
+	}
+
+	result.Status = MessageEventBatchResultStatusStored
+	result.MessageStatus = &message.Status
+	return result
+}
+
+// MessageReconcileState is the state of a message in the outbox of the phone
+type MessageReconcileState string
+
+const (
+	// MessageReconcileStatePending means the phone has the message in its outbox but has not sent it
+	MessageReconcileStatePending = MessageReconcileState("pending")
+
+	// MessageReconcileStateSent means the phone sent the message
+	MessageReconcileStateSent = MessageReconcileState("sent")
+
+	// MessageReconcileStateDelivered means the phone received the delivery report of the message
+	MessageReconcileStateDelivered = MessageReconcileState("delivered")
+
+	// MessageReconcileStateFailed means the phone could not send the message
+	MessageReconcileStateFailed = MessageReconcileState("failed")
+)
+
+// MessageReconcileAction is what the phone must do with a message after reconciliation
+type MessageReconcileAction string
+
+const (
+	// MessageReconcileActionNone means the phone and the server agree on the state of the message
+	MessageReconcileActionNone = MessageReconcileAction("none")
+
+	// MessageReconcileActionSend means the phone should send the pending message
+	MessageReconcileActionSend = MessageReconcileAction("send")
+
+	// MessageReconcileActionDiscard means the phone must remove the message from its outbox without sending it
+	MessageReconcileActionDiscard = MessageReconcileAction("discard")
+
+	// MessageReconcileActionRetry means the server could not reconcile the message and the phone should report it again later
+	MessageReconcileActionRetry = MessageReconcileAction("retry")
+)
+
+// MessageReconcileItem is the state of a message reported by the phone
+type MessageReconcileItem struct {
+	MessageID    uuid.UUID
+	State        MessageReconcileState
+	Timestamp    time.Time
+	ErrorMessage *string
+	FailureCode  entities.MessageFailureCode
+}
+
+// MessageReconcileParams are parameters for reconciling the outbox of a phone
+type MessageReconcileParams struct {
+	UserID entities.UserID
+	Owner  string
+	Source string
+	Items  []MessageReconcileItem
+}
+
+// MessageReconcileResult is the corrective action for a message reported by the phone
+type MessageReconcileResult struct {
+	MessageID uuid.UUID               `json:"message_id" example:"32343a19-da5e-4b1b-a767-3298a73703ca"`
+	Action    MessageReconcileAction  `json:"action" example:"discard"`
+	Status    *entities.MessageStatus `json:"status" example:"expired"`
+	Reason    string                  `json:"reason" example:"the message is [expired] on the server"`
+}
+
+// Reconcile compares the outbox of a phone which was offline with the messages on the server.
+// The state reported by the phone is stored when the phone has progressed further than the server, e.g. it sent a message
+// which the server still considers to be sending. A message which the phone has not sent is only sent when the server
+// still expects it to be sent, this prevents sending a message again after it expired or was sent by another attempt.
+func (service *MessageService) Reconcile(ctx context.Context, params *MessageReconcileParams) []*MessageReconcileResult {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	order := make([]int, len(params.Items))
+	for index := range params.Items {
+		order[index] = index
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return params.Items[order[i]].Timestamp.Before(params.Items[order[j]].Timestamp)
+	})
+
+	results := make([]*MessageReconcileResult, len(params.Items))
+	for _, index := range order {
+		results[index] = service.reconcileItem(ctx, params, params.Items[index])
+	}
+
+	ctxLogger.Info(fmt.Sprintf("reconciled [%d] messages in the outbox of [%s] for user [%s]", len(params.Items), params.Owner, params.UserID))
+	return results
+}
+
+func (service *MessageService) reconcileItem(ctx context.Context, params *MessageReconcileParams, item MessageReconcileItem) *MessageReconcileResult {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	result := &MessageReconcileResult{MessageID: item.MessageID}
+
+	message, err := service.repository.Load(ctx, params.UserID, item.MessageID)
+	if (err != nil && stacktrace.GetCode(err) == repositories.ErrCodeNotFound) || (err == nil && message.Owner != params.Owner) {
+		result.Action = MessageReconcileActionDiscard
+		result.Reason = fmt.Sprintf("the message does not exist for the phone [%s]", params.Owner)
+		return result
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot load message [%s] for user [%s]", item.MessageID, params.UserID)
+		ctxLogger.Error(service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg)))
+		result.Action = MessageReconcileActionRetry
+		result.Reason = "the message cannot be loaded"
+		return result
+	}
+
+	if item.State == MessageReconcileStatePending {
+		return service.reconcilePendingItem(message, result)
+	}
+
+	if !service.isReconcileEventRequired(message, item.State) {
+		result.Action = MessageReconcileActionNone
+		result.Status = &message.Status
+		result.Reason = fmt.Sprintf("the message is [%s] on the server", message.Status)
+		return result
+	}
+
+	message, err = service.StoreEvent(ctx, message, MessageStoreEventParams{
+		MessageID:    message.ID,
+		EventName:    entities.MessageEventName(strings.ToUpper(string(item.State))),
+		Timestamp:    item.Timestamp,
+		ErrorMessage: item.ErrorMessage,
+		FailureCode:  item.FailureCode,
+		Source:       params.Source,
+	})
+	if err != nil {
+		msg := fmt.Sprintf("cannot store [%s] state of message [%s] reported by [%s]", item.State, item.MessageID, params.Owner)
+		ctxLogger.Error(service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg)))
+		result.Action = MessageReconcileActionRetry
+		result.Reason = fmt.Sprintf("the [%s] state of the message cannot be stored", item.State)
+		return result
+	}
+
+	result.Action = MessageReconcileActionNone
+	result.Status = &message.Status
+	result.Reason = fmt.Sprintf("the message was updated to [%s] on the server", message.Status)
+	return result
+}
+
+// reconcilePendingItem allows the phone to send a message in its outbox only if the server is still waiting for it to be sent
+func (service *MessageService) reconcilePendingItem(message *entities.Message, result *MessageReconcileResult) *MessageReconcileResult {
+	result.Status = &message.Status
+	if message.IsPending() || message.IsScheduled() || message.IsSending() {
+		result.Action = MessageReconcileActionSend
+		result.Reason = fmt.Sprintf("the message is [%s] on the server", message.Status)
+		return result
+	}
+
+	result.Action = MessageReconcileActionDiscard
+	result.Reason = fmt.Sprintf("the message is [%s] on the server and must not be sent", message.Status)
+	return result
+}
+
+// isReconcileEventRequired checks if the state reported by the phone is newer than the status of the message on the server
+func (service *MessageService) isReconcileEventRequired(message *entities.Message, state MessageReconcileState) bool {
+	if message.IsDelivered() || message.IsFailed() {
+		return false
+	}
+
+	if message.IsSent() || message.IsDeliveryUnknown() {
+		return state != MessageReconcileStateSent
+	}
+
+	return true
+}
+
 // MessageReceiveParams parameters registering a message event
 type MessageReceiveParams struct {
 	Contact   string
@@ -175,6 +612,9 @@ type MessageReceiveParams struct {
 	SIM       entities.SIM
 	Timestamp time.Time
 	Source    string
+
+	// Attachments of an MMS message
+	Attachments []AttachmentUpload
 }
 
 // ReceiveMessage handles message received by a mobile phone
@@ -184,6 +624,23 @@ func (service *MessageService) ReceiveMessage(ctx context.Context, params Messag
 
 	ctxLogger := service.tracer.CtxLogger(service.logger, span)
 
+	params.Timestamp = service.normalizeTimestamp(ctx, params.UserID, phonenumbers.Format(&params.Owner, phonenumbers.E164), params.Timestamp)
+
+	duplicate, err := service.receivedDuplicate(ctx, params)
+	if err == nil {
+		if err = service.repository.Update(ctx, duplicate.AddDuplicateCount()); err != nil {
+			msg := fmt.Sprintf("cannot update duplicate count of message with id [%s]", duplicate.ID)
+			return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+		}
+		ctxLogger.Info(fmt.Sprintf("collapsed duplicate received message into message with id [%s] and duplicate count [%d]", duplicate.ID, duplicate.DuplicateCount))
+		return duplicate, nil
+	}
+
+	if stacktrace.GetCode(err) != repositories.ErrCodeNotFound {
+		msg := fmt.Sprintf("cannot check for duplicate of message from [%s] for user [%s]", params.Contact, params.UserID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
 	eventPayload := events.MessagePhoneReceivedPayload{
 		MessageID: uuid.New(),
 		UserID:    params.UserID,
@@ -194,6 +651,21 @@ func (service *MessageService) ReceiveMessage(ctx context.Context, params Messag
 		SIM:       params.SIM,
 	}
 
+	eventPayload.ConversationID = service.replyConversationID(ctx, params.UserID, eventPayload.Owner, params.Contact, params.Timestamp)
+
+	if len(params.Attachments) > 0 {
+		eventPayload.Attachments, err = service.attachmentService.Store(ctx, &AttachmentStoreParams{
+			Source:    params.Source,
+			UserID:    params.UserID,
+			MessageID: eventPayload.MessageID,
+			Uploads:   params.Attachments,
+		})
+		if err != nil {
+			msg := fmt.Sprintf("cannot store [%d] attachments of received message with ID [%s]", len(params.Attachments), eventPayload.MessageID)
+			return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+		}
+	}
+
 	ctxLogger.Info(fmt.Sprintf("creating cloud event for received with ID [%s]", eventPayload.MessageID))
 
 	event, err := service.createMessagePhoneReceivedEvent(params.Source, eventPayload)
@@ -213,6 +685,61 @@ func (service *MessageService) ReceiveMessage(ctx context.Context, params Messag
 	return service.storeReceivedMessage(ctx, eventPayload)
 }
 
+// replyConversationID returns the conversation ID of the last message sent to the contact within the conversation window
+func (service *MessageService) replyConversationID(ctx context.Context, userID entities.UserID, owner string, contact string, timestamp time.Time) *string {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	message, err := service.repository.LoadLastInConversation(ctx, userID, owner, contact, timestamp.Add(-messageConversationWindow))
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		return nil
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot load last message in a conversation from owner [%s] to contact [%s]", owner, contact)
+		ctxLogger.Error(service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg)))
+		return nil
+	}
+
+	return message.ConversationID
+}
+
+// normalizeTimestamp corrects a timestamp reported by the phone with the clock offset of the phone, the offset is sampled from heartbeats only
+func (service *MessageService) normalizeTimestamp(ctx context.Context, userID entities.UserID, owner string, timestamp time.Time) time.Time {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	phone, err := service.phoneService.Load(ctx, userID, owner)
+	if err != nil {
+		msg := fmt.Sprintf("cannot normalize timestamp [%s] for userID [%s] and owner [%s]", timestamp, userID, owner)
+		ctxLogger.Error(service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg)))
+		return timestamp
+	}
+
+	return phone.NormalizeTimestamp(timestamp)
+}
+
+// receivedDuplicate loads a message which was already received within the duplicate window of the phone
+func (service *MessageService) receivedDuplicate(ctx context.Context, params MessageReceiveParams) (*entities.Message, error) {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	owner := phonenumbers.Format(&params.Owner, phonenumbers.E164)
+
+	phone, err := service.phoneService.Load(ctx, params.UserID, owner)
+	if err != nil {
+		msg := fmt.Sprintf("cannot load phone for userID [%s] and owner [%s]", params.UserID, owner)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if phone.DuplicateMessageWindow() == 0 {
+		msg := fmt.Sprintf("duplicate collapse is disabled for phone with id [%s]", phone.ID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.NewErrorWithCode(repositories.ErrCodeNotFound, msg))
+	}
+
+	return service.repository.LoadReceivedDuplicate(ctx, params.UserID, owner, params.Contact, params.Content, params.Timestamp.Add(-phone.DuplicateMessageWindow()))
+}
+
 func (service *MessageService) handleMessageSentEvent(ctx context.Context, params MessageStoreEventParams, message *entities.Message) error {
 	ctx, span := service.tracer.Start(ctx)
 	defer span.End()
@@ -235,108 +762,841 @@ func (service *MessageService) handleMessageSentEvent(ctx context.Context, param
 		msg := fmt.Sprintf("cannot dispatch event type [%s] and id [%s]", event.Type(), event.ID())
 		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
 	}
-	return nil
+	return nil
+}
+
+func (service *MessageService) handleMessageDeliveredEvent(ctx context.Context, params MessageStoreEventParams, message *entities.Message) error {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	event, err := service.createMessagePhoneDeliveredEvent(params.Source, events.MessagePhoneDeliveredPayload{
+		ID:        message.ID,
+		Owner:     message.Owner,
+		UserID:    message.UserID,
+		Timestamp: params.Timestamp,
+		Contact:   message.Contact,
+		Content:   message.Content,
+		SIM:       message.SIM,
+	})
+	if err != nil {
+		msg := fmt.Sprintf("cannot create event [%s] for message [%s]", events.EventTypeMessagePhoneSent, message.ID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if err = service.eventDispatcher.Dispatch(ctx, event); err != nil {
+		msg := fmt.Sprintf("cannot dispatch event type [%s] and id [%s]", event.Type(), event.ID())
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+	return nil
+}
+
+func (service *MessageService) handleMessageSkippedExpiredEvent(ctx context.Context, params MessageStoreEventParams, message *entities.Message) error {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	if !message.IsPending() && !message.IsSending() && !message.IsScheduled() {
+		ctxLogger.Info(fmt.Sprintf("ignoring [%s] event for message with ID [%s] and status [%s]", params.EventName, message.ID, message.Status))
+		return nil
+	}
+
+	event, err := service.createMessageSendExpiredEvent(params.Source, events.MessageSendExpiredPayload{
+		MessageID: message.ID,
+		Owner:     message.Owner,
+		Contact:   message.Contact,
+		UserID:    message.UserID,
+		Timestamp: params.Timestamp,
+		Content:   message.Content,
+		SIM:       message.SIM,
+	})
+	if err != nil {
+		msg := fmt.Sprintf("cannot create event [%s] for message with id [%s]", events.EventTypeMessageSendExpired, message.ID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if err = service.eventDispatcher.Dispatch(ctx, event); err != nil {
+		msg := fmt.Sprintf("cannot dispatch event [%s] for message with ID [%s]", event.Type(), message.ID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("message [%s] was skipped by the phone because it expired", message.ID))
+	return nil
+}
+
+func (service *MessageService) handleMessageFailedEvent(ctx context.Context, params MessageStoreEventParams, message *entities.Message) error {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	errorMessage := "UNKNOWN ERROR"
+	if params.ErrorMessage != nil {
+		errorMessage = *params.ErrorMessage
+	}
+
+	event, err := service.createMessageSendFailedEvent(params.Source, events.MessageSendFailedPayload{
+		ID:           message.ID,
+		Owner:        message.Owner,
+		ErrorMessage: errorMessage,
+		FailureCode:  params.FailureCode,
+		Timestamp:    params.Timestamp,
+		Contact:      message.Contact,
+		UserID:       message.UserID,
+		Content:      message.Content,
+		SIM:          message.SIM,
+	})
+	if err != nil {
+		msg := fmt.Sprintf("cannot create event [%s] for message [%s]", events.EventTypeMessageSendFailed, message.ID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if err = service.eventDispatcher.Dispatch(ctx, event); err != nil {
+		msg := fmt.Sprintf("cannot dispatch event type [%s] and id [%s]", event.Type(), event.ID())
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+	return nil
+}
+
+// MessageSendParams parameters for sending a new message
+type MessageSendParams struct {
+	// MessageID is generated by the client so that a request can be retried safely, a new ID is generated when it is nil
+	MessageID *uuid.UUID
+
+	Owner             phonenumbers.PhoneNumber
+	Contact           string
+	Content           string
+	Source            string
+	SIM               entities.SIM
+	UserID            entities.UserID
+	RequestReceivedAt time.Time
+	BatchID           *uuid.UUID
+	SendAt            *time.Time
+
+	// Attachments of an MMS message
+	Attachments []AttachmentUpload
+
+	// ConversationID groups the message with the replies from the contact
+	ConversationID *string
+
+	// ExpiresIn is the expiration of the message in seconds, the default of the user is used when it is nil
+	ExpiresIn *uint
+
+	// Priority determines the order in which the waiting messages of the phone are dispatched
+	Priority entities.MessagePriority
+
+	// AfterMessageID is the message which must be sent before this message is dispatched to the phone
+	AfterMessageID *uuid.UUID
+
+	// TemplateID is the entities.Template which was used for the content of the message
+	TemplateID *uuid.UUID
+}
+
+// SendMessage a new message
+func (service *MessageService) SendMessage(ctx context.Context, params MessageSendParams) (*entities.Message, error) {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	ctxLogger := service.tracer.CtxLogger(service.logger, span)
+
+	user, err := service.userRepository.Load(ctx, params.UserID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot load user with ID [%s]", params.UserID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if user.IsSuspended() {
+		msg := fmt.Sprintf("user [%s] cannot send a message because the account is suspended with reason [%s]", user.ID, *user.SuspensionReason)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.NewErrorWithCode(ErrCodeUserSuspended, msg))
+	}
+
+	messageID := uuid.New()
+	if params.MessageID != nil {
+		existing, err := service.loadExisting(ctx, params)
+		if err != nil {
+			msg := fmt.Sprintf("cannot check if message with ID [%s] exists for user [%s]", *params.MessageID, params.UserID)
+			return nil, service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
+		}
+
+		if existing != nil {
+			ctxLogger.Info(fmt.Sprintf("message with id [%s] has already been sent by user [%s]", existing.ID, params.UserID))
+			return existing, nil
+		}
+		messageID = *params.MessageID
+	}
+
+	eventPayload := events.MessageAPISentPayload{
+		MessageID:         messageID,
+		UserID:            params.UserID,
+		MaxSendAttempts:   service.maxSendAttempts(ctx, params.UserID, phonenumbers.Format(&params.Owner, phonenumbers.E164)),
+		Owner:             phonenumbers.Format(&params.Owner, phonenumbers.E164),
+		Contact:           params.Contact,
+		RequestReceivedAt: params.RequestReceivedAt,
+		Content:           params.Content,
+		SIM:               params.SIM,
+		BatchID:           params.BatchID,
+		SendAt:            params.SendAt,
+		AttachmentCount:   uint(len(params.Attachments)),
+		ConversationID:    params.ConversationID,
+		ExpiresIn:         params.ExpiresIn,
+		Priority:          params.Priority.OrDefault(),
+		AfterMessageID:    params.AfterMessageID,
+		TemplateID:        params.TemplateID,
+	}
+
+	if eventPayload.ExpiresIn == nil && user.MessageExpirationTimeout > 0 {
+		eventPayload.ExpiresIn = &user.MessageExpirationTimeout
+	}
+
+	blocked, err := service.blocklistRepository.Existing(ctx, params.UserID, []string{params.Contact})
+	if err != nil {
+		msg := fmt.Sprintf("cannot check if contact [%s] is on the blocklist of user [%s]", params.Contact, params.UserID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if len(blocked) > 0 {
+		ctxLogger.Info(fmt.Sprintf("message with id [%s] is not sent because contact [%s] is on the blocklist of user [%s]", eventPayload.MessageID, params.Contact, params.UserID))
+		return service.storedOrExisting(ctx, params)(service.storeOptedOutMessage(ctx, eventPayload))
+	}
+
+	if len(params.Attachments) > 0 {
+		attachments, err := service.attachmentService.Store(ctx, &AttachmentStoreParams{
+			Source:            params.Source,
+			UserID:            params.UserID,
+			MessageID:         eventPayload.MessageID,
+			Uploads:           params.Attachments,
+			RejectQuarantined: true,
+		})
+		if err != nil {
+			msg := fmt.Sprintf("cannot store [%d] attachments of message with ID [%s]", len(params.Attachments), eventPayload.MessageID)
+			return nil, service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
+		}
+		eventPayload.Attachments = attachments
+	}
+
+	if params.AfterMessageID != nil {
+		predecessor, err := service.loadPredecessor(ctx, params.UserID, *params.AfterMessageID)
+		if err != nil {
+			msg := fmt.Sprintf("cannot load predecessor [%s] of message with id [%s]", *params.AfterMessageID, eventPayload.MessageID)
+			return nil, service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
+		}
+
+		if predecessor.IsFailed() {
+			ctxLogger.Info(fmt.Sprintf("message with id [%s] is not sent because its predecessor [%s] failed", eventPayload.MessageID, predecessor.ID))
+			return service.storedOrExisting(ctx, params)(service.storeDependencyFailedMessage(ctx, eventPayload))
+		}
+
+		if !predecessor.IsSent() && !predecessor.IsDelivered() {
+			ctxLogger.Info(fmt.Sprintf("message with id [%s] is waiting for predecessor [%s] with status [%s]", eventPayload.MessageID, predecessor.ID, predecessor.Status))
+			return service.storedOrExisting(ctx, params)(service.storeAwaitingMessage(ctx, params.Source, eventPayload))
+		}
+	}
+
+	if params.SendAt != nil && params.SendAt.After(time.Now().UTC()) {
+		ctxLogger.Info(fmt.Sprintf("message with id [%s] is queued to be sent at [%s]", eventPayload.MessageID, params.SendAt.UTC()))
+		return service.storedOrExisting(ctx, params)(service.storeSentMessage(ctx, eventPayload))
+	}
+
+	// the message is stored before it is dispatched so that a concurrent retry with the same ID is never sent twice
+	message, err := service.storeSentMessage(ctx, eventPayload)
+	if err != nil {
+		return service.storedOrExisting(ctx, params)(message, err)
+	}
+
+	if err = service.creditService.DebitMessage(ctx, &eventPayload); err != nil {
+		msg := fmt.Sprintf("cannot debit the credits of message with id [%s] for user [%s]", eventPayload.MessageID, params.UserID)
+		return nil, service.tracer.WrapErrorSpan(span, service.failUndispatched(ctx, message, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg)))
+	}
+
+	event, err := service.createMessageAPISentEvent(params.Source, eventPayload)
+	if err != nil {
+		msg := fmt.Sprintf("cannot create %T from payload with message id [%s]", event, eventPayload.MessageID)
+		return nil, service.tracer.WrapErrorSpan(span, service.failUndispatched(ctx, message, stacktrace.Propagate(err, msg)))
+	}
+	ctxLogger.Info(fmt.Sprintf("created event [%s] with id [%s] and message id [%s]", event.Type(), event.ID(), eventPayload.MessageID))
+
+	if err = service.eventDispatcher.Dispatch(ctx, event); err != nil {
+		msg := fmt.Sprintf("cannot dispatch event type [%s] and id [%s]", event.Type(), event.ID())
+		return nil, service.tracer.WrapErrorSpan(span, service.failUndispatched(ctx, message, stacktrace.Propagate(err, msg)))
+	}
+	ctxLogger.Info(fmt.Sprintf("event [%s] dispatched succesfully", event.ID()))
+
+	return message, nil
+}
+
+// DispatchDue sends the messages which are queued for the future to the phone once their SendAt time has passed
+func (service *MessageService) DispatchDue(ctx context.Context, source string, timestamp time.Time, limit int) (int, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	messages, err := service.repository.FetchDue(ctx, timestamp, limit)
+	if err != nil {
+		msg := fmt.Sprintf("cannot fetch messages which are due at [%s]", timestamp)
+		return 0, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	count := 0
+	for _, message := range messages {
+		promoted, err := service.repository.Promote(ctx, message.UserID, message.ID)
+		if err != nil {
+			msg := fmt.Sprintf("cannot promote message with ID [%s] for user [%s]", message.ID, message.UserID)
+			return count, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+		}
+
+		if !promoted {
+			ctxLogger.Info(fmt.Sprintf("message with ID [%s] has already been promoted", message.ID))
+			continue
+		}
+
+		dispatched, err := service.dispatchPromoted(ctx, source, message)
+		if err != nil {
+			msg := fmt.Sprintf("cannot dispatch promoted message with ID [%s] for user [%s]", message.ID, message.UserID)
+			return count, service.tracer.WrapErrorSpan(span, service.demote(ctx, message, stacktrace.Propagate(err, msg)))
+		}
+
+		if dispatched {
+			count++
+		}
+	}
+
+	return count, nil
+}
+
+// dispatchPromoted debits the credits of a message which was promoted from the future and dispatches it to the phone.
+// It returns false when the message is failed because the user does not have enough credits.
+func (service *MessageService) dispatchPromoted(ctx context.Context, source string, message *entities.Message) (bool, error) {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	debited, err := service.debitStored(ctx, message)
+	if err != nil {
+		msg := fmt.Sprintf("cannot debit the credits of message with ID [%s] for user [%s]", message.ID, message.UserID)
+		return false, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if !debited {
+		return false, nil
+	}
+
+	if err = service.attachmentService.LoadForMessages(ctx, message.UserID, message); err != nil {
+		msg := fmt.Sprintf("cannot load attachments of message with ID [%s] for user [%s]", message.ID, message.UserID)
+		return false, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	event, err := service.createMessageAPISentEvent(source, service.storedMessagePayload(message))
+	if err != nil {
+		msg := fmt.Sprintf("cannot create [%s] event for message with ID [%s]", events.EventTypeMessageAPISent, message.ID)
+		return false, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if err = service.eventDispatcher.Dispatch(ctx, event); err != nil {
+		msg := fmt.Sprintf("cannot dispatch event type [%s] and id [%s]", event.Type(), event.ID())
+		return false, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return true, nil
+}
+
+// demote queues a promoted message for the future again after it could not be dispatched so that the next tick retries it.
+// The credits of the message are debited only once because the debit is idempotent for the ID of the message.
+func (service *MessageService) demote(ctx context.Context, message *entities.Message, cause error) error {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	demoted, err := service.repository.Demote(ctx, message.UserID, message.ID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot queue message with ID [%s] for the future after it was not dispatched", message.ID)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return cause
+	}
+
+	if demoted {
+		ctxLogger.Info(fmt.Sprintf("message with ID [%s] is queued for the future again after it was not dispatched", message.ID))
+	}
+	return cause
+}
+
+// loadExisting loads the entities.Message with the client generated ID of a send request which is retried.
+// It returns nil when the message does not exist and ErrCodeMessageIDExists when the ID is used by a different message.
+func (service *MessageService) loadExisting(ctx context.Context, params MessageSendParams) (*entities.Message, error) {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	message, err := service.repository.Load(ctx, params.UserID, *params.MessageID)
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		return nil, service.checkArchived(ctx, params.UserID, *params.MessageID)
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot load message with ID [%s] for user [%s]", *params.MessageID, params.UserID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if message.Type != entities.MessageTypeMobileTerminated ||
+		message.Owner != phonenumbers.Format(&params.Owner, phonenumbers.E164) ||
+		message.Contact != params.Contact ||
+		message.Content != params.Content {
+		msg := fmt.Sprintf("the ID [%s] is used by a different message of user [%s]", message.ID, params.UserID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.NewErrorWithCode(ErrCodeMessageIDExists, msg))
+	}
+
+	if err = service.attachmentService.LoadForMessages(ctx, params.UserID, message); err != nil {
+		msg := fmt.Sprintf("cannot load attachments of message with ID [%s] for user [%s]", message.ID, params.UserID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return message, nil
+}
+
+// checkArchived returns ErrCodeMessageIDExists when a client generated ID is used by an archived message of the user
+func (service *MessageService) checkArchived(ctx context.Context, userID entities.UserID, messageID uuid.UUID) error {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	_, err := service.archivedMessageRepository.Load(ctx, userID, messageID)
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		return nil
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot load archived message with ID [%s] for user [%s]", messageID, userID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	msg := fmt.Sprintf("the ID [%s] is used by an archived message of user [%s]", messageID, userID)
+	return service.tracer.WrapErrorSpan(span, stacktrace.NewErrorWithCode(ErrCodeMessageIDExists, msg))
+}
+
+// storedOrExisting returns the message which was stored for a send request. When a concurrent retry with the same
+// client generated ID stored its message first, the existing message is returned without dispatching it again.
+func (service *MessageService) storedOrExisting(ctx context.Context, params MessageSendParams) func(message *entities.Message, err error) (*entities.Message, error) {
+	return func(message *entities.Message, err error) (*entities.Message, error) {
+		if err == nil {
+			return message, nil
+		}
+
+		if params.MessageID == nil || stacktrace.GetCode(err) != repositories.ErrCodeMessageExists {
+			return nil, err
+		}
+
+		ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+		defer span.End()
+
+		existing, err := service.loadExisting(ctx, params)
+		if err != nil {
+			msg := fmt.Sprintf("cannot load message with ID [%s] which was stored by a concurrent request of user [%s]", *params.MessageID, params.UserID)
+			return nil, service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
+		}
+
+		if existing == nil {
+			msg := fmt.Sprintf("the ID [%s] is used by a message which does not belong to user [%s]", *params.MessageID, params.UserID)
+			return nil, service.tracer.WrapErrorSpan(span, stacktrace.NewErrorWithCode(ErrCodeMessageIDExists, msg))
+		}
+
+		ctxLogger.Info(fmt.Sprintf("message with id [%s] has already been stored by a concurrent request of user [%s]", existing.ID, params.UserID))
+		return existing, nil
+	}
+}
+
+// failUndispatched fails a stored message which could not be dispatched to the phone so that a retry with the same ID does not return a message which is never sent
+func (service *MessageService) failUndispatched(ctx context.Context, message *entities.Message, cause error) error {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	code, reason := entities.MessageFailureCodeGenericFailure, "the message could not be dispatched to the phone"
+	if stacktrace.GetCode(cause) == ErrCodeInsufficientCredits {
+		code, reason = entities.MessageFailureCodeInsufficientCredits, "the user does not have enough credits to send the message"
+	}
+
+	message.Failed(time.Now().UTC(), code, reason)
+	if err := service.repository.Update(ctx, message); err != nil {
+		msg := fmt.Sprintf("cannot update message with ID [%s] as failed", message.ID)
+		service.tracer.CtxLogger(service.logger, span).Error(stacktrace.Propagate(err, msg))
+	}
+	return cause
+}
+
+// debitStored debits the credits of a stored message before it is dispatched to the phone.
+// The message is failed and false is returned when the user does not have enough credits to send it.
+func (service *MessageService) debitStored(ctx context.Context, message *entities.Message) (bool, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	payload := service.storedMessagePayload(message)
+	err := service.creditService.DebitMessage(ctx, &payload)
+	if err == nil {
+		return true, nil
+	}
+
+	if stacktrace.GetCode(err) != ErrCodeInsufficientCredits {
+		msg := fmt.Sprintf("cannot debit the credits of message with ID [%s] for user [%s]", message.ID, message.UserID)
+		return false, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	message.Failed(time.Now().UTC(), entities.MessageFailureCodeInsufficientCredits, "the user does not have enough credits to send the message")
+	if err = service.repository.Update(ctx, message); err != nil {
+		msg := fmt.Sprintf("cannot update message with ID [%s] as failed", message.ID)
+		return false, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("failed message with ID [%s] because user [%s] does not have enough credits", message.ID, message.UserID))
+
+	if err = service.failSuccessors(ctx, message); err != nil {
+		msg := fmt.Sprintf("cannot fail the messages waiting for message with ID [%s]", message.ID)
+		return false, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+	return false, nil
+}
+
+// loadPredecessor loads the outgoing entities.Message which a new message is sent after
+func (service *MessageService) loadPredecessor(ctx context.Context, userID entities.UserID, messageID uuid.UUID) (*entities.Message, error) {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	predecessor, err := service.repository.Load(ctx, userID, messageID)
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		msg := fmt.Sprintf("cannot find predecessor message with ID [%s] for user [%s]", messageID, userID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, ErrCodeMessagePredecessorInvalid, msg))
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot load predecessor message with ID [%s] for user [%s]", messageID, userID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if predecessor.Type != entities.MessageTypeMobileTerminated {
+		msg := fmt.Sprintf("predecessor message with ID [%s] for user [%s] has type [%s]", messageID, userID, predecessor.Type)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.NewErrorWithCode(ErrCodeMessagePredecessorInvalid, msg))
+	}
+
+	return predecessor, nil
+}
+
+// releaseSuccessors dispatches the messages which are waiting for a message to be sent
+func (service *MessageService) releaseSuccessors(ctx context.Context, source string, predecessor *entities.Message) error {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	messages, err := service.repository.FetchAwaiting(ctx, predecessor.UserID, predecessor.ID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot fetch messages waiting for message with ID [%s]", predecessor.ID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	for _, message := range messages {
+		var status entities.MessageStatus = entities.MessageStatusPending
+		if message.SendAt != nil && message.SendAt.After(time.Now().UTC()) {
+			status = entities.MessageStatusQueuedForFuture
+		}
+
+		released, err := service.repository.Release(ctx, message.UserID, message.ID, status)
+		if err != nil {
+			msg := fmt.Sprintf("cannot release message with ID [%s] for user [%s]", message.ID, message.UserID)
+			return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+		}
+
+		if !released || status == entities.MessageStatusQueuedForFuture {
+			continue
+		}
+
+		debited, err := service.debitStored(ctx, message)
+		if err != nil {
+			msg := fmt.Sprintf("cannot debit the credits of message with ID [%s] for user [%s]", message.ID, message.UserID)
+			return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+		}
+
+		if !debited {
+			continue
+		}
+
+		if err = service.attachmentService.LoadForMessages(ctx, message.UserID, message); err != nil {
+			msg := fmt.Sprintf("cannot load attachments of message with ID [%s] for user [%s]", message.ID, message.UserID)
+			return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+		}
+
+		event, err := service.createMessageAPISentEvent(source, service.storedMessagePayload(message))
+		if err != nil {
+			msg := fmt.Sprintf("cannot create [%s] event for message with ID [%s]", events.EventTypeMessageAPISent, message.ID)
+			return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+		}
+
+		if err = service.eventDispatcher.Dispatch(ctx, event); err != nil {
+			msg := fmt.Sprintf("cannot dispatch event type [%s] and id [%s]", event.Type(), event.ID())
+			return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+		}
+
+		ctxLogger.Info(fmt.Sprintf("released message with ID [%s] after predecessor [%s] was sent", message.ID, predecessor.ID))
+	}
+
+	return nil
+}
+
+// failSuccessors fails the messages which are waiting for a message which will never be sent
+func (service *MessageService) failSuccessors(ctx context.Context, predecessor *entities.Message) error {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	messages, err := service.repository.FetchAwaiting(ctx, predecessor.UserID, predecessor.ID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot fetch messages waiting for message with ID [%s]", predecessor.ID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	for _, message := range messages {
+		message.Failed(time.Now().UTC(), entities.MessageFailureCodeDependencyFailed, fmt.Sprintf("the message [%s] which this message is sent after failed", predecessor.ID))
+		if err = service.repository.Update(ctx, message); err != nil {
+			msg := fmt.Sprintf("cannot update message with ID [%s] as failed", message.ID)
+			return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+		}
+
+		ctxLogger.Info(fmt.Sprintf("failed message with ID [%s] because predecessor [%s] failed", message.ID, predecessor.ID))
+
+		// the messages waiting for this message will also never be sent
+		if err = service.failSuccessors(ctx, message); err != nil {
+			msg := fmt.Sprintf("cannot fail the messages waiting for message with ID [%s]", message.ID)
+			return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+		}
+	}
+
+	return nil
+}
+
+// PauseMessages pauses the messages of a user which are waiting for the phone after the account is suspended
+func (service *MessageService) PauseMessages(ctx context.Context, userID entities.UserID) error {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	count, err := service.repository.Pause(ctx, userID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot pause the messages of user [%s]", userID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("paused [%d] messages of suspended user [%s]", count, userID))
+	return nil
+}
+
+// ResumeMessages sends the paused messages of a user to the phone again after the account is reactivated
+func (service *MessageService) ResumeMessages(ctx context.Context, source string, userID entities.UserID) error {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	count := 0
+	for {
+		messages, err := service.repository.FetchPaused(ctx, userID, messageSchedulerBatchSize)
+		if err != nil {
+			msg := fmt.Sprintf("cannot fetch paused messages of user [%s]", userID)
+			return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+		}
+
+		for _, message := range messages {
+			resumed, err := service.repository.Resume(ctx, userID, message.ID)
+			if err != nil {
+				msg := fmt.Sprintf("cannot resume message with ID [%s] for user [%s]", message.ID, userID)
+				return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+			}
+
+			if !resumed {
+				continue
+			}
+
+			// the message was already registered when it was sent through the API so it is dispatched like a retry
+			event, err := service.createMessageSendRetryEvent(source, &events.MessageSendRetryPayload{
+				MessageID: message.ID,
+				Timestamp: time.Now().UTC(),
+				Contact:   message.Contact,
+				Owner:     message.Owner,
+				UserID:    message.UserID,
+				Content:   message.Content,
+				SIM:       message.SIM,
+				Priority:  message.Priority,
+			})
+			if err != nil {
+				msg := fmt.Sprintf("cannot create [%s] event for paused message with ID [%s]", events.EventTypeMessageSendRetry, message.ID)
+				return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+			}
+
+			if err = service.eventDispatcher.Dispatch(ctx, event); err != nil {
+				msg := fmt.Sprintf("cannot dispatch [%s] event for message with ID [%s]", event.Type(), message.ID)
+				return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+			}
+			count++
+		}
+
+		if len(messages) < messageSchedulerBatchSize {
+			break
+		}
+	}
+
+	ctxLogger.Info(fmt.Sprintf("resumed [%d] paused messages of user [%s]", count, userID))
+	return nil
+}
+
+// FailoverMessages moves the messages which are waiting for the phone of an owner to the phone of another owner
+func (service *MessageService) FailoverMessages(ctx context.Context, source string, userID entities.UserID, from string, to string) (int, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	count := 0
+	for {
+		messages, err := service.repository.FetchWaiting(ctx, userID, from, messageSchedulerBatchSize)
+		if err != nil {
+			msg := fmt.Sprintf("cannot fetch waiting messages of owner [%s] for user [%s]", from, userID)
+			return count, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+		}
+
+		for _, message := range messages {
+			reassigned, err := service.repository.Reassign(ctx, userID, message.ID, from, to)
+			if err != nil {
+				msg := fmt.Sprintf("cannot reassign message with ID [%s] from [%s] to [%s]", message.ID, from, to)
+				return count, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+			}
+
+			if !reassigned {
+				continue
+			}
+
+			event, err := service.createMessageSendRetryEvent(source, &events.MessageSendRetryPayload{
+				MessageID: message.ID,
+				Timestamp: time.Now().UTC(),
+				Contact:   message.Contact,
+				Owner:     to,
+				UserID:    message.UserID,
+				Content:   message.Content,
+				SIM:       message.SIM,
+				Priority:  message.Priority,
+			})
+			if err != nil {
+				msg := fmt.Sprintf("cannot create [%s] event for reassigned message with ID [%s]", events.EventTypeMessageSendRetry, message.ID)
+				return count, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+			}
+
+			if err = service.eventDispatcher.Dispatch(ctx, event); err != nil {
+				msg := fmt.Sprintf("cannot dispatch [%s] event for message with ID [%s]", event.Type(), message.ID)
+				return count, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+			}
+			count++
+		}
+
+		// messages which could not be reassigned are no longer fetched so a short batch means there is nothing left
+		if len(messages) < messageSchedulerBatchSize {
+			break
+		}
+	}
+
+	ctxLogger.Info(fmt.Sprintf("moved [%d] waiting messages of user [%s] from [%s] to [%s]", count, userID, from, to))
+	return count, nil
+}
+
+// pauseIfSuspended pauses the messages of a suspended user which reached the phone through a retry or while the account was being suspended
+func (service *MessageService) pauseIfSuspended(ctx context.Context, userID entities.UserID) error {
+	user, err := service.userRepository.Load(ctx, userID)
+	if err != nil {
+		return stacktrace.Propagate(err, fmt.Sprintf("cannot load user with ID [%s]", userID))
+	}
+
+	if !user.IsSuspended() {
+		return nil
+	}
+
+	if _, err = service.repository.Pause(ctx, userID); err != nil {
+		return stacktrace.Propagate(err, fmt.Sprintf("cannot pause the messages of suspended user [%s]", userID))
+	}
+
+	return stacktrace.NewErrorWithCode(repositories.ErrCodeNotFound, fmt.Sprintf("user [%s] is suspended and has no outstanding messages", userID))
 }
 
-func (service *MessageService) handleMessageDeliveredEvent(ctx context.Context, params MessageStoreEventParams, message *entities.Message) error {
-	ctx, span := service.tracer.Start(ctx)
-	defer span.End()
+// checkKillSwitch returns an error with ErrCodePhoneKillSwitchEnabled when the phone which sends the message must not send messages
+func (service *MessageService) checkKillSwitch(ctx context.Context, userID entities.UserID, messageID uuid.UUID) error {
+	message, err := service.repository.Load(ctx, userID, messageID)
+	if err != nil {
+		return stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), fmt.Sprintf("cannot load message with ID [%s]", messageID))
+	}
 
-	event, err := service.createMessagePhoneDeliveredEvent(params.Source, events.MessagePhoneDeliveredPayload{
-		ID:        message.ID,
-		Owner:     message.Owner,
-		UserID:    message.UserID,
-		Timestamp: params.Timestamp,
-		Contact:   message.Contact,
-		Content:   message.Content,
-		SIM:       message.SIM,
-	})
+	phone, err := service.phoneService.Load(ctx, userID, message.Owner)
 	if err != nil {
-		msg := fmt.Sprintf("cannot create event [%s] for message [%s]", events.EventTypeMessagePhoneSent, message.ID)
-		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+		return stacktrace.Propagate(err, fmt.Sprintf("cannot load phone [%s] of message [%s]", message.Owner, messageID))
 	}
 
-	if err = service.eventDispatcher.Dispatch(ctx, event); err != nil {
-		msg := fmt.Sprintf("cannot dispatch event type [%s] and id [%s]", event.Type(), event.ID())
-		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	if !phone.IsKillSwitchEnabled() {
+		return nil
 	}
-	return nil
+
+	return stacktrace.NewErrorWithCode(ErrCodePhoneKillSwitchEnabled, fmt.Sprintf("phone [%s] cannot send message [%s] because its kill switch is enabled", phone.ID, messageID))
 }
 
-func (service *MessageService) handleMessageFailedEvent(ctx context.Context, params MessageStoreEventParams, message *entities.Message) error {
-	ctx, span := service.tracer.Start(ctx)
+// MessageBatch is the list of entities.Message created by a bulk send request
+type MessageBatch struct {
+	BatchID  uuid.UUID           `json:"batch_id" example:"32343a19-da5e-4b1b-a767-3298a73703cc"`
+	Messages []*entities.Message `json:"messages"`
+}
+
+// BulkSendMessage sends the same message to many contacts with a shared batch ID.
+// The messages which were sent are returned with the error when a message cannot be sent.
+func (service *MessageService) BulkSendMessage(ctx context.Context, params []MessageSendParams) (*MessageBatch, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
 	defer span.End()
 
-	errorMessage := "UNKNOWN ERROR"
-	if params.ErrorMessage != nil {
-		errorMessage = *params.ErrorMessage
+	batch := &MessageBatch{BatchID: uuid.New(), Messages: make([]*entities.Message, 0, len(params))}
+	for _, param := range params {
+		param.BatchID = &batch.BatchID
+		message, err := service.SendMessage(ctx, param)
+		if err != nil {
+			msg := fmt.Sprintf("cannot send message to contact [%s] in batch [%s] after sending [%d] messages", param.Contact, batch.BatchID, len(batch.Messages))
+			return batch, service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
+		}
+		batch.Messages = append(batch.Messages, message)
 	}
 
-	event, err := service.createMessageSendFailedEvent(params.Source, events.MessageSendFailedPayload{
-		ID:           message.ID,
-		Owner:        message.Owner,
-		ErrorMessage: errorMessage,
-		Timestamp:    params.Timestamp,
-		Contact:      message.Contact,
-		UserID:       message.UserID,
-		Content:      message.Content,
-		SIM:          message.SIM,
-	})
-	if err != nil {
-		msg := fmt.Sprintf("cannot create event [%s] for message [%s]", events.EventTypeMessageSendFailed, message.ID)
-		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
-	}
+	ctxLogger.Info(fmt.Sprintf("sent [%d] messages in batch [%s]", len(batch.Messages), batch.BatchID))
+	return batch, nil
+}
 
-	if err = service.eventDispatcher.Dispatch(ctx, event); err != nil {
-		msg := fmt.Sprintf("cannot dispatch event type [%s] and id [%s]", event.Type(), event.ID())
-		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
-	}
-	return nil
+// MessageBatchRecipient is the status of the entities.Message sent to a contact in a MessageBatch
+type MessageBatchRecipient struct {
+	MessageID     uuid.UUID              `json:"message_id" example:"32343a19-da5e-4b1b-a767-3298a73703cb"`
+	Contact       string                 `json:"contact" example:"+18005550100"`
+	Status        entities.MessageStatus `json:"status" example:"delivered"`
+	FailureReason *string                `json:"failure_reason" example:"UNKNOWN"`
 }
 
-// MessageSendParams parameters for sending a new message
-type MessageSendParams struct {
-	Owner             phonenumbers.PhoneNumber
-	Contact           string
-	Content           string
-	Source            string
-	SIM               entities.SIM
-	UserID            entities.UserID
-	RequestReceivedAt time.Time
+// MessageBatchStatus summarises the status of the messages in a MessageBatch
+type MessageBatchStatus struct {
+	BatchID    uuid.UUID                      `json:"batch_id" example:"32343a19-da5e-4b1b-a767-3298a73703cc"`
+	Total      int                            `json:"total" example:"1000"`
+	Statuses   map[entities.MessageStatus]int `json:"statuses"`
+	Recipients []MessageBatchRecipient        `json:"recipients"`
 }
 
-// SendMessage a new message
-func (service *MessageService) SendMessage(ctx context.Context, params MessageSendParams) (*entities.Message, error) {
+// GetBatchStatus fetches the status of each entities.Message in a MessageBatch
+func (service *MessageService) GetBatchStatus(ctx context.Context, userID entities.UserID, batchID uuid.UUID) (*MessageBatchStatus, error) {
 	ctx, span := service.tracer.Start(ctx)
 	defer span.End()
 
-	ctxLogger := service.tracer.CtxLogger(service.logger, span)
-
-	eventPayload := events.MessageAPISentPayload{
-		MessageID:         uuid.New(),
-		UserID:            params.UserID,
-		MaxSendAttempts:   service.maxSendAttempts(ctx, params.UserID, phonenumbers.Format(&params.Owner, phonenumbers.E164)),
-		Owner:             phonenumbers.Format(&params.Owner, phonenumbers.E164),
-		Contact:           params.Contact,
-		RequestReceivedAt: params.RequestReceivedAt,
-		Content:           params.Content,
-		SIM:               params.SIM,
-	}
-
-	event, err := service.createMessageAPISentEvent(params.Source, eventPayload)
+	messages, err := service.repository.IndexBatch(ctx, userID, batchID)
 	if err != nil {
-		msg := fmt.Sprintf("cannot create %T from payload with message id [%s]", event, eventPayload.MessageID)
+		msg := fmt.Sprintf("cannot fetch messages in batch [%s] for user [%s]", batchID, userID)
 		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
 	}
-	ctxLogger.Info(fmt.Sprintf("created event [%s] with id [%s] and message id [%s]", event.Type(), event.ID(), eventPayload.MessageID))
 
-	if err = service.eventDispatcher.Dispatch(ctx, event); err != nil {
-		msg := fmt.Sprintf("cannot dispatch event type [%s] and id [%s]", event.Type(), event.ID())
-		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	if len(messages) == 0 {
+		msg := fmt.Sprintf("batch with ID [%s] for user [%s] does not exist", batchID, userID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.NewErrorWithCode(repositories.ErrCodeNotFound, msg))
+	}
+
+	status := &MessageBatchStatus{
+		BatchID:    batchID,
+		Total:      len(messages),
+		Statuses:   map[entities.MessageStatus]int{},
+		Recipients: make([]MessageBatchRecipient, 0, len(messages)),
+	}
+	for _, message := range messages {
+		status.Statuses[message.Status]++
+		status.Recipients = append(status.Recipients, MessageBatchRecipient{
+			MessageID:     message.ID,
+			Contact:       message.Contact,
+			Status:        message.Status,
+			FailureReason: message.FailureReason,
+		})
 	}
-	ctxLogger.Info(fmt.Sprintf("event [%s] dispatched succesfully", event.ID()))
 
-	return service.storeSentMessage(ctx, eventPayload)
+	return status, nil
 }
 
 // StoreReceivedMessage a new message
@@ -360,6 +1620,9 @@ func (service *MessageService) storeReceivedMessage(ctx context.Context, params
 		UpdatedAt:         time.Now().UTC(),
 		OrderTimestamp:    params.Timestamp,
 		ReceivedAt:        &params.Timestamp,
+		AttachmentCount:   uint(len(params.Attachments)),
+		Attachments:       params.Attachments,
+		ConversationID:    params.ConversationID,
 	}
 
 	if err := service.repository.Store(ctx, message); err != nil {
@@ -430,6 +1693,17 @@ func (service *MessageService) HandleMessageSent(ctx context.Context, params Han
 	}
 
 	ctxLogger.Info(fmt.Sprintf("message with id [%s] has been updated to status [%s]", message.ID, message.Status))
+
+	if err = service.scheduleDeliveryCheck(ctx, params.Source, message); err != nil {
+		msg := fmt.Sprintf("cannot schedule delivery check for message with id [%s]", message.ID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if err = service.releaseSuccessors(ctx, params.Source, message); err != nil {
+		msg := fmt.Sprintf("cannot release the messages waiting for message with id [%s]", message.ID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
 	return nil
 }
 
@@ -438,7 +1712,9 @@ type HandleMessageFailedParams struct {
 	ID           uuid.UUID
 	UserID       entities.UserID
 	ErrorMessage string
+	FailureCode  entities.MessageFailureCode
 	Timestamp    time.Time
+	Source       string
 }
 
 // HandleMessageFailed handles when a message could not be sent by a mobile phone
@@ -459,12 +1735,57 @@ func (service *MessageService) HandleMessageFailed(ctx context.Context, params H
 		return service.tracer.WrapErrorSpan(span, stacktrace.NewError(msg))
 	}
 
-	if err = service.repository.Update(ctx, message.Failed(params.Timestamp, params.ErrorMessage)); err != nil {
+	if err = service.repository.Update(ctx, message.Failed(params.Timestamp, params.FailureCode, params.ErrorMessage)); err != nil {
 		msg := fmt.Sprintf("cannot update message with id [%s] as sent", message.ID)
 		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
 	}
 
 	ctxLogger.Info(fmt.Sprintf("message with id [%s] has been updated to status [%s]", message.ID, message.Status))
+	return service.retryFailedMessage(ctx, params.Source, message, params.FailureCode)
+}
+
+// retryFailedMessage reschedules a failed message according to the entities.RetryPolicy of the failure code
+func (service *MessageService) retryFailedMessage(ctx context.Context, source string, message *entities.Message, code entities.MessageFailureCode) error {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	policy, err := service.retryPolicyService.Load(ctx, message.UserID, code)
+	if err != nil {
+		msg := fmt.Sprintf("cannot load retry policy with failure code [%s] for message with ID [%s]", code, message.ID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if !policy.CanRetry(message.RetryCount) {
+		ctxLogger.Info(fmt.Sprintf("message with ID [%s] will not be retried after [%d] retries for failure code [%s]", message.ID, message.RetryCount, code))
+		return service.failSuccessors(ctx, message)
+	}
+
+	if err = service.repository.Update(ctx, message.AddRetryCount()); err != nil {
+		msg := fmt.Sprintf("cannot update retry count of message with id [%s]", message.ID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	event, err := service.createMessageSendRetryEvent(source, &events.MessageSendRetryPayload{
+		MessageID: message.ID,
+		Timestamp: time.Now().UTC().Add(policy.Delay()),
+		Contact:   message.Contact,
+		Owner:     message.Owner,
+		UserID:    message.UserID,
+		Content:   message.Content,
+		SIM:       message.SIM,
+		Priority:  message.Priority,
+	})
+	if err != nil {
+		msg := fmt.Sprintf("cannot create [%s] event for failed message with ID [%s]", events.EventTypeMessageSendRetry, message.ID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if _, err = service.eventDispatcher.DispatchWithTimeout(ctx, event, policy.Delay()); err != nil {
+		msg := fmt.Sprintf("cannot dispatch [%s] event for message with ID [%s]", event.Type(), message.ID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("failed message with ID [%s] will be retried in [%s] for failure code [%s]", message.ID, policy.Delay(), code))
 	return nil
 }
 
@@ -481,8 +1802,8 @@ func (service *MessageService) HandleMessageDelivered(ctx context.Context, param
 		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
 	}
 
-	if !message.IsSent() && !message.IsSending() && !message.IsExpired() {
-		msg := fmt.Sprintf("message has wrong status [%s]. expected [%s, %s, %s]", message.Status, entities.MessageStatusSent, entities.MessageStatusSending, entities.MessageStatusExpired)
+	if !message.IsSent() && !message.IsSending() && !message.IsExpired() && !message.IsDeliveryUnknown() {
+		msg := fmt.Sprintf("message has wrong status [%s]. expected [%s, %s, %s, %s]", message.Status, entities.MessageStatusSent, entities.MessageStatusSending, entities.MessageStatusExpired, entities.MessageStatusDeliveryUnknown)
 		return service.tracer.WrapErrorSpan(span, stacktrace.NewError(msg))
 	}
 
@@ -508,7 +1829,7 @@ func (service *MessageService) HandleMessageNotificationScheduled(ctx context.Co
 		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
 	}
 
-	if !message.IsPending() && !message.IsExpired() && !message.IsSending() {
+	if !message.IsPending() && !message.IsExpired() && !message.IsSending() && !message.IsFailed() {
 		ctxLogger.Warn(stacktrace.NewError(fmt.Sprintf("received scheduled event for message with id [%s] message has status [%s]", message.ID, message.Status)))
 	}
 
@@ -557,7 +1878,7 @@ func (service *MessageService) HandleMessageExpired(ctx context.Context, params
 	}
 
 	if !message.IsSending() && !message.IsScheduled() {
-		msg := fmt.Sprintf("message has wrong status [%s]. expected [%s, %s, %s]", message.Status, entities.MessageStatusSending, entities.MessageStatusScheduled)
+		msg := fmt.Sprintf("message has wrong status [%s]. expected [%s, %s]", message.Status, entities.MessageStatusSending, entities.MessageStatusScheduled)
 		return service.tracer.WrapErrorSpan(span, stacktrace.NewError(msg))
 	}
 
@@ -580,6 +1901,7 @@ func (service *MessageService) HandleMessageExpired(ctx context.Context, params
 		UserID:    message.UserID,
 		Content:   message.Content,
 		SIM:       message.SIM,
+		Priority:  message.Priority,
 	})
 	if err != nil {
 		msg := fmt.Sprintf("cannot create [%s] event for expired message with ID [%s]", events.EventTypeMessageSendRetry, message.ID)
@@ -684,6 +2006,103 @@ func (service *MessageService) CheckExpired(ctx context.Context, params MessageC
 	return nil
 }
 
+// MessageCheckDelivery are parameters for checking if a sent message has been delivered
+type MessageCheckDelivery struct {
+	MessageID uuid.UUID
+	UserID    entities.UserID
+	Source    string
+}
+
+// CheckDelivery marks a sent message as entities.MessageStatusDeliveryUnknown if no delivery report was received
+func (service *MessageService) CheckDelivery(ctx context.Context, params MessageCheckDelivery) error {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	ctxLogger := service.tracer.CtxLogger(service.logger, span)
+
+	message, err := service.repository.Load(ctx, params.UserID, params.MessageID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot load message with userID [%s] and messageID [%s]", params.UserID, params.MessageID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if !message.IsSent() {
+		ctxLogger.Info(fmt.Sprintf("message with ID [%s] has status [%s] and does not need a delivery report", message.ID, message.Status))
+		return nil
+	}
+
+	if err = service.repository.Update(ctx, message.DeliveryUnknown(time.Now().UTC())); err != nil {
+		msg := fmt.Sprintf("cannot update message with id [%s] as [%s]", message.ID, entities.MessageStatusDeliveryUnknown)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	event, err := service.createMessageDeliveryUnknownEvent(params.Source, events.MessageDeliveryUnknownPayload{
+		MessageID: message.ID,
+		Owner:     message.Owner,
+		Contact:   message.Contact,
+		UserID:    message.UserID,
+		SentAt:    *message.SentAt,
+		Timestamp: *message.DeliveryUnknownAt,
+		Content:   message.Content,
+		SIM:       message.SIM,
+	})
+	if err != nil {
+		msg := fmt.Sprintf("cannot create event [%s] for message with id [%s]", events.EventTypeMessageDeliveryUnknown, params.MessageID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if err = service.eventDispatcher.Dispatch(ctx, event); err != nil {
+		msg := fmt.Sprintf("cannot dispatch event [%s] for message with ID [%s]", event.Type(), params.MessageID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("message with id [%s] has been updated to status [%s]", message.ID, message.Status))
+	return nil
+}
+
+// scheduleDeliveryCheck schedules an event to check if a sent message got a delivery report
+func (service *MessageService) scheduleDeliveryCheck(ctx context.Context, source string, message *entities.Message) error {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	ctxLogger := service.tracer.CtxLogger(service.logger, span)
+
+	timeout := service.deliveryReportTimeout(ctx, message.UserID, message.Owner)
+	event, err := service.createMessageDeliveryCheckEvent(source, &events.MessageDeliveryCheckPayload{
+		MessageID:   message.ID,
+		ScheduledAt: message.SentAt.Add(timeout),
+		UserID:      message.UserID,
+	})
+	if err != nil {
+		msg := fmt.Sprintf("cannot create event [%s] for message with id [%s]", events.EventTypeMessageDeliveryCheck, message.ID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if _, err = service.eventDispatcher.DispatchWithTimeout(ctx, event, timeout); err != nil {
+		msg := fmt.Sprintf("cannot dispatch event [%s] for message with ID [%s]", event.Type(), message.ID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("scheduled delivery check for message id [%s] at [%s]", message.ID, message.SentAt.Add(timeout)))
+	return nil
+}
+
+func (service *MessageService) deliveryReportTimeout(ctx context.Context, userID entities.UserID, owner string) time.Duration {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	ctxLogger := service.tracer.CtxLogger(service.logger, span)
+
+	phone, err := service.phoneService.Load(ctx, userID, owner)
+	if err != nil {
+		msg := fmt.Sprintf("cannot load phone for userID [%s] and owner [%s]. using default delivery report timeout", userID, owner)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return (&entities.Phone{}).DeliveryReportTimeoutDuration()
+	}
+
+	return phone.DeliveryReportTimeoutDuration()
+}
+
 func (service *MessageService) maxSendAttempts(ctx context.Context, userID entities.UserID, owner string) uint {
 	ctx, span := service.tracer.Start(ctx)
 	defer span.End()
@@ -707,7 +2126,93 @@ func (service *MessageService) storeSentMessage(ctx context.Context, payload eve
 
 	ctxLogger := service.tracer.CtxLogger(service.logger, span)
 
-	message := &entities.Message{
+	message := service.sentMessage(payload)
+	if payload.SendAt != nil && payload.SendAt.After(time.Now().UTC()) {
+		message.Status = entities.MessageStatusQueuedForFuture
+	}
+
+	if err := service.repository.Store(ctx, message); err != nil {
+		msg := fmt.Sprintf("cannot save message with id [%s]", payload.MessageID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("message saved with id [%s]", payload.MessageID))
+	return message, nil
+}
+
+// storeAwaitingMessage saves a message which is dispatched to the phone once the message it is sent after has been sent
+func (service *MessageService) storeAwaitingMessage(ctx context.Context, source string, payload events.MessageAPISentPayload) (*entities.Message, error) {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	message := service.sentMessage(payload)
+	message.Status = entities.MessageStatusAwaitingPredecessor
+
+	if err := service.repository.Store(ctx, message); err != nil {
+		msg := fmt.Sprintf("cannot save awaiting message with id [%s]", payload.MessageID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	// the predecessor may have been sent or failed while the message was being stored
+	predecessor, err := service.repository.Load(ctx, payload.UserID, *payload.AfterMessageID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot load predecessor [%s] of message with id [%s]", *payload.AfterMessageID, payload.MessageID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if predecessor.IsSent() || predecessor.IsDelivered() {
+		err = service.releaseSuccessors(ctx, source, predecessor)
+	} else if predecessor.IsFailed() {
+		err = service.failSuccessors(ctx, predecessor)
+	}
+	if err != nil {
+		msg := fmt.Sprintf("cannot resolve the messages waiting for message with id [%s]", predecessor.ID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return message, nil
+}
+
+// storeDependencyFailedMessage saves a message which is sent after a failed message as failed without sending it to the phone
+func (service *MessageService) storeDependencyFailedMessage(ctx context.Context, payload events.MessageAPISentPayload) (*entities.Message, error) {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	message := service.sentMessage(payload).Failed(
+		time.Now().UTC(),
+		entities.MessageFailureCodeDependencyFailed,
+		fmt.Sprintf("the message [%s] which this message is sent after failed", *payload.AfterMessageID),
+	)
+
+	if err := service.repository.Store(ctx, message); err != nil {
+		msg := fmt.Sprintf("cannot save dependency failed message with id [%s]", payload.MessageID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return message, nil
+}
+
+// storeOptedOutMessage saves a message to a contact on the blocklist as failed without sending it to the phone
+func (service *MessageService) storeOptedOutMessage(ctx context.Context, payload events.MessageAPISentPayload) (*entities.Message, error) {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	message := service.sentMessage(payload).Failed(
+		time.Now().UTC(),
+		entities.MessageFailureCodeOptedOut,
+		fmt.Sprintf("the contact [%s] opted out of receiving messages", payload.Contact),
+	)
+
+	if err := service.repository.Store(ctx, message); err != nil {
+		msg := fmt.Sprintf("cannot save opted out message with id [%s]", payload.MessageID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return message, nil
+}
+
+func (service *MessageService) sentMessage(payload events.MessageAPISentPayload) *entities.Message {
+	return &entities.Message{
 		ID:                payload.MessageID,
 		Owner:             payload.Owner,
 		Contact:           payload.Contact,
@@ -721,15 +2226,39 @@ func (service *MessageService) storeSentMessage(ctx context.Context, payload eve
 		UpdatedAt:         time.Now().UTC(),
 		MaxSendAttempts:   payload.MaxSendAttempts,
 		OrderTimestamp:    payload.RequestReceivedAt,
+		BatchID:           payload.BatchID,
+		SendAt:            payload.SendAt,
+		AttachmentCount:   payload.AttachmentCount,
+		Attachments:       payload.Attachments,
+		ConversationID:    payload.ConversationID,
+		ExpiresIn:         payload.ExpiresIn,
+		Priority:          payload.Priority.OrDefault(),
+		AfterMessageID:    payload.AfterMessageID,
+		TemplateID:        payload.TemplateID,
 	}
+}
 
-	if err := service.repository.Store(ctx, message); err != nil {
-		msg := fmt.Sprintf("cannot save message with id [%s]", payload.MessageID)
-		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+// storedMessagePayload creates the events.MessageAPISentPayload of a message which was stored before it was dispatched to the phone
+func (service *MessageService) storedMessagePayload(message *entities.Message) events.MessageAPISentPayload {
+	return events.MessageAPISentPayload{
+		MessageID:         message.ID,
+		UserID:            message.UserID,
+		Owner:             message.Owner,
+		MaxSendAttempts:   message.MaxSendAttempts,
+		Contact:           message.Contact,
+		RequestReceivedAt: message.RequestReceivedAt,
+		Content:           message.Content,
+		SIM:               message.SIM,
+		BatchID:           message.BatchID,
+		SendAt:            message.SendAt,
+		AttachmentCount:   message.AttachmentCount,
+		Attachments:       message.Attachments,
+		ConversationID:    message.ConversationID,
+		ExpiresIn:         message.ExpiresIn,
+		Priority:          message.Priority,
+		AfterMessageID:    message.AfterMessageID,
+		TemplateID:        message.TemplateID,
 	}
-
-	ctxLogger.Info(fmt.Sprintf("message saved with id [%s]", payload.MessageID))
-	return message, nil
 }
 
 func (service *MessageService) createMessageSendExpiredEvent(source string, payload events.MessageSendExpiredPayload) (cloudevents.Event, error) {
@@ -740,6 +2269,14 @@ func (service *MessageService) createMessageSendExpiredCheckEvent(source string,
 	return service.createEvent(events.EventTypeMessageSendExpiredCheck, source, payload)
 }
 
+func (service *MessageService) createMessageDeliveryCheckEvent(source string, payload *events.MessageDeliveryCheckPayload) (cloudevents.Event, error) {
+	return service.createEvent(events.EventTypeMessageDeliveryCheck, source, payload)
+}
+
+func (service *MessageService) createMessageDeliveryUnknownEvent(source string, payload events.MessageDeliveryUnknownPayload) (cloudevents.Event, error) {
+	return service.createEvent(events.EventTypeMessageDeliveryUnknown, source, payload)
+}
+
 func (service *MessageService) createMessageAPISentEvent(source string, payload events.MessageAPISentPayload) (cloudevents.Event, error) {
 	return service.createEvent(events.EventTypeMessageAPISent, source, payload)
 }
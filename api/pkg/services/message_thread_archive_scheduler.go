@@ -0,0 +1,47 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/palantir/stacktrace"
+)
+
+// MessageThreadArchiveScheduler periodically archives the message threads which had no activity for the auto archive days of their user
+type MessageThreadArchiveScheduler struct {
+	logger   telemetry.Logger
+	tracer   telemetry.Tracer
+	service  *MessageThreadService
+	interval time.Duration
+}
+
+// NewMessageThreadArchiveScheduler creates a new MessageThreadArchiveScheduler
+func NewMessageThreadArchiveScheduler(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	service *MessageThreadService,
+	interval time.Duration,
+) (s *MessageThreadArchiveScheduler) {
+	return &MessageThreadArchiveScheduler{
+		logger:   logger.WithService(fmt.Sprintf("%T", s)),
+		tracer:   tracer,
+		service:  service,
+		interval: interval,
+	}
+}
+
+// Run archives the inactive message threads on every tick until the context is cancelled
+func (scheduler *MessageThreadArchiveScheduler) Run(ctx context.Context) {
+	runTicker(ctx, scheduler.logger, "message thread archive scheduler", scheduler.interval, scheduler.tick)
+}
+
+func (scheduler *MessageThreadArchiveScheduler) tick(ctx context.Context) {
+	ctx, span, ctxLogger := scheduler.tracer.StartWithLogger(ctx, scheduler.logger)
+	defer span.End()
+
+	if _, err := scheduler.service.AutoArchive(ctx, time.Now().UTC()); err != nil {
+		ctxLogger.Error(stacktrace.Propagate(err, "cannot auto archive the inactive message threads"))
+	}
+}
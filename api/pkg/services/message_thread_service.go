@@ -104,6 +104,90 @@ func (service *MessageThreadService) UpdateStatus(ctx context.Context, params Me
 	return thread, nil
 }
 
+// MessageThreadTagsParams are parameters for setting the tags of an entities.MessageThread
+type MessageThreadTagsParams struct {
+	UserID          entities.UserID
+	MessageThreadID uuid.UUID
+	Tags            []string
+}
+
+// UpdateTags sets the names of the entities.Tag of an entities.MessageThread
+func (service *MessageThreadService) UpdateTags(ctx context.Context, params MessageThreadTagsParams) (*entities.MessageThread, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	thread, err := service.repository.Load(ctx, params.UserID, params.MessageThreadID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot find thread with id [%s]", params.MessageThreadID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
+	}
+
+	thread.Tags = params.Tags
+	thread.UpdatedAt = time.Now().UTC()
+	if err = service.repository.Update(ctx, thread); err != nil {
+		msg := fmt.Sprintf("cannot update the tags of message thread with id [%s]", thread.ID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("set [%d] tags on thread [%s] for user [%s]", len(params.Tags), thread.ID, params.UserID))
+	return thread, nil
+}
+
+// AutoArchive archives the threads which had no activity for the entities.User.MessageThreadAutoArchiveDays of their user
+func (service *MessageThreadService) AutoArchive(ctx context.Context, timestamp time.Time) (int64, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	count, err := service.repository.AutoArchive(ctx, timestamp)
+	if err != nil {
+		msg := fmt.Sprintf("cannot auto archive the inactive message threads at [%s]", timestamp)
+		return 0, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("auto archived [%d] inactive message threads at [%s]", count, timestamp))
+	return count, nil
+}
+
+// MessageThreadUnarchiveParams are parameters for unarchiving threads in bulk
+type MessageThreadUnarchiveParams struct {
+	UserID           entities.UserID
+	Owner            string
+	MessageThreadIDs []uuid.UUID
+}
+
+// MessageThreadUnarchiveResult is the outcome of unarchiving threads in bulk
+type MessageThreadUnarchiveResult struct {
+	Unarchived int64 `json:"unarchived" example:"12"`
+}
+
+// Unarchive moves the archived threads with the given IDs back to the inbox, all the archived threads of the owner are unarchived when there are no IDs
+func (service *MessageThreadService) Unarchive(ctx context.Context, params MessageThreadUnarchiveParams) (*MessageThreadUnarchiveResult, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	count, err := service.repository.Unarchive(ctx, params.UserID, params.Owner, params.MessageThreadIDs)
+	if err != nil {
+		msg := fmt.Sprintf("cannot unarchive message threads with params [%+#v]", params)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("unarchived [%d] message threads with owner [%s] for user [%s]", count, params.Owner, params.UserID))
+	return &MessageThreadUnarchiveResult{Unarchived: count}, nil
+}
+
+// ClearContent removes the last message content of the threads whose last message was deleted or anonymized
+func (service *MessageThreadService) ClearContent(ctx context.Context, userID entities.UserID, messageIDs []uuid.UUID) error {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	if err := service.repository.ClearLastMessageContent(ctx, userID, messageIDs); err != nil {
+		msg := fmt.Sprintf("cannot clear the content of threads with last messages [%v] for user [%s]", messageIDs, userID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
 func (service *MessageThreadService) createThread(ctx context.Context, params MessageThreadUpdateParams) error {
 	ctx, span := service.tracer.Start(ctx)
 	defer span.End()
@@ -170,6 +254,9 @@ type MessageThreadGetParams struct {
 	IsArchived bool
 	UserID     entities.UserID
 	Owner      string
+
+	// Tags are the tag names which the threads must have at least one of
+	Tags []string
 }
 
 // GetThreads fetches threads for an owner
@@ -179,7 +266,7 @@ func (service *MessageThreadService) GetThreads(ctx context.Context, params Mess
 
 	ctxLogger := service.tracer.CtxLogger(service.logger, span)
 
-	threads, err := service.repository.Index(ctx, params.UserID, params.Owner, params.IsArchived, params.IndexParams)
+	threads, err := service.repository.Index(ctx, params.UserID, params.Owner, params.IsArchived, params.Tags, params.IndexParams)
 	if err != nil {
 		msg := fmt.Sprintf("could not fetch messages threads for params [%+#v]", params)
 		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
@@ -0,0 +1,208 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+	"github.com/google/uuid"
+	"github.com/palantir/stacktrace"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+)
+
+// MQTTPhoneCommand is published to the command topic of a phone when it has to send a message
+type MQTTPhoneCommand struct {
+	MessageID      uuid.UUID  `json:"message_id"`
+	NotificationID uuid.UUID  `json:"notification_id"`
+	ExpiresAt      *time.Time `json:"expires_at,omitempty"`
+}
+
+// MQTTPhoneStatus is published by a phone to its status topic when a message is sent, delivered or failed
+type MQTTPhoneStatus struct {
+	MessageID  uuid.UUID                 `json:"message_id"`
+	EventName  entities.MessageEventName `json:"event_name"`
+	Timestamp  time.Time                 `json:"timestamp"`
+	Reason     *string                   `json:"reason"`
+	ResultCode *int                      `json:"result_code"`
+}
+
+// MQTTPhoneBridge connects phones which cannot receive push notifications through an MQTT broker.
+//
+// Each phone subscribes to "<prefix>/users/<user ID>/phones/<phone ID>/commands" and publishes the events of its messages
+// to "<prefix>/users/<user ID>/phones/<phone ID>/status". The broker is expected to restrict each device to its own topics.
+type MQTTPhoneBridge struct {
+	logger          telemetry.Logger
+	tracer          telemetry.Tracer
+	client          mqtt.Client
+	prefix          string
+	phoneRepository repositories.PhoneRepository
+	messageService  *MessageService
+}
+
+// mqttPhoneBridgeTimeout is the maximum duration to wait for the broker to acknowledge an operation
+const mqttPhoneBridgeTimeout = 10 * time.Second
+
+// NewMQTTPhoneBridge connects to the MQTT broker and subscribes to the status topics of all phones.
+// The subscription is shared so each status is handled by only one API instance.
+func NewMQTTPhoneBridge(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	options *mqtt.ClientOptions,
+	prefix string,
+	phoneRepository repositories.PhoneRepository,
+	messageService *MessageService,
+) (*MQTTPhoneBridge, error) {
+	bridge := &MQTTPhoneBridge{
+		logger:          logger.WithService(fmt.Sprintf("%T", &MQTTPhoneBridge{})),
+		tracer:          tracer,
+		prefix:          prefix,
+		phoneRepository: phoneRepository,
+		messageService:  messageService,
+	}
+
+	// the session is not persisted so the status topics are subscribed to again after every reconnection
+	options.SetOnConnectHandler(func(client mqtt.Client) {
+		token := client.Subscribe(bridge.statusSubscription(), 1, bridge.onStatus)
+		if token.WaitTimeout(mqttPhoneBridgeTimeout) && token.Error() != nil {
+			bridge.logger.Error(stacktrace.Propagate(token.Error(), fmt.Sprintf("cannot subscribe to [%s]", bridge.statusSubscription())))
+		}
+	})
+
+	bridge.client = mqtt.NewClient(options)
+	token := bridge.client.Connect()
+	if !token.WaitTimeout(mqttPhoneBridgeTimeout) {
+		return nil, stacktrace.NewError(fmt.Sprintf("cannot connect to the MQTT broker after [%s]", mqttPhoneBridgeTimeout))
+	}
+	if token.Error() != nil {
+		return nil, stacktrace.Propagate(token.Error(), "cannot connect to the MQTT broker")
+	}
+
+	return bridge, nil
+}
+
+// Publish a command to the command topic of the phone
+func (bridge *MQTTPhoneBridge) Publish(ctx context.Context, phone *entities.Phone, command *MQTTPhoneCommand) (string, error) {
+	ctx, span := bridge.tracer.Start(ctx)
+	defer span.End()
+
+	data, err := json.Marshal(command)
+	if err != nil {
+		msg := fmt.Sprintf("cannot marshal command for message [%s]", command.MessageID)
+		return "", bridge.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	topic := bridge.CommandTopic(phone)
+	token := bridge.client.Publish(topic, 1, false, data)
+
+	select {
+	case <-token.Done():
+	case <-ctx.Done():
+		msg := fmt.Sprintf("context is done before publishing command for message [%s] to topic [%s]", command.MessageID, topic)
+		return "", bridge.tracer.WrapErrorSpan(span, stacktrace.Propagate(ctx.Err(), msg))
+	case <-time.After(mqttPhoneBridgeTimeout):
+		msg := fmt.Sprintf("broker did not acknowledge command for message [%s] to topic [%s] after [%s]", command.MessageID, topic, mqttPhoneBridgeTimeout)
+		return "", bridge.tracer.WrapErrorSpan(span, stacktrace.NewError(msg))
+	}
+
+	if err = token.Error(); err != nil {
+		msg := fmt.Sprintf("cannot publish command for message [%s] to topic [%s]", command.MessageID, topic)
+		return "", bridge.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return fmt.Sprintf("%s:%s", topic, command.NotificationID), nil
+}
+
+// CommandTopic is the MQTT topic which the phone subscribes to
+func (bridge *MQTTPhoneBridge) CommandTopic(phone *entities.Phone) string {
+	return fmt.Sprintf("%s/users/%s/phones/%s/commands", bridge.prefix, phone.UserID, phone.ID)
+}
+
+// StatusTopic is the MQTT topic which the phone publishes the events of its messages to
+func (bridge *MQTTPhoneBridge) StatusTopic(phone *entities.Phone) string {
+	return fmt.Sprintf("%s/users/%s/phones/%s/status", bridge.prefix, phone.UserID, phone.ID)
+}
+
+func (bridge *MQTTPhoneBridge) statusSubscription() string {
+	return fmt.Sprintf("$share/httpsms-api/%s/users/+/phones/+/status", bridge.prefix)
+}
+
+func (bridge *MQTTPhoneBridge) onStatus(_ mqtt.Client, mqttMessage mqtt.Message) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	ctx, span, ctxLogger := bridge.tracer.StartWithLogger(ctx, bridge.logger)
+	defer span.End()
+
+	if err := bridge.handleStatus(ctx, mqttMessage.Topic(), mqttMessage.Payload()); err != nil {
+		ctxLogger.Error(bridge.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, fmt.Sprintf("cannot handle status [%s] on topic [%s]", mqttMessage.Payload(), mqttMessage.Topic()))))
+	}
+}
+
+func (bridge *MQTTPhoneBridge) handleStatus(ctx context.Context, topic string, payload []byte) error {
+	ctx, span, ctxLogger := bridge.tracer.StartWithLogger(ctx, bridge.logger)
+	defer span.End()
+
+	userID, phoneID, err := bridge.parseStatusTopic(topic)
+	if err != nil {
+		return bridge.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, fmt.Sprintf("cannot parse status topic [%s]", topic)))
+	}
+
+	status := new(MQTTPhoneStatus)
+	if err = json.Unmarshal(payload, status); err != nil {
+		return bridge.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, fmt.Sprintf("cannot unmarshal [%s] into [%T]", payload, status)))
+	}
+
+	phone, err := bridge.phoneRepository.LoadByID(ctx, userID, phoneID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot load phone with ID [%s] for user [%s]", phoneID, userID)
+		return bridge.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	message, err := bridge.messageService.GetMessage(ctx, userID, status.MessageID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot load message with ID [%s] for user [%s]", status.MessageID, userID)
+		return bridge.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if message.Owner != phone.PhoneNumber {
+		ctxLogger.Warn(stacktrace.NewError(fmt.Sprintf("phone [%s] cannot report event [%s] for message [%s] of [%s]", phone.ID, status.EventName, message.ID, message.Owner)))
+		return nil
+	}
+
+	_, err = bridge.messageService.StoreEvent(ctx, message, MessageStoreEventParams{
+		MessageID:    message.ID,
+		EventName:    status.EventName,
+		Timestamp:    status.Timestamp,
+		ErrorMessage: status.Reason,
+		FailureCode:  entities.MessageFailureCodeFromResultCode(status.ResultCode),
+		Source:       "mqtt://" + topic,
+	})
+	if err != nil {
+		msg := fmt.Sprintf("cannot store event [%s] for message [%s]", status.EventName, message.ID)
+		return bridge.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("stored event [%s] for message [%s] from phone [%s]", status.EventName, message.ID, phone.ID))
+	return nil
+}
+
+// parseStatusTopic returns the user ID and phone ID of a "<prefix>/users/<user ID>/phones/<phone ID>/status" topic
+func (bridge *MQTTPhoneBridge) parseStatusTopic(topic string) (entities.UserID, uuid.UUID, error) {
+	parts := strings.Split(strings.TrimPrefix(topic, bridge.prefix+"/"), "/")
+	if len(parts) != 5 || parts[0] != "users" || parts[2] != "phones" || parts[4] != "status" {
+		return "", uuid.Nil, stacktrace.NewError(fmt.Sprintf("topic [%s] is not a phone status topic", topic))
+	}
+
+	phoneID, err := uuid.Parse(parts[3])
+	if err != nil {
+		return "", uuid.Nil, stacktrace.Propagate(err, fmt.Sprintf("cannot parse phone ID [%s]", parts[3]))
+	}
+
+	return entities.UserID(parts[1]), phoneID, nil
+}
@@ -0,0 +1,99 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nats-io/nats.go"
+	"github.com/palantir/stacktrace"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+)
+
+// NATSPhoneJob is published to the subject of a phone when it has to send a message
+type NATSPhoneJob struct {
+	MessageID      uuid.UUID       `json:"message_id"`
+	NotificationID uuid.UUID       `json:"notification_id"`
+	PhoneID        uuid.UUID       `json:"phone_id"`
+	UserID         entities.UserID `json:"user_id"`
+	Owner          string          `json:"owner"`
+	ExpiresAt      *time.Time      `json:"expires_at,omitempty"`
+}
+
+// NATSPhoneTransport publishes jobs for phones to NATS JetStream so phones or bridges can subscribe instead of relying on FCM.
+//
+// The jobs of a phone are published on the "<prefix>.phones.<phone ID>.messages" subject of the "<stream>" stream.
+type NATSPhoneTransport struct {
+	logger    telemetry.Logger
+	tracer    telemetry.Tracer
+	jetStream nats.JetStreamContext
+	prefix    string
+}
+
+// NewNATSPhoneTransport creates a new NATSPhoneTransport and makes sure the stream for the phone subjects exists
+func NewNATSPhoneTransport(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	connection *nats.Conn,
+	stream string,
+	prefix string,
+) (*NATSPhoneTransport, error) {
+	jetStream, err := connection.JetStream()
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "cannot create JetStream context")
+	}
+
+	config := &nats.StreamConfig{
+		Name:       stream,
+		Subjects:   []string{prefix + ".phones.>"},
+		Retention:  nats.LimitsPolicy,
+		Storage:    nats.FileStorage,
+		MaxAge:     24 * time.Hour,
+		Duplicates: 10 * time.Minute,
+	}
+
+	if _, err = jetStream.StreamInfo(stream); err == nil {
+		_, err = jetStream.UpdateStream(config)
+	} else {
+		_, err = jetStream.AddStream(config)
+	}
+	if err != nil {
+		return nil, stacktrace.Propagate(err, fmt.Sprintf("cannot configure JetStream stream [%s]", stream))
+	}
+
+	return &NATSPhoneTransport{
+		logger:    logger.WithService(fmt.Sprintf("%T", &NATSPhoneTransport{})),
+		tracer:    tracer,
+		jetStream: jetStream,
+		prefix:    prefix,
+	}, nil
+}
+
+// Publish a job to the subject of the phone, the notification ID deduplicates jobs which are published more than once
+func (transport *NATSPhoneTransport) Publish(ctx context.Context, phone *entities.Phone, job *NATSPhoneJob) (string, error) {
+	ctx, span := transport.tracer.Start(ctx)
+	defer span.End()
+
+	data, err := json.Marshal(job)
+	if err != nil {
+		msg := fmt.Sprintf("cannot marshal job for message [%s]", job.MessageID)
+		return "", transport.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ack, err := transport.jetStream.Publish(transport.Subject(phone), data, nats.Context(ctx), nats.MsgId(job.NotificationID.String()))
+	if err != nil {
+		msg := fmt.Sprintf("cannot publish job for message [%s] to subject [%s]", job.MessageID, transport.Subject(phone))
+		return "", transport.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return fmt.Sprintf("%s:%d", ack.Stream, ack.Sequence), nil
+}
+
+// Subject is the NATS subject which the phone subscribes to
+func (transport *NATSPhoneTransport) Subject(phone *entities.Phone) string {
+	return fmt.Sprintf("%s.phones.%s.messages", transport.prefix, phone.ID)
+}
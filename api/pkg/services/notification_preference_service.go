@@ -0,0 +1,148 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/events"
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/google/uuid"
+	"github.com/palantir/stacktrace"
+)
+
+// NotificationPreferenceEvents are the events which generate a notification on each entities.NotificationChannel
+var NotificationPreferenceEvents = map[entities.NotificationChannel][]string{
+	entities.NotificationChannelEmail:       {events.EventTypePhoneHeartbeatDead, events.EventTypeCanaryFailed, events.EventTypeWebhookDeliveryFailed, events.EventTypePhoneDeviceRegistered, events.EventTypeBroadcastPublished},
+	entities.NotificationChannelWebhook:     {events.EventTypeMessagePhoneReceived, events.EventTypeMessageRead, events.EventTypeMessagePurged, events.EventTypeBroadcastPublished},
+	entities.NotificationChannelIntegration: {events.EventTypeMessagePhoneReceived, events.EventTypeWebhookDeliveryFailed, events.EventTypeMessageSendFailed, events.EventTypePhoneHeartbeatDead, events.EventTypePhoneReputationDegraded, events.EventTypePhoneFilteringSuspected},
+}
+
+// NotificationPreferenceChannels is the order in which the entities.NotificationChannel are listed
+var NotificationPreferenceChannels = []entities.NotificationChannel{
+	entities.NotificationChannelEmail,
+	entities.NotificationChannelWebhook,
+	entities.NotificationChannelIntegration,
+}
+
+// NotificationPreferenceService manages the entities.NotificationPreference of users
+type NotificationPreferenceService struct {
+	service
+	logger     telemetry.Logger
+	tracer     telemetry.Tracer
+	repository repositories.NotificationPreferenceRepository
+}
+
+// NewNotificationPreferenceService creates a new NotificationPreferenceService
+func NewNotificationPreferenceService(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	repository repositories.NotificationPreferenceRepository,
+) (s *NotificationPreferenceService) {
+	return &NotificationPreferenceService{
+		logger:     logger.WithService(fmt.Sprintf("%T", s)),
+		tracer:     tracer,
+		repository: repository,
+	}
+}
+
+// Index returns the effective entities.NotificationPreference of a user for every event and entities.NotificationChannel
+func (service *NotificationPreferenceService) Index(ctx context.Context, userID entities.UserID) ([]*entities.NotificationPreference, error) {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	preferences, err := service.repository.Index(ctx, userID)
+	if err != nil {
+		msg := fmt.Sprintf("could not fetch notification preferences for user [%s]", userID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	configured := make(map[string]*entities.NotificationPreference, len(preferences))
+	for _, preference := range preferences {
+		configured[service.key(preference.EventType, preference.Channel)] = preference
+	}
+
+	result := make([]*entities.NotificationPreference, 0, len(preferences))
+	for _, channel := range NotificationPreferenceChannels {
+		for _, eventType := range NotificationPreferenceEvents[channel] {
+			if preference, ok := configured[service.key(eventType, channel)]; ok {
+				result = append(result, preference)
+				continue
+			}
+			result = append(result, entities.DefaultNotificationPreference(userID, eventType, channel))
+		}
+	}
+
+	return result, nil
+}
+
+// IsEnabled checks if an event generates a notification on an entities.NotificationChannel for a user
+func (service *NotificationPreferenceService) IsEnabled(ctx context.Context, userID entities.UserID, eventType string, channel entities.NotificationChannel) bool {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	preference, err := service.repository.Load(ctx, userID, eventType, channel)
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		return true
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot load notification preference for event [%s] and channel [%s] of user [%s], sending notification", eventType, channel, userID)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return true
+	}
+
+	return preference.Enabled
+}
+
+// NotificationPreferenceParams is the preference of a user for an event on an entities.NotificationChannel
+type NotificationPreferenceParams struct {
+	EventType string
+	Channel   entities.NotificationChannel
+	Enabled   bool
+}
+
+// NotificationPreferencesUpdateParams are parameters for updating the entities.NotificationPreference of a user
+type NotificationPreferencesUpdateParams struct {
+	UserID      entities.UserID
+	Preferences []NotificationPreferenceParams
+}
+
+// Update the entities.NotificationPreference of a user
+func (service *NotificationPreferenceService) Update(ctx context.Context, params *NotificationPreferencesUpdateParams) ([]*entities.NotificationPreference, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	for _, item := range params.Preferences {
+		preference, err := service.repository.Load(ctx, params.UserID, item.EventType, item.Channel)
+		if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+			preference = &entities.NotificationPreference{
+				ID:        uuid.New(),
+				UserID:    params.UserID,
+				EventType: item.EventType,
+				Channel:   item.Channel,
+				CreatedAt: time.Now().UTC(),
+			}
+		} else if err != nil {
+			msg := fmt.Sprintf("could not load notification preference for event [%s] and channel [%s] of user [%s]", item.EventType, item.Channel, params.UserID)
+			return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+		}
+
+		preference.Enabled = item.Enabled
+		preference.UpdatedAt = time.Now().UTC()
+
+		if err = service.repository.Save(ctx, preference); err != nil {
+			msg := fmt.Sprintf("cannot save notification preference with id [%s]", preference.ID)
+			return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+		}
+	}
+
+	ctxLogger.Info(fmt.Sprintf("updated [%d] notification preferences for user [%s]", len(params.Preferences), params.UserID))
+	return service.Index(ctx, params.UserID)
+}
+
+func (service *NotificationPreferenceService) key(eventType string, channel entities.NotificationChannel) string {
+	return eventType + "|" + string(channel)
+}
@@ -0,0 +1,300 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/events"
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/google/uuid"
+	"github.com/palantir/stacktrace"
+)
+
+// ErrCodeNumberPoolExhausted is thrown when all the owner numbers in a pool are already leased
+const ErrCodeNumberPoolExhausted = stacktrace.ErrorCode(2000)
+
+// NumberLeaseService allocates owner numbers from the pool of phones of a user
+type NumberLeaseService struct {
+	service
+	logger          telemetry.Logger
+	tracer          telemetry.Tracer
+	repository      repositories.NumberLeaseRepository
+	phoneRepository repositories.PhoneRepository
+	dispatcher      *EventDispatcher
+}
+
+// NewNumberLeaseService creates a new NumberLeaseService
+func NewNumberLeaseService(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	repository repositories.NumberLeaseRepository,
+	phoneRepository repositories.PhoneRepository,
+	dispatcher *EventDispatcher,
+) (s *NumberLeaseService) {
+	return &NumberLeaseService{
+		logger:          logger.WithService(fmt.Sprintf("%T", s)),
+		tracer:          tracer,
+		repository:      repository,
+		phoneRepository: phoneRepository,
+		dispatcher:      dispatcher,
+	}
+}
+
+// Index fetches the entities.NumberLease of a user
+func (service *NumberLeaseService) Index(ctx context.Context, userID entities.UserID, params repositories.IndexParams) ([]*entities.NumberLease, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	leases, err := service.repository.Index(ctx, userID, params)
+	if err != nil {
+		msg := fmt.Sprintf("could not fetch number leases with params [%+#v]", params)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("fetched [%d] number leases with prams [%+#v]", len(leases), params))
+	return leases, nil
+}
+
+// NumberLeaseAllocateParams are parameters for allocating an owner number to a conversation or campaign
+type NumberLeaseAllocateParams struct {
+	UserID            entities.UserID
+	Reference         string
+	Pool              []string
+	InactivityTimeout time.Duration
+	Source            string
+}
+
+// Allocate leases an owner number which is not in use to the reference.
+// The existing lease is returned if the reference already has an active lease.
+func (service *NumberLeaseService) Allocate(ctx context.Context, params *NumberLeaseAllocateParams) (*entities.NumberLease, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	lease, err := service.repository.LoadActiveByReference(ctx, params.UserID, params.Reference)
+	if err == nil {
+		ctxLogger.Info(fmt.Sprintf("reference [%s] already has an active lease [%s] on owner [%s]", params.Reference, lease.ID, lease.Owner))
+		return lease, nil
+	}
+
+	if stacktrace.GetCode(err) != repositories.ErrCodeNotFound {
+		msg := fmt.Sprintf("cannot load active number lease for reference [%s]", params.Reference)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	owner, err := service.availableOwner(ctx, params.UserID, params.Pool)
+	if err != nil {
+		msg := fmt.Sprintf("cannot find an available owner number for reference [%s]", params.Reference)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
+	}
+
+	lease = &entities.NumberLease{
+		ID:                       uuid.New(),
+		UserID:                   params.UserID,
+		Owner:                    owner,
+		Reference:                params.Reference,
+		Status:                   entities.NumberLeaseStatusActive,
+		InactivityTimeoutSeconds: uint(params.InactivityTimeout.Seconds()),
+		LastActivityAt:           time.Now().UTC(),
+		CreatedAt:                time.Now().UTC(),
+		UpdatedAt:                time.Now().UTC(),
+	}
+
+	if err = service.repository.Store(ctx, lease); err != nil {
+		msg := fmt.Sprintf("cannot save number lease with id [%s]", lease.ID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("owner [%s] leased to reference [%s] with lease id [%s]", lease.Owner, lease.Reference, lease.ID))
+
+	if err = service.scheduleInactivityCheck(ctx, lease, params.Source); err != nil {
+		msg := fmt.Sprintf("cannot schedule inactivity check for number lease with id [%s]", lease.ID)
+		ctxLogger.Error(service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg)))
+	}
+
+	return lease, nil
+}
+
+// Release returns the owner number of an entities.NumberLease to the pool
+func (service *NumberLeaseService) Release(ctx context.Context, userID entities.UserID, leaseID uuid.UUID) (*entities.NumberLease, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	lease, err := service.repository.Load(ctx, userID, leaseID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot load number lease with ID [%s] for user [%s]", leaseID, userID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
+	}
+
+	if !lease.IsActive() {
+		ctxLogger.Info(fmt.Sprintf("number lease [%s] already has status [%s]", lease.ID, lease.Status))
+		return lease, nil
+	}
+
+	if err = service.repository.Update(ctx, lease.Release(time.Now().UTC())); err != nil {
+		msg := fmt.Sprintf("cannot release number lease with ID [%s]", lease.ID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("number lease [%s] on owner [%s] has been released", lease.ID, lease.Owner))
+	return lease, nil
+}
+
+// ReleaseByReference releases the active entities.NumberLease of a conversation or campaign if it exists
+func (service *NumberLeaseService) ReleaseByReference(ctx context.Context, userID entities.UserID, reference string) error {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	lease, err := service.repository.LoadActiveByReference(ctx, userID, reference)
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		return nil
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot load active number lease for reference [%s]", reference)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if _, err = service.Release(ctx, userID, lease.ID); err != nil {
+		msg := fmt.Sprintf("cannot release number lease [%s] for reference [%s]", lease.ID, reference)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+// Touch records activity on the active entities.NumberLease of an owner number
+func (service *NumberLeaseService) Touch(ctx context.Context, userID entities.UserID, owner string, timestamp time.Time) error {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	lease, err := service.repository.LoadActiveByOwner(ctx, userID, owner)
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		ctxLogger.Info(fmt.Sprintf("no active number lease for owner [%s]", owner))
+		return nil
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot load active number lease for owner [%s]", owner)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if !timestamp.After(lease.LastActivityAt) {
+		return nil
+	}
+
+	lease.LastActivityAt = timestamp
+	if err = service.repository.Update(ctx, lease); err != nil {
+		msg := fmt.Sprintf("cannot update activity of number lease [%s]", lease.ID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+// NumberLeaseCheckInactivityParams are parameters for checking if an entities.NumberLease is inactive
+type NumberLeaseCheckInactivityParams struct {
+	LeaseID uuid.UUID
+	UserID  entities.UserID
+	Source  string
+}
+
+// CheckInactivity releases a number lease when no message has been exchanged within the inactivity timeout
+func (service *NumberLeaseService) CheckInactivity(ctx context.Context, params NumberLeaseCheckInactivityParams) error {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	lease, err := service.repository.Load(ctx, params.UserID, params.LeaseID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot load number lease with ID [%s] for user [%s]", params.LeaseID, params.UserID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if !lease.IsActive() {
+		ctxLogger.Info(fmt.Sprintf("number lease [%s] has status [%s] and cannot be released", lease.ID, lease.Status))
+		return nil
+	}
+
+	if !lease.IsInactive(time.Now().UTC()) {
+		return service.scheduleInactivityCheck(ctx, lease, params.Source)
+	}
+
+	if err = service.repository.Update(ctx, lease.Release(time.Now().UTC())); err != nil {
+		msg := fmt.Sprintf("cannot release inactive number lease with ID [%s]", lease.ID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("number lease [%s] on owner [%s] released after inactivity since [%s]", lease.ID, lease.Owner, lease.LastActivityAt))
+	return nil
+}
+
+func (service *NumberLeaseService) availableOwner(ctx context.Context, userID entities.UserID, pool []string) (string, error) {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	phones, err := service.phoneRepository.Index(ctx, userID, repositories.IndexParams{Skip: 0, Limit: 100})
+	if err != nil {
+		msg := fmt.Sprintf("cannot fetch phones of user [%s]", userID)
+		return "", service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	allowed := make(map[string]bool, len(pool))
+	for _, owner := range pool {
+		allowed[owner] = true
+	}
+
+	// prefer the phones which are not slowed down by adaptive pacing
+	sort.SliceStable(*phones, func(i, j int) bool {
+		return (*phones)[i].Pace() > (*phones)[j].Pace()
+	})
+
+	for _, phone := range *phones {
+		if len(allowed) > 0 && !allowed[phone.PhoneNumber] {
+			continue
+		}
+
+		_, err = service.repository.LoadActiveByOwner(ctx, userID, phone.PhoneNumber)
+		if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+			return phone.PhoneNumber, nil
+		}
+
+		if err != nil {
+			msg := fmt.Sprintf("cannot load active number lease for owner [%s]", phone.PhoneNumber)
+			return "", service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+		}
+	}
+
+	msg := fmt.Sprintf("all [%d] owner numbers in the pool of user [%s] are leased", len(*phones), userID)
+	return "", service.tracer.WrapErrorSpan(span, stacktrace.NewErrorWithCode(ErrCodeNumberPoolExhausted, msg))
+}
+
+func (service *NumberLeaseService) scheduleInactivityCheck(ctx context.Context, lease *entities.NumberLease, source string) error {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	scheduledAt := lease.LastActivityAt.Add(lease.InactivityTimeout())
+	event, err := service.createNumberLeaseInactivityCheckEvent(source, &events.NumberLeaseInactivityCheckPayload{
+		LeaseID:     lease.ID,
+		ScheduledAt: scheduledAt,
+		UserID:      lease.UserID,
+	})
+	if err != nil {
+		msg := fmt.Sprintf("cannot create event [%s] for number lease with id [%s]", events.EventTypeNumberLeaseInactivityCheck, lease.ID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if _, err = service.dispatcher.DispatchWithTimeout(ctx, event, time.Until(scheduledAt)); err != nil {
+		msg := fmt.Sprintf("cannot dispatch event [%s] for number lease with ID [%s]", event.Type(), lease.ID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+func (service *NumberLeaseService) createNumberLeaseInactivityCheckEvent(source string, payload *events.NumberLeaseInactivityCheckPayload) (cloudevents.Event, error) {
+	return service.createEvent(events.EventTypeNumberLeaseInactivityCheck, source, payload)
+}
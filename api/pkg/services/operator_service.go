@@ -0,0 +1,36 @@
+package services
+
+import (
+	"fmt"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+)
+
+// OperatorService checks if a user is one of the operators who manage the instance
+type OperatorService struct {
+	service
+	logger    telemetry.Logger
+	operators map[entities.UserID]bool
+}
+
+// NewOperatorService creates a new OperatorService
+func NewOperatorService(
+	logger telemetry.Logger,
+	operators []entities.UserID,
+) (s *OperatorService) {
+	operatorIDs := map[entities.UserID]bool{}
+	for _, userID := range operators {
+		operatorIDs[userID] = true
+	}
+
+	return &OperatorService{
+		logger:    logger.WithService(fmt.Sprintf("%T", s)),
+		operators: operatorIDs,
+	}
+}
+
+// IsOperator checks if a user is allowed to manage broadcasts, credits, coupons, templates, access logs and the accounts of other users
+func (service *OperatorService) IsOperator(userID entities.UserID) bool {
+	return service.operators[userID]
+}
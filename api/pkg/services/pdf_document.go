@@ -0,0 +1,78 @@
+package services
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// pdfDocument is a minimal writer for single page A4 PDF documents using the standard Helvetica fonts.
+// Only printable ASCII characters are supported, other characters are replaced with "?".
+type pdfDocument struct {
+	content bytes.Buffer
+}
+
+const (
+	pdfPageWidth  = 595
+	pdfPageHeight = 842
+)
+
+// Text draws a line of text with the baseline at (x, y) where y is measured from the top of the page
+func (document *pdfDocument) Text(x float64, y float64, size float64, bold bool, text string) {
+	font := "F1"
+	if bold {
+		font = "F2"
+	}
+	document.content.WriteString(fmt.Sprintf("BT /%s %.1f Tf %.2f %.2f Td (%s) Tj ET\n", font, size, x, pdfPageHeight-y, document.escape(text)))
+}
+
+// Line draws a horizontal line from x1 to x2 where y is measured from the top of the page
+func (document *pdfDocument) Line(x1 float64, x2 float64, y float64) {
+	document.content.WriteString(fmt.Sprintf("0.5 w %.2f %.2f m %.2f %.2f l S\n", x1, pdfPageHeight-y, x2, pdfPageHeight-y))
+}
+
+// Bytes encodes the document as a PDF file
+func (document *pdfDocument) Bytes() []byte {
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] /Resources << /Font << /F1 4 0 R /F2 5 0 R >> >> /Contents 6 0 R >>", pdfPageWidth, pdfPageHeight),
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>",
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>",
+		fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", document.content.Len(), document.content.String()),
+	}
+
+	buffer := new(bytes.Buffer)
+	buffer.WriteString("%PDF-1.4\n")
+
+	offsets := make([]int, len(objects))
+	for index, object := range objects {
+		offsets[index] = buffer.Len()
+		buffer.WriteString(fmt.Sprintf("%d 0 obj\n%s\nendobj\n", index+1, object))
+	}
+
+	xref := buffer.Len()
+	buffer.WriteString(fmt.Sprintf("xref\n0 %d\n0000000000 65535 f \n", len(objects)+1))
+	for _, offset := range offsets {
+		buffer.WriteString(fmt.Sprintf("%010d 00000 n \n", offset))
+	}
+	buffer.WriteString(fmt.Sprintf("trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref))
+
+	return buffer.Bytes()
+}
+
+func (document *pdfDocument) escape(text string) string {
+	builder := new(strings.Builder)
+	for _, char := range text {
+		switch {
+		case char == '\\' || char == '(' || char == ')':
+			builder.WriteRune('\\')
+			builder.WriteRune(char)
+		case char < 32 || char > 126:
+			builder.WriteRune('?')
+		default:
+			builder.WriteRune(char)
+		}
+	}
+	return builder.String()
+}
@@ -0,0 +1,178 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/palantir/stacktrace"
+)
+
+// PhoneFailoverService moves the messages of a phone which stopped sending heartbeats to a backup phone
+type PhoneFailoverService struct {
+	service
+	logger              telemetry.Logger
+	tracer              telemetry.Tracer
+	repository          repositories.PhoneFailoverPolicyRepository
+	phoneRepository     repositories.PhoneRepository
+	heartbeatRepository repositories.HeartbeatRepository
+	messageService      *MessageService
+}
+
+// NewPhoneFailoverService creates a new PhoneFailoverService
+func NewPhoneFailoverService(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	repository repositories.PhoneFailoverPolicyRepository,
+	phoneRepository repositories.PhoneRepository,
+	heartbeatRepository repositories.HeartbeatRepository,
+	messageService *MessageService,
+) (s *PhoneFailoverService) {
+	return &PhoneFailoverService{
+		logger:              logger.WithService(fmt.Sprintf("%T", s)),
+		tracer:              tracer,
+		repository:          repository,
+		phoneRepository:     phoneRepository,
+		heartbeatRepository: heartbeatRepository,
+		messageService:      messageService,
+	}
+}
+
+// Index returns the entities.PhoneFailoverPolicy configured by a user
+func (service *PhoneFailoverService) Index(ctx context.Context, userID entities.UserID) ([]*entities.PhoneFailoverPolicy, error) {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	policies, err := service.repository.Index(ctx, userID)
+	if err != nil {
+		msg := fmt.Sprintf("could not fetch phone failover policies for user [%s]", userID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return policies, nil
+}
+
+// PhoneFailoverPolicyUpsertParams are parameters for configuring an entities.PhoneFailoverPolicy
+type PhoneFailoverPolicyUpsertParams struct {
+	UserID       entities.UserID
+	Owner        string
+	BackupOwners []string
+}
+
+// Upsert configures the backup phones of an owner
+func (service *PhoneFailoverService) Upsert(ctx context.Context, params *PhoneFailoverPolicyUpsertParams) (*entities.PhoneFailoverPolicy, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	policy, err := service.repository.Load(ctx, params.UserID, params.Owner)
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		policy = &entities.PhoneFailoverPolicy{
+			ID:        uuid.New(),
+			UserID:    params.UserID,
+			Owner:     params.Owner,
+			CreatedAt: time.Now().UTC(),
+		}
+	} else if err != nil {
+		msg := fmt.Sprintf("could not load phone failover policy with owner [%s] for user [%s]", params.Owner, params.UserID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	policy.BackupOwners = pq.StringArray(params.BackupOwners)
+	policy.UpdatedAt = time.Now().UTC()
+
+	if err = service.repository.Save(ctx, policy); err != nil {
+		msg := fmt.Sprintf("cannot save phone failover policy with id [%s]", policy.ID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("phone failover policy saved with id [%s] for owner [%s]", policy.ID, policy.Owner))
+	return policy, nil
+}
+
+// Delete an entities.PhoneFailoverPolicy so that the messages of the owner wait for its phone
+func (service *PhoneFailoverService) Delete(ctx context.Context, userID entities.UserID, policyID uuid.UUID) error {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	if err := service.repository.Delete(ctx, userID, policyID); err != nil {
+		msg := fmt.Sprintf("cannot delete phone failover policy with id [%s] for user [%s]", policyID, userID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("phone failover policy with id [%s] deleted for user [%s]", policyID, userID))
+	return nil
+}
+
+// PhoneFailoverParams are parameters for moving the messages of a phone which is offline
+type PhoneFailoverParams struct {
+	Source string
+	UserID entities.UserID
+	Owner  string
+}
+
+// Failover moves the waiting messages of an owner to the first backup phone which is online
+func (service *PhoneFailoverService) Failover(ctx context.Context, params *PhoneFailoverParams) error {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	policy, err := service.repository.Load(ctx, params.UserID, params.Owner)
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		ctxLogger.Info(fmt.Sprintf("user [%s] has no phone failover policy for owner [%s]", params.UserID, params.Owner))
+		return nil
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("could not load phone failover policy with owner [%s] for user [%s]", params.Owner, params.UserID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	backup := service.onlineBackup(ctx, policy)
+	if backup == nil {
+		ctxLogger.Warn(stacktrace.NewError(fmt.Sprintf("none of the backup phones %v of owner [%s] for user [%s] is online", policy.BackupOwners, policy.Owner, policy.UserID)))
+		return nil
+	}
+
+	count, err := service.messageService.FailoverMessages(ctx, params.Source, params.UserID, params.Owner, backup.PhoneNumber)
+	if err != nil {
+		msg := fmt.Sprintf("cannot move the messages of owner [%s] to backup phone [%s] after moving [%d] messages", params.Owner, backup.ID, count)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("moved [%d] messages of offline owner [%s] to backup phone [%s]", count, params.Owner, backup.ID))
+	return nil
+}
+
+// onlineBackup returns the first backup entities.Phone of a policy which can send messages
+func (service *PhoneFailoverService) onlineBackup(ctx context.Context, policy *entities.PhoneFailoverPolicy) *entities.Phone {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	for _, owner := range policy.BackupOwners {
+		phone, err := service.phoneRepository.Load(ctx, policy.UserID, owner)
+		if err != nil {
+			ctxLogger.Warn(stacktrace.Propagate(err, fmt.Sprintf("cannot load backup phone [%s] of owner [%s]", owner, policy.Owner)))
+			continue
+		}
+
+		if phone.IsKillSwitchEnabled() {
+			continue
+		}
+
+		heartbeat, err := service.heartbeatRepository.Last(ctx, policy.UserID, owner)
+		if err != nil {
+			ctxLogger.Warn(stacktrace.Propagate(err, fmt.Sprintf("cannot load last heartbeat of backup phone [%s]", phone.ID)))
+			continue
+		}
+
+		if time.Now().UTC().Sub(heartbeat.Timestamp) <= heartbeatCheckInterval {
+			return phone
+		}
+	}
+
+	return nil
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strconv"
 	"time"
 
 	"github.com/NdoleStudio/httpsms/pkg/events"
@@ -24,7 +25,10 @@ type PhoneNotificationService struct {
 	tracer                      telemetry.Tracer
 	phoneNotificationRepository repositories.PhoneNotificationRepository
 	phoneRepository             repositories.PhoneRepository
+	messageRepository           repositories.MessageRepository
 	messagingClient             *messaging.Client
+	natsTransport               *NATSPhoneTransport
+	mqttBridge                  *MQTTPhoneBridge
 	eventDispatcher             *EventDispatcher
 }
 
@@ -35,7 +39,10 @@ func NewNotificationService(
 	messagingClient *messaging.Client,
 	phoneRepository repositories.PhoneRepository,
 	phoneNotificationRepository repositories.PhoneNotificationRepository,
+	messageRepository repositories.MessageRepository,
 	dispatcher *EventDispatcher,
+	natsTransport *NATSPhoneTransport,
+	mqttBridge *MQTTPhoneBridge,
 ) (s *PhoneNotificationService) {
 	return &PhoneNotificationService{
 		logger:                      logger.WithService(fmt.Sprintf("%T", s)),
@@ -43,7 +50,10 @@ func NewNotificationService(
 		messagingClient:             messagingClient,
 		phoneNotificationRepository: phoneNotificationRepository,
 		phoneRepository:             phoneRepository,
+		messageRepository:           messageRepository,
 		eventDispatcher:             dispatcher,
+		natsTransport:               natsTransport,
+		mqttBridge:                  mqttBridge,
 	}
 }
 
@@ -102,16 +112,69 @@ func (service *PhoneNotificationService) Send(ctx context.Context, params *Phone
 		return service.handleNotificationFailed(ctx, errors.New(msg), params)
 	}
 
+	// the notification is released when the kill switch is disabled
+	if phone.IsKillSwitchEnabled() {
+		service.updateStatus(ctx, params.PhoneNotificationID, entities.PhoneNotificationStatusWaiting)
+		return nil
+	}
+
+	full, err := service.isInFlightLimitReached(ctx, phone, params.MessageID)
+	if err != nil {
+		return service.tracer.WrapErrorSpan(span, err)
+	}
+
+	if full {
+		service.updateStatus(ctx, params.PhoneNotificationID, entities.PhoneNotificationStatusWaiting)
+		return nil
+	}
+
+	message, err := service.messageRepository.Load(ctx, params.UserID, params.MessageID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot load message with userID [%s] and messageID [%s]", params.UserID, params.MessageID)
+		return service.handleNotificationFailed(ctx, errors.New(msg), params)
+	}
+
+	sim, available, err := service.selectSIM(ctx, phone, message)
+	if err != nil {
+		return service.tracer.WrapErrorSpan(span, err)
+	}
+
+	if !available {
+		return service.deferForSIMLimit(ctx, params)
+	}
+
+	if sim != message.SIM {
+		if err = service.messageRepository.AssignSIM(ctx, message.UserID, message.ID, sim); err != nil {
+			msg := fmt.Sprintf("cannot assign SIM [%s] to message [%s]", sim, message.ID)
+			return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+		}
+		message.SIM = sim
+	}
+
+	switch phone.Transport {
+	case entities.PhoneTransportNATS:
+		return service.sendNATS(ctx, phone, message, params)
+	case entities.PhoneTransportMQTT:
+		return service.sendMQTT(ctx, phone, message, params)
+	}
+
 	if phone.FcmToken == nil {
 		msg := fmt.Sprintf("phone with id [%s] has no FCM token", phone.ID)
 		return service.handleNotificationFailed(ctx, errors.New(msg), params)
 	}
 
-	ttl := phone.MessageExpirationDuration()
+	data := map[string]string{
+		"KEY_MESSAGE_ID": params.MessageID.String(),
+	}
+
+	// the phone skips the message and reports it as SKIPPED-EXPIRED when it is received after this time
+	if expiresAt := message.ExpiresAt(phone, time.Now().UTC()); expiresAt != nil {
+		data["KEY_MESSAGE_EXPIRES_AT"] = expiresAt.Format(time.RFC3339)
+	}
+
+	ttl := message.ExpirationDuration(phone)
 	result, err := service.messagingClient.Send(ctx, &messaging.Message{
-		Data: map[string]string{
-			"KEY_MESSAGE_ID": params.MessageID.String(),
-		},
+		Data: data,
 		Android: &messaging.AndroidConfig{
 			Priority: "normal",
 			TTL:      &ttl,
@@ -121,7 +184,286 @@ func (service *PhoneNotificationService) Send(ctx context.Context, params *Phone
 	if err != nil {
 		return service.handleNotificationFailed(ctx, err, params)
 	}
-	return service.handleNotificationSent(ctx, phone, result, params)
+	return service.handleNotificationSent(ctx, phone, message, result, params)
+}
+
+// sendNATS publishes the message job to the NATS subject of the phone instead of sending an FCM notification
+func (service *PhoneNotificationService) sendNATS(ctx context.Context, phone *entities.Phone, message *entities.Message, params *PhoneNotificationSendParams) error {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	if service.natsTransport == nil {
+		msg := fmt.Sprintf("phone with id [%s] uses the NATS transport but NATS is not configured on the server", phone.ID)
+		return service.handleNotificationFailed(ctx, errors.New(msg), params)
+	}
+
+	result, err := service.natsTransport.Publish(ctx, phone, &NATSPhoneJob{
+		MessageID:      params.MessageID,
+		NotificationID: params.PhoneNotificationID,
+		PhoneID:        phone.ID,
+		UserID:         phone.UserID,
+		Owner:          phone.PhoneNumber,
+		ExpiresAt:      message.ExpiresAt(phone, time.Now().UTC()),
+	})
+	if err != nil {
+		return service.handleNotificationFailed(ctx, err, params)
+	}
+	return service.handleNotificationSent(ctx, phone, message, result, params)
+}
+
+// sendMQTT publishes the message command to the MQTT command topic of the phone
+func (service *PhoneNotificationService) sendMQTT(ctx context.Context, phone *entities.Phone, message *entities.Message, params *PhoneNotificationSendParams) error {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	if service.mqttBridge == nil {
+		msg := fmt.Sprintf("phone with id [%s] uses the MQTT transport but MQTT is not configured on the server", phone.ID)
+		return service.handleNotificationFailed(ctx, errors.New(msg), params)
+	}
+
+	result, err := service.mqttBridge.Publish(ctx, phone, &MQTTPhoneCommand{
+		MessageID:      params.MessageID,
+		NotificationID: params.PhoneNotificationID,
+		ExpiresAt:      message.ExpiresAt(phone, time.Now().UTC()),
+	})
+	if err != nil {
+		return service.handleNotificationFailed(ctx, err, params)
+	}
+	return service.handleNotificationSent(ctx, phone, message, result, params)
+}
+
+// SendKillSwitch tells the phone to stop sending messages immediately or to resume sending messages
+func (service *PhoneNotificationService) SendKillSwitch(ctx context.Context, payload *events.PhoneKillSwitchUpdatedPayload) error {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	phone, err := service.phoneRepository.LoadByID(ctx, payload.UserID, payload.PhoneID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot load phone with userID [%s] and phoneID [%s]", payload.UserID, payload.PhoneID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	// phones without an FCM token find out about the kill switch when they fetch their next message
+	if phone.FcmToken == nil {
+		ctxLogger.Info(fmt.Sprintf("phone with id [%s] has no FCM token for the kill switch", phone.ID))
+		return nil
+	}
+
+	data := map[string]string{
+		"KEY_KILL_SWITCH": strconv.FormatBool(payload.Enabled),
+	}
+	if payload.Reason != nil {
+		data["KEY_KILL_SWITCH_REASON"] = *payload.Reason
+	}
+
+	result, err := service.messagingClient.Send(ctx, &messaging.Message{
+		Data: data,
+		Android: &messaging.AndroidConfig{
+			Priority: "high",
+		},
+		Token: *phone.FcmToken,
+	})
+	if err != nil {
+		msg := fmt.Sprintf("cannot send kill switch FCM to phone with id [%s]", phone.ID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("sent kill switch [%t] FCM [%s] to phone with ID [%s] for user [%s]", payload.Enabled, result, phone.ID, phone.UserID))
+	return nil
+}
+
+// PhoneNotificationReleaseParams are parameters for releasing waiting notifications
+type PhoneNotificationReleaseParams struct {
+	UserID    entities.UserID
+	Owner     string
+	Source    string
+	MessageID uuid.UUID
+}
+
+// ReleaseWaiting sends the waiting notifications of a phone which fit within entities.Phone.MaxInFlightMessages
+func (service *PhoneNotificationService) ReleaseWaiting(ctx context.Context, params *PhoneNotificationReleaseParams) error {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	phone, err := service.phoneRepository.Load(ctx, params.UserID, params.Owner)
+	if err != nil {
+		msg := fmt.Sprintf("cannot load phone with userID [%s] and phone [%s]", params.UserID, params.Owner)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if phone.IsKillSwitchEnabled() {
+		return nil
+	}
+
+	limit := 100
+	if phone.MaxInFlightMessages > 0 {
+		count, err := service.phoneNotificationRepository.CountInFlight(ctx, phone.ID, params.MessageID)
+		if err != nil {
+			msg := fmt.Sprintf("cannot count in flight messages for phone [%s]", phone.ID)
+			return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+		}
+		if count >= int64(phone.MaxInFlightMessages) {
+			return nil
+		}
+		limit = int(int64(phone.MaxInFlightMessages) - count)
+	}
+
+	notifications, err := service.phoneNotificationRepository.FetchWaiting(ctx, phone.ID, limit)
+	if err != nil {
+		msg := fmt.Sprintf("cannot fetch waiting notifications for phone [%s]", phone.ID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	for index := range notifications {
+		released, err := service.phoneNotificationRepository.Release(ctx, notifications[index].ID)
+		if err != nil {
+			msg := fmt.Sprintf("cannot release notification [%s] for phone [%s]", notifications[index].ID, phone.ID)
+			return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+		}
+
+		// another worker already released this notification
+		if !released {
+			continue
+		}
+
+		notifications[index].ScheduledAt = time.Now().UTC()
+		if err = service.dispatchMessageNotificationSend(ctx, params.Source, &notifications[index]); err != nil {
+			return service.tracer.WrapErrorSpan(span, err)
+		}
+		ctxLogger.Info(fmt.Sprintf("released waiting notification [%s] for message [%s] to phone [%s]", notifications[index].ID, notifications[index].MessageID, phone.ID))
+	}
+
+	return nil
+}
+
+// phoneSIMLimitRetryInterval is how long a notification waits before it is sent again when all the SIM cards reached their send limits
+const phoneSIMLimitRetryInterval = 10 * time.Minute
+
+// selectSIM returns the SIM card which sends the message. It returns false when the SIM cards which can send the message reached their send limits.
+func (service *PhoneNotificationService) selectSIM(ctx context.Context, phone *entities.Phone, message *entities.Message) (entities.SIM, bool, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	if message.SIM != entities.SIMDefault || !phone.IsSIMBalanced() {
+		available, err := service.isSIMAvailable(ctx, phone, message.SIM)
+		return message.SIM, available, err
+	}
+
+	candidates := phone.RoundRobinSIMs()
+	if phone.SIMBalancing == entities.PhoneSIMBalancingLeastRecentlyUsed {
+		var err error
+		if candidates, err = service.leastRecentlyUsedSIMs(ctx, phone); err != nil {
+			return message.SIM, false, stacktrace.Propagate(err, fmt.Sprintf("cannot order the SIM cards of phone [%s]", phone.ID))
+		}
+	}
+
+	for _, sim := range candidates {
+		available, err := service.isSIMAvailable(ctx, phone, sim)
+		if err != nil {
+			return message.SIM, false, err
+		}
+
+		if !available {
+			continue
+		}
+
+		if phone.SIMBalancing == entities.PhoneSIMBalancingRoundRobin {
+			phone.LastBalancedSIM = sim
+			if err = service.phoneRepository.Save(ctx, phone); err != nil {
+				return message.SIM, false, stacktrace.Propagate(err, fmt.Sprintf("cannot save last balanced SIM [%s] of phone [%s]", sim, phone.ID))
+			}
+		}
+
+		ctxLogger.Info(fmt.Sprintf("[%s] balancing selected SIM [%s] of phone [%s] for message [%s]", phone.SIMBalancing, sim, phone.ID, message.ID))
+		return sim, true, nil
+	}
+
+	return message.SIM, false, nil
+}
+
+// leastRecentlyUsedSIMs returns SIM1 and SIM2 ordered by the time they last sent a message with the oldest first
+func (service *PhoneNotificationService) leastRecentlyUsedSIMs(ctx context.Context, phone *entities.Phone) ([]entities.SIM, error) {
+	sim1, err := service.messageRepository.LastAttemptedBySIM(ctx, phone.UserID, phone.PhoneNumber, entities.SIM1)
+	if err != nil {
+		return nil, stacktrace.Propagate(err, fmt.Sprintf("cannot load last message sent with [%s] by phone [%s]", entities.SIM1, phone.ID))
+	}
+
+	sim2, err := service.messageRepository.LastAttemptedBySIM(ctx, phone.UserID, phone.PhoneNumber, entities.SIM2)
+	if err != nil {
+		return nil, stacktrace.Propagate(err, fmt.Sprintf("cannot load last message sent with [%s] by phone [%s]", entities.SIM2, phone.ID))
+	}
+
+	if sim2 == nil || (sim1 != nil && sim1.After(*sim2)) {
+		return []entities.SIM{entities.SIM2, entities.SIM1}, nil
+	}
+	return []entities.SIM{entities.SIM1, entities.SIM2}, nil
+}
+
+// isSIMAvailable checks if a SIM card has not reached its hourly or daily send limit
+func (service *PhoneNotificationService) isSIMAvailable(ctx context.Context, phone *entities.Phone, sim entities.SIM) (bool, error) {
+	hourly, daily := phone.SIMLimits(sim)
+
+	limits := map[time.Duration]uint{time.Hour: hourly, 24 * time.Hour: daily}
+	for window, limit := range limits {
+		if limit == 0 {
+			continue
+		}
+
+		count, err := service.messageRepository.CountAttemptedBySIM(ctx, phone.UserID, phone.PhoneNumber, sim, time.Now().UTC().Add(-window))
+		if err != nil {
+			return false, stacktrace.Propagate(err, fmt.Sprintf("cannot count messages sent with SIM [%s] by phone [%s]", sim, phone.ID))
+		}
+
+		if count >= int64(limit) {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+// deferForSIMLimit sends the notification again after phoneSIMLimitRetryInterval
+func (service *PhoneNotificationService) deferForSIMLimit(ctx context.Context, params *PhoneNotificationSendParams) error {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	notification := &entities.PhoneNotification{
+		ID:          params.PhoneNotificationID,
+		MessageID:   params.MessageID,
+		UserID:      params.UserID,
+		PhoneID:     params.PhoneID,
+		ScheduledAt: time.Now().UTC().Add(phoneSIMLimitRetryInterval),
+	}
+
+	if err := service.dispatchMessageNotificationSend(ctx, params.Source, notification); err != nil {
+		return service.tracer.WrapErrorSpan(span, err)
+	}
+
+	ctxLogger.Info(fmt.Sprintf("notification [%s] for message [%s] deferred to [%s] because the SIM cards of phone [%s] reached their send limits", notification.ID, params.MessageID, notification.ScheduledAt, params.PhoneID))
+	return nil
+}
+
+// isInFlightLimitReached checks if the phone cannot be notified about another message until it finishes sending the in flight messages
+func (service *PhoneNotificationService) isInFlightLimitReached(ctx context.Context, phone *entities.Phone, messageID uuid.UUID) (bool, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	if phone.MaxInFlightMessages == 0 {
+		return false, nil
+	}
+
+	count, err := service.phoneNotificationRepository.CountInFlight(ctx, phone.ID, messageID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot count in flight messages for phone [%s]", phone.ID)
+		return false, stacktrace.Propagate(err, msg)
+	}
+
+	if count < int64(phone.MaxInFlightMessages) {
+		return false, nil
+	}
+
+	ctxLogger.Info(fmt.Sprintf("phone [%s] has [%d] messages in flight which reaches the limit of [%d]", phone.ID, count, phone.MaxInFlightMessages))
+	return true, nil
 }
 
 // PhoneNotificationScheduleParams are parameters for sending a notification
@@ -133,6 +475,7 @@ type PhoneNotificationScheduleParams struct {
 	Content   string
 	SIM       entities.SIM
 	MessageID uuid.UUID
+	Priority  entities.MessagePriority
 }
 
 // Schedule a notification to be sent to a phone
@@ -154,12 +497,13 @@ func (service *PhoneNotificationService) Schedule(ctx context.Context, params *P
 		UserID:      params.UserID,
 		PhoneID:     phone.ID,
 		Status:      entities.PhoneNotificationStatusPending,
+		Priority:    params.Priority.OrDefault(),
 		ScheduledAt: time.Now().UTC(),
 		CreatedAt:   time.Now().UTC(),
 		UpdatedAt:   time.Now().UTC(),
 	}
 
-	if err = service.phoneNotificationRepository.Schedule(ctx, phone.MessagesPerMinute, notification); err != nil {
+	if err = service.phoneNotificationRepository.Schedule(ctx, phone.SendInterval(time.Now().UTC()), notification); err != nil {
 		msg := fmt.Sprintf("cannot schedule notification for message [%s] to phone [%s]", params.MessageID, phone.ID)
 		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
 	}
@@ -238,7 +582,7 @@ func (service *PhoneNotificationService) handleNotificationFailed(ctx context.Co
 	return nil
 }
 
-func (service *PhoneNotificationService) handleNotificationSent(ctx context.Context, phone *entities.Phone, result string, params *PhoneNotificationSendParams) error {
+func (service *PhoneNotificationService) handleNotificationSent(ctx context.Context, phone *entities.Phone, message *entities.Message, result string, params *PhoneNotificationSendParams) error {
 	ctx, span := service.tracer.Start(ctx)
 	defer span.End()
 
@@ -246,7 +590,7 @@ func (service *PhoneNotificationService) handleNotificationSent(ctx context.Cont
 
 	ctxLogger.Info(fmt.Sprintf("sent notification [%s] for message [%s] to phone [%s]", result, params.MessageID, params.PhoneID))
 
-	event, err := service.createMessageNotificationSentEvent(params.Source, phone, result, params)
+	event, err := service.createMessageNotificationSentEvent(params.Source, phone, message, result, params)
 	if err != nil {
 		return stacktrace.Propagate(err, fmt.Sprintf("cannot create [%s] event for notification [%s]", events.EventTypeMessageNotificationSent, params.PhoneNotificationID))
 	}
@@ -267,7 +611,7 @@ func (service *PhoneNotificationService) createMessageNotificationSendEvent(sour
 	return service.createEvent(events.EventTypeMessageNotificationSend, source, payload)
 }
 
-func (service *PhoneNotificationService) createMessageNotificationSentEvent(source string, phone *entities.Phone, fcmMessageID string, params *PhoneNotificationSendParams) (cloudevents.Event, error) {
+func (service *PhoneNotificationService) createMessageNotificationSentEvent(source string, phone *entities.Phone, message *entities.Message, fcmMessageID string, params *PhoneNotificationSendParams) (cloudevents.Event, error) {
 	event := cloudevents.NewEvent()
 
 	event.SetSource(source)
@@ -280,7 +624,7 @@ func (service *PhoneNotificationService) createMessageNotificationSentEvent(sour
 		UserID:                    params.UserID,
 		PhoneID:                   params.PhoneID,
 		ScheduledAt:               params.ScheduledAt,
-		MessageExpirationDuration: phone.MessageExpirationDuration(),
+		MessageExpirationDuration: message.ExpirationDuration(phone),
 		FcmMessageID:              fcmMessageID,
 		NotificationSentAt:        time.Now().UTC(),
 		NotificationID:            params.PhoneNotificationID,
@@ -0,0 +1,128 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/palantir/stacktrace"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/metric/instrument"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+)
+
+// PhonePaceService slows down the dispatch rate of a phone based on the failures and send durations it reports
+type PhonePaceService struct {
+	service
+	logger            telemetry.Logger
+	tracer            telemetry.Tracer
+	phoneRepository   repositories.PhoneRepository
+	messageRepository repositories.MessageRepository
+	paceHistogram     instrument.Float64Histogram
+}
+
+// NewPhonePaceService creates a new PhonePaceService
+func NewPhonePaceService(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	meter metric.Meter,
+	phoneRepository repositories.PhoneRepository,
+	messageRepository repositories.MessageRepository,
+) (s *PhonePaceService) {
+	logger = logger.WithService(fmt.Sprintf("%T", s))
+
+	histogram, err := meter.Float64Histogram(
+		"httpsms.phone.pace",
+		instrument.WithDescription("The fraction of the configured sending rate used by a phone"),
+		instrument.WithUnit("1"),
+	)
+	if err != nil {
+		logger.Error(stacktrace.Propagate(err, "cannot create phone pace histogram"))
+	}
+
+	return &PhonePaceService{
+		logger:            logger,
+		tracer:            tracer,
+		phoneRepository:   phoneRepository,
+		messageRepository: messageRepository,
+		paceHistogram:     histogram,
+	}
+}
+
+// PhonePaceRecordParams are parameters for recording the outcome of a message sent by a phone
+type PhonePaceRecordParams struct {
+	UserID    entities.UserID
+	Owner     string
+	MessageID uuid.UUID
+	Failed    bool
+	Timestamp time.Time
+}
+
+// Record adjusts the pace of the phone which sent a message
+func (service *PhonePaceService) Record(ctx context.Context, params *PhonePaceRecordParams) error {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	phone, err := service.phoneRepository.Load(ctx, params.UserID, params.Owner)
+	if err != nil {
+		msg := fmt.Sprintf("cannot load phone with userID [%s] and owner [%s]", params.UserID, params.Owner)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	pace := phone.Pace()
+	phone.UpdatePace(params.Failed, service.sendDuration(ctx, params), time.Now().UTC())
+
+	if err = service.phoneRepository.UpdatePace(ctx, phone); err != nil {
+		msg := fmt.Sprintf("cannot update pace of phone [%s]", phone.ID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if service.paceHistogram != nil {
+		service.paceHistogram.Record(ctx, phone.Pace(), attribute.String("phone_id", phone.ID.String()))
+	}
+
+	if phone.Pace() != pace {
+		ctxLogger.Info(fmt.Sprintf(
+			"pace of phone [%s] changed from [%.2f] to [%.2f] with failure rate [%.2f] and send duration [%.1fs]",
+			phone.ID,
+			pace,
+			phone.Pace(),
+			phone.PaceFailureRate,
+			phone.PaceSendDurationSeconds,
+		))
+	}
+
+	return nil
+}
+
+// sendDuration is the time between the phone being notified about a message and the phone sending it
+func (service *PhonePaceService) sendDuration(ctx context.Context, params *PhonePaceRecordParams) time.Duration {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	if params.Failed {
+		return 0
+	}
+
+	message, err := service.messageRepository.Load(ctx, params.UserID, params.MessageID)
+	if err != nil {
+		ctxLogger.Warn(stacktrace.Propagate(err, fmt.Sprintf("cannot load message [%s] to compute the send duration", params.MessageID)))
+		return 0
+	}
+
+	startedAt := message.LastAttemptedAt
+	if startedAt == nil {
+		startedAt = message.NotificationScheduledAt
+	}
+
+	if startedAt == nil || params.Timestamp.Before(*startedAt) {
+		return 0
+	}
+
+	return params.Timestamp.Sub(*startedAt)
+}
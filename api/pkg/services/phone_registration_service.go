@@ -0,0 +1,190 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/emails"
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/google/uuid"
+	"github.com/palantir/stacktrace"
+)
+
+// ErrCodePhoneRegistrationExpired is returned when the revoke link of an entities.PhoneRegistration has expired
+const ErrCodePhoneRegistrationExpired = stacktrace.ErrorCode(2016)
+
+// phoneRegistrationRevokeTTL is how long the revoke link in the email can be used
+const phoneRegistrationRevokeTTL = 7 * 24 * time.Hour
+
+// PhoneRegistrationService notifies users about the devices which are registered on their account
+type PhoneRegistrationService struct {
+	service
+	logger          telemetry.Logger
+	tracer          telemetry.Tracer
+	repository      repositories.PhoneRegistrationRepository
+	phoneRepository repositories.PhoneRepository
+	userRepository  repositories.UserRepository
+	phoneService    *PhoneService
+	mailer          emails.Mailer
+	emailFactory    emails.UserEmailFactory
+}
+
+// NewPhoneRegistrationService creates a new PhoneRegistrationService
+func NewPhoneRegistrationService(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	repository repositories.PhoneRegistrationRepository,
+	phoneRepository repositories.PhoneRepository,
+	userRepository repositories.UserRepository,
+	phoneService *PhoneService,
+	mailer emails.Mailer,
+	emailFactory emails.UserEmailFactory,
+) (s *PhoneRegistrationService) {
+	return &PhoneRegistrationService{
+		logger:          logger.WithService(fmt.Sprintf("%T", s)),
+		tracer:          tracer,
+		repository:      repository,
+		phoneRepository: phoneRepository,
+		userRepository:  userRepository,
+		phoneService:    phoneService,
+		mailer:          mailer,
+		emailFactory:    emailFactory,
+	}
+}
+
+// PhoneRegistrationNotifyParams are parameters for notifying a user about a registered device
+type PhoneRegistrationNotifyParams struct {
+	UserID     entities.UserID
+	PhoneID    uuid.UUID
+	IsNewPhone bool
+	Timestamp  time.Time
+}
+
+// Notify stores an entities.PhoneRegistration and emails the user a link which revokes the device.
+// The token of the link is not part of the event because events are stored and sent to webhooks.
+func (service *PhoneRegistrationService) Notify(ctx context.Context, params *PhoneRegistrationNotifyParams) error {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	phone, err := service.phoneRepository.LoadByID(ctx, params.UserID, params.PhoneID)
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		ctxLogger.Info(fmt.Sprintf("phone [%s] of user [%s] was deleted before the device registration was notified", params.PhoneID, params.UserID))
+		return nil
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot load phone [%s] of user [%s]", params.PhoneID, params.UserID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	user, err := service.userRepository.Load(ctx, params.UserID)
+	if err != nil {
+		msg := fmt.Sprintf("could not get [%T] with ID [%s]", user, params.UserID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	token, tokenHash, err := service.generateToken()
+	if err != nil {
+		msg := fmt.Sprintf("cannot generate revoke token for phone [%s]", phone.ID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	timestamp := time.Now().UTC()
+	registration := &entities.PhoneRegistration{
+		ID:              uuid.New(),
+		UserID:          phone.UserID,
+		PhoneID:         phone.ID,
+		Owner:           phone.PhoneNumber,
+		IsNewPhone:      params.IsNewPhone,
+		FcmTokenHash:    service.fcmTokenHash(phone),
+		RevokeTokenHash: tokenHash,
+		ExpiresAt:       timestamp.Add(phoneRegistrationRevokeTTL),
+		CreatedAt:       timestamp,
+		UpdatedAt:       timestamp,
+	}
+
+	if err = service.repository.Store(ctx, registration); err != nil {
+		msg := fmt.Sprintf("cannot store registration of phone [%s]", phone.ID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	email, err := service.emailFactory.PhoneRegistered(user, registration, params.Timestamp, token)
+	if err != nil {
+		msg := fmt.Sprintf("cannot create phone registered email for user [%s]", params.UserID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if err = service.mailer.Send(ctx, email); err != nil {
+		msg := fmt.Sprintf("canot send phone registered notification to user [%s]", params.UserID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("phone registered notification sent successfully to [%s] about [%s]", user.Email, phone.PhoneNumber))
+	return nil
+}
+
+// Revoke deletes the phone of an entities.PhoneRegistration with the token from the email.
+// The phone is kept when another device registered on it since the email was sent.
+func (service *PhoneRegistrationService) Revoke(ctx context.Context, source string, token string) (*entities.PhoneRegistration, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	registration, err := service.repository.LoadByRevokeToken(ctx, service.hashToken(token))
+	if err != nil {
+		msg := "cannot load phone registration with revoke token"
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
+	}
+
+	if registration.IsRevoked() {
+		return registration, nil
+	}
+
+	timestamp := time.Now().UTC()
+	if registration.IsExpired(timestamp) {
+		msg := fmt.Sprintf("revoke token of phone registration [%s] expired at [%s]", registration.ID, registration.ExpiresAt)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.NewErrorWithCode(ErrCodePhoneRegistrationExpired, msg))
+	}
+
+	phone, err := service.phoneRepository.LoadByID(ctx, registration.UserID, registration.PhoneID)
+	if err != nil && stacktrace.GetCode(err) != repositories.ErrCodeNotFound {
+		msg := fmt.Sprintf("cannot load phone [%s] of user [%s]", registration.PhoneID, registration.UserID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if err == nil && service.isSameDevice(registration, phone) {
+		if err = service.phoneService.Delete(ctx, source, registration.UserID, registration.PhoneID); err != nil {
+			msg := fmt.Sprintf("cannot delete phone [%s] of registration [%s]", registration.PhoneID, registration.ID)
+			return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+		}
+		ctxLogger.Info(fmt.Sprintf("deleted phone [%s] of user [%s] after the device was revoked", registration.PhoneID, registration.UserID))
+	}
+
+	registration.RevokedAt = &timestamp
+	registration.UpdatedAt = timestamp
+	if err = service.repository.Update(ctx, registration); err != nil {
+		msg := fmt.Sprintf("cannot update phone registration [%s]", registration.ID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("phone registration [%s] of user [%s] revoked successfully", registration.ID, registration.UserID))
+	return registration, nil
+}
+
+func (service *PhoneRegistrationService) isSameDevice(registration *entities.PhoneRegistration, phone *entities.Phone) bool {
+	current := service.fcmTokenHash(phone)
+	if registration.FcmTokenHash == nil || current == nil {
+		return registration.FcmTokenHash == current
+	}
+	return *registration.FcmTokenHash == *current
+}
+
+func (service *PhoneRegistrationService) fcmTokenHash(phone *entities.Phone) *string {
+	if phone.FcmToken == nil {
+		return nil
+	}
+	hash := service.hashToken(*phone.FcmToken)
+	return &hash
+}
@@ -0,0 +1,215 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/events"
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/google/uuid"
+	"github.com/palantir/stacktrace"
+)
+
+// PhoneReputationService scores the reputation of owner phone numbers using the sentiment of the replies of contacts
+type PhoneReputationService struct {
+	service
+	logger     telemetry.Logger
+	tracer     telemetry.Tracer
+	repository repositories.PhoneReputationRepository
+	dispatcher *EventDispatcher
+}
+
+// NewPhoneReputationService creates a new PhoneReputationService
+func NewPhoneReputationService(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	repository repositories.PhoneReputationRepository,
+	dispatcher *EventDispatcher,
+) (s *PhoneReputationService) {
+	return &PhoneReputationService{
+		logger:     logger.WithService(fmt.Sprintf("%T", s)),
+		tracer:     tracer,
+		repository: repository,
+		dispatcher: dispatcher,
+	}
+}
+
+// Index computes the entities.PhoneReputation of every owner of a user which sent or received messages recently
+func (service *PhoneReputationService) Index(ctx context.Context, userID entities.UserID) ([]*entities.PhoneReputation, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	now := time.Now().UTC()
+	days, err := service.repository.IndexDays(ctx, userID, "", service.windowStart(now).Add(-entities.PhoneReputationWindow), now)
+	if err != nil {
+		msg := fmt.Sprintf("cannot fetch reputation days of user [%s]", userID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	owners := make(map[string][]*entities.PhoneReputationDay)
+	reputations := make([]*entities.PhoneReputation, 0)
+	for _, day := range days {
+		if _, ok := owners[day.Owner]; !ok {
+			reputations = append(reputations, &entities.PhoneReputation{UserID: userID, Owner: day.Owner})
+		}
+		owners[day.Owner] = append(owners[day.Owner], day)
+	}
+
+	for _, reputation := range reputations {
+		reputation.Compute(service.windowStart(now), owners[reputation.Owner])
+	}
+
+	ctxLogger.Info(fmt.Sprintf("computed reputation of [%d] owners for user [%s]", len(reputations), userID))
+	return reputations, nil
+}
+
+// RecordSent counts a message which was sent by an owner
+func (service *PhoneReputationService) RecordSent(ctx context.Context, userID entities.UserID, owner string, timestamp time.Time) error {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	day := service.day(userID, owner, timestamp)
+	day.Sent = 1
+
+	if err := service.repository.IncrementDay(ctx, day); err != nil {
+		msg := fmt.Sprintf("cannot count message sent by owner [%s] for user [%s]", owner, userID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+// PhoneReplyParams are parameters for recording a message which was received by an owner
+type PhoneReplyParams struct {
+	UserID    entities.UserID
+	Owner     string
+	Content   string
+	Timestamp time.Time
+	Source    string
+}
+
+// RecordReply classifies the sentiment of a received message, and dispatches the events.EventTypePhoneReputationDegraded event when a
+// negative reply increases the risk of the owner being blocked by carriers.
+func (service *PhoneReputationService) RecordReply(ctx context.Context, params *PhoneReplyParams) error {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	day := service.day(params.UserID, params.Owner, params.Timestamp)
+	day.Replies = 1
+
+	sentiment := entities.ClassifyReply(params.Content)
+	switch sentiment {
+	case entities.ReplySentimentNegative:
+		day.Negative = 1
+	case entities.ReplySentimentComplaint:
+		day.Complaints = 1
+	}
+
+	if err := service.repository.IncrementDay(ctx, day); err != nil {
+		msg := fmt.Sprintf("cannot count reply received by owner [%s] for user [%s]", params.Owner, params.UserID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if sentiment == entities.ReplySentimentNeutral {
+		return nil
+	}
+
+	ctxLogger.Info(fmt.Sprintf("owner [%s] of user [%s] received a [%s] reply", params.Owner, params.UserID, sentiment))
+	if err := service.evaluate(ctx, params); err != nil {
+		msg := fmt.Sprintf("cannot evaluate reputation of owner [%s] for user [%s]", params.Owner, params.UserID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+// evaluate computes the entities.PhoneReputation of an owner and alerts the user when the risk is higher than the stored risk
+func (service *PhoneReputationService) evaluate(ctx context.Context, params *PhoneReplyParams) error {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	reputation, err := service.repository.Load(ctx, params.UserID, params.Owner)
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		reputation = &entities.PhoneReputation{
+			ID:        uuid.New(),
+			UserID:    params.UserID,
+			Owner:     params.Owner,
+			Score:     100,
+			Risk:      entities.PhoneReputationRiskLow,
+			CreatedAt: time.Now().UTC(),
+		}
+	} else if err != nil {
+		msg := fmt.Sprintf("cannot load reputation of owner [%s] for user [%s]", params.Owner, params.UserID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	now := time.Now().UTC()
+	days, err := service.repository.IndexDays(ctx, params.UserID, params.Owner, service.windowStart(now).Add(-entities.PhoneReputationWindow), now)
+	if err != nil {
+		msg := fmt.Sprintf("cannot fetch reputation days of owner [%s] for user [%s]", params.Owner, params.UserID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	previousRisk := reputation.Risk
+	reputation.Compute(service.windowStart(now), days)
+	reputation.UpdatedAt = now
+
+	if err = service.repository.Save(ctx, reputation); err != nil {
+		msg := fmt.Sprintf("cannot save reputation of owner [%s] for user [%s]", params.Owner, params.UserID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if !reputation.Risk.IsWorseThan(previousRisk) {
+		return nil
+	}
+
+	event, err := service.createPhoneReputationDegradedEvent(params.Source, &events.PhoneReputationDegradedPayload{
+		UserID:        reputation.UserID,
+		Owner:         reputation.Owner,
+		Score:         reputation.Score,
+		PreviousScore: reputation.PreviousScore,
+		Risk:          reputation.Risk,
+		PreviousRisk:  previousRisk,
+		Sent:          reputation.Sent,
+		Negative:      reputation.Negative,
+		Complaints:    reputation.Complaints,
+		Timestamp:     now,
+	})
+	if err != nil {
+		msg := fmt.Sprintf("cannot create event when reputation of owner [%s] degraded", params.Owner)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if err = service.dispatcher.Dispatch(ctx, event); err != nil {
+		msg := fmt.Sprintf("cannot dispatch event [%s] for owner [%s] of user [%s]", event.Type(), params.Owner, params.UserID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("reputation of owner [%s] for user [%s] degraded from [%s] to [%s] risk with score [%d]", params.Owner, params.UserID, previousRisk, reputation.Risk, reputation.Score))
+	return nil
+}
+
+// windowStart is the first day of the current entities.PhoneReputationWindow
+func (service *PhoneReputationService) windowStart(now time.Time) time.Time {
+	return now.Truncate(24 * time.Hour).Add(-entities.PhoneReputationWindow + 24*time.Hour)
+}
+
+// day creates an empty entities.PhoneReputationDay of an owner
+func (service *PhoneReputationService) day(userID entities.UserID, owner string, timestamp time.Time) *entities.PhoneReputationDay {
+	return &entities.PhoneReputationDay{
+		ID:        uuid.New(),
+		UserID:    userID,
+		Owner:     owner,
+		Date:      timestamp.UTC().Truncate(24 * time.Hour),
+		CreatedAt: time.Now().UTC(),
+		UpdatedAt: time.Now().UTC(),
+	}
+}
+
+func (service *PhoneReputationService) createPhoneReputationDegradedEvent(source string, payload *events.PhoneReputationDegradedPayload) (cloudevents.Event, error) {
+	return service.createEvent(events.EventTypePhoneReputationDegraded, source, payload)
+}
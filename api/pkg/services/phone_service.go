@@ -18,6 +18,9 @@ import (
 	"github.com/NdoleStudio/httpsms/pkg/telemetry"
 )
 
+// ErrCodePhoneKillSwitchEnabled is thrown when a phone fetches a message to send while its kill switch is enabled
+const ErrCodePhoneKillSwitchEnabled = stacktrace.ErrorCode(2008)
+
 // PhoneService is handles phone requests
 type PhoneService struct {
 	service
@@ -67,14 +70,57 @@ func (service *PhoneService) Load(ctx context.Context, userID entities.UserID, o
 	return service.repository.Load(ctx, userID, owner)
 }
 
+// PhoneSyncClockParams are parameters for detecting the clock skew of an entities.Phone
+type PhoneSyncClockParams struct {
+	UserID     entities.UserID
+	Owner      string
+	Timestamp  time.Time
+	ReceivedAt time.Time
+}
+
+// SyncClock adds the timestamp reported by the phone in a heartbeat as a clock sample of an entities.Phone and returns the normalized timestamp
+func (service *PhoneService) SyncClock(ctx context.Context, params *PhoneSyncClockParams) (time.Time, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	phone, err := service.repository.Load(ctx, params.UserID, params.Owner)
+	if err != nil {
+		msg := fmt.Sprintf("cannot load phone with userID [%s] and owner [%s]", params.UserID, params.Owner)
+		return params.Timestamp, service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
+	}
+
+	changed := phone.AddClockSample(params.Timestamp, params.ReceivedAt)
+	if err = service.repository.UpdateClock(ctx, phone); err != nil {
+		msg := fmt.Sprintf("cannot update clock offset of phone with id [%s]", phone.ID)
+		return params.Timestamp, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if changed {
+		ctxLogger.Info(fmt.Sprintf("clock offset of phone with id [%s] is [%s]", phone.ID, phone.ClockOffset()))
+	}
+	return phone.NormalizeTimestamp(params.Timestamp), nil
+}
+
 // PhoneUpsertParams are parameters for creating a new entities.Phone
 type PhoneUpsertParams struct {
 	PhoneNumber               phonenumbers.PhoneNumber
 	FcmToken                  *string
 	MessagesPerMinute         *uint
 	MaxSendAttempts           *uint
+	MaxInFlightMessages       *uint
+	Transport                 *entities.PhoneTransport
+	ReleaseChannel            *entities.PhoneReleaseChannel
 	MessageExpirationDuration *time.Duration
+	DeliveryReportTimeout     *time.Duration
+	DuplicateCollapseDisabled *bool
+	WarmupEnabled             *bool
+	WarmupSchedule            []int64
 	IsDualSIM                 bool
+	SIMBalancing              *entities.PhoneSIMBalancing
+	SIM1HourlyLimit           *uint
+	SIM1DailyLimit            *uint
+	SIM2HourlyLimit           *uint
+	SIM2DailyLimit            *uint
 	Source                    string
 	UserID                    entities.UserID
 }
@@ -88,7 +134,11 @@ func (service *PhoneService) Upsert(ctx context.Context, params PhoneUpsertParam
 
 	phone, err := service.repository.Load(ctx, params.UserID, phonenumbers.Format(&params.PhoneNumber, phonenumbers.E164))
 	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
-		return service.createPhone(ctx, params)
+		if phone, err = service.createPhone(ctx, params); err != nil {
+			msg := fmt.Sprintf("cannot create phone with number [%s] for user [%s]", phonenumbers.Format(&params.PhoneNumber, phonenumbers.E164), params.UserID)
+			return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+		}
+		return phone, service.dispatchDeviceRegistered(ctx, params.Source, phone, true)
 	}
 
 	if err != nil {
@@ -96,6 +146,7 @@ func (service *PhoneService) Upsert(ctx context.Context, params PhoneUpsertParam
 		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
 	}
 
+	previousFcmToken := phone.FcmToken
 	if err = service.repository.Save(ctx, service.update(phone, params)); err != nil {
 		msg := fmt.Sprintf("cannot update phone with id [%s] and number [%s]", phone.ID, phone.PhoneNumber)
 		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
@@ -119,9 +170,39 @@ func (service *PhoneService) Upsert(ctx context.Context, params PhoneUpsertParam
 		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
 	}
 
+	if phone.FcmToken != nil && (previousFcmToken == nil || *previousFcmToken != *phone.FcmToken) {
+		return phone, service.dispatchDeviceRegistered(ctx, params.Source, phone, false)
+	}
+
 	return phone, nil
 }
 
+// dispatchDeviceRegistered notifies the user that a device was registered so that a rogue device can be revoked
+func (service *PhoneService) dispatchDeviceRegistered(ctx context.Context, source string, phone *entities.Phone, isNewPhone bool) error {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	event, err := service.createEvent(events.EventTypePhoneDeviceRegistered, source, events.PhoneDeviceRegisteredPayload{
+		PhoneID:    phone.ID,
+		UserID:     phone.UserID,
+		Owner:      phone.PhoneNumber,
+		IsNewPhone: isNewPhone,
+		Timestamp:  time.Now().UTC(),
+	})
+	if err != nil {
+		msg := fmt.Sprintf("cannot create event [%s] for phone with id [%s]", events.EventTypePhoneDeviceRegistered, phone.ID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if err = service.dispatcher.Dispatch(ctx, event); err != nil {
+		msg := fmt.Sprintf("cannot dispatch event [%s] for phone with id [%s]", event.Type(), phone.ID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("device registered on phone [%s] of user [%s]", phone.ID, phone.UserID))
+	return nil
+}
+
 // Delete an entities.Phone
 func (service *PhoneService) Delete(ctx context.Context, source string, userID entities.UserID, phoneID uuid.UUID) error {
 	ctx, span := service.tracer.Start(ctx)
@@ -162,6 +243,103 @@ func (service *PhoneService) Delete(ctx context.Context, source string, userID e
 	return nil
 }
 
+// PhoneKillSwitchParams are parameters for enabling or disabling the kill switch of an entities.Phone
+type PhoneKillSwitchParams struct {
+	Source  string
+	UserID  entities.UserID
+	PhoneID uuid.UUID
+	Enabled bool
+	Reason  *string
+}
+
+// UpdateKillSwitch stops a phone from sending messages or allows it to send messages again.
+// The phone is told about the change with a push notification and the messages which are held back are sent once the kill switch is disabled.
+func (service *PhoneService) UpdateKillSwitch(ctx context.Context, params *PhoneKillSwitchParams) (*entities.Phone, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	phone, err := service.repository.LoadByID(ctx, params.UserID, params.PhoneID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot load phone with userID [%s] and phoneID [%s]", params.UserID, params.PhoneID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
+	}
+
+	if params.Enabled == phone.IsKillSwitchEnabled() {
+		ctxLogger.Info(fmt.Sprintf("kill switch of phone [%s] is already set to [%t]", phone.ID, params.Enabled))
+		return phone, nil
+	}
+
+	phone.KillSwitchEnabledAt = nil
+	phone.KillSwitchReason = nil
+	if params.Enabled {
+		timestamp := time.Now().UTC()
+		phone.KillSwitchEnabledAt = &timestamp
+		phone.KillSwitchReason = params.Reason
+	}
+
+	if err = service.repository.Save(ctx, phone); err != nil {
+		msg := fmt.Sprintf("cannot save kill switch of phone with id [%s]", phone.ID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	event, err := service.createEvent(events.EventTypePhoneKillSwitchUpdated, params.Source, events.PhoneKillSwitchUpdatedPayload{
+		PhoneID:   phone.ID,
+		UserID:    phone.UserID,
+		Owner:     phone.PhoneNumber,
+		Enabled:   params.Enabled,
+		Reason:    phone.KillSwitchReason,
+		Timestamp: time.Now().UTC(),
+	})
+	if err != nil {
+		msg := fmt.Sprintf("cannot create event [%s] for phone with id [%s]", events.EventTypePhoneKillSwitchUpdated, phone.ID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if err = service.dispatcher.Dispatch(ctx, event); err != nil {
+		msg := fmt.Sprintf("cannot dispatch event [%s] for phone with id [%s]", event.Type(), phone.ID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("kill switch of phone [%s] for user [%s] set to [%t]", phone.ID, phone.UserID, params.Enabled))
+	return phone, nil
+}
+
+// PhoneMissedCallParams are parameters for registering a missed call on an entities.Phone
+type PhoneMissedCallParams struct {
+	Source    string
+	Owner     string
+	Contact   string
+	SIM       entities.SIM
+	UserID    entities.UserID
+	Timestamp time.Time
+}
+
+// MissedCall registers a call which was missed on the phone
+func (service *PhoneService) MissedCall(ctx context.Context, params PhoneMissedCallParams) error {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	event, err := service.createCallMissedEvent(params.Source, events.CallMissedPayload{
+		Owner:     params.Owner,
+		Contact:   params.Contact,
+		UserID:    params.UserID,
+		SIM:       params.SIM,
+		Timestamp: params.Timestamp,
+	})
+	if err != nil {
+		msg := fmt.Sprintf("cannot create event when call from [%s] is missed on [%s]", params.Contact, params.Owner)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if err = service.dispatcher.Dispatch(ctx, event); err != nil {
+		msg := fmt.Sprintf("cannot dispatch event [%s] for missed call on owner [%s]", event.Type(), params.Owner)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("missed call from [%s] registered on owner [%s]", params.Contact, params.Owner))
+	return nil
+}
+
 func (service *PhoneService) createPhone(ctx context.Context, params PhoneUpsertParams) (*entities.Phone, error) {
 	ctx, span := service.tracer.Start(ctx)
 	defer span.End()
@@ -172,15 +350,23 @@ func (service *PhoneService) createPhone(ctx context.Context, params PhoneUpsert
 		FcmToken: params.FcmToken,
 		// Android has a limit of 30 SMS messages per minute without user permission, to be safe let's use 10 messages per minute
 		// https://android.googlesource.com/platform/frameworks/opt/telephony/+/master/src/java/com/android/internal/telephony/SmsUsageMonitor.java#80
-		MessagesPerMinute:        10,
-		MessageExpirationSeconds: 15 * 60, // 15 minutes
-		MaxSendAttempts:          2,
-		IsDualSIM:                params.IsDualSIM,
-		PhoneNumber:              phonenumbers.Format(&params.PhoneNumber, phonenumbers.E164),
-		CreatedAt:                time.Now().UTC(),
-		UpdatedAt:                time.Now().UTC(),
+		MessagesPerMinute:            10,
+		MessageExpirationSeconds:     15 * 60,      // 15 minutes
+		DeliveryReportTimeoutSeconds: 24 * 60 * 60, // 24 hours
+		MaxSendAttempts:              2,
+		PaceFactor:                   1,
+		Transport:                    entities.PhoneTransportFCM,
+		ReleaseChannel:               entities.PhoneReleaseChannelStable,
+		SIMBalancing:                 entities.PhoneSIMBalancingNone,
+		IsDualSIM:                    params.IsDualSIM,
+		PhoneNumber:                  phonenumbers.Format(&params.PhoneNumber, phonenumbers.E164),
+		CreatedAt:                    time.Now().UTC(),
+		UpdatedAt:                    time.Now().UTC(),
 	}
 
+	service.updateWarmup(phone, params)
+	service.updateSIMBalancing(phone, params)
+
 	if err := service.repository.Save(ctx, phone); err != nil {
 		msg := fmt.Sprintf("cannot create phone with id [%s] and number [%s]", phone.ID, phone.PhoneNumber)
 		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
@@ -197,6 +383,10 @@ func (service *PhoneService) createPhoneDeletedEvent(source string, payload even
 	return service.createEvent(events.EventTypePhoneDeleted, source, payload)
 }
 
+func (service *PhoneService) createCallMissedEvent(source string, payload events.CallMissedPayload) (cloudevents.Event, error) {
+	return service.createEvent(events.EventTypeCallMissed, source, payload)
+}
+
 func (service *PhoneService) update(phone *entities.Phone, params PhoneUpsertParams) *entities.Phone {
 	if phone.FcmToken != nil {
 		phone.FcmToken = params.FcmToken
@@ -209,11 +399,75 @@ func (service *PhoneService) update(phone *entities.Phone, params PhoneUpsertPar
 		phone.MaxSendAttempts = *params.MaxSendAttempts
 	}
 
+	if params.MaxInFlightMessages != nil {
+		phone.MaxInFlightMessages = *params.MaxInFlightMessages
+	}
+
+	if params.Transport != nil {
+		phone.Transport = *params.Transport
+	}
+
+	if params.ReleaseChannel != nil {
+		phone.ReleaseChannel = *params.ReleaseChannel
+	}
+
 	if params.MessageExpirationDuration != nil {
 		phone.MessageExpirationSeconds = uint(params.MessageExpirationDuration.Seconds())
 	}
 
+	if params.DeliveryReportTimeout != nil {
+		phone.DeliveryReportTimeoutSeconds = uint(params.DeliveryReportTimeout.Seconds())
+	}
+
+	if params.DuplicateCollapseDisabled != nil {
+		phone.DuplicateCollapseDisabled = *params.DuplicateCollapseDisabled
+	}
+
+	service.updateWarmup(phone, params)
+	service.updateSIMBalancing(phone, params)
+
 	phone.IsDualSIM = params.IsDualSIM
 
 	return phone
 }
+
+// updateSIMBalancing configures how messages are spread across the SIM cards of the phone and their send limits
+func (service *PhoneService) updateSIMBalancing(phone *entities.Phone, params PhoneUpsertParams) {
+	if params.SIMBalancing != nil {
+		phone.SIMBalancing = *params.SIMBalancing
+	}
+
+	if params.SIM1HourlyLimit != nil {
+		phone.SIM1HourlyLimit = *params.SIM1HourlyLimit
+	}
+
+	if params.SIM1DailyLimit != nil {
+		phone.SIM1DailyLimit = *params.SIM1DailyLimit
+	}
+
+	if params.SIM2HourlyLimit != nil {
+		phone.SIM2HourlyLimit = *params.SIM2HourlyLimit
+	}
+
+	if params.SIM2DailyLimit != nil {
+		phone.SIM2DailyLimit = *params.SIM2DailyLimit
+	}
+}
+
+// updateWarmup starts or stops the warm-up of a phone, the warm-up is not restarted when the schedule changes
+func (service *PhoneService) updateWarmup(phone *entities.Phone, params PhoneUpsertParams) {
+	if params.WarmupEnabled != nil && !*params.WarmupEnabled {
+		phone.WarmupStartedAt = nil
+		phone.WarmupSchedule = nil
+	}
+
+	if params.WarmupEnabled != nil && *params.WarmupEnabled && phone.WarmupStartedAt == nil {
+		startedAt := time.Now().UTC()
+		phone.WarmupStartedAt = &startedAt
+		phone.WarmupSchedule = entities.DefaultWarmupSchedule()
+	}
+
+	if phone.WarmupStartedAt != nil && len(params.WarmupSchedule) > 0 {
+		phone.WarmupSchedule = params.WarmupSchedule
+	}
+}
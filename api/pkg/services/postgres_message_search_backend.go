@@ -0,0 +1,65 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/google/uuid"
+	"github.com/palantir/stacktrace"
+)
+
+type postgresMessageSearchBackend struct {
+	logger     telemetry.Logger
+	tracer     telemetry.Tracer
+	repository repositories.MessageRepository
+}
+
+// NewPostgresMessageSearchBackend creates a MessageSearchBackend which uses the full text search of postgres on the messages table
+func NewPostgresMessageSearchBackend(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	repository repositories.MessageRepository,
+) MessageSearchBackend {
+	return &postgresMessageSearchBackend{
+		logger:     logger.WithService(fmt.Sprintf("%T", &postgresMessageSearchBackend{})),
+		tracer:     tracer,
+		repository: repository,
+	}
+}
+
+// Name of the backend
+func (backend *postgresMessageSearchBackend) Name() string {
+	return "postgres"
+}
+
+// Index does nothing because the messages table is searched directly
+func (backend *postgresMessageSearchBackend) Index(_ context.Context, _ *MessageSearchDocument) error {
+	return nil
+}
+
+// Delete does nothing because the messages table is searched directly
+func (backend *postgresMessageSearchBackend) Delete(_ context.Context, _ []uuid.UUID) error {
+	return nil
+}
+
+// DeleteUser does nothing because the messages table is searched directly
+func (backend *postgresMessageSearchBackend) DeleteUser(_ context.Context, _ entities.UserID) error {
+	return nil
+}
+
+// Search the content of the messages with a tsquery
+func (backend *postgresMessageSearchBackend) Search(ctx context.Context, userID entities.UserID, params repositories.MessageSearchParams) ([]*entities.MessageSearchHit, error) {
+	ctx, span := backend.tracer.Start(ctx)
+	defer span.End()
+
+	hits, err := backend.repository.Search(ctx, userID, params)
+	if err != nil {
+		msg := fmt.Sprintf("cannot search messages of user [%s] in postgres", userID)
+		return nil, backend.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return hits, nil
+}
@@ -0,0 +1,245 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/google/uuid"
+	"github.com/palantir/stacktrace"
+)
+
+const (
+	// ErrCodeCouponNotRedeemable is returned when a coupon is expired, fully redeemed or was already redeemed by the user
+	ErrCodeCouponNotRedeemable = stacktrace.ErrorCode(2003)
+
+	// ErrCodeCouponCodeTaken is returned when creating a coupon with the code of an existing coupon
+	ErrCodeCouponCodeTaken = stacktrace.ErrorCode(2004)
+)
+
+// PromotionService manages coupons, extended trials and plan overrides which change the usage limit of users
+type PromotionService struct {
+	service
+	logger                 telemetry.Logger
+	tracer                 telemetry.Tracer
+	couponRepository       repositories.CouponRepository
+	planOverrideRepository repositories.PlanOverrideRepository
+	userRepository         repositories.UserRepository
+}
+
+// NewPromotionService creates a new PromotionService
+func NewPromotionService(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	couponRepository repositories.CouponRepository,
+	planOverrideRepository repositories.PlanOverrideRepository,
+	userRepository repositories.UserRepository,
+) (s *PromotionService) {
+	return &PromotionService{
+		logger:                 logger.WithService(fmt.Sprintf("%T", s)),
+		tracer:                 tracer,
+		couponRepository:       couponRepository,
+		planOverrideRepository: planOverrideRepository,
+		userRepository:         userRepository,
+	}
+}
+
+// CouponStoreParams are parameters for creating an entities.Coupon
+type CouponStoreParams struct {
+	Code             string
+	Description      string
+	SubscriptionName entities.SubscriptionName
+	MessageLimit     *uint
+	DurationDays     uint
+	MaxRedemptions   uint
+	ExpiresAt        *time.Time
+	CreatedBy        entities.UserID
+}
+
+// StoreCoupon creates a new entities.Coupon
+func (service *PromotionService) StoreCoupon(ctx context.Context, params *CouponStoreParams) (*entities.Coupon, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	_, err := service.couponRepository.LoadByCode(ctx, params.Code)
+	if err == nil {
+		msg := fmt.Sprintf("a coupon with code [%s] already exists", params.Code)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.NewErrorWithCode(ErrCodeCouponCodeTaken, msg))
+	}
+
+	if stacktrace.GetCode(err) != repositories.ErrCodeNotFound {
+		msg := fmt.Sprintf("cannot load coupon with code [%s]", params.Code)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	coupon := &entities.Coupon{
+		ID:               uuid.New(),
+		Code:             strings.ToUpper(params.Code),
+		Description:      params.Description,
+		SubscriptionName: params.SubscriptionName,
+		MessageLimit:     params.MessageLimit,
+		DurationDays:     params.DurationDays,
+		MaxRedemptions:   params.MaxRedemptions,
+		ExpiresAt:        params.ExpiresAt,
+		CreatedBy:        params.CreatedBy,
+		CreatedAt:        time.Now().UTC(),
+		UpdatedAt:        time.Now().UTC(),
+	}
+
+	if err = service.couponRepository.Store(ctx, coupon); err != nil {
+		msg := fmt.Sprintf("cannot store coupon with code [%s]", coupon.Code)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("coupon [%s] with code [%s] created by [%s]", coupon.ID, coupon.Code, params.CreatedBy))
+	return coupon, nil
+}
+
+// IndexCoupons fetches all the entities.Coupon of the instance
+func (service *PromotionService) IndexCoupons(ctx context.Context, params repositories.IndexParams) ([]*entities.Coupon, error) {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	coupons, err := service.couponRepository.Index(ctx, params)
+	if err != nil {
+		msg := fmt.Sprintf("could not fetch coupons with params [%+#v]", params)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return coupons, nil
+}
+
+// DeleteCoupon deletes an entities.Coupon so that it cannot be redeemed anymore
+func (service *PromotionService) DeleteCoupon(ctx context.Context, couponID uuid.UUID) error {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	if err := service.couponRepository.Delete(ctx, couponID); err != nil {
+		msg := fmt.Sprintf("cannot delete coupon with ID [%s]", couponID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("coupon with ID [%s] deleted", couponID))
+	return nil
+}
+
+// RedeemCoupon creates an entities.PlanOverride for a user from the entities.Coupon with the code
+func (service *PromotionService) RedeemCoupon(ctx context.Context, userID entities.UserID, code string) (*entities.PlanOverride, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	coupon, err := service.couponRepository.LoadByCode(ctx, code)
+	if err != nil {
+		msg := fmt.Sprintf("cannot load coupon with code [%s]", code)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
+	}
+
+	timestamp := time.Now().UTC()
+	if !coupon.IsRedeemable(timestamp) {
+		msg := fmt.Sprintf("coupon [%s] with code [%s] cannot be redeemed at [%s]", coupon.ID, coupon.Code, timestamp)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.NewErrorWithCode(ErrCodeCouponNotRedeemable, msg))
+	}
+
+	endsAt := timestamp.AddDate(0, 0, int(coupon.DurationDays))
+	override := &entities.PlanOverride{
+		ID:               uuid.New(),
+		UserID:           userID,
+		Type:             entities.PlanOverrideTypeCoupon,
+		SubscriptionName: coupon.SubscriptionName,
+		MessageLimit:     coupon.MessageLimit,
+		CouponID:         &coupon.ID,
+		StartsAt:         timestamp,
+		EndsAt:           &endsAt,
+		CreatedAt:        timestamp,
+		UpdatedAt:        timestamp,
+	}
+
+	redeemed, err := service.couponRepository.Redeem(ctx, coupon, override, timestamp)
+	if err != nil {
+		msg := fmt.Sprintf("cannot redeem coupon [%s] for user [%s]", coupon.ID, userID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if !redeemed {
+		msg := fmt.Sprintf("coupon [%s] is fully redeemed or was already redeemed by user [%s]", coupon.ID, userID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.NewErrorWithCode(ErrCodeCouponNotRedeemable, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("user [%s] redeemed coupon [%s] for plan [%s] until [%s]", userID, coupon.Code, coupon.SubscriptionName, endsAt))
+	return override, nil
+}
+
+// PlanOverrideStoreParams are parameters for creating an entities.PlanOverride
+type PlanOverrideStoreParams struct {
+	UserID           entities.UserID
+	Type             entities.PlanOverrideType
+	SubscriptionName entities.SubscriptionName
+	MessageLimit     *uint
+	EndsAt           *time.Time
+	CreatedBy        entities.UserID
+}
+
+// StorePlanOverride extends the trial or overrides the plan of a user
+func (service *PromotionService) StorePlanOverride(ctx context.Context, params *PlanOverrideStoreParams) (*entities.PlanOverride, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	if _, err := service.userRepository.Load(ctx, params.UserID); err != nil {
+		msg := fmt.Sprintf("cannot load user with ID [%s]", params.UserID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
+	}
+
+	timestamp := time.Now().UTC()
+	override := &entities.PlanOverride{
+		ID:               uuid.New(),
+		UserID:           params.UserID,
+		Type:             params.Type,
+		SubscriptionName: params.SubscriptionName,
+		MessageLimit:     params.MessageLimit,
+		StartsAt:         timestamp,
+		EndsAt:           params.EndsAt,
+		CreatedBy:        &params.CreatedBy,
+		CreatedAt:        timestamp,
+		UpdatedAt:        timestamp,
+	}
+
+	if err := service.planOverrideRepository.Store(ctx, override); err != nil {
+		msg := fmt.Sprintf("cannot store [%s] plan override for user [%s]", override.Type, override.UserID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("[%s] plan override [%s] for plan [%s] created for user [%s] by [%s]", override.Type, override.ID, override.SubscriptionName, override.UserID, params.CreatedBy))
+	return override, nil
+}
+
+// IndexPlanOverrides fetches the entities.PlanOverride of a user
+func (service *PromotionService) IndexPlanOverrides(ctx context.Context, userID entities.UserID, params repositories.IndexParams) ([]*entities.PlanOverride, error) {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	overrides, err := service.planOverrideRepository.Index(ctx, userID, params)
+	if err != nil {
+		msg := fmt.Sprintf("could not fetch plan overrides for user [%s] with params [%+#v]", userID, params)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return overrides, nil
+}
+
+// DeletePlanOverride deletes an entities.PlanOverride so that the subscription of the user is enforced again
+func (service *PromotionService) DeletePlanOverride(ctx context.Context, userID entities.UserID, overrideID uuid.UUID) error {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	if err := service.planOverrideRepository.Delete(ctx, userID, overrideID); err != nil {
+		msg := fmt.Sprintf("cannot delete plan override [%s] for user [%s]", overrideID, userID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("plan override [%s] deleted for user [%s]", overrideID, userID))
+	return nil
+}
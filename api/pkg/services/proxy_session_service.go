@@ -0,0 +1,317 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/events"
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/google/uuid"
+	"github.com/nyaruka/phonenumbers"
+	"github.com/palantir/stacktrace"
+)
+
+// ProxySessionService relays messages between the parties of an entities.ProxySession
+type ProxySessionService struct {
+	service
+	logger         telemetry.Logger
+	tracer         telemetry.Tracer
+	repository     repositories.ProxySessionRepository
+	messageService *MessageService
+	leaseService   *NumberLeaseService
+	dispatcher     *EventDispatcher
+}
+
+// NewProxySessionService creates a new ProxySessionService
+func NewProxySessionService(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	repository repositories.ProxySessionRepository,
+	messageService *MessageService,
+	leaseService *NumberLeaseService,
+	dispatcher *EventDispatcher,
+) (s *ProxySessionService) {
+	return &ProxySessionService{
+		logger:         logger.WithService(fmt.Sprintf("%T", s)),
+		tracer:         tracer,
+		repository:     repository,
+		messageService: messageService,
+		leaseService:   leaseService,
+		dispatcher:     dispatcher,
+	}
+}
+
+// Index fetches the entities.ProxySession of a user
+func (service *ProxySessionService) Index(ctx context.Context, userID entities.UserID, params repositories.IndexParams) ([]*entities.ProxySession, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	sessions, err := service.repository.Index(ctx, userID, params)
+	if err != nil {
+		msg := fmt.Sprintf("could not fetch proxy sessions with params [%+#v]", params)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("fetched [%d] proxy sessions with prams [%+#v]", len(sessions), params))
+	return sessions, nil
+}
+
+// LoadActive loads the active entities.ProxySession on an owner which has the contact as a party
+func (service *ProxySessionService) LoadActive(ctx context.Context, userID entities.UserID, owner string, contact string) (*entities.ProxySession, error) {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	return service.repository.LoadActive(ctx, userID, owner, contact)
+}
+
+// Transcript fetches the messages relayed through an entities.ProxySession
+func (service *ProxySessionService) Transcript(ctx context.Context, userID entities.UserID, sessionID uuid.UUID, params repositories.IndexParams) ([]*entities.ProxySessionMessage, error) {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	if _, err := service.repository.Load(ctx, userID, sessionID); err != nil {
+		msg := fmt.Sprintf("cannot load proxy session with ID [%s] for user [%s]", sessionID, userID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
+	}
+
+	messages, err := service.repository.IndexMessages(ctx, userID, sessionID, params)
+	if err != nil {
+		msg := fmt.Sprintf("cannot fetch transcript of proxy session with ID [%s]", sessionID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return messages, nil
+}
+
+// ProxySessionStoreParams are parameters for creating a new entities.ProxySession.
+// The owner number is leased from the pool of phones when Owner is empty.
+type ProxySessionStoreParams struct {
+	UserID      entities.UserID
+	Owner       string
+	FirstParty  string
+	SecondParty string
+	TTL         time.Duration
+	Source      string
+}
+
+// Store a new entities.ProxySession
+func (service *ProxySessionService) Store(ctx context.Context, params *ProxySessionStoreParams) (*entities.ProxySession, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	session := &entities.ProxySession{
+		ID:          uuid.New(),
+		UserID:      params.UserID,
+		Owner:       params.Owner,
+		FirstParty:  params.FirstParty,
+		SecondParty: params.SecondParty,
+		Status:      entities.ProxySessionStatusActive,
+		ExpiresAt:   time.Now().UTC().Add(params.TTL),
+		CreatedAt:   time.Now().UTC(),
+		UpdatedAt:   time.Now().UTC(),
+	}
+
+	if session.Owner == "" {
+		lease, err := service.leaseService.Allocate(ctx, &NumberLeaseAllocateParams{
+			UserID:            session.UserID,
+			Reference:         service.leaseReference(session),
+			InactivityTimeout: params.TTL,
+			Source:            params.Source,
+		})
+		if err != nil {
+			msg := fmt.Sprintf("cannot lease owner number for proxy session with id [%s]", session.ID)
+			return nil, service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
+		}
+		session.Owner = lease.Owner
+	}
+
+	if err := service.repository.Store(ctx, session); err != nil {
+		msg := fmt.Sprintf("cannot save proxy session with id [%s]", session.ID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("proxy session saved with id [%s] in the [%T]", session.ID, service.repository))
+
+	if err := service.scheduleExpiredCheck(ctx, session, params.Source); err != nil {
+		msg := fmt.Sprintf("cannot schedule expiry check for proxy session with id [%s]", session.ID)
+		ctxLogger.Error(service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg)))
+	}
+
+	return session, nil
+}
+
+// Close an active entities.ProxySession
+func (service *ProxySessionService) Close(ctx context.Context, userID entities.UserID, sessionID uuid.UUID) (*entities.ProxySession, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	session, err := service.repository.Load(ctx, userID, sessionID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot load proxy session with ID [%s] for user [%s]", sessionID, userID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
+	}
+
+	if session.Status != entities.ProxySessionStatusActive {
+		ctxLogger.Info(fmt.Sprintf("proxy session [%s] already has status [%s]", session.ID, session.Status))
+		return session, nil
+	}
+
+	if err = service.repository.Update(ctx, session.Close(time.Now().UTC())); err != nil {
+		msg := fmt.Sprintf("cannot close proxy session with ID [%s]", session.ID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("proxy session [%s] has been closed", session.ID))
+
+	if err = service.leaseService.ReleaseByReference(ctx, session.UserID, service.leaseReference(session)); err != nil {
+		msg := fmt.Sprintf("cannot release number lease of proxy session with ID [%s]", session.ID)
+		ctxLogger.Error(service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg)))
+	}
+
+	return session, nil
+}
+
+// ProxySessionCheckExpiredParams are parameters for checking if an entities.ProxySession is expired
+type ProxySessionCheckExpiredParams struct {
+	SessionID uuid.UUID
+	UserID    entities.UserID
+	Source    string
+}
+
+// CheckExpired expires a proxy session when it has reached the expiry time
+func (service *ProxySessionService) CheckExpired(ctx context.Context, params ProxySessionCheckExpiredParams) error {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	session, err := service.repository.Load(ctx, params.UserID, params.SessionID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot load proxy session with ID [%s] for user [%s]", params.SessionID, params.UserID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if session.Status != entities.ProxySessionStatusActive {
+		ctxLogger.Info(fmt.Sprintf("proxy session [%s] has status [%s] and cannot expire", session.ID, session.Status))
+		return nil
+	}
+
+	if session.IsActive(time.Now().UTC()) {
+		return service.scheduleExpiredCheck(ctx, session, params.Source)
+	}
+
+	if err = service.repository.Update(ctx, session.Expire(time.Now().UTC())); err != nil {
+		msg := fmt.Sprintf("cannot expire proxy session with ID [%s]", session.ID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("proxy session [%s] has expired", session.ID))
+
+	if err = service.leaseService.ReleaseByReference(ctx, session.UserID, service.leaseReference(session)); err != nil {
+		msg := fmt.Sprintf("cannot release number lease of proxy session with ID [%s]", session.ID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+// Relay forwards a message received on an owner number to the other party of the active entities.ProxySession
+func (service *ProxySessionService) Relay(ctx context.Context, source string, payload *events.MessagePhoneReceivedPayload) error {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	session, err := service.repository.LoadActive(ctx, payload.UserID, payload.Owner, payload.Contact)
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		ctxLogger.Info(fmt.Sprintf("no active proxy session for owner [%s] and contact [%s]", payload.Owner, payload.Contact))
+		return nil
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot load active proxy session for owner [%s] and contact [%s]", payload.Owner, payload.Contact)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if !session.IsActive(payload.Timestamp) {
+		ctxLogger.Info(fmt.Sprintf("proxy session [%s] expired at [%s] message [%s] will not be relayed", session.ID, session.ExpiresAt, payload.MessageID))
+		return nil
+	}
+
+	owner, err := phonenumbers.Parse(session.Owner, phonenumbers.UNKNOWN_REGION)
+	if err != nil {
+		msg := fmt.Sprintf("cannot parse owner [%s] of proxy session [%s]", session.Owner, session.ID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	message, err := service.messageService.SendMessage(ctx, MessageSendParams{
+		Owner:             *owner,
+		Contact:           session.OtherParty(payload.Contact),
+		Content:           payload.Content,
+		Source:            source,
+		SIM:               payload.SIM,
+		UserID:            payload.UserID,
+		RequestReceivedAt: time.Now().UTC(),
+	})
+	if err != nil {
+		msg := fmt.Sprintf("cannot relay message [%s] through proxy session [%s]", payload.MessageID, session.ID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	err = service.repository.StoreMessage(ctx, &entities.ProxySessionMessage{
+		ID:                uuid.New(),
+		SessionID:         session.ID,
+		UserID:            session.UserID,
+		From:              payload.Contact,
+		To:                message.Contact,
+		Content:           payload.Content,
+		InboundMessageID:  payload.MessageID,
+		OutboundMessageID: message.ID,
+		Timestamp:         payload.Timestamp,
+		CreatedAt:         time.Now().UTC(),
+	})
+	if err != nil {
+		msg := fmt.Sprintf("cannot store transcript of message [%s] for proxy session [%s]", payload.MessageID, session.ID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	session.LastMessageAt = &payload.Timestamp
+	if err = service.repository.Update(ctx, session); err != nil {
+		msg := fmt.Sprintf("cannot update proxy session [%s] after relaying message [%s]", session.ID, payload.MessageID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("relayed message [%s] as [%s] through proxy session [%s]", payload.MessageID, message.ID, session.ID))
+	return nil
+}
+
+// leaseReference is the entities.NumberLease reference of a proxy session with a leased owner number
+func (service *ProxySessionService) leaseReference(session *entities.ProxySession) string {
+	return "proxy-session:" + session.ID.String()
+}
+
+func (service *ProxySessionService) scheduleExpiredCheck(ctx context.Context, session *entities.ProxySession, source string) error {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	event, err := service.createProxySessionExpiredCheckEvent(source, &events.ProxySessionExpiredCheckPayload{
+		SessionID:   session.ID,
+		ScheduledAt: session.ExpiresAt,
+		UserID:      session.UserID,
+	})
+	if err != nil {
+		msg := fmt.Sprintf("cannot create event [%s] for proxy session with id [%s]", events.EventTypeProxySessionExpiredCheck, session.ID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if _, err = service.dispatcher.DispatchWithTimeout(ctx, event, time.Until(session.ExpiresAt)); err != nil {
+		msg := fmt.Sprintf("cannot dispatch event [%s] for proxy session with ID [%s]", event.Type(), session.ID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+func (service *ProxySessionService) createProxySessionExpiredCheckEvent(source string, payload *events.ProxySessionExpiredCheckPayload) (cloudevents.Event, error) {
+	return service.createEvent(events.EventTypeProxySessionExpiredCheck, source, payload)
+}
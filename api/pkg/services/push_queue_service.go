@@ -13,6 +13,9 @@ type PushQueueTask struct {
 	URL     string
 	Body    []byte
 	Headers map[string]string
+
+	// RoutingKey is used by message brokers to route the task, it is the event type for events
+	RoutingKey string
 }
 
 // PushQueueConfig configurations for the push queue
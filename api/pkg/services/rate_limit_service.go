@@ -0,0 +1,116 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/palantir/stacktrace"
+	"github.com/redis/go-redis/v9"
+)
+
+// rateLimitTakeScript refills the token buckets in KEYS for the time since they were last used and takes a token from
+// each bucket only when every bucket has a token. ARGV has the current time in milliseconds followed by the limit per
+// minute of each key. It returns 0 when the tokens were taken or the milliseconds to wait until a token is available.
+var rateLimitTakeScript = redis.NewScript(`
+local now = tonumber(ARGV[1])
+local tokens = {}
+local wait = 0
+for i, key in ipairs(KEYS) do
+	local limit = tonumber(ARGV[i + 1])
+	local bucket = redis.call('HMGET', key, 'tokens', 'timestamp')
+	local available = tonumber(bucket[1]) or limit
+	local timestamp = tonumber(bucket[2]) or now
+	available = math.min(limit, available + (now - timestamp) * limit / 60000)
+	tokens[i] = available
+	if available < 1 then
+		wait = math.max(wait, math.ceil((1 - available) * 60000 / limit))
+	end
+end
+
+if wait == 0 then
+	for i, key in ipairs(KEYS) do
+		tokens[i] = tokens[i] - 1
+	end
+end
+
+for i, key in ipairs(KEYS) do
+	redis.call('HSET', key, 'tokens', tostring(tokens[i]), 'timestamp', now)
+	redis.call('PEXPIRE', key, 60000)
+end
+return wait
+`)
+
+// RateLimitService limits the number of messages which a user can send with a token bucket stored in redis
+type RateLimitService struct {
+	service
+	logger         telemetry.Logger
+	tracer         telemetry.Tracer
+	redis          *redis.Client
+	userRepository repositories.UserRepository
+}
+
+// NewRateLimitService creates a new RateLimitService
+func NewRateLimitService(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	redisClient *redis.Client,
+	userRepository repositories.UserRepository,
+) (s *RateLimitService) {
+	return &RateLimitService{
+		logger:         logger.WithService(fmt.Sprintf("%T", s)),
+		tracer:         tracer,
+		redis:          redisClient,
+		userRepository: userRepository,
+	}
+}
+
+// RateLimitResult is the outcome of taking a token from the rate limit of a user
+type RateLimitResult struct {
+	Allowed    bool
+	RetryAfter time.Duration
+}
+
+// TakeSend takes a token from the send rate limit of a user and from the rate limit of the contact.
+// The message is allowed when the rate limiter is unavailable so that redis is not a single point of failure for sending.
+func (service *RateLimitService) TakeSend(ctx context.Context, userID entities.UserID, contact string) (*RateLimitResult, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	user, err := service.userRepository.Load(ctx, userID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot load user with ID [%s]", userID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	keys := make([]string, 0, 2)
+	args := []interface{}{time.Now().UTC().UnixMilli()}
+	if user.SendRateLimit > 0 {
+		keys = append(keys, fmt.Sprintf("rate-limit:send:%s", userID))
+		args = append(args, user.SendRateLimit)
+	}
+	if user.ContactSendRateLimit > 0 {
+		keys = append(keys, fmt.Sprintf("rate-limit:send:%s:%s", userID, contact))
+		args = append(args, user.ContactSendRateLimit)
+	}
+
+	if len(keys) == 0 {
+		return &RateLimitResult{Allowed: true}, nil
+	}
+
+	wait, err := rateLimitTakeScript.Run(ctx, service.redis, keys, args...).Int64()
+	if err != nil {
+		ctxLogger.Error(stacktrace.Propagate(err, fmt.Sprintf("cannot take send rate limit token for user [%s] and contact [%s]", userID, contact)))
+		return &RateLimitResult{Allowed: true}, nil
+	}
+
+	if wait > 0 {
+		ctxLogger.Info(fmt.Sprintf("user [%s] exceeded the send rate limit to contact [%s], retry after [%dms]", userID, contact, wait))
+		return &RateLimitResult{Allowed: false, RetryAfter: time.Duration(wait) * time.Millisecond}, nil
+	}
+
+	return &RateLimitResult{Allowed: true}, nil
+}
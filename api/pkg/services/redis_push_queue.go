@@ -0,0 +1,233 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/carlmjohnson/requests"
+	"github.com/google/uuid"
+	"github.com/palantir/stacktrace"
+	"github.com/redis/go-redis/v9"
+
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+)
+
+const (
+	redisPushQueuePollInterval = time.Second
+	redisPushQueueBatchSize    = 50
+	redisPushQueueMaxAttempts  = 10
+
+	// redisPushQueueLease is how long a claimed task is hidden from other consumers before it is delivered again
+	redisPushQueueLease = 5 * time.Minute
+)
+
+// redisPushQueueClaimScript atomically moves the due tasks in the schedule to the lease time so only one consumer pushes them
+var redisPushQueueClaimScript = redis.NewScript(`
+local ids = redis.call('ZRANGEBYSCORE', KEYS[1], '-inf', ARGV[1], 'LIMIT', 0, ARGV[3])
+for _, id in ipairs(ids) do
+	redis.call('ZADD', KEYS[1], ARGV[2], id)
+end
+return ids
+`)
+
+// redisPushQueueTask is a PushQueueTask stored in redis
+type redisPushQueueTask struct {
+	Task     PushQueueTask `json:"task"`
+	Attempts int           `json:"attempts"`
+}
+
+// RedisPushQueue is a PushQueue which stores delayed tasks in redis so it can run without Google Cloud Tasks
+type RedisPushQueue struct {
+	config PushQueueConfig
+	client *http.Client
+	redis  *redis.Client
+	logger telemetry.Logger
+	tracer telemetry.Tracer
+}
+
+// NewRedisPushQueue creates a new RedisPushQueue
+func NewRedisPushQueue(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	client *http.Client,
+	redisClient *redis.Client,
+	config PushQueueConfig,
+) *RedisPushQueue {
+	return &RedisPushQueue{
+		tracer: tracer,
+		logger: logger.WithService(fmt.Sprintf("%T", &RedisPushQueue{})),
+		client: client,
+		redis:  redisClient,
+		config: config,
+	}
+}
+
+// Enqueue a task to the queue
+func (queue *RedisPushQueue) Enqueue(ctx context.Context, task *PushQueueTask, timeout time.Duration) (queueID string, err error) {
+	ctx, span, ctxLogger := queue.tracer.StartWithLogger(ctx, queue.logger)
+	defer span.End()
+
+	queueID = uuid.New().String()
+
+	payload, err := json.Marshal(&redisPushQueueTask{Task: *task})
+	if err != nil {
+		msg := fmt.Sprintf("cannot marshal queue task to URL [%s]", task.URL)
+		return queueID, queue.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	scheduledAt := time.Now().UTC().Add(timeout)
+	_, err = queue.redis.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, queue.tasksKey(), queueID, payload)
+		pipe.ZAdd(ctx, queue.scheduleKey(), redis.Z{Score: float64(scheduledAt.UnixMilli()), Member: queueID})
+		return nil
+	})
+	if err != nil {
+		msg := fmt.Sprintf("cannot store queue task [%s] in redis", queueID)
+		return queueID, queue.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf(
+		"task added to [%s] queue with ID [%s] and scheduled at [%s]",
+		queue.config.Name,
+		queueID,
+		scheduledAt,
+	))
+
+	return queueID, nil
+}
+
+// Consume pushes the due tasks to their URL until the context is cancelled
+func (queue *RedisPushQueue) Consume(ctx context.Context) {
+	ticker := time.NewTicker(redisPushQueuePollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := queue.consumeDue(ctx); err != nil {
+				queue.logger.Error(stacktrace.Propagate(err, fmt.Sprintf("cannot consume due tasks in [%s] queue", queue.config.Name)))
+			}
+		}
+	}
+}
+
+func (queue *RedisPushQueue) consumeDue(ctx context.Context) error {
+	now := time.Now().UTC()
+	ids, err := redisPushQueueClaimScript.Run(
+		ctx,
+		queue.redis,
+		[]string{queue.scheduleKey()},
+		now.UnixMilli(),
+		now.Add(redisPushQueueLease).UnixMilli(),
+		redisPushQueueBatchSize,
+	).StringSlice()
+	if err != nil {
+		return stacktrace.Propagate(err, "cannot claim due tasks")
+	}
+
+	for _, queueID := range ids {
+		queue.consume(ctx, queueID)
+	}
+
+	return nil
+}
+
+func (queue *RedisPushQueue) consume(ctx context.Context, queueID string) {
+	payload, err := queue.redis.HGet(ctx, queue.tasksKey(), queueID).Bytes()
+	if errors.Is(err, redis.Nil) {
+		queue.redis.ZRem(ctx, queue.scheduleKey(), queueID)
+		return
+	}
+	if err != nil {
+		queue.logger.Error(stacktrace.Propagate(err, fmt.Sprintf("cannot load queue task [%s]", queueID)))
+		return
+	}
+
+	task := new(redisPushQueueTask)
+	if err = json.Unmarshal(payload, task); err != nil {
+		queue.logger.Error(stacktrace.Propagate(err, fmt.Sprintf("cannot unmarshal queue task [%s], it will be dropped", queueID)))
+		queue.remove(ctx, queueID)
+		return
+	}
+
+	if err = queue.push(ctx, task.Task); err == nil {
+		queue.logger.Info(fmt.Sprintf("queue task [%s] sent to URL [%s]", queueID, task.Task.URL))
+		queue.remove(ctx, queueID)
+		return
+	}
+
+	task.Attempts++
+	if task.Attempts >= redisPushQueueMaxAttempts {
+		queue.logger.Error(stacktrace.Propagate(err, fmt.Sprintf("dropping queue task [%s] to URL [%s] after [%d] attempts", queueID, task.Task.URL, task.Attempts)))
+		queue.remove(ctx, queueID)
+		return
+	}
+
+	queue.logger.Warn(stacktrace.Propagate(err, fmt.Sprintf("cannot send queue task [%s] to URL [%s] on attempt [%d]", queueID, task.Task.URL, task.Attempts)))
+	queue.retry(ctx, queueID, task)
+}
+
+// retry schedules the task again with an exponential backoff
+func (queue *RedisPushQueue) retry(ctx context.Context, queueID string, task *redisPushQueueTask) {
+	payload, err := json.Marshal(task)
+	if err != nil {
+		queue.logger.Error(stacktrace.Propagate(err, fmt.Sprintf("cannot marshal queue task [%s]", queueID)))
+		return
+	}
+
+	scheduledAt := time.Now().UTC().Add(time.Duration(1<<task.Attempts) * time.Second)
+	_, err = queue.redis.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, queue.tasksKey(), queueID, payload)
+		pipe.ZAdd(ctx, queue.scheduleKey(), redis.Z{Score: float64(scheduledAt.UnixMilli()), Member: queueID})
+		return nil
+	})
+	if err != nil {
+		queue.logger.Error(stacktrace.Propagate(err, fmt.Sprintf("cannot reschedule queue task [%s] at [%s]", queueID, scheduledAt)))
+	}
+}
+
+func (queue *RedisPushQueue) remove(ctx context.Context, queueID string) {
+	_, err := queue.redis.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.ZRem(ctx, queue.scheduleKey(), queueID)
+		pipe.HDel(ctx, queue.tasksKey(), queueID)
+		return nil
+	})
+	if err != nil {
+		queue.logger.Error(stacktrace.Propagate(err, fmt.Sprintf("cannot remove queue task [%s]", queueID)))
+	}
+}
+
+func (queue *RedisPushQueue) push(ctx context.Context, task PushQueueTask) error {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	request := requests.
+		URL(task.URL).
+		Client(queue.client).
+		Method(task.Method).
+		BodyBytes(task.Body)
+
+	// add headers
+	for key, value := range task.Headers {
+		request.Header(key, value)
+	}
+
+	// add content type
+	request.Header("Content-Type", "application/json")
+
+	return request.Fetch(ctx)
+}
+
+func (queue *RedisPushQueue) scheduleKey() string {
+	return "push-queue:" + queue.config.Name + ":schedule"
+}
+
+func (queue *RedisPushQueue) tasksKey() string {
+	return "push-queue:" + queue.config.Name + ":tasks"
+}
@@ -0,0 +1,303 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/events"
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/google/uuid"
+	"github.com/palantir/stacktrace"
+)
+
+// ErrCodeReferralNotAttributable is returned when a user cannot be attributed to the owner of a referral code
+const ErrCodeReferralNotAttributable = stacktrace.ErrorCode(2006)
+
+const (
+	// referralAttributionWindow is how long after signing up a new user can enter a referral code
+	referralAttributionWindow = 7 * 24 * time.Hour
+
+	// referralCodeAlphabet excludes characters which are easily confused e.g. 0 and O
+	referralCodeAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+	referralCodeLength   = 8
+)
+
+// ReferralReward configures the reward of a referrer.
+// Bonus credits move the referrer to prepaid credits so they suit instances which bill with credits.
+type ReferralReward struct {
+	Type    entities.ReferralRewardType
+	Credits int64
+
+	// SubscriptionName is the plan of the referrer for Days when the reward is a discount
+	SubscriptionName entities.SubscriptionName
+	Days             uint
+
+	// OnSignup rewards the referrer when the user is attributed instead of when the user starts a subscription
+	OnSignup bool
+}
+
+// ReferralService attributes new users to the users who referred them and rewards the referrers
+type ReferralService struct {
+	service
+	logger           telemetry.Logger
+	tracer           telemetry.Tracer
+	repository       repositories.ReferralRepository
+	userRepository   repositories.UserRepository
+	creditRepository repositories.CreditRepository
+	reward           ReferralReward
+}
+
+// NewReferralService creates a new ReferralService
+func NewReferralService(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	repository repositories.ReferralRepository,
+	userRepository repositories.UserRepository,
+	creditRepository repositories.CreditRepository,
+	reward ReferralReward,
+) (s *ReferralService) {
+	return &ReferralService{
+		logger:           logger.WithService(fmt.Sprintf("%T", s)),
+		tracer:           tracer,
+		repository:       repository,
+		userRepository:   userRepository,
+		creditRepository: creditRepository,
+		reward:           reward,
+	}
+}
+
+// Code loads the entities.ReferralCode of a user and creates it the first time
+func (service *ReferralService) Code(ctx context.Context, userID entities.UserID) (*entities.ReferralCode, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	code, err := service.repository.LoadCode(ctx, userID)
+	if err == nil {
+		return code, nil
+	}
+
+	if stacktrace.GetCode(err) != repositories.ErrCodeNotFound {
+		msg := fmt.Sprintf("cannot load referral code of user [%s]", userID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	// a new code is generated when it collides with the code of another user
+	for attempt := 1; attempt <= 3; attempt++ {
+		code = &entities.ReferralCode{
+			UserID:    userID,
+			Code:      service.generateCode(),
+			CreatedAt: time.Now().UTC(),
+		}
+
+		if err = service.repository.StoreCode(ctx, code); err == nil {
+			ctxLogger.Info(fmt.Sprintf("created referral code [%s] for user [%s]", code.Code, userID))
+			return code, nil
+		}
+
+		// the code was created by a concurrent request of the same user
+		if existing, loadErr := service.repository.LoadCode(ctx, userID); loadErr == nil {
+			return existing, nil
+		}
+	}
+
+	msg := fmt.Sprintf("cannot create referral code for user [%s]", userID)
+	return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+}
+
+// Attribute a new user to the owner of a referral code
+func (service *ReferralService) Attribute(ctx context.Context, userID entities.UserID, code string) (*entities.Referral, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	referralCode, err := service.repository.LoadCodeByCode(ctx, code)
+	if err != nil {
+		msg := fmt.Sprintf("cannot load referral code [%s]", code)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
+	}
+
+	if referralCode.UserID == userID {
+		msg := fmt.Sprintf("user [%s] cannot use their own referral code [%s]", userID, code)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.NewErrorWithCode(ErrCodeReferralNotAttributable, msg))
+	}
+
+	user, err := service.userRepository.Load(ctx, userID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot load user with ID [%s]", userID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if time.Since(user.CreatedAt) > referralAttributionWindow {
+		msg := fmt.Sprintf("user [%s] signed up at [%s] which is outside the attribution window", userID, user.CreatedAt)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.NewErrorWithCode(ErrCodeReferralNotAttributable, msg))
+	}
+
+	referral := &entities.Referral{
+		ID:             uuid.New(),
+		UserID:         referralCode.UserID,
+		ReferredUserID: userID,
+		Code:           referralCode.Code,
+		Status:         entities.ReferralStatusPending,
+		RewardType:     service.reward.Type,
+		CreatedAt:      time.Now().UTC(),
+		UpdatedAt:      time.Now().UTC(),
+	}
+
+	if service.reward.Type == entities.ReferralRewardTypeDiscount {
+		referral.RewardDays = service.reward.Days
+	} else {
+		referral.RewardCredits = service.reward.Credits
+	}
+
+	stored, err := service.repository.Store(ctx, referral)
+	if err != nil {
+		msg := fmt.Sprintf("cannot store referral of user [%s] by [%s]", userID, referralCode.UserID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if !stored {
+		msg := fmt.Sprintf("user [%s] was already referred", userID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.NewErrorWithCode(ErrCodeReferralNotAttributable, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("user [%s] attributed to referrer [%s] with referral [%s]", userID, referral.UserID, referral.ID))
+
+	if !service.reward.OnSignup {
+		return referral, nil
+	}
+
+	if err = service.applyReward(ctx, referral); err != nil {
+		msg := fmt.Sprintf("cannot reward referral [%s] on signup", referral.ID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return referral, nil
+}
+
+// Index fetches the entities.Referral made by a user
+func (service *ReferralService) Index(ctx context.Context, userID entities.UserID, params repositories.IndexParams) ([]*entities.Referral, error) {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	referrals, err := service.repository.Index(ctx, userID, params)
+	if err != nil {
+		msg := fmt.Sprintf("could not fetch referrals with params [%+#v]", params)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return referrals, nil
+}
+
+// Stats returns the referral code of a user with the number of referrals and the rewards earned
+func (service *ReferralService) Stats(ctx context.Context, userID entities.UserID) (*entities.ReferralStats, error) {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	code, err := service.Code(ctx, userID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot load referral code of user [%s]", userID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	stats, err := service.repository.Stats(ctx, userID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot load referral stats of user [%s]", userID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	stats.Code = code.Code
+	return stats, nil
+}
+
+// RewardSubscription rewards the referrer of a user who started a subscription
+func (service *ReferralService) RewardSubscription(ctx context.Context, payload *events.UserSubscriptionCreatedPayload) error {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	referral, err := service.repository.LoadByReferredUserID(ctx, payload.UserID)
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		ctxLogger.Info(fmt.Sprintf("user [%s] was not referred by another user", payload.UserID))
+		return nil
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot load referral of user [%s]", payload.UserID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if referral.Status != entities.ReferralStatusPending {
+		ctxLogger.Info(fmt.Sprintf("referral [%s] of user [%s] was already rewarded", referral.ID, payload.UserID))
+		return nil
+	}
+
+	if err = service.applyReward(ctx, referral); err != nil {
+		msg := fmt.Sprintf("cannot reward referral [%s] for subscription [%s]", referral.ID, payload.SubscriptionID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+// applyReward gives the reward of a referral to the referrer once.
+// Bonus credits use the ID of the referral so that they are added once when the referral is rewarded again after a failure.
+func (service *ReferralService) applyReward(ctx context.Context, referral *entities.Referral) error {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	var override *entities.PlanOverride
+	if referral.RewardType == entities.ReferralRewardTypeDiscount {
+		endsAt := time.Now().UTC().AddDate(0, 0, int(referral.RewardDays))
+		override = &entities.PlanOverride{
+			ID:               uuid.New(),
+			UserID:           referral.UserID,
+			Type:             entities.PlanOverrideTypeReferral,
+			SubscriptionName: service.reward.SubscriptionName,
+			StartsAt:         time.Now().UTC(),
+			EndsAt:           &endsAt,
+			CreatedAt:        time.Now().UTC(),
+			UpdatedAt:        time.Now().UTC(),
+		}
+	}
+
+	if referral.RewardType == entities.ReferralRewardTypeCredits && referral.RewardCredits > 0 {
+		_, err := service.creditRepository.Apply(ctx, &entities.CreditTransaction{
+			ID:          referral.ID,
+			UserID:      referral.UserID,
+			Type:        entities.CreditTransactionTypeReferralBonus,
+			Amount:      referral.RewardCredits,
+			Description: fmt.Sprintf("referral of user %s", referral.ReferredUserID),
+			CreatedAt:   time.Now().UTC(),
+		})
+		if err != nil {
+			msg := fmt.Sprintf("cannot add [%d] bonus credits of referral [%s] to user [%s]", referral.RewardCredits, referral.ID, referral.UserID)
+			return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+		}
+	}
+
+	rewarded, err := service.repository.Reward(ctx, referral, override)
+	if err != nil {
+		msg := fmt.Sprintf("cannot mark referral [%s] as rewarded", referral.ID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if rewarded {
+		ctxLogger.Info(fmt.Sprintf("referrer [%s] rewarded with [%s] for referral [%s]", referral.UserID, referral.RewardType, referral.ID))
+	}
+	return nil
+}
+
+func (service *ReferralService) generateCode() string {
+	code := make([]byte, referralCodeLength)
+	for index := range code {
+		n, err := rand.Int(rand.Reader, big.NewInt(int64(len(referralCodeAlphabet))))
+		if err != nil {
+			n = big.NewInt(time.Now().UnixNano() % int64(len(referralCodeAlphabet)))
+		}
+		code[index] = referralCodeAlphabet[n.Int64()]
+	}
+	return string(code)
+}
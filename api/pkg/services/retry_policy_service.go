@@ -0,0 +1,133 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/google/uuid"
+	"github.com/palantir/stacktrace"
+)
+
+// RetryPolicyService manages the entities.RetryPolicy of users
+type RetryPolicyService struct {
+	service
+	logger     telemetry.Logger
+	tracer     telemetry.Tracer
+	repository repositories.RetryPolicyRepository
+}
+
+// NewRetryPolicyService creates a new RetryPolicyService
+func NewRetryPolicyService(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	repository repositories.RetryPolicyRepository,
+) (s *RetryPolicyService) {
+	return &RetryPolicyService{
+		logger:     logger.WithService(fmt.Sprintf("%T", s)),
+		tracer:     tracer,
+		repository: repository,
+	}
+}
+
+// Index returns the effective entities.RetryPolicy of a user for every entities.MessageFailureCode
+func (service *RetryPolicyService) Index(ctx context.Context, userID entities.UserID) ([]*entities.RetryPolicy, error) {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	policies, err := service.repository.Index(ctx, userID)
+	if err != nil {
+		msg := fmt.Sprintf("could not fetch retry policies for user [%s]", userID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	configured := make(map[entities.MessageFailureCode]*entities.RetryPolicy, len(policies))
+	for _, policy := range policies {
+		configured[policy.FailureCode] = policy
+	}
+
+	result := make([]*entities.RetryPolicy, 0, len(entities.MessageFailureCodes))
+	for _, code := range entities.MessageFailureCodes {
+		if policy, ok := configured[code]; ok {
+			result = append(result, policy)
+			continue
+		}
+		result = append(result, entities.DefaultRetryPolicy(userID, code))
+	}
+
+	return result, nil
+}
+
+// Load returns the entities.RetryPolicy of a user for an entities.MessageFailureCode
+func (service *RetryPolicyService) Load(ctx context.Context, userID entities.UserID, code entities.MessageFailureCode) (*entities.RetryPolicy, error) {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	policy, err := service.repository.Load(ctx, userID, code)
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		return entities.DefaultRetryPolicy(userID, code), nil
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("could not load retry policy with failure code [%s] for user [%s]", code, userID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return policy, nil
+}
+
+// RetryPolicyUpsertParams are parameters for configuring an entities.RetryPolicy
+type RetryPolicyUpsertParams struct {
+	UserID      entities.UserID
+	FailureCode entities.MessageFailureCode
+	MaxRetries  uint
+	Delay       time.Duration
+}
+
+// Upsert configures the entities.RetryPolicy of a user for an entities.MessageFailureCode
+func (service *RetryPolicyService) Upsert(ctx context.Context, params *RetryPolicyUpsertParams) (*entities.RetryPolicy, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	policy, err := service.repository.Load(ctx, params.UserID, params.FailureCode)
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		policy = &entities.RetryPolicy{
+			ID:          uuid.New(),
+			UserID:      params.UserID,
+			FailureCode: params.FailureCode,
+			CreatedAt:   time.Now().UTC(),
+		}
+	} else if err != nil {
+		msg := fmt.Sprintf("could not load retry policy with failure code [%s] for user [%s]", params.FailureCode, params.UserID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	policy.MaxRetries = params.MaxRetries
+	policy.DelaySeconds = uint(params.Delay.Seconds())
+	policy.UpdatedAt = time.Now().UTC()
+
+	if err = service.repository.Save(ctx, policy); err != nil {
+		msg := fmt.Sprintf("cannot save retry policy with id [%s]", policy.ID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("retry policy saved with id [%s] for failure code [%s]", policy.ID, policy.FailureCode))
+	return policy, nil
+}
+
+// Delete restores the default entities.RetryPolicy of a user for an entities.MessageFailureCode
+func (service *RetryPolicyService) Delete(ctx context.Context, userID entities.UserID, code entities.MessageFailureCode) (*entities.RetryPolicy, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	if err := service.repository.Delete(ctx, userID, code); err != nil {
+		msg := fmt.Sprintf("cannot delete retry policy with failure code [%s] for user [%s]", code, userID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("retry policy for failure code [%s] of user [%s] restored to default", code, userID))
+	return entities.DefaultRetryPolicy(userID, code), nil
+}
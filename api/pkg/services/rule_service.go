@@ -0,0 +1,303 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/events"
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/google/uuid"
+	"github.com/nyaruka/phonenumbers"
+	"github.com/palantir/stacktrace"
+)
+
+// RuleService manages and executes entities.Rule
+type RuleService struct {
+	service
+	logger         telemetry.Logger
+	tracer         telemetry.Tracer
+	repository     repositories.RuleRepository
+	messageService *MessageService
+}
+
+// NewRuleService creates a new RuleService
+func NewRuleService(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	repository repositories.RuleRepository,
+	messageService *MessageService,
+) (s *RuleService) {
+	return &RuleService{
+		logger:         logger.WithService(fmt.Sprintf("%T", s)),
+		tracer:         tracer,
+		repository:     repository,
+		messageService: messageService,
+	}
+}
+
+// Index fetches the entities.Rule of a user
+func (service *RuleService) Index(ctx context.Context, userID entities.UserID, params repositories.IndexParams) ([]*entities.Rule, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	rules, err := service.repository.Index(ctx, userID, params)
+	if err != nil {
+		msg := fmt.Sprintf("could not fetch rules with params [%+#v]", params)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("fetched [%d] rules with prams [%+#v]", len(rules), params))
+	return rules, nil
+}
+
+// RuleStoreParams are parameters for creating a new entities.Rule
+type RuleStoreParams struct {
+	UserID          entities.UserID
+	Owner           string
+	Type            entities.RuleType
+	Content         string
+	Match           entities.RuleMatch
+	Pattern         string
+	CooldownMinutes uint
+}
+
+// Store a new entities.Rule
+func (service *RuleService) Store(ctx context.Context, params *RuleStoreParams) (*entities.Rule, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	rule := &entities.Rule{
+		ID:        uuid.New(),
+		UserID:    params.UserID,
+		Owner:     params.Owner,
+		Type:      params.Type,
+		Content:   params.Content,
+		Enabled:   true,
+		CreatedAt: time.Now().UTC(),
+		UpdatedAt: time.Now().UTC(),
+	}
+
+	if rule.Type == entities.RuleTypeAutoReply {
+		rule.Match = params.Match
+		rule.Pattern = params.Pattern
+		rule.CooldownMinutes = params.CooldownMinutes
+	}
+
+	if err := service.repository.Store(ctx, rule); err != nil {
+		msg := fmt.Sprintf("cannot save rule with id [%s]", rule.ID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("rule saved with id [%s] in the [%T]", rule.ID, service.repository))
+	return rule, nil
+}
+
+// RuleUpdateParams are parameters for updating an entities.Rule
+type RuleUpdateParams struct {
+	UserID          entities.UserID
+	RuleID          uuid.UUID
+	Content         string
+	Enabled         bool
+	Match           *entities.RuleMatch
+	Pattern         *string
+	CooldownMinutes *uint
+}
+
+// Update an entities.Rule
+func (service *RuleService) Update(ctx context.Context, params *RuleUpdateParams) (*entities.Rule, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	rule, err := service.repository.Load(ctx, params.UserID, params.RuleID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot load rule with ID [%s] for user [%s]", params.RuleID, params.UserID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
+	}
+
+	rule.Content = params.Content
+	rule.Enabled = params.Enabled
+	rule.UpdatedAt = time.Now().UTC()
+
+	if rule.Type == entities.RuleTypeAutoReply {
+		if params.Match != nil {
+			rule.Match = *params.Match
+		}
+		if params.Pattern != nil {
+			rule.Pattern = *params.Pattern
+		}
+		if params.CooldownMinutes != nil {
+			rule.CooldownMinutes = *params.CooldownMinutes
+		}
+	}
+
+	if err = service.repository.Update(ctx, rule); err != nil {
+		msg := fmt.Sprintf("cannot update rule with id [%s]", rule.ID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("rule updated with id [%s] in the [%T]", rule.ID, service.repository))
+	return rule, nil
+}
+
+// Delete an entities.Rule
+func (service *RuleService) Delete(ctx context.Context, userID entities.UserID, ruleID uuid.UUID) error {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	if _, err := service.repository.Load(ctx, userID, ruleID); err != nil {
+		msg := fmt.Sprintf("cannot load rule with ID [%s] for user [%s]", ruleID, userID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
+	}
+
+	if err := service.repository.Delete(ctx, userID, ruleID); err != nil {
+		msg := fmt.Sprintf("cannot delete rule with id [%s] and user id [%s]", ruleID, userID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("deleted rule with id [%s] and user id [%s]", ruleID, userID))
+	return nil
+}
+
+// HandleMissedCall sends the message of the missed call rules of the owner to the caller.
+// A rule sends at most 1 message to the same caller per day.
+func (service *RuleService) HandleMissedCall(ctx context.Context, source string, payload *events.CallMissedPayload) error {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	rules, err := service.repository.FetchEnabled(ctx, payload.UserID, payload.Owner, entities.RuleTypeMissedCall)
+	if err != nil {
+		msg := fmt.Sprintf("cannot fetch missed call rules for owner [%s]", payload.Owner)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if len(rules) == 0 {
+		ctxLogger.Info(fmt.Sprintf("user [%s] has no missed call rule for owner [%s]", payload.UserID, payload.Owner))
+		return nil
+	}
+
+	owner, err := phonenumbers.Parse(payload.Owner, phonenumbers.UNKNOWN_REGION)
+	if err != nil {
+		msg := fmt.Sprintf("cannot parse owner [%s] of missed call", payload.Owner)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	day := payload.Timestamp.UTC().Format("2006-01-02")
+	for _, rule := range rules {
+		executed, err := service.repository.HasExecution(ctx, rule.ID, payload.Contact, day)
+		if err != nil {
+			msg := fmt.Sprintf("cannot check if rule [%s] was executed for contact [%s] on [%s]", rule.ID, payload.Contact, day)
+			return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+		}
+
+		if executed {
+			ctxLogger.Info(fmt.Sprintf("rule [%s] has already been executed for contact [%s] on [%s]", rule.ID, payload.Contact, day))
+			continue
+		}
+
+		message, err := service.messageService.SendMessage(ctx, MessageSendParams{
+			Owner:             *owner,
+			Contact:           payload.Contact,
+			Content:           rule.Content,
+			Source:            source,
+			SIM:               payload.SIM,
+			UserID:            payload.UserID,
+			RequestReceivedAt: time.Now().UTC(),
+		})
+		if err != nil {
+			msg := fmt.Sprintf("cannot send message for rule [%s] to contact [%s]", rule.ID, payload.Contact)
+			return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+		}
+
+		err = service.repository.StoreExecution(ctx, &entities.RuleExecution{
+			ID:        uuid.New(),
+			RuleID:    rule.ID,
+			UserID:    rule.UserID,
+			Contact:   payload.Contact,
+			Day:       day,
+			MessageID: message.ID,
+			CreatedAt: time.Now().UTC(),
+		})
+		if err != nil {
+			msg := fmt.Sprintf("cannot store execution of rule [%s] for message [%s]", rule.ID, message.ID)
+			return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+		}
+
+		ctxLogger.Info(fmt.Sprintf("rule [%s] sent message [%s] to caller [%s]", rule.ID, message.ID, payload.Contact))
+	}
+
+	return nil
+}
+
+// HandleReceived sends the content of the auto reply rules of the owner which match a received message.
+// A rule replies at most once to the same contact within its cooldown so that 2 auto responders cannot reply to each other forever.
+func (service *RuleService) HandleReceived(ctx context.Context, source string, payload *events.MessagePhoneReceivedPayload) error {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	rules, err := service.repository.FetchEnabled(ctx, payload.UserID, payload.Owner, entities.RuleTypeAutoReply)
+	if err != nil {
+		msg := fmt.Sprintf("cannot fetch auto reply rules for owner [%s]", payload.Owner)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	var owner *phonenumbers.PhoneNumber
+	for _, rule := range rules {
+		if !rule.Matches(payload.Content) {
+			continue
+		}
+
+		executed, err := service.repository.HasExecutionSince(ctx, rule.ID, payload.Contact, time.Now().UTC().Add(-rule.Cooldown()))
+		if err != nil {
+			msg := fmt.Sprintf("cannot check if rule [%s] was executed for contact [%s] within [%s]", rule.ID, payload.Contact, rule.Cooldown())
+			return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+		}
+
+		if executed {
+			ctxLogger.Info(fmt.Sprintf("rule [%s] is cooling down for contact [%s]", rule.ID, payload.Contact))
+			continue
+		}
+
+		if owner == nil {
+			if owner, err = phonenumbers.Parse(payload.Owner, phonenumbers.UNKNOWN_REGION); err != nil {
+				msg := fmt.Sprintf("cannot parse owner [%s] of received message [%s]", payload.Owner, payload.MessageID)
+				return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+			}
+		}
+
+		message, err := service.messageService.SendMessage(ctx, MessageSendParams{
+			Owner:             *owner,
+			Contact:           payload.Contact,
+			Content:           rule.Content,
+			Source:            source,
+			SIM:               payload.SIM,
+			UserID:            payload.UserID,
+			RequestReceivedAt: time.Now().UTC(),
+			ConversationID:    payload.ConversationID,
+		})
+		if err != nil {
+			msg := fmt.Sprintf("cannot send auto reply for rule [%s] to contact [%s]", rule.ID, payload.Contact)
+			return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+		}
+
+		err = service.repository.StoreExecution(ctx, &entities.RuleExecution{
+			ID:        uuid.New(),
+			RuleID:    rule.ID,
+			UserID:    rule.UserID,
+			Contact:   payload.Contact,
+			Day:       payload.Timestamp.UTC().Format(time.RFC3339Nano),
+			MessageID: message.ID,
+			CreatedAt: time.Now().UTC(),
+		})
+		if err != nil {
+			msg := fmt.Sprintf("cannot store execution of rule [%s] for message [%s]", rule.ID, message.ID)
+			return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+		}
+
+		ctxLogger.Info(fmt.Sprintf("rule [%s] replied with message [%s] to contact [%s]", rule.ID, message.ID, payload.Contact))
+	}
+
+	return nil
+}
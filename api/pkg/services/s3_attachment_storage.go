@@ -0,0 +1,220 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/carlmjohnson/requests"
+	"github.com/palantir/stacktrace"
+)
+
+// s3PresignMaxExpiry is the maximum lifetime of a presigned URL allowed by S3
+const s3PresignMaxExpiry = 7 * 24 * time.Hour
+
+// S3Config is the configuration of an S3 compatible bucket
+type S3Config struct {
+	// Endpoint of an S3 compatible service e.g. https://minio.example.com, path style URLs are used when it is set
+	Endpoint        string
+	Region          string
+	Bucket          string
+	AccessKeyID     string
+	SecretAccessKey string
+}
+
+type s3AttachmentStorage struct {
+	logger telemetry.Logger
+	tracer telemetry.Tracer
+	client *http.Client
+	config S3Config
+}
+
+// NewS3AttachmentStorage creates an AttachmentStorage which keeps attachments in an S3 bucket.
+// Requests are authenticated with presigned URLs so that no SDK is needed.
+func NewS3AttachmentStorage(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	client *http.Client,
+	config S3Config,
+) AttachmentStorage {
+	config.Endpoint = strings.TrimRight(config.Endpoint, "/")
+	return &s3AttachmentStorage{
+		logger: logger.WithService(fmt.Sprintf("%T", &s3AttachmentStorage{})),
+		tracer: tracer,
+		client: client,
+		config: config,
+	}
+}
+
+// Name of the storage
+func (storage *s3AttachmentStorage) Name() string {
+	return "s3"
+}
+
+// Store the content of an attachment as an object in the bucket
+func (storage *s3AttachmentStorage) Store(ctx context.Context, key string, contentType string, content []byte) error {
+	ctx, span := storage.tracer.Start(ctx)
+	defer span.End()
+
+	link := storage.presign(http.MethodPut, key, time.Now().UTC(), 15*time.Minute)
+	err := requests.URL(link).
+		Client(storage.client).
+		Put().
+		ContentType(contentType).
+		BodyBytes(content).
+		Fetch(ctx)
+	if err != nil {
+		msg := fmt.Sprintf("cannot upload [%d] bytes of attachment [%s] to bucket [%s]", len(content), key, storage.config.Bucket)
+		return storage.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+// Load the content of an attachment from the bucket
+func (storage *s3AttachmentStorage) Load(ctx context.Context, key string) ([]byte, error) {
+	ctx, span := storage.tracer.Start(ctx)
+	defer span.End()
+
+	buffer := new(bytes.Buffer)
+	err := requests.URL(storage.presign(http.MethodGet, key, time.Now().UTC(), 15*time.Minute)).
+		Client(storage.client).
+		ToBytesBuffer(buffer).
+		Fetch(ctx)
+	if err != nil {
+		msg := fmt.Sprintf("cannot download attachment [%s] from bucket [%s]", key, storage.config.Bucket)
+		return nil, storage.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return buffer.Bytes(), nil
+}
+
+// SignedURL creates a presigned GET URL, the expiry is capped at the 7 days allowed by S3
+func (storage *s3AttachmentStorage) SignedURL(_ context.Context, key string, expiresAt time.Time) (string, error) {
+	timestamp := time.Now().UTC()
+
+	expiry := expiresAt.Sub(timestamp)
+	if expiry > s3PresignMaxExpiry {
+		expiry = s3PresignMaxExpiry
+	}
+	if expiry < time.Second {
+		return "", stacktrace.NewError(fmt.Sprintf("cannot sign URL for attachment [%s] which expires in the past at [%s]", key, expiresAt))
+	}
+
+	return storage.presign(http.MethodGet, key, timestamp, expiry), nil
+}
+
+// presign creates a URL signed with AWS signature version 4 in the query string
+// https://docs.aws.amazon.com/AmazonS3/latest/API/sigv4-query-string-auth.html
+func (storage *s3AttachmentStorage) presign(method string, key string, timestamp time.Time, expiry time.Duration) string {
+	host, path := storage.location(key)
+	return fmt.Sprintf("%s://%s%s?%s", storage.scheme(), host, path, storage.presignQuery(method, host, path, timestamp, expiry))
+}
+
+// presignQuery creates the query string with the authentication parameters of a request
+func (storage *s3AttachmentStorage) presignQuery(method string, host string, path string, timestamp time.Time, expiry time.Duration) string {
+	date := timestamp.Format("20060102")
+	scope := fmt.Sprintf("%s/%s/s3/aws4_request", date, storage.config.Region)
+
+	query := map[string]string{
+		"X-Amz-Algorithm":     "AWS4-HMAC-SHA256",
+		"X-Amz-Credential":    storage.config.AccessKeyID + "/" + scope,
+		"X-Amz-Date":          timestamp.Format("20060102T150405Z"),
+		"X-Amz-Expires":       fmt.Sprintf("%d", int64(expiry/time.Second)),
+		"X-Amz-SignedHeaders": "host",
+	}
+	canonicalQuery := storage.canonicalQuery(query)
+
+	canonicalRequest := strings.Join([]string{
+		method,
+		path,
+		canonicalQuery,
+		"host:" + host + "\n",
+		"host",
+		"UNSIGNED-PAYLOAD",
+	}, "\n")
+
+	hash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		query["X-Amz-Date"],
+		scope,
+		hex.EncodeToString(hash[:]),
+	}, "\n")
+
+	signingKey := storage.hmac([]byte("AWS4"+storage.config.SecretAccessKey), date)
+	signingKey = storage.hmac(signingKey, storage.config.Region)
+	signingKey = storage.hmac(signingKey, "s3")
+	signingKey = storage.hmac(signingKey, "aws4_request")
+	signature := hex.EncodeToString(storage.hmac(signingKey, stringToSign))
+
+	return canonicalQuery + "&X-Amz-Signature=" + signature
+}
+
+// location returns the host and the URI encoded path of an object
+func (storage *s3AttachmentStorage) location(key string) (string, string) {
+	segments := strings.Split(strings.TrimLeft(key, "/"), "/")
+	for index, segment := range segments {
+		segments[index] = storage.encode(segment)
+	}
+	path := "/" + strings.Join(segments, "/")
+
+	if storage.config.Endpoint == "" {
+		return fmt.Sprintf("%s.s3.%s.amazonaws.com", storage.config.Bucket, storage.config.Region), path
+	}
+
+	endpoint, err := url.Parse(storage.config.Endpoint)
+	if err != nil {
+		return storage.config.Endpoint, "/" + storage.encode(storage.config.Bucket) + path
+	}
+	return endpoint.Host, strings.TrimRight(endpoint.Path, "/") + "/" + storage.encode(storage.config.Bucket) + path
+}
+
+func (storage *s3AttachmentStorage) scheme() string {
+	if strings.HasPrefix(storage.config.Endpoint, "http://") {
+		return "http"
+	}
+	return "https"
+}
+
+func (storage *s3AttachmentStorage) canonicalQuery(query map[string]string) string {
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, 0, len(keys))
+	for _, key := range keys {
+		pairs = append(pairs, storage.encode(key)+"="+storage.encode(query[key]))
+	}
+	return strings.Join(pairs, "&")
+}
+
+// encode escapes a string as specified in RFC 3986 where only unreserved characters are left as is
+func (storage *s3AttachmentStorage) encode(value string) string {
+	builder := new(strings.Builder)
+	for _, char := range []byte(value) {
+		if (char >= 'A' && char <= 'Z') || (char >= 'a' && char <= 'z') || (char >= '0' && char <= '9') || char == '-' || char == '_' || char == '.' || char == '~' {
+			builder.WriteByte(char)
+			continue
+		}
+		builder.WriteString(fmt.Sprintf("%%%02X", char))
+	}
+	return builder.String()
+}
+
+func (storage *s3AttachmentStorage) hmac(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
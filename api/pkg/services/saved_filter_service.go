@@ -0,0 +1,237 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/google/uuid"
+	"github.com/palantir/stacktrace"
+	"gorm.io/datatypes"
+)
+
+// SavedFilterService manages the entities.SavedFilter of a user and executes them
+type SavedFilterService struct {
+	service
+	logger               telemetry.Logger
+	tracer               telemetry.Tracer
+	repository           repositories.SavedFilterRepository
+	messageService       *MessageService
+	messageThreadService *MessageThreadService
+	tagService           *TagService
+}
+
+// NewSavedFilterService creates a new SavedFilterService
+func NewSavedFilterService(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	repository repositories.SavedFilterRepository,
+	messageService *MessageService,
+	messageThreadService *MessageThreadService,
+	tagService *TagService,
+) (s *SavedFilterService) {
+	return &SavedFilterService{
+		logger:               logger.WithService(fmt.Sprintf("%T", s)),
+		tracer:               tracer,
+		repository:           repository,
+		messageService:       messageService,
+		messageThreadService: messageThreadService,
+		tagService:           tagService,
+	}
+}
+
+// Index fetches all the entities.SavedFilter of a user ordered by position
+func (service *SavedFilterService) Index(ctx context.Context, userID entities.UserID) ([]*entities.SavedFilter, error) {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	filters, err := service.repository.Index(ctx, userID)
+	if err != nil {
+		msg := fmt.Sprintf("could not fetch saved filters for user [%s]", userID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return filters, nil
+}
+
+// SavedFilterStoreParams are parameters for creating an entities.SavedFilter
+type SavedFilterStoreParams struct {
+	UserID   entities.UserID
+	Name     string
+	Target   entities.SavedFilterTarget
+	Filters  entities.SavedFilterCriteria
+	Position int
+}
+
+// Store a new entities.SavedFilter
+func (service *SavedFilterService) Store(ctx context.Context, params *SavedFilterStoreParams) (*entities.SavedFilter, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	filter := &entities.SavedFilter{
+		ID:        uuid.New(),
+		UserID:    params.UserID,
+		Name:      params.Name,
+		Target:    params.Target,
+		Filters:   datatypes.JSONType[entities.SavedFilterCriteria]{Data: params.Filters},
+		Position:  params.Position,
+		CreatedAt: time.Now().UTC(),
+		UpdatedAt: time.Now().UTC(),
+	}
+
+	if err := service.repository.Store(ctx, filter); err != nil {
+		msg := fmt.Sprintf("cannot save saved filter with id [%s]", filter.ID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("saved filter saved with id [%s] in the [%T]", filter.ID, service.repository))
+	return filter, nil
+}
+
+// SavedFilterUpdateParams are parameters for updating an entities.SavedFilter
+type SavedFilterUpdateParams struct {
+	SavedFilterStoreParams
+	SavedFilterID uuid.UUID
+}
+
+// Update an entities.SavedFilter
+func (service *SavedFilterService) Update(ctx context.Context, params *SavedFilterUpdateParams) (*entities.SavedFilter, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	filter, err := service.repository.Load(ctx, params.UserID, params.SavedFilterID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot load saved filter with ID [%s] for user [%s]", params.SavedFilterID, params.UserID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
+	}
+
+	filter.Name = params.Name
+	filter.Target = params.Target
+	filter.Filters = datatypes.JSONType[entities.SavedFilterCriteria]{Data: params.Filters}
+	filter.Position = params.Position
+	filter.UpdatedAt = time.Now().UTC()
+
+	if err = service.repository.Update(ctx, filter); err != nil {
+		msg := fmt.Sprintf("cannot update saved filter with id [%s]", filter.ID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("saved filter updated with id [%s] in the [%T]", filter.ID, service.repository))
+	return filter, nil
+}
+
+// Delete an entities.SavedFilter
+func (service *SavedFilterService) Delete(ctx context.Context, userID entities.UserID, savedFilterID uuid.UUID) error {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	if _, err := service.repository.Load(ctx, userID, savedFilterID); err != nil {
+		msg := fmt.Sprintf("cannot load saved filter with ID [%s] for user [%s]", savedFilterID, userID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
+	}
+
+	if err := service.repository.Delete(ctx, userID, savedFilterID); err != nil {
+		msg := fmt.Sprintf("cannot delete saved filter with ID [%s] for user [%s]", savedFilterID, userID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("saved filter with ID [%s] deleted for user [%s]", savedFilterID, userID))
+	return nil
+}
+
+// SavedFilterExecuteParams are parameters for fetching a page of the items which match an entities.SavedFilter.
+// The messages are paginated with the Cursor and the message threads with Skip.
+type SavedFilterExecuteParams struct {
+	UserID        entities.UserID
+	SavedFilterID uuid.UUID
+	Cursor        *repositories.EventCursor
+	Skip          int
+	Limit         int
+}
+
+// SavedFilterResult is a page of the items which match an entities.SavedFilter, only the list of the target of the filter is set
+type SavedFilterResult struct {
+	Target         entities.SavedFilterTarget `json:"target" example:"messages"`
+	Messages       []*entities.Message        `json:"messages,omitempty"`
+	MessageThreads []entities.MessageThread   `json:"message_threads,omitempty"`
+
+	// NextCursor is used to fetch the next page of messages, it is null when there are no more messages or the target is message-threads
+	NextCursor *string `json:"next_cursor" example:"MTY1NDQzMjM2OTUyNzk3NjAwMDozMjM0M2ExOS1kYTVlLTRiMWItYTc2Ny0zMjk4YTczNzAzY2I"`
+}
+
+// Execute fetches a page of the entities.Message or entities.MessageThread which currently match an entities.SavedFilter
+func (service *SavedFilterService) Execute(ctx context.Context, params *SavedFilterExecuteParams) (*SavedFilterResult, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	filter, err := service.repository.Load(ctx, params.UserID, params.SavedFilterID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot load saved filter with ID [%s] for user [%s]", params.SavedFilterID, params.UserID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
+	}
+
+	criteria := filter.Filters.Data
+
+	var tags []string
+	if criteria.Tag != "" {
+		if tags, err = service.tagService.Expand(ctx, params.UserID, criteria.Tag); err != nil {
+			msg := fmt.Sprintf("cannot expand tag [%s] of saved filter [%s]", criteria.Tag, filter.ID)
+			return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+		}
+	}
+
+	result := &SavedFilterResult{Target: filter.Target}
+	switch filter.Target {
+	case entities.SavedFilterTargetMessageThreads:
+		threads, err := service.messageThreadService.GetThreads(ctx, MessageThreadGetParams{
+			IndexParams: repositories.IndexParams{Skip: params.Skip, Query: criteria.Query, Limit: params.Limit},
+			IsArchived:  criteria.IsArchived,
+			UserID:      params.UserID,
+			Owner:       criteria.Owner,
+			Tags:        tags,
+		})
+		if err != nil {
+			msg := fmt.Sprintf("cannot fetch message threads of saved filter [%s]", filter.ID)
+			return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+		}
+		result.MessageThreads = *threads
+	default:
+		page, err := service.messageService.IndexMessages(ctx, params.UserID, service.messageIndexParams(criteria, tags, params))
+		if err != nil {
+			msg := fmt.Sprintf("cannot fetch messages of saved filter [%s]", filter.ID)
+			return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+		}
+		result.Messages = page.Messages
+		result.NextCursor = page.NextCursor
+	}
+
+	ctxLogger.Info(fmt.Sprintf("executed saved filter [%s] with target [%s] for user [%s]", filter.ID, filter.Target, params.UserID))
+	return result, nil
+}
+
+func (service *SavedFilterService) messageIndexParams(criteria entities.SavedFilterCriteria, tags []string, params *SavedFilterExecuteParams) repositories.MessageIndexParams {
+	indexParams := repositories.MessageIndexParams{
+		Owner:    criteria.Owner,
+		Contact:  criteria.Contact,
+		Statuses: criteria.Statuses,
+		SIM:      criteria.SIM,
+		Query:    criteria.Query,
+		Tags:     tags,
+		Cursor:   params.Cursor,
+		Limit:    params.Limit,
+	}
+
+	if criteria.Type != "" {
+		indexParams.Types = []entities.MessageType{criteria.Type}
+	}
+
+	if criteria.WithinDays > 0 {
+		since := time.Now().UTC().AddDate(0, 0, -criteria.WithinDays)
+		indexParams.Since = &since
+	}
+
+	return indexParams
+}
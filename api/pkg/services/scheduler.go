@@ -0,0 +1,26 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+)
+
+// runTicker calls tick on every interval until the context is cancelled, it is the loop which is shared by the schedulers
+func runTicker(ctx context.Context, logger telemetry.Logger, name string, interval time.Duration, tick func(ctx context.Context)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	logger.Info(fmt.Sprintf("%s started with interval [%s]", name, interval))
+	for {
+		select {
+		case <-ctx.Done():
+			logger.Info(fmt.Sprintf("%s stopped", name))
+			return
+		case <-ticker.C:
+			tick(ctx)
+		}
+	}
+}
@@ -0,0 +1,220 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/google/uuid"
+	"github.com/palantir/stacktrace"
+	"gorm.io/datatypes"
+)
+
+// segmentResolveBatchSize is the number of contacts fetched per query when resolving an entities.Segment
+const segmentResolveBatchSize = 500
+
+// SegmentService manages entities.Segment and resolves them into contacts
+type SegmentService struct {
+	service
+	logger            telemetry.Logger
+	tracer            telemetry.Tracer
+	repository        repositories.SegmentRepository
+	contactRepository repositories.ContactRepository
+}
+
+// NewSegmentService creates a new SegmentService
+func NewSegmentService(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	repository repositories.SegmentRepository,
+	contactRepository repositories.ContactRepository,
+) (s *SegmentService) {
+	return &SegmentService{
+		logger:            logger.WithService(fmt.Sprintf("%T", s)),
+		tracer:            tracer,
+		repository:        repository,
+		contactRepository: contactRepository,
+	}
+}
+
+// Index fetches the entities.Segment of a user
+func (service *SegmentService) Index(ctx context.Context, userID entities.UserID, params repositories.IndexParams) ([]*entities.Segment, error) {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	segments, err := service.repository.Index(ctx, userID, params)
+	if err != nil {
+		msg := fmt.Sprintf("could not fetch segments with params [%+#v]", params)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return segments, nil
+}
+
+// SegmentStoreParams are parameters for creating an entities.Segment
+type SegmentStoreParams struct {
+	UserID  entities.UserID
+	Name    string
+	Filters []entities.SegmentFilter
+}
+
+// Store a new entities.Segment
+func (service *SegmentService) Store(ctx context.Context, params *SegmentStoreParams) (*entities.Segment, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	segment := &entities.Segment{
+		ID:        uuid.New(),
+		UserID:    params.UserID,
+		Name:      params.Name,
+		Filters:   datatypes.JSONType[[]entities.SegmentFilter]{Data: params.Filters},
+		CreatedAt: time.Now().UTC(),
+		UpdatedAt: time.Now().UTC(),
+	}
+
+	if err := service.repository.Store(ctx, segment); err != nil {
+		msg := fmt.Sprintf("cannot save segment with id [%s]", segment.ID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("segment saved with id [%s] in the [%T]", segment.ID, service.repository))
+	return segment, nil
+}
+
+// SegmentUpdateParams are parameters for updating an entities.Segment
+type SegmentUpdateParams struct {
+	UserID    entities.UserID
+	SegmentID uuid.UUID
+	Name      string
+	Filters   []entities.SegmentFilter
+}
+
+// Update an entities.Segment
+func (service *SegmentService) Update(ctx context.Context, params *SegmentUpdateParams) (*entities.Segment, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	segment, err := service.repository.Load(ctx, params.UserID, params.SegmentID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot load segment with ID [%s] for user [%s]", params.SegmentID, params.UserID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
+	}
+
+	segment.Name = params.Name
+	segment.Filters = datatypes.JSONType[[]entities.SegmentFilter]{Data: params.Filters}
+	segment.UpdatedAt = time.Now().UTC()
+
+	if err = service.repository.Update(ctx, segment); err != nil {
+		msg := fmt.Sprintf("cannot update segment with id [%s]", segment.ID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("segment updated with id [%s] in the [%T]", segment.ID, service.repository))
+	return segment, nil
+}
+
+// Delete an entities.Segment
+func (service *SegmentService) Delete(ctx context.Context, userID entities.UserID, segmentID uuid.UUID) error {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	if _, err := service.repository.Load(ctx, userID, segmentID); err != nil {
+		msg := fmt.Sprintf("cannot load segment with ID [%s] for user [%s]", segmentID, userID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
+	}
+
+	if err := service.repository.Delete(ctx, userID, segmentID); err != nil {
+		msg := fmt.Sprintf("cannot delete segment with ID [%s] for user [%s]", segmentID, userID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("segment with ID [%s] deleted for user [%s]", segmentID, userID))
+	return nil
+}
+
+// SegmentCount is the number of entities.Contact which match the filters of an entities.Segment
+type SegmentCount struct {
+	Contacts  int64     `json:"contacts" example:"120"`
+	OptedOut  int64     `json:"opted_out" example:"3"`
+	CountedAt time.Time `json:"counted_at" example:"2022-06-05T14:26:10.303278+03:00"`
+}
+
+// Count returns the number of entities.Contact which currently match the filters of a segment
+func (service *SegmentService) Count(ctx context.Context, userID entities.UserID, filters []entities.SegmentFilter) (*SegmentCount, error) {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	contacts, err := service.contactRepository.Count(ctx, userID, repositories.ContactIndexParams{Filters: filters})
+	if err != nil {
+		msg := fmt.Sprintf("cannot count contacts of user [%s] matching [%d] filters", userID, len(filters))
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	isOptedOut := true
+	optedOut, err := service.contactRepository.Count(ctx, userID, repositories.ContactIndexParams{Filters: filters, IsOptedOut: &isOptedOut})
+	if err != nil {
+		msg := fmt.Sprintf("cannot count opted out contacts of user [%s] matching [%d] filters", userID, len(filters))
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return &SegmentCount{
+		Contacts:  contacts,
+		OptedOut:  optedOut,
+		CountedAt: time.Now().UTC(),
+	}, nil
+}
+
+// CountSegment returns the number of entities.Contact which currently match an entities.Segment
+func (service *SegmentService) CountSegment(ctx context.Context, userID entities.UserID, segmentID uuid.UUID) (*SegmentCount, error) {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	segment, err := service.repository.Load(ctx, userID, segmentID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot load segment with ID [%s] for user [%s]", segmentID, userID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
+	}
+
+	count, err := service.Count(ctx, userID, segment.Filters.Data)
+	if err != nil {
+		msg := fmt.Sprintf("cannot count contacts of segment [%s]", segment.ID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return count, nil
+}
+
+// Resolve returns the entities.Contact which match an entities.Segment at the time it is called
+func (service *SegmentService) Resolve(ctx context.Context, userID entities.UserID, segmentID uuid.UUID) ([]*entities.Contact, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	segment, err := service.repository.Load(ctx, userID, segmentID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot load segment with ID [%s] for user [%s]", segmentID, userID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
+	}
+
+	contacts := make([]*entities.Contact, 0)
+	for {
+		batch, err := service.contactRepository.Index(ctx, userID, repositories.ContactIndexParams{
+			IndexParams: repositories.IndexParams{Skip: len(contacts), Limit: segmentResolveBatchSize},
+			Filters:     segment.Filters.Data,
+		})
+		if err != nil {
+			msg := fmt.Sprintf("cannot fetch contacts for segment [%s] after [%d] contacts", segment.ID, len(contacts))
+			return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+		}
+
+		contacts = append(contacts, batch...)
+		if len(batch) < segmentResolveBatchSize {
+			break
+		}
+	}
+
+	ctxLogger.Info(fmt.Sprintf("resolved segment [%s] into [%d] contacts for user [%s]", segment.ID, len(contacts), userID))
+	return contacts, nil
+}
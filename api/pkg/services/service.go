@@ -1,8 +1,13 @@
 package services
 
 import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"fmt"
 	"regexp"
+	"strings"
 	"time"
 
 	"github.com/NdoleStudio/httpsms/pkg/telemetry"
@@ -49,3 +54,18 @@ func (service *service) getFormattedNumber(ctxLogger telemetry.Logger, phoneNumb
 
 	return phonenumbers.Format(number, phonenumbers.INTERNATIONAL)
 }
+
+// generateToken returns a random url safe token and the hash which is persisted in the database
+func (service *service) generateToken() (string, string, error) {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return "", "", stacktrace.Propagate(err, fmt.Sprintf("cannot generate [%d] random bytes", len(key)))
+	}
+	token := base64.RawURLEncoding.EncodeToString(key)
+	return token, service.hashToken(token), nil
+}
+
+func (service *service) hashToken(token string) string {
+	hash := sha256.Sum256([]byte(strings.TrimSpace(token)))
+	return hex.EncodeToString(hash[:])
+}
@@ -0,0 +1,42 @@
+package services
+
+import (
+	"strings"
+	"unicode/utf16"
+)
+
+// gsm7Characters are the characters of the GSM 03.38 basic character set
+const gsm7Characters = "@£$¥èéùìòÇ\nØø\rÅåΔ_ΦΓΛΩΠΨΣΘΞÆæßÉ !\"#¤%&'()*+,-./0123456789:;<=>?¡ABCDEFGHIJKLMNOPQRSTUVWXYZÄÖÑÜ§¿abcdefghijklmnopqrstuvwxyzäöñüà"
+
+// gsm7ExtensionCharacters are encoded with an escape character so they use 2 septets
+const gsm7ExtensionCharacters = "^{}\\[~]|€\f"
+
+// SMSSegments returns the number of SMS parts which are needed to send the content.
+// GSM 03.38 content uses 160 characters per SMS or 153 per part, any other content is sent as UCS-2 with 70 characters per SMS or 67 per part.
+func SMSSegments(content string) int {
+	length, isGSM7 := 0, true
+	for _, character := range content {
+		switch {
+		case strings.ContainsRune(gsm7Characters, character):
+			length++
+		case strings.ContainsRune(gsm7ExtensionCharacters, character):
+			length += 2
+		default:
+			isGSM7 = false
+		}
+		if !isGSM7 {
+			break
+		}
+	}
+
+	single, multi := 160, 153
+	if !isGSM7 {
+		length = len(utf16.Encode([]rune(content)))
+		single, multi = 70, 67
+	}
+
+	if length <= single {
+		return 1
+	}
+	return (length + multi - 1) / multi
+}
@@ -0,0 +1,222 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/cache"
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/nyaruka/phonenumbers"
+	"github.com/palantir/stacktrace"
+)
+
+const (
+	statusCacheKey             = "instance-status"
+	statusCacheTTL             = time.Minute
+	statusWindow               = time.Hour
+	statusMaxLatencySamples    = 10000
+	statusMinRegionSamples     = 10
+	statusDegradedQueueLag     = 5 * time.Minute
+	statusDegradedDeliveryP90  = 5 * time.Minute
+	statusOtherRegion          = "other"
+	statusUnknownRegionDefault = "ZZ"
+)
+
+// processStartedAt is when the API process started
+var processStartedAt = time.Now().UTC()
+
+// StatusService computes the anonymized health of the instance
+type StatusService struct {
+	service
+	logger     telemetry.Logger
+	tracer     telemetry.Tracer
+	cache      cache.Cache
+	repository repositories.AnalyticsRepository
+	components []entities.InstanceStatusComponentName
+}
+
+// NewStatusService creates a new StatusService
+func NewStatusService(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	cache cache.Cache,
+	repository repositories.AnalyticsRepository,
+	components []entities.InstanceStatusComponentName,
+) (s *StatusService) {
+	return &StatusService{
+		logger:     logger.WithService(fmt.Sprintf("%T", s)),
+		tracer:     tracer,
+		cache:      cache,
+		repository: repository,
+		components: components,
+	}
+}
+
+// Status returns the entities.InstanceStatus of the configured components
+func (service *StatusService) Status(ctx context.Context) (*entities.InstanceStatus, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	status := new(entities.InstanceStatus)
+	if value, err := service.cache.Get(ctx, statusCacheKey); err == nil && json.Unmarshal([]byte(value), status) == nil {
+		return status, nil
+	}
+
+	status = &entities.InstanceStatus{
+		Status:     entities.InstanceStatusLevelOperational,
+		Components: make([]*entities.InstanceStatusComponent, 0, len(service.components)),
+		UpdatedAt:  time.Now().UTC(),
+	}
+
+	for _, name := range service.components {
+		component, err := service.component(ctx, name)
+		if err != nil {
+			msg := fmt.Sprintf("cannot compute status of component [%s]", name)
+			return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+		}
+
+		if component.Status != entities.InstanceStatusLevelOperational {
+			status.Status = entities.InstanceStatusLevelDegraded
+		}
+		status.Components = append(status.Components, component)
+	}
+
+	if value, err := json.Marshal(status); err == nil {
+		if err = service.cache.Set(ctx, statusCacheKey, string(value), statusCacheTTL); err != nil {
+			ctxLogger.Error(stacktrace.Propagate(err, "cannot cache instance status"))
+		}
+	}
+
+	return status, nil
+}
+
+func (service *StatusService) component(ctx context.Context, name entities.InstanceStatusComponentName) (*entities.InstanceStatusComponent, error) {
+	switch name {
+	case entities.InstanceStatusComponentAPI:
+		uptime := int64(time.Since(processStartedAt).Seconds())
+		return &entities.InstanceStatusComponent{
+			Name:          name,
+			Status:        entities.InstanceStatusLevelOperational,
+			StartedAt:     &processStartedAt,
+			UptimeSeconds: &uptime,
+		}, nil
+	case entities.InstanceStatusComponentQueue:
+		return service.queueComponent(ctx)
+	case entities.InstanceStatusComponentDelivery:
+		return service.deliveryComponent(ctx)
+	default:
+		return &entities.InstanceStatusComponent{Name: name, Status: entities.InstanceStatusLevelUnknown}, nil
+	}
+}
+
+func (service *StatusService) queueComponent(ctx context.Context) (*entities.InstanceStatusComponent, error) {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	oldest, err := service.repository.OldestOutstanding(ctx, time.Now().UTC().Add(-statusWindow))
+	if err != nil {
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, "cannot load oldest outstanding message"))
+	}
+
+	lag := 0.0
+	if oldest != nil {
+		lag = math.Round(time.Since(*oldest).Seconds()*100) / 100
+	}
+
+	component := &entities.InstanceStatusComponent{
+		Name:            entities.InstanceStatusComponentQueue,
+		Status:          entities.InstanceStatusLevelOperational,
+		QueueLagSeconds: &lag,
+	}
+	if lag > statusDegradedQueueLag.Seconds() {
+		component.Status = entities.InstanceStatusLevelDegraded
+	}
+
+	return component, nil
+}
+
+func (service *StatusService) deliveryComponent(ctx context.Context) (*entities.InstanceStatusComponent, error) {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	samples, err := service.repository.DeliveryLatencies(ctx, time.Now().UTC().Add(-statusWindow), statusMaxLatencySamples)
+	if err != nil {
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, "cannot load delivery latencies"))
+	}
+
+	regions := map[string][]float64{}
+	for _, sample := range samples {
+		region := service.region(sample.Contact)
+		regions[region] = append(regions[region], sample.Seconds)
+	}
+
+	// regions with few messages are merged so that individual users cannot be identified
+	for region, values := range regions {
+		if len(values) < statusMinRegionSamples && region != statusOtherRegion {
+			regions[statusOtherRegion] = append(regions[statusOtherRegion], values...)
+			delete(regions, region)
+		}
+	}
+	if len(regions[statusOtherRegion]) < statusMinRegionSamples {
+		delete(regions, statusOtherRegion)
+	}
+
+	component := &entities.InstanceStatusComponent{
+		Name:              entities.InstanceStatusComponentDelivery,
+		Status:            entities.InstanceStatusLevelOperational,
+		DeliveryLatencies: make([]*entities.RegionDeliveryLatency, 0, len(regions)),
+	}
+
+	for region, values := range regions {
+		sort.Float64s(values)
+		latency := &entities.RegionDeliveryLatency{
+			Region:     region,
+			Count:      len(values),
+			P50Seconds: service.percentile(values, 50),
+			P90Seconds: service.percentile(values, 90),
+			P99Seconds: service.percentile(values, 99),
+		}
+		if latency.P90Seconds > statusDegradedDeliveryP90.Seconds() {
+			component.Status = entities.InstanceStatusLevelDegraded
+		}
+		component.DeliveryLatencies = append(component.DeliveryLatencies, latency)
+	}
+
+	sort.Slice(component.DeliveryLatencies, func(i, j int) bool {
+		return component.DeliveryLatencies[i].Region < component.DeliveryLatencies[j].Region
+	})
+
+	return component, nil
+}
+
+func (service *StatusService) region(contact string) string {
+	number, err := phonenumbers.Parse(contact, phonenumbers.UNKNOWN_REGION)
+	if err != nil {
+		return statusOtherRegion
+	}
+
+	region := phonenumbers.GetRegionCodeForNumber(number)
+	if region == "" || region == statusUnknownRegionDefault {
+		return statusOtherRegion
+	}
+	return region
+}
+
+// percentile returns the nearest rank percentile of sorted values
+func (service *StatusService) percentile(values []float64, percent float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+
+	rank := int(math.Ceil(percent/100*float64(len(values)))) - 1
+	if rank < 0 {
+		rank = 0
+	}
+	return math.Round(values[rank]*100) / 100
+}
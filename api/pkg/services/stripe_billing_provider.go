@@ -0,0 +1,206 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/carlmjohnson/requests"
+	"github.com/palantir/stacktrace"
+)
+
+const stripeAPIBaseURL = "https://api.stripe.com/v1"
+
+// StripeSubscription is the subscription object of the stripe API
+type StripeSubscription struct {
+	ID                string            `json:"id"`
+	Customer          string            `json:"customer"`
+	Status            string            `json:"status"`
+	CancelAtPeriodEnd bool              `json:"cancel_at_period_end"`
+	Created           int64             `json:"created"`
+	CurrentPeriodEnd  int64             `json:"current_period_end"`
+	CanceledAt        *int64            `json:"canceled_at"`
+	EndedAt           *int64            `json:"ended_at"`
+	Metadata          map[string]string `json:"metadata"`
+	Items             struct {
+		Data []struct {
+			ID    string `json:"id"`
+			Price struct {
+				ID string `json:"id"`
+			} `json:"price"`
+		} `json:"data"`
+	} `json:"items"`
+}
+
+// PriceID returns the ID of the price of the first item in the subscription
+func (subscription *StripeSubscription) PriceID() string {
+	if len(subscription.Items.Data) == 0 {
+		return ""
+	}
+	return subscription.Items.Data[0].Price.ID
+}
+
+type stripeBillingProvider struct {
+	logger    telemetry.Logger
+	tracer    telemetry.Tracer
+	client    *http.Client
+	secretKey string
+	prices    BillingPlans
+	returnURL string
+}
+
+// NewStripeBillingProvider creates a BillingProvider which uses stripe checkout and the stripe customer portal.
+// The prices are the IDs of the recurring prices of each plan and the returnURL is where the user is redirected after a checkout.
+func NewStripeBillingProvider(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	client *http.Client,
+	secretKey string,
+	prices BillingPlans,
+	returnURL string,
+) BillingProvider {
+	return &stripeBillingProvider{
+		logger:    logger.WithService(fmt.Sprintf("%T", &stripeBillingProvider{})),
+		tracer:    tracer,
+		client:    client,
+		secretKey: secretKey,
+		prices:    prices,
+		returnURL: returnURL,
+	}
+}
+
+// Name of the provider
+func (provider *stripeBillingProvider) Name() string {
+	return "stripe"
+}
+
+// CheckoutURL creates a stripe checkout session for the price of the plan
+func (provider *stripeBillingProvider) CheckoutURL(ctx context.Context, user *entities.User, plan entities.SubscriptionName) (string, error) {
+	ctx, span := provider.tracer.Start(ctx)
+	defer span.End()
+
+	priceID, ok := provider.prices[plan]
+	if !ok {
+		return "", provider.tracer.WrapErrorSpan(span, stacktrace.NewError(fmt.Sprintf("no stripe price is configured for plan [%s]", plan)))
+	}
+
+	form := url.Values{}
+	form.Set("mode", "subscription")
+	form.Set("line_items[0][price]", priceID)
+	form.Set("line_items[0][quantity]", "1")
+	form.Set("client_reference_id", string(user.ID))
+	form.Set("customer_email", user.Email)
+	form.Set("subscription_data[metadata][user_id]", string(user.ID))
+	form.Set("success_url", provider.returnURL)
+	form.Set("cancel_url", provider.returnURL)
+
+	session := new(struct {
+		URL string `json:"url"`
+	})
+	if err := provider.request("/checkout/sessions").BodyForm(form).ToJSON(session).Fetch(ctx); err != nil {
+		msg := fmt.Sprintf("cannot create stripe checkout session for user [%s] and plan [%s]", user.ID, plan)
+		return "", provider.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return session.URL, nil
+}
+
+// UpdatePaymentMethodURL creates a session on the stripe customer portal
+func (provider *stripeBillingProvider) UpdatePaymentMethodURL(ctx context.Context, user *entities.User) (string, error) {
+	ctx, span := provider.tracer.Start(ctx)
+	defer span.End()
+
+	subscription, err := provider.subscription(ctx, user)
+	if err != nil {
+		return "", provider.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, fmt.Sprintf("cannot load subscription of user [%s]", user.ID)))
+	}
+
+	form := url.Values{}
+	form.Set("customer", subscription.Customer)
+	form.Set("return_url", provider.returnURL)
+
+	session := new(struct {
+		URL string `json:"url"`
+	})
+	if err = provider.request("/billing_portal/sessions").BodyForm(form).ToJSON(session).Fetch(ctx); err != nil {
+		msg := fmt.Sprintf("cannot create stripe portal session for customer [%s] of user [%s]", subscription.Customer, user.ID)
+		return "", provider.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return session.URL, nil
+}
+
+// ChangePlan swaps the price of the subscription item and creates prorations for the rest of the billing period
+func (provider *stripeBillingProvider) ChangePlan(ctx context.Context, user *entities.User, plan entities.SubscriptionName) error {
+	ctx, span := provider.tracer.Start(ctx)
+	defer span.End()
+
+	priceID, ok := provider.prices[plan]
+	if !ok {
+		return provider.tracer.WrapErrorSpan(span, stacktrace.NewError(fmt.Sprintf("no stripe price is configured for plan [%s]", plan)))
+	}
+
+	subscription, err := provider.subscription(ctx, user)
+	if err != nil {
+		return provider.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, fmt.Sprintf("cannot load subscription of user [%s]", user.ID)))
+	}
+
+	if len(subscription.Items.Data) == 0 {
+		return provider.tracer.WrapErrorSpan(span, stacktrace.NewError(fmt.Sprintf("stripe subscription [%s] has no items", subscription.ID)))
+	}
+
+	form := url.Values{}
+	form.Set("items[0][id]", subscription.Items.Data[0].ID)
+	form.Set("items[0][price]", priceID)
+	form.Set("proration_behavior", "create_prorations")
+	form.Set("cancel_at_period_end", "false")
+
+	if err = provider.request("/subscriptions/" + subscription.ID).BodyForm(form).Fetch(ctx); err != nil {
+		msg := fmt.Sprintf("cannot change stripe subscription [%s] of user [%s] to plan [%s]", subscription.ID, user.ID, plan)
+		return provider.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+// Cancel the subscription of a user at the end of the current billing period
+func (provider *stripeBillingProvider) Cancel(ctx context.Context, user *entities.User) error {
+	ctx, span := provider.tracer.Start(ctx)
+	defer span.End()
+
+	if user.SubscriptionID == nil {
+		return provider.tracer.WrapErrorSpan(span, stacktrace.NewError(fmt.Sprintf("user [%s] has no subscription", user.ID)))
+	}
+
+	form := url.Values{}
+	form.Set("cancel_at_period_end", "true")
+
+	if err := provider.request("/subscriptions/" + *user.SubscriptionID).BodyForm(form).Fetch(ctx); err != nil {
+		msg := fmt.Sprintf("cannot cancel stripe subscription [%s] of user [%s]", *user.SubscriptionID, user.ID)
+		return provider.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+func (provider *stripeBillingProvider) subscription(ctx context.Context, user *entities.User) (*StripeSubscription, error) {
+	if user.SubscriptionID == nil {
+		return nil, stacktrace.NewError(fmt.Sprintf("user [%s] has no subscription", user.ID))
+	}
+
+	subscription := new(StripeSubscription)
+	if err := provider.request("/subscriptions/" + *user.SubscriptionID).ToJSON(subscription).Fetch(ctx); err != nil {
+		return nil, stacktrace.Propagate(err, fmt.Sprintf("cannot fetch stripe subscription [%s]", *user.SubscriptionID))
+	}
+
+	return subscription, nil
+}
+
+func (provider *stripeBillingProvider) request(path string) *requests.Builder {
+	return requests.URL(stripeAPIBaseURL + path).
+		Client(provider.client).
+		Bearer(provider.secretKey)
+}
@@ -0,0 +1,185 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/events"
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/palantir/stacktrace"
+)
+
+// StripeEvent is an event sent by stripe to the webhook endpoint
+type StripeEvent struct {
+	ID   string `json:"id"`
+	Type string `json:"type"`
+	Data struct {
+		Object json.RawMessage `json:"object"`
+	} `json:"data"`
+}
+
+// StripeService is responsible for managing stripe events
+type StripeService struct {
+	service
+	logger          telemetry.Logger
+	tracer          telemetry.Tracer
+	eventDispatcher *EventDispatcher
+	userRepository  repositories.UserRepository
+	prices          BillingPlans
+}
+
+// NewStripeService creates a new StripeService
+func NewStripeService(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	repository repositories.UserRepository,
+	eventDispatcher *EventDispatcher,
+	prices BillingPlans,
+) (s *StripeService) {
+	return &StripeService{
+		logger:          logger.WithService(fmt.Sprintf("%T", s)),
+		tracer:          tracer,
+		userRepository:  repository,
+		eventDispatcher: eventDispatcher,
+		prices:          prices,
+	}
+}
+
+// HandleEvent handles the customer.subscription.* stripe events, other events are ignored
+func (service *StripeService) HandleEvent(ctx context.Context, source string, request *StripeEvent) error {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	switch request.Type {
+	case "customer.subscription.created", "customer.subscription.updated", "customer.subscription.deleted":
+	default:
+		ctxLogger.Info(fmt.Sprintf("ignoring stripe event [%s] with type [%s]", request.ID, request.Type))
+		return nil
+	}
+
+	subscription := new(StripeSubscription)
+	if err := json.Unmarshal(request.Data.Object, subscription); err != nil {
+		msg := fmt.Sprintf("cannot unmarshal object of stripe event [%s] into [%T]", request.ID, subscription)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	userID, err := service.userID(ctx, subscription)
+	if err != nil {
+		msg := fmt.Sprintf("cannot find user for stripe subscription [%s]", subscription.ID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if request.Type == "customer.subscription.deleted" || subscription.CancelAtPeriodEnd || subscription.Status == "canceled" {
+		return service.handleSubscriptionCancelled(ctx, source, userID, subscription)
+	}
+
+	if subscription.Status == "past_due" || subscription.Status == "unpaid" {
+		return service.handleSubscriptionPaymentFailed(ctx, source, userID, subscription)
+	}
+	return service.handleSubscriptionCreated(ctx, source, userID, subscription)
+}
+
+func (service *StripeService) handleSubscriptionPaymentFailed(ctx context.Context, source string, userID entities.UserID, subscription *StripeSubscription) error {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	payload := &events.UserSubscriptionPaymentFailedPayload{
+		UserID:             userID,
+		SubscriptionID:     subscription.ID,
+		SubscriptionName:   service.subscriptionName(subscription),
+		SubscriptionStatus: subscription.Status,
+		FailedAt:           time.Now().UTC(),
+	}
+
+	if err := service.dispatch(ctx, events.UserSubscriptionPaymentFailed, source, payload); err != nil {
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, fmt.Sprintf("cannot dispatch event for user [%s]", userID)))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("payment of stripe subscription [%s] failed for user [%s] with status [%s]", payload.SubscriptionID, payload.UserID, payload.SubscriptionStatus))
+	return nil
+}
+
+func (service *StripeService) handleSubscriptionCreated(ctx context.Context, source string, userID entities.UserID, subscription *StripeSubscription) error {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	payload := &events.UserSubscriptionCreatedPayload{
+		UserID:                userID,
+		SubscriptionCreatedAt: time.Unix(subscription.Created, 0).UTC(),
+		SubscriptionID:        subscription.ID,
+		SubscriptionName:      service.subscriptionName(subscription),
+		SubscriptionRenewsAt:  time.Unix(subscription.CurrentPeriodEnd, 0).UTC(),
+		SubscriptionStatus:    subscription.Status,
+	}
+
+	if err := service.dispatch(ctx, events.UserSubscriptionCreated, source, payload); err != nil {
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, fmt.Sprintf("cannot dispatch event for user [%s]", userID)))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("[%s] stripe subscription [%s] created for user [%s]", payload.SubscriptionName, payload.SubscriptionID, payload.UserID))
+	return nil
+}
+
+func (service *StripeService) handleSubscriptionCancelled(ctx context.Context, source string, userID entities.UserID, subscription *StripeSubscription) error {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	cancelledAt := time.Now().UTC()
+	if subscription.CanceledAt != nil {
+		cancelledAt = time.Unix(*subscription.CanceledAt, 0).UTC()
+	}
+
+	endsAt := time.Unix(subscription.CurrentPeriodEnd, 0).UTC()
+	if subscription.EndedAt != nil {
+		endsAt = time.Unix(*subscription.EndedAt, 0).UTC()
+	}
+
+	payload := &events.UserSubscriptionCancelledPayload{
+		UserID:                  userID,
+		SubscriptionCancelledAt: cancelledAt,
+		SubscriptionID:          subscription.ID,
+		SubscriptionName:        service.subscriptionName(subscription),
+		SubscriptionEndsAt:      endsAt,
+		SubscriptionStatus:      subscription.Status,
+	}
+
+	if err := service.dispatch(ctx, events.UserSubscriptionCancelled, source, payload); err != nil {
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, fmt.Sprintf("cannot dispatch event for user [%s]", userID)))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("[%s] stripe subscription [%s] cancelled for user [%s]", payload.SubscriptionName, payload.SubscriptionID, payload.UserID))
+	return nil
+}
+
+func (service *StripeService) dispatch(ctx context.Context, eventType string, source string, payload any) error {
+	event, err := service.createEvent(eventType, source, payload)
+	if err != nil {
+		return stacktrace.Propagate(err, fmt.Sprintf("cannot create [%s] event", eventType))
+	}
+
+	if err = service.eventDispatcher.Dispatch(ctx, event); err != nil {
+		return stacktrace.Propagate(err, fmt.Sprintf("cannot dispatch [%s] event with ID [%s]", eventType, event.ID()))
+	}
+	return nil
+}
+
+func (service *StripeService) userID(ctx context.Context, subscription *StripeSubscription) (entities.UserID, error) {
+	if userID, ok := subscription.Metadata["user_id"]; ok && userID != "" {
+		return entities.UserID(userID), nil
+	}
+
+	user, err := service.userRepository.LoadBySubscriptionID(ctx, subscription.ID)
+	if err != nil {
+		return "", stacktrace.Propagate(err, fmt.Sprintf("cannot load user with subscription ID [%s]", subscription.ID))
+	}
+	return user.ID, nil
+}
+
+func (service *StripeService) subscriptionName(subscription *StripeSubscription) entities.SubscriptionName {
+	name, _ := service.prices.Name(subscription.PriceID())
+	return name
+}
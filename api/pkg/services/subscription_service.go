@@ -0,0 +1,323 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/events"
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/google/uuid"
+	"github.com/nyaruka/phonenumbers"
+	"github.com/palantir/stacktrace"
+)
+
+// SubscriptionService manages the double opt-in of contacts to entities.SubscriptionKeyword
+type SubscriptionService struct {
+	service
+	logger         telemetry.Logger
+	tracer         telemetry.Tracer
+	repository     repositories.SubscriptionRepository
+	contactService *ContactService
+	messageService *MessageService
+}
+
+// NewSubscriptionService creates a new SubscriptionService
+func NewSubscriptionService(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	repository repositories.SubscriptionRepository,
+	contactService *ContactService,
+	messageService *MessageService,
+) (s *SubscriptionService) {
+	return &SubscriptionService{
+		logger:         logger.WithService(fmt.Sprintf("%T", s)),
+		tracer:         tracer,
+		repository:     repository,
+		contactService: contactService,
+		messageService: messageService,
+	}
+}
+
+// IndexKeywords fetches the entities.SubscriptionKeyword of a user
+func (service *SubscriptionService) IndexKeywords(ctx context.Context, userID entities.UserID, params repositories.IndexParams) ([]*entities.SubscriptionKeyword, error) {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	keywords, err := service.repository.IndexKeywords(ctx, userID, params)
+	if err != nil {
+		msg := fmt.Sprintf("could not fetch subscription keywords with params [%+#v]", params)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return keywords, nil
+}
+
+// SubscriptionKeywordStoreParams are parameters for creating an entities.SubscriptionKeyword
+type SubscriptionKeywordStoreParams struct {
+	UserID               entities.UserID
+	Owner                string
+	Keyword              string
+	List                 string
+	ConfirmationTemplate string
+	WelcomeTemplate      string
+	Enabled              bool
+}
+
+// StoreKeyword creates a new entities.SubscriptionKeyword
+func (service *SubscriptionService) StoreKeyword(ctx context.Context, params *SubscriptionKeywordStoreParams) (*entities.SubscriptionKeyword, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	keyword := &entities.SubscriptionKeyword{
+		ID:                   uuid.New(),
+		UserID:               params.UserID,
+		Owner:                params.Owner,
+		Keyword:              strings.ToUpper(params.Keyword),
+		List:                 params.List,
+		ConfirmationTemplate: params.ConfirmationTemplate,
+		WelcomeTemplate:      params.WelcomeTemplate,
+		Enabled:              params.Enabled,
+		CreatedAt:            time.Now().UTC(),
+		UpdatedAt:            time.Now().UTC(),
+	}
+
+	if err := service.repository.StoreKeyword(ctx, keyword); err != nil {
+		msg := fmt.Sprintf("cannot save subscription keyword with id [%s]", keyword.ID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("subscription keyword saved with id [%s] in the [%T]", keyword.ID, service.repository))
+	return keyword, nil
+}
+
+// SubscriptionKeywordUpdateParams are parameters for updating an entities.SubscriptionKeyword
+type SubscriptionKeywordUpdateParams struct {
+	SubscriptionKeywordStoreParams
+	KeywordID uuid.UUID
+}
+
+// UpdateKeyword updates an entities.SubscriptionKeyword
+func (service *SubscriptionService) UpdateKeyword(ctx context.Context, params *SubscriptionKeywordUpdateParams) (*entities.SubscriptionKeyword, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	keyword, err := service.repository.LoadKeyword(ctx, params.UserID, params.KeywordID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot load subscription keyword with ID [%s] for user [%s]", params.KeywordID, params.UserID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
+	}
+
+	keyword.Owner = params.Owner
+	keyword.Keyword = strings.ToUpper(params.Keyword)
+	keyword.List = params.List
+	keyword.ConfirmationTemplate = params.ConfirmationTemplate
+	keyword.WelcomeTemplate = params.WelcomeTemplate
+	keyword.Enabled = params.Enabled
+	keyword.UpdatedAt = time.Now().UTC()
+
+	if err = service.repository.UpdateKeyword(ctx, keyword); err != nil {
+		msg := fmt.Sprintf("cannot update subscription keyword with id [%s]", keyword.ID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("subscription keyword updated with id [%s] in the [%T]", keyword.ID, service.repository))
+	return keyword, nil
+}
+
+// DeleteKeyword deletes an entities.SubscriptionKeyword
+func (service *SubscriptionService) DeleteKeyword(ctx context.Context, userID entities.UserID, keywordID uuid.UUID) error {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	if _, err := service.repository.LoadKeyword(ctx, userID, keywordID); err != nil {
+		msg := fmt.Sprintf("cannot load subscription keyword with ID [%s] for user [%s]", keywordID, userID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
+	}
+
+	if err := service.repository.DeleteKeyword(ctx, userID, keywordID); err != nil {
+		msg := fmt.Sprintf("cannot delete subscription keyword with ID [%s] for user [%s]", keywordID, userID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("deleted subscription keyword with ID [%s] for user [%s]", keywordID, userID))
+	return nil
+}
+
+// Index fetches the entities.Subscription of a user
+func (service *SubscriptionService) Index(ctx context.Context, userID entities.UserID, params repositories.SubscriptionIndexParams) ([]*entities.Subscription, error) {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	subscriptions, err := service.repository.Index(ctx, userID, params)
+	if err != nil {
+		msg := fmt.Sprintf("could not fetch subscriptions with params [%+#v]", params)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return subscriptions, nil
+}
+
+// HandleReceived starts a subscription when the message contains an entities.SubscriptionKeyword
+// and confirms the pending subscriptions of the contact when the message is a YES reply.
+func (service *SubscriptionService) HandleReceived(ctx context.Context, source string, payload *events.MessagePhoneReceivedPayload) error {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	content := strings.ToUpper(strings.TrimSpace(payload.Content))
+	if content == "" {
+		return nil
+	}
+
+	for _, keyword := range entities.SubscriptionConfirmationKeywords {
+		if content == keyword {
+			if err := service.confirm(ctx, source, payload); err != nil {
+				msg := fmt.Sprintf("cannot confirm subscriptions of contact [%s] with message [%s]", payload.Contact, payload.MessageID)
+				return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+			}
+			return nil
+		}
+	}
+
+	keyword, err := service.repository.FindKeyword(ctx, payload.UserID, payload.Owner, content)
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		ctxLogger.Info(fmt.Sprintf("message [%s] does not match a subscription keyword of owner [%s]", payload.MessageID, payload.Owner))
+		return nil
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot find subscription keyword for message [%s]", payload.MessageID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if err = service.request(ctx, source, keyword, payload); err != nil {
+		msg := fmt.Sprintf("cannot subscribe contact [%s] to keyword [%s]", payload.Contact, keyword.ID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+// request creates a pending entities.Subscription and sends the confirmation message to the contact
+func (service *SubscriptionService) request(ctx context.Context, source string, keyword *entities.SubscriptionKeyword, payload *events.MessagePhoneReceivedPayload) error {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	subscription, err := service.repository.Load(ctx, payload.UserID, keyword.ID, payload.Contact)
+	if err != nil && stacktrace.GetCode(err) != repositories.ErrCodeNotFound {
+		msg := fmt.Sprintf("cannot load subscription of contact [%s] to keyword [%s]", payload.Contact, keyword.ID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if subscription != nil && subscription.IsConfirmed() {
+		ctxLogger.Info(fmt.Sprintf("contact [%s] is already subscribed to keyword [%s]", payload.Contact, keyword.ID))
+		return nil
+	}
+
+	message, err := service.send(ctx, source, payload, keyword.ConfirmationMessage())
+	if err != nil {
+		msg := fmt.Sprintf("cannot send confirmation message for keyword [%s] to contact [%s]", keyword.ID, payload.Contact)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if subscription != nil {
+		subscription.ConfirmationMessageID = &message.ID
+		subscription.RequestedAt = payload.Timestamp
+		subscription.UpdatedAt = time.Now().UTC()
+		if err = service.repository.Update(ctx, subscription); err != nil {
+			msg := fmt.Sprintf("cannot update subscription [%s] after resending the confirmation message", subscription.ID)
+			return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+		}
+		ctxLogger.Info(fmt.Sprintf("resent confirmation message [%s] for subscription [%s]", message.ID, subscription.ID))
+		return nil
+	}
+
+	subscription = &entities.Subscription{
+		ID:                    uuid.New(),
+		UserID:                payload.UserID,
+		KeywordID:             keyword.ID,
+		Owner:                 payload.Owner,
+		Contact:               payload.Contact,
+		List:                  keyword.List,
+		Status:                entities.SubscriptionStatusPending,
+		ConfirmationMessageID: &message.ID,
+		RequestedAt:           payload.Timestamp,
+		CreatedAt:             time.Now().UTC(),
+		UpdatedAt:             time.Now().UTC(),
+	}
+
+	if err = service.repository.Store(ctx, subscription); err != nil {
+		msg := fmt.Sprintf("cannot store subscription of contact [%s] to keyword [%s]", payload.Contact, keyword.ID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("created pending subscription [%s] for contact [%s] to list [%s]", subscription.ID, payload.Contact, keyword.List))
+	return nil
+}
+
+// confirm adds the contact to the list of its pending entities.Subscription on the owner phone
+func (service *SubscriptionService) confirm(ctx context.Context, source string, payload *events.MessagePhoneReceivedPayload) error {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	subscriptions, err := service.repository.FetchPending(ctx, payload.UserID, payload.Owner, payload.Contact)
+	if err != nil {
+		msg := fmt.Sprintf("cannot fetch pending subscriptions for contact [%s] on owner [%s]", payload.Contact, payload.Owner)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	for _, subscription := range subscriptions {
+		if err = service.contactService.AddTag(ctx, subscription.UserID, subscription.Contact, subscription.List); err != nil {
+			msg := fmt.Sprintf("cannot add contact [%s] to list [%s] for subscription [%s]", subscription.Contact, subscription.List, subscription.ID)
+			return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+		}
+
+		confirmedAt := payload.Timestamp
+		subscription.Status = entities.SubscriptionStatusConfirmed
+		subscription.ConfirmedAt = &confirmedAt
+		subscription.UpdatedAt = time.Now().UTC()
+		if err = service.repository.Update(ctx, subscription); err != nil {
+			msg := fmt.Sprintf("cannot confirm subscription [%s]", subscription.ID)
+			return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+		}
+
+		ctxLogger.Info(fmt.Sprintf("contact [%s] confirmed subscription [%s] to list [%s]", subscription.Contact, subscription.ID, subscription.List))
+
+		keyword, err := service.repository.LoadKeyword(ctx, subscription.UserID, subscription.KeywordID)
+		if err != nil {
+			msg := fmt.Sprintf("cannot load keyword [%s] of subscription [%s]", subscription.KeywordID, subscription.ID)
+			return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+		}
+
+		if strings.TrimSpace(keyword.WelcomeTemplate) == "" {
+			continue
+		}
+
+		if _, err = service.send(ctx, source, payload, keyword.WelcomeMessage()); err != nil {
+			msg := fmt.Sprintf("cannot send welcome message for subscription [%s]", subscription.ID)
+			return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+		}
+	}
+
+	return nil
+}
+
+func (service *SubscriptionService) send(ctx context.Context, source string, payload *events.MessagePhoneReceivedPayload, content string) (*entities.Message, error) {
+	owner, err := phonenumbers.Parse(payload.Owner, phonenumbers.UNKNOWN_REGION)
+	if err != nil {
+		return nil, stacktrace.Propagate(err, fmt.Sprintf("cannot parse owner [%s]", payload.Owner))
+	}
+
+	return service.messageService.SendMessage(ctx, MessageSendParams{
+		Owner:             *owner,
+		Contact:           payload.Contact,
+		Content:           content,
+		Source:            source,
+		SIM:               payload.SIM,
+		UserID:            payload.UserID,
+		RequestReceivedAt: time.Now().UTC(),
+	})
+}
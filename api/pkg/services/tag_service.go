@@ -0,0 +1,298 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/google/uuid"
+	"github.com/palantir/stacktrace"
+)
+
+// ErrCodeTagExists is returned when a user already has an entities.Tag with the same name
+const ErrCodeTagExists = stacktrace.ErrorCode(2024)
+
+// ErrCodeTagParentInvalid is returned when the parent of an entities.Tag does not exist or is the tag itself or one of its descendants
+const ErrCodeTagParentInvalid = stacktrace.ErrorCode(2025)
+
+// TagService manages the entities.Tag of a user and the tags set on contacts, messages and message threads
+type TagService struct {
+	service
+	logger     telemetry.Logger
+	tracer     telemetry.Tracer
+	repository repositories.TagRepository
+}
+
+// NewTagService creates a new TagService
+func NewTagService(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	repository repositories.TagRepository,
+) (s *TagService) {
+	return &TagService{
+		logger:     logger.WithService(fmt.Sprintf("%T", s)),
+		tracer:     tracer,
+		repository: repository,
+	}
+}
+
+// Index fetches all the entities.Tag of a user
+func (service *TagService) Index(ctx context.Context, userID entities.UserID) ([]*entities.Tag, error) {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	tags, err := service.repository.Index(ctx, userID)
+	if err != nil {
+		msg := fmt.Sprintf("could not fetch tags for user [%s]", userID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return tags, nil
+}
+
+// TagStoreParams are parameters for creating an entities.Tag
+type TagStoreParams struct {
+	UserID   entities.UserID
+	Name     string
+	Color    string
+	ParentID *uuid.UUID
+}
+
+// Store a new entities.Tag
+func (service *TagService) Store(ctx context.Context, params *TagStoreParams) (*entities.Tag, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	if err := service.ensureNameIsFree(ctx, params.UserID, params.Name, nil); err != nil {
+		msg := fmt.Sprintf("cannot create tag with name [%s] for user [%s]", params.Name, params.UserID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
+	}
+
+	tag := &entities.Tag{
+		ID:        uuid.New(),
+		UserID:    params.UserID,
+		Name:      params.Name,
+		Color:     params.Color,
+		ParentID:  params.ParentID,
+		CreatedAt: time.Now().UTC(),
+		UpdatedAt: time.Now().UTC(),
+	}
+
+	if err := service.ensureParentIsValid(ctx, tag); err != nil {
+		msg := fmt.Sprintf("cannot create tag with name [%s] for user [%s]", params.Name, params.UserID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
+	}
+
+	if err := service.repository.Store(ctx, tag); err != nil {
+		msg := fmt.Sprintf("cannot save tag with id [%s]", tag.ID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("tag saved with id [%s] in the [%T]", tag.ID, service.repository))
+	return tag, nil
+}
+
+// TagUpdateParams are parameters for updating an entities.Tag
+type TagUpdateParams struct {
+	UserID   entities.UserID
+	TagID    uuid.UUID
+	Name     string
+	Color    string
+	ParentID *uuid.UUID
+}
+
+// Update an entities.Tag, the new name is set on the contacts, messages and message threads which have the old name
+func (service *TagService) Update(ctx context.Context, params *TagUpdateParams) (*entities.Tag, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	tag, err := service.repository.Load(ctx, params.UserID, params.TagID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot load tag with ID [%s] for user [%s]", params.TagID, params.UserID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
+	}
+
+	if err = service.ensureNameIsFree(ctx, params.UserID, params.Name, &tag.ID); err != nil {
+		msg := fmt.Sprintf("cannot rename tag [%s] to [%s] for user [%s]", tag.ID, params.Name, params.UserID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
+	}
+
+	previousName := tag.Name
+	tag.Name = params.Name
+	tag.Color = params.Color
+	tag.ParentID = params.ParentID
+	tag.UpdatedAt = time.Now().UTC()
+
+	if err = service.ensureParentIsValid(ctx, tag); err != nil {
+		msg := fmt.Sprintf("cannot change the parent of tag [%s] for user [%s]", tag.ID, params.UserID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
+	}
+
+	if err = service.repository.Update(ctx, tag); err != nil {
+		msg := fmt.Sprintf("cannot update tag with id [%s]", tag.ID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if previousName != tag.Name {
+		if err = service.repository.Rename(ctx, tag.UserID, previousName, tag.Name); err != nil {
+			msg := fmt.Sprintf("cannot rename tag [%s] from [%s] to [%s] on the items of user [%s]", tag.ID, previousName, tag.Name, tag.UserID)
+			return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+		}
+	}
+
+	ctxLogger.Info(fmt.Sprintf("tag updated with id [%s] in the [%T]", tag.ID, service.repository))
+	return tag, nil
+}
+
+// Delete an entities.Tag and remove it from the contacts, messages and message threads of the user.
+// The children of the tag are moved to the parent of the tag.
+func (service *TagService) Delete(ctx context.Context, userID entities.UserID, tagID uuid.UUID) error {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	tag, err := service.repository.Load(ctx, userID, tagID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot load tag with ID [%s] for user [%s]", tagID, userID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
+	}
+
+	if err = service.repository.Delete(ctx, tag); err != nil {
+		msg := fmt.Sprintf("cannot delete tag with ID [%s] for user [%s]", tagID, userID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if err = service.repository.Remove(ctx, userID, tag.Name); err != nil {
+		msg := fmt.Sprintf("cannot remove tag [%s] from the items of user [%s]", tag.Name, userID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("tag with ID [%s] deleted for user [%s]", tagID, userID))
+	return nil
+}
+
+// Usage returns the number of contacts, messages and message threads of a user with each entities.Tag
+func (service *TagService) Usage(ctx context.Context, userID entities.UserID) ([]*entities.TagUsage, error) {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	tags, err := service.repository.Index(ctx, userID)
+	if err != nil {
+		msg := fmt.Sprintf("could not fetch tags for user [%s]", userID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	counts, err := service.repository.Usage(ctx, userID)
+	if err != nil {
+		msg := fmt.Sprintf("could not count the usage of the tags of user [%s]", userID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	usage := make([]*entities.TagUsage, 0, len(tags))
+	for _, tag := range tags {
+		item := &entities.TagUsage{TagID: tag.ID, Name: tag.Name}
+		if count, ok := counts[tag.Name]; ok {
+			item.Contacts = count.Contacts
+			item.Messages = count.Messages
+			item.MessageThreads = count.MessageThreads
+		}
+		usage = append(usage, item)
+	}
+
+	return usage, nil
+}
+
+// Expand returns the name of a tag and the names of all its descendants which are used to filter contacts, messages and message threads.
+// The name is returned alone when the user has no entities.Tag with the name so that tags which are not managed can still be filtered.
+func (service *TagService) Expand(ctx context.Context, userID entities.UserID, name string) ([]string, error) {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	tags, err := service.repository.Index(ctx, userID)
+	if err != nil {
+		msg := fmt.Sprintf("could not fetch tags for user [%s]", userID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	names := []string{name}
+	for _, tag := range tags {
+		if tag.Name == name {
+			for _, descendant := range service.descendants(tags, tag.ID) {
+				names = append(names, descendant.Name)
+			}
+		}
+	}
+
+	return names, nil
+}
+
+// ensureNameIsFree returns ErrCodeTagExists when another entities.Tag of the user has the name
+func (service *TagService) ensureNameIsFree(ctx context.Context, userID entities.UserID, name string, tagID *uuid.UUID) error {
+	existing, err := service.repository.LoadByName(ctx, userID, name)
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		return nil
+	}
+
+	if err != nil {
+		return stacktrace.Propagate(err, fmt.Sprintf("cannot load tag with name [%s] for user [%s]", name, userID))
+	}
+
+	if tagID != nil && existing.ID == *tagID {
+		return nil
+	}
+
+	return stacktrace.NewErrorWithCode(ErrCodeTagExists, fmt.Sprintf("user [%s] already has tag [%s] with name [%s]", userID, existing.ID, name))
+}
+
+// ensureParentIsValid returns ErrCodeTagParentInvalid when the parent of the entities.Tag does not exist or would create a cycle
+func (service *TagService) ensureParentIsValid(ctx context.Context, tag *entities.Tag) error {
+	if tag.ParentID == nil {
+		return nil
+	}
+
+	tags, err := service.repository.Index(ctx, tag.UserID)
+	if err != nil {
+		return stacktrace.Propagate(err, fmt.Sprintf("cannot fetch tags for user [%s]", tag.UserID))
+	}
+
+	parentExists := false
+	for _, item := range tags {
+		parentExists = parentExists || item.ID == *tag.ParentID
+	}
+	if !parentExists {
+		return stacktrace.NewErrorWithCode(ErrCodeTagParentInvalid, fmt.Sprintf("parent tag [%s] does not exist for user [%s]", tag.ParentID, tag.UserID))
+	}
+
+	if *tag.ParentID == tag.ID {
+		return stacktrace.NewErrorWithCode(ErrCodeTagParentInvalid, fmt.Sprintf("tag [%s] cannot be its own parent", tag.ID))
+	}
+
+	for _, descendant := range service.descendants(tags, tag.ID) {
+		if descendant.ID == *tag.ParentID {
+			return stacktrace.NewErrorWithCode(ErrCodeTagParentInvalid, fmt.Sprintf("tag [%s] cannot be a child of its descendant [%s]", tag.ID, descendant.ID))
+		}
+	}
+
+	return nil
+}
+
+// descendants returns the children of a tag and their children recursively
+func (service *TagService) descendants(tags []*entities.Tag, tagID uuid.UUID) []*entities.Tag {
+	result := make([]*entities.Tag, 0)
+	visited := map[uuid.UUID]bool{tagID: true}
+	queue := []uuid.UUID{tagID}
+	for len(queue) > 0 {
+		parentID := queue[0]
+		queue = queue[1:]
+		for _, tag := range tags {
+			if tag.ParentID != nil && *tag.ParentID == parentID && !visited[tag.ID] {
+				visited[tag.ID] = true
+				result = append(result, tag)
+				queue = append(queue, tag.ID)
+			}
+		}
+	}
+	return result
+}
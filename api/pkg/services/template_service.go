@@ -0,0 +1,316 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/google/uuid"
+	"github.com/palantir/stacktrace"
+)
+
+// ErrCodeTemplateNotApproved is returned when a campaign of a user who requires template approval does not use an approved template
+const ErrCodeTemplateNotApproved = stacktrace.ErrorCode(2020)
+
+// ErrCodeTemplateTransitionInvalid is returned when a template cannot move from its current status to the requested status
+const ErrCodeTemplateTransitionInvalid = stacktrace.ErrorCode(2021)
+
+// TemplateService manages the review of entities.Template by the operators of the instance
+type TemplateService struct {
+	service
+	logger         telemetry.Logger
+	tracer         telemetry.Tracer
+	repository     repositories.TemplateRepository
+	userRepository repositories.UserRepository
+}
+
+// NewTemplateService creates a new TemplateService
+func NewTemplateService(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	repository repositories.TemplateRepository,
+	userRepository repositories.UserRepository,
+) (s *TemplateService) {
+	return &TemplateService{
+		logger:         logger.WithService(fmt.Sprintf("%T", s)),
+		tracer:         tracer,
+		repository:     repository,
+		userRepository: userRepository,
+	}
+}
+
+// Index fetches the entities.Template of a user
+func (service *TemplateService) Index(ctx context.Context, userID entities.UserID, params repositories.IndexParams) ([]*entities.Template, error) {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	templates, err := service.repository.Index(ctx, userID, params)
+	if err != nil {
+		msg := fmt.Sprintf("could not fetch templates with params [%+#v]", params)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return templates, nil
+}
+
+// IndexSubmitted fetches the entities.Template of all users which are waiting for a review
+func (service *TemplateService) IndexSubmitted(ctx context.Context, params repositories.IndexParams) ([]*entities.Template, error) {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	templates, err := service.repository.IndexByStatus(ctx, entities.TemplateStatusSubmitted, params)
+	if err != nil {
+		msg := fmt.Sprintf("could not fetch submitted templates with params [%+#v]", params)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return templates, nil
+}
+
+// Load an entities.Template of a user
+func (service *TemplateService) Load(ctx context.Context, userID entities.UserID, templateID uuid.UUID) (*entities.Template, error) {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	template, err := service.repository.Load(ctx, userID, templateID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot load template with ID [%s] for user [%s]", templateID, userID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
+	}
+
+	return template, nil
+}
+
+// Reviews fetches the audit history of an entities.Template
+func (service *TemplateService) Reviews(ctx context.Context, userID entities.UserID, templateID uuid.UUID) ([]*entities.TemplateReview, error) {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	if _, err := service.repository.Load(ctx, userID, templateID); err != nil {
+		msg := fmt.Sprintf("cannot load template with ID [%s] for user [%s]", templateID, userID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
+	}
+
+	reviews, err := service.repository.IndexReviews(ctx, userID, templateID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot fetch reviews of template with ID [%s] for user [%s]", templateID, userID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return reviews, nil
+}
+
+// TemplateStoreParams are parameters for creating an entities.Template
+type TemplateStoreParams struct {
+	UserID  entities.UserID
+	Name    string
+	Content string
+}
+
+// Store a new entities.Template as a draft
+func (service *TemplateService) Store(ctx context.Context, params *TemplateStoreParams) (*entities.Template, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	template := &entities.Template{
+		ID:        uuid.New(),
+		UserID:    params.UserID,
+		Name:      params.Name,
+		Content:   params.Content,
+		Status:    entities.TemplateStatusDraft,
+		CreatedAt: time.Now().UTC(),
+		UpdatedAt: time.Now().UTC(),
+	}
+
+	if err := service.repository.Store(ctx, template); err != nil {
+		msg := fmt.Sprintf("cannot save template with id [%s]", template.ID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if err := service.storeReview(ctx, template, params.UserID, "", nil); err != nil {
+		msg := fmt.Sprintf("cannot save the creation of template [%s] in the audit history", template.ID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("template saved with id [%s] in the [%T]", template.ID, service.repository))
+	return template, nil
+}
+
+// TemplateUpdateParams are parameters for updating an entities.Template
+type TemplateUpdateParams struct {
+	TemplateStoreParams
+	TemplateID uuid.UUID
+}
+
+// Update an entities.Template, the template goes back to draft when its content changes so that it is reviewed again
+func (service *TemplateService) Update(ctx context.Context, params *TemplateUpdateParams) (*entities.Template, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	template, err := service.repository.Load(ctx, params.UserID, params.TemplateID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot load template with ID [%s] for user [%s]", params.TemplateID, params.UserID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
+	}
+
+	contentChanged := template.Content != params.Content
+	template.Name = params.Name
+	template.Content = params.Content
+	if !contentChanged || template.Status == entities.TemplateStatusDraft {
+		template.UpdatedAt = time.Now().UTC()
+		if err = service.repository.Update(ctx, template); err != nil {
+			msg := fmt.Sprintf("cannot update template with id [%s]", template.ID)
+			return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+		}
+		return template, nil
+	}
+
+	if err = service.transition(ctx, template, params.UserID, entities.TemplateStatusDraft, nil); err != nil {
+		msg := fmt.Sprintf("cannot move template [%s] back to draft after its content changed", template.ID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("template [%s] moved back to draft because its content changed", template.ID))
+	return template, nil
+}
+
+// Submit an entities.Template so that it is reviewed by an operator
+func (service *TemplateService) Submit(ctx context.Context, userID entities.UserID, templateID uuid.UUID) (*entities.Template, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	template, err := service.repository.Load(ctx, userID, templateID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot load template with ID [%s] for user [%s]", templateID, userID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
+	}
+
+	if err = service.transition(ctx, template, userID, entities.TemplateStatusSubmitted, nil); err != nil {
+		msg := fmt.Sprintf("cannot submit template [%s] for user [%s]", templateID, userID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("template [%s] submitted for review by user [%s]", templateID, userID))
+	return template, nil
+}
+
+// TemplateReviewParams are parameters for approving or rejecting an entities.Template
+type TemplateReviewParams struct {
+	ActorID    entities.UserID
+	TemplateID uuid.UUID
+	Status     entities.TemplateStatus
+	Note       *string
+}
+
+// Review approves or rejects a submitted entities.Template, only operators can review templates
+func (service *TemplateService) Review(ctx context.Context, params *TemplateReviewParams) (*entities.Template, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	template, err := service.repository.LoadByID(ctx, params.TemplateID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot load template with ID [%s]", params.TemplateID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
+	}
+
+	if params.Status != entities.TemplateStatusApproved && params.Status != entities.TemplateStatusRejected {
+		msg := fmt.Sprintf("template [%s] cannot be reviewed with status [%s]", template.ID, params.Status)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.NewErrorWithCode(ErrCodeTemplateTransitionInvalid, msg))
+	}
+
+	reviewedAt := time.Now().UTC()
+	template.ReviewedAt = &reviewedAt
+	template.ReviewedBy = &params.ActorID
+	template.ReviewNote = params.Note
+	if err = service.transition(ctx, template, params.ActorID, params.Status, params.Note); err != nil {
+		msg := fmt.Sprintf("cannot review template [%s] with status [%s]", template.ID, params.Status)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("template [%s] of user [%s] was [%s] by operator [%s]", template.ID, template.UserID, template.Status, params.ActorID))
+	return template, nil
+}
+
+// Delete an entities.Template, the campaigns which use it are skipped until they use another template
+func (service *TemplateService) Delete(ctx context.Context, userID entities.UserID, templateID uuid.UUID) error {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	if _, err := service.repository.Load(ctx, userID, templateID); err != nil {
+		msg := fmt.Sprintf("cannot load template with ID [%s] for user [%s]", templateID, userID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
+	}
+
+	if err := service.repository.Delete(ctx, userID, templateID); err != nil {
+		msg := fmt.Sprintf("cannot delete template with ID [%s] for user [%s]", templateID, userID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("template with ID [%s] deleted for user [%s]", templateID, userID))
+	return nil
+}
+
+// SetApprovalRequired sets if the campaigns of a user can only send approved templates
+func (service *TemplateService) SetApprovalRequired(ctx context.Context, userID entities.UserID, required bool) (*entities.User, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	user, err := service.userRepository.Load(ctx, userID)
+	if err != nil {
+		msg := fmt.Sprintf("could not get [%T] with with ID [%s]", user, userID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
+	}
+
+	user.TemplateApprovalRequired = required
+	if err = service.userRepository.Update(ctx, user); err != nil {
+		msg := fmt.Sprintf("could not update template approval of [%T] with ID [%s]", user, user.ID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("template approval required set to [%t] for user [%s]", required, userID))
+	return user, nil
+}
+
+// transition moves an entities.Template to a status and records the change in the audit history
+func (service *TemplateService) transition(ctx context.Context, template *entities.Template, actorID entities.UserID, status entities.TemplateStatus, note *string) error {
+	if !template.CanTransition(status) {
+		msg := fmt.Sprintf("template [%s] cannot move from [%s] to [%s]", template.ID, template.Status, status)
+		return stacktrace.NewErrorWithCode(ErrCodeTemplateTransitionInvalid, msg)
+	}
+
+	from := template.Status
+	template.Status = status
+	template.UpdatedAt = time.Now().UTC()
+	if status == entities.TemplateStatusSubmitted {
+		submittedAt := template.UpdatedAt
+		template.SubmittedAt = &submittedAt
+	}
+
+	if err := service.repository.Update(ctx, template); err != nil {
+		return stacktrace.Propagate(err, fmt.Sprintf("cannot update status of template [%s] to [%s]", template.ID, status))
+	}
+
+	return service.storeReview(ctx, template, actorID, from, note)
+}
+
+func (service *TemplateService) storeReview(ctx context.Context, template *entities.Template, actorID entities.UserID, from entities.TemplateStatus, note *string) error {
+	review := &entities.TemplateReview{
+		ID:         uuid.New(),
+		TemplateID: template.ID,
+		UserID:     template.UserID,
+		ActorID:    actorID,
+		FromStatus: from,
+		ToStatus:   template.Status,
+		Content:    template.Content,
+		Note:       note,
+		CreatedAt:  time.Now().UTC(),
+	}
+
+	if err := service.repository.StoreReview(ctx, review); err != nil {
+		return stacktrace.Propagate(err, fmt.Sprintf("cannot save review of template [%s] from [%s] to [%s]", template.ID, from, template.Status))
+	}
+	return nil
+}
@@ -0,0 +1,192 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/google/uuid"
+	"github.com/palantir/stacktrace"
+)
+
+// TemplateOutcome is what happened to a message which was sent with an entities.Template
+type TemplateOutcome string
+
+const (
+	// TemplateOutcomeSent is recorded when the phone sent the message
+	TemplateOutcomeSent = TemplateOutcome("sent")
+
+	// TemplateOutcomeDelivered is recorded when the message was delivered to the contact
+	TemplateOutcomeDelivered = TemplateOutcome("delivered")
+
+	// TemplateOutcomeFailed is recorded when the message could not be sent
+	TemplateOutcomeFailed = TemplateOutcome("failed")
+)
+
+// TemplateStatService tracks the performance of the messages sent with an entities.Template
+type TemplateStatService struct {
+	service
+	logger             telemetry.Logger
+	tracer             telemetry.Tracer
+	repository         repositories.TemplateStatRepository
+	templateRepository repositories.TemplateRepository
+	messageRepository  repositories.MessageRepository
+}
+
+// NewTemplateStatService creates a new TemplateStatService
+func NewTemplateStatService(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	repository repositories.TemplateStatRepository,
+	templateRepository repositories.TemplateRepository,
+	messageRepository repositories.MessageRepository,
+) (s *TemplateStatService) {
+	return &TemplateStatService{
+		logger:             logger.WithService(fmt.Sprintf("%T", s)),
+		tracer:             tracer,
+		repository:         repository,
+		templateRepository: templateRepository,
+		messageRepository:  messageRepository,
+	}
+}
+
+// TemplateStatsParams are parameters for fetching the entities.TemplateStats of a template
+type TemplateStatsParams struct {
+	UserID     entities.UserID
+	TemplateID uuid.UUID
+	From       time.Time
+	To         time.Time
+}
+
+// Stats fetches the totals and the daily stats of an entities.Template
+func (service *TemplateStatService) Stats(ctx context.Context, params *TemplateStatsParams) (*entities.TemplateStats, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	if _, err := service.templateRepository.Load(ctx, params.UserID, params.TemplateID); err != nil {
+		msg := fmt.Sprintf("cannot load template with ID [%s] for user [%s]", params.TemplateID, params.UserID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
+	}
+
+	days, err := service.repository.Index(ctx, params.UserID, params.TemplateID, params.From, params.To)
+	if err != nil {
+		msg := fmt.Sprintf("cannot fetch stats of template [%s] with params [%+#v]", params.TemplateID, params)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("fetched [%d] daily stats of template [%s] for user [%s]", len(days), params.TemplateID, params.UserID))
+	return entities.NewTemplateStats(params.TemplateID, params.From, params.To, days), nil
+}
+
+// TemplateOutcomeParams are parameters for recording the outcome of a message
+type TemplateOutcomeParams struct {
+	UserID    entities.UserID
+	MessageID uuid.UUID
+	Outcome   TemplateOutcome
+}
+
+// RecordOutcome counts the outcome of a message when it was sent with an entities.Template
+func (service *TemplateStatService) RecordOutcome(ctx context.Context, params *TemplateOutcomeParams) error {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	message, err := service.messageRepository.Load(ctx, params.UserID, params.MessageID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot load message [%s] of user [%s]", params.MessageID, params.UserID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if message.TemplateID == nil {
+		return nil
+	}
+
+	stat := service.stat(message)
+	switch params.Outcome {
+	case TemplateOutcomeSent:
+		stat.Sent = 1
+	case TemplateOutcomeDelivered:
+		stat.Delivered = 1
+	case TemplateOutcomeFailed:
+		stat.Failed = 1
+	}
+
+	if err = service.repository.Increment(ctx, stat); err != nil {
+		msg := fmt.Sprintf("cannot record outcome [%s] of message [%s] for template [%s]", params.Outcome, message.ID, stat.TemplateID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("recorded outcome [%s] of message [%s] for template [%s]", params.Outcome, message.ID, stat.TemplateID))
+	return nil
+}
+
+// TemplateReplyParams are parameters for recording a message which was received from a contact
+type TemplateReplyParams struct {
+	UserID    entities.UserID
+	Owner     string
+	Contact   string
+	Content   string
+	Timestamp time.Time
+}
+
+// RecordReply counts a received message as a reply to the last message sent to the contact when it was sent with an entities.Template.
+// A message is counted as a reply when it is the first message received after the template was sent, opt outs are counted for every received message.
+func (service *TemplateStatService) RecordReply(ctx context.Context, params *TemplateReplyParams) error {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	message, err := service.messageRepository.LoadLastSent(ctx, params.UserID, params.Owner, params.Contact, params.Timestamp)
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		return nil
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot load last message sent from [%s] to [%s] for user [%s]", params.Owner, params.Contact, params.UserID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if message.TemplateID == nil {
+		return nil
+	}
+
+	received, err := service.messageRepository.CountReceived(ctx, params.UserID, params.Owner, params.Contact, *message.SentAt, params.Timestamp)
+	if err != nil {
+		msg := fmt.Sprintf("cannot count messages received from [%s] after message [%s]", params.Contact, message.ID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	stat := service.stat(message)
+	if received == 0 {
+		stat.Replies = 1
+	}
+	if entities.IsOptOutKeyword(params.Content) {
+		stat.OptOuts = 1
+	}
+
+	if stat.Replies == 0 && stat.OptOuts == 0 {
+		return nil
+	}
+
+	if err = service.repository.Increment(ctx, stat); err != nil {
+		msg := fmt.Sprintf("cannot record reply from [%s] to message [%s] for template [%s]", params.Contact, message.ID, stat.TemplateID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("recorded [%d] replies and [%d] opt outs to message [%s] for template [%s]", stat.Replies, stat.OptOuts, message.ID, stat.TemplateID))
+	return nil
+}
+
+// stat creates an empty entities.TemplateStat on the day when the message was requested
+func (service *TemplateStatService) stat(message *entities.Message) *entities.TemplateStat {
+	requestedAt := message.RequestReceivedAt.UTC()
+	return &entities.TemplateStat{
+		ID:         uuid.New(),
+		TemplateID: *message.TemplateID,
+		UserID:     message.UserID,
+		Date:       time.Date(requestedAt.Year(), requestedAt.Month(), requestedAt.Day(), 0, 0, 0, 0, time.UTC),
+		CreatedAt:  time.Now().UTC(),
+		UpdatedAt:  time.Now().UTC(),
+	}
+}
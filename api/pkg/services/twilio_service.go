@@ -0,0 +1,310 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/carlmjohnson/requests"
+	"github.com/google/uuid"
+	"github.com/palantir/stacktrace"
+)
+
+// TwilioAPIVersion is the version of the Twilio API which is emulated by the TwilioService
+const TwilioAPIVersion = "2010-04-01"
+
+// TwilioSignatureHeader is the HTTP header which contains the signature of a Twilio status callback
+const TwilioSignatureHeader = "X-Twilio-Signature"
+
+const (
+	// TwilioMessageStatusSent is the Twilio status of a message which was sent by the phone
+	TwilioMessageStatusSent = "sent"
+
+	// TwilioMessageStatusDelivered is the Twilio status of a message which was delivered to the contact
+	TwilioMessageStatusDelivered = "delivered"
+
+	// TwilioMessageStatusFailed is the Twilio status of a message which could not be sent
+	TwilioMessageStatusFailed = "failed"
+)
+
+// twilioMessageSIDPrefix is the prefix of the SID of a Twilio message resource
+const twilioMessageSIDPrefix = "SM"
+
+// twilioErrorCodeUnknown is the Twilio error code for a message which failed with an unknown error
+const twilioErrorCodeUnknown = 30008
+
+// TwilioMessage is a message formatted like the message resource of the Twilio API
+type TwilioMessage struct {
+	SID                 string            `json:"sid" example:"SM32343a19da5e4b1ba7673298a73703cb"`
+	AccountSID          string            `json:"account_sid" example:"ACXXXXXXXXXXXXXXXXXXXXXXXXXXXXXXXX"`
+	APIVersion          string            `json:"api_version" example:"2010-04-01"`
+	Body                string            `json:"body" example:"This is a sample text message"`
+	DateCreated         string            `json:"date_created" example:"Sun, 05 Jun 2022 14:26:02 +0300"`
+	DateSent            *string           `json:"date_sent" example:"Sun, 05 Jun 2022 14:26:09 +0300"`
+	DateUpdated         string            `json:"date_updated" example:"Sun, 05 Jun 2022 14:26:10 +0300"`
+	Direction           string            `json:"direction" example:"outbound-api"`
+	ErrorCode           *int              `json:"error_code" example:"30008"`
+	ErrorMessage        *string           `json:"error_message" example:"UNKNOWN"`
+	From                string            `json:"from" example:"+18005550199"`
+	To                  string            `json:"to" example:"+18005550100"`
+	MessagingServiceSID *string           `json:"messaging_service_sid"`
+	NumMedia            string            `json:"num_media" example:"0"`
+	NumSegments         string            `json:"num_segments" example:"1"`
+	Price               *string           `json:"price"`
+	PriceUnit           string            `json:"price_unit" example:"USD"`
+	Status              string            `json:"status" example:"queued"`
+	SubresourceURIs     map[string]string `json:"subresource_uris"`
+	URI                 string            `json:"uri" example:"/2010-04-01/Accounts/ACXXXXXXXXXXXXXXXXXXXXXXXXXXXXXXXX/Messages/SM32343a19da5e4b1ba7673298a73703cb.json"`
+}
+
+// TwilioService maps requests made with Twilio SDKs onto the MessageService
+type TwilioService struct {
+	service
+	logger         telemetry.Logger
+	tracer         telemetry.Tracer
+	client         *http.Client
+	messageService *MessageService
+	repository     repositories.TwilioStatusCallbackRepository
+	userRepository repositories.UserRepository
+}
+
+// NewTwilioService creates a new TwilioService
+func NewTwilioService(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	client *http.Client,
+	messageService *MessageService,
+	repository repositories.TwilioStatusCallbackRepository,
+	userRepository repositories.UserRepository,
+) (s *TwilioService) {
+	return &TwilioService{
+		logger:         logger.WithService(fmt.Sprintf("%T", s)),
+		tracer:         tracer,
+		client:         client,
+		messageService: messageService,
+		repository:     repository,
+		userRepository: userRepository,
+	}
+}
+
+// TwilioMessageSendParams are parameters for sending a message with the Twilio compatible API
+type TwilioMessageSendParams struct {
+	AccountSID     string
+	StatusCallback string
+	Message        MessageSendParams
+}
+
+// SendMessage sends a message and registers the URL which is notified when the status of the message changes
+func (service *TwilioService) SendMessage(ctx context.Context, params TwilioMessageSendParams) (*TwilioMessage, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	message, err := service.messageService.SendMessage(ctx, params.Message)
+	if err != nil {
+		msg := fmt.Sprintf("cannot send twilio message for user [%s]", params.Message.UserID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
+	}
+
+	if params.StatusCallback != "" {
+		callback := &entities.TwilioStatusCallback{
+			MessageID:  message.ID,
+			UserID:     message.UserID,
+			AccountSID: params.AccountSID,
+			URL:        params.StatusCallback,
+			CreatedAt:  time.Now().UTC(),
+		}
+		if err = service.repository.Store(ctx, callback); err != nil {
+			msg := fmt.Sprintf("cannot store twilio status callback for message [%s]", message.ID)
+			return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+		}
+		ctxLogger.Info(fmt.Sprintf("stored twilio status callback [%s] for message [%s]", callback.URL, message.ID))
+	}
+
+	return service.toTwilioMessage(params.AccountSID, message), nil
+}
+
+// GetMessage fetches a message of a user as a TwilioMessage
+func (service *TwilioService) GetMessage(ctx context.Context, userID entities.UserID, accountSID string, messageID uuid.UUID) (*TwilioMessage, error) {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	message, err := service.messageService.GetMessage(ctx, userID, messageID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot fetch message with ID [%s] for user [%s]", messageID, userID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
+	}
+
+	return service.toTwilioMessage(accountSID, message), nil
+}
+
+// TwilioMessageStatusParams are parameters for notifying the status callback of a message
+type TwilioMessageStatusParams struct {
+	UserID    entities.UserID
+	MessageID uuid.UUID
+	Status    string
+}
+
+// HandleMessageStatus posts the status of a message to its status callback the way Twilio does
+func (service *TwilioService) HandleMessageStatus(ctx context.Context, params TwilioMessageStatusParams) error {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	callback, err := service.repository.Load(ctx, params.UserID, params.MessageID)
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		return nil
+	}
+	if err != nil {
+		msg := fmt.Sprintf("cannot load twilio status callback for message [%s]", params.MessageID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	message, err := service.messageService.GetMessage(ctx, params.UserID, params.MessageID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot load message [%s] for user [%s]", params.MessageID, params.UserID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	user, err := service.userRepository.Load(ctx, params.UserID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot load user [%s]", params.UserID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	form := url.Values{}
+	form.Set("MessageSid", TwilioMessageSID(message.ID))
+	form.Set("SmsSid", TwilioMessageSID(message.ID))
+	form.Set("AccountSid", callback.AccountSID)
+	form.Set("From", message.Owner)
+	form.Set("To", message.Contact)
+	form.Set("MessageStatus", params.Status)
+	form.Set("SmsStatus", params.Status)
+	form.Set("ApiVersion", TwilioAPIVersion)
+	if params.Status == TwilioMessageStatusFailed {
+		form.Set("ErrorCode", fmt.Sprintf("%d", twilioErrorCodeUnknown))
+	}
+
+	var response string
+	err = requests.URL(callback.URL).
+		Client(service.client).
+		Header(TwilioSignatureHeader, TwilioSignature(user.APIKey, callback.URL, form)).
+		BodyForm(form).
+		ToString(&response).
+		Fetch(ctx)
+	if err != nil {
+		msg := fmt.Sprintf("cannot post status [%s] of message [%s] to twilio status callback [%s] with response [%s]", params.Status, message.ID, callback.URL, response)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("posted status [%s] of message [%s] to twilio status callback [%s]", params.Status, message.ID, callback.URL))
+	return nil
+}
+
+// TwilioMessageSID formats the ID of a message as the SID of a Twilio message resource
+func TwilioMessageSID(messageID uuid.UUID) string {
+	return twilioMessageSIDPrefix + strings.ReplaceAll(messageID.String(), "-", "")
+}
+
+// ParseTwilioMessageSID parses the ID of a message from the SID of a Twilio message resource
+func ParseTwilioMessageSID(sid string) (uuid.UUID, error) {
+	if !strings.HasPrefix(sid, twilioMessageSIDPrefix) {
+		return uuid.Nil, stacktrace.NewError(fmt.Sprintf("the message SID [%s] does not start with [%s]", sid, twilioMessageSIDPrefix))
+	}
+	return uuid.Parse(strings.TrimPrefix(sid, twilioMessageSIDPrefix))
+}
+
+// TwilioSignature computes the X-Twilio-Signature header of a request to the URL with the form parameters
+func TwilioSignature(authToken string, callbackURL string, form url.Values) string {
+	keys := make([]string, 0, len(form))
+	for key := range form {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	payload := callbackURL
+	for _, key := range keys {
+		payload += key + form.Get(key)
+	}
+
+	mac := hmac.New(sha1.New, []byte(authToken))
+	mac.Write([]byte(payload))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// twilioErrorCode is the Twilio error code of a failed message
+func (service *TwilioService) twilioErrorCode(message *entities.Message) *int {
+	if !message.IsFailed() {
+		return nil
+	}
+	code := twilioErrorCodeUnknown
+	return &code
+}
+
+// twilioStatus maps the status of a message to the status of a Twilio message resource
+func (service *TwilioService) twilioStatus(message *entities.Message) string {
+	switch message.Status {
+	case entities.MessageStatusQueuedForFuture:
+		return "scheduled"
+	case entities.MessageStatusScheduled, entities.MessageStatusSending:
+		return "sending"
+	case entities.MessageStatusSent, entities.MessageStatusDeliveryUnknown:
+		return TwilioMessageStatusSent
+	case entities.MessageStatusDelivered:
+		return TwilioMessageStatusDelivered
+	case entities.MessageStatusFailed:
+		return TwilioMessageStatusFailed
+	case entities.MessageStatusExpired:
+		return "undelivered"
+	case entities.MessageStatusReceived:
+		return "received"
+	default:
+		return "queued"
+	}
+}
+
+func (service *TwilioService) toTwilioMessage(accountSID string, message *entities.Message) *TwilioMessage {
+	sid := TwilioMessageSID(message.ID)
+	uri := fmt.Sprintf("/%s/Accounts/%s/Messages/%s", TwilioAPIVersion, accountSID, sid)
+
+	result := &TwilioMessage{
+		SID:             sid,
+		AccountSID:      accountSID,
+		APIVersion:      TwilioAPIVersion,
+		Body:            message.Content,
+		DateCreated:     message.CreatedAt.Format(time.RFC1123Z),
+		DateUpdated:     message.UpdatedAt.Format(time.RFC1123Z),
+		Direction:       "outbound-api",
+		ErrorCode:       service.twilioErrorCode(message),
+		ErrorMessage:    message.FailureReason,
+		From:            message.Owner,
+		To:              message.Contact,
+		NumMedia:        fmt.Sprintf("%d", message.AttachmentCount),
+		NumSegments:     fmt.Sprintf("%d", SMSSegments(message.Content)),
+		PriceUnit:       "USD",
+		Status:          service.twilioStatus(message),
+		SubresourceURIs: map[string]string{"media": uri + "/Media.json"},
+		URI:             uri + ".json",
+	}
+
+	if message.Type == entities.MessageTypeMobileOriginated {
+		result.Direction = "inbound"
+		result.From = message.Contact
+		result.To = message.Owner
+	}
+
+	if message.SentAt != nil {
+		dateSent := message.SentAt.Format(time.RFC1123Z)
+		result.DateSent = &dateSent
+	}
+
+	return result
+}
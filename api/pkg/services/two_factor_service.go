@@ -0,0 +1,357 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/google/uuid"
+	"github.com/palantir/stacktrace"
+	"github.com/pquerna/otp"
+	"github.com/pquerna/otp/totp"
+)
+
+const (
+	// ErrCodeTwoFactorRequired is returned when a sensitive operation is carried out without the code of a user who enabled two factor authentication
+	ErrCodeTwoFactorRequired = stacktrace.ErrorCode(2012)
+
+	// ErrCodeTwoFactorInvalidCode is returned when the TOTP or recovery code does not match
+	ErrCodeTwoFactorInvalidCode = stacktrace.ErrorCode(2013)
+
+	// ErrCodeTwoFactorAlreadyEnabled is returned when enrolling a user who already enabled two factor authentication
+	ErrCodeTwoFactorAlreadyEnabled = stacktrace.ErrorCode(2014)
+
+	// ErrCodeTwoFactorLocked is returned when the codes of a user are rejected because too many invalid codes were used
+	ErrCodeTwoFactorLocked = stacktrace.ErrorCode(2027)
+)
+
+// twoFactorRecoveryCodeCount is the number of recovery codes generated when two factor authentication is enabled
+const twoFactorRecoveryCodeCount = 10
+
+// twoFactorPeriod is the number of seconds in a TOTP time step
+const twoFactorPeriod = 30
+
+// twoFactorSkew is the number of time steps before or after the current time step in which a TOTP code is accepted
+const twoFactorSkew = 1
+
+// twoFactorMaxFailedAttempts is the number of invalid codes after which the codes of a user are rejected for twoFactorLockout
+const twoFactorMaxFailedAttempts = 5
+
+// twoFactorLockout is how long the codes of a user are rejected after twoFactorMaxFailedAttempts invalid codes
+const twoFactorLockout = 15 * time.Minute
+
+// TwoFactorService manages the TOTP second factor which protects sensitive operations of dashboard users
+type TwoFactorService struct {
+	service
+	logger     telemetry.Logger
+	tracer     telemetry.Tracer
+	repository repositories.TwoFactorAuthRepository
+	issuer     string
+}
+
+// NewTwoFactorService creates a new TwoFactorService
+func NewTwoFactorService(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	repository repositories.TwoFactorAuthRepository,
+	issuer string,
+) (s *TwoFactorService) {
+	return &TwoFactorService{
+		logger:     logger.WithService(fmt.Sprintf("%T", s)),
+		tracer:     tracer,
+		repository: repository,
+		issuer:     issuer,
+	}
+}
+
+// Get the entities.TwoFactorAuth of a user
+func (service *TwoFactorService) Get(ctx context.Context, userID entities.UserID) (*entities.TwoFactorAuth, error) {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	auth, err := service.repository.Load(ctx, userID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot load two factor auth for user [%s]", userID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
+	}
+
+	return auth, nil
+}
+
+// TwoFactorEnrollment is the secret which the user adds to an authenticator app
+type TwoFactorEnrollment struct {
+	Secret string
+	URL    string
+}
+
+// Enroll generates a new TOTP secret for a user, the secret is only used after it is confirmed with Enable
+func (service *TwoFactorService) Enroll(ctx context.Context, user entities.AuthUser) (*TwoFactorEnrollment, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	auth, err := service.repository.Load(ctx, user.ID)
+	if err != nil && stacktrace.GetCode(err) != repositories.ErrCodeNotFound {
+		msg := fmt.Sprintf("cannot load two factor auth for user [%s]", user.ID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if auth != nil && auth.IsEnabled() {
+		msg := fmt.Sprintf("two factor auth is already enabled for user [%s]", user.ID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.NewErrorWithCode(ErrCodeTwoFactorAlreadyEnabled, msg))
+	}
+
+	key, err := totp.Generate(totp.GenerateOpts{Issuer: service.issuer, AccountName: user.Email})
+	if err != nil {
+		msg := fmt.Sprintf("cannot generate TOTP secret for user [%s]", user.ID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if auth == nil {
+		auth = &entities.TwoFactorAuth{ID: uuid.New(), UserID: user.ID, CreatedAt: time.Now().UTC()}
+	}
+	auth.Secret = key.Secret()
+	auth.RecoveryCodes = nil
+	auth.UpdatedAt = time.Now().UTC()
+
+	if err = service.repository.Save(ctx, auth); err != nil {
+		msg := fmt.Sprintf("cannot save two factor auth for user [%s]", user.ID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("two factor auth enrollment [%s] started for user [%s]", auth.ID, user.ID))
+	return &TwoFactorEnrollment{Secret: key.Secret(), URL: key.URL()}, nil
+}
+
+// Enable confirms the enrollment of a user with a TOTP code and returns the recovery codes which are only shown once
+func (service *TwoFactorService) Enable(ctx context.Context, userID entities.UserID, code string) ([]string, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	auth, err := service.repository.Load(ctx, userID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot load two factor auth for user [%s]", userID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
+	}
+
+	if auth.IsEnabled() {
+		msg := fmt.Sprintf("two factor auth is already enabled for user [%s]", userID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.NewErrorWithCode(ErrCodeTwoFactorAlreadyEnabled, msg))
+	}
+
+	timeStep, ok := service.validateCode(auth, strings.TrimSpace(code), time.Now().UTC())
+	if !ok {
+		msg := fmt.Sprintf("invalid TOTP code while enabling two factor auth for user [%s]", userID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.NewErrorWithCode(ErrCodeTwoFactorInvalidCode, msg))
+	}
+	auth.LastTimeStep = timeStep
+
+	codes, err := service.setRecoveryCodes(auth)
+	if err != nil {
+		msg := fmt.Sprintf("cannot generate recovery codes for user [%s]", userID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	timestamp := time.Now().UTC()
+	auth.EnabledAt = &timestamp
+	auth.UpdatedAt = timestamp
+
+	if err = service.repository.Save(ctx, auth); err != nil {
+		msg := fmt.Sprintf("cannot enable two factor auth for user [%s]", userID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("two factor auth enabled for user [%s]", userID))
+	return codes, nil
+}
+
+// Disable removes the second factor of a user after checking a TOTP or recovery code
+func (service *TwoFactorService) Disable(ctx context.Context, userID entities.UserID, code string) error {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	if err := service.Check(ctx, userID, code); err != nil {
+		msg := fmt.Sprintf("cannot verify two factor code of user [%s]", userID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
+	}
+
+	if err := service.repository.Delete(ctx, userID); err != nil {
+		msg := fmt.Sprintf("cannot disable two factor auth for user [%s]", userID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("two factor auth disabled for user [%s]", userID))
+	return nil
+}
+
+// RegenerateRecoveryCodes replaces the recovery codes of a user after checking a TOTP or recovery code
+func (service *TwoFactorService) RegenerateRecoveryCodes(ctx context.Context, userID entities.UserID, code string) ([]string, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	if err := service.Check(ctx, userID, code); err != nil {
+		msg := fmt.Sprintf("cannot verify two factor code of user [%s]", userID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
+	}
+
+	auth, err := service.repository.Load(ctx, userID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot load two factor auth for user [%s]", userID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
+	}
+
+	codes, err := service.setRecoveryCodes(auth)
+	if err != nil {
+		msg := fmt.Sprintf("cannot generate recovery codes for user [%s]", userID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	auth.UpdatedAt = time.Now().UTC()
+	if err = service.repository.Save(ctx, auth); err != nil {
+		msg := fmt.Sprintf("cannot save recovery codes for user [%s]", userID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("[%d] recovery codes generated for user [%s]", len(codes), userID))
+	return codes, nil
+}
+
+// Check verifies the TOTP or recovery code of a user before a sensitive operation.
+// It passes when the user has not enabled two factor authentication, a TOTP code and a recovery code can only be used once.
+// The codes of the user are rejected for twoFactorLockout after twoFactorMaxFailedAttempts invalid codes.
+func (service *TwoFactorService) Check(ctx context.Context, userID entities.UserID, code string) error {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	auth, err := service.repository.Load(ctx, userID)
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		return nil
+	}
+	if err != nil {
+		msg := fmt.Sprintf("cannot load two factor auth for user [%s]", userID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if !auth.IsEnabled() {
+		return nil
+	}
+
+	if auth.IsLocked(time.Now().UTC()) {
+		msg := fmt.Sprintf("two factor auth of user [%s] is locked until [%s] because too many invalid codes were used", userID, auth.LockedUntil)
+		return service.tracer.WrapErrorSpan(span, stacktrace.NewErrorWithCode(ErrCodeTwoFactorLocked, msg))
+	}
+
+	code = strings.TrimSpace(code)
+	if code == "" {
+		msg := fmt.Sprintf("user [%s] has enabled two factor auth but no code was provided", userID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.NewErrorWithCode(ErrCodeTwoFactorRequired, msg))
+	}
+
+	if timeStep, ok := service.validateCode(auth, code, time.Now().UTC()); ok {
+		claimed, err := service.repository.ClaimTimeStep(ctx, auth, timeStep)
+		if err != nil {
+			msg := fmt.Sprintf("cannot store TOTP time step [%d] of user [%s]", timeStep, userID)
+			return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+		}
+		if claimed {
+			return nil
+		}
+
+		msg := fmt.Sprintf("TOTP code of time step [%d] for user [%s] has already been used", timeStep, userID)
+		return service.tracer.WrapErrorSpan(span, service.rejectCode(ctx, auth, msg))
+	}
+
+	hash := service.hashRecoveryCode(code)
+	for _, recoveryCode := range auth.RecoveryCodes {
+		if recoveryCode != hash {
+			continue
+		}
+
+		consumed, err := service.repository.ConsumeRecoveryCode(ctx, auth, hash)
+		if err != nil {
+			msg := fmt.Sprintf("cannot remove used recovery code of user [%s]", userID)
+			return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+		}
+
+		if !consumed {
+			msg := fmt.Sprintf("recovery code of user [%s] has already been used", userID)
+			return service.tracer.WrapErrorSpan(span, service.rejectCode(ctx, auth, msg))
+		}
+
+		ctxLogger.Info(fmt.Sprintf("user [%s] used a recovery code, [%d] recovery codes are left", userID, len(auth.RecoveryCodes)-1))
+		return nil
+	}
+
+	msg := fmt.Sprintf("invalid two factor code for user [%s]", userID)
+	return service.tracer.WrapErrorSpan(span, service.rejectCode(ctx, auth, msg))
+}
+
+// rejectCode counts an invalid code of a user and returns ErrCodeTwoFactorInvalidCode
+func (service *TwoFactorService) rejectCode(ctx context.Context, auth *entities.TwoFactorAuth, reason string) error {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	lockedUntil := time.Now().UTC().Add(twoFactorLockout)
+	if err := service.repository.RecordFailedAttempt(ctx, auth, twoFactorMaxFailedAttempts, lockedUntil); err != nil {
+		msg := fmt.Sprintf("cannot record failed two factor attempt of user [%s]", auth.UserID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return stacktrace.NewErrorWithCode(ErrCodeTwoFactorInvalidCode, reason)
+}
+
+// validateCode returns the time step of a TOTP code which is within the skew of the timestamp.
+// A code is rejected when its time step is not after the last time step which was accepted for the user.
+func (service *TwoFactorService) validateCode(auth *entities.TwoFactorAuth, code string, timestamp time.Time) (int64, bool) {
+	current := timestamp.Unix() / twoFactorPeriod
+	for timeStep := current - twoFactorSkew; timeStep <= current+twoFactorSkew; timeStep++ {
+		if timeStep <= auth.LastTimeStep {
+			continue
+		}
+
+		valid, err := totp.ValidateCustom(code, auth.Secret, time.Unix(timeStep*twoFactorPeriod, 0).UTC(), totp.ValidateOpts{
+			Period:    twoFactorPeriod,
+			Skew:      0,
+			Digits:    otp.DigitsSix,
+			Algorithm: otp.AlgorithmSHA1,
+		})
+		if err == nil && valid {
+			return timeStep, true
+		}
+	}
+	return 0, false
+}
+
+// setRecoveryCodes replaces the recovery codes of an entities.TwoFactorAuth and returns the plain codes
+func (service *TwoFactorService) setRecoveryCodes(auth *entities.TwoFactorAuth) ([]string, error) {
+	codes := make([]string, 0, twoFactorRecoveryCodeCount)
+	hashes := make([]string, 0, twoFactorRecoveryCodeCount)
+	for i := 0; i < twoFactorRecoveryCodeCount; i++ {
+		b := make([]byte, 8)
+		if _, err := rand.Read(b); err != nil {
+			return nil, stacktrace.Propagate(err, fmt.Sprintf("cannot generate [%d] random bytes", len(b)))
+		}
+
+		code := strings.ToLower(base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(b))
+		code = code[:5] + "-" + code[5:10]
+
+		codes = append(codes, code)
+		hashes = append(hashes, service.hashRecoveryCode(code))
+	}
+
+	auth.RecoveryCodes = hashes
+	return codes, nil
+}
+
+func (service *TwoFactorService) hashRecoveryCode(code string) string {
+	normalized := strings.ToLower(strings.ReplaceAll(strings.TrimSpace(code), "-", ""))
+	hash := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(hash[:])
+}
@@ -0,0 +1,303 @@
+package services
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/events"
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/google/uuid"
+	"github.com/palantir/stacktrace"
+)
+
+// ErrCodeUserSubscriptionActive is returned when a user with an active subscription requests the deletion of their account
+const ErrCodeUserSubscriptionActive = stacktrace.ErrorCode(2023)
+
+// userDataExportPageSize is the number of rows which are fetched at once while exporting the data of a user
+const userDataExportPageSize = 500
+
+// UserDataService exports the data of an entities.User and erases it when the user deletes their account
+type UserDataService struct {
+	service
+	logger            telemetry.Logger
+	tracer            telemetry.Tracer
+	repository        repositories.UserDataRepository
+	userRepository    repositories.UserRepository
+	messageRepository repositories.MessageRepository
+	archiveRepository repositories.ArchivedMessageRepository
+	contactRepository repositories.ContactRepository
+	webhookRepository repositories.WebhookRepository
+	eventRepository   repositories.EventRepository
+	eventDispatcher   *EventDispatcher
+}
+
+// NewUserDataService creates a new UserDataService
+func NewUserDataService(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	repository repositories.UserDataRepository,
+	userRepository repositories.UserRepository,
+	messageRepository repositories.MessageRepository,
+	archiveRepository repositories.ArchivedMessageRepository,
+	contactRepository repositories.ContactRepository,
+	webhookRepository repositories.WebhookRepository,
+	eventRepository repositories.EventRepository,
+	eventDispatcher *EventDispatcher,
+) (s *UserDataService) {
+	return &UserDataService{
+		logger:            logger.WithService(fmt.Sprintf("%T", s)),
+		tracer:            tracer,
+		repository:        repository,
+		userRepository:    userRepository,
+		messageRepository: messageRepository,
+		archiveRepository: archiveRepository,
+		contactRepository: contactRepository,
+		webhookRepository: webhookRepository,
+		eventRepository:   eventRepository,
+		eventDispatcher:   eventDispatcher,
+	}
+}
+
+// Export writes a zip archive with the data of a user to the writer.
+// The user is stored in user.json and every other file contains one JSON object per line so that large exports can be processed as a stream.
+func (service *UserDataService) Export(ctx context.Context, userID entities.UserID, writer io.Writer) error {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	archive := zip.NewWriter(writer)
+	files := []struct {
+		name   string
+		export func(ctx context.Context, userID entities.UserID, encoder *json.Encoder) (int, error)
+	}{
+		{"user.json", service.exportUser},
+		{"messages.jsonl", service.exportMessages},
+		{"archived_messages.jsonl", service.exportArchivedMessages},
+		{"contacts.jsonl", service.exportContacts},
+		{"webhooks.jsonl", service.exportWebhooks},
+		{"events.jsonl", service.exportEvents},
+	}
+
+	for _, file := range files {
+		content, err := archive.Create(file.name)
+		if err != nil {
+			msg := fmt.Sprintf("cannot create file [%s] in the export of user [%s]", file.name, userID)
+			return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+		}
+
+		count, err := file.export(ctx, userID, json.NewEncoder(content))
+		if err != nil {
+			msg := fmt.Sprintf("cannot export [%s] of user [%s]", file.name, userID)
+			return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+		}
+
+		ctxLogger.Info(fmt.Sprintf("exported [%d] rows into [%s] for user [%s]", count, file.name, userID))
+	}
+
+	if err := archive.Close(); err != nil {
+		msg := fmt.Sprintf("cannot close the export of user [%s]", userID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+func (service *UserDataService) exportUser(ctx context.Context, userID entities.UserID, encoder *json.Encoder) (int, error) {
+	user, err := service.userRepository.Load(ctx, userID)
+	if err != nil {
+		return 0, stacktrace.Propagate(err, fmt.Sprintf("cannot load user [%s]", userID))
+	}
+	return 1, encoder.Encode(user)
+}
+
+func (service *UserDataService) exportMessages(ctx context.Context, userID entities.UserID, encoder *json.Encoder) (int, error) {
+	count := 0
+	params := repositories.MessageIndexParams{Limit: userDataExportPageSize}
+	for {
+		messages, err := service.messageRepository.IndexPage(ctx, userID, params)
+		if err != nil {
+			return count, stacktrace.Propagate(err, fmt.Sprintf("cannot fetch messages of user [%s] with params [%+#v]", userID, params))
+		}
+
+		for _, message := range messages {
+			if err = encoder.Encode(message); err != nil {
+				return count, stacktrace.Propagate(err, fmt.Sprintf("cannot encode message [%s]", message.ID))
+			}
+		}
+
+		count += len(messages)
+		if len(messages) < params.Limit {
+			return count, nil
+		}
+
+		last := messages[len(messages)-1]
+		params.Cursor = &repositories.EventCursor{Time: last.OrderTimestamp, ID: last.ID}
+	}
+}
+
+func (service *UserDataService) exportArchivedMessages(ctx context.Context, userID entities.UserID, encoder *json.Encoder) (int, error) {
+	count := 0
+	params := repositories.ArchivedMessageIndexParams{Limit: userDataExportPageSize}
+	for {
+		messages, err := service.archiveRepository.Index(ctx, userID, params)
+		if err != nil {
+			return count, stacktrace.Propagate(err, fmt.Sprintf("cannot fetch archived messages of user [%s] with params [%+#v]", userID, params))
+		}
+
+		for _, message := range messages {
+			if err = encoder.Encode(message); err != nil {
+				return count, stacktrace.Propagate(err, fmt.Sprintf("cannot encode archived message [%s]", message.ID))
+			}
+		}
+
+		count += len(messages)
+		if len(messages) < params.Limit {
+			return count, nil
+		}
+
+		last := messages[len(messages)-1]
+		params.Cursor = &repositories.EventCursor{Time: last.OrderTimestamp, ID: last.ID}
+	}
+}
+
+func (service *UserDataService) exportContacts(ctx context.Context, userID entities.UserID, encoder *json.Encoder) (int, error) {
+	count := 0
+	params := repositories.ContactIndexParams{IndexParams: repositories.IndexParams{Limit: userDataExportPageSize}}
+	for {
+		contacts, err := service.contactRepository.Index(ctx, userID, params)
+		if err != nil {
+			return count, stacktrace.Propagate(err, fmt.Sprintf("cannot fetch contacts of user [%s] with params [%+#v]", userID, params))
+		}
+
+		for _, contact := range contacts {
+			if err = encoder.Encode(contact); err != nil {
+				return count, stacktrace.Propagate(err, fmt.Sprintf("cannot encode contact [%s]", contact.ID))
+			}
+		}
+
+		count += len(contacts)
+		if len(contacts) < params.Limit {
+			return count, nil
+		}
+		params.Skip += len(contacts)
+	}
+}
+
+func (service *UserDataService) exportWebhooks(ctx context.Context, userID entities.UserID, encoder *json.Encoder) (int, error) {
+	count := 0
+	params := repositories.IndexParams{Limit: userDataExportPageSize}
+	for {
+		webhooks, err := service.webhookRepository.Index(ctx, userID, params)
+		if err != nil {
+			return count, stacktrace.Propagate(err, fmt.Sprintf("cannot fetch webhooks of user [%s] with params [%+#v]", userID, params))
+		}
+
+		for _, webhook := range webhooks {
+			if err = encoder.Encode(webhook); err != nil {
+				return count, stacktrace.Propagate(err, fmt.Sprintf("cannot encode webhook [%s]", webhook.ID))
+			}
+		}
+
+		count += len(webhooks)
+		if len(webhooks) < params.Limit {
+			return count, nil
+		}
+		params.Skip += len(webhooks)
+	}
+}
+
+func (service *UserDataService) exportEvents(ctx context.Context, userID entities.UserID, encoder *json.Encoder) (int, error) {
+	count := 0
+	params := repositories.EventIndexParams{Limit: userDataExportPageSize}
+	for {
+		page, err := service.eventRepository.Index(ctx, userID, params)
+		if err != nil {
+			return count, stacktrace.Propagate(err, fmt.Sprintf("cannot fetch events of user [%s] with params [%+#v]", userID, params))
+		}
+
+		for _, event := range page {
+			if err = encoder.Encode(event); err != nil {
+				return count, stacktrace.Propagate(err, fmt.Sprintf("cannot encode event [%s]", event.ID()))
+			}
+		}
+
+		count += len(page)
+		if len(page) < params.Limit {
+			return count, nil
+		}
+
+		last := page[len(page)-1]
+		params.Cursor = &repositories.EventCursor{Time: last.Time(), ID: uuid.MustParse(last.ID())}
+	}
+}
+
+// RequestErasure dispatches the events.UserAccountDeletionRequested event so that the data of the user is deleted in the background.
+// Users with an active subscription must cancel it first so that they are not charged after their account is deleted.
+func (service *UserDataService) RequestErasure(ctx context.Context, userID entities.UserID, source string) error {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	user, err := service.userRepository.Load(ctx, userID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot load user [%s]", userID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
+	}
+
+	if user.SubscriptionRenewsAt != nil {
+		msg := fmt.Sprintf("user [%s] cannot be deleted because the [%s] subscription renews at [%s]", userID, user.SubscriptionName, user.SubscriptionRenewsAt)
+		return service.tracer.WrapErrorSpan(span, stacktrace.NewErrorWithCode(ErrCodeUserSubscriptionActive, msg))
+	}
+
+	event, err := service.createEvent(events.UserAccountDeletionRequested, source, &events.UserAccountDeletionRequestedPayload{
+		UserID:      userID,
+		RequestedAt: time.Now().UTC(),
+	})
+	if err != nil {
+		msg := fmt.Sprintf("cannot create [%s] event for user [%s]", events.UserAccountDeletionRequested, userID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if err = service.eventDispatcher.Dispatch(ctx, event); err != nil {
+		msg := fmt.Sprintf("cannot dispatch event type [%s] and id [%s]", event.Type(), event.ID())
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("user [%s] requested the deletion of their account with event [%s]", userID, event.ID()))
+	return nil
+}
+
+// Erase deletes all the data of a user and dispatches the events.UserAccountDeleted event when it is done
+func (service *UserDataService) Erase(ctx context.Context, source string, payload *events.UserAccountDeletionRequestedPayload) error {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	count, err := service.repository.Erase(ctx, payload.UserID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot erase the data of user [%s] after deleting [%d] rows", payload.UserID, count)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	event, err := service.createEvent(events.UserAccountDeleted, source, &events.UserAccountDeletedPayload{
+		UserID:      payload.UserID,
+		DeletedRows: count,
+		RequestedAt: payload.RequestedAt,
+		DeletedAt:   time.Now().UTC(),
+	})
+	if err != nil {
+		msg := fmt.Sprintf("cannot create [%s] event for user [%s]", events.UserAccountDeleted, payload.UserID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if err = service.eventDispatcher.Dispatch(ctx, event); err != nil {
+		msg := fmt.Sprintf("cannot dispatch event type [%s] and id [%s]", event.Type(), event.ID())
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("deleted [%d] rows of user [%s]", count, payload.UserID))
+	return nil
+}
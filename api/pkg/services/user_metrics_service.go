@@ -0,0 +1,207 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/palantir/stacktrace"
+)
+
+// UserMetricsContentType is the content type of the OpenMetrics text exposition format
+const UserMetricsContentType = "application/openmetrics-text; version=1.0.0; charset=utf-8"
+
+// userMetricsPhonesLimit is the maximum number of phones which are exported
+const userMetricsPhonesLimit = 100
+
+// UserMetricsService exports the delivery metrics of a single user in the OpenMetrics format so that they can be scraped by Prometheus
+type UserMetricsService struct {
+	service
+	logger              telemetry.Logger
+	tracer              telemetry.Tracer
+	analyticsRepository repositories.AnalyticsRepository
+	phoneRepository     repositories.PhoneRepository
+	heartbeatRepository repositories.HeartbeatRepository
+	userRepository      repositories.UserRepository
+}
+
+// NewUserMetricsService creates a new UserMetricsService
+func NewUserMetricsService(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	analyticsRepository repositories.AnalyticsRepository,
+	phoneRepository repositories.PhoneRepository,
+	heartbeatRepository repositories.HeartbeatRepository,
+	userRepository repositories.UserRepository,
+) (s *UserMetricsService) {
+	return &UserMetricsService{
+		logger:              logger.WithService(fmt.Sprintf("%T", s)),
+		tracer:              tracer,
+		analyticsRepository: analyticsRepository,
+		phoneRepository:     phoneRepository,
+		heartbeatRepository: heartbeatRepository,
+		userRepository:      userRepository,
+	}
+}
+
+// ScrapeURL returns the URL which exports the metrics of a user without an API key header.
+// The signature is derived from the API key of the user so rotating the API key invalidates the URL.
+func (service *UserMetricsService) ScrapeURL(ctx context.Context, baseURL string, userID entities.UserID) (string, error) {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	user, err := service.userRepository.Load(ctx, userID)
+	if err != nil {
+		msg := fmt.Sprintf("could not get [%T] with ID [%s]", user, userID)
+		return "", service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return fmt.Sprintf("%s/v1/metrics/%s?signature=%s", strings.TrimRight(baseURL, "/"), user.ID, service.signature(user)), nil
+}
+
+// Verify checks the signature of a scrape URL which was created with ScrapeURL
+func (service *UserMetricsService) Verify(ctx context.Context, userID entities.UserID, signature string) (bool, error) {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	user, err := service.userRepository.Load(ctx, userID)
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		return false, nil
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("could not get [%T] with ID [%s]", user, userID)
+		return false, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return hmac.Equal([]byte(service.signature(user)), []byte(signature)), nil
+}
+
+// Render exports the metrics of a user in the OpenMetrics text format
+func (service *UserMetricsService) Render(ctx context.Context, userID entities.UserID) (string, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	totals, err := service.analyticsRepository.MessageTotals(ctx, userID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot count messages of user [%s]", userID)
+		return "", service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	queue, err := service.analyticsRepository.QueueDepthByOwner(ctx, userID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot count queued messages of user [%s]", userID)
+		return "", service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	phones, err := service.phoneRepository.Index(ctx, userID, repositories.IndexParams{Limit: userMetricsPhonesLimit})
+	if err != nil {
+		msg := fmt.Sprintf("cannot fetch phones of user [%s]", userID)
+		return "", service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	builder := new(strings.Builder)
+	service.writeMessageTotals(builder, totals)
+	service.writeQueueDepth(builder, queue)
+	if err = service.writePhones(ctx, builder, userID, *phones); err != nil {
+		msg := fmt.Sprintf("cannot export phone metrics of user [%s]", userID)
+		return "", service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+	builder.WriteString("# EOF\n")
+
+	ctxLogger.Info(fmt.Sprintf("exported metrics of [%d] owners for user [%s]", len(totals), userID))
+	return builder.String(), nil
+}
+
+func (service *UserMetricsService) writeMessageTotals(builder *strings.Builder, totals []*entities.MessageTotals) {
+	builder.WriteString("# TYPE httpsms_messages counter\n")
+	builder.WriteString("# HELP httpsms_messages Number of messages by owner phone number and outcome.\n")
+	for _, item := range totals {
+		for _, sample := range []struct {
+			status string
+			value  uint64
+		}{
+			{"sent", item.Sent},
+			{"delivered", item.Delivered},
+			{"failed", item.Failed},
+			{"expired", item.Expired},
+			{"received", item.Received},
+		} {
+			builder.WriteString(fmt.Sprintf("httpsms_messages_total{owner=\"%s\",status=\"%s\"} %d\n", service.escapeLabel(item.Owner), sample.status, sample.value))
+		}
+	}
+
+	builder.WriteString("# TYPE httpsms_message_send_duration_seconds histogram\n")
+	builder.WriteString("# UNIT httpsms_message_send_duration_seconds seconds\n")
+	builder.WriteString("# HELP httpsms_message_send_duration_seconds Duration from when a message is requested until it is sent by the phone.\n")
+	for _, item := range totals {
+		owner := service.escapeLabel(item.Owner)
+		for i, bucket := range entities.MessageSendDurationBuckets {
+			builder.WriteString(fmt.Sprintf("httpsms_message_send_duration_seconds_bucket{owner=\"%s\",le=\"%s\"} %d\n", owner, strconv.FormatFloat(bucket, 'f', -1, 64), item.SendDurationBuckets[i]))
+		}
+		builder.WriteString(fmt.Sprintf("httpsms_message_send_duration_seconds_bucket{owner=\"%s\",le=\"+Inf\"} %d\n", owner, item.SendDurationCount))
+		builder.WriteString(fmt.Sprintf("httpsms_message_send_duration_seconds_count{owner=\"%s\"} %d\n", owner, item.SendDurationCount))
+		builder.WriteString(fmt.Sprintf("httpsms_message_send_duration_seconds_sum{owner=\"%s\"} %s\n", owner, strconv.FormatFloat(item.SendDurationSeconds, 'f', -1, 64)))
+	}
+}
+
+func (service *UserMetricsService) writeQueueDepth(builder *strings.Builder, queue []*entities.MessageQueueCount) {
+	builder.WriteString("# TYPE httpsms_messages_queued gauge\n")
+	builder.WriteString("# HELP httpsms_messages_queued Number of messages which are waiting to be sent by owner phone number and status.\n")
+	for _, item := range queue {
+		builder.WriteString(fmt.Sprintf("httpsms_messages_queued{owner=\"%s\",status=\"%s\"} %d\n", service.escapeLabel(item.Owner), service.escapeLabel(string(item.Status)), item.Count))
+	}
+}
+
+func (service *UserMetricsService) writePhones(ctx context.Context, builder *strings.Builder, userID entities.UserID, phones []entities.Phone) error {
+	up := new(strings.Builder)
+	heartbeats := new(strings.Builder)
+	now := time.Now().UTC()
+
+	for _, phone := range phones {
+		owner := service.escapeLabel(phone.PhoneNumber)
+
+		heartbeat, err := service.heartbeatRepository.Last(ctx, userID, phone.PhoneNumber)
+		if err != nil && stacktrace.GetCode(err) != repositories.ErrCodeNotFound {
+			return stacktrace.Propagate(err, fmt.Sprintf("cannot load last heartbeat of phone [%s]", phone.ID))
+		}
+
+		value := 0
+		if err == nil {
+			if now.Sub(heartbeat.Timestamp) <= heartbeatCheckInterval {
+				value = 1
+			}
+			heartbeats.WriteString(fmt.Sprintf("httpsms_phone_last_heartbeat_timestamp_seconds{owner=\"%s\"} %d\n", owner, heartbeat.Timestamp.Unix()))
+		}
+		up.WriteString(fmt.Sprintf("httpsms_phone_up{owner=\"%s\"} %d\n", owner, value))
+	}
+
+	builder.WriteString("# TYPE httpsms_phone_up gauge\n")
+	builder.WriteString("# HELP httpsms_phone_up Whether the phone sent a heartbeat recently.\n")
+	builder.WriteString(up.String())
+
+	builder.WriteString("# TYPE httpsms_phone_last_heartbeat_timestamp_seconds gauge\n")
+	builder.WriteString("# UNIT httpsms_phone_last_heartbeat_timestamp_seconds seconds\n")
+	builder.WriteString("# HELP httpsms_phone_last_heartbeat_timestamp_seconds Unix time of the last heartbeat of the phone.\n")
+	builder.WriteString(heartbeats.String())
+	return nil
+}
+
+func (service *UserMetricsService) signature(user *entities.User) string {
+	mac := hmac.New(sha256.New, []byte(user.APIKey))
+	mac.Write([]byte("metrics:" + string(user.ID)))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// escapeLabel escapes a label value as required by the OpenMetrics text format
+func (service *UserMetricsService) escapeLabel(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value)
+}
@@ -3,12 +3,12 @@ package services
 import (
 	"context"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/NdoleStudio/httpsms/pkg/events"
 
 	"github.com/NdoleStudio/httpsms/pkg/emails"
-	lemonsqueezy "github.com/NdoleStudio/lemonsqueezy-go"
 
 	"github.com/NdoleStudio/httpsms/pkg/repositories"
 	"github.com/google/uuid"
@@ -18,16 +18,24 @@ import (
 	"github.com/NdoleStudio/httpsms/pkg/telemetry"
 )
 
+// ErrCodeUserRegionLocked is thrown when the region of a user is changed after it was chosen
+const ErrCodeUserRegionLocked = stacktrace.ErrorCode(2002)
+
+// ErrCodeUserSuspended is thrown when a suspended user tries to send a message or a webhook event
+const ErrCodeUserSuspended = stacktrace.ErrorCode(2007)
+
 // UserService is handles user requests
 type UserService struct {
 	service
-	logger             telemetry.Logger
-	tracer             telemetry.Tracer
-	emailFactory       emails.UserEmailFactory
-	mailer             emails.Mailer
-	repository         repositories.UserRepository
-	marketingService   *MarketingService
-	lemonsqueezyClient *lemonsqueezy.Client
+	logger           telemetry.Logger
+	tracer           telemetry.Tracer
+	emailFactory     emails.UserEmailFactory
+	mailer           emails.Mailer
+	repository       repositories.UserRepository
+	digestRepository repositories.NotificationDigestRepository
+	dispatcher       *EventDispatcher
+	marketingService *MarketingService
+	billingProvider  BillingProvider
 }
 
 // NewUserService creates a new UserService
@@ -35,19 +43,23 @@ func NewUserService(
 	logger telemetry.Logger,
 	tracer telemetry.Tracer,
 	repository repositories.UserRepository,
+	digestRepository repositories.NotificationDigestRepository,
+	dispatcher *EventDispatcher,
 	mailer emails.Mailer,
 	emailFactory emails.UserEmailFactory,
 	marketingService *MarketingService,
-	lemonsqueezyClient *lemonsqueezy.Client,
+	billingProvider BillingProvider,
 ) (s *UserService) {
 	return &UserService{
-		logger:             logger.WithService(fmt.Sprintf("%T", s)),
-		tracer:             tracer,
-		mailer:             mailer,
-		marketingService:   marketingService,
-		emailFactory:       emailFactory,
-		repository:         repository,
-		lemonsqueezyClient: lemonsqueezyClient,
+		logger:           logger.WithService(fmt.Sprintf("%T", s)),
+		tracer:           tracer,
+		mailer:           mailer,
+		marketingService: marketingService,
+		emailFactory:     emailFactory,
+		repository:       repository,
+		digestRepository: digestRepository,
+		dispatcher:       dispatcher,
+		billingProvider:  billingProvider,
 	}
 }
 
@@ -69,10 +81,35 @@ func (service *UserService) Get(ctx context.Context, authUser entities.AuthUser)
 	return user, nil
 }
 
+// RotateAPIKey replaces the API key of a user, requests with the previous API key are rejected afterwards
+func (service *UserService) RotateAPIKey(ctx context.Context, userID entities.UserID) (*entities.User, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	user, err := service.repository.RotateAPIKey(ctx, userID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot rotate api key of user [%s]", userID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("api key of user [%s] rotated successfully", userID))
+	return user, nil
+}
+
 // UserUpdateParams are parameters for updating an entities.User
 type UserUpdateParams struct {
-	Timezone      *time.Location
-	ActivePhoneID uuid.UUID
+	Timezone                     *time.Location
+	ActivePhoneID                uuid.UUID
+	NotificationDigestMinutes    *uint
+	MessageExpirationTimeout     *uint
+	SendRateLimit                *uint
+	ContactSendRateLimit         *uint
+	OptOutKeywords               *[]string
+	OptInKeywords                *[]string
+	MessageThreadAutoArchiveDays *uint
+	MessageRetentionDays         *uint
+	MessageAnonymizeDays         *uint
+	Region                       *string
 }
 
 // Update an entities.User
@@ -94,6 +131,41 @@ func (service *UserService) Update(ctx context.Context, authUser entities.AuthUs
 
 	user.Timezone = params.Timezone.String()
 	user.ActivePhoneID = &params.ActivePhoneID
+	if params.NotificationDigestMinutes != nil {
+		user.NotificationDigestMinutes = *params.NotificationDigestMinutes
+	}
+	if params.MessageExpirationTimeout != nil {
+		user.MessageExpirationTimeout = *params.MessageExpirationTimeout
+	}
+	if params.SendRateLimit != nil {
+		user.SendRateLimit = *params.SendRateLimit
+	}
+	if params.ContactSendRateLimit != nil {
+		user.ContactSendRateLimit = *params.ContactSendRateLimit
+	}
+	if params.OptOutKeywords != nil {
+		user.OptOutKeywords = *params.OptOutKeywords
+	}
+	if params.OptInKeywords != nil {
+		user.OptInKeywords = *params.OptInKeywords
+	}
+	if params.MessageThreadAutoArchiveDays != nil {
+		user.MessageThreadAutoArchiveDays = *params.MessageThreadAutoArchiveDays
+	}
+	if params.MessageRetentionDays != nil {
+		user.MessageRetentionDays = *params.MessageRetentionDays
+	}
+	if params.MessageAnonymizeDays != nil {
+		user.MessageAnonymizeDays = *params.MessageAnonymizeDays
+	}
+
+	if params.Region != nil && *params.Region != user.Region {
+		if user.Region != "" {
+			msg := fmt.Sprintf("cannot change region of user [%s] from [%s] to [%s]", user.ID, user.Region, *params.Region)
+			return nil, service.tracer.WrapErrorSpan(span, stacktrace.NewErrorWithCode(ErrCodeUserRegionLocked, msg))
+		}
+		user.Region = *params.Region
+	}
 
 	if err = service.repository.Update(ctx, user); err != nil {
 		msg := fmt.Sprintf("cannot save user with id [%s]", user.ID)
@@ -106,6 +178,7 @@ func (service *UserService) Update(ctx context.Context, authUser entities.AuthUs
 
 // UserSendPhoneDeadEmailParams are parameters for notifying a user when a phone is dead
 type UserSendPhoneDeadEmailParams struct {
+	Source                 string
 	UserID                 entities.UserID
 	PhoneID                uuid.UUID
 	Owner                  string
@@ -125,6 +198,11 @@ func (service *UserService) SendPhoneDeadEmail(ctx context.Context, params *User
 		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
 	}
 
+	if user.NotificationDigestMinutes > 0 {
+		summary := fmt.Sprintf("Phone %s stopped sending heartbeats", params.Owner)
+		return service.addToDigest(ctx, params.Source, user, events.EventTypePhoneHeartbeatDead, summary, params.LastHeartbeatTimestamp)
+	}
+
 	email, err := service.emailFactory.PhoneDead(user, params.LastHeartbeatTimestamp, params.Owner)
 	if err != nil {
 		msg := fmt.Sprintf("cannot create phone dead email for user [%s]", params.UserID)
@@ -140,6 +218,215 @@ func (service *UserService) SendPhoneDeadEmail(ctx context.Context, params *User
 	return nil
 }
 
+// UserSendCanaryFailedEmailParams are parameters for notifying a user when a canary message failed
+type UserSendCanaryFailedEmailParams struct {
+	Source    string
+	UserID    entities.UserID
+	Owner     string
+	Contact   string
+	Reason    string
+	Timestamp time.Time
+}
+
+// SendCanaryFailedEmail sends an email to an entities.User when a canary message failed
+func (service *UserService) SendCanaryFailedEmail(ctx context.Context, params *UserSendCanaryFailedEmailParams) error {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	user, err := service.repository.Load(ctx, params.UserID)
+	if err != nil {
+		msg := fmt.Sprintf("could not get [%T] with ID [%s]", user, params.UserID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if user.NotificationDigestMinutes > 0 {
+		summary := fmt.Sprintf("Canary message from %s to %s failed because the %s", params.Owner, params.Contact, strings.TrimPrefix(params.Reason, "canary "))
+		return service.addToDigest(ctx, params.Source, user, events.EventTypeCanaryFailed, summary, params.Timestamp)
+	}
+
+	email, err := service.emailFactory.CanaryFailed(user, params.Owner, params.Contact, params.Reason)
+	if err != nil {
+		msg := fmt.Sprintf("cannot create canary failed email for user [%s]", params.UserID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if err = service.mailer.Send(ctx, email); err != nil {
+		msg := fmt.Sprintf("canot send canary failed notification to user [%s]", params.UserID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("canary failed notification sent successfully to [%s] about [%s]", user.Email, params.Owner))
+	return nil
+}
+
+// UserSendWebhookDeliveryFailedEmailParams are parameters for notifying a user when a webhook delivery failed
+type UserSendWebhookDeliveryFailedEmailParams struct {
+	Source       string
+	UserID       entities.UserID
+	URL          string
+	EventType    string
+	StatusCode   int
+	RedeliverURL string
+	Timestamp    time.Time
+}
+
+// SendWebhookDeliveryFailedEmail sends an email to an entities.User when an event could not be sent to a webhook
+func (service *UserService) SendWebhookDeliveryFailedEmail(ctx context.Context, params *UserSendWebhookDeliveryFailedEmailParams) error {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	user, err := service.repository.Load(ctx, params.UserID)
+	if err != nil {
+		msg := fmt.Sprintf("could not get [%T] with ID [%s]", user, params.UserID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if user.NotificationDigestMinutes > 0 {
+		summary := fmt.Sprintf("Event %s could not be sent to webhook %s", params.EventType, params.URL)
+		return service.addToDigest(ctx, params.Source, user, events.EventTypeWebhookDeliveryFailed, summary, params.Timestamp)
+	}
+
+	email, err := service.emailFactory.WebhookDeliveryFailed(user, params.URL, params.EventType, params.StatusCode, params.RedeliverURL)
+	if err != nil {
+		msg := fmt.Sprintf("cannot create webhook delivery failed email for user [%s]", params.UserID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if err = service.mailer.Send(ctx, email); err != nil {
+		msg := fmt.Sprintf("cannot send webhook delivery failed notification to user [%s]", params.UserID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("webhook delivery failed notification sent successfully to [%s] about [%s]", user.Email, params.URL))
+	return nil
+}
+
+// UserSendBroadcastEmailParams are parameters for sending an entities.Broadcast to a user
+type UserSendBroadcastEmailParams struct {
+	UserID      entities.UserID
+	BroadcastID uuid.UUID
+	Title       string
+	Body        string
+	Severity    entities.BroadcastSeverity
+}
+
+// SendBroadcastEmail sends an announcement of the operators to an entities.User, it is never batched in the digest
+func (service *UserService) SendBroadcastEmail(ctx context.Context, params *UserSendBroadcastEmailParams) error {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	user, err := service.repository.Load(ctx, params.UserID)
+	if err != nil {
+		msg := fmt.Sprintf("could not get [%T] with ID [%s]", user, params.UserID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	email, err := service.emailFactory.Broadcast(user, params.Title, params.Body, params.Severity)
+	if err != nil {
+		msg := fmt.Sprintf("cannot create broadcast email for user [%s]", params.UserID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if err = service.mailer.Send(ctx, email); err != nil {
+		msg := fmt.Sprintf("cannot send broadcast [%s] to user [%s]", params.BroadcastID, params.UserID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("broadcast [%s] sent successfully to [%s]", params.BroadcastID, user.Email))
+	return nil
+}
+
+// SendDigestEmail sends the alerts collected for an entities.User in a single email
+func (service *UserService) SendDigestEmail(ctx context.Context, userID entities.UserID) error {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	user, err := service.repository.Load(ctx, userID)
+	if err != nil {
+		msg := fmt.Sprintf("could not get [%T] with ID [%s]", user, userID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	items, err := service.digestRepository.Index(ctx, userID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot fetch notification digest items for user [%s]", userID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if len(items) == 0 {
+		ctxLogger.Info(fmt.Sprintf("there are no notification digest items for user [%s]", userID))
+		return nil
+	}
+
+	email, err := service.emailFactory.NotificationDigest(user, items)
+	if err != nil {
+		msg := fmt.Sprintf("cannot create notification digest email for user [%s]", userID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if err = service.mailer.Send(ctx, email); err != nil {
+		msg := fmt.Sprintf("canot send notification digest to user [%s]", userID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	itemIDs := make([]uuid.UUID, 0, len(items))
+	for _, item := range items {
+		itemIDs = append(itemIDs, item.ID)
+	}
+
+	if err = service.digestRepository.Delete(ctx, userID, itemIDs); err != nil {
+		msg := fmt.Sprintf("cannot delete [%d] notification digest items for user [%s]", len(itemIDs), userID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("notification digest with [%d] alerts sent successfully to [%s]", len(items), user.Email))
+	return nil
+}
+
+// addToDigest stores an alert which will be sent to the user when the digest window elapses
+func (service *UserService) addToDigest(ctx context.Context, source string, user *entities.User, eventType string, summary string, occurredAt time.Time) error {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	item := &entities.NotificationDigestItem{
+		ID:         uuid.New(),
+		UserID:     user.ID,
+		EventType:  eventType,
+		Summary:    summary,
+		OccurredAt: occurredAt,
+		CreatedAt:  time.Now().UTC(),
+	}
+
+	pending, err := service.digestRepository.Store(ctx, item)
+	if err != nil {
+		msg := fmt.Sprintf("cannot store notification digest item for event [%s] and user [%s]", eventType, user.ID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	// the digest is scheduled once when the first alert of the window arrives
+	if pending > 1 {
+		ctxLogger.Info(fmt.Sprintf("added alert [%s] to the notification digest of user [%s] with [%d] pending alerts", eventType, user.ID, pending))
+		return nil
+	}
+
+	event, err := service.createEvent(events.EventTypeNotificationDigestSend, source, &events.NotificationDigestSendPayload{
+		UserID:      user.ID,
+		ScheduledAt: time.Now().UTC().Add(user.NotificationDigestInterval()),
+	})
+	if err != nil {
+		msg := fmt.Sprintf("cannot create event [%s] for user [%s]", events.EventTypeNotificationDigestSend, user.ID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if _, err = service.dispatcher.DispatchWithTimeout(ctx, event, user.NotificationDigestInterval()); err != nil {
+		msg := fmt.Sprintf("cannot dispatch event [%s] for user [%s]", event.Type(), user.ID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("scheduled notification digest for user [%s] in [%s]", user.ID, user.NotificationDigestInterval()))
+	return nil
+}
+
 // StartSubscription starts a subscription for an entities.User
 func (service *UserService) StartSubscription(ctx context.Context, params *events.UserSubscriptionCreatedPayload) error {
 	ctx, span := service.tracer.Start(ctx)
@@ -165,7 +452,7 @@ func (service *UserService) StartSubscription(ctx context.Context, params *event
 	return nil
 }
 
-// InitiateSubscriptionCancel initiates the cancelling of a subscription on lemonsqueezy
+// InitiateSubscriptionCancel initiates the cancelling of a subscription on the BillingProvider
 func (service *UserService) InitiateSubscriptionCancel(ctx context.Context, userID entities.UserID) error {
 	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
 	defer span.End()
@@ -176,8 +463,8 @@ func (service *UserService) InitiateSubscriptionCancel(ctx context.Context, user
 		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
 	}
 
-	if _, _, err = service.lemonsqueezyClient.Subscriptions.Cancel(ctx, *user.SubscriptionID); err != nil {
-		msg := fmt.Sprintf("could not cancel subscription [%s] for [%T] with with ID [%s]", *user.SubscriptionID, user, user.ID)
+	if err = service.billingProvider.Cancel(ctx, user); err != nil {
+		msg := fmt.Sprintf("could not cancel [%s] subscription [%s] for [%T] with with ID [%s]", service.billingProvider.Name(), *user.SubscriptionID, user, user.ID)
 		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
 	}
 
@@ -185,7 +472,7 @@ func (service *UserService) InitiateSubscriptionCancel(ctx context.Context, user
 	return nil
 }
 
-// GetSubscriptionUpdateURL initiates the cancelling of a subscription on lemonsqueezy
+// GetSubscriptionUpdateURL returns the URL where the user can update the payment method of their subscription
 func (service *UserService) GetSubscriptionUpdateURL(ctx context.Context, userID entities.UserID) (url string, err error) {
 	ctx, span := service.tracer.Start(ctx)
 	defer span.End()
@@ -196,13 +483,52 @@ func (service *UserService) GetSubscriptionUpdateURL(ctx context.Context, userID
 		return "", service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
 	}
 
-	subscription, _, err := service.lemonsqueezyClient.Subscriptions.Get(ctx, *user.SubscriptionID)
+	if url, err = service.billingProvider.UpdatePaymentMethodURL(ctx, user); err != nil {
+		msg := fmt.Sprintf("could not get [%s] update URL for [%T] with with ID [%s]", service.billingProvider.Name(), user, user.ID)
+		return url, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return url, nil
+}
+
+// GetSubscriptionCheckoutURL returns the URL where the user pays for a new subscription
+func (service *UserService) GetSubscriptionCheckoutURL(ctx context.Context, userID entities.UserID, plan entities.SubscriptionName) (url string, err error) {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	user, err := service.repository.Load(ctx, userID)
 	if err != nil {
-		msg := fmt.Sprintf("could not get subscription [%s] for [%T] with with ID [%s]", *user.SubscriptionID, user, user.ID)
+		msg := fmt.Sprintf("could not get [%T] with with ID [%s]", user, userID)
+		return "", service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if url, err = service.billingProvider.CheckoutURL(ctx, user, plan); err != nil {
+		msg := fmt.Sprintf("could not get [%s] checkout URL for plan [%s] and [%T] with with ID [%s]", service.billingProvider.Name(), plan, user, user.ID)
 		return url, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
 	}
 
-	return subscription.Data.Attributes.Urls.UpdatePaymentMethod, nil
+	return url, nil
+}
+
+// ChangeSubscriptionPlan moves the subscription of a user to another plan.
+// The user is updated when the BillingProvider sends the subscription updated event.
+func (service *UserService) ChangeSubscriptionPlan(ctx context.Context, userID entities.UserID, plan entities.SubscriptionName) error {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	user, err := service.repository.Load(ctx, userID)
+	if err != nil {
+		msg := fmt.Sprintf("could not get [%T] with with ID [%s]", user, userID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if err = service.billingProvider.ChangePlan(ctx, user, plan); err != nil {
+		msg := fmt.Sprintf("could not change [%s] subscription of [%T] with with ID [%s] to plan [%s]", service.billingProvider.Name(), user, user.ID, plan)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("changed [%s] subscription for user [%s] from [%s] to [%s]", service.billingProvider.Name(), user.ID, user.SubscriptionName, plan))
+	return nil
 }
 
 // CancelSubscription starts a subscription for an entities.User
@@ -229,3 +555,115 @@ func (service *UserService) CancelSubscription(ctx context.Context, params *even
 
 	return nil
 }
+
+// UserSuspendParams are parameters for suspending the account of a user
+type UserSuspendParams struct {
+	Source string
+	UserID entities.UserID
+	Reason entities.UserSuspensionReason
+}
+
+// Suspend the account of a user, the services which send messages and webhooks react to the events.UserAccountSuspended event
+func (service *UserService) Suspend(ctx context.Context, params *UserSuspendParams) (*entities.User, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	user, err := service.repository.Load(ctx, params.UserID)
+	if err != nil {
+		msg := fmt.Sprintf("could not get [%T] with with ID [%s]", user, params.UserID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
+	}
+
+	if user.IsSuspended() {
+		// an abuse suspension must not be lifted when the user pays so it takes precedence over a billing suspension
+		if params.Reason == entities.UserSuspensionReasonAbuse && *user.SuspensionReason != params.Reason {
+			user.SuspensionReason = &params.Reason
+			if err = service.repository.Update(ctx, user); err != nil {
+				msg := fmt.Sprintf("could not update suspension reason of [%T] with ID [%s]", user, user.ID)
+				return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+			}
+		}
+		ctxLogger.Info(fmt.Sprintf("user [%s] is already suspended with reason [%s]", user.ID, *user.SuspensionReason))
+		return user, nil
+	}
+
+	suspendedAt := time.Now().UTC()
+	user.SuspendedAt = &suspendedAt
+	user.SuspensionReason = &params.Reason
+	if err = service.repository.Update(ctx, user); err != nil {
+		msg := fmt.Sprintf("could not suspend [%T] with ID [%s]", user, user.ID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	event, err := service.createEvent(events.UserAccountSuspended, params.Source, &events.UserAccountSuspendedPayload{
+		UserID:      user.ID,
+		Reason:      params.Reason,
+		SuspendedAt: suspendedAt,
+	})
+	if err != nil {
+		msg := fmt.Sprintf("cannot create event [%s] for user [%s]", events.UserAccountSuspended, user.ID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if err = service.dispatcher.Dispatch(ctx, event); err != nil {
+		msg := fmt.Sprintf("cannot dispatch event [%s] for user [%s]", event.Type(), user.ID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("suspended account of user [%s] with reason [%s]", user.ID, params.Reason))
+	return user, nil
+}
+
+// UserReactivateParams are parameters for lifting the suspension of a user account
+type UserReactivateParams struct {
+	Source string
+	UserID entities.UserID
+
+	// Reason only lifts a suspension with this reason when it is set
+	Reason *entities.UserSuspensionReason
+}
+
+// Reactivate lifts the suspension of a user account, paused messages and held webhooks are sent after the events.UserAccountReactivated event
+func (service *UserService) Reactivate(ctx context.Context, params *UserReactivateParams) (*entities.User, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	user, err := service.repository.Load(ctx, params.UserID)
+	if err != nil {
+		msg := fmt.Sprintf("could not get [%T] with with ID [%s]", user, params.UserID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
+	}
+
+	if !user.IsSuspended() || (params.Reason != nil && *params.Reason != *user.SuspensionReason) {
+		ctxLogger.Info(fmt.Sprintf("user [%s] has no suspension to lift with reason [%v]", user.ID, params.Reason))
+		return user, nil
+	}
+
+	payload := &events.UserAccountReactivatedPayload{
+		UserID:        user.ID,
+		Reason:        *user.SuspensionReason,
+		SuspendedAt:   *user.SuspendedAt,
+		ReactivatedAt: time.Now().UTC(),
+	}
+
+	user.SuspendedAt = nil
+	user.SuspensionReason = nil
+	if err = service.repository.Update(ctx, user); err != nil {
+		msg := fmt.Sprintf("could not reactivate [%T] with ID [%s]", user, user.ID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	event, err := service.createEvent(events.UserAccountReactivated, params.Source, payload)
+	if err != nil {
+		msg := fmt.Sprintf("cannot create event [%s] for user [%s]", events.UserAccountReactivated, user.ID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if err = service.dispatcher.Dispatch(ctx, event); err != nil {
+		msg := fmt.Sprintf("cannot dispatch event [%s] for user [%s]", event.Type(), user.ID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("reactivated account of user [%s] which was suspended with reason [%s]", user.ID, payload.Reason))
+	return user, nil
+}
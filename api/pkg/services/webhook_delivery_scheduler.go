@@ -0,0 +1,61 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/palantir/stacktrace"
+)
+
+const webhookDeliverySchedulerBatchSize = 100
+
+// WebhookDeliveryScheduler periodically retries the webhook deliveries which failed and are due for another attempt
+type WebhookDeliveryScheduler struct {
+	logger   telemetry.Logger
+	tracer   telemetry.Tracer
+	service  *WebhookService
+	interval time.Duration
+}
+
+// NewWebhookDeliveryScheduler creates a new WebhookDeliveryScheduler
+func NewWebhookDeliveryScheduler(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	service *WebhookService,
+	interval time.Duration,
+) (s *WebhookDeliveryScheduler) {
+	return &WebhookDeliveryScheduler{
+		logger:   logger.WithService(fmt.Sprintf("%T", s)),
+		tracer:   tracer,
+		service:  service,
+		interval: interval,
+	}
+}
+
+// Run retries the due deliveries on every tick until the context is cancelled
+func (scheduler *WebhookDeliveryScheduler) Run(ctx context.Context) {
+	runTicker(ctx, scheduler.logger, "webhook delivery scheduler", scheduler.interval, scheduler.tick)
+}
+
+func (scheduler *WebhookDeliveryScheduler) tick(ctx context.Context) {
+	ctx, span, ctxLogger := scheduler.tracer.StartWithLogger(ctx, scheduler.logger)
+	defer span.End()
+
+	for {
+		count, err := scheduler.service.RetryDue(ctx, time.Now().UTC(), webhookDeliverySchedulerBatchSize)
+		if err != nil {
+			ctxLogger.Error(stacktrace.Propagate(err, "cannot retry webhook deliveries which are due"))
+			return
+		}
+
+		if count > 0 {
+			ctxLogger.Info(fmt.Sprintf("retried [%d] webhook deliveries", count))
+		}
+
+		if count < webhookDeliverySchedulerBatchSize {
+			return
+		}
+	}
+}
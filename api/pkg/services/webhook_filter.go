@@ -0,0 +1,128 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/palantir/stacktrace"
+)
+
+// webhookFilterPathRegex matches the subset of JSONPath which is supported in an entities.WebhookFilter e.g. $.attachments[0].content_type
+var webhookFilterPathRegex = regexp.MustCompile(`^\$((\.[a-zA-Z0-9_]+)|(\[[0-9]+\]))+$`)
+
+// webhookFilterSegmentRegex splits a JSONPath expression into object keys and array indexes
+var webhookFilterSegmentRegex = regexp.MustCompile(`\.([a-zA-Z0-9_]+)|\[([0-9]+)\]`)
+
+// IsValidWebhookFilterPath checks if a JSONPath expression can be evaluated by an entities.WebhookFilter
+func IsValidWebhookFilterPath(path string) bool {
+	return webhookFilterPathRegex.MatchString(path)
+}
+
+// matchesWebhookFilters checks if the JSON data of an event matches all the filters
+func matchesWebhookFilters(filters []entities.WebhookFilter, data []byte) (bool, error) {
+	if len(filters) == 0 {
+		return true, nil
+	}
+
+	var document any
+	if err := json.Unmarshal(data, &document); err != nil {
+		return false, stacktrace.Propagate(err, "cannot unmarshal event data into JSON")
+	}
+
+	for _, filter := range filters {
+		matched, err := matchesWebhookFilter(filter, document)
+		if err != nil {
+			return false, stacktrace.Propagate(err, fmt.Sprintf("cannot evaluate filter on path [%s] with operator [%s]", filter.Path, filter.Operator))
+		}
+		if !matched {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+func matchesWebhookFilter(filter entities.WebhookFilter, document any) (bool, error) {
+	value, found := webhookFilterLookup(document, filter.Path)
+	if filter.Operator == entities.WebhookFilterOperatorExists {
+		return found, nil
+	}
+
+	actual := webhookFilterString(value)
+	switch filter.Operator {
+	case entities.WebhookFilterOperatorEqual:
+		return found && actual == filter.Value, nil
+	case entities.WebhookFilterOperatorNotEqual:
+		return !found || actual != filter.Value, nil
+	case entities.WebhookFilterOperatorContains:
+		return found && strings.Contains(actual, filter.Value), nil
+	case entities.WebhookFilterOperatorMatches:
+		expression, err := regexp.Compile(filter.Value)
+		if err != nil {
+			return false, stacktrace.Propagate(err, fmt.Sprintf("cannot compile regular expression [%s]", filter.Value))
+		}
+		return found && expression.MatchString(actual), nil
+	case entities.WebhookFilterOperatorIn:
+		return found && webhookFilterContains(filter.Values, actual), nil
+	case entities.WebhookFilterOperatorNotIn:
+		return !found || !webhookFilterContains(filter.Values, actual), nil
+	default:
+		return false, stacktrace.NewError(fmt.Sprintf("the filter operator [%s] is not supported", filter.Operator))
+	}
+}
+
+// webhookFilterLookup returns the value at a JSONPath expression in a JSON document
+func webhookFilterLookup(document any, path string) (any, bool) {
+	current := document
+	for _, segment := range webhookFilterSegmentRegex.FindAllStringSubmatch(path, -1) {
+		if segment[1] != "" {
+			object, ok := current.(map[string]any)
+			if !ok {
+				return nil, false
+			}
+			if current, ok = object[segment[1]]; !ok {
+				return nil, false
+			}
+			continue
+		}
+
+		array, ok := current.([]any)
+		if !ok {
+			return nil, false
+		}
+		index, err := strconv.Atoi(segment[2])
+		if err != nil || index >= len(array) {
+			return nil, false
+		}
+		current = array[index]
+	}
+	return current, current != nil
+}
+
+func webhookFilterString(value any) string {
+	switch item := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return item
+	case float64:
+		return strconv.FormatFloat(item, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(item)
+	default:
+		encoded, _ := json.Marshal(item)
+		return string(encoded)
+	}
+}
+
+func webhookFilterContains(values []string, value string) bool {
+	for _, item := range values {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,135 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/events"
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/google/uuid"
+	"github.com/palantir/stacktrace"
+)
+
+// webhookFixturesSource is the source of the sample events which are sent to certify webhook consumers
+const webhookFixturesSource = "/webhooks/fixtures"
+
+// webhookFixtureTimestamp is fixed so that the fixtures are identical every time they are fetched
+var webhookFixtureTimestamp = time.Date(2022, 6, 5, 14, 26, 9, 0, time.UTC)
+
+// WebhookFixture is the canonical payload of an event type in a webhook version
+type WebhookFixture struct {
+	EventType string                  `json:"event_type" example:"message.phone.received"`
+	Version   entities.WebhookVersion `json:"version" example:"v2"`
+	Payload   json.RawMessage         `json:"payload" swaggertype:"object"`
+}
+
+// webhookFixtureID derives a stable ID from a name so that consumers can deduplicate replayed fixtures
+func webhookFixtureID(name string) uuid.UUID {
+	return uuid.NewSHA1(uuid.NameSpaceURL, []byte(webhookFixturesSource+"/"+name))
+}
+
+// webhookFixtureEvents returns a sample event for every event type which webhooks can subscribe to
+func webhookFixtureEvents(userID entities.UserID) ([]cloudevents.Event, error) {
+	conversationID := "bot-session-1234"
+	payloads := []struct {
+		eventType string
+		data      any
+	}{
+		{
+			eventType: events.EventTypeMessagePhoneReceived,
+			data: &events.MessagePhoneReceivedPayload{
+				MessageID:      webhookFixtureID("message"),
+				UserID:         userID,
+				Owner:          "+18005550199",
+				Contact:        "+18005550100",
+				Timestamp:      webhookFixtureTimestamp,
+				Content:        "This is a sample text message received on a phone",
+				SIM:            entities.SIM1,
+				ConversationID: &conversationID,
+			},
+		},
+		{
+			eventType: events.EventTypeMessageRead,
+			data: &events.MessageReadPayload{
+				MessageIDs: []uuid.UUID{webhookFixtureID("message")},
+				Owner:      "+18005550199",
+				Contact:    "+18005550100",
+				UserID:     userID,
+				Timestamp:  webhookFixtureTimestamp.Add(time.Minute),
+			},
+		},
+		{
+			eventType: events.EventTypeMessagePurged,
+			data: &events.MessagePurgedPayload{
+				MessageIDs: []uuid.UUID{webhookFixtureID("message")},
+				UserID:     userID,
+				Action:     events.MessagePurgeActionDeleted,
+				Before:     webhookFixtureTimestamp.Add(time.Hour),
+				Timestamp:  webhookFixtureTimestamp.AddDate(0, 0, 30).Add(time.Hour),
+			},
+		},
+		{
+			eventType: events.EventTypeBroadcastPublished,
+			data: &events.BroadcastPublishedPayload{
+				BroadcastID: webhookFixtureID("broadcast"),
+				UserID:      userID,
+				Title:       "Scheduled maintenance",
+				Body:        "The API will be unavailable for 10 minutes on Sunday at 02:00 UTC.",
+				Severity:    entities.BroadcastSeverityWarning,
+				Channels:    []string{string(entities.BroadcastChannelWebhook)},
+				StartsAt:    webhookFixtureTimestamp,
+				Timestamp:   webhookFixtureTimestamp,
+			},
+		},
+	}
+
+	result := make([]cloudevents.Event, 0, len(payloads))
+	for _, payload := range payloads {
+		event := cloudevents.NewEvent()
+		event.SetSource(webhookFixturesSource)
+		event.SetType(payload.eventType)
+		event.SetTime(webhookFixtureTimestamp)
+		event.SetID(webhookFixtureID(payload.eventType).String())
+
+		if err := event.SetData(cloudevents.ApplicationJSON, payload.data); err != nil {
+			msg := fmt.Sprintf("cannot encode %T [%#+v] as JSON", payload.data, payload.data)
+			return nil, stacktrace.Propagate(err, msg)
+		}
+		result = append(result, event)
+	}
+
+	return result, nil
+}
+
+// webhookFixtures renders the sample events in every entities.WebhookVersion
+func webhookFixtures(userID entities.UserID) ([]*WebhookFixture, error) {
+	samples, err := webhookFixtureEvents(userID)
+	if err != nil {
+		return nil, stacktrace.Propagate(err, fmt.Sprintf("cannot create sample events for user [%s]", userID))
+	}
+
+	fixtures := make([]*WebhookFixture, 0, len(samples)*len(entities.WebhookVersions))
+	for _, version := range entities.WebhookVersions {
+		for _, event := range samples {
+			payload, err := webhookPayloadConverters[version](event)
+			if err != nil {
+				return nil, stacktrace.Propagate(err, fmt.Sprintf("cannot convert event [%s] to version [%s]", event.Type(), version))
+			}
+
+			content, err := json.Marshal(payload)
+			if err != nil {
+				return nil, stacktrace.Propagate(err, fmt.Sprintf("cannot marshal event [%s] in version [%s]", event.Type(), version))
+			}
+
+			fixtures = append(fixtures, &WebhookFixture{
+				EventType: event.Type(),
+				Version:   version,
+				Payload:   content,
+			})
+		}
+	}
+
+	return fixtures, nil
+}
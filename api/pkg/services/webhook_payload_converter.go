@@ -0,0 +1,40 @@
+package services
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/palantir/stacktrace"
+)
+
+// webhookPayloadConverter renders a cloud event in the payload of an entities.WebhookVersion
+type webhookPayloadConverter func(event cloudevents.Event) (any, error)
+
+// webhookPayloadV2 is the payload sent to webhooks pinned on entities.WebhookVersionV2
+type webhookPayloadV2 struct {
+	ID        string          `json:"id"`
+	Type      string          `json:"type"`
+	Version   string          `json:"version"`
+	Timestamp time.Time       `json:"timestamp"`
+	Data      json.RawMessage `json:"data"`
+}
+
+var webhookPayloadConverters = map[entities.WebhookVersion]webhookPayloadConverter{
+	entities.WebhookVersionV1: func(event cloudevents.Event) (any, error) {
+		return event, nil
+	},
+	entities.WebhookVersionV2: func(event cloudevents.Event) (any, error) {
+		if !json.Valid(event.Data()) {
+			return nil, stacktrace.NewError("cannot render event [%s] with ID [%s] because the data is not valid JSON", event.Type(), event.ID())
+		}
+		return &webhookPayloadV2{
+			ID:        event.ID(),
+			Type:      event.Type(),
+			Version:   string(entities.WebhookVersionV2),
+			Timestamp: event.Time(),
+			Data:      event.Data(),
+		}, nil
+	},
+}
@@ -2,6 +2,11 @@ package services
 
 import (
 	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"strings"
@@ -21,29 +26,69 @@ import (
 	"github.com/google/uuid"
 	"github.com/lib/pq"
 	"github.com/palantir/stacktrace"
+	"gorm.io/datatypes"
 )
 
+const (
+	// webhookRetryBaseDelay is the delay before the first retry, it doubles after every failed attempt
+	webhookRetryBaseDelay = 30 * time.Second
+
+	// webhookRetryMaxDelay caps the delay between attempts
+	webhookRetryMaxDelay = 6 * time.Hour
+
+	// webhookRetryLease is how long a claimed delivery is hidden from other workers while it is being sent
+	webhookRetryLease = 2 * time.Minute
+
+	// webhookDeliverySource is the source of the events emitted while delivering webhooks
+	webhookDeliverySource = "/webhook-deliveries"
+)
+
+// ErrCodeWebhookRotationPending is returned when the signing key of a webhook is rotated before the previous rotation is confirmed
+const ErrCodeWebhookRotationPending = stacktrace.ErrorCode(2028)
+
 // WebhookService is responsible for handling webhooks
 type WebhookService struct {
 	service
-	logger     telemetry.Logger
-	tracer     telemetry.Tracer
-	client     *http.Client
-	repository repositories.WebhookRepository
+	logger             telemetry.Logger
+	tracer             telemetry.Tracer
+	proxy              *EgressProxy
+	repository         repositories.WebhookRepository
+	deliveryRepository repositories.WebhookDeliveryRepository
+	userRepository     repositories.UserRepository
+	dispatcher         *EventDispatcher
+	maxAttempts        uint
+	appURL             string
+	signingKey         ed25519.PrivateKey
 }
 
 // NewWebhookService creates a new WebhookService
 func NewWebhookService(
 	logger telemetry.Logger,
 	tracer telemetry.Tracer,
-	client *http.Client,
+	proxy *EgressProxy,
 	repository repositories.WebhookRepository,
+	deliveryRepository repositories.WebhookDeliveryRepository,
+	userRepository repositories.UserRepository,
+	dispatcher *EventDispatcher,
+	maxAttempts uint,
+	appURL string,
+	signingKey ed25519.PrivateKey,
 ) (s *WebhookService) {
+	if maxAttempts == 0 {
+		maxAttempts = 1
+	}
+
 	return &WebhookService{
-		logger:     logger.WithService(fmt.Sprintf("%T", s)),
-		tracer:     tracer,
-		client:     client,
-		repository: repository,
+		logger:             logger.WithService(fmt.Sprintf("%T", s)),
+		tracer:             tracer,
+		proxy:              proxy,
+		repository:         repository,
+		deliveryRepository: deliveryRepository,
+		userRepository:     userRepository,
+		dispatcher:         dispatcher,
+		maxAttempts:        maxAttempts,
+		appURL:             strings.TrimRight(appURL, "/"),
+		signingKey:         signingKey,
 	}
 }
 
@@ -91,6 +136,9 @@ type WebhookStoreParams struct {
 	SigningKey string
 	URL        string
 	Events     pq.StringArray
+	Filters    []entities.WebhookFilter
+	Version    entities.WebhookVersion
+	ProxyURL   *string
 }
 
 // Store a new entities.Webhook
@@ -106,6 +154,9 @@ func (service *WebhookService) Store(ctx context.Context, params *WebhookStorePa
 		URL:        params.URL,
 		SigningKey: params.SigningKey,
 		Events:     params.Events,
+		Filters:    datatypes.JSONType[[]entities.WebhookFilter]{Data: params.Filters},
+		Version:    params.Version,
+		ProxyURL:   params.ProxyURL,
 		CreatedAt:  time.Now().UTC(),
 		UpdatedAt:  time.Now().UTC(),
 	}
@@ -125,6 +176,9 @@ type WebhookUpdateParams struct {
 	SigningKey string
 	URL        string
 	Events     pq.StringArray
+	Filters    []entities.WebhookFilter
+	Version    entities.WebhookVersion
+	ProxyURL   *string
 	WebhookID  uuid.UUID
 }
 
@@ -142,6 +196,11 @@ func (service *WebhookService) Update(ctx context.Context, params *WebhookUpdate
 	webhook.URL = params.URL
 	webhook.SigningKey = params.SigningKey
 	webhook.Events = params.Events
+	webhook.Filters = datatypes.JSONType[[]entities.WebhookFilter]{Data: params.Filters}
+	webhook.ProxyURL = params.ProxyURL
+	if params.Version != "" {
+		webhook.Version = params.Version
+	}
 
 	if err = service.repository.Save(ctx, webhook); err != nil {
 		msg := fmt.Sprintf("cannot save webhook with id [%s] after update", webhook.ID)
@@ -152,6 +211,81 @@ func (service *WebhookService) Update(ctx context.Context, params *WebhookUpdate
 	return webhook, nil
 }
 
+// WebhookRotateKeyParams are parameters for rotating the signing key of an entities.Webhook
+type WebhookRotateKeyParams struct {
+	UserID     entities.UserID
+	WebhookID  uuid.UUID
+	SigningKey string
+}
+
+// RotateKey makes a new signing key the primary key of a webhook and keeps the current key as the secondary key.
+// Payloads are signed with both keys until the rotation is confirmed so receivers can switch keys without dropping events.
+// A rotation is rejected until the previous rotation is confirmed so that the key which receivers still use is never dropped.
+func (service *WebhookService) RotateKey(ctx context.Context, params *WebhookRotateKeyParams) (*entities.Webhook, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	webhook, err := service.repository.Load(ctx, params.UserID, params.WebhookID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot load webhook with userID [%s] and webhookID [%s]", params.UserID, params.WebhookID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
+	}
+
+	if webhook.SecondarySigningKey != "" {
+		msg := fmt.Sprintf("the key rotation of webhook [%s] for user [%s] has not been confirmed", webhook.ID, webhook.UserID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.NewErrorWithCode(ErrCodeWebhookRotationPending, msg))
+	}
+
+	signingKey := params.SigningKey
+	if signingKey == "" {
+		if signingKey, err = service.generateSigningKey(); err != nil {
+			msg := fmt.Sprintf("cannot generate signing key for webhook [%s]", webhook.ID)
+			return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+		}
+	}
+
+	rotatedAt := time.Now().UTC()
+	webhook.SecondarySigningKey = webhook.SigningKey
+	webhook.SigningKey = signingKey
+	webhook.SigningKeyRotatedAt = &rotatedAt
+	webhook.UpdatedAt = rotatedAt
+
+	if err = service.repository.Save(ctx, webhook); err != nil {
+		msg := fmt.Sprintf("cannot save webhook with id [%s] after rotating the signing key", webhook.ID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("rotated signing key of webhook with id [%s] for user [%s]", webhook.ID, webhook.UserID))
+	return webhook, nil
+}
+
+// ConfirmKeyRotation removes the secondary signing key of a webhook once the receiver verifies requests with the primary key
+func (service *WebhookService) ConfirmKeyRotation(ctx context.Context, userID entities.UserID, webhookID uuid.UUID) (*entities.Webhook, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	webhook, err := service.repository.Load(ctx, userID, webhookID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot load webhook with userID [%s] and webhookID [%s]", userID, webhookID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
+	}
+
+	if webhook.SecondarySigningKey == "" {
+		return webhook, nil
+	}
+
+	webhook.SecondarySigningKey = ""
+	webhook.UpdatedAt = time.Now().UTC()
+
+	if err = service.repository.Save(ctx, webhook); err != nil {
+		msg := fmt.Sprintf("cannot save webhook with id [%s] after confirming the key rotation", webhook.ID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("confirmed key rotation of webhook with id [%s] for user [%s]", webhook.ID, webhook.UserID))
+	return webhook, nil
+}
+
 // Send an event to a subscribed webhook
 func (service *WebhookService) Send(ctx context.Context, userID entities.UserID, event cloudevents.Event) error {
 	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
@@ -168,12 +302,30 @@ func (service *WebhookService) Send(ctx context.Context, userID entities.UserID,
 		return nil
 	}
 
+	user, err := service.userRepository.Load(ctx, userID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot load user with ID [%s]", userID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
 	var wg sync.WaitGroup
 	for _, webhook := range webhooks {
+		matched, err := matchesWebhookFilters(webhook.FiltersFor(event.Type()), event.Data())
+		if err != nil {
+			msg := fmt.Sprintf("cannot evaluate filters of webhook [%s] for event [%s] with ID [%s]", webhook.ID, event.Type(), event.ID())
+			ctxLogger.Error(service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg)))
+			continue
+		}
+
+		if !matched {
+			ctxLogger.Info(fmt.Sprintf("skipped event [%s] with ID [%s] because it does not match the filters of webhook [%s]", event.Type(), event.ID(), webhook.ID))
+			continue
+		}
+
 		wg.Add(1)
 		go func(webhook *entities.Webhook) {
 			defer wg.Done()
-			service.sendNotification(ctx, event, webhook)
+			service.sendNotification(ctx, event, webhook, user.IsSuspended())
 		}(webhook)
 	}
 	wg.Wait()
@@ -181,7 +333,315 @@ func (service *WebhookService) Send(ctx context.Context, userID entities.UserID,
 	return nil
 }
 
-func (service *WebhookService) sendNotification(ctx context.Context, event cloudevents.Event, webhook *entities.Webhook) {
+// WebhookDeliveryIndexParams are parameters for fetching the deliveries of a webhook
+type WebhookDeliveryIndexParams struct {
+	repositories.WebhookDeliveryIndexParams
+	UserID entities.UserID
+}
+
+// IndexDeliveries fetches the entities.WebhookDelivery of a webhook
+func (service *WebhookService) IndexDeliveries(ctx context.Context, params *WebhookDeliveryIndexParams) ([]*entities.WebhookDelivery, error) {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	if _, err := service.repository.Load(ctx, params.UserID, params.WebhookID); err != nil {
+		msg := fmt.Sprintf("cannot load webhook with ID [%s] for user [%s]", params.WebhookID, params.UserID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
+	}
+
+	deliveries, err := service.deliveryRepository.Index(ctx, params.UserID, params.WebhookDeliveryIndexParams)
+	if err != nil {
+		msg := fmt.Sprintf("cannot fetch deliveries of webhook [%s] with params [%+#v]", params.WebhookID, params)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return deliveries, nil
+}
+
+// IndexFailures fetches the entities.WebhookDelivery of all the webhooks of a user which are still in the dead-letter queue.
+// A failure is resolved once it is replayed successfully.
+func (service *WebhookService) IndexFailures(ctx context.Context, userID entities.UserID, params repositories.IndexParams) ([]*entities.WebhookDelivery, error) {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	deliveries, err := service.deliveryRepository.IndexFailed(ctx, userID, params)
+	if err != nil {
+		msg := fmt.Sprintf("cannot fetch failed webhook deliveries of user [%s] with params [%+#v]", userID, params)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return deliveries, nil
+}
+
+// ReplayDelivery sends the payload of an entities.WebhookDelivery again, it is used to recover deliveries from the dead-letter queue
+func (service *WebhookService) ReplayDelivery(ctx context.Context, userID entities.UserID, webhookID uuid.UUID, deliveryID uuid.UUID) (*entities.WebhookDelivery, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	webhook, err := service.repository.Load(ctx, userID, webhookID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot load webhook with ID [%s] for user [%s]", webhookID, userID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
+	}
+
+	user, err := service.userRepository.Load(ctx, userID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot load user with ID [%s]", userID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if user.IsSuspended() {
+		msg := fmt.Sprintf("user [%s] cannot replay webhook deliveries because the account is suspended with reason [%s]", user.ID, *user.SuspensionReason)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.NewErrorWithCode(ErrCodeUserSuspended, msg))
+	}
+
+	delivery, err := service.deliveryRepository.Load(ctx, userID, deliveryID)
+	if err == nil && delivery.WebhookID != webhook.ID {
+		err = stacktrace.NewErrorWithCode(repositories.ErrCodeNotFound, fmt.Sprintf("delivery [%s] belongs to webhook [%s]", delivery.ID, delivery.WebhookID))
+	}
+	if err != nil {
+		msg := fmt.Sprintf("cannot load delivery with ID [%s] of webhook [%s]", deliveryID, webhookID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
+	}
+
+	// a replay is a single manual attempt which does not schedule retries when it fails
+	delivery.MaxAttempts = delivery.Attempts + 1
+	service.attempt(ctx, webhook, delivery)
+
+	if err = service.deliveryRepository.Update(ctx, delivery); err != nil {
+		msg := fmt.Sprintf("cannot update delivery [%s] after replay", delivery.ID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("replayed delivery [%s] of webhook [%s] with status [%s]", delivery.ID, webhook.ID, delivery.Status))
+	return delivery, nil
+}
+
+// Fixtures returns the canonical payloads of the webhook events so that consumers can replay them in their own tests
+func (service *WebhookService) Fixtures(ctx context.Context, userID entities.UserID) ([]*WebhookFixture, error) {
+	_, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	fixtures, err := webhookFixtures(userID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot create webhook fixtures for user [%s]", userID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return fixtures, nil
+}
+
+// WebhookContractTestParams are parameters for sending the webhook fixtures to a consumer
+type WebhookContractTestParams struct {
+	UserID    entities.UserID
+	WebhookID uuid.UUID
+	// URL receives the fixtures instead of the URL of the webhook when it is not empty
+	URL string
+}
+
+// WebhookContractResult is the response of a consumer to a WebhookFixture
+type WebhookContractResult struct {
+	EventType  string                  `json:"event_type" example:"message.phone.received"`
+	Version    entities.WebhookVersion `json:"version" example:"v2"`
+	DeliveryID string                  `json:"delivery_id" example:"32343a19-da5e-4b1b-a767-3298a73703cb"`
+	StatusCode int                     `json:"status_code" example:"200"`
+	Accepted   bool                    `json:"accepted" example:"true"`
+	Error      string                  `json:"error" example:""`
+	// Duration is the number of milliseconds the consumer took to respond
+	Duration int64 `json:"duration" example:"125"`
+}
+
+// ContractTest sends the signed fixtures of every event type and version to a consumer one after the other and reports which ones were accepted.
+// The deliveries are not stored so they don't show up in the delivery log of the webhook.
+func (service *WebhookService) ContractTest(ctx context.Context, params *WebhookContractTestParams) ([]*WebhookContractResult, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	webhook, err := service.repository.Load(ctx, params.UserID, params.WebhookID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot load webhook with ID [%s] for user [%s]", params.WebhookID, params.UserID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
+	}
+
+	user, err := service.userRepository.Load(ctx, params.UserID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot load user with ID [%s]", params.UserID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if user.IsSuspended() {
+		msg := fmt.Sprintf("user [%s] cannot run webhook contract tests because the account is suspended with reason [%s]", user.ID, *user.SuspensionReason)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.NewErrorWithCode(ErrCodeUserSuspended, msg))
+	}
+
+	fixtures, err := webhookFixtures(params.UserID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot create webhook fixtures for user [%s]", params.UserID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	token, err := service.getAuthToken(webhook)
+	if err != nil {
+		msg := fmt.Sprintf("cannot generate auth token for user [%s] and webhook [%s]", webhook.UserID, webhook.ID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	url := webhook.URL
+	if params.URL != "" {
+		url = params.URL
+	}
+
+	accepted := 0
+	results := make([]*WebhookContractResult, 0, len(fixtures))
+	for _, fixture := range fixtures {
+		result := &WebhookContractResult{
+			EventType:  fixture.EventType,
+			Version:    fixture.Version,
+			DeliveryID: uuid.NewString(),
+		}
+
+		start := time.Now()
+		_, result.StatusCode, err = service.post(ctx, url, token, webhook, fixture.Version, fixture.EventType, result.DeliveryID, fixture.Payload)
+		result.Duration = time.Since(start).Milliseconds()
+		if err != nil {
+			result.Error = err.Error()
+		} else {
+			result.Accepted = true
+			accepted++
+		}
+		results = append(results, result)
+	}
+
+	ctxLogger.Info(fmt.Sprintf("consumer [%s] of webhook [%s] accepted [%d/%d] fixtures", url, webhook.ID, accepted, len(results)))
+	return results, nil
+}
+
+// ReleaseHeld schedules the deliveries which were held while the account of a user was suspended
+func (service *WebhookService) ReleaseHeld(ctx context.Context, userID entities.UserID) error {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	count, err := service.deliveryRepository.Release(ctx, userID, time.Now().UTC())
+	if err != nil {
+		msg := fmt.Sprintf("cannot release held webhook deliveries of user [%s]", userID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("released [%d] held webhook deliveries of user [%s]", count, userID))
+	return nil
+}
+
+// RetryDue sends the deliveries which failed and are due for another attempt at the timestamp
+func (service *WebhookService) RetryDue(ctx context.Context, timestamp time.Time, limit int) (int, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	deliveries, err := service.deliveryRepository.FetchDue(ctx, timestamp, limit)
+	if err != nil {
+		msg := fmt.Sprintf("cannot fetch webhook deliveries which are due at [%s]", timestamp)
+		return 0, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	suspended := map[entities.UserID]bool{}
+	for _, delivery := range deliveries {
+		claimed, err := service.deliveryRepository.Claim(ctx, delivery, timestamp.Add(webhookRetryLease))
+		if err != nil {
+			msg := fmt.Sprintf("cannot claim webhook delivery [%s]", delivery.ID)
+			return 0, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+		}
+
+		if !claimed {
+			ctxLogger.Info(fmt.Sprintf("webhook delivery [%s] was claimed by another worker", delivery.ID))
+			continue
+		}
+
+		if _, ok := suspended[delivery.UserID]; !ok {
+			user, err := service.userRepository.Load(ctx, delivery.UserID)
+			if err != nil {
+				msg := fmt.Sprintf("cannot load user [%s] of webhook delivery [%s]", delivery.UserID, delivery.ID)
+				return 0, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+			}
+			suspended[delivery.UserID] = user.IsSuspended()
+		}
+
+		webhook, err := service.repository.Load(ctx, delivery.UserID, delivery.WebhookID)
+		if suspended[delivery.UserID] && err == nil {
+			delivery.Status = entities.WebhookDeliveryStatusHeld
+			delivery.NextAttemptAt = nil
+		} else if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+			delivery.Status = entities.WebhookDeliveryStatusFailed
+			delivery.LastError = "the webhook was deleted"
+			delivery.NextAttemptAt = nil
+		} else if err != nil {
+			msg := fmt.Sprintf("cannot load webhook [%s] of delivery [%s]", delivery.WebhookID, delivery.ID)
+			return 0, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+		} else {
+			service.attempt(ctx, webhook, delivery)
+		}
+
+		if err = service.deliveryRepository.Update(ctx, delivery); err != nil {
+			msg := fmt.Sprintf("cannot update webhook delivery [%s] after retry", delivery.ID)
+			return 0, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+		}
+	}
+
+	return len(deliveries), nil
+}
+
+// sendNotification records an entities.WebhookDelivery for an event and makes the first attempt unless the delivery is held
+func (service *WebhookService) sendNotification(ctx context.Context, event cloudevents.Event, webhook *entities.Webhook, held bool) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	payload, err := json.Marshal(service.getPayload(ctxLogger, event, webhook))
+	if err != nil {
+		msg := fmt.Sprintf("cannot encode [%s] event with ID [%s] for webhook [%s]", event.Type(), event.ID(), webhook.ID)
+		ctxLogger.Error(service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg)))
+		return
+	}
+
+	delivery := &entities.WebhookDelivery{
+		ID:          uuid.New(),
+		UserID:      webhook.UserID,
+		WebhookID:   webhook.ID,
+		EventID:     event.ID(),
+		EventType:   event.Type(),
+		Status:      entities.WebhookDeliveryStatusPending,
+		Payload:     string(payload),
+		MaxAttempts: service.maxAttempts,
+		CreatedAt:   time.Now().UTC(),
+		UpdatedAt:   time.Now().UTC(),
+	}
+
+	if held {
+		delivery.Status = entities.WebhookDeliveryStatusHeld
+		if err = service.deliveryRepository.Store(ctx, delivery); err != nil {
+			msg := fmt.Sprintf("cannot hold delivery of event [%s] to webhook [%s] for suspended user [%s]", event.ID(), webhook.ID, webhook.UserID)
+			ctxLogger.Error(service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg)))
+		}
+		return
+	}
+
+	stored := true
+	if err = service.deliveryRepository.Store(ctx, delivery); err != nil {
+		msg := fmt.Sprintf("cannot store delivery of event [%s] to webhook [%s], the event will not be retried", event.ID(), webhook.ID)
+		ctxLogger.Error(service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg)))
+		stored = false
+	}
+
+	service.attempt(ctx, webhook, delivery)
+	if !stored {
+		return
+	}
+
+	if err = service.deliveryRepository.Update(ctx, delivery); err != nil {
+		msg := fmt.Sprintf("cannot update delivery [%s] of event [%s] to webhook [%s]", delivery.ID, event.ID(), webhook.ID)
+		ctxLogger.Error(service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg)))
+	}
+}
+
+// attempt sends the payload of a delivery to the webhook and schedules the next attempt with an exponential backoff when it fails
+func (service *WebhookService) attempt(ctx context.Context, webhook *entities.Webhook, delivery *entities.WebhookDelivery) {
 	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
 	defer span.End()
 
@@ -191,32 +651,126 @@ func (service *WebhookService) sendNotification(ctx context.Context, event cloud
 		ctxLogger.Error(service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg)))
 	}
 
+	response, statusCode, err := service.post(ctx, webhook.URL, token, webhook, service.getVersion(webhook), delivery.EventType, delivery.ID.String(), []byte(delivery.Payload))
+
+	timestamp := time.Now().UTC()
+	delivery.LastStatusCode = statusCode
+	delivery.Attempts++
+	delivery.UpdatedAt = timestamp
+
+	if err == nil {
+		delivery.Status = entities.WebhookDeliveryStatusSucceeded
+		delivery.LastError = ""
+		delivery.NextAttemptAt = nil
+		delivery.DeliveredAt = &timestamp
+		ctxLogger.Info(fmt.Sprintf("sent webhook to url [%s] for event [%s] with ID [%s] and response [%s]", webhook.URL, delivery.EventType, delivery.EventID, response))
+		return
+	}
+
+	delivery.LastError = err.Error()
+
+	if delivery.Attempts >= delivery.MaxAttempts {
+		delivery.Status = entities.WebhookDeliveryStatusFailed
+		delivery.NextAttemptAt = nil
+		service.dispatchDeliveryFailed(ctx, webhook, delivery)
+	} else {
+		nextAttemptAt := timestamp.Add(service.backoff(delivery.Attempts))
+		delivery.Status = entities.WebhookDeliveryStatusRetrying
+		delivery.NextAttemptAt = &nextAttemptAt
+	}
+
+	msg := fmt.Sprintf("cannot send [%s] event to webhook [%s] for user [%s] on attempt [%d/%d]", delivery.EventType, webhook.URL, webhook.UserID, delivery.Attempts, delivery.MaxAttempts)
+	ctxLogger.Error(service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg)))
+}
+
+// post sends a signed payload to a URL, the status code is 0 when the URL did not respond
+func (service *WebhookService) post(ctx context.Context, url string, token string, webhook *entities.Webhook, version entities.WebhookVersion, eventType string, deliveryID string, payload []byte) (string, int, error) {
 	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
 
-	var response string
-	err = requests.URL(webhook.URL).
-		Client(service.client).
+	client, err := service.proxy.Client(webhook.ProxyURL)
+	if err != nil {
+		return "", 0, stacktrace.Propagate(err, fmt.Sprintf("cannot create http client for webhook [%s]", webhook.ID))
+	}
+
+	timestamp := time.Now().UTC()
+	builder := requests.URL(url).
+		Client(client).
 		Bearer(token).
-		Header("X-Event-Type", event.Type()).
-		BodyJSON(service.getPayload(ctxLogger, event, webhook)).
+		ContentType("application/json").
+		Header("X-Event-Type", eventType).
+		Header("X-Webhook-Version", string(version)).
+		Header("X-Delivery-ID", deliveryID).
+		Header(WebhookSignatureHeader, WebhookSignature(payload, timestamp, webhook.SigningKeys()...))
+
+	if service.signingKey != nil {
+		builder.Header(WebhookAsymmetricSignatureHeader, WebhookAsymmetricSignature(payload, timestamp, service.signingKey))
+	}
+
+	var response string
+	var statusCode int
+	err = builder.
+		BodyBytes(payload).
+		AddValidator(func(response *http.Response) error {
+			statusCode = response.StatusCode
+			return nil
+		}).
+		AddValidator(requests.DefaultValidator).
 		ToString(&response).
 		Fetch(ctx)
+
+	if err != nil && !errors.As(err, new(*requests.ResponseError)) {
+		statusCode = 0
+	}
+	return response, statusCode, err
+}
+
+// dispatchDeliveryFailed emits the events.EventTypeWebhookDeliveryFailed event when a delivery is moved to the dead-letter queue
+func (service *WebhookService) dispatchDeliveryFailed(ctx context.Context, webhook *entities.Webhook, delivery *entities.WebhookDelivery) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	event, err := service.createEvent(events.EventTypeWebhookDeliveryFailed, webhookDeliverySource, &events.WebhookDeliveryFailedPayload{
+		WebhookID:      webhook.ID,
+		DeliveryID:     delivery.ID,
+		UserID:         delivery.UserID,
+		URL:            webhook.URL,
+		EventID:        delivery.EventID,
+		EventType:      delivery.EventType,
+		Attempts:       delivery.Attempts,
+		LastStatusCode: delivery.LastStatusCode,
+		LastError:      delivery.LastError,
+		Timestamp:      delivery.UpdatedAt,
+		RedeliverURL:   fmt.Sprintf("%s/settings?webhook_id=%s&delivery_id=%s", service.appURL, webhook.ID, delivery.ID),
+	})
 	if err != nil {
-		msg := fmt.Sprintf("cannot send [%s] event to webhook [%s] for user [%s]", event.Type(), webhook.URL, webhook.UserID)
+		msg := fmt.Sprintf("cannot create [%s] event for delivery [%s]", events.EventTypeWebhookDeliveryFailed, delivery.ID)
 		ctxLogger.Error(service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg)))
+		return
 	}
 
-	ctxLogger.Info(fmt.Sprintf("sent webhook to url [%s] for event [%s] with ID [%s] and response [%s]", webhook.URL, event.Type(), event.ID(), response))
+	if err = service.dispatcher.Dispatch(ctx, event); err != nil {
+		msg := fmt.Sprintf("cannot dispatch [%s] event for delivery [%s]", event.Type(), delivery.ID)
+		ctxLogger.Error(service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg)))
+	}
 }
 
-func (service *WebhookService) getPayload(ctxLogger telemetry.Logger, event cloudevents.Event, webhook *entities.Webhook) any {
-	if event.Type() != events.EventTypeMessagePhoneReceived {
-		return event
+// backoff is the delay before the next attempt after a number of failed attempts
+func (service *WebhookService) backoff(attempts uint) time.Duration {
+	delay := webhookRetryBaseDelay
+	for i := uint(1); i < attempts && delay < webhookRetryMaxDelay; i++ {
+		delay *= 2
 	}
 
-	if !strings.HasPrefix(webhook.URL, "https://discord.com/api/webhooks/") {
-		return event
+	if delay > webhookRetryMaxDelay {
+		return webhookRetryMaxDelay
+	}
+	return delay
+}
+
+func (service *WebhookService) getPayload(ctxLogger telemetry.Logger, event cloudevents.Event, webhook *entities.Webhook) any {
+	if event.Type() != events.EventTypeMessagePhoneReceived || !strings.HasPrefix(webhook.URL, "https://discord.com/api/webhooks/") {
+		return service.convertPayload(ctxLogger, event, webhook)
 	}
 
 	payload := new(events.MessagePhoneReceivedPayload)
@@ -257,6 +811,32 @@ func (service *WebhookService) getPayload(ctxLogger telemetry.Logger, event clou
 	}
 }
 
+// convertPayload renders the event in the version pinned by the webhook
+func (service *WebhookService) convertPayload(ctxLogger telemetry.Logger, event cloudevents.Event, webhook *entities.Webhook) any {
+	converter, ok := webhookPayloadConverters[service.getVersion(webhook)]
+	if !ok {
+		ctxLogger.Error(stacktrace.NewError(fmt.Sprintf("no payload converter for version [%s] of webhook [%s]", webhook.Version, webhook.ID)))
+		return event
+	}
+
+	payload, err := converter(event)
+	if err != nil {
+		ctxLogger.Error(stacktrace.Propagate(err, fmt.Sprintf("cannot convert event [%s] to version [%s] for webhook [%s]", event.ID(), webhook.Version, webhook.ID)))
+		return event
+	}
+
+	return payload
+}
+
+func (service *WebhookService) getVersion(webhook *entities.Webhook) entities.WebhookVersion {
+	if webhook.Version == "" {
+		return entities.WebhookVersionV1
+	}
+	return webhook.Version
+}
+
+// getAuthToken creates the bearer token of a request to a webhook. The token has a single signature so it is signed with the
+// previous key until the key rotation is confirmed, otherwise receivers which still verify with the previous key reject it.
 func (service *WebhookService) getAuthToken(webhook *entities.Webhook) (string, error) {
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.StandardClaims{
 		Audience:  webhook.URL,
@@ -266,5 +846,16 @@ func (service *WebhookService) getAuthToken(webhook *entities.Webhook) (string,
 		NotBefore: time.Now().UTC().Add(-10 * time.Minute).Unix(),
 		Subject:   string(webhook.UserID),
 	})
+	if webhook.SecondarySigningKey != "" {
+		return token.SignedString([]byte(webhook.SecondarySigningKey))
+	}
 	return token.SignedString([]byte(webhook.SigningKey))
 }
+
+func (service *WebhookService) generateSigningKey() (string, error) {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return "", stacktrace.Propagate(err, fmt.Sprintf("cannot generate [%d] random bytes", len(key)))
+	}
+	return base64.RawURLEncoding.EncodeToString(key), nil
+}
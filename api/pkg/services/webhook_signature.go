@@ -0,0 +1,163 @@
+package services
+
+import (
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// WebhookSignatureHeader is the HTTP header which contains the signature of a webhook payload
+const WebhookSignatureHeader = "X-Httpsms-Signature"
+
+// WebhookAsymmetricSignatureHeader is the HTTP header which contains the Ed25519 signature of a webhook payload.
+// It is only sent when the instance has a webhook signing private key so receivers can verify payloads with the public key.
+const WebhookAsymmetricSignatureHeader = "X-Httpsms-Signature-Ed25519"
+
+// WebhookSignatureTolerance is the maximum age of a signature before it is considered as a replay
+const WebhookSignatureTolerance = 5 * time.Minute
+
+// WebhookSignature computes the header value for a webhook payload in the format "t=<timestamp>,v1=<signature>".
+// There is one v1 signature for every signing key so receivers can verify payloads while a key is being rotated.
+func WebhookSignature(payload []byte, timestamp time.Time, signingKeys ...string) string {
+	unix := strconv.FormatInt(timestamp.Unix(), 10)
+
+	parts := []string{"t=" + unix}
+	for _, key := range signingKeys {
+		if key == "" {
+			continue
+		}
+		parts = append(parts, "v1="+hex.EncodeToString(webhookHMAC(key, unix, payload)))
+	}
+	return strings.Join(parts, ",")
+}
+
+// VerifyWebhookSignature checks that the header was generated for the payload by one of the signing keys within the WebhookSignatureTolerance
+func VerifyWebhookSignature(header string, payload []byte, now time.Time, signingKeys ...string) error {
+	var timestamp string
+	var signatures [][]byte
+	for _, item := range strings.Split(header, ",") {
+		key, value, found := strings.Cut(strings.TrimSpace(item), "=")
+		if !found {
+			continue
+		}
+		switch key {
+		case "t":
+			timestamp = value
+		case "v1":
+			if signature, err := hex.DecodeString(value); err == nil {
+				signatures = append(signatures, signature)
+			}
+		}
+	}
+
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return fmt.Errorf("the timestamp [%s] in the signature header is invalid", timestamp)
+	}
+
+	if age := now.Sub(time.Unix(seconds, 0)); age > WebhookSignatureTolerance || age < -WebhookSignatureTolerance {
+		return fmt.Errorf("the signature timestamp [%d] is outside the tolerance of [%s]", seconds, WebhookSignatureTolerance)
+	}
+
+	for _, key := range signingKeys {
+		if key == "" {
+			continue
+		}
+		expected := webhookHMAC(key, timestamp, payload)
+		for _, signature := range signatures {
+			if hmac.Equal(expected, signature) {
+				return nil
+			}
+		}
+	}
+	return fmt.Errorf("no signature in the header matches the payload")
+}
+
+func webhookHMAC(signingKey string, timestamp string, payload []byte) []byte {
+	mac := hmac.New(sha256.New, []byte(signingKey))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
+
+// ParseWebhookSigningPrivateKey parses a base64 encoded Ed25519 private key or the 32 byte seed of the key
+func ParseWebhookSigningPrivateKey(value string) (ed25519.PrivateKey, error) {
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(value))
+	if err != nil {
+		return nil, fmt.Errorf("the webhook signing private key is not valid base64: %w", err)
+	}
+
+	switch len(key) {
+	case ed25519.SeedSize:
+		return ed25519.NewKeyFromSeed(key), nil
+	case ed25519.PrivateKeySize:
+		return ed25519.PrivateKey(key), nil
+	default:
+		return nil, fmt.Errorf("the webhook signing private key has [%d] bytes instead of [%d] or [%d]", len(key), ed25519.SeedSize, ed25519.PrivateKeySize)
+	}
+}
+
+// WebhookPublicKeyID is a short fingerprint of an Ed25519 public key so receivers can tell which key signed a payload while keys are being rotated
+func WebhookPublicKeyID(publicKey ed25519.PublicKey) string {
+	sum := sha256.Sum256(publicKey)
+	return hex.EncodeToString(sum[:8])
+}
+
+// WebhookAsymmetricSignature computes the header value for a webhook payload in the format "t=<timestamp>,kid=<key ID>,ed25519=<signature>".
+// The signature is the base64 encoded Ed25519 signature of "<timestamp>.<payload>".
+func WebhookAsymmetricSignature(payload []byte, timestamp time.Time, privateKey ed25519.PrivateKey) string {
+	unix := strconv.FormatInt(timestamp.Unix(), 10)
+	signature := ed25519.Sign(privateKey, webhookSignedContent(unix, payload))
+	return fmt.Sprintf(
+		"t=%s,kid=%s,ed25519=%s",
+		unix,
+		WebhookPublicKeyID(privateKey.Public().(ed25519.PublicKey)),
+		base64.StdEncoding.EncodeToString(signature),
+	)
+}
+
+// VerifyWebhookAsymmetricSignature checks that the header was generated for the payload by the private key of the public key within the WebhookSignatureTolerance
+func VerifyWebhookAsymmetricSignature(header string, payload []byte, now time.Time, publicKey ed25519.PublicKey) error {
+	var timestamp string
+	var signature []byte
+	for _, item := range strings.Split(header, ",") {
+		key, value, found := strings.Cut(strings.TrimSpace(item), "=")
+		if !found {
+			continue
+		}
+		switch key {
+		case "t":
+			timestamp = value
+		case "ed25519":
+			signature, _ = base64.StdEncoding.DecodeString(value)
+		}
+	}
+
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return fmt.Errorf("the timestamp [%s] in the signature header is invalid", timestamp)
+	}
+
+	if age := now.Sub(time.Unix(seconds, 0)); age > WebhookSignatureTolerance || age < -WebhookSignatureTolerance {
+		return fmt.Errorf("the signature timestamp [%d] is outside the tolerance of [%s]", seconds, WebhookSignatureTolerance)
+	}
+
+	if len(publicKey) != ed25519.PublicKeySize {
+		return fmt.Errorf("the public key has [%d] bytes instead of [%d]", len(publicKey), ed25519.PublicKeySize)
+	}
+
+	if !ed25519.Verify(publicKey, webhookSignedContent(timestamp, payload), signature) {
+		return fmt.Errorf("the ed25519 signature in the header does not match the payload")
+	}
+	return nil
+}
+
+func webhookSignedContent(timestamp string, payload []byte) []byte {
+	return append([]byte(timestamp+"."), payload...)
+}
@@ -0,0 +1,47 @@
+package telemetry
+
+import (
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ErrorReporter sends errors to an error tracking service
+type ErrorReporter interface {
+	// CaptureError reports an error, the span context links the error to the trace which caused it
+	CaptureError(err error, span *trace.SpanContext)
+
+	// AddBreadcrumb records an event which is sent with the errors reported in the same trace
+	AddBreadcrumb(level string, message string, span *trace.SpanContext)
+
+	// Flush waits until the buffered errors are sent or the timeout expires
+	Flush(timeout time.Duration) bool
+}
+
+// ErrorReporterConfig is the configuration of an ErrorReporter
+type ErrorReporterConfig struct {
+	DSN         string
+	Environment string
+	Release     string
+
+	// SampleRate is the fraction of errors which are reported, between 0 and 1
+	SampleRate float64
+
+	// ScrubPII removes message content and phone numbers from errors and breadcrumbs before they are sent
+	ScrubPII bool
+}
+
+type noopErrorReporter struct{}
+
+// NewNoopErrorReporter creates an ErrorReporter which discards all errors
+func NewNoopErrorReporter() ErrorReporter {
+	return &noopErrorReporter{}
+}
+
+func (reporter *noopErrorReporter) CaptureError(_ error, _ *trace.SpanContext) {}
+
+func (reporter *noopErrorReporter) AddBreadcrumb(_ string, _ string, _ *trace.SpanContext) {}
+
+func (reporter *noopErrorReporter) Flush(_ time.Duration) bool {
+	return true
+}
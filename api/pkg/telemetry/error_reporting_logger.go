@@ -0,0 +1,82 @@
+package telemetry
+
+import (
+	"fmt"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// errorReportingFlushTimeout is how long a fatal error waits to be reported before the process exits
+const errorReportingFlushTimeout = 2 * time.Second
+
+type errorReportingLogger struct {
+	logger      Logger
+	reporter    ErrorReporter
+	spanContext *trace.SpanContext
+}
+
+// NewErrorReportingLogger creates a Logger which sends errors to an ErrorReporter.
+// Informational messages and warnings are recorded as breadcrumbs of the trace in which they are logged.
+func NewErrorReportingLogger(logger Logger, reporter ErrorReporter) Logger {
+	return &errorReportingLogger{
+		logger:   logger,
+		reporter: reporter,
+	}
+}
+
+func (logger *errorReportingLogger) Error(err error) {
+	logger.logger.Error(err)
+	logger.reporter.CaptureError(err, logger.spanContext)
+}
+
+func (logger *errorReportingLogger) WithService(service string) Logger {
+	return logger.wrap(logger.logger.WithService(service), logger.spanContext)
+}
+
+func (logger *errorReportingLogger) WithString(key string, value string) Logger {
+	return logger.wrap(logger.logger.WithString(key, value), logger.spanContext)
+}
+
+func (logger *errorReportingLogger) WithSpan(spanContext trace.SpanContext) Logger {
+	return logger.wrap(logger.logger.WithSpan(spanContext), &spanContext)
+}
+
+func (logger *errorReportingLogger) Trace(value string) {
+	logger.logger.Trace(value)
+}
+
+func (logger *errorReportingLogger) Info(value string) {
+	logger.logger.Info(value)
+	logger.reporter.AddBreadcrumb("info", value, logger.spanContext)
+}
+
+func (logger *errorReportingLogger) Warn(err error) {
+	logger.logger.Warn(err)
+	if err != nil {
+		logger.reporter.AddBreadcrumb("warning", err.Error(), logger.spanContext)
+	}
+}
+
+func (logger *errorReportingLogger) Debug(value string) {
+	logger.logger.Debug(value)
+}
+
+func (logger *errorReportingLogger) Fatal(err error) {
+	logger.reporter.CaptureError(err, logger.spanContext)
+	logger.reporter.Flush(errorReportingFlushTimeout)
+	logger.logger.Fatal(err)
+}
+
+func (logger *errorReportingLogger) Printf(format string, values ...interface{}) {
+	logger.logger.Printf(format, values...)
+	logger.reporter.AddBreadcrumb("info", fmt.Sprintf(format, values...), logger.spanContext)
+}
+
+func (logger *errorReportingLogger) wrap(inner Logger, spanContext *trace.SpanContext) Logger {
+	return &errorReportingLogger{
+		logger:      inner,
+		reporter:    logger.reporter,
+		spanContext: spanContext,
+	}
+}
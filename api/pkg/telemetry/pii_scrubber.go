@@ -0,0 +1,53 @@
+package telemetry
+
+import (
+	"regexp"
+	"strings"
+)
+
+// piiFilteredValue replaces the personal data which is removed from errors
+const piiFilteredValue = "[Filtered]"
+
+var (
+	// piiPhoneNumberPattern matches E.164 phone numbers including URL encoded ones
+	piiPhoneNumberPattern = regexp.MustCompile(`(\+|%2[bB])[1-9]\d{6,14}`)
+
+	// piiContentPatterns match the message content in JSON payloads and in structs formatted with %+v or %+#v
+	piiContentPatterns = []*regexp.Regexp{
+		regexp.MustCompile(`(?i)("(?:content|body|text)"\s*:\s*)"(?:[^"\\]|\\.)*"`),
+		regexp.MustCompile(`((?:Content|Body|Text):)"(?:[^"\\]|\\.)*"`),
+		regexp.MustCompile(`((?:Content|Body|Text):)[^"\s,}][^\s,}]*`),
+	}
+
+	// piiKeys are the keys of structured data whose values are always removed
+	piiKeys = map[string]bool{
+		"content": true,
+		"body":    true,
+		"text":    true,
+	}
+)
+
+// scrubPII removes message content and phone numbers from a string
+func scrubPII(value string) string {
+	for _, pattern := range piiContentPatterns {
+		value = pattern.ReplaceAllString(value, `${1}"`+piiFilteredValue+`"`)
+	}
+	return piiPhoneNumberPattern.ReplaceAllString(value, piiFilteredValue)
+}
+
+// scrubPIIMap removes message content and phone numbers from the values of structured data
+func scrubPIIMap(values map[string]any) {
+	for key, value := range values {
+		if piiKeys[strings.ToLower(key)] {
+			values[key] = piiFilteredValue
+			continue
+		}
+
+		switch typed := value.(type) {
+		case string:
+			values[key] = scrubPII(typed)
+		case map[string]any:
+			scrubPIIMap(typed)
+		}
+	}
+}
@@ -0,0 +1,161 @@
+package telemetry
+
+import (
+	"sync"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/palantir/stacktrace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	// sentryMaxTraces is the number of traces whose breadcrumbs are kept in memory
+	sentryMaxTraces = 1000
+
+	// sentryMaxBreadcrumbs is the number of breadcrumbs which are kept for each trace
+	sentryMaxBreadcrumbs = 30
+)
+
+// sentryErrorReporter reports errors to Sentry or to any service which is compatible with the Sentry protocol
+type sentryErrorReporter struct {
+	scrubPII    bool
+	mutex       sync.Mutex
+	traces      []string
+	breadcrumbs map[string][]*sentry.Breadcrumb
+}
+
+// NewSentryErrorReporter creates an ErrorReporter which sends errors to the Sentry DSN
+func NewSentryErrorReporter(config ErrorReporterConfig) (ErrorReporter, error) {
+	reporter := &sentryErrorReporter{
+		scrubPII:    config.ScrubPII,
+		breadcrumbs: map[string][]*sentry.Breadcrumb{},
+	}
+
+	err := sentry.Init(sentry.ClientOptions{
+		Dsn:              config.DSN,
+		Environment:      config.Environment,
+		Release:          config.Release,
+		SampleRate:       config.SampleRate,
+		AttachStacktrace: true,
+		MaxBreadcrumbs:   sentryMaxBreadcrumbs,
+		BeforeSend:       reporter.beforeSend,
+		BeforeBreadcrumb: reporter.beforeBreadcrumb,
+	})
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "cannot initialize the sentry client")
+	}
+
+	return reporter, nil
+}
+
+// CaptureError sends an error to sentry with the breadcrumbs of its trace
+func (reporter *sentryErrorReporter) CaptureError(err error, span *trace.SpanContext) {
+	if err == nil {
+		return
+	}
+
+	hub := sentry.CurrentHub().Clone()
+	hub.ConfigureScope(func(scope *sentry.Scope) {
+		if span == nil || !span.IsValid() {
+			return
+		}
+
+		scope.SetTag("trace_id", span.TraceID().String())
+		scope.SetTag("span_id", span.SpanID().String())
+		for _, breadcrumb := range reporter.traceBreadcrumbs(span.TraceID().String()) {
+			scope.AddBreadcrumb(breadcrumb, sentryMaxBreadcrumbs)
+		}
+	})
+
+	hub.CaptureException(err)
+}
+
+// AddBreadcrumb stores a breadcrumb until an error is reported in the same trace.
+// Breadcrumbs are not recorded on the global scope because it is shared by concurrent requests.
+func (reporter *sentryErrorReporter) AddBreadcrumb(level string, message string, span *trace.SpanContext) {
+	if span == nil || !span.IsValid() {
+		return
+	}
+
+	breadcrumb := &sentry.Breadcrumb{
+		Type:      "default",
+		Category:  "log",
+		Message:   message,
+		Level:     sentry.Level(level),
+		Timestamp: time.Now().UTC(),
+	}
+
+	reporter.mutex.Lock()
+	defer reporter.mutex.Unlock()
+
+	traceID := span.TraceID().String()
+	if _, ok := reporter.breadcrumbs[traceID]; !ok {
+		if len(reporter.traces) == sentryMaxTraces {
+			delete(reporter.breadcrumbs, reporter.traces[0])
+			reporter.traces = reporter.traces[1:]
+		}
+		reporter.traces = append(reporter.traces, traceID)
+	}
+
+	breadcrumbs := append(reporter.breadcrumbs[traceID], breadcrumb)
+	if len(breadcrumbs) > sentryMaxBreadcrumbs {
+		breadcrumbs = breadcrumbs[len(breadcrumbs)-sentryMaxBreadcrumbs:]
+	}
+	reporter.breadcrumbs[traceID] = breadcrumbs
+}
+
+// Flush waits until the buffered errors are sent to sentry
+func (reporter *sentryErrorReporter) Flush(timeout time.Duration) bool {
+	return sentry.Flush(timeout)
+}
+
+func (reporter *sentryErrorReporter) traceBreadcrumbs(traceID string) []*sentry.Breadcrumb {
+	reporter.mutex.Lock()
+	defer reporter.mutex.Unlock()
+
+	breadcrumbs := make([]*sentry.Breadcrumb, len(reporter.breadcrumbs[traceID]))
+	copy(breadcrumbs, reporter.breadcrumbs[traceID])
+	return breadcrumbs
+}
+
+func (reporter *sentryErrorReporter) beforeBreadcrumb(breadcrumb *sentry.Breadcrumb, _ *sentry.BreadcrumbHint) *sentry.Breadcrumb {
+	if reporter.scrubPII {
+		reporter.scrubBreadcrumb(breadcrumb)
+	}
+	return breadcrumb
+}
+
+// beforeSend removes personal data from the message, the exceptions and their stack traces, the breadcrumbs and the request of an event
+func (reporter *sentryErrorReporter) beforeSend(event *sentry.Event, _ *sentry.EventHint) *sentry.Event {
+	if !reporter.scrubPII {
+		return event
+	}
+
+	event.Message = scrubPII(event.Message)
+	for i := range event.Exception {
+		event.Exception[i].Value = scrubPII(event.Exception[i].Value)
+	}
+
+	for _, breadcrumb := range event.Breadcrumbs {
+		reporter.scrubBreadcrumb(breadcrumb)
+	}
+
+	for key, value := range event.Tags {
+		event.Tags[key] = scrubPII(value)
+	}
+	scrubPIIMap(event.Extra)
+
+	if event.Request != nil {
+		event.Request.URL = scrubPII(event.Request.URL)
+		event.Request.QueryString = scrubPII(event.Request.QueryString)
+		event.Request.Data = scrubPII(event.Request.Data)
+	}
+
+	return event
+}
+
+func (reporter *sentryErrorReporter) scrubBreadcrumb(breadcrumb *sentry.Breadcrumb) {
+	breadcrumb.Message = scrubPII(breadcrumb.Message)
+	scrubPIIMap(breadcrumb.Data)
+}
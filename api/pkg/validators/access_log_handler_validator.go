@@ -0,0 +1,59 @@
+package validators
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+
+	"github.com/NdoleStudio/httpsms/pkg/requests"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/thedevsaddam/govalidator"
+)
+
+// AccessLogHandlerValidator validates models used in handlers.AccessLogHandler
+type AccessLogHandlerValidator struct {
+	validator
+	logger telemetry.Logger
+	tracer telemetry.Tracer
+}
+
+// NewAccessLogHandlerValidator creates a new handlers.AccessLogHandler validator
+func NewAccessLogHandlerValidator(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+) (v *AccessLogHandlerValidator) {
+	return &AccessLogHandlerValidator{
+		logger: logger.WithService(fmt.Sprintf("%T", v)),
+		tracer: tracer,
+	}
+}
+
+// ValidateIndex validates the requests.AccessLogIndex request
+func (validator *AccessLogHandlerValidator) ValidateIndex(_ context.Context, request requests.AccessLogIndex) url.Values {
+	v := govalidator.New(govalidator.Options{
+		Data: &request,
+		Rules: govalidator.MapData{
+			"limit": []string{
+				"required",
+				"numeric",
+				"min:1",
+				"max:100",
+			},
+			"skip": []string{
+				"required",
+				"numeric",
+				"min:0",
+			},
+			"query": []string{
+				"max:100",
+			},
+			"user_id": []string{
+				"max:255",
+			},
+			"status": []string{
+				"numeric_between:100,599",
+			},
+		},
+	})
+	return v.ValidateStruct()
+}
@@ -0,0 +1,71 @@
+package validators
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/requests"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/thedevsaddam/govalidator"
+)
+
+// ActivityHandlerValidator validates models used in handlers.ActivityHandler
+type ActivityHandlerValidator struct {
+	validator
+	logger telemetry.Logger
+	tracer telemetry.Tracer
+}
+
+// NewActivityHandlerValidator creates a new handlers.ActivityHandler validator
+func NewActivityHandlerValidator(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+) (v *ActivityHandlerValidator) {
+	return &ActivityHandlerValidator{
+		logger: logger.WithService(fmt.Sprintf("%T", v)),
+		tracer: tracer,
+	}
+}
+
+// ValidateIndex validates the requests.ActivityIndex request
+func (validator *ActivityHandlerValidator) ValidateIndex(_ context.Context, request requests.ActivityIndex) url.Values {
+	v := govalidator.New(govalidator.Options{
+		Data: &request,
+		Rules: govalidator.MapData{
+			"limit": []string{
+				"required",
+				"numeric",
+				"min:1",
+				"max:100",
+			},
+		},
+	})
+
+	result := v.ValidateStruct()
+	if len(result) != 0 {
+		return result
+	}
+
+	categories := map[string]bool{}
+	names := make([]string, 0, len(entities.ActivityCategories))
+	for _, category := range entities.ActivityCategories {
+		categories[string(category)] = true
+		names = append(names, string(category))
+	}
+
+	for _, category := range strings.Split(request.Category, ",") {
+		if category != "" && !categories[category] {
+			result.Add("category", fmt.Sprintf("The category [%s] is invalid, it must be one of [%s]", category, strings.Join(names, ", ")))
+		}
+	}
+
+	if _, err := services.DecodeEventCursor(request.Cursor); request.Cursor != "" && err != nil {
+		result.Add("cursor", "The cursor field must be the next_cursor returned by the previous page")
+	}
+
+	return result
+}
@@ -0,0 +1,116 @@
+package validators
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/requests"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/thedevsaddam/govalidator"
+)
+
+// AlertChannelHandlerValidator validates models used in handlers.AlertChannelHandler
+type AlertChannelHandlerValidator struct {
+	validator
+	logger telemetry.Logger
+	tracer telemetry.Tracer
+}
+
+// NewAlertChannelHandlerValidator creates a new handlers.AlertChannelHandler validator
+func NewAlertChannelHandlerValidator(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+) (v *AlertChannelHandlerValidator) {
+	return &AlertChannelHandlerValidator{
+		logger: logger.WithService(fmt.Sprintf("%T", v)),
+		tracer: tracer,
+	}
+}
+
+// ValidateIndex validates the requests.AlertChannelIndex request
+func (validator *AlertChannelHandlerValidator) ValidateIndex(_ context.Context, request requests.AlertChannelIndex) url.Values {
+	v := govalidator.New(govalidator.Options{
+		Data: &request,
+		Rules: govalidator.MapData{
+			"limit": []string{
+				"required",
+				"numeric",
+				"min:1",
+				"max:100",
+			},
+			"skip": []string{
+				"required",
+				"numeric",
+				"min:0",
+			},
+			"query": []string{
+				"max:100",
+			},
+		},
+	})
+	return v.ValidateStruct()
+}
+
+// ValidateStore validates the requests.AlertChannelStore request
+func (validator *AlertChannelHandlerValidator) ValidateStore(_ context.Context, request requests.AlertChannelStore) url.Values {
+	v := govalidator.New(govalidator.Options{
+		Data: &request,
+		Rules: govalidator.MapData{
+			"name": []string{
+				"required",
+				"min:1",
+				"max:100",
+			},
+			"type": []string{
+				"required",
+				"in:" + string(entities.AlertChannelTypeSlack) + "," + string(entities.AlertChannelTypeDiscord),
+			},
+			"webhook_url": []string{
+				"required",
+				"url",
+				"max:255",
+			},
+			"proxy_url": []string{
+				"max:1000",
+				egressProxyURLRule,
+			},
+		},
+	})
+
+	result := v.ValidateStruct()
+	if request.WebhookURL != "" && !entities.AlertChannelType(request.Type).AllowsWebhookURL(request.WebhookURL) {
+		result.Add("webhook_url", fmt.Sprintf("The webhook_url field must be a [%s] webhook URL", request.Type))
+	}
+
+	if len(request.Events) == 0 {
+		result.Add("events", "The events field must contain at least 1 event")
+	}
+
+	for _, event := range request.Events {
+		if !validator.isAlertChannelEvent(event) {
+			result.Add("events", fmt.Sprintf("The events field has an invalid event [%s], it must be one of [%s]", event, strings.Join(services.AlertChannelEvents, ", ")))
+		}
+	}
+	return result
+}
+
+// ValidateUpdate validates the requests.AlertChannelUpdate request
+func (validator *AlertChannelHandlerValidator) ValidateUpdate(ctx context.Context, request requests.AlertChannelUpdate) url.Values {
+	if result := validator.ValidateUUID(ctx, request.ChannelID, "channelID"); len(result) != 0 {
+		return result
+	}
+	return validator.ValidateStore(ctx, request.AlertChannelStore)
+}
+
+func (validator *AlertChannelHandlerValidator) isAlertChannelEvent(event string) bool {
+	for _, value := range services.AlertChannelEvents {
+		if value == event {
+			return true
+		}
+	}
+	return false
+}
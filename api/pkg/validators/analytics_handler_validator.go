@@ -0,0 +1,137 @@
+package validators
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/requests"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/thedevsaddam/govalidator"
+)
+
+// AnalyticsHandlerValidator validates models used in handlers.AnalyticsHandler
+type AnalyticsHandlerValidator struct {
+	validator
+	logger telemetry.Logger
+	tracer telemetry.Tracer
+}
+
+// NewAnalyticsHandlerValidator creates a new handlers.AnalyticsHandler validator
+func NewAnalyticsHandlerValidator(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+) (v *AnalyticsHandlerValidator) {
+	return &AnalyticsHandlerValidator{
+		logger: logger.WithService(fmt.Sprintf("%T", v)),
+		tracer: tracer,
+	}
+}
+
+// ValidateTimeSeries validates the requests.AnalyticsTimeSeries request
+func (validator *AnalyticsHandlerValidator) ValidateTimeSeries(_ context.Context, request requests.AnalyticsTimeSeries) url.Values {
+	v := govalidator.New(govalidator.Options{
+		Data: &request,
+		Rules: govalidator.MapData{
+			"metric": []string{
+				"required",
+				"in:" + strings.Join([]string{
+					string(entities.TimeSeriesMetricSent),
+					string(entities.TimeSeriesMetricDelivered),
+					string(entities.TimeSeriesMetricFailed),
+					string(entities.TimeSeriesMetricReceived),
+					string(entities.TimeSeriesMetricLatency),
+				}, ","),
+			},
+			"granularity": []string{
+				"required",
+				"in:" + strings.Join([]string{
+					string(entities.TimeSeriesGranularityHour),
+					string(entities.TimeSeriesGranularityDay),
+				}, ","),
+			},
+			"group_by": []string{
+				"in:" + strings.Join([]string{
+					string(entities.TimeSeriesGroupPhone),
+					string(entities.TimeSeriesGroupSIM),
+				}, ","),
+			},
+			"from": []string{
+				"required",
+			},
+			"to": []string{
+				"required",
+			},
+		},
+	})
+
+	result := v.ValidateStruct()
+	if len(result) != 0 {
+		return result
+	}
+
+	maxDays := 366
+	if request.Granularity == string(entities.TimeSeriesGranularityHour) {
+		maxDays = 31
+	}
+
+	return validator.validateTimeRange(result, request.From, request.To, maxDays)
+}
+
+// ValidateFailureStats validates the requests.AnalyticsFailureStats request
+func (validator *AnalyticsHandlerValidator) ValidateFailureStats(_ context.Context, request requests.AnalyticsFailureStats) url.Values {
+	v := govalidator.New(govalidator.Options{
+		Data: &request,
+		Rules: govalidator.MapData{
+			"group_by": []string{
+				"in:" + strings.Join([]string{
+					string(entities.TimeSeriesGroupPhone),
+					string(entities.TimeSeriesGroupSIM),
+				}, ","),
+			},
+			"from": []string{
+				"required",
+			},
+			"to": []string{
+				"required",
+			},
+		},
+	})
+
+	result := v.ValidateStruct()
+	if len(result) != 0 {
+		return result
+	}
+
+	return validator.validateTimeRange(result, request.From, request.To, 366)
+}
+
+// ValidateCarrierFiltering validates the requests.AnalyticsCarrierFiltering request
+func (validator *AnalyticsHandlerValidator) ValidateCarrierFiltering(_ context.Context, request requests.AnalyticsCarrierFiltering) url.Values {
+	v := govalidator.New(govalidator.Options{
+		Data: &request,
+		Rules: govalidator.MapData{
+			"prefix_length": []string{
+				"required",
+				"numeric",
+				"min:2",
+				"max:8",
+			},
+			"from": []string{
+				"required",
+			},
+			"to": []string{
+				"required",
+			},
+		},
+	})
+
+	result := v.ValidateStruct()
+	if len(result) != 0 {
+		return result
+	}
+
+	return validator.validateTimeRange(result, request.From, request.To, 31)
+}
@@ -0,0 +1,131 @@
+package validators
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+
+	"github.com/NdoleStudio/httpsms/pkg/requests"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/thedevsaddam/govalidator"
+)
+
+// AuthHandlerValidator validates models used in handlers.AuthHandler
+type AuthHandlerValidator struct {
+	validator
+	logger telemetry.Logger
+	tracer telemetry.Tracer
+}
+
+const (
+	// minPasswordLength is the shortest password which can be chosen when signing up
+	minPasswordLength = 8
+
+	// maxPasswordLength is the longest password in bytes accepted by bcrypt
+	maxPasswordLength = 72
+)
+
+// NewAuthHandlerValidator creates a new handlers.AuthHandler validator
+func NewAuthHandlerValidator(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+) (v *AuthHandlerValidator) {
+	return &AuthHandlerValidator{
+		logger: logger.WithService(fmt.Sprintf("%T", v)),
+		tracer: tracer,
+	}
+}
+
+// ValidateSignup validates the requests.AuthSignup request
+func (validator *AuthHandlerValidator) ValidateSignup(_ context.Context, request requests.AuthSignup) url.Values {
+	v := govalidator.New(govalidator.Options{
+		Data: &request,
+		Rules: govalidator.MapData{
+			"email": []string{
+				"required",
+				"email",
+				"max:255",
+			},
+			"password": validator.passwordRules(),
+		},
+	})
+	result := v.ValidateStruct()
+	validator.validatePasswordBytes(result, request.Password)
+	return result
+}
+
+// ValidateLogin validates the requests.AuthLogin request
+func (validator *AuthHandlerValidator) ValidateLogin(_ context.Context, request requests.AuthLogin) url.Values {
+	v := govalidator.New(govalidator.Options{
+		Data: &request,
+		Rules: govalidator.MapData{
+			"email": []string{
+				"required",
+				"email",
+			},
+			"password": []string{
+				"required",
+			},
+		},
+	})
+	return v.ValidateStruct()
+}
+
+// ValidateVerifyEmail validates the requests.AuthVerifyEmail request
+func (validator *AuthHandlerValidator) ValidateVerifyEmail(_ context.Context, request requests.AuthVerifyEmail) url.Values {
+	v := govalidator.New(govalidator.Options{
+		Data: &request,
+		Rules: govalidator.MapData{
+			"token": []string{
+				"required",
+			},
+		},
+	})
+	return v.ValidateStruct()
+}
+
+// ValidateForgotPassword validates the requests.AuthForgotPassword request
+func (validator *AuthHandlerValidator) ValidateForgotPassword(_ context.Context, request requests.AuthForgotPassword) url.Values {
+	v := govalidator.New(govalidator.Options{
+		Data: &request,
+		Rules: govalidator.MapData{
+			"email": []string{
+				"required",
+				"email",
+			},
+		},
+	})
+	return v.ValidateStruct()
+}
+
+// ValidateResetPassword validates the requests.AuthResetPassword request
+func (validator *AuthHandlerValidator) ValidateResetPassword(_ context.Context, request requests.AuthResetPassword) url.Values {
+	v := govalidator.New(govalidator.Options{
+		Data: &request,
+		Rules: govalidator.MapData{
+			"token": []string{
+				"required",
+			},
+			"password": validator.passwordRules(),
+		},
+	})
+	result := v.ValidateStruct()
+	validator.validatePasswordBytes(result, request.Password)
+	return result
+}
+
+func (validator *AuthHandlerValidator) passwordRules() []string {
+	return []string{
+		"required",
+		fmt.Sprintf("min:%d", minPasswordLength),
+		fmt.Sprintf("max:%d", maxPasswordLength),
+	}
+}
+
+// validatePasswordBytes checks the length of the password in bytes because bcrypt ignores the bytes after the first 72
+// and the max rule counts characters, which are more than 1 byte when they are not ASCII.
+func (validator *AuthHandlerValidator) validatePasswordBytes(result url.Values, password string) {
+	if len([]byte(password)) > maxPasswordLength {
+		result.Add("password", fmt.Sprintf("The password field must be maximum %d bytes", maxPasswordLength))
+	}
+}
@@ -0,0 +1,25 @@
+package validators
+
+import (
+	"fmt"
+
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+)
+
+// AuthSessionHandlerValidator validates models used in handlers.AuthSessionHandler
+type AuthSessionHandlerValidator struct {
+	validator
+	logger telemetry.Logger
+	tracer telemetry.Tracer
+}
+
+// NewAuthSessionHandlerValidator creates a new handlers.AuthSessionHandler validator
+func NewAuthSessionHandlerValidator(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+) (v *AuthSessionHandlerValidator) {
+	return &AuthSessionHandlerValidator{
+		logger: logger.WithService(fmt.Sprintf("%T", v)),
+		tracer: tracer,
+	}
+}
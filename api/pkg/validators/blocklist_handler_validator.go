@@ -0,0 +1,83 @@
+package validators
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+
+	"github.com/NdoleStudio/httpsms/pkg/requests"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/thedevsaddam/govalidator"
+)
+
+// blocklistImportMaxBytes is the maximum size of a CSV file which can be imported
+const blocklistImportMaxBytes = 4 * 1024 * 1024
+
+// BlocklistHandlerValidator validates models used in handlers.BlocklistHandler
+type BlocklistHandlerValidator struct {
+	validator
+	logger telemetry.Logger
+	tracer telemetry.Tracer
+}
+
+// NewBlocklistHandlerValidator creates a new handlers.BlocklistHandler validator
+func NewBlocklistHandlerValidator(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+) (v *BlocklistHandlerValidator) {
+	return &BlocklistHandlerValidator{
+		logger: logger.WithService(fmt.Sprintf("%T", v)),
+		tracer: tracer,
+	}
+}
+
+// ValidateIndex validates the requests.BlocklistIndex request
+func (validator *BlocklistHandlerValidator) ValidateIndex(_ context.Context, request requests.BlocklistIndex) url.Values {
+	v := govalidator.New(govalidator.Options{
+		Data: &request,
+		Rules: govalidator.MapData{
+			"limit": []string{
+				"required",
+				"numeric",
+				"min:1",
+				"max:100",
+			},
+			"skip": []string{
+				"required",
+				"numeric",
+				"min:0",
+			},
+			"query": []string{
+				"max:100",
+			},
+		},
+	})
+	return v.ValidateStruct()
+}
+
+// ValidateImport validates the requests.BlocklistImport request
+func (validator *BlocklistHandlerValidator) ValidateImport(_ context.Context, request requests.BlocklistImport) url.Values {
+	v := govalidator.New(govalidator.Options{
+		Data: &request,
+		Rules: govalidator.MapData{
+			"dry_run": []string{
+				"required",
+				"in:true,false",
+			},
+			"reason": []string{
+				"required",
+				"in:opt-out,blocked",
+			},
+		},
+	})
+
+	result := v.ValidateStruct()
+	if len(request.Content) == 0 {
+		result.Add("document", "The document field must be a CSV file containing phone numbers")
+	}
+	if len(request.Content) > blocklistImportMaxBytes {
+		result.Add("document", fmt.Sprintf("The document field must be a CSV file smaller than %d bytes", blocklistImportMaxBytes))
+	}
+
+	return result
+}
@@ -0,0 +1,104 @@
+package validators
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/requests"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/thedevsaddam/govalidator"
+)
+
+// BroadcastHandlerValidator validates models used in handlers.BroadcastHandler
+type BroadcastHandlerValidator struct {
+	validator
+	logger telemetry.Logger
+	tracer telemetry.Tracer
+}
+
+// NewBroadcastHandlerValidator creates a new handlers.BroadcastHandler validator
+func NewBroadcastHandlerValidator(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+) (v *BroadcastHandlerValidator) {
+	return &BroadcastHandlerValidator{
+		logger: logger.WithService(fmt.Sprintf("%T", v)),
+		tracer: tracer,
+	}
+}
+
+// ValidateIndex validates the requests.BroadcastIndex request
+func (validator *BroadcastHandlerValidator) ValidateIndex(_ context.Context, request requests.BroadcastIndex) url.Values {
+	v := govalidator.New(govalidator.Options{
+		Data: &request,
+		Rules: govalidator.MapData{
+			"limit": []string{
+				"required",
+				"numeric",
+				"min:1",
+				"max:100",
+			},
+			"skip": []string{
+				"required",
+				"numeric",
+				"min:0",
+			},
+			"query": []string{
+				"max:100",
+			},
+		},
+	})
+	return v.ValidateStruct()
+}
+
+// ValidateStore validates the requests.BroadcastStore request
+func (validator *BroadcastHandlerValidator) ValidateStore(_ context.Context, request requests.BroadcastStore) url.Values {
+	v := govalidator.New(govalidator.Options{
+		Data: &request,
+		Rules: govalidator.MapData{
+			"title": []string{
+				"required",
+				"min:1",
+				"max:150",
+			},
+			"body": []string{
+				"required",
+				"min:1",
+				"max:5000",
+			},
+			"severity": []string{
+				"required",
+				"in:" + strings.Join([]string{
+					string(entities.BroadcastSeverityInfo),
+					string(entities.BroadcastSeverityWarning),
+					string(entities.BroadcastSeverityCritical),
+				}, ","),
+			},
+		},
+	})
+
+	result := v.ValidateStruct()
+
+	supported := map[string]bool{}
+	for _, channel := range entities.BroadcastChannels {
+		supported[string(channel)] = true
+	}
+	for _, channel := range request.Channels {
+		if !supported[channel] {
+			result.Add("channels", fmt.Sprintf("The channels field contains an unsupported channel [%s]", channel))
+		}
+	}
+
+	if len(request.UserIDs) > 1000 {
+		result.Add("user_ids", "The user_ids field cannot contain more than 1000 users")
+	}
+
+	if request.StartsAt != nil && request.EndsAt != nil && !request.EndsAt.After(*request.StartsAt) {
+		result.Add("ends_at", "The ends_at field must be after the starts_at field")
+	}
+
+	return result
+}
@@ -0,0 +1,164 @@
+package validators
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
+	"github.com/NdoleStudio/httpsms/pkg/requests"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/palantir/stacktrace"
+	"github.com/thedevsaddam/govalidator"
+)
+
+const (
+	campaignMinIntervalMinutes = 15
+	campaignMaxIntervalMinutes = 366 * 24 * 60
+)
+
+// CampaignHandlerValidator validates models used in handlers.CampaignHandler
+type CampaignHandlerValidator struct {
+	validator
+	logger       telemetry.Logger
+	tracer       telemetry.Tracer
+	phoneService *services.PhoneService
+}
+
+// NewCampaignHandlerValidator creates a new handlers.CampaignHandler validator
+func NewCampaignHandlerValidator(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	phoneService *services.PhoneService,
+) (v *CampaignHandlerValidator) {
+	return &CampaignHandlerValidator{
+		logger:       logger.WithService(fmt.Sprintf("%T", v)),
+		tracer:       tracer,
+		phoneService: phoneService,
+	}
+}
+
+// ValidateIndex validates the requests.CampaignIndex request
+func (validator *CampaignHandlerValidator) ValidateIndex(_ context.Context, request requests.CampaignIndex) url.Values {
+	v := govalidator.New(govalidator.Options{
+		Data: &request,
+		Rules: govalidator.MapData{
+			"limit": []string{
+				"required",
+				"numeric",
+				"min:1",
+				"max:100",
+			},
+			"skip": []string{
+				"required",
+				"numeric",
+				"min:0",
+			},
+			"query": []string{
+				"max:100",
+			},
+		},
+	})
+	return v.ValidateStruct()
+}
+
+// ValidateStore validates the requests.CampaignStore request
+func (validator *CampaignHandlerValidator) ValidateStore(ctx context.Context, userID entities.UserID, request requests.CampaignStore) url.Values {
+	ctx, span, ctxLogger := validator.tracer.StartWithLogger(ctx, validator.logger)
+	defer span.End()
+
+	v := govalidator.New(govalidator.Options{
+		Data: &request,
+		Rules: govalidator.MapData{
+			"name": []string{
+				"required",
+				"min:1",
+				"max:100",
+			},
+			"from": []string{
+				"required",
+				phoneNumberRule,
+			},
+			"sim": []string{
+				"required",
+				"in:" + strings.Join([]string{
+					string(entities.SIM1),
+					string(entities.SIM2),
+					string(entities.SIMDefault),
+				}, ","),
+			},
+			"segment_id": []string{
+				"uuid",
+			},
+			"group_id": []string{
+				"uuid",
+			},
+			"template_id": []string{
+				"uuid",
+			},
+			"content": []string{
+				"max:1024",
+			},
+		},
+	})
+
+	result := v.ValidateStruct()
+	if request.TemplateID == "" && strings.TrimSpace(request.Content) == "" {
+		result.Add("content", "The content field is required when the campaign does not use a template_id")
+	}
+	if (request.SegmentID == "") == (request.GroupID == "") {
+		result.Add("segment_id", "The campaign must have either a segment_id or a group_id")
+	}
+	validator.validateSchedule(result, request)
+	if len(result) != 0 {
+		return result
+	}
+
+	_, err := validator.phoneService.Load(ctx, userID, request.From)
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		result.Add("from", fmt.Sprintf("no phone found with with 'from' number [%s]. install the android app on your phone to start sending messages", request.From))
+		return result
+	}
+
+	if err != nil {
+		ctxLogger.Error(validator.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, fmt.Sprintf("could not load phone for user [%s] and phone [%s]", userID, request.From))))
+		result.Add("from", fmt.Sprintf("could not validate 'from' number [%s], please try again later", request.From))
+	}
+
+	return result
+}
+
+// ValidateUpdate validates the requests.CampaignUpdate request
+func (validator *CampaignHandlerValidator) ValidateUpdate(ctx context.Context, userID entities.UserID, request requests.CampaignUpdate) url.Values {
+	result := validator.ValidateUUID(ctx, request.CampaignID, "campaignID")
+	for key, values := range validator.ValidateStore(ctx, userID, request.CampaignStore) {
+		result[key] = append(result[key], values...)
+	}
+	return result
+}
+
+func (validator *CampaignHandlerValidator) validateSchedule(result url.Values, request requests.CampaignStore) {
+	if _, err := time.LoadLocation(request.Timezone); err != nil {
+		result.Add("timezone", fmt.Sprintf("The timezone field [%s] is not a valid IANA timezone e.g Europe/Helsinki", request.Timezone))
+	}
+
+	if request.Cron != "" && request.IntervalMinutes != 0 {
+		result.Add("cron", "The cron field cannot be used together with the interval_minutes field")
+		return
+	}
+
+	if request.Cron != "" {
+		if _, err := services.ParseCronSchedule(request.Cron); err != nil {
+			result.Add("cron", fmt.Sprintf("The cron field [%s] must be a 5 field cron expression e.g \"0 9 * * 1\"", request.Cron))
+		}
+		return
+	}
+
+	if request.IntervalMinutes < campaignMinIntervalMinutes || request.IntervalMinutes > campaignMaxIntervalMinutes {
+		result.Add("interval_minutes", fmt.Sprintf("The interval_minutes field must be between %d and %d when the cron field is empty", campaignMinIntervalMinutes, campaignMaxIntervalMinutes))
+	}
+}
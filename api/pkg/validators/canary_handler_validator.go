@@ -0,0 +1,139 @@
+package validators
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
+	"github.com/NdoleStudio/httpsms/pkg/requests"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/palantir/stacktrace"
+	"github.com/thedevsaddam/govalidator"
+)
+
+// CanaryHandlerValidator validates models used in handlers.CanaryHandler
+type CanaryHandlerValidator struct {
+	validator
+	logger       telemetry.Logger
+	tracer       telemetry.Tracer
+	phoneService *services.PhoneService
+}
+
+// NewCanaryHandlerValidator creates a new handlers.CanaryHandler validator
+func NewCanaryHandlerValidator(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	phoneService *services.PhoneService,
+) (v *CanaryHandlerValidator) {
+	return &CanaryHandlerValidator{
+		logger:       logger.WithService(fmt.Sprintf("%T", v)),
+		tracer:       tracer,
+		phoneService: phoneService,
+	}
+}
+
+// ValidateIndex validates the requests.CanaryIndex request
+func (validator *CanaryHandlerValidator) ValidateIndex(_ context.Context, request requests.CanaryIndex) url.Values {
+	v := govalidator.New(govalidator.Options{
+		Data: &request,
+		Rules: govalidator.MapData{
+			"limit": []string{
+				"required",
+				"numeric",
+				"min:1",
+				"max:100",
+			},
+			"skip": []string{
+				"required",
+				"numeric",
+				"min:0",
+			},
+			"query": []string{
+				"max:100",
+			},
+		},
+	})
+	return v.ValidateStruct()
+}
+
+// ValidateRunIndex validates the requests.CanaryRunIndex request
+func (validator *CanaryHandlerValidator) ValidateRunIndex(_ context.Context, request requests.CanaryRunIndex) url.Values {
+	v := govalidator.New(govalidator.Options{
+		Data: &request,
+		Rules: govalidator.MapData{
+			"limit": []string{
+				"required",
+				"numeric",
+				"min:1",
+				"max:100",
+			},
+			"skip": []string{
+				"required",
+				"numeric",
+				"min:0",
+			},
+			"canaryID": []string{
+				"required",
+				"uuid",
+			},
+		},
+	})
+	return v.ValidateStruct()
+}
+
+// ValidateStore validates the requests.CanaryStore request
+func (validator *CanaryHandlerValidator) ValidateStore(ctx context.Context, userID entities.UserID, request requests.CanaryStore) url.Values {
+	ctx, span, ctxLogger := validator.tracer.StartWithLogger(ctx, validator.logger)
+	defer span.End()
+
+	v := govalidator.New(govalidator.Options{
+		Data: &request,
+		Rules: govalidator.MapData{
+			"owner": []string{
+				"required",
+				phoneNumberRule,
+			},
+			"contact": []string{
+				"required",
+				phoneNumberRule,
+			},
+			"interval_seconds": []string{
+				"min:300",
+				"max:86400",
+			},
+			"timeout_seconds": []string{
+				"min:60",
+				"max:3600",
+			},
+		},
+	})
+
+	result := v.ValidateStruct()
+	if len(result) != 0 {
+		return result
+	}
+
+	if request.TimeoutSeconds > request.IntervalSeconds {
+		result.Add("timeout_seconds", "The timeout_seconds field must not be greater than the interval_seconds field")
+		return result
+	}
+
+	// the canary message is received by one of the user's phones so both numbers must be registered
+	for field, number := range map[string]string{"owner": request.Owner, "contact": request.Contact} {
+		_, err := validator.phoneService.Load(ctx, userID, number)
+		if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+			result.Add(field, fmt.Sprintf("no phone found with with '%s' number [%s]. install the android app on your phone to send and receive canary messages", field, number))
+			continue
+		}
+
+		if err != nil {
+			ctxLogger.Error(validator.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, fmt.Sprintf("could not load phone for user [%s] and phone [%s]", userID, number))))
+			result.Add(field, fmt.Sprintf("could not validate '%s' number [%s], please try again later", field, number))
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,98 @@
+package validators
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+
+	"github.com/NdoleStudio/httpsms/pkg/requests"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/google/uuid"
+	"github.com/thedevsaddam/govalidator"
+)
+
+// ContactGroupHandlerValidator validates models used in handlers.ContactGroupHandler
+type ContactGroupHandlerValidator struct {
+	validator
+	logger telemetry.Logger
+	tracer telemetry.Tracer
+}
+
+// NewContactGroupHandlerValidator creates a new handlers.ContactGroupHandler validator
+func NewContactGroupHandlerValidator(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+) (v *ContactGroupHandlerValidator) {
+	return &ContactGroupHandlerValidator{
+		logger: logger.WithService(fmt.Sprintf("%T", v)),
+		tracer: tracer,
+	}
+}
+
+// ValidateIndex validates the requests.ContactGroupIndex request
+func (validator *ContactGroupHandlerValidator) ValidateIndex(_ context.Context, request requests.ContactGroupIndex) url.Values {
+	v := govalidator.New(govalidator.Options{
+		Data: &request,
+		Rules: govalidator.MapData{
+			"limit": []string{
+				"required",
+				"numeric",
+				"min:1",
+				"max:100",
+			},
+			"skip": []string{
+				"required",
+				"numeric",
+				"min:0",
+			},
+			"query": []string{
+				"max:100",
+			},
+		},
+	})
+	return v.ValidateStruct()
+}
+
+// ValidateStore validates the requests.ContactGroupStore request
+func (validator *ContactGroupHandlerValidator) ValidateStore(_ context.Context, request requests.ContactGroupStore) url.Values {
+	v := govalidator.New(govalidator.Options{
+		Data: &request,
+		Rules: govalidator.MapData{
+			"name": []string{
+				"required",
+				"min:1",
+				"max:100",
+			},
+			"description": []string{
+				"max:255",
+			},
+		},
+	})
+	return v.ValidateStruct()
+}
+
+// ValidateUpdate validates the requests.ContactGroupUpdate request
+func (validator *ContactGroupHandlerValidator) ValidateUpdate(ctx context.Context, request requests.ContactGroupUpdate) url.Values {
+	result := validator.ValidateUUID(ctx, request.GroupID, "groupID")
+	for key, values := range validator.ValidateStore(ctx, request.ContactGroupStore) {
+		result[key] = append(result[key], values...)
+	}
+	return result
+}
+
+// ValidateAddContacts validates the requests.ContactGroupAddContacts request
+func (validator *ContactGroupHandlerValidator) ValidateAddContacts(ctx context.Context, request requests.ContactGroupAddContacts) url.Values {
+	result := validator.ValidateUUID(ctx, request.GroupID, "groupID")
+	if len(request.ContactIDs) == 0 {
+		result.Add("contact_ids", "The contact_ids field must have at least 1 contact ID")
+	}
+	if len(request.ContactIDs) > 100 {
+		result.Add("contact_ids", "The contact_ids field must have at most 100 contact IDs")
+	}
+	for index, contactID := range request.ContactIDs {
+		if _, err := uuid.Parse(contactID); err != nil {
+			result.Add("contact_ids", fmt.Sprintf("The contact ID in index [%d] must be a valid UUID", index))
+		}
+	}
+	return result
+}
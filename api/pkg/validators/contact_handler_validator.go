@@ -0,0 +1,122 @@
+package validators
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"regexp"
+
+	"github.com/NdoleStudio/httpsms/pkg/requests"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/thedevsaddam/govalidator"
+)
+
+// ContactHandlerValidator validates models used in handlers.ContactHandler
+type ContactHandlerValidator struct {
+	validator
+	logger telemetry.Logger
+	tracer telemetry.Tracer
+}
+
+// NewContactHandlerValidator creates a new handlers.ContactHandler validator
+func NewContactHandlerValidator(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+) (v *ContactHandlerValidator) {
+	return &ContactHandlerValidator{
+		logger: logger.WithService(fmt.Sprintf("%T", v)),
+		tracer: tracer,
+	}
+}
+
+// ValidateIndex validates the requests.ContactIndex request
+func (validator *ContactHandlerValidator) ValidateIndex(_ context.Context, request requests.ContactIndex) url.Values {
+	v := govalidator.New(govalidator.Options{
+		Data: &request,
+		Rules: govalidator.MapData{
+			"limit": []string{
+				"required",
+				"numeric",
+				"min:1",
+				"max:100",
+			},
+			"skip": []string{
+				"required",
+				"numeric",
+				"min:0",
+			},
+			"query": []string{
+				"max:100",
+			},
+			"is_opted_out": []string{
+				"in:true,false",
+			},
+			"group_id": []string{
+				"uuid",
+			},
+			"tag": []string{
+				"max:50",
+			},
+			"sort_by": []string{
+				"in:messages_sent,messages_received,reply_rate,last_sent_at,last_reply_at",
+			},
+		},
+	})
+	return v.ValidateStruct()
+}
+
+// ValidateStore validates the requests.ContactStore request
+func (validator *ContactHandlerValidator) ValidateStore(_ context.Context, request requests.ContactStore) url.Values {
+	v := govalidator.New(govalidator.Options{
+		Data: &request,
+		Rules: govalidator.MapData{
+			"phone_number": []string{
+				"required",
+				contactPhoneNumberRule,
+			},
+			"name": []string{
+				"max:100",
+			},
+		},
+	})
+
+	result := v.ValidateStruct()
+	validator.validateTagsAndAttributes(result, request.Tags, request.Attributes)
+	return result
+}
+
+// ValidateUpdate validates the requests.ContactUpdate request
+func (validator *ContactHandlerValidator) ValidateUpdate(_ context.Context, request requests.ContactUpdate) url.Values {
+	v := govalidator.New(govalidator.Options{
+		Data: &request,
+		Rules: govalidator.MapData{
+			"contactID": []string{
+				"required",
+				"uuid",
+			},
+			"name": []string{
+				"max:100",
+			},
+		},
+	})
+
+	result := v.ValidateStruct()
+	validator.validateTagsAndAttributes(result, request.Tags, request.Attributes)
+	return result
+}
+
+func (validator *ContactHandlerValidator) validateTagsAndAttributes(result url.Values, tags []string, attributes map[string]string) {
+	validator.validateTags(result, tags)
+
+	if len(attributes) > 50 {
+		result.Add("attributes", "The attributes field must have at most 50 attributes")
+	}
+	for key, value := range attributes {
+		if match, err := regexp.MatchString("^[a-zA-Z0-9_]{1,50}$", key); err != nil || !match {
+			result.Add("attributes", fmt.Sprintf("The attribute [%s] must contain only letters, digits and underscores and must be at most 50 characters", key))
+		}
+		if len(value) > 255 {
+			result.Add("attributes", fmt.Sprintf("The value of the attribute [%s] must be at most 255 characters", key))
+		}
+	}
+}
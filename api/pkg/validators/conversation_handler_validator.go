@@ -0,0 +1,107 @@
+package validators
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+
+	"github.com/NdoleStudio/httpsms/pkg/requests"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/thedevsaddam/govalidator"
+)
+
+// ConversationHandlerValidator validates models used in handlers.ConversationHandler
+type ConversationHandlerValidator struct {
+	validator
+	logger telemetry.Logger
+	tracer telemetry.Tracer
+}
+
+// NewConversationHandlerValidator creates a new handlers.ConversationHandler validator
+func NewConversationHandlerValidator(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+) (v *ConversationHandlerValidator) {
+	return &ConversationHandlerValidator{
+		logger: logger.WithService(fmt.Sprintf("%T", v)),
+		tracer: tracer,
+	}
+}
+
+// ValidateIndex validates the requests.ConversationIndex request
+func (validator *ConversationHandlerValidator) ValidateIndex(_ context.Context, request requests.ConversationIndex) url.Values {
+	rules := govalidator.MapData{
+		"limit": []string{
+			"required",
+			"numeric",
+			"min:1",
+			"max:100",
+		},
+	}
+	if request.Owner != "" {
+		rules["owner"] = []string{phoneNumberRule}
+	}
+
+	v := govalidator.New(govalidator.Options{
+		Data:  &request,
+		Rules: rules,
+	})
+
+	result := v.ValidateStruct()
+	validator.validateCursor(result, request.Cursor)
+	return result
+}
+
+// ValidateMessages validates the requests.ConversationMessages request
+func (validator *ConversationHandlerValidator) ValidateMessages(_ context.Context, request requests.ConversationMessages) url.Values {
+	v := govalidator.New(govalidator.Options{
+		Data: &request,
+		Rules: govalidator.MapData{
+			"owner": []string{
+				"required",
+				phoneNumberRule,
+			},
+			"contact": []string{
+				"required",
+				"min:1",
+			},
+			"limit": []string{
+				"required",
+				"numeric",
+				"min:1",
+				"max:100",
+			},
+		},
+	})
+
+	result := v.ValidateStruct()
+	if _, err := services.DecodeSequenceCursor(request.Cursor); request.Cursor != "" && err != nil {
+		result.Add("cursor", "The cursor field must be the next_cursor returned by the previous page")
+	}
+	return result
+}
+
+// ValidateRead validates the requests.ConversationRead request
+func (validator *ConversationHandlerValidator) ValidateRead(_ context.Context, request requests.ConversationRead) url.Values {
+	v := govalidator.New(govalidator.Options{
+		Data: &request,
+		Rules: govalidator.MapData{
+			"owner": []string{
+				"required",
+				phoneNumberRule,
+			},
+			"contact": []string{
+				"required",
+				"min:1",
+			},
+		},
+	})
+	return v.ValidateStruct()
+}
+
+func (validator *ConversationHandlerValidator) validateCursor(result url.Values, cursor string) {
+	if _, err := services.DecodeEventCursor(cursor); cursor != "" && err != nil {
+		result.Add("cursor", "The cursor field must be the next_cursor returned by the previous page")
+	}
+}
@@ -0,0 +1,84 @@
+package validators
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/requests"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/thedevsaddam/govalidator"
+)
+
+// creditMaxTopUp is the maximum number of credits which can be added or removed in a single transaction
+const creditMaxTopUp = 10_000_000
+
+// CreditHandlerValidator validates models used in handlers.CreditHandler
+type CreditHandlerValidator struct {
+	validator
+	logger telemetry.Logger
+	tracer telemetry.Tracer
+}
+
+// NewCreditHandlerValidator creates a new handlers.CreditHandler validator
+func NewCreditHandlerValidator(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+) (v *CreditHandlerValidator) {
+	return &CreditHandlerValidator{
+		logger: logger.WithService(fmt.Sprintf("%T", v)),
+		tracer: tracer,
+	}
+}
+
+// ValidateIndex validates the requests.CreditTransactionIndex request
+func (validator *CreditHandlerValidator) ValidateIndex(_ context.Context, request requests.CreditTransactionIndex) url.Values {
+	v := govalidator.New(govalidator.Options{
+		Data: &request,
+		Rules: govalidator.MapData{
+			"limit": []string{
+				"required",
+				"numeric",
+				"min:1",
+				"max:100",
+			},
+			"skip": []string{
+				"required",
+				"numeric",
+				"min:0",
+			},
+		},
+	})
+
+	result := v.ValidateStruct()
+	if _, err := time.Parse(time.RFC3339, request.From); request.From != "" && err != nil {
+		result.Add("from", "The from field must be a valid RFC3339 timestamp e.g 2022-06-05T14:26:09+03:00")
+	}
+	if _, err := time.Parse(time.RFC3339, request.To); request.To != "" && err != nil {
+		result.Add("to", "The to field must be a valid RFC3339 timestamp e.g 2022-06-05T14:26:09+03:00")
+	}
+	return result
+}
+
+// ValidateTopUp validates the requests.CreditTopUp request
+func (validator *CreditHandlerValidator) ValidateTopUp(_ context.Context, request requests.CreditTopUp) url.Values {
+	v := govalidator.New(govalidator.Options{
+		Data: &request,
+		Rules: govalidator.MapData{
+			"user_id": []string{
+				"required",
+				"max:100",
+			},
+			"description": []string{
+				"max:255",
+			},
+		},
+	})
+
+	result := v.ValidateStruct()
+	if request.Amount == 0 || request.Amount > creditMaxTopUp || request.Amount < -creditMaxTopUp {
+		result.Add("amount", fmt.Sprintf("The amount field must be a non zero number between -%d and %d", creditMaxTopUp, creditMaxTopUp))
+	}
+	return result
+}
@@ -0,0 +1,85 @@
+package validators
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/requests"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/thedevsaddam/govalidator"
+)
+
+// EventsHandlerValidator validates models used in handlers.EventsHandler
+type EventsHandlerValidator struct {
+	validator
+	logger telemetry.Logger
+	tracer telemetry.Tracer
+}
+
+// NewEventsHandlerValidator creates a new handlers.EventsHandler validator
+func NewEventsHandlerValidator(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+) (v *EventsHandlerValidator) {
+	return &EventsHandlerValidator{
+		logger: logger.WithService(fmt.Sprintf("%T", v)),
+		tracer: tracer,
+	}
+}
+
+// ValidateIndex validates the requests.EventIndex request
+func (validator *EventsHandlerValidator) ValidateIndex(_ context.Context, request requests.EventIndex) url.Values {
+	v := govalidator.New(govalidator.Options{
+		Data: &request,
+		Rules: govalidator.MapData{
+			"limit": []string{
+				"required",
+				"numeric",
+				"min:1",
+				"max:100",
+			},
+			"type": []string{
+				"max:1000",
+			},
+			"source": []string{
+				"max:255",
+			},
+			"entity_id": []string{
+				"uuid",
+			},
+		},
+	})
+
+	result := v.ValidateStruct()
+	if len(result) != 0 {
+		return result
+	}
+
+	for field, value := range map[string]string{"since": request.Since, "until": request.Until} {
+		if _, err := time.Parse(time.RFC3339, value); value != "" && err != nil {
+			result.Add(field, fmt.Sprintf("The %s field must be a valid RFC3339 timestamp e.g 2022-06-05T14:26:09+03:00", field))
+		}
+	}
+
+	if _, err := services.DecodeEventCursor(request.Cursor); request.Cursor != "" && err != nil {
+		result.Add("cursor", "The cursor field must be the next_cursor returned by the previous page")
+	}
+
+	return result
+}
+
+// ValidateStream validates the requests.EventStream request
+func (validator *EventsHandlerValidator) ValidateStream(_ context.Context, request requests.EventStream) url.Values {
+	v := govalidator.New(govalidator.Options{
+		Data: &request,
+		Rules: govalidator.MapData{
+			"type": []string{
+				"max:1000",
+			},
+		},
+	})
+	return v.ValidateStruct()
+}
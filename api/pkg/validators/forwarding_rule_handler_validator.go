@@ -0,0 +1,124 @@
+package validators
+
+import (
+	"context"
+	"fmt"
+	"net/mail"
+	"net/url"
+	"regexp"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/requests"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/nyaruka/phonenumbers"
+	"github.com/thedevsaddam/govalidator"
+)
+
+// maxForwardingRuleEmails is the highest number of email addresses which receive the messages of a forwarding rule
+const maxForwardingRuleEmails = 10
+
+// ForwardingRuleHandlerValidator validates models used in handlers.ForwardingRuleHandler
+type ForwardingRuleHandlerValidator struct {
+	validator
+	logger telemetry.Logger
+	tracer telemetry.Tracer
+}
+
+// NewForwardingRuleHandlerValidator creates a new handlers.ForwardingRuleHandler validator
+func NewForwardingRuleHandlerValidator(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+) (v *ForwardingRuleHandlerValidator) {
+	return &ForwardingRuleHandlerValidator{
+		logger: logger.WithService(fmt.Sprintf("%T", v)),
+		tracer: tracer,
+	}
+}
+
+// ValidateIndex validates the requests.ForwardingRuleIndex request
+func (validator *ForwardingRuleHandlerValidator) ValidateIndex(_ context.Context, request requests.ForwardingRuleIndex) url.Values {
+	v := govalidator.New(govalidator.Options{
+		Data: &request,
+		Rules: govalidator.MapData{
+			"limit": []string{
+				"required",
+				"numeric",
+				"min:1",
+				"max:100",
+			},
+			"skip": []string{
+				"required",
+				"numeric",
+				"min:0",
+			},
+			"query": []string{
+				"max:100",
+			},
+		},
+	})
+	return v.ValidateStruct()
+}
+
+// ValidateStore validates the requests.ForwardingRuleStore request
+func (validator *ForwardingRuleHandlerValidator) ValidateStore(_ context.Context, request requests.ForwardingRuleStore) url.Values {
+	v := govalidator.New(govalidator.Options{
+		Data: &request,
+		Rules: govalidator.MapData{
+			"name": []string{
+				"required",
+				"min:1",
+				"max:100",
+			},
+			"match": []string{
+				"required",
+				"in:" + string(entities.RuleMatchKeyword) + "," + string(entities.RuleMatchRegex),
+			},
+			"pattern": []string{
+				"max:255",
+			},
+		},
+	})
+
+	result := v.ValidateStruct()
+	validator.validateRule(result, request)
+	return result
+}
+
+// ValidateUpdate validates the requests.ForwardingRuleUpdate request
+func (validator *ForwardingRuleHandlerValidator) ValidateUpdate(ctx context.Context, request requests.ForwardingRuleUpdate) url.Values {
+	if result := validator.ValidateUUID(ctx, request.RuleID, "ruleID"); len(result) != 0 {
+		return result
+	}
+	return validator.ValidateStore(ctx, request.ForwardingRuleStore)
+}
+
+func (validator *ForwardingRuleHandlerValidator) validateRule(result url.Values, request requests.ForwardingRuleStore) {
+	if request.Owner != "" && !validator.isPhoneNumber(request.Owner) {
+		result.Add("owner", "The owner field must be a valid E.164 phone number: https://en.wikipedia.org/wiki/E.164")
+	}
+
+	if request.Contact != "" && !validator.isPhoneNumber(request.Contact) {
+		result.Add("contact", "The contact field must be a valid E.164 phone number: https://en.wikipedia.org/wiki/E.164")
+	}
+
+	if request.Match == string(entities.RuleMatchRegex) && request.Pattern != "" {
+		if _, err := regexp.Compile(request.Pattern); err != nil {
+			result.Add("pattern", fmt.Sprintf("The pattern field is not a valid regular expression: %s", err.Error()))
+		}
+	}
+
+	if len(request.Emails) == 0 || len(request.Emails) > maxForwardingRuleEmails {
+		result.Add("emails", fmt.Sprintf("The emails field must contain between 1 and %d email addresses", maxForwardingRuleEmails))
+	}
+
+	for _, email := range request.Emails {
+		if address, err := mail.ParseAddress(email); err != nil || address.Address != email {
+			result.Add("emails", fmt.Sprintf("The emails field contains an invalid email address [%s]", email))
+		}
+	}
+}
+
+func (validator *ForwardingRuleHandlerValidator) isPhoneNumber(value string) bool {
+	_, err := phonenumbers.Parse(value, phonenumbers.UNKNOWN_REGION)
+	return err == nil
+}
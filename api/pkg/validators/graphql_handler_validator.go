@@ -0,0 +1,46 @@
+package validators
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+
+	"github.com/NdoleStudio/httpsms/pkg/requests"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/thedevsaddam/govalidator"
+)
+
+// GraphQLHandlerValidator validates models used in handlers.GraphQLHandler
+type GraphQLHandlerValidator struct {
+	validator
+	logger telemetry.Logger
+	tracer telemetry.Tracer
+}
+
+// NewGraphQLHandlerValidator creates a new handlers.GraphQLHandler validator
+func NewGraphQLHandlerValidator(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+) (v *GraphQLHandlerValidator) {
+	return &GraphQLHandlerValidator{
+		logger: logger.WithService(fmt.Sprintf("%T", v)),
+		tracer: tracer,
+	}
+}
+
+// ValidateQuery validates the requests.GraphQLQuery request
+func (validator *GraphQLHandlerValidator) ValidateQuery(_ context.Context, request requests.GraphQLQuery) url.Values {
+	v := govalidator.New(govalidator.Options{
+		Data: &request,
+		Rules: govalidator.MapData{
+			"query": []string{
+				"required",
+				"max:10000",
+			},
+			"operationName": []string{
+				"max:100",
+			},
+		},
+	})
+	return v.ValidateStruct()
+}
@@ -0,0 +1,92 @@
+package validators
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/requests"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/jinzhu/now"
+	"github.com/thedevsaddam/govalidator"
+)
+
+// InvoiceHandlerValidator validates models used in handlers.InvoiceHandler
+type InvoiceHandlerValidator struct {
+	validator
+	logger telemetry.Logger
+	tracer telemetry.Tracer
+}
+
+// NewInvoiceHandlerValidator creates a new handlers.InvoiceHandler validator
+func NewInvoiceHandlerValidator(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+) (v *InvoiceHandlerValidator) {
+	return &InvoiceHandlerValidator{
+		logger: logger.WithService(fmt.Sprintf("%T", v)),
+		tracer: tracer,
+	}
+}
+
+// ValidateIndex validates the requests.InvoiceIndex request
+func (validator *InvoiceHandlerValidator) ValidateIndex(_ context.Context, request requests.InvoiceIndex) url.Values {
+	v := govalidator.New(govalidator.Options{
+		Data: &request,
+		Rules: govalidator.MapData{
+			"limit": []string{
+				"required",
+				"numeric",
+				"min:1",
+				"max:100",
+			},
+			"skip": []string{
+				"required",
+				"numeric",
+				"min:0",
+			},
+		},
+	})
+	return v.ValidateStruct()
+}
+
+// ValidateStore validates the requests.InvoiceStore request
+func (validator *InvoiceHandlerValidator) ValidateStore(_ context.Context, request requests.InvoiceStore) url.Values {
+	result := url.Values{}
+
+	period, err := time.Parse(requests.InvoicePeriodLayout, request.Period)
+	if err != nil {
+		result.Add("period", fmt.Sprintf("The period field [%s] must be a month in the format YYYY-MM e.g 2022-01", request.Period))
+		return result
+	}
+
+	if !period.Before(now.New(time.Now().UTC()).BeginningOfMonth()) {
+		result.Add("period", fmt.Sprintf("The period field [%s] must be a month which has already ended", request.Period))
+	}
+
+	return result
+}
+
+// ValidateDownload validates the requests.InvoiceDownload request
+func (validator *InvoiceHandlerValidator) ValidateDownload(_ context.Context, request requests.InvoiceDownload) url.Values {
+	v := govalidator.New(govalidator.Options{
+		Data: &request,
+		Rules: govalidator.MapData{
+			"invoiceID": []string{
+				"required",
+				"uuid",
+			},
+			"format": []string{
+				"required",
+				"in:" + strings.Join([]string{
+					string(services.InvoiceFormatPDF),
+					string(services.InvoiceFormatCSV),
+				}, ","),
+			},
+		},
+	})
+	return v.ValidateStruct()
+}
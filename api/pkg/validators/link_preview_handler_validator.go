@@ -0,0 +1,50 @@
+package validators
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/NdoleStudio/httpsms/pkg/requests"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/thedevsaddam/govalidator"
+)
+
+// LinkPreviewHandlerValidator validates models used in handlers.LinkPreviewHandler
+type LinkPreviewHandlerValidator struct {
+	validator
+	logger telemetry.Logger
+	tracer telemetry.Tracer
+}
+
+// NewLinkPreviewHandlerValidator creates a new handlers.LinkPreviewHandler validator
+func NewLinkPreviewHandlerValidator(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+) (v *LinkPreviewHandlerValidator) {
+	return &LinkPreviewHandlerValidator{
+		logger: logger.WithService(fmt.Sprintf("%T", v)),
+		tracer: tracer,
+	}
+}
+
+// ValidateShow validates the requests.LinkPreviewShow request
+func (validator *LinkPreviewHandlerValidator) ValidateShow(_ context.Context, request requests.LinkPreviewShow) url.Values {
+	v := govalidator.New(govalidator.Options{
+		Data: &request,
+		Rules: govalidator.MapData{
+			"url": []string{
+				"required",
+				"url",
+				"max:2048",
+			},
+		},
+	})
+
+	result := v.ValidateStruct()
+	if len(result) == 0 && !strings.HasPrefix(request.URL, "http://") && !strings.HasPrefix(request.URL, "https://") {
+		result.Add("url", "The url field must start with http:// or https://")
+	}
+	return result
+}
@@ -0,0 +1,57 @@
+package validators
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+
+	"github.com/NdoleStudio/httpsms/pkg/requests"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/thedevsaddam/govalidator"
+)
+
+// MessageArchiveHandlerValidator validates models used in handlers.MessageArchiveHandler
+type MessageArchiveHandlerValidator struct {
+	validator
+	logger telemetry.Logger
+	tracer telemetry.Tracer
+}
+
+// NewMessageArchiveHandlerValidator creates a new handlers.MessageArchiveHandler validator
+func NewMessageArchiveHandlerValidator(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+) (v *MessageArchiveHandlerValidator) {
+	return &MessageArchiveHandlerValidator{
+		logger: logger.WithService(fmt.Sprintf("%T", v)),
+		tracer: tracer,
+	}
+}
+
+// ValidateIndex validates the requests.ArchivedMessageIndex request
+func (validator *MessageArchiveHandlerValidator) ValidateIndex(_ context.Context, request requests.ArchivedMessageIndex) url.Values {
+	rules := govalidator.MapData{
+		"limit": []string{
+			"required",
+			"numeric",
+			"min:1",
+			"max:100",
+		},
+	}
+	if request.Owner != "" {
+		rules["owner"] = []string{phoneNumberRule}
+	}
+
+	v := govalidator.New(govalidator.Options{
+		Data:  &request,
+		Rules: rules,
+	})
+
+	result := v.ValidateStruct()
+	if _, err := services.DecodeEventCursor(request.Cursor); request.Cursor != "" && err != nil {
+		result.Add("cursor", "The cursor field must be the next_cursor returned by the previous page")
+	}
+
+	return result
+}
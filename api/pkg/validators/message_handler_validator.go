@@ -2,12 +2,15 @@ package validators
 
 import (
 	"context"
+	"encoding/base64"
 	"fmt"
 	"net/url"
 	"strings"
+	"time"
 
 	"github.com/NdoleStudio/httpsms/pkg/repositories"
 	"github.com/NdoleStudio/httpsms/pkg/services"
+	"github.com/google/uuid"
 	"github.com/palantir/stacktrace"
 
 	"github.com/NdoleStudio/httpsms/pkg/entities"
@@ -17,6 +20,30 @@ import (
 	"github.com/thedevsaddam/govalidator"
 )
 
+// messageStatuses are the entities.MessageStatus which can be used to filter messages
+var messageStatuses = []entities.MessageStatus{
+	entities.MessageStatusPending, entities.MessageStatusScheduled, entities.MessageStatusSending, entities.MessageStatusSent,
+	entities.MessageStatusReceived, entities.MessageStatusFailed, entities.MessageStatusDelivered, entities.MessageStatusExpired,
+	entities.MessageStatusDeliveryUnknown, entities.MessageStatusQueuedForFuture, entities.MessageStatusPaused, entities.MessageStatusAwaitingPredecessor,
+}
+
+const (
+	maxMessageAttachments    = 10
+	maxMessageAttachmentSize = 1024 * 1024
+
+	// minMessageExpiresIn and maxMessageExpiresIn are the same bounds as the message expiration of a phone
+	minMessageExpiresIn = 60
+	maxMessageExpiresIn = 60 * 60
+)
+
+var allowedAttachmentContentTypes = map[string]bool{
+	"image/jpeg":   true,
+	"image/png":    true,
+	"image/gif":    true,
+	"text/vcard":   true,
+	"text/x-vcard": true,
+}
+
 // MessageHandlerValidator validates models used in handlers.MessageHandler
 type MessageHandlerValidator struct {
 	validator
@@ -50,11 +77,7 @@ func (validator MessageHandlerValidator) ValidateMessageReceive(_ context.Contex
 			"from": []string{
 				"required",
 			},
-			"content": []string{
-				"required",
-				"min:1",
-				"max:1024",
-			},
+			"content": validator.contentRules(len(request.Attachments)),
 			"sim": []string{
 				"required",
 				"in:" + strings.Join([]string{
@@ -66,7 +89,9 @@ func (validator MessageHandlerValidator) ValidateMessageReceive(_ context.Contex
 		},
 	})
 
-	return v.ValidateStruct()
+	result := v.ValidateStruct()
+	validator.validateAttachments(result, request.Attachments)
+	return result
 }
 
 // ValidateMessageSend validates the requests.MessageSend request
@@ -87,11 +112,7 @@ func (validator MessageHandlerValidator) ValidateMessageSend(ctx context.Context
 				"required",
 				phoneNumberRule,
 			},
-			"content": []string{
-				"required",
-				"min:1",
-				"max:1024",
-			},
+			"content": validator.contentRules(len(request.Attachments)),
 			"sim": []string{
 				"required",
 				"in:" + strings.Join([]string{
@@ -100,10 +121,36 @@ func (validator MessageHandlerValidator) ValidateMessageSend(ctx context.Context
 					string(entities.SIMDefault),
 				}, ","),
 			},
+			"priority": []string{
+				"required",
+				"in:" + strings.Join([]string{
+					string(entities.MessagePriorityHigh),
+					string(entities.MessagePriorityNormal),
+					string(entities.MessagePriorityLow),
+				}, ","),
+			},
 		},
 	})
 
 	result := v.ValidateStruct()
+	validator.validateSendAt(result, request.SendAt)
+	validator.validateAttachments(result, request.Attachments)
+	if request.ConversationID != nil && len(*request.ConversationID) > 255 {
+		result.Add("conversation_id", "The conversation_id field must not be longer than 255 characters")
+	}
+	if request.ExpiresIn != nil && (*request.ExpiresIn < minMessageExpiresIn || *request.ExpiresIn > maxMessageExpiresIn) {
+		result.Add("expires_in", fmt.Sprintf("The expires_in field must be between %d and %d seconds", minMessageExpiresIn, maxMessageExpiresIn))
+	}
+	if request.AfterMessageID != nil {
+		if _, err := uuid.Parse(*request.AfterMessageID); err != nil {
+			result.Add("after_message_id", "The after_message_id field must be a valid UUID")
+		}
+	}
+	if request.ID != nil {
+		if _, err := uuid.Parse(*request.ID); err != nil {
+			result.Add("id", "The id field must be a valid UUID")
+		}
+	}
 	if len(result) != 0 {
 		return result
 	}
@@ -133,7 +180,7 @@ func (validator MessageHandlerValidator) ValidateMessageBulkSend(ctx context.Con
 		Rules: govalidator.MapData{
 			"to": []string{
 				"required",
-				"max:50",
+				"max:1000",
 				"min:1",
 				multipleContactPhoneNumberRule,
 			},
@@ -158,6 +205,7 @@ func (validator MessageHandlerValidator) ValidateMessageBulkSend(ctx context.Con
 	})
 
 	result := v.ValidateStruct()
+	validator.validateSendAt(result, request.SendAt)
 	if len(result) != 0 {
 		return result
 	}
@@ -175,6 +223,58 @@ func (validator MessageHandlerValidator) ValidateMessageBulkSend(ctx context.Con
 	return result
 }
 
+// validateSendAt checks that a scheduled message is sent within the next year, a small clock skew in the past is tolerated
+func (validator MessageHandlerValidator) validateSendAt(result url.Values, sendAt *time.Time) {
+	if sendAt == nil {
+		return
+	}
+
+	if sendAt.Before(time.Now().Add(-1 * time.Minute)) {
+		result.Add("send_at", "The send_at field must be a time in the future")
+	}
+
+	if sendAt.After(time.Now().AddDate(1, 0, 0)) {
+		result.Add("send_at", "The send_at field cannot be more than 1 year in the future")
+	}
+}
+
+// contentRules allows MMS messages which only have attachments to be sent without text
+func (validator MessageHandlerValidator) contentRules(attachments int) []string {
+	if attachments > 0 {
+		return []string{"max:1024"}
+	}
+	return []string{"required", "min:1", "max:1024"}
+}
+
+// validateAttachments checks the number, type and size of the attachments of an MMS message
+func (validator MessageHandlerValidator) validateAttachments(result url.Values, attachments []requests.MessageAttachment) {
+	if len(attachments) > maxMessageAttachments {
+		result.Add("attachments", fmt.Sprintf("The attachments field cannot have more than %d attachments", maxMessageAttachments))
+		return
+	}
+
+	for index, attachment := range attachments {
+		field := fmt.Sprintf("attachments.%d", index)
+		if strings.TrimSpace(attachment.Name) == "" || len(attachment.Name) > 255 {
+			result.Add(field, "The attachment name is required and must be at most 255 characters")
+		}
+
+		if !allowedAttachmentContentTypes[strings.ToLower(strings.TrimSpace(attachment.ContentType))] {
+			result.Add(field, fmt.Sprintf("The attachment content_type [%s] is not supported, only images and vCards can be attached", attachment.ContentType))
+		}
+
+		content, err := base64.StdEncoding.DecodeString(attachment.Content)
+		if err != nil || len(content) == 0 {
+			result.Add(field, "The attachment content must be a non empty base64 encoded string")
+			continue
+		}
+
+		if len(content) > maxMessageAttachmentSize {
+			result.Add(field, fmt.Sprintf("The attachment [%s] is [%d] bytes which is larger than the maximum of [%d] bytes", attachment.Name, len(content), maxMessageAttachmentSize))
+		}
+	}
+}
+
 // ValidateMessageOutstanding validates the requests.MessageOutstanding request
 func (validator MessageHandlerValidator) ValidateMessageOutstanding(_ context.Context, request requests.MessageOutstanding) url.Values {
 	v := govalidator.New(govalidator.Options{
@@ -221,6 +321,126 @@ func (validator MessageHandlerValidator) ValidateMessageIndex(_ context.Context,
 	return v.ValidateStruct()
 }
 
+// ValidateMessageSearch validates the requests.MessageSearch request
+func (validator MessageHandlerValidator) ValidateMessageSearch(_ context.Context, request requests.MessageSearch) url.Values {
+	rules := govalidator.MapData{
+		"q": []string{
+			"required",
+			"min:1",
+			"max:100",
+		},
+		"limit": []string{
+			"required",
+			"numeric",
+			"min:1",
+			"max:100",
+		},
+		"skip": []string{
+			"required",
+			"numeric",
+			"min:0",
+		},
+	}
+	if request.Owner != "" {
+		rules["owner"] = []string{phoneNumberRule}
+	}
+
+	v := govalidator.New(govalidator.Options{
+		Data:  &request,
+		Rules: rules,
+	})
+
+	result := v.ValidateStruct()
+	if len(result) == 0 && len(entities.ParseMessageSearchQuery(request.Q)) == 0 {
+		result.Add("q", "The q field must contain at least one word with letters or digits")
+	}
+	return result
+}
+
+// ValidateMessagePageIndex validates the requests.MessagePageIndex request
+func (validator MessageHandlerValidator) ValidateMessagePageIndex(_ context.Context, request requests.MessagePageIndex) url.Values {
+	rules := govalidator.MapData{
+		"limit": []string{
+			"required",
+			"numeric",
+			"min:1",
+			"max:100",
+		},
+		"type": []string{
+			"in:" + strings.Join([]string{entities.MessageTypeMobileTerminated, entities.MessageTypeMobileOriginated}, ","),
+		},
+		"sim": []string{
+			"in:" + strings.Join([]string{string(entities.SIM1), string(entities.SIM2), string(entities.SIMDefault)}, ","),
+		},
+		"query": []string{
+			"max:100",
+		},
+		"tag": []string{
+			"max:50",
+		},
+	}
+	if request.Owner != "" {
+		rules["owner"] = []string{phoneNumberRule}
+	}
+
+	v := govalidator.New(govalidator.Options{
+		Data:  &request,
+		Rules: rules,
+	})
+
+	result := v.ValidateStruct()
+	if len(result) != 0 {
+		return result
+	}
+
+	statuses := map[string]bool{}
+	for _, status := range messageStatuses {
+		statuses[string(status)] = true
+	}
+	for _, status := range strings.Split(request.Status, ",") {
+		if request.Status != "" && !statuses[status] {
+			result.Add("status", fmt.Sprintf("The status [%s] is not a valid message status", status))
+		}
+	}
+
+	for field, value := range map[string]string{"since": request.Since, "until": request.Until} {
+		if _, err := time.Parse(time.RFC3339, value); value != "" && err != nil {
+			result.Add(field, fmt.Sprintf("The %s field must be a valid RFC3339 timestamp e.g 2022-06-05T14:26:09+03:00", field))
+		}
+	}
+
+	if _, err := services.DecodeEventCursor(request.Cursor); request.Cursor != "" && err != nil {
+		result.Add("cursor", "The cursor field must be the next_cursor returned by the previous page")
+	}
+
+	return result
+}
+
+// ValidateMessageEventIndex validates the requests.MessageEventIndex request
+func (validator MessageHandlerValidator) ValidateMessageEventIndex(_ context.Context, request requests.MessageEventIndex) url.Values {
+	v := govalidator.New(govalidator.Options{
+		Data: &request,
+		Rules: govalidator.MapData{
+			"messageID": []string{
+				"required",
+				"uuid",
+			},
+			"limit": []string{
+				"required",
+				"numeric",
+				"min:1",
+				"max:100",
+			},
+		},
+	})
+
+	result := v.ValidateStruct()
+	if _, err := services.DecodeEventCursor(request.Cursor); request.Cursor != "" && err != nil {
+		result.Add("cursor", "The cursor field must be the next_cursor returned by the previous page")
+	}
+	return result
+}
+
 // ValidateMessageEvent validates the requests.MessageEvent request
 func (validator MessageHandlerValidator) ValidateMessageEvent(_ context.Context, request requests.MessageEvent) url.Values {
 	v := govalidator.New(govalidator.Options{
@@ -232,6 +452,7 @@ func (validator MessageHandlerValidator) ValidateMessageEvent(_ context.Context,
 					string(entities.MessageEventNameSent),
 					string(entities.MessageEventNameFailed),
 					string(entities.MessageEventNameDelivered),
+					string(entities.MessageEventNameSkippedExpired),
 				}, ","),
 			},
 			"messageID": []string{
@@ -242,3 +463,77 @@ func (validator MessageHandlerValidator) ValidateMessageEvent(_ context.Context,
 	})
 	return v.ValidateStruct()
 }
+
+// maxMessageEventBatchSize is the maximum number of events in a requests.MessageEventBatch
+const maxMessageEventBatchSize = 100
+
+// ValidateMessageEventBatch validates the requests.MessageEventBatch request, the errors of an event are keyed by its index
+func (validator MessageHandlerValidator) ValidateMessageEventBatch(ctx context.Context, request requests.MessageEventBatch) url.Values {
+	result := url.Values{}
+	if len(request.Events) == 0 || len(request.Events) > maxMessageEventBatchSize {
+		result.Add("events", fmt.Sprintf("The events field must contain between 1 and %d events", maxMessageEventBatchSize))
+		return result
+	}
+
+	for index, event := range request.Events {
+		for field, errors := range validator.ValidateMessageEvent(ctx, event.ToMessageEvent()) {
+			if field == "messageID" {
+				field = "message_id"
+			}
+			for _, err := range errors {
+				result.Add(fmt.Sprintf("events[%d].%s", index, field), err)
+			}
+		}
+	}
+
+	return result
+}
+
+// ValidateTagsUpdate validates the requests.TagsUpdate of a message
+func (validator MessageHandlerValidator) ValidateTagsUpdate(ctx context.Context, messageID string, request requests.TagsUpdate) url.Values {
+	result := validator.ValidateUUID(ctx, messageID, "messageID")
+	validator.validateTags(result, request.Tags)
+	return result
+}
+
+// maxMessageReconcileSize is the maximum number of messages in a requests.MessageReconcile
+const maxMessageReconcileSize = 500
+
+// ValidateMessageReconcile validates the requests.MessageReconcile request
+func (validator MessageHandlerValidator) ValidateMessageReconcile(_ context.Context, request requests.MessageReconcile) url.Values {
+	v := govalidator.New(govalidator.Options{
+		Data: &request,
+		Rules: govalidator.MapData{
+			"owner": []string{
+				"required",
+				phoneNumberRule,
+			},
+		},
+	})
+
+	result := v.ValidateStruct()
+	if len(request.Messages) == 0 || len(request.Messages) > maxMessageReconcileSize {
+		result.Add("messages", fmt.Sprintf("The messages field must contain between 1 and %d messages", maxMessageReconcileSize))
+		return result
+	}
+
+	states := []string{
+		string(services.MessageReconcileStatePending),
+		string(services.MessageReconcileStateSent),
+		string(services.MessageReconcileStateDelivered),
+		string(services.MessageReconcileStateFailed),
+	}
+
+	for index, message := range request.Messages {
+		if _, err := uuid.Parse(message.MessageID); err != nil {
+			result.Add(fmt.Sprintf("messages[%d].message_id", index), "The message_id field must be a valid UUID")
+		}
+		switch services.MessageReconcileState(message.State) {
+		case services.MessageReconcileStatePending, services.MessageReconcileStateSent, services.MessageReconcileStateDelivered, services.MessageReconcileStateFailed:
+		default:
+			result.Add(fmt.Sprintf("messages[%d].state", index), fmt.Sprintf("The state field must be one of [%s]", strings.Join(states, ", ")))
+		}
+	}
+
+	return result
+}
@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"net/url"
 
+	"github.com/google/uuid"
+
 	"github.com/NdoleStudio/httpsms/pkg/requests"
 	"github.com/NdoleStudio/httpsms/pkg/telemetry"
 	"github.com/thedevsaddam/govalidator"
@@ -55,6 +57,9 @@ func (validator *MessageThreadHandlerValidator) ValidateMessageThreadIndex(_ con
 				"required",
 				phoneNumberRule,
 			},
+			"tag": []string{
+				"max:50",
+			},
 		},
 	})
 	return v.ValidateStruct()
@@ -74,3 +79,40 @@ func (validator *MessageThreadHandlerValidator) ValidateUpdate(_ context.Context
 
 	return v.ValidateStruct()
 }
+
+// ValidateTagsUpdate validates the requests.TagsUpdate of a message thread
+func (validator *MessageThreadHandlerValidator) ValidateTagsUpdate(ctx context.Context, messageThreadID string, request requests.TagsUpdate) url.Values {
+	result := validator.ValidateUUID(ctx, messageThreadID, "messageThreadID")
+	validator.validateTags(result, request.Tags)
+	return result
+}
+
+// maxMessageThreadUnarchiveSize is the maximum number of threads in a requests.MessageThreadUnarchive
+const maxMessageThreadUnarchiveSize = 100
+
+// ValidateUnarchive validates requests.MessageThreadUnarchive
+func (validator *MessageThreadHandlerValidator) ValidateUnarchive(_ context.Context, request requests.MessageThreadUnarchive) url.Values {
+	v := govalidator.New(govalidator.Options{
+		Data: &request,
+		Rules: govalidator.MapData{
+			"owner": []string{
+				"required",
+				phoneNumberRule,
+			},
+		},
+	})
+
+	result := v.ValidateStruct()
+	if len(request.MessageThreadIDs) > maxMessageThreadUnarchiveSize {
+		result.Add("message_thread_ids", fmt.Sprintf("The message_thread_ids field must contain at most %d IDs", maxMessageThreadUnarchiveSize))
+		return result
+	}
+
+	for index, ID := range request.MessageThreadIDs {
+		if _, err := uuid.Parse(ID); err != nil {
+			result.Add(fmt.Sprintf("message_thread_ids[%d]", index), "The message_thread_ids field must contain valid UUIDs")
+		}
+	}
+
+	return result
+}
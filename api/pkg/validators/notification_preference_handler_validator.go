@@ -0,0 +1,62 @@
+package validators
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/requests"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+)
+
+// NotificationPreferenceHandlerValidator validates models used in handlers.NotificationPreferenceHandler
+type NotificationPreferenceHandlerValidator struct {
+	validator
+	logger telemetry.Logger
+	tracer telemetry.Tracer
+}
+
+// NewNotificationPreferenceHandlerValidator creates a new handlers.NotificationPreferenceHandler validator
+func NewNotificationPreferenceHandlerValidator(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+) (v *NotificationPreferenceHandlerValidator) {
+	return &NotificationPreferenceHandlerValidator{
+		logger: logger.WithService(fmt.Sprintf("%T", v)),
+		tracer: tracer,
+	}
+}
+
+// ValidateUpdate validates the requests.NotificationPreferencesUpdate request
+func (validator *NotificationPreferenceHandlerValidator) ValidateUpdate(_ context.Context, request requests.NotificationPreferencesUpdate) url.Values {
+	result := url.Values{}
+	if len(request.Preferences) == 0 {
+		result.Add("preferences", "The preferences field must contain at least 1 preference")
+		return result
+	}
+
+	for index, preference := range request.Preferences {
+		eventTypes, ok := services.NotificationPreferenceEvents[entities.NotificationChannel(preference.Channel)]
+		if !ok {
+			result.Add(fmt.Sprintf("preferences[%d].channel", index), fmt.Sprintf("The channel [%s] is not a valid notification channel", preference.Channel))
+			continue
+		}
+
+		if !validator.contains(eventTypes, preference.EventType) {
+			result.Add(fmt.Sprintf("preferences[%d].event_type", index), fmt.Sprintf("The event [%s] does not generate notifications on the [%s] channel", preference.EventType, preference.Channel))
+		}
+	}
+
+	return result
+}
+
+func (validator *NotificationPreferenceHandlerValidator) contains(values []string, value string) bool {
+	for _, item := range values {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,104 @@
+package validators
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
+	"github.com/NdoleStudio/httpsms/pkg/requests"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/palantir/stacktrace"
+	"github.com/thedevsaddam/govalidator"
+)
+
+// NumberLeaseHandlerValidator validates models used in handlers.NumberLeaseHandler
+type NumberLeaseHandlerValidator struct {
+	validator
+	logger       telemetry.Logger
+	tracer       telemetry.Tracer
+	phoneService *services.PhoneService
+}
+
+// NewNumberLeaseHandlerValidator creates a new handlers.NumberLeaseHandler validator
+func NewNumberLeaseHandlerValidator(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	phoneService *services.PhoneService,
+) (v *NumberLeaseHandlerValidator) {
+	return &NumberLeaseHandlerValidator{
+		logger:       logger.WithService(fmt.Sprintf("%T", v)),
+		tracer:       tracer,
+		phoneService: phoneService,
+	}
+}
+
+// ValidateIndex validates the requests.NumberLeaseIndex request
+func (validator *NumberLeaseHandlerValidator) ValidateIndex(_ context.Context, request requests.NumberLeaseIndex) url.Values {
+	v := govalidator.New(govalidator.Options{
+		Data: &request,
+		Rules: govalidator.MapData{
+			"limit": []string{
+				"required",
+				"numeric",
+				"min:1",
+				"max:100",
+			},
+			"skip": []string{
+				"required",
+				"numeric",
+				"min:0",
+			},
+			"query": []string{
+				"max:100",
+			},
+		},
+	})
+	return v.ValidateStruct()
+}
+
+// ValidateStore validates the requests.NumberLeaseStore request
+func (validator *NumberLeaseHandlerValidator) ValidateStore(ctx context.Context, userID entities.UserID, request requests.NumberLeaseStore) url.Values {
+	ctx, span, ctxLogger := validator.tracer.StartWithLogger(ctx, validator.logger)
+	defer span.End()
+
+	v := govalidator.New(govalidator.Options{
+		Data: &request,
+		Rules: govalidator.MapData{
+			"reference": []string{
+				"required",
+				"min:1",
+				"max:255",
+			},
+			"pool": []string{
+				multipleContactPhoneNumberRule,
+			},
+			"inactivity_timeout_seconds": []string{
+				"required",
+				"numeric_between:60,2592000",
+			},
+		},
+	})
+
+	result := v.ValidateStruct()
+	if len(result) != 0 {
+		return result
+	}
+
+	for _, owner := range request.Pool {
+		_, err := validator.phoneService.Load(ctx, userID, owner)
+		if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+			result.Add("pool", fmt.Sprintf("no phone found with with number [%s]. install the android app on your phone to add it to the pool", owner))
+			continue
+		}
+
+		if err != nil {
+			ctxLogger.Error(validator.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, fmt.Sprintf("could not load phone for user [%s] and phone [%s]", userID, owner))))
+			result.Add("pool", fmt.Sprintf("could not validate number [%s], please try again later", owner))
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,60 @@
+package validators
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+
+	"github.com/NdoleStudio/httpsms/pkg/requests"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/thedevsaddam/govalidator"
+)
+
+// PhoneFailoverHandlerValidator validates models used in handlers.PhoneFailoverHandler
+type PhoneFailoverHandlerValidator struct {
+	validator
+	logger telemetry.Logger
+	tracer telemetry.Tracer
+}
+
+// maxPhoneFailoverBackupOwners is the maximum number of backup phones of an owner
+const maxPhoneFailoverBackupOwners = 5
+
+// NewPhoneFailoverHandlerValidator creates a new handlers.PhoneFailoverHandler validator
+func NewPhoneFailoverHandlerValidator(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+) (v *PhoneFailoverHandlerValidator) {
+	return &PhoneFailoverHandlerValidator{
+		logger: logger.WithService(fmt.Sprintf("%T", v)),
+		tracer: tracer,
+	}
+}
+
+// ValidateUpsert validates the requests.PhoneFailoverPolicyUpsert request
+func (validator *PhoneFailoverHandlerValidator) ValidateUpsert(_ context.Context, request requests.PhoneFailoverPolicyUpsert) url.Values {
+	v := govalidator.New(govalidator.Options{
+		Data: &request,
+		Rules: govalidator.MapData{
+			"owner": []string{
+				"required",
+				phoneNumberRule,
+			},
+			"backup_owners": []string{
+				"required",
+				"min:1",
+				fmt.Sprintf("max:%d", maxPhoneFailoverBackupOwners),
+				multipleContactPhoneNumberRule,
+			},
+		},
+	})
+
+	result := v.ValidateStruct()
+	for _, owner := range request.BackupOwners {
+		if owner == request.Owner {
+			result.Add("backup_owners", fmt.Sprintf("The backup_owners field cannot contain the owner [%s]", owner))
+		}
+	}
+
+	return result
+}
@@ -4,6 +4,9 @@ import (
 	"context"
 	"fmt"
 	"net/url"
+	"strings"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
 
 	"github.com/NdoleStudio/httpsms/pkg/requests"
 	"github.com/NdoleStudio/httpsms/pkg/telemetry"
@@ -61,6 +64,27 @@ func (validator *PhoneHandlerValidator) ValidateUpsert(_ context.Context, reques
 				"required",
 				phoneNumberRule,
 			},
+			"transport": []string{
+				"in:" + strings.Join([]string{
+					string(entities.PhoneTransportFCM),
+					string(entities.PhoneTransportNATS),
+					string(entities.PhoneTransportMQTT),
+				}, ","),
+			},
+			"release_channel": []string{
+				"in:" + strings.Join([]string{
+					string(entities.PhoneReleaseChannelStable),
+					string(entities.PhoneReleaseChannelBeta),
+					string(entities.PhoneReleaseChannelAlpha),
+				}, ","),
+			},
+			"sim_balancing": []string{
+				"in:" + strings.Join([]string{
+					string(entities.PhoneSIMBalancingNone),
+					string(entities.PhoneSIMBalancingRoundRobin),
+					string(entities.PhoneSIMBalancingLeastRecentlyUsed),
+				}, ","),
+			},
 			"fcm_token": []string{
 				"min:0",
 				"max:1000",
@@ -77,6 +101,10 @@ func (validator *PhoneHandlerValidator) ValidateUpsert(_ context.Context, reques
 				"min:60",
 				"max:3600",
 			},
+			"delivery_report_timeout_seconds": []string{
+				"min:0",
+				"max:604800",
+			},
 		},
 	})
 
@@ -89,9 +117,41 @@ func (validator *PhoneHandlerValidator) ValidateUpsert(_ context.Context, reques
 		result.Add("message_expiration_seconds", "message_expiration_seconds cannot be 0 when max_send_attempts is greater than 0")
 	}
 
+	if request.DeliveryReportTimeoutSeconds > 0 && request.DeliveryReportTimeoutSeconds < request.MessageExpirationSeconds {
+		result.Add("delivery_report_timeout_seconds", "delivery_report_timeout_seconds cannot be less than message_expiration_seconds")
+	}
+
+	if request.MaxInFlightMessages != nil && *request.MaxInFlightMessages > 100 {
+		result.Add("max_in_flight_messages", "max_in_flight_messages cannot be greater than 100")
+	}
+
+	validator.validateSIMLimits(result, "sim1", request.SIM1HourlyLimit, request.SIM1DailyLimit)
+	validator.validateSIMLimits(result, "sim2", request.SIM2HourlyLimit, request.SIM2DailyLimit)
+
+	if len(request.WarmupSchedule) > 90 {
+		result.Add("warmup_schedule", "warmup_schedule cannot have more than 90 days")
+	}
+
+	for index, limit := range request.WarmupSchedule {
+		if limit < 1 || limit > 100000 {
+			result.Add("warmup_schedule", fmt.Sprintf("the daily limit in index [%d] of warmup_schedule must be between 1 and 100000", index))
+		}
+	}
+
 	return result
 }
 
+// validateSIMLimits checks that the hourly send limit of a SIM card is not greater than its daily limit
+func (validator *PhoneHandlerValidator) validateSIMLimits(result url.Values, sim string, hourly *uint, daily *uint) {
+	if hourly == nil || daily == nil || *hourly == 0 || *daily == 0 {
+		return
+	}
+
+	if *hourly > *daily {
+		result.Add(sim+"_hourly_limit", fmt.Sprintf("%s_hourly_limit cannot be greater than %s_daily_limit", sim, sim))
+	}
+}
+
 // ValidateDelete ValidateUpsert validates requests.PhoneDelete
 func (validator *PhoneHandlerValidator) ValidateDelete(_ context.Context, request requests.PhoneDelete) url.Values {
 	v := govalidator.New(govalidator.Options{
@@ -106,3 +166,48 @@ func (validator *PhoneHandlerValidator) ValidateDelete(_ context.Context, reques
 
 	return v.ValidateStruct()
 }
+
+// ValidateKillSwitch validates the requests.PhoneKillSwitch request
+func (validator *PhoneHandlerValidator) ValidateKillSwitch(_ context.Context, request requests.PhoneKillSwitch) url.Values {
+	v := govalidator.New(govalidator.Options{
+		Data: &request,
+		Rules: govalidator.MapData{
+			"phoneID": []string{
+				"required",
+				"uuid",
+			},
+			"reason": []string{
+				"max:255",
+			},
+		},
+	})
+
+	return v.ValidateStruct()
+}
+
+// ValidateMissedCall validates the requests.PhoneMissedCall request
+func (validator *PhoneHandlerValidator) ValidateMissedCall(_ context.Context, request requests.PhoneMissedCall) url.Values {
+	v := govalidator.New(govalidator.Options{
+		Data: &request,
+		Rules: govalidator.MapData{
+			"to": []string{
+				"required",
+				phoneNumberRule,
+			},
+			"from": []string{
+				"required",
+				contactPhoneNumberRule,
+			},
+			"sim": []string{
+				"required",
+				"in:" + strings.Join([]string{
+					string(entities.SIM1),
+					string(entities.SIM2),
+					string(entities.SIMDefault),
+				}, ","),
+			},
+		},
+	})
+
+	return v.ValidateStruct()
+}
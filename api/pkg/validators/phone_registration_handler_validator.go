@@ -0,0 +1,42 @@
+package validators
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+
+	"github.com/NdoleStudio/httpsms/pkg/requests"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/thedevsaddam/govalidator"
+)
+
+// PhoneRegistrationHandlerValidator validates models used in handlers.PhoneRegistrationHandler
+type PhoneRegistrationHandlerValidator struct {
+	validator
+	logger telemetry.Logger
+	tracer telemetry.Tracer
+}
+
+// NewPhoneRegistrationHandlerValidator creates a new handlers.PhoneRegistrationHandler validator
+func NewPhoneRegistrationHandlerValidator(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+) (v *PhoneRegistrationHandlerValidator) {
+	return &PhoneRegistrationHandlerValidator{
+		logger: logger.WithService(fmt.Sprintf("%T", v)),
+		tracer: tracer,
+	}
+}
+
+// ValidateRevoke validates the requests.PhoneRegistrationRevoke request
+func (validator *PhoneRegistrationHandlerValidator) ValidateRevoke(_ context.Context, request requests.PhoneRegistrationRevoke) url.Values {
+	v := govalidator.New(govalidator.Options{
+		Data: &request,
+		Rules: govalidator.MapData{
+			"token": []string{
+				"required",
+			},
+		},
+	})
+	return v.ValidateStruct()
+}
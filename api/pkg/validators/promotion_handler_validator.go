@@ -0,0 +1,167 @@
+package validators
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/requests"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/thedevsaddam/govalidator"
+)
+
+// promotionSubscriptionNames are the plans which can be granted by a coupon or a plan override
+var promotionSubscriptionNames = "in:" + strings.Join([]string{
+	string(entities.SubscriptionNameFree),
+	string(entities.SubscriptionNameProMonthly),
+	string(entities.SubscriptionNameProYearly),
+	string(entities.SubscriptionNameProLifetime),
+	string(entities.SubscriptionNameUltraMonthly),
+	string(entities.SubscriptionNameUltraYearly),
+}, ",")
+
+// PromotionHandlerValidator validates models used in handlers.PromotionHandler
+type PromotionHandlerValidator struct {
+	validator
+	logger telemetry.Logger
+	tracer telemetry.Tracer
+}
+
+// NewPromotionHandlerValidator creates a new handlers.PromotionHandler validator
+func NewPromotionHandlerValidator(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+) (v *PromotionHandlerValidator) {
+	return &PromotionHandlerValidator{
+		logger: logger.WithService(fmt.Sprintf("%T", v)),
+		tracer: tracer,
+	}
+}
+
+// ValidateCouponIndex validates the requests.CouponIndex request
+func (validator *PromotionHandlerValidator) ValidateCouponIndex(_ context.Context, request requests.CouponIndex) url.Values {
+	v := govalidator.New(govalidator.Options{
+		Data: &request,
+		Rules: govalidator.MapData{
+			"limit": []string{
+				"required",
+				"numeric",
+				"min:1",
+				"max:100",
+			},
+			"skip": []string{
+				"required",
+				"numeric",
+				"min:0",
+			},
+			"query": []string{
+				"max:100",
+			},
+		},
+	})
+	return v.ValidateStruct()
+}
+
+// ValidateCouponStore validates the requests.CouponStore request
+func (validator *PromotionHandlerValidator) ValidateCouponStore(_ context.Context, request requests.CouponStore) url.Values {
+	v := govalidator.New(govalidator.Options{
+		Data: &request,
+		Rules: govalidator.MapData{
+			"code": []string{
+				"required",
+				"alpha_dash",
+				"min:3",
+				"max:50",
+			},
+			"description": []string{
+				"max:255",
+			},
+			"subscription_name": []string{
+				"required",
+				promotionSubscriptionNames,
+			},
+			"duration_days": []string{
+				"required",
+				"min:1",
+				"max:3660",
+			},
+		},
+	})
+
+	result := v.ValidateStruct()
+	if request.ExpiresAt != nil && !request.ExpiresAt.After(time.Now()) {
+		result.Add("expires_at", "The expires_at field must be in the future")
+	}
+	return result
+}
+
+// ValidateCouponRedeem validates the requests.CouponRedeem request
+func (validator *PromotionHandlerValidator) ValidateCouponRedeem(_ context.Context, request requests.CouponRedeem) url.Values {
+	v := govalidator.New(govalidator.Options{
+		Data: &request,
+		Rules: govalidator.MapData{
+			"code": []string{
+				"required",
+				"max:50",
+			},
+		},
+	})
+	return v.ValidateStruct()
+}
+
+// ValidatePlanOverrideIndex validates the requests.PlanOverrideIndex request
+func (validator *PromotionHandlerValidator) ValidatePlanOverrideIndex(_ context.Context, request requests.PlanOverrideIndex) url.Values {
+	v := govalidator.New(govalidator.Options{
+		Data: &request,
+		Rules: govalidator.MapData{
+			"limit": []string{
+				"required",
+				"numeric",
+				"min:1",
+				"max:100",
+			},
+			"skip": []string{
+				"required",
+				"numeric",
+				"min:0",
+			},
+		},
+	})
+	return v.ValidateStruct()
+}
+
+// ValidatePlanOverrideStore validates the requests.PlanOverrideStore request
+func (validator *PromotionHandlerValidator) ValidatePlanOverrideStore(_ context.Context, request requests.PlanOverrideStore) url.Values {
+	v := govalidator.New(govalidator.Options{
+		Data: &request,
+		Rules: govalidator.MapData{
+			"userID": []string{
+				"required",
+				"max:100",
+			},
+			"type": []string{
+				"required",
+				"in:" + strings.Join([]string{
+					string(entities.PlanOverrideTypeTrial),
+					string(entities.PlanOverrideTypeOverride),
+				}, ","),
+			},
+			"subscription_name": []string{
+				"required",
+				promotionSubscriptionNames,
+			},
+		},
+	})
+
+	result := v.ValidateStruct()
+	if request.Type == string(entities.PlanOverrideTypeTrial) && request.EndsAt == nil {
+		result.Add("ends_at", "The ends_at field is required for a trial")
+	}
+	if request.EndsAt != nil && !request.EndsAt.After(time.Now()) {
+		result.Add("ends_at", "The ends_at field must be in the future")
+	}
+	return result
+}
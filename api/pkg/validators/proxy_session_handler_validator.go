@@ -0,0 +1,160 @@
+package validators
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
+	"github.com/NdoleStudio/httpsms/pkg/requests"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/nyaruka/phonenumbers"
+	"github.com/palantir/stacktrace"
+	"github.com/thedevsaddam/govalidator"
+)
+
+// ProxySessionHandlerValidator validates models used in handlers.ProxySessionHandler
+type ProxySessionHandlerValidator struct {
+	validator
+	logger         telemetry.Logger
+	tracer         telemetry.Tracer
+	phoneService   *services.PhoneService
+	sessionService *services.ProxySessionService
+}
+
+// NewProxySessionHandlerValidator creates a new handlers.ProxySessionHandler validator
+func NewProxySessionHandlerValidator(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	phoneService *services.PhoneService,
+	sessionService *services.ProxySessionService,
+) (v *ProxySessionHandlerValidator) {
+	return &ProxySessionHandlerValidator{
+		logger:         logger.WithService(fmt.Sprintf("%T", v)),
+		tracer:         tracer,
+		phoneService:   phoneService,
+		sessionService: sessionService,
+	}
+}
+
+// ValidateIndex validates the requests.ProxySessionIndex request
+func (validator *ProxySessionHandlerValidator) ValidateIndex(_ context.Context, request requests.ProxySessionIndex) url.Values {
+	v := govalidator.New(govalidator.Options{
+		Data: &request,
+		Rules: govalidator.MapData{
+			"limit": []string{
+				"required",
+				"numeric",
+				"min:1",
+				"max:100",
+			},
+			"skip": []string{
+				"required",
+				"numeric",
+				"min:0",
+			},
+			"query": []string{
+				"max:100",
+			},
+		},
+	})
+	return v.ValidateStruct()
+}
+
+// ValidateTranscript validates the requests.ProxySessionTranscript request
+func (validator *ProxySessionHandlerValidator) ValidateTranscript(_ context.Context, request requests.ProxySessionTranscript) url.Values {
+	v := govalidator.New(govalidator.Options{
+		Data: &request,
+		Rules: govalidator.MapData{
+			"limit": []string{
+				"required",
+				"numeric",
+				"min:1",
+				"max:100",
+			},
+			"skip": []string{
+				"required",
+				"numeric",
+				"min:0",
+			},
+			"sessionID": []string{
+				"required",
+				"uuid",
+			},
+		},
+	})
+	return v.ValidateStruct()
+}
+
+// ValidateStore validates the requests.ProxySessionStore request
+func (validator *ProxySessionHandlerValidator) ValidateStore(ctx context.Context, userID entities.UserID, request requests.ProxySessionStore) url.Values {
+	ctx, span, ctxLogger := validator.tracer.StartWithLogger(ctx, validator.logger)
+	defer span.End()
+
+	v := govalidator.New(govalidator.Options{
+		Data: &request,
+		Rules: govalidator.MapData{
+			"first_party": []string{
+				"required",
+				contactPhoneNumberRule,
+			},
+			"second_party": []string{
+				"required",
+				contactPhoneNumberRule,
+			},
+			"ttl_seconds": []string{
+				"required",
+				"numeric_between:60,2592000",
+			},
+		},
+	})
+
+	result := v.ValidateStruct()
+	if len(result) != 0 {
+		return result
+	}
+
+	if request.FirstParty == request.SecondParty {
+		result.Add("second_party", "The second_party field must be different from the first_party field")
+		return result
+	}
+
+	// the owner number will be leased from the pool of phones
+	if request.Owner == "" {
+		return result
+	}
+
+	if _, err := phonenumbers.Parse(request.Owner, phonenumbers.UNKNOWN_REGION); err != nil {
+		result.Add("owner", "The owner field must be a valid E.164 phone number: https://en.wikipedia.org/wiki/E.164")
+		return result
+	}
+
+	_, err := validator.phoneService.Load(ctx, userID, request.Owner)
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		result.Add("owner", fmt.Sprintf("no phone found with with 'owner' number [%s]. install the android app on your phone to start relaying messages", request.Owner))
+		return result
+	}
+
+	if err != nil {
+		ctxLogger.Error(validator.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, fmt.Sprintf("could not load phone for user [%s] and phone [%s]", userID, request.Owner))))
+		result.Add("owner", fmt.Sprintf("could not validate 'owner' number [%s], please try again later", request.Owner))
+		return result
+	}
+
+	for field, contact := range map[string]string{"first_party": request.FirstParty, "second_party": request.SecondParty} {
+		_, err = validator.sessionService.LoadActive(ctx, userID, request.Owner, contact)
+		if err == nil {
+			result.Add(field, fmt.Sprintf("[%s] already has an active proxy session on the owner number [%s]", contact, request.Owner))
+			continue
+		}
+
+		if stacktrace.GetCode(err) != repositories.ErrCodeNotFound {
+			ctxLogger.Error(validator.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, fmt.Sprintf("could not load active proxy session for owner [%s] and contact [%s]", request.Owner, contact))))
+			result.Add(field, fmt.Sprintf("could not validate [%s], please try again later", contact))
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,65 @@
+package validators
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+
+	"github.com/NdoleStudio/httpsms/pkg/requests"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/thedevsaddam/govalidator"
+)
+
+// ReferralHandlerValidator validates models used in handlers.ReferralHandler
+type ReferralHandlerValidator struct {
+	validator
+	logger telemetry.Logger
+	tracer telemetry.Tracer
+}
+
+// NewReferralHandlerValidator creates a new handlers.ReferralHandler validator
+func NewReferralHandlerValidator(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+) (v *ReferralHandlerValidator) {
+	return &ReferralHandlerValidator{
+		logger: logger.WithService(fmt.Sprintf("%T", v)),
+		tracer: tracer,
+	}
+}
+
+// ValidateIndex validates the requests.ReferralIndex request
+func (validator *ReferralHandlerValidator) ValidateIndex(_ context.Context, request requests.ReferralIndex) url.Values {
+	v := govalidator.New(govalidator.Options{
+		Data: &request,
+		Rules: govalidator.MapData{
+			"limit": []string{
+				"required",
+				"numeric",
+				"min:1",
+				"max:100",
+			},
+			"skip": []string{
+				"required",
+				"numeric",
+				"min:0",
+			},
+		},
+	})
+	return v.ValidateStruct()
+}
+
+// ValidateAttribute validates the requests.ReferralAttribute request
+func (validator *ReferralHandlerValidator) ValidateAttribute(_ context.Context, request requests.ReferralAttribute) url.Values {
+	v := govalidator.New(govalidator.Options{
+		Data: &request,
+		Rules: govalidator.MapData{
+			"code": []string{
+				"required",
+				"alpha_num",
+				"len:8",
+			},
+		},
+	})
+	return v.ValidateStruct()
+}
@@ -0,0 +1,73 @@
+package validators
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/requests"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/thedevsaddam/govalidator"
+)
+
+// RetryPolicyHandlerValidator validates models used in handlers.RetryPolicyHandler
+type RetryPolicyHandlerValidator struct {
+	validator
+	logger telemetry.Logger
+	tracer telemetry.Tracer
+}
+
+// NewRetryPolicyHandlerValidator creates a new handlers.RetryPolicyHandler validator
+func NewRetryPolicyHandlerValidator(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+) (v *RetryPolicyHandlerValidator) {
+	return &RetryPolicyHandlerValidator{
+		logger: logger.WithService(fmt.Sprintf("%T", v)),
+		tracer: tracer,
+	}
+}
+
+// ValidateUpsert validates the requests.RetryPolicyUpsert request
+func (validator *RetryPolicyHandlerValidator) ValidateUpsert(_ context.Context, request requests.RetryPolicyUpsert) url.Values {
+	v := govalidator.New(govalidator.Options{
+		Data: &request,
+		Rules: govalidator.MapData{
+			"max_retries": []string{
+				"min:0",
+				"max:10",
+			},
+			"delay_seconds": []string{
+				"min:0",
+				"max:86400",
+			},
+			"failureCode": validator.failureCodeRules(),
+		},
+	})
+	return v.ValidateStruct()
+}
+
+// ValidateFailureCode validates the failure code of an entities.RetryPolicy
+func (validator *RetryPolicyHandlerValidator) ValidateFailureCode(_ context.Context, code string) url.Values {
+	request := map[string]string{
+		"failureCode": code,
+	}
+
+	v := govalidator.New(govalidator.Options{
+		Data: &request,
+		Rules: govalidator.MapData{
+			"failureCode": validator.failureCodeRules(),
+		},
+	})
+	return v.ValidateStruct()
+}
+
+func (validator *RetryPolicyHandlerValidator) failureCodeRules() []string {
+	codes := make([]string, 0, len(entities.MessageFailureCodes))
+	for _, code := range entities.MessageFailureCodes {
+		codes = append(codes, string(code))
+	}
+	return []string{"required", "in:" + strings.Join(codes, ",")}
+}
@@ -0,0 +1,158 @@
+package validators
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
+	"github.com/NdoleStudio/httpsms/pkg/requests"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/palantir/stacktrace"
+	"github.com/thedevsaddam/govalidator"
+)
+
+// RuleHandlerValidator validates models used in handlers.RuleHandler
+type RuleHandlerValidator struct {
+	validator
+	logger       telemetry.Logger
+	tracer       telemetry.Tracer
+	phoneService *services.PhoneService
+}
+
+// maxRuleCooldownMinutes is the longest cooldown of an auto reply rule which is 1 week
+const maxRuleCooldownMinutes = 7 * 24 * 60
+
+// NewRuleHandlerValidator creates a new handlers.RuleHandler validator
+func NewRuleHandlerValidator(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	phoneService *services.PhoneService,
+) (v *RuleHandlerValidator) {
+	return &RuleHandlerValidator{
+		logger:       logger.WithService(fmt.Sprintf("%T", v)),
+		tracer:       tracer,
+		phoneService: phoneService,
+	}
+}
+
+// ValidateIndex validates the requests.RuleIndex request
+func (validator *RuleHandlerValidator) ValidateIndex(_ context.Context, request requests.RuleIndex) url.Values {
+	v := govalidator.New(govalidator.Options{
+		Data: &request,
+		Rules: govalidator.MapData{
+			"limit": []string{
+				"required",
+				"numeric",
+				"min:1",
+				"max:100",
+			},
+			"skip": []string{
+				"required",
+				"numeric",
+				"min:0",
+			},
+			"query": []string{
+				"max:100",
+			},
+		},
+	})
+	return v.ValidateStruct()
+}
+
+// ValidateStore validates the requests.RuleStore request
+func (validator *RuleHandlerValidator) ValidateStore(ctx context.Context, userID entities.UserID, request requests.RuleStore) url.Values {
+	ctx, span, ctxLogger := validator.tracer.StartWithLogger(ctx, validator.logger)
+	defer span.End()
+
+	v := govalidator.New(govalidator.Options{
+		Data: &request,
+		Rules: govalidator.MapData{
+			"owner": []string{
+				"required",
+				phoneNumberRule,
+			},
+			"type": []string{
+				"required",
+				"in:" + strings.Join([]string{
+					string(entities.RuleTypeMissedCall),
+					string(entities.RuleTypeAutoReply),
+				}, ","),
+			},
+			"content": []string{
+				"required",
+				"min:1",
+				"max:1024",
+			},
+		},
+	})
+
+	result := v.ValidateStruct()
+	if request.Type == string(entities.RuleTypeAutoReply) {
+		validator.validateAutoReply(result, &request.Match, &request.Pattern, &request.CooldownMinutes)
+	}
+
+	if len(result) != 0 {
+		return result
+	}
+
+	_, err := validator.phoneService.Load(ctx, userID, request.Owner)
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		result.Add("owner", fmt.Sprintf("no phone found with with 'owner' number [%s]. install the android app on your phone to start using rules", request.Owner))
+		return result
+	}
+
+	if err != nil {
+		ctxLogger.Error(validator.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, fmt.Sprintf("could not load phone for user [%s] and phone [%s]", userID, request.Owner))))
+		result.Add("owner", fmt.Sprintf("could not validate 'owner' number [%s], please try again later", request.Owner))
+	}
+
+	return result
+}
+
+// ValidateUpdate validates the requests.RuleUpdate request
+func (validator *RuleHandlerValidator) ValidateUpdate(_ context.Context, request requests.RuleUpdate) url.Values {
+	v := govalidator.New(govalidator.Options{
+		Data: &request,
+		Rules: govalidator.MapData{
+			"content": []string{
+				"required",
+				"min:1",
+				"max:1024",
+			},
+			"ruleID": []string{
+				"required",
+				"uuid",
+			},
+		},
+	})
+
+	result := v.ValidateStruct()
+	validator.validateAutoReply(result, request.Match, request.Pattern, request.CooldownMinutes)
+	return result
+}
+
+// validateAutoReply validates the match, pattern and cooldown of an auto reply rule, nil values are not validated
+func (validator *RuleHandlerValidator) validateAutoReply(result url.Values, match *string, pattern *string, cooldownMinutes *uint) {
+	if match != nil && *match != string(entities.RuleMatchKeyword) && *match != string(entities.RuleMatchRegex) {
+		result.Add("match", fmt.Sprintf("The match field must be one of [%s, %s]", entities.RuleMatchKeyword, entities.RuleMatchRegex))
+	}
+
+	if pattern != nil && (strings.TrimSpace(*pattern) == "" || len(*pattern) > 255) {
+		result.Add("pattern", "The pattern field is required and must be at most 255 characters")
+	}
+
+	if match != nil && pattern != nil && *match == string(entities.RuleMatchRegex) {
+		if _, err := regexp.Compile(*pattern); err != nil {
+			result.Add("pattern", fmt.Sprintf("The pattern field is not a valid regular expression: %s", err.Error()))
+		}
+	}
+
+	if cooldownMinutes != nil && *cooldownMinutes > maxRuleCooldownMinutes {
+		result.Add("cooldown_minutes", fmt.Sprintf("The cooldown_minutes field must be between 0 and %d", maxRuleCooldownMinutes))
+	}
+}
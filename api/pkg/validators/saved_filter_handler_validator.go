@@ -0,0 +1,164 @@
+package validators
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/requests"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/thedevsaddam/govalidator"
+)
+
+// SavedFilterHandlerValidator validates models used in handlers.SavedFilterHandler
+type SavedFilterHandlerValidator struct {
+	validator
+	logger telemetry.Logger
+	tracer telemetry.Tracer
+}
+
+// NewSavedFilterHandlerValidator creates a new handlers.SavedFilterHandler validator
+func NewSavedFilterHandlerValidator(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+) (v *SavedFilterHandlerValidator) {
+	return &SavedFilterHandlerValidator{
+		logger: logger.WithService(fmt.Sprintf("%T", v)),
+		tracer: tracer,
+	}
+}
+
+// ValidateStore validates the requests.SavedFilterStore request
+func (validator *SavedFilterHandlerValidator) ValidateStore(_ context.Context, request requests.SavedFilterStore) url.Values {
+	v := govalidator.New(govalidator.Options{
+		Data: &request,
+		Rules: govalidator.MapData{
+			"name": []string{
+				"required",
+				"min:1",
+				"max:100",
+			},
+			"target": []string{
+				"required",
+				"in:" + strings.Join([]string{string(entities.SavedFilterTargetMessages), string(entities.SavedFilterTargetMessageThreads)}, ","),
+			},
+			"position": []string{
+				"min:0",
+				"max:1000",
+			},
+		},
+	})
+
+	result := v.ValidateStruct()
+	validator.validateCriteria(result, entities.SavedFilterTarget(request.Target), request.Filters)
+	return result
+}
+
+// ValidateUpdate validates the requests.SavedFilterUpdate request
+func (validator *SavedFilterHandlerValidator) ValidateUpdate(ctx context.Context, request requests.SavedFilterUpdate) url.Values {
+	result := validator.ValidateUUID(ctx, request.SavedFilterID, "savedFilterID")
+	for key, values := range validator.ValidateStore(ctx, request.SavedFilterStore) {
+		result[key] = append(result[key], values...)
+	}
+	return result
+}
+
+// ValidateExecute validates the requests.SavedFilterExecute request
+func (validator *SavedFilterHandlerValidator) ValidateExecute(ctx context.Context, request requests.SavedFilterExecute) url.Values {
+	v := govalidator.New(govalidator.Options{
+		Data: &request,
+		Rules: govalidator.MapData{
+			"limit": []string{
+				"required",
+				"numeric",
+				"min:1",
+				"max:100",
+			},
+			"skip": []string{
+				"required",
+				"numeric",
+				"min:0",
+			},
+		},
+	})
+
+	result := v.ValidateStruct()
+	for key, values := range validator.ValidateUUID(ctx, request.SavedFilterID, "savedFilterID") {
+		result[key] = append(result[key], values...)
+	}
+
+	if _, err := services.DecodeEventCursor(request.Cursor); request.Cursor != "" && err != nil {
+		result.Add("cursor", "The cursor field must be the next_cursor returned by the previous page")
+	}
+
+	return result
+}
+
+// validateCriteria validates the entities.SavedFilterCriteria of a saved filter, the errors are added with the filters. prefix
+func (validator *SavedFilterHandlerValidator) validateCriteria(result url.Values, target entities.SavedFilterTarget, criteria entities.SavedFilterCriteria) {
+	rules := govalidator.MapData{
+		"type": []string{
+			"in:" + strings.Join([]string{entities.MessageTypeMobileTerminated, entities.MessageTypeMobileOriginated}, ","),
+		},
+		"sim": []string{
+			"in:" + strings.Join([]string{string(entities.SIM1), string(entities.SIM2), string(entities.SIMDefault)}, ","),
+		},
+		"query": []string{
+			"max:100",
+		},
+		"tag": []string{
+			"max:50",
+		},
+		"within_days": []string{
+			"min:0",
+			"max:3650",
+		},
+	}
+	if criteria.Owner != "" {
+		rules["owner"] = []string{phoneNumberRule}
+	}
+	if criteria.Contact != "" {
+		rules["contact"] = []string{contactPhoneNumberRule}
+	}
+
+	v := govalidator.New(govalidator.Options{
+		Data:  &criteria,
+		Rules: rules,
+	})
+	for key, values := range v.ValidateStruct() {
+		result["filters."+key] = append(result["filters."+key], values...)
+	}
+
+	statuses := map[entities.MessageStatus]bool{}
+	for _, status := range messageStatuses {
+		statuses[status] = true
+	}
+	for _, status := range criteria.Statuses {
+		if !statuses[status] {
+			result.Add("filters.statuses", fmt.Sprintf("The status [%s] is not a valid message status", status))
+		}
+	}
+
+	if target != entities.SavedFilterTargetMessageThreads {
+		if criteria.IsArchived {
+			result.Add("filters.is_archived", "The is_archived filter can only be used with the message-threads target")
+		}
+		return
+	}
+
+	// message threads are only filtered by the owner, query, tag and archive status
+	for field, isSet := range map[string]bool{
+		"contact":     criteria.Contact != "",
+		"statuses":    len(criteria.Statuses) > 0,
+		"type":        criteria.Type != "",
+		"sim":         criteria.SIM != "",
+		"within_days": criteria.WithinDays > 0,
+	} {
+		if isSet {
+			result.Add("filters."+field, fmt.Sprintf("The %s filter can only be used with the messages target", field))
+		}
+	}
+}
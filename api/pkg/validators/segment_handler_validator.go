@@ -0,0 +1,133 @@
+package validators
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/requests"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/thedevsaddam/govalidator"
+)
+
+// SegmentHandlerValidator validates models used in handlers.SegmentHandler
+type SegmentHandlerValidator struct {
+	validator
+	logger telemetry.Logger
+	tracer telemetry.Tracer
+}
+
+// NewSegmentHandlerValidator creates a new handlers.SegmentHandler validator
+func NewSegmentHandlerValidator(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+) (v *SegmentHandlerValidator) {
+	return &SegmentHandlerValidator{
+		logger: logger.WithService(fmt.Sprintf("%T", v)),
+		tracer: tracer,
+	}
+}
+
+// ValidateIndex validates the requests.SegmentIndex request
+func (validator *SegmentHandlerValidator) ValidateIndex(_ context.Context, request requests.SegmentIndex) url.Values {
+	v := govalidator.New(govalidator.Options{
+		Data: &request,
+		Rules: govalidator.MapData{
+			"limit": []string{
+				"required",
+				"numeric",
+				"min:1",
+				"max:100",
+			},
+			"skip": []string{
+				"required",
+				"numeric",
+				"min:0",
+			},
+			"query": []string{
+				"max:100",
+			},
+		},
+	})
+	return v.ValidateStruct()
+}
+
+// ValidateStore validates the requests.SegmentStore request
+func (validator *SegmentHandlerValidator) ValidateStore(_ context.Context, request requests.SegmentStore) url.Values {
+	v := govalidator.New(govalidator.Options{
+		Data: &request,
+		Rules: govalidator.MapData{
+			"name": []string{
+				"required",
+				"min:1",
+				"max:100",
+			},
+		},
+	})
+
+	result := v.ValidateStruct()
+	validator.validateFilters(result, request.Filters)
+	return result
+}
+
+// ValidateUpdate validates the requests.SegmentUpdate request
+func (validator *SegmentHandlerValidator) ValidateUpdate(ctx context.Context, request requests.SegmentUpdate) url.Values {
+	result := validator.ValidateUUID(ctx, request.SegmentID, "segmentID")
+	for key, values := range validator.ValidateStore(ctx, request.SegmentStore) {
+		result[key] = append(result[key], values...)
+	}
+	return result
+}
+
+// ValidatePreview validates the requests.SegmentPreview request
+func (validator *SegmentHandlerValidator) ValidatePreview(_ context.Context, request requests.SegmentPreview) url.Values {
+	result := url.Values{}
+	validator.validateFilters(result, request.Filters)
+	return result
+}
+
+func (validator *SegmentHandlerValidator) validateFilters(result url.Values, filters []entities.SegmentFilter) {
+	if len(filters) > 20 {
+		result.Add("filters", "The filters field must have at most 20 filters")
+		return
+	}
+
+	for index, filter := range filters {
+		if _, ok := entities.SegmentFilterOperators[filter.Field]; !ok {
+			result.Add("filters", fmt.Sprintf("The filter in index [%d] has an invalid field [%s]", index, filter.Field))
+			continue
+		}
+
+		if !filter.IsSupported() {
+			result.Add("filters", fmt.Sprintf("The filter in index [%d] cannot use the operator [%s] with the field [%s]", index, filter.Operator, filter.Field))
+			continue
+		}
+
+		if len(filter.Value) > 255 {
+			result.Add("filters", fmt.Sprintf("The value of the filter in index [%d] must be at most 255 characters", index))
+		}
+
+		switch filter.Field {
+		case entities.SegmentFilterFieldAttribute:
+			if match, err := regexp.MatchString("^[a-zA-Z0-9_]{1,50}$", filter.Key); err != nil || !match {
+				result.Add("filters", fmt.Sprintf("The filter in index [%d] must have a key containing only letters, digits and underscores", index))
+			}
+		case entities.SegmentFilterFieldMessagesSent, entities.SegmentFilterFieldMessagesReceived, entities.SegmentFilterFieldReplyRate:
+			if _, err := strconv.ParseFloat(filter.Value, 64); err != nil {
+				result.Add("filters", fmt.Sprintf("The filter in index [%d] must have a numeric value", index))
+			}
+		case entities.SegmentFilterFieldLastSentAt, entities.SegmentFilterFieldLastReplyAt:
+			if _, err := time.Parse(time.RFC3339, filter.Value); err != nil {
+				result.Add("filters", fmt.Sprintf("The filter in index [%d] must have an RFC3339 timestamp value e.g 2022-06-05T14:26:09+03:00", index))
+			}
+		case entities.SegmentFilterFieldIsOptedOut:
+			if filter.Value != "true" && filter.Value != "false" {
+				result.Add("filters", fmt.Sprintf("The filter in index [%d] must have a value of true or false", index))
+			}
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package validators
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+)
+
+// stripeSignatureTolerance is the maximum age of a signed stripe event
+const stripeSignatureTolerance = 5 * time.Minute
+
+// StripeHandlerValidator validates models used in handlers.StripeHandler
+type StripeHandlerValidator struct {
+	logger        telemetry.Logger
+	tracer        telemetry.Tracer
+	signingSecret string
+}
+
+// NewStripeHandlerValidator creates a new handlers.StripeHandler validator
+func NewStripeHandlerValidator(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	signingSecret string,
+) (v *StripeHandlerValidator) {
+	return &StripeHandlerValidator{
+		logger:        logger.WithService(fmt.Sprintf("%T", v)),
+		tracer:        tracer,
+		signingSecret: signingSecret,
+	}
+}
+
+// ValidateEvent checks that an event is coming from stripe using the Stripe-Signature header
+func (validator *StripeHandlerValidator) ValidateEvent(ctx context.Context, signature string, request []byte) url.Values {
+	_, span := validator.tracer.Start(ctx)
+	defer span.End()
+
+	if !validator.isValidSignature(signature, request, time.Now().UTC()) {
+		return url.Values{
+			"body": []string{
+				"The signature is not valid",
+			},
+		}
+	}
+	return url.Values{}
+}
+
+func (validator *StripeHandlerValidator) isValidSignature(header string, request []byte, now time.Time) bool {
+	if validator.signingSecret == "" {
+		return false
+	}
+
+	var timestamp string
+	var signatures []string
+	for _, item := range strings.Split(header, ",") {
+		key, value, found := strings.Cut(strings.TrimSpace(item), "=")
+		if !found {
+			continue
+		}
+		switch key {
+		case "t":
+			timestamp = value
+		case "v1":
+			signatures = append(signatures, value)
+		}
+	}
+
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return false
+	}
+
+	if age := now.Sub(time.Unix(seconds, 0)); age > stripeSignatureTolerance || age < -stripeSignatureTolerance {
+		return false
+	}
+
+	mac := hmac.New(sha256.New, []byte(validator.signingSecret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(request)
+	expected := mac.Sum(nil)
+
+	for _, signature := range signatures {
+		actual, err := hex.DecodeString(signature)
+		if err == nil && hmac.Equal(expected, actual) {
+			return true
+		}
+	}
+	return false
+}
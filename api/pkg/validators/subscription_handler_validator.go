@@ -0,0 +1,168 @@
+package validators
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
+	"github.com/NdoleStudio/httpsms/pkg/requests"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/palantir/stacktrace"
+	"github.com/thedevsaddam/govalidator"
+)
+
+// SubscriptionHandlerValidator validates models used in handlers.SubscriptionHandler
+type SubscriptionHandlerValidator struct {
+	validator
+	logger       telemetry.Logger
+	tracer       telemetry.Tracer
+	phoneService *services.PhoneService
+}
+
+// NewSubscriptionHandlerValidator creates a new handlers.SubscriptionHandler validator
+func NewSubscriptionHandlerValidator(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	phoneService *services.PhoneService,
+) (v *SubscriptionHandlerValidator) {
+	return &SubscriptionHandlerValidator{
+		logger:       logger.WithService(fmt.Sprintf("%T", v)),
+		tracer:       tracer,
+		phoneService: phoneService,
+	}
+}
+
+// ValidateKeywordIndex validates the requests.SubscriptionKeywordIndex request
+func (validator *SubscriptionHandlerValidator) ValidateKeywordIndex(_ context.Context, request requests.SubscriptionKeywordIndex) url.Values {
+	v := govalidator.New(govalidator.Options{
+		Data: &request,
+		Rules: govalidator.MapData{
+			"limit": []string{
+				"required",
+				"numeric",
+				"min:1",
+				"max:100",
+			},
+			"skip": []string{
+				"required",
+				"numeric",
+				"min:0",
+			},
+			"query": []string{
+				"max:100",
+			},
+		},
+	})
+	return v.ValidateStruct()
+}
+
+// ValidateIndex validates the requests.SubscriptionIndex request
+func (validator *SubscriptionHandlerValidator) ValidateIndex(_ context.Context, request requests.SubscriptionIndex) url.Values {
+	v := govalidator.New(govalidator.Options{
+		Data: &request,
+		Rules: govalidator.MapData{
+			"limit": []string{
+				"required",
+				"numeric",
+				"min:1",
+				"max:100",
+			},
+			"skip": []string{
+				"required",
+				"numeric",
+				"min:0",
+			},
+			"query": []string{
+				"max:100",
+			},
+			"keyword_id": []string{
+				"uuid",
+			},
+			"status": []string{
+				"in:" + strings.Join([]string{
+					string(entities.SubscriptionStatusPending),
+					string(entities.SubscriptionStatusConfirmed),
+				}, ","),
+			},
+		},
+	})
+	return v.ValidateStruct()
+}
+
+// ValidateKeywordStore validates the requests.SubscriptionKeywordStore request
+func (validator *SubscriptionHandlerValidator) ValidateKeywordStore(ctx context.Context, userID entities.UserID, request requests.SubscriptionKeywordStore) url.Values {
+	ctx, span, ctxLogger := validator.tracer.StartWithLogger(ctx, validator.logger)
+	defer span.End()
+
+	result := validator.validateKeyword(request)
+	if len(result) != 0 {
+		return result
+	}
+
+	_, err := validator.phoneService.Load(ctx, userID, request.Owner)
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		result.Add("owner", fmt.Sprintf("no phone found with with 'owner' number [%s]. install the android app on your phone to start using subscriptions", request.Owner))
+		return result
+	}
+
+	if err != nil {
+		ctxLogger.Error(validator.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, fmt.Sprintf("could not load phone for user [%s] and phone [%s]", userID, request.Owner))))
+		result.Add("owner", fmt.Sprintf("could not validate 'owner' number [%s], please try again later", request.Owner))
+	}
+
+	return result
+}
+
+// ValidateKeywordUpdate validates the requests.SubscriptionKeywordUpdate request
+func (validator *SubscriptionHandlerValidator) ValidateKeywordUpdate(ctx context.Context, userID entities.UserID, request requests.SubscriptionKeywordUpdate) url.Values {
+	result := validator.ValidateUUID(ctx, request.KeywordID, "keywordID")
+	if len(result) != 0 {
+		return result
+	}
+	return validator.ValidateKeywordStore(ctx, userID, request.SubscriptionKeywordStore)
+}
+
+func (validator *SubscriptionHandlerValidator) validateKeyword(request requests.SubscriptionKeywordStore) url.Values {
+	v := govalidator.New(govalidator.Options{
+		Data: &request,
+		Rules: govalidator.MapData{
+			"owner": []string{
+				"required",
+				phoneNumberRule,
+			},
+			"keyword": []string{
+				"required",
+				"regex:^[A-Z0-9]{2,20}$",
+			},
+			"list": []string{
+				"required",
+				"min:1",
+				"max:50",
+			},
+			"confirmation_template": []string{
+				"required",
+				"min:1",
+				"max:320",
+			},
+			"welcome_template": []string{
+				"max:320",
+			},
+		},
+	})
+
+	result := v.ValidateStruct()
+	if entities.IsReservedKeyword(request.Keyword) {
+		result.Add("keyword", fmt.Sprintf("The keyword [%s] is reserved for opting in, opting out or confirming a subscription", request.Keyword))
+	}
+
+	if match, _ := regexp.MatchString(`(?i)\bYES\b`, request.ConfirmationTemplate); !match {
+		result.Add("confirmation_template", "The confirmation_template field must ask the contact to reply YES to confirm the subscription")
+	}
+
+	return result
+}
@@ -0,0 +1,62 @@
+package validators
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+
+	"github.com/NdoleStudio/httpsms/pkg/requests"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/thedevsaddam/govalidator"
+)
+
+// TagHandlerValidator validates models used in handlers.TagHandler
+type TagHandlerValidator struct {
+	validator
+	logger telemetry.Logger
+	tracer telemetry.Tracer
+}
+
+// NewTagHandlerValidator creates a new handlers.TagHandler validator
+func NewTagHandlerValidator(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+) (v *TagHandlerValidator) {
+	return &TagHandlerValidator{
+		logger: logger.WithService(fmt.Sprintf("%T", v)),
+		tracer: tracer,
+	}
+}
+
+// ValidateStore validates the requests.TagStore request
+func (validator *TagHandlerValidator) ValidateStore(_ context.Context, request requests.TagStore) url.Values {
+	rules := govalidator.MapData{
+		"name": []string{
+			"required",
+			"min:1",
+			"max:50",
+		},
+		"color": []string{
+			"required",
+			"regex:^#[0-9a-f]{6}$",
+		},
+	}
+	if request.ParentID != "" {
+		rules["parent_id"] = []string{"uuid"}
+	}
+
+	v := govalidator.New(govalidator.Options{
+		Data:  &request,
+		Rules: rules,
+	})
+	return v.ValidateStruct()
+}
+
+// ValidateUpdate validates the requests.TagUpdate request
+func (validator *TagHandlerValidator) ValidateUpdate(ctx context.Context, request requests.TagUpdate) url.Values {
+	result := validator.ValidateUUID(ctx, request.TagID, "tagID")
+	for key, values := range validator.ValidateStore(ctx, request.TagStore) {
+		result[key] = append(result[key], values...)
+	}
+	return result
+}
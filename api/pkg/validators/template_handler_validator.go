@@ -0,0 +1,152 @@
+package validators
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/requests"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/thedevsaddam/govalidator"
+)
+
+// TemplateHandlerValidator validates models used in handlers.TemplateHandler
+type TemplateHandlerValidator struct {
+	validator
+	logger telemetry.Logger
+	tracer telemetry.Tracer
+}
+
+// NewTemplateHandlerValidator creates a new handlers.TemplateHandler validator
+func NewTemplateHandlerValidator(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+) (v *TemplateHandlerValidator) {
+	return &TemplateHandlerValidator{
+		logger: logger.WithService(fmt.Sprintf("%T", v)),
+		tracer: tracer,
+	}
+}
+
+// ValidateIndex validates the requests.TemplateIndex request
+func (validator *TemplateHandlerValidator) ValidateIndex(_ context.Context, request requests.TemplateIndex) url.Values {
+	v := govalidator.New(govalidator.Options{
+		Data: &request,
+		Rules: govalidator.MapData{
+			"limit": []string{
+				"required",
+				"numeric",
+				"min:1",
+				"max:100",
+			},
+			"skip": []string{
+				"required",
+				"numeric",
+				"min:0",
+			},
+			"query": []string{
+				"max:100",
+			},
+		},
+	})
+	return v.ValidateStruct()
+}
+
+// ValidateStore validates the requests.TemplateStore request
+func (validator *TemplateHandlerValidator) ValidateStore(_ context.Context, request requests.TemplateStore) url.Values {
+	v := govalidator.New(govalidator.Options{
+		Data: &request,
+		Rules: govalidator.MapData{
+			"name": []string{
+				"required",
+				"min:1",
+				"max:100",
+			},
+			"content": []string{
+				"required",
+				"min:1",
+				"max:1024",
+			},
+		},
+	})
+	return v.ValidateStruct()
+}
+
+// ValidateUpdate validates the requests.TemplateUpdate request
+func (validator *TemplateHandlerValidator) ValidateUpdate(ctx context.Context, request requests.TemplateUpdate) url.Values {
+	result := validator.ValidateUUID(ctx, request.TemplateID, "templateID")
+	for key, values := range validator.ValidateStore(ctx, request.TemplateStore) {
+		result[key] = append(result[key], values...)
+	}
+	return result
+}
+
+// ValidateReview validates the requests.TemplateReview request
+func (validator *TemplateHandlerValidator) ValidateReview(ctx context.Context, request requests.TemplateReview) url.Values {
+	v := govalidator.New(govalidator.Options{
+		Data: &request,
+		Rules: govalidator.MapData{
+			"status": []string{
+				"required",
+				"in:" + strings.Join([]string{
+					string(entities.TemplateStatusApproved),
+					string(entities.TemplateStatusRejected),
+				}, ","),
+			},
+			"note": []string{
+				"max:1000",
+			},
+		},
+	})
+
+	result := v.ValidateStruct()
+	for key, values := range validator.ValidateUUID(ctx, request.TemplateID, "templateID") {
+		result[key] = append(result[key], values...)
+	}
+	if request.Status == string(entities.TemplateStatusRejected) && request.Note == "" {
+		result.Add("note", "The note field is required when a template is rejected")
+	}
+	return result
+}
+
+// ValidateTemplateApproval validates the requests.UserTemplateApproval request
+func (validator *TemplateHandlerValidator) ValidateTemplateApproval(_ context.Context, request requests.UserTemplateApproval) url.Values {
+	v := govalidator.New(govalidator.Options{
+		Data: &request,
+		Rules: govalidator.MapData{
+			"userID": []string{
+				"required",
+				"max:255",
+			},
+		},
+	})
+	return v.ValidateStruct()
+}
+
+// ValidateStats validates the requests.TemplateStats request
+func (validator *TemplateHandlerValidator) ValidateStats(ctx context.Context, request requests.TemplateStats) url.Values {
+	result := validator.ValidateUUID(ctx, request.TemplateID, "templateID")
+	if len(result) != 0 {
+		return result
+	}
+
+	v := govalidator.New(govalidator.Options{
+		Data: &request,
+		Rules: govalidator.MapData{
+			"from": []string{
+				"required",
+			},
+			"to": []string{
+				"required",
+			},
+		},
+	})
+
+	if result = v.ValidateStruct(); len(result) != 0 {
+		return result
+	}
+
+	return validator.validateTimeRange(result, request.From, request.To, 366)
+}
@@ -0,0 +1,60 @@
+package validators
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/requests"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/thedevsaddam/govalidator"
+)
+
+// TwilioHandlerValidator validates models used in handlers.TwilioHandler
+type TwilioHandlerValidator struct {
+	validator
+	logger           telemetry.Logger
+	tracer           telemetry.Tracer
+	messageValidator *MessageHandlerValidator
+}
+
+// NewTwilioHandlerValidator creates a new handlers.TwilioHandler validator
+func NewTwilioHandlerValidator(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	messageValidator *MessageHandlerValidator,
+) (v *TwilioHandlerValidator) {
+	return &TwilioHandlerValidator{
+		logger:           logger.WithService(fmt.Sprintf("%T", v)),
+		tracer:           tracer,
+		messageValidator: messageValidator,
+	}
+}
+
+// ValidateMessageSend validates the requests.TwilioMessageSend request
+func (validator *TwilioHandlerValidator) ValidateMessageSend(ctx context.Context, userID entities.UserID, request requests.TwilioMessageSend) url.Values {
+	ctx, span := validator.tracer.Start(ctx)
+	defer span.End()
+
+	v := govalidator.New(govalidator.Options{
+		Data: &request,
+		Rules: govalidator.MapData{
+			"account_sid": []string{
+				"required",
+				"max:64",
+			},
+			"status_callback": []string{
+				"url",
+				"max:1000",
+			},
+		},
+	})
+
+	result := v.ValidateStruct()
+	if len(result) != 0 {
+		return result
+	}
+
+	return validator.messageValidator.ValidateMessageSend(ctx, userID, request.ToMessageSend())
+}
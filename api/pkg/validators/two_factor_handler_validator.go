@@ -0,0 +1,44 @@
+package validators
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+
+	"github.com/NdoleStudio/httpsms/pkg/requests"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/thedevsaddam/govalidator"
+)
+
+// TwoFactorHandlerValidator validates models used in handlers.TwoFactorHandler
+type TwoFactorHandlerValidator struct {
+	validator
+	logger telemetry.Logger
+	tracer telemetry.Tracer
+}
+
+// NewTwoFactorHandlerValidator creates a new handlers.TwoFactorHandler validator
+func NewTwoFactorHandlerValidator(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+) (v *TwoFactorHandlerValidator) {
+	return &TwoFactorHandlerValidator{
+		logger: logger.WithService(fmt.Sprintf("%T", v)),
+		tracer: tracer,
+	}
+}
+
+// ValidateCode validates the requests.TwoFactorCode request
+func (validator *TwoFactorHandlerValidator) ValidateCode(_ context.Context, request requests.TwoFactorCode) url.Values {
+	v := govalidator.New(govalidator.Options{
+		Data: &request,
+		Rules: govalidator.MapData{
+			"code": []string{
+				"required",
+				"min:6",
+				"max:16",
+			},
+		},
+	})
+	return v.ValidateStruct()
+}
@@ -4,7 +4,9 @@ import (
 	"context"
 	"fmt"
 	"net/url"
+	"strings"
 
+	"github.com/NdoleStudio/httpsms/pkg/entities"
 	"github.com/NdoleStudio/httpsms/pkg/requests"
 	"github.com/NdoleStudio/httpsms/pkg/telemetry"
 	"github.com/thedevsaddam/govalidator"
@@ -13,18 +15,33 @@ import (
 // UserHandlerValidator validates models used in handlers.UserHandler
 type UserHandlerValidator struct {
 	validator
-	logger telemetry.Logger
-	tracer telemetry.Tracer
+	logger  telemetry.Logger
+	tracer  telemetry.Tracer
+	regions []string
 }
 
+// maxSendRateLimit is the highest number of messages per minute which can be configured as a send rate limit
+const maxSendRateLimit = 6000
+
+// maxKeywords is the highest number of opt-out or opt-in keywords of a user
+const maxKeywords = 20
+
+// maxMessageThreadAutoArchiveDays is the longest period of inactivity after which message threads are archived automatically
+const maxMessageThreadAutoArchiveDays = 3650
+
+// maxMessageRetentionDays is the longest period after which messages are deleted or anonymized by a retention policy
+const maxMessageRetentionDays = 3650
+
 // NewUserHandlerValidator creates a new handlers.UserHandler validator
 func NewUserHandlerValidator(
 	logger telemetry.Logger,
 	tracer telemetry.Tracer,
+	regions []string,
 ) (v *UserHandlerValidator) {
 	return &UserHandlerValidator{
-		logger: logger.WithService(fmt.Sprintf("%T", v)),
-		tracer: tracer,
+		logger:  logger.WithService(fmt.Sprintf("%T", v)),
+		tracer:  tracer,
+		regions: regions,
 	}
 }
 
@@ -40,5 +57,113 @@ func (validator *UserHandlerValidator) ValidateUpdate(_ context.Context, request
 		},
 	})
 
+	result := v.ValidateStruct()
+	if request.NotificationDigestMinutes != nil && *request.NotificationDigestMinutes > 1440 {
+		result.Add("notification_digest_minutes", "The notification_digest_minutes field must be between 0 and 1440")
+	}
+
+	if timeout := request.MessageExpirationTimeout; timeout != nil && *timeout != 0 && (*timeout < minMessageExpiresIn || *timeout > maxMessageExpiresIn) {
+		result.Add("message_expiration_timeout", fmt.Sprintf("The message_expiration_timeout field must be 0 or between %d and %d", minMessageExpiresIn, maxMessageExpiresIn))
+	}
+
+	if request.SendRateLimit != nil && *request.SendRateLimit > maxSendRateLimit {
+		result.Add("send_rate_limit", fmt.Sprintf("The send_rate_limit field must be between 0 and %d", maxSendRateLimit))
+	}
+
+	if request.ContactSendRateLimit != nil && *request.ContactSendRateLimit > maxSendRateLimit {
+		result.Add("contact_send_rate_limit", fmt.Sprintf("The contact_send_rate_limit field must be between 0 and %d", maxSendRateLimit))
+	}
+
+	if request.MessageThreadAutoArchiveDays != nil && *request.MessageThreadAutoArchiveDays > maxMessageThreadAutoArchiveDays {
+		result.Add("message_thread_auto_archive_days", fmt.Sprintf("The message_thread_auto_archive_days field must be between 0 and %d", maxMessageThreadAutoArchiveDays))
+	}
+
+	if request.MessageRetentionDays != nil && *request.MessageRetentionDays > maxMessageRetentionDays {
+		result.Add("message_retention_days", fmt.Sprintf("The message_retention_days field must be between 0 and %d", maxMessageRetentionDays))
+	}
+
+	if request.MessageAnonymizeDays != nil && *request.MessageAnonymizeDays > maxMessageRetentionDays {
+		result.Add("message_anonymize_days", fmt.Sprintf("The message_anonymize_days field must be between 0 and %d", maxMessageRetentionDays))
+	}
+
+	if request.MessageRetentionDays != nil && request.MessageAnonymizeDays != nil && *request.MessageRetentionDays != 0 && *request.MessageAnonymizeDays >= *request.MessageRetentionDays {
+		result.Add("message_anonymize_days", "The message_anonymize_days field must be less than the message_retention_days field")
+	}
+
+	if request.OptOutKeywords != nil && len(*request.OptOutKeywords) > maxKeywords {
+		result.Add("opt_out_keywords", fmt.Sprintf("The opt_out_keywords field must have at most %d keywords", maxKeywords))
+	}
+
+	if request.OptInKeywords != nil && len(*request.OptInKeywords) > maxKeywords {
+		result.Add("opt_in_keywords", fmt.Sprintf("The opt_in_keywords field must have at most %d keywords", maxKeywords))
+	}
+
+	if request.OptOutKeywords != nil && request.OptInKeywords != nil && validator.hasCommonKeyword(*request.OptOutKeywords, *request.OptInKeywords) {
+		result.Add("opt_in_keywords", "The opt_in_keywords field cannot contain a keyword from the opt_out_keywords field")
+	}
+
+	if request.Region != nil && !validator.isRegion(*request.Region) {
+		result.Add("region", fmt.Sprintf("The region field must be one of [%s]", strings.Join(validator.regions, ", ")))
+	}
+	return result
+}
+
+func (validator *UserHandlerValidator) hasCommonKeyword(optOutKeywords []string, optInKeywords []string) bool {
+	for _, optOut := range optOutKeywords {
+		for _, optIn := range optInKeywords {
+			if optOut == optIn {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (validator *UserHandlerValidator) isRegion(region string) bool {
+	for _, value := range validator.regions {
+		if value == region {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateSubscriptionPlan validates requests.UserSubscriptionPlan
+func (validator *UserHandlerValidator) ValidateSubscriptionPlan(_ context.Context, request requests.UserSubscriptionPlan) url.Values {
+	v := govalidator.New(govalidator.Options{
+		Data: &request,
+		Rules: govalidator.MapData{
+			"plan": []string{
+				"required",
+				"in:" + strings.Join([]string{
+					string(entities.SubscriptionNameProMonthly),
+					string(entities.SubscriptionNameProYearly),
+					string(entities.SubscriptionNameUltraMonthly),
+					string(entities.SubscriptionNameUltraYearly),
+				}, ","),
+			},
+		},
+	})
+	return v.ValidateStruct()
+}
+
+// ValidateSuspend validates requests.UserSuspend
+func (validator *UserHandlerValidator) ValidateSuspend(_ context.Context, request requests.UserSuspend) url.Values {
+	v := govalidator.New(govalidator.Options{
+		Data: &request,
+		Rules: govalidator.MapData{
+			"userID": []string{
+				"required",
+				"max:255",
+			},
+			"reason": []string{
+				"required",
+				"in:" + strings.Join([]string{
+					string(entities.UserSuspensionReasonBilling),
+					string(entities.UserSuspensionReasonAbuse),
+				}, ","),
+			},
+		},
+	})
 	return v.ValidateStruct()
 }
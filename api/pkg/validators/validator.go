@@ -5,8 +5,11 @@ import (
 	"fmt"
 	"net/url"
 	"regexp"
+	"strings"
+	"time"
 
 	"github.com/NdoleStudio/httpsms/pkg/events"
+	"github.com/NdoleStudio/httpsms/pkg/services"
 
 	"github.com/nyaruka/phonenumbers"
 	"github.com/thedevsaddam/govalidator"
@@ -19,6 +22,7 @@ const (
 	contactPhoneNumberRule         = "contactPhoneNumber"
 	multipleContactPhoneNumberRule = "multipleContactPhoneNumber"
 	webhookEventsRule              = "webhookEvents"
+	egressProxyURLRule             = "egressProxyURL"
 )
 
 func init() {
@@ -80,6 +84,9 @@ func init() {
 
 		validEvents := map[string]bool{
 			events.EventTypeMessagePhoneReceived: true,
+			events.EventTypeMessageRead:          true,
+			events.EventTypeMessagePurged:        true,
+			events.EventTypeBroadcastPublished:   true,
 		}
 
 		for _, event := range input {
@@ -90,6 +97,19 @@ func init() {
 
 		return nil
 	})
+
+	govalidator.AddCustomRule(egressProxyURLRule, func(field string, rule string, message string, value interface{}) error {
+		input, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("The %s field must be a string", field)
+		}
+
+		if _, err := services.ParseEgressProxyURL(input); input != "" && err != nil {
+			return fmt.Errorf("The %s field must be a proxy URL with one of the schemes [%s] e.g. socks5://proxy.example.com:1080", field, strings.Join(services.EgressProxySchemes, ", "))
+		}
+
+		return nil
+	})
 }
 
 // ValidateUUID that the payload is a UUID
@@ -110,3 +130,43 @@ func (validator *validator) ValidateUUID(_ context.Context, ID string, name stri
 
 	return v.ValidateStruct()
 }
+
+// validateTags validates the names of the entities.Tag which are set on a contact, message or message thread
+func (validator *validator) validateTags(result url.Values, tags []string) {
+	if len(tags) > 50 {
+		result.Add("tags", "The tags field must have at most 50 tags")
+	}
+	for index, tag := range tags {
+		if len(tag) > 50 {
+			result.Add("tags", fmt.Sprintf("The tag in index [%d] must be at most 50 characters", index))
+		}
+	}
+}
+
+// validateTimeRange validates the RFC3339 from and to fields of a request which must not be more than maxDays apart
+func (validator *validator) validateTimeRange(result url.Values, fromValue string, toValue string, maxDays int) url.Values {
+	from, err := time.Parse(time.RFC3339, fromValue)
+	if err != nil {
+		result.Add("from", "The from field must be a valid RFC3339 timestamp e.g 2022-06-05T14:26:09+03:00")
+	}
+
+	to, err := time.Parse(time.RFC3339, toValue)
+	if err != nil {
+		result.Add("to", "The to field must be a valid RFC3339 timestamp e.g 2022-06-05T14:26:09+03:00")
+	}
+
+	if len(result) != 0 {
+		return result
+	}
+
+	if !from.Before(to) {
+		result.Add("from", "The from field must be before the to field")
+		return result
+	}
+
+	if to.Sub(from) > time.Duration(maxDays)*24*time.Hour {
+		result.Add("from", fmt.Sprintf("The time range must not be more than %d days", maxDays))
+	}
+
+	return result
+}
@@ -4,13 +4,21 @@ import (
 	"context"
 	"fmt"
 	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
 
 	"github.com/NdoleStudio/httpsms/pkg/requests"
+	"github.com/NdoleStudio/httpsms/pkg/services"
 
 	"github.com/NdoleStudio/httpsms/pkg/telemetry"
 	"github.com/thedevsaddam/govalidator"
 )
 
+// webhookSigningKeyMinLength is the minimum length of a signing key which is supplied when the key of a webhook is rotated
+const webhookSigningKeyMinLength = 32
+
 // WebhookHandlerValidator validates models used in handlers.WebhookHandler
 type WebhookHandlerValidator struct {
 	validator
@@ -68,13 +76,21 @@ func (validator *WebhookHandlerValidator) ValidateStore(_ context.Context, reque
 				"url",
 				"max:255",
 			},
+			"proxy_url": []string{
+				"max:1000",
+				egressProxyURLRule,
+			},
 			"events": []string{
 				"required",
 				webhookEventsRule,
 			},
 		},
 	})
-	return v.ValidateStruct()
+
+	result := v.ValidateStruct()
+	validator.validateVersion(result, request.Version)
+	validator.validateFilters(result, request.Events, request.Filters)
+	return result
 }
 
 // ValidateUpdate validates the requests.WebhookUpdate request
@@ -96,11 +112,174 @@ func (validator *WebhookHandlerValidator) ValidateUpdate(_ context.Context, requ
 				"url",
 				"max:255",
 			},
+			"proxy_url": []string{
+				"max:1000",
+				egressProxyURLRule,
+			},
 			"events": []string{
 				"required",
 				webhookEventsRule,
 			},
 		},
 	})
+
+	result := v.ValidateStruct()
+	validator.validateVersion(result, request.Version)
+	validator.validateFilters(result, request.Events, request.Filters)
+	return result
+}
+
+// ValidateDeliveryIndex validates the requests.WebhookDeliveryIndex request
+func (validator *WebhookHandlerValidator) ValidateDeliveryIndex(_ context.Context, request requests.WebhookDeliveryIndex) url.Values {
+	v := govalidator.New(govalidator.Options{
+		Data: &request,
+		Rules: govalidator.MapData{
+			"webhookID": []string{
+				"required",
+				"uuid",
+			},
+			"limit": []string{
+				"required",
+				"numeric",
+				"min:1",
+				"max:100",
+			},
+			"skip": []string{
+				"required",
+				"numeric",
+				"min:0",
+			},
+			"status": []string{
+				"in:" + strings.Join([]string{
+					string(entities.WebhookDeliveryStatusPending),
+					string(entities.WebhookDeliveryStatusRetrying),
+					string(entities.WebhookDeliveryStatusSucceeded),
+					string(entities.WebhookDeliveryStatusHeld),
+					string(entities.WebhookDeliveryStatusFailed),
+				}, ","),
+			},
+		},
+	})
+	return v.ValidateStruct()
+}
+
+// ValidateFailureIndex validates the requests.WebhookFailureIndex request
+func (validator *WebhookHandlerValidator) ValidateFailureIndex(_ context.Context, request requests.WebhookFailureIndex) url.Values {
+	v := govalidator.New(govalidator.Options{
+		Data: &request,
+		Rules: govalidator.MapData{
+			"limit": []string{
+				"required",
+				"numeric",
+				"min:1",
+				"max:100",
+			},
+			"skip": []string{
+				"required",
+				"numeric",
+				"min:0",
+			},
+		},
+	})
 	return v.ValidateStruct()
 }
+
+// ValidateRotateKey validates the requests.WebhookRotateKey request
+func (validator *WebhookHandlerValidator) ValidateRotateKey(_ context.Context, request requests.WebhookRotateKey) url.Values {
+	v := govalidator.New(govalidator.Options{
+		Data: &request,
+		Rules: govalidator.MapData{
+			"webhookID": []string{
+				"required",
+				"uuid",
+			},
+			"signing_key": []string{
+				fmt.Sprintf("min:%d", webhookSigningKeyMinLength),
+				"max:255",
+			},
+		},
+	})
+	return v.ValidateStruct()
+}
+
+// ValidateContractTest validates the requests.WebhookContractTest request
+func (validator *WebhookHandlerValidator) ValidateContractTest(_ context.Context, request requests.WebhookContractTest) url.Values {
+	v := govalidator.New(govalidator.Options{
+		Data: &request,
+		Rules: govalidator.MapData{
+			"webhookID": []string{
+				"required",
+				"uuid",
+			},
+			"url": []string{
+				"url",
+				"max:255",
+			},
+		},
+	})
+	return v.ValidateStruct()
+}
+
+func (validator *WebhookHandlerValidator) validateVersion(result url.Values, version string) {
+	if version == "" {
+		return
+	}
+
+	versions := make([]string, 0, len(entities.WebhookVersions))
+	for _, item := range entities.WebhookVersions {
+		if string(item) == version {
+			return
+		}
+		versions = append(versions, string(item))
+	}
+
+	result.Add("version", fmt.Sprintf("The version field must be one of [%s]", strings.Join(versions, ", ")))
+}
+
+func (validator *WebhookHandlerValidator) validateFilters(result url.Values, events []string, filters []entities.WebhookFilter) {
+	if len(filters) > 20 {
+		result.Add("filters", "The filters field must have at most 20 filters")
+		return
+	}
+
+	for index, filter := range filters {
+		if !validator.containsString(events, filter.Event) {
+			result.Add("filters", fmt.Sprintf("The filter in index [%d] must have an event [%s] which is in the events field", index, filter.Event))
+		}
+
+		if !services.IsValidWebhookFilterPath(filter.Path) || len(filter.Path) > 255 {
+			result.Add("filters", fmt.Sprintf("The filter in index [%d] must have a JSONPath e.g $.content or $.attachments[0].content_type", index))
+		}
+
+		if len(filter.Value) > 255 {
+			result.Add("filters", fmt.Sprintf("The value of the filter in index [%d] must be at most 255 characters", index))
+		}
+
+		switch filter.Operator {
+		case entities.WebhookFilterOperatorEqual, entities.WebhookFilterOperatorNotEqual, entities.WebhookFilterOperatorContains, entities.WebhookFilterOperatorExists:
+		case entities.WebhookFilterOperatorMatches:
+			if _, err := regexp.Compile(filter.Value); err != nil || filter.Value == "" {
+				result.Add("filters", fmt.Sprintf("The filter in index [%d] must have a valid regular expression value", index))
+			}
+		case entities.WebhookFilterOperatorIn, entities.WebhookFilterOperatorNotIn:
+			if len(filter.Values) == 0 || len(filter.Values) > 100 {
+				result.Add("filters", fmt.Sprintf("The filter in index [%d] must have between 1 and 100 values", index))
+			}
+		default:
+			operators := make([]string, 0, len(entities.WebhookFilterOperators))
+			for _, operator := range entities.WebhookFilterOperators {
+				operators = append(operators, string(operator))
+			}
+			result.Add("filters", fmt.Sprintf("The filter in index [%d] has an invalid operator [%s], it must be one of [%s]", index, filter.Operator, strings.Join(operators, ", ")))
+		}
+	}
+}
+
+func (validator *WebhookHandlerValidator) containsString(values []string, value string) bool {
+	for _, item := range values {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,74 @@
+package validators
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+
+	"github.com/NdoleStudio/httpsms/pkg/requests"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/thedevsaddam/govalidator"
+)
+
+// webSocketMaxTopics is the maximum number of topics in a requests.WebSocketCommand
+const webSocketMaxTopics = 50
+
+// WebSocketHandlerValidator validates models used in handlers.WebSocketHandler
+type WebSocketHandlerValidator struct {
+	validator
+	logger telemetry.Logger
+	tracer telemetry.Tracer
+}
+
+// NewWebSocketHandlerValidator creates a new handlers.WebSocketHandler validator
+func NewWebSocketHandlerValidator(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+) (v *WebSocketHandlerValidator) {
+	return &WebSocketHandlerValidator{
+		logger: logger.WithService(fmt.Sprintf("%T", v)),
+		tracer: tracer,
+	}
+}
+
+// ValidateConnect validates the requests.WebSocketConnect request
+func (validator *WebSocketHandlerValidator) ValidateConnect(_ context.Context, request requests.WebSocketConnect) url.Values {
+	v := govalidator.New(govalidator.Options{
+		Data: &request,
+		Rules: govalidator.MapData{
+			"topics": []string{
+				"max:1000",
+			},
+			"last_event_id": []string{
+				"uuid",
+			},
+		},
+	})
+	return v.ValidateStruct()
+}
+
+// ValidateCommand validates the requests.WebSocketCommand request
+func (validator *WebSocketHandlerValidator) ValidateCommand(_ context.Context, request requests.WebSocketCommand) url.Values {
+	v := govalidator.New(govalidator.Options{
+		Data: &request,
+		Rules: govalidator.MapData{
+			"action": []string{
+				"required",
+				"in:subscribe,unsubscribe",
+			},
+		},
+	})
+
+	result := v.ValidateStruct()
+	if len(request.Topics) == 0 || len(request.Topics) > webSocketMaxTopics {
+		result.Add("topics", fmt.Sprintf("The topics field must contain between 1 and %d event types", webSocketMaxTopics))
+	}
+
+	for index, topic := range request.Topics {
+		if len(topic) > 100 {
+			result.Add("topics", fmt.Sprintf("The topic at index [%d] must be at most 100 characters", index))
+		}
+	}
+
+	return result
+}